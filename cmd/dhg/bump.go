@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func newBumpCmd() *cobra.Command {
+	var patch, minor, major bool
+
+	cmd := &cobra.Command{
+		Use:   "bump <chart-dir>",
+		Short: "Increment the chart version and add a CHANGELOG entry",
+		Long: `Increment the version in Chart.yaml and prepend a CHANGELOG.md entry listing
+the templates added, changed and removed since the previous bump. The released
+templates are recorded in <chart>/.dhg/release; the first bump of a chart is
+recorded as its initial release.
+
+Examples:
+  dhg bump ./chart/myapp            # 0.1.0 -> 0.1.1
+  dhg bump ./chart/myapp --minor    # 0.1.1 -> 0.2.0
+  dhg bump ./chart/myapp --major    # 0.2.0 -> 1.0.0`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			level := generator.BumpPatch
+			switch {
+			case minor:
+				level = generator.BumpMinor
+			case major:
+				level = generator.BumpMajor
+			}
+			result, err := generator.BumpChart(args[0], level, time.Now())
+			if err != nil {
+				return err
+			}
+			printBumpResult(cmd.OutOrStdout(), result)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&patch, "patch", false, "Increment the patch version (default)")
+	cmd.Flags().BoolVar(&minor, "minor", false, "Increment the minor version")
+	cmd.Flags().BoolVar(&major, "major", false, "Increment the major version")
+	cmd.MarkFlagsMutuallyExclusive("patch", "minor", "major")
+
+	return cmd
+}
+
+func printBumpResult(w io.Writer, result *generator.BumpResult) {
+	fmt.Fprintf(w, "Chart version %s -> %s\n", result.PreviousVersion, result.Version)
+	switch {
+	case result.Initial && result.HasHistory:
+		fmt.Fprintf(w, "Recorded %d templates; changes are listed from the next bump\n", len(result.Added))
+		return
+	case result.Initial:
+		fmt.Fprintf(w, "Initial release with %d templates\n", len(result.Added))
+		return
+	}
+	fmt.Fprintf(w, "%d added, %d changed, %d removed templates since %s\n",
+		len(result.Added), len(result.Changed), len(result.Removed), result.PreviousVersion)
+}
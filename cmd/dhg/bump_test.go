@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBumpCmd(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	chartDir := filepath.Join(outDir, "app")
+
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	out, err := executeCmd(t, "bump", chartDir)
+	if err != nil {
+		t.Fatalf("bump: %v", err)
+	}
+	if !strings.Contains(out, "Chart version 0.1.0 -> 0.1.1") || !strings.Contains(out, "Initial release") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	out, err = executeCmd(t, "status", chartDir)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if strings.Contains(out, "Chart.yaml") || !strings.Contains(out, " 0 modified,") {
+		t.Errorf("expected the bumped Chart.yaml to stay unmodified:\n%s", out)
+	}
+
+	tmplPath := filepath.Join(chartDir, "templates", "web-deployment.yaml")
+	tmpl, err := os.ReadFile(tmplPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmplPath, append(tmpl, "# edited\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = executeCmd(t, "bump", chartDir, "--minor")
+	if err != nil {
+		t.Fatalf("bump --minor: %v", err)
+	}
+	if !strings.Contains(out, "0 added, 1 changed, 0 removed templates since 0.1.1") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	chart, err := os.ReadFile(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(chart), "version: 0.2.0") {
+		t.Errorf("Chart.yaml not bumped:\n%s", chart)
+	}
+	changelog, err := os.ReadFile(filepath.Join(chartDir, "CHANGELOG.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(changelog), "- `templates/web-deployment.yaml` (+1/-0 lines)") {
+		t.Errorf("unexpected CHANGELOG.md:\n%s", changelog)
+	}
+
+	if _, err := executeCmd(t, "bump", chartDir, "--minor", "--major"); err == nil {
+		t.Error("expected --minor and --major to be mutually exclusive")
+	}
+}
+
+func TestGenerateCmd_ChartVersionAuto(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"commit", "-q", "-m", "initial"},
+		{"tag", "v2.3.0"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", inputDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app", "--chart-version", "auto"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	chart, err := os.ReadFile(filepath.Join(outDir, "app", "Chart.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(chart), "version: 2.3.0") {
+		t.Errorf("expected the version of the git tag:\n%s", chart)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+)
+
+func newBundleCmd() *cobra.Command {
+	var (
+		registry       string
+		outputFile     string
+		mirrorTool     string
+		platforms      []string
+		registryAccess registryFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bundle <chart-dir>...",
+		Short: "Package charts and their images list into an air-gap bundle",
+		Long: `Package generated charts into a single tarball for installation in an
+air-gapped environment. The bundle contains:
+
+  charts/<name>-<version>.tgz  the packaged charts
+  images.txt                   the images the charts and their subcharts render
+                               with their default values
+  mirror-images.sh             copies the images to the target registry with
+                               skopeo or crane, keeping their digests
+  values-airgap.yaml           points the charts at the target registry
+  SHA256SUMS                   checksums of the other files
+
+With --platforms, the manifests of the images are looked up in their
+registries: images.txt lists the digest of every selected platform, and
+mirror-images.sh copies only the selected platforms of multi-platform
+images, except for images pinned by digest, which are copied whole.
+
+Use "dhg bundle verify" on the air-gapped side to check the bundle and that
+its images have been mirrored.`,
+		Example: `  # Bundle a chart for registry.internal:5000
+  dhg bundle ./charts/myapp --registry registry.internal:5000 -o myapp-bundle.tar.gz
+
+  # Mirror with crane instead of skopeo
+  dhg bundle ./charts/frontend ./charts/backend --registry registry.internal:5000 --mirror-tool crane
+
+  # Mirror only the amd64 and arm64 platforms
+  dhg bundle ./charts/myapp --registry registry.internal:5000 --mirror-tool crane --platforms linux/amd64,linux/arm64`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if registry == "" {
+				return fmt.Errorf("--registry is required")
+			}
+			tool := generator.MirrorTool(mirrorTool)
+			if err := generator.ValidatePlatforms(platforms); err != nil {
+				return err
+			}
+
+			tmpDir, err := os.MkdirTemp("", "dhg-bundle-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmpDir)
+
+			var files []generator.BundleFile
+			var images []generator.ImageRef
+			for _, chartDir := range args {
+				archive, err := helm.PackageChart(chartDir, tmpDir)
+				if err != nil {
+					return err
+				}
+				data, err := os.ReadFile(archive)
+				if err != nil {
+					return err
+				}
+				files = append(files, generator.BundleFile{Path: "charts/" + filepath.Base(archive), Content: data})
+
+				refs, err := generator.ChartImages(chartDir)
+				if err != nil {
+					return fmt.Errorf("listing images: %w", err)
+				}
+				images = append(images, refs...)
+			}
+			if len(platforms) > 0 {
+				registryOpts, err := registryAccess.options()
+				if err != nil {
+					return err
+				}
+				resolve, err := registryOpts.PlatformResolver()
+				if err != nil {
+					return err
+				}
+				if images, err = generator.ResolveImagePlatforms(cmd.Context(), images, platforms, resolve); err != nil {
+					return fmt.Errorf("resolving image platforms: %w", err)
+				}
+			}
+
+			script, err := generator.GenerateMirrorScriptWith(images, registry, tool)
+			if err != nil {
+				return fmt.Errorf("generating mirror script: %w", err)
+			}
+			values, err := yaml.Marshal(generator.GenerateAirgapValues(registry))
+			if err != nil {
+				return err
+			}
+			imageList := generator.GenerateImageList(images)
+			files = append(files,
+				generator.BundleFile{Path: generator.BundleImagesFile, Content: []byte(imageList)},
+				generator.BundleFile{Path: generator.BundleMirrorScript, Content: []byte(script), Executable: true},
+				generator.BundleFile{Path: generator.BundleAirgapValues, Content: values},
+			)
+
+			f, err := os.Create(outputFile)
+			if err != nil {
+				return fmt.Errorf("cannot create bundle: %w", err)
+			}
+			defer f.Close()
+			if err := generator.WriteBundle(f, files); err != nil {
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s: %d chart(s), %d image(s) for %s\n",
+				outputFile, len(args), len(generator.ParseImageList(imageList)), registry)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&registry, "registry", "", "Target registry of the air-gapped environment, e.g. registry.internal:5000 (required)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "bundle.tar.gz", "Bundle file to write")
+	cmd.Flags().StringVar(&mirrorTool, "mirror-tool", string(generator.MirrorToolSkopeo), "Tool mirror-images.sh copies images with: skopeo or crane")
+	cmd.Flags().StringSliceVar(&platforms, "platforms", nil, "Platforms of the images to list and mirror, e.g. linux/amd64,linux/arm64, or \"all\" to list every platform; looks the images up in their registries (skopeo mirrors a single selected platform only)")
+	registryAccess.register(cmd.Flags())
+
+	cmd.AddCommand(newBundleVerifyCmd())
+	return cmd
+}
+
+func newBundleVerifyCmd() *cobra.Command {
+	var (
+		registry       string
+		registryAccess registryFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify <bundle>",
+		Short: "Check an air-gap bundle and that its images are in the target registry",
+		Long: `Check the files of a bundle written by "dhg bundle" against its SHA256SUMS,
+then check that every image of images.txt has been mirrored to the target
+registry, where mirror-images.sh copies it. Images pinned by digest must
+have been mirrored with the same digest, and images listed with their
+platforms must have been mirrored with each of them. Registry credentials are read from
+--registry-auth, DHG_REGISTRY_AUTH and the Docker config.
+
+The target registry defaults to the one the bundle was built for.`,
+		Example: `  dhg bundle verify myapp-bundle.tar.gz
+  dhg bundle verify myapp-bundle.tar.gz --registry mirror.internal:5000
+
+  # Through a TLS-intercepting proxy, with explicit credentials
+  dhg bundle verify myapp-bundle.tar.gz --proxy http://proxy.corp:3128 \
+    --ca-file corp-ca.pem --registry-auth registry.internal:5000=ci:$TOKEN`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registryOpts, err := registryAccess.options()
+			if err != nil {
+				return err
+			}
+			resolve, err := registryOpts.DigestResolver()
+			if err != nil {
+				return err
+			}
+			resolvePlatforms, err := registryOpts.PlatformResolver()
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("cannot open bundle: %w", err)
+			}
+			defer f.Close()
+			files, err := generator.ReadBundle(f)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Checksums: %d file(s) OK\n", len(files))
+
+			if registry == "" {
+				registry, err = bundleRegistry(files[generator.BundleAirgapValues])
+				if err != nil {
+					return err
+				}
+			}
+			images := generator.ParseImageList(string(files[generator.BundleImagesFile]))
+			results := generator.VerifyMirroredImages(cmd.Context(), images, registry, resolve, resolvePlatforms)
+			return printMirroredImages(out, results, registry)
+		},
+	}
+
+	cmd.Flags().StringVar(&registry, "registry", "", "Target registry to check (default: the registry of the bundle's values-airgap.yaml)")
+	registryAccess.register(cmd.Flags())
+	return cmd
+}
+
+// bundleRegistry returns the target registry recorded in the
+// values-airgap.yaml of a bundle.
+func bundleRegistry(values []byte) (string, error) {
+	var v struct {
+		Global struct {
+			ImageRegistry string `json:"imageRegistry"`
+		} `json:"global"`
+	}
+	if err := yaml.Unmarshal(values, &v); err != nil {
+		return "", fmt.Errorf("%s: %w", generator.BundleAirgapValues, err)
+	}
+	if v.Global.ImageRegistry == "" {
+		return "", fmt.Errorf("%s has no global.imageRegistry; set --registry", generator.BundleAirgapValues)
+	}
+	return v.Global.ImageRegistry, nil
+}
+
+// printMirroredImages reports the images checked by "dhg bundle verify" and
+// returns an error when any is not mirrored.
+func printMirroredImages(w io.Writer, results []generator.MirroredImage, registry string) error {
+	missing := 0
+	for _, r := range results {
+		if r.Err != nil {
+			missing++
+			fmt.Fprintf(w, "  ✗ %s: %v\n", r.Target, r.Err)
+			continue
+		}
+		fmt.Fprintf(w, "  ✓ %s (%s)\n", r.Target, r.Digest)
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of %d image(s) are not mirrored to %s", missing, len(results), registry)
+	}
+	fmt.Fprintf(w, "Images: %d mirrored to %s\n", len(results), registry)
+	return nil
+}
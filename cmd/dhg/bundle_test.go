@@ -0,0 +1,166 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func TestBundleCmd(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	target := strings.TrimPrefix(srv.URL, "http://")
+
+	bundle := filepath.Join(t.TempDir(), "app-bundle.tar.gz")
+	out, err := executeCmd(t, "bundle", filepath.Join(outDir, "app"), "--registry", target, "-o", bundle)
+	if err != nil {
+		t.Fatalf("bundle: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "1 chart(s), 1 image(s)") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	files, err := generator.ReadBundle(f)
+	if err != nil {
+		t.Fatalf("ReadBundle: %v", err)
+	}
+	for _, name := range []string{"charts/app-0.1.0.tgz", generator.BundleImagesFile, generator.BundleMirrorScript, generator.BundleAirgapValues} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+	if got := string(files[generator.BundleImagesFile]); got != "nginx:1.25\n" {
+		t.Errorf("images.txt = %q", got)
+	}
+	if script := string(files[generator.BundleMirrorScript]); !strings.Contains(script, "skopeo copy --all docker://nginx:1.25 docker://${TARGET_REGISTRY}/nginx:1.25\n") {
+		t.Errorf("unexpected mirror script:\n%s", script)
+	}
+
+	out, err = executeCmd(t, "bundle", "verify", bundle)
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 image(s) are not mirrored to "+target) {
+		t.Errorf("expected the image not to be mirrored yet, got %v\n%s", err, out)
+	}
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(img, target+"/nginx:1.25"); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeCmd(t, "bundle", "verify", bundle)
+	if err != nil {
+		t.Fatalf("verify: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Checksums: 4 file(s) OK") || !strings.Contains(out, "✓ "+target+"/nginx:1.25 (sha256:") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestBundleCmd_Platforms(t *testing.T) {
+	srv := httptest.NewServer(registry.New())
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	amd64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arm64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+	)
+	source, err := name.ParseReference(host + "/src/web:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteIndex(source, index); err != nil {
+		t.Fatal(err)
+	}
+	arm64Digest, _ := arm64.Digest()
+
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "nginx:%s", source.String(), 1)
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	bundle := filepath.Join(t.TempDir(), "app-bundle.tar.gz")
+	target := host + "/mirror"
+	out, err := executeCmd(t, "bundle", filepath.Join(outDir, "app"), "--registry", target, "-o", bundle,
+		"--mirror-tool", "crane", "--platforms", "linux/arm64")
+	if err != nil {
+		t.Fatalf("bundle: %v\n%s", err, out)
+	}
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	files, err := generator.ReadBundle(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(files[generator.BundleImagesFile]), source.String()+"\n#   linux/arm64 "+arm64Digest.String()+"\n"; got != want {
+		t.Errorf("images.txt = %q, want %q", got, want)
+	}
+	if script, want := string(files[generator.BundleMirrorScript]), "crane index filter "+source.String()+" --platform linux/arm64 -t ${TARGET_REGISTRY}/src/web:1.0\n"; !strings.Contains(script, want) {
+		t.Errorf("expected %q in mirror script:\n%s", want, script)
+	}
+
+	// A mirror of the wrong platform is reported.
+	if err := crane.Push(amd64, target+"/src/web:1.0"); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeCmd(t, "bundle", "verify", bundle)
+	if err == nil || !strings.Contains(out, "platform linux/arm64 ("+arm64Digest.String()+") not mirrored") {
+		t.Errorf("expected the arm64 platform to be missing, got %v\n%s", err, out)
+	}
+
+	if err := crane.Push(arm64, target+"/src/web:1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if out, err = executeCmd(t, "bundle", "verify", bundle); err != nil {
+		t.Fatalf("verify: %v\n%s", err, out)
+	}
+
+	if _, err := executeCmd(t, "bundle", filepath.Join(outDir, "app"), "--registry", target, "-o", bundle, "--platforms", "windows/amd64"); err == nil || !strings.Contains(err.Error(), "no windows/amd64 image") {
+		t.Errorf("expected an error for a missing platform, got %v", err)
+	}
+}
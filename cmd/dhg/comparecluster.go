@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+)
+
+func newCompareClusterCmd() *cobra.Command {
+	var opts compareClusterOptions
+
+	cmd := &cobra.Command{
+		Use:   "compare-cluster <chart-dir>",
+		Short: "Compare a chart with the live state of a cluster",
+		Long: `Render a chart with its values and compare every rendered resource with
+the live cluster, the way "kubectl diff" does: each resource is server-side
+applied with dryRun=All, so nothing is changed, and the result is compared
+with the live object. Defaults and admission changes of the API server are
+therefore not reported as drift. Each resource is reported as:
+  in-sync   applying the chart would not change it
+  drifted   the live object differs (shown as a diff from live to chart)
+  missing   the object does not exist in the cluster
+  failed    the cluster does not serve its kind or rejected it
+
+Server-managed metadata and status are not compared. Helm hooks and
+subcharts are not rendered. The command fails when any resource is not in
+sync.`,
+		Example: `  dhg compare-cluster ./chart/myapp -n production --values ./chart/myapp/values-prod.yaml
+
+  # Another cluster, with a value override
+  dhg compare-cluster ./chart/myapp --context staging -n myapp --set services.web.deployment.replicas=2`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.chartDir = args[0]
+			return runCompareCluster(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&opts.valueFiles, "values", nil, "Values file(s) merged over the chart's values.yaml, in order (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.setValues, "set", nil, "Set values as in helm --set: path=value, comma-separated, lists as {a,b}; applied after --values (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.setStrings, "set-string", nil, "Set string values as in helm --set-string, applied after --set (repeatable)")
+	cmd.Flags().StringVar(&opts.releaseName, "release-name", "release", "Release name the chart is rendered with")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", "default", "Release namespace: the namespace of resources without one")
+	cmd.Flags().StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to kubeconfig file")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVar(&opts.fieldManager, "field-manager", "dhg", "Field manager of the dry-run apply; use the manager that deploys the chart (e.g. helm) to see fields the chart no longer sets")
+
+	return cmd
+}
+
+type compareClusterOptions struct {
+	chartDir     string
+	valueFiles   []string
+	setValues    []string
+	setStrings   []string
+	releaseName  string
+	namespace    string
+	kubeconfig   string
+	kubeContext  string
+	fieldManager string
+}
+
+func runCompareCluster(ctx context.Context, w io.Writer, opts compareClusterOptions) error {
+	values, err := helm.ValueOverrides(opts.valueFiles, opts.setValues, opts.setStrings)
+	if err != nil {
+		return err
+	}
+	rendered, err := helm.RenderChart(opts.chartDir, helm.RenderOptions{
+		ReleaseName: opts.releaseName,
+		Namespace:   opts.namespace,
+		Values:      values,
+	})
+	if err != nil {
+		return err
+	}
+	all, err := parseObjects(rendered.Bundle())
+	if err != nil {
+		return err
+	}
+	var objs []*unstructured.Unstructured
+	for _, obj := range all {
+		if helmHook(obj) == "" {
+			objs = append(objs, obj)
+		}
+	}
+	if len(objs) == 0 {
+		return fmt.Errorf("the chart renders no resources")
+	}
+
+	cluster := extractor.NewClusterExtractorWithConfig(extractor.ClusterExtractorConfig{
+		Kubeconfig: opts.kubeconfig,
+		Context:    opts.kubeContext,
+	})
+	applied, err := cluster.DryRunApply(ctx, extractor.Options{}, objs, opts.namespace, opts.fieldManager)
+	if err != nil {
+		return err
+	}
+
+	drifts := make([]generator.ResourceDrift, 0, len(applied))
+	for _, a := range applied {
+		drifts = append(drifts, generator.CompareLiveObject(a.Desired, a.Live, a.Applied, a.Err))
+	}
+	return printClusterDrift(w, drifts)
+}
+
+// parseObjects parses the objects of a YAML stream, skipping empty
+// documents.
+func parseObjects(stream string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	for _, doc := range strings.Split("\n"+stream, "\n---") {
+		var m map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			return nil, fmt.Errorf("rendered output is not valid YAML: %w", err)
+		}
+		if len(m) == 0 {
+			continue
+		}
+		objs = append(objs, &unstructured.Unstructured{Object: m})
+	}
+	return objs, nil
+}
+
+// helmHook returns the hooks an object is run at, empty for an object that
+// is part of the release state.
+func helmHook(obj *unstructured.Unstructured) string {
+	return obj.GetAnnotations()["helm.sh/hook"]
+}
+
+// printClusterDrift reports the resources compared by "dhg compare-cluster"
+// and returns an error when any is not in sync.
+func printClusterDrift(w io.Writer, drifts []generator.ResourceDrift) error {
+	counts := make(map[generator.ClusterState]int)
+	for _, d := range drifts {
+		counts[d.State]++
+		switch d.State {
+		case generator.ClusterInSync:
+			fmt.Fprintf(w, "  ✓ %s\n", d)
+		case generator.ClusterMissing:
+			fmt.Fprintf(w, "  + %s: not in the cluster\n", d)
+		case generator.ClusterFailed:
+			fmt.Fprintf(w, "  ✗ %s: %v\n", d, d.Err)
+		default:
+			fmt.Fprintf(w, "  ~ %s: drifted\n", d)
+			printHunks(w, d.Hunks)
+		}
+	}
+	fmt.Fprintf(w, "\n%d in sync, %d drifted, %d missing, %d failed\n",
+		counts[generator.ClusterInSync], counts[generator.ClusterDrifted], counts[generator.ClusterMissing], counts[generator.ClusterFailed])
+	if differ := len(drifts) - counts[generator.ClusterInSync]; differ > 0 {
+		return fmt.Errorf("%d of %d resource(s) differ from the cluster", differ, len(drifts))
+	}
+	return nil
+}
+
+// printHunks prints diff hunks indented under the item they belong to.
+func printHunks(w io.Writer, hunks []generator.Hunk) {
+	for _, h := range hunks {
+		fmt.Fprintf(w, "      @@ line %d @@\n", h.Line)
+		for _, l := range h.Old {
+			fmt.Fprintf(w, "      -%s\n", strings.TrimSuffix(l, "\n"))
+		}
+		for _, l := range h.New {
+			fmt.Fprintf(w, "      +%s\n", strings.TrimSuffix(l, "\n"))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeApplyServer mimics an API server serving ConfigMaps: GET returns the
+// objects of live, and a dry-run apply returns the applied object with the
+// metadata the server adds.
+func fakeApplyServer(t *testing.T, live map[string]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1" {
+			_, _ = fmt.Fprint(w, `{"kind":"APIResourceList","resources":[{"name":"configmaps","kind":"ConfigMap","namespaced":true}]}`)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/prod/configmaps/")
+		switch r.Method {
+		case http.MethodGet:
+			obj, ok := live[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = fmt.Fprint(w, `{"kind":"Status","message":"not found"}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(obj)
+		case http.MethodPatch:
+			if r.URL.Query().Get("dryRun") != "All" {
+				t.Errorf("apply without dryRun=All: %s", r.URL)
+			}
+			var obj map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&obj)
+			obj["metadata"].(map[string]interface{})["resourceVersion"] = "2"
+			_ = json.NewEncoder(w).Encode(obj)
+		}
+	}))
+}
+
+func TestCompareClusterCmd(t *testing.T) {
+	chart := writeLintChart(t, map[string]string{
+		"values.yaml":         "replicas: 3\n",
+		"templates/cm.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  replicas: \"{{ .Values.replicas }}\"\n",
+		"templates/same.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: same\ndata:\n  key: value\n",
+		"templates/new.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: new\n",
+		"templates/hook.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: hook\n  annotations:\n    helm.sh/hook: pre-install\n",
+	})
+	configMap := func(name string, data map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "prod", "resourceVersion": "1"},
+			"data":       data,
+		}
+	}
+	srv := fakeApplyServer(t, map[string]map[string]interface{}{
+		"app":  configMap("app", map[string]interface{}{"replicas": "1"}),
+		"same": configMap("same", map[string]interface{}{"key": "value"}),
+	})
+	defer srv.Close()
+	kubeconfig := writeKubeconfig(t, srv.URL)
+
+	out, err := executeCmd(t, "compare-cluster", chart, "-n", "prod", "--kubeconfig", kubeconfig)
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 resource(s) differ from the cluster") {
+		t.Fatalf("expected drift to be reported as an error, got %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"✓ ConfigMap prod/same",
+		"~ ConfigMap prod/app: drifted",
+		"-  replicas: \"1\"",
+		"+  replicas: \"3\"",
+		"+ ConfigMap prod/new: not in the cluster",
+		"1 in sync, 1 drifted, 1 missing, 0 failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hook") {
+		t.Errorf("hooks should not be compared:\n%s", out)
+	}
+
+	out, err = executeCmd(t, "compare-cluster", chart, "-n", "prod", "--kubeconfig", kubeconfig, "--set", "replicas=1")
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 resource(s)") || !strings.Contains(out, "✓ ConfigMap prod/app") {
+		t.Errorf("--set should bring app in sync, got %v\n%s", err, out)
+	}
+}
@@ -4,9 +4,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
 )
 
+// DefaultConfigFile is the config file generate reads from the current
+// directory when --config is not set.
+const DefaultConfigFile = ".dhg.yaml"
+
 // DHGConfig holds the configuration for the deckhouse-helm-generator tool.
 // It is populated from a .dhg.yaml file and may be overridden by CLI flags.
 type DHGConfig struct {
@@ -36,6 +43,14 @@ type DHGConfig struct {
 
 	// Plugins lists paths to external processor plugin binaries.
 	Plugins []string `yaml:"plugins" json:"plugins"`
+
+	// Inject adds organization-wide labels and annotations to the generated
+	// resources.
+	Inject *generator.InjectionPolicy `yaml:"inject" json:"inject"`
+
+	// FeatureFlags defines feature flags gating resources of the chart
+	// beyond the standard categories of --feature-flags.
+	FeatureFlags []generator.CustomFeatureFlag `yaml:"featureFlags" json:"featureFlags"`
 }
 
 // LoadConfig reads a .dhg.yaml file at path and unmarshals it into a DHGConfig.
@@ -59,6 +74,19 @@ func LoadConfig(path string) (*DHGConfig, error) {
 	return cfg, nil
 }
 
+// resolveConfigFlag points an unset --config at DefaultConfigFile when the
+// current directory has one, so that the file is recorded with the other
+// flags.
+func resolveConfigFlag(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("config") {
+		return nil
+	}
+	if _, err := os.Stat(DefaultConfigFile); err != nil {
+		return nil
+	}
+	return cmd.Flags().Set("config", DefaultConfigFile)
+}
+
 // MergeConfigWithFlags returns a new DHGConfig where non-zero flag values
 // override the corresponding config fields. The original cfg is not mutated.
 // If flags is nil, a shallow copy of cfg is returned unchanged.
@@ -283,3 +283,64 @@ plugins:
 		}
 	}
 }
+
+// ── Test 11: LoadConfig — injection policy parsed correctly ──────────────────
+
+func TestLoadConfig_Inject(t *testing.T) {
+	yaml := `
+inject:
+  labels:
+    team: payments
+    cost-center: "4711"
+  annotations:
+    backstage.io/kubernetes-id: shop
+  excludeKinds:
+    - CustomResourceDefinition
+`
+	path := writeTempYAML(t, yaml)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Inject == nil {
+		t.Fatal("expected an injection policy")
+	}
+	if cfg.Inject.Labels["team"] != "payments" || cfg.Inject.Labels["cost-center"] != "4711" {
+		t.Errorf("Labels = %v", cfg.Inject.Labels)
+	}
+	if cfg.Inject.Annotations["backstage.io/kubernetes-id"] != "shop" {
+		t.Errorf("Annotations = %v", cfg.Inject.Annotations)
+	}
+	if len(cfg.Inject.ExcludeKinds) != 1 || cfg.Inject.ExcludeKinds[0] != "CustomResourceDefinition" {
+		t.Errorf("ExcludeKinds = %v", cfg.Inject.ExcludeKinds)
+	}
+}
+
+func TestLoadConfig_FeatureFlags(t *testing.T) {
+	yaml := `
+featureFlags:
+  - name: canary
+    valuesPath: canary.enabled
+    default: false
+    kinds: [Deployment]
+    resources:
+      - Service/*-canary
+`
+	path := writeTempYAML(t, yaml)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.FeatureFlags) != 1 {
+		t.Fatalf("FeatureFlags = %+v", cfg.FeatureFlags)
+	}
+	flag := cfg.FeatureFlags[0]
+	if flag.Name != "canary" || flag.ValuesPath != "canary.enabled" || flag.Default == nil || *flag.Default {
+		t.Errorf("FeatureFlags[0] = %+v", flag)
+	}
+	if len(flag.Kinds) != 1 || len(flag.Resources) != 1 || flag.Resources[0] != "Service/*-canary" {
+		t.Errorf("Kinds = %v, Resources = %v", flag.Kinds, flag.Resources)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/plugin"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// costOptions returns the cost estimation options of dhg analyze.
+func (o analyzeOptions) costOptions() (generator.CostEstimateOptions, error) {
+	provider := generator.CloudProvider(o.costProvider)
+	if !generator.IsCostProvider(provider) {
+		return generator.CostEstimateOptions{}, fmt.Errorf("invalid cost provider: %s (must be aws, gcp, or azure)", o.costProvider)
+	}
+	if o.cpuPrice < 0 || o.memoryPrice < 0 {
+		return generator.CostEstimateOptions{}, fmt.Errorf("--cpu-price and --memory-price must not be negative")
+	}
+	return generator.CostEstimateOptions{
+		Provider:              provider,
+		Unit:                  generator.CostUnitMonthly,
+		IncludeStorage:        true,
+		CPUPricePerHour:       o.cpuPrice,
+		MemoryPricePerGiBHour: o.memoryPrice,
+	}, nil
+}
+
+// costBaselineGraph extracts and analyzes the manifests of --cost-baseline
+// with the filters of the analyzed resources.
+func costBaselineGraph(ctx context.Context, opts analyzeOptions, plugins []*plugin.Plugin) (*types.ResourceGraph, error) {
+	extracted, warnings, err := dhg.Extract(ctx, types.SourceFile, extractor.Options{
+		Paths:         opts.costBaseline,
+		Namespace:     opts.namespace,
+		Namespaces:    opts.namespaces,
+		LabelSelector: opts.labelSelector,
+		IncludeKinds:  opts.includeKinds,
+		ExcludeKinds:  opts.excludeKinds,
+		Recursive:     opts.recursive,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cost baseline: %w", err)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", w)
+	}
+	pipeline := dhg.New(dhg.Options{ChartName: "analysis", Plugins: plugins})
+	processed, err := pipeline.Process(ctx, extracted)
+	if err != nil {
+		return nil, fmt.Errorf("cost baseline: %w", err)
+	}
+	return pipeline.Analyze(ctx, processed)
+}
+
+// costReportSection renders a cost estimate as a report section with an
+// item per service and a total.
+func costReportSection(report *generator.CostEstimateReport) pattern.ReportSection {
+	section := pattern.ReportSection{
+		Title: "Cost Estimate",
+		Description: fmt.Sprintf("Monthly cost of container requests (limits where requests are unset) × replicas at $%.4f per vCPU-hour and $%.4f per GiB-hour (%s prices for storage)",
+			report.CPUPricePerHour, report.MemoryPricePerGiBHour, report.Provider),
+	}
+
+	byService := make(map[string][]generator.WorkloadCostEstimate)
+	for _, w := range report.Workloads {
+		byService[w.Service] = append(byService[w.Service], w)
+	}
+	for _, svc := range report.Services {
+		var lines []string
+		level := "info"
+		for _, w := range byService[svc.Name] {
+			lines = append(lines, fmt.Sprintf("%s/%s: %d × (%s CPU, %s memory) = %s",
+				w.Kind, w.Name, w.Replicas, formatMillicores(w.CPUMillicores), formatMiB(w.MemoryMiB), formatCost(w.TotalCost)))
+			for _, warning := range w.Warnings {
+				lines = append(lines, "  Warning: "+warning)
+				level = "warning"
+			}
+		}
+		if svc.StorageCost > 0 {
+			lines = append(lines, "Storage: "+formatCost(svc.StorageCost))
+		}
+		section.Items = append(section.Items, pattern.ReportItem{
+			Title:   fmt.Sprintf("%s: %s/month", svc.Name, formatCost(svc.TotalCost)),
+			Content: strings.Join(lines, "\n"),
+			Level:   level,
+		})
+	}
+
+	var cpu, memory float64
+	for _, svc := range report.Services {
+		cpu += svc.CPUCost
+		memory += svc.MemoryCost
+	}
+	section.Items = append(section.Items, pattern.ReportItem{
+		Title: fmt.Sprintf("Total: %s/month", formatCost(report.GrandTotal)),
+		Content: fmt.Sprintf("CPU %s, memory %s, storage %s",
+			formatCost(cpu), formatCost(memory), formatCost(report.TotalStorageCost)),
+		Level: "info",
+	})
+	return section
+}
+
+// costDeltaSection renders the cost change from the --cost-baseline
+// manifests as a report section.
+func costDeltaSection(delta *generator.CostEstimateDelta, baseline []string) pattern.ReportSection {
+	section := pattern.ReportSection{
+		Title:       "Cost Delta",
+		Description: "Monthly cost change from the baseline manifests " + strings.Join(baseline, ", "),
+	}
+	for _, svc := range delta.Services {
+		if svc.Delta == 0 {
+			continue
+		}
+		section.Items = append(section.Items, pattern.ReportItem{
+			Title:   fmt.Sprintf("%s: %s/month", svc.Name, formatCostDelta(svc.Delta)),
+			Content: fmt.Sprintf("%s → %s", formatCost(svc.Baseline), formatCost(svc.Current)),
+			Level:   costDeltaLevel(svc.Delta),
+		})
+	}
+	section.Items = append(section.Items, pattern.ReportItem{
+		Title:   fmt.Sprintf("Total: %s/month", formatCostDelta(delta.Delta)),
+		Content: fmt.Sprintf("%s → %s", formatCost(delta.BaselineTotal), formatCost(delta.CurrentTotal)),
+		Level:   costDeltaLevel(delta.Delta),
+	})
+	return section
+}
+
+func costDeltaLevel(delta float64) string {
+	switch {
+	case delta > 0:
+		return "warning"
+	case delta < 0:
+		return "success"
+	}
+	return "info"
+}
+
+func formatCost(cost float64) string {
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+func formatCostDelta(delta float64) string {
+	if delta < 0 {
+		return "-" + formatCost(-delta)
+	}
+	return "+" + formatCost(delta)
+}
+
+func formatMillicores(m int64) string {
+	return resource.NewMilliQuantity(m, resource.DecimalSI).String()
+}
+
+func formatMiB(mib int64) string {
+	return resource.NewQuantity(mib*1024*1024, resource.BinarySI).String()
+}
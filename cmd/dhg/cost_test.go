@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCostManifest(t *testing.T, dir string, replicas, cpu string) string {
+	t.Helper()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: ` + replicas + `
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        resources:
+          requests:
+            cpu: ` + cpu + `
+            memory: 1Gi
+`
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "web.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAnalyzeCmd_Cost(t *testing.T) {
+	tmpDir := t.TempDir()
+	current := writeCostManifest(t, filepath.Join(tmpDir, "current"), "2", "500m")
+	baseline := writeCostManifest(t, filepath.Join(tmpDir, "baseline"), "1", "500m")
+	reportFile := filepath.Join(tmpDir, "report.md")
+
+	// 2 replicas × (0.5 vCPU × $0.10 + 1 GiB × $0.01) × 730 hours = $87.60
+	if _, err := executeCmd(t, "analyze", "-f", current, "--output-format", "markdown", "-o", reportFile,
+		"--cost-baseline", baseline, "--cpu-price", "0.10", "--memory-price", "0.01"); err != nil {
+		t.Fatalf("analyze --cost-baseline failed: %v", err)
+	}
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		"## Cost Estimate",
+		"### web: $87.60/month",
+		"Deployment/web: 2 × (500m CPU, 1Gi memory) = $87.60",
+		"### Total: $87.60/month",
+		"## Cost Delta",
+		"### web: +$43.80/month",
+		"$43.80 → $87.60",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("missing %q in report:\n%s", want, report)
+		}
+	}
+}
+
+func TestAnalyzeCmd_CostInvalidProvider(t *testing.T) {
+	path := writeCostManifest(t, t.TempDir(), "1", "100m")
+	_, err := executeCmd(t, "analyze", "-f", path, "--cost", "--cost-provider", "oracle")
+	if err == nil || !strings.Contains(err.Error(), "invalid cost provider") {
+		t.Errorf("expected invalid cost provider error, got %v", err)
+	}
+}
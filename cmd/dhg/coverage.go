@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func newCoverageCmd() *cobra.Command {
+	var (
+		paths            []string
+		source           string
+		namespace        string
+		namespaces       []string
+		labelSelector    string
+		includeKinds     []string
+		excludeKinds     []string
+		recursive        bool
+		kubeConfig       string
+		kubeContext      string
+		passthroughKinds []string
+		templateKinds    []string
+		plugins          []string
+		pluginDirs       []string
+		format           string
+		outputFile       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "coverage",
+		Short: "Report which resource kinds dhg templates with dedicated processors",
+		Long: `Run the input resources through the processors, without generating a chart,
+and report how every GroupVersionKind is handled:
+  processor    templated by a dedicated processor (named in the report)
+  generic      no processor handles the kind: only metadata is templated and
+               spec is copied into values as is
+  passthrough  copied verbatim (--passthrough-kinds, --template-kinds)
+  skipped      left out of the chart: objects owned by a controller or
+               created by Kubernetes (in cluster dumps), and resources the
+               processor failed on
+
+Generic kinds are where the chart parameterization is poor and a processor
+(or plugin) is worth adding, or where the dhg.deckhouse.io/values annotation
+should list the fields to move to values.`,
+		Example: `  # Coverage of manifests
+  dhg coverage -f ./manifests
+
+  # Coverage of a live namespace as JSON
+  dhg coverage -s cluster -n prod --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var sourceType types.Source
+			switch source {
+			case "file", "":
+				sourceType = types.SourceFile
+				if len(paths) == 0 {
+					return fmt.Errorf("at least one path is required for file source (-f flag)")
+				}
+			case "cluster":
+				sourceType = types.SourceCluster
+			case "compose":
+				sourceType = types.SourceCompose
+				if len(paths) == 0 {
+					return fmt.Errorf("at least one compose file is required for compose source (-f flag)")
+				}
+			default:
+				return fmt.Errorf("invalid source: %s (must be file, cluster, or compose)", source)
+			}
+			switch format {
+			case "text", "json":
+			default:
+				return fmt.Errorf("unknown format: %q (must be text or json)", format)
+			}
+
+			var (
+				mu      sync.Mutex
+				skipped []dhg.SkippedResource
+			)
+			resources, warnings, err := dhg.Extract(cmd.Context(), sourceType, extractor.Options{
+				Paths:         paths,
+				Namespace:     namespace,
+				Namespaces:    namespaces,
+				LabelSelector: labelSelector,
+				IncludeKinds:  includeKinds,
+				ExcludeKinds:  excludeKinds,
+				Recursive:     recursive,
+				Stdin:         cmd.InOrStdin(),
+				KubeConfig:    kubeConfig,
+				KubeContext:   kubeContext,
+				OnSkip: func(obj *unstructured.Unstructured, reason string) {
+					mu.Lock()
+					defer mu.Unlock()
+					skipped = append(skipped, dhg.SkippedResource{Object: obj, Reason: reason})
+				},
+			})
+			if err != nil {
+				return err
+			}
+			for _, w := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", w)
+			}
+
+			loaded, err := loadPlugins(cmd.Context(), plugins, pluginDirs, func(err error) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: plugin: %v\n", err)
+			})
+			if err != nil {
+				return err
+			}
+			pipeline := dhg.New(dhg.Options{
+				ChartName:        "coverage",
+				PassthroughKinds: passthroughKinds,
+				TemplateKinds:    templateKinds,
+				Plugins:          loaded,
+			})
+			coverage, err := pipeline.Coverage(cmd.Context(), resources, skipped)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return fmt.Errorf("cannot create output file: %w", err)
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := writeCoverage(out, coverage, format); err != nil {
+				return err
+			}
+			if outputFile != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Wrote coverage of %d kind(s) to %s\n", len(coverage.Kinds), outputFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories; - reads from stdin (required for file source)")
+	cmd.Flags().StringVarP(&source, "source", "s", "file", "Source type: file, cluster or compose")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Filter by namespace")
+	cmd.Flags().StringSliceVar(&namespaces, "namespaces", nil, "Filter by multiple namespaces")
+	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector filter")
+	cmd.Flags().StringSliceVar(&includeKinds, "include-kinds", nil, "Include only these resource kinds")
+	cmd.Flags().StringSliceVar(&excludeKinds, "exclude-kinds", nil, "Exclude these resource kinds")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Recursively scan directories")
+	cmd.Flags().StringVar(&kubeConfig, "kubeconfig", "", "Path to kubeconfig file")
+	cmd.Flags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringSliceVar(&passthroughKinds, "passthrough-kinds", nil, "Report resources of these kinds as copied verbatim, as generate does")
+	cmd.Flags().StringSliceVar(&templateKinds, "template-kinds", nil, "Report resources of kinds not listed as copied verbatim, as generate does")
+	cmd.Flags().StringSliceVar(&plugins, "plugin", nil, "Path(s) to plugin executables providing processors (subprocess JSON protocol)")
+	cmd.Flags().StringSliceVar(&pluginDirs, "plugin-dir", nil, "Directories to load all executable plugins from")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+
+	return cmd
+}
+
+func writeCoverage(w io.Writer, coverage *dhg.Coverage, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(coverage, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	for _, k := range coverage.Kinds {
+		detail := k.Processor
+		if k.Handling == dhg.HandlingSkipped {
+			detail = k.Reason
+		}
+		line := fmt.Sprintf("%-11s %-44s %4d  %s", k.Handling, k.APIVersion+" "+k.Kind, k.Resources, detail)
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+
+	r := coverage.Resources
+	fmt.Fprintf(w, "\n%d by processors, %d generic, %d passthrough, %d skipped\n",
+		r[dhg.HandlingProcessor], r[dhg.HandlingGeneric], r[dhg.HandlingPassthrough], r[dhg.HandlingSkipped])
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+)
+
+const coverageTestManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: gadget
+  namespace: prod
+spec:
+  size: 3
+---
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-7d4b9c
+  namespace: prod
+  resourceVersion: "12"
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: web
+      uid: 9f2e
+      controller: true
+`
+
+func TestCoverageCmd(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifests.yaml")
+	if err := os.WriteFile(manifest, []byte(coverageTestManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, "coverage", "-f", manifest)
+	if err != nil {
+		t.Fatalf("coverage: %v", err)
+	}
+	for _, want := range []string{
+		"processor   apps/v1 Deployment",
+		"generic     example.com/v1 Widget",
+		"skipped     apps/v1 ReplicaSet",
+		"owned by a controller",
+		"1 by processors, 1 generic, 0 passthrough, 1 skipped",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	report := filepath.Join(dir, "coverage.json")
+	if _, err := executeCmd(t, "coverage", "-f", manifest, "--passthrough-kinds", "Widget", "--format", "json", "-o", report); err != nil {
+		t.Fatalf("coverage --format json: %v", err)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var coverage dhg.Coverage
+	if err := json.Unmarshal(data, &coverage); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if coverage.Resources[dhg.HandlingPassthrough] != 1 || coverage.Resources[dhg.HandlingGeneric] != 0 {
+		t.Errorf("expected Widget passed through, got %+v", coverage)
+	}
+
+	if _, err := executeCmd(t, "coverage", "-f", manifest, "--format", "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
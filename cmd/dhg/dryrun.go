@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// dryRunFormats are the --output-format values of dhg generate --dry-run.
+var dryRunFormats = []string{"text", "yaml-bundle", "tar", "dir"}
+
+// validateDryRunFormat checks --output-format.
+func validateDryRunFormat(opts generateOptions) error {
+	valid := false
+	for _, f := range dryRunFormats {
+		valid = valid || opts.dryRunFormat == f
+	}
+	if !valid {
+		return fmt.Errorf("invalid --output-format %q (must be %s)", opts.dryRunFormat, strings.Join(dryRunFormats, ", "))
+	}
+	if opts.dryRunFormat != "text" && !opts.dryRun {
+		return fmt.Errorf("--output-format requires --dry-run")
+	}
+	return nil
+}
+
+// printDryRun writes the generated charts to w in a --dry-run output format:
+//
+//   - text: the chart files one after another, for reading;
+//   - yaml-bundle: the rendered manifests of all charts as one YAML stream,
+//     as printed by "helm template";
+//   - tar: a gzipped tarball of the charts, as packaged by "helm package";
+//   - dir: the charts are written to a new temporary directory and the path
+//     of each chart is printed, one per line.
+//
+// Nothing else is written to w, so that its output can be piped.
+func printDryRun(w io.Writer, charts []*types.GeneratedChart, format string) error {
+	if format == "text" {
+		printDryRunText(w, charts)
+		return nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dhg-dry-run-")
+	if err != nil {
+		return err
+	}
+	if format != "dir" {
+		defer os.RemoveAll(tmpDir)
+	}
+	if err := dhg.WriteCharts(charts, tmpDir); err != nil {
+		return err
+	}
+	chartDirs := make([]string, 0, len(charts))
+	for _, chart := range charts {
+		chartDirs = append(chartDirs, filepath.Join(tmpDir, chart.Name))
+	}
+
+	switch format {
+	case "dir":
+		for _, dir := range chartDirs {
+			fmt.Fprintln(w, dir)
+		}
+	case "tar":
+		if err := helm.WriteChartArchive(w, chartDirs...); err != nil {
+			return fmt.Errorf("dry-run: %w", err)
+		}
+	case "yaml-bundle":
+		for _, dir := range chartDirs {
+			rendered, err := helm.RenderChart(dir, helm.RenderOptions{ReleaseName: filepath.Base(dir)})
+			if err != nil {
+				return fmt.Errorf("dry-run: chart %s: %w", filepath.Base(dir), err)
+			}
+			io.WriteString(w, rendered.Bundle())
+		}
+	}
+	return nil
+}
+
+// printDryRunText writes the chart files one after another.
+func printDryRunText(w io.Writer, charts []*types.GeneratedChart) {
+	for _, chart := range charts {
+		fmt.Fprintf(w, "---\n# Chart: %s\n", chart.Name)
+		fmt.Fprintf(w, "# Chart.yaml\n%s\n", chart.ChartYAML)
+		fmt.Fprintf(w, "---\n# values.yaml\n%s\n", chart.ValuesYAML)
+
+		// Print templates sorted
+		templatePaths := make([]string, 0, len(chart.Templates))
+		for path := range chart.Templates {
+			templatePaths = append(templatePaths, path)
+		}
+		sort.Strings(templatePaths)
+		for _, path := range templatePaths {
+			fmt.Fprintf(w, "---\n# %s\n%s\n", path, chart.Templates[path])
+		}
+
+		if chart.Helpers != "" {
+			fmt.Fprintf(w, "---\n# templates/_helpers.tpl\n%s\n", chart.Helpers)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func dryRunTestChart() *types.GeneratedChart {
+	return &types.GeneratedChart{
+		Name:       "app",
+		ChartYAML:  "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		ValuesYAML: "replicas: 2\n",
+		Templates: map[string]string{
+			"templates/web-deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ .Release.Name }}-web\nspec:\n  replicas: {{ .Values.replicas }}\n",
+		},
+	}
+}
+
+func TestValidateDryRunFormat(t *testing.T) {
+	tests := []struct {
+		opts generateOptions
+		want string
+	}{
+		{generateOptions{dryRunFormat: "text"}, ""},
+		{generateOptions{dryRunFormat: "tar", dryRun: true}, ""},
+		{generateOptions{dryRunFormat: "tar"}, "requires --dry-run"},
+		{generateOptions{dryRunFormat: "zip", dryRun: true}, "invalid --output-format"},
+	}
+	for _, tt := range tests {
+		err := validateDryRunFormat(tt.opts)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("validateDryRunFormat(%+v) = %v; want %q", tt.opts, err, tt.want)
+		}
+	}
+}
+
+func TestPrintDryRun_YAMLBundle(t *testing.T) {
+	var out bytes.Buffer
+	if err := printDryRun(&out, []*types.GeneratedChart{dryRunTestChart()}, "yaml-bundle"); err != nil {
+		t.Fatal(err)
+	}
+	want := "---\n# Source: app/templates/web-deployment.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app-web\nspec:\n  replicas: 2\n"
+	if out.String() != want {
+		t.Errorf("bundle =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestPrintDryRun_Tar(t *testing.T) {
+	var out bytes.Buffer
+	if err := printDryRun(&out, []*types.GeneratedChart{dryRunTestChart()}, "tar"); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	if got := strings.Join(names, ","); !strings.Contains(got, "app/Chart.yaml") || !strings.Contains(got, "app/templates/web-deployment.yaml") {
+		t.Errorf("entries = %s", got)
+	}
+}
+
+func TestPrintDryRun_Dir(t *testing.T) {
+	var out bytes.Buffer
+	if err := printDryRun(&out, []*types.GeneratedChart{dryRunTestChart()}, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	dir := strings.TrimSpace(out.String())
+	defer os.RemoveAll(filepath.Dir(dir))
+	if filepath.Base(dir) != "app" {
+		t.Fatalf("printed %q; want the chart directory", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Chart.yaml")); err != nil {
+		t.Errorf("chart not written to %s: %v", dir, err)
+	}
+}
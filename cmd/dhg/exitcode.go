@@ -0,0 +1,38 @@
+package main
+
+import "errors"
+
+// Exit codes of dhg. Errors without an exit code exit with exitFailure.
+const (
+	// exitFailure: invalid flags, unreadable files and other failures.
+	exitFailure = 1
+	// exitPolicy: analyze found violations at or above --fail-on, or
+	// violations that are not in --baseline.
+	exitPolicy = 2
+	// exitExtraction: no resources could be extracted from the source.
+	exitExtraction = 3
+)
+
+// exitCodeError is an error that makes dhg exit with code.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// withExitCode makes err exit dhg with code.
+func withExitCode(code int, err error) error {
+	return &exitCodeError{code: code, err: err}
+}
+
+// exitCode returns the exit code of err.
+func exitCode(err error) int {
+	var e *exitCodeError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitFailure
+}
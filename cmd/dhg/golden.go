@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+)
+
+func newTestCmd() *cobra.Command {
+	var opts goldenOptions
+
+	cmd := &cobra.Command{
+		Use:   "test <chart-dir>",
+		Short: "Compare the rendered output of a chart with golden files",
+		Long: `Render a chart and compare every rendered template with its golden file,
+a snapshot of the expected output kept in the repository of the chart. The
+golden file of templates/deployment.yaml is <golden>/templates/deployment.yaml;
+templates/NOTES.txt is compared too. The command fails when a template renders
+differently, renders without a golden file, or no longer renders while its
+golden file exists.
+
+--update writes the golden files from the current output instead, removing
+those of templates that no longer render: run it to create the snapshots and
+to accept intended changes, and review the diff of the golden directory.
+
+Render a chart with several value sets by keeping a golden directory for
+each. Subcharts are not rendered.`,
+		Example: `  dhg test ./chart/myapp --golden ./chart/myapp/tests/golden/default --update
+  dhg test ./chart/myapp --golden ./chart/myapp/tests/golden/default
+
+  # Another value set
+  dhg test ./chart/myapp --golden ./chart/myapp/tests/golden/prod --values ./chart/myapp/values-prod.yaml
+
+  # Resources guarded by --capabilities-guards, with the CRDs of the cluster
+  dhg test ./chart/myapp --golden ./chart/myapp/tests/golden/default --api-versions monitoring.coreos.com/v1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.chartDir = args[0]
+			return runGoldenTest(cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.goldenDir, "golden", "", "Directory of the golden files (required)")
+	cmd.Flags().BoolVar(&opts.update, "update", false, "Write the golden files from the rendered output instead of comparing")
+	cmd.Flags().StringArrayVar(&opts.valueFiles, "values", nil, "Values file(s) merged over the chart's values.yaml, in order (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.setValues, "set", nil, "Set values as in helm --set: path=value, comma-separated, lists as {a,b}; applied after --values (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.setStrings, "set-string", nil, "Set string values as in helm --set-string, applied after --set (repeatable)")
+	cmd.Flags().StringVar(&opts.releaseName, "release-name", "release", "Release name the chart is rendered with")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", "default", "Release namespace the chart is rendered with")
+	cmd.Flags().StringArrayVarP(&opts.apiVersions, "api-versions", "a", nil, "API version reported by .Capabilities.APIVersions in addition to the built-in ones, e.g. monitoring.coreos.com/v1 (repeatable)")
+	_ = cmd.MarkFlagRequired("golden")
+
+	return cmd
+}
+
+type goldenOptions struct {
+	chartDir    string
+	goldenDir   string
+	update      bool
+	valueFiles  []string
+	setValues   []string
+	setStrings  []string
+	releaseName string
+	namespace   string
+	apiVersions []string
+}
+
+func runGoldenTest(w io.Writer, opts goldenOptions) error {
+	values, err := helm.ValueOverrides(opts.valueFiles, opts.setValues, opts.setStrings)
+	if err != nil {
+		return err
+	}
+	chart, err := helm.RenderChart(opts.chartDir, helm.RenderOptions{
+		ReleaseName: opts.releaseName,
+		Namespace:   opts.namespace,
+		APIVersions: opts.apiVersions,
+		Values:      values,
+	})
+	if err != nil {
+		return err
+	}
+	rendered := make(map[string]string, len(chart.Manifests)+1)
+	for path, content := range chart.Manifests {
+		if strings.TrimSpace(content) != "" {
+			rendered[path] = content
+		}
+	}
+	if strings.TrimSpace(chart.Notes) != "" {
+		rendered["templates/NOTES.txt"] = chart.Notes
+	}
+
+	if opts.update {
+		results, err := generator.UpdateSnapshots(rendered, opts.goldenDir)
+		if err != nil {
+			return err
+		}
+		counts := countSnapshots(results)
+		fmt.Fprintf(w, "Updated %d golden file(s) in %s: %d new, %d changed, %d removed\n",
+			len(results)-counts[generator.SnapshotMatch], opts.goldenDir,
+			counts[generator.SnapshotNew], counts[generator.SnapshotChanged], counts[generator.SnapshotRemoved])
+		return nil
+	}
+
+	results, err := generator.CompareSnapshots(rendered, opts.goldenDir)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		switch r.State {
+		case generator.SnapshotMatch:
+			fmt.Fprintf(w, "  ✓ %s\n", r.Path)
+		case generator.SnapshotNew:
+			fmt.Fprintf(w, "  + %s: no golden file\n", r.Path)
+		case generator.SnapshotRemoved:
+			fmt.Fprintf(w, "  - %s: no longer rendered\n", r.Path)
+		default:
+			fmt.Fprintf(w, "  ~ %s: changed\n", r.Path)
+			printHunks(w, r.Hunks)
+		}
+	}
+	counts := countSnapshots(results)
+	fmt.Fprintf(w, "\n%d match, %d changed, %d new, %d removed\n",
+		counts[generator.SnapshotMatch], counts[generator.SnapshotChanged], counts[generator.SnapshotNew], counts[generator.SnapshotRemoved])
+	if differ := len(results) - counts[generator.SnapshotMatch]; differ > 0 {
+		return fmt.Errorf("%d of %d template(s) differ from the golden files in %s; run with --update to accept the changes", differ, len(results), opts.goldenDir)
+	}
+	return nil
+}
+
+// countSnapshots counts the results of each state.
+func countSnapshots(results []generator.SnapshotResult) map[generator.SnapshotState]int {
+	counts := make(map[generator.SnapshotState]int)
+	for _, r := range results {
+		counts[r.State]++
+	}
+	return counts
+}
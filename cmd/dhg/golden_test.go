@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTestCmd_Golden(t *testing.T) {
+	chart := writeLintChart(t, map[string]string{
+		"values.yaml": "name: app\nreplicas: 1\n",
+		"templates/cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\ndata:\n" +
+			"  replicas: \"{{ .Values.replicas }}\"\n",
+	})
+	golden := filepath.Join(t.TempDir(), "golden")
+
+	if _, err := executeCmd(t, "test", chart); err == nil {
+		t.Error("expected --golden to be required")
+	}
+
+	out, err := executeCmd(t, "test", chart, "--golden", golden)
+	if err == nil || !strings.Contains(out, "+ templates/cm.yaml: no golden file") {
+		t.Fatalf("expected missing golden files to fail, got %v\n%s", err, out)
+	}
+
+	out, err = executeCmd(t, "test", chart, "--golden", golden, "--update")
+	if err != nil {
+		t.Fatalf("--update: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Updated 2 golden file(s)") {
+		t.Errorf("expected cm.yaml and NOTES.txt to be written:\n%s", out)
+	}
+	if data, err := os.ReadFile(filepath.Join(golden, "templates", "NOTES.txt")); err != nil || string(data) != "installed\n" {
+		t.Errorf("NOTES.txt golden file = %q, %v", data, err)
+	}
+
+	if out, err := executeCmd(t, "test", chart, "--golden", golden); err != nil {
+		t.Errorf("expected the snapshots to match: %v\n%s", err, out)
+	}
+
+	out, err = executeCmd(t, "test", chart, "--golden", golden, "--set", "replicas=3")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 template(s) differ") {
+		t.Fatalf("expected a changed rendering to fail, got %v\n%s", err, out)
+	}
+	for _, want := range []string{"~ templates/cm.yaml: changed", `-  replicas: "1"`, `+  replicas: "3"`, "✓ templates/NOTES.txt"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func newImagesCmd() *cobra.Command {
+	var (
+		paths         []string
+		source        string
+		namespace     string
+		namespaces    []string
+		labelSelector string
+		includeKinds  []string
+		excludeKinds  []string
+		recursive     bool
+		kubeConfig    string
+		kubeContext   string
+		format        string
+		outputFile    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "List the container images referenced by resources",
+		Long: `List every container image referenced by the input resources (containers,
+init containers and ephemeral containers of workloads) with the workloads
+that use it, without generating a chart.
+
+Formats:
+  text       image and its users, one image per line (default)
+  json       array of {image, repository, tag, digest, usedBy}
+  cyclonedx  CycloneDX 1.5 JSON BOM with a container component per image,
+             for vulnerability scanners`,
+		Example: `  # List images of manifests
+  dhg images -f ./manifests
+
+  # Write a CycloneDX BOM of what runs in the prod namespace
+  dhg images -s cluster -n prod --format cyclonedx -o sbom.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var sourceType types.Source
+			switch source {
+			case "file", "":
+				sourceType = types.SourceFile
+				if len(paths) == 0 {
+					return fmt.Errorf("at least one path is required for file source (-f flag)")
+				}
+			case "cluster":
+				sourceType = types.SourceCluster
+			case "compose":
+				sourceType = types.SourceCompose
+				if len(paths) == 0 {
+					return fmt.Errorf("at least one compose file is required for compose source (-f flag)")
+				}
+			default:
+				return fmt.Errorf("invalid source: %s (must be file, cluster, or compose)", source)
+			}
+			switch format {
+			case "text", "json", "cyclonedx":
+			default:
+				return fmt.Errorf("unknown format: %q (must be text, json, or cyclonedx)", format)
+			}
+
+			resources, warnings, err := dhg.Extract(cmd.Context(), sourceType, extractor.Options{
+				Paths:         paths,
+				Namespace:     namespace,
+				Namespaces:    namespaces,
+				LabelSelector: labelSelector,
+				IncludeKinds:  includeKinds,
+				ExcludeKinds:  excludeKinds,
+				Recursive:     recursive,
+				Stdin:         cmd.InOrStdin(),
+				KubeConfig:    kubeConfig,
+				KubeContext:   kubeContext,
+			})
+			if err != nil {
+				return err
+			}
+			for _, w := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", w)
+			}
+
+			images := generator.BuildImageInventory(resources)
+
+			out := cmd.OutOrStdout()
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return fmt.Errorf("cannot create output file: %w", err)
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := writeImageInventory(out, images, format); err != nil {
+				return err
+			}
+			if outputFile != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d image(s) to %s\n", len(images), outputFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories; - reads from stdin (required for file source)")
+	cmd.Flags().StringVarP(&source, "source", "s", "file", "Source type: file, cluster or compose")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Filter by namespace")
+	cmd.Flags().StringSliceVar(&namespaces, "namespaces", nil, "Filter by multiple namespaces")
+	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector filter")
+	cmd.Flags().StringSliceVar(&includeKinds, "include-kinds", nil, "Include only these resource kinds")
+	cmd.Flags().StringSliceVar(&excludeKinds, "exclude-kinds", nil, "Exclude these resource kinds")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Recursively scan directories")
+	cmd.Flags().StringVar(&kubeConfig, "kubeconfig", "", "Path to kubeconfig file")
+	cmd.Flags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json, cyclonedx")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+
+	return cmd
+}
+
+func writeImageInventory(w io.Writer, images []generator.InventoryImage, format string) error {
+	switch format {
+	case "json":
+		if images == nil {
+			images = []generator.InventoryImage{}
+		}
+		data, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "cyclonedx":
+		data, err := generator.GenerateCycloneDX(images, version)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	for _, img := range images {
+		users := make([]string, 0, len(img.UsedBy))
+		for _, u := range img.UsedBy {
+			users = append(users, u.String())
+		}
+		fmt.Fprintf(w, "%s\n  used by: %s\n", img.Image, strings.Join(users, ", "))
+	}
+	fmt.Fprintf(w, "\n%d image(s)\n", len(images))
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const imagesTestManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: ghcr.io/acme/migrate:v1
+      containers:
+        - name: web
+          image: nginx:1.25
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+  namespace: prod
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+              image: nginx:1.25
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  key: value
+`
+
+func TestImagesCmd(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifests.yaml")
+	if err := os.WriteFile(manifest, []byte(imagesTestManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, "images", "-f", manifest)
+	if err != nil {
+		t.Fatalf("images: %v", err)
+	}
+	if !strings.Contains(out, "nginx:1.25\n  used by: Deployment prod/web (web), CronJob prod/backup (backup)") {
+		t.Errorf("expected nginx with both users:\n%s", out)
+	}
+	if !strings.Contains(out, "2 image(s)") {
+		t.Errorf("expected 2 images:\n%s", out)
+	}
+
+	out, err = executeCmd(t, "images", "-f", manifest, "--format", "json")
+	if err != nil {
+		t.Fatalf("images --format json: %v", err)
+	}
+	var images []struct {
+		Image  string `json:"image"`
+		Tag    string `json:"tag"`
+		UsedBy []struct {
+			Container string `json:"container"`
+		} `json:"usedBy"`
+	}
+	if err := json.Unmarshal([]byte(out), &images); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(images) != 2 || images[0].Image != "ghcr.io/acme/migrate:v1" || images[0].UsedBy[0].Container != "migrate" {
+		t.Errorf("unexpected images: %+v", images)
+	}
+
+	sbom := filepath.Join(dir, "sbom.json")
+	if _, err := executeCmd(t, "images", "-f", manifest, "--format", "cyclonedx", "-o", sbom); err != nil {
+		t.Fatalf("images --format cyclonedx: %v", err)
+	}
+	data, err := os.ReadFile(sbom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"bomFormat": "CycloneDX"`) || !strings.Contains(string(data), `"type": "container"`) {
+		t.Errorf("unexpected BOM:\n%s", data)
+	}
+
+	if _, err := executeCmd(t, "images", "-f", manifest, "--format", "spdx"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+)
+
+func newInstallCheckCmd() *cobra.Command {
+	var opts installCheckOptions
+
+	cmd := &cobra.Command{
+		Use:   "install-check <chart-dir>",
+		Short: "Check that a chart would install or upgrade on a cluster",
+		Long: `Simulate "helm install" or "helm upgrade" of a chart: the CRDs of crds/
+and the rendered resources, including Helm hooks other than tests, are
+server-side applied with dryRun=All, so nothing is changed in the cluster.
+The API server runs its validation and the admission webhooks and policies
+of the cluster on every object, which reports problems a client-side render
+cannot see:
+  admission webhook   a validating or mutating webhook denied the object
+  admission policy    a ValidatingAdmissionPolicy denied the object
+  immutable field     the upgrade changes a field that cannot be changed,
+                      e.g. a Deployment selector or a Job template
+  invalid, forbidden  schema validation or authorization failed
+
+Custom resources whose CRD the chart installs are skipped when the cluster
+does not serve them yet. Objects that exist are upgraded, others created.
+
+With --local a throwaway kind cluster is created for the check and deleted
+afterwards (requires kind and Docker): it validates the chart against a
+clean cluster of the --local-image Kubernetes version, without the webhooks
+of a real one. The command fails when any object would be rejected.`,
+		Example: `  dhg install-check ./chart/myapp -n production --values ./chart/myapp/values-prod.yaml
+
+  # Against a clean Kubernetes 1.31 cluster
+  dhg install-check ./chart/myapp --local --local-image kindest/node:v1.31.0`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.chartDir = args[0]
+			if opts.local && (opts.kubeconfig != "" || opts.kubeContext != "") {
+				return fmt.Errorf("--local cannot be combined with --kubeconfig or --context")
+			}
+			if opts.localImage != "" && !opts.local {
+				return fmt.Errorf("--local-image requires --local")
+			}
+			return runInstallCheck(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), opts)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&opts.valueFiles, "values", nil, "Values file(s) merged over the chart's values.yaml, in order (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.setValues, "set", nil, "Set values as in helm --set: path=value, comma-separated, lists as {a,b}; applied after --values (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.setStrings, "set-string", nil, "Set string values as in helm --set-string, applied after --set (repeatable)")
+	cmd.Flags().StringVar(&opts.releaseName, "release-name", "release", "Release name the chart is rendered with")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", "default", "Release namespace: the namespace of resources without one; it must exist")
+	cmd.Flags().StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to kubeconfig file")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVar(&opts.fieldManager, "field-manager", "helm", "Field manager of the dry-run apply")
+	cmd.Flags().BoolVar(&opts.local, "local", false, "Check against a throwaway kind cluster instead of the kubeconfig cluster")
+	cmd.Flags().StringVar(&opts.localImage, "local-image", "", "kind node image of the --local cluster, e.g. kindest/node:v1.31.0 (default: the kind default)")
+
+	return cmd
+}
+
+type installCheckOptions struct {
+	chartDir     string
+	valueFiles   []string
+	setValues    []string
+	setStrings   []string
+	releaseName  string
+	namespace    string
+	kubeconfig   string
+	kubeContext  string
+	fieldManager string
+	local        bool
+	localImage   string
+}
+
+func runInstallCheck(ctx context.Context, w, progress io.Writer, opts installCheckOptions) error {
+	values, err := helm.ValueOverrides(opts.valueFiles, opts.setValues, opts.setStrings)
+	if err != nil {
+		return err
+	}
+	rendered, err := helm.RenderChart(opts.chartDir, helm.RenderOptions{
+		ReleaseName: opts.releaseName,
+		Namespace:   opts.namespace,
+		Values:      values,
+	})
+	if err != nil {
+		return err
+	}
+	objs, err := chartCRDs(opts.chartDir)
+	if err != nil {
+		return err
+	}
+	all, err := parseObjects(rendered.Bundle())
+	if err != nil {
+		return err
+	}
+	for _, obj := range all {
+		if !strings.Contains(helmHook(obj), "test") {
+			objs = append(objs, obj)
+		}
+	}
+	if len(objs) == 0 {
+		return fmt.Errorf("the chart renders no resources")
+	}
+
+	if opts.local {
+		kubeconfig, stop, err := startKindCluster(ctx, progress, opts.localImage)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		opts.kubeconfig = kubeconfig
+	}
+
+	cluster := extractor.NewClusterExtractorWithConfig(extractor.ClusterExtractorConfig{
+		Kubeconfig: opts.kubeconfig,
+		Context:    opts.kubeContext,
+	})
+	applied, err := cluster.DryRunApply(ctx, extractor.Options{}, objs, opts.namespace, opts.fieldManager)
+	if err != nil {
+		return err
+	}
+
+	kinds := chartCRDKinds(objs)
+	results := make([]generator.InstallResult, 0, len(applied))
+	for _, a := range applied {
+		r := generator.CheckInstallObject(a.Desired, a.Live, a.Applied, a.Err)
+		var notServed *extractor.NotServedError
+		if errors.As(a.Err, &notServed) && kinds[groupKind(a.Desired)] {
+			r.Action, r.Reason, r.Err = generator.InstallSkipped, "its CRD is installed by the chart", nil
+		}
+		results = append(results, r)
+	}
+	return printInstallCheck(w, results)
+}
+
+// chartCRDs returns the CRDs of the crds/ directory of a chart, which Helm
+// installs before the templates.
+func chartCRDs(chartDir string) ([]*unstructured.Unstructured, error) {
+	files, err := filepath.Glob(filepath.Join(chartDir, "crds", "*.y*ml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var crds []*unstructured.Unstructured
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := parseObjects(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		crds = append(crds, objs...)
+	}
+	return crds, nil
+}
+
+// chartCRDKinds returns the group/kind of the custom resources defined by
+// the CRDs among objs.
+func chartCRDKinds(objs []*unstructured.Unstructured) map[string]bool {
+	kinds := make(map[string]bool)
+	for _, obj := range objs {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+		kinds[group+"/"+kind] = true
+	}
+	return kinds
+}
+
+// groupKind returns the group/kind of obj.
+func groupKind(obj *unstructured.Unstructured) string {
+	return obj.GroupVersionKind().Group + "/" + obj.GetKind()
+}
+
+// startKindCluster creates a throwaway kind cluster and returns its
+// kubeconfig and a function deleting the cluster.
+func startKindCluster(ctx context.Context, progress io.Writer, image string) (string, func(), error) {
+	kind, err := exec.LookPath("kind")
+	if err != nil {
+		return "", nil, fmt.Errorf("--local requires kind (https://kind.sigs.k8s.io): %w", err)
+	}
+	dir, err := os.MkdirTemp("", "dhg-install-check-")
+	if err != nil {
+		return "", nil, err
+	}
+	name := filepath.Base(dir)
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+
+	args := []string{"create", "cluster", "--name", name, "--kubeconfig", kubeconfig, "--wait", "2m"}
+	if image != "" {
+		args = append(args, "--image", image)
+	}
+	fmt.Fprintf(progress, "Creating kind cluster %s...\n", name)
+	create := exec.CommandContext(ctx, kind, args...) //nolint:gosec
+	create.Stdout, create.Stderr = progress, progress
+
+	stop := func() {
+		del := exec.Command(kind, "delete", "cluster", "--name", name, "--kubeconfig", kubeconfig) //nolint:gosec
+		del.Stdout, del.Stderr = progress, progress
+		if err := del.Run(); err != nil {
+			fmt.Fprintf(progress, "Warning: cannot delete kind cluster %s: %v\n", name, err)
+		}
+		_ = os.RemoveAll(dir)
+	}
+	if err := create.Run(); err != nil {
+		stop()
+		return "", nil, fmt.Errorf("kind create cluster: %w", err)
+	}
+	return kubeconfig, stop, nil
+}
+
+// printInstallCheck reports the objects checked by "dhg install-check" and
+// returns an error when any would be rejected.
+func printInstallCheck(w io.Writer, results []generator.InstallResult) error {
+	counts := make(map[generator.InstallAction]int)
+	for _, r := range results {
+		counts[r.Action]++
+		switch r.Action {
+		case generator.InstallCreate:
+			fmt.Fprintf(w, "  + %s: create\n", r)
+		case generator.InstallUpdate:
+			fmt.Fprintf(w, "  ~ %s: update\n", r)
+		case generator.InstallUnchanged:
+			fmt.Fprintf(w, "  = %s: unchanged\n", r)
+		case generator.InstallSkipped:
+			fmt.Fprintf(w, "  - %s: skipped, %s\n", r, r.Reason)
+		default:
+			if r.Reason != "" {
+				fmt.Fprintf(w, "  ✗ %s: rejected (%s): %v\n", r, r.Reason, r.Err)
+			} else {
+				fmt.Fprintf(w, "  ✗ %s: rejected: %v\n", r, r.Err)
+			}
+			if r.Reason == generator.RejectImmutableField {
+				fmt.Fprintln(w, "      the object must be deleted and recreated, or renamed, for the upgrade")
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d to create, %d to update, %d unchanged, %d rejected, %d skipped\n",
+		counts[generator.InstallCreate], counts[generator.InstallUpdate], counts[generator.InstallUnchanged],
+		counts[generator.InstallRejected], counts[generator.InstallSkipped])
+	if n := counts[generator.InstallRejected]; n > 0 {
+		return fmt.Errorf("%d of %d resource(s) would be rejected", n, len(results))
+	}
+	return nil
+}
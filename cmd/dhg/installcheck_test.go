@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeInstallServer mimics an API server serving ConfigMaps and CRDs, with no
+// live objects, whose admission webhook denies the ConfigMap "deny". It
+// records the objects applied.
+func fakeInstallServer(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var applied []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1":
+			_, _ = fmt.Fprint(w, `{"kind":"APIResourceList","resources":[{"name":"configmaps","kind":"ConfigMap","namespaced":true}]}`)
+			return
+		case "/apis/apiextensions.k8s.io/v1":
+			_, _ = fmt.Fprint(w, `{"kind":"APIResourceList","resources":[{"name":"customresourcedefinitions","kind":"CustomResourceDefinition","namespaced":false}]}`)
+			return
+		}
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"kind":"Status","message":"not found"}`)
+			return
+		}
+		if r.URL.Query().Get("dryRun") != "All" {
+			t.Errorf("apply without dryRun=All: %s", r.URL)
+		}
+		mu.Lock()
+		applied = append(applied, r.URL.Path)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/deny") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"kind":"Status","message":"admission webhook \"policy.example.com\" denied the request: label team is required"}`)
+			return
+		}
+		var obj map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&obj)
+		_ = json.NewEncoder(w).Encode(obj)
+	}))
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), applied...)
+	}
+}
+
+func writeKubeconfig(t *testing.T, server string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	config := fmt.Sprintf("apiVersion: v1\nkind: Config\ncurrent-context: test\nclusters:\n- name: test\n  cluster:\n    server: %s\ncontexts:\n- name: test\n  context:\n    cluster: test\n    user: test\nusers:\n- name: test\n  user:\n    token: test\n", server)
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInstallCheckCmd(t *testing.T) {
+	chart := writeLintChart(t, map[string]string{
+		"crds/widgets.yaml":     "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\nspec:\n  group: example.com\n  names:\n    kind: Widget\n    plural: widgets\n  scope: Namespaced\n",
+		"templates/widget.yaml": "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n",
+		"templates/deny.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: deny\n",
+		"templates/hook.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: migrate\n  annotations:\n    helm.sh/hook: pre-install\n",
+		"templates/test.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: smoke\n  annotations:\n    helm.sh/hook: test\n",
+	})
+	srv, applied := fakeInstallServer(t)
+	defer srv.Close()
+	kubeconfig := writeKubeconfig(t, srv.URL)
+
+	out, err := executeCmd(t, "install-check", chart, "-n", "prod", "--kubeconfig", kubeconfig)
+	if err == nil || !strings.Contains(err.Error(), "1 of 5 resource(s) would be rejected") {
+		t.Fatalf("expected the webhook rejection to fail the check, got %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"+ CustomResourceDefinition widgets.example.com: create",
+		"+ ConfigMap prod/app: create",
+		"+ ConfigMap prod/migrate: create",
+		"- Widget w: skipped, its CRD is installed by the chart",
+		`✗ ConfigMap prod/deny: rejected (admission webhook): admission webhook "policy.example.com" denied the request`,
+		"3 to create, 0 to update, 0 unchanged, 1 rejected, 1 skipped",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, path := range applied() {
+		if strings.HasSuffix(path, "/smoke") {
+			t.Error("test hooks should not be applied")
+		}
+	}
+
+	if _, err := executeCmd(t, "install-check", chart, "--local", "--kubeconfig", kubeconfig); err == nil {
+		t.Error("expected --local with --kubeconfig to be refused")
+	}
+}
+
+func TestInstallCheckCmd_Local(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake kind is a shell script")
+	}
+	chart := writeLintChart(t, nil)
+	srv, _ := fakeInstallServer(t)
+	defer srv.Close()
+	kubeconfig := writeKubeconfig(t, srv.URL)
+
+	// The fake kind writes a kubeconfig of the fake API server on create and
+	// logs its arguments.
+	bin := t.TempDir()
+	log := filepath.Join(bin, "kind.log")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %[1]s
+if [ "$1" = create ]; then
+  while [ $# -gt 0 ]; do
+    if [ "$1" = --kubeconfig ]; then cp %[2]s "$2"; fi
+    shift
+  done
+fi
+`, log, kubeconfig)
+	if err := os.WriteFile(filepath.Join(bin, "kind"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	out, err := executeCmd(t, "install-check", chart, "--local", "--local-image", "kindest/node:v1.31.0")
+	if err != nil {
+		t.Fatalf("install-check --local: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "+ ConfigMap default/app: create") {
+		t.Errorf("expected the check to run against the kind cluster:\n%s", out)
+	}
+	calls, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "create cluster --name dhg-install-check-") ||
+		!strings.HasSuffix(lines[0], "--image kindest/node:v1.31.0") || !strings.HasPrefix(lines[1], "delete cluster --name dhg-install-check-") {
+		t.Errorf("expected kind to create and delete a cluster, got:\n%s", calls)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := executeCmd(t, "install-check", chart, "--local"); err == nil || !strings.Contains(err.Error(), "requires kind") {
+		t.Errorf("expected a missing kind to be reported, got %v", err)
+	}
+}
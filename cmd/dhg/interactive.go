@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// validServiceName matches names accepted for services in interactive mode.
+// Names end up in template paths and values keys, so keep them DNS-label-like.
+var validServiceName = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+
+// invalidServiceNameChars matches the runs of characters a service name
+// cannot contain.
+var invalidServiceNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// maxNameAttempts is how many invalid names are accepted for a service before
+// interactive mode gives up.
+const maxNameAttempts = 3
+
+// clusterScopedLabel is shown instead of an empty namespace in selection lists.
+const clusterScopedLabel = "(cluster-scoped)"
+
+// interactivePrompter drives the guided `dhg generate --interactive` flow.
+// It uses plain line-based prompts so it works in any terminal, over SSH and
+// when input is piped (e.g. in tests).
+type interactivePrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// eof is set once input is exhausted: every later answer is the default.
+	eof bool
+}
+
+// newInteractivePrompter creates a prompter reading answers from in and
+// writing prompts to out.
+func newInteractivePrompter(in io.Reader, out io.Writer) *interactivePrompter {
+	return &interactivePrompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// ask prints a question and returns the trimmed answer, or def when the
+// answer is empty or input is exhausted.
+func (p *interactivePrompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading input: %w", err)
+	}
+	if err == io.EOF {
+		p.eof = true
+		if line == "" {
+			fmt.Fprintln(p.out)
+		}
+	}
+
+	answer := strings.TrimSpace(line)
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// confirm asks a yes/no question. Empty input selects def.
+func (p *interactivePrompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+
+	for {
+		answer, err := p.ask(fmt.Sprintf("%s (%s)", question, hint), "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintf(p.out, "  Please answer y or n.\n")
+	}
+}
+
+// deselect shows a numbered list of items and lets the user exclude some of
+// them by number. Returns the items that remain selected, in input order.
+func (p *interactivePrompter) deselect(title string, items []string, counts map[string]int) ([]string, error) {
+	fmt.Fprintf(p.out, "\n%s:\n", title)
+	for i, item := range items {
+		fmt.Fprintf(p.out, "  %2d) %s (%d)\n", i+1, item, counts[item])
+	}
+
+	answer, err := p.ask("Numbers to exclude, comma-separated (Enter keeps all)", "")
+	if err != nil {
+		return nil, err
+	}
+	if answer == "" {
+		return items, nil
+	}
+
+	excluded := make(map[int]bool)
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(items) {
+			return nil, fmt.Errorf("invalid selection %q: expected numbers between 1 and %d", field, len(items))
+		}
+		excluded[n-1] = true
+	}
+
+	kept := make([]string, 0, len(items))
+	for i, item := range items {
+		if !excluded[i] {
+			kept = append(kept, item)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("at least one entry of %q must stay selected", strings.ToLower(title))
+	}
+	return kept, nil
+}
+
+// filterResources lets the user uncheck resource kinds and, when the input
+// spans several namespaces, namespaces. Returns the resources that remain.
+func (p *interactivePrompter) filterResources(resources []*types.ExtractedResource) ([]*types.ExtractedResource, error) {
+	kindCounts := make(map[string]int)
+	nsCounts := make(map[string]int)
+	for _, r := range resources {
+		kindCounts[r.GVK.Kind]++
+		nsCounts[namespaceLabel(r.Object.GetNamespace())]++
+	}
+
+	fmt.Fprintf(p.out, "Extracted %d resources.\n", len(resources))
+
+	keptKinds, err := p.deselect("Resource kinds", sortedKeys(kindCounts), kindCounts)
+	if err != nil {
+		return nil, err
+	}
+
+	keptNamespaces := sortedKeys(nsCounts)
+	if len(nsCounts) > 1 {
+		keptNamespaces, err = p.deselect("Namespaces", keptNamespaces, nsCounts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	kinds := toSet(keptKinds)
+	namespaces := toSet(keptNamespaces)
+
+	filtered := make([]*types.ExtractedResource, 0, len(resources))
+	for _, r := range resources {
+		if kinds[r.GVK.Kind] && namespaces[namespaceLabel(r.Object.GetNamespace())] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// nameServices asks for a name for every detected service. Returns a map of
+// detected name to chosen name, containing only the services that were renamed.
+func (p *interactivePrompter) nameServices(resources []*types.ExtractedResource) (map[string]string, error) {
+	seen := make(map[string]int)
+	for _, r := range resources {
+		seen[processor.ServiceNameFromResource(r.Object)]++
+	}
+	names := sortedKeys(seen)
+
+	fmt.Fprintf(p.out, "\nService names (Enter keeps the detected name):\n")
+
+	renames := make(map[string]string)
+	for _, name := range names {
+		def := suggestServiceName(name)
+		for attempt := 1; ; attempt++ {
+			answer, err := p.ask(fmt.Sprintf("  %s (%d resources)", name, seen[name]), def)
+			if err != nil {
+				return nil, err
+			}
+			if validServiceName.MatchString(answer) {
+				if answer != name {
+					renames[name] = answer
+				}
+				break
+			}
+			if p.eof {
+				return nil, fmt.Errorf("input ended before a valid name was given for service %q", name)
+			}
+			if attempt == maxNameAttempts {
+				return nil, fmt.Errorf("no valid name given for service %q after %d attempts", name, maxNameAttempts)
+			}
+			fmt.Fprintf(p.out, "  Invalid name %q: use lowercase letters, digits, '-' and '.'.\n", answer)
+		}
+	}
+	return renames, nil
+}
+
+// suggestServiceName returns the name offered for a detected service: the
+// name itself when valid, otherwise the sanitized service name lowercased
+// with the remaining invalid characters replaced by '-' (My_App -> my-app).
+// Returns "" when nothing valid is left.
+func suggestServiceName(name string) string {
+	if validServiceName.MatchString(name) {
+		return name
+	}
+	suggested := strings.ToLower(processor.SanitizeServiceName(name))
+	suggested = invalidServiceNameChars.ReplaceAllString(suggested, "-")
+	return strings.Trim(suggested, "-.")
+}
+
+// chooseMode shows the output modes with the analyzer's recommendation as
+// the default and returns the selected mode.
+func (p *interactivePrompter) chooseMode(recommended pattern.ChartStrategy) (types.OutputMode, error) {
+	modes := []types.OutputMode{
+		types.OutputModeUniversal,
+		types.OutputModeSeparate,
+		types.OutputModeLibrary,
+		types.OutputModeUmbrella,
+	}
+
+	// Hybrid has no dedicated generator; universal is the closest fit.
+	def := types.OutputModeUniversal
+	for _, m := range modes {
+		if string(m) == string(recommended) {
+			def = m
+		}
+	}
+
+	fmt.Fprintf(p.out, "\nOutput mode (analyzer recommends: %s):\n", recommended)
+	for i, m := range modes {
+		marker := " "
+		if m == def {
+			marker = "*"
+		}
+		fmt.Fprintf(p.out, "  %s%d) %s\n", marker, i+1, m)
+	}
+
+	for {
+		answer, err := p.ask("Select mode", string(def))
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(modes) {
+			return modes[n-1], nil
+		}
+		for _, m := range modes {
+			if answer == string(m) {
+				return m, nil
+			}
+		}
+		fmt.Fprintf(p.out, "  Unknown mode %q.\n", answer)
+	}
+}
+
+// previewCharts prints the values.yaml of every chart and asks whether to
+// write the result.
+func (p *interactivePrompter) previewCharts(charts []*types.GeneratedChart) (bool, error) {
+	for _, chart := range charts {
+		fmt.Fprintf(p.out, "\n--- %s/values.yaml (%d templates) ---\n%s\n", chart.Name, len(chart.Templates), chart.ValuesYAML)
+	}
+	return p.confirm("Write chart(s) to disk?", true)
+}
+
+// namespaceLabel returns a display label for a namespace.
+func namespaceLabel(ns string) string {
+	if ns == "" {
+		return clusterScopedLabel
+	}
+	return ns
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toSet converts a slice into a membership set.
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
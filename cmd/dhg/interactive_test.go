@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+func newTestPrompter(input string) (*interactivePrompter, *bytes.Buffer) {
+	out := new(bytes.Buffer)
+	return newInteractivePrompter(strings.NewReader(input), out), out
+}
+
+func makeExtracted(kind, namespace, name, app string) *types.ExtractedResource {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	if app != "" {
+		obj.SetLabels(map[string]string{"app": app})
+	}
+	return &types.ExtractedResource{
+		Object: obj,
+		Source: types.SourceFile,
+		GVK:    schema.GroupVersionKind{Version: "v1", Kind: kind},
+	}
+}
+
+func interactiveFixture() []*types.ExtractedResource {
+	return []*types.ExtractedResource{
+		makeExtracted("ConfigMap", "default", "web-config", "web"),
+		makeExtracted("Deployment", "default", "web", "web"),
+		makeExtracted("Deployment", "prod", "api", "api"),
+		makeExtracted("Service", "default", "web", "web"),
+	}
+}
+
+// ── confirm ───────────────────────────────────────────────────────────────────
+
+func TestInteractive_Confirm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		def   bool
+		want  bool
+	}{
+		{"empty uses default true", "\n", true, true},
+		{"empty uses default false", "\n", false, false},
+		{"yes", "yes\n", false, true},
+		{"no", "n\n", true, false},
+		{"retry after garbage", "maybe\ny\n", false, true},
+		{"EOF uses default", "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPrompter(tt.input)
+			got, err := p.confirm("Continue?", tt.def)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// ── filterResources ───────────────────────────────────────────────────────────
+
+func TestInteractive_FilterResources_KeepAll(t *testing.T) {
+	p, out := newTestPrompter("\n\n")
+	resources := interactiveFixture()
+
+	got, err := p.filterResources(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(resources) {
+		t.Errorf("expected %d resources, got %d", len(resources), len(got))
+	}
+	if !strings.Contains(out.String(), "Namespaces") {
+		t.Errorf("expected namespace selection for multi-namespace input, got:\n%s", out.String())
+	}
+}
+
+func TestInteractive_FilterResources_ExcludeKindAndNamespace(t *testing.T) {
+	// Kinds are sorted: 1) ConfigMap 2) Deployment 3) Service.
+	// Namespaces are sorted: 1) default 2) prod.
+	p, _ := newTestPrompter("1\n2\n")
+
+	got, err := p.filterResources(interactiveFixture())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(got))
+	}
+	for _, r := range got {
+		if r.GVK.Kind == "ConfigMap" {
+			t.Errorf("ConfigMap should have been excluded")
+		}
+		if r.Object.GetNamespace() == "prod" {
+			t.Errorf("namespace prod should have been excluded")
+		}
+	}
+}
+
+func TestInteractive_FilterResources_SingleNamespaceSkipsPrompt(t *testing.T) {
+	p, out := newTestPrompter("\n")
+	resources := []*types.ExtractedResource{
+		makeExtracted("Deployment", "default", "web", "web"),
+		makeExtracted("Service", "default", "web", "web"),
+	}
+
+	if _, err := p.filterResources(resources); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "Namespaces") {
+		t.Errorf("did not expect namespace selection for single-namespace input")
+	}
+}
+
+func TestInteractive_FilterResources_InvalidSelection(t *testing.T) {
+	p, _ := newTestPrompter("9\n")
+	if _, err := p.filterResources(interactiveFixture()); err == nil {
+		t.Fatal("expected error for out-of-range selection")
+	}
+}
+
+func TestInteractive_FilterResources_ExcludeAll(t *testing.T) {
+	p, _ := newTestPrompter("1,2,3\n")
+	if _, err := p.filterResources(interactiveFixture()); err == nil {
+		t.Fatal("expected error when every kind is excluded")
+	}
+}
+
+// ── nameServices ──────────────────────────────────────────────────────────────
+
+func TestInteractive_NameServices(t *testing.T) {
+	// Services are sorted: api, web. Keep api, rename web (after one invalid attempt).
+	p, out := newTestPrompter("\nBad_Name\nfrontend\n")
+
+	renames, err := p.nameServices(interactiveFixture())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(renames) != 1 {
+		t.Fatalf("expected 1 rename, got %v", renames)
+	}
+	if renames["web"] != "frontend" {
+		t.Errorf("expected web -> frontend, got %q", renames["web"])
+	}
+	if !strings.Contains(out.String(), "Invalid name") {
+		t.Errorf("expected invalid name warning in output")
+	}
+}
+
+// ── chooseMode ────────────────────────────────────────────────────────────────
+
+func TestInteractive_NameServices_InvalidDetectedName(t *testing.T) {
+	resources := []*types.ExtractedResource{makeExtracted("Deployment", "default", "web", "My_App")}
+
+	// Input is exhausted: the sanitized name is suggested and taken.
+	p, out := newTestPrompter("")
+	renames, err := p.nameServices(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renames["My_App"] != "my-app" {
+		t.Errorf("expected My_App -> my-app, got %v", renames)
+	}
+	if !strings.Contains(out.String(), "My_App (1 resources) [my-app]") {
+		t.Errorf("expected the sanitized name to be suggested:\n%s", out.String())
+	}
+
+	// Nothing valid to suggest and no input left.
+	p, _ = newTestPrompter("")
+	if _, err := p.nameServices([]*types.ExtractedResource{makeExtracted("Deployment", "default", "web", "__")}); err == nil {
+		t.Error("expected an error when input ends without a valid name")
+	}
+
+	// Invalid answers only.
+	p, _ = newTestPrompter("Bad_Name\nBad_Name\nBad_Name\nfrontend\n")
+	if _, err := p.nameServices(resources); err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("expected an error after 3 invalid names, got %v", err)
+	}
+}
+
+func TestInteractive_ChooseMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		recommended pattern.ChartStrategy
+		want        types.OutputMode
+	}{
+		{"default follows recommendation", "\n", pattern.StrategyLibrary, types.OutputModeLibrary},
+		{"hybrid falls back to universal", "\n", pattern.StrategyHybrid, types.OutputModeUniversal},
+		{"select by number", "2\n", pattern.StrategyUniversal, types.OutputModeSeparate},
+		{"select by name", "umbrella\n", pattern.StrategyUniversal, types.OutputModeUmbrella},
+		{"retry after unknown", "helmfile\n3\n", pattern.StrategyUniversal, types.OutputModeLibrary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPrompter(tt.input)
+			got, err := p.chooseMode(tt.recommended)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// ── previewCharts ─────────────────────────────────────────────────────────────
+
+func TestInteractive_PreviewCharts(t *testing.T) {
+	charts := []*types.GeneratedChart{{
+		Name:       "myapp",
+		ValuesYAML: "services:\n  web:\n    enabled: true\n",
+		Templates:  map[string]string{"templates/web.yaml": ""},
+	}}
+
+	p, out := newTestPrompter("n\n")
+	write, err := p.previewCharts(charts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if write {
+		t.Error("expected preview to be declined")
+	}
+	if !strings.Contains(out.String(), "enabled: true") {
+		t.Errorf("expected values.yaml in preview, got:\n%s", out.String())
+	}
+}
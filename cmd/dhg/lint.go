@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/lint"
+)
+
+func newLintCmd() *cobra.Command {
+	var (
+		paths        []string
+		failOn       string
+		outputFormat string
+		configFile   string
+		rulesFile    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Lint Helm charts with chart rules and best-practice checks",
+		Long: `Render charts and check them for:
+  - render errors and invalid rendered YAML
+  - values referenced in templates but not defined in values.yaml
+  - values.yaml keys not used by any template
+  - missing NOTES.txt
+  - deprecated or removed apiVersions
+  - best-practice checks of "dhg analyze" (BP-* rules)
+
+Rules are disabled or re-graded in .dhglint.yaml in the chart directory:
+  disable: [missing-notes, BP-HA-001]
+  severity:
+    unused-values: error
+
+The best-practice checks are tuned by the .dhg-rules.yaml of "dhg analyze"
+in the working directory:
+  minReplicas: 3
+  allowPrivileged: [node-exporter]`,
+		Example: `  # Lint a chart, failing on errors
+  dhg lint -f ./chart/myapp
+
+  # Fail on warnings too and print JSON
+  dhg lint -f ./chart/myapp --fail-on warning --output-format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLint(cmd.Context(), cmd.OutOrStdout(), lintOptions{
+				paths:        paths,
+				failOn:       failOn,
+				outputFormat: outputFormat,
+				configFile:   configFile,
+				rulesFile:    rulesFile,
+			})
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{"."}, "Path(s) to chart directories to lint")
+	cmd.Flags().StringVar(&failOn, "fail-on", "error", "Exit with an error when a finding has this severity or higher: warning, error")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format: text, json")
+	cmd.Flags().StringVar(&configFile, "config", "", "Lint config file (default: <chart>/"+lint.ConfigFile+")")
+	cmd.Flags().StringVar(&rulesFile, "rules", "", "Checker rule configuration file (default: ./"+pattern.RulesFile+")")
+
+	return cmd
+}
+
+type lintOptions struct {
+	paths        []string
+	failOn       string
+	outputFormat string
+	configFile   string
+	rulesFile    string
+}
+
+func runLint(ctx context.Context, w io.Writer, opts lintOptions) error {
+	if opts.failOn != string(lint.SeverityWarning) && opts.failOn != string(lint.SeverityError) {
+		return fmt.Errorf("invalid --fail-on: %s (must be warning or error)", opts.failOn)
+	}
+	threshold := lint.Severity(opts.failOn)
+	if opts.outputFormat != "text" && opts.outputFormat != "json" {
+		return fmt.Errorf("invalid output format: %s (must be text or json)", opts.outputFormat)
+	}
+
+	rulesFile := opts.rulesFile
+	if rulesFile == "" {
+		rulesFile = pattern.RulesFile
+	}
+	rules, err := pattern.LoadRules(rulesFile)
+	if err != nil {
+		return err
+	}
+
+	results := make([]*lint.Result, 0, len(opts.paths))
+	for _, chartPath := range opts.paths {
+		configFile := opts.configFile
+		if configFile == "" {
+			configFile = filepath.Join(chartPath, lint.ConfigFile)
+		}
+		cfg, err := lint.LoadConfig(configFile)
+		if err != nil {
+			return err
+		}
+		cfg.Rules = rules
+
+		result, err := lint.Lint(ctx, chartPath, cfg)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	}
+
+	if opts.outputFormat == "json" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	} else {
+		for _, r := range results {
+			printLintResult(w, r)
+		}
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Fails(threshold) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("lint failed: %d chart(s) have findings at or above %s", failed, threshold)
+	}
+	return nil
+}
+
+func printLintResult(w io.Writer, r *lint.Result) {
+	fmt.Fprintf(w, "Linting chart at: %s\n", r.Chart)
+	for _, f := range r.Findings {
+		file := f.File
+		if file == "" {
+			file = "-"
+		}
+		fmt.Fprintf(w, "  %-7s [%s] %s: %s\n", f.Severity, f.Rule, file, f.Message)
+	}
+	fmt.Fprintf(w, "%d error(s), %d warning(s), %d info\n\n",
+		r.Count(lint.SeverityError), r.Count(lint.SeverityWarning), r.Count(lint.SeverityInfo))
+}
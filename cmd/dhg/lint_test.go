@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLintChart(t *testing.T, extra map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":          "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"values.yaml":         "name: app\nleftover: true\n",
+		"templates/NOTES.txt": "installed\n",
+		"templates/cm.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\n",
+	}
+	for k, v := range extra {
+		files[k] = v
+	}
+	for rel, content := range files {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLintCmd_FailOn(t *testing.T) {
+	dir := writeLintChart(t, nil)
+
+	out, err := executeCmd(t, "lint", "-f", dir)
+	if err != nil {
+		t.Fatalf("warnings should not fail with the default --fail-on error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "[unused-values] values.yaml: leftover is not used by any template") {
+		t.Errorf("expected an unused-values warning:\n%s", out)
+	}
+
+	if _, err := executeCmd(t, "lint", "-f", dir, "--fail-on", "warning"); err == nil {
+		t.Error("expected --fail-on warning to fail on the unused value")
+	}
+	if _, err := executeCmd(t, "lint", "-f", dir, "--fail-on", "info"); err == nil {
+		t.Error("expected an invalid --fail-on to be rejected")
+	}
+}
+
+func TestLintCmd_ConfigAndJSON(t *testing.T) {
+	dir := writeLintChart(t, map[string]string{".dhglint.yaml": "disable: [unused-values]\n"})
+
+	out, err := executeCmd(t, "lint", "-f", dir, "--fail-on", "warning", "--output-format", "json")
+	if err != nil {
+		t.Fatalf("disabled rule should not fail the lint: %v\n%s", err, out)
+	}
+
+	var results []struct {
+		Chart    string `json:"chart"`
+		Findings []struct {
+			Rule string `json:"rule"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if len(results) != 1 || results[0].Chart != dir {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	for _, f := range results[0].Findings {
+		if f.Rule == "unused-values" {
+			t.Errorf("disabled rule reported: %+v", results)
+		}
+	}
+}
+
+func TestLintCmd_Rules(t *testing.T) {
+	dir := writeLintChart(t, map[string]string{
+		"templates/deploy.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node-exporter
+spec:
+  selector:
+    matchLabels:
+      app: node-exporter
+  template:
+    metadata:
+      labels:
+        app: node-exporter
+    spec:
+      containers:
+      - name: exporter
+        image: prom/node-exporter:v1.8.0
+        securityContext:
+          privileged: true
+`,
+	})
+
+	out, err := executeCmd(t, "lint", "-f", dir)
+	if err == nil || !strings.Contains(out, "[BP-SEC-003]") {
+		t.Fatalf("expected the privileged Deployment to fail the lint: %v\n%s", err, out)
+	}
+
+	rules := filepath.Join(t.TempDir(), ".dhg-rules.yaml")
+	if err := os.WriteFile(rules, []byte("allowPrivileged: [node-exporter]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeCmd(t, "lint", "-f", dir, "--rules", rules)
+	if strings.Contains(out, "[BP-SEC-003]") || strings.Contains(out, "[BP-PSS-001]") {
+		t.Errorf("allowed privileged workload reported: %v\n%s", err, out)
+	}
+}
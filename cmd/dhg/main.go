@@ -2,22 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/detector"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/lint"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/logging"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/metrics"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/policy"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/k8s"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/value"
@@ -44,7 +56,7 @@ func main() {
 
 	// Execute root command
 	if err := newRootCmd().ExecuteContext(ctx); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -56,24 +68,43 @@ func newRootCmd() *cobra.Command {
 from Kubernetes resources with automatic relationship detection.
 
 It supports extracting resources from:
-  - YAML files
+  - YAML files, Kustomize directories and Helm charts
+  - docker-compose files
   - Live Kubernetes clusters
   - GitOps repositories`,
 		Version: fmt.Sprintf("%s (built: %s)", version, buildTime),
 	}
 
 	rootCmd.AddCommand(newGenerateCmd())
+	rootCmd.AddCommand(newUpgradeChartCmd())
+	rootCmd.AddCommand(newStatusCmd())
+	rootCmd.AddCommand(newBumpCmd())
+	rootCmd.AddCommand(newRegenerateCmd())
 	rootCmd.AddCommand(newAnalyzeCmd())
+	rootCmd.AddCommand(newGraphCmd())
 	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newLintCmd())
 	rootCmd.AddCommand(newDiffCmd())
 	rootCmd.AddCommand(newMigrateCmd())
 	rootCmd.AddCommand(newFixCmd())
+	rootCmd.AddCommand(newImagesCmd())
+	rootCmd.AddCommand(newCoverageCmd())
+	rootCmd.AddCommand(newBundleCmd())
+	rootCmd.AddCommand(newCompareClusterCmd())
+	rootCmd.AddCommand(newInstallCheckCmd())
+	rootCmd.AddCommand(newTestCmd())
 	rootCmd.AddCommand(newVersionCmd())
 
 	return rootCmd
 }
 
 func newGenerateCmd() *cobra.Command {
+	return newGenerateLikeCmd(runGenerate)
+}
+
+// newGenerateLikeCmd builds the generate command with its flags; commands that
+// reuse the generate pipeline (upgrade-chart) pass their own run function.
+func newGenerateLikeCmd(run func(context.Context, generateOptions) error) *cobra.Command {
 	var (
 		paths           []string
 		outputDir       string
@@ -87,7 +118,11 @@ func newGenerateCmd() *cobra.Command {
 		labelSelector   string
 		includeKinds    []string
 		excludeKinds    []string
+		omitFields      []string
+		defaultOmit     bool
 		recursive       bool
+		helmValues      []string
+		helmRelease     string
 		kubeConfig         string
 		kubeContext        string
 		clusterNamespace   string
@@ -100,11 +135,22 @@ func newGenerateCmd() *cobra.Command {
 		verbose         bool
 		envValues       bool
 		deckhouseModule    bool
+		werf               bool
 		dryRun             bool
+		dryRunFormat       string
+		summaryJSON        string
 		airgapRegistry     string
+		mirrorTool         string
+		platforms          []string
 		namespaceResources bool
+		namespacePreset    string
 		multiTenant        bool
 		featureFlags       bool
+		capabilitiesGuards bool
+		fixes              []string
+		applyBestPractices bool
+		synthesizeProbes   bool
+		probePath          string
 		cloudProvider      string
 		cloudInternal      bool
 		detectIngress      bool
@@ -114,10 +160,64 @@ func newGenerateCmd() *cobra.Command {
 		kustomize          bool
 		postRenderer       bool
 		autoDeps           bool
+		depsIndex          string
+		depsOffline        bool
 		tenantCount        int
+		tenantsFile        string
 		templateStyle      string
 		includeHooks       bool
+		inferHooks         bool
+		externalizeConfigs bool
+		preserveSelectors  bool
+		passthroughKinds   []string
+		templateKinds      []string
+		apiUpgrade         bool
+		ha                 bool
+		topologySpread     bool
+		autoscaling        bool
+		serviceAccounts    bool
+		priorityClasses    bool
+		noTokenAutomount   bool
+		skipPVs            bool
+		clusterScope       string
+		onDuplicate        string
+		renames            []string
+		imageRewrites      []string
+		pinDigests         bool
+		registryAccess     registryFlags
 		valuesFlat         bool
+		valuesDocs         bool
+		dedupValues        bool
+		preserveNamespaces bool
+		tplEnv             bool
+		valueFiles         []string
+		setValues          []string
+		setStrings         []string
+		configFile         string
+		interactive        bool
+		logFormat          string
+		logLevel           string
+		plugins            []string
+		pluginDirs         []string
+		groupBy            string
+		groupsFile         string
+		chartMetadata      string
+		chartAPIVersion    string
+		maintainers        []string
+		home               string
+		chartSources       []string
+		keywords           []string
+		icon               string
+		kubeVersion        string
+		chartAnnotations   []string
+		metricsSource      string
+		prometheusURL      string
+		metricsWindow      time.Duration
+		sign               string
+		signKey            string
+		fromSnapshot       string
+		watch              bool
+		watchDebounce      time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -129,14 +229,33 @@ Examples:
   # Generate from YAML files
   dhg generate -f ./manifests -o ./chart --chart-name myapp
 
+  # Generate from a Kustomize overlay (built before extraction)
+  dhg generate -f ./deploy/overlays/prod --chart-name myapp
+
+  # Re-chart an existing Helm chart rendered with its values
+  dhg generate -f ./legacy-chart --helm-values values-prod.yaml --chart-name myapp
+
+  # Convert a docker-compose project
+  dhg generate -s compose -f ./docker-compose.yml --chart-name myapp
+
+  # Generate from manifests piped on stdin
+  kubectl get all -n app -o yaml | dhg generate -f - --chart-name myapp
+
   # Generate from live cluster
   dhg generate -s cluster -n production --kubeconfig ~/.kube/config
 
   # Generate with filtering
-  dhg generate -f ./manifests --include-kinds Deployment,Service,Ingress`,
+  dhg generate -f ./manifests --include-kinds Deployment,Service,Ingress
+
+  # Guided generation: pick kinds, namespaces, mode and service names
+  dhg generate -f ./manifests --chart-name myapp --interactive`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGenerate(cmd.Context(), generateOptions{
+			if err := resolveConfigFlag(cmd); err != nil {
+				return err
+			}
+			return run(cmd.Context(), generateOptions{
 				paths:           paths,
+				stdin:           cmd.InOrStdin(),
 				outputDir:       outputDir,
 				chartName:       chartName,
 				chartVersion:    chartVersion,
@@ -148,7 +267,10 @@ Examples:
 				labelSelector:   labelSelector,
 				includeKinds:    includeKinds,
 				excludeKinds:    excludeKinds,
+				omitFields:      omitFieldPaths(omitFields, defaultOmit),
 				recursive:       recursive,
+				helmValues:      helmValues,
+				helmRelease:     helmRelease,
 				kubeConfig:       kubeConfig,
 				kubeContext:      kubeContext,
 				clusterNamespace: clusterNamespace,
@@ -161,11 +283,22 @@ Examples:
 				verbose:         verbose,
 				envValues:       envValues,
 				deckhouseModule:    deckhouseModule,
+				werf:               werf,
 				dryRun:             dryRun,
+				dryRunFormat:       dryRunFormat,
+				summaryJSON:        summaryJSON,
 				airgapRegistry:     airgapRegistry,
+				mirrorTool:         mirrorTool,
+				platforms:          platforms,
 				namespaceResources: namespaceResources,
+				namespacePreset:    namespacePreset,
 				multiTenant:        multiTenant,
 				featureFlags:       featureFlags,
+				capabilitiesGuards: capabilitiesGuards,
+				fixes:              fixes,
+				applyBestPractices: applyBestPractices,
+				synthesizeProbes:   synthesizeProbes,
+				probePath:          probePath,
 				cloudProvider:      cloudProvider,
 				cloudInternal:      cloudInternal,
 				detectIngress:      detectIngress,
@@ -175,27 +308,87 @@ Examples:
 				kustomize:          kustomize,
 				postRenderer:       postRenderer,
 				autoDeps:           autoDeps,
+				depsIndex:          depsIndex,
+				depsOffline:        depsOffline,
 				tenantCount:        tenantCount,
+				tenantsFile:        tenantsFile,
 				templateStyle:      templateStyle,
 				includeHooks:       includeHooks,
+				inferHooks:         inferHooks,
+				externalizeConfigs: externalizeConfigs,
+				preserveSelectors:  preserveSelectors,
+				passthroughKinds:   passthroughKinds,
+				templateKinds:      templateKinds,
+				apiUpgrade:         apiUpgrade,
+				ha:                 ha,
+				topologySpread:     topologySpread,
+				autoscaling:        autoscaling,
+				serviceAccounts:    serviceAccounts,
+				priorityClasses:    priorityClasses,
+				noTokenAutomount:   noTokenAutomount,
+				skipPVs:            skipPVs,
+				clusterScope:       clusterScope,
+				onDuplicate:        onDuplicate,
+				renames:            renames,
+				imageRewrites:      imageRewrites,
+				pinDigests:         pinDigests,
+				registryAccess:     registryAccess,
 				valuesFlat:         valuesFlat,
+				valuesDocs:         valuesDocs,
+				dedupValues:        dedupValues,
+				preserveNamespaces: preserveNamespaces,
+				tplEnv:             tplEnv,
+				valueFiles:         valueFiles,
+				setValues:          setValues,
+				setStrings:         setStrings,
+				configFile:         configFile,
+				interactive:        interactive,
+				logFormat:          logFormat,
+				logLevel:           logLevel,
+				plugins:            plugins,
+				pluginDirs:         pluginDirs,
+				groupBy:            groupBy,
+				groupsFile:         groupsFile,
+				chartMetadata:      chartMetadata,
+				chartAPIVersion:    chartAPIVersion,
+				maintainers:        maintainers,
+				home:               home,
+				chartSources:       chartSources,
+				keywords:           keywords,
+				icon:               icon,
+				kubeVersion:        kubeVersion,
+				chartAnnotations:   chartAnnotations,
+				metricsSource:      metricsSource,
+				prometheusURL:      prometheusURL,
+				metricsWindow:      metricsWindow,
+				sign:               sign,
+				signKey:            signKey,
+				flags:              changedFlags(cmd),
+				options:            generationOptions(cmd),
+				fromSnapshot:       fromSnapshot,
+				watch:              watch,
+				watchDebounce:      watchDebounce,
 			})
 		},
 	}
 
-	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories")
+	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories (- reads a YAML stream from stdin)")
 	cmd.Flags().StringVarP(&outputDir, "output", "o", "./chart", "Output directory for the chart")
 	cmd.Flags().StringVar(&chartName, "chart-name", "", "Name of the chart (required)")
-	cmd.Flags().StringVar(&chartVersion, "chart-version", "0.1.0", "Chart version")
+	cmd.Flags().StringVar(&chartVersion, "chart-version", "0.1.0", "Chart version, or auto to derive it from the git tags and commits of the input")
 	cmd.Flags().StringVar(&appVersion, "app-version", "1.0.0", "Application version")
 	cmd.Flags().StringVar(&mode, "mode", "universal", "Output mode: universal, separate, library, umbrella")
-	cmd.Flags().StringVarP(&source, "source", "s", "file", "Source type: file (default). cluster and gitops are not yet implemented.")
+	cmd.Flags().StringVarP(&source, "source", "s", "file", "Source type: file (default), cluster or compose (docker-compose.yml). gitops is not yet implemented.")
 	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Filter by namespace")
 	cmd.Flags().StringSliceVar(&namespaces, "namespaces", []string{}, "Filter by multiple namespaces")
 	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector filter")
 	cmd.Flags().StringSliceVar(&includeKinds, "include-kinds", []string{}, "Include only these resource kinds")
 	cmd.Flags().StringSliceVar(&excludeKinds, "exclude-kinds", []string{}, "Exclude these resource kinds")
+	cmd.Flags().StringArrayVar(&omitFields, "omit-fields", nil, `Field path to strip from source manifests, e.g. metadata.annotations["example.com/key"] or spec.template.spec.containers.*.terminationMessagePath (repeatable; added to the defaults)`)
+	cmd.Flags().BoolVar(&defaultOmit, "default-omit-fields", true, "Strip last-applied-configuration, managedFields, creationTimestamp, status, nodeName and other server-populated fields from source manifests")
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Recursively scan directories")
+	cmd.Flags().StringSliceVar(&helmValues, "helm-values", []string{}, "Values file(s) for rendering input Helm charts (directories with Chart.yaml)")
+	cmd.Flags().StringVar(&helmRelease, "helm-release-name", "release", "Release name for rendering input Helm charts")
 	cmd.Flags().StringVar(&kubeConfig, "kubeconfig", "", "Path to kubeconfig file")
 	cmd.Flags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use")
 	cmd.Flags().StringVar(&clusterNamespace, "cluster-namespace", "", "Namespace for cluster extraction (not yet implemented)")
@@ -208,24 +401,90 @@ Examples:
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	cmd.Flags().BoolVar(&envValues, "env-values", false, "Generate environment-specific values (dev/staging/prod)")
 	cmd.Flags().BoolVar(&deckhouseModule, "deckhouse-module", false, "Generate Deckhouse module scaffold (helm_lib, openapi/, images/, hooks/)")
+	cmd.Flags().BoolVar(&werf, "werf", false, "Generate a werf project: werf.yaml with an image per service and the chart in .helm/")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print generated chart to stdout without writing to disk")
+	cmd.Flags().StringVar(&summaryJSON, "summary-json", "", "Write a JSON summary of the generation (charts, templates, values keys, detected patterns, warnings) to this file, or - for stdout")
+	cmd.Flags().StringVar(&dryRunFormat, "output-format", "text", "Output format of --dry-run: text, yaml-bundle (rendered manifests), tar (gzipped charts) or dir (charts in a temporary directory)")
 	cmd.Flags().StringVar(&airgapRegistry, "airgap-registry", "", "Generate air-gapped artifacts (images.txt, values-airgap.yaml, mirror-images.sh) targeting this registry")
-	cmd.Flags().BoolVar(&namespaceResources, "namespace-resources", false, "Generate namespace governance resources (ResourceQuota, LimitRange, NetworkPolicy)")
+	cmd.Flags().StringVar(&mirrorTool, "mirror-tool", string(generator.MirrorToolSkopeo), "Tool mirror-images.sh copies images with: skopeo or crane (with --airgap-registry)")
+	cmd.Flags().StringSliceVar(&platforms, "platforms", nil, "Platforms of the images to list and mirror, e.g. linux/amd64,linux/arm64, or \"all\" to list every platform; looks the images up in their registries (with --airgap-registry; skopeo mirrors a single selected platform only)")
+	cmd.Flags().BoolVar(&namespaceResources, "namespace-resources", false, "Generate namespace governance resources (ResourceQuota, LimitRange, NetworkPolicy, Pod Security labels)")
+	cmd.Flags().StringVar(&namespacePreset, "namespace-preset", "", "Sizing of the --namespace-resources quota and LimitRange: small, medium or large, or a preset YAML file (default medium)")
 	cmd.Flags().BoolVar(&multiTenant, "multi-tenant", false, "Generate multi-tenant chart overlay with per-tenant isolation")
-	cmd.Flags().BoolVar(&featureFlags, "feature-flags", false, "Inject feature flags (monitoring, ingress, autoscaling, security, storage, rbac)")
-	cmd.Flags().StringVar(&cloudProvider, "cloud-provider", "", "Cloud provider for Service annotations (aws, gcp, azure)")
+	cmd.Flags().BoolVar(&capabilitiesGuards, "capabilities-guards", false, "Render CRD and beta API resources only when the cluster serves their API, falling back to older apiVersions of PDB, HPA and CronJob")
+	cmd.Flags().BoolVar(&featureFlags, "feature-flags", false, "Inject feature flags (monitoring, ingress, autoscaling, security, storage, rbac and the featureFlags of --config)")
+	cmd.Flags().StringSliceVar(&fixes, "fix", nil, "Apply generator fixes to the chart (graceful-shutdown: parameterized terminationGracePeriodSeconds and preStop sleep for workloads behind a Service or Ingress)")
+	cmd.Flags().BoolVar(&synthesizeProbes, "synthesize-probes", false, "Add HTTP readiness and liveness probes to Deployment containers that expose an HTTP port and have no probes, gated by services.<name>.probes.enabled")
+	cmd.Flags().StringVar(&probePath, "probe-path", generator.DefaultProbePath, "HTTP path of the probes added by --synthesize-probes")
+	cmd.Flags().BoolVar(&applyBestPractices, "apply-best-practices", false, "Fix the auto-fixable best-practice violations found by analyze in the chart values (resources, probe stubs, securityContext, replicas) and print what was changed")
+	cmd.Flags().StringVar(&cloudProvider, "cloud-provider", "", "Cloud provider for Service annotations and the IngressClass, StorageClass and workload identity defaults in values (aws, gcp, azure, yandex, vk, openstack)")
 	cmd.Flags().BoolVar(&cloudInternal, "cloud-internal", false, "Use internal load balancer for cloud annotations")
 	cmd.Flags().BoolVar(&detectIngress, "detect-ingress", false, "Auto-detect ingress controller and generate controller-specific annotations")
 	cmd.Flags().BoolVar(&monorepo, "monorepo", false, "Generate monorepo layout with Makefile, .helmignore, and ct.yaml")
-	cmd.Flags().BoolVar(&spot, "spot", false, "Inject spot/preemptible instance tolerations and PDB")
+	cmd.Flags().BoolVar(&spot, "spot", false, "Schedule stateless Deployments with 2+ replicas on spot/preemptible instances (tolerations, PDB); other workloads prefer on-demand nodes")
 	cmd.Flags().IntVar(&spotGracePeriod, "spot-grace-period", 15, "Grace period in seconds for spot instance preStop hook")
 	cmd.Flags().BoolVar(&kustomize, "kustomize", false, "Generate Kustomize layout with base and dev/staging/prod overlays")
 	cmd.Flags().BoolVar(&postRenderer, "post-renderer", false, "Generate Kustomize overlays compatible with Helm post-rendering (Flux CD postBuild)")
 	cmd.Flags().BoolVar(&autoDeps, "auto-deps", false, "Auto-detect infrastructure dependencies (PostgreSQL, Redis, etc.)")
+	cmd.Flags().StringVar(&depsIndex, "deps-index", "", "Helm repository index.yaml URL to resolve --auto-deps versions from (default: ArtifactHub)")
+	cmd.Flags().BoolVar(&depsOffline, "deps-offline", false, "Keep --auto-deps version ranges and skip Chart.lock instead of resolving versions")
 	cmd.Flags().IntVar(&tenantCount, "tenant-count", 2, "Number of tenant examples to scaffold (default: 2)")
+	cmd.Flags().StringVar(&tenantsFile, "tenants-file", "", "tenants.yaml with tenant names, namespaces, quotas and ingress domains: generates the tenants, their quotas and NetworkPolicies and a values-tenant-<name>.yaml per tenant (implies --multi-tenant, replaces --tenant-count)")
 	cmd.Flags().StringVar(&templateStyle, "template-style", "standard", "Template output style: standard, helm")
 	cmd.Flags().BoolVar(&includeHooks, "hooks", false, "Generate Helm lifecycle hook Job templates (pre-upgrade, post-install, pre-delete)")
+	cmd.Flags().BoolVar(&inferHooks, "infer-hooks", false, "Turn run-once migration Jobs (migrate/init/seed names) into pre-install,pre-upgrade Helm hooks")
+	cmd.Flags().BoolVar(&externalizeConfigs, "externalize-configmaps", false, "Write every ConfigMap data key to files/<service>/<key> in the chart and read it with .Files.Get instead of inlining it in values.yaml")
+	cmd.Flags().BoolVar(&preserveSelectors, "preserve-selectors", false, "Render the original spec.selector of Deployments, StatefulSets and DaemonSets instead of one built from the chart's labels, so the chart can be upgraded over already deployed objects (always on for --source cluster)")
+	cmd.Flags().StringSliceVar(&passthroughKinds, "passthrough-kinds", nil, "Copy resources of these kinds (e.g. CustomResourceDefinition,ClusterRole) into the chart verbatim instead of templating them")
+	cmd.Flags().StringSliceVar(&templateKinds, "template-kinds", nil, "Template only resources of these kinds; resources of other kinds are copied verbatim")
+	cmd.Flags().BoolVar(&apiUpgrade, "api-upgrade", false, "Convert resources using deprecated or removed apiVersions (extensions/v1beta1 Ingress, policy/v1beta1 PDB, batch/v1beta1 CronJob, ...) to their replacement")
+	cmd.Flags().BoolVar(&ha, "ha", false, "Generate a PodDisruptionBudget (maxUnavailable in values, gated by pdb.enabled) for every Deployment/StatefulSet with more than one replica")
+	cmd.Flags().BoolVar(&topologySpread, "topology-spread", false, "Spread the pods of every Deployment/StatefulSet with more than one replica across zones and nodes (topologySpreadConstraints with maxSkew and whenUnsatisfiable in values)")
+	cmd.Flags().BoolVar(&autoscaling, "autoscaling", false, "Generate a HorizontalPodAutoscaler (CPU utilization of the requests, gated by autoscaling.enabled) for every stateless Deployment")
+	cmd.Flags().BoolVar(&serviceAccounts, "service-accounts", false, "Give workloads running as the default ServiceAccount their own, and create missing ServiceAccounts, each with a Role scaffold and RoleBinding")
+	cmd.Flags().BoolVar(&priorityClasses, "priority-classes", false, "Assign workloads without a priorityClassName to a <chart>-critical (StatefulSet, DaemonSet), <chart>-standard (Deployment) or <chart>-batch (Job, CronJob) PriorityClass and generate the referenced classes")
+	cmd.Flags().BoolVar(&noTokenAutomount, "no-token-automount", false, "Set automountServiceAccountToken: false (a values toggle) on workloads whose ServiceAccount has no RoleBinding or ClusterRoleBinding in the input")
+	cmd.Flags().BoolVar(&skipPVs, "skip-persistent-volumes", false, "Leave PersistentVolumes out of the chart: they are bound to the storage of the source cluster")
+	cmd.Flags().StringVar(&onDuplicate, "on-duplicate", string(dhg.DuplicateLast), "Resolution of resources found more than once in the input (same kind, namespace and name): error, first, last or merge (maps merged, later values win)")
+	cmd.Flags().StringVar(&clusterScope, "cluster-scope-strategy", string(generator.ClusterScopeTemplates), "Placement of cluster-scoped resources (CRDs, ClusterRoles, StorageClasses, ...): templates, crds (CRDs in crds/), subchart (a cluster-resources subchart) or value (gated by installClusterResources)")
+	cmd.Flags().StringArrayVar(&renames, "rename", nil, "Rename resources before processing: old=new replaces old in resource names, references, labels and selectors, and so the service, template and values names derived from it (repeatable; also renames: in --groups-file)")
+	cmd.Flags().StringArrayVar(&imageRewrites, "image-rewrite", nil, "Rewrite container image registries/repository prefixes before processing: old=new (repeatable; e.g. docker.io=registry.example.com/mirror)")
+	cmd.Flags().BoolVar(&pinDigests, "pin-digests", false, "Resolve image tags to digests via the registry API (see --registry-auth) and deploy by digest; values keep both tag and digest")
+	registryAccess.register(cmd.Flags())
 	cmd.Flags().BoolVar(&valuesFlat, "values-flat", false, "Add inline dot-notation path comments to values.yaml for --set reference")
+	cmd.Flags().BoolVar(&valuesDocs, "values-docs", false, "Add helm-docs \"# --\" comments (source resource and field) to values.yaml and a values table to README.md (see --include-readme)")
+	cmd.Flags().BoolVar(&preserveNamespaces, "preserve-namespaces", false, "Keep resources in their source namespaces (values namespaces.<name>) and generate the Namespace objects instead of installing everything into the release namespace (universal mode)")
+	cmd.Flags().BoolVar(&tplEnv, "tpl-env", false, "Rewrite Service DNS names (http://api.ns.svc.cluster.local) in env vars and ConfigMap data to services.<name>.env.<SERVICE>_URL values that default to the Service's name in the release, rendered through tpl (universal mode)")
+	cmd.Flags().StringArrayVar(&valueFiles, "values", nil, "Values file(s) merged over the generated values.yaml defaults, in order (repeatable; universal mode)")
+	cmd.Flags().StringArrayVar(&setValues, "set", nil, "Override generated values.yaml defaults as in helm --set: path=value, comma-separated, lists as {a,b}, e.g. services.web.deployment.replicas=2; applied after --values (repeatable; universal mode)")
+	cmd.Flags().StringArrayVar(&setStrings, "set-string", nil, "Override generated values.yaml defaults with strings as in helm --set-string, applied after --set (repeatable; universal mode)")
+	cmd.Flags().BoolVar(&dedupValues, "dedup-values", false, "Factor resources, probes and securityContext blocks repeated across workloads into a common: values section that services override (universal mode)")
+	cmd.Flags().StringSliceVar(&plugins, "plugin", nil, "Path(s) to plugin executables providing processors/detectors (subprocess JSON protocol)")
+	cmd.Flags().StringSliceVar(&pluginDirs, "plugin-dir", nil, "Directories to load all executable plugins from")
+	cmd.Flags().StringVar(&logFormat, "log-format", "text", "Log output format: text, json (logs are written to stderr)")
+	cmd.Flags().StringVar(&logLevel, "log-level", "", "Log level: debug, info, warn, error (default warn, or debug with --verbose)")
+	cmd.Flags().StringVar(&groupBy, "group-by", "", "Service grouping strategy applied before the default heuristics: labels:<key>, namespace, owner, manual")
+	cmd.Flags().StringVar(&groupsFile, "groups-file", "", "Path to groups.yaml pinning resources into named services (required for --group-by manual)")
+	cmd.Flags().StringVar(&configFile, "config", "", "Config file with the label and annotation injection policy and custom feature flags (default: "+DefaultConfigFile+" in the current directory, when present)")
+	cmd.Flags().StringVar(&chartMetadata, "chart-metadata", "", "YAML file with Chart.yaml metadata: description, keywords, home, sources, maintainers, icon, kubeVersion, annotations")
+	cmd.Flags().StringVar(&chartAPIVersion, "chart-api-version", generator.ChartAPIVersionV2, "Chart.yaml apiVersion: v2, or v1 for Helm 2 consumers (dependencies in requirements.yaml, no chart type)")
+	cmd.Flags().StringArrayVar(&maintainers, "maintainer", nil, "Chart maintainer as \"Name <email> (url)\" (repeatable; email and url are optional)")
+	cmd.Flags().StringVar(&home, "home", "", "Chart home page URL")
+	cmd.Flags().StringSliceVar(&chartSources, "chart-source", nil, "Chart source code URL(s)")
+	cmd.Flags().StringSliceVar(&keywords, "keywords", nil, "Chart keywords, added to the default ones")
+	cmd.Flags().StringVar(&icon, "icon", "", "Chart icon URL")
+	cmd.Flags().StringVar(&kubeVersion, "kube-version", "", "Chart kubeVersion constraint (e.g. \">=1.25.0-0\")")
+	cmd.Flags().StringArrayVar(&chartAnnotations, "chart-annotation", nil, "Chart.yaml annotation as key=value (repeatable), e.g. artifacthub.io/license=Apache-2.0")
+	cmd.Flags().StringVar(&metricsSource, "metrics", "", "Write requests/limits recommended from observed usage into values-prod.yaml (requires --env-values): metrics-server (requires --source cluster) or prometheus")
+	cmd.Flags().StringVar(&prometheusURL, "prometheus-url", "", "Prometheus server URL for --metrics prometheus")
+	cmd.Flags().DurationVar(&metricsWindow, "metrics-window", metrics.DefaultWindow, "Usage history to look back over for --metrics prometheus")
+	cmd.Flags().StringVar(&sign, "sign", "", "Package each chart into the output directory and sign it with its provenance: pgp (Helm .prov via gpg) or cosign")
+	cmd.Flags().StringVar(&signKey, "sign-key", "", "Key for --sign: gpg user ID or fingerprint, or cosign key reference (default: gpg default key, cosign keyless)")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Regenerate the chart each time the input files change, rewriting only the changed files (file and compose sources)")
+	cmd.Flags().DurationVar(&watchDebounce, "watch-debounce", defaultWatchDebounce, "How long --watch waits for changes to settle before regenerating")
+	cmd.Flags().StringVar(&fromSnapshot, "from-snapshot", "", "Extract the resources from a recorded source snapshot (used by dhg regenerate)")
+	_ = cmd.Flags().MarkHidden("from-snapshot")
+	cmd.Flags().BoolVar(&interactive, "interactive", false, "Guided mode: select kinds/namespaces, output mode and service names, preview values before writing")
 
 	_ = cmd.MarkFlagRequired("chart-name")
 
@@ -234,6 +493,7 @@ Examples:
 
 type generateOptions struct {
 	paths           []string
+	stdin           io.Reader
 	outputDir       string
 	chartName       string
 	chartVersion    string
@@ -245,7 +505,10 @@ type generateOptions struct {
 	labelSelector   string
 	includeKinds    []string
 	excludeKinds    []string
+	omitFields      []string
 	recursive       bool
+	helmValues      []string
+	helmRelease     string
 	kubeConfig       string
 	kubeContext      string
 	clusterNamespace string
@@ -258,11 +521,22 @@ type generateOptions struct {
 	verbose         bool
 	envValues       bool
 	deckhouseModule    bool
+	werf               bool
 	dryRun             bool
+	dryRunFormat       string
+	summaryJSON        string
 	airgapRegistry     string
+	mirrorTool         string
+	platforms          []string
 	namespaceResources bool
+	namespacePreset    string
 	multiTenant        bool
 	featureFlags       bool
+	capabilitiesGuards bool
+	fixes              []string
+	applyBestPractices bool
+	synthesizeProbes   bool
+	probePath          string
 	cloudProvider      string
 	cloudInternal      bool
 	detectIngress      bool
@@ -272,20 +546,198 @@ type generateOptions struct {
 	kustomize          bool
 	postRenderer       bool
 	autoDeps           bool
+	depsIndex          string
+	depsOffline        bool
 	tenantCount        int
+	tenantsFile        string
 	templateStyle      string
 	includeHooks       bool
+	inferHooks         bool
+	externalizeConfigs bool
+	preserveSelectors  bool
+	passthroughKinds   []string
+	templateKinds      []string
+	apiUpgrade         bool
+	ha                 bool
+	topologySpread     bool
+	autoscaling        bool
+	serviceAccounts    bool
+	priorityClasses    bool
+	noTokenAutomount   bool
+	skipPVs            bool
+	clusterScope       string
+	onDuplicate        string
+	renames            []string
+	imageRewrites      []string
+	pinDigests         bool
+	registryAccess     registryFlags
 	valuesFlat         bool
+	valuesDocs         bool
+	dedupValues        bool
+	preserveNamespaces bool
+	tplEnv             bool
+	valueFiles         []string
+	setValues          []string
+	setStrings         []string
+	configFile         string
+	interactive        bool
+	logFormat          string
+	logLevel           string
+	plugins            []string
+	pluginDirs         []string
+	groupBy            string
+	groupsFile         string
+	chartMetadata      string
+	chartAPIVersion    string
+	maintainers        []string
+	home               string
+	chartSources       []string
+	keywords           []string
+	icon               string
+	kubeVersion        string
+	chartAnnotations   []string
+	metricsSource      string
+	prometheusURL      string
+	metricsWindow      time.Duration
+	sign               string
+	signKey            string
+
+	// flags are the flags set on the command line, recorded in the
+	// provenance of signed charts.
+	flags map[string]string
+
+	// options are the generate flags recorded in .dhg/generation.yaml; no
+	// generation record is written when nil.
+	options map[string]interface{}
+
+	// fromSnapshot replays a generation from a recorded source snapshot
+	// instead of the cluster or stdin.
+	fromSnapshot string
+
+	// watch regenerates on input changes (see runWatch).
+	watch         bool
+	watchDebounce time.Duration
+
+	// skipSummary suppresses the final success message (upgrade-chart
+	// generates into a temporary directory and reports on its own).
+	skipSummary bool
 }
 
-func runGenerate(ctx context.Context, opts generateOptions) error {
+// buildChartMetadata collects the Chart.yaml metadata of --chart-metadata and
+// the individual metadata flags; flags take precedence over the file.
+func buildChartMetadata(opts generateOptions) (helm.ChartMetadata, error) {
+	var meta helm.ChartMetadata
+	if opts.chartMetadata != "" {
+		fileMeta, err := helm.ReadChartMetadata(opts.chartMetadata)
+		if err != nil {
+			return meta, err
+		}
+		meta = fileMeta
+	}
+
+	flagMeta := helm.ChartMetadata{
+		Keywords:    opts.keywords,
+		Home:        opts.home,
+		Sources:     opts.chartSources,
+		Icon:        opts.icon,
+		KubeVersion: opts.kubeVersion,
+	}
+	for _, m := range opts.maintainers {
+		maintainer, err := helm.ParseMaintainer(m)
+		if err != nil {
+			return meta, fmt.Errorf("--maintainer: %w", err)
+		}
+		flagMeta.Maintainers = append(flagMeta.Maintainers, maintainer)
+	}
+	for _, a := range opts.chartAnnotations {
+		key, value, ok := strings.Cut(a, "=")
+		if !ok || key == "" {
+			return meta, fmt.Errorf("--chart-annotation %q: expected key=value", a)
+		}
+		if flagMeta.Annotations == nil {
+			flagMeta.Annotations = make(map[string]string)
+		}
+		flagMeta.Annotations[key] = value
+	}
+	return helm.MergeChartMetadata(meta, flagMeta), nil
+}
+
+// newGenerateLogger builds the pipeline logger from --log-format/--log-level.
+// Without an explicit level, --verbose selects debug and the default is warn,
+// which keeps regular runs as quiet as before.
+func newGenerateLogger(opts generateOptions) (*logging.Logger, error) {
+	format, err := logging.ParseFormat(opts.logFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	level := slog.LevelWarn
 	if opts.verbose {
-		fmt.Printf("Starting chart generation...\n")
-		fmt.Printf("Chart name: %s\n", opts.chartName)
-		fmt.Printf("Output directory: %s\n", opts.outputDir)
-		fmt.Printf("Mode: %s\n", opts.mode)
+		level = slog.LevelDebug
+	}
+	if opts.logLevel != "" {
+		level, err = logging.ParseLevel(opts.logLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return logging.New(os.Stderr, format, level), nil
+}
+
+// loadGrouping builds the service grouping from --group-by/--groups-file.
+// Manual assignments from the groups file apply with any strategy.
+func loadGrouping(groupBy, groupsFile string) (analyzer.Grouping, error) {
+	grouping, err := analyzer.ParseGroupBy(groupBy)
+	if err != nil {
+		return analyzer.Grouping{}, err
+	}
+	if groupsFile == "" {
+		if grouping.Strategy == analyzer.GroupByManual {
+			return analyzer.Grouping{}, fmt.Errorf("--group-by manual requires --groups-file")
+		}
+		return grouping, nil
+	}
+
+	grouping.Manual, err = analyzer.LoadGroupMapping(groupsFile)
+	if err != nil {
+		return analyzer.Grouping{}, err
+	}
+	return grouping, nil
+}
+
+// omitFieldPaths returns the --omit-fields paths, after the default set
+// unless --default-omit-fields=false.
+func omitFieldPaths(paths []string, defaults bool) []string {
+	if !defaults {
+		return paths
+	}
+	return append(append([]string{}, extractor.DefaultOmitFields...), paths...)
+}
+
+// chartDir returns the directory the chart name is written to: the .helm/
+// directory of its werf project with --werf.
+func (o generateOptions) chartDir(name string) string {
+	if o.werf {
+		return filepath.Join(o.outputDir, name, generator.WerfChartDir)
+	}
+	return filepath.Join(o.outputDir, name)
+}
+
+func runGenerate(ctx context.Context, opts generateOptions) error {
+	if opts.watch {
+		return runWatch(ctx, opts)
 	}
 
+	logger, err := newGenerateLogger(opts)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+
+	logger.Debug("starting chart generation",
+		"chart", opts.chartName, "output", opts.outputDir, "mode", opts.mode)
+
 	// Validate output mode
 	var outputMode types.OutputMode
 	switch opts.mode {
@@ -311,12 +763,127 @@ func runGenerate(ctx context.Context, opts generateOptions) error {
 		}
 	case "cluster":
 		sourceType = types.SourceCluster
-		fmt.Fprintln(os.Stderr, "WARNING: cluster extraction is not yet implemented. Use --source=file instead.")
+	case "compose":
+		sourceType = types.SourceCompose
+		if len(opts.paths) == 0 {
+			return fmt.Errorf("at least one compose file is required for compose source (-f flag)")
+		}
 	case "gitops":
 		sourceType = types.SourceGitOps
-		fmt.Fprintln(os.Stderr, "WARNING: gitops extraction is not yet implemented. Use --source=file instead.")
+		logger.Warn("gitops extraction is not yet implemented, use --source=file instead")
 	default:
-		return fmt.Errorf("invalid source: %s (must be file, cluster, or gitops)", opts.source)
+		return fmt.Errorf("invalid source: %s (must be file, cluster, compose, or gitops)", opts.source)
+	}
+
+	if opts.interactive {
+		for _, p := range opts.paths {
+			if p == "-" {
+				return fmt.Errorf("--interactive reads its answers from stdin and cannot be used with -f -; write the manifests to a file")
+			}
+		}
+	}
+
+	if err := validateMetricsSource(opts.metricsSource, opts.prometheusURL, opts.metricsWindow, sourceType); err != nil {
+		return err
+	}
+	if opts.metricsSource != "" && !opts.envValues {
+		return fmt.Errorf("--metrics writes its recommendations into values-prod.yaml and requires --env-values")
+	}
+
+	if err := validateDryRunFormat(opts); err != nil {
+		return err
+	}
+	if err := validateSummaryJSON(opts); err != nil {
+		return err
+	}
+
+	if opts.sign != "" {
+		if err := generator.ValidateSignMethod(opts.sign); err != nil {
+			return err
+		}
+	} else if opts.signKey != "" {
+		return fmt.Errorf("--sign-key requires --sign")
+	}
+
+	if opts.chartVersion == generator.ChartVersionAuto {
+		if (sourceType != types.SourceFile && sourceType != types.SourceCompose) || opts.paths[0] == "-" {
+			return fmt.Errorf("--chart-version auto requires input files in a git repository")
+		}
+		version, err := generator.GitChartVersion(ctx, opts.paths[0])
+		if err != nil {
+			return err
+		}
+		logger.Info("derived chart version from git", "version", version)
+		opts.chartVersion = version
+	}
+
+	chartMeta, err := buildChartMetadata(opts)
+	if err != nil {
+		return err
+	}
+
+	valueOverrides, err := helm.ValueOverrides(opts.valueFiles, opts.setValues, opts.setStrings)
+	if err != nil {
+		return err
+	}
+
+	var injection *generator.InjectionPolicy
+	var customFeatureFlags []generator.CustomFeatureFlag
+	if opts.configFile != "" {
+		cfg, err := LoadConfig(opts.configFile)
+		if err != nil {
+			return err
+		}
+		injection = cfg.Inject
+		for _, flag := range cfg.FeatureFlags {
+			if err := flag.Validate(); err != nil {
+				return fmt.Errorf("config %s: %w", opts.configFile, err)
+			}
+		}
+		if len(cfg.FeatureFlags) > 0 && !opts.featureFlags {
+			logger.Warn("feature flags of the config are injected with --feature-flags only", "config", opts.configFile)
+		}
+		customFeatureFlags = cfg.FeatureFlags
+	}
+
+	imageRewrites := make([]processor.ImageRewrite, 0, len(opts.imageRewrites))
+	for _, s := range opts.imageRewrites {
+		r, err := processor.ParseImageRewrite(s)
+		if err != nil {
+			return err
+		}
+		imageRewrites = append(imageRewrites, r)
+	}
+
+	// Registry access for --pin-digests, --platforms and the dependency
+	// version lookups of --auto-deps.
+	registryOpts, err := opts.registryAccess.options()
+	if err != nil {
+		return err
+	}
+	var digestResolver processor.DigestResolver
+	if opts.pinDigests {
+		if digestResolver, err = registryOpts.DigestResolver(); err != nil {
+			return err
+		}
+	}
+	var resolvePlatforms generator.PlatformResolver
+	if len(opts.platforms) > 0 {
+		if opts.airgapRegistry == "" {
+			return fmt.Errorf("--platforms requires --airgap-registry")
+		}
+		if err := generator.ValidatePlatforms(opts.platforms); err != nil {
+			return err
+		}
+		if resolvePlatforms, err = registryOpts.PlatformResolver(); err != nil {
+			return err
+		}
+	}
+	var depsClient *http.Client
+	if opts.autoDeps && !opts.depsOffline {
+		if depsClient, err = registryOpts.HTTPClient(); err != nil {
+			return err
+		}
 	}
 
 	// Validate mutually exclusive flags
@@ -332,21 +899,105 @@ func runGenerate(ctx context.Context, opts generateOptions) error {
 		return fmt.Errorf("unknown template style: %q (must be standard or helm)", opts.templateStyle)
 	}
 
+	// Validate duplicate policy
+	switch dhg.DuplicatePolicy(opts.onDuplicate) {
+	case dhg.DuplicateError, dhg.DuplicateFirst, dhg.DuplicateLast, dhg.DuplicateMerge:
+		// valid
+	default:
+		return fmt.Errorf("unknown duplicate policy: %q (must be error, first, last or merge)", opts.onDuplicate)
+	}
+
+	// Validate cluster scope strategy
+	switch generator.ClusterScopeStrategy(opts.clusterScope) {
+	case generator.ClusterScopeTemplates, generator.ClusterScopeCRDs, generator.ClusterScopeValue:
+		// valid
+	case generator.ClusterScopeSubchart:
+		if outputMode != types.OutputModeUniversal {
+			return fmt.Errorf("--cluster-scope-strategy subchart is only supported in universal mode")
+		}
+	default:
+		return fmt.Errorf("unknown cluster scope strategy: %q (must be templates, crds, subchart or value)", opts.clusterScope)
+	}
+
+	// Validate chart API version
+	switch opts.chartAPIVersion {
+	case generator.ChartAPIVersionV1, generator.ChartAPIVersionV2:
+		// valid
+	default:
+		return fmt.Errorf("unknown chart API version: %q (must be v1 or v2)", opts.chartAPIVersion)
+	}
+
+	// Validate fixes
+	for _, fix := range opts.fixes {
+		if fix != "graceful-shutdown" {
+			return fmt.Errorf("unknown fix: %q (must be graceful-shutdown)", fix)
+		}
+	}
+
+	if opts.synthesizeProbes && !strings.HasPrefix(opts.probePath, "/") {
+		return fmt.Errorf("invalid probe path: %q (must start with /)", opts.probePath)
+	}
+
 	// Validate cloud provider
 	if opts.cloudProvider != "" {
 		switch opts.cloudProvider {
-		case "aws", "gcp", "azure":
+		case "aws", "gcp", "azure", "yandex", "vk", "openstack":
 			// valid
 		default:
-			return fmt.Errorf("unknown cloud provider: %q (must be aws, gcp, or azure)", opts.cloudProvider)
+			return fmt.Errorf("unknown cloud provider: %q (must be aws, gcp, azure, yandex, vk, or openstack)", opts.cloudProvider)
 		}
 	}
 
-	// Step 1: Extract resources
-	if opts.verbose {
-		fmt.Printf("\n[1/5] Extracting resources from source...\n")
+	grouping, err := loadGrouping(opts.groupBy, opts.groupsFile)
+	if err != nil {
+		return err
+	}
+
+	// --rename wins over renames: in the groups file.
+	renames := make(map[string]string)
+	if grouping.Manual != nil {
+		for from, to := range grouping.Manual.Renames {
+			renames[from] = to
+		}
+	}
+	for _, s := range opts.renames {
+		from, to, err := dhg.ParseRename(s)
+		if err != nil {
+			return err
+		}
+		renames[from] = to
+	}
+
+	var nsPreset generator.NamespacePreset
+	if opts.namespacePreset != "" {
+		if !opts.namespaceResources {
+			return fmt.Errorf("--namespace-preset requires --namespace-resources")
+		}
+		if nsPreset, err = generator.LoadNamespacePreset(opts.namespacePreset); err != nil {
+			return err
+		}
 	}
 
+	var tenants *generator.TenantManifest
+	if opts.tenantsFile != "" {
+		if tenants, err = generator.LoadTenantManifest(opts.tenantsFile); err != nil {
+			return err
+		}
+	}
+
+	plugins, err := loadPlugins(ctx, opts.plugins, opts.pluginDirs, func(err error) {
+		logger.Warn("plugin error", "error", err)
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range plugins {
+		logger.Debug("plugin loaded", "plugin", p.Name(), "version", p.Manifest.Version, "path", p.Path)
+	}
+
+	// Step 1: Extract resources
+	extractStage := logger.StartStage("extract")
+
 	extractorRegistry := extractor.DefaultRegistry()
 	ext, ok := extractorRegistry.Get(sourceType)
 	if !ok {
@@ -354,25 +1005,39 @@ func runGenerate(ctx context.Context, opts generateOptions) error {
 	}
 
 	extractOpts := extractor.Options{
-		Paths:         opts.paths,
-		Namespace:     opts.namespace,
-		Namespaces:    opts.namespaces,
-		LabelSelector: opts.labelSelector,
-		IncludeKinds:  opts.includeKinds,
-		ExcludeKinds:  opts.excludeKinds,
-		Recursive:     opts.recursive,
-		KubeConfig:    opts.kubeConfig,
-		KubeContext:   opts.kubeContext,
-	}
-
-	if err := ext.Validate(ctx, extractOpts); err != nil {
+		Paths:           opts.paths,
+		Namespace:       opts.namespace,
+		Namespaces:      opts.namespaces,
+		LabelSelector:   opts.labelSelector,
+		IncludeKinds:    opts.includeKinds,
+		ExcludeKinds:    opts.excludeKinds,
+		OmitFields:      opts.omitFields,
+		Recursive:       opts.recursive,
+		HelmValues:      opts.helmValues,
+		HelmReleaseName: opts.helmRelease,
+		Stdin:           opts.stdin,
+		KubeConfig:      opts.kubeConfig,
+		KubeContext:     opts.kubeContext,
+	}
+
+	// A replayed generation reads the resources recorded with the chart; the
+	// source extractor is still used for --metrics.
+	source, sourceOpts := ext, extractOpts
+	if opts.fromSnapshot != "" {
+		source, _ = extractorRegistry.Get(types.SourceFile)
+		sourceOpts.Paths = []string{opts.fromSnapshot}
+		sourceOpts.Stdin = nil
+	}
+
+	if err := source.Validate(ctx, sourceOpts); err != nil {
 		return fmt.Errorf("extractor validation failed: %w", err)
 	}
 
-	resourceChan, errChan := ext.Extract(ctx, extractOpts)
+	resourceChan, errChan := source.Extract(ctx, sourceOpts)
 
 	var extractedResources []*types.ExtractedResource
 	extractErrors := make([]error, 0)
+	extractProgress := logger.NewProgress("Extracting resources", 0)
 
 drain:
 	for {
@@ -386,9 +1051,8 @@ drain:
 				continue
 			}
 			extractedResources = append(extractedResources, resource)
-			if opts.verbose {
-				fmt.Printf("  Extracted: %s\n", resource.ResourceKey().String())
-			}
+			extractProgress.Increment()
+			logger.Debug("resource extracted", "resource", resource.ResourceKey().String())
 		case err, ok := <-errChan:
 			if !ok {
 				errChan = nil
@@ -398,157 +1062,280 @@ drain:
 				continue
 			}
 			extractErrors = append(extractErrors, err)
-			fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+			logger.Warn("extraction warning", "error", err)
 		case <-ctx.Done():
+			extractProgress.Finish()
 			return ctx.Err()
 		}
 	}
+	extractProgress.Finish()
 
 	if len(extractedResources) == 0 {
 		return fmt.Errorf("no resources extracted")
 	}
 
-	if opts.verbose {
-		fmt.Printf("  Total extracted: %d resources\n", len(extractedResources))
-		if len(extractErrors) > 0 {
-			fmt.Printf("  Warnings: %d\n", len(extractErrors))
+	extractStage.Done("resources", len(extractedResources), "warnings", len(extractErrors))
+
+	// Resources that cannot be read again are recorded with the chart, before
+	// processing modifies them.
+	var sourceSnapshot []byte
+	if opts.options != nil && needsSourceSnapshot(opts, sourceType) {
+		if sourceSnapshot, err = generator.MarshalSourceSnapshot(extractedResources); err != nil {
+			return err
+		}
+	}
+
+	// Interactive mode: let the user narrow the input and name services.
+	var prompter *interactivePrompter
+	var serviceRenames map[string]string
+	if opts.interactive {
+		prompter = newInteractivePrompter(os.Stdin, os.Stdout)
+		var err error
+		extractedResources, err = prompter.filterResources(extractedResources)
+		if err != nil {
+			return fmt.Errorf("interactive selection: %w", err)
+		}
+		if len(extractedResources) == 0 {
+			return fmt.Errorf("no resources selected")
+		}
+		serviceRenames, err = prompter.nameServices(extractedResources)
+		if err != nil {
+			return fmt.Errorf("interactive naming: %w", err)
 		}
 	}
 
 	// Step 2: Process resources
-	if opts.verbose {
-		fmt.Printf("\n[2/5] Processing resources...\n")
+	processStage := logger.StartStage("process")
+
+	processProgress := logger.NewProgress("Processing resources", len(extractedResources))
+	defer processProgress.Finish()
+
+	pipeline := dhg.New(dhg.Options{
+		ChartName:             opts.chartName,
+		ChartVersion:          opts.chartVersion,
+		AppVersion:            opts.appVersion,
+		ChartMetadata:         chartMeta,
+		Mode:                  outputMode,
+		Namespace:             opts.namespace,
+		OutputDir:             opts.outputDir,
+		IncludeTests:          opts.includeTests,
+		IncludeREADME:         opts.includeREADME,
+		IncludeSchema:         opts.includeSchema,
+		IncludeHooks:          opts.includeHooks,
+		InferHooks:            opts.inferHooks,
+		ExternalizeConfigMaps: opts.externalizeConfigs,
+		PreserveSelectors:     opts.preserveSelectors,
+		PassthroughKinds:      opts.passthroughKinds,
+		TemplateKinds:         opts.templateKinds,
+		APIUpgrade:            opts.apiUpgrade,
+		HA:                    opts.ha,
+		TopologySpread:        opts.topologySpread,
+		Autoscaling:           opts.autoscaling,
+		ServiceAccounts:       opts.serviceAccounts,
+		PriorityClasses:       opts.priorityClasses,
+		NoTokenAutomount:      opts.noTokenAutomount,
+		SkipPersistentVolumes: opts.skipPVs,
+		OnDuplicate:           dhg.DuplicatePolicy(opts.onDuplicate),
+		Renames:               renames,
+		ImageRewrites:         imageRewrites,
+		PinDigests:            opts.pinDigests,
+		DigestResolver:        digestResolver,
+		EnvValues:             opts.envValues,
+		DeckhouseModule:       opts.deckhouseModule,
+		TemplateStyle:         opts.templateStyle,
+		ValuesFlat:            opts.valuesFlat,
+		ValuesDocs:            opts.valuesDocs,
+		DedupValues:           opts.dedupValues,
+		PreserveNamespaces:    opts.preserveNamespaces,
+		TemplateEnv:           opts.tplEnv,
+		ValueOverrides:        valueOverrides,
+		Injection:             injection,
+		ServiceNames:          serviceRenames,
+		Grouping:              grouping,
+		Plugins:               plugins,
+		OnProcessed: func(processed *types.ProcessedResource) {
+			processProgress.Increment()
+			logger.Debug("resource processed",
+				"resource", processed.Original.ResourceKey().String(), "service", processed.ServiceName)
+		},
+	})
+
+	processed, err := pipeline.Process(ctx, extractedResources)
+	if err != nil {
+		return err
 	}
+	processProgress.Finish()
 
-	processorRegistry := processor.NewRegistry()
-	k8s.RegisterAll(processorRegistry)
+	for _, r := range processed.Renamed {
+		logger.Info("renamed resource", "resource", r.Resource.String(), "from", r.From)
+	}
 
-	// Initialize value processor and external file manager
-	valueProcessor := value.DefaultProcessor()
-	externalFileManager := value.NewExternalFileManager()
+	for _, d := range processed.Duplicates {
+		logger.Warn("resource found more than once in the input",
+			"resource", d.Resource.String(), "sources", strings.Join(d.Sources, ","), "resolved", opts.onDuplicate)
+	}
+	for _, from := range processed.UnusedRenames {
+		logger.Warn("rename matched no resource", "name", from)
+	}
 
-	var processedResources []*types.ProcessedResource
-	allResourcesMap := make(map[types.ResourceKey]*types.ExtractedResource)
-	for _, r := range extractedResources {
-		allResourcesMap[r.ResourceKey()] = r
+	for _, c := range processed.SelectorConflicts {
+		logger.Warn("chart labels conflict with a preserved selector label; selectors built from them may not match the pods",
+			"resource", c.Resource.String(), "label", c.Label, "value", c.Value)
 	}
 
-	for _, extracted := range extractedResources {
-		if err := ctx.Err(); err != nil {
-			return err
-		}
-		procCtx := processor.Context{
-			Ctx:                 ctx,
-			ChartName:           opts.chartName,
-			OutputMode:          outputMode,
-			Namespace:           extracted.Object.GetNamespace(),
-			AllResources:        allResourcesMap,
-			ExternalFileManager: externalFileManager,
-			ValueProcessor:      valueProcessor,
-		}
+	for _, u := range processed.APIUpgrades {
+		logger.Info("upgraded deprecated apiVersion",
+			"resource", u.Resource.String(), "from", u.From, "to", u.Resource.GVK.GroupVersion().String())
+	}
 
-		result, err := processorRegistry.Process(procCtx, extracted.Object)
-		if err != nil {
-			return fmt.Errorf("failed to process %s: %w", extracted.ResourceKey().String(), err)
-		}
+	for _, pdb := range processed.PodDisruptionBudgets {
+		logger.Info("added PodDisruptionBudget", "resource", pdb.String())
+	}
 
-		processed := &types.ProcessedResource{
-			Original:        extracted,
-			ServiceName:     result.ServiceName,
-			TemplatePath:    result.TemplatePath,
-			TemplateContent: result.TemplateContent,
-			ValuesPath:      result.ValuesPath,
-			Values:          result.Values,
-			Dependencies:    result.Dependencies,
-		}
+	for _, r := range processed.TopologySpread {
+		logger.Info("added topologySpreadConstraints", "resource", r.String())
+	}
 
-		processedResources = append(processedResources, processed)
+	for _, hpa := range processed.HorizontalPodAutoscalers {
+		logger.Info("added HorizontalPodAutoscaler", "resource", hpa.String())
+	}
+	for _, d := range processed.AutoscalingSkipped {
+		logger.Warn("no HorizontalPodAutoscaler added: not all containers request CPU", "resource", d.String())
+	}
 
-		if opts.verbose {
-			fmt.Printf("  Processed: %s -> service: %s\n", extracted.ResourceKey().String(), result.ServiceName)
+	for _, c := range processed.ServiceAccountChanges {
+		logger.Info("moved workload off the default ServiceAccount",
+			"resource", c.Resource.String(), "serviceAccount", c.ServiceAccount)
+	}
+	for _, r := range processed.RBACResources {
+		logger.Info("added RBAC resource", "resource", r.String())
+	}
+
+	for _, c := range processed.PriorityClassChanges {
+		logger.Info("assigned PriorityClass",
+			"resource", c.Resource.String(), "priorityClass", c.PriorityClass)
+	}
+	for _, pc := range processed.PriorityClasses {
+		logger.Info("added PriorityClass", "resource", pc.String())
+	}
+
+	for _, r := range processed.TokenAutomountDisabled {
+		logger.Info("disabled ServiceAccount token automounting", "resource", r.String())
+	}
+
+	for _, pv := range processed.PersistentVolumes {
+		if opts.skipPVs {
+			logger.Info("left out PersistentVolume", "resource", pv.String())
+			continue
 		}
+		logger.Warn("PersistentVolume is bound to the storage of the source cluster; set its values per cluster or leave it out with --skip-persistent-volumes",
+			"resource", pv.String())
 	}
 
-	if opts.verbose {
-		fmt.Printf("  Total processed: %d resources\n", len(processedResources))
+	if processed.ModuleConfig != nil {
+		logger.Info("mapped ModuleConfig settings to openapi/config-values.yaml",
+			"resource", processed.ModuleConfig.String(), "valuesKey", generator.ModuleValuesKey(opts.chartName))
 	}
 
-	// Step 3: Analyze relationships
-	if opts.verbose {
-		fmt.Printf("\n[3/5] Analyzing relationships...\n")
+	for _, u := range processed.ImageUpdates {
+		logger.Info("updated container image",
+			"resource", u.Resource.String(), "container", u.Container, "from", u.From, "to", u.To)
 	}
 
-	analyzer := analyzer.NewDefaultAnalyzer()
-	detector.RegisterAll(analyzer)
+	processStage.Done("resources", len(processed.Resources))
 
-	graph, err := analyzer.Analyze(ctx, processedResources)
+	// Step 3: Analyze relationships
+	analyzeStage := logger.StartStage("analyze")
+
+	graph, err := pipeline.Analyze(ctx, processed)
 	if err != nil {
-		return fmt.Errorf("analysis failed: %w", err)
+		return err
 	}
 
-	if opts.verbose {
-		fmt.Printf("  Detected relationships: %d\n", len(graph.Relationships))
-		fmt.Printf("  Service groups: %d\n", len(graph.Groups))
-		for _, group := range graph.Groups {
-			fmt.Printf("    - %s (%d resources)\n", group.Name, len(group.Resources))
-		}
+	for _, group := range graph.Groups {
+		logger.Debug("service group", "name", group.Name, "resources", len(group.Resources))
 	}
+	analyzeStage.Done("relationships", len(graph.Relationships), "groups", len(graph.Groups))
 
-	// Step 4: Generate chart
-	if opts.verbose {
-		fmt.Printf("\n[4/5] Generating Helm chart...\n")
+	if opts.preserveNamespaces {
+		for _, problem := range generator.UnresolvedNamespaceReferences(graph) {
+			logger.Warn("unresolved cross-namespace reference", "reference", problem)
+		}
 	}
 
-	generatorRegistry := generator.DefaultRegistry()
-	gen, err := generatorRegistry.Get(outputMode)
-	if err != nil {
-		return fmt.Errorf("failed to get generator: %w", err)
+	var resourceRecs []generator.ResourceRecommendation
+	if opts.metricsSource != "" {
+		usage, err := fetchUsage(ctx, ext, extractOpts, opts.metricsSource, opts.prometheusURL, opts.metricsWindow)
+		if err != nil {
+			return fmt.Errorf("metrics: %w", err)
+		}
+		resourceRecs = generator.RecommendResources(graph, usage)
+		for _, rec := range resourceRecs {
+			logger.Info("recommended container resources", "resource", rec.Kind+"/"+rec.Name,
+				"container", rec.Container, "changes", strings.Join(rec.Changes, ", "))
+		}
 	}
 
-	genOpts := generator.Options{
-		OutputDir:           opts.outputDir,
-		ChartName:           opts.chartName,
-		ChartVersion:        opts.chartVersion,
-		AppVersion:          opts.appVersion,
-		Mode:                outputMode,
-		Namespace:           opts.namespace,
-		IncludeTests:        opts.includeTests,
-		IncludeREADME:       opts.includeREADME,
-		IncludeSchema:       opts.includeSchema,
-		ExternalFileManager: externalFileManager,
-		EnvValues:           opts.envValues,
-		DeckhouseModule:     opts.deckhouseModule,
-		TemplateStyle:       opts.templateStyle,
-		IncludeHooks:        opts.includeHooks,
-		ValuesFlat:          opts.valuesFlat,
+	if prompter != nil {
+		recommended := pattern.DefaultAnalyzer().Analyze(graph).RecommendedStrategy
+		outputMode, err = prompter.chooseMode(recommended)
+		if err != nil {
+			return fmt.Errorf("interactive mode selection: %w", err)
+		}
+		pipeline = pipeline.WithMode(outputMode)
 	}
 
-	charts, err := gen.Generate(ctx, graph, genOpts)
+	// Step 4: Generate chart
+	generateStage := logger.StartStage("generate")
+
+	charts, err := pipeline.Generate(ctx, graph, processed)
 	if err != nil {
-		return fmt.Errorf("chart generation failed: %w", err)
+		return err
 	}
 
-	if len(charts) == 0 {
-		return fmt.Errorf("no charts generated")
-	}
+	generateStage.Done("charts", len(charts), "mode", string(outputMode))
+
+	// Step 4b-4j: Post-process generated charts
+	postprocessStage := logger.StartStage("postprocess")
 
 	// Apply Deckhouse module scaffold if requested
 	if opts.deckhouseModule {
-		if opts.verbose {
-			fmt.Printf("\n[4b/5] Applying Deckhouse module scaffold...\n")
+		logger.Debug("applying Deckhouse module scaffold")
+		for i, chart := range charts {
+			hooks := generator.DetectModuleHooks(graph, chart.Name)
+			for _, hook := range hooks {
+				logger.Info("generated Deckhouse module hook", "chart", chart.Name,
+					"hook", "hooks/"+hook.Name+".go", "kind", string(hook.Kind), "secrets", strings.Join(hook.Secrets, ","))
+			}
+			charts[i] = generator.GenerateDeckhouseModule(chart, processed.ModuleSettings, hooks...)
 		}
+	}
+
+	// Build the service images with werf if requested
+	werfConfigs := make(map[string]string, len(charts))
+	if opts.werf {
+		images := generator.BuildWerfImages(graph)
 		for i, chart := range charts {
-			charts[i] = generator.GenerateDeckhouseModule(chart, nil)
+			var used []generator.WerfImage
+			charts[i], used = generator.InjectWerfImages(chart, images)
+			werfConfigs[chart.Name] = generator.GenerateWerfConfig(chart.Name, used)
+			for _, image := range used {
+				logger.Info("added werf image", "chart", chart.Name, "image", image.Name, "from", image.From)
+			}
 		}
 	}
 
 	// Apply air-gapped artifacts if requested
 	if opts.airgapRegistry != "" {
-		if opts.verbose {
-			fmt.Printf("\n[4c/5] Generating air-gapped artifacts for registry: %s\n", opts.airgapRegistry)
-		}
+		logger.Debug("generating air-gapped artifacts", "registry", opts.airgapRegistry)
 		for _, chart := range charts {
 			refs := generator.ExtractImageReferences(chart)
+			if resolvePlatforms != nil {
+				var err error
+				if refs, err = generator.ResolveImagePlatforms(ctx, refs, opts.platforms, resolvePlatforms); err != nil {
+					return fmt.Errorf("resolving image platforms: %w", err)
+				}
+			}
 
 			// Add images.txt
 			imageList := generator.GenerateImageList(refs)
@@ -557,7 +1344,7 @@ drain:
 			})
 
 			// Add mirror-images.sh
-			mirrorScript, err := generator.GenerateMirrorScript(refs, opts.airgapRegistry)
+			mirrorScript, err := generator.GenerateMirrorScriptWith(refs, opts.airgapRegistry, generator.MirrorTool(opts.mirrorTool))
 			if err != nil {
 				return fmt.Errorf("generating mirror script: %w", err)
 			}
@@ -586,17 +1373,26 @@ drain:
 
 	// Apply namespace resources if requested
 	if opts.namespaceResources {
-		if opts.verbose {
-			fmt.Printf("\n[4d/5] Generating namespace governance resources...\n")
-		}
+		logger.Debug("generating namespace governance resources")
+		// Pod Security admission level of the namespace: restricted when
+		// every workload complies with it, baseline otherwise.
+		podSecurity := generator.PSSBaseline
+		switch pattern.NewPodSecurityStandardsChecker().PodSecurityLevel(graph) {
+		case string(generator.PSSRestricted):
+			podSecurity = generator.PSSRestricted
+		case string(generator.PSSPrivileged):
+			logger.Warn("privileged workloads are rejected by the baseline pod-security labels of the namespace; set namespace.podSecurity in values")
+		}
+
+		// The broad per-group NetworkPolicy of the namespace resources is
+		// replaced by the least-privilege policies from the service analysis
+		// and a default-deny policy.
 		nsOpts := generator.NamespaceOpts{
 			ResourceQuota: true,
 			LimitRange:    true,
-			NetworkPolicy: true,
+			Preset:        nsPreset,
+			PodSecurity:   podSecurity,
 		}
-		nsTemplates := generator.GenerateNamespaceResources(groupingResult.Groups, nsOpts)
-
-		// Also generate auto-NetworkPolicies from service analysis.
 		// NOTE: If --multi-tenant is also active, GenerateMultiTenantOverlay (applied later)
 		// adds tenant-networkpolicies.yaml. Auto-NP uses per-group paths
 		// (<group>-networkpolicy.yaml), so there is no key collision, but both
@@ -604,46 +1400,33 @@ drain:
 		// auto-NP handles service-level ingress/egress while tenant-NP handles
 		// cross-tenant isolation.
 		autoNP := generator.GenerateAutoNetworkPolicies(graph, groupingResult.Groups)
-
-		// Build a set of group names that have fine-grained auto-NP policies.
-		// When a group has a fine-grained NP (e.g. templates/<group>-networkpolicy.yaml),
-		// we skip the broad default NP from namespace resources
-		// (templates/<group>-networkpolicy-default.yaml) to avoid conflicting policies.
-		autoNPGroups := make(map[string]struct{}, len(autoNP))
-		for path := range autoNP {
-			// Extract group name from "templates/<group>-networkpolicy.yaml"
-			name := strings.TrimPrefix(path, "templates/")
-			name = strings.TrimSuffix(name, "-networkpolicy.yaml")
-			if name != path { // successfully trimmed both
-				autoNPGroups[name] = struct{}{}
-			}
-		}
+		autoNP[generator.DefaultDenyPolicyPath] = generator.GenerateDefaultDenyTemplate()
 
 		// Copy-on-write: build a new Templates map instead of mutating in place.
 		for i, chart := range charts {
+			// The templates include the helpers of the chart they are
+			// written to (subcharts of umbrella charts are named
+			// <parent>/charts/<subchart>).
+			chartOpts := nsOpts
+			chartOpts.ChartName = filepath.Base(chart.Name)
+			nsTemplates := generator.GenerateNamespaceResources(groupingResult.Groups, chartOpts)
 			templates := make(map[string]string, len(chart.Templates)+len(nsTemplates)+len(autoNP))
 			for k, v := range chart.Templates {
 				templates[k] = v
 			}
 			for path, content := range nsTemplates {
-				// Skip default NP for groups that have fine-grained auto-NP
-				if strings.HasSuffix(path, "-networkpolicy-default.yaml") {
-					groupName := strings.TrimPrefix(path, "templates/")
-					groupName = strings.TrimSuffix(groupName, "-networkpolicy-default.yaml")
-					if _, has := autoNPGroups[groupName]; has {
-						continue
-					}
-				}
 				templates[path] = content
 			}
 			for path, content := range autoNP {
 				templates[path] = content
 			}
+			valuesYAML := generator.AppendNamespaceValues(chart.ValuesYAML, groupingResult.Groups, nsOpts)
+			valuesYAML = generator.AppendNetworkPolicyValues(valuesYAML, groupingResult.Groups)
 			charts[i] = &types.GeneratedChart{
 				Name:          chart.Name,
 				Path:          chart.Path,
 				ChartYAML:     chart.ChartYAML,
-				ValuesYAML:    chart.ValuesYAML,
+				ValuesYAML:    valuesYAML,
 				Templates:     templates,
 				Helpers:       chart.Helpers,
 				Notes:         chart.Notes,
@@ -653,11 +1436,39 @@ drain:
 		}
 	}
 
+	// Place cluster-scoped resources if requested
+	if strategy := generator.ClusterScopeStrategy(opts.clusterScope); strategy != generator.ClusterScopeTemplates {
+		crds := make(map[string]types.ExternalFileInfo)
+		if strategy == generator.ClusterScopeCRDs {
+			for _, r := range processed.Resources {
+				if r.TemplatePath == "" || r.Original.Object.GetKind() != "CustomResourceDefinition" {
+					continue
+				}
+				for path, content := range k8s.GenerateCRDInstallFiles([]*unstructured.Unstructured{r.Original.Object}) {
+					crds[r.TemplatePath] = types.ExternalFileInfo{Path: path, Content: content}
+				}
+			}
+		}
+		for i, chart := range charts {
+			var placed []string
+			charts[i], placed, err = generator.ApplyClusterScopeStrategy(chart, strategy, crds)
+			if err != nil {
+				return err
+			}
+			for _, path := range placed {
+				logger.Info("placed cluster-scoped resource", "chart", chart.Name, "template", path, "strategy", string(strategy))
+			}
+		}
+	}
+
 	// Apply multi-tenant overlay if requested
-	if opts.multiTenant {
-		if opts.verbose {
-			fmt.Printf("\n[4e/5] Applying multi-tenant overlay...\n")
+	if tenants != nil {
+		logger.Debug("applying multi-tenant overlay", "tenants", len(tenants.Tenants), "file", opts.tenantsFile)
+		for i, chart := range charts {
+			charts[i] = generator.GenerateTenantOverlay(chart, tenants)
 		}
+	} else if opts.multiTenant {
+		logger.Debug("applying multi-tenant overlay", "tenants", opts.tenantCount)
 		for i, chart := range charts {
 			charts[i] = generator.GenerateMultiTenantOverlay(chart, opts.tenantCount)
 		}
@@ -665,20 +1476,72 @@ drain:
 
 	// Apply feature flags if requested
 	if opts.featureFlags {
-		if opts.verbose {
-			fmt.Printf("\n[4f/5] Injecting feature flags...\n")
-		}
+		logger.Debug("injecting feature flags")
 		config := generator.DefaultFeatureFlagConfig()
+		config.Custom = customFeatureFlags
+		config.ResourceNames = make(map[string]string, len(processed.Resources))
+		for _, r := range processed.Resources {
+			if r.TemplatePath != "" {
+				config.ResourceNames[r.TemplatePath] = r.Original.Object.GetName()
+			}
+		}
 		for i, chart := range charts {
 			charts[i] = generator.InjectFeatureFlags(chart, config)
 		}
 	}
 
+	// Guard resources by the APIs served by the cluster if requested
+	if opts.capabilitiesGuards {
+		for i, chart := range charts {
+			var guarded int
+			charts[i], guarded = generator.InjectCapabilitiesGuards(chart)
+			logger.Debug("injected capabilities guards", "chart", chart.Name, "templates", guarded)
+		}
+	}
+
+	// Apply generator fixes if requested
+	for _, fix := range opts.fixes {
+		switch fix {
+		case "graceful-shutdown":
+			targets := generator.GracefulShutdownTargets(graph)
+			for i, chart := range charts {
+				var patched int
+				charts[i], patched = generator.InjectGracefulShutdownValues(chart, targets)
+				logger.Debug("injected graceful shutdown", "chart", chart.Name, "templates", patched)
+			}
+		}
+	}
+
+	// Synthesize probes from container ports if requested
+	if opts.synthesizeProbes {
+		for i, chart := range charts {
+			var patched int
+			charts[i], patched = generator.InjectProbes(chart, opts.probePath)
+			logger.Debug("synthesized probes", "chart", chart.Name, "templates", patched)
+		}
+	}
+
+	// Fix best-practice violations if requested
+	bestPracticeFixes := make(map[string][]generator.BestPracticeFix, len(charts))
+	if opts.applyBestPractices {
+		practices := pattern.DefaultAnalyzer().Analyze(graph).BestPractices
+		for i, chart := range charts {
+			charts[i], bestPracticeFixes[chart.Name] = generator.ApplyBestPractices(chart, graph, practices)
+			for _, fix := range bestPracticeFixes[chart.Name] {
+				if fix.Skipped != "" {
+					logger.Info("best practice not fixed", "chart", chart.Name, "practice", fix.Practice,
+						"resource", fix.Resource.String(), "reason", fix.Skipped)
+					continue
+				}
+				logger.Debug("fixed best practice", "chart", chart.Name, "practice", fix.Practice,
+					"resource", fix.Resource.String(), "path", fix.Path)
+			}
+		}
+	}
+
 	// Apply cloud annotations if requested
 	if opts.cloudProvider != "" {
-		if opts.verbose {
-			fmt.Printf("\n[4g/5] Injecting cloud annotations for %s...\n", opts.cloudProvider)
-		}
+		logger.Debug("injecting cloud annotations", "provider", opts.cloudProvider)
 		cloudConfig := generator.CloudAnnotationConfig{
 			Provider: generator.CloudProvider(opts.cloudProvider),
 			Internal: opts.cloudInternal,
@@ -695,13 +1558,8 @@ drain:
 
 	// Auto-detect ingress controller and inject annotations if requested
 	if opts.detectIngress {
-		if opts.verbose {
-			fmt.Printf("\n[4h/5] Detecting ingress controller...\n")
-		}
-		controller := generator.DetectIngressController(processedResources)
-		if opts.verbose {
-			fmt.Printf("  Detected controller: %s\n", controller)
-		}
+		controller := generator.DetectIngressController(processed.Resources)
+		logger.Debug("detected ingress controller", "controller", string(controller))
 		if controller != generator.ControllerUnknown {
 			features := []generator.IngressFeature{
 				generator.IngressSSLRedirect,
@@ -714,9 +1572,7 @@ drain:
 
 	// Apply spot instance configuration if requested
 	if opts.spot {
-		if opts.verbose {
-			fmt.Printf("\n[4i/5] Injecting spot/preemptible instance configuration...\n")
-		}
+		logger.Debug("injecting spot/preemptible instance configuration")
 		spotConfig := generator.SpotConfig{
 			GracePeriod: opts.spotGracePeriod,
 			Enabled:     true,
@@ -728,6 +1584,12 @@ drain:
 			spotConfig.Provider = generator.SpotGCP
 		case "azure":
 			spotConfig.Provider = generator.SpotAzure
+		case "yandex":
+			spotConfig.Provider = generator.SpotYandex
+		case "vk":
+			spotConfig.Provider = generator.SpotVK
+		case "openstack":
+			spotConfig.Provider = generator.SpotOpenStack
 		}
 		for i, chart := range charts {
 			charts[i] = generator.InjectSpotConfig(chart, spotConfig)
@@ -736,67 +1598,89 @@ drain:
 
 	// Auto-detect dependencies if requested
 	if opts.autoDeps {
-		if opts.verbose {
-			fmt.Printf("\n[4j/5] Auto-detecting infrastructure dependencies...\n")
-		}
-		detected := generator.DetectCommonDependencies(processedResources)
-		if opts.verbose {
-			fmt.Printf("  Detected %d dependencies\n", len(detected))
+		detected := generator.DetectCommonDependencies(processed.Resources)
+		logger.Debug("detected infrastructure dependencies", "count", len(detected))
+
+		pinned := false
+		if len(detected) > 0 && !opts.depsOffline {
+			var resolver generator.DependencyResolver = &generator.ArtifactHubResolver{Client: depsClient}
+			if opts.depsIndex != "" {
+				resolver = &generator.RepoIndexResolver{IndexURL: opts.depsIndex, Client: depsClient}
+			}
+			resolved, err := generator.ResolveDependencyVersions(ctx, detected, resolver)
+			if err != nil {
+				logger.Warn("dependency versions not resolved, keeping version ranges", "error", err)
+			} else {
+				detected, pinned = resolved, true
+			}
 		}
+
 		for i, chart := range charts {
 			charts[i] = generator.InjectDependencies(chart, detected)
+			if pinned {
+				locked, err := generator.InjectChartLock(charts[i], detected, time.Now())
+				if err != nil {
+					return err
+				}
+				charts[i] = locked
+			}
 		}
 	}
 
-	// Dry-run: print to stdout instead of writing to disk
-	if opts.dryRun {
-		for _, chart := range charts {
-			fmt.Printf("---\n# Chart: %s\n", chart.Name)
-			fmt.Printf("# Chart.yaml\n%s\n", chart.ChartYAML)
-			fmt.Printf("---\n# values.yaml\n%s\n", chart.ValuesYAML)
-
-			// Print templates sorted
-			templatePaths := make([]string, 0, len(chart.Templates))
-			for path := range chart.Templates {
-				templatePaths = append(templatePaths, path)
-			}
-			sort.Strings(templatePaths)
-			for _, path := range templatePaths {
-				fmt.Printf("---\n# %s\n%s\n", path, chart.Templates[path])
-			}
-
-			if chart.Helpers != "" {
-				fmt.Printf("---\n# templates/_helpers.tpl\n%s\n", chart.Helpers)
+	// Convert the charts for Helm 2 consumers if requested
+	if opts.chartAPIVersion == generator.ChartAPIVersionV1 {
+		logger.Debug("converting charts to apiVersion v1")
+		for i, chart := range charts {
+			converted, err := generator.ConvertChartToV1(chart)
+			if err != nil {
+				return err
 			}
+			charts[i] = converted
 		}
-		return nil
 	}
 
-	// Step 5: Write charts to disk
-	if opts.verbose {
-		fmt.Printf("\n[5/5] Writing charts to disk...\n")
-	}
+	postprocessStage.Done()
 
-	for _, chart := range charts {
-		if err := generator.ValidateChart(chart); err != nil {
-			return fmt.Errorf("chart validation failed for %s: %w", chart.Name, err)
+	// Interactive preview: show values and ask before writing anything.
+	if prompter != nil && !opts.dryRun {
+		write, err := prompter.previewCharts(charts)
+		if err != nil {
+			return fmt.Errorf("interactive preview: %w", err)
 		}
+		if !write {
+			fmt.Println("Generation cancelled; nothing was written.")
+			return nil
+		}
+	}
 
-		if err := generator.WriteChart(chart, opts.outputDir); err != nil {
-			return fmt.Errorf("failed to write chart %s: %w", chart.Name, err)
+	// Dry-run: print to stdout instead of writing to disk
+	if opts.dryRun {
+		if err := printDryRun(os.Stdout, charts, opts.dryRunFormat); err != nil {
+			return err
+		}
+		if opts.summaryJSON != "" {
+			return writeSummaryJSON(opts, logger, charts, graph, started)
 		}
+		return nil
+	}
 
-		if opts.verbose {
-			fmt.Printf("  Written chart: %s\n", chart.Name)
-			fmt.Printf("    Templates: %d\n", len(chart.Templates))
+	// Step 5: Write charts to disk
+	writeStage := logger.StartStage("write")
+
+	if opts.werf {
+		if err := dhg.WriteWerfProjects(charts, werfConfigs, opts.outputDir); err != nil {
+			return err
 		}
+	} else if err := dhg.WriteCharts(charts, opts.outputDir); err != nil {
+		return err
+	}
+	for _, chart := range charts {
+		logger.Debug("chart written", "chart", chart.Name, "templates", len(chart.Templates))
 	}
 
 	// Generate environment-specific values if requested
 	if opts.envValues {
-		if opts.verbose {
-			fmt.Printf("\n[5b/5] Generating environment-specific values...\n")
-		}
+		logger.Debug("generating environment-specific values")
 
 		// Build a name→group index for workload-aware profile selection.
 		var groupsByName map[string]*generator.ServiceGroup
@@ -818,35 +1702,40 @@ drain:
 					_ = yaml.Unmarshal([]byte(chart.ValuesYAML), &baseValues)
 				}
 				envFiles = generator.GenerateEnvValuesForWorkload(baseValues, workloadType)
-				if opts.verbose {
-					fmt.Printf("  Chart %s: workload=%s (workload-aware profiles)\n", chart.Name, workloadType)
-				}
+				logger.Debug("using workload-aware profiles", "chart", chart.Name, "workload", string(workloadType))
 			} else {
 				// Fallback: no matching group — use static profiles.
 				envFiles = generator.GenerateEnvValues(nil)
-				if opts.verbose {
-					fmt.Printf("  Chart %s: using default profiles (no group match)\n", chart.Name)
+				logger.Debug("using default profiles, no group match", "chart", chart.Name)
+			}
+
+			if len(resourceRecs) > 0 {
+				var baseValues map[string]interface{}
+				if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &baseValues); err != nil {
+					return fmt.Errorf("parsing values of chart %s: %w", chart.Name, err)
 				}
+				prod, applied, err := generator.ApplyResourceRecommendations(envFiles["values-prod.yaml"], baseValues, resourceRecs)
+				if err != nil {
+					return fmt.Errorf("values-prod.yaml of chart %s: %w", chart.Name, err)
+				}
+				envFiles["values-prod.yaml"] = prod
+				logger.Info("wrote recommended container resources to values-prod.yaml", "chart", chart.Name, "containers", applied)
 			}
 
-			chartDir := filepath.Join(opts.outputDir, chart.Name)
+			chartDir := opts.chartDir(chart.Name)
 			for filename, content := range envFiles {
 				envPath := filepath.Join(chartDir, filename)
 				if err := os.WriteFile(envPath, content, 0644); err != nil {
 					return fmt.Errorf("failed to write %s: %w", filename, err)
 				}
-				if opts.verbose {
-					fmt.Printf("  Written: %s/%s\n", chart.Name, filename)
-				}
+				logger.Debug("file written", "chart", chart.Name, "file", filename)
 			}
 		}
 	}
 
 	// Generate monorepo layout if requested
 	if opts.monorepo {
-		if opts.verbose {
-			fmt.Printf("\n[5c/5] Generating monorepo layout...\n")
-		}
+		logger.Debug("generating monorepo layout")
 		layout, err := generator.GenerateMonorepoLayout(charts, opts.chartName)
 		if err != nil {
 			return fmt.Errorf("monorepo layout generation failed: %w", err)
@@ -866,22 +1755,16 @@ drain:
 		if err := os.WriteFile(ctConfigPath, []byte(layout.CTConfig), 0644); err != nil {
 			return fmt.Errorf("failed to write ct.yaml: %w", err)
 		}
-		if opts.verbose {
-			fmt.Printf("  Written: Makefile, .helmignore, ct.yaml\n")
-		}
+		logger.Debug("monorepo layout written", "files", "Makefile,.helmignore,ct.yaml")
 	}
 
 	// Generate Kustomize layout if requested
 	if opts.kustomize {
-		if opts.verbose {
-			fmt.Printf("\n[5d/5] Generating Kustomize layout...\n")
-		}
+		logger.Debug("generating Kustomize layout")
 		for _, chart := range charts {
 			kustomizeOutput, err := generator.GenerateKustomizeLayout(chart)
 			if err != nil {
-				if opts.verbose {
-					fmt.Fprintf(os.Stderr, "  Warning: Kustomize generation skipped for %s: %v\n", chart.Name, err)
-				}
+				logger.Warn("Kustomize generation skipped", "chart", chart.Name, "error", err)
 				continue
 			}
 			kustomizeDir := filepath.Join(opts.outputDir, chart.Name, "kustomize")
@@ -908,23 +1791,90 @@ drain:
 					}
 				}
 			}
-			if opts.verbose {
-				fmt.Printf("  Written: kustomize layout for %s\n", chart.Name)
-			}
+			logger.Debug("Kustomize layout written", "chart", chart.Name)
 		}
 	}
 
 	// Post-renderer mode: when enabled, Kustomize overlays are generated with
 	// Flux CD postBuild-compatible structure. Currently infrastructure-only.
 	if opts.postRenderer {
-		if opts.verbose {
-			fmt.Println("\nPost-renderer mode enabled: Kustomize overlays will be compatible with Helm post-rendering.")
-		}
+		logger.Debug("post-renderer mode enabled, Kustomize overlays will be compatible with Helm post-rendering")
 		// TODO: Integrate actual post-renderer pipeline (Flux CD postBuild, kustomize --enable-helm).
 		// For now, --post-renderer implies --kustomize behavior with Flux-compatible annotations.
 	}
 
+	var record *generator.GenerationRecord
+	if opts.options != nil {
+		if record, err = generationRecord(opts, processed.Resources, sourceSnapshot != nil); err != nil {
+			return err
+		}
+	}
+
+	// Mark generated files as owned by dhg and record what was generated as
+	// the base for dhg status and future upgrade-chart merges. Changes since
+	// the previous generation go to CHANGELOG.md first, while its base is kept.
+	// The generation record lets dhg regenerate replay the generation.
+	for _, chart := range charts {
+		chartDir := opts.chartDir(chart.Name)
+		changes, err := generator.UpdateGenerationChangelog(chartDir, chart, time.Now())
+		if err != nil {
+			return err
+		}
+		if changes != nil && !changes.IsEmpty() {
+			logger.Info("recorded changes since the previous generation", "chart", chart.Name, "file", generator.ChangelogFile)
+		}
+		if _, err := generator.StampOwnership(chartDir, version); err != nil {
+			return err
+		}
+		if err := generator.SaveBaseSnapshot(chartDir); err != nil {
+			return err
+		}
+		if record != nil {
+			if err := generator.WriteGenerationRecord(chartDir, record, sourceSnapshot); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.sign != "" {
+		files, err := signCharts(ctx, opts, charts)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			logger.Info("wrote signed chart artifact", "file", file)
+		}
+	}
+
+	writeStage.Done("charts", len(charts))
+	logger.Info("generation completed",
+		"charts", len(charts), "output", opts.outputDir, "duration_ms", time.Since(started).Milliseconds())
+
+	if opts.summaryJSON != "" {
+		if err := writeSummaryJSON(opts, logger, charts, graph, started); err != nil {
+			return err
+		}
+	}
+
+	if opts.skipSummary || opts.summaryJSON == "-" {
+		return nil
+	}
+
+	for _, chart := range charts {
+		if fixes := bestPracticeFixes[chart.Name]; len(fixes) > 0 {
+			fmt.Printf("\nBest-practice fixes in chart %q:\n", chart.Name)
+			for _, fix := range fixes {
+				fmt.Printf("  %s\n", fix)
+			}
+		}
+	}
+
 	fmt.Printf("\n✓ Successfully generated %d chart(s) in %s\n", len(charts), opts.outputDir)
+	if opts.werf {
+		fmt.Printf("\nTo build and deploy with werf, run:\n")
+		fmt.Printf("  cd %s && werf converge --repo <registry>/%s\n", filepath.Join(opts.outputDir, opts.chartName), opts.chartName)
+		return nil
+	}
 	fmt.Printf("\nTo install the chart, run:\n")
 	fmt.Printf("  helm install my-release %s/%s\n", opts.outputDir, opts.chartName)
 
@@ -933,43 +1883,122 @@ drain:
 
 func newAnalyzeCmd() *cobra.Command {
 	var (
-		paths         []string
-		outputFormat  string
-		outputFile    string
-		summaryOnly   bool
-		color         bool
-		verbose       bool
-		namespace     string
-		namespaces    []string
-		includeKinds  []string
-		excludeKinds  []string
-		recursive     bool
+		paths             []string
+		outputFormat      string
+		outputFile        string
+		summaryOnly       bool
+		color             bool
+		verbose           bool
+		namespace         string
+		namespaces        []string
+		includeKinds      []string
+		excludeKinds      []string
+		recursive         bool
+		plugins           []string
+		pluginDirs        []string
+		source            string
+		labelSelector     string
+		kubeConfig        string
+		kubeContext       string
+		graphFile         string
+		allowedRegistries []string
+		rulesFile         string
+		baseline          string
+		updateBaseline    bool
+		failOn            string
+		cost              bool
+		costProvider      string
+		cpuPrice          float64
+		memoryPrice       float64
+		costBaseline      []string
+		metricsSource     string
+		prometheusURL     string
+		metricsWindow     time.Duration
 	)
 
-	cmd := &cobra.Command{
-		Use:   "analyze",
-		Short: "Analyze resources and provide recommendations",
-		Long: `Analyze Kubernetes resources for architecture patterns, best practices,
-and provide recommendations for Helm chart organization.`,
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze resources and provide recommendations",
+		Long: `Analyze Kubernetes resources for architecture patterns, best practices,
+and provide recommendations for Helm chart organization.`,
+		Example: `  # Analyze manifests from a directory
+  dhg analyze -f ./manifests
+
+  # Analyze what is running in the prod namespace of the current cluster
+  dhg analyze -s cluster -n prod
+
+  # Analyze several namespaces and write the relationship graph
+  dhg analyze -s cluster --namespaces frontend,backend --graph graph.dot
+
+  # Flag images pulled from outside the company registries (BP-IMG-003)
+  dhg analyze -f ./manifests --allowed-registries registry.example.com,cr.example.com/mirror
+
+  # Tune the checks with a rule file (min replicas, allowed privileged workloads,
+  # severity overrides, disabled rules)
+  dhg analyze -f ./manifests --rules ./ci/.dhg-rules.yaml
+
+  # Fail CI on errors and critical violations (exit code 2)
+  dhg analyze -f ./manifests --fail-on error
+
+  # Record the current violations, then fail only on new ones
+  dhg analyze -f ./manifests --baseline baseline.yaml --update-baseline
+  dhg analyze -f ./manifests --baseline baseline.yaml
+
+  # Estimate the monthly cost per service at custom prices
+  dhg analyze -f ./manifests --cost --cpu-price 0.035 --memory-price 0.005
+
+  # Show how the monthly cost changes compared with the previous manifests
+  dhg analyze -f ./manifests --cost-baseline ./manifests-v1
+
+  # Recommend requests and limits from the usage reported by metrics-server
+  dhg analyze -s cluster -n prod --metrics metrics-server
+
+  # Recommend requests and limits from two weeks of Prometheus history
+  dhg analyze -s cluster -n prod --metrics prometheus --prometheus-url http://prometheus.example.com:9090 --metrics-window 336h`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runAnalyze(cmd.Context(), analyzeOptions{
-				paths:        paths,
-				outputFormat: outputFormat,
-				outputFile:   outputFile,
-				summaryOnly:  summaryOnly,
-				color:        color,
-				verbose:      verbose,
-				namespace:    namespace,
-				namespaces:   namespaces,
-				includeKinds: includeKinds,
-				excludeKinds: excludeKinds,
-				recursive:    recursive,
+				paths:             paths,
+				outputFormat:      outputFormat,
+				outputFile:        outputFile,
+				summaryOnly:       summaryOnly,
+				color:             color,
+				verbose:           verbose,
+				namespace:         namespace,
+				namespaces:        namespaces,
+				includeKinds:      includeKinds,
+				excludeKinds:      excludeKinds,
+				recursive:         recursive,
+				plugins:           plugins,
+				pluginDirs:        pluginDirs,
+				source:            source,
+				labelSelector:     labelSelector,
+				kubeConfig:        kubeConfig,
+				kubeContext:       kubeContext,
+				graphFile:         graphFile,
+				allowedRegistries: allowedRegistries,
+				rulesFile:         rulesFile,
+				baseline:          baseline,
+				updateBaseline:    updateBaseline,
+				failOn:            failOn,
+				cost:              cost,
+				costProvider:      costProvider,
+				cpuPrice:          cpuPrice,
+				memoryPrice:       memoryPrice,
+				costBaseline:      costBaseline,
+				metricsSource:     metricsSource,
+				prometheusURL:     prometheusURL,
+				metricsWindow:     metricsWindow,
 			})
 		},
 	}
 
-	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories (required)")
-	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format: text, json, markdown")
+	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories (required for file source)")
+	cmd.Flags().StringVarP(&source, "source", "s", "file", "Source type: file, cluster or compose")
+	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector filter")
+	cmd.Flags().StringVar(&kubeConfig, "kubeconfig", "", "Path to kubeconfig file")
+	cmd.Flags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVar(&graphFile, "graph", "", "Write the resource relationship graph (DOT format) to this file")
+	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format: text, json, markdown, sarif, html")
 	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
 	cmd.Flags().BoolVar(&summaryOnly, "summary", false, "Show only summary")
 	cmd.Flags().BoolVar(&color, "color", true, "Enable colored output")
@@ -979,49 +2008,150 @@ and provide recommendations for Helm chart organization.`,
 	cmd.Flags().StringSliceVar(&includeKinds, "include-kinds", nil, "Include only these resource kinds")
 	cmd.Flags().StringSliceVar(&excludeKinds, "exclude-kinds", nil, "Exclude these resource kinds")
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Recursively scan directories")
-
-	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().StringSliceVar(&plugins, "plugin", nil, "Path(s) to plugin executables providing processors/detectors/checkers (subprocess JSON protocol)")
+	cmd.Flags().StringSliceVar(&pluginDirs, "plugin-dir", nil, "Directories to load all executable plugins from")
+	cmd.Flags().StringSliceVar(&allowedRegistries, "allowed-registries", nil, "Registries (or registry/repository prefixes) images may come from; others are reported as BP-IMG-003")
+	cmd.Flags().StringVar(&rulesFile, "rules", "", "Checker rule configuration file (default: ./"+pattern.RulesFile+")")
+	cmd.Flags().StringVar(&baseline, "baseline", "", "Baseline file of known violations: they are left out of the report, and the command fails on new violations")
+	cmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "Write the current violations to the --baseline file instead of comparing with it")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with code 2 when a violation has this severity or higher: critical, error, warning (default: never, or any new violation with --baseline)")
+	cmd.Flags().BoolVar(&cost, "cost", false, "Add a monthly cost estimate per service and in total, from container requests (limits where unset) and replicas")
+	cmd.Flags().StringVar(&costProvider, "cost-provider", "aws", "Pricing preset for --cost: aws, gcp, azure")
+	cmd.Flags().Float64Var(&cpuPrice, "cpu-price", 0, "Price of a vCPU-hour in USD for --cost (default: the --cost-provider preset)")
+	cmd.Flags().Float64Var(&memoryPrice, "memory-price", 0, "Price of a GiB-hour of memory in USD for --cost (default: the --cost-provider preset)")
+	cmd.Flags().StringSliceVar(&costBaseline, "cost-baseline", nil, "Manifests to compare the cost estimate with; adds the per-service cost delta (implies --cost)")
+	cmd.Flags().StringVar(&metricsSource, "metrics", "", "Recommend requests/limits from observed usage: metrics-server (requires --source cluster) or prometheus")
+	cmd.Flags().StringVar(&prometheusURL, "prometheus-url", "", "Prometheus server URL for --metrics prometheus")
+	cmd.Flags().DurationVar(&metricsWindow, "metrics-window", metrics.DefaultWindow, "Usage history to look back over for --metrics prometheus")
 
 	return cmd
 }
 
 type analyzeOptions struct {
-	paths        []string
-	outputFormat string
-	outputFile   string
-	summaryOnly  bool
-	color        bool
-	verbose      bool
-	namespace    string
-	namespaces   []string
-	includeKinds []string
-	excludeKinds []string
-	recursive    bool
+	paths             []string
+	outputFormat      string
+	outputFile        string
+	summaryOnly       bool
+	color             bool
+	verbose           bool
+	namespace         string
+	namespaces        []string
+	includeKinds      []string
+	excludeKinds      []string
+	recursive         bool
+	plugins           []string
+	pluginDirs        []string
+	source            string
+	labelSelector     string
+	kubeConfig        string
+	kubeContext       string
+	graphFile         string
+	allowedRegistries []string
+	rulesFile         string
+	baseline          string
+	updateBaseline    bool
+	failOn            string
+	cost              bool
+	costProvider      string
+	cpuPrice          float64
+	memoryPrice       float64
+	costBaseline      []string
+	metricsSource     string
+	prometheusURL     string
+	metricsWindow     time.Duration
 }
 
 func runAnalyze(ctx context.Context, opts analyzeOptions) error {
+	var sourceType types.Source
+	switch opts.source {
+	case "file", "":
+		sourceType = types.SourceFile
+		if len(opts.paths) == 0 {
+			return fmt.Errorf("at least one path is required for file source (-f flag)")
+		}
+	case "cluster":
+		sourceType = types.SourceCluster
+	case "compose":
+		sourceType = types.SourceCompose
+		if len(opts.paths) == 0 {
+			return fmt.Errorf("at least one compose file is required for compose source (-f flag)")
+		}
+	default:
+		return fmt.Errorf("invalid source: %s (must be file, cluster, or compose)", opts.source)
+	}
+
+	if err := validateMetricsSource(opts.metricsSource, opts.prometheusURL, opts.metricsWindow, sourceType); err != nil {
+		return err
+	}
+
+	switch pattern.Severity(opts.failOn) {
+	case "", pattern.SeverityCritical, pattern.SeverityError, pattern.SeverityWarning:
+	default:
+		return fmt.Errorf("invalid --fail-on: %s (must be critical, error or warning)", opts.failOn)
+	}
+
+	rulesFile := opts.rulesFile
+	if rulesFile == "" {
+		rulesFile = pattern.RulesFile
+	}
+	rules, err := pattern.LoadRules(rulesFile)
+	if err != nil {
+		return err
+	}
+
+	var baseline *pattern.Baseline
+	if opts.updateBaseline && opts.baseline == "" {
+		return fmt.Errorf("--update-baseline requires --baseline")
+	}
+	if opts.baseline != "" && !opts.updateBaseline {
+		if baseline, err = pattern.LoadBaseline(opts.baseline); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("%w (create it with --update-baseline)", err)
+			}
+			return err
+		}
+	}
+
+	var costOpts generator.CostEstimateOptions
+	if opts.cost || len(opts.costBaseline) > 0 {
+		var err error
+		if costOpts, err = opts.costOptions(); err != nil {
+			return err
+		}
+	}
+
+	plugins, err := loadPlugins(ctx, opts.plugins, opts.pluginDirs, func(err error) {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+	})
+	if err != nil {
+		return err
+	}
+
 	// Step 1: Extract resources
 	if opts.verbose {
 		fmt.Printf("[1/4] Extracting resources...\n")
 	}
 
 	extractorRegistry := extractor.DefaultRegistry()
-	ext, ok := extractorRegistry.Get(types.SourceFile)
+	ext, ok := extractorRegistry.Get(sourceType)
 	if !ok {
-		return fmt.Errorf("file extractor not available")
+		return withExitCode(exitExtraction, fmt.Errorf("no extractor available for source type: %s", sourceType))
 	}
 
 	extractOpts := extractor.Options{
-		Paths:        opts.paths,
-		Namespace:    opts.namespace,
-		Namespaces:   opts.namespaces,
-		IncludeKinds: opts.includeKinds,
-		ExcludeKinds: opts.excludeKinds,
-		Recursive:    opts.recursive,
+		Paths:         opts.paths,
+		Namespace:     opts.namespace,
+		Namespaces:    opts.namespaces,
+		LabelSelector: opts.labelSelector,
+		IncludeKinds:  opts.includeKinds,
+		ExcludeKinds:  opts.excludeKinds,
+		Recursive:     opts.recursive,
+		KubeConfig:    opts.kubeConfig,
+		KubeContext:   opts.kubeContext,
 	}
 
 	if err := ext.Validate(ctx, extractOpts); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return withExitCode(exitExtraction, fmt.Errorf("validation failed: %w", err))
 	}
 
 	resourceChan, errChan := ext.Extract(ctx, extractOpts)
@@ -1058,7 +2188,7 @@ drainExtract:
 	}
 
 	if len(extractedResources) == 0 {
-		return fmt.Errorf("no resources extracted")
+		return withExitCode(exitExtraction, fmt.Errorf("no resources extracted"))
 	}
 
 	if opts.verbose {
@@ -1072,6 +2202,9 @@ drainExtract:
 
 	processorRegistry := processor.NewRegistry()
 	k8s.RegisterAll(processorRegistry)
+	for _, p := range plugins {
+		p.RegisterProcessors(processorRegistry)
+	}
 
 	var processedResources []*types.ProcessedResource
 	allResourcesMap := make(map[types.ResourceKey]*types.ExtractedResource)
@@ -1122,6 +2255,9 @@ drainExtract:
 
 	relationshipAnalyzer := analyzer.NewDefaultAnalyzer()
 	detector.RegisterAll(relationshipAnalyzer)
+	for _, p := range plugins {
+		p.RegisterDetectors(relationshipAnalyzer)
+	}
 
 	resourceGraph, err := relationshipAnalyzer.Analyze(ctx, processedResources)
 	if err != nil {
@@ -1133,17 +2269,65 @@ drainExtract:
 		fmt.Printf("  Grouped into: %d services\n", len(resourceGraph.Groups))
 	}
 
+	if opts.graphFile != "" {
+		if err := os.WriteFile(opts.graphFile, []byte(analyzer.GenerateDOTGraph(resourceGraph)), 0644); err != nil {
+			return fmt.Errorf("failed to write graph file: %w", err)
+		}
+		if opts.verbose {
+			fmt.Printf("  Graph written to: %s\n", opts.graphFile)
+		}
+	}
+
 	// Step 4: Pattern analysis
 	if opts.verbose {
 		fmt.Printf("\n[4/4] Analyzing patterns and best practices...\n")
 	}
 
 	patternAnalyzer := pattern.DefaultAnalyzer()
+	patternAnalyzer.ApplyRules(rules)
+	patternAnalyzer.ApplyBaseline(baseline)
+	if images, ok := patternAnalyzer.Checker("image-hygiene").(*pattern.ImageChecker); ok {
+		images.AllowedRegistries = opts.allowedRegistries
+	}
+	for _, p := range plugins {
+		p.RegisterCheckers(patternAnalyzer)
+	}
 	recommender := pattern.NewRecommender(patternAnalyzer)
 	report := recommender.GenerateReport(resourceGraph)
 
+	if opts.updateBaseline {
+		recorded := pattern.NewBaseline(report.AnalysisResult.BestPractices)
+		if err := recorded.Save(opts.baseline); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Baseline written to %s: %d violation(s)\n", opts.baseline, len(recorded.Violations))
+	}
+
+	if opts.cost || len(opts.costBaseline) > 0 {
+		costReport := generator.GenerateCostEstimate(resourceGraph, costOpts)
+		report.Sections = append(report.Sections, costReportSection(costReport))
+		if len(opts.costBaseline) > 0 {
+			baselineGraph, err := costBaselineGraph(ctx, opts, plugins)
+			if err != nil {
+				return err
+			}
+			delta := generator.CompareCostEstimates(generator.GenerateCostEstimate(baselineGraph, costOpts), costReport)
+			report.Sections = append(report.Sections, costDeltaSection(delta, opts.costBaseline))
+		}
+	}
+
+	if opts.metricsSource != "" {
+		usage, err := fetchUsage(ctx, ext, extractOpts, opts.metricsSource, opts.prometheusURL, opts.metricsWindow)
+		if err != nil {
+			return fmt.Errorf("metrics: %w", err)
+		}
+		recs := generator.RecommendResources(resourceGraph, usage)
+		report.Sections = append(report.Sections, rightSizingSection(recs, opts.metricsSource, opts.metricsWindow))
+	}
+
 	// Output
 	formatter := pattern.NewFormatter(opts.color)
+	formatter.ToolVersion = version
 
 	var output string
 
@@ -1161,8 +2345,20 @@ drainExtract:
 			}
 		case "markdown", "md":
 			output = formatter.FormatMarkdown(report)
+		case "sarif":
+			var sarifErr error
+			output, sarifErr = formatter.FormatSARIF(report, resourceGraph)
+			if sarifErr != nil {
+				return fmt.Errorf("failed to format SARIF: %w", sarifErr)
+			}
+		case "html":
+			var htmlErr error
+			output, htmlErr = formatter.FormatHTML(report, resourceGraph)
+			if htmlErr != nil {
+				return fmt.Errorf("failed to format HTML: %w", htmlErr)
+			}
 		default:
-			return fmt.Errorf("invalid output format: %s (must be text, json, or markdown)", opts.outputFormat)
+			return fmt.Errorf("invalid output format: %s (must be text, json, markdown, sarif, or html)", opts.outputFormat)
 		}
 	}
 
@@ -1176,40 +2372,291 @@ drainExtract:
 		fmt.Print(output)
 	}
 
+	if baseline != nil && report.AnalysisResult.BaselineSuppressed > 0 {
+		fmt.Fprintf(os.Stderr, "%d violation(s) suppressed by baseline %s\n", report.AnalysisResult.BaselineSuppressed, opts.baseline)
+	}
+
+	// Fail on violations at or above --fail-on, or on any new violation
+	// with --baseline. Recording a baseline does not fail.
+	if (opts.failOn != "" || baseline != nil) && !opts.updateBaseline {
+		threshold := pattern.Severity(opts.failOn)
+		var failed []pattern.BestPractice
+		for _, bp := range report.AnalysisResult.BestPractices {
+			if !bp.Compliant && bp.Severity.AtLeast(threshold) {
+				failed = append(failed, bp)
+			}
+		}
+		if n := len(pattern.NewBaseline(failed).Violations); n > 0 {
+			desc := fmt.Sprintf("%d violation(s)", n)
+			if baseline != nil {
+				desc = fmt.Sprintf("%d new violation(s)", n)
+			}
+			if opts.failOn != "" {
+				desc += " at or above " + opts.failOn
+			}
+			if baseline != nil {
+				desc += " not in baseline " + opts.baseline
+			}
+			return withExitCode(exitPolicy, errors.New(desc))
+		}
+	}
+
 	return nil
 }
 
+func newGraphCmd() *cobra.Command {
+	var (
+		paths         []string
+		format        string
+		outputFile    string
+		namespace     string
+		namespaces    []string
+		includeKinds  []string
+		excludeKinds  []string
+		recursive     bool
+		plugins       []string
+		pluginDirs    []string
+		source        string
+		labelSelector string
+		kubeConfig    string
+		kubeContext   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the resource relationship graph",
+		Long: `Extract and analyze Kubernetes resources and export the relationship graph.
+
+Nodes are colored by resource kind, edges are labeled by relationship type and
+resources detected as one service are drawn inside a common cluster, so service
+boundaries can be reviewed before generating charts.`,
+		Example: `  # Render the graph with Graphviz
+  dhg graph -f ./manifests | dot -Tsvg -o graph.svg
+
+  # Mermaid flowchart for a Markdown document
+  dhg graph -f ./manifests --format mermaid -o graph.mmd
+
+  # Machine-readable graph of a live namespace
+  dhg graph -s cluster -n prod --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraph(cmd.Context(), graphOptions{
+				paths:         paths,
+				format:        format,
+				outputFile:    outputFile,
+				namespace:     namespace,
+				namespaces:    namespaces,
+				includeKinds:  includeKinds,
+				excludeKinds:  excludeKinds,
+				recursive:     recursive,
+				plugins:       plugins,
+				pluginDirs:    pluginDirs,
+				source:        source,
+				labelSelector: labelSelector,
+				kubeConfig:    kubeConfig,
+				kubeContext:   kubeContext,
+			})
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{}, "Path(s) to YAML files or directories (required for file source)")
+	cmd.Flags().StringVarP(&source, "source", "s", "file", "Source type: file, cluster or compose")
+	cmd.Flags().StringVar(&format, "format", "dot", "Graph format: dot, mermaid, json")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector filter")
+	cmd.Flags().StringVar(&kubeConfig, "kubeconfig", "", "Path to kubeconfig file")
+	cmd.Flags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Filter by namespace")
+	cmd.Flags().StringSliceVar(&namespaces, "namespaces", nil, "Filter by multiple namespaces")
+	cmd.Flags().StringSliceVar(&includeKinds, "include-kinds", nil, "Include only these resource kinds")
+	cmd.Flags().StringSliceVar(&excludeKinds, "exclude-kinds", nil, "Exclude these resource kinds")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Recursively scan directories")
+	cmd.Flags().StringSliceVar(&plugins, "plugin", nil, "Path(s) to plugin executables providing processors/detectors/checkers (subprocess JSON protocol)")
+	cmd.Flags().StringSliceVar(&pluginDirs, "plugin-dir", nil, "Directories to load all executable plugins from")
+
+	return cmd
+}
+
+type graphOptions struct {
+	paths         []string
+	format        string
+	outputFile    string
+	namespace     string
+	namespaces    []string
+	includeKinds  []string
+	excludeKinds  []string
+	recursive     bool
+	plugins       []string
+	pluginDirs    []string
+	source        string
+	labelSelector string
+	kubeConfig    string
+	kubeContext   string
+}
+
+func runGraph(ctx context.Context, opts graphOptions) error {
+	switch opts.format {
+	case "dot", "mermaid", "json":
+	default:
+		return fmt.Errorf("invalid format: %s (must be dot, mermaid, or json)", opts.format)
+	}
+
+	var sourceType types.Source
+	switch opts.source {
+	case "file", "":
+		sourceType = types.SourceFile
+		if len(opts.paths) == 0 {
+			return fmt.Errorf("at least one path is required for file source (-f flag)")
+		}
+	case "cluster":
+		sourceType = types.SourceCluster
+	case "compose":
+		sourceType = types.SourceCompose
+		if len(opts.paths) == 0 {
+			return fmt.Errorf("at least one compose file is required for compose source (-f flag)")
+		}
+	default:
+		return fmt.Errorf("invalid source: %s (must be file, cluster, or compose)", opts.source)
+	}
+
+	plugins, err := loadPlugins(ctx, opts.plugins, opts.pluginDirs, func(err error) {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+	})
+	if err != nil {
+		return err
+	}
+
+	extracted, warnings, err := dhg.Extract(ctx, sourceType, extractor.Options{
+		Paths:         opts.paths,
+		Namespace:     opts.namespace,
+		Namespaces:    opts.namespaces,
+		LabelSelector: opts.labelSelector,
+		IncludeKinds:  opts.includeKinds,
+		ExcludeKinds:  opts.excludeKinds,
+		Recursive:     opts.recursive,
+		KubeConfig:    opts.kubeConfig,
+		KubeContext:   opts.kubeContext,
+	})
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", w)
+	}
+	if len(extracted) == 0 {
+		return fmt.Errorf("no resources extracted")
+	}
+
+	pipeline := dhg.New(dhg.Options{ChartName: "graph", Plugins: plugins})
+	processed, err := pipeline.Process(ctx, extracted)
+	if err != nil {
+		return err
+	}
+	resourceGraph, err := pipeline.Analyze(ctx, processed)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	switch opts.format {
+	case "dot":
+		output = []byte(analyzer.GenerateDOTGraph(resourceGraph))
+	case "mermaid":
+		output = []byte(analyzer.GenerateMermaidGraph(resourceGraph))
+	case "json":
+		output, err = analyzer.GenerateJSONGraph(resourceGraph)
+		if err != nil {
+			return fmt.Errorf("failed to format JSON: %w", err)
+		}
+		output = append(output, '\n')
+	}
+
+	if opts.outputFile != "" {
+		if err := os.WriteFile(opts.outputFile, output, 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Graph written to: %s\n", opts.outputFile)
+		return nil
+	}
+
+	_, err = os.Stdout.Write(output)
+	return err
+}
+
 func newValidateCmd() *cobra.Command {
 	var (
-		paths   []string
-		verbose bool
+		paths          []string
+		verbose        bool
+		render         bool
+		kubeVersion    string
+		schemaLocation string
+		policies       []string
+		opa            string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate Helm chart structure and templates",
-		Long: `Validate Helm chart for common issues:
-  - Chart.yaml presence and required fields
-  - values.yaml syntax
-  - Template syntax (Go template parsing)
-  - Required files presence`,
+		Long: `Validate Helm chart the way "helm lint" does, without helm installed:
+  - Chart.yaml presence and required fields (apiVersion, name, SemVer version)
+  - values.yaml syntax and, if present, values.schema.json
+  - Template syntax (Go template parsing with the Helm and Sprig functions)
+  - .Values references not defined in values.yaml
+  - Rendering with the chart's values and validity of the rendered YAML
+
+With --render, every rendered resource is also validated against the
+Kubernetes API of --kube-version: unknown fields, type errors and apiVersions
+that version does not serve. Schemas come from the built-in Kubernetes API
+types or, with --schema-location, from an offline kubeconform-style bundle.
+
+With --policy, the rendered resources are evaluated against Kyverno
+ClusterPolicy/Policy resources and Rego policies (deny, violation and warn
+rules, conftest style; requires the opa CLI). Violations of Enforce policies
+and deny rules fail validation; Audit policies and warn rules are warnings.
+
+Problems are reported with the file and line they were found at.`,
+		Example: `  # Validate a chart
+  dhg validate -f ./chart/myapp
+
+  # Also validate the rendered resources for Kubernetes 1.29
+  dhg validate -f ./chart/myapp --render --kube-version 1.29
+
+  # Use an offline JSON schema bundle (e.g. a kubernetes-json-schema checkout)
+  dhg validate -f ./chart/myapp --render --kube-version 1.29 --schema-location ./kubernetes-json-schema
+
+  # Gate the chart with the admission controller's policies
+  dhg validate -f ./chart/myapp --policy ./policies`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runValidate(cmd.Context(), validateOptions{
-				paths:   paths,
-				verbose: verbose,
+				paths:          paths,
+				verbose:        verbose,
+				render:         render,
+				kubeVersion:    kubeVersion,
+				schemaLocation: schemaLocation,
+				policies:       policies,
+				opa:            opa,
 			})
 		},
 	}
 
 	cmd.Flags().StringSliceVarP(&paths, "file", "f", []string{"."}, "Path(s) to chart directories to validate")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	cmd.Flags().BoolVar(&render, "render", false, "Validate rendered resources against the Kubernetes API schemas of --kube-version")
+	cmd.Flags().StringVar(&kubeVersion, "kube-version", strings.TrimPrefix(helm.DefaultKubeVersion, "v"), "Kubernetes version to render and validate for (e.g. 1.29)")
+	cmd.Flags().StringVar(&schemaLocation, "schema-location", "", "Directory of kubeconform-style standalone JSON schemas (<version>-standalone-strict/<kind>-<group>-<version>.json)")
+	cmd.Flags().StringSliceVar(&policies, "policy", nil, "Kyverno policy or Rego files and directories to evaluate the rendered resources against")
+	cmd.Flags().StringVar(&opa, "opa", policy.DefaultOPA, "opa executable used to evaluate Rego policies")
 
 	return cmd
 }
 
 type validateOptions struct {
-	paths   []string
-	verbose bool
+	paths          []string
+	verbose        bool
+	render         bool
+	kubeVersion    string
+	schemaLocation string
+	policies       []string
+	opa            string
 }
 
 func runValidate(_ context.Context, opts validateOptions) error {
@@ -1219,95 +2666,34 @@ func runValidate(_ context.Context, opts validateOptions) error {
 	for _, chartPath := range opts.paths {
 		fmt.Printf("Validating chart at: %s\n", chartPath)
 
-		// Check Chart.yaml
-		chartYAMLPath := filepath.Join(chartPath, "Chart.yaml")
-		if _, err := os.Stat(chartYAMLPath); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "  ERROR: Chart.yaml not found at %s\n", chartYAMLPath)
-			totalErrors++
-		} else {
-			data, err := os.ReadFile(chartYAMLPath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  ERROR: Cannot read Chart.yaml: %v\n", err)
-				totalErrors++
-			} else {
-				if opts.verbose {
-					fmt.Printf("  OK: Chart.yaml found (%d bytes)\n", len(data))
-				}
-				// Check required fields
-				content := string(data)
-				requiredFields := []string{"apiVersion:", "name:", "version:"}
-				for _, field := range requiredFields {
-					if !strings.Contains(content, field) {
-						fmt.Fprintf(os.Stderr, "  ERROR: Chart.yaml missing required field: %s\n", strings.TrimSuffix(field, ":"))
-						totalErrors++
-					}
-				}
-			}
-		}
-
-		// Check values.yaml
-		valuesPath := filepath.Join(chartPath, "values.yaml")
-		if _, err := os.Stat(valuesPath); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "  WARNING: values.yaml not found\n")
-			totalWarnings++
-		} else {
-			data, err := os.ReadFile(valuesPath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  ERROR: Cannot read values.yaml: %v\n", err)
-				totalErrors++
-			} else {
-				// Try to parse YAML
-				var values map[string]interface{}
-				if err := yaml.Unmarshal(data, &values); err != nil {
-					fmt.Fprintf(os.Stderr, "  ERROR: Invalid YAML in values.yaml: %v\n", err)
-					totalErrors++
-				} else if opts.verbose {
-					fmt.Printf("  OK: values.yaml valid (%d bytes)\n", len(data))
-				}
+		result := lint.Validate(chartPath, lint.ValidateOptions{
+			KubeVersion:    opts.kubeVersion,
+			KubeSchemas:    opts.render,
+			SchemaLocation: opts.schemaLocation,
+			PolicyPaths:    opts.policies,
+			OPA:            opts.opa,
+		})
+		for _, f := range result.Findings {
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
 			}
-		}
-
-		// Check templates directory
-		templatesDir := filepath.Join(chartPath, "templates")
-		if _, err := os.Stat(templatesDir); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "  WARNING: templates/ directory not found\n")
-			totalWarnings++
-		} else {
-			// Parse templates for syntax
-			entries, err := os.ReadDir(templatesDir)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  ERROR: Cannot read templates directory: %v\n", err)
+			switch f.Severity {
+			case lint.SeverityError:
+				fmt.Fprintf(os.Stderr, "  ERROR: %s: %s\n", location, f.Message)
 				totalErrors++
-			} else {
-				templateCount := 0
-				for _, entry := range entries {
-					if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
-						continue
-					}
-					templateCount++
-					tmplPath := filepath.Join(templatesDir, entry.Name())
-					data, err := os.ReadFile(tmplPath)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "  ERROR: Cannot read template %s: %v\n", entry.Name(), err)
-						totalErrors++
-						continue
-					}
-					// Basic Go template syntax check (check balanced {{ }})
-					content := string(data)
-					opens := strings.Count(content, "{{")
-					closes := strings.Count(content, "}}")
-					if opens != closes {
-						fmt.Fprintf(os.Stderr, "  ERROR: Unbalanced template delimiters in %s ({{ count: %d, }} count: %d)\n", entry.Name(), opens, closes)
-						totalErrors++
-					} else if opts.verbose {
-						fmt.Printf("  OK: %s (%d template expressions)\n", entry.Name(), opens)
-					}
-				}
+			case lint.SeverityWarning:
+				fmt.Fprintf(os.Stderr, "  WARNING: %s: %s\n", location, f.Message)
+				totalWarnings++
+			default:
 				if opts.verbose {
-					fmt.Printf("  Templates: %d files checked\n", templateCount)
+					fmt.Printf("  INFO: %s: %s\n", location, f.Message)
 				}
 			}
 		}
+		if opts.verbose && len(result.Findings) == 0 {
+			fmt.Printf("  OK: Chart.yaml, values and templates are valid\n")
+		}
 	}
 
 	// Summary
@@ -1656,36 +3042,64 @@ func loadChartFromDir(dir string) (*types.GeneratedChart, error) {
 
 func newDiffCmd() *cobra.Command {
 	var (
-		color bool
+		color  bool
+		values []string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "diff <dir1> <dir2>",
 		Short: "Show differences between two chart directories",
 		Long: `Compare two Helm chart directories and show differences.
-Useful for comparing generated charts before and after changes.`,
-		Args: cobra.ExactArgs(2),
+Useful for comparing generated charts before and after changes.
+
+With --values given twice, a single chart is rendered with each values file
+(merged over its values.yaml) and the rendered manifests are compared, e.g.
+to audit what changes between the dev and prod values of --env-values.`,
+		Example: `  # Compare two generated charts
+  dhg diff ./chart-old ./chart-new
+
+  # Compare the rendered output of two environments
+  dhg diff ./chart --values ./chart/values-dev.yaml --values ./chart/values-prod.yaml`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(values) > 0 {
+				if len(values) != 2 {
+					return fmt.Errorf("--values must be given exactly twice, got %d", len(values))
+				}
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDiff(cmd.Context(), diffOptions{
-				dir1:  args[0],
-				dir2:  args[1],
-				color: color,
-			})
+			opts := diffOptions{
+				dir1:   args[0],
+				color:  color,
+				values: values,
+			}
+			if len(args) > 1 {
+				opts.dir2 = args[1]
+			}
+			return runDiff(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().BoolVar(&color, "color", true, "Enable colored output")
+	cmd.Flags().StringArrayVar(&values, "values", nil, "Values file to render the chart with; give twice to diff two value sets of one chart")
 
 	return cmd
 }
 
 type diffOptions struct {
-	dir1  string
-	dir2  string
-	color bool
+	dir1   string
+	dir2   string
+	color  bool
+	values []string
 }
 
 func runDiff(_ context.Context, opts diffOptions) error {
+	if len(opts.values) > 0 {
+		return runValuesDiff(opts)
+	}
+
 	// Validate directories exist
 	for _, dir := range []string{opts.dir1, opts.dir2} {
 		info, err := os.Stat(dir)
@@ -1707,6 +3121,67 @@ func runDiff(_ context.Context, opts diffOptions) error {
 		return fmt.Errorf("failed to scan %s: %w", opts.dir2, err)
 	}
 
+	if !printFileSetDiff(files1, files2, func(relPath string) (string, string) {
+		return opts.dir1 + "/" + relPath, opts.dir2 + "/" + relPath
+	}, opts.color) {
+		fmt.Println("No differences found.")
+	}
+
+	return nil
+}
+
+// runValuesDiff renders the chart opts.dir1 with each of the two opts.values
+// files and prints the differences between the rendered manifests.
+func runValuesDiff(opts diffOptions) error {
+	if len(opts.values) != 2 {
+		return fmt.Errorf("--values must be given exactly twice, got %d", len(opts.values))
+	}
+
+	rendered := make([]map[string]string, 2)
+	for i, valuesFile := range opts.values {
+		manifests, err := renderWithValues(opts.dir1, valuesFile)
+		if err != nil {
+			return err
+		}
+		rendered[i] = manifests
+	}
+
+	if !printFileSetDiff(rendered[0], rendered[1], func(relPath string) (string, string) {
+		return relPath + " (" + opts.values[0] + ")", relPath + " (" + opts.values[1] + ")"
+	}, opts.color) {
+		fmt.Println("No differences found.")
+	}
+
+	return nil
+}
+
+// renderWithValues renders chartDir with valuesFile merged over its
+// values.yaml and returns the manifests keyed by template path. Templates
+// that render to nothing are left out, so a disabled resource shows up as
+// removed.
+func renderWithValues(chartDir, valuesFile string) (map[string]string, error) {
+	values, err := helm.ReadValuesFiles(valuesFile)
+	if err != nil {
+		return nil, err
+	}
+	chart, err := helm.RenderChart(chartDir, helm.RenderOptions{Values: values})
+	if err != nil {
+		return nil, fmt.Errorf("render %s with %s: %w", chartDir, valuesFile, err)
+	}
+
+	manifests := make(map[string]string, len(chart.Manifests))
+	for path, content := range chart.Manifests {
+		if strings.TrimSpace(content) != "" {
+			manifests[path] = content
+		}
+	}
+	return manifests, nil
+}
+
+// printFileSetDiff prints the differences between two sets of files keyed by
+// relative path; names returns the header names of a path on each side. It
+// reports whether any difference was found.
+func printFileSetDiff(files1, files2 map[string]string, names func(relPath string) (string, string), color bool) bool {
 	// Build a union of all relative paths
 	allFiles := make(map[string]bool)
 	for f := range files1 {
@@ -1727,33 +3202,30 @@ func runDiff(_ context.Context, opts diffOptions) error {
 	for _, relPath := range sortedFiles {
 		content1, in1 := files1[relPath]
 		content2, in2 := files2[relPath]
+		name1, name2 := names(relPath)
 
 		if !in1 {
 			hasDiff = true
-			printDiffHeader(opts.dir1, opts.dir2, relPath, "added", opts.color)
-			printLines(content2, "+", opts.color)
+			printDiffHeader(name1, name2, "added", color)
+			printLines(content2, "+", color)
 			continue
 		}
 
 		if !in2 {
 			hasDiff = true
-			printDiffHeader(opts.dir1, opts.dir2, relPath, "removed", opts.color)
-			printLines(content1, "-", opts.color)
+			printDiffHeader(name1, name2, "removed", color)
+			printLines(content1, "-", color)
 			continue
 		}
 
 		if content1 != content2 {
 			hasDiff = true
-			printDiffHeader(opts.dir1, opts.dir2, relPath, "modified", opts.color)
-			printUnifiedDiff(content1, content2, opts.color)
+			printDiffHeader(name1, name2, "modified", color)
+			printUnifiedDiff(content1, content2, color)
 		}
 	}
 
-	if !hasDiff {
-		fmt.Println("No differences found.")
-	}
-
-	return nil
+	return hasDiff
 }
 
 // collectFiles walks a directory and returns map of relative_path -> content
@@ -1780,14 +3252,14 @@ func collectFiles(dir string) (map[string]string, error) {
 	return files, err
 }
 
-func printDiffHeader(dir1, dir2, relPath, status string, color bool) {
+func printDiffHeader(name1, name2, status string, color bool) {
 	if color {
-		fmt.Printf("\033[1m--- %s/%s\033[0m\n", dir1, relPath)
-		fmt.Printf("\033[1m+++ %s/%s\033[0m\n", dir2, relPath)
+		fmt.Printf("\033[1m--- %s\033[0m\n", name1)
+		fmt.Printf("\033[1m+++ %s\033[0m\n", name2)
 		fmt.Printf("\033[36m@@ %s @@\033[0m\n", status)
 	} else {
-		fmt.Printf("--- %s/%s\n", dir1, relPath)
-		fmt.Printf("+++ %s/%s\n", dir2, relPath)
+		fmt.Printf("--- %s\n", name1)
+		fmt.Printf("+++ %s\n", name2)
 		fmt.Printf("@@ %s @@\n", status)
 	}
 }
@@ -3,10 +3,16 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"sigs.k8s.io/yaml"
 )
 
 // ── helpers ───────────────────────────────────────────────────────────────────
@@ -44,15 +50,15 @@ func TestNewRootCmd(t *testing.T) {
 		subNames[sub.Use] = true
 	}
 
-	for _, expected := range []string{"generate", "analyze", "validate", "diff <dir1> <dir2>", "version"} {
+	for _, expected := range []string{"generate", "upgrade-chart", "status <chart-dir>", "bump <chart-dir>", "regenerate <chart-dir>", "analyze", "graph", "validate", "lint", "diff <dir1> <dir2>", "images", "coverage", "bundle <chart-dir>...", "compare-cluster <chart-dir>", "install-check <chart-dir>", "test <chart-dir>", "version"} {
 		if !subNames[expected] {
 			t.Errorf("expected subcommand %q to be registered", expected)
 		}
 	}
 
 	got := len(cmd.Commands())
-	if got != 7 {
-		t.Errorf("expected 7 subcommands (generate, analyze, validate, diff, version, fix, migrate), got %d", got)
+	if got != 19 {
+		t.Errorf("expected 19 subcommands (generate, upgrade-chart, status, bump, regenerate, analyze, graph, validate, lint, diff, version, fix, migrate, images, coverage, bundle, compare-cluster, install-check, test), got %d", got)
 	}
 }
 
@@ -109,6 +115,11 @@ func TestNewGenerateCmd_Flags(t *testing.T) {
 		"deckhouse-module",
 		"template-style",
 		"values-flat",
+		"interactive",
+		"log-format",
+		"log-level",
+		"plugin",
+		"plugin-dir",
 	}
 
 	for _, name := range expectedFlags {
@@ -187,6 +198,11 @@ func TestNewAnalyzeCmd_AdditionalFlags(t *testing.T) {
 		"include-kinds",
 		"exclude-kinds",
 		"recursive",
+		"source",
+		"selector",
+		"kubeconfig",
+		"context",
+		"graph",
 	}
 
 	for _, name := range expectedFlags {
@@ -311,7 +327,7 @@ func TestValidateCmd_ValidChart(t *testing.T) {
 	if err := os.MkdirAll(filepath.Join(tmpDir, "templates"), 0755); err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(tmpDir, "templates", "test.yaml"), []byte("{{ .Values.key }}"), 0644); err != nil {
+	if err := os.WriteFile(filepath.Join(tmpDir, "templates", "test.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\ndata:\n  key: {{ .Values.key }}\n"), 0644); err != nil {
 		t.Fatal(err)
 	}
 
@@ -325,6 +341,79 @@ func TestValidateCmd_ValidChart(t *testing.T) {
 	}
 }
 
+func TestValidateCmd_TemplateSyntaxError(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Balanced delimiters, but not a valid template
+	chartYAML := "apiVersion: v2\nname: test-chart\nversion: 0.1.0\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "templates", "test.yaml"), []byte("kind: ConfigMap\n{{ if .Values.key }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runValidate(context.Background(), validateOptions{
+		paths: []string{tmpDir},
+	})
+
+	if err == nil {
+		t.Error("Expected error for an unterminated if")
+	}
+}
+
+func TestValidateCmd_PolicyViolation(t *testing.T) {
+	tmpDir := t.TempDir()
+	policyDir := t.TempDir()
+
+	chartYAML := "apiVersion: v2\nname: test-chart\nversion: 0.1.0\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "templates", "test.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy := `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  validationFailureAction: Enforce
+  rules:
+    - name: team-label
+      match:
+        any:
+          - resources:
+              kinds: [ConfigMap]
+      validate:
+        message: "label team is required"
+        pattern:
+          metadata:
+            labels:
+              team: "?*"
+`
+	if err := os.WriteFile(filepath.Join(policyDir, "policy.yaml"), []byte(policy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runValidate(context.Background(), validateOptions{paths: []string{tmpDir}}); err != nil {
+		t.Fatalf("Expected the chart to be valid without policies, got: %v", err)
+	}
+	err := runValidate(context.Background(), validateOptions{
+		paths:    []string{tmpDir},
+		policies: []string{policyDir},
+	})
+	if err == nil {
+		t.Error("Expected error for a ConfigMap without the required label")
+	}
+}
+
 // ── TestDiffCmd ───────────────────────────────────────────────────────────────
 
 func TestDiffCmd_IdenticalDirs(t *testing.T) {
@@ -362,6 +451,46 @@ func TestDiffCmd_NonexistentDir(t *testing.T) {
 	}
 }
 
+func TestDiffCmd_Values(t *testing.T) {
+	chart := writeLintChart(t, map[string]string{
+		"values.yaml":        "replicas: 1\ningress:\n  enabled: false\n",
+		"templates/cm.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  replicas: \"{{ .Values.replicas }}\"\n",
+		"templates/ing.yaml": "{{- if .Values.ingress.enabled }}\napiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: app\n{{- end }}\n",
+		"values-dev.yaml":    "replicas: 1\n",
+		"values-prod.yaml":   "replicas: 3\ningress:\n  enabled: true\n",
+	})
+	dev := filepath.Join(chart, "values-dev.yaml")
+	prod := filepath.Join(chart, "values-prod.yaml")
+
+	devOut, err := renderWithValues(chart, dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodOut, err := renderWithValues(chart, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := devOut["templates/ing.yaml"]; ok {
+		t.Error("expected the disabled Ingress to be left out of the dev render")
+	}
+	if _, ok := prodOut["templates/ing.yaml"]; !ok {
+		t.Error("expected the Ingress in the prod render")
+	}
+	if !strings.Contains(prodOut["templates/cm.yaml"], `replicas: "3"`) {
+		t.Errorf("expected prod values to be applied:\n%s", prodOut["templates/cm.yaml"])
+	}
+
+	if _, err := executeCmd(t, "diff", chart, "--values", dev, "--values", prod, "--color=false"); err != nil {
+		t.Errorf("values diff failed: %v", err)
+	}
+	if _, err := executeCmd(t, "diff", chart, "--values", dev); err == nil {
+		t.Error("expected an error for a single --values")
+	}
+	if _, err := executeCmd(t, "diff", chart, chart, "--values", dev, "--values", prod); err == nil {
+		t.Error("expected an error for two directories with --values")
+	}
+}
+
 // ── TestGenerateCmd_HasDryRunFlag ─────────────────────────────────────────────
 
 // ── TestGenerateCmd_CloudProviderValidation ───────────────────────────────────
@@ -454,6 +583,178 @@ func TestGenerateCmd_AzureCloudProviderAccepted(t *testing.T) {
 	}
 }
 
+func TestGenerateCmd_DeckhouseCloudProvidersAccepted(t *testing.T) {
+	for _, provider := range []string{"yandex", "vk", "openstack"} {
+		tmpDir := t.TempDir()
+		_, err := executeCmd(t,
+			"generate",
+			"--file", tmpDir,
+			"--chart-name", "test",
+			"--cloud-provider", provider,
+			"--spot",
+			"--dry-run",
+		)
+		if err != nil && strings.Contains(err.Error(), "unknown cloud provider") {
+			t.Errorf("%s should be accepted as cloud provider, got: %v", provider, err)
+		}
+	}
+}
+
+func TestGenerateCmd_FixGracefulShutdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "--file", tmpDir, "--output", outDir, "--chart-name", "app", "--fix", "graceful-shutdown"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	tmpl, err := os.ReadFile(filepath.Join(outDir, "app", "templates", "web-deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tmpl), "terminationGracePeriodSeconds: {{ $shutdown.terminationGracePeriodSeconds }}") {
+		t.Errorf("expected a parameterized terminationGracePeriodSeconds, got:\n%s", tmpl)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "app", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), "gracefulShutdown:\n        enabled: true\n        preStopSleepSeconds: 5\n        terminationGracePeriodSeconds: 35") {
+		t.Errorf("expected gracefulShutdown values of a workload behind a Service, got:\n%s", values)
+	}
+
+	if _, err := executeCmd(t, "generate", "--file", tmpDir, "--chart-name", "app", "--fix", "everything", "--dry-run"); err == nil || !strings.Contains(err.Error(), "unknown fix") {
+		t.Errorf("expected an unknown fix to be rejected, got: %v", err)
+	}
+}
+
+func TestGenerateCmd_ApplyBestPractices(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        ports:
+        - containerPort: 8080
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "--file", tmpDir, "--output", outDir, "--chart-name", "app", "--apply-best-practices"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "app", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"replicas: 2",
+		"automountServiceAccountToken: false",
+		"readinessProbe:",
+		"runAsNonRoot: true",
+		"type: RuntimeDefault",
+		"memory: 128Mi",
+	} {
+		if !strings.Contains(string(values), want) {
+			t.Errorf("expected %q in values.yaml, got:\n%s", want, values)
+		}
+	}
+}
+
+func TestGenerateCmd_SynthesizeProbes(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        ports:
+        - name: http
+          containerPort: 8080
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "web.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "--file", tmpDir, "--output", outDir, "--chart-name", "app", "--synthesize-probes", "--probe-path", "/ready"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	tmpl, err := os.ReadFile(filepath.Join(outDir, "app", "templates", "web-deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tmpl), "$probePort := and $svc.probes $svc.probes.enabled") {
+		t.Errorf("expected probes gated by services.web.probes.enabled, got:\n%s", tmpl)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "app", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), "probes:\n      enabled: true\n      path: /ready\n      ports:\n        web: http") {
+		t.Errorf("expected probes values of the web service, got:\n%s", values)
+	}
+
+	if _, err := executeCmd(t, "generate", "--file", tmpDir, "--chart-name", "app", "--synthesize-probes", "--probe-path", "ready", "--dry-run"); err == nil || !strings.Contains(err.Error(), "invalid probe path") {
+		t.Errorf("expected a relative probe path to be rejected, got: %v", err)
+	}
+}
+
 func TestGenerateCmd_SpotDefaultsToAWS(t *testing.T) {
 	tmpDir := t.TempDir()
 	manifest := `apiVersion: apps/v1
@@ -499,99 +800,132 @@ func TestGenerateCmd_HasDryRunFlag(t *testing.T) {
 	}
 }
 
-// ── TestNamespaceResources_SkipsDefaultNPWhenAutoNPExists ────────────────────
-
-// TestNamespaceResources_SkipsDefaultNPWhenAutoNPExists verifies that when both
-// namespace-resources and auto-NetworkPolicy generators produce output for the
-// same group, the broad default NP (from namespace.go) is excluded in favor of
-// the fine-grained NP (from networkpolicy.go).
-func TestNamespaceResources_SkipsDefaultNPWhenAutoNPExists(t *testing.T) {
-	// Simulate namespace-resources templates (broad default NP)
-	nsTemplates := map[string]string{
-		"templates/frontend-resourcequota.yaml":          "kind: ResourceQuota",
-		"templates/frontend-limitrange.yaml":             "kind: LimitRange",
-		"templates/frontend-networkpolicy-default.yaml":  "kind: NetworkPolicy\npodSelector: {}",
-		"templates/backend-resourcequota.yaml":           "kind: ResourceQuota",
-		"templates/backend-limitrange.yaml":              "kind: LimitRange",
-		"templates/backend-networkpolicy-default.yaml":   "kind: NetworkPolicy\npodSelector: {}",
-	}
+// ── TestNamespaceResources_NetworkPolicies ───────────────────────────────────
 
-	// Simulate auto-NP templates (fine-grained per-service) — only for "frontend"
-	autoNP := map[string]string{
-		"templates/frontend-networkpolicy.yaml": "kind: NetworkPolicy\ningress:\n- from: [{podSelector: {matchLabels: {app: api}}}]",
-	}
+const networkPolicyTestManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: web:1.0
+        env:
+        - name: API_URL
+          value: http://api:8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+    targetPort: 8080
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              number: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  selector:
+    app: api
+  ports:
+  - port: 8080
+`
 
-	// Build autoNPGroups set (same logic as main.go)
-	autoNPGroups := make(map[string]struct{}, len(autoNP))
-	for path := range autoNP {
-		name := strings.TrimPrefix(path, "templates/")
-		name = strings.TrimSuffix(name, "-networkpolicy.yaml")
-		if name != path {
-			autoNPGroups[name] = struct{}{}
-		}
+// TestNamespaceResources_NetworkPolicies verifies that --namespace-resources
+// replaces the broad per-group NetworkPolicy with least-privilege policies
+// from the detected traffic, a default-deny policy and their values toggles.
+func TestNamespaceResources_NetworkPolicies(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(networkPolicyTestManifest), 0644); err != nil {
+		t.Fatal(err)
 	}
-
-	// Existing chart templates
-	chartTemplates := map[string]string{
-		"templates/deployment.yaml": "kind: Deployment",
+	outDir := t.TempDir()
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "shop", "-o", outDir, "--namespace-resources"); err != nil {
+		t.Fatalf("generate failed: %v", err)
 	}
+	chartDir := filepath.Join(outDir, "shop")
 
-	// Merge with filtering (same logic as main.go)
-	templates := make(map[string]string, len(chartTemplates)+len(nsTemplates)+len(autoNP))
-	for k, v := range chartTemplates {
-		templates[k] = v
-	}
-	for path, content := range nsTemplates {
-		if strings.HasSuffix(path, "-networkpolicy-default.yaml") {
-			groupName := strings.TrimPrefix(path, "templates/")
-			groupName = strings.TrimSuffix(groupName, "-networkpolicy-default.yaml")
-			if _, has := autoNPGroups[groupName]; has {
-				continue
-			}
-		}
-		templates[path] = content
-	}
-	for path, content := range autoNP {
-		templates[path] = content
+	if matches, _ := filepath.Glob(filepath.Join(chartDir, "templates", "*-networkpolicy-default.yaml")); len(matches) != 0 {
+		t.Errorf("expected no broad per-group NetworkPolicy, got %v", matches)
 	}
-
-	// Verify: frontend default NP should be excluded (fine-grained NP takes precedence)
-	if _, exists := templates["templates/frontend-networkpolicy-default.yaml"]; exists {
-		t.Error("frontend-networkpolicy-default.yaml should be excluded when fine-grained auto-NP exists")
+	if _, err := os.Stat(filepath.Join(chartDir, "templates", "networkpolicy-default-deny.yaml")); err != nil {
+		t.Errorf("expected the default-deny policy: %v", err)
 	}
-
-	// Verify: frontend fine-grained NP should be present
-	if _, exists := templates["templates/frontend-networkpolicy.yaml"]; !exists {
-		t.Error("frontend-networkpolicy.yaml (fine-grained) should be present")
+	if _, err := os.Stat(filepath.Join(chartDir, "templates", "namespace.yaml")); err != nil {
+		t.Errorf("expected the Namespace template with Pod Security labels: %v", err)
 	}
 
-	// Verify: backend default NP should be kept (no auto-NP for backend)
-	if _, exists := templates["templates/backend-networkpolicy-default.yaml"]; !exists {
-		t.Error("backend-networkpolicy-default.yaml should be kept when no auto-NP exists for that group")
+	web, err := os.ReadFile(filepath.Join(chartDir, "templates", "web-networkpolicy.yaml"))
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	// Verify: other namespace templates are not affected
-	if _, exists := templates["templates/frontend-resourcequota.yaml"]; !exists {
-		t.Error("frontend-resourcequota.yaml should not be affected by NP filtering")
+	for _, want := range []string{"From the ingress controller: Ingress web → Service web", "- port: 8080", "To Service api (env API_URL)"} {
+		if !strings.Contains(string(web), want) {
+			t.Errorf("expected %q in the web policy:\n%s", want, web)
+		}
 	}
-	if _, exists := templates["templates/frontend-limitrange.yaml"]; !exists {
-		t.Error("frontend-limitrange.yaml should not be affected by NP filtering")
+	api, err := os.ReadFile(filepath.Join(chartDir, "templates", "api-networkpolicy.yaml"))
+	if err != nil {
+		t.Fatal(err)
 	}
-	if _, exists := templates["templates/backend-resourcequota.yaml"]; !exists {
-		t.Error("backend-resourcequota.yaml should not be affected by NP filtering")
+	if !strings.Contains(string(api), "From the clients of Service api: web (env API_URL)") {
+		t.Errorf("expected ingress to api from web only:\n%s", api)
 	}
 
-	// Verify: original chart templates preserved
-	if _, exists := templates["templates/deployment.yaml"]; !exists {
-		t.Error("original chart templates should be preserved")
+	values, err := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	// Verify total count: 1 (deployment) + 2 (frontend quota+limit) + 3 (backend quota+limit+defaultNP) + 1 (frontend auto-NP) = 7
-	expected := 7
-	if len(templates) != expected {
-		t.Errorf("expected %d templates total, got %d", expected, len(templates))
-		for k := range templates {
-			t.Logf("  template: %s", k)
+	for _, want := range []string{"namespace:\n  create: false\n  limitRange:\n    enabled: true", "  podSecurity:\n    audit: baseline\n    enforce: baseline\n    warn: baseline", "networkPolicies:\n  defaultDeny:\n    enabled: true", "    api:\n      enabled: true", "    web:\n      enabled: true"} {
+		if !strings.Contains(string(values), want) {
+			t.Errorf("expected %q in values:\n%s", want, values)
 		}
 	}
 }
@@ -712,35 +1046,1110 @@ spec:
 	}
 }
 
-// ── TestGenerateCmd_MonorepoKustomizeConflict ─────────────────────────────────
+// ── TestGenerateCmd_ClusterScopeStrategyFlag ──────────────────────────────────
 
-func TestGenerateCmd_MonorepoKustomizeConflict(t *testing.T) {
+func TestGenerateCmd_ClusterScopeStrategyFlag(t *testing.T) {
 	tmpDir := t.TempDir()
+	manifest := `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+rules:
+- apiGroups: [""]
+  resources: [pods]
+  verbs: [get]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  key: value
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "resources.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
 	_, err := executeCmd(t,
 		"generate",
 		"--file", tmpDir,
 		"--chart-name", "test",
-		"--monorepo",
-		"--kustomize",
+		"--output", outDir,
+		"--cluster-scope-strategy", "subchart",
+	)
+	if err != nil {
+		t.Fatalf("expected no error for --cluster-scope-strategy subchart, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "test", "charts", "cluster-resources", "Chart.yaml")); err != nil {
+		t.Errorf("expected the cluster-resources subchart: %v", err)
+	}
+
+	_, err = executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--mode", "separate",
+		"--cluster-scope-strategy", "subchart",
 		"--dry-run",
 	)
-	if err == nil {
-		t.Fatal("expected error when both --monorepo and --kustomize are set, got nil")
+	if err == nil || !strings.Contains(err.Error(), "only supported in universal mode") {
+		t.Errorf("expected subchart to be rejected in separate mode, got: %v", err)
 	}
-	if !strings.Contains(err.Error(), "mutually exclusive") {
-		t.Errorf("expected error to mention 'mutually exclusive', got: %v", err)
+
+	_, err = executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--cluster-scope-strategy", "invalid",
+		"--dry-run",
+	)
+	if err == nil || !strings.Contains(err.Error(), "unknown cluster scope strategy") {
+		t.Errorf("expected error to mention 'unknown cluster scope strategy', got: %v", err)
 	}
 }
 
-// ── TestGenerateCmd_PostRendererFlag ──────────────────────────────────────────
+// ── TestGenerateCmd_OnDuplicateFlag ───────────────────────────────────────────
 
-func TestGenerateCmd_PostRendererFlag(t *testing.T) {
-	cmd := newGenerateCmd()
-	flag := cmd.Flags().Lookup("post-renderer")
-	if flag == nil {
-		t.Fatal("Expected --post-renderer flag on generate command")
+func TestGenerateCmd_OnDuplicateFlag(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  key: value
+`
+	for _, name := range []string{"base.yaml", "overlay.yaml"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
-	if flag.DefValue != "false" {
-		t.Errorf("Expected default value 'false', got '%s'", flag.DefValue)
+
+	_, err := executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--dry-run",
+	)
+	if err != nil {
+		t.Fatalf("expected duplicates to be resolved by default, got: %v", err)
+	}
+
+	_, err = executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--on-duplicate", "error",
+		"--dry-run",
+	)
+	if err == nil || !strings.Contains(err.Error(), "duplicate resources in the input") {
+		t.Errorf("expected a duplicate error with --on-duplicate error, got: %v", err)
+	}
+
+	base := filepath.Join(tmpDir, "base.yaml")
+	_, err = executeCmd(t,
+		"generate",
+		"--file", base,
+		"--file", base,
+		"--chart-name", "test",
+		"--on-duplicate", "error",
+		"--dry-run",
+	)
+	if err != nil {
+		t.Errorf("expected a file passed twice to be read once, got: %v", err)
+	}
+
+	_, err = executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--on-duplicate", "newest",
+		"--dry-run",
+	)
+	if err == nil || !strings.Contains(err.Error(), "unknown duplicate policy") {
+		t.Errorf("expected error to mention 'unknown duplicate policy', got: %v", err)
+	}
+}
+
+// ── TestGenerateCmd_MonorepoKustomizeConflict ─────────────────────────────────
+
+func TestGenerateCmd_MonorepoKustomizeConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--monorepo",
+		"--kustomize",
+		"--dry-run",
+	)
+	if err == nil {
+		t.Fatal("expected error when both --monorepo and --kustomize are set, got nil")
+	}
+	if !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("expected error to mention 'mutually exclusive', got: %v", err)
+	}
+}
+
+// ── TestGenerateCmd_PostRendererFlag ──────────────────────────────────────────
+
+func TestGenerateCmd_PostRendererFlag(t *testing.T) {
+	cmd := newGenerateCmd()
+	flag := cmd.Flags().Lookup("post-renderer")
+	if flag == nil {
+		t.Fatal("Expected --post-renderer flag on generate command")
+	}
+	if flag.DefValue != "false" {
+		t.Errorf("Expected default value 'false', got '%s'", flag.DefValue)
+	}
+}
+
+// ── TestGenerateCmd_LogFlags ──────────────────────────────────────────────────
+
+func TestGenerateCmd_InvalidLogFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--log-format", "xml",
+	)
+	if err == nil {
+		t.Fatal("expected error for unknown --log-format, got nil")
+	}
+	if !strings.Contains(err.Error(), "log format") {
+		t.Errorf("expected error to mention 'log format', got: %v", err)
+	}
+}
+
+func TestGenerateCmd_InvalidLogLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--log-level", "trace",
+	)
+	if err == nil {
+		t.Fatal("expected error for unknown --log-level, got nil")
+	}
+	if !strings.Contains(err.Error(), "log level") {
+		t.Errorf("expected error to mention 'log level', got: %v", err)
+	}
+}
+
+// ── TestGenerateCmd_GroupBy ──────────────────────────────────────────────────
+
+func TestGenerateCmd_GroupByInvalid(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_, err := executeCmd(t, "generate", "--file", tmpDir, "--chart-name", "test", "--group-by", "color")
+	if err == nil || !strings.Contains(err.Error(), "unknown grouping strategy") {
+		t.Errorf("expected unknown grouping strategy error, got: %v", err)
+	}
+
+	_, err = executeCmd(t, "generate", "--file", tmpDir, "--chart-name", "test", "--group-by", "manual")
+	if err == nil || !strings.Contains(err.Error(), "--groups-file") {
+		t.Errorf("expected --group-by manual to require --groups-file, got: %v", err)
+	}
+}
+
+func TestGenerateCmd_GroupsFile(t *testing.T) {
+	inputDir := t.TempDir()
+	manifests := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: shop
+  labels:
+    app: web
+data:
+  key: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: worker-config
+  namespace: shop
+  labels:
+    app: worker
+data:
+  key: value
+`
+	if err := os.WriteFile(filepath.Join(inputDir, "configmaps.yaml"), []byte(manifests), 0644); err != nil {
+		t.Fatal(err)
+	}
+	groupsFile := filepath.Join(t.TempDir(), "groups.yaml")
+	groups := `groups:
+  storefront:
+    - kind: ConfigMap
+      name: web-*
+`
+	if err := os.WriteFile(groupsFile, []byte(groups), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	_, err := executeCmd(t,
+		"generate",
+		"--file", inputDir,
+		"--chart-name", "test",
+		"--output", outDir,
+		"--group-by", "manual",
+		"--groups-file", groupsFile,
+	)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pinned ConfigMap moves to storefront; the other keeps its detected service.
+	for _, service := range []string{"storefront:", "worker:"} {
+		if !strings.Contains(string(values), service) {
+			t.Errorf("expected service %q in values.yaml:\n%s", service, values)
+		}
+	}
+	if strings.Contains(string(values), "\n  web:") {
+		t.Errorf("pinned resource should not keep its detected service:\n%s", values)
+	}
+}
+
+// ── TestGenerateCmd_PluginFlags ───────────────────────────────────────────────
+
+func TestGenerateCmd_MissingPlugin(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := executeCmd(t,
+		"generate",
+		"--file", tmpDir,
+		"--chart-name", "test",
+		"--plugin", filepath.Join(tmpDir, "no-such-plugin"),
+	)
+	if err == nil {
+		t.Fatal("expected error for missing plugin executable, got nil")
+	}
+	if !strings.Contains(err.Error(), "loading plugins") {
+		t.Errorf("expected error to mention 'loading plugins', got: %v", err)
+	}
+}
+
+func TestAnalyzeCmd_PluginDirFlag(t *testing.T) {
+	cmd := newAnalyzeCmd()
+	for _, name := range []string{"plugin", "plugin-dir"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected --%s flag on analyze command", name)
+		}
+	}
+}
+
+// ── TestAnalyzeCmd_ClusterSource ──────────────────────────────────────────────
+
+// newFakeCluster starts an API server serving a Deployment and Service in the
+// prod namespace and returns a kubeconfig pointing at it.
+func newFakeCluster(t *testing.T) string {
+	t.Helper()
+
+	labels := map[string]interface{}{"app": "web"}
+	responses := map[string]interface{}{
+		"/api/v1": map[string]interface{}{"resources": []interface{}{
+			map[string]interface{}{"name": "services", "kind": "Service", "namespaced": true, "verbs": []string{"list"}},
+		}},
+		"/apis": map[string]interface{}{"groups": []interface{}{
+			map[string]interface{}{
+				"name":             "apps",
+				"versions":         []interface{}{map[string]interface{}{"groupVersion": "apps/v1", "version": "v1"}},
+				"preferredVersion": map[string]interface{}{"groupVersion": "apps/v1", "version": "v1"},
+			},
+		}},
+		"/apis/apps/v1": map[string]interface{}{"resources": []interface{}{
+			map[string]interface{}{"name": "deployments", "kind": "Deployment", "namespaced": true, "verbs": []string{"list"}},
+		}},
+		"/api/v1/namespaces/prod/services": map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "web", "namespace": "prod", "labels": labels},
+				"spec": map[string]interface{}{
+					"selector": labels,
+					"ports":    []interface{}{map[string]interface{}{"port": 80}},
+				},
+			},
+		}},
+		"/apis/apps/v1/namespaces/prod/deployments": map[string]interface{}{"items": []interface{}{
+			map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "web", "namespace": "prod", "labels": labels},
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{"matchLabels": labels},
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"labels": labels},
+						"spec": map[string]interface{}{"containers": []interface{}{
+							map[string]interface{}{"name": "web", "image": "nginx:1.25"},
+						}},
+					},
+				},
+			},
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+users:
+- name: test
+  user:
+    token: test-token
+`, server.URL)
+	if err := os.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return kubeconfig
+}
+
+func TestAnalyzeCmd_ClusterSource(t *testing.T) {
+	kubeconfig := newFakeCluster(t)
+	tmpDir := t.TempDir()
+	reportFile := filepath.Join(tmpDir, "report.json")
+	graphFile := filepath.Join(tmpDir, "graph.dot")
+
+	_, err := executeCmd(t, "analyze",
+		"-s", "cluster",
+		"-n", "prod",
+		"--kubeconfig", kubeconfig,
+		"--output-format", "json",
+		"-o", reportFile,
+		"--graph", graphFile,
+	)
+	if err != nil {
+		t.Fatalf("analyze -s cluster failed: %v", err)
+	}
+
+	report, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("expected report file: %v", err)
+	}
+	if !json.Valid(report) {
+		t.Errorf("expected JSON report, got:\n%s", report)
+	}
+
+	graph, err := os.ReadFile(graphFile)
+	if err != nil {
+		t.Fatalf("expected graph file: %v", err)
+	}
+	if !strings.Contains(string(graph), "digraph") || !strings.Contains(string(graph), "->") {
+		t.Errorf("expected graph with the service → deployment edge, got:\n%s", graph)
+	}
+}
+
+func TestAnalyzeCmd_InvalidSource(t *testing.T) {
+	_, err := executeCmd(t, "analyze", "-s", "gitops")
+	if err == nil || !strings.Contains(err.Error(), "invalid source") {
+		t.Errorf("expected invalid source error, got %v", err)
+	}
+}
+
+// ── TestAnalyzeCmd_Baseline ───────────────────────────────────────────────────
+
+func TestAnalyzeCmd_Baseline(t *testing.T) {
+	tmpDir := t.TempDir()
+	deployment := func(name string) string {
+		return `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ` + name + `
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: ` + name + `
+  template:
+    metadata:
+      labels:
+        app: ` + name + `
+    spec:
+      containers:
+      - name: ` + name + `
+        image: nginx:latest
+`
+	}
+	manifestPath := filepath.Join(tmpDir, "web.yaml")
+	if err := os.WriteFile(manifestPath, []byte(deployment("web")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	baseline := filepath.Join(tmpDir, "baseline.yaml")
+	reportFile := filepath.Join(tmpDir, "report.txt")
+
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "-o", reportFile, "--baseline", baseline); err == nil || !strings.Contains(err.Error(), "--update-baseline") {
+		t.Errorf("expected a missing baseline to point to --update-baseline, got %v", err)
+	}
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "-o", reportFile, "--baseline", baseline, "--update-baseline"); err != nil {
+		t.Fatalf("analyze --update-baseline failed: %v", err)
+	}
+	data, err := os.ReadFile(baseline)
+	if err != nil || !strings.Contains(string(data), "rule: BP-HA-001") {
+		t.Fatalf("expected the baseline to record BP-HA-001, got %v:\n%s", err, data)
+	}
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "-o", reportFile, "--baseline", baseline); err != nil {
+		t.Errorf("known violations should not fail the analysis: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "api.yaml"), []byte(deployment("api")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "analyze", "-f", tmpDir, "-o", reportFile, "--baseline", baseline); err == nil || !strings.Contains(err.Error(), "new violation(s) not in baseline") {
+		t.Errorf("expected the violations of a new Deployment to fail the analysis, got %v", err)
+	}
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "--update-baseline"); err == nil || !strings.Contains(err.Error(), "requires --baseline") {
+		t.Errorf("expected --update-baseline without --baseline to be rejected, got %v", err)
+	}
+}
+
+// ── TestAnalyzeCmd_FailOn ─────────────────────────────────────────────────────
+
+func TestAnalyzeCmd_FailOn(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+`
+	manifestPath := filepath.Join(tmpDir, "web.yaml")
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reportFile := filepath.Join(tmpDir, "report.txt")
+
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "-o", reportFile); err != nil {
+		t.Errorf("analyze without --fail-on should not fail: %v", err)
+	}
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "-o", reportFile, "--fail-on", "critical"); err != nil {
+		t.Errorf("no critical violations expected: %v", err)
+	}
+	_, err := executeCmd(t, "analyze", "-f", manifestPath, "-o", reportFile, "--fail-on", "error")
+	if err == nil || exitCode(err) != exitPolicy || !strings.Contains(err.Error(), "at or above error") {
+		t.Errorf("expected a policy failure (exit code %d), got %v (exit code %d)", exitPolicy, err, exitCode(err))
+	}
+
+	_, err = executeCmd(t, "analyze", "-f", filepath.Join(tmpDir, "missing"), "--fail-on", "error")
+	if exitCode(err) != exitExtraction {
+		t.Errorf("expected an extraction failure (exit code %d), got %v (exit code %d)", exitExtraction, err, exitCode(err))
+	}
+	_, err = executeCmd(t, "analyze", "-f", manifestPath, "--fail-on", "info")
+	if err == nil || exitCode(err) != exitFailure {
+		t.Errorf("expected an invalid --fail-on to be rejected with exit code %d, got %v", exitFailure, err)
+	}
+}
+
+// ── TestAnalyzeCmd_SARIFOutput ────────────────────────────────────────────────
+
+func TestAnalyzeCmd_SARIFOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:latest
+`
+	manifestPath := filepath.Join(tmpDir, "web.yaml")
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reportFile := filepath.Join(tmpDir, "report.sarif")
+
+	if _, err := executeCmd(t, "analyze", "-f", manifestPath, "--output-format", "sarif", "-o", reportFile); err != nil {
+		t.Fatalf("analyze --output-format sarif failed: %v", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("expected SARIF report: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected a single SARIF 2.1.0 run, got %+v", log)
+	}
+	if len(log.Runs[0].Results) == 0 {
+		t.Fatal("expected findings for a deployment without limits or probes")
+	}
+	if uri := log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != filepath.ToSlash(manifestPath) {
+		t.Errorf("expected result location %q, got %q", manifestPath, uri)
+	}
+}
+
+// ── TestGraphCmd ──────────────────────────────────────────────────────────────
+
+func TestNewGraphCmd_Flags(t *testing.T) {
+	cmd := newGraphCmd()
+
+	if f := cmd.Flags().Lookup("format"); f == nil || f.DefValue != "dot" {
+		t.Fatalf("expected 'format' flag defaulting to dot, got %+v", f)
+	}
+	for _, name := range []string{"file", "output", "source", "selector", "kubeconfig", "context", "namespace", "namespaces", "include-kinds", "exclude-kinds", "recursive", "plugin", "plugin-dir"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected flag %q to be registered on graph command", name)
+		}
+	}
+}
+
+func writeGraphManifest(t *testing.T) string {
+	t.Helper()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+`
+	path := filepath.Join(t.TempDir(), "web.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGraphCmd_Formats(t *testing.T) {
+	manifestPath := writeGraphManifest(t)
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"dot", []string{"digraph", `subgraph "cluster_web"`, `label="label_selector"`}},
+		{"mermaid", []string{"flowchart LR", `subgraph svc_web["web"]`, "Service_default_web -->|label_selector| Deployment_default_web"}},
+		{"json", []string{`"id": "Deployment/default/web"`, `"type": "label_selector"`, `"name": "web"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outFile := filepath.Join(t.TempDir(), "graph."+tt.format)
+			if _, err := executeCmd(t, "graph", "-f", manifestPath, "--format", tt.format, "-o", outFile); err != nil {
+				t.Fatalf("graph --format %s failed: %v", tt.format, err)
+			}
+			data, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("expected graph output file: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("expected %q in %s output:\n%s", want, tt.format, data)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphCmd_InvalidFormat(t *testing.T) {
+	_, err := executeCmd(t, "graph", "-f", writeGraphManifest(t), "--format", "svg")
+	if err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("expected invalid format error, got %v", err)
+	}
+}
+
+func TestGraphCmd_MissingFileFlag(t *testing.T) {
+	_, err := executeCmd(t, "graph")
+	if err == nil || !strings.Contains(err.Error(), "at least one path is required") {
+		t.Errorf("expected missing path error, got %v", err)
+	}
+}
+
+func TestGenerateCmd_Stdin(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: piped-config
+    namespace: default
+    resourceVersion: "42"
+  data:
+    key: value
+`
+	root := newRootCmd()
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetIn(strings.NewReader(manifest))
+	outDir := t.TempDir()
+	root.SetArgs([]string{"generate", "-f", "-", "--chart-name", "test", "-o", outDir})
+
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("generate from stdin failed: %v\n%s", err, buf.String())
+	}
+	matches, _ := filepath.Glob(filepath.Join(outDir, "test", "templates", "*configmap*.yaml"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one ConfigMap template from stdin, got %v", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "resourceVersion") {
+		t.Errorf("expected runtime metadata to be stripped:\n%s", data)
+	}
+}
+
+func TestGenerateCmd_StdinInteractive(t *testing.T) {
+	root := newRootCmd()
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetIn(strings.NewReader("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\n"))
+	outDir := t.TempDir()
+	root.SetArgs([]string{"generate", "-f", "-", "--interactive", "--chart-name", "test", "-o", outDir})
+
+	err := root.ExecuteContext(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cannot be used with -f -") {
+		t.Fatalf("expected -f - with --interactive to be rejected, got %v", err)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		t.Errorf("expected nothing to be written, got %d entries", len(entries))
+	}
+}
+
+func TestGenerateCmd_ChartMetadata(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  key: value\n"
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	metaFile := filepath.Join(dir, "meta.yaml")
+	if err := os.WriteFile(metaFile, []byte("home: https://file.example.com\nicon: https://example.com/icon.png\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	_, err := executeCmd(t, "generate", "-f", filepath.Join(dir, "cm.yaml"), "--chart-name", "test", "-o", outDir,
+		"--chart-metadata", metaFile,
+		"--home", "https://example.com",
+		"--maintainer", "Platform team <platform@example.com>",
+		"--keywords", "web",
+		"--kube-version", ">=1.25.0-0",
+		"--chart-annotation", "artifacthub.io/license=Apache-2.0",
+	)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "test", "Chart.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chart struct {
+		Home        string            `json:"home"`
+		Icon        string            `json:"icon"`
+		Keywords    []string          `json:"keywords"`
+		KubeVersion string            `json:"kubeVersion"`
+		Annotations map[string]string `json:"annotations"`
+		Maintainers []struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"maintainers"`
+	}
+	if err := yaml.Unmarshal(data, &chart); err != nil {
+		t.Fatalf("Chart.yaml is not valid YAML: %v\n%s", err, data)
+	}
+	if chart.Home != "https://example.com" {
+		t.Errorf("expected --home to override the metadata file, got %q", chart.Home)
+	}
+	if chart.Icon != "https://example.com/icon.png" {
+		t.Errorf("expected icon from the metadata file, got %q", chart.Icon)
+	}
+	if chart.KubeVersion != ">=1.25.0-0" || chart.Annotations["artifacthub.io/license"] != "Apache-2.0" {
+		t.Errorf("unexpected Chart.yaml:\n%s", data)
+	}
+	if len(chart.Maintainers) != 1 || chart.Maintainers[0].Email != "platform@example.com" {
+		t.Errorf("unexpected maintainers:\n%s", data)
+	}
+	if !strings.Contains(strings.Join(chart.Keywords, ","), "web") {
+		t.Errorf("expected keyword web in %v", chart.Keywords)
+	}
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--chart-annotation", "novalue"); err == nil {
+		t.Error("expected an error for an annotation without a value")
+	}
+}
+
+func TestGenerateCmd_ImageRewrite(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+`
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	_, err := executeCmd(t, "generate", "-f", filepath.Join(dir, "deploy.yaml"), "--chart-name", "test", "-o", outDir,
+		"--image-rewrite", "docker.io=registry.example.com/mirror")
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "repository: registry.example.com/mirror/nginx") {
+		t.Errorf("expected the rewritten repository in values.yaml:\n%s", data)
+	}
+
+	_, err = executeCmd(t, "generate", "-f", filepath.Join(dir, "deploy.yaml"), "--chart-name", "test", "-o", t.TempDir(),
+		"--image-rewrite", "docker.io")
+	if err == nil || !strings.Contains(err.Error(), "old=new") {
+		t.Errorf("expected an invalid rule error, got %v", err)
+	}
+}
+
+func TestGenerateCmd_HA(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	manifest = strings.Replace(manifest, "replicas: 1", "replicas: 3", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--ha"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	pdb, err := os.ReadFile(filepath.Join(outDir, "test", "templates", "web-pdb.yaml"))
+	if err != nil {
+		t.Fatalf("expected a PDB template: %v", err)
+	}
+	if !strings.Contains(string(pdb), "{{- if .enabled }}") {
+		t.Errorf("PDB template should be gated by pdb.enabled:\n%s", pdb)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), "pdb:\n      enabled: true\n      maxUnavailable: 1") {
+		t.Errorf("expected pdb values for web:\n%s", values)
+	}
+}
+
+func TestGenerateCmd_Autoscaling(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1) + `        resources:
+          requests:
+            cpu: 100m
+`
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--autoscaling"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	hpa, err := os.ReadFile(filepath.Join(outDir, "test", "templates", "web-hpa.yaml"))
+	if err != nil {
+		t.Fatalf("expected an HPA template: %v", err)
+	}
+	if !strings.Contains(string(hpa), "{{- with $svc.autoscaling }}") {
+		t.Errorf("HPA template should be gated by autoscaling.enabled:\n%s", hpa)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"autoscaling:\n      enabled: true", "averageUtilization: 80", "maxReplicas: 3"} {
+		if !strings.Contains(string(values), want) {
+			t.Errorf("expected %q in values:\n%s", want, values)
+		}
+	}
+}
+
+func TestGenerateCmd_ServiceAccounts(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--service-accounts"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	for _, name := range []string{"web-serviceaccount.yaml", "web-role.yaml", "web-rolebinding.yaml"} {
+		if _, err := os.Stat(filepath.Join(outDir, "test", "templates", name)); err != nil {
+			t.Errorf("expected template %s: %v", name, err)
+		}
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), "serviceAccountName: web") {
+		t.Errorf("expected the Deployment to use the web ServiceAccount:\n%s", values)
+	}
+}
+
+func TestGenerateCmd_PriorityClasses(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--priority-classes"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "test", "templates", "priorityclass-testStandard.yaml")); err != nil {
+		t.Errorf("expected the standard PriorityClass template: %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), "priorityClassName: test-standard") {
+		t.Errorf("expected the Deployment to use the standard PriorityClass:\n%s", values)
+	}
+}
+
+func TestGenerateCmd_DeckhouseModuleHooks(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1) + `        envFrom:
+        - secretRef:
+            name: web-env
+`
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--deckhouse-module"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	hook, err := os.ReadFile(filepath.Join(outDir, "test", "hooks", "watch_secrets.go"))
+	if err != nil {
+		t.Fatalf("expected a hook watching the Secret: %v", err)
+	}
+	if !strings.Contains(string(hook), `MatchNames: []string{"web-env"}`) {
+		t.Errorf("expected the hook to watch Secret web-env:\n%s", hook)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "test", "hooks", "watch_secrets_test.go")); err != nil {
+		t.Errorf("expected the hook test: %v", err)
+	}
+}
+
+func TestGenerateCmd_Werf(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--werf"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	werfYAML, err := os.ReadFile(filepath.Join(outDir, "test", "werf.yaml"))
+	if err != nil {
+		t.Fatalf("expected werf.yaml in the project: %v", err)
+	}
+	if !strings.Contains(string(werfYAML), "image: web\nfrom: nginx:1.25\n") {
+		t.Errorf("expected an image for service web:\n%s", werfYAML)
+	}
+	deployment, err := os.ReadFile(filepath.Join(outDir, "test", ".helm", "templates", "web-deployment.yaml"))
+	if err != nil {
+		t.Fatalf("expected the chart in .helm/: %v", err)
+	}
+	if !strings.Contains(string(deployment), "$.Values.werf.image") {
+		t.Errorf("expected the Deployment to run the werf image:\n%s", deployment)
+	}
+}
+
+func TestGenerateCmd_TopologySpread(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	manifest = strings.Replace(manifest, "replicas: 1", "replicas: 3", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--topology-spread"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"topologySpreadConstraints:", "topologyKey: topology.kubernetes.io/zone", "topologyKey: kubernetes.io/hostname", "whenUnsatisfiable: ScheduleAnyway"} {
+		if !strings.Contains(string(values), want) {
+			t.Errorf("expected %q in values:\n%s", want, values)
+		}
+	}
+}
+
+func TestGenerateCmd_TenantsFile(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tenantsFile := filepath.Join(t.TempDir(), "tenants.yaml")
+	tenants := "tenants:\n  - name: acme\n    quota:\n      pods: 20\n  - name: globex\n"
+	if err := os.WriteFile(tenantsFile, []byte(tenants), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--tenants-file", tenantsFile); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"name: acme", "namespace: test-acme", "pods: 20", "name: globex"} {
+		if !strings.Contains(string(values), want) {
+			t.Errorf("expected %q in values:\n%s", want, values)
+		}
+	}
+	for _, name := range []string{"values-tenant-acme.yaml", "values-tenant-globex.yaml", "templates/tenant-resourcequotas.yaml"} {
+		if _, err := os.Stat(filepath.Join(outDir, "test", name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", t.TempDir(), "--tenants-file", filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing tenants file")
+	}
+}
+
+func TestGenerateCmd_NamespacePreset(t *testing.T) {
+	dir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", outDir, "--namespace-resources", "--namespace-preset", "large"); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	quotas, err := filepath.Glob(filepath.Join(outDir, "test", "templates", "*-resourcequota.yaml"))
+	if err != nil || len(quotas) == 0 {
+		t.Fatalf("expected a ResourceQuota template: %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(outDir, "test", "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`requests.cpu: "4"`, `limits.memory: 16Gi`, `pods: "50"`} {
+		if !strings.Contains(string(values), want) {
+			t.Errorf("expected %q in the ResourceQuota values:\n%s", want, values)
+		}
+	}
+
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", t.TempDir(), "--namespace-preset", "small"); err == nil || !strings.Contains(err.Error(), "requires --namespace-resources") {
+		t.Errorf("expected --namespace-preset to require --namespace-resources, got %v", err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", dir, "--chart-name", "test", "-o", t.TempDir(), "--namespace-resources", "--namespace-preset", "huge"); err == nil {
+		t.Error("expected an error for an unknown preset")
 	}
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/plugin"
+)
+
+// loadPlugins loads plugin executables given via --plugin and every executable
+// found in --plugin-dir directories. Errors raised later by plugin detectors
+// and checkers (whose interfaces cannot return errors) are passed to onError.
+func loadPlugins(ctx context.Context, paths, dirs []string, onError func(error)) ([]*plugin.Plugin, error) {
+	if len(paths) == 0 && len(dirs) == 0 {
+		return nil, nil
+	}
+
+	plugins, err := plugin.LoadAll(ctx, paths, dirs, plugin.DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("loading plugins: %w", err)
+	}
+	for _, p := range plugins {
+		p.OnError = onError
+	}
+	return plugins, nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// unrecordedFlags do not affect the generated chart and are left out of the
+// generation record; regenerate sets the output directory itself. Registry
+// credentials and the proxy are secrets or specific to the environment.
+var unrecordedFlags = map[string]bool{
+	"help":           true,
+	"output":         true,
+	"dry-run":        true,
+	"output-format":  true,
+	"summary-json":   true,
+	"verbose":        true,
+	"log-format":     true,
+	"log-level":      true,
+	"from-snapshot":  true,
+	"watch":          true,
+	"watch-debounce": true,
+	"registry-auth":  true,
+	"proxy":          true,
+}
+
+// pathFlags hold file paths, recorded as absolute paths so that a chart can
+// be regenerated from any directory.
+var pathFlags = map[string]bool{
+	"file":            true,
+	"helm-values":     true,
+	"values":          true,
+	"groups-file":     true,
+	"tenants-file":    true,
+	"chart-metadata":  true,
+	"config":          true,
+	"kubeconfig":      true,
+	"ssh-key":         true,
+	"plugin":          true,
+	"plugin-dir":      true,
+	"registry-config": true,
+	"ca-file":         true,
+}
+
+// namedPathFlags take a built-in name or a file path; only paths are
+// recorded as absolute paths.
+var namedPathFlags = map[string]func(string) bool{
+	"namespace-preset": generator.IsNamespacePreset,
+}
+
+// generationOptions returns the generate flags to record in the generation
+// record: every flag with its current value, a list for repeatable flags.
+func generationOptions(cmd *cobra.Command) map[string]interface{} {
+	options := make(map[string]interface{})
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if unrecordedFlags[f.Name] {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			values := append([]string{}, slice.GetSlice()...)
+			if pathFlags[f.Name] {
+				values = absPaths(values)
+			}
+			options[f.Name] = values
+			return
+		}
+		value := f.Value.String()
+		isName := namedPathFlags[f.Name]
+		if (pathFlags[f.Name] || (isName != nil && !isName(value))) && value != "" {
+			value = absPaths([]string{value})[0]
+		}
+		options[f.Name] = value
+	})
+	return options
+}
+
+// absPaths makes paths absolute, leaving "-" (stdin) as is.
+func absPaths(paths []string) []string {
+	abs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p != "-" {
+			if a, err := filepath.Abs(p); err == nil {
+				p = a
+			}
+		}
+		abs = append(abs, p)
+	}
+	return abs
+}
+
+// needsSourceSnapshot reports whether the resources of a generation cannot be
+// read again and must be recorded with the chart.
+func needsSourceSnapshot(opts generateOptions, sourceType types.Source) bool {
+	if opts.fromSnapshot != "" || sourceType == types.SourceCluster {
+		return true
+	}
+	for _, p := range opts.paths {
+		if p == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// generationRecord describes the current generation for dhg regenerate. The
+// input files are hashed unless the resources come from a source snapshot;
+// the chart version is recorded as resolved (--chart-version auto).
+func generationRecord(opts generateOptions, resources []*types.ProcessedResource, snapshot bool) (*generator.GenerationRecord, error) {
+	options := make(map[string]interface{}, len(opts.options))
+	for name, value := range opts.options {
+		options[name] = value
+	}
+	options["chart-version"] = opts.chartVersion
+
+	var inputs []string
+	if !snapshot {
+		inputs = append(inputs, opts.paths...)
+	}
+	inputs = append(inputs, opts.helmValues...)
+	inputs = append(inputs, opts.valueFiles...)
+	for _, file := range []string{opts.groupsFile, opts.tenantsFile, opts.chartMetadata, opts.configFile} {
+		if file != "" {
+			inputs = append(inputs, file)
+		}
+	}
+	if opts.namespacePreset != "" && !generator.IsNamespacePreset(opts.namespacePreset) {
+		inputs = append(inputs, opts.namespacePreset)
+	}
+	sources, err := generator.HashSourceFiles(inputs)
+	if err != nil {
+		return nil, err
+	}
+	generatedAt, err := sourceDateEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	return &generator.GenerationRecord{
+		DhgVersion:  version,
+		GeneratedAt: generatedAt,
+		Source:      opts.source,
+		Options:     options,
+		Sources:     sources,
+		Resources:   generator.ResourceInventory(resources),
+	}, nil
+}
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment
+// variable (https://reproducible-builds.org/specs/source-date-epoch/), or nil
+// when it is not set.
+func sourceDateEpoch() (*time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: expected seconds since the Unix epoch", epoch)
+	}
+	t := time.Unix(seconds, 0).UTC()
+	return &t, nil
+}
+
+func newRegenerateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "regenerate <chart-dir>",
+		Short: "Replay the generation of a chart from its generation record",
+		Long: `Replay the generation of a chart with the options recorded in
+<chart>/.dhg/generation.yaml. Resources extracted from a cluster or stdin are
+replayed from the snapshot in <chart>/.dhg/source.yaml; input files are read
+again, and the ones changed since the recorded generation are reported.
+
+Like "dhg generate", regenerate overwrites the generated files; use
+"dhg upgrade-chart" to keep manual edits.
+
+Examples:
+  dhg regenerate ./chart/myapp`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRegenerate(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+func runRegenerate(ctx context.Context, w io.Writer, chartDir string) error {
+	record, err := generator.ReadGenerationRecord(chartDir)
+	if err != nil {
+		return err
+	}
+	if record.Options["interactive"] == "true" {
+		return fmt.Errorf("%s was generated with --interactive; the selections made are not recorded", chartDir)
+	}
+	if record.DhgVersion != version {
+		fmt.Fprintf(w, "! chart was generated by dhg %s, regenerating with dhg %s\n", record.DhgVersion, version)
+	}
+
+	gen := newGenerateCmd()
+	names := make([]string, 0, len(record.Options))
+	for name := range record.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := setRecordedFlag(gen.Flags(), name, record.Options[name]); err != nil {
+			return fmt.Errorf("%s: %w", generator.GenerationRecordFile, err)
+		}
+	}
+
+	outputDir := filepath.Dir(chartDir)
+	if record.Options["werf"] == "true" {
+		outputDir = filepath.Dir(outputDir)
+	}
+	if err := gen.Flags().Set("output", outputDir); err != nil {
+		return err
+	}
+
+	if record.Snapshot != nil {
+		if err := record.VerifySnapshot(chartDir); err != nil {
+			return err
+		}
+		if err := gen.Flags().Set("from-snapshot", filepath.Join(chartDir, filepath.FromSlash(record.Snapshot.File))); err != nil {
+			return err
+		}
+	}
+	if len(record.Sources) > 0 {
+		// Hash the recorded inputs again, including files added to them.
+		current, err := generator.HashSourceFiles(recordedInputs(record))
+		if err != nil {
+			return err
+		}
+		for _, p := range generator.ChangedSources(record.Sources, current) {
+			fmt.Fprintf(w, "! source changed since the recorded generation: %s\n", p)
+		}
+	}
+
+	gen.SetContext(ctx)
+	return gen.RunE(gen, nil)
+}
+
+// recordedInputs returns the input paths recorded in the options of a
+// generation that still exist, as hashed by generationRecord.
+func recordedInputs(record *generator.GenerationRecord) []string {
+	var inputs []string
+	for _, name := range []string{"file", "helm-values", "groups-file", "tenants-file", "chart-metadata", "namespace-preset"} {
+		if name == "file" && record.Snapshot != nil {
+			continue
+		}
+		for _, p := range optionValues(record.Options[name]) {
+			if _, err := os.Stat(p); err == nil && p != "-" {
+				inputs = append(inputs, p)
+			}
+		}
+	}
+	return inputs
+}
+
+// setRecordedFlag sets a generate flag to its recorded value.
+func setRecordedFlag(flags *pflag.FlagSet, name string, value interface{}) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("option --%s is not supported by dhg %s", name, version)
+	}
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		if err := slice.Replace(optionValues(value)); err != nil {
+			return fmt.Errorf("option --%s: %w", name, err)
+		}
+		f.Changed = true
+		return nil
+	}
+	if err := flags.Set(name, fmt.Sprint(value)); err != nil {
+		return fmt.Errorf("option --%s: %w", name, err)
+	}
+	return nil
+}
+
+// optionValues returns a recorded option as a list of strings.
+func optionValues(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case []string:
+		return v
+	case nil:
+		return nil
+	}
+	return []string{fmt.Sprint(value)}
+}
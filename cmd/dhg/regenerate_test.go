@@ -0,0 +1,192 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func TestRegenerateCmd(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	chartDir := filepath.Join(outDir, "app")
+	manifestPath := filepath.Join(inputDir, "deploy.yaml")
+
+	if err := os.WriteFile(manifestPath, []byte(strings.Replace(upgradeTestManifest, "%s", "1.25", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app", "--include-kinds", "Deployment", "--include-schema"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	record, err := generator.ReadGenerationRecord(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Source != "file" || record.Snapshot != nil {
+		t.Errorf("record = %+v; want a file source without snapshot", record)
+	}
+	if files := optionValues(record.Options["file"]); len(files) != 1 || files[0] != inputDir {
+		t.Errorf("file = %v; want the absolute input directory", files)
+	}
+	if record.Options["include-schema"] != "true" || record.Options["chart-name"] != "app" {
+		t.Errorf("options = %v", record.Options)
+	}
+	if _, ok := record.Options["output"]; ok {
+		t.Error("--output should not be recorded")
+	}
+	if len(record.Sources) != 1 || record.Sources[0].Path != filepath.ToSlash(manifestPath) {
+		t.Errorf("sources = %+v", record.Sources)
+	}
+	if len(record.Resources) != 1 || record.Resources[0].Kind != "Deployment" || record.Resources[0].Service != "web" {
+		t.Errorf("resources = %+v", record.Resources)
+	}
+
+	schemaPath := filepath.Join(chartDir, "values.schema.json")
+	if err := os.Remove(schemaPath); err != nil {
+		t.Fatalf("--include-schema should generate %s: %v", schemaPath, err)
+	}
+	if err := os.WriteFile(manifestPath, []byte(strings.Replace(upgradeTestManifest, "%s", "1.27", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, "regenerate", chartDir)
+	if err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if !strings.Contains(out, "source changed since the recorded generation: "+filepath.ToSlash(manifestPath)) {
+		t.Errorf("changed source not reported:\n%s", out)
+	}
+	if _, err := os.Stat(schemaPath); err != nil {
+		t.Errorf("regenerate should replay --include-schema: %v", err)
+	}
+	values, err := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), `"1.27"`) {
+		t.Errorf("values.yaml not regenerated from the changed source:\n%s", values)
+	}
+}
+
+// chartFiles returns the files of a chart directory, .dhg/ included, by path
+// relative to it.
+func chartFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestGenerateCmd_Deterministic(t *testing.T) {
+	generate := func() map[string]string {
+		outDir := t.TempDir()
+		if _, err := executeCmd(t, "generate", "-f", "../../examples/05-full-stack", "-o", outDir, "--chart-name", "app"); err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		return chartFiles(t, filepath.Join(outDir, "app"))
+	}
+
+	first := generate()
+	if record, ok := first[filepath.FromSlash(generator.GenerationRecordFile)]; !ok || strings.Contains(record, "generatedAt") {
+		t.Fatalf("expected %s to be written without the time of the generation:\n%s", generator.GenerationRecordFile, record)
+	}
+	second := generate()
+	if len(second) != len(first) {
+		t.Fatalf("second run wrote %d files, first run %d", len(second), len(first))
+	}
+	for path, content := range first {
+		if second[path] != content {
+			t.Errorf("%s differs between runs:\n--- first\n%s\n--- second\n%s", path, content, second[path])
+		}
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	record := generate()[filepath.FromSlash(generator.GenerationRecordFile)]
+	if !strings.Contains(record, `generatedAt: "2023-11-14T22:13:20Z"`) {
+		t.Errorf("expected generatedAt from SOURCE_DATE_EPOCH:\n%s", record)
+	}
+}
+
+func TestRegenerateCmd_Snapshot(t *testing.T) {
+	outDir := t.TempDir()
+	chartDir := filepath.Join(outDir, "app")
+
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.WriteString(strings.Replace(upgradeTestManifest, "%s", "1.25", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	origStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = origStdin }()
+
+	if _, err := executeCmd(t, "generate", "-f", "-", "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	os.Stdin = origStdin
+
+	record, err := generator.ReadGenerationRecord(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Snapshot == nil {
+		t.Fatal("stdin input should be recorded in a source snapshot")
+	}
+	before, err := os.ReadFile(filepath.Join(chartDir, "templates", "web-deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(chartDir, "templates")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeCmd(t, "regenerate", chartDir); err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	after, err := os.ReadFile(filepath.Join(chartDir, "templates", "web-deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("regenerated template differs:\n%s\nwant\n%s", after, before)
+	}
+	if again, err := generator.ReadGenerationRecord(chartDir); err != nil || again.Snapshot == nil || again.Options["file"] == nil {
+		t.Errorf("the replayed generation should keep its snapshot and options: %+v, %v", again, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(chartDir, generator.SourceSnapshotFile), []byte("---\nkind: Secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "regenerate", chartDir); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("expected a modified snapshot error, got %v", err)
+	}
+}
+
+func TestRegenerateCmd_NoRecord(t *testing.T) {
+	if _, err := executeCmd(t, "regenerate", t.TempDir()); err == nil || !strings.Contains(err.Error(), "generation record") {
+		t.Errorf("expected a missing record error, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/registry"
+)
+
+// registryFlags configure access to container registries and chart
+// repositories, shared by the commands that query them.
+type registryFlags struct {
+	auth   []string
+	config string
+	proxy  string
+	caFile string
+}
+
+// register adds the registry access flags to fs.
+func (r *registryFlags) register(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&r.auth, "registry-auth", nil, "Registry or chart repository credential as host=username:password, used before the Docker config (repeatable; also "+registry.AuthEnv+", comma-separated)")
+	fs.StringVar(&r.config, "registry-config", "", "Docker config.json with registry credentials (default: $DOCKER_CONFIG/config.json or ~/.docker/config.json)")
+	fs.StringVar(&r.proxy, "proxy", "", "HTTP proxy URL for registry and chart repository requests (default: HTTPS_PROXY, HTTP_PROXY and NO_PROXY)")
+	fs.StringVar(&r.caFile, "ca-file", "", "PEM file of additional trusted certificate authorities, e.g. of a TLS-intercepting proxy")
+}
+
+// options returns the registry options of the flags. Credentials of
+// --registry-auth come before those of the environment.
+func (r registryFlags) options() (registry.Options, error) {
+	opts := registry.Options{DockerConfig: r.config, Proxy: r.proxy, CAFile: r.caFile}
+	for _, s := range r.auth {
+		c, err := registry.ParseCredential(s)
+		if err != nil {
+			return registry.Options{}, err
+		}
+		opts.Credentials = append(opts.Credentials, c)
+	}
+	env, err := registry.CredentialsFromEnv()
+	if err != nil {
+		return registry.Options{}, err
+	}
+	opts.Credentials = append(opts.Credentials, env...)
+	return opts, nil
+}
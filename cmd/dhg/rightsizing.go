@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/metrics"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const (
+	metricsServer     = "metrics-server"
+	metricsPrometheus = "prometheus"
+)
+
+// validateMetricsSource checks the --metrics, --prometheus-url and
+// --metrics-window flags.
+func validateMetricsSource(metricsSource, prometheusURL string, window time.Duration, source types.Source) error {
+	switch metricsSource {
+	case "":
+		return nil
+	case metricsServer:
+		if source != types.SourceCluster {
+			return fmt.Errorf("--metrics metrics-server requires --source cluster")
+		}
+	case metricsPrometheus:
+		if prometheusURL == "" {
+			return fmt.Errorf("--metrics prometheus requires --prometheus-url")
+		}
+		if window <= 0 {
+			return fmt.Errorf("--metrics-window must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid metrics source: %s (must be metrics-server or prometheus)", metricsSource)
+	}
+	return nil
+}
+
+// fetchUsage collects the container usage of the extracted namespaces from
+// metrics-server (through the cluster extractor) or Prometheus.
+func fetchUsage(ctx context.Context, ext extractor.Extractor, extractOpts extractor.Options, metricsSource, prometheusURL string, window time.Duration) ([]metrics.ContainerUsage, error) {
+	switch metricsSource {
+	case metricsServer:
+		cluster, ok := ext.(*extractor.ClusterExtractor)
+		if !ok {
+			return nil, fmt.Errorf("--metrics metrics-server requires --source cluster")
+		}
+		items, err := cluster.PodMetrics(ctx, extractOpts)
+		if err != nil {
+			return nil, err
+		}
+		return metrics.FromPodMetrics(items)
+	case metricsPrometheus:
+		namespaces := extractOpts.Namespaces
+		if extractOpts.Namespace != "" {
+			namespaces = []string{extractOpts.Namespace}
+		}
+		client := &metrics.PrometheusClient{URL: prometheusURL, Window: window}
+		return client.Usage(ctx, namespaces)
+	}
+	return nil, nil
+}
+
+// rightSizingSection renders usage-based resource recommendations as a
+// report section with an item per container.
+func rightSizingSection(recs []generator.ResourceRecommendation, metricsSource string, window time.Duration) pattern.ReportSection {
+	observed := "the current usage reported by metrics-server"
+	if metricsSource == metricsPrometheus {
+		observed = fmt.Sprintf("the p95 CPU and peak memory usage over %s recorded by Prometheus", formatWindow(window))
+	}
+	section := pattern.ReportSection{
+		Title: "Right-Sizing",
+		Description: fmt.Sprintf("Requests at %.0f%% of %s, memory limits at %.1f× the request; values within %.0f%% of the recommendation are kept",
+			generator.UsageHeadroom*100, observed, generator.UsageLimitRatio, generator.UsageChangeThreshold*100),
+	}
+	for _, rec := range recs {
+		pods := fmt.Sprintf("%d pods", rec.Pods)
+		if rec.Pods == 1 {
+			pods = "1 pod"
+		}
+		lines := []string{
+			fmt.Sprintf("Observed peak: %s CPU, %s memory (%s)",
+				formatMillicores(rec.CPUMillicores), formatMiB((rec.MemoryBytes+1<<20-1)>>20), pods),
+		}
+		lines = append(lines, rec.Changes...)
+		if rec.ValuesPath != "" {
+			lines = append(lines, fmt.Sprintf("Values: %s.containers[%s].resources", rec.ValuesPath, rec.Container))
+		}
+		level := "info"
+		if rec.UnderProvisioned {
+			level = "warning"
+		}
+		section.Items = append(section.Items, pattern.ReportItem{
+			Title:   fmt.Sprintf("%s/%s container %s", rec.Kind, rec.Name, rec.Container),
+			Content: strings.Join(lines, "\n"),
+			Level:   level,
+		})
+	}
+	if len(section.Items) == 0 {
+		section.Items = append(section.Items, pattern.ReportItem{
+			Title:   "No adjustments",
+			Content: "Requests and limits of all observed containers match their usage",
+			Level:   "success",
+		})
+	}
+	return section
+}
+
+// formatWindow formats a duration without trailing zero units, e.g. 168h.
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
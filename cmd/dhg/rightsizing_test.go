@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFakePrometheus serves one web container using 100m CPU and 200Mi memory.
+func newFakePrometheus(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := "209715200"
+		if strings.Contains(r.URL.Query().Get("query"), "cpu") {
+			value = "0.1"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": "vector",
+				"result": []interface{}{map[string]interface{}{
+					"metric": map[string]string{"namespace": "default", "pod": "web-7d9f8b6c5d-x2x4z", "container": "web"},
+					"value":  []interface{}{1700000000.0, value},
+				}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnalyzeCmd_Metrics(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := writeCostManifest(t, tmpDir, "2", "500m")
+	reportFile := filepath.Join(tmpDir, "report.md")
+	prometheus := newFakePrometheus(t)
+
+	if _, err := executeCmd(t, "analyze", "-f", path, "--output-format", "markdown", "-o", reportFile,
+		"--metrics", "prometheus", "--prometheus-url", prometheus.URL, "--metrics-window", "336h"); err != nil {
+		t.Fatalf("analyze --metrics failed: %v", err)
+	}
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		"## Right-Sizing",
+		"over 336h recorded by Prometheus",
+		"### Deployment/web container web",
+		"Observed peak: 100m CPU, 200Mi memory (1 pod)",
+		"CPU request 500m → 120m",
+		"memory request 1Gi → 240Mi",
+		"memory limit unset → 360Mi",
+		"Values: services.web.deployment.containers[web].resources",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("missing %q in report:\n%s", want, report)
+		}
+	}
+}
+
+func TestAnalyzeCmd_MetricsValidation(t *testing.T) {
+	path := writeCostManifest(t, t.TempDir(), "1", "100m")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--metrics", "metrics-server"}, "requires --source cluster"},
+		{[]string{"--metrics", "prometheus"}, "requires --prometheus-url"},
+		{[]string{"--metrics", "datadog"}, "invalid metrics source"},
+	}
+	for _, tt := range tests {
+		_, err := executeCmd(t, append([]string{"analyze", "-f", path}, tt.args...)...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
+
+func TestGenerateCmd_MetricsWritesValuesProd(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := writeCostManifest(t, tmpDir, "2", "500m")
+	outDir := filepath.Join(tmpDir, "chart")
+	prometheus := newFakePrometheus(t)
+
+	if _, err := executeCmd(t, "generate", "-f", path, "--chart-name", "app", "-o", outDir,
+		"--env-values", "--metrics", "prometheus", "--prometheus-url", prometheus.URL); err != nil {
+		t.Fatalf("generate --metrics failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "app", "values-prod.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod := string(data)
+	for _, want := range []string{"services:", "repository: nginx", "cpu: 120m", "memory: 240Mi", "memory: 360Mi", "replicaCount: 3"} {
+		if !strings.Contains(prod, want) {
+			t.Errorf("missing %q in values-prod.yaml:\n%s", want, prod)
+		}
+	}
+}
+
+func TestGenerateCmd_MetricsRequiresEnvValues(t *testing.T) {
+	path := writeCostManifest(t, t.TempDir(), "1", "100m")
+	_, err := executeCmd(t, "generate", "-f", path, "--chart-name", "app", "-o", t.TempDir(),
+		"--metrics", "prometheus", "--prometheus-url", "http://prometheus:9090")
+	if err == nil || !strings.Contains(err.Error(), "requires --env-values") {
+		t.Errorf("expected --env-values error, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// changedFlags returns the flags set on the command line (or by the config
+// file), recorded as the invocation parameters of signed charts. Registry
+// credentials, and the proxy URL that may embed some, are left out.
+func changedFlags(cmd *cobra.Command) map[string]string {
+	flags := make(map[string]string)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "registry-auth" || f.Name == "proxy" {
+			return
+		}
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
+
+// signCharts packages each generated chart into the output directory and
+// signs it with its provenance. It returns the files written, in order.
+func signCharts(ctx context.Context, opts generateOptions, charts []*types.GeneratedChart) ([]string, error) {
+	materials, err := provenanceMaterials(opts)
+	if err != nil {
+		return nil, err
+	}
+	prov := generator.ChartProvenance{
+		DhgVersion: version,
+		Materials:  materials,
+		Parameters: opts.flags,
+	}
+
+	var written []string
+	for _, chart := range charts {
+		archive, err := helm.PackageChart(opts.chartDir(chart.Name), opts.outputDir)
+		if err != nil {
+			return nil, err
+		}
+		files, err := generator.SignChartPackage(ctx, archive, generator.SignOptions{Method: opts.sign, Key: opts.signKey}, prov)
+		if err != nil {
+			return nil, fmt.Errorf("signing chart %s: %w", chart.Name, err)
+		}
+		written = append(written, archive)
+		written = append(written, files...)
+	}
+	return written, nil
+}
+
+// provenanceMaterials describes the sources of the generation: each input
+// path with its digest, or the cluster context for --source cluster.
+func provenanceMaterials(opts generateOptions) ([]generator.ProvenanceMaterial, error) {
+	if opts.source == "cluster" {
+		uri := "kubernetes://current-context"
+		if opts.kubeContext != "" {
+			uri = "kubernetes://" + opts.kubeContext
+		}
+		return []generator.ProvenanceMaterial{{URI: uri}}, nil
+	}
+
+	materials := make([]generator.ProvenanceMaterial, 0, len(opts.paths))
+	for _, p := range opts.paths {
+		if p == "-" {
+			materials = append(materials, generator.ProvenanceMaterial{URI: "stdin"})
+			continue
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := generator.SourceDigest(abs)
+		if err != nil {
+			return nil, err
+		}
+		materials = append(materials, generator.ProvenanceMaterial{URI: "file://" + filepath.ToSlash(abs), SHA256: digest})
+	}
+	sort.Slice(materials, func(i, j int) bool { return materials[i].URI < materials[j].URI })
+	return materials, nil
+}
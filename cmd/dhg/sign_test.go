@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func TestGenerateCmd_SignPGP(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A gpg stand-in that writes the --output file.
+	bin := t.TempDir()
+	script := "#!/bin/sh\nwhile [ $# -gt 0 ]; do\n  [ \"$1\" = --output ] && echo signed > \"$2\"\n  shift\ndone\n"
+	if err := os.WriteFile(filepath.Join(bin, "gpg"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app", "--sign", "pgp"); err != nil {
+		t.Fatalf("generate --sign pgp: %v", err)
+	}
+	archive := filepath.Join(outDir, "app-0.1.0.tgz")
+	for _, file := range []string{archive, archive + ".prov", archive + generator.ProvenanceSuffix} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s: %v", file, err)
+		}
+	}
+
+	data, err := os.ReadFile(archive + generator.ProvenanceSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stmt struct {
+		Predicate struct {
+			Invocation struct {
+				Parameters map[string]string `json:"parameters"`
+			} `json:"invocation"`
+			Materials []struct {
+				URI    string            `json:"uri"`
+				Digest map[string]string `json:"digest"`
+			} `json:"materials"`
+		} `json:"predicate"`
+	}
+	if err := json.Unmarshal(data, &stmt); err != nil {
+		t.Fatal(err)
+	}
+	if params := stmt.Predicate.Invocation.Parameters; params["chart-name"] != "app" || params["sign"] != "pgp" {
+		t.Errorf("parameters = %v; want the flags set on the command line", params)
+	}
+	if m := stmt.Predicate.Materials; len(m) != 1 || !strings.HasPrefix(m[0].URI, "file://") || m[0].Digest["sha256"] == "" {
+		t.Errorf("materials = %+v; want the input directory with its digest", m)
+	}
+}
+
+func TestGenerateCmd_SignErrors(t *testing.T) {
+	inputDir := t.TempDir()
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", t.TempDir(), "--chart-name", "app", "--sign", "x509"); err == nil || !strings.Contains(err.Error(), "invalid signing method") {
+		t.Errorf("expected an invalid method error, got %v", err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", t.TempDir(), "--chart-name", "app", "--sign-key", "ops"); err == nil || !strings.Contains(err.Error(), "--sign-key requires --sign") {
+		t.Errorf("expected a --sign-key error, got %v", err)
+	}
+	if _, err := executeCmd(t, "upgrade-chart", "-f", inputDir, "-o", t.TempDir(), "--chart-name", "app", "--sign", "pgp"); err == nil || !strings.Contains(err.Error(), "not supported by upgrade-chart") {
+		t.Errorf("expected upgrade-chart to reject --sign, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func newStatusCmd() *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "status <chart-dir>",
+		Short: "Show which chart files were changed since the last generation",
+		Long: `Compare a generated chart with the manifest recorded at generation time
+(.dhg/manifest.json) and report each file as:
+  modified    generated file edited by hand
+  missing     generated file deleted
+  orphaned    file with a dhg ownership header that the last generation did not produce
+  untracked   file added by hand
+  unmodified  generated file left as is (shown with --all)
+
+Examples:
+  dhg status ./chart/myapp
+  dhg status ./chart/myapp --all`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses, err := generator.ChartStatus(args[0])
+			if err != nil {
+				return fmt.Errorf("%w (was the chart generated by dhg?)", err)
+			}
+			printChartStatus(cmd.OutOrStdout(), statuses, all)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Also list unmodified files")
+
+	return cmd
+}
+
+func printChartStatus(w io.Writer, statuses []generator.FileStatus, all bool) {
+	counts := make(map[generator.FileState]int)
+	for _, s := range statuses {
+		counts[s.State]++
+		if s.State == generator.FileUnmodified && !all {
+			continue
+		}
+		fmt.Fprintf(w, "%-11s %s\n", s.State, s.Path)
+	}
+
+	fmt.Fprintf(w, "\n%d unmodified, %d modified, %d missing, %d orphaned, %d untracked\n",
+		counts[generator.FileUnmodified], counts[generator.FileModified], counts[generator.FileMissing],
+		counts[generator.FileOrphaned], counts[generator.FileUntracked])
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatusCmd_ReportsDrift(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	chartDir := filepath.Join(outDir, "app")
+
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1)
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	tmplPath := filepath.Join(chartDir, "templates", "web-deployment.yaml")
+	tmpl, err := os.ReadFile(tmplPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(tmpl), "{{/* Generated by dhg (sha256:") {
+		t.Errorf("generated template should carry an ownership header:\n%s", tmpl)
+	}
+	if err := os.WriteFile(tmplPath, append(tmpl, "# edited\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := executeCmd(t, "status", chartDir)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out, "modified    templates/web-deployment.yaml") {
+		t.Errorf("status should report the edited template:\n%s", out)
+	}
+	if strings.Contains(out, "unmodified  values.yaml") {
+		t.Errorf("unmodified files should be hidden without --all:\n%s", out)
+	}
+
+	out, err = executeCmd(t, "status", chartDir, "--all")
+	if err != nil {
+		t.Fatalf("status --all: %v", err)
+	}
+	if !strings.Contains(out, "unmodified  values.yaml") {
+		t.Errorf("--all should list unmodified files:\n%s", out)
+	}
+}
+
+func TestStatusCmd_NotGenerated(t *testing.T) {
+	if _, err := executeCmd(t, "status", t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without a generation manifest")
+	}
+}
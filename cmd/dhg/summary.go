@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/logging"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// validateSummaryJSON checks that a --summary-json on stdout does not share
+// it with the --dry-run output or the --interactive prompts.
+func validateSummaryJSON(opts generateOptions) error {
+	if opts.summaryJSON != "-" {
+		return nil
+	}
+	switch {
+	case opts.dryRun:
+		return fmt.Errorf("--summary-json - and --dry-run both write to stdout; write the summary to a file")
+	case opts.interactive:
+		return fmt.Errorf("--summary-json - and --interactive both write to stdout; write the summary to a file")
+	}
+	return nil
+}
+
+// writeSummaryJSON writes the JSON summary of a generation to the
+// --summary-json file, or to stdout for "-".
+func writeSummaryJSON(opts generateOptions, logger *logging.Logger, charts []*types.GeneratedChart, graph *types.ResourceGraph, started time.Time) error {
+	summary := dhg.Summarize(charts, graph)
+	summary.DryRun = opts.dryRun
+	if !opts.dryRun {
+		summary.Output = opts.outputDir
+	}
+	summary.DurationMs = time.Since(started).Milliseconds()
+	summary.Warnings = logger.Warnings()
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if opts.summaryJSON == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(opts.summaryJSON, data, 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+)
+
+func TestGenerateCmd_SummaryJSON(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	manifest := strings.Replace(upgradeTestManifest, "%s", "1.25", 1) + "---\nkind: [\n"
+	if err := os.WriteFile(filepath.Join(inputDir, "deploy.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app", "--summary-json", summaryPath); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary dhg.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("invalid summary JSON: %v\n%s", err, data)
+	}
+	if summary.Output != outDir || summary.DryRun || summary.Resources != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(summary.Charts) != 1 || summary.Charts[0].Name != "app" || summary.Charts[0].Templates == 0 || summary.Charts[0].ValuesKeys == 0 {
+		t.Errorf("charts = %+v", summary.Charts)
+	}
+	if len(summary.Warnings) != 1 || !strings.Contains(summary.Warnings[0], "extraction warning") {
+		t.Errorf("warnings = %q; want the unparsable document", summary.Warnings)
+	}
+}
+
+func TestValidateSummaryJSON(t *testing.T) {
+	if err := validateSummaryJSON(generateOptions{summaryJSON: "-"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateSummaryJSON(generateOptions{summaryJSON: "summary.json", dryRun: true}); err != nil {
+		t.Errorf("a summary file should be allowed with --dry-run: %v", err)
+	}
+	if err := validateSummaryJSON(generateOptions{summaryJSON: "-", dryRun: true}); err == nil {
+		t.Error("expected an error for --summary-json - with --dry-run")
+	}
+}
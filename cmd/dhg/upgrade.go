@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func newUpgradeChartCmd() *cobra.Command {
+	cmd := newGenerateLikeCmd(runUpgradeChart)
+	cmd.Use = "upgrade-chart"
+	cmd.Short = "Re-generate into an existing chart, preserving manual edits"
+	cmd.Long = `Re-generate a chart into an existing output directory without losing manual edits.
+
+The chart is generated with the same flags as "dhg generate" and merged into
+the existing chart file by file. Each file is merged three ways: the version
+produced by the previous generation (kept in <chart>/.dhg/base), the file on
+disk and the new generation. Generated changes are applied where the file was
+not edited; changes that overlap local edits are written to <file>.rej and the
+local version is kept. The differences from the previous generation are
+prepended to <chart>/CHANGELOG.md.
+
+Examples:
+  # Update ./chart/myapp after the manifests changed
+  dhg upgrade-chart -f ./manifests -o ./chart --chart-name myapp`
+
+	return cmd
+}
+
+func runUpgradeChart(ctx context.Context, opts generateOptions) error {
+	if opts.dryRun {
+		return fmt.Errorf("--dry-run is not supported by upgrade-chart; use dhg generate --dry-run")
+	}
+	if opts.sign != "" {
+		return fmt.Errorf("--sign is not supported by upgrade-chart; package and sign the merged chart with helm package --sign")
+	}
+	if opts.watch {
+		return fmt.Errorf("--watch is not supported by upgrade-chart; use dhg generate --watch")
+	}
+	if opts.summaryJSON != "" {
+		return fmt.Errorf("--summary-json is not supported by upgrade-chart")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dhg-upgrade-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outputDir := opts.outputDir
+	opts.outputDir = tmpDir
+	opts.skipSummary = true
+	if err := runGenerate(ctx, opts); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to read generated output: %w", err)
+	}
+
+	conflicts := 0
+	for _, entry := range entries {
+		generatedPath := filepath.Join(tmpDir, entry.Name())
+		targetPath := filepath.Join(outputDir, entry.Name())
+
+		if !entry.IsDir() {
+			// Top-level layout files (Makefile, ct.yaml) have no base snapshot.
+			conflict, err := upgradeLayoutFile(targetPath, generatedPath)
+			if err != nil {
+				return err
+			}
+			if conflict {
+				conflicts++
+				fmt.Printf("  C %s (see %s%s)\n", targetPath, targetPath, generator.RejectSuffix)
+			}
+			continue
+		}
+
+		generated, err := generator.ReadGeneratedChart(generatedPath)
+		if err != nil {
+			return fmt.Errorf("failed to read generated chart %s: %w", entry.Name(), err)
+		}
+		if _, err := generator.UpdateGenerationChangelog(targetPath, generated, time.Now()); err != nil {
+			return err
+		}
+		report, err := generator.UpgradeChart(targetPath, generatedPath)
+		if err != nil {
+			return fmt.Errorf("failed to upgrade chart %s: %w", entry.Name(), err)
+		}
+		conflicts += len(report.Conflicts)
+		printUpgradeReport(targetPath, report)
+	}
+
+	if conflicts > 0 {
+		fmt.Printf("\n! %d file(s) have generated changes that conflict with local edits; resolve the %s files and delete them\n",
+			conflicts, generator.RejectSuffix)
+	} else {
+		fmt.Printf("\n✓ Successfully upgraded chart(s) in %s\n", outputDir)
+	}
+	return nil
+}
+
+// upgradeLayoutFile copies a generated file that does not exist yet, and
+// writes a .rej file when an existing one differs. It reports a conflict.
+func upgradeLayoutFile(targetPath, generatedPath string) (bool, error) {
+	generated, err := os.ReadFile(generatedPath)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := os.ReadFile(targetPath)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return false, err
+		}
+		return false, os.WriteFile(targetPath, generated, 0644)
+	}
+	if err != nil {
+		return false, err
+	}
+	if string(current) == string(generated) {
+		return false, nil
+	}
+
+	rejects := generator.FormatRejects(generator.DiffHunks(string(current), string(generated)))
+	return true, os.WriteFile(targetPath+generator.RejectSuffix, []byte(rejects), 0644)
+}
+
+func printUpgradeReport(chartDir string, report *generator.UpgradeReport) {
+	fmt.Printf("%s: %d added, %d updated, %d removed, %d conflict(s)\n",
+		chartDir, len(report.Added), len(report.Updated), len(report.Removed), len(report.Conflicts))
+	for _, f := range report.Added {
+		fmt.Printf("  A %s\n", f)
+	}
+	for _, f := range report.Updated {
+		fmt.Printf("  U %s\n", f)
+	}
+	for _, f := range report.Removed {
+		fmt.Printf("  D %s\n", f)
+	}
+	for _, f := range report.Conflicts {
+		fmt.Printf("  C %s (see %s%s)\n", f, f, generator.RejectSuffix)
+	}
+	for _, f := range report.Kept {
+		fmt.Printf("  K %s (no longer generated, kept because it was edited)\n", f)
+	}
+}
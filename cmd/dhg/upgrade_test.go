@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const upgradeTestManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:%s
+`
+
+func TestUpgradeChartCmd_PreservesManualEdits(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifest := filepath.Join(inputDir, "deploy.yaml")
+	chartDir := filepath.Join(outDir, "app")
+
+	if err := os.WriteFile(manifest, []byte(strings.Replace(upgradeTestManifest, "%s", "1.25", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, ".dhg", "base", "values.yaml")); err != nil {
+		t.Fatalf("generate should record the base snapshot: %v", err)
+	}
+
+	// Hand-edit a template and add a custom one.
+	tmplPath := filepath.Join(chartDir, "templates", "web-deployment.yaml")
+	tmpl, err := os.ReadFile(tmplPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := "# owned by the platform team\n" + string(tmpl)
+	if err := os.WriteFile(tmplPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	customPath := filepath.Join(chartDir, "templates", "custom.yaml")
+	if err := os.WriteFile(customPath, []byte("# custom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The source manifest changes and the chart is upgraded.
+	if err := os.WriteFile(manifest, []byte(strings.Replace(upgradeTestManifest, "%s", "1.26", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "upgrade-chart", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("upgrade-chart: %v", err)
+	}
+
+	values, err := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(values), `tag: "1.26"`) {
+		t.Errorf("values.yaml should pick up the new image tag:\n%s", values)
+	}
+	if got, _ := os.ReadFile(tmplPath); string(got) != edited {
+		t.Errorf("hand-edited template should be preserved:\n%s", got)
+	}
+	if _, err := os.Stat(customPath); err != nil {
+		t.Errorf("custom template should be preserved: %v", err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(chartDir, "*", "*.rej"))
+	if len(matches) != 0 {
+		t.Errorf("expected no conflicts, got %v", matches)
+	}
+}
+
+func TestUpgradeChartCmd_RejectsDryRun(t *testing.T) {
+	_, err := executeCmd(t, "upgrade-chart", "-f", t.TempDir(), "--chart-name", "app", "--dry-run")
+	if err == nil || !strings.Contains(err.Error(), "--dry-run") {
+		t.Errorf("expected --dry-run to be rejected, got: %v", err)
+	}
+}
+
+func TestGenerateCmd_ChangelogBetweenGenerations(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifest := filepath.Join(inputDir, "deploy.yaml")
+	changelog := filepath.Join(outDir, "app", "CHANGELOG.md")
+
+	for _, tag := range []string{"1.25", "1.25"} {
+		if err := os.WriteFile(manifest, []byte(strings.Replace(upgradeTestManifest, "%s", tag, 1)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := executeCmd(t, "generate", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+	}
+	if _, err := os.Stat(changelog); !os.IsNotExist(err) {
+		t.Error("an unchanged regeneration should not write CHANGELOG.md")
+	}
+
+	if err := os.WriteFile(manifest, []byte(strings.Replace(upgradeTestManifest, "%s", "1.27", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCmd(t, "upgrade-chart", "-f", inputDir, "-o", outDir, "--chart-name", "app"); err != nil {
+		t.Fatalf("upgrade-chart: %v", err)
+	}
+	data, err := os.ReadFile(changelog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "- `services.web.deployment.containers[web].image.tag`: `1.25` → `1.27`") {
+		t.Errorf("missing the changed image tag in CHANGELOG.md:\n%s", data)
+	}
+
+	out, err := executeCmd(t, "status", filepath.Join(outDir, "app"))
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if strings.Contains(out, "CHANGELOG.md") {
+		t.Errorf("CHANGELOG.md should not be tracked as a generated file:\n%s", out)
+	}
+}
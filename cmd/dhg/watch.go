@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// defaultWatchDebounce is how long --watch waits for input changes to settle
+// before regenerating.
+const defaultWatchDebounce = 300 * time.Millisecond
+
+// validateWatch checks that a generation can be watched: its inputs must be
+// files that can be read again, and it must write without prompting.
+func validateWatch(opts generateOptions) error {
+	if opts.source != "file" && opts.source != "compose" {
+		return fmt.Errorf("--watch requires --source file or compose")
+	}
+	for _, p := range opts.paths {
+		if p == "-" {
+			return fmt.Errorf("--watch cannot watch stdin")
+		}
+	}
+	switch {
+	case opts.dryRun:
+		return fmt.Errorf("--watch and --dry-run are mutually exclusive")
+	case opts.interactive:
+		return fmt.Errorf("--watch and --interactive are mutually exclusive")
+	case opts.sign != "":
+		return fmt.Errorf("--watch and --sign are mutually exclusive")
+	case opts.summaryJSON != "":
+		return fmt.Errorf("--watch and --summary-json are mutually exclusive")
+	case opts.watchDebounce <= 0:
+		return fmt.Errorf("--watch-debounce must be positive")
+	}
+	return nil
+}
+
+// runWatch generates the chart, then regenerates it each time the input files
+// change until ctx is cancelled. Each cycle generates into a temporary
+// directory and rewrites only the output files that changed.
+func runWatch(ctx context.Context, opts generateOptions) error {
+	if err := validateWatch(opts); err != nil {
+		return err
+	}
+	opts.watch = false
+	if err := runGenerate(ctx, opts); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	defer watcher.Close()
+
+	inputs := absPaths(watchInputs(opts))
+	for _, input := range inputs {
+		if err := addWatches(watcher, input, opts.recursive); err != nil {
+			return err
+		}
+	}
+	outputDir, err := filepath.Abs(opts.outputDir)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	fmt.Printf("\nWatching %s for changes (Ctrl+C to stop)\n", strings.Join(opts.paths, ", "))
+
+	debounce := time.NewTimer(opts.watchDebounce)
+	debounce.Stop()
+	// changed maps the changed files to whether they were created since the
+	// last cycle: temporary files of editors and tools come and go in between.
+	changed := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !watchedEvent(event, inputs, outputDir) {
+				continue
+			}
+			if event.Has(fsnotify.Create) && opts.recursive {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatches(watcher, event.Name, true); err != nil {
+						fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+					}
+				}
+			}
+			changed[event.Name] = changed[event.Name] || event.Has(fsnotify.Create)
+			debounce.Reset(opts.watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+
+		case <-debounce.C:
+			files := make([]string, 0, len(changed))
+			for file, created := range changed {
+				if _, err := os.Stat(file); created && err != nil {
+					continue
+				}
+				files = append(files, file)
+			}
+			changed = make(map[string]bool)
+			if len(files) == 0 {
+				continue
+			}
+			sort.Strings(files)
+			regenerateOnChange(ctx, os.Stdout, opts, files)
+		}
+	}
+}
+
+// watchInputs returns the input files and directories of a generation.
+func watchInputs(opts generateOptions) []string {
+	inputs := append([]string{}, opts.paths...)
+	inputs = append(inputs, opts.helmValues...)
+	inputs = append(inputs, opts.valueFiles...)
+	for _, file := range []string{opts.groupsFile, opts.tenantsFile, opts.chartMetadata, opts.configFile} {
+		if file != "" {
+			inputs = append(inputs, file)
+		}
+	}
+	return inputs
+}
+
+// addWatches watches a directory (and its subdirectories when recursive), or
+// the directory of a file.
+func addWatches(watcher *fsnotify.Watcher, input string, recursive bool) error {
+	info, err := os.Stat(input)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	if !info.IsDir() {
+		input = filepath.Dir(input)
+	}
+	if !info.IsDir() || !recursive {
+		if err := watcher.Add(input); err != nil {
+			return fmt.Errorf("watch %s: %w", input, err)
+		}
+		return nil
+	}
+	return filepath.WalkDir(input, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != input && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(p); err != nil {
+			return fmt.Errorf("watch %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// watchedEvent reports whether a file system event changes an input: editor
+// swap and backup files, hidden files and the output directory are ignored.
+func watchedEvent(event fsnotify.Event, inputs []string, outputDir string) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || strings.HasSuffix(name, ".swp") {
+		return false
+	}
+	if within(event.Name, outputDir) {
+		return false
+	}
+	for _, input := range inputs {
+		if event.Name == input || within(event.Name, input) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is inside dir.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// regenerateOnChange runs one watch cycle: it generates into a temporary
+// directory, syncs the changed files into the output directory and prints
+// what changed. Errors are reported and the watch goes on.
+func regenerateOnChange(ctx context.Context, w io.Writer, opts generateOptions, files []string) {
+	started := time.Now()
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	fmt.Fprintf(w, "\n[%s] changed: %s\n", started.Format("15:04:05"), strings.Join(names, ", "))
+
+	tmpDir, err := os.MkdirTemp("", "dhg-watch-")
+	if err != nil {
+		fmt.Fprintf(w, "  ! %v\n", err)
+		return
+	}
+	defer os.RemoveAll(tmpDir)
+
+	generated := opts
+	generated.outputDir = tmpDir
+	generated.skipSummary = true
+	if err := runGenerate(ctx, generated); err != nil {
+		fmt.Fprintf(w, "  ! generation failed: %v\n", err)
+		return
+	}
+
+	// Compare each chart with its previous generation before it is replaced.
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		fmt.Fprintf(w, "  ! %v\n", err)
+		return
+	}
+	var summaries []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		next, err := generator.ReadGeneratedChart(generated.chartDir(entry.Name()))
+		if err != nil || next.ChartYAML == "" {
+			continue
+		}
+		prev, err := generator.ReadGeneratedChart(filepath.Join(opts.chartDir(entry.Name()), generator.BaseSnapshotDir))
+		if err != nil {
+			prev = &types.GeneratedChart{Templates: map[string]string{}}
+		}
+		summaries = append(summaries, fmt.Sprintf("  %s: %s", entry.Name(), describeGenerationChanges(generator.CompareGenerations(prev, next))))
+	}
+
+	report, err := generator.SyncGeneration(opts.outputDir, tmpDir)
+	if err != nil {
+		fmt.Fprintf(w, "  ! %v\n", err)
+		return
+	}
+	for _, s := range summaries {
+		fmt.Fprintln(w, s)
+	}
+	for _, f := range report.Added {
+		fmt.Fprintf(w, "  A %s\n", f)
+	}
+	for _, f := range report.Updated {
+		fmt.Fprintf(w, "  U %s\n", f)
+	}
+	for _, f := range report.Removed {
+		fmt.Fprintf(w, "  D %s\n", f)
+	}
+	if report.IsEmpty() {
+		fmt.Fprintln(w, "  no files changed")
+	}
+	fmt.Fprintf(w, "  regenerated in %s\n", time.Since(started).Round(time.Millisecond))
+}
+
+// describeGenerationChanges summarizes the changes between two generations on
+// one line.
+func describeGenerationChanges(c *generator.GenerationChanges) string {
+	if c.IsEmpty() {
+		return "no changes"
+	}
+	var parts []string
+	add := func(n int, format string) {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf(format, n))
+		}
+	}
+	add(len(c.AddedResources), "%d added resources")
+	add(len(c.RemovedResources), "%d removed resources")
+	add(len(c.ChangedResources), "%d changed resources")
+	add(len(c.ChangedDefaults), "%d changed defaults")
+	add(len(c.AddedKeys), "%d new values keys")
+	add(len(c.RemovedKeys), "%d removed values keys")
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+)
+
+func TestValidateWatch(t *testing.T) {
+	base := generateOptions{source: "file", paths: []string{"manifests"}, watch: true, watchDebounce: defaultWatchDebounce}
+	if err := validateWatch(base); err != nil {
+		t.Fatalf("validateWatch: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*generateOptions)
+		want   string
+	}{
+		{"cluster", func(o *generateOptions) { o.source = "cluster" }, "--source file or compose"},
+		{"stdin", func(o *generateOptions) { o.paths = []string{"-"} }, "stdin"},
+		{"dry-run", func(o *generateOptions) { o.dryRun = true }, "--dry-run"},
+		{"sign", func(o *generateOptions) { o.sign = "pgp" }, "--sign"},
+		{"debounce", func(o *generateOptions) { o.watchDebounce = 0 }, "--watch-debounce"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			tt.modify(&opts)
+			if err := validateWatch(opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWatchedEvent(t *testing.T) {
+	inputs := []string{"/src/manifests", "/src/values.yaml"}
+	tests := []struct {
+		event fsnotify.Event
+		want  bool
+	}{
+		{fsnotify.Event{Name: "/src/manifests/deploy.yaml", Op: fsnotify.Write}, true},
+		{fsnotify.Event{Name: "/src/values.yaml", Op: fsnotify.Create}, true},
+		{fsnotify.Event{Name: "/src/manifests/deploy.yaml", Op: fsnotify.Chmod}, false},
+		{fsnotify.Event{Name: "/src/manifests/.deploy.yaml.swp", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: "/src/manifests/deploy.yaml~", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: "/src/other.yaml", Op: fsnotify.Write}, false},
+		{fsnotify.Event{Name: "/src/manifests/out/app/values.yaml", Op: fsnotify.Write}, false},
+	}
+	for _, tt := range tests {
+		if got := watchedEvent(tt.event, inputs, "/src/manifests/out"); got != tt.want {
+			t.Errorf("watchedEvent(%s) = %v; want %v", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeGenerationChanges(t *testing.T) {
+	if got := describeGenerationChanges(&generator.GenerationChanges{}); got != "no changes" {
+		t.Errorf("got %q", got)
+	}
+	changes := &generator.GenerationChanges{
+		AddedResources: []generator.ResourceChange{{Kind: "Service", Path: "templates/web-service.yaml"}},
+		AddedKeys:      []string{"services.web.service"},
+	}
+	if got := describeGenerationChanges(changes); got != "1 added resources, 1 new values keys" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestUpgradeChartCmd_RejectsWatch(t *testing.T) {
+	_, err := executeCmd(t, "upgrade-chart", t.TempDir(), "-f", t.TempDir(), "--chart-name", "app", "--watch")
+	if err == nil || !strings.Contains(err.Error(), "--watch") {
+		t.Errorf("expected a --watch error, got %v", err)
+	}
+}
+
+func TestGenerateCmd_Watch(t *testing.T) {
+	inputDir := t.TempDir()
+	outDir := t.TempDir()
+	manifestPath := filepath.Join(inputDir, "deploy.yaml")
+	valuesPath := filepath.Join(outDir, "app", "values.yaml")
+	if err := os.WriteFile(manifestPath, []byte(strings.Replace(upgradeTestManifest, "%s", "1.25", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		root := newRootCmd()
+		root.SetArgs([]string{"generate", "-f", inputDir, "-o", outDir, "--chart-name", "app", "--watch", "--watch-debounce", "50ms"})
+		done <- root.ExecuteContext(ctx)
+	}()
+
+	// The change is written until it is picked up: the watch only starts
+	// after the initial generation.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		values, _ := os.ReadFile(valuesPath)
+		if strings.Contains(string(values), `"1.27"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("values.yaml not regenerated:\n%s", values)
+		}
+		if len(values) > 0 {
+			if err := os.WriteFile(manifestPath, []byte(strings.Replace(upgradeTestManifest, "%s", "1.27", 1)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("generate --watch: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("generate --watch did not stop when its context was cancelled")
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "app", "CHANGELOG.md")); err == nil {
+		data, _ := os.ReadFile(filepath.Join(outDir, "app", "CHANGELOG.md"))
+		if strings.Contains(string(data), "1.27") {
+			t.Error("watch cycles should not update CHANGELOG.md")
+		}
+	}
+}
@@ -48,7 +48,7 @@ func NewDefaultAnalyzer() *DefaultAnalyzer {
 func (a *DefaultAnalyzer) AddDetector(d Detector) {
 	a.detectors = append(a.detectors, d)
 	// Sort by priority (highest first) using stable full sort.
-	sort.Slice(a.detectors, func(i, j int) bool {
+	sort.SliceStable(a.detectors, func(i, j int) bool {
 		return a.detectors[i].Priority() > a.detectors[j].Priority()
 	})
 }
@@ -57,6 +57,13 @@ func (a *DefaultAnalyzer) AddDetector(d Detector) {
 func (a *DefaultAnalyzer) Analyze(ctx context.Context, resources []*types.ProcessedResource) (*types.ResourceGraph, error) {
 	graph := types.NewResourceGraph()
 
+	// Detect in resource key order so relationships, and the groups formed
+	// from them, do not depend on the order resources were extracted in.
+	resources = append([]*types.ProcessedResource(nil), resources...)
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Original.ResourceKey().String() < resources[j].Original.ResourceKey().String()
+	})
+
 	// Build resource map
 	resourceMap := make(map[types.ResourceKey]*types.ProcessedResource)
 	for _, r := range resources {
@@ -91,9 +98,11 @@ func (a *DefaultAnalyzer) Analyze(ctx context.Context, resources []*types.Proces
 func (a *DefaultAnalyzer) groupResources(graph *types.ResourceGraph) error {
 	grouped := make(map[string]bool)
 	serviceMap := make(map[string]*types.ResourceGroup)
+	keys := sortedResourceKeys(graph)
 
 	// First pass: create groups from existing service names
-	for key, resource := range graph.Resources {
+	for _, key := range keys {
+		resource := graph.Resources[key]
 		if resource.ServiceName != "" {
 			if _, exists := serviceMap[resource.ServiceName]; !exists {
 				serviceMap[resource.ServiceName] = &types.ResourceGroup{
@@ -108,7 +117,8 @@ func (a *DefaultAnalyzer) groupResources(graph *types.ResourceGraph) error {
 	}
 
 	// Second pass: group remaining resources based on relationships
-	for key, resource := range graph.Resources {
+	for _, key := range keys {
+		resource := graph.Resources[key]
 		if grouped[key.String()] {
 			continue
 		}
@@ -123,7 +133,8 @@ func (a *DefaultAnalyzer) groupResources(graph *types.ResourceGraph) error {
 	}
 
 	// Third pass: remaining resources are orphans or standalone services
-	for key, resource := range graph.Resources {
+	for _, key := range keys {
+		resource := graph.Resources[key]
 		if grouped[key.String()] {
 			continue
 		}
@@ -146,18 +157,27 @@ func (a *DefaultAnalyzer) groupResources(graph *types.ResourceGraph) error {
 		grouped[key.String()] = true
 	}
 
-	// Add groups to graph
-	for _, group := range serviceMap {
-		graph.AddGroup(group)
+	// Add groups to graph, ordered by name
+	names := make([]string, 0, len(serviceMap))
+	for name := range serviceMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		graph.AddGroup(serviceMap[name])
 	}
 
 	return nil
 }
-
 // findRelatedService finds a service name related to the given resource through relationships.
+// Relationships that do not join groups are skipped: talking to a service, or
+// sharing a Gateway or an issuer with it, is not belonging to it.
 func (a *DefaultAnalyzer) findRelatedService(key types.ResourceKey, graph *types.ResourceGraph, grouped map[string]bool) string {
 	// Check outgoing relationships
 	for _, rel := range graph.GetRelationshipsFrom(key) {
+		if !rel.JoinsGroup() {
+			continue
+		}
 		if targetResource, ok := graph.GetResourceByKey(rel.To); ok {
 			if targetResource.ServiceName != "" && grouped[rel.To.String()] {
 				return targetResource.ServiceName
@@ -167,6 +187,9 @@ func (a *DefaultAnalyzer) findRelatedService(key types.ResourceKey, graph *types
 
 	// Check incoming relationships
 	for _, rel := range graph.GetRelationshipsTo(key) {
+		if !rel.JoinsGroup() {
+			continue
+		}
 		if sourceResource, ok := graph.GetResourceByKey(rel.From); ok {
 			if sourceResource.ServiceName != "" && grouped[rel.From.String()] {
 				return sourceResource.ServiceName
@@ -45,6 +45,8 @@ func (d *LabelSelectorDetector) Detect(ctx context.Context, resource *types.Proc
 		relationships = append(relationships, d.detectServiceToWorkload(resource, allResources)...)
 	case "ServiceMonitor":
 		relationships = append(relationships, d.detectServiceMonitorToService(resource, allResources)...)
+	case "PodMonitor":
+		relationships = append(relationships, d.detectPodMonitorToWorkload(resource, allResources)...)
 	}
 
 	return relationships
@@ -123,27 +125,19 @@ func (d *LabelSelectorDetector) detectServiceMonitorToService(resource *types.Pr
 	var relationships []types.Relationship
 
 	obj := resource.Original.Object
-	namespace := obj.GetNamespace()
-
-	// Get ServiceMonitor selector
-	selectorMap, found, err := unstructured.NestedMap(obj.Object, "spec", "selector")
-	if !found || err != nil {
-		return relationships
-	}
 
-	matchLabels, found, err := unstructured.NestedStringMap(selectorMap, "matchLabels")
-	if !found || err != nil || len(matchLabels) == 0 {
+	labelSelector, ok := monitorSelector(obj)
+	if !ok {
 		return relationships
 	}
-
-	labelSelector := labels.Set(matchLabels).AsSelector()
+	inNamespace := monitorNamespaces(obj)
 
 	// Check all Services
 	for key, targetResource := range allResources {
 		if key.GVK.Kind != "Service" {
 			continue
 		}
-		if key.Namespace != namespace {
+		if !inNamespace(key.Namespace) {
 			continue
 		}
 
@@ -169,3 +163,63 @@ func (d *LabelSelectorDetector) detectServiceMonitorToService(resource *types.Pr
 
 	return relationships
 }
+
+// detectPodMonitorToWorkload detects PodMonitor -> workload relationships by
+// matching the selector against pod template labels.
+func (d *LabelSelectorDetector) detectPodMonitorToWorkload(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+
+	labelSelector, ok := monitorSelector(obj)
+	if !ok {
+		return relationships
+	}
+
+	for _, key := range matchWorkloads(allResources, labelSelector, monitorNamespaces(obj)) {
+		relationships = append(relationships, types.Relationship{
+			From:  resource.Original.ResourceKey(),
+			To:    key,
+			Type:  types.RelationPodMonitor,
+			Field: "spec.selector",
+			Details: map[string]string{
+				"selector": labelSelector.String(),
+			},
+		})
+	}
+
+	return relationships
+}
+
+// monitorSelector returns the spec.selector of a Prometheus Operator monitor.
+// ok is false when the selector is missing, empty or invalid.
+func monitorSelector(obj *unstructured.Unstructured) (labels.Selector, bool) {
+	selectorMap, found, err := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if !found || err != nil || len(selectorMap) == 0 {
+		return nil, false
+	}
+	selector, err := toSelector(selectorMap)
+	if err != nil || selector.Empty() {
+		return nil, false
+	}
+	return selector, true
+}
+
+// monitorNamespaces returns a predicate for the namespaces a monitor watches:
+// spec.namespaceSelector.any, spec.namespaceSelector.matchNames, or the
+// monitor's own namespace by default.
+func monitorNamespaces(obj *unstructured.Unstructured) func(string) bool {
+	namespace := obj.GetNamespace()
+
+	if anyNS, _, _ := unstructured.NestedBool(obj.Object, "spec", "namespaceSelector", "any"); anyNS {
+		return func(string) bool { return true }
+	}
+	if names, found, _ := unstructured.NestedStringSlice(obj.Object, "spec", "namespaceSelector", "matchNames"); found && len(names) > 0 {
+		allowed := make(map[string]bool, len(names))
+		for _, n := range names {
+			allowed[n] = true
+		}
+		return func(ns string) bool { return allowed[ns] }
+	}
+	return func(ns string) bool { return ns == namespace }
+}
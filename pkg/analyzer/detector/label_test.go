@@ -586,3 +586,88 @@ func TestLabelDetector_WorkloadWithEmptyPodTemplateLabels(t *testing.T) {
 		t.Errorf("workload with empty pod template labels: expected 0 relationships, got %d", len(rels))
 	}
 }
+
+// TestLabelDetector_ServiceMonitorMatchExpressions verifies that matchExpressions
+// in a ServiceMonitor selector are honored.
+func TestLabelDetector_ServiceMonitorMatchExpressions(t *testing.T) {
+	sm := makeProcessedResource(
+		"monitoring.coreos.com/v1", "ServiceMonitor", "my-sm", "default",
+		nil, nil,
+		map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchExpressions": []interface{}{
+					map[string]interface{}{"key": "tier", "operator": "In", "values": []interface{}{"api"}},
+				},
+			},
+		},
+	)
+	api := makeProcessedResource("v1", "Service", "api", "default", map[string]interface{}{"tier": "api"}, nil, nil)
+	web := makeProcessedResource("v1", "Service", "web", "default", map[string]interface{}{"tier": "web"}, nil, nil)
+
+	rels := NewLabelSelectorDetector().Detect(context.Background(), sm, buildAllResources(sm, api, web))
+
+	if len(rels) != 1 || rels[0].To != api.Original.ResourceKey() {
+		t.Errorf("expected only the api Service to be selected, got %v", rels)
+	}
+}
+
+// TestLabelDetector_ServiceMonitorNamespaceSelector verifies namespaceSelector
+// matchNames and any.
+func TestLabelDetector_ServiceMonitorNamespaceSelector(t *testing.T) {
+	prod := makeProcessedResource("v1", "Service", "web", "prod", map[string]interface{}{"app": "web"}, nil, nil)
+	stage := makeProcessedResource("v1", "Service", "web", "stage", map[string]interface{}{"app": "web"}, nil, nil)
+
+	tests := []struct {
+		name              string
+		namespaceSelector map[string]interface{}
+		want              int
+	}{
+		{"own namespace only", nil, 0},
+		{"matchNames", map[string]interface{}{"matchNames": []interface{}{"prod"}}, 1},
+		{"any", map[string]interface{}{"any": true}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			}
+			if tt.namespaceSelector != nil {
+				spec["namespaceSelector"] = tt.namespaceSelector
+			}
+			sm := makeProcessedResource("monitoring.coreos.com/v1", "ServiceMonitor", "web", "monitoring", nil, nil, spec)
+
+			rels := NewLabelSelectorDetector().Detect(context.Background(), sm, buildAllResources(sm, prod, stage))
+			if len(rels) != tt.want {
+				t.Errorf("expected %d relationships, got %v", tt.want, rels)
+			}
+		})
+	}
+}
+
+// TestLabelDetector_PodMonitorToWorkload verifies that a PodMonitor is linked to
+// the workloads whose pod template labels match its selector.
+func TestLabelDetector_PodMonitorToWorkload(t *testing.T) {
+	pm := makeProcessedResource(
+		"monitoring.coreos.com/v1", "PodMonitor", "my-pm", "default",
+		nil, nil,
+		map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "worker"}},
+		},
+	)
+	worker := makeProcessedResource("apps/v1", "Deployment", "worker", "default", nil, nil, map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "worker"}},
+		},
+	})
+	svc := makeProcessedResource("v1", "Service", "worker", "default", map[string]interface{}{"app": "worker"}, nil, nil)
+
+	rels := NewLabelSelectorDetector().Detect(context.Background(), pm, buildAllResources(pm, worker, svc))
+
+	if len(rels) != 1 {
+		t.Fatalf("expected 1 relationship for PodMonitor, got %d", len(rels))
+	}
+	if rels[0].Type != types.RelationPodMonitor || rels[0].To != worker.Original.ResourceKey() {
+		t.Errorf("unexpected relationship: %+v", rels[0])
+	}
+}
@@ -0,0 +1,273 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// namespaceNameLabel is the label the API server sets on every Namespace.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// NetworkPolicyDetector detects relationships described by NetworkPolicies.
+// Example: NetworkPolicy selecting Deployment web (network_policy), and
+// Deployment frontend allowed to reach Deployment web by an ingress rule
+// (network_peer).
+type NetworkPolicyDetector struct {
+	priority int
+}
+
+// NewNetworkPolicyDetector creates a new NetworkPolicy detector.
+func NewNetworkPolicyDetector() *NetworkPolicyDetector {
+	return &NetworkPolicyDetector{
+		priority: 85,
+	}
+}
+
+// Name returns the detector name.
+func (d *NetworkPolicyDetector) Name() string {
+	return "network_policy"
+}
+
+// Priority returns the detector priority.
+func (d *NetworkPolicyDetector) Priority() int {
+	return d.priority
+}
+
+// Detect detects NetworkPolicy → workload and workload → workload relationships.
+func (d *NetworkPolicyDetector) Detect(ctx context.Context, resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+	if obj.GetKind() != "NetworkPolicy" {
+		return relationships
+	}
+
+	policyKey := resource.Original.ResourceKey()
+	namespace := obj.GetNamespace()
+
+	// spec.podSelector is required; an empty selector selects every pod.
+	podSelectorMap, _, _ := unstructured.NestedMap(obj.Object, "spec", "podSelector")
+	podSelector, err := toSelector(podSelectorMap)
+	if err != nil {
+		return relationships
+	}
+
+	selected := matchWorkloads(allResources, podSelector, func(ns string) bool { return ns == namespace })
+	for _, key := range selected {
+		relationships = append(relationships, types.Relationship{
+			From:  policyKey,
+			To:    key,
+			Type:  types.RelationNetworkPolicy,
+			Field: "spec.podSelector",
+			Details: map[string]string{
+				"selector": podSelector.String(),
+			},
+		})
+	}
+	if len(selected) == 0 {
+		return relationships
+	}
+
+	seen := make(map[string]bool)
+	addPeer := func(from, to types.ResourceKey, direction, field, ports string) {
+		if from == to {
+			return
+		}
+		id := from.String() + "|" + to.String()
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+
+		details := map[string]string{
+			"policy":    obj.GetName(),
+			"direction": direction,
+		}
+		if ports != "" {
+			details["ports"] = ports
+		}
+		relationships = append(relationships, types.Relationship{
+			From:    from,
+			To:      to,
+			Type:    types.RelationNetworkPeer,
+			Field:   field,
+			Details: details,
+		})
+	}
+
+	// Ingress: peers may connect to the selected pods.
+	ingress, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ingress")
+	for i, r := range ingress {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := fmt.Sprintf("spec.ingress[%d].from", i)
+		ports := rulePorts(rule)
+		for _, peer := range d.rulePeers(rule, "from", namespace, allResources) {
+			for _, target := range selected {
+				addPeer(peer, target, "ingress", field, ports)
+			}
+		}
+	}
+
+	// Egress: the selected pods may connect to peers.
+	egress, _, _ := unstructured.NestedSlice(obj.Object, "spec", "egress")
+	for i, r := range egress {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := fmt.Sprintf("spec.egress[%d].to", i)
+		ports := rulePorts(rule)
+		for _, peer := range d.rulePeers(rule, "to", namespace, allResources) {
+			for _, source := range selected {
+				addPeer(source, peer, "egress", field, ports)
+			}
+		}
+	}
+
+	return relationships
+}
+
+// rulePeers returns the workloads matched by the from/to peers of a rule.
+// ipBlock peers are ignored; a rule without peers matches nothing here, since
+// "allow all" carries no information about which services talk to each other.
+func (d *NetworkPolicyDetector) rulePeers(rule map[string]interface{}, field, policyNamespace string, allResources map[types.ResourceKey]*types.ProcessedResource) []types.ResourceKey {
+	peers, _, _ := unstructured.NestedSlice(rule, field)
+
+	var keys []types.ResourceKey
+	for _, p := range peers {
+		peer, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		podSelectorMap, hasPod := peer["podSelector"].(map[string]interface{})
+		nsSelectorMap, hasNS := peer["namespaceSelector"].(map[string]interface{})
+		if !hasPod && !hasNS {
+			continue
+		}
+
+		podSelector := labels.Everything()
+		if hasPod {
+			s, err := toSelector(podSelectorMap)
+			if err != nil {
+				continue
+			}
+			podSelector = s
+		}
+
+		inNamespace := func(ns string) bool { return ns == policyNamespace }
+		if hasNS {
+			nsSelector, err := toSelector(nsSelectorMap)
+			if err != nil {
+				continue
+			}
+			inNamespace = func(ns string) bool {
+				return nsSelector.Matches(namespaceLabels(ns, allResources))
+			}
+		}
+
+		keys = append(keys, matchWorkloads(allResources, podSelector, inNamespace)...)
+	}
+	return keys
+}
+
+// toSelector converts an unstructured LabelSelector to a labels.Selector.
+// A nil or empty selector matches everything.
+func toSelector(m map[string]interface{}) (labels.Selector, error) {
+	if len(m) == 0 {
+		return labels.Everything(), nil
+	}
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &ls); err != nil {
+		return nil, err
+	}
+	return metav1.LabelSelectorAsSelector(&ls)
+}
+
+// namespaceLabels returns the labels of a namespace: those of its Namespace
+// object when it is among the resources, plus kubernetes.io/metadata.name.
+func namespaceLabels(ns string, allResources map[types.ResourceKey]*types.ProcessedResource) labels.Set {
+	set := labels.Set{namespaceNameLabel: ns}
+	for key, r := range allResources {
+		if key.GVK.Kind == "Namespace" && key.Name == ns {
+			for k, v := range r.Original.Object.GetLabels() {
+				set[k] = v
+			}
+			break
+		}
+	}
+	return set
+}
+
+// matchWorkloads returns the workloads whose pod labels match selector in
+// namespaces accepted by inNamespace, sorted for deterministic output.
+func matchWorkloads(allResources map[types.ResourceKey]*types.ProcessedResource, selector labels.Selector, inNamespace func(string) bool) []types.ResourceKey {
+	var keys []types.ResourceKey
+	for key, r := range allResources {
+		podLabels, ok := podTemplateLabels(r.Original.Object)
+		if !ok || !inNamespace(key.Namespace) {
+			continue
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// podTemplateLabels returns the labels of the pods a workload runs. ok is
+// false for resources that do not run pods.
+func podTemplateLabels(obj *unstructured.Unstructured) (map[string]string, bool) {
+	var podLabels map[string]string
+	switch obj.GetKind() {
+	case "Pod":
+		podLabels = obj.GetLabels()
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		podLabels, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+	case "CronJob":
+		podLabels, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "jobTemplate", "spec", "template", "metadata", "labels")
+	default:
+		return nil, false
+	}
+	return podLabels, true
+}
+
+// rulePorts renders the ports of a rule as "TCP/80,TCP/443" (protocol
+// defaults to TCP). Returns "" when the rule allows all ports.
+func rulePorts(rule map[string]interface{}) string {
+	ports, _, _ := unstructured.NestedSlice(rule, "ports")
+
+	var out []string
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		protocol, _ := port["protocol"].(string)
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		value, ok := port["port"]
+		if !ok {
+			out = append(out, protocol)
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s/%v", protocol, value))
+	}
+	return strings.Join(out, ",")
+}
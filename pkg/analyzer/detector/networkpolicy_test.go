@@ -0,0 +1,246 @@
+package detector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// makeNPDeployment creates a Deployment whose pod template carries podLabels.
+func makeNPDeployment(name, namespace string, podLabels map[string]interface{}) *types.ProcessedResource {
+	return makeProcessedResource("apps/v1", "Deployment", name, namespace, nil, nil, map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": podLabels},
+		},
+	})
+}
+
+func makeNetworkPolicy(name, namespace string, spec map[string]interface{}) *types.ProcessedResource {
+	return makeProcessedResource("networking.k8s.io/v1", "NetworkPolicy", name, namespace, nil, nil, spec)
+}
+
+func matchLabels(kv ...string) map[string]interface{} {
+	m := make(map[string]interface{})
+	for i := 0; i+1 < len(kv); i += 2 {
+		m[kv[i]] = kv[i+1]
+	}
+	return map[string]interface{}{"matchLabels": m}
+}
+
+func relsOfType(rels []types.Relationship, t types.RelationshipType) []types.Relationship {
+	var out []types.Relationship
+	for _, r := range rels {
+		if r.Type == t {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func TestNetworkPolicyDetector_NameAndPriority(t *testing.T) {
+	d := NewNetworkPolicyDetector()
+	if d.Name() != "network_policy" {
+		t.Errorf("Name() = %q", d.Name())
+	}
+	if d.Priority() != 85 {
+		t.Errorf("Priority() = %d", d.Priority())
+	}
+}
+
+func TestNetworkPolicyDetector_IgnoresOtherKinds(t *testing.T) {
+	web := makeNPDeployment("web", "default", map[string]interface{}{"app": "web"})
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), web, buildAllResources(web))
+	if len(rels) != 0 {
+		t.Errorf("expected no relationships for a Deployment, got %v", rels)
+	}
+}
+
+func TestNetworkPolicyDetector_PodSelector(t *testing.T) {
+	web := makeNPDeployment("web", "default", map[string]interface{}{"app": "web"})
+	api := makeNPDeployment("api", "default", map[string]interface{}{"app": "api"})
+	otherNS := makeNPDeployment("web", "other", map[string]interface{}{"app": "web"})
+	np := makeNetworkPolicy("web-policy", "default", map[string]interface{}{
+		"podSelector": matchLabels("app", "web"),
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(web, api, otherNS, np))
+
+	if len(rels) != 1 {
+		t.Fatalf("expected 1 relationship, got %d: %v", len(rels), rels)
+	}
+	rel := rels[0]
+	if rel.Type != types.RelationNetworkPolicy || rel.From != np.Original.ResourceKey() || rel.To != web.Original.ResourceKey() {
+		t.Errorf("unexpected relationship: %+v", rel)
+	}
+	if rel.Field != "spec.podSelector" || rel.Details["selector"] != "app=web" {
+		t.Errorf("unexpected field/details: %q %v", rel.Field, rel.Details)
+	}
+}
+
+func TestNetworkPolicyDetector_EmptyPodSelectorSelectsNamespace(t *testing.T) {
+	web := makeNPDeployment("web", "default", map[string]interface{}{"app": "web"})
+	api := makeNPDeployment("api", "default", map[string]interface{}{"app": "api"})
+	np := makeNetworkPolicy("deny-all", "default", map[string]interface{}{
+		"podSelector": map[string]interface{}{},
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(web, api, np))
+	if got := relsOfType(rels, types.RelationNetworkPolicy); len(got) != 2 {
+		t.Errorf("expected both workloads selected, got %v", got)
+	}
+}
+
+func TestNetworkPolicyDetector_MatchExpressions(t *testing.T) {
+	web := makeNPDeployment("web", "default", map[string]interface{}{"tier": "frontend"})
+	db := makeNPDeployment("db", "default", map[string]interface{}{"tier": "data"})
+	np := makeNetworkPolicy("frontend", "default", map[string]interface{}{
+		"podSelector": map[string]interface{}{
+			"matchExpressions": []interface{}{
+				map[string]interface{}{"key": "tier", "operator": "In", "values": []interface{}{"frontend", "edge"}},
+			},
+		},
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(web, db, np))
+	if len(rels) != 1 || rels[0].To != web.Original.ResourceKey() {
+		t.Errorf("expected only web to be selected, got %v", rels)
+	}
+}
+
+func TestNetworkPolicyDetector_IngressPeers(t *testing.T) {
+	api := makeNPDeployment("api", "default", map[string]interface{}{"app": "api"})
+	web := makeNPDeployment("web", "default", map[string]interface{}{"app": "web"})
+	batch := makeNPDeployment("batch", "default", map[string]interface{}{"app": "batch"})
+	np := makeNetworkPolicy("api-ingress", "default", map[string]interface{}{
+		"podSelector": matchLabels("app", "api"),
+		"ingress": []interface{}{
+			map[string]interface{}{
+				"from": []interface{}{
+					map[string]interface{}{"podSelector": matchLabels("app", "web")},
+				},
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(8080)},
+					map[string]interface{}{"port": int64(9090), "protocol": "UDP"},
+				},
+			},
+		},
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(api, web, batch, np))
+
+	peers := relsOfType(rels, types.RelationNetworkPeer)
+	if len(peers) != 1 {
+		t.Fatalf("expected 1 peer relationship, got %v", peers)
+	}
+	peer := peers[0]
+	if peer.From != web.Original.ResourceKey() || peer.To != api.Original.ResourceKey() {
+		t.Errorf("expected web → api, got %s → %s", peer.From, peer.To)
+	}
+	if peer.Field != "spec.ingress[0].from" {
+		t.Errorf("unexpected field %q", peer.Field)
+	}
+	if peer.Details["direction"] != "ingress" || peer.Details["policy"] != "api-ingress" || peer.Details["ports"] != "TCP/8080,UDP/9090" {
+		t.Errorf("unexpected details: %v", peer.Details)
+	}
+}
+
+func TestNetworkPolicyDetector_EgressPeers(t *testing.T) {
+	web := makeNPDeployment("web", "default", map[string]interface{}{"app": "web"})
+	api := makeNPDeployment("api", "default", map[string]interface{}{"app": "api"})
+	np := makeNetworkPolicy("web-egress", "default", map[string]interface{}{
+		"podSelector": matchLabels("app", "web"),
+		"egress": []interface{}{
+			map[string]interface{}{
+				"to": []interface{}{
+					map[string]interface{}{"podSelector": matchLabels("app", "api")},
+					map[string]interface{}{"ipBlock": map[string]interface{}{"cidr": "10.0.0.0/8"}},
+				},
+			},
+		},
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(web, api, np))
+
+	peers := relsOfType(rels, types.RelationNetworkPeer)
+	if len(peers) != 1 {
+		t.Fatalf("expected 1 peer relationship, got %v", peers)
+	}
+	if peers[0].From != web.Original.ResourceKey() || peers[0].To != api.Original.ResourceKey() {
+		t.Errorf("expected web → api, got %s → %s", peers[0].From, peers[0].To)
+	}
+	if peers[0].Details["direction"] != "egress" || peers[0].Details["ports"] != "" {
+		t.Errorf("unexpected details: %v", peers[0].Details)
+	}
+}
+
+func TestNetworkPolicyDetector_NamespaceSelector(t *testing.T) {
+	api := makeNPDeployment("api", "backend", map[string]interface{}{"app": "api"})
+	web := makeNPDeployment("web", "frontend", map[string]interface{}{"app": "web"})
+	admin := makeNPDeployment("admin", "ops", map[string]interface{}{"app": "web"})
+	opsNS := makeProcessedResource("v1", "Namespace", "ops", "", map[string]interface{}{"team": "ops"}, nil, nil)
+	np := makeNetworkPolicy("api", "backend", map[string]interface{}{
+		"podSelector": matchLabels("app", "api"),
+		"ingress": []interface{}{
+			map[string]interface{}{
+				"from": []interface{}{
+					// By well-known name label.
+					map[string]interface{}{
+						"namespaceSelector": matchLabels("kubernetes.io/metadata.name", "frontend"),
+						"podSelector":       matchLabels("app", "web"),
+					},
+					// By labels of a Namespace object present in the input.
+					map[string]interface{}{"namespaceSelector": matchLabels("team", "ops")},
+				},
+			},
+		},
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(api, web, admin, opsNS, np))
+
+	peers := relsOfType(rels, types.RelationNetworkPeer)
+	if len(peers) != 2 {
+		t.Fatalf("expected 2 peer relationships, got %v", peers)
+	}
+	from := map[types.ResourceKey]bool{peers[0].From: true, peers[1].From: true}
+	if !from[web.Original.ResourceKey()] || !from[admin.Original.ResourceKey()] {
+		t.Errorf("expected web and admin as peers, got %v", peers)
+	}
+}
+
+func TestNetworkPolicyDetector_NoSelfPeers(t *testing.T) {
+	web := makeNPDeployment("web", "default", map[string]interface{}{"app": "web"})
+	np := makeNetworkPolicy("same-app", "default", map[string]interface{}{
+		"podSelector": matchLabels("app", "web"),
+		"ingress": []interface{}{
+			map[string]interface{}{
+				"from": []interface{}{map[string]interface{}{"podSelector": map[string]interface{}{}}},
+			},
+		},
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(web, np))
+	if got := relsOfType(rels, types.RelationNetworkPeer); len(got) != 0 {
+		t.Errorf("expected no self peer relationship, got %v", got)
+	}
+}
+
+func TestNetworkPolicyDetector_CronJobPods(t *testing.T) {
+	job := makeProcessedResource("batch/v1", "CronJob", "report", "default", nil, nil, map[string]interface{}{
+		"jobTemplate": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "report"}},
+				},
+			},
+		},
+	})
+	np := makeNetworkPolicy("report", "default", map[string]interface{}{
+		"podSelector": matchLabels("app", "report"),
+	})
+
+	rels := NewNetworkPolicyDetector().Detect(context.Background(), np, buildAllResources(job, np))
+	if len(rels) != 1 || rels[0].To != job.Original.ResourceKey() {
+		t.Errorf("expected the CronJob to be selected, got %v", rels)
+	}
+}
@@ -2,6 +2,7 @@ package detector
 
 import (
 	"context"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -44,17 +45,29 @@ func (d *NameReferenceDetector) Detect(ctx context.Context, resource *types.Proc
 		relationships = append(relationships, d.detectIngressToService(resource, allResources)...)
 	case "StatefulSet":
 		relationships = append(relationships, d.detectStatefulSetToService(resource, allResources)...)
+		relationships = append(relationships, d.detectEnvServiceURLs(resource, allResources)...)
+	case "Deployment", "DaemonSet":
+		relationships = append(relationships, d.detectEnvServiceURLs(resource, allResources)...)
+	case "ConfigMap":
+		relationships = append(relationships, d.detectConfigServiceURLs(resource, allResources)...)
 	case "RoleBinding":
 		relationships = append(relationships, d.detectRoleBindingReferences(resource, allResources)...)
 	case "ClusterRoleBinding":
 		relationships = append(relationships, d.detectClusterRoleBindingReferences(resource, allResources)...)
-	case "PersistentVolumeClaim":
+	case "PersistentVolumeClaim", "PersistentVolume":
 		relationships = append(relationships, d.detectPVCToStorageClass(resource, allResources)...)
+	case "Certificate":
+		relationships = append(relationships, d.detectCertificateToIssuer(resource, allResources)...)
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		relationships = append(relationships, d.detectWebhookReferences(resource, allResources)...)
 	}
 
 	// Common: ServiceAccount references
 	relationships = append(relationships, d.detectServiceAccountReferences(resource, allResources)...)
 
+	// Common: PriorityClass references
+	relationships = append(relationships, d.detectPriorityClassReferences(resource, allResources)...)
+
 	// Common: ImagePullSecrets references
 	relationships = append(relationships, d.detectImagePullSecretReferences(resource, allResources)...)
 
@@ -153,6 +166,27 @@ func (d *NameReferenceDetector) detectIngressToService(resource *types.Processed
 					},
 				})
 			}
+
+			// cert-manager Certificates issuing the TLS secret
+			for key, r := range allResources {
+				if key.GVK.Group != "cert-manager.io" || key.GVK.Kind != "Certificate" || key.Namespace != namespace {
+					continue
+				}
+				certSecret, _, _ := unstructured.NestedString(r.Original.Object.Object, "spec", "secretName")
+				if certSecret != secretName {
+					continue
+				}
+				relationships = append(relationships, types.Relationship{
+					From:  resource.Original.ResourceKey(),
+					To:    key,
+					Type:  types.RelationNameReference,
+					Field: "spec.tls[].secretName",
+					Details: map[string]string{
+						"secretName":  secretName,
+						"certificate": key.Name,
+					},
+				})
+			}
 		}
 	}
 
@@ -343,6 +377,50 @@ func (d *NameReferenceDetector) detectServiceAccountReferences(resource *types.P
 	return relationships
 }
 
+// detectPriorityClassReferences detects PriorityClass references in workloads.
+// PriorityClasses are cluster-scoped.
+func (d *NameReferenceDetector) detectPriorityClassReferences(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+
+	var field []string
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet", "DaemonSet", "Job":
+		field = []string{"spec", "template", "spec", "priorityClassName"}
+	case "CronJob":
+		field = []string{"spec", "jobTemplate", "spec", "template", "spec", "priorityClassName"}
+	case "Pod":
+		field = []string{"spec", "priorityClassName"}
+	default:
+		return relationships
+	}
+
+	name, found, _ := unstructured.NestedString(obj.Object, field...)
+	if !found || name == "" {
+		return relationships
+	}
+
+	targetKey := types.ResourceKey{
+		GVK:  schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+		Name: name,
+	}
+
+	if _, exists := allResources[targetKey]; exists {
+		relationships = append(relationships, types.Relationship{
+			From:  resource.Original.ResourceKey(),
+			To:    targetKey,
+			Type:  types.RelationPriorityClass,
+			Field: strings.Join(field, "."),
+			Details: map[string]string{
+				"priorityClassName": name,
+			},
+		})
+	}
+
+	return relationships
+}
+
 // detectImagePullSecretReferences detects imagePullSecrets references.
 func (d *NameReferenceDetector) detectImagePullSecretReferences(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
 	var relationships []types.Relationship
@@ -412,7 +490,8 @@ func (d *NameReferenceDetector) detectImagePullSecretReferences(resource *types.
 	return relationships
 }
 
-// detectPVCToStorageClass detects PVC -> StorageClass relationships via spec.storageClassName.
+// detectPVCToStorageClass detects PVC (and PersistentVolume) -> StorageClass
+// relationships via spec.storageClassName.
 func (d *NameReferenceDetector) detectPVCToStorageClass(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
 	var relationships []types.Relationship
 
@@ -442,3 +521,118 @@ func (d *NameReferenceDetector) detectPVCToStorageClass(resource *types.Processe
 
 	return relationships
 }
+
+// detectCertificateToIssuer detects cert-manager Certificate -> Issuer/ClusterIssuer relationships.
+func (d *NameReferenceDetector) detectCertificateToIssuer(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+	if resource.Original.GVK.Group != "cert-manager.io" {
+		return relationships
+	}
+
+	name, found, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "name")
+	if !found || name == "" {
+		return relationships
+	}
+	group, _, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "group")
+	if group != "" && group != "cert-manager.io" {
+		return relationships
+	}
+
+	// issuerRef.kind defaults to a namespaced Issuer
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "issuerRef", "kind")
+	namespace := obj.GetNamespace()
+	switch kind {
+	case "", "Issuer":
+		kind = "Issuer"
+	case "ClusterIssuer":
+		namespace = ""
+	default:
+		return relationships
+	}
+
+	targetKey := types.ResourceKey{
+		GVK:       schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind},
+		Namespace: namespace,
+		Name:      name,
+	}
+
+	if _, exists := allResources[targetKey]; exists {
+		relationships = append(relationships, types.Relationship{
+			From:  resource.Original.ResourceKey(),
+			To:    targetKey,
+			Type:  types.RelationNameReference,
+			Field: "spec.issuerRef",
+			Details: map[string]string{
+				"issuerKind": kind,
+				"issuerName": name,
+			},
+		})
+	}
+
+	return relationships
+}
+
+// detectWebhookReferences detects webhook configuration -> Service
+// relationships (webhooks[].clientConfig.service) and -> Certificate
+// relationships (cert-manager.io/inject-ca-from annotation).
+func (d *NameReferenceDetector) detectWebhookReferences(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+	if resource.Original.GVK.Group != "admissionregistration.k8s.io" {
+		return relationships
+	}
+	from := resource.Original.ResourceKey()
+
+	seen := make(map[types.ResourceKey]bool)
+	webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+	for _, item := range webhooks {
+		webhook, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "name")
+		namespace, _, _ := unstructured.NestedString(webhook, "clientConfig", "service", "namespace")
+		if name == "" {
+			continue
+		}
+		targetKey := types.ResourceKey{
+			GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+			Namespace: namespace,
+			Name:      name,
+		}
+		if _, exists := allResources[targetKey]; exists && !seen[targetKey] {
+			seen[targetKey] = true
+			webhookName, _ := webhook["name"].(string)
+			relationships = append(relationships, types.Relationship{
+				From:  from,
+				To:    targetKey,
+				Type:  types.RelationNameReference,
+				Field: "webhooks[].clientConfig.service",
+				Details: map[string]string{
+					"webhook": webhookName,
+				},
+			})
+		}
+	}
+
+	if namespace, name, ok := strings.Cut(obj.GetAnnotations()["cert-manager.io/inject-ca-from"], "/"); ok && name != "" {
+		targetKey := types.ResourceKey{
+			GVK:       schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			Namespace: namespace,
+			Name:      name,
+		}
+		if _, exists := allResources[targetKey]; exists {
+			relationships = append(relationships, types.Relationship{
+				From:  from,
+				To:    targetKey,
+				Type:  types.RelationNameReference,
+				Field: "metadata.annotations[cert-manager.io/inject-ca-from]",
+			})
+		}
+	}
+
+	return relationships
+}
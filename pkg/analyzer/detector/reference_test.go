@@ -987,6 +987,23 @@ func TestReferenceDetector_PVCToStorageClass(t *testing.T) {
 	}
 }
 
+// TestReferenceDetector_PersistentVolumeToStorageClass verifies that a
+// PersistentVolume references its StorageClass like a PVC does.
+func TestReferenceDetector_PersistentVolumeToStorageClass(t *testing.T) {
+	pv := makeProcessedResourceExtra("v1", "PersistentVolume", "data", "", map[string]interface{}{
+		"spec": map[string]interface{}{"storageClassName": "fast-ssd"},
+	})
+	sc := makeProcessedResourceExtra("storage.k8s.io/v1", "StorageClass", "fast-ssd", "", nil)
+
+	rels := NewNameReferenceDetector().Detect(context.Background(), pv, buildAllResources(pv, sc))
+	if len(rels) != 1 || rels[0].Type != types.RelationStorageClass || rels[0].To != sc.Original.ResourceKey() {
+		t.Errorf("expected PersistentVolume → StorageClass fast-ssd, got %v", rels)
+	}
+	if rels[0].JoinsGroup() {
+		t.Error("a StorageClass is shared and should not join groups")
+	}
+}
+
 // TestReferenceDetector_PVCMissingStorageClass verifies no relationship when StorageClass is absent.
 func TestReferenceDetector_PVCMissingStorageClass(t *testing.T) {
 	pvc := makeProcessedResourceExtra(
@@ -1576,3 +1593,133 @@ func TestReferenceDetector_RoleBindingSubjectsNonMapEntry(t *testing.T) {
 		t.Errorf("expected role_binding relationship to ServiceAccount %q after skipping non-map subject, got: %v", saName, rels)
 	}
 }
+
+// TestReferenceDetector_IngressTLSCertificate verifies that an Ingress is linked
+// to the cert-manager Certificate that issues its TLS secret.
+func TestReferenceDetector_IngressTLSCertificate(t *testing.T) {
+	ingress := makeProcessedResource("networking.k8s.io/v1", "Ingress", "web", "default", nil, nil, map[string]interface{}{
+		"rules": []interface{}{},
+		"tls": []interface{}{
+			map[string]interface{}{"hosts": []interface{}{"example.com"}, "secretName": "web-tls"},
+		},
+	})
+	cert := makeProcessedResource("cert-manager.io/v1", "Certificate", "web", "default", nil, nil, map[string]interface{}{
+		"secretName": "web-tls",
+	})
+	otherNS := makeProcessedResource("cert-manager.io/v1", "Certificate", "web", "other", nil, nil, map[string]interface{}{
+		"secretName": "web-tls",
+	})
+	otherSecret := makeProcessedResource("cert-manager.io/v1", "Certificate", "api", "default", nil, nil, map[string]interface{}{
+		"secretName": "api-tls",
+	})
+
+	rels := NewNameReferenceDetector().Detect(context.Background(), ingress, buildAllResources(ingress, cert, otherNS, otherSecret))
+
+	if len(rels) != 1 {
+		t.Fatalf("expected 1 relationship, got %d: %v", len(rels), rels)
+	}
+	if rels[0].To != cert.Original.ResourceKey() || rels[0].Details["certificate"] != "web" {
+		t.Errorf("expected Ingress → Certificate web, got %+v", rels[0])
+	}
+}
+
+// TestReferenceDetector_CertificateToIssuer verifies issuerRef resolution for
+// both the default namespaced Issuer and a ClusterIssuer.
+func TestReferenceDetector_CertificateToIssuer(t *testing.T) {
+	issuer := makeProcessedResource("cert-manager.io/v1", "Issuer", "selfsigned", "default", nil, nil, nil)
+	clusterIssuer := makeProcessedResource("cert-manager.io/v1", "ClusterIssuer", "letsencrypt", "", nil, nil, nil)
+	namespaced := makeProcessedResource("cert-manager.io/v1", "Certificate", "internal", "default", nil, nil, map[string]interface{}{
+		"issuerRef": map[string]interface{}{"name": "selfsigned"},
+	})
+	cluster := makeProcessedResource("cert-manager.io/v1", "Certificate", "public", "default", nil, nil, map[string]interface{}{
+		"issuerRef": map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer", "group": "cert-manager.io"},
+	})
+	external := makeProcessedResource("cert-manager.io/v1", "Certificate", "external", "default", nil, nil, map[string]interface{}{
+		"issuerRef": map[string]interface{}{"name": "letsencrypt", "kind": "AWSPCAClusterIssuer", "group": "awspca.cert-manager.io"},
+	})
+	all := buildAllResources(issuer, clusterIssuer, namespaced, cluster, external)
+	d := NewNameReferenceDetector()
+
+	rels := d.Detect(context.Background(), namespaced, all)
+	if len(rels) != 1 || rels[0].To != issuer.Original.ResourceKey() || rels[0].Field != "spec.issuerRef" {
+		t.Errorf("expected Certificate → Issuer selfsigned, got %v", rels)
+	}
+
+	rels = d.Detect(context.Background(), cluster, all)
+	if len(rels) != 1 || rels[0].To != clusterIssuer.Original.ResourceKey() || rels[0].Details["issuerKind"] != "ClusterIssuer" {
+		t.Errorf("expected Certificate → ClusterIssuer letsencrypt, got %v", rels)
+	}
+
+	if rels := d.Detect(context.Background(), external, all); len(rels) != 0 {
+		t.Errorf("expected no relationship for an external issuer, got %v", rels)
+	}
+}
+
+// TestReferenceDetector_WebhookReferences verifies webhook configuration
+// references to the Services it calls and to the Certificate cert-manager
+// injects the CA from.
+func TestReferenceDetector_WebhookReferences(t *testing.T) {
+	svc := makeProcessedResource("v1", "Service", "op-webhook", "op-system", nil, nil, nil)
+	cert := makeProcessedResource("cert-manager.io/v1", "Certificate", "op-serving-cert", "op-system", nil, nil, nil)
+	service := func(path string) map[string]interface{} {
+		return map[string]interface{}{"service": map[string]interface{}{"name": "op-webhook", "namespace": "op-system", "path": path}}
+	}
+	cfg := makeProcessedResourceExtra("admissionregistration.k8s.io/v1", "ValidatingWebhookConfiguration", "op", "", map[string]interface{}{
+		"webhooks": []interface{}{
+			map[string]interface{}{"name": "a.example.com", "clientConfig": service("/a")},
+			map[string]interface{}{"name": "b.example.com", "clientConfig": service("/b")},
+			map[string]interface{}{"name": "ext.example.com", "clientConfig": map[string]interface{}{"url": "https://hooks.example.com"}},
+		},
+	})
+	cfg.Original.Object.SetAnnotations(map[string]string{"cert-manager.io/inject-ca-from": "op-system/op-serving-cert"})
+	all := buildAllResources(svc, cert, cfg)
+
+	rels := NewNameReferenceDetector().Detect(context.Background(), cfg, all)
+	if len(rels) != 2 {
+		t.Fatalf("expected 2 relationships, got %v", rels)
+	}
+	if rels[0].To != svc.Original.ResourceKey() || rels[0].Field != "webhooks[].clientConfig.service" || rels[0].Details["webhook"] != "a.example.com" {
+		t.Errorf("expected webhook configuration → Service op-webhook, got %+v", rels[0])
+	}
+	if rels[1].To != cert.Original.ResourceKey() || rels[1].Type != types.RelationNameReference {
+		t.Errorf("expected webhook configuration → Certificate op-serving-cert, got %+v", rels[1])
+	}
+
+	if rels := NewNameReferenceDetector().Detect(context.Background(), cfg, buildAllResources(cfg)); len(rels) != 0 {
+		t.Errorf("expected no relationships to resources outside the input, got %v", rels)
+	}
+}
+
+func TestReferenceDetector_DeploymentToPriorityClass(t *testing.T) {
+	deploy := makeProcessedResource(
+		"apps/v1", "Deployment", "my-deploy", "default",
+		nil, nil,
+		map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"priorityClassName": "critical",
+					"containers": []interface{}{
+						map[string]interface{}{"name": "main", "image": "nginx:latest"},
+					},
+				},
+			},
+		},
+	)
+	pc := makeProcessedResourceExtra("scheduling.k8s.io/v1", "PriorityClass", "critical", "", map[string]interface{}{"value": int64(1000000)})
+
+	d := NewNameReferenceDetector()
+	rels := d.Detect(context.Background(), deploy, buildAllResources(deploy, pc))
+
+	found := false
+	for _, rel := range rels {
+		if rel.Type == types.RelationPriorityClass && rel.To == pc.Original.ResourceKey() {
+			found = true
+			if rel.Field != "spec.template.spec.priorityClassName" {
+				t.Errorf("Field = %q", rel.Field)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a priority_class relationship to %v, got %v", pc.Original.ResourceKey(), rels)
+	}
+}
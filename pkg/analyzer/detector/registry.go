@@ -11,4 +11,6 @@ func RegisterAll(a *analyzer.DefaultAnalyzer) {
 	a.AddDetector(NewVolumeMountDetector())
 	a.AddDetector(NewAnnotationDetector())
 	a.AddDetector(NewDeckhouseDetector())
+	a.AddDetector(NewNetworkPolicyDetector())
+	a.AddDetector(NewRouteDetector())
 }
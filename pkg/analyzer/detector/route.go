@@ -0,0 +1,277 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const (
+	gatewayAPIGroup = "gateway.networking.k8s.io"
+	istioGroup      = "networking.istio.io"
+)
+
+// RouteDetector detects routing relationships of Gateway API routes and Istio
+// traffic management resources.
+// Example: HTTPRoute → Service (backendRefs), HTTPRoute → Gateway (parentRefs),
+// VirtualService → Service (route destinations), DestinationRule → Service (host).
+type RouteDetector struct {
+	priority int
+}
+
+// NewRouteDetector creates a new route detector.
+func NewRouteDetector() *RouteDetector {
+	return &RouteDetector{
+		priority: 90,
+	}
+}
+
+// Name returns the detector name.
+func (d *RouteDetector) Name() string {
+	return "route"
+}
+
+// Priority returns the detector priority.
+func (d *RouteDetector) Priority() int {
+	return d.priority
+}
+
+// Detect detects route → backend and route → gateway relationships.
+func (d *RouteDetector) Detect(ctx context.Context, resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	gvk := resource.Original.GVK
+	switch gvk.Group {
+	case gatewayAPIGroup:
+		switch gvk.Kind {
+		case "HTTPRoute", "GRPCRoute", "TLSRoute", "TCPRoute", "UDPRoute":
+			relationships = append(relationships, d.detectGatewayAPIRoute(resource, allResources)...)
+		}
+	case istioGroup:
+		switch gvk.Kind {
+		case "VirtualService":
+			relationships = append(relationships, d.detectVirtualService(resource, allResources)...)
+		case "DestinationRule":
+			relationships = append(relationships, d.detectDestinationRule(resource, allResources)...)
+		}
+	}
+
+	return relationships
+}
+
+// detectGatewayAPIRoute detects Gateway API route → Gateway and route → Service relationships.
+func (d *RouteDetector) detectGatewayAPIRoute(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+	routeKey := resource.Original.ResourceKey()
+	namespace := obj.GetNamespace()
+
+	parentRefs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	for _, p := range parentRefs {
+		ref, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, kind, refNamespace, name := objectRef(ref, gatewayAPIGroup, "Gateway", namespace)
+		targetKey, found := findResource(allResources, group, kind, refNamespace, name)
+		if !found {
+			continue
+		}
+		relationships = append(relationships, types.Relationship{
+			From:  routeKey,
+			To:    targetKey,
+			Type:  types.RelationGatewayRoute,
+			Field: "spec.parentRefs",
+			Details: map[string]string{
+				"gateway": name,
+			},
+		})
+	}
+
+	seen := make(map[types.ResourceKey]bool)
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for i, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, b := range backendRefs {
+			ref, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			group, kind, refNamespace, name := objectRef(ref, "", "Service", namespace)
+			targetKey, found := findResource(allResources, group, kind, refNamespace, name)
+			if !found || seen[targetKey] {
+				continue
+			}
+			seen[targetKey] = true
+
+			details := map[string]string{
+				"serviceName": name,
+			}
+			if port, ok := ref["port"]; ok {
+				details["port"] = fmt.Sprintf("%v", port)
+			}
+			relationships = append(relationships, types.Relationship{
+				From:    routeKey,
+				To:      targetKey,
+				Type:    types.RelationNameReference,
+				Field:   fmt.Sprintf("spec.rules[%d].backendRefs", i),
+				Details: details,
+			})
+		}
+	}
+
+	return relationships
+}
+
+// detectVirtualService detects VirtualService → Gateway and VirtualService → Service relationships.
+func (d *RouteDetector) detectVirtualService(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+	vsKey := resource.Original.ResourceKey()
+	namespace := obj.GetNamespace()
+
+	// "mesh" is reserved for the sidecars of the mesh; "ns/name" names another namespace.
+	gateways, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "gateways")
+	for _, gw := range gateways {
+		if gw == "mesh" {
+			continue
+		}
+		gwNamespace, gwName := namespace, gw
+		if i := strings.Index(gw, "/"); i >= 0 {
+			gwNamespace, gwName = gw[:i], gw[i+1:]
+		}
+		targetKey, found := findResource(allResources, istioGroup, "Gateway", gwNamespace, gwName)
+		if !found {
+			continue
+		}
+		relationships = append(relationships, types.Relationship{
+			From:  vsKey,
+			To:    targetKey,
+			Type:  types.RelationGatewayRoute,
+			Field: "spec.gateways",
+			Details: map[string]string{
+				"gateway": gw,
+			},
+		})
+	}
+
+	seen := make(map[types.ResourceKey]bool)
+	for _, protocol := range []string{"http", "tcp", "tls"} {
+		routes, _, _ := unstructured.NestedSlice(obj.Object, "spec", protocol)
+		for _, r := range routes {
+			route, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			for _, dst := range destinations {
+				dest, ok := dst.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(dest, "destination", "host")
+				targetKey, found := serviceForHost(allResources, host, namespace)
+				if !found || seen[targetKey] {
+					continue
+				}
+				seen[targetKey] = true
+
+				relationships = append(relationships, types.Relationship{
+					From:  vsKey,
+					To:    targetKey,
+					Type:  types.RelationNameReference,
+					Field: fmt.Sprintf("spec.%s[].route[].destination.host", protocol),
+					Details: map[string]string{
+						"host": host,
+					},
+				})
+			}
+		}
+	}
+
+	return relationships
+}
+
+// detectDestinationRule detects DestinationRule → Service relationships.
+func (d *RouteDetector) detectDestinationRule(resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	var relationships []types.Relationship
+
+	obj := resource.Original.Object
+	host, _, _ := unstructured.NestedString(obj.Object, "spec", "host")
+	targetKey, found := serviceForHost(allResources, host, obj.GetNamespace())
+	if !found {
+		return relationships
+	}
+
+	relationships = append(relationships, types.Relationship{
+		From:  resource.Original.ResourceKey(),
+		To:    targetKey,
+		Type:  types.RelationNameReference,
+		Field: "spec.host",
+		Details: map[string]string{
+			"host": host,
+		},
+	})
+
+	return relationships
+}
+
+// objectRef reads a Gateway API object reference, applying the defaults for
+// group, kind and namespace.
+func objectRef(ref map[string]interface{}, defaultGroup, defaultKind, defaultNamespace string) (group, kind, namespace, name string) {
+	group, kind, namespace = defaultGroup, defaultKind, defaultNamespace
+	if g, ok := ref["group"].(string); ok {
+		group = g
+	}
+	if k, ok := ref["kind"].(string); ok && k != "" {
+		kind = k
+	}
+	if ns, ok := ref["namespace"].(string); ok && ns != "" {
+		namespace = ns
+	}
+	name, _ = ref["name"].(string)
+	return group, kind, namespace, name
+}
+
+// serviceForHost resolves an Istio host to a Service among the resources.
+// Short names resolve in the referring namespace; "name.ns.svc[.cluster.local]"
+// names the namespace. Other hosts are external to the cluster.
+func serviceForHost(allResources map[types.ResourceKey]*types.ProcessedResource, host, namespace string) (types.ResourceKey, bool) {
+	if host == "" || strings.Contains(host, "*") {
+		return types.ResourceKey{}, false
+	}
+
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1:
+		return findResource(allResources, "", "Service", namespace, parts[0])
+	case len(parts) >= 3 && parts[2] == "svc":
+		return findResource(allResources, "", "Service", parts[1], parts[0])
+	default:
+		return types.ResourceKey{}, false
+	}
+}
+
+// findResource looks up a resource by group, kind, namespace and name,
+// regardless of the API version it was written in.
+func findResource(allResources map[types.ResourceKey]*types.ProcessedResource, group, kind, namespace, name string) (types.ResourceKey, bool) {
+	if name == "" {
+		return types.ResourceKey{}, false
+	}
+	for key := range allResources {
+		if key.GVK.Group == group && key.GVK.Kind == kind && key.Namespace == namespace && key.Name == name {
+			return key, true
+		}
+	}
+	return types.ResourceKey{}, false
+}
@@ -0,0 +1,135 @@
+package detector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func makeService(name, namespace string) *types.ProcessedResource {
+	return makeProcessedResource("v1", "Service", name, namespace, nil, nil, map[string]interface{}{})
+}
+
+func TestRouteDetector_NameAndPriority(t *testing.T) {
+	d := NewRouteDetector()
+	if d.Name() != "route" {
+		t.Errorf("Name() = %q", d.Name())
+	}
+	if d.Priority() != 90 {
+		t.Errorf("Priority() = %d", d.Priority())
+	}
+}
+
+func TestRouteDetector_HTTPRoute(t *testing.T) {
+	web := makeService("web", "default")
+	api := makeService("api", "backend")
+	gw := makeProcessedResource("gateway.networking.k8s.io/v1", "Gateway", "public", "infra", nil, nil, map[string]interface{}{})
+	route := makeProcessedResource("gateway.networking.k8s.io/v1", "HTTPRoute", "web", "default", nil, nil, map[string]interface{}{
+		"parentRefs": []interface{}{
+			map[string]interface{}{"name": "public", "namespace": "infra"},
+			map[string]interface{}{"name": "missing"},
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": "web", "port": int64(80)},
+					map[string]interface{}{"name": "api", "namespace": "backend", "port": int64(8080)},
+					// Non-Service backends are not resolved to Services.
+					map[string]interface{}{"name": "web", "group": "example.com", "kind": "Bucket"},
+				},
+			},
+		},
+	})
+
+	rels := NewRouteDetector().Detect(context.Background(), route, buildAllResources(web, api, gw, route))
+
+	gwRels := relsOfType(rels, types.RelationGatewayRoute)
+	if len(gwRels) != 1 || gwRels[0].To != gw.Original.ResourceKey() {
+		t.Fatalf("expected route → Gateway public, got %v", gwRels)
+	}
+
+	backends := relsOfType(rels, types.RelationNameReference)
+	if len(backends) != 2 {
+		t.Fatalf("expected 2 backend relationships, got %v", backends)
+	}
+	if backends[0].To != web.Original.ResourceKey() || backends[0].Details["port"] != "80" {
+		t.Errorf("unexpected first backend: %+v", backends[0])
+	}
+	if backends[1].To != api.Original.ResourceKey() || backends[1].Field != "spec.rules[0].backendRefs" {
+		t.Errorf("unexpected second backend: %+v", backends[1])
+	}
+}
+
+func TestRouteDetector_VirtualService(t *testing.T) {
+	reviews := makeService("reviews", "default")
+	ratings := makeService("ratings", "prod")
+	gw := makeProcessedResource("networking.istio.io/v1beta1", "Gateway", "ingress", "istio-system", nil, nil, map[string]interface{}{})
+	vs := makeProcessedResource("networking.istio.io/v1", "VirtualService", "reviews", "default", nil, nil, map[string]interface{}{
+		"hosts":    []interface{}{"reviews.example.com"},
+		"gateways": []interface{}{"mesh", "istio-system/ingress"},
+		"http": []interface{}{
+			map[string]interface{}{
+				"route": []interface{}{
+					map[string]interface{}{"destination": map[string]interface{}{"host": "reviews", "subset": "v1"}},
+					map[string]interface{}{"destination": map[string]interface{}{"host": "reviews", "subset": "v2"}},
+				},
+			},
+		},
+		"tcp": []interface{}{
+			map[string]interface{}{
+				"route": []interface{}{
+					map[string]interface{}{"destination": map[string]interface{}{"host": "ratings.prod.svc.cluster.local"}},
+					map[string]interface{}{"destination": map[string]interface{}{"host": "api.example.com"}},
+				},
+			},
+		},
+	})
+
+	rels := NewRouteDetector().Detect(context.Background(), vs, buildAllResources(reviews, ratings, gw, vs))
+
+	gwRels := relsOfType(rels, types.RelationGatewayRoute)
+	if len(gwRels) != 1 || gwRels[0].To != gw.Original.ResourceKey() {
+		t.Fatalf("expected VirtualService → Gateway ingress, got %v", gwRels)
+	}
+
+	dests := relsOfType(rels, types.RelationNameReference)
+	if len(dests) != 2 {
+		t.Fatalf("expected 2 destination relationships, got %v", dests)
+	}
+	if dests[0].To != reviews.Original.ResourceKey() || dests[0].Field != "spec.http[].route[].destination.host" {
+		t.Errorf("unexpected http destination: %+v", dests[0])
+	}
+	if dests[1].To != ratings.Original.ResourceKey() || dests[1].Details["host"] != "ratings.prod.svc.cluster.local" {
+		t.Errorf("unexpected tcp destination: %+v", dests[1])
+	}
+}
+
+func TestRouteDetector_DestinationRule(t *testing.T) {
+	reviews := makeService("reviews", "default")
+	dr := makeProcessedResource("networking.istio.io/v1beta1", "DestinationRule", "reviews", "default", nil, nil, map[string]interface{}{
+		"host": "reviews.default.svc.cluster.local",
+	})
+	external := makeProcessedResource("networking.istio.io/v1beta1", "DestinationRule", "external", "default", nil, nil, map[string]interface{}{
+		"host": "*.example.com",
+	})
+	all := buildAllResources(reviews, dr, external)
+
+	rels := NewRouteDetector().Detect(context.Background(), dr, all)
+	if len(rels) != 1 || rels[0].To != reviews.Original.ResourceKey() || rels[0].Field != "spec.host" {
+		t.Errorf("expected DestinationRule → Service reviews, got %v", rels)
+	}
+
+	if rels := NewRouteDetector().Detect(context.Background(), external, all); len(rels) != 0 {
+		t.Errorf("expected no relationship for a wildcard host, got %v", rels)
+	}
+}
+
+func TestRouteDetector_IgnoresOtherKinds(t *testing.T) {
+	// A Gateway API Gateway has the same kind as an Istio Gateway but routes nothing.
+	gw := makeProcessedResource("gateway.networking.k8s.io/v1", "Gateway", "public", "default", nil, nil, map[string]interface{}{})
+	svc := makeService("web", "default")
+	if rels := NewRouteDetector().Detect(context.Background(), gw, buildAllResources(gw, svc)); len(rels) != 0 {
+		t.Errorf("expected no relationships, got %v", rels)
+	}
+}
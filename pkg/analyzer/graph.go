@@ -45,6 +45,13 @@ var edgeStyles = map[types.RelationshipType]string{
 	types.RelationPVC:              "dotted",
 	types.RelationGatewayRoute:     "solid",
 	types.RelationScaleTarget:      "bold",
+	types.RelationPriorityClass:    "dashed",
+	types.RelationNetworkPolicy:    "dashed",
+	types.RelationNetworkPeer:      "dotted",
+	types.RelationEnvServiceURL:    "dotted",
+	types.RelationConfigServiceURL: "dotted",
+	types.RelationServiceMonitor:   "dashed",
+	types.RelationPodMonitor:       "dashed",
 	types.RelationCustomDependency: "solid",
 }
 
@@ -63,44 +70,46 @@ func GenerateDOTGraph(graph *types.ResourceGraph) string {
 	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
 	b.WriteString("\n")
 
-	// Collect and sort resource keys for deterministic output
-	keys := make([]types.ResourceKey, 0, len(graph.Resources))
-	for k := range graph.Resources {
-		keys = append(keys, k)
-	}
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i].String() < keys[j].String()
-	})
+	keys := sortedResourceKeys(graph)
 
-	// Emit nodes
-	for _, key := range keys {
+	// Emit nodes, clustering each service group so service boundaries are visible.
+	writeNode := func(indent string, key types.ResourceKey) {
 		nodeID := sanitizeDOTID(key.String())
-		color := kindColors[key.GVK.Kind]
-		if color == "" {
-			color = "#C0C0C0" // default gray for unknown kinds
-		}
+		color := kindColor(key.GVK.Kind)
 
 		label := fmt.Sprintf("%s\\n%s", key.GVK.Kind, key.Name)
 		if key.Namespace != "" {
 			label += fmt.Sprintf("\\n(%s)", key.Namespace)
 		}
 
-		b.WriteString(fmt.Sprintf("  %q [label=%q, fillcolor=%q];\n", nodeID, label, color))
+		b.WriteString(fmt.Sprintf("%s%q [label=%q, fillcolor=%q];\n", indent, nodeID, label, color))
 	}
 
-	b.WriteString("\n")
+	grouped := make(map[types.ResourceKey]bool)
+	for _, group := range sortedGroups(graph) {
+		members := groupKeys(group, graph, grouped)
+		if len(members) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  subgraph %q {\n", "cluster_"+sanitizeDOTID(group.Name)))
+		b.WriteString(fmt.Sprintf("    label=%q;\n", group.Name))
+		b.WriteString("    style=rounded;\n")
+		for _, key := range members {
+			writeNode("    ", key)
+		}
+		b.WriteString("  }\n")
+	}
 
-	// Emit edges (sorted for determinism)
-	sortedRels := make([]types.Relationship, len(graph.Relationships))
-	copy(sortedRels, graph.Relationships)
-	sort.Slice(sortedRels, func(i, j int) bool {
-		if sortedRels[i].From.String() != sortedRels[j].From.String() {
-			return sortedRels[i].From.String() < sortedRels[j].From.String()
+	for _, key := range keys {
+		if !grouped[key] {
+			writeNode("  ", key)
 		}
-		return sortedRels[i].To.String() < sortedRels[j].To.String()
-	})
+	}
+
+	b.WriteString("\n")
 
-	for _, rel := range sortedRels {
+	// Emit edges (sorted for determinism)
+	for _, rel := range sortedRelationships(graph) {
 		fromID := sanitizeDOTID(rel.From.String())
 		toID := sanitizeDOTID(rel.To.String())
 		style := edgeStyles[rel.Type]
@@ -116,6 +125,41 @@ func GenerateDOTGraph(graph *types.ResourceGraph) string {
 	return b.String()
 }
 
+// kindColor returns the node color for a resource kind (gray for unknown kinds).
+func kindColor(kind string) string {
+	if color, ok := kindColors[kind]; ok {
+		return color
+	}
+	return "#C0C0C0"
+}
+
+// sortedGroups returns the graph's service groups ordered by name.
+func sortedGroups(graph *types.ResourceGraph) []*types.ResourceGroup {
+	groups := append([]*types.ResourceGroup(nil), graph.Groups...)
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// groupKeys returns the sorted keys of a group's resources that are present in
+// the graph and not already in seen, marking them as seen so a resource is
+// only attributed to the first group listing it.
+func groupKeys(group *types.ResourceGroup, graph *types.ResourceGraph, seen map[types.ResourceKey]bool) []types.ResourceKey {
+	keys := make([]types.ResourceKey, 0, len(group.Resources))
+	for _, r := range group.Resources {
+		key := r.Original.ResourceKey()
+		if _, ok := graph.Resources[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
 // sanitizeDOTID converts a resource key string to a valid DOT node identifier.
 func sanitizeDOTID(s string) string {
 	replacer := strings.NewReplacer("/", "_", ".", "_", "-", "_", " ", "_")
@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// GenerateMermaidGraph produces a Mermaid flowchart from a ResourceGraph.
+// Service groups become subgraphs, nodes are colored by resource kind and
+// edges are labeled by relationship type (line style follows edgeStyles).
+func GenerateMermaidGraph(graph *types.ResourceGraph) string {
+	if graph == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	keys := sortedResourceKeys(graph)
+
+	writeNode := func(indent string, key types.ResourceKey) {
+		label := key.GVK.Kind + "<br/>" + key.Name
+		if key.Namespace != "" {
+			label += "<br/>(" + key.Namespace + ")"
+		}
+		b.WriteString(fmt.Sprintf("%s%s[\"%s\"]:::%s\n", indent, sanitizeDOTID(key.String()), label, mermaidClass(key.GVK.Kind)))
+	}
+
+	grouped := make(map[types.ResourceKey]bool)
+	for _, group := range sortedGroups(graph) {
+		members := groupKeys(group, graph, grouped)
+		if len(members) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("  subgraph %s[\"%s\"]\n", "svc_"+sanitizeDOTID(group.Name), group.Name))
+		for _, key := range members {
+			writeNode("    ", key)
+		}
+		b.WriteString("  end\n")
+	}
+	for _, key := range keys {
+		if !grouped[key] {
+			writeNode("  ", key)
+		}
+	}
+
+	for _, rel := range sortedRelationships(graph) {
+		arrow := "-->"
+		switch edgeStyles[rel.Type] {
+		case "dashed", "dotted":
+			arrow = "-.->"
+		case "bold":
+			arrow = "==>"
+		}
+		b.WriteString(fmt.Sprintf("  %s %s|%s| %s\n",
+			sanitizeDOTID(rel.From.String()), arrow, rel.Type, sanitizeDOTID(rel.To.String())))
+	}
+
+	// One class per kind present in the graph.
+	kinds := make(map[string]bool)
+	for _, key := range keys {
+		kinds[key.GVK.Kind] = true
+	}
+	kindNames := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		kindNames = append(kindNames, kind)
+	}
+	sort.Strings(kindNames)
+	for _, kind := range kindNames {
+		b.WriteString(fmt.Sprintf("  classDef %s fill:%s,stroke:#333,color:#000\n", mermaidClass(kind), kindColor(kind)))
+	}
+
+	return b.String()
+}
+
+// mermaidClass returns the Mermaid class name for a resource kind.
+func mermaidClass(kind string) string {
+	return "kind" + sanitizeDOTID(kind)
+}
+
+// GraphJSON is the JSON representation of a ResourceGraph.
+type GraphJSON struct {
+	Nodes  []GraphJSONNode  `json:"nodes"`
+	Edges  []GraphJSONEdge  `json:"edges"`
+	Groups []GraphJSONGroup `json:"groups"`
+}
+
+// GraphJSONNode is a resource in GraphJSON.
+type GraphJSONNode struct {
+	ID         string `json:"id"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Service    string `json:"service,omitempty"`
+	Color      string `json:"color"`
+}
+
+// GraphJSONEdge is a relationship in GraphJSON.
+type GraphJSONEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Type  string `json:"type"`
+	Field string `json:"field,omitempty"`
+}
+
+// GraphJSONGroup is a service group in GraphJSON.
+type GraphJSONGroup struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"`
+	Resources []string `json:"resources"`
+}
+
+// GenerateJSONGraph produces a JSON document with the graph's nodes, edges and
+// service groups. Node IDs are resource key strings (Kind/namespace/name).
+func GenerateJSONGraph(graph *types.ResourceGraph) ([]byte, error) {
+	out := GraphJSON{
+		Nodes:  []GraphJSONNode{},
+		Edges:  []GraphJSONEdge{},
+		Groups: []GraphJSONGroup{},
+	}
+
+	if graph != nil {
+		for _, key := range sortedResourceKeys(graph) {
+			node := GraphJSONNode{
+				ID:         key.String(),
+				APIVersion: key.GVK.GroupVersion().String(),
+				Kind:       key.GVK.Kind,
+				Namespace:  key.Namespace,
+				Name:       key.Name,
+				Color:      kindColor(key.GVK.Kind),
+			}
+			if r := graph.Resources[key]; r != nil {
+				node.Service = r.ServiceName
+			}
+			out.Nodes = append(out.Nodes, node)
+		}
+
+		for _, rel := range sortedRelationships(graph) {
+			out.Edges = append(out.Edges, GraphJSONEdge{
+				From:  rel.From.String(),
+				To:    rel.To.String(),
+				Type:  string(rel.Type),
+				Field: rel.Field,
+			})
+		}
+
+		seen := make(map[types.ResourceKey]bool)
+		for _, group := range sortedGroups(graph) {
+			g := GraphJSONGroup{Name: group.Name, Namespace: group.Namespace, Resources: []string{}}
+			for _, key := range groupKeys(group, graph, seen) {
+				g.Resources = append(g.Resources, key.String())
+			}
+			out.Groups = append(out.Groups, g)
+		}
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// sortedResourceKeys returns the graph's resource keys in string order.
+func sortedResourceKeys(graph *types.ResourceGraph) []types.ResourceKey {
+	keys := make([]types.ResourceKey, 0, len(graph.Resources))
+	for k := range graph.Resources {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// sortedRelationships returns the graph's relationships ordered by source,
+// target and type.
+func sortedRelationships(graph *types.ResourceGraph) []types.Relationship {
+	rels := append([]types.Relationship(nil), graph.Relationships...)
+	sort.Slice(rels, func(i, j int) bool {
+		if a, b := rels[i].From.String(), rels[j].From.String(); a != b {
+			return a < b
+		}
+		if a, b := rels[i].To.String(), rels[j].To.String(); a != b {
+			return a < b
+		}
+		return rels[i].Type < rels[j].Type
+	})
+	return rels
+}
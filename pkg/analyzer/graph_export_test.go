@@ -0,0 +1,135 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// buildGroupedTestGraph returns a graph with a "web" group (Deployment +
+// Service, linked by a label selector) and an ungrouped ConfigMap mounted by
+// the Deployment.
+func buildGroupedTestGraph() *types.ResourceGraph {
+	deploy := makeTestResource("Deployment", "web", "default", "web")
+	svc := makeTestResource("Service", "web-svc", "default", "web")
+	cm := makeTestResource("ConfigMap", "shared", "default", "")
+
+	graph := buildTestGraph(
+		[]*types.ProcessedResource{deploy, svc, cm},
+		[]types.Relationship{
+			{From: svc.Original.ResourceKey(), To: deploy.Original.ResourceKey(), Type: types.RelationLabelSelector},
+			{From: deploy.Original.ResourceKey(), To: cm.Original.ResourceKey(), Type: types.RelationVolumeMount, Field: "spec.template.spec.volumes"},
+		},
+	)
+	graph.AddGroup(&types.ResourceGroup{
+		Name:      "web",
+		Namespace: "default",
+		Resources: []*types.ProcessedResource{deploy, svc},
+	})
+	return graph
+}
+
+// ============================================================
+// DOT service clusters
+// ============================================================
+
+func TestGenerateDOTGraph_ServiceClusters(t *testing.T) {
+	result := GenerateDOTGraph(buildGroupedTestGraph())
+
+	testutil.AssertContains(t, result, `subgraph "cluster_web"`, "should cluster the web group")
+	testutil.AssertContains(t, result, `label="web";`, "cluster should be labeled with the group name")
+
+	// The ConfigMap is not part of any group and must be emitted outside the cluster.
+	clusterEnd := strings.Index(result, "  }\n")
+	cmNode := strings.Index(result, `"ConfigMap_default_shared"`)
+	if clusterEnd < 0 || cmNode < clusterEnd {
+		t.Errorf("expected ungrouped ConfigMap after the cluster, got:\n%s", result)
+	}
+}
+
+func TestGenerateDOTGraph_ResourceInSeveralGroups(t *testing.T) {
+	graph := buildGroupedTestGraph()
+	shared := makeTestResource("ConfigMap", "shared", "default", "")
+	graph.AddGroup(&types.ResourceGroup{Name: "a", Resources: []*types.ProcessedResource{shared}})
+	graph.AddGroup(&types.ResourceGroup{Name: "b", Resources: []*types.ProcessedResource{shared}})
+
+	result := GenerateDOTGraph(graph)
+	if n := strings.Count(result, `"ConfigMap_default_shared" [label="ConfigMap`); n != 1 {
+		t.Errorf("expected the shared ConfigMap node once, got %d times:\n%s", n, result)
+	}
+}
+
+// ============================================================
+// Mermaid
+// ============================================================
+
+func TestGenerateMermaidGraph_NilGraph(t *testing.T) {
+	if got := GenerateMermaidGraph(nil); got != "" {
+		t.Errorf("expected empty output for nil graph, got %q", got)
+	}
+}
+
+func TestGenerateMermaidGraph_NodesEdgesAndGroups(t *testing.T) {
+	result := GenerateMermaidGraph(buildGroupedTestGraph())
+
+	testutil.AssertContains(t, result, "flowchart LR\n", "should be a flowchart")
+	testutil.AssertContains(t, result, `subgraph svc_web["web"]`, "should render the service group")
+	testutil.AssertContains(t, result, `Deployment_default_web["Deployment<br/>web<br/>(default)"]:::kindDeployment`, "should render a labeled, classed node")
+	testutil.AssertContains(t, result, "Service_default_web_svc -->|label_selector| Deployment_default_web", "solid edge with label")
+	testutil.AssertContains(t, result, "Deployment_default_web -.->|volume_mount| ConfigMap_default_shared", "dotted edge with label")
+	testutil.AssertContains(t, result, "classDef kindDeployment fill:"+kindColors["Deployment"], "kind class should use the kind color")
+	testutil.AssertContains(t, result, "classDef kindConfigMap fill:"+kindColors["ConfigMap"], "kind class should use the kind color")
+}
+
+func TestGenerateMermaidGraph_DeterministicOutput(t *testing.T) {
+	graph := buildGroupedTestGraph()
+	testutil.AssertEqual(t, GenerateMermaidGraph(graph), GenerateMermaidGraph(graph), "Mermaid output should be deterministic")
+}
+
+// ============================================================
+// JSON
+// ============================================================
+
+func TestGenerateJSONGraph(t *testing.T) {
+	data, err := GenerateJSONGraph(buildGroupedTestGraph())
+	testutil.AssertNoError(t, err)
+
+	var out GraphJSON
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+
+	if len(out.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(out.Nodes))
+	}
+	// Nodes are sorted by ID: ConfigMap, Deployment, Service.
+	deploy := out.Nodes[1]
+	testutil.AssertEqual(t, "Deployment/default/web", deploy.ID)
+	testutil.AssertEqual(t, "apps/v1", deploy.APIVersion)
+	testutil.AssertEqual(t, "web", deploy.Service)
+	testutil.AssertEqual(t, kindColors["Deployment"], deploy.Color)
+
+	if len(out.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(out.Edges))
+	}
+	testutil.AssertEqual(t, "volume_mount", out.Edges[0].Type)
+	testutil.AssertEqual(t, "spec.template.spec.volumes", out.Edges[0].Field)
+
+	if len(out.Groups) != 1 || out.Groups[0].Name != "web" {
+		t.Fatalf("expected the web group, got %+v", out.Groups)
+	}
+	testutil.AssertEqual(t, []string{"Deployment/default/web", "Service/default/web-svc"}, out.Groups[0].Resources)
+}
+
+func TestGenerateJSONGraph_NilGraph(t *testing.T) {
+	data, err := GenerateJSONGraph(nil)
+	testutil.AssertNoError(t, err)
+
+	// Arrays must be present even when empty.
+	testutil.AssertContains(t, string(data), `"nodes": []`)
+	testutil.AssertContains(t, string(data), `"edges": []`)
+	testutil.AssertContains(t, string(data), `"groups": []`)
+}
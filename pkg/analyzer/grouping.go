@@ -0,0 +1,227 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// GroupByStrategy selects how resources are assigned to services before the
+// relationship heuristics run.
+type GroupByStrategy string
+
+const (
+	// GroupByDefault keeps the built-in heuristics (app labels, then resource name).
+	GroupByDefault GroupByStrategy = ""
+
+	// GroupByLabels assigns resources to the service named by the value of a label.
+	GroupByLabels GroupByStrategy = "labels"
+
+	// GroupByNamespace assigns every namespaced resource to a service named after its namespace.
+	GroupByNamespace GroupByStrategy = "namespace"
+
+	// GroupByOwner assigns resources to the service of their top-most ownerReference.
+	GroupByOwner GroupByStrategy = "owner"
+
+	// GroupByManual assigns only the resources pinned in a groups file.
+	GroupByManual GroupByStrategy = "manual"
+)
+
+// Grouping configures how resources are assigned to services. Manual
+// assignments always win over the strategy; resources the strategy cannot
+// place fall through to the default heuristics.
+type Grouping struct {
+	// Strategy is the grouping strategy.
+	Strategy GroupByStrategy
+
+	// LabelKey is the label used by GroupByLabels.
+	LabelKey string
+
+	// Manual pins specific resources into named services (groups.yaml).
+	Manual *GroupMapping
+}
+
+// ParseGroupBy converts a --group-by flag value to a Grouping.
+// Accepted values: labels:<key>, namespace, owner, manual.
+func ParseGroupBy(s string) (Grouping, error) {
+	strategy, arg, _ := strings.Cut(s, ":")
+	switch GroupByStrategy(strategy) {
+	case GroupByDefault:
+		return Grouping{}, nil
+	case GroupByLabels:
+		if arg == "" {
+			return Grouping{}, fmt.Errorf("--group-by labels requires a label key (e.g. labels:app.kubernetes.io/part-of)")
+		}
+		return Grouping{Strategy: GroupByLabels, LabelKey: arg}, nil
+	case GroupByNamespace, GroupByOwner, GroupByManual:
+		if arg != "" {
+			return Grouping{}, fmt.Errorf("--group-by %s does not take an argument", strategy)
+		}
+		return Grouping{Strategy: GroupByStrategy(strategy)}, nil
+	default:
+		return Grouping{}, fmt.Errorf("unknown grouping strategy: %q (must be labels:<key>, namespace, owner, or manual)", s)
+	}
+}
+
+// ServiceName returns the service obj is assigned to, or "" when neither a
+// manual pin nor the strategy applies and the default heuristics should run.
+func (g Grouping) ServiceName(obj *unstructured.Unstructured, all map[types.ResourceKey]*types.ExtractedResource) string {
+	if name, ok := g.Manual.ServiceFor(obj); ok {
+		return name
+	}
+
+	switch g.Strategy {
+	case GroupByLabels:
+		return obj.GetLabels()[g.LabelKey]
+	case GroupByNamespace:
+		return obj.GetNamespace()
+	case GroupByOwner:
+		root := rootOwner(obj, all)
+		if root == obj {
+			return ""
+		}
+		return processor.ServiceNameFromResource(root)
+	}
+	return ""
+}
+
+// rootOwner follows ownerReferences through the extracted resources and
+// returns the top-most owner that is part of the input, or obj itself.
+func rootOwner(obj *unstructured.Unstructured, all map[types.ResourceKey]*types.ExtractedResource) *unstructured.Unstructured {
+	seen := map[*unstructured.Unstructured]bool{obj: true}
+	current := obj
+	for {
+		owner := findOwner(current, all)
+		if owner == nil || seen[owner] {
+			return current
+		}
+		seen[owner] = true
+		current = owner
+	}
+}
+
+// findOwner returns the first owner of obj present in all. Owners live in the
+// same namespace as the objects they own.
+func findOwner(obj *unstructured.Unstructured, all map[types.ResourceKey]*types.ExtractedResource) *unstructured.Unstructured {
+	for _, ref := range obj.GetOwnerReferences() {
+		for key, r := range all {
+			if key.GVK.Kind == ref.Kind && key.Name == ref.Name && key.Namespace == obj.GetNamespace() {
+				return r.Object
+			}
+		}
+	}
+	return nil
+}
+
+// GroupMapping is a groups.yaml file that pins resources into named services
+// and renames resources before they are grouped:
+//
+//	groups:
+//	  frontend:
+//	    - kind: Deployment
+//	      name: web
+//	    - kind: ConfigMap
+//	      name: web-*
+//	      namespace: prod
+//	renames:
+//	  web-prod: web
+type GroupMapping struct {
+	// Groups maps a service name to the selectors of resources pinned into it.
+	Groups map[string][]GroupSelector `json:"groups"`
+
+	// Renames maps original names to the names used in the chart (see
+	// dhg.Options.Renames). Selectors match the new names.
+	Renames map[string]string `json:"renames,omitempty"`
+}
+
+// GroupSelector matches resources by kind, name and namespace. Empty kind or
+// namespace match any; name accepts shell patterns (path.Match).
+type GroupSelector struct {
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// LoadGroupMapping reads and validates a groups.yaml file.
+func LoadGroupMapping(file string) (*GroupMapping, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("groups file: %w", err)
+	}
+	return ParseGroupMapping(data)
+}
+
+// ParseGroupMapping parses and validates groups.yaml content.
+func ParseGroupMapping(data []byte) (*GroupMapping, error) {
+	var m GroupMapping
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("groups file: %w", err)
+	}
+
+	for service, selectors := range m.Groups {
+		if service == "" {
+			return nil, fmt.Errorf("groups file: empty service name")
+		}
+		for i, sel := range selectors {
+			if sel.Name == "" {
+				return nil, fmt.Errorf("groups file: %s[%d]: name is required", service, i)
+			}
+			if _, err := path.Match(sel.Name, ""); err != nil {
+				return nil, fmt.Errorf("groups file: %s[%d]: invalid name pattern %q: %w", service, i, sel.Name, err)
+			}
+		}
+	}
+	for from, to := range m.Renames {
+		if from == "" {
+			return nil, fmt.Errorf("groups file: renames: empty name")
+		}
+		if errs := validation.IsDNS1123Subdomain(to); len(errs) > 0 {
+			return nil, fmt.Errorf("groups file: renames: %s: invalid name %q: %s", from, to, strings.Join(errs, "; "))
+		}
+	}
+	return &m, nil
+}
+
+// ServiceFor returns the service obj is pinned into. When several services
+// match, the first one in alphabetical order wins so results are stable.
+func (m *GroupMapping) ServiceFor(obj *unstructured.Unstructured) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+
+	services := make([]string, 0, len(m.Groups))
+	for service := range m.Groups {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		for _, sel := range m.Groups[service] {
+			if sel.Matches(obj) {
+				return service, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Matches reports whether obj is selected.
+func (s GroupSelector) Matches(obj *unstructured.Unstructured) bool {
+	if s.Kind != "" && s.Kind != obj.GetKind() {
+		return false
+	}
+	if s.Namespace != "" && s.Namespace != obj.GetNamespace() {
+		return false
+	}
+	ok, _ := path.Match(s.Name, obj.GetName())
+	return ok
+}
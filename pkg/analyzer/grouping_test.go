@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func makeExtracted(kind, name, namespace string, labels map[string]string) *types.ExtractedResource {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(labels)
+	return &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()}
+}
+
+func extractedIndex(resources ...*types.ExtractedResource) map[types.ResourceKey]*types.ExtractedResource {
+	all := make(map[types.ResourceKey]*types.ExtractedResource, len(resources))
+	for _, r := range resources {
+		all[r.ResourceKey()] = r
+	}
+	return all
+}
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Grouping
+		wantErr bool
+	}{
+		{in: "", want: Grouping{}},
+		{in: "labels:app.kubernetes.io/part-of", want: Grouping{Strategy: GroupByLabels, LabelKey: "app.kubernetes.io/part-of"}},
+		{in: "namespace", want: Grouping{Strategy: GroupByNamespace}},
+		{in: "owner", want: Grouping{Strategy: GroupByOwner}},
+		{in: "manual", want: Grouping{Strategy: GroupByManual}},
+		{in: "labels", wantErr: true},
+		{in: "labels:", wantErr: true},
+		{in: "namespace:prod", wantErr: true},
+		{in: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseGroupBy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseGroupBy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (got.Strategy != tt.want.Strategy || got.LabelKey != tt.want.LabelKey) {
+			t.Errorf("ParseGroupBy(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGrouping_ServiceName_Strategies(t *testing.T) {
+	cm := makeExtracted("ConfigMap", "web-config", "shop", map[string]string{"app.kubernetes.io/part-of": "storefront"})
+	ns := makeExtracted("Namespace", "shop", "", nil)
+	all := extractedIndex(cm, ns)
+
+	labels := Grouping{Strategy: GroupByLabels, LabelKey: "app.kubernetes.io/part-of"}
+	if got := labels.ServiceName(cm.Object, all); got != "storefront" {
+		t.Errorf("labels strategy: got %q", got)
+	}
+	if got := labels.ServiceName(ns.Object, all); got != "" {
+		t.Errorf("labels strategy without the label should defer to heuristics, got %q", got)
+	}
+
+	byNamespace := Grouping{Strategy: GroupByNamespace}
+	if got := byNamespace.ServiceName(cm.Object, all); got != "shop" {
+		t.Errorf("namespace strategy: got %q", got)
+	}
+	if got := byNamespace.ServiceName(ns.Object, all); got != "" {
+		t.Errorf("namespace strategy for a cluster-scoped resource should defer to heuristics, got %q", got)
+	}
+
+	if got := (Grouping{}).ServiceName(cm.Object, all); got != "" {
+		t.Errorf("default strategy should defer to heuristics, got %q", got)
+	}
+}
+
+func TestGrouping_ServiceName_Owner(t *testing.T) {
+	app := makeExtracted("Application", "billing", "prod", map[string]string{"app.kubernetes.io/name": "billing-api"})
+	secret := makeExtracted("Secret", "billing-db", "prod", nil)
+	secret.Object.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Application", Name: "billing"}})
+	cm := makeExtracted("ConfigMap", "billing-db-init", "prod", nil)
+	cm.Object.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Secret", Name: "billing-db"}})
+	orphan := makeExtracted("ConfigMap", "orphan", "prod", nil)
+	orphan.Object.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Deployment", Name: "missing"}})
+	all := extractedIndex(app, secret, cm, orphan)
+
+	g := Grouping{Strategy: GroupByOwner}
+	for _, r := range []*types.ExtractedResource{secret, cm} {
+		if got := g.ServiceName(r.Object, all); got != "billing-api" {
+			t.Errorf("%s: expected the root owner's service, got %q", r.Object.GetName(), got)
+		}
+	}
+	if got := g.ServiceName(app.Object, all); got != "" {
+		t.Errorf("root owner should defer to heuristics, got %q", got)
+	}
+	if got := g.ServiceName(orphan.Object, all); got != "" {
+		t.Errorf("owner outside the input should defer to heuristics, got %q", got)
+	}
+}
+
+func TestGrouping_ManualWinsOverStrategy(t *testing.T) {
+	cm := makeExtracted("ConfigMap", "web-config", "shop", nil)
+	g := Grouping{
+		Strategy: GroupByNamespace,
+		Manual: &GroupMapping{Groups: map[string][]GroupSelector{
+			"frontend": {{Kind: "ConfigMap", Name: "web-*"}},
+		}},
+	}
+	if got := g.ServiceName(cm.Object, extractedIndex(cm)); got != "frontend" {
+		t.Errorf("expected manual pin, got %q", got)
+	}
+}
+
+func TestParseGroupMapping(t *testing.T) {
+	m, err := ParseGroupMapping([]byte(`
+groups:
+  frontend:
+    - kind: Deployment
+      name: web
+    - name: web-*
+      namespace: prod
+  backend:
+    - name: "*"
+      namespace: prod
+renames:
+  web-prod: web
+`))
+	if err != nil {
+		t.Fatalf("ParseGroupMapping: %v", err)
+	}
+	if m.Renames["web-prod"] != "web" {
+		t.Errorf("Renames = %v", m.Renames)
+	}
+
+	tests := []struct {
+		kind, name, namespace string
+		want                  string
+	}{
+		{kind: "Deployment", name: "web", namespace: "dev", want: "frontend"},
+		{kind: "Service", name: "web", namespace: "dev", want: ""},
+		// Both services match: the alphabetically first one wins.
+		{kind: "ConfigMap", name: "web-config", namespace: "prod", want: "backend"},
+		{kind: "ConfigMap", name: "web-config", namespace: "dev", want: ""},
+	}
+	for _, tt := range tests {
+		got, ok := m.ServiceFor(makeExtracted(tt.kind, tt.name, tt.namespace, nil).Object)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("ServiceFor(%s/%s/%s) = %q, %v; want %q", tt.kind, tt.namespace, tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseGroupMapping_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing name":   "groups:\n  web:\n    - kind: Deployment\n",
+		"bad pattern":    "groups:\n  web:\n    - name: \"[\"\n",
+		"unknown field":  "groups:\n  web:\n    - name: web\n      label: x\n",
+		"malformed yaml": "groups: [",
+		"bad rename":     "renames:\n  web-prod: Web_Prod\n",
+	}
+	for name, data := range tests {
+		if _, err := ParseGroupMapping([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if !strings.HasPrefix(err.Error(), "groups file:") {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestGroupMapping_NilIsEmpty(t *testing.T) {
+	var m *GroupMapping
+	if _, ok := m.ServiceFor(makeExtracted("ConfigMap", "x", "default", nil).Object); ok {
+		t.Error("nil mapping should not pin anything")
+	}
+}
@@ -11,6 +11,8 @@ import (
 type Analyzer struct {
 	detectors []PatternDetector
 	checkers  []BestPracticeChecker
+	rules     *Rules
+	baseline  *Baseline
 }
 
 // NewAnalyzer creates a new pattern analyzer.
@@ -31,6 +33,16 @@ func (a *Analyzer) AddChecker(c BestPracticeChecker) {
 	a.checkers = append(a.checkers, c)
 }
 
+// Checker returns the registered checker with the given name, or nil.
+func (a *Analyzer) Checker(name string) BestPracticeChecker {
+	for _, c := range a.checkers {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
 // Analyze performs comprehensive analysis on resource graph.
 func (a *Analyzer) Analyze(graph *types.ResourceGraph) *AnalysisResult {
 	result := &AnalysisResult{
@@ -70,6 +82,8 @@ func (a *Analyzer) Analyze(graph *types.ResourceGraph) *AnalysisResult {
 		practices := checker.Check(graph)
 		result.BestPractices = append(result.BestPractices, practices...)
 	}
+	result.BestPractices = a.rules.apply(result.BestPractices)
+	result.BestPractices, result.BaselineSuppressed = a.baseline.filter(result.BestPractices)
 
 	// Generate recommendations
 	result.RecommendedStrategy = a.recommendStrategy(result.PrimaryPattern, result.Metrics)
@@ -476,8 +490,15 @@ func DefaultAnalyzer() *Analyzer {
 	a.AddChecker(NewDaemonSetPatternChecker())
 	a.AddChecker(NewGracefulShutdownChecker())
 	a.AddChecker(NewPodSecurityStandardsChecker())
+	a.AddChecker(NewPodSecurityContextChecker())
+	a.AddChecker(NewServiceAccountChecker())
+	a.AddChecker(NewTokenAutomountChecker())
 	a.AddChecker(NewTopologySpreadChecker())
+	a.AddChecker(NewNodeOSChecker())
+	a.AddChecker(NewPersistentVolumeChecker())
 	a.AddChecker(NewDeckhouseCompatChecker())
+	a.AddChecker(NewDeprecatedAPIChecker())
+	a.AddChecker(NewImageChecker())
 
 	return a
 }
@@ -0,0 +1,154 @@
+package pattern
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// baselineHeader starts baseline files written by Save.
+const baselineHeader = "# Violations suppressed by dhg analyze --baseline. Refresh with --update-baseline.\n"
+
+// BaselineEntry is a violation recorded in a baseline: the rule and the
+// resource it was reported for. Violations without resources have only Rule.
+type BaselineEntry struct {
+	Rule      string `json:"rule"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Baseline is a set of known violations that Analyze does not report, so
+// that only new violations fail the analysis:
+//
+//	violations:
+//	  - rule: BP-HA-001
+//	    kind: Deployment
+//	    namespace: prod
+//	    name: web
+type Baseline struct {
+	Violations []BaselineEntry `json:"violations"`
+}
+
+// NewBaseline records the violations of practices, sorted.
+func NewBaseline(practices []BestPractice) *Baseline {
+	seen := make(map[BaselineEntry]bool)
+	b := &Baseline{Violations: make([]BaselineEntry, 0)}
+	add := func(e BaselineEntry) {
+		if !seen[e] {
+			seen[e] = true
+			b.Violations = append(b.Violations, e)
+		}
+	}
+	for _, bp := range practices {
+		if bp.Compliant {
+			continue
+		}
+		if len(bp.AffectedResources) == 0 {
+			add(BaselineEntry{Rule: bp.ID})
+		}
+		for _, key := range bp.AffectedResources {
+			add(baselineEntry(bp.ID, key))
+		}
+	}
+	sort.Slice(b.Violations, func(i, j int) bool {
+		x, y := b.Violations[i], b.Violations[j]
+		if x.Rule != y.Rule {
+			return x.Rule < y.Rule
+		}
+		if x.Kind != y.Kind {
+			return x.Kind < y.Kind
+		}
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		return x.Name < y.Name
+	})
+	return b
+}
+
+// baselineEntry returns the entry of the violation of rule by key. The
+// apiVersion is left out so that upgrading it does not make known
+// violations new.
+func baselineEntry(rule string, key types.ResourceKey) BaselineEntry {
+	return BaselineEntry{Rule: rule, Kind: key.GVK.Kind, Namespace: key.Namespace, Name: key.Name}
+}
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(file string) (*Baseline, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	var b Baseline
+	if err := yaml.UnmarshalStrict(data, &b); err != nil {
+		return nil, fmt.Errorf("baseline %s: %w", file, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to file.
+func (b *Baseline) Save(file string) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+	if err := os.WriteFile(file, append([]byte(baselineHeader), data...), 0644); err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+	return nil
+}
+
+// ApplyBaseline makes Analyze leave out the violations recorded in baseline.
+// baseline may be nil.
+func (a *Analyzer) ApplyBaseline(baseline *Baseline) {
+	a.baseline = baseline
+}
+
+// filter drops the violations recorded in the baseline from practices.
+// Practices left without affected resources are dropped. Returns the
+// remaining practices and the count of dropped violations.
+func (b *Baseline) filter(practices []BestPractice) ([]BestPractice, int) {
+	if b == nil {
+		return practices, 0
+	}
+	known := make(map[BaselineEntry]bool, len(b.Violations))
+	for _, e := range b.Violations {
+		known[e] = true
+	}
+
+	suppressed := 0
+	out := make([]BestPractice, 0, len(practices))
+	for _, bp := range practices {
+		if bp.Compliant {
+			out = append(out, bp)
+			continue
+		}
+		if len(bp.AffectedResources) == 0 {
+			if known[BaselineEntry{Rule: bp.ID}] {
+				suppressed++
+				continue
+			}
+			out = append(out, bp)
+			continue
+		}
+		affected := make([]types.ResourceKey, 0, len(bp.AffectedResources))
+		for _, key := range bp.AffectedResources {
+			if known[baselineEntry(bp.ID, key)] {
+				suppressed++
+				continue
+			}
+			affected = append(affected, key)
+		}
+		if len(affected) == 0 {
+			continue
+		}
+		bp.AffectedResources = affected
+		out = append(out, bp)
+	}
+	return out, suppressed
+}
@@ -0,0 +1,95 @@
+package pattern
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestBaseline_SaveAndLoad(t *testing.T) {
+	g := makeGraph()
+	web := addResource(g, "apps", "v1", "Deployment", "web", "prod", "web").Original.ResourceKey()
+	api := addResource(g, "apps", "v1", "Deployment", "api", "prod", "api").Original.ResourceKey()
+
+	b := NewBaseline([]BestPractice{
+		{ID: "BP-HA-001", AffectedResources: []types.ResourceKey{web, api}},
+		{ID: "BP-001", AffectedResources: []types.ResourceKey{web}},
+		{ID: "BP-PAT-001"},
+		{ID: "BP-SEC-001", Compliant: true},
+	})
+	want := []BaselineEntry{
+		{Rule: "BP-001", Kind: "Deployment", Namespace: "prod", Name: "web"},
+		{Rule: "BP-HA-001", Kind: "Deployment", Namespace: "prod", Name: "api"},
+		{Rule: "BP-HA-001", Kind: "Deployment", Namespace: "prod", Name: "web"},
+		{Rule: "BP-PAT-001"},
+	}
+	if len(b.Violations) != len(want) {
+		t.Fatalf("violations = %+v, want %+v", b.Violations, want)
+	}
+	for i := range want {
+		if b.Violations[i] != want[i] {
+			t.Errorf("violation %d = %+v, want %+v", i, b.Violations[i], want[i])
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "baseline.yaml")
+	if err := b.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBaseline(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Violations) != len(want) || loaded.Violations[3] != want[3] {
+		t.Errorf("loaded baseline = %+v, want %+v", loaded.Violations, want)
+	}
+
+	if _, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing baseline")
+	}
+}
+
+func TestAnalyzer_ApplyBaseline(t *testing.T) {
+	g := makeGraph()
+	for _, name := range []string{"web", "api"} {
+		pr := addWorkloadWithContainers(g, "Deployment", name, name, []map[string]interface{}{{"name": name}})
+		pr.Values["replicas"] = int64(1)
+	}
+
+	before := DefaultAnalyzer().Analyze(g)
+	ha := practiceByID(before.BestPractices, "BP-HA-001")
+	if ha == nil || len(ha.AffectedResources) != 2 {
+		t.Fatalf("expected BP-HA-001 for both Deployments, got %+v", ha)
+	}
+
+	baseline := NewBaseline(before.BestPractices)
+	var apiEntries []BaselineEntry
+	for _, e := range baseline.Violations {
+		if e.Name != "api" {
+			apiEntries = append(apiEntries, e)
+		}
+	}
+	baseline.Violations = apiEntries
+
+	a := DefaultAnalyzer()
+	a.ApplyBaseline(baseline)
+	after := a.Analyze(g)
+
+	if after.BaselineSuppressed != len(apiEntries) {
+		t.Errorf("BaselineSuppressed = %d, want %d", after.BaselineSuppressed, len(apiEntries))
+	}
+	for _, bp := range after.BestPractices {
+		if bp.Compliant {
+			continue
+		}
+		for _, key := range bp.AffectedResources {
+			if key.Name != "api" {
+				t.Errorf("%s: baselined violation of %s reported", bp.ID, key)
+			}
+		}
+	}
+	if remaining := NewBaseline(after.BestPractices).Violations; len(remaining) == 0 {
+		t.Error("violations of api are not in the baseline and should be reported")
+	}
+}
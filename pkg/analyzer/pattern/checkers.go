@@ -1,8 +1,12 @@
 package pattern
 
 import (
+	"fmt"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -88,7 +92,7 @@ func (c *ResourceLimitsChecker) Check(graph *types.ResourceGraph) []BestPractice
 				"Consider using VPA (Vertical Pod Autoscaler) for automatic recommendations",
 			},
 			AffectedResources: missingLimits,
-			AutoFixable:       false,
+			AutoFixable:       true,
 		})
 	}
 
@@ -107,7 +111,7 @@ func (c *ResourceLimitsChecker) Check(graph *types.ResourceGraph) []BestPractice
 				"Ensure requests <= limits",
 			},
 			AffectedResources: missingRequests,
-			AutoFixable:       false,
+			AutoFixable:       true,
 		})
 	}
 
@@ -133,7 +137,11 @@ func (c *ResourceLimitsChecker) Check(graph *types.ResourceGraph) []BestPractice
 }
 
 // SecurityChecker checks for security best practices.
-type SecurityChecker struct{}
+type SecurityChecker struct {
+	// AllowPrivileged lists workloads, by name or namespace/name, that may
+	// run privileged containers.
+	AllowPrivileged []string
+}
 
 func NewSecurityChecker() *SecurityChecker {
 	return &SecurityChecker{}
@@ -165,10 +173,15 @@ func (c *SecurityChecker) Check(graph *types.ResourceGraph) []BestPractice {
 			continue
 		}
 
+		// Containers inherit runAsNonRoot from the pod-level securityContext.
+		podNonRoot, _ := podSecurityContext(resource)["runAsNonRoot"].(bool)
+
 		// Check securityContext
 		containers := resource.Values["containers"]
 		if containers == nil {
-			runAsNonRoot = append(runAsNonRoot, key)
+			if !podNonRoot {
+				runAsNonRoot = append(runAsNonRoot, key)
+			}
 			readOnlyRootFS = append(readOnlyRootFS, key)
 			continue
 		}
@@ -181,13 +194,19 @@ func (c *SecurityChecker) Check(graph *types.ResourceGraph) []BestPractice {
 		for _, container := range containerList {
 			secCtx, ok := container["securityContext"].(map[string]interface{})
 			if !ok {
-				runAsNonRoot = append(runAsNonRoot, key)
+				if !podNonRoot {
+					runAsNonRoot = append(runAsNonRoot, key)
+				}
 				readOnlyRootFS = append(readOnlyRootFS, key)
 				continue
 			}
 
 			// Check runAsNonRoot
-			if runAsNonRootVal, ok := secCtx["runAsNonRoot"].(bool); !ok || !runAsNonRootVal {
+			runAsNonRootVal, ok := secCtx["runAsNonRoot"].(bool)
+			if !ok {
+				runAsNonRootVal = podNonRoot
+			}
+			if !runAsNonRootVal {
 				runAsNonRoot = append(runAsNonRoot, key)
 			}
 
@@ -197,7 +216,7 @@ func (c *SecurityChecker) Check(graph *types.ResourceGraph) []BestPractice {
 			}
 
 			// Check for privileged containers
-			if privilegedVal, ok := secCtx["privileged"].(bool); ok && privilegedVal {
+			if privilegedVal, ok := secCtx["privileged"].(bool); ok && privilegedVal && !allowedByName(key, c.AllowPrivileged) {
 				privilegedContainers = append(privilegedContainers, key)
 			}
 		}
@@ -264,7 +283,11 @@ func (c *SecurityChecker) Check(graph *types.ResourceGraph) []BestPractice {
 }
 
 // HighAvailabilityChecker checks for high availability best practices.
-type HighAvailabilityChecker struct{}
+type HighAvailabilityChecker struct {
+	// MinReplicas is the replica count below which Deployments are
+	// reported (default 2).
+	MinReplicas int
+}
 
 func NewHighAvailabilityChecker() *HighAvailabilityChecker {
 	return &HighAvailabilityChecker{}
@@ -278,6 +301,14 @@ func (c *HighAvailabilityChecker) Category() string {
 	return "High Availability"
 }
 
+// minReplicas returns MinReplicas, or the default when it is not set.
+func (c *HighAvailabilityChecker) minReplicas() int {
+	if c.MinReplicas > 0 {
+		return c.MinReplicas
+	}
+	return defaultMinReplicas
+}
+
 func (c *HighAvailabilityChecker) Check(graph *types.ResourceGraph) []BestPractice {
 	practices := make([]BestPractice, 0)
 
@@ -296,7 +327,7 @@ func (c *HighAvailabilityChecker) Check(graph *types.ResourceGraph) []BestPracti
 
 		// Check replicas
 		if replicasRaw, ok := resource.Values["replicas"]; ok {
-			if replicas, ok := replicasRaw.(int64); ok && replicas == 1 {
+			if replicas, ok := replicasRaw.(int64); ok && replicas > 0 && replicas < int64(c.minReplicas()) {
 				singleReplica = append(singleReplica, key)
 			}
 		}
@@ -335,15 +366,19 @@ func (c *HighAvailabilityChecker) Check(graph *types.ResourceGraph) []BestPracti
 
 	// Single replica warning
 	if len(singleReplica) > 0 {
+		title := "Single Replica Deployments"
+		if c.minReplicas() > defaultMinReplicas {
+			title = fmt.Sprintf("Deployments With Fewer Than %d Replicas", c.minReplicas())
+		}
 		practices = append(practices, BestPractice{
 			ID:          "BP-HA-001",
-			Title:       "Single Replica Deployments",
+			Title:       title,
 			Description: "Deployments with single replica have no redundancy",
 			Category:    c.Category(),
 			Severity:    SeverityWarning,
 			Compliant:   false,
 			Recommendations: []string{
-				"Increase replicas to at least 2 for production workloads",
+				fmt.Sprintf("Increase replicas to at least %d for production workloads", c.minReplicas()),
 				"Use HorizontalPodAutoscaler for automatic scaling",
 				"Consider using pod anti-affinity for availability zones",
 			},
@@ -367,7 +402,7 @@ func (c *HighAvailabilityChecker) Check(graph *types.ResourceGraph) []BestPracti
 				"Use appropriate probe types (HTTP, TCP, exec) for your application",
 			},
 			AffectedResources: missingProbes,
-			AutoFixable:       false,
+			AutoFixable:       true,
 		})
 	}
 
@@ -384,6 +419,7 @@ func (c *HighAvailabilityChecker) Check(graph *types.ResourceGraph) []BestPracti
 				"Create PodDisruptionBudget for critical deployments",
 				"Set minAvailable or maxUnavailable based on requirements",
 				"Ensure enough replicas to satisfy PDB constraints",
+				"Run dhg generate with --ha to generate PDBs for replicated workloads",
 			},
 			AffectedResources: missingPDB,
 			AutoFixable:       false,
@@ -555,7 +591,7 @@ func (c *QoSClassChecker) Check(graph *types.ResourceGraph) []BestPractice {
 				"Use Burstable QoS for workloads with variable resource needs",
 			},
 			AffectedResources: bestEffortResources,
-			AutoFixable:       false,
+			AutoFixable:       true,
 		})
 	}
 
@@ -802,7 +838,11 @@ func (c *GracefulShutdownChecker) Check(graph *types.ResourceGraph) []BestPracti
 }
 
 // PodSecurityStandardsChecker checks for Pod Security Standards compliance.
-type PodSecurityStandardsChecker struct{}
+type PodSecurityStandardsChecker struct {
+	// AllowPrivileged lists workloads, by name or namespace/name, that may
+	// run at the privileged level.
+	AllowPrivileged []string
+}
 
 func NewPodSecurityStandardsChecker() *PodSecurityStandardsChecker {
 	return &PodSecurityStandardsChecker{}
@@ -843,7 +883,7 @@ func (c *PodSecurityStandardsChecker) Check(graph *types.ResourceGraph) []BestPr
 
 		level := c.classifyPSSLevel(resource)
 
-		if level == pssPrivileged {
+		if level == pssPrivileged && !allowedByName(key, c.AllowPrivileged) {
 			privilegedResources = append(privilegedResources, key)
 		}
 	}
@@ -871,6 +911,28 @@ func (c *PodSecurityStandardsChecker) Check(graph *types.ResourceGraph) []BestPr
 	return practices
 }
 
+// PodSecurityLevel returns the strictest Pod Security Standards level that
+// every Deployment, StatefulSet and DaemonSet of graph complies with:
+// "restricted", "baseline" or "privileged". A graph without workloads is
+// restricted.
+func (c *PodSecurityStandardsChecker) PodSecurityLevel(graph *types.ResourceGraph) string {
+	level := pssRestricted
+	for key, resource := range graph.Resources {
+		switch key.GVK.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+		default:
+			continue
+		}
+		switch c.classifyPSSLevel(resource) {
+		case pssPrivileged:
+			return string(pssPrivileged)
+		case pssBaseline:
+			level = pssBaseline
+		}
+	}
+	return string(level)
+}
+
 // classifyPSSLevel determines the PSS level for a workload resource.
 func (c *PodSecurityStandardsChecker) classifyPSSLevel(resource *types.ProcessedResource) pssLevel {
 	// Check pod-level security context for baseline violations
@@ -884,6 +946,9 @@ func (c *PodSecurityStandardsChecker) classifyPSSLevel(resource *types.Processed
 		return pssPrivileged
 	}
 
+	podSC := podSecurityContext(resource)
+	podNonRoot, _ := podSC["runAsNonRoot"].(bool)
+
 	// Check container-level security context
 	containers, ok := resource.Values["containers"]
 	if !ok || containers == nil {
@@ -908,8 +973,12 @@ func (c *PodSecurityStandardsChecker) classifyPSSLevel(resource *types.Processed
 			return pssPrivileged
 		}
 
-		// Restricted checks
-		runAsNonRoot, _ := secCtx["runAsNonRoot"].(bool)
+		// Restricted checks; runAsNonRoot and seccompProfile may be set at
+		// pod level.
+		runAsNonRoot, ok := secCtx["runAsNonRoot"].(bool)
+		if !ok {
+			runAsNonRoot = podNonRoot
+		}
 		if !runAsNonRoot {
 			return pssBaseline
 		}
@@ -931,7 +1000,11 @@ func (c *PodSecurityStandardsChecker) classifyPSSLevel(resource *types.Processed
 		}
 
 		// Check seccompProfile
-		if _, ok := secCtx["seccompProfile"]; !ok {
+		seccomp := profileType(secCtx, "seccompProfile")
+		if seccomp == "" {
+			seccomp = profileType(podSC, "seccompProfile")
+		}
+		if !restrictedSeccomp(seccomp) {
 			return pssBaseline
 		}
 	}
@@ -1020,6 +1093,7 @@ func (c *TopologySpreadChecker) Check(graph *types.ResourceGraph) []BestPractice
 				"Add topologySpreadConstraints with topologyKey: topology.kubernetes.io/zone",
 				"Set maxSkew: 1 for strict distribution across zones",
 				"Use whenUnsatisfiable: DoNotSchedule for critical workloads",
+				"Run dhg generate with --topology-spread to add zone and hostname constraints",
 			},
 			AffectedResources: missingTSC,
 			AutoFixable:       false,
@@ -1046,3 +1120,432 @@ func (c *TopologySpreadChecker) Check(graph *types.ResourceGraph) []BestPractice
 
 	return practices
 }
+
+// ServiceAccountChecker checks that workloads do not run as the default
+// ServiceAccount of their namespace.
+type ServiceAccountChecker struct{}
+
+func NewServiceAccountChecker() *ServiceAccountChecker {
+	return &ServiceAccountChecker{}
+}
+
+func (c *ServiceAccountChecker) Name() string {
+	return "service-account"
+}
+
+func (c *ServiceAccountChecker) Category() string {
+	return "Security"
+}
+
+func (c *ServiceAccountChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	defaultSA := make([]types.ResourceKey, 0)
+
+	for key, resource := range graph.Resources {
+		if resource.Original == nil || resource.Original.Object == nil {
+			continue
+		}
+		// Pods and ReplicaSets created by a controller are reported
+		// through the controller.
+		if len(resource.Original.Object.GetOwnerReferences()) > 0 {
+			continue
+		}
+		if processor.PodServiceAccountName(resource.Original.Object) == processor.DefaultServiceAccountName {
+			defaultSA = append(defaultSA, key)
+		}
+	}
+
+	if len(defaultSA) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-SEC-004",
+			Title:       "Workloads Running as the Default ServiceAccount",
+			Description: "Workloads share the default ServiceAccount of the namespace, so any permissions granted to it apply to all of them",
+			Category:    c.Category(),
+			Severity:    SeverityWarning,
+			Compliant:   false,
+			Recommendations: []string{
+				"Run dhg generate with --service-accounts to create a ServiceAccount with a minimal Role per workload",
+				"Set serviceAccountName to a dedicated ServiceAccount",
+				"Grant each ServiceAccount only the permissions its workload needs",
+			},
+			AffectedResources: defaultSA,
+			AutoFixable:       false,
+		})
+	}
+
+	return practices
+}
+
+// TokenAutomountChecker checks that workloads whose ServiceAccount has no
+// RBAC bindings do not mount a ServiceAccount token they cannot use.
+type TokenAutomountChecker struct{}
+
+func NewTokenAutomountChecker() *TokenAutomountChecker {
+	return &TokenAutomountChecker{}
+}
+
+func (c *TokenAutomountChecker) Name() string {
+	return "service-account-token"
+}
+
+func (c *TokenAutomountChecker) Category() string {
+	return "Security"
+}
+
+func (c *TokenAutomountChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	objs := make([]*unstructured.Unstructured, 0, len(graph.Resources))
+	// accountAutomount is the automountServiceAccountToken setting of the
+	// ServiceAccounts that set it, which pods inherit.
+	accountAutomount := make(map[string]bool)
+	for _, resource := range graph.Resources {
+		if resource.Original == nil || resource.Original.Object == nil {
+			continue
+		}
+		obj := resource.Original.Object
+		objs = append(objs, obj)
+		if obj.GetKind() == "ServiceAccount" {
+			if automount, found, _ := unstructured.NestedBool(obj.Object, "automountServiceAccountToken"); found {
+				accountAutomount[processor.ServiceAccountKey(obj.GetNamespace(), obj.GetName())] = automount
+			}
+		}
+	}
+	bound := processor.BoundServiceAccounts(objs)
+
+	unbound := make([]types.ResourceKey, 0)
+	for key, resource := range graph.Resources {
+		if resource.Original == nil || resource.Original.Object == nil {
+			continue
+		}
+		obj := resource.Original.Object
+		// Pods and ReplicaSets created by a controller are reported
+		// through the controller.
+		if len(obj.GetOwnerReferences()) > 0 {
+			continue
+		}
+		sa := processor.PodServiceAccountName(obj)
+		if sa == "" {
+			continue
+		}
+		account := processor.ServiceAccountKey(obj.GetNamespace(), sa)
+		automount, set := processor.PodAutomountServiceAccountToken(obj)
+		if !set {
+			automount, set = accountAutomount[account]
+		}
+		if (set && !automount) || bound[account] {
+			continue
+		}
+		unbound = append(unbound, key)
+	}
+
+	if len(unbound) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-SEC-009",
+			Title:       "ServiceAccount Token Mounted Without RBAC Bindings",
+			Description: "Pods mount a ServiceAccount token although no RoleBinding or ClusterRoleBinding grants the ServiceAccount any access; the token only serves an attacker who compromises the pod",
+			Category:    c.Category(),
+			Severity:    SeverityWarning,
+			Compliant:   false,
+			Recommendations: []string{
+				"Run dhg generate with --no-token-automount to set automountServiceAccountToken: false (toggle in values)",
+				"Set automountServiceAccountToken: false in the pod spec or on the ServiceAccount",
+			},
+			AffectedResources: unbound,
+			AutoFixable:       true,
+		})
+	}
+
+	return practices
+}
+
+// NodeOSChecker checks that, when the workloads of the input target nodes of
+// different operating systems, every workload selects the OS it runs on.
+// Without a kubernetes.io/os selector pods of a mixed Linux/Windows cluster
+// can be scheduled to nodes that cannot run their images.
+type NodeOSChecker struct{}
+
+func NewNodeOSChecker() *NodeOSChecker {
+	return &NodeOSChecker{}
+}
+
+func (c *NodeOSChecker) Name() string {
+	return "node-os"
+}
+
+func (c *NodeOSChecker) Category() string {
+	return "Reliability"
+}
+
+func (c *NodeOSChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	targetOS := make(map[string]bool)
+	missingOS := make([]types.ResourceKey, 0)
+
+	for key, resource := range graph.Resources {
+		if resource.Original == nil || resource.Original.Object == nil {
+			continue
+		}
+		obj := resource.Original.Object
+		if processor.PodSpecPath(obj.GetKind()) == nil || len(obj.GetOwnerReferences()) > 0 {
+			continue
+		}
+		if os := processor.PodNodeOS(obj); os != "" {
+			targetOS[os] = true
+		} else {
+			missingOS = append(missingOS, key)
+		}
+	}
+
+	// Linux is the default: inputs are mixed only when some workload targets
+	// another OS.
+	mixed := len(targetOS) > 1 || (len(targetOS) == 1 && !targetOS["linux"])
+	if mixed && len(missingOS) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-OS-001",
+			Title:       "Workloads Without a Node OS Selector",
+			Description: "Workloads target nodes of different operating systems, but some of them do not select one and may be scheduled to nodes that cannot run them",
+			Category:    c.Category(),
+			Severity:    SeverityWarning,
+			Compliant:   false,
+			Recommendations: []string{
+				"Add nodeSelector kubernetes.io/os: linux or kubernetes.io/os: windows to the pod template",
+				"Add tolerations for the taints of the Windows node pool to Windows workloads",
+				"Adjust the generated nodeSelector and tolerations values per environment",
+			},
+			AffectedResources: missingOS,
+			AutoFixable:       false,
+		})
+	}
+
+	return practices
+}
+
+// appArmorAnnotationPrefix is the prefix of the per-container AppArmor
+// annotations used before the appArmorProfile field (Kubernetes 1.30).
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// PodSecurityContextChecker checks the pod-level securityContext of
+// workloads against the restricted Pod Security Standard: a RuntimeDefault or
+// Localhost seccomp profile for every container, no unconfined AppArmor
+// profile, an fsGroup for pods that mount persistent volumes, and no root
+// group in runAsGroup, fsGroup or supplementalGroups. Container-level
+// settings override the pod-level ones.
+type PodSecurityContextChecker struct{}
+
+func NewPodSecurityContextChecker() *PodSecurityContextChecker {
+	return &PodSecurityContextChecker{}
+}
+
+func (c *PodSecurityContextChecker) Name() string {
+	return "pod-security-context"
+}
+
+func (c *PodSecurityContextChecker) Category() string {
+	return "Security"
+}
+
+func (c *PodSecurityContextChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	noSeccomp := make([]types.ResourceKey, 0)
+	unconfinedAppArmor := make([]types.ResourceKey, 0)
+	noFSGroup := make([]types.ResourceKey, 0)
+	rootGroup := make([]types.ResourceKey, 0)
+
+	for key, resource := range graph.Resources {
+		if resource.Original == nil || resource.Original.Object == nil {
+			continue
+		}
+		obj := resource.Original.Object
+		specPath := processor.PodSpecPath(obj.GetKind())
+		if specPath == nil || len(obj.GetOwnerReferences()) > 0 {
+			continue
+		}
+		podSC := podSecurityContext(resource)
+		containers := podContainers(obj)
+
+		seccompOK := len(containers) > 0 || restrictedSeccomp(profileType(podSC, "seccompProfile"))
+		appArmorOK := profileType(podSC, "appArmorProfile") != "Unconfined"
+		groupOK := !isRootID(podSC["runAsGroup"]) && !isRootID(podSC["fsGroup"])
+		if groups, ok := podSC["supplementalGroups"].([]interface{}); ok {
+			for _, g := range groups {
+				if isRootID(g) {
+					groupOK = false
+				}
+			}
+		}
+		for _, container := range containers {
+			sc, _ := container["securityContext"].(map[string]interface{})
+			seccomp := profileType(sc, "seccompProfile")
+			if seccomp == "" {
+				seccomp = profileType(podSC, "seccompProfile")
+			}
+			if !restrictedSeccomp(seccomp) {
+				seccompOK = false
+			}
+			if profileType(sc, "appArmorProfile") == "Unconfined" {
+				appArmorOK = false
+			}
+			if isRootID(sc["runAsGroup"]) {
+				groupOK = false
+			}
+		}
+		templateMeta, _, _ := unstructured.NestedStringMap(obj.Object, append(append([]string{}, specPath[:len(specPath)-1]...), "metadata", "annotations")...)
+		for k, v := range templateMeta {
+			if strings.HasPrefix(k, appArmorAnnotationPrefix) && v == "unconfined" {
+				appArmorOK = false
+			}
+		}
+
+		if !seccompOK {
+			noSeccomp = append(noSeccomp, key)
+		}
+		if !appArmorOK {
+			unconfinedAppArmor = append(unconfinedAppArmor, key)
+		}
+		if !groupOK {
+			rootGroup = append(rootGroup, key)
+		}
+		if _, ok := podSC["fsGroup"]; !ok && mountsPersistentVolumes(obj, specPath) {
+			noFSGroup = append(noFSGroup, key)
+		}
+	}
+
+	if len(noSeccomp) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-SEC-005",
+			Title:       "Pods Without a Seccomp Profile",
+			Description: "The restricted Pod Security Standard requires a RuntimeDefault or Localhost seccomp profile for every container; without one, containers can use all system calls",
+			Category:    c.Category(),
+			Severity:    SeverityWarning,
+			Compliant:   false,
+			Recommendations: []string{
+				"Set securityContext.seccompProfile.type: RuntimeDefault at pod level so that it applies to every container",
+				"Remove seccompProfile.type: Unconfined from pods and containers",
+				"Run dhg fix to add seccompProfile: RuntimeDefault to the generated templates",
+			},
+			AffectedResources: noSeccomp,
+			AutoFixable:       true,
+		})
+	}
+
+	if len(unconfinedAppArmor) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-SEC-006",
+			Title:       "Pods With an Unconfined AppArmor Profile",
+			Description: "Unconfined AppArmor profiles disable the default confinement and violate the baseline Pod Security Standard",
+			Category:    c.Category(),
+			Severity:    SeverityError,
+			Compliant:   false,
+			Recommendations: []string{
+				"Remove appArmorProfile.type: Unconfined or use RuntimeDefault",
+				"Remove container.apparmor.security.beta.kubernetes.io/<container>: unconfined annotations",
+			},
+			AffectedResources: unconfinedAppArmor,
+			AutoFixable:       false,
+		})
+	}
+
+	if len(noFSGroup) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-SEC-007",
+			Title:       "Pods Mounting Volumes Without fsGroup",
+			Description: "Without securityContext.fsGroup, volumes keep the ownership of the image or the provisioner, and non-root containers may not be able to write to them",
+			Category:    c.Category(),
+			Severity:    SeverityInfo,
+			Compliant:   false,
+			Recommendations: []string{
+				"Set securityContext.fsGroup to the group of the application user",
+				"Set securityContext.fsGroupChangePolicy: OnRootMismatch to speed up mounting large volumes",
+			},
+			AffectedResources: noFSGroup,
+			AutoFixable:       false,
+		})
+	}
+
+	if len(rootGroup) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-SEC-008",
+			Title:       "Pods Running With the Root Group",
+			Description: "runAsGroup, fsGroup or supplementalGroups include GID 0, giving processes access to files owned by the root group",
+			Category:    c.Category(),
+			Severity:    SeverityWarning,
+			Compliant:   false,
+			Recommendations: []string{
+				"Use a non-zero GID in runAsGroup and fsGroup",
+				"Remove 0 from supplementalGroups",
+			},
+			AffectedResources: rootGroup,
+			AutoFixable:       false,
+		})
+	}
+
+	return practices
+}
+
+// podSecurityContext returns the pod-level securityContext of a workload,
+// from its pod template or, without a source object, from the values the
+// processor extracted.
+func podSecurityContext(resource *types.ProcessedResource) map[string]interface{} {
+	if resource.Original != nil && resource.Original.Object != nil {
+		obj := resource.Original.Object
+		if specPath := processor.PodSpecPath(obj.GetKind()); specPath != nil {
+			sc, _, _ := unstructured.NestedMap(obj.Object, append(append([]string{}, specPath...), "securityContext")...)
+			if sc != nil {
+				return sc
+			}
+		}
+	}
+	sc, _ := resource.Values["podSecurityContext"].(map[string]interface{})
+	return sc
+}
+
+// profileType returns the type of a seccompProfile or appArmorProfile in
+// securityContext sc.
+func profileType(sc map[string]interface{}, field string) string {
+	profile, _ := sc[field].(map[string]interface{})
+	t, _ := profile["type"].(string)
+	return t
+}
+
+// restrictedSeccomp reports whether a seccomp profile type is allowed by the
+// restricted Pod Security Standard.
+func restrictedSeccomp(t string) bool {
+	return t == "RuntimeDefault" || t == "Localhost"
+}
+
+// isRootID reports whether a UID/GID value is 0.
+func isRootID(v interface{}) bool {
+	switch n := v.(type) {
+	case int64:
+		return n == 0
+	case int:
+		return n == 0
+	case float64:
+		return n == 0
+	}
+	return false
+}
+
+// mountsPersistentVolumes reports whether a workload mounts
+// PersistentVolumeClaims or has volumeClaimTemplates.
+func mountsPersistentVolumes(obj *unstructured.Unstructured, specPath []string) bool {
+	if templates, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates"); len(templates) > 0 {
+		return true
+	}
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, append(append([]string{}, specPath...), "volumes")...)
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]interface{}); ok {
+			if _, ok := volume["persistentVolumeClaim"]; ok {
+				return true
+			}
+			if _, ok := volume["ephemeral"]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package pattern
+
+import (
+	"fmt"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DeprecatedAPIChecker reports resources that use apiVersions deprecated or
+// removed from Kubernetes (extensions/v1beta1 Ingress, policy/v1beta1 PDB,
+// batch/v1beta1 CronJob, ...).
+type DeprecatedAPIChecker struct{}
+
+// NewDeprecatedAPIChecker creates a new deprecated API checker.
+func NewDeprecatedAPIChecker() *DeprecatedAPIChecker {
+	return &DeprecatedAPIChecker{}
+}
+
+func (c *DeprecatedAPIChecker) Name() string {
+	return "deprecated-api"
+}
+
+func (c *DeprecatedAPIChecker) Category() string {
+	return "Compatibility"
+}
+
+func (c *DeprecatedAPIChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	upgradable := make([]types.ResourceKey, 0)
+	removed := make([]types.ResourceKey, 0)
+	var upgradeNotes, removedNotes []string
+	seen := make(map[string]bool)
+
+	for key := range graph.Resources {
+		apiVersion := key.GVK.GroupVersion().String()
+		m := processor.LookupAPIMigration(apiVersion, key.GVK.Kind)
+		if m == nil {
+			continue
+		}
+
+		note := fmt.Sprintf("%s %s was removed in Kubernetes %s", m.OldAPIVersion, m.OldKind, m.RemovedIn)
+		if m.NewAPIVersion == "" {
+			removed = append(removed, key)
+			if m.Notes != "" {
+				note += ": " + m.Notes
+			}
+			if !seen[note] {
+				seen[note] = true
+				removedNotes = append(removedNotes, note)
+			}
+			continue
+		}
+
+		upgradable = append(upgradable, key)
+		note += fmt.Sprintf("; use %s", m.NewAPIVersion)
+		if !seen[note] {
+			seen[note] = true
+			upgradeNotes = append(upgradeNotes, note)
+		}
+	}
+
+	if len(upgradable) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-API-001",
+			Title:       "Deprecated Kubernetes API Version",
+			Description: "Resources use apiVersions that are deprecated or removed and will not install on current clusters",
+			Category:    c.Category(),
+			Severity:    SeverityError,
+			Compliant:   false,
+			Recommendations: append(upgradeNotes,
+				"Regenerate with --api-upgrade to convert the resources to the replacement apiVersion"),
+			AffectedResources: upgradable,
+			AutoFixable:       true,
+		})
+	}
+
+	if len(removed) > 0 {
+		practices = append(practices, BestPractice{
+			ID:                "BP-API-002",
+			Title:             "Removed Kubernetes API Without Replacement",
+			Description:       "Resources use APIs removed from Kubernetes that have no direct replacement",
+			Category:          c.Category(),
+			Severity:          SeverityError,
+			Compliant:         false,
+			Recommendations:   removedNotes,
+			AffectedResources: removed,
+			AutoFixable:       false,
+		})
+	}
+
+	return practices
+}
@@ -0,0 +1,47 @@
+package pattern
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeprecatedAPIChecker(t *testing.T) {
+	g := makeGraph()
+	addResource(g, "extensions", "v1beta1", "Ingress", "web", "default", "web")
+	addResource(g, "policy", "v1beta1", "PodDisruptionBudget", "web", "default", "web")
+	addResource(g, "policy", "v1beta1", "PodSecurityPolicy", "restricted", "", "web")
+	addResource(g, "networking.k8s.io", "v1", "Ingress", "api", "default", "api")
+
+	results := NewDeprecatedAPIChecker().Check(g)
+
+	byID := make(map[string]BestPractice)
+	for _, bp := range results {
+		byID[bp.ID] = bp
+	}
+
+	upgradable, ok := byID["BP-API-001"]
+	if !ok {
+		t.Fatalf("expected BP-API-001, got %+v", results)
+	}
+	if upgradable.Severity != SeverityError || !upgradable.AutoFixable || len(upgradable.AffectedResources) != 2 {
+		t.Errorf("unexpected BP-API-001: %+v", upgradable)
+	}
+	if !strings.Contains(strings.Join(upgradable.Recommendations, "\n"), "--api-upgrade") {
+		t.Errorf("recommendations should mention --api-upgrade: %v", upgradable.Recommendations)
+	}
+
+	removed, ok := byID["BP-API-002"]
+	if !ok || len(removed.AffectedResources) != 1 || removed.AffectedResources[0].GVK.Kind != "PodSecurityPolicy" {
+		t.Errorf("expected BP-API-002 for the PodSecurityPolicy, got %+v", removed)
+	}
+}
+
+func TestDeprecatedAPIChecker_CurrentAPIs(t *testing.T) {
+	g := makeGraph()
+	addResource(g, "batch", "v1", "CronJob", "report", "default", "report")
+	addResource(g, "apps", "v1", "Deployment", "web", "default", "web")
+
+	if results := NewDeprecatedAPIChecker().Check(g); len(results) != 0 {
+		t.Errorf("expected no findings for current APIs, got %+v", results)
+	}
+}
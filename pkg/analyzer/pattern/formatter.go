@@ -10,6 +10,9 @@ import (
 type Formatter struct {
 	// ColorEnabled enables ANSI color output
 	ColorEnabled bool
+
+	// ToolVersion is reported as the tool version in machine-readable formats (SARIF)
+	ToolVersion string
 }
 
 // NewFormatter creates a new formatter.
@@ -0,0 +1,318 @@
+package pattern
+
+import (
+	"bytes"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// htmlMaxEdges caps the relationship table so reports for large clusters stay readable.
+const htmlMaxEdges = 200
+
+// htmlReport is the view model rendered by htmlTemplate.
+type htmlReport struct {
+	Title       string
+	ToolVersion string
+	Result      *AnalysisResult
+	Kinds       []htmlCount
+	Sections    []ReportSection
+	Practices   []htmlPractice
+	Violations  int
+	Graph       *htmlGraph
+}
+
+type htmlCount struct {
+	Name  string
+	Count int
+}
+
+type htmlPractice struct {
+	BestPractice
+	Affected []string
+}
+
+type htmlGraph struct {
+	Resources     int
+	Relationships int
+	ByType        []htmlCount
+	Groups        []htmlGroup
+	Edges         []types.Relationship
+	EdgesOmitted  int
+	Orphans       int
+}
+
+type htmlGroup struct {
+	Name      string
+	Namespace string
+	Resources int
+}
+
+// FormatHTML renders the report as a single self-contained HTML page (inline
+// CSS, no external assets). When graph is non-nil a relationship summary is
+// included.
+func (f *Formatter) FormatHTML(report *Report, graph *types.ResourceGraph) (string, error) {
+	view := htmlReport{
+		Title:       "Deckhouse Helm Generator - Analysis Report",
+		ToolVersion: f.ToolVersion,
+	}
+	if report != nil {
+		view.Sections = report.Sections
+		view.Result = report.AnalysisResult
+	}
+	if view.Result == nil {
+		view.Result = &AnalysisResult{}
+	}
+
+	for kind, count := range view.Result.Metrics.ResourcesByKind {
+		view.Kinds = append(view.Kinds, htmlCount{Name: kind, Count: count})
+	}
+	sortCounts(view.Kinds)
+
+	for _, bp := range view.Result.BestPractices {
+		p := htmlPractice{BestPractice: bp}
+		for _, key := range bp.AffectedResources {
+			p.Affected = append(p.Affected, key.String())
+		}
+		sort.Strings(p.Affected)
+		if !bp.Compliant {
+			view.Violations++
+		}
+		view.Practices = append(view.Practices, p)
+	}
+	// Violations first, most severe first; stable to keep checker order otherwise.
+	sort.SliceStable(view.Practices, func(i, j int) bool {
+		a, b := view.Practices[i], view.Practices[j]
+		if a.Compliant != b.Compliant {
+			return !a.Compliant
+		}
+		return severityRank(a.Severity) > severityRank(b.Severity)
+	})
+
+	if graph != nil {
+		view.Graph = summarizeGraph(graph)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, view); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// summarizeGraph builds the relationship summary shown in the HTML report.
+func summarizeGraph(graph *types.ResourceGraph) *htmlGraph {
+	g := &htmlGraph{
+		Resources:     len(graph.Resources),
+		Relationships: len(graph.Relationships),
+		Orphans:       len(graph.Orphans),
+	}
+
+	byType := make(map[string]int)
+	for _, rel := range graph.Relationships {
+		byType[string(rel.Type)]++
+	}
+	for name, count := range byType {
+		g.ByType = append(g.ByType, htmlCount{Name: name, Count: count})
+	}
+	sortCounts(g.ByType)
+
+	for _, group := range graph.Groups {
+		g.Groups = append(g.Groups, htmlGroup{Name: group.Name, Namespace: group.Namespace, Resources: len(group.Resources)})
+	}
+	sort.Slice(g.Groups, func(i, j int) bool { return g.Groups[i].Name < g.Groups[j].Name })
+
+	edges := append([]types.Relationship(nil), graph.Relationships...)
+	sort.Slice(edges, func(i, j int) bool {
+		if a, b := edges[i].From.String(), edges[j].From.String(); a != b {
+			return a < b
+		}
+		return edges[i].To.String() < edges[j].To.String()
+	})
+	if len(edges) > htmlMaxEdges {
+		g.EdgesOmitted = len(edges) - htmlMaxEdges
+		edges = edges[:htmlMaxEdges]
+	}
+	g.Edges = edges
+
+	return g
+}
+
+// sortCounts orders counts by descending count, then name.
+func sortCounts(counts []htmlCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+}
+
+// severityRank orders severities from least to most severe.
+func severityRank(s Severity) int {
+	switch s {
+	case SeverityCritical:
+		return 3
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+header { background: #24292f; color: #fff; padding: 20px 32px; }
+header h1 { margin: 0; font-size: 22px; }
+header .meta { color: #afb8c1; font-size: 13px; margin-top: 4px; }
+main { max-width: 1100px; margin: 0 auto; padding: 24px 32px; }
+section { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 16px 20px; margin-bottom: 20px; }
+h2 { font-size: 18px; margin: 0 0 12px; }
+h3 { font-size: 15px; margin: 16px 0 6px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { border-bottom: 1px solid #d8dee4; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 8px 10px; border-radius: 4px; white-space: pre-wrap; font-size: 12px; margin: 4px 0 0; }
+ul { margin: 4px 0; padding-left: 18px; }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; }
+.card { flex: 1 1 150px; border: 1px solid #d0d7de; border-radius: 6px; padding: 10px 12px; }
+.card .value { font-size: 22px; font-weight: 600; }
+.card .label { font-size: 12px; color: #57606a; }
+.badge { display: inline-block; padding: 1px 8px; border-radius: 10px; font-size: 11px; font-weight: 600; color: #fff; }
+.badge.critical { background: #8250df; }
+.badge.error { background: #cf222e; }
+.badge.warning { background: #bf8700; }
+.badge.info { background: #0969da; }
+.badge.ok { background: #1a7f37; }
+.item.success { border-left: 3px solid #1a7f37; padding-left: 8px; }
+.item.info { border-left: 3px solid #0969da; padding-left: 8px; }
+.item.warning { border-left: 3px solid #bf8700; padding-left: 8px; }
+.item.error { border-left: 3px solid #cf222e; padding-left: 8px; }
+.muted { color: #57606a; font-size: 12px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{ .Title }}</h1>
+<div class="meta">dhg{{ with .ToolVersion }} {{ . }}{{ end }}</div>
+</header>
+<main>
+<section id="summary">
+<h2>Summary</h2>
+<div class="cards">
+<div class="card"><div class="value">{{ .Result.Metrics.TotalServices }}</div><div class="label">Services</div></div>
+<div class="card"><div class="value">{{ .Result.Metrics.TotalResources }}</div><div class="label">Resources</div></div>
+<div class="card"><div class="value">{{ .Result.Metrics.ComplexityScore }}</div><div class="label">Complexity score</div></div>
+<div class="card"><div class="value">{{ .Result.Metrics.CouplingScore }}</div><div class="label">Coupling score</div></div>
+<div class="card"><div class="value">{{ .Violations }}</div><div class="label">Best-practice violations</div></div>
+<div class="card"><div class="value">{{ if .Result.RecommendedStrategy }}{{ .Result.RecommendedStrategy }}{{ else }}-{{ end }}</div><div class="label">Recommended strategy ({{ .Result.Confidence }}% confidence)</div></div>
+</div>
+{{- if .Result.PrimaryPattern }}
+<p>Primary pattern: <strong>{{ .Result.PrimaryPattern }}</strong>{{ if .Result.DetectedPatterns }} (detected: {{ range $i, $p := .Result.DetectedPatterns }}{{ if $i }}, {{ end }}{{ $p }}{{ end }}){{ end }}</p>
+{{- end }}
+{{- if .Kinds }}
+<h3>Resources by kind</h3>
+<table>
+<tr><th>Kind</th><th>Count</th></tr>
+{{- range .Kinds }}
+<tr><td>{{ .Name }}</td><td>{{ .Count }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</section>
+
+<section id="best-practices">
+<h2>Best practices</h2>
+{{- if .Practices }}
+<table>
+<tr><th>ID</th><th>Severity</th><th>Status</th><th>Title</th><th>Affected resources</th><th>Recommendations</th></tr>
+{{- range .Practices }}
+<tr>
+<td>{{ .ID }}</td>
+<td><span class="badge {{ lower (print .Severity) }}">{{ .Severity }}</span></td>
+<td>{{ if .Compliant }}<span class="badge ok">pass</span>{{ else }}<span class="badge error">fail</span>{{ end }}</td>
+<td><strong>{{ .Title }}</strong>{{ with .Description }}<div class="muted">{{ . }}</div>{{ end }}{{ with .Category }}<div class="muted">{{ . }}</div>{{ end }}</td>
+<td>{{ if .Affected }}<ul>{{ range .Affected }}<li>{{ . }}</li>{{ end }}</ul>{{ else }}-{{ end }}</td>
+<td>{{ if .Recommendations }}<ul>{{ range .Recommendations }}<li>{{ . }}</li>{{ end }}</ul>{{ end }}</td>
+</tr>
+{{- end }}
+</table>
+{{- else }}
+<p class="muted">No best-practice checks were run.</p>
+{{- end }}
+</section>
+
+{{- with .Graph }}
+
+<section id="relationships">
+<h2>Relationship graph</h2>
+<div class="cards">
+<div class="card"><div class="value">{{ .Resources }}</div><div class="label">Resources</div></div>
+<div class="card"><div class="value">{{ .Relationships }}</div><div class="label">Relationships</div></div>
+<div class="card"><div class="value">{{ len .Groups }}</div><div class="label">Service groups</div></div>
+<div class="card"><div class="value">{{ .Orphans }}</div><div class="label">Ungrouped resources</div></div>
+</div>
+{{- if .ByType }}
+<h3>Relationships by type</h3>
+<table>
+<tr><th>Type</th><th>Count</th></tr>
+{{- range .ByType }}
+<tr><td>{{ .Name }}</td><td>{{ .Count }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Groups }}
+<h3>Service groups</h3>
+<table>
+<tr><th>Service</th><th>Namespace</th><th>Resources</th></tr>
+{{- range .Groups }}
+<tr><td>{{ .Name }}</td><td>{{ .Namespace }}</td><td>{{ .Resources }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Edges }}
+<h3>Relationships</h3>
+<table>
+<tr><th>From</th><th>To</th><th>Type</th><th>Field</th></tr>
+{{- range .Edges }}
+<tr><td>{{ .From }}</td><td>{{ .To }}</td><td>{{ .Type }}</td><td>{{ .Field }}</td></tr>
+{{- end }}
+</table>
+{{- if .EdgesOmitted }}
+<p class="muted">{{ .EdgesOmitted }} more relationships omitted.</p>
+{{- end }}
+{{- end }}
+</section>
+{{- end }}
+
+{{- range .Sections }}
+
+<section>
+<h2>{{ .Title }}</h2>
+{{- with .Description }}
+<p class="muted">{{ . }}</p>
+{{- end }}
+{{- range .Items }}
+<div class="item {{ .Level }}">
+<h3>{{ .Title }}</h3>
+<pre>{{ .Content }}</pre>
+</div>
+{{- end }}
+</section>
+{{- end }}
+</main>
+</body>
+</html>
+`))
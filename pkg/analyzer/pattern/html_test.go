@@ -0,0 +1,105 @@
+package pattern
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ── FormatHTML ───────────────────────────────────────────────────────────────
+
+func TestFormatHTML_RendersReport(t *testing.T) {
+	g := makeGraph()
+	svc := addResource(g, "", "v1", "Service", "web", "prod", "web")
+	deploy := addResource(g, "apps", "v1", "Deployment", "web", "prod", "web")
+	addGroup(g, "web", svc, deploy)
+	g.AddRelationship(types.Relationship{
+		From:  svc.Original.ResourceKey(),
+		To:    deploy.Original.ResourceKey(),
+		Type:  types.RelationLabelSelector,
+		Field: "spec.selector",
+	})
+
+	report := NewRecommender(DefaultAnalyzer()).GenerateReport(g)
+	report.AnalysisResult.BestPractices = append(report.AnalysisResult.BestPractices, BestPractice{
+		ID:                "BP-SEC-001",
+		Title:             "Containers <Running> as Root",
+		Severity:          SeverityCritical,
+		AffectedResources: []types.ResourceKey{deploy.Original.ResourceKey()},
+	})
+
+	f := NewFormatter(true)
+	f.ToolVersion = "1.2.3"
+	out, err := f.FormatHTML(report, g)
+	if err != nil {
+		t.Fatalf("FormatHTML: %v", err)
+	}
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"dhg 1.2.3",
+		`<section id="best-practices">`,
+		`<span class="badge critical">critical</span>`,
+		"Deployment/prod/web",
+		`<section id="relationships">`,
+		"label_selector",
+		"Service/prod/web",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected HTML to contain %q", want)
+		}
+	}
+
+	// Content is escaped and no ANSI color codes leak into HTML.
+	if strings.Contains(out, "<Running>") || !strings.Contains(out, "&lt;Running&gt;") {
+		t.Error("expected practice title to be HTML-escaped")
+	}
+	if strings.Contains(out, "\033[") {
+		t.Error("HTML output must not contain ANSI escape codes")
+	}
+	// Self-contained: no external stylesheets or scripts.
+	if strings.Contains(out, "<link") || strings.Contains(out, "<script") {
+		t.Error("HTML output must not reference external assets")
+	}
+}
+
+func TestFormatHTML_ViolationsFirst(t *testing.T) {
+	report := &Report{AnalysisResult: &AnalysisResult{BestPractices: []BestPractice{
+		{ID: "BP-OK", Title: "ok", Severity: SeverityInfo, Compliant: true},
+		{ID: "BP-WARN", Title: "warn", Severity: SeverityWarning},
+		{ID: "BP-ERR", Title: "err", Severity: SeverityError},
+	}}}
+
+	out, err := NewFormatter(false).FormatHTML(report, nil)
+	if err != nil {
+		t.Fatalf("FormatHTML: %v", err)
+	}
+
+	errIdx, warnIdx, okIdx := strings.Index(out, "BP-ERR"), strings.Index(out, "BP-WARN"), strings.Index(out, "BP-OK")
+	if !(errIdx < warnIdx && warnIdx < okIdx) {
+		t.Errorf("expected order BP-ERR < BP-WARN < BP-OK, got %d, %d, %d", errIdx, warnIdx, okIdx)
+	}
+	if strings.Contains(out, `id="relationships"`) {
+		t.Error("expected no relationship section without a graph")
+	}
+}
+
+func TestSummarizeGraph_CapsEdges(t *testing.T) {
+	g := makeGraph()
+	for i := 0; i < htmlMaxEdges+5; i++ {
+		g.AddRelationship(types.Relationship{
+			From: types.ResourceKey{Name: strings.Repeat("a", i+1)},
+			To:   types.ResourceKey{Name: "b"},
+			Type: types.RelationNameReference,
+		})
+	}
+
+	summary := summarizeGraph(g)
+	if len(summary.Edges) != htmlMaxEdges || summary.EdgesOmitted != 5 {
+		t.Errorf("expected %d edges and 5 omitted, got %d and %d", htmlMaxEdges, len(summary.Edges), summary.EdgesOmitted)
+	}
+	if summary.Relationships != htmlMaxEdges+5 {
+		t.Errorf("expected total relationship count %d, got %d", htmlMaxEdges+5, summary.Relationships)
+	}
+}
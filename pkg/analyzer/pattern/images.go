@@ -0,0 +1,236 @@
+package pattern
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ImageChecker checks the provenance and tags of container images: mutable
+// :latest tags, images not pinned by digest, images from registries outside
+// AllowedRegistries, and imagePullPolicy Always on images whose tag is
+// pinned.
+type ImageChecker struct {
+	// AllowedRegistries are the registries (or registry/repository
+	// prefixes) images may be pulled from. Images without a registry host
+	// are from docker.io. When empty, registries are not checked.
+	AllowedRegistries []string
+}
+
+// NewImageChecker creates a new image checker.
+func NewImageChecker(allowedRegistries ...string) *ImageChecker {
+	return &ImageChecker{AllowedRegistries: allowedRegistries}
+}
+
+func (c *ImageChecker) Name() string {
+	return "image-hygiene"
+}
+
+func (c *ImageChecker) Category() string {
+	return "Supply Chain"
+}
+
+// imageRef is a container image reference split into its parts.
+type imageRef struct {
+	name   string
+	tag    string
+	digest string
+}
+
+// parseImageRef splits image into name, tag and digest.
+// "registry:5000/app:1.0@sha256:..." has the name "registry:5000/app".
+func parseImageRef(image string) imageRef {
+	var ref imageRef
+	ref.name, ref.digest, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(ref.name, ":"); i > strings.LastIndex(ref.name, "/") {
+		ref.name, ref.tag = ref.name[:i], ref.name[i+1:]
+	}
+	return ref
+}
+
+// registry returns the registry host of the image, docker.io when it has
+// none.
+func (r imageRef) registry() string {
+	first, _, ok := strings.Cut(r.name, "/")
+	if !ok || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return "docker.io"
+	}
+	return first
+}
+
+func (c *ImageChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	var latest, undigested, unapproved, alwaysPull []types.ResourceKey
+	var latestImages, undigestedImages, unapprovedRegistries, alwaysPullImages []string
+
+	for key, resource := range graph.Resources {
+		if resource.Original == nil || resource.Original.Object == nil {
+			continue
+		}
+		obj := resource.Original.Object
+		// Pods and ReplicaSets created by a controller are reported
+		// through the controller.
+		if len(obj.GetOwnerReferences()) > 0 {
+			continue
+		}
+		var isLatest, isUndigested, isUnapproved, isAlwaysPull bool
+		for _, container := range podContainers(obj) {
+			image, _ := container["image"].(string)
+			if image == "" {
+				continue
+			}
+			ref := parseImageRef(image)
+
+			if ref.digest == "" && (ref.tag == "" || ref.tag == "latest") {
+				isLatest = true
+				latestImages = append(latestImages, image)
+			}
+			if ref.digest == "" {
+				isUndigested = true
+				undigestedImages = append(undigestedImages, image)
+			}
+			if len(c.AllowedRegistries) > 0 && !c.allowed(image) {
+				isUnapproved = true
+				unapprovedRegistries = append(unapprovedRegistries, ref.registry())
+			}
+			pinned := ref.digest != "" || (ref.tag != "" && ref.tag != "latest")
+			if policy, _ := container["imagePullPolicy"].(string); policy == "Always" && pinned {
+				isAlwaysPull = true
+				alwaysPullImages = append(alwaysPullImages, image)
+			}
+		}
+		if isLatest {
+			latest = append(latest, key)
+		}
+		if isUndigested {
+			undigested = append(undigested, key)
+		}
+		if isUnapproved {
+			unapproved = append(unapproved, key)
+		}
+		if isAlwaysPull {
+			alwaysPull = append(alwaysPull, key)
+		}
+	}
+
+	if len(latest) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-IMG-001",
+			Title:       "Images Using the Latest Tag",
+			Description: "Images without a tag or with :latest change under the same reference, so deployments are not reproducible and rollbacks do not restore the previous version",
+			Category:    c.Category(),
+			Severity:    SeverityWarning,
+			Compliant:   false,
+			Recommendations: []string{
+				fmt.Sprintf("Pin a version tag for %s", strings.Join(uniqueSorted(latestImages), ", ")),
+				"Regenerate with --pin-digests to deploy the image the tag points to now by digest",
+			},
+			AffectedResources: latest,
+			AutoFixable:       true,
+		})
+	}
+
+	if len(undigested) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-IMG-002",
+			Title:       "Images Not Pinned by Digest",
+			Description: "Tags can be moved to other images; only a digest guarantees that the image that was tested and scanned is the one that runs",
+			Category:    c.Category(),
+			Severity:    SeverityInfo,
+			Compliant:   false,
+			Recommendations: []string{
+				"Regenerate with --pin-digests to resolve tags to digests (values keep both tag and digest)",
+				fmt.Sprintf("Images without a digest: %s", strings.Join(uniqueSorted(undigestedImages), ", ")),
+			},
+			AffectedResources: undigested,
+			AutoFixable:       true,
+		})
+	}
+
+	if len(unapproved) > 0 {
+		registries := uniqueSorted(unapprovedRegistries)
+		recommendations := make([]string, 0, len(registries)+1)
+		for _, r := range registries {
+			recommendations = append(recommendations,
+				fmt.Sprintf("Mirror the images of %s and regenerate with --image-rewrite %s=%s", r, r, c.AllowedRegistries[0]))
+		}
+		recommendations = append(recommendations, fmt.Sprintf("Allowed registries: %s", strings.Join(c.AllowedRegistries, ", ")))
+		practices = append(practices, BestPractice{
+			ID:                "BP-IMG-003",
+			Title:             "Images From Unapproved Registries",
+			Description:       "Images are pulled from registries outside the allowlist, bypassing the scanning and availability guarantees of the approved registries",
+			Category:          c.Category(),
+			Severity:          SeverityError,
+			Compliant:         false,
+			Recommendations:   recommendations,
+			AffectedResources: unapproved,
+			AutoFixable:       true,
+		})
+	}
+
+	if len(alwaysPull) > 0 {
+		practices = append(practices, BestPractice{
+			ID:          "BP-IMG-004",
+			Title:       "imagePullPolicy Always on Pinned Images",
+			Description: "Pinned images do not change, so pulling them on every pod start only adds latency and makes pods depend on the registry being available",
+			Category:    c.Category(),
+			Severity:    SeverityInfo,
+			Compliant:   false,
+			Recommendations: []string{
+				fmt.Sprintf("Set imagePullPolicy: IfNotPresent for %s", strings.Join(uniqueSorted(alwaysPullImages), ", ")),
+			},
+			AffectedResources: alwaysPull,
+			AutoFixable:       false,
+		})
+	}
+
+	return practices
+}
+
+// allowed reports whether image is from one of the allowed registries.
+func (c *ImageChecker) allowed(image string) bool {
+	for _, r := range c.AllowedRegistries {
+		rule := processor.ImageRewrite{From: strings.TrimSuffix(r, "/"), To: r}
+		if _, ok := rule.Apply(image); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// podContainers returns the init and regular containers of a workload.
+func podContainers(obj *unstructured.Unstructured) []map[string]interface{} {
+	specPath := processor.PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return nil
+	}
+	var containers []map[string]interface{}
+	for _, field := range []string{"initContainers", "containers"} {
+		list, _, _ := unstructured.NestedSlice(obj.Object, append(append([]string{}, specPath...), field)...)
+		for _, item := range list {
+			if container, ok := item.(map[string]interface{}); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+	return containers
+}
+
+func uniqueSorted(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
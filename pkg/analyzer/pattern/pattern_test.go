@@ -1,9 +1,11 @@
 package pattern
 
 import (
+	"sort"
 	"strings"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -101,8 +103,8 @@ func TestDefaultAnalyzer_HasDetectorsAndCheckers(t *testing.T) {
 	if len(a.detectors) != 6 {
 		t.Errorf("DefaultAnalyzer detectors = %d; want 6", len(a.detectors))
 	}
-	if len(a.checkers) != 11 {
-		t.Errorf("DefaultAnalyzer checkers = %d; want 11", len(a.checkers))
+	if len(a.checkers) != 18 {
+		t.Errorf("DefaultAnalyzer checkers = %d; want 18", len(a.checkers))
 	}
 }
 
@@ -3867,3 +3869,399 @@ func TestTopologySpreadChecker_InvalidMaxSkew(t *testing.T) {
 		t.Error("expected BP-TSC-002 for invalid maxSkew value")
 	}
 }
+
+// ── ServiceAccountChecker ───────────────────────────────────────────────────
+
+func TestServiceAccountChecker_DefaultServiceAccount(t *testing.T) {
+	c := NewServiceAccountChecker()
+	g := makeGraph()
+	addResource(g, "apps", "v1", "Deployment", "web", "default", "web")
+	named := addResource(g, "apps", "v1", "Deployment", "api", "default", "api")
+	_ = unstructured.SetNestedField(named.Original.Object.Object, "api", "spec", "template", "spec", "serviceAccountName")
+	owned := addResource(g, "", "v1", "Pod", "web-abc", "default", "web")
+	owned.Original.Object.SetOwnerReferences([]metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-123"}})
+	addResource(g, "", "v1", "Service", "web", "default", "web")
+
+	practices := c.Check(g)
+	if len(practices) != 1 || practices[0].ID != "BP-SEC-004" {
+		t.Fatalf("expected BP-SEC-004, got %+v", practices)
+	}
+	affected := practices[0].AffectedResources
+	if len(affected) != 1 || affected[0].Name != "web" {
+		t.Errorf("expected only the web Deployment, got %v", affected)
+	}
+}
+
+func TestServiceAccountChecker_DedicatedServiceAccounts(t *testing.T) {
+	c := NewServiceAccountChecker()
+	g := makeGraph()
+	pr := addResource(g, "batch", "v1", "CronJob", "backup", "default", "backup")
+	_ = unstructured.SetNestedField(pr.Original.Object.Object, "backup", "spec", "jobTemplate", "spec", "template", "spec", "serviceAccountName")
+
+	if practices := c.Check(g); len(practices) != 0 {
+		t.Errorf("expected no practices, got %+v", practices)
+	}
+}
+
+// ── TokenAutomountChecker ───────────────────────────────────────────────────
+
+func TestTokenAutomountChecker_Unbound(t *testing.T) {
+	c := NewTokenAutomountChecker()
+	g := makeGraph()
+	addResource(g, "apps", "v1", "Deployment", "web", "default", "web")
+	disabled := addResource(g, "apps", "v1", "Deployment", "static", "default", "static")
+	_ = unstructured.SetNestedField(disabled.Original.Object.Object, false, "spec", "template", "spec", "automountServiceAccountToken")
+	inherited := addResource(g, "apps", "v1", "Deployment", "worker", "default", "worker")
+	_ = unstructured.SetNestedField(inherited.Original.Object.Object, "worker", "spec", "template", "spec", "serviceAccountName")
+	sa := addResource(g, "", "v1", "ServiceAccount", "worker", "default", "worker")
+	_ = unstructured.SetNestedField(sa.Original.Object.Object, false, "automountServiceAccountToken")
+	operator := addResource(g, "apps", "v1", "Deployment", "operator", "default", "operator")
+	_ = unstructured.SetNestedField(operator.Original.Object.Object, "operator", "spec", "template", "spec", "serviceAccountName")
+	binding := addResource(g, "rbac.authorization.k8s.io", "v1", "RoleBinding", "operator", "default", "operator")
+	_ = unstructured.SetNestedSlice(binding.Original.Object.Object, []interface{}{
+		map[string]interface{}{"kind": "ServiceAccount", "name": "operator"},
+	}, "subjects")
+
+	practices := c.Check(g)
+	if len(practices) != 1 || practices[0].ID != "BP-SEC-009" {
+		t.Fatalf("expected BP-SEC-009, got %+v", practices)
+	}
+	affected := practices[0].AffectedResources
+	if len(affected) != 1 || affected[0].Name != "web" {
+		t.Errorf("expected only the web Deployment, got %v", affected)
+	}
+}
+
+func TestTokenAutomountChecker_ExplicitAutomount(t *testing.T) {
+	c := NewTokenAutomountChecker()
+	g := makeGraph()
+	pr := addResource(g, "batch", "v1", "CronJob", "backup", "default", "backup")
+	_ = unstructured.SetNestedField(pr.Original.Object.Object, true, "spec", "jobTemplate", "spec", "template", "spec", "automountServiceAccountToken")
+	sa := addResource(g, "", "v1", "ServiceAccount", "default", "default", "backup")
+	_ = unstructured.SetNestedField(sa.Original.Object.Object, false, "automountServiceAccountToken")
+
+	// The pod setting overrides the ServiceAccount.
+	if practices := c.Check(g); len(practices) != 1 {
+		t.Errorf("expected BP-SEC-009, got %+v", practices)
+	}
+}
+
+// ── NodeOSChecker ───────────────────────────────────────────────────────────
+
+func TestNodeOSChecker_MixedInputs(t *testing.T) {
+	c := NewNodeOSChecker()
+	g := makeGraph()
+	win := addResource(g, "apps", "v1", "Deployment", "iis", "default", "iis")
+	_ = unstructured.SetNestedStringMap(win.Original.Object.Object, map[string]string{"kubernetes.io/os": "windows"}, "spec", "template", "spec", "nodeSelector")
+	addResource(g, "apps", "v1", "Deployment", "web", "default", "web")
+	addResource(g, "batch", "v1", "CronJob", "backup", "default", "backup")
+	addResource(g, "", "v1", "Service", "web", "default", "web")
+
+	practices := c.Check(g)
+	if len(practices) != 1 || practices[0].ID != "BP-OS-001" {
+		t.Fatalf("expected BP-OS-001, got %+v", practices)
+	}
+	if affected := practices[0].AffectedResources; len(affected) != 2 {
+		t.Errorf("expected the web Deployment and the backup CronJob, got %v", affected)
+	}
+}
+
+func TestNodeOSChecker_LinuxOnly(t *testing.T) {
+	c := NewNodeOSChecker()
+	g := makeGraph()
+	linux := addResource(g, "apps", "v1", "Deployment", "api", "default", "api")
+	_ = unstructured.SetNestedStringMap(linux.Original.Object.Object, map[string]string{"kubernetes.io/os": "linux"}, "spec", "template", "spec", "nodeSelector")
+	addResource(g, "apps", "v1", "Deployment", "web", "default", "web")
+
+	if practices := c.Check(g); len(practices) != 0 {
+		t.Errorf("expected no practices for Linux-only inputs, got %+v", practices)
+	}
+}
+
+func TestPersistentVolumeChecker(t *testing.T) {
+	c := NewPersistentVolumeChecker()
+	g := makeGraph()
+	addResource(g, "", "v1", "PersistentVolumeClaim", "data", "default", "db")
+	addResource(g, "storage.k8s.io", "v1", "StorageClass", "fast", "", "fast")
+	if practices := c.Check(g); len(practices) != 0 {
+		t.Fatalf("expected no practices without PersistentVolumes, got %+v", practices)
+	}
+
+	pv := addResource(g, "", "v1", "PersistentVolume", "pvc-1234", "", "db")
+	pv.Original.Object.SetAnnotations(map[string]string{"pv.kubernetes.io/provisioned-by": "ebs.csi.aws.com"})
+	practices := c.Check(g)
+	if len(practices) != 1 || practices[0].ID != "BP-STOR-001" || practices[0].Severity != SeverityWarning {
+		t.Fatalf("expected a BP-STOR-001 warning, got %+v", practices)
+	}
+	if affected := practices[0].AffectedResources; len(affected) != 1 || affected[0].Name != "pvc-1234" {
+		t.Errorf("expected the PersistentVolume, got %v", affected)
+	}
+	if recs := practices[0].Recommendations; !strings.Contains(recs[len(recs)-1], "provisioner") {
+		t.Errorf("expected a recommendation for provisioned volumes, got %v", recs)
+	}
+}
+
+// addWorkloadImages creates a Deployment whose pod template runs the given
+// containers.
+func addWorkloadImages(g *types.ResourceGraph, name string, containers ...map[string]interface{}) *types.ProcessedResource {
+	pr := addResource(g, "apps", "v1", "Deployment", name, "default", name)
+	list := make([]interface{}, 0, len(containers))
+	for _, c := range containers {
+		list = append(list, c)
+	}
+	_ = unstructured.SetNestedSlice(pr.Original.Object.Object, list, "spec", "template", "spec", "containers")
+	return pr
+}
+
+func practiceByID(practices []BestPractice, id string) *BestPractice {
+	for i := range practices {
+		if practices[i].ID == id {
+			return &practices[i]
+		}
+	}
+	return nil
+}
+
+func TestImageChecker_Findings(t *testing.T) {
+	g := makeGraph()
+	addWorkloadImages(g, "latest",
+		map[string]interface{}{"name": "app", "image": "nginx"},
+		map[string]interface{}{"name": "sidecar", "image": "quay.io/envoy:latest"})
+	addWorkloadImages(g, "tagged",
+		map[string]interface{}{"name": "app", "image": "registry.example.com/team/api:1.4", "imagePullPolicy": "Always"})
+	addWorkloadImages(g, "digest",
+		map[string]interface{}{"name": "app", "image": "registry.example.com/team/db:16@sha256:abc", "imagePullPolicy": "IfNotPresent"})
+
+	practices := NewImageChecker("registry.example.com/team").Check(g)
+
+	expect := map[string][]string{
+		"BP-IMG-001": {"latest"},
+		"BP-IMG-002": {"latest", "tagged"},
+		"BP-IMG-003": {"latest"},
+		"BP-IMG-004": {"tagged"},
+	}
+	if len(practices) != len(expect) {
+		t.Fatalf("expected %d practices, got %+v", len(expect), practices)
+	}
+	for id, names := range expect {
+		bp := practiceByID(practices, id)
+		if bp == nil {
+			t.Errorf("missing %s", id)
+			continue
+		}
+		var got []string
+		for _, key := range bp.AffectedResources {
+			got = append(got, key.Name)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(names, ",") {
+			t.Errorf("%s affects %v, want %v", id, got, names)
+		}
+	}
+
+	recommendations := strings.Join(practiceByID(practices, "BP-IMG-003").Recommendations, "\n")
+	for _, want := range []string{
+		"--image-rewrite docker.io=registry.example.com/team",
+		"--image-rewrite quay.io=registry.example.com/team",
+	} {
+		if !strings.Contains(recommendations, want) {
+			t.Errorf("BP-IMG-003 recommendations missing %q:\n%s", want, recommendations)
+		}
+	}
+	if r := practiceByID(practices, "BP-IMG-001").Recommendations[0]; r != "Pin a version tag for nginx, quay.io/envoy:latest" {
+		t.Errorf("unexpected BP-IMG-001 recommendation %q", r)
+	}
+}
+
+func TestImageChecker_NoAllowlist(t *testing.T) {
+	g := makeGraph()
+	addWorkloadImages(g, "web", map[string]interface{}{"name": "app", "image": "docker.io/library/nginx:1.25@sha256:abc"})
+
+	if practices := NewImageChecker().Check(g); len(practices) != 0 {
+		t.Errorf("expected no practices for a pinned image without an allowlist, got %+v", practices)
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image                       string
+		name, tag, digest, registry string
+	}{
+		{"nginx", "nginx", "", "", "docker.io"},
+		{"nginx:1.25", "nginx", "1.25", "", "docker.io"},
+		{"localhost:5000/app", "localhost:5000/app", "", "", "localhost:5000"},
+		{"registry:5000/team/app:v1@sha256:abc", "registry:5000/team/app", "v1", "sha256:abc", "registry:5000"},
+		{"ghcr.io/org/app@sha256:abc", "ghcr.io/org/app", "", "sha256:abc", "ghcr.io"},
+	}
+	for _, tt := range tests {
+		ref := parseImageRef(tt.image)
+		if ref.name != tt.name || ref.tag != tt.tag || ref.digest != tt.digest || ref.registry() != tt.registry {
+			t.Errorf("parseImageRef(%q) = %+v (registry %s)", tt.image, ref, ref.registry())
+		}
+	}
+}
+
+func TestAnalyzer_Checker(t *testing.T) {
+	a := DefaultAnalyzer()
+	if _, ok := a.Checker("image-hygiene").(*ImageChecker); !ok {
+		t.Error("expected DefaultAnalyzer to register the image checker")
+	}
+	if a.Checker("missing") != nil {
+		t.Error("expected nil for an unknown checker")
+	}
+}
+
+func TestPodSecurityContextChecker_Findings(t *testing.T) {
+	g := makeGraph()
+	// Pod-level RuntimeDefault covers the container.
+	restricted := addWorkloadImages(g, "restricted", map[string]interface{}{"name": "app", "image": "app:1"})
+	_ = unstructured.SetNestedMap(restricted.Original.Object.Object, map[string]interface{}{
+		"runAsNonRoot":   true,
+		"fsGroup":        int64(1000),
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}, "spec", "template", "spec", "securityContext")
+	_ = unstructured.SetNestedSlice(restricted.Original.Object.Object, []interface{}{
+		map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "data"}},
+	}, "spec", "template", "spec", "volumes")
+
+	// The container overrides the pod-level profile with Unconfined.
+	override := addWorkloadImages(g, "override", map[string]interface{}{
+		"name": "app", "image": "app:1",
+		"securityContext": map[string]interface{}{"seccompProfile": map[string]interface{}{"type": "Unconfined"}},
+	})
+	_ = unstructured.SetNestedMap(override.Original.Object.Object, map[string]interface{}{
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}, "spec", "template", "spec", "securityContext")
+
+	// No securityContext, an unconfined AppArmor annotation, a PVC and the
+	// root group.
+	legacy := addWorkloadImages(g, "legacy", map[string]interface{}{
+		"name": "app", "image": "app:1",
+		"securityContext": map[string]interface{}{"runAsGroup": int64(0)},
+	})
+	_ = unstructured.SetNestedStringMap(legacy.Original.Object.Object, map[string]string{
+		"container.apparmor.security.beta.kubernetes.io/app": "unconfined",
+	}, "spec", "template", "metadata", "annotations")
+	_ = unstructured.SetNestedSlice(legacy.Original.Object.Object, []interface{}{
+		map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "data"}},
+	}, "spec", "template", "spec", "volumes")
+
+	// Supplemental root group at pod level.
+	groups := addWorkloadImages(g, "groups", map[string]interface{}{"name": "app", "image": "app:1"})
+	_ = unstructured.SetNestedMap(groups.Original.Object.Object, map[string]interface{}{
+		"seccompProfile":     map[string]interface{}{"type": "Localhost", "localhostProfile": "app.json"},
+		"appArmorProfile":    map[string]interface{}{"type": "Unconfined"},
+		"supplementalGroups": []interface{}{int64(1000), int64(0)},
+	}, "spec", "template", "spec", "securityContext")
+
+	practices := NewPodSecurityContextChecker().Check(g)
+
+	expect := map[string][]string{
+		"BP-SEC-005": {"legacy", "override"},
+		"BP-SEC-006": {"groups", "legacy"},
+		"BP-SEC-007": {"legacy"},
+		"BP-SEC-008": {"groups", "legacy"},
+	}
+	if len(practices) != len(expect) {
+		t.Fatalf("expected %d practices, got %+v", len(expect), practices)
+	}
+	for id, names := range expect {
+		bp := practiceByID(practices, id)
+		if bp == nil {
+			t.Errorf("missing %s", id)
+			continue
+		}
+		var got []string
+		for _, key := range bp.AffectedResources {
+			got = append(got, key.Name)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(names, ",") {
+			t.Errorf("%s affects %v, want %v", id, got, names)
+		}
+	}
+}
+
+func TestSecurityChecker_PodLevelRunAsNonRoot(t *testing.T) {
+	g := makeGraph()
+	pr := addWorkloadWithContainers(g, "Deployment", "app", "app", []map[string]interface{}{
+		{"name": "main", "securityContext": map[string]interface{}{"readOnlyRootFilesystem": true}},
+		{"name": "sidecar"},
+	})
+	pr.Values["podSecurityContext"] = map[string]interface{}{"runAsNonRoot": true}
+
+	for _, p := range NewSecurityChecker().Check(g) {
+		if p.ID == "BP-SEC-001" {
+			t.Errorf("containers inherit runAsNonRoot from the pod securityContext, got %+v", p)
+		}
+	}
+}
+
+func TestPodSecurityStandardsChecker_PodLevelRestricted(t *testing.T) {
+	g := makeGraph()
+	pr := addWorkloadWithContainers(g, "Deployment", "app", "app", []map[string]interface{}{
+		{
+			"name": "main",
+			"securityContext": map[string]interface{}{
+				"capabilities": map[string]interface{}{"drop": []interface{}{"ALL"}},
+			},
+		},
+	})
+	pr.Values["podSecurityContext"] = map[string]interface{}{
+		"runAsNonRoot":   true,
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}
+
+	if level := NewPodSecurityStandardsChecker().classifyPSSLevel(pr); level != pssRestricted {
+		t.Errorf("expected restricted with pod-level runAsNonRoot and seccompProfile, got %s", level)
+	}
+}
+
+func TestPodSecurityStandardsChecker_PodSecurityLevel(t *testing.T) {
+	c := NewPodSecurityStandardsChecker()
+	g := makeGraph()
+	if level := c.PodSecurityLevel(g); level != "restricted" {
+		t.Errorf("expected restricted without workloads, got %s", level)
+	}
+
+	pr := addWorkloadWithContainers(g, "Deployment", "web", "web", []map[string]interface{}{
+		{
+			"name": "main",
+			"securityContext": map[string]interface{}{
+				"capabilities": map[string]interface{}{"drop": []interface{}{"ALL"}},
+			},
+		},
+	})
+	pr.Values["podSecurityContext"] = map[string]interface{}{
+		"runAsNonRoot":   true,
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}
+	if level := c.PodSecurityLevel(g); level != "restricted" {
+		t.Errorf("expected restricted for a restricted workload, got %s", level)
+	}
+
+	addWorkloadWithContainers(g, "StatefulSet", "db", "db", []map[string]interface{}{{"name": "main"}})
+	if level := c.PodSecurityLevel(g); level != "baseline" {
+		t.Errorf("expected baseline with a baseline workload, got %s", level)
+	}
+
+	agent := addWorkloadWithContainers(g, "DaemonSet", "agent", "agent", nil)
+	agent.Values["hostNetwork"] = true
+	if level := c.PodSecurityLevel(g); level != "privileged" {
+		t.Errorf("expected privileged with a hostNetwork workload, got %s", level)
+	}
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	order := []Severity{SeverityInfo, SeverityWarning, SeverityError, SeverityCritical}
+	for i, s := range order {
+		for j, threshold := range order {
+			if got := s.AtLeast(threshold); got != (i >= j) {
+				t.Errorf("%s.AtLeast(%s) = %v", s, threshold, got)
+			}
+		}
+	}
+	if !SeverityInfo.AtLeast("") {
+		t.Error("every severity should be at least the empty threshold")
+	}
+}
@@ -0,0 +1,132 @@
+package pattern
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// RulesFile is the rule configuration file looked up in the working
+// directory.
+const RulesFile = ".dhg-rules.yaml"
+
+// defaultMinReplicas is the replica count below which Deployments are
+// reported by BP-HA-001.
+const defaultMinReplicas = 2
+
+// Rules is the content of a .dhg-rules.yaml file, tuning the best-practice
+// checkers:
+//
+//	minReplicas: 3
+//	allowPrivileged:
+//	  - node-exporter
+//	  - kube-system/cilium
+//	severity:
+//	  BP-HA-001: error
+//	disable:
+//	  - BP-IMG-002
+type Rules struct {
+	// MinReplicas is the replica count below which Deployments are
+	// reported by BP-HA-001 (default 2).
+	MinReplicas int `json:"minReplicas,omitempty"`
+
+	// AllowPrivileged lists workloads, by name or namespace/name, that may
+	// run privileged containers; they are not reported by BP-SEC-003 and
+	// BP-PSS-001.
+	AllowPrivileged []string `json:"allowPrivileged,omitempty"`
+
+	// Severity overrides the severity of violations of rule IDs.
+	Severity map[string]Severity `json:"severity,omitempty"`
+
+	// Disable lists rule IDs that are not checked.
+	Disable []string `json:"disable,omitempty"`
+}
+
+// LoadRules reads a rule configuration file. A missing file yields an empty
+// configuration.
+func LoadRules(file string) (*Rules, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Rules{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+	return ParseRules(data)
+}
+
+// ParseRules parses rule configuration YAML.
+func ParseRules(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+	if rules.MinReplicas < 0 {
+		return nil, fmt.Errorf("rules: minReplicas must not be negative, got %d", rules.MinReplicas)
+	}
+	for rule, sev := range rules.Severity {
+		switch sev {
+		case SeverityInfo, SeverityWarning, SeverityError, SeverityCritical:
+		default:
+			return nil, fmt.Errorf("rules: rule %s: invalid severity %q (must be info, warning, error or critical)", rule, sev)
+		}
+	}
+	return &rules, nil
+}
+
+// ApplyRules configures the registered checkers with the thresholds of rules
+// and makes Analyze drop the disabled rules and re-grade violations. rules
+// may be nil.
+func (a *Analyzer) ApplyRules(rules *Rules) {
+	a.rules = rules
+	if rules == nil {
+		return
+	}
+	for _, c := range a.checkers {
+		switch c := c.(type) {
+		case *HighAvailabilityChecker:
+			c.MinReplicas = rules.MinReplicas
+		case *SecurityChecker:
+			c.AllowPrivileged = rules.AllowPrivileged
+		case *PodSecurityStandardsChecker:
+			c.AllowPrivileged = rules.AllowPrivileged
+		}
+	}
+}
+
+// apply drops disabled rules and applies severity overrides to violations.
+func (r *Rules) apply(practices []BestPractice) []BestPractice {
+	if r == nil {
+		return practices
+	}
+	disabled := make(map[string]bool, len(r.Disable))
+	for _, rule := range r.Disable {
+		disabled[rule] = true
+	}
+
+	out := make([]BestPractice, 0, len(practices))
+	for _, bp := range practices {
+		if disabled[bp.ID] {
+			continue
+		}
+		if sev, ok := r.Severity[bp.ID]; ok && !bp.Compliant {
+			bp.Severity = sev
+		}
+		out = append(out, bp)
+	}
+	return out
+}
+
+// allowedByName reports whether key is in names, by name or namespace/name.
+func allowedByName(key types.ResourceKey, names []string) bool {
+	for _, name := range names {
+		if name == key.Name || name == key.Namespace+"/"+key.Name {
+			return true
+		}
+	}
+	return false
+}
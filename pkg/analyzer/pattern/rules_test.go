@@ -0,0 +1,86 @@
+package pattern
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte("minReplicas: 3\nallowPrivileged: [node-exporter]\nseverity:\n  BP-HA-001: error\ndisable: [BP-IMG-002]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.MinReplicas != 3 || len(rules.AllowPrivileged) != 1 || rules.Severity["BP-HA-001"] != SeverityError || rules.Disable[0] != "BP-IMG-002" {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	for _, tc := range []struct {
+		data string
+		want string
+	}{
+		{"minReplica: 3\n", "unknown field"},
+		{"minReplicas: -1\n", "minReplicas must not be negative"},
+		{"severity:\n  BP-HA-001: fatal\n", "invalid severity"},
+	} {
+		if _, err := ParseRules([]byte(tc.data)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: expected an error containing %q, got %v", tc.data, tc.want, err)
+		}
+	}
+}
+
+func TestLoadRules_MissingFile(t *testing.T) {
+	rules, err := LoadRules(filepath.Join(t.TempDir(), RulesFile))
+	if err != nil || rules == nil || rules.MinReplicas != 0 {
+		t.Errorf("expected empty rules for a missing file, got %+v, %v", rules, err)
+	}
+
+	file := filepath.Join(t.TempDir(), RulesFile)
+	if err := os.WriteFile(file, []byte("disable: [BP-HA-002]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rules, err := LoadRules(file); err != nil || len(rules.Disable) != 1 {
+		t.Errorf("expected the rules of the file, got %+v, %v", rules, err)
+	}
+}
+
+func TestAnalyzer_ApplyRules(t *testing.T) {
+	g := makeGraph()
+	web := addWorkloadWithContainers(g, "Deployment", "web", "web", []map[string]interface{}{{"name": "web"}})
+	web.Values["replicas"] = int64(2)
+	addWorkloadWithContainers(g, "DaemonSet", "node-exporter", "node-exporter", []map[string]interface{}{
+		{"name": "exporter", "securityContext": map[string]interface{}{"privileged": true}},
+	})
+
+	defaults := DefaultAnalyzer().Analyze(g).BestPractices
+	if practiceByID(defaults, "BP-HA-001") != nil {
+		t.Error("2 replicas should not be reported by default")
+	}
+	for _, id := range []string{"BP-SEC-003", "BP-PSS-001", "BP-HA-002"} {
+		if practiceByID(defaults, id) == nil {
+			t.Fatalf("expected %s by default, got %+v", id, defaults)
+		}
+	}
+
+	a := DefaultAnalyzer()
+	a.ApplyRules(&Rules{
+		MinReplicas:     3,
+		AllowPrivileged: []string{"default/node-exporter"},
+		Severity:        map[string]Severity{"BP-HA-001": SeverityError},
+		Disable:         []string{"BP-HA-002"},
+	})
+	practices := a.Analyze(g).BestPractices
+
+	if ha := practiceByID(practices, "BP-HA-001"); ha == nil || ha.Severity != SeverityError || len(ha.AffectedResources) != 1 {
+		t.Errorf("expected BP-HA-001 as an error for 2 of 3 replicas, got %+v", ha)
+	} else if !strings.Contains(strings.Join(ha.Recommendations, "\n"), "at least 3") {
+		t.Errorf("recommendations should mention the minimum, got %v", ha.Recommendations)
+	}
+	if practiceByID(practices, "BP-SEC-003") != nil || practiceByID(practices, "BP-PSS-001") != nil {
+		t.Error("allowed privileged workloads should not be reported")
+	}
+	if practiceByID(practices, "BP-HA-002") != nil {
+		t.Error("disabled rules should not be reported")
+	}
+}
@@ -0,0 +1,239 @@
+package pattern
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// SARIF 2.1.0 constants.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	sarifToolName = "dhg"
+	sarifToolURI  = "https://github.com/deckhouse/deckhouse-helm-generator"
+)
+
+// sarifLog is the root SARIF document.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name,omitempty"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	FullDescription      *sarifMessage       `json:"fullDescription,omitempty"`
+	Help                 *sarifMessage       `json:"help,omitempty"`
+	DefaultConfiguration sarifRuleConfig     `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// FormatSARIF formats best-practice violations as a SARIF 2.1.0 log.
+//
+// Every practice ID becomes a rule; each affected resource of a non-compliant
+// practice becomes a result. Resources are reported as logical locations
+// (Kind/namespace/name); when graph is non-nil and a resource was extracted
+// from a file, the file is added as its physical location.
+func (f *Formatter) FormatSARIF(report *Report, graph *types.ResourceGraph) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			Version:        f.ToolVersion,
+			InformationURI: sarifToolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	var practices []BestPractice
+	if report != nil && report.AnalysisResult != nil {
+		practices = report.AnalysisResult.BestPractices
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, bp := range practices {
+		idx, ok := ruleIndex[bp.ID]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[bp.ID] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFor(bp))
+		}
+		if bp.Compliant {
+			continue
+		}
+
+		affected := append([]types.ResourceKey(nil), bp.AffectedResources...)
+		sort.Slice(affected, func(i, j int) bool {
+			return affected[i].String() < affected[j].String()
+		})
+		for _, key := range affected {
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    bp.ID,
+				RuleIndex: idx,
+				Level:     sarifLevel(bp.Severity),
+				Message:   sarifMessage{Text: bp.Title + ": " + key.String()},
+				Locations: []sarifLocation{sarifLocationFor(key, graph)},
+			})
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sarifRuleFor builds a rule descriptor from a best practice.
+func sarifRuleFor(bp BestPractice) sarifRule {
+	rule := sarifRule{
+		ID:                   bp.ID,
+		Name:                 sarifRuleName(bp.Title),
+		ShortDescription:     sarifMessage{Text: bp.Title},
+		DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(bp.Severity)},
+		Properties: sarifRuleProperties{
+			SecuritySeverity: sarifSecuritySeverity(bp.Severity),
+		},
+	}
+	if bp.Category != "" {
+		rule.Properties.Tags = []string{bp.Category}
+	}
+	if bp.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: bp.Description}
+	}
+	if len(bp.Recommendations) > 0 {
+		rule.Help = &sarifMessage{Text: strings.Join(bp.Recommendations, "\n")}
+	}
+	return rule
+}
+
+// sarifLocationFor builds the location of an affected resource.
+func sarifLocationFor(key types.ResourceKey, graph *types.ResourceGraph) sarifLocation {
+	loc := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{
+			Name:               key.Name,
+			FullyQualifiedName: key.String(),
+			Kind:               "resource",
+		}},
+	}
+
+	if graph == nil {
+		return loc
+	}
+	r, ok := graph.Resources[key]
+	if !ok || r.Original == nil || r.Original.SourcePath == "" || r.Original.Source == types.SourceCluster {
+		return loc
+	}
+	loc.PhysicalLocation = &sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(r.Original.SourcePath)},
+	}
+	return loc
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifSecuritySeverity maps a severity to a CVSS-like score used by code
+// scanning tools to rank findings.
+func sarifSecuritySeverity(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return "9.0"
+	case SeverityError:
+		return "7.0"
+	case SeverityWarning:
+		return "4.0"
+	default:
+		return "1.0"
+	}
+}
+
+// sarifRuleName converts a practice title to a PascalCase rule name.
+func sarifRuleName(title string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(title, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		sb.WriteString(strings.ToUpper(word[:1]))
+		sb.WriteString(word[1:])
+	}
+	return sb.String()
+}
@@ -0,0 +1,134 @@
+package pattern
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ── FormatSARIF ──────────────────────────────────────────────────────────────
+
+func sarifReport(practices ...BestPractice) *Report {
+	return &Report{AnalysisResult: &AnalysisResult{BestPractices: practices}}
+}
+
+func decodeSARIF(t *testing.T, out string) sarifLog {
+	t.Helper()
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v\n%s", err, out)
+	}
+	return log
+}
+
+func TestFormatSARIF_RulesAndResults(t *testing.T) {
+	g := makeGraph()
+	web := addResource(g, "apps", "v1", "Deployment", "web", "prod", "web")
+	web.Original.Source = types.SourceFile
+	web.Original.SourcePath = "manifests/web.yaml"
+	api := addResource(g, "apps", "v1", "Deployment", "api", "prod", "api")
+
+	report := sarifReport(
+		BestPractice{
+			ID:                "BP-SEC-001",
+			Title:             "Containers Running as Root",
+			Description:       "Containers should not run as root",
+			Category:          "security",
+			Severity:          SeverityError,
+			Recommendations:   []string{"Set runAsNonRoot: true"},
+			AffectedResources: []types.ResourceKey{web.Original.ResourceKey(), api.Original.ResourceKey()},
+		},
+		BestPractice{
+			ID:        "BP-HA-001",
+			Title:     "Replicas Configured",
+			Category:  "high-availability",
+			Severity:  SeverityInfo,
+			Compliant: true,
+		},
+	)
+
+	f := NewFormatter(false)
+	f.ToolVersion = "1.2.3"
+	out, err := f.FormatSARIF(report, g)
+	if err != nil {
+		t.Fatalf("FormatSARIF: %v", err)
+	}
+	log := decodeSARIF(t, out)
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected one SARIF 2.1.0 run, got version %q with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "dhg" || run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("unexpected driver: %+v", run.Tool.Driver)
+	}
+
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.ID != "BP-SEC-001" || rule.Name != "ContainersRunningAsRoot" {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+	if rule.DefaultConfiguration.Level != "error" || rule.Properties.SecuritySeverity != "7.0" {
+		t.Errorf("unexpected rule severity: %+v", rule)
+	}
+	if rule.Help == nil || rule.Help.Text != "Set runAsNonRoot: true" {
+		t.Errorf("expected recommendations as help text, got %+v", rule.Help)
+	}
+
+	// Compliant practices produce rules but no results.
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+	for _, res := range run.Results {
+		if res.RuleID != "BP-SEC-001" || res.RuleIndex != 0 || res.Level != "error" {
+			t.Errorf("unexpected result: %+v", res)
+		}
+	}
+
+	// Results are sorted by resource: api (no file) before web (file).
+	apiLoc := run.Results[0].Locations[0]
+	if apiLoc.PhysicalLocation != nil {
+		t.Errorf("expected no physical location for api, got %+v", apiLoc.PhysicalLocation)
+	}
+	if apiLoc.LogicalLocations[0].FullyQualifiedName != "Deployment/prod/api" {
+		t.Errorf("unexpected logical location: %+v", apiLoc.LogicalLocations)
+	}
+	webLoc := run.Results[1].Locations[0]
+	if webLoc.PhysicalLocation == nil || webLoc.PhysicalLocation.ArtifactLocation.URI != "manifests/web.yaml" {
+		t.Errorf("expected physical location manifests/web.yaml, got %+v", webLoc.PhysicalLocation)
+	}
+}
+
+func TestFormatSARIF_EmptyReport(t *testing.T) {
+	out, err := NewFormatter(false).FormatSARIF(sarifReport(), nil)
+	if err != nil {
+		t.Fatalf("FormatSARIF: %v", err)
+	}
+
+	// rules and results must be present (empty arrays) for SARIF consumers.
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		t.Fatal(err)
+	}
+	run := raw["runs"].([]interface{})[0].(map[string]interface{})
+	if results, ok := run["results"].([]interface{}); !ok || len(results) != 0 {
+		t.Errorf("expected empty results array, got %v", run["results"])
+	}
+}
+
+func TestSarifLevel(t *testing.T) {
+	tests := map[Severity]string{
+		SeverityCritical: "error",
+		SeverityError:    "error",
+		SeverityWarning:  "warning",
+		SeverityInfo:     "note",
+	}
+	for severity, want := range tests {
+		if got := sarifLevel(severity); got != want {
+			t.Errorf("sarifLevel(%q) = %q; want %q", severity, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pattern
+
+import (
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// provisionedByAnnotation is set on the PersistentVolumes a provisioner
+// created for a PVC.
+const provisionedByAnnotation = "pv.kubernetes.io/provisioned-by"
+
+// PersistentVolumeChecker reports PersistentVolumes embedded in the chart.
+// A PersistentVolume is bound to the storage of one cluster (volume handles,
+// NFS servers, host paths, node affinity): installed elsewhere it points at
+// storage that does not exist or belongs to another workload.
+type PersistentVolumeChecker struct{}
+
+// NewPersistentVolumeChecker creates a new PersistentVolume checker.
+func NewPersistentVolumeChecker() *PersistentVolumeChecker {
+	return &PersistentVolumeChecker{}
+}
+
+func (c *PersistentVolumeChecker) Name() string {
+	return "persistent-volumes"
+}
+
+func (c *PersistentVolumeChecker) Category() string {
+	return "Portability"
+}
+
+func (c *PersistentVolumeChecker) Check(graph *types.ResourceGraph) []BestPractice {
+	practices := make([]BestPractice, 0)
+
+	pvs := make([]types.ResourceKey, 0)
+	provisioned := false
+	for key, resource := range graph.Resources {
+		if key.GVK.Group != "" || key.GVK.Kind != "PersistentVolume" {
+			continue
+		}
+		pvs = append(pvs, key)
+		if resource.Original != nil && resource.Original.Object != nil {
+			if _, ok := resource.Original.Object.GetAnnotations()[provisionedByAnnotation]; ok {
+				provisioned = true
+			}
+		}
+	}
+
+	if len(pvs) > 0 {
+		recommendations := []string{
+			"Run dhg generate with --skip-persistent-volumes to leave them out of the chart",
+			"Provision volumes dynamically: PVCs with a StorageClass instead of PersistentVolumes",
+			"For static provisioning, set the volume source (services.<name>.persistentVolumes.<volume>.source) per cluster",
+		}
+		if provisioned {
+			recommendations = append(recommendations,
+				"Volumes annotated "+provisionedByAnnotation+" were created by a provisioner for a PVC; the PVC provisions a new one in every cluster")
+		}
+		practices = append(practices, BestPractice{
+			ID:                "BP-STOR-001",
+			Title:             "Cluster-Specific PersistentVolumes",
+			Description:       "PersistentVolumes are bound to the storage of one cluster; installed elsewhere they point at storage that does not exist or belongs to another workload",
+			Category:          c.Category(),
+			Severity:          SeverityWarning,
+			Compliant:         false,
+			Recommendations:   recommendations,
+			AffectedResources: pvs,
+			AutoFixable:       true,
+		})
+	}
+
+	return practices
+}
@@ -67,6 +67,23 @@ const (
 	SeverityCritical Severity = "critical"
 )
 
+func (s Severity) rank() int {
+	switch s {
+	case SeverityCritical:
+		return 3
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as severe as threshold or more.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() >= threshold.rank()
+}
+
 // BestPractice represents a detected best practice or anti-pattern.
 type BestPractice struct {
 	// ID is unique identifier
@@ -119,6 +136,10 @@ type AnalysisResult struct {
 
 	// Recommendations are high-level architectural recommendations
 	Recommendations []Recommendation
+
+	// BaselineSuppressed is the count of violations left out because they
+	// are recorded in the baseline
+	BaselineSuppressed int `json:",omitempty"`
 }
 
 // AnalysisMetrics contains quantitative analysis metrics.
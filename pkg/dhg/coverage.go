@@ -0,0 +1,161 @@
+package dhg
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/value"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// Handling is how the resources of a GroupVersionKind end up in a chart.
+type Handling string
+
+const (
+	// HandlingProcessor: templated by a dedicated processor.
+	HandlingProcessor Handling = "processor"
+	// HandlingGeneric: no processor handled the kind; the generic fallback
+	// templated only metadata and copied spec into values.
+	HandlingGeneric Handling = "generic"
+	// HandlingPassthrough: copied verbatim (--passthrough-kinds,
+	// --template-kinds).
+	HandlingPassthrough Handling = "passthrough"
+	// HandlingSkipped: left out of the chart.
+	HandlingSkipped Handling = "skipped"
+)
+
+// handlingOrder orders the kinds of a coverage report.
+var handlingOrder = map[Handling]int{
+	HandlingProcessor:   0,
+	HandlingGeneric:     1,
+	HandlingPassthrough: 2,
+	HandlingSkipped:     3,
+}
+
+// KindCoverage describes the handling of the resources of one
+// GroupVersionKind.
+type KindCoverage struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Handling   Handling `json:"handling"`
+
+	// Processor is the name of the processor that handled the resources.
+	Processor string `json:"processor,omitempty"`
+
+	// Reason is why the resources were skipped.
+	Reason string `json:"reason,omitempty"`
+
+	// Resources is the number of resources.
+	Resources int `json:"resources"`
+}
+
+// Coverage reports which GroupVersionKinds of the input are templated by
+// dedicated processors, fall back to generic handling or are skipped
+// (dhg coverage).
+type Coverage struct {
+	Kinds []KindCoverage `json:"kinds"`
+
+	// Resources counts the resources by handling.
+	Resources map[Handling]int `json:"resources"`
+}
+
+// SkippedResource is an object left out before processing, such as the
+// objects reported by extractor.Options.OnSkip.
+type SkippedResource struct {
+	Object *unstructured.Unstructured
+	Reason string
+}
+
+// Coverage runs resources through the processor registry and reports how
+// each GroupVersionKind is handled. Resources that fail to process are
+// reported as skipped with the error, and the objects in skipped with their
+// reason. No chart is generated.
+func (g *Generator) Coverage(ctx context.Context, resources []*types.ExtractedResource, skipped []SkippedResource) (*Coverage, error) {
+	type key struct {
+		apiVersion string
+		kind       string
+		handling   Handling
+		processor  string
+		reason     string
+	}
+	counts := make(map[key]int)
+
+	all := make(map[types.ResourceKey]*types.ExtractedResource, len(resources))
+	for _, r := range resources {
+		all[r.ResourceKey()] = r
+	}
+	externalFiles := value.NewExternalFileManager()
+	valueProcessor := value.DefaultProcessor()
+	processorOptions := g.processorOptions()
+
+	for _, extracted := range resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		obj := extracted.Object
+		procCtx := processor.Context{
+			Ctx:                 ctx,
+			ChartName:           g.opts.ChartName,
+			OutputMode:          g.opts.Mode,
+			ServiceName:         g.serviceName(obj, all),
+			Namespace:           obj.GetNamespace(),
+			AllResources:        all,
+			ExternalFileManager: externalFiles,
+			ValueProcessor:      valueProcessor,
+			Options:             processorOptions,
+		}
+
+		k := key{apiVersion: obj.GetAPIVersion(), kind: obj.GetKind()}
+		result, err := g.processors.Process(procCtx, obj)
+		switch {
+		case err != nil:
+			k.handling, k.reason = HandlingSkipped, err.Error()
+		case result.Processor == processor.GenericProcessorName:
+			k.handling = HandlingGeneric
+		case result.Processor == processor.PassthroughProcessorName:
+			k.handling = HandlingPassthrough
+		default:
+			k.handling, k.processor = HandlingProcessor, result.Processor
+		}
+		counts[k]++
+	}
+	for _, s := range skipped {
+		counts[key{apiVersion: s.Object.GetAPIVersion(), kind: s.Object.GetKind(), handling: HandlingSkipped, reason: s.Reason}]++
+	}
+
+	out := &Coverage{
+		Kinds:     make([]KindCoverage, 0, len(counts)),
+		Resources: make(map[Handling]int, len(handlingOrder)),
+	}
+	for h := range handlingOrder {
+		out.Resources[h] = 0
+	}
+	for k, n := range counts {
+		out.Kinds = append(out.Kinds, KindCoverage{
+			APIVersion: k.apiVersion,
+			Kind:       k.kind,
+			Handling:   k.handling,
+			Processor:  k.processor,
+			Reason:     k.reason,
+			Resources:  n,
+		})
+		out.Resources[k.handling] += n
+	}
+	sort.Slice(out.Kinds, func(i, j int) bool {
+		a, b := out.Kinds[i], out.Kinds[j]
+		if a.Handling != b.Handling {
+			return handlingOrder[a.Handling] < handlingOrder[b.Handling]
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.APIVersion != b.APIVersion {
+			return a.APIVersion < b.APIVersion
+		}
+		return a.Reason < b.Reason
+	})
+	return out, nil
+}
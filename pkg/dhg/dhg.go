@@ -0,0 +1,1517 @@
+// Package dhg is the programmatic API of Deckhouse Helm Generator.
+//
+// It wraps the extract → process → analyze → generate pipeline used by the
+// dhg CLI so that other programs (operators, CI tools) can turn Kubernetes
+// objects into Helm charts without re-implementing it:
+//
+//	g := dhg.New(dhg.Options{ChartName: "myapp"})
+//	res, err := g.GenerateFromObjects(ctx, objects)
+//	if err != nil { ... }
+//	for _, chart := range res.Charts { ... }
+//
+// Each pipeline step is also exposed (Process, Analyze, Generate) for callers
+// that need to inspect or adjust intermediate results. Chart post-processors
+// (feature flags, cloud annotations, spot, etc.) live in pkg/generator and can
+// be applied to the returned charts.
+package dhg
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/detector"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/plugin"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/k8s"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/value"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// Default option values, matching the CLI defaults.
+const (
+	DefaultChartVersion  = "0.1.0"
+	DefaultAppVersion    = "1.0.0"
+	DefaultTemplateStyle = "standard"
+)
+
+// Options configures a Generator.
+type Options struct {
+	// ChartName is the name of the chart (required).
+	ChartName string
+
+	// ChartVersion is the chart version (default DefaultChartVersion).
+	ChartVersion string
+
+	// AppVersion is the application version (default DefaultAppVersion).
+	AppVersion string
+
+	// ChartMetadata holds extra Chart.yaml fields (maintainers, home,
+	// sources, keywords, icon, kubeVersion, annotations).
+	ChartMetadata helm.ChartMetadata
+
+	// Mode is the output mode (default types.OutputModeUniversal).
+	Mode types.OutputMode
+
+	// Namespace is the default namespace for resources.
+	Namespace string
+
+	// OutputDir is recorded as the chart path; charts are only written by WriteCharts.
+	OutputDir string
+
+	// IncludeTests generates helm-unittest templates.
+	IncludeTests bool
+
+	// IncludeREADME generates README.md.
+	IncludeREADME bool
+
+	// IncludeSchema generates values.schema.json.
+	IncludeSchema bool
+
+	// IncludeHooks generates Helm lifecycle hook Job templates.
+	IncludeHooks bool
+
+	// InferHooks turns run-once migration Jobs (migrate/init/seed) into
+	// pre-install/pre-upgrade Helm hooks.
+	InferHooks bool
+
+	// ExternalizeConfigMaps writes every ConfigMap data key to
+	// files/<service>/<key> in the chart; templates read it with .Files.Get.
+	ExternalizeConfigMaps bool
+
+	// PreserveSelectors renders the original spec.selector of Deployments,
+	// StatefulSets and DaemonSets, whose selector is immutable, instead of
+	// one built from the chart's helpers. Resources read from a cluster
+	// always keep their selectors.
+	PreserveSelectors bool
+
+	// PassthroughKinds lists kinds, such as CustomResourceDefinition,
+	// copied into the chart verbatim instead of templated.
+	PassthroughKinds []string
+
+	// TemplateKinds, when set, lists the only kinds that are templated;
+	// resources of other kinds are copied verbatim.
+	TemplateKinds []string
+
+	// APIUpgrade converts resources that use deprecated or removed apiVersions
+	// (extensions/v1beta1 Ingress, policy/v1beta1 PDB, ...) to their
+	// replacement before processing.
+	APIUpgrade bool
+
+	// HA adds a PodDisruptionBudget (maxUnavailable: 1, gated by
+	// services.<svc>.pdb.enabled) for every Deployment and StatefulSet with
+	// more than one replica that no input PodDisruptionBudget covers.
+	HA bool
+
+	// TopologySpread adds topologySpreadConstraints across zones and nodes
+	// (maxSkew 1, ScheduleAnyway; both editable in values) to every
+	// Deployment and StatefulSet with more than one replica that has none.
+	TopologySpread bool
+
+	// Autoscaling adds a HorizontalPodAutoscaler (gated by
+	// services.<svc>.autoscaling.enabled) targeting CPU utilization of the
+	// requests for every stateless Deployment that no input
+	// HorizontalPodAutoscaler scales. Deployments with containers without
+	// CPU requests are skipped.
+	Autoscaling bool
+
+	// ServiceAccounts gives every workload that runs as the default
+	// ServiceAccount its own ServiceAccount (named after the workload), and
+	// creates the ServiceAccounts workloads reference but the input lacks.
+	// Each created ServiceAccount gets a Role scaffold without rules and a
+	// RoleBinding.
+	ServiceAccounts bool
+
+	// PriorityClasses assigns every workload without a priorityClassName to
+	// a processor.PriorityTiers tier by its kind and adds the PriorityClasses
+	// (named <ChartName>-<tier>) that workloads reference but the input
+	// lacks.
+	PriorityClasses bool
+
+	// NoTokenAutomount sets automountServiceAccountToken: false on the
+	// pods of every workload that does not set it and whose ServiceAccount
+	// neither sets it nor is a subject of a RoleBinding or ClusterRoleBinding
+	// in the input. The setting becomes a values toggle, e.g.
+	// services.<svc>.deployment.automountServiceAccountToken.
+	NoTokenAutomount bool
+
+	// SkipPersistentVolumes leaves the PersistentVolumes of the input out
+	// of the chart. They are bound to the storage of the source cluster
+	// (volume handles, NFS servers, host paths), which other clusters lack.
+	SkipPersistentVolumes bool
+
+	// OnDuplicate selects how resources found more than once in the input
+	// (same group, kind, namespace and name), e.g. in several files or in a
+	// base and its overlay, are resolved; DuplicateLast when empty. Helm
+	// refuses to install a chart rendering a resource twice.
+	OnDuplicate DuplicatePolicy
+
+	// Renames replaces the names in the input resources equal to a key with
+	// its value before processing: resource names and the references,
+	// labels and selectors that carry them, and so the service, template
+	// and values names derived from them (e.g. api-prod=api). Other strings,
+	// such as ConfigMap data, env values and args, are left alone.
+	Renames map[string]string
+
+	// ImageRewrites replace registry or repository prefixes of container
+	// images before processing (e.g. docker.io=registry.example.com/mirror).
+	ImageRewrites []processor.ImageRewrite
+
+	// PinDigests resolves the container images that have no digest (after
+	// ImageRewrites) and adds the digest to the image values, so templates
+	// deploy by digest.
+	PinDigests bool
+
+	// DigestResolver resolves image digests for PinDigests. When nil,
+	// processor.RegistryDigest queries the registries.
+	DigestResolver processor.DigestResolver
+
+	// EnvValues enables environment-specific values generation.
+	EnvValues bool
+
+	// DeckhouseModule enables Deckhouse module scaffold generation. The
+	// ModuleConfig of the module (named ChartName, or the only ModuleConfig of
+	// the input) is not templated: its settings become Processed.ModuleSettings.
+	DeckhouseModule bool
+
+	// TemplateStyle selects the template style: "standard" or "helm".
+	TemplateStyle string
+
+	// ValuesFlat adds dot-notation path comments to values.yaml.
+	ValuesFlat bool
+
+	// ValuesDocs adds helm-docs comments to values.yaml and, with
+	// IncludeREADME, a README.md values table.
+	ValuesDocs bool
+
+	// PreserveNamespaces keeps resources in their source namespaces instead
+	// of the release namespace and generates the Namespace objects
+	// (universal mode).
+	PreserveNamespaces bool
+
+	// DedupValues factors resources, probes and securityContext blocks
+	// repeated across workloads into a common: values section (universal
+	// mode).
+	DedupValues bool
+
+	// TemplateEnv rewrites Service DNS names in env vars and ConfigMap data
+	// to services.<name>.env values that default to the Service's name in
+	// the release, rendered through tpl (universal mode).
+	TemplateEnv bool
+
+	// ValueOverrides is deep-merged over the generated values.yaml, e.g.
+	// services.web.deployment.replicas: 1 to scale down the chart defaults
+	// (universal mode).
+	ValueOverrides map[string]interface{}
+
+	// Injection adds organization-wide labels and annotations, such as team
+	// or cost center, to the generated resources (universal mode).
+	Injection *generator.InjectionPolicy
+
+	// ServiceNames renames detected services, keyed by the detected name
+	// (see processor.ServiceNameFromResource).
+	ServiceNames map[string]string
+
+	// Grouping assigns resources to services before the default heuristics
+	// (--group-by and groups.yaml). Its assignments take precedence over
+	// ServiceNames.
+	Grouping analyzer.Grouping
+
+	// Processors are additional in-process processors registered after the
+	// built-in ones. Higher priority processors win for the same GVK.
+	Processors []processor.Processor
+
+	// Detectors are additional in-process relationship detectors.
+	Detectors []analyzer.Detector
+
+	// Plugins are external plugins whose processors and detectors are registered.
+	Plugins []*plugin.Plugin
+
+	// OnProcessed, when set, is called after each resource is processed
+	// (e.g. for progress reporting).
+	OnProcessed func(*types.ProcessedResource)
+}
+
+// Generator runs the chart generation pipeline. It holds no per-run state and
+// may be reused for multiple runs.
+type Generator struct {
+	opts       Options
+	processors *processor.Registry
+	analyzer   *analyzer.DefaultAnalyzer
+	generators *generator.Registry
+}
+
+// New creates a Generator with the built-in processors and detectors plus any
+// extensions given in opts. Options are validated when the pipeline runs.
+func New(opts Options) *Generator {
+	if opts.ChartVersion == "" {
+		opts.ChartVersion = DefaultChartVersion
+	}
+	if opts.AppVersion == "" {
+		opts.AppVersion = DefaultAppVersion
+	}
+	if opts.Mode == "" {
+		opts.Mode = types.OutputModeUniversal
+	}
+	if opts.TemplateStyle == "" {
+		opts.TemplateStyle = DefaultTemplateStyle
+	}
+
+	processors := processor.NewRegistry()
+	k8s.RegisterAll(processors)
+	for _, p := range opts.Processors {
+		processors.Register(p)
+	}
+
+	a := analyzer.NewDefaultAnalyzer()
+	detector.RegisterAll(a)
+	for _, d := range opts.Detectors {
+		a.AddDetector(d)
+	}
+
+	for _, p := range opts.Plugins {
+		p.RegisterProcessors(processors)
+		p.RegisterDetectors(a)
+	}
+
+	return &Generator{
+		opts:       opts,
+		processors: processors,
+		analyzer:   a,
+		generators: generator.DefaultRegistry(),
+	}
+}
+
+// Options returns the effective options, with defaults applied.
+func (g *Generator) Options() Options {
+	return g.opts
+}
+
+// WithMode returns a copy of g that generates charts in the given mode.
+func (g *Generator) WithMode(mode types.OutputMode) *Generator {
+	out := *g
+	out.opts.Mode = mode
+	return &out
+}
+
+// Result is the outcome of a full pipeline run.
+type Result struct {
+	// Charts are the generated charts.
+	Charts []*types.GeneratedChart
+
+	// Graph is the analyzed resource graph.
+	Graph *types.ResourceGraph
+
+	// Resources are the processed resources.
+	Resources []*types.ProcessedResource
+}
+
+// Processed is the outcome of the Process step.
+type Processed struct {
+	// Resources are the processed resources, in input order.
+	Resources []*types.ProcessedResource
+
+	// ExternalFiles collects files (e.g. large ConfigMap data) referenced by
+	// templates; it is consumed by Generate.
+	ExternalFiles *value.ExternalFileManager
+
+	// APIUpgrades lists the resources converted by Options.APIUpgrade.
+	APIUpgrades []APIUpgrade
+
+	// Renamed lists the resources changed by Options.Renames.
+	Renamed []Rename
+
+	// UnusedRenames lists the Options.Renames keys that matched nothing.
+	UnusedRenames []string
+
+	// Duplicates lists the resources found more than once in the input,
+	// resolved by Options.OnDuplicate.
+	Duplicates []Duplicate
+
+	// PodDisruptionBudgets lists the PodDisruptionBudgets added by Options.HA.
+	PodDisruptionBudgets []types.ResourceKey
+
+	// TopologySpread lists the workloads Options.TopologySpread added
+	// topologySpreadConstraints to.
+	TopologySpread []types.ResourceKey
+
+	// HorizontalPodAutoscalers lists the HorizontalPodAutoscalers added by
+	// Options.Autoscaling.
+	HorizontalPodAutoscalers []types.ResourceKey
+
+	// AutoscalingSkipped lists the stateless Deployments Options.Autoscaling
+	// added no HorizontalPodAutoscaler for because they do not request CPU.
+	AutoscalingSkipped []types.ResourceKey
+
+	// ServiceAccountChanges lists the workloads Options.ServiceAccounts moved
+	// off the default ServiceAccount.
+	ServiceAccountChanges []ServiceAccountChange
+
+	// RBACResources lists the ServiceAccounts, Roles and RoleBindings added
+	// by Options.ServiceAccounts.
+	RBACResources []types.ResourceKey
+
+	// PriorityClassChanges lists the workloads Options.PriorityClasses
+	// assigned a PriorityClass to.
+	PriorityClassChanges []PriorityClassChange
+
+	// PriorityClasses lists the PriorityClasses added by
+	// Options.PriorityClasses.
+	PriorityClasses []types.ResourceKey
+
+	// TokenAutomountDisabled lists the workloads
+	// Options.NoTokenAutomount disabled token automounting for.
+	TokenAutomountDisabled []types.ResourceKey
+
+	// ImageUpdates lists the container images changed by
+	// Options.ImageRewrites and Options.PinDigests.
+	ImageUpdates []ImageUpdate
+
+	// ModuleConfig is the ModuleConfig of the module taken out of the
+	// resources by Options.DeckhouseModule, or nil.
+	ModuleConfig *types.ResourceKey
+
+	// ModuleSettings are the spec.settings of ModuleConfig, for
+	// generator.GenerateDeckhouseModule.
+	ModuleSettings map[string]interface{}
+
+	// SelectorConflicts lists the labels of preserved workload selectors
+	// that the chart's labels helper also sets, with another value.
+	SelectorConflicts []SelectorConflict
+
+	// PersistentVolumes lists the PersistentVolumes of the input, which are
+	// bound to the storage of the source cluster. With
+	// Options.SkipPersistentVolumes they are left out of the chart.
+	PersistentVolumes []types.ResourceKey
+}
+
+// SelectorConflict records a label of a preserved workload selector that the
+// chart's labels helper would change. The pods keep the original value, so
+// selectors built from the selectorLabels helper, such as those of the
+// chart's Services, may not match them.
+type SelectorConflict struct {
+	// Resource is the workload.
+	Resource types.ResourceKey
+
+	// Label is the selector label.
+	Label string
+
+	// Value is the original value of the label.
+	Value string
+}
+
+// APIUpgrade records a resource converted from a deprecated apiVersion.
+type APIUpgrade struct {
+	// Resource is the resource after the upgrade.
+	Resource types.ResourceKey
+
+	// From is the original apiVersion.
+	From string
+}
+
+// DuplicatePolicy selects how resources found more than once in the input
+// are resolved.
+type DuplicatePolicy string
+
+const (
+	// DuplicateError fails processing.
+	DuplicateError DuplicatePolicy = "error"
+
+	// DuplicateFirst keeps the first occurrence.
+	DuplicateFirst DuplicatePolicy = "first"
+
+	// DuplicateLast keeps the last occurrence, as kubectl apply of the
+	// input would.
+	DuplicateLast DuplicatePolicy = "last"
+
+	// DuplicateMerge merges the occurrences in input order: maps are merged
+	// recursively, and other values, lists included, of later occurrences
+	// replace earlier ones.
+	DuplicateMerge DuplicatePolicy = "merge"
+)
+
+// Duplicate records a resource found more than once in the input.
+type Duplicate struct {
+	// Resource is the resource kept.
+	Resource types.ResourceKey
+
+	// Sources are the source paths of the occurrences, in input order.
+	Sources []string
+}
+
+// Rename records a resource changed by Options.Renames.
+type Rename struct {
+	// Resource is the resource after the renames.
+	Resource types.ResourceKey
+
+	// From is the original name of the resource.
+	From string
+}
+
+// ImageUpdate records a container image changed before processing.
+type ImageUpdate struct {
+	// Resource is the workload the container belongs to.
+	Resource types.ResourceKey
+
+	processor.ImageChange
+}
+
+// ServiceAccountChange records a workload moved off the default
+// ServiceAccount before processing.
+type ServiceAccountChange struct {
+	// Resource is the workload.
+	Resource types.ResourceKey
+
+	// ServiceAccount is the ServiceAccount its pods run as now.
+	ServiceAccount string
+}
+
+// PriorityClassChange records a workload assigned a PriorityClass before
+// processing.
+type PriorityClassChange struct {
+	// Resource is the workload.
+	Resource types.ResourceKey
+
+	// PriorityClass is the priorityClassName of its pods.
+	PriorityClass string
+}
+
+// Validate checks the options for errors.
+func (g *Generator) Validate() error {
+	if g.opts.ChartName == "" {
+		return fmt.Errorf("chart name is required")
+	}
+	switch g.opts.Mode {
+	case types.OutputModeUniversal, types.OutputModeSeparate, types.OutputModeLibrary, types.OutputModeUmbrella:
+	default:
+		return fmt.Errorf("invalid mode: %s (must be universal, separate, library, or umbrella)", g.opts.Mode)
+	}
+	switch g.opts.TemplateStyle {
+	case "standard", "helm":
+	default:
+		return fmt.Errorf("unknown template style: %q (must be standard or helm)", g.opts.TemplateStyle)
+	}
+	switch g.opts.OnDuplicate {
+	case "", DuplicateError, DuplicateFirst, DuplicateLast, DuplicateMerge:
+	default:
+		return fmt.Errorf("unknown duplicate policy: %q (must be error, first, last or merge)", g.opts.OnDuplicate)
+	}
+	if g.opts.PreserveNamespaces && g.opts.Mode != types.OutputModeUniversal {
+		return fmt.Errorf("preserving namespaces is only supported in universal mode")
+	}
+	if g.opts.TemplateEnv && g.opts.Mode != types.OutputModeUniversal {
+		return fmt.Errorf("templating env service URLs is only supported in universal mode")
+	}
+	if len(g.opts.ValueOverrides) > 0 && g.opts.Mode != types.OutputModeUniversal {
+		return fmt.Errorf("value overrides are only supported in universal mode")
+	}
+	if g.opts.Injection != nil {
+		if g.opts.Mode != types.OutputModeUniversal {
+			return fmt.Errorf("label and annotation injection is only supported in universal mode")
+		}
+		if err := g.opts.Injection.Validate(); err != nil {
+			return fmt.Errorf("injection policy: %w", err)
+		}
+	}
+	for _, kind := range g.opts.PassthroughKinds {
+		for _, templated := range g.opts.TemplateKinds {
+			if strings.EqualFold(kind, templated) {
+				return fmt.Errorf("kind %s is both passed through and templated", kind)
+			}
+		}
+	}
+	return nil
+}
+
+// GenerateFromObjects runs the full pipeline on in-memory Kubernetes objects.
+func (g *Generator) GenerateFromObjects(ctx context.Context, objects []unstructured.Unstructured) (*Result, error) {
+	resources := make([]*types.ExtractedResource, 0, len(objects))
+	for i := range objects {
+		obj := objects[i].DeepCopy()
+		resources = append(resources, &types.ExtractedResource{
+			Object: obj,
+			Source: types.SourceFile,
+			GVK:    obj.GroupVersionKind(),
+		})
+	}
+	return g.GenerateFromResources(ctx, resources)
+}
+
+// GenerateFromResources runs the process, analyze and generate steps on
+// already extracted resources.
+func (g *Generator) GenerateFromResources(ctx context.Context, resources []*types.ExtractedResource) (*Result, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("no resources to generate from")
+	}
+
+	processed, err := g.Process(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := g.Analyze(ctx, processed)
+	if err != nil {
+		return nil, err
+	}
+
+	charts, err := g.Generate(ctx, graph, processed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Charts: charts, Graph: graph, Resources: processed.Resources}, nil
+}
+
+// Process runs every resource through the processor registry.
+func (g *Generator) Process(ctx context.Context, resources []*types.ExtractedResource) (*Processed, error) {
+	out := &Processed{
+		Resources:     make([]*types.ProcessedResource, 0, len(resources)),
+		ExternalFiles: value.NewExternalFileManager(),
+	}
+	if len(g.opts.Renames) > 0 {
+		resources, out.Renamed, out.UnusedRenames = renameResources(resources, g.opts.Renames)
+	}
+	if g.opts.APIUpgrade {
+		resources, out.APIUpgrades = upgradeAPIVersions(resources)
+	}
+	var err error
+	if resources, out.Duplicates, err = resolveDuplicates(resources, g.opts.OnDuplicate, g.opts.Namespace); err != nil {
+		return nil, err
+	}
+	if g.opts.DeckhouseModule {
+		resources, out.ModuleConfig, out.ModuleSettings = takeModuleConfig(resources, g.opts.ChartName)
+	}
+	resources, out.PersistentVolumes = persistentVolumes(resources, g.opts.SkipPersistentVolumes)
+	if g.opts.HA {
+		resources, out.PodDisruptionBudgets = addPodDisruptionBudgets(resources)
+	}
+	if g.opts.TopologySpread {
+		var err error
+		resources, out.TopologySpread, err = spreadTopology(resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.Autoscaling {
+		resources, out.HorizontalPodAutoscalers, out.AutoscalingSkipped = addHorizontalPodAutoscalers(resources)
+	}
+	// Token automounting is decided on the input bindings, before
+	// Options.ServiceAccounts adds RoleBinding scaffolds.
+	if g.opts.NoTokenAutomount {
+		var err error
+		resources, out.TokenAutomountDisabled, err = disableTokenAutomount(resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.ServiceAccounts {
+		var err error
+		resources, out.ServiceAccountChanges, out.RBACResources, err = addServiceAccounts(resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.opts.PriorityClasses {
+		var err error
+		resources, out.PriorityClassChanges, out.PriorityClasses, err = addPriorityClasses(resources, g.opts.ChartName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(g.opts.ImageRewrites) > 0 || g.opts.PinDigests {
+		var err error
+		resources, out.ImageUpdates, err = g.updateImages(ctx, resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	all := make(map[types.ResourceKey]*types.ExtractedResource, len(resources))
+	for _, r := range resources {
+		all[r.ResourceKey()] = r
+	}
+	valueProcessor := value.DefaultProcessor()
+	processorOptions := g.processorOptions()
+	// Live objects keep their selectors: spec.selector is immutable, and a
+	// chart that changes it cannot be upgraded over them.
+	liveOptions := make(map[string]interface{}, len(processorOptions))
+	for k, v := range processorOptions {
+		liveOptions[k] = v
+	}
+	liveOptions[processor.OptionPreserveSelectors] = true
+
+	for _, extracted := range resources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		procCtx := processor.Context{
+			Ctx:                 ctx,
+			ChartName:           g.opts.ChartName,
+			OutputMode:          g.opts.Mode,
+			ServiceName:         g.serviceName(extracted.Object, all),
+			Namespace:           extracted.Object.GetNamespace(),
+			AllResources:        all,
+			ExternalFileManager: out.ExternalFiles,
+			ValueProcessor:      valueProcessor,
+			Options:             processorOptions,
+		}
+		if extracted.Source == types.SourceCluster {
+			procCtx.Options = liveOptions
+		}
+
+		result, err := g.processors.Process(procCtx, extracted.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process %s: %w", extracted.ResourceKey().String(), err)
+		}
+
+		processed := &types.ProcessedResource{
+			Original:        extracted,
+			ServiceName:     result.ServiceName,
+			TemplatePath:    result.TemplatePath,
+			TemplateContent: result.TemplateContent,
+			ValuesPath:      result.ValuesPath,
+			Values:          result.Values,
+			Dependencies:    result.Dependencies,
+		}
+		out.Resources = append(out.Resources, processed)
+		if preserve, _ := procCtx.Options[processor.OptionPreserveSelectors].(bool); preserve {
+			out.SelectorConflicts = append(out.SelectorConflicts, selectorConflicts(extracted, g.opts.ChartName, result.ServiceName)...)
+		}
+
+		if g.opts.OnProcessed != nil {
+			g.opts.OnProcessed(processed)
+		}
+	}
+
+	return out, nil
+}
+
+// processorOptions returns the processor.Context options of g.
+func (g *Generator) processorOptions() map[string]interface{} {
+	return map[string]interface{}{
+		processor.OptionInferHooks:            g.opts.InferHooks,
+		processor.OptionExternalizeConfigMaps: g.opts.ExternalizeConfigMaps,
+		processor.OptionPreserveSelectors:     g.opts.PreserveSelectors,
+		processor.OptionPassthroughKinds:      g.opts.PassthroughKinds,
+		processor.OptionTemplateKinds:         g.opts.TemplateKinds,
+	}
+}
+
+// selectorConflicts returns the labels of the selector of a workload that
+// the chart's labels helper sets to another value. The helper values of
+// app.kubernetes.io/instance, app.kubernetes.io/version and helm.sh/chart
+// depend on the release and always conflict.
+func selectorConflicts(r *types.ExtractedResource, chartName, serviceName string) []SelectorConflict {
+	if r.Object.GroupVersionKind().Group != "apps" {
+		return nil
+	}
+	switch r.Object.GetKind() {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return nil
+	}
+	matchLabels, _, _ := unstructured.NestedStringMap(r.Object.Object, "spec", "selector", "matchLabels")
+	helperLabels := map[string]string{
+		"app.kubernetes.io/name":       chartName,
+		"app.kubernetes.io/instance":   "",
+		"app.kubernetes.io/version":    "",
+		"app.kubernetes.io/managed-by": "Helm",
+		"app.kubernetes.io/component":  serviceName,
+		"helm.sh/chart":                "",
+	}
+	var conflicts []SelectorConflict
+	for label, value := range matchLabels {
+		if helperValue, ok := helperLabels[label]; ok && value != helperValue {
+			conflicts = append(conflicts, SelectorConflict{Resource: r.ResourceKey(), Label: label, Value: value})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Label < conflicts[j].Label })
+	return conflicts
+}
+
+// ParseRename parses an "old=new" rename. The new name must be a valid
+// Kubernetes resource name.
+func ParseRename(s string) (from, to string, err error) {
+	from, to, ok := strings.Cut(s, "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" {
+		return "", "", fmt.Errorf("invalid rename %q (must be old=new)", s)
+	}
+	if errs := validation.IsDNS1123Subdomain(to); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid rename %q: %s", s, strings.Join(errs, "; "))
+	}
+	return from, to, nil
+}
+
+// renameResources returns resources with the names equal to a key of renames
+// replaced by its value: metadata names, label and selector values and the
+// reference fields listed in renameKeys and renameNameParents. Other strings,
+// such as ConfigMap data, env values and args, are left alone. It also
+// returns the renamed resources and the keys that matched nothing. Renamed
+// resources are copies; the input is not modified.
+func renameResources(resources []*types.ExtractedResource, renames map[string]string) ([]*types.ExtractedResource, []Rename, []string) {
+	used := make(map[string]bool, len(renames))
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var renamed []Rename
+	for _, r := range resources {
+		obj := r.Object.DeepCopy()
+		if !renameFields(obj.Object, "", "", renames, used) {
+			out = append(out, r)
+			continue
+		}
+		updated := *r
+		updated.Object = obj
+		out = append(out, &updated)
+		renamed = append(renamed, Rename{Resource: updated.ResourceKey(), From: r.Object.GetName()})
+	}
+
+	var unused []string
+	for from := range renames {
+		if !used[from] {
+			unused = append(unused, from)
+		}
+	}
+	sort.Strings(unused)
+	return out, renamed, unused
+}
+
+// renameKeys are the fields whose string value is the name of another
+// resource wherever they appear.
+var renameKeys = map[string]bool{
+	"serviceName":        true,
+	"serviceAccountName": true,
+	"secretName":         true,
+	"claimName":          true,
+	"volumeName":         true,
+	"priorityClassName":  true,
+	"storageClassName":   true,
+	"ingressClassName":   true,
+	"runtimeClassName":   true,
+}
+
+// renameNameParents are the fields whose "name" field, or the "name" field
+// of each of their items, is the name of a resource.
+var renameNameParents = map[string]bool{
+	"metadata":         true,
+	"configMapRef":     true,
+	"secretRef":        true,
+	"configMapKeyRef":  true,
+	"secretKeyRef":     true,
+	"configMap":        true,
+	"secret":           true,
+	"service":          true,
+	"scaleTargetRef":   true,
+	"targetRef":        true,
+	"roleRef":          true,
+	"subjects":         true,
+	"imagePullSecrets": true,
+	"secrets":          true,
+	"parentRefs":       true,
+	"backendRefs":      true,
+}
+
+// renameLabelMaps are the fields holding label values.
+var renameLabelMaps = map[string]bool{
+	"labels":      true,
+	"matchLabels": true,
+	"selector":    true,
+}
+
+// renameFields replaces the names in v in place and reports whether any was
+// replaced. key is the field holding v and parent the field holding key;
+// list items take the field of their list.
+func renameFields(v interface{}, key, parent string, renames map[string]string, used map[string]bool) bool {
+	changed := false
+	rename := func(k string, s string) (string, bool) {
+		if !renameField(k, key) {
+			return "", false
+		}
+		to, ok := renames[s]
+		if ok {
+			used[s] = true
+		}
+		return to, ok
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if s, ok := child.(string); ok {
+				if to, ok := rename(k, s); ok {
+					v[k] = to
+					changed = true
+				}
+				continue
+			}
+			changed = renameFields(child, k, key, renames, used) || changed
+		}
+	case []interface{}:
+		for i, child := range v {
+			if s, ok := child.(string); ok {
+				// Only the values of a label selector expression are names.
+				if key == "values" && parent == "matchExpressions" {
+					if to, ok := renames[s]; ok {
+						used[s] = true
+						v[i] = to
+						changed = true
+					}
+				}
+				continue
+			}
+			changed = renameFields(child, key, parent, renames, used) || changed
+		}
+	}
+	return changed
+}
+
+// renameField reports whether the string field key of the object held by
+// parent is a name.
+func renameField(key, parent string) bool {
+	switch {
+	case renameKeys[key]:
+		return true
+	case key == "name":
+		return renameNameParents[parent]
+	default:
+		return renameLabelMaps[parent]
+	}
+}
+
+// upgradeAPIVersions returns resources with deprecated apiVersions converted
+// to their replacement. Upgraded resources are copies; the input is not
+// modified.
+func upgradeAPIVersions(resources []*types.ExtractedResource) ([]*types.ExtractedResource, []APIUpgrade) {
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var upgrades []APIUpgrade
+	for _, r := range resources {
+		if processor.LookupAPIMigration(r.Object.GetAPIVersion(), r.Object.GetKind()) == nil {
+			out = append(out, r)
+			continue
+		}
+		obj := r.Object.DeepCopy()
+		m := processor.UpgradeAPIVersion(obj)
+		if m == nil {
+			out = append(out, r)
+			continue
+		}
+		upgraded := *r
+		upgraded.Object = obj
+		upgraded.GVK = obj.GroupVersionKind()
+		out = append(out, &upgraded)
+		upgrades = append(upgrades, APIUpgrade{Resource: upgraded.ResourceKey(), From: m.OldAPIVersion})
+	}
+	return out, upgrades
+}
+
+// resolveDuplicates returns resources with the occurrences of each resource
+// found more than once, by group, kind, namespace and name, resolved by
+// policy into one, at the position of the first, and the duplicates found.
+// Resources of namespaced kinds without a namespace are in namespace, or in
+// default if it is empty. Merged resources are copies; the input is not
+// modified.
+func resolveDuplicates(resources []*types.ExtractedResource, policy DuplicatePolicy, namespace string) ([]*types.ExtractedResource, []Duplicate, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	type duplicateKey struct {
+		group, kind, namespace, name string
+	}
+	keyOf := func(r *types.ExtractedResource) duplicateKey {
+		ns := r.Object.GetNamespace()
+		if generator.IsClusterScoped(r.GVK.Kind) {
+			ns = ""
+		} else if ns == "" {
+			ns = namespace
+		}
+		return duplicateKey{r.GVK.Group, r.GVK.Kind, ns, r.Object.GetName()}
+	}
+
+	occurrences := make(map[duplicateKey][]*types.ExtractedResource, len(resources))
+	for _, r := range resources {
+		key := keyOf(r)
+		occurrences[key] = append(occurrences[key], r)
+	}
+	if len(occurrences) == len(resources) {
+		return resources, nil, nil
+	}
+
+	out := make([]*types.ExtractedResource, 0, len(occurrences))
+	var duplicates []Duplicate
+	for _, r := range resources {
+		key := keyOf(r)
+		all, ok := occurrences[key]
+		if !ok {
+			continue
+		}
+		delete(occurrences, key)
+		if len(all) == 1 {
+			out = append(out, r)
+			continue
+		}
+
+		kept := all[len(all)-1]
+		switch policy {
+		case DuplicateFirst:
+			kept = all[0]
+		case DuplicateMerge:
+			merged := *kept
+			object := all[0].Object.DeepCopy().Object
+			for _, next := range all[1:] {
+				object = helm.MergeValues(object, next.Object.DeepCopy().Object)
+			}
+			merged.Object = &unstructured.Unstructured{Object: object}
+			merged.GVK = merged.Object.GroupVersionKind()
+			kept = &merged
+		}
+		out = append(out, kept)
+
+		sources := make([]string, 0, len(all))
+		for _, o := range all {
+			sources = append(sources, o.SourcePath)
+		}
+		duplicates = append(duplicates, Duplicate{Resource: kept.ResourceKey(), Sources: sources})
+	}
+
+	if policy == DuplicateError {
+		descriptions := make([]string, 0, len(duplicates))
+		for _, d := range duplicates {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s)", d.Resource.String(), strings.Join(d.Sources, ", ")))
+		}
+		return nil, duplicates, fmt.Errorf("duplicate resources in the input: %s", strings.Join(descriptions, "; "))
+	}
+	return out, duplicates, nil
+}
+
+// takeModuleConfig returns resources without the ModuleConfig of the module
+// moduleName, with its key and spec.settings. The ModuleConfig of the module
+// is the one named moduleName or, failing that, the only ModuleConfig.
+func takeModuleConfig(resources []*types.ExtractedResource, moduleName string) ([]*types.ExtractedResource, *types.ResourceKey, map[string]interface{}) {
+	index := -1
+	count := 0
+	for i, r := range resources {
+		if gvk := r.Object.GroupVersionKind(); gvk.Group != "deckhouse.io" || gvk.Kind != "ModuleConfig" {
+			continue
+		}
+		count++
+		if r.Object.GetName() == moduleName {
+			index = i
+			break
+		}
+		if count == 1 {
+			index = i
+		}
+	}
+	if index < 0 || (count > 1 && resources[index].Object.GetName() != moduleName) {
+		return resources, nil, nil
+	}
+
+	mc := resources[index]
+	key := mc.ResourceKey()
+	settings, _, _ := unstructured.NestedMap(mc.Object.Object, "spec", "settings")
+	out := make([]*types.ExtractedResource, 0, len(resources)-1)
+	out = append(out, resources[:index]...)
+	out = append(out, resources[index+1:]...)
+	return out, &key, settings
+}
+
+// persistentVolumes returns the keys of the PersistentVolumes of resources,
+// and resources without them when skip is set.
+func persistentVolumes(resources []*types.ExtractedResource, skip bool) ([]*types.ExtractedResource, []types.ResourceKey) {
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var pvs []types.ResourceKey
+	for _, r := range resources {
+		if gvk := r.Object.GroupVersionKind(); gvk.Group == "" && gvk.Kind == "PersistentVolume" {
+			pvs = append(pvs, r.ResourceKey())
+			if skip {
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out, pvs
+}
+
+// addPodDisruptionBudgets returns resources with a PodDisruptionBudget added
+// after every replicated Deployment and StatefulSet that no input
+// PodDisruptionBudget covers.
+func addPodDisruptionBudgets(resources []*types.ExtractedResource) ([]*types.ExtractedResource, []types.ResourceKey) {
+	var pdbs []*unstructured.Unstructured
+	for _, r := range resources {
+		if r.Object.GetKind() == "PodDisruptionBudget" {
+			pdbs = append(pdbs, r.Object)
+		}
+	}
+
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var added []types.ResourceKey
+	for _, r := range resources {
+		out = append(out, r)
+		if !processor.NeedsPodDisruptionBudget(r.Object) || processor.CoveredByPodDisruptionBudget(r.Object, pdbs) {
+			continue
+		}
+		pdb := processor.NewPodDisruptionBudget(r.Object)
+		generated := &types.ExtractedResource{
+			Object:     pdb,
+			Source:     r.Source,
+			SourcePath: r.SourcePath,
+			GVK:        pdb.GroupVersionKind(),
+		}
+		out = append(out, generated)
+		added = append(added, generated.ResourceKey())
+	}
+	return out, added
+}
+
+// spreadTopology returns resources with topologySpreadConstraints added to
+// every replicated Deployment and StatefulSet without any. Changed resources
+// are copies; the input is not modified.
+func spreadTopology(resources []*types.ExtractedResource) ([]*types.ExtractedResource, []types.ResourceKey, error) {
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var spread []types.ResourceKey
+	for _, r := range resources {
+		if !processor.NeedsTopologySpread(r.Object) {
+			out = append(out, r)
+			continue
+		}
+		obj := r.Object.DeepCopy()
+		if err := processor.AddTopologySpreadConstraints(obj); err != nil {
+			return nil, nil, fmt.Errorf("cannot add topologySpreadConstraints to %s: %w", r.ResourceKey().String(), err)
+		}
+		updated := *r
+		updated.Object = obj
+		out = append(out, &updated)
+		spread = append(spread, updated.ResourceKey())
+	}
+	return out, spread, nil
+}
+
+// addHorizontalPodAutoscalers returns resources with a
+// HorizontalPodAutoscaler added after every stateless Deployment that no input
+// HorizontalPodAutoscaler scales, and the Deployments skipped because not all
+// of their containers request CPU.
+func addHorizontalPodAutoscalers(resources []*types.ExtractedResource) ([]*types.ExtractedResource, []types.ResourceKey, []types.ResourceKey) {
+	var hpas []*unstructured.Unstructured
+	for _, r := range resources {
+		if r.Object.GetKind() == "HorizontalPodAutoscaler" {
+			hpas = append(hpas, r.Object)
+		}
+	}
+
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var added, skipped []types.ResourceKey
+	for _, r := range resources {
+		out = append(out, r)
+		if !processor.IsStatelessDeployment(r.Object) || processor.ScaledByHorizontalPodAutoscaler(r.Object, hpas) {
+			continue
+		}
+		if !processor.HasCPURequests(r.Object) {
+			skipped = append(skipped, r.ResourceKey())
+			continue
+		}
+		hpa := processor.NewHorizontalPodAutoscaler(r.Object)
+		generated := &types.ExtractedResource{
+			Object:     hpa,
+			Source:     r.Source,
+			SourcePath: r.SourcePath,
+			GVK:        hpa.GroupVersionKind(),
+		}
+		out = append(out, generated)
+		added = append(added, generated.ResourceKey())
+	}
+	return out, added, skipped
+}
+
+// disableTokenAutomount returns resources with automountServiceAccountToken
+// set to false on the workloads that do not set it and run as a
+// ServiceAccount that does not set it either and has no RoleBinding or
+// ClusterRoleBinding in resources. Changed workloads are copies; the input
+// is not modified.
+func disableTokenAutomount(resources []*types.ExtractedResource) ([]*types.ExtractedResource, []types.ResourceKey, error) {
+	objs := make([]*unstructured.Unstructured, 0, len(resources))
+	// accountSets are the ServiceAccounts that set automountServiceAccountToken.
+	accountSets := make(map[string]bool)
+	for _, r := range resources {
+		objs = append(objs, r.Object)
+		if r.Object.GetKind() == "ServiceAccount" {
+			if _, found, _ := unstructured.NestedBool(r.Object.Object, "automountServiceAccountToken"); found {
+				accountSets[processor.ServiceAccountKey(r.Object.GetNamespace(), r.Object.GetName())] = true
+			}
+		}
+	}
+	bound := processor.BoundServiceAccounts(objs)
+
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var changed []types.ResourceKey
+	for _, r := range resources {
+		sa := processor.PodServiceAccountName(r.Object)
+		if sa == "" {
+			out = append(out, r)
+			continue
+		}
+		account := processor.ServiceAccountKey(r.Object.GetNamespace(), sa)
+		if _, set := processor.PodAutomountServiceAccountToken(r.Object); set || accountSets[account] || bound[account] {
+			out = append(out, r)
+			continue
+		}
+		obj := r.Object.DeepCopy()
+		if err := processor.SetPodAutomountServiceAccountToken(obj, false); err != nil {
+			return nil, nil, fmt.Errorf("cannot disable token automounting of %s: %w", r.ResourceKey().String(), err)
+		}
+		updated := *r
+		updated.Object = obj
+		out = append(out, &updated)
+		changed = append(changed, updated.ResourceKey())
+	}
+	return out, changed, nil
+}
+
+// addServiceAccounts returns resources with the workloads that run as the
+// default ServiceAccount switched to a ServiceAccount named after them, and
+// a ServiceAccount, Role and RoleBinding added after the first workload that
+// uses each ServiceAccount missing from the input. A Role or RoleBinding of
+// the same name in the input is kept instead. Changed workloads are copies;
+// the input is not modified.
+func addServiceAccounts(resources []*types.ExtractedResource) ([]*types.ExtractedResource, []ServiceAccountChange, []types.ResourceKey, error) {
+	existing := make(map[types.ResourceKey]bool)
+	for _, r := range resources {
+		switch r.Object.GetKind() {
+		case "ServiceAccount", "Role", "RoleBinding":
+			existing[r.ResourceKey()] = true
+		}
+	}
+	key := func(obj *unstructured.Unstructured) types.ResourceKey {
+		return types.ResourceKey{GVK: obj.GroupVersionKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	}
+
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var changes []ServiceAccountChange
+	var added []types.ResourceKey
+	for _, r := range resources {
+		sa := processor.PodServiceAccountName(r.Object)
+		if sa == "" {
+			out = append(out, r)
+			continue
+		}
+		if sa == processor.DefaultServiceAccountName {
+			obj := r.Object.DeepCopy()
+			if err := processor.SetPodServiceAccountName(obj, obj.GetName()); err != nil {
+				return nil, nil, nil, fmt.Errorf("cannot set the ServiceAccount of %s: %w", r.ResourceKey().String(), err)
+			}
+			updated := *r
+			updated.Object = obj
+			r = &updated
+			sa = obj.GetName()
+			changes = append(changes, ServiceAccountChange{Resource: r.ResourceKey(), ServiceAccount: sa})
+		}
+		out = append(out, r)
+
+		account := processor.NewServiceAccount(sa, r.Object)
+		if existing[key(account)] {
+			continue
+		}
+		for _, obj := range []*unstructured.Unstructured{
+			account,
+			processor.NewServiceAccountRole(sa, r.Object),
+			processor.NewServiceAccountRoleBinding(sa, r.Object),
+		} {
+			k := key(obj)
+			if existing[k] {
+				continue
+			}
+			existing[k] = true
+			out = append(out, &types.ExtractedResource{
+				Object:     obj,
+				Source:     r.Source,
+				SourcePath: r.SourcePath,
+				GVK:        obj.GroupVersionKind(),
+			})
+			added = append(added, k)
+		}
+	}
+	return out, changes, added, nil
+}
+
+// addPriorityClasses returns resources with the workloads that have no
+// priorityClassName assigned to the <chartName>-<tier> PriorityClass of their
+// tier, followed by the tier PriorityClasses referenced by workloads and
+// missing from the input. Changed workloads are copies; the input is not
+// modified.
+func addPriorityClasses(resources []*types.ExtractedResource, chartName string) ([]*types.ExtractedResource, []PriorityClassChange, []types.ResourceKey, error) {
+	existing := make(map[string]bool)
+	for _, r := range resources {
+		if r.Object.GetKind() == "PriorityClass" {
+			existing[r.Object.GetName()] = true
+		}
+	}
+
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var changes []PriorityClassChange
+	// referencedBy is the first workload referencing each PriorityClass.
+	referencedBy := make(map[string]*types.ExtractedResource)
+	for _, r := range resources {
+		name := processor.PodPriorityClassName(r.Object)
+		if tier := processor.WorkloadPriorityTier(r.Object); name == "" && tier != "" {
+			name = chartName + "-" + tier
+			obj := r.Object.DeepCopy()
+			if err := processor.SetPodPriorityClassName(obj, name); err != nil {
+				return nil, nil, nil, fmt.Errorf("cannot set the PriorityClass of %s: %w", r.ResourceKey().String(), err)
+			}
+			updated := *r
+			updated.Object = obj
+			r = &updated
+			changes = append(changes, PriorityClassChange{Resource: r.ResourceKey(), PriorityClass: name})
+		}
+		if name != "" && referencedBy[name] == nil {
+			referencedBy[name] = r
+		}
+		out = append(out, r)
+	}
+
+	var added []types.ResourceKey
+	for _, tier := range processor.PriorityTiers {
+		name := chartName + "-" + tier.Name
+		workload := referencedBy[name]
+		if workload == nil || existing[name] {
+			continue
+		}
+		obj := processor.NewPriorityClass(name, tier)
+		pc := &types.ExtractedResource{
+			Object:     obj,
+			Source:     workload.Source,
+			SourcePath: workload.SourcePath,
+			GVK:        obj.GroupVersionKind(),
+		}
+		out = append(out, pc)
+		added = append(added, pc.ResourceKey())
+	}
+	return out, changes, added, nil
+}
+
+// updateImages returns resources with Options.ImageRewrites applied and,
+// with Options.PinDigests, image digests pinned. Changed resources are
+// copies; the input is not modified. Every image is resolved once.
+func (g *Generator) updateImages(ctx context.Context, resources []*types.ExtractedResource) ([]*types.ExtractedResource, []ImageUpdate, error) {
+	resolve := g.opts.DigestResolver
+	if resolve == nil {
+		resolve = processor.RegistryDigest
+	}
+	digests := make(map[string]string)
+	cached := func(ctx context.Context, image string) (string, error) {
+		if d, ok := digests[image]; ok {
+			return d, nil
+		}
+		d, err := resolve(ctx, image)
+		if err != nil {
+			return "", err
+		}
+		digests[image] = d
+		return d, nil
+	}
+
+	out := make([]*types.ExtractedResource, 0, len(resources))
+	var updates []ImageUpdate
+	for _, r := range resources {
+		if processor.PodSpecPath(r.Object.GetKind()) == nil {
+			out = append(out, r)
+			continue
+		}
+		obj := r.Object.DeepCopy()
+		changes := processor.RewriteImages(obj, g.opts.ImageRewrites)
+		if g.opts.PinDigests {
+			pinned, err := processor.PinImageDigests(ctx, obj, cached)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", r.ResourceKey().String(), err)
+			}
+			changes = mergeImageChanges(changes, pinned)
+		}
+		if len(changes) == 0 {
+			out = append(out, r)
+			continue
+		}
+		updated := *r
+		updated.Object = obj
+		out = append(out, &updated)
+		for _, c := range changes {
+			updates = append(updates, ImageUpdate{Resource: updated.ResourceKey(), ImageChange: c})
+		}
+	}
+	return out, updates, nil
+}
+
+// mergeImageChanges folds the changes of a second pass over the same
+// containers into the first, so each image is reported once with its
+// original and final reference.
+func mergeImageChanges(first, second []processor.ImageChange) []processor.ImageChange {
+	out := append([]processor.ImageChange{}, first...)
+	for _, c := range second {
+		merged := false
+		for i := range out {
+			if out[i].Container == c.Container && out[i].To == c.From {
+				out[i].To = c.To
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// serviceName returns the service assigned to obj by the grouping options or
+// a rename, or "" to let the processor detect it.
+func (g *Generator) serviceName(obj *unstructured.Unstructured, all map[types.ResourceKey]*types.ExtractedResource) string {
+	if name := g.opts.Grouping.ServiceName(obj, all); name != "" {
+		return name
+	}
+	return g.opts.ServiceNames[processor.ServiceNameFromResource(obj)]
+}
+
+// Analyze detects relationships and groups processed resources into services.
+func (g *Generator) Analyze(ctx context.Context, processed *Processed) (*types.ResourceGraph, error) {
+	graph, err := g.analyzer.Analyze(ctx, processed.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	return graph, nil
+}
+
+// Generate builds charts from an analyzed graph in the configured mode.
+func (g *Generator) Generate(ctx context.Context, graph *types.ResourceGraph, processed *Processed) ([]*types.GeneratedChart, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	gen, err := g.generators.Get(g.opts.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generator: %w", err)
+	}
+
+	charts, err := gen.Generate(ctx, graph, g.generatorOptions(processed))
+	if err != nil {
+		return nil, fmt.Errorf("chart generation failed: %w", err)
+	}
+	if len(charts) == 0 {
+		return nil, fmt.Errorf("no charts generated")
+	}
+	return charts, nil
+}
+
+// generatorOptions maps Options to generator.Options.
+func (g *Generator) generatorOptions(processed *Processed) generator.Options {
+	opts := generator.Options{
+		OutputDir:          g.opts.OutputDir,
+		ChartName:          g.opts.ChartName,
+		ChartVersion:       g.opts.ChartVersion,
+		AppVersion:         g.opts.AppVersion,
+		Mode:               g.opts.Mode,
+		Namespace:          g.opts.Namespace,
+		IncludeTests:       g.opts.IncludeTests,
+		IncludeREADME:      g.opts.IncludeREADME,
+		IncludeSchema:      g.opts.IncludeSchema,
+		EnvValues:          g.opts.EnvValues,
+		DeckhouseModule:    g.opts.DeckhouseModule,
+		TemplateStyle:      g.opts.TemplateStyle,
+		IncludeHooks:       g.opts.IncludeHooks,
+		ValuesFlat:         g.opts.ValuesFlat,
+		ValuesDocs:         g.opts.ValuesDocs,
+		DedupValues:        g.opts.DedupValues,
+		PreserveNamespaces: g.opts.PreserveNamespaces,
+		TemplateEnv:        g.opts.TemplateEnv,
+		ValueOverrides:     g.opts.ValueOverrides,
+		Injection:          g.opts.Injection,
+		ChartMetadata:      g.opts.ChartMetadata,
+	}
+	if processed != nil {
+		opts.ExternalFileManager = processed.ExternalFiles
+	}
+	return opts
+}
+
+// Extract collects resources from a source using the default extractor
+// registry. Non-fatal extraction errors (e.g. unparsable documents) are
+// returned separately from the fatal error.
+func Extract(ctx context.Context, source types.Source, opts extractor.Options) ([]*types.ExtractedResource, []error, error) {
+	ext, ok := extractor.DefaultRegistry().Get(source)
+	if !ok {
+		return nil, nil, fmt.Errorf("no extractor available for source type: %s", source)
+	}
+	if err := ext.Validate(ctx, opts); err != nil {
+		return nil, nil, fmt.Errorf("extractor validation failed: %w", err)
+	}
+
+	resourceChan, errChan := ext.Extract(ctx, opts)
+
+	var resources []*types.ExtractedResource
+	var warnings []error
+	for resourceChan != nil || errChan != nil {
+		select {
+		case r, ok := <-resourceChan:
+			if !ok {
+				resourceChan = nil
+				continue
+			}
+			resources = append(resources, r)
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			warnings = append(warnings, err)
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	return resources, warnings, nil
+}
+
+// WriteCharts validates each chart and writes it under dir. Charts are
+// written concurrently, each with its files replaced atomically.
+func WriteCharts(charts []*types.GeneratedChart, dir string) error {
+	return writeConcurrently(charts, "chart", func(chart *types.GeneratedChart) error {
+		return generator.WriteChart(chart, dir)
+	})
+}
+
+// WriteWerfProjects writes each chart as a werf project in dir: werf.yaml,
+// from werfConfigs by chart name, and the chart in .helm/.
+func WriteWerfProjects(charts []*types.GeneratedChart, werfConfigs map[string]string, dir string) error {
+	return writeConcurrently(charts, "werf project", func(chart *types.GeneratedChart) error {
+		return generator.WriteWerfProject(chart, werfConfigs[chart.Name], dir)
+	})
+}
+
+// writeConcurrently validates all charts, then writes them with write, at
+// most one per CPU at a time. The first write error is returned.
+func writeConcurrently(charts []*types.GeneratedChart, what string, write func(*types.GeneratedChart) error) error {
+	for _, chart := range charts {
+		if err := generator.ValidateChart(chart); err != nil {
+			return fmt.Errorf("chart validation failed for %s: %w", chart.Name, err)
+		}
+	}
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for _, chart := range charts {
+		g.Go(func() error {
+			if err := write(chart); err != nil {
+				return fmt.Errorf("failed to write %s %s: %w", what, chart.Name, err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,1973 @@
+package dhg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/extractor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+func deployment(name string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app": name},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": name},
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": name},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": name, "image": "nginx:1.25"},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func service(name string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app": name},
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"app": name},
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(80), "targetPort": int64(8080)},
+			},
+		},
+	}}
+}
+
+// ── New / Validate ────────────────────────────────────────────────────────────
+
+func TestNew_AppliesDefaults(t *testing.T) {
+	opts := New(Options{ChartName: "app"}).Options()
+
+	if opts.ChartVersion != DefaultChartVersion {
+		t.Errorf("expected chart version %q, got %q", DefaultChartVersion, opts.ChartVersion)
+	}
+	if opts.AppVersion != DefaultAppVersion {
+		t.Errorf("expected app version %q, got %q", DefaultAppVersion, opts.AppVersion)
+	}
+	if opts.Mode != types.OutputModeUniversal {
+		t.Errorf("expected universal mode, got %q", opts.Mode)
+	}
+	if opts.TemplateStyle != DefaultTemplateStyle {
+		t.Errorf("expected template style %q, got %q", DefaultTemplateStyle, opts.TemplateStyle)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"valid", Options{ChartName: "app"}, ""},
+		{"missing chart name", Options{}, "chart name is required"},
+		{"invalid mode", Options{ChartName: "app", Mode: "bogus"}, "invalid mode"},
+		{"invalid template style", Options{ChartName: "app", TemplateStyle: "fancy"}, "unknown template style"},
+		{"template env outside universal", Options{ChartName: "app", Mode: types.OutputModeSeparate, TemplateEnv: true}, "only supported in universal mode"},
+		{"kind both passed through and templated", Options{ChartName: "app", PassthroughKinds: []string{"Role"}, TemplateKinds: []string{"role"}}, "both passed through and templated"},
+		{"value overrides outside universal", Options{ChartName: "app", Mode: types.OutputModeLibrary, ValueOverrides: map[string]interface{}{"x": 1}}, "only supported in universal mode"},
+		{"invalid duplicate policy", Options{ChartName: "app", OnDuplicate: "newest"}, "unknown duplicate policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.opts).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithMode_DoesNotMutateOriginal(t *testing.T) {
+	g := New(Options{ChartName: "app"})
+	separate := g.WithMode(types.OutputModeSeparate)
+
+	if g.Options().Mode != types.OutputModeUniversal {
+		t.Errorf("original mode changed to %q", g.Options().Mode)
+	}
+	if separate.Options().Mode != types.OutputModeSeparate {
+		t.Errorf("expected separate mode, got %q", separate.Options().Mode)
+	}
+}
+
+// ── GenerateFromObjects ───────────────────────────────────────────────────────
+
+func TestGenerateFromObjects_Universal(t *testing.T) {
+	objects := []unstructured.Unstructured{deployment("web"), service("web")}
+
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), objects)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+
+	if len(res.Charts) != 1 {
+		t.Fatalf("expected 1 chart, got %d", len(res.Charts))
+	}
+	chart := res.Charts[0]
+	if chart.Name != "myapp" {
+		t.Errorf("expected chart name %q, got %q", "myapp", chart.Name)
+	}
+	if !strings.Contains(chart.ChartYAML, "version: 0.1.0") {
+		t.Errorf("expected default chart version in Chart.yaml, got:\n%s", chart.ChartYAML)
+	}
+	if len(chart.Templates) == 0 {
+		t.Error("expected templates to be generated")
+	}
+	if len(res.Resources) != 2 {
+		t.Errorf("expected 2 processed resources, got %d", len(res.Resources))
+	}
+	if res.Graph == nil || len(res.Graph.Relationships) == 0 {
+		t.Error("expected the service → deployment relationship to be detected")
+	}
+}
+
+func TestGenerateFromObjects_DoesNotMutateInput(t *testing.T) {
+	objects := []unstructured.Unstructured{deployment("web")}
+	before := objects[0].DeepCopy()
+
+	if _, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), objects); err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	if !equalObjects(before, &objects[0]) {
+		t.Error("input object was modified")
+	}
+}
+
+func TestGenerateFromObjects_ServiceNames(t *testing.T) {
+	objects := []unstructured.Unstructured{deployment("web")}
+
+	res, err := New(Options{
+		ChartName:    "myapp",
+		ServiceNames: map[string]string{"web": "frontend"},
+	}).GenerateFromObjects(context.Background(), objects)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	if got := res.Resources[0].ServiceName; got != "frontend" {
+		t.Errorf("expected renamed service %q, got %q", "frontend", got)
+	}
+}
+
+func TestGenerateFromObjects_GroupingPinsBeforeHeuristics(t *testing.T) {
+	objects := []unstructured.Unstructured{deployment("web"), service("web"), deployment("worker")}
+
+	res, err := New(Options{
+		ChartName:    "myapp",
+		ServiceNames: map[string]string{"web": "renamed"},
+		Grouping: analyzer.Grouping{
+			Strategy: analyzer.GroupByManual,
+			Manual: &analyzer.GroupMapping{Groups: map[string][]analyzer.GroupSelector{
+				"frontend": {{Name: "web"}, {Kind: "Deployment", Name: "work*"}},
+			}},
+		},
+	}).GenerateFromObjects(context.Background(), objects)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+
+	for _, r := range res.Resources {
+		if r.ServiceName != "frontend" {
+			t.Errorf("%s: expected pinned service %q, got %q", r.Original.ResourceKey(), "frontend", r.ServiceName)
+		}
+	}
+	if len(res.Graph.Groups) != 1 || res.Graph.Groups[0].Name != "frontend" {
+		t.Errorf("expected a single frontend group, got %v", res.Graph.Groups)
+	}
+}
+
+func TestProcess_InferHooks(t *testing.T) {
+	job := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]interface{}{"name": "db-migrate", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "migrate", "image": "app:1.0"}},
+				},
+			},
+		},
+	}}
+	resources := []*types.ExtractedResource{{Object: &job, GVK: job.GroupVersionKind()}}
+
+	for _, infer := range []bool{false, true} {
+		processed, err := New(Options{ChartName: "myapp", InferHooks: infer}).Process(context.Background(), resources)
+		if err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+		if got := strings.Contains(processed.Resources[0].TemplateContent, "helm.sh/hook"); got != infer {
+			t.Errorf("InferHooks=%v: hook annotations present = %v", infer, got)
+		}
+	}
+}
+
+func TestProcess_APIUpgrade(t *testing.T) {
+	ingress := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "extensions/v1beta1",
+		"kind":       "Ingress",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{
+				"host": "web.example.com",
+				"http": map[string]interface{}{"paths": []interface{}{map[string]interface{}{
+					"path":    "/",
+					"backend": map[string]interface{}{"serviceName": "web", "servicePort": int64(80)},
+				}}},
+			}},
+		},
+	}}
+	resources := []*types.ExtractedResource{{Object: &ingress, GVK: ingress.GroupVersionKind()}}
+
+	processed, err := New(Options{ChartName: "myapp"}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.APIUpgrades) != 0 || strings.Contains(processed.Resources[0].TemplateContent, "networking.k8s.io/v1") {
+		t.Error("resources must not be upgraded without APIUpgrade")
+	}
+
+	processed, err = New(Options{ChartName: "myapp", APIUpgrade: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.APIUpgrades) != 1 || processed.APIUpgrades[0].From != "extensions/v1beta1" ||
+		processed.APIUpgrades[0].Resource.GVK.Group != "networking.k8s.io" {
+		t.Errorf("unexpected upgrades: %+v", processed.APIUpgrades)
+	}
+	if content := processed.Resources[0].TemplateContent; !strings.Contains(content, "networking.k8s.io/v1") {
+		t.Errorf("expected a networking.k8s.io/v1 Ingress template:\n%s", content)
+	}
+	if ingress.GetAPIVersion() != "extensions/v1beta1" {
+		t.Error("input resource must not be modified")
+	}
+}
+
+func TestProcess_Duplicates(t *testing.T) {
+	configMap := func(source string, data map[string]interface{}) *types.ExtractedResource {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+			"data":       data,
+		}}
+		return &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind(), SourcePath: source}
+	}
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+	}}
+	resources := []*types.ExtractedResource{
+		configMap("base.yaml", map[string]interface{}{"A": "1", "B": "2"}),
+		{Object: secret, GVK: secret.GroupVersionKind(), SourcePath: "base.yaml"},
+		configMap("overlay.yaml", map[string]interface{}{"B": "3", "C": "4"}),
+	}
+
+	tests := []struct {
+		policy DuplicatePolicy
+		data   map[string]interface{}
+	}{
+		{"", map[string]interface{}{"B": "3", "C": "4"}},
+		{DuplicateFirst, map[string]interface{}{"A": "1", "B": "2"}},
+		{DuplicateLast, map[string]interface{}{"B": "3", "C": "4"}},
+		{DuplicateMerge, map[string]interface{}{"A": "1", "B": "3", "C": "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			processed, err := New(Options{ChartName: "myapp", OnDuplicate: tt.policy}).Process(context.Background(), resources)
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if len(processed.Resources) != 2 || processed.Resources[0].Original.Object.GetKind() != "ConfigMap" {
+				t.Fatalf("expected the ConfigMap once, in its first position, and the Secret")
+			}
+			data, _, _ := unstructured.NestedMap(processed.Resources[0].Original.Object.Object, "data")
+			if !reflect.DeepEqual(data, tt.data) {
+				t.Errorf("expected data %v, got %v", tt.data, data)
+			}
+			if len(processed.Duplicates) != 1 || processed.Duplicates[0].Resource.String() != "ConfigMap/default/settings" ||
+				!reflect.DeepEqual(processed.Duplicates[0].Sources, []string{"base.yaml", "overlay.yaml"}) {
+				t.Errorf("unexpected duplicates: %+v", processed.Duplicates)
+			}
+		})
+	}
+
+	_, err := New(Options{ChartName: "myapp", OnDuplicate: DuplicateError}).Process(context.Background(), resources)
+	if err == nil || !strings.Contains(err.Error(), "ConfigMap/default/settings (base.yaml, overlay.yaml)") {
+		t.Errorf("expected a duplicate error, got %v", err)
+	}
+	if data, _, _ := unstructured.NestedMap(resources[0].Object.Object, "data"); len(data) != 2 || data["B"] != "2" {
+		t.Error("input resources must not be modified")
+	}
+}
+
+func TestProcess_DuplicatesEffectiveNamespace(t *testing.T) {
+	configMap := func(source, namespace string) *types.ExtractedResource {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "settings"},
+		}}
+		obj.SetNamespace(namespace)
+		return &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind(), SourcePath: source}
+	}
+
+	tests := []struct {
+		name       string
+		namespace  string
+		other      string
+		duplicates int
+	}{
+		{"default namespace", "", "default", 1},
+		{"--namespace", "prod", "prod", 1},
+		{"other namespace", "prod", "default", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := []*types.ExtractedResource{configMap("base.yaml", ""), configMap("overlay.yaml", tt.other)}
+			processed, err := New(Options{ChartName: "myapp", Namespace: tt.namespace, OnDuplicate: DuplicateLast}).Process(context.Background(), resources)
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if len(processed.Duplicates) != tt.duplicates {
+				t.Errorf("expected %d duplicates, got %+v", tt.duplicates, processed.Duplicates)
+			}
+		})
+	}
+}
+
+func TestProcess_HA(t *testing.T) {
+	web, api, single := deployment("web"), deployment("api"), deployment("single")
+	_ = unstructured.SetNestedField(single.Object, int64(1), "spec", "replicas")
+	existing := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy/v1",
+		"kind":       "PodDisruptionBudget",
+		"metadata":   map[string]interface{}{"name": "api", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"minAvailable": int64(1),
+			"selector":     map[string]interface{}{"matchLabels": map[string]interface{}{"app": "api"}},
+		},
+	}}
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{&web, &api, &single, &existing} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	processed, err := New(Options{ChartName: "myapp", HA: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.PodDisruptionBudgets) != 1 || processed.PodDisruptionBudgets[0].Name != "web" {
+		t.Fatalf("expected a PDB for web only, got %v", processed.PodDisruptionBudgets)
+	}
+	if len(processed.Resources) != 5 {
+		t.Fatalf("expected 5 processed resources, got %d", len(processed.Resources))
+	}
+	pdb := processed.Resources[1]
+	if pdb.Original.GVK.Kind != "PodDisruptionBudget" || pdb.ServiceName != "web" {
+		t.Errorf("expected the web PDB after the web Deployment, got %s (service %s)", pdb.Original.ResourceKey(), pdb.ServiceName)
+	}
+	if pdb.Values["enabled"] != true || pdb.Values["maxUnavailable"] != int64(1) {
+		t.Errorf("unexpected PDB values: %v", pdb.Values)
+	}
+}
+
+func TestProcess_TopologySpread(t *testing.T) {
+	web, single := deployment("web"), deployment("single")
+	_ = unstructured.SetNestedField(single.Object, int64(1), "spec", "replicas")
+	resources := []*types.ExtractedResource{
+		{Object: &web, GVK: web.GroupVersionKind()},
+		{Object: &single, GVK: single.GroupVersionKind()},
+	}
+
+	processed, err := New(Options{ChartName: "myapp", TopologySpread: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.TopologySpread) != 1 || processed.TopologySpread[0].Name != "web" {
+		t.Fatalf("expected constraints for web only, got %v", processed.TopologySpread)
+	}
+	tsc, ok := processed.Resources[0].Values["topologySpreadConstraints"].([]interface{})
+	if !ok || len(tsc) != 2 {
+		t.Fatalf("expected zone and hostname constraints in values, got %v", processed.Resources[0].Values["topologySpreadConstraints"])
+	}
+	if _, found, _ := unstructured.NestedSlice(web.Object, "spec", "template", "spec", "topologySpreadConstraints"); found {
+		t.Error("the input Deployment was modified")
+	}
+}
+
+func TestProcess_Autoscaling(t *testing.T) {
+	web, api, noRequests := deployment("web"), deployment("api"), deployment("worker")
+	for _, d := range []*unstructured.Unstructured{&web, &api} {
+		_ = unstructured.SetNestedSlice(d.Object, []interface{}{map[string]interface{}{
+			"name":      d.GetName(),
+			"image":     "nginx:1.25",
+			"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "100m"}},
+		}}, "spec", "template", "spec", "containers")
+	}
+	existing := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "api", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "api"},
+			"maxReplicas":    int64(4),
+		},
+	}}
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{&web, &api, &noRequests, &existing} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	processed, err := New(Options{ChartName: "myapp", Autoscaling: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.HorizontalPodAutoscalers) != 1 || processed.HorizontalPodAutoscalers[0].Name != "web" {
+		t.Fatalf("expected an HPA for web only, got %v", processed.HorizontalPodAutoscalers)
+	}
+	if len(processed.AutoscalingSkipped) != 1 || processed.AutoscalingSkipped[0].Name != "worker" {
+		t.Errorf("expected worker to be skipped, got %v", processed.AutoscalingSkipped)
+	}
+	hpa := processed.Resources[1]
+	if hpa.Original.GVK.Kind != "HorizontalPodAutoscaler" || hpa.ServiceName != "web" {
+		t.Fatalf("expected the web HPA after the web Deployment, got %s (service %s)", hpa.Original.ResourceKey(), hpa.ServiceName)
+	}
+	if hpa.ValuesPath != "services.web.autoscaling" || hpa.Values["enabled"] != true {
+		t.Errorf("unexpected HPA values %s: %v", hpa.ValuesPath, hpa.Values)
+	}
+	if hpa.Values["minReplicas"] != int64(2) || hpa.Values["maxReplicas"] != int64(6) {
+		t.Errorf("unexpected HPA replicas: %v", hpa.Values)
+	}
+}
+
+func TestProcess_ServiceAccounts(t *testing.T) {
+	web, api, worker := deployment("web"), deployment("api"), deployment("worker")
+	_ = unstructured.SetNestedField(api.Object, "api-sa", "spec", "template", "spec", "serviceAccountName")
+	_ = unstructured.SetNestedField(worker.Object, "shared", "spec", "template", "spec", "serviceAccountName")
+	shared := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ServiceAccount",
+		"metadata":   map[string]interface{}{"name": "shared", "namespace": "default"},
+	}}
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{&web, &api, &worker, &shared} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	processed, err := New(Options{ChartName: "myapp", ServiceAccounts: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.ServiceAccountChanges) != 1 || processed.ServiceAccountChanges[0].ServiceAccount != "web" {
+		t.Errorf("expected web to get its own ServiceAccount, got %v", processed.ServiceAccountChanges)
+	}
+	var added []string
+	for _, k := range processed.RBACResources {
+		added = append(added, k.GVK.Kind+"/"+k.Name)
+	}
+	want := []string{"ServiceAccount/web", "Role/web", "RoleBinding/web", "ServiceAccount/api-sa", "Role/api-sa", "RoleBinding/api-sa"}
+	if strings.Join(added, ",") != strings.Join(want, ",") {
+		t.Errorf("added %v, want %v", added, want)
+	}
+	if sa := processed.Resources[0].Values["serviceAccountName"]; sa != "web" {
+		t.Errorf("web serviceAccountName = %v", sa)
+	}
+	if name, _, _ := unstructured.NestedString(web.Object, "spec", "template", "spec", "serviceAccountName"); name != "" {
+		t.Errorf("the input Deployment was modified: %q", name)
+	}
+	if len(processed.Resources) != 10 {
+		t.Errorf("expected 10 processed resources, got %d", len(processed.Resources))
+	}
+}
+
+func TestProcess_PriorityClasses(t *testing.T) {
+	web, api := deployment("web"), deployment("api")
+	_ = unstructured.SetNestedField(api.Object, "system-cluster-critical", "spec", "template", "spec", "priorityClassName")
+	db := deployment("db")
+	db.SetKind("StatefulSet")
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{&web, &api, &db} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	processed, err := New(Options{ChartName: "myapp", PriorityClasses: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	var changes []string
+	for _, c := range processed.PriorityClassChanges {
+		changes = append(changes, c.Resource.Name+"="+c.PriorityClass)
+	}
+	if want := "web=myapp-standard,db=myapp-critical"; strings.Join(changes, ",") != want {
+		t.Errorf("changes %v, want %s", changes, want)
+	}
+	var added []string
+	for _, k := range processed.PriorityClasses {
+		added = append(added, k.Name)
+	}
+	if want := "myapp-critical,myapp-standard"; strings.Join(added, ",") != want {
+		t.Errorf("added %v, want %s", added, want)
+	}
+	if pc := processed.Resources[0].Values["priorityClassName"]; pc != "myapp-standard" {
+		t.Errorf("web priorityClassName = %v", pc)
+	}
+	if pc := processed.Resources[1].Values["priorityClassName"]; pc != "system-cluster-critical" {
+		t.Errorf("api priorityClassName = %v, want it kept", pc)
+	}
+	if last := processed.Resources[len(processed.Resources)-1]; last.Original.Object.GetKind() != "PriorityClass" {
+		t.Errorf("expected the PriorityClasses to be processed last, got %s", last.Original.ResourceKey().String())
+	}
+	if name, _, _ := unstructured.NestedString(web.Object, "spec", "template", "spec", "priorityClassName"); name != "" {
+		t.Errorf("the input Deployment was modified: %q", name)
+	}
+}
+
+func TestPriorityClasses_Render(t *testing.T) {
+	objs := []unstructured.Unstructured{deployment("web")}
+	opts := Options{ChartName: "myapp", PriorityClasses: true}
+
+	res, err := New(opts).GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	// Values at the path of earlier versions override those of the service.
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{Values: map[string]interface{}{
+		"priorityClasses": map[string]interface{}{"myappStandard": map[string]interface{}{"value": 5000}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := rendered.Manifests["templates/priorityclass-myappStandard.yaml"]
+	if !strings.Contains(pc, "name: myapp-standard") || !strings.Contains(pc, "value: 5000") || !strings.Contains(pc, "preemptionPolicy: PreemptLowerPriority") {
+		t.Errorf("expected the PriorityClass with the overridden value:\n%s", pc)
+	}
+
+	// The generated PriorityClass is in a chart of its own in separate mode.
+	opts.Mode = types.OutputModeSeparate
+	res, err = New(opts).GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir = t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, chart := range res.Charts {
+		if _, err := helm.RenderChart(filepath.Join(dir, chart.Name), helm.RenderOptions{}); err != nil {
+			t.Errorf("render %s: %v", chart.Name, err)
+		}
+	}
+}
+
+func TestPassthrough_Render(t *testing.T) {
+	labels := map[string]interface{}{"app.kubernetes.io/name": "web"}
+	role := func(name string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "Role",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default", "labels": labels},
+			"rules":      []interface{}{},
+		}}
+	}
+	config := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "web-config", "namespace": "default", "labels": labels},
+		"data":       map[string]interface{}{"mode": "fast"},
+	}}
+	objs := []unstructured.Unstructured{deployment("web"), config, role("web-reader"), role("web-writer")}
+
+	for _, tt := range []struct {
+		name  string
+		roles int
+	}{{"one of a kind", 1}, {"two of a kind", 2}} {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := New(Options{ChartName: "myapp", TemplateKinds: []string{"Deployment"}}).GenerateFromObjects(context.Background(), objs[:2+tt.roles])
+			if err != nil {
+				t.Fatalf("GenerateFromObjects: %v", err)
+			}
+			dir := t.TempDir()
+			if err := WriteCharts(res.Charts, dir); err != nil {
+				t.Fatal(err)
+			}
+			rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{})
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if !strings.Contains(rendered.Manifests["templates/configmap-web-config.yaml"], "mode: fast") {
+				t.Errorf("expected the passed-through ConfigMap:\n%v", rendered.Manifests)
+			}
+			if !strings.Contains(rendered.Manifests["templates/role-web-reader.yaml"], "name: web-reader") {
+				t.Errorf("expected the passed-through Role:\n%v", rendered.Manifests)
+			}
+
+			// Each resource is disabled at the values path the generator wrote.
+			roleValues := map[string]interface{}{"role": map[string]interface{}{"enabled": false}}
+			if tt.roles > 1 {
+				roleValues = map[string]interface{}{"roles": map[string]interface{}{"webReader": map[string]interface{}{"enabled": false}}}
+			}
+			roleValues["configMaps"] = map[string]interface{}{"webConfig": map[string]interface{}{"enabled": false}}
+			rendered, err = helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{Values: map[string]interface{}{
+				"services": map[string]interface{}{"web": roleValues},
+			}})
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			for _, path := range []string{"templates/configmap-web-config.yaml", "templates/role-web-reader.yaml"} {
+				if strings.TrimSpace(rendered.Manifests[path]) != "" {
+					t.Errorf("expected %s to be disabled:\n%s", path, rendered.Manifests[path])
+				}
+			}
+			if tt.roles > 1 && !strings.Contains(rendered.Manifests["templates/role-web-writer.yaml"], "name: web-writer") {
+				t.Errorf("expected the other Role to stay enabled:\n%v", rendered.Manifests)
+			}
+		})
+	}
+}
+
+func TestProcess_NoTokenAutomount(t *testing.T) {
+	web, operator, api := deployment("web"), deployment("operator"), deployment("api")
+	_ = unstructured.SetNestedField(operator.Object, "operator", "spec", "template", "spec", "serviceAccountName")
+	_ = unstructured.SetNestedField(api.Object, true, "spec", "template", "spec", "automountServiceAccountToken")
+	binding := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "RoleBinding",
+		"metadata":   map[string]interface{}{"name": "operator", "namespace": "default"},
+		"roleRef":    map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "view"},
+		"subjects":   []interface{}{map[string]interface{}{"kind": "ServiceAccount", "name": "operator"}},
+	}}
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{&web, &operator, &api, &binding} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	// The RoleBinding scaffolds of ServiceAccounts do not count as bindings.
+	processed, err := New(Options{ChartName: "myapp", NoTokenAutomount: true, ServiceAccounts: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(processed.TokenAutomountDisabled) != 1 || processed.TokenAutomountDisabled[0].Name != "web" {
+		t.Errorf("expected token automounting disabled for web only, got %v", processed.TokenAutomountDisabled)
+	}
+	for _, r := range processed.Resources {
+		if r.Original.Object.GetKind() != "Deployment" {
+			continue
+		}
+		automount, found := r.Values["automountServiceAccountToken"]
+		switch r.Original.Object.GetName() {
+		case "web":
+			if automount != false {
+				t.Errorf("web automountServiceAccountToken = %v, want false", automount)
+			}
+		case "api":
+			if automount != true {
+				t.Errorf("api automountServiceAccountToken = %v, want it kept", automount)
+			}
+		case "operator":
+			if found {
+				t.Errorf("operator automountServiceAccountToken = %v, want unset", automount)
+			}
+		}
+	}
+	if _, found, _ := unstructured.NestedBool(web.Object, "spec", "template", "spec", "automountServiceAccountToken"); found {
+		t.Error("the input Deployment was modified")
+	}
+}
+
+func TestProcess_DeckhouseModuleConfig(t *testing.T) {
+	moduleConfig := func(name string, settings map[string]interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "deckhouse.io/v1alpha1",
+			"kind":       "ModuleConfig",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       map[string]interface{}{"enabled": true, "settings": settings},
+		}}
+	}
+	web := deployment("web")
+	own := moduleConfig("myapp", map[string]interface{}{"logLevel": "info"})
+	other := moduleConfig("cert-manager", nil)
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{&other, &web, &own} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	processed, err := New(Options{ChartName: "myapp", DeckhouseModule: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if processed.ModuleConfig == nil || processed.ModuleConfig.Name != "myapp" {
+		t.Fatalf("expected the myapp ModuleConfig, got %v", processed.ModuleConfig)
+	}
+	if processed.ModuleSettings["logLevel"] != "info" {
+		t.Errorf("ModuleSettings = %v", processed.ModuleSettings)
+	}
+	if len(processed.Resources) != 2 {
+		t.Errorf("expected the module ModuleConfig not to be processed, got %d resources", len(processed.Resources))
+	}
+
+	// Several ModuleConfigs, none named after the module: none is taken.
+	processed, err = New(Options{ChartName: "other", DeckhouseModule: true}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if processed.ModuleConfig != nil || len(processed.Resources) != 3 {
+		t.Errorf("expected no module ModuleConfig, got %v", processed.ModuleConfig)
+	}
+}
+
+func TestProcess_ImageRewritesAndDigests(t *testing.T) {
+	web, api := deployment("web"), deployment("api")
+	resources := []*types.ExtractedResource{
+		{Object: &web, GVK: web.GroupVersionKind()},
+		{Object: &api, GVK: api.GroupVersionKind()},
+	}
+	calls := 0
+	resolve := func(_ context.Context, image string) (string, error) {
+		calls++
+		if image != "mirror.local/nginx:1.25" {
+			t.Errorf("resolved %q, want the rewritten image", image)
+		}
+		return "sha256:0123", nil
+	}
+
+	processed, err := New(Options{
+		ChartName:      "myapp",
+		ImageRewrites:  []processor.ImageRewrite{{From: "docker.io", To: "mirror.local"}},
+		PinDigests:     true,
+		DigestResolver: resolve,
+	}).Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected one resolve for the shared image, got %d", calls)
+	}
+	if len(processed.ImageUpdates) != 2 {
+		t.Fatalf("unexpected updates: %+v", processed.ImageUpdates)
+	}
+	if u := processed.ImageUpdates[0]; u.From != "nginx:1.25" || u.To != "mirror.local/nginx:1.25@sha256:0123" {
+		t.Errorf("unexpected update: %+v", u)
+	}
+
+	containers, _ := processed.Resources[0].Values["containers"].([]map[string]interface{})
+	if len(containers) != 1 {
+		t.Fatalf("unexpected values: %v", processed.Resources[0].Values)
+	}
+	image, _ := containers[0]["image"].(map[string]interface{})
+	if image["repository"] != "mirror.local/nginx" || image["tag"] != "1.25" || image["digest"] != "sha256:0123" {
+		t.Errorf("unexpected image values: %v", image)
+	}
+	if !strings.Contains(processed.Resources[0].TemplateContent, "@{{ .image.digest }}") {
+		t.Error("expected the template to deploy by digest")
+	}
+	if img, _, _ := unstructured.NestedSlice(web.Object, "spec", "template", "spec", "containers"); img[0].(map[string]interface{})["image"] != "nginx:1.25" {
+		t.Error("input resource must not be modified")
+	}
+}
+
+func TestGenerateFromObjects_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := New(Options{ChartName: "app"}).GenerateFromObjects(ctx, nil); err == nil {
+		t.Error("expected error for empty input")
+	}
+	if _, err := New(Options{}).GenerateFromObjects(ctx, []unstructured.Unstructured{deployment("web")}); err == nil {
+		t.Error("expected error for missing chart name")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := New(Options{ChartName: "app"}).GenerateFromObjects(cancelled, []unstructured.Unstructured{deployment("web")}); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+// ── Process ───────────────────────────────────────────────────────────────────
+
+func TestProcess_CallsOnProcessed(t *testing.T) {
+	var seen []string
+	g := New(Options{
+		ChartName: "app",
+		OnProcessed: func(r *types.ProcessedResource) {
+			seen = append(seen, r.Original.Object.GetKind())
+		},
+	})
+
+	obj := deployment("web")
+	resources := []*types.ExtractedResource{{Object: &obj, Source: types.SourceFile, GVK: obj.GroupVersionKind()}}
+	processed, err := g.Process(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if len(processed.Resources) != 1 || processed.ExternalFiles == nil {
+		t.Errorf("unexpected process result: %+v", processed)
+	}
+	if len(seen) != 1 || seen[0] != "Deployment" {
+		t.Errorf("expected OnProcessed for the deployment, got %v", seen)
+	}
+}
+
+// ── Extract / WriteCharts ─────────────────────────────────────────────────────
+
+func TestExtract_File(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\ndata:\n  a: b\n"
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resources, warnings, err := Extract(context.Background(), types.SourceFile, extractor.Options{Paths: []string{dir}})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if len(resources) != 1 || resources[0].Object.GetName() != "cfg" {
+		t.Errorf("expected the cfg ConfigMap, got %v", resources)
+	}
+}
+
+func TestWriteCharts(t *testing.T) {
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{deployment("web")})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatalf("WriteCharts: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "myapp", "Chart.yaml")); err != nil {
+		t.Errorf("expected Chart.yaml to be written: %v", err)
+	}
+}
+
+func TestWriteCharts_Concurrent(t *testing.T) {
+	var charts []*types.GeneratedChart
+	for i := 0; i < 20; i++ {
+		charts = append(charts, &types.GeneratedChart{
+			Name:       fmt.Sprintf("svc-%02d", i),
+			ChartYAML:  fmt.Sprintf("apiVersion: v2\nname: svc-%02d\nversion: 0.1.0\n", i),
+			ValuesYAML: "replicas: 1\n",
+			Templates:  map[string]string{"templates/deployment.yaml": "kind: Deployment\n"},
+		})
+	}
+
+	dir := t.TempDir()
+	if err := WriteCharts(charts, dir); err != nil {
+		t.Fatalf("WriteCharts: %v", err)
+	}
+	for _, chart := range charts {
+		data, err := os.ReadFile(filepath.Join(dir, chart.Name, "Chart.yaml"))
+		if err != nil || string(data) != chart.ChartYAML {
+			t.Errorf("%s/Chart.yaml = %q, %v", chart.Name, data, err)
+		}
+	}
+
+	charts[7].ValuesYAML = ""
+	if err := WriteCharts(charts, t.TempDir()); err == nil || !strings.Contains(err.Error(), "svc-07") {
+		t.Errorf("expected a validation error for svc-07, got %v", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{deployment("web"), deployment("api")})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+
+	summary := Summarize(res.Charts, res.Graph)
+	if summary.Resources != 2 || len(summary.Charts) != 1 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	chart := summary.Charts[0]
+	if chart.Name != "myapp" || chart.Templates != len(res.Charts[0].Templates) || chart.ValuesKeys == 0 {
+		t.Errorf("chart summary = %+v", chart)
+	}
+	if summary.Patterns.RecommendedStrategy == "" || summary.Warnings == nil || summary.Patterns.Detected == nil {
+		t.Errorf("patterns and warnings should be set: %+v", summary)
+	}
+
+	if got := countValuesKeys("a:\n  b: 1\n  c: {}\nd: [1, 2]\n"); got != 3 {
+		t.Errorf("countValuesKeys = %d; want 3", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "w", "namespace": "default"},
+		"spec":       map[string]interface{}{"size": int64(3)},
+	}}
+	var resources []*types.ExtractedResource
+	for _, obj := range []unstructured.Unstructured{deployment("web"), deployment("api"), service("web"), *widget} {
+		obj := obj
+		resources = append(resources, &types.ExtractedResource{Object: &obj, GVK: obj.GroupVersionKind()})
+	}
+	replicaSet := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet"}}
+
+	coverage, err := New(Options{ChartName: "myapp", PassthroughKinds: []string{"Service"}}).Coverage(context.Background(), resources,
+		[]SkippedResource{{Object: replicaSet, Reason: extractor.SkipControllerOwned}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []KindCoverage{
+		{APIVersion: "apps/v1", Kind: "Deployment", Handling: HandlingProcessor, Processor: "deployment", Resources: 2},
+		{APIVersion: "example.com/v1", Kind: "Widget", Handling: HandlingGeneric, Resources: 1},
+		{APIVersion: "v1", Kind: "Service", Handling: HandlingPassthrough, Resources: 1},
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Handling: HandlingSkipped, Reason: extractor.SkipControllerOwned, Resources: 1},
+	}
+	if len(coverage.Kinds) != len(want) {
+		t.Fatalf("kinds = %+v", coverage.Kinds)
+	}
+	for i := range want {
+		if coverage.Kinds[i] != want[i] {
+			t.Errorf("kind %d = %+v, want %+v", i, coverage.Kinds[i], want[i])
+		}
+	}
+	if r := coverage.Resources; r[HandlingProcessor] != 2 || r[HandlingGeneric] != 1 || r[HandlingPassthrough] != 1 || r[HandlingSkipped] != 1 {
+		t.Errorf("resources = %v", r)
+	}
+}
+
+func TestStatefulSetPersistence_Render(t *testing.T) {
+	sts := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "StatefulSet",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"serviceName": "db",
+			"selector":    map[string]interface{}{"matchLabels": map[string]interface{}{"app": "db"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "db"}},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "db", "image": "postgres:16"}},
+				},
+			},
+			"volumeClaimTemplates": []interface{}{
+				map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "data"},
+					"spec": map[string]interface{}{
+						"accessModes":      []interface{}{"ReadWriteOnce"},
+						"storageClassName": "fast-ssd",
+						"resources":        map[string]interface{}{"requests": map[string]interface{}{"storage": "10Gi"}},
+					},
+				},
+			},
+		},
+	}}
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{sts})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{Values: map[string]interface{}{
+		"services": map[string]interface{}{"db": map[string]interface{}{"statefulSet": map[string]interface{}{
+			"persistence": map[string]interface{}{"data": map[string]interface{}{"size": "20Gi"}},
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := rendered.Manifests["templates/db-statefulset.yaml"]
+	want := `  volumeClaimTemplates:
+    - metadata:
+        name: data
+      spec:
+        accessModes:
+          - ReadWriteOnce
+        storageClassName: "fast-ssd"
+        resources:
+          requests:
+            storage: "20Gi"
+`
+	if !strings.Contains(manifest, want) {
+		t.Errorf("expected the overridden claim in:\n%s", manifest)
+	}
+}
+
+func TestGenerate_SeparateRender(t *testing.T) {
+	resources, _, err := Extract(context.Background(), types.SourceFile, extractor.Options{Paths: []string{
+		"../../examples/05-full-stack",
+		"../../examples/06-separate-mode",
+	}})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	res, err := New(Options{ChartName: "myapp", Mode: types.OutputModeSeparate}).GenerateFromResources(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("GenerateFromResources: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chart := range res.Charts {
+		rendered, err := helm.RenderChart(filepath.Join(dir, chart.Name), helm.RenderOptions{})
+		if err != nil {
+			t.Fatalf("render %s: %v", chart.Name, err)
+		}
+		if len(rendered.Manifests) == 0 {
+			t.Errorf("expected chart %s to render its resources", chart.Name)
+		}
+		for path, manifest := range rendered.Manifests {
+			if !strings.Contains(manifest, "app.kubernetes.io/name: "+chart.Name) {
+				t.Errorf("expected %s of chart %s labelled by its own helpers:\n%s", path, chart.Name, manifest)
+			}
+		}
+	}
+}
+
+func TestService_Render(t *testing.T) {
+	service := func(name string, spec map[string]interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       spec,
+		}}
+	}
+	objs := []unstructured.Unstructured{
+		service("db", map[string]interface{}{
+			"clusterIP":                "None",
+			"publishNotReadyAddresses": true,
+			"selector":                 map[string]interface{}{"app": "db"},
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(5432)},
+				map[string]interface{}{"port": int64(9187), "name": "metrics"},
+			},
+		}),
+		service("edge", map[string]interface{}{
+			"type":     "LoadBalancer",
+			"selector": map[string]interface{}{"app": "edge"},
+			"ports":    []interface{}{map[string]interface{}{"port": int64(443), "nodePort": int64(30443)}},
+		}),
+		service("upstream", map[string]interface{}{
+			"type":         "ExternalName",
+			"externalName": "api.example.com",
+		}),
+	}
+	res, err := New(Options{ChartName: "myapp", IncludeSchema: true}).GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	if !strings.Contains(res.Charts[0].ValuesSchema, "maximum: 32767") {
+		t.Errorf("expected the node port range in the schema:\n%s", res.Charts[0].ValuesSchema)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := rendered.Manifests["templates/db-service.yaml"]
+	for _, want := range []string{"type: ClusterIP", "clusterIP: None", "publishNotReadyAddresses: true", "name: port-5432", "name: metrics", "targetPort: 9187"} {
+		if !strings.Contains(db, want) {
+			t.Errorf("expected %q in the headless service:\n%s", want, db)
+		}
+	}
+	if edge := rendered.Manifests["templates/edge-service.yaml"]; !strings.Contains(edge, "nodePort: 30443") {
+		t.Errorf("expected the node port of the LoadBalancer service:\n%s", edge)
+	}
+	upstream := rendered.Manifests["templates/upstream-service.yaml"]
+	if !strings.Contains(upstream, "externalName: api.example.com") || strings.Contains(upstream, "selector:") {
+		t.Errorf("expected an ExternalName service without a selector:\n%s", upstream)
+	}
+}
+
+func TestIngress_Render(t *testing.T) {
+	backend := func(name string, port int64) map[string]interface{} {
+		return map[string]interface{}{"service": map[string]interface{}{"name": name, "port": map[string]interface{}{"number": port}}}
+	}
+	ingress := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":        "web",
+			"namespace":   "default",
+			"annotations": map[string]interface{}{"nginx.ingress.kubernetes.io/proxy-body-size": "8m"},
+		},
+		"spec": map[string]interface{}{
+			"ingressClassName": "nginx",
+			"tls":              []interface{}{map[string]interface{}{"hosts": []interface{}{"web.example.com"}, "secretName": "web-tls"}},
+			"rules": []interface{}{
+				map[string]interface{}{"host": "web.example.com", "http": map[string]interface{}{"paths": []interface{}{
+					map[string]interface{}{"path": "/", "pathType": "Prefix", "backend": backend("web", 80)},
+					map[string]interface{}{"path": "/api", "pathType": "Prefix", "backend": backend("api", 8080)},
+				}}},
+			},
+		},
+	}}
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{ingress})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{Values: map[string]interface{}{
+		"services": map[string]interface{}{"web": map[string]interface{}{"ingress": map[string]interface{}{
+			"className": "traefik",
+			"hosts": []interface{}{
+				map[string]interface{}{"host": "staging.example.com", "paths": []interface{}{
+					map[string]interface{}{"path": "/", "service": map[string]interface{}{"name": "web", "port": 80}},
+				}},
+			},
+			"tls": []interface{}{map[string]interface{}{"hosts": []interface{}{"staging.example.com"}, "secretName": "staging-tls"}},
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := rendered.Manifests["templates/web-ingress.yaml"]
+	want := `spec:
+  ingressClassName: traefik
+  tls:
+    - hosts:
+        - "staging.example.com"
+      secretName: staging-tls
+  rules:
+    - http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: web
+                port:
+                  number: 80
+      host: "staging.example.com"
+`
+	if !strings.Contains(manifest, want) {
+		t.Errorf("expected the overridden rules in:\n%s", manifest)
+	}
+	if !strings.Contains(manifest, "nginx.ingress.kubernetes.io/proxy-body-size: 8m") {
+		t.Errorf("expected the source annotations in:\n%s", manifest)
+	}
+}
+
+func TestWebhookConfiguration_Render(t *testing.T) {
+	webhook := func(name string, failurePolicy string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                    name,
+			"admissionReviewVersions": []interface{}{"v1"},
+			"sideEffects":             "None",
+			"failurePolicy":           failurePolicy,
+			"clientConfig": map[string]interface{}{
+				"caBundle": "Cg==",
+				"service":  map[string]interface{}{"name": "op-webhook", "namespace": "op-system", "path": "/validate", "port": int64(443)},
+			},
+		}
+	}
+	svc := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "op-webhook", "namespace": "op-system"},
+		"spec":       map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(443)}}},
+	}}
+	cfg := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingWebhookConfiguration",
+		"metadata": map[string]interface{}{
+			"name":        "op",
+			"annotations": map[string]interface{}{"cert-manager.io/inject-ca-from": "op-system/op-serving-cert"},
+		},
+		"webhooks": []interface{}{webhook("a.example.com", "Fail"), webhook("b.example.com", "Fail")},
+	}}
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{svc, cfg})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{Namespace: "prod", Values: map[string]interface{}{
+		"services": map[string]interface{}{"op": map[string]interface{}{"validatingWebhookConfiguration": map[string]interface{}{
+			"failurePolicy": "Ignore",
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := rendered.Manifests["templates/op-validatingwebhookconfiguration.yaml"]
+	for _, want := range []string{
+		"cert-manager.io/inject-ca-from: op-system/op-serving-cert",
+		`    clientConfig:
+      service:
+        name: op-webhook
+        namespace: prod
+        path: "/validate"
+        port: 443
+    failurePolicy: Ignore`,
+	} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("expected %q in:\n%s", want, manifest)
+		}
+	}
+	if strings.Contains(manifest, "caBundle") {
+		t.Errorf("caBundle should be left to the cert-manager CA injector:\n%s", manifest)
+	}
+}
+
+func TestPersistentVolumes_Render(t *testing.T) {
+	pv := func(name string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolume",
+			"metadata":   map[string]interface{}{"name": name, "labels": map[string]interface{}{"app.kubernetes.io/name": "db"}},
+			"spec": map[string]interface{}{
+				"capacity":         map[string]interface{}{"storage": "10Gi"},
+				"accessModes":      []interface{}{"ReadWriteOnce"},
+				"storageClassName": "fast-ssd",
+				"nfs":              map[string]interface{}{"server": "10.0.0.5", "path": "/exports/" + name},
+			},
+		}}
+	}
+	sc := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":  "storage.k8s.io/v1",
+		"kind":        "StorageClass",
+		"metadata":    map[string]interface{}{"name": "fast-ssd"},
+		"provisioner": "nfs.csi.k8s.io",
+		"parameters":  map[string]interface{}{"server": "10.0.0.5"},
+	}}
+	objs := []unstructured.Unstructured{pv("db-0"), pv("db-1"), sc}
+
+	res, err := New(Options{ChartName: "myapp"}).GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{Values: map[string]interface{}{
+		"services": map[string]interface{}{"db": map[string]interface{}{"persistentVolumes": map[string]interface{}{
+			"db1": map[string]interface{}{"enabled": false},
+		}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest := rendered.Manifests["templates/persistentvolume-db0.yaml"]; !strings.Contains(manifest, "  nfs:\n    path: /exports/db-0\n") {
+		t.Errorf("expected the volume source in:\n%s", manifest)
+	}
+	if manifest := rendered.Manifests["templates/persistentvolume-db1.yaml"]; strings.TrimSpace(manifest) != "" {
+		t.Errorf("expected the disabled volume to render nothing, got:\n%s", manifest)
+	}
+	if manifest := rendered.Manifests["templates/storageclass-fastSsd.yaml"]; !strings.Contains(manifest, "provisioner: nfs.csi.k8s.io") {
+		t.Errorf("expected the provisioner in:\n%s", manifest)
+	}
+
+	skipped, err := New(Options{ChartName: "myapp", SkipPersistentVolumes: true}).GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	for path := range skipped.Charts[0].Templates {
+		if strings.HasPrefix(path, "templates/persistentvolume-") {
+			t.Errorf("expected no PersistentVolume templates with SkipPersistentVolumes, got %s", path)
+		}
+	}
+}
+
+func TestExternalizeConfigMaps_Render(t *testing.T) {
+	nginxConf := "server {\n  listen {{ .Values.port }};\n}\n"
+	cm := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "labels": map[string]interface{}{"app.kubernetes.io/name": "web"}},
+		"data":       map[string]interface{}{"nginx.conf": nginxConf},
+	}}
+
+	for _, mode := range []types.OutputMode{types.OutputModeUniversal, types.OutputModeSeparate} {
+		t.Run(string(mode), func(t *testing.T) {
+			res, err := New(Options{ChartName: "myapp", Mode: mode, ExternalizeConfigMaps: true}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{cm})
+			if err != nil {
+				t.Fatalf("GenerateFromObjects: %v", err)
+			}
+			if strings.Contains(res.Charts[0].ValuesYAML, "listen") {
+				t.Errorf("the ConfigMap data should not be in values.yaml:\n%s", res.Charts[0].ValuesYAML)
+			}
+			dir := t.TempDir()
+			if err := WriteCharts(res.Charts, dir); err != nil {
+				t.Fatal(err)
+			}
+			chartDir := filepath.Join(dir, res.Charts[0].Name)
+			data, err := os.ReadFile(filepath.Join(chartDir, "files", "web", "nginx.conf"))
+			if err != nil || string(data) != nginxConf {
+				t.Fatalf("files/web/nginx.conf = %q, %v", data, err)
+			}
+
+			if mode != types.OutputModeUniversal {
+				return
+			}
+			rendered, err := helm.RenderChart(chartDir, helm.RenderOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest := rendered.Manifests["templates/web-configmap-web.yaml"]
+			if !strings.Contains(manifest, "    listen {{ .Values.port }};") {
+				t.Errorf("expected the file content in the ConfigMap:\n%s", manifest)
+			}
+
+			// With _tpl the file is rendered as a template.
+			rendered, err = helm.RenderChart(chartDir, helm.RenderOptions{Values: map[string]interface{}{
+				"port": 8080,
+				"services": map[string]interface{}{"web": map[string]interface{}{"configMaps": map[string]interface{}{"web": map[string]interface{}{
+					"data": map[string]interface{}{"nginx.conf": map[string]interface{}{"_tpl": true}},
+				}}}},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if manifest := rendered.Manifests["templates/web-configmap-web.yaml"]; !strings.Contains(manifest, "    listen 8080;") {
+				t.Errorf("expected the file rendered with tpl:\n%s", manifest)
+			}
+		})
+	}
+}
+
+func TestTemplateEnv_Render(t *testing.T) {
+	objs := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "frontend", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "frontend"}},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "frontend"}},
+					"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{
+						"name":  "app",
+						"image": "frontend:1.0",
+						"env": []interface{}{
+							map[string]interface{}{"name": "API_URL", "value": "http://backend.default.svc.cluster.local:8080/api"},
+							map[string]interface{}{"name": "GREETING", "value": "{{ hello }}"},
+						},
+					}}},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "frontend-proxy", "namespace": "default", "labels": map[string]interface{}{"app.kubernetes.io/name": "frontend"}},
+			"data":       map[string]interface{}{"nginx.conf": "proxy_pass http://backend:8080;\n"},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": "backend", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"app": "backend"},
+				"ports":    []interface{}{map[string]interface{}{"port": int64(8080)}},
+			},
+		}},
+	}
+	res, err := New(Options{ChartName: "myapp", TemplateEnv: true}).GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	if !strings.Contains(res.Charts[0].ValuesYAML, "BACKEND_URL:") {
+		t.Errorf("expected services.frontend.env.BACKEND_URL in values.yaml:\n%s", res.Charts[0].ValuesYAML)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	chartDir := filepath.Join(dir, "myapp")
+	rendered, err := helm.RenderChart(chartDir, helm.RenderOptions{ReleaseName: "shop", Namespace: "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := rendered.Bundle()
+	for _, want := range []string{
+		"http://shop-myapp-backend.staging.svc:8080/api",
+		"proxy_pass http://shop-myapp-backend.staging.svc:8080;",
+		"name: shop-myapp-backend\n",
+		"{{ hello }}",
+	} {
+		if !strings.Contains(bundle, want) {
+			t.Errorf("expected %q in the rendered chart:\n%s", want, bundle)
+		}
+	}
+
+	// The DNS name can be pointed elsewhere.
+	rendered, err = helm.RenderChart(chartDir, helm.RenderOptions{Values: map[string]interface{}{
+		"services": map[string]interface{}{"frontend": map[string]interface{}{"env": map[string]interface{}{"BACKEND_URL": "backend.example.com"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle := rendered.Bundle(); !strings.Contains(bundle, "http://backend.example.com:8080/api") {
+		t.Errorf("expected the overridden DNS name:\n%s", bundle)
+	}
+}
+
+func TestPreserveSelectors(t *testing.T) {
+	labels := map[string]interface{}{"app": "web", "app.kubernetes.io/name": "web"}
+	obj := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": labels},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web", "app.kubernetes.io/name": "web", "tier": "front"}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx:1.25"}}},
+			},
+		},
+	}}
+	res, err := New(Options{ChartName: "myapp", PreserveSelectors: true}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{obj})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{ReleaseName: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deploy unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(rendered.Manifests["templates/web-deployment.yaml"]), &deploy.Object); err != nil {
+		t.Fatal(err)
+	}
+	selector, _, _ := unstructured.NestedStringMap(deploy.Object, "spec", "selector", "matchLabels")
+	if fmt.Sprint(selector) != fmt.Sprint(labels) {
+		t.Errorf("selector = %v; want the original %v", selector, labels)
+	}
+	podLabels, _, _ := unstructured.NestedStringMap(deploy.Object, "spec", "template", "metadata", "labels")
+	for k, v := range map[string]string{"app": "web", "app.kubernetes.io/name": "web", "tier": "front", "app.kubernetes.io/instance": "shop", "app.kubernetes.io/component": "web"} {
+		if podLabels[k] != v {
+			t.Errorf("pod label %s = %q; want %q", k, podLabels[k], v)
+		}
+	}
+
+	// Live objects keep their selectors without the option and report the
+	// labels the chart's helpers would change.
+	processed, err := New(Options{ChartName: "myapp"}).Process(context.Background(), []*types.ExtractedResource{
+		{Object: &obj, GVK: obj.GroupVersionKind(), Source: types.SourceCluster},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(processed.Resources[0].TemplateContent, "{{- with .selector }}") {
+		t.Errorf("expected the original selector in the template:\n%s", processed.Resources[0].TemplateContent)
+	}
+	if len(processed.SelectorConflicts) != 1 || processed.SelectorConflicts[0].Label != "app.kubernetes.io/name" || processed.SelectorConflicts[0].Value != "web" {
+		t.Errorf("SelectorConflicts = %+v", processed.SelectorConflicts)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	db := service("db")
+	db.Object["spec"].(map[string]interface{})["type"] = "LoadBalancer"
+	db.Object["spec"].(map[string]interface{})["ports"] = []interface{}{map[string]interface{}{"port": int64(5432)}}
+	objs := []unstructured.Unstructured{deployment("web"), service("web"), deployment("db"), db,
+		{Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "labels": map[string]interface{}{"app": "web"}},
+			"spec": map[string]interface{}{
+				"tls": []interface{}{map[string]interface{}{"hosts": []interface{}{"shop.example.com"}}},
+				"rules": []interface{}{map[string]interface{}{
+					"host": "shop.example.com",
+					"http": map[string]interface{}{"paths": []interface{}{map[string]interface{}{
+						"path":    "/",
+						"backend": map[string]interface{}{"service": map[string]interface{}{"name": "web", "port": map[string]interface{}{"number": int64(80)}}},
+					}}},
+				}},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "db-credentials", "namespace": "default", "labels": map[string]interface{}{"app": "db"}},
+			"data":       map[string]interface{}{"password": "c2VjcmV0", "tls.key": "a2V5"},
+		}},
+	}
+
+	render := func(t *testing.T, mode types.OutputMode, values map[string]interface{}) string {
+		t.Helper()
+		res, err := New(Options{ChartName: "myapp", Mode: mode}).GenerateFromObjects(context.Background(), objs)
+		if err != nil {
+			t.Fatalf("GenerateFromObjects: %v", err)
+		}
+		dir := t.TempDir()
+		// The umbrella chart has no templates of its own, which WriteCharts
+		// rejects.
+		if err := generator.WriteChart(res.Charts[0], dir); err != nil {
+			t.Fatal(err)
+		}
+		rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{ReleaseName: "shop", Namespace: "prod", Values: values})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rendered.Notes
+	}
+
+	notes := render(t, types.OutputModeUniversal, nil)
+	for _, want := range []string{
+		"kubectl port-forward svc/shop-myapp-web 8080:80 -n prod",
+		"kubectl get svc shop-myapp-db -n prod -o jsonpath='{.status.loadBalancer.ingress[0].ip}'",
+		"echo http://$SERVICE_IP:5432",
+		"https://shop.example.com/",
+		"kubectl get secret shop-myapp-db-credentials -n prod -o jsonpath='{.data.password}' | base64 -d",
+		`-o jsonpath='{.data.tls\.key}'`,
+	} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("expected %q in NOTES.txt:\n%s", want, notes)
+		}
+	}
+	// Disabled services are left out.
+	notes = render(t, types.OutputModeUniversal, map[string]interface{}{
+		"services": map[string]interface{}{"web": map[string]interface{}{"enabled": false}},
+	})
+	if strings.Contains(notes, "svc/shop-myapp-web") || strings.Contains(notes, "shop.example.com") {
+		t.Errorf("expected no instructions for the disabled service:\n%s", notes)
+	}
+
+	// Umbrella subcharts name Services after themselves; only the NOTES.txt
+	// of the umbrella chart is shown on install.
+	notes = render(t, types.OutputModeUmbrella, nil)
+	for _, want := range []string{"svc/shop-web-web 8080:80", "kubectl get svc shop-db-db"} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("expected %q in the umbrella NOTES.txt:\n%s", want, notes)
+		}
+	}
+}
+
+func TestValueOverrides(t *testing.T) {
+	overrides := map[string]interface{}{
+		"services": map[string]interface{}{"web": map[string]interface{}{"deployment": map[string]interface{}{"replicas": 1}}},
+		"extra":    "value",
+	}
+	res, err := New(Options{ChartName: "myapp", ValueOverrides: overrides}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{deployment("web")})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	values := res.Charts[0].ValuesYAML
+	for _, want := range []string{"replicas: 1\n", "extra: value\n", "image:"} {
+		if !strings.Contains(values, want) {
+			t.Errorf("expected %q in values.yaml:\n%s", want, values)
+		}
+	}
+	if strings.Contains(values, "replicas: 2") {
+		t.Errorf("expected the source replica count to be overridden:\n%s", values)
+	}
+
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{ReleaseName: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle := rendered.Bundle(); !strings.Contains(bundle, "replicas: 1\n") {
+		t.Errorf("expected the overridden replica count in the rendered chart:\n%s", bundle)
+	}
+}
+
+func TestKindPolicy(t *testing.T) {
+	role := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]interface{}{"name": "web-reader", "labels": map[string]interface{}{"app": "web"}},
+		"rules": []interface{}{map[string]interface{}{
+			"apiGroups": []interface{}{""},
+			"resources": []interface{}{"pods"},
+			"verbs":     []interface{}{"get", "list"},
+		}},
+	}}
+	objs := []unstructured.Unstructured{deployment("web"), role}
+
+	for name, opts := range map[string]Options{
+		"passthrough kinds": {ChartName: "myapp", PassthroughKinds: []string{"ClusterRole"}},
+		"template kinds":    {ChartName: "myapp", TemplateKinds: []string{"Deployment"}},
+	} {
+		res, err := New(opts).GenerateFromObjects(context.Background(), objs)
+		if err != nil {
+			t.Fatalf("GenerateFromObjects: %v", err)
+		}
+		dir := t.TempDir()
+		if err := WriteCharts(res.Charts, dir); err != nil {
+			t.Fatal(err)
+		}
+		rendered, err := helm.RenderChart(filepath.Join(dir, "myapp"), helm.RenderOptions{ReleaseName: "shop"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle := rendered.Bundle()
+		for _, want := range []string{"name: web-reader\n", "name: shop-myapp-web\n", "- list\n"} {
+			if !strings.Contains(bundle, want) {
+				t.Errorf("%s: expected %q in the rendered chart:\n%s", name, want, bundle)
+			}
+		}
+		if strings.Contains(bundle, "shop-myapp-web-reader") {
+			t.Errorf("%s: expected the ClusterRole to keep its name:\n%s", name, bundle)
+		}
+	}
+}
+
+func TestInjection(t *testing.T) {
+	svc := service("web")
+	svc.SetAnnotations(map[string]string{"team": "resource-owner"})
+	role := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "Role",
+		"metadata":   map[string]interface{}{"name": "web-reader", "namespace": "default", "labels": map[string]interface{}{"app": "web"}},
+		"rules":      []interface{}{},
+	}}
+	policy := &generator.InjectionPolicy{
+		Labels:       map[string]string{"team": "payments"},
+		Annotations:  map[string]string{"team": "payments", "backstage.io/kubernetes-id": "shop"},
+		ExcludeKinds: []string{"Role"},
+	}
+	res, err := New(Options{ChartName: "myapp", Injection: policy}).GenerateFromObjects(context.Background(), []unstructured.Unstructured{deployment("web"), svc, role})
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	dir := t.TempDir()
+	if err := WriteCharts(res.Charts, dir); err != nil {
+		t.Fatal(err)
+	}
+	chartDir := filepath.Join(dir, "myapp")
+
+	render := func(values map[string]interface{}) map[string]*unstructured.Unstructured {
+		t.Helper()
+		rendered, err := helm.RenderChart(chartDir, helm.RenderOptions{ReleaseName: "shop", Values: values})
+		if err != nil {
+			t.Fatal(err)
+		}
+		objects := make(map[string]*unstructured.Unstructured)
+		for _, doc := range strings.Split(rendered.Bundle(), "\n---\n") {
+			obj := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(doc), &obj.Object); err != nil || obj.Object == nil {
+				continue
+			}
+			objects[obj.GetKind()] = obj
+		}
+		return objects
+	}
+
+	objects := render(nil)
+	deploy, service, reader := objects["Deployment"], objects["Service"], objects["Role"]
+	if deploy == nil || service == nil || reader == nil {
+		t.Fatalf("expected a Deployment, a Service and a Role, got %v", objects)
+	}
+	if deploy.GetLabels()["team"] != "payments" || deploy.GetAnnotations()["backstage.io/kubernetes-id"] != "shop" {
+		t.Errorf("expected the injected metadata on the Deployment: %v, %v", deploy.GetLabels(), deploy.GetAnnotations())
+	}
+	podLabels, _, _ := unstructured.NestedStringMap(deploy.Object, "spec", "template", "metadata", "labels")
+	if podLabels["team"] != "payments" {
+		t.Errorf("expected the injected label on the pods: %v", podLabels)
+	}
+	if service.GetAnnotations()["team"] != "resource-owner" || service.GetAnnotations()["backstage.io/kubernetes-id"] != "shop" {
+		t.Errorf("expected the Service annotations to win over the injected ones: %v", service.GetAnnotations())
+	}
+	if _, ok := reader.GetLabels()["team"]; ok || len(reader.GetAnnotations()) > 0 {
+		t.Errorf("expected the excluded Role to be left alone: %v, %v", reader.GetLabels(), reader.GetAnnotations())
+	}
+
+	// The injected labels can be overridden per release.
+	objects = render(map[string]interface{}{"commonLabels": map[string]interface{}{"team": "checkout"}})
+	if got := objects["Deployment"].GetLabels()["team"]; got != "checkout" {
+		t.Errorf("expected the overridden label, got %q", got)
+	}
+}
+
+func TestParseRename(t *testing.T) {
+	from, to, err := ParseRename(" api-prod = api ")
+	if err != nil || from != "api-prod" || to != "api" {
+		t.Errorf("ParseRename = %q, %q, %v", from, to, err)
+	}
+	for _, s := range []string{"api-prod", "=api", "api-prod=", "api-prod=API_Prod"} {
+		if _, _, err := ParseRename(s); err == nil {
+			t.Errorf("ParseRename(%q): expected an error", s)
+		}
+	}
+}
+
+func TestRenames(t *testing.T) {
+	objs := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "api-prod", "namespace": "default", "labels": map[string]interface{}{"app": "api-prod"}},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "api-prod"}},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "api-prod"}},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{"name": "api", "image": "api:1.0"}},
+						"volumes": []interface{}{map[string]interface{}{
+							"name":      "config",
+							"configMap": map[string]interface{}{"name": "api-prod-config"},
+						}},
+					},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "api-prod-config", "namespace": "default"},
+			"data":       map[string]interface{}{"api-prod": "key, not renamed"},
+		}},
+	}
+	g := New(Options{ChartName: "myapp", Renames: map[string]string{"api-prod": "api", "api-prod-config": "api-config", "web-prod": "web"}})
+	res, err := g.GenerateFromObjects(context.Background(), objs)
+	if err != nil {
+		t.Fatalf("GenerateFromObjects: %v", err)
+	}
+	chart := res.Charts[0]
+	for path := range chart.Templates {
+		if strings.Contains(path, "prod") {
+			t.Errorf("template %s still carries the original name", path)
+		}
+	}
+	if strings.Contains(chart.ValuesYAML, "apiProd") || !strings.Contains(chart.ValuesYAML, "apiConfig:") {
+		t.Errorf("expected renamed values keys:\n%s", chart.ValuesYAML)
+	}
+	if !strings.Contains(chart.ValuesYAML, "api-prod: key, not renamed") {
+		t.Errorf("map keys should not be renamed:\n%s", chart.ValuesYAML)
+	}
+	// References follow the renamed resources.
+	found := false
+	for _, rel := range res.Graph.Relationships {
+		found = found || (rel.From.Name == "api" && rel.To.GVK.Kind == "ConfigMap" && rel.To.Name == "api-config")
+	}
+	if !found {
+		t.Errorf("expected the Deployment to reference the renamed ConfigMap: %+v", res.Graph.Relationships)
+	}
+
+	processed, err := g.Process(context.Background(), []*types.ExtractedResource{{Object: &objs[1], GVK: objs[1].GroupVersionKind()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(processed.Renamed) != 1 || processed.Renamed[0].From != "api-prod-config" || processed.Renamed[0].Resource.Name != "api-config" {
+		t.Errorf("Renamed = %+v", processed.Renamed)
+	}
+	if strings.Join(processed.UnusedRenames, ",") != "api-prod,web-prod" {
+		t.Errorf("UnusedRenames = %v", processed.UnusedRenames)
+	}
+	if objs[1].GetName() != "api-prod-config" {
+		t.Error("input object was modified")
+	}
+}
+
+func TestRenames_OtherStringsKept(t *testing.T) {
+	deploy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "api-prod"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{
+						"name":  "api-prod",
+						"args":  []interface{}{"api-prod"},
+						"env":   []interface{}{map[string]interface{}{"name": "SERVICE", "value": "api-prod"}},
+						"image": "api:1.0",
+					}},
+				},
+			},
+		},
+	}}
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "api-config"},
+		"data":       map[string]interface{}{"upstream": "api-prod"},
+	}}
+	ing := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "Ingress",
+		"metadata":   map[string]interface{}{"name": "api"},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{
+				"http": map[string]interface{}{"paths": []interface{}{map[string]interface{}{
+					"backend": map[string]interface{}{"service": map[string]interface{}{"name": "api-prod"}},
+				}}},
+			}},
+		},
+	}}
+	var resources []*types.ExtractedResource
+	for _, obj := range []*unstructured.Unstructured{deploy, cm, ing} {
+		resources = append(resources, &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()})
+	}
+
+	out, renamed, _ := renameResources(resources, map[string]string{"api-prod": "api", "Deployment": "StatefulSet"})
+	if len(renamed) != 2 {
+		t.Errorf("expected the Deployment and the Ingress to be renamed, got %+v", renamed)
+	}
+	d := out[0].Object
+	if d.GetName() != "api" || d.GetKind() != "Deployment" {
+		t.Errorf("expected only the name of the Deployment to change: %s %s", d.GetKind(), d.GetName())
+	}
+	container, _, _ := unstructured.NestedSlice(d.Object, "spec", "template", "spec", "containers")
+	c := container[0].(map[string]interface{})
+	if c["name"] != "api-prod" || c["args"].([]interface{})[0] != "api-prod" || c["env"].([]interface{})[0].(map[string]interface{})["value"] != "api-prod" {
+		t.Errorf("container name, args and env values should be left alone: %v", c)
+	}
+	if v, _, _ := unstructured.NestedString(out[1].Object.Object, "data", "upstream"); v != "api-prod" {
+		t.Errorf("ConfigMap data should be left alone, got %q", v)
+	}
+	if out[1] != resources[1] {
+		t.Error("expected the untouched ConfigMap to be passed through")
+	}
+	rules, _, _ := unstructured.NestedSlice(out[2].Object.Object, "spec", "rules")
+	backend := rules[0].(map[string]interface{})["http"].(map[string]interface{})["paths"].([]interface{})[0].(map[string]interface{})["backend"]
+	if name, _, _ := unstructured.NestedString(backend.(map[string]interface{}), "service", "name"); name != "api" {
+		t.Errorf("expected the Ingress backend to follow the renamed Service, got %q", name)
+	}
+}
+
+// ── Determinism ───────────────────────────────────────────────────────────────
+
+// writtenFiles writes charts to a fresh directory and returns the files by
+// path relative to it.
+func writtenFiles(t *testing.T, charts []*types.GeneratedChart) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, chart := range charts {
+		if err := generator.WriteChart(chart, dir); err != nil {
+			t.Fatalf("WriteChart %s: %v", chart.Name, err)
+		}
+	}
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	resources, _, err := Extract(context.Background(), types.SourceFile, extractor.Options{Paths: []string{
+		"../../examples/05-full-stack",
+		"../../examples/08-umbrella-mode",
+		"../../examples/11-monitoring-stack/input",
+	}})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	reversed := make([]*types.ExtractedResource, len(resources))
+	for i, r := range resources {
+		reversed[len(resources)-1-i] = r
+	}
+
+	for _, mode := range []types.OutputMode{types.OutputModeUniversal, types.OutputModeSeparate, types.OutputModeLibrary, types.OutputModeUmbrella} {
+		t.Run(string(mode), func(t *testing.T) {
+			opts := Options{ChartName: "myapp", Mode: mode, IncludeSchema: true, IncludeTests: true, ValuesDocs: true, DedupValues: true}
+
+			first, err := New(opts).GenerateFromResources(context.Background(), resources)
+			if err != nil {
+				t.Fatalf("first run: %v", err)
+			}
+			want := writtenFiles(t, first.Charts)
+
+			// Repeat runs, alternating input order: output must be byte-identical.
+			for run := 0; run < 5; run++ {
+				input := resources
+				if run%2 == 0 {
+					input = reversed
+				}
+				res, err := New(opts).GenerateFromResources(context.Background(), input)
+				if err != nil {
+					t.Fatalf("run %d: %v", run, err)
+				}
+				got := writtenFiles(t, res.Charts)
+				if len(got) != len(want) {
+					t.Fatalf("run %d wrote %d files, first run %d", run, len(got), len(want))
+				}
+				for path, content := range want {
+					if got[path] != content {
+						t.Fatalf("run %d: %s differs from the first run:\n--- first\n%s\n--- run %d\n%s", run, path, content, run, got[path])
+					}
+				}
+			}
+		})
+	}
+}
+
+func equalObjects(a, b *unstructured.Unstructured) bool {
+	ja, _ := a.MarshalJSON()
+	jb, _ := b.MarshalJSON()
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,111 @@
+package dhg
+
+import (
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// Summary is a machine-readable account of a generation, for CI pipelines
+// to assert on (dhg generate --summary-json).
+type Summary struct {
+	// Output is the directory the charts were written to; empty for a dry run.
+	Output string `json:"output"`
+
+	// DryRun is set when nothing was written.
+	DryRun bool `json:"dryRun"`
+
+	// DurationMs is the duration of the generation in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+
+	// Resources is the number of resources in the resource graph.
+	Resources int `json:"resources"`
+
+	Charts   []ChartSummary `json:"charts"`
+	Patterns PatternSummary `json:"patterns"`
+
+	// Warnings are the warnings logged during the generation.
+	Warnings []string `json:"warnings"`
+}
+
+// ChartSummary describes one generated chart.
+type ChartSummary struct {
+	Name string `json:"name"`
+
+	// Templates is the number of templates, without _helpers.tpl and
+	// NOTES.txt.
+	Templates int `json:"templates"`
+
+	// ValuesKeys is the number of leaf keys in values.yaml.
+	ValuesKeys int `json:"valuesKeys"`
+
+	// Files is the number of files outside templates/ (large ConfigMap data
+	// and similar).
+	Files int `json:"files"`
+}
+
+// PatternSummary lists the architecture patterns detected in the resource
+// graph.
+type PatternSummary struct {
+	Detected            []string `json:"detected"`
+	Primary             string   `json:"primary"`
+	RecommendedStrategy string   `json:"recommendedStrategy"`
+	Confidence          int      `json:"confidence"`
+}
+
+// Summarize describes the charts generated from graph. Output, DryRun,
+// DurationMs and Warnings are left for the caller to fill in.
+func Summarize(charts []*types.GeneratedChart, graph *types.ResourceGraph) *Summary {
+	summary := &Summary{
+		Charts:   make([]ChartSummary, 0, len(charts)),
+		Warnings: []string{},
+		Patterns: PatternSummary{Detected: []string{}},
+	}
+	for _, chart := range charts {
+		summary.Charts = append(summary.Charts, ChartSummary{
+			Name:       chart.Name,
+			Templates:  len(chart.Templates),
+			ValuesKeys: countValuesKeys(chart.ValuesYAML),
+			Files:      len(chart.ExternalFiles),
+		})
+	}
+	if graph == nil {
+		return summary
+	}
+	summary.Resources = len(graph.Resources)
+
+	analysis := pattern.DefaultAnalyzer().Analyze(graph)
+	for _, p := range analysis.DetectedPatterns {
+		summary.Patterns.Detected = append(summary.Patterns.Detected, string(p))
+	}
+	sort.Strings(summary.Patterns.Detected)
+	summary.Patterns.Primary = string(analysis.PrimaryPattern)
+	summary.Patterns.RecommendedStrategy = string(analysis.RecommendedStrategy)
+	summary.Patterns.Confidence = analysis.Confidence
+	return summary
+}
+
+// countValuesKeys returns the number of leaf keys in a values.yaml document;
+// empty maps count as leaves.
+func countValuesKeys(valuesYAML string) int {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(valuesYAML), &values); err != nil {
+		return 0
+	}
+	return countLeaves(values)
+}
+
+func countLeaves(m map[string]interface{}) int {
+	n := 0
+	for _, v := range m {
+		if child, ok := v.(map[string]interface{}); ok && len(child) > 0 {
+			n += countLeaves(child)
+			continue
+		}
+		n++
+	}
+	return n
+}
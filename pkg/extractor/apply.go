@@ -0,0 +1,186 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// errNotFound is returned by getObject for objects that do not exist.
+var errNotFound = errors.New("not found")
+
+// NotServedError reports an object of a kind the cluster does not serve,
+// such as a custom resource whose CRD is not installed.
+type NotServedError struct {
+	APIVersion string
+	Kind       string
+}
+
+func (e *NotServedError) Error() string {
+	return fmt.Sprintf("the cluster does not serve %s %s", e.APIVersion, e.Kind)
+}
+
+// AppliedObject is the result of a server-side dry-run apply of an object.
+type AppliedObject struct {
+	// Desired is the object that was applied, with the namespace it was
+	// applied to.
+	Desired *unstructured.Unstructured
+
+	// Live is the object in the cluster, nil when it does not exist.
+	Live *unstructured.Unstructured
+
+	// Applied is the object as the apply would leave it, with the defaults
+	// and admission changes of the API server.
+	Applied *unstructured.Unstructured
+
+	// Err reports why the object could not be applied.
+	Err error
+}
+
+// DryRunApply server-side applies every object with dryRun=All, as
+// "kubectl diff" does, and returns each with its live state. Nothing is
+// changed in the cluster. Namespaced objects without a namespace are applied
+// to namespace. Failures of individual objects, such as kinds the cluster
+// does not serve or objects the API server rejects, are reported in their
+// AppliedObject.
+func (e *ClusterExtractor) DryRunApply(ctx context.Context, opts Options, objs []*unstructured.Unstructured, namespace, fieldManager string) ([]AppliedObject, error) {
+	client, err := e.getClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cluster client: %w", err)
+	}
+
+	resources := make(map[string][]apiResource)
+	results := make([]AppliedObject, 0, len(objs))
+	for _, obj := range objs {
+		results = append(results, client.dryRunApply(ctx, resources, obj, namespace, fieldManager))
+	}
+	return results, nil
+}
+
+// dryRunApply applies obj with dryRun=All. resources caches the resources
+// of the group versions looked up.
+func (c *clusterClient) dryRunApply(ctx context.Context, resources map[string][]apiResource, obj *unstructured.Unstructured, namespace, fieldManager string) AppliedObject {
+	result := AppliedObject{Desired: obj}
+	gv := obj.GetAPIVersion()
+	if _, ok := resources[gv]; !ok {
+		served, err := c.groupVersionResources(ctx, gv)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		resources[gv] = served
+	}
+	var ar *apiResource
+	for i, r := range resources[gv] {
+		if r.Kind == obj.GetKind() {
+			ar = &resources[gv][i]
+			break
+		}
+	}
+	if ar == nil {
+		result.Err = &NotServedError{APIVersion: gv, Kind: obj.GetKind()}
+		return result
+	}
+
+	if ar.Namespaced && obj.GetNamespace() == "" {
+		result.Desired = obj.DeepCopy()
+		result.Desired.SetNamespace(namespace)
+	}
+	path := buildListPath(*ar, result.Desired.GetNamespace()) + "/" + url.PathEscape(obj.GetName())
+
+	live, err := c.getObject(ctx, path)
+	if err != nil && !errors.Is(err, errNotFound) {
+		result.Err = err
+		return result
+	}
+	result.Live = live
+
+	body, err := json.Marshal(result.Desired.Object)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	query := url.Values{"dryRun": {"All"}, "fieldManager": {fieldManager}, "force": {"true"}}
+	resp, status, err := c.request(ctx, http.MethodPatch, path+"?"+query.Encode(), "application/apply-patch+yaml", body)
+	switch {
+	case err != nil:
+		result.Err = err
+	case status < 200 || status >= 300:
+		result.Err = errors.New(apiErrorMessage(resp))
+	default:
+		result.Applied = &unstructured.Unstructured{}
+		if err := json.Unmarshal(resp, &result.Applied.Object); err != nil {
+			result.Applied, result.Err = nil, fmt.Errorf("cannot parse apply response for %s: %w", path, err)
+		}
+	}
+	return result
+}
+
+// groupVersionResources returns the resources of the group version gv
+// (e.g. "v1" or "apps/v1"), including those that cannot be listed, and none
+// for a group version the cluster does not serve.
+func (c *clusterClient) groupVersionResources(ctx context.Context, gv string) ([]apiResource, error) {
+	path, group, version := "/api/"+gv, "", gv
+	if g, v, ok := strings.Cut(gv, "/"); ok {
+		path, group, version = "/apis/"+gv, g, v
+	}
+	body, status, err := c.request(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("HTTP %d from %s: %s", status, path, apiErrorMessage(body))
+	}
+	var list k8sResourceList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse %s response: %w", path, err)
+	}
+	var resources []apiResource
+	for _, r := range list.Resources {
+		if strings.Contains(r.Name, "/") {
+			continue
+		}
+		resources = append(resources, apiResource{Group: group, Version: version, Kind: r.Kind, Name: r.Name, Namespaced: r.Namespaced})
+	}
+	return resources, nil
+}
+
+// getObject returns the object at path, or errNotFound.
+func (c *clusterClient) getObject(ctx context.Context, path string) (*unstructured.Unstructured, error) {
+	body, status, err := c.request(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("HTTP %d from %s: %s", status, path, apiErrorMessage(body))
+	}
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(body, &obj.Object); err != nil {
+		return nil, fmt.Errorf("cannot parse response from %s: %w", path, err)
+	}
+	return obj, nil
+}
+
+// apiErrorMessage returns the message of a Kubernetes Status response, or
+// the start of the body.
+func apiErrorMessage(body []byte) string {
+	var status struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &status); err == nil && status.Message != "" {
+		return status.Message
+	}
+	return truncateStr(string(body), 200)
+}
@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// handleDryRunApply serves GET and dry-run apply PATCH requests for path on
+// f: live is the object returned by GET (nil for 404), and the apply returns
+// the applied object with "defaulted: true" added to its data, as an API
+// server adding defaults would.
+func (f *fakeKubeAPIServer) handleDryRunApply(t *testing.T, path string, live map[string]interface{}) {
+	f.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			if live == nil {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"kind":"Status","message":"not found"}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(live)
+		case http.MethodPatch:
+			if got := r.Header.Get("Content-Type"); got != "application/apply-patch+yaml" {
+				t.Errorf("apply Content-Type = %q", got)
+			}
+			if q := r.URL.Query(); q.Get("dryRun") != "All" || q.Get("fieldManager") != "dhg" {
+				t.Errorf("apply must be a dry run with field manager dhg, got query %q", r.URL.RawQuery)
+			}
+			var obj map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+				t.Errorf("apply body: %v", err)
+			}
+			_ = unstructured.SetNestedField(obj, "true", "data", "defaulted")
+			_ = json.NewEncoder(w).Encode(obj)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+		}
+	})
+}
+
+func TestDryRunApply(t *testing.T) {
+	f := newFakeKubeAPIServer()
+	defer f.close()
+	f.setResponse("/api/v1", coreResourceList(
+		k8sResourceEntry{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"get", "patch"}},
+	))
+	live := configMapItem("app", "prod")
+	f.handleDryRunApply(t, "/api/v1/namespaces/prod/configmaps/app", live)
+	f.handleDryRunApply(t, "/api/v1/namespaces/prod/configmaps/new", nil)
+
+	configMap := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name},
+			"data":       map[string]interface{}{"key": "value"},
+		}}
+	}
+	cert := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "tls"},
+	}}
+
+	e := NewClusterExtractorWithConfig(ClusterExtractorConfig{Kubeconfig: writeTestKubeconfig(t, f.server.URL)})
+	objs := []*unstructured.Unstructured{configMap("app"), configMap("new"), cert}
+	results, err := e.DryRunApply(context.Background(), Options{}, objs, "prod", "dhg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	app := results[0]
+	if app.Err != nil || app.Live == nil || app.Live.GetNamespace() != "prod" {
+		t.Fatalf("app: unexpected result %+v", app)
+	}
+	if got, _, _ := unstructured.NestedString(app.Applied.Object, "data", "defaulted"); got != "true" || app.Applied.GetNamespace() != "prod" {
+		t.Errorf("app: applied object should come from the server in namespace prod, got %v", app.Applied.Object)
+	}
+	if app.Desired.GetNamespace() != "prod" || objs[0].GetNamespace() != "" {
+		t.Error("the applied object should be a copy with the namespace set")
+	}
+
+	if created := results[1]; created.Err != nil || created.Live != nil || created.Applied == nil {
+		t.Errorf("new: expected no live object, got %+v", created)
+	}
+
+	var notServed *NotServedError
+	if err := results[2].Err; !errors.As(err, &notServed) || notServed.Kind != "Certificate" {
+		t.Errorf("Certificate: expected an unserved group version, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -133,6 +134,11 @@ type ClusterExtractorConfig struct {
 	// IncludeSecrets controls whether Secret resources are extracted.
 	IncludeSecrets bool
 
+	// IncludeOwned controls whether objects managed by a controller (e.g. Pods
+	// of a ReplicaSet, ReplicaSets of a Deployment) are extracted. They are
+	// skipped by default since their owner already describes them.
+	IncludeOwned bool
+
 	// SecretStrategy defines how secrets are handled: "mask", "include", or "external-secret".
 	SecretStrategy string
 
@@ -355,6 +361,9 @@ func (e *ClusterExtractor) Validate(ctx context.Context, opts Options) error {
 	if err := e.config.Validate(); err != nil {
 		return fmt.Errorf("invalid cluster config: %w", err)
 	}
+	if err := validateOmitFields(opts); err != nil {
+		return err
+	}
 
 	client, err := e.getClient(opts)
 	if err != nil {
@@ -392,7 +401,7 @@ func (e *ClusterExtractor) Extract(ctx context.Context, opts Options) (<-chan *t
 			return
 		}
 
-		namespace := e.effectiveNamespace(opts)
+		namespaces := e.effectiveNamespaces(opts)
 
 		for _, ar := range apiResources {
 			if ctx.Err() != nil {
@@ -404,31 +413,58 @@ func (e *ClusterExtractor) Extract(ctx context.Context, opts Options) (<-chan *t
 				continue
 			}
 
-			err := client.listResources(ctx, ar, namespace, e.effectiveSelector(opts), e.config.Pagination.Limit, func(obj *unstructured.Unstructured) {
-				// Apply namespace exclusion filter.
-				if e.isExcludedNamespace(obj.GetNamespace()) {
-					return
-				}
-
-				// Apply secret strategy.
-				if obj.GetKind() == "Secret" {
-					e.applySecretStrategy(obj)
-				}
+			// Skip runtime-only kinds unless explicitly requested.
+			if len(opts.IncludeKinds) == 0 && runtimeKinds[ar.Kind] {
+				continue
+			}
+			if !matchesKindFilters(ar.Kind, opts) {
+				continue
+			}
 
-				resource := &types.ExtractedResource{
-					Object:     obj,
-					Source:     types.SourceCluster,
-					SourcePath: client.server,
-					GVK:        obj.GroupVersionKind(),
-				}
+			// Cluster-scoped resources are listed once regardless of namespaces.
+			listNamespaces := namespaces
+			if !ar.Namespaced {
+				listNamespaces = []string{""}
+			}
 
-				select {
-				case resources <- resource:
-				case <-ctx.Done():
+			for _, namespace := range listNamespaces {
+				err := client.listResources(ctx, ar, namespace, e.effectiveSelector(opts), e.config.Pagination.Limit, func(obj *unstructured.Unstructured) {
+					// Apply namespace exclusion filter.
+					if e.isExcludedNamespace(obj.GetNamespace()) {
+						return
+					}
+
+					if !e.config.IncludeOwned && isControllerOwned(obj) {
+						opts.skip(obj, SkipControllerOwned)
+						return
+					}
+					if isSystemManaged(obj) {
+						opts.skip(obj, SkipSystemManaged)
+						return
+					}
+					stripRuntimeFields(obj)
+					omitFields(obj, opts)
+
+					// Apply secret strategy.
+					if obj.GetKind() == "Secret" {
+						e.applySecretStrategy(obj)
+					}
+
+					resource := &types.ExtractedResource{
+						Object:     obj,
+						Source:     types.SourceCluster,
+						SourcePath: client.server,
+						GVK:        obj.GroupVersionKind(),
+					}
+
+					select {
+					case resources <- resource:
+					case <-ctx.Done():
+					}
+				})
+				if err != nil {
+					errors <- fmt.Errorf("error listing %s: %w", ar.Kind, err)
 				}
-			})
-			if err != nil {
-				errors <- fmt.Errorf("error listing %s: %w", ar.Kind, err)
 			}
 		}
 	}()
@@ -470,6 +506,18 @@ func (e *ClusterExtractor) effectiveNamespace(opts Options) string {
 	return opts.Namespace
 }
 
+// effectiveNamespaces returns the namespaces to list namespaced resources
+// from; a single empty string means all namespaces.
+func (e *ClusterExtractor) effectiveNamespaces(opts Options) []string {
+	if ns := e.effectiveNamespace(opts); ns != "" {
+		return []string{ns}
+	}
+	if len(opts.Namespaces) > 0 {
+		return opts.Namespaces
+	}
+	return []string{""}
+}
+
 func (e *ClusterExtractor) effectiveSelector(opts Options) string {
 	if e.config.Selector != "" {
 		return e.config.Selector
@@ -502,6 +550,70 @@ func (e *ClusterExtractor) applySecretStrategy(obj *unstructured.Unstructured) {
 	}
 }
 
+// runtimeKinds are kinds that only describe cluster runtime state and are
+// never part of an application's desired configuration.
+var runtimeKinds = map[string]bool{
+	"Event":           true,
+	"Endpoints":       true,
+	"EndpointSlice":   true,
+	"Lease":           true,
+	"ComponentStatus": true,
+	"Node":            true,
+}
+
+// isControllerOwned reports whether obj is managed by a controller owner.
+func isControllerOwned(obj *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			return true
+		}
+	}
+	return false
+}
+
+// isSystemManaged reports whether obj is created automatically by Kubernetes
+// in every namespace (or cluster) and should not be part of a chart.
+func isSystemManaged(obj *unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "ConfigMap":
+		return obj.GetName() == "kube-root-ca.crt"
+	case "ServiceAccount":
+		return obj.GetName() == "default"
+	case "Service":
+		return obj.GetName() == "kubernetes" && obj.GetNamespace() == "default"
+	}
+	return false
+}
+
+// stripRuntimeFields removes server-populated fields (status, managed fields,
+// allocated cluster IPs, etc.) so a live object looks like an applied manifest.
+func stripRuntimeFields(obj *unstructured.Unstructured) {
+	delete(obj.Object, "status")
+
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "selfLink")
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		delete(annotations, "deployment.kubernetes.io/revision")
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	if obj.GetKind() == "Service" {
+		if ip, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); ip != "None" {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+	}
+}
+
 func maskFields(obj *unstructured.Unstructured, placeholder string) {
 	if data, ok, _ := unstructuredNestedMap(obj.Object, "data"); ok {
 		masked := make(map[string]interface{}, len(data))
@@ -831,13 +943,37 @@ func (c *clusterClient) listResources(ctx context.Context, ar apiResource, names
 }
 
 func (c *clusterClient) doGet(ctx context.Context, path string) ([]byte, error) {
-	url := c.server + path
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return c.do(ctx, http.MethodGet, path, "", nil)
+}
+
+// do sends a request with body and returns the response body, or an error
+// for a status other than 2xx.
+func (c *clusterClient) do(ctx context.Context, method, path, contentType string, body []byte) ([]byte, error) {
+	resp, status, err := c.request(ctx, method, path, contentType, body)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create request for %s: %w", path, err)
+		return nil, err
 	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("HTTP %d from %s: %s", status, path, apiErrorMessage(resp))
+	}
+	return resp, nil
+}
 
+// request sends a request to the API server and returns the response body
+// and status code.
+func (c *clusterClient) request(ctx context.Context, method, path, contentType string, body []byte) ([]byte, int, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, reader)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot create request for %s: %w", path, err)
+	}
 	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	for k, vals := range c.headers {
 		for _, v := range vals {
 			req.Header.Set(k, v)
@@ -846,20 +982,15 @@ func (c *clusterClient) doGet(ctx context.Context, path string) ([]byte, error)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+		return nil, 0, fmt.Errorf("request to %s failed: %w", path, err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read response from %s: %w", path, err)
+		return nil, 0, fmt.Errorf("cannot read response from %s: %w", path, err)
 	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("HTTP %d from %s: %s", resp.StatusCode, path, truncateStr(string(body), 200))
-	}
-
-	return body, nil
+	return data, resp.StatusCode, nil
 }
 
 // ── K8s API response types ─────────────────────────────────────────────────
@@ -1252,3 +1252,169 @@ func TestClusterClient_DoGet_HTTPError(t *testing.T) {
 		t.Errorf("error = %q; want to contain 'HTTP 403'", err.Error())
 	}
 }
+
+// ── Live-cluster filtering ─────────────────────────────────────────────────
+
+// extractAll drains the extractor channels.
+func extractAll(t *testing.T, ce *ClusterExtractor, opts Options) []*types.ExtractedResource {
+	t.Helper()
+	resCh, errCh := ce.Extract(context.Background(), opts)
+
+	var resources []*types.ExtractedResource
+	for r := range resCh {
+		resources = append(resources, r)
+	}
+	for err := range errCh {
+		t.Errorf("unexpected error: %v", err)
+	}
+	return resources
+}
+
+func TestClusterExtractor_Extract_MultipleNamespaces(t *testing.T) {
+	fake := newFakeKubeAPIServer()
+	defer fake.close()
+
+	fake.setResponse("/api/v1", coreResourceList(
+		k8sResourceEntry{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}},
+		k8sResourceEntry{Name: "namespaces", Kind: "Namespace", Namespaced: false, Verbs: []string{"list"}},
+	))
+	fake.setResponse("/apis", emptyGroupList())
+	fake.setResponse("/api/v1/namespaces/prod/configmaps", itemList(configMapItem("cm-prod", "prod")))
+	fake.setResponse("/api/v1/namespaces/stage/configmaps", itemList(configMapItem("cm-stage", "stage")))
+	fake.setResponse("/api/v1/namespaces", itemList(map[string]interface{}{
+		"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]interface{}{"name": "prod"},
+	}))
+
+	ce := NewClusterExtractor()
+	ce.SetClient(fake.client())
+
+	resources := extractAll(t, ce, Options{Namespaces: []string{"prod", "stage"}})
+
+	names := make(map[string]bool)
+	for _, r := range resources {
+		names[r.Object.GetName()] = true
+	}
+	if len(resources) != 3 || !names["cm-prod"] || !names["cm-stage"] || !names["prod"] {
+		t.Errorf("got %v; want cm-prod, cm-stage and the prod namespace", names)
+	}
+}
+
+func TestClusterExtractor_Extract_KindFilters(t *testing.T) {
+	fake := newFakeKubeAPIServer()
+	defer fake.close()
+
+	fake.setResponse("/api/v1", coreResourceList(
+		k8sResourceEntry{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}},
+		k8sResourceEntry{Name: "events", Kind: "Event", Namespaced: true, Verbs: []string{"list"}},
+		k8sResourceEntry{Name: "services", Kind: "Service", Namespaced: true, Verbs: []string{"list"}},
+	))
+	fake.setResponse("/apis", emptyGroupList())
+	fake.setResponse("/api/v1/configmaps", itemList(configMapItem("cfg", "default")))
+	fake.setResponse("/api/v1/events", itemList(map[string]interface{}{
+		"apiVersion": "v1", "kind": "Event", "metadata": map[string]interface{}{"name": "ev", "namespace": "default"},
+	}))
+	fake.setResponse("/api/v1/services", itemList(map[string]interface{}{
+		"apiVersion": "v1", "kind": "Service", "metadata": map[string]interface{}{"name": "web", "namespace": "default"},
+	}))
+
+	ce := NewClusterExtractor()
+	ce.SetClient(fake.client())
+
+	kinds := func(opts Options) map[string]bool {
+		out := make(map[string]bool)
+		for _, r := range extractAll(t, ce, opts) {
+			out[r.GVK.Kind] = true
+		}
+		return out
+	}
+
+	if got := kinds(Options{}); got["Event"] || !got["ConfigMap"] || !got["Service"] {
+		t.Errorf("default extraction: got kinds %v; want ConfigMap and Service without Event", got)
+	}
+	if got := kinds(Options{ExcludeKinds: []string{"service"}}); got["Service"] || !got["ConfigMap"] {
+		t.Errorf("exclude service: got kinds %v", got)
+	}
+	if got := kinds(Options{IncludeKinds: []string{"Event"}}); len(got) != 1 || !got["Event"] {
+		t.Errorf("include event: got kinds %v; want only Event", got)
+	}
+}
+
+func TestClusterExtractor_Extract_SkipsOwnedAndSystemObjects(t *testing.T) {
+	fake := newFakeKubeAPIServer()
+	defer fake.close()
+
+	owned := configMapItem("owned", "default")
+	owned["metadata"].(map[string]interface{})["ownerReferences"] = []interface{}{
+		map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "rs", "uid": "1", "controller": true},
+	}
+
+	fake.setResponse("/api/v1", coreResourceList(
+		k8sResourceEntry{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}},
+	))
+	fake.setResponse("/apis", emptyGroupList())
+	fake.setResponse("/api/v1/configmaps", itemList(
+		configMapItem("app", "default"),
+		configMapItem("kube-root-ca.crt", "default"),
+		owned,
+	))
+
+	ce := NewClusterExtractor()
+	ce.SetClient(fake.client())
+
+	resources := extractAll(t, ce, Options{})
+	if len(resources) != 1 || resources[0].Object.GetName() != "app" {
+		t.Errorf("got %d resources; want only app", len(resources))
+	}
+
+	ce = NewClusterExtractorWithConfig(ClusterExtractorConfig{IncludeOwned: true})
+	ce.SetClient(fake.client())
+	if resources := extractAll(t, ce, Options{}); len(resources) != 2 {
+		t.Errorf("IncludeOwned: got %d resources; want 2", len(resources))
+	}
+}
+
+func TestStripRuntimeFields(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":              "web",
+			"uid":               "abc",
+			"resourceVersion":   "42",
+			"creationTimestamp": "2024-01-01T00:00:00Z",
+			"managedFields":     []interface{}{map[string]interface{}{"manager": "kubectl"}},
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				"team": "web",
+			},
+		},
+		"spec": map[string]interface{}{
+			"clusterIP":  "10.0.0.1",
+			"clusterIPs": []interface{}{"10.0.0.1"},
+		},
+		"status": map[string]interface{}{"loadBalancer": map[string]interface{}{}},
+	}}
+
+	stripRuntimeFields(obj)
+
+	if _, ok := obj.Object["status"]; ok {
+		t.Error("status should be removed")
+	}
+	if obj.GetUID() != "" || obj.GetResourceVersion() != "" || len(obj.GetManagedFields()) != 0 {
+		t.Errorf("runtime metadata should be removed: %v", obj.Object["metadata"])
+	}
+	if got := obj.GetAnnotations(); len(got) != 1 || got["team"] != "web" {
+		t.Errorf("annotations = %v; want only team", got)
+	}
+	if _, ok, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); ok {
+		t.Error("allocated clusterIP should be removed")
+	}
+
+	headless := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Service", "spec": map[string]interface{}{"clusterIP": "None"},
+	}}
+	stripRuntimeFields(headless)
+	if ip, _, _ := unstructured.NestedString(headless.Object, "spec", "clusterIP"); ip != "None" {
+		t.Errorf("headless clusterIP = %q; want None", ip)
+	}
+}
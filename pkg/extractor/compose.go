@@ -0,0 +1,752 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ComposeFileNames are the file names looked up when a compose source path
+// is a directory, in order of preference.
+var ComposeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// DefaultComposeVolumeSize is the storage request of PersistentVolumeClaims
+// generated for named compose volumes.
+const DefaultComposeVolumeSize = "1Gi"
+
+// composeDependsOnAnnotation records depends_on on the generated Deployment.
+const composeDependsOnAnnotation = "dhg.deckhouse.io/depends-on"
+
+// ComposeExtractor converts docker-compose files into synthetic Kubernetes
+// resources: a Deployment per service, a Service for services with ports and
+// a PersistentVolumeClaim per named volume.
+type ComposeExtractor struct{}
+
+// NewComposeExtractor creates a new compose extractor.
+func NewComposeExtractor() *ComposeExtractor {
+	return &ComposeExtractor{}
+}
+
+// Source returns the source type.
+func (e *ComposeExtractor) Source() types.Source {
+	return types.SourceCompose
+}
+
+// Validate checks that every path is a compose file or a directory with one.
+func (e *ComposeExtractor) Validate(ctx context.Context, opts Options) error {
+	if len(opts.Paths) == 0 {
+		return fmt.Errorf("at least one path is required")
+	}
+	if err := validateOmitFields(opts); err != nil {
+		return err
+	}
+	for _, path := range opts.Paths {
+		if _, err := composeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract converts the compose files into resources. Conversion warnings
+// (unsupported keys, bind mounts) are sent on the error channel.
+func (e *ComposeExtractor) Extract(ctx context.Context, opts Options) (<-chan *types.ExtractedResource, <-chan error) {
+	resources := make(chan *types.ExtractedResource, 100)
+	errors := make(chan error, 10)
+
+	go func() {
+		defer close(resources)
+		defer close(errors)
+
+		for _, path := range opts.Paths {
+			if err := ctx.Err(); err != nil {
+				errors <- err
+				return
+			}
+
+			file, err := composeFile(path)
+			if err != nil {
+				errors <- err
+				continue
+			}
+			objects, warnings, err := ConvertCompose(file)
+			if err != nil {
+				errors <- err
+				continue
+			}
+			for _, w := range warnings {
+				errors <- fmt.Errorf("%s: %s", file, w)
+			}
+
+			for _, obj := range objects {
+				if !matchesKindFilters(obj.GetKind(), opts) {
+					continue
+				}
+				omitFields(obj, opts)
+				select {
+				case resources <- &types.ExtractedResource{
+					Object:     obj,
+					Source:     types.SourceCompose,
+					SourcePath: file,
+					GVK:        obj.GroupVersionKind(),
+				}:
+				case <-ctx.Done():
+					errors <- ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+
+	return resources, errors
+}
+
+// composeFile resolves path to a compose file.
+func composeFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot access path %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+	for _, name := range ComposeFileNames {
+		p := filepath.Join(path, name)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no compose file (%s) in %s", strings.Join(ComposeFileNames, ", "), path)
+}
+
+// composeProject is the subset of the Compose specification dhg converts.
+// Keys that have short and long syntaxes are decoded as interface{}.
+type composeProject struct {
+	Services map[string]composeService `json:"services"`
+	Volumes  map[string]interface{}    `json:"volumes"`
+}
+
+type composeService struct {
+	Image       string        `json:"image"`
+	Build       interface{}   `json:"build"`
+	Command     interface{}   `json:"command"`
+	Entrypoint  interface{}   `json:"entrypoint"`
+	Environment interface{}   `json:"environment"`
+	EnvFile     interface{}   `json:"env_file"`
+	Ports       []interface{} `json:"ports"`
+	Expose      []interface{} `json:"expose"`
+	Volumes     []interface{} `json:"volumes"`
+	DependsOn   interface{}   `json:"depends_on"`
+	WorkingDir  string        `json:"working_dir"`
+	Healthcheck *struct {
+		Test        interface{} `json:"test"`
+		Interval    string      `json:"interval"`
+		Timeout     string      `json:"timeout"`
+		Retries     int64       `json:"retries"`
+		StartPeriod string      `json:"start_period"`
+		Disable     bool        `json:"disable"`
+	} `json:"healthcheck"`
+	Deploy *struct {
+		Replicas  *int64 `json:"replicas"`
+		Resources struct {
+			Limits       composeResources `json:"limits"`
+			Reservations composeResources `json:"reservations"`
+		} `json:"resources"`
+	} `json:"deploy"`
+}
+
+type composeResources struct {
+	CPUs   interface{} `json:"cpus"`
+	Memory string      `json:"memory"`
+}
+
+// ConvertCompose reads a compose file and returns the equivalent Kubernetes
+// objects, sorted by kind and name, plus warnings for parts that could not be
+// converted faithfully. ${VAR} and ${VAR:-default} are interpolated from the
+// environment.
+func ConvertCompose(file string) ([]*unstructured.Unstructured, []string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read %s: %w", file, err)
+	}
+	var project composeProject
+	if err := yaml.Unmarshal([]byte(interpolateCompose(string(data))), &project); err != nil {
+		return nil, nil, fmt.Errorf("cannot parse compose file %s: %w", file, err)
+	}
+	if len(project.Services) == 0 {
+		return nil, nil, fmt.Errorf("compose file %s defines no services", file)
+	}
+
+	c := &composeConverter{dir: filepath.Dir(file), project: &project, claims: make(map[string][]string)}
+	names := make([]string, 0, len(project.Services))
+	for name := range project.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var objects []*unstructured.Unstructured
+	for _, name := range names {
+		objs, err := c.convertService(name, project.Services[name])
+		if err != nil {
+			return nil, c.warnings, fmt.Errorf("%s: service %s: %w", file, name, err)
+		}
+		objects = append(objects, objs...)
+	}
+
+	claims := make([]string, 0, len(c.claims))
+	for name := range c.claims {
+		claims = append(claims, name)
+	}
+	sort.Strings(claims)
+	for _, name := range claims {
+		objects = append(objects, composePVC(name, c.claims[name]))
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].GetKind() != objects[j].GetKind() {
+			return objects[i].GetKind() < objects[j].GetKind()
+		}
+		return objects[i].GetName() < objects[j].GetName()
+	})
+	return objects, c.warnings, nil
+}
+
+type composeConverter struct {
+	dir      string
+	project  *composeProject
+	claims   map[string][]string // claim name -> services mounting it
+	warnings []string
+}
+
+func (c *composeConverter) warnf(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// composeName turns a compose service or volume name into a DNS-1123 label.
+func composeName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func (c *composeConverter) convertService(name string, svc composeService) ([]*unstructured.Unstructured, error) {
+	k8sName := composeName(name)
+	if svc.Image == "" {
+		if svc.Build == nil {
+			return nil, fmt.Errorf("image or build is required")
+		}
+		svc.Image = k8sName + ":latest"
+		c.warnf("service %s is built locally; using image %q, push it to a registry", name, svc.Image)
+	}
+
+	labels := map[string]interface{}{"app.kubernetes.io/name": k8sName}
+	container := map[string]interface{}{"name": k8sName, "image": svc.Image}
+
+	if cmd := composeStrings(svc.Entrypoint); len(cmd) > 0 {
+		container["command"] = toInterfaces(cmd)
+	}
+	if args := composeStrings(svc.Command); len(args) > 0 {
+		container["args"] = toInterfaces(args)
+	}
+	if svc.WorkingDir != "" {
+		container["workingDir"] = svc.WorkingDir
+	}
+
+	env, err := c.environment(svc)
+	if err != nil {
+		return nil, err
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+
+	ports, err := composePorts(svc)
+	if err != nil {
+		return nil, err
+	}
+	if len(ports) > 0 {
+		containerPorts := make([]interface{}, 0, len(ports))
+		for _, p := range ports {
+			containerPorts = append(containerPorts, map[string]interface{}{
+				"name": p.name(), "containerPort": p.target, "protocol": p.protocol,
+			})
+		}
+		container["ports"] = containerPorts
+	}
+
+	mounts, volumes := c.volumes(name, svc.Volumes)
+	if len(mounts) > 0 {
+		container["volumeMounts"] = mounts
+	}
+
+	if probe := c.probe(name, svc); probe != nil {
+		container["livenessProbe"] = probe
+	}
+
+	replicas := int64(1)
+	if svc.Deploy != nil {
+		if svc.Deploy.Replicas != nil {
+			replicas = *svc.Deploy.Replicas
+		}
+		if res := composeContainerResources(svc.Deploy.Resources.Limits, svc.Deploy.Resources.Reservations); res != nil {
+			container["resources"] = res
+		}
+	}
+
+	podSpec := map[string]interface{}{"containers": []interface{}{container}}
+	if len(volumes) > 0 {
+		podSpec["volumes"] = volumes
+	}
+
+	metadata := map[string]interface{}{"name": k8sName, "labels": copyLabels(labels)}
+	if deps := composeDependsOn(svc.DependsOn); len(deps) > 0 {
+		for i := range deps {
+			deps[i] = composeName(deps[i])
+		}
+		metadata["annotations"] = map[string]interface{}{composeDependsOnAnnotation: strings.Join(deps, ",")}
+	}
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"selector": map[string]interface{}{"matchLabels": copyLabels(labels)},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": copyLabels(labels)},
+				"spec":     podSpec,
+			},
+		},
+	}}
+	objects := []*unstructured.Unstructured{deployment}
+
+	if len(ports) > 0 {
+		servicePorts := make([]interface{}, 0, len(ports))
+		for _, p := range ports {
+			servicePorts = append(servicePorts, map[string]interface{}{
+				"name": p.name(), "port": p.target, "targetPort": p.target, "protocol": p.protocol,
+			})
+		}
+		objects = append(objects, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": k8sName, "labels": copyLabels(labels)},
+			"spec": map[string]interface{}{
+				"selector": copyLabels(labels),
+				"ports":    servicePorts,
+			},
+		}})
+	}
+	return objects, nil
+}
+
+func copyLabels(labels map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+func toInterfaces(ss []string) []interface{} {
+	out := make([]interface{}, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
+
+// composeStrings decodes a string or list value (command, entrypoint). A
+// string is split on whitespace, honouring simple quotes.
+func composeStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return splitShellWords(t)
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			out = append(out, fmt.Sprint(item))
+		}
+		return out
+	}
+	return nil
+}
+
+func splitShellWords(s string) []string {
+	var words []string
+	var cur strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words
+}
+
+// environment merges env_file entries and environment, the latter winning.
+func (c *composeConverter) environment(svc composeService) ([]interface{}, error) {
+	vars := make(map[string]string)
+	var order []string
+	set := func(k, v string) {
+		if _, ok := vars[k]; !ok {
+			order = append(order, k)
+		}
+		vars[k] = v
+	}
+
+	for _, f := range composeStrings(svc.EnvFile) {
+		path := f
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("env_file: %w", err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			k, v, _ := strings.Cut(line, "=")
+			set(strings.TrimSpace(k), strings.Trim(strings.TrimSpace(v), `"'`))
+		}
+	}
+
+	switch env := svc.Environment.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if env[k] == nil {
+				set(k, os.Getenv(k))
+				continue
+			}
+			set(k, fmt.Sprint(env[k]))
+		}
+	case []interface{}:
+		for _, item := range env {
+			k, v, ok := strings.Cut(fmt.Sprint(item), "=")
+			if !ok {
+				v = os.Getenv(k)
+			}
+			set(k, v)
+		}
+	}
+
+	out := make([]interface{}, 0, len(order))
+	for _, k := range order {
+		out = append(out, map[string]interface{}{"name": k, "value": vars[k]})
+	}
+	return out, nil
+}
+
+type composePort struct {
+	target   int64
+	protocol string
+}
+
+func (p composePort) name() string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(p.protocol), p.target)
+}
+
+// composePorts collects the container ports of ports and expose. Published
+// host ports are dropped: inside the cluster, services are reached on the
+// container port, as on a compose network.
+func composePorts(svc composeService) ([]composePort, error) {
+	var ports []composePort
+	seen := make(map[composePort]bool)
+	add := func(p composePort) {
+		if !seen[p] {
+			seen[p] = true
+			ports = append(ports, p)
+		}
+	}
+
+	for _, item := range append(append([]interface{}{}, svc.Ports...), svc.Expose...) {
+		switch p := item.(type) {
+		case map[string]interface{}:
+			target, err := strconv.ParseInt(fmt.Sprint(p["target"]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port target %v", p["target"])
+			}
+			protocol := "TCP"
+			if proto, ok := p["protocol"].(string); ok && proto != "" {
+				protocol = strings.ToUpper(proto)
+			}
+			add(composePort{target: target, protocol: protocol})
+		default:
+			spec := fmt.Sprint(p)
+			protocol := "TCP"
+			if s, proto, ok := strings.Cut(spec, "/"); ok {
+				spec, protocol = s, strings.ToUpper(proto)
+			}
+			parts := strings.Split(spec, ":")
+			container := parts[len(parts)-1]
+			// A range (8000-8002) maps to one port per number.
+			first, last, isRange := strings.Cut(container, "-")
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port %q", fmt.Sprint(p))
+			}
+			end := start
+			if isRange {
+				if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+					return nil, fmt.Errorf("invalid port range %q", fmt.Sprint(p))
+				}
+			}
+			for n := start; n <= end; n++ {
+				add(composePort{target: n, protocol: protocol})
+			}
+		}
+	}
+	return ports, nil
+}
+
+// volumes converts service volumes. Named volumes become PVCs; bind mounts
+// and anonymous volumes become emptyDir with a warning.
+func (c *composeConverter) volumes(service string, items []interface{}) ([]interface{}, []interface{}) {
+	var mounts, volumes []interface{}
+	added := make(map[string]bool)
+	for i, item := range items {
+		var source, target string
+		readOnly := false
+		switch v := item.(type) {
+		case map[string]interface{}:
+			source, _ = v["source"].(string)
+			target, _ = v["target"].(string)
+			readOnly, _ = v["read_only"].(bool)
+			if t, _ := v["type"].(string); t == "tmpfs" {
+				source = ""
+			}
+		default:
+			parts := strings.Split(fmt.Sprint(v), ":")
+			switch len(parts) {
+			case 1:
+				target = parts[0]
+			default:
+				source, target = parts[0], parts[1]
+				if len(parts) > 2 && strings.Contains(parts[2], "ro") {
+					readOnly = true
+				}
+			}
+		}
+		if target == "" {
+			continue
+		}
+
+		volumeName := fmt.Sprintf("%s-%d", composeName(service), i)
+		volume := map[string]interface{}{"name": volumeName}
+		switch {
+		case source == "":
+			volume["emptyDir"] = map[string]interface{}{}
+		case strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~"):
+			c.warnf("service %s: bind mount %s:%s converted to emptyDir; ship the files in a ConfigMap or image instead", service, source, target)
+			volume["emptyDir"] = map[string]interface{}{}
+		default:
+			if _, declared := c.project.Volumes[source]; !declared {
+				c.warnf("service %s: volume %s is not declared in top-level volumes", service, source)
+			}
+			claim := composeName(source)
+			if users := c.claims[claim]; len(users) == 0 || users[len(users)-1] != composeName(service) {
+				c.claims[claim] = append(users, composeName(service))
+			}
+			volumeName = claim
+			volume = map[string]interface{}{"name": claim, "persistentVolumeClaim": map[string]interface{}{"claimName": claim}}
+		}
+
+		mount := map[string]interface{}{"name": volumeName, "mountPath": target}
+		if readOnly {
+			mount["readOnly"] = true
+		}
+		mounts = append(mounts, mount)
+		if !added[volumeName] {
+			added[volumeName] = true
+			volumes = append(volumes, volume)
+		}
+	}
+	return mounts, volumes
+}
+
+// composePVC returns the claim for a named volume. A claim used by a single
+// service carries its labels so that it is grouped with that service.
+func composePVC(name string, services []string) *unstructured.Unstructured {
+	metadata := map[string]interface{}{"name": name}
+	if len(services) == 1 {
+		metadata["labels"] = map[string]interface{}{"app.kubernetes.io/name": services[0]}
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"accessModes": []interface{}{"ReadWriteOnce"},
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"storage": DefaultComposeVolumeSize},
+			},
+		},
+	}}
+}
+
+// probe converts healthcheck into an exec liveness probe.
+func (c *composeConverter) probe(service string, svc composeService) map[string]interface{} {
+	hc := svc.Healthcheck
+	if hc == nil || hc.Disable {
+		return nil
+	}
+	test := composeStrings(hc.Test)
+	if len(test) == 0 {
+		return nil
+	}
+	var command []string
+	switch test[0] {
+	case "NONE":
+		return nil
+	case "CMD":
+		command = test[1:]
+	case "CMD-SHELL":
+		command = []string{"sh", "-c", strings.Join(test[1:], " ")}
+	default:
+		command = []string{"sh", "-c", strings.Join(test, " ")}
+	}
+
+	probe := map[string]interface{}{"exec": map[string]interface{}{"command": toInterfaces(command)}}
+	for field, value := range map[string]string{
+		"periodSeconds":       hc.Interval,
+		"timeoutSeconds":      hc.Timeout,
+		"initialDelaySeconds": hc.StartPeriod,
+	} {
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			c.warnf("service %s: invalid healthcheck duration %q", service, value)
+			continue
+		}
+		if secs := int64(d.Seconds()); secs > 0 {
+			probe[field] = secs
+		}
+	}
+	if hc.Retries > 0 {
+		probe["failureThreshold"] = hc.Retries
+	}
+	return probe
+}
+
+// composeContainerResources maps deploy.resources to container resources.
+func composeContainerResources(limits, reservations composeResources) map[string]interface{} {
+	res := make(map[string]interface{})
+	for key, r := range map[string]composeResources{"limits": limits, "requests": reservations} {
+		m := make(map[string]interface{})
+		if r.CPUs != nil {
+			m["cpu"] = fmt.Sprint(r.CPUs)
+		}
+		if r.Memory != "" {
+			m["memory"] = composeMemory(r.Memory)
+		}
+		if len(m) > 0 {
+			res[key] = m
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// composeMemory converts compose byte units (512m, 1g, 1gb) to Kubernetes
+// quantities (512Mi, 1Gi).
+func composeMemory(s string) string {
+	lower := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "b")
+	for suffix, unit := range map[string]string{"k": "Ki", "m": "Mi", "g": "Gi"} {
+		if n, ok := strings.CutSuffix(lower, suffix); ok {
+			return n + unit
+		}
+	}
+	return s
+}
+
+func composeDependsOn(v interface{}) []string {
+	switch t := v.(type) {
+	case []interface{}:
+		return composeStrings(t)
+	case map[string]interface{}:
+		out := make([]string, 0, len(t))
+		for k := range t {
+			out = append(out, k)
+		}
+		sort.Strings(out)
+		return out
+	}
+	return nil
+}
+
+var composeVarPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?])([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// interpolateCompose expands $VAR, ${VAR}, ${VAR:-default} and ${VAR-default}
+// from the environment; $$ is a literal $.
+func interpolateCompose(s string) string {
+	return composeVarPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := composeVarPattern.FindStringSubmatch(m)
+		name, op, def := sub[1], sub[2], sub[3]
+		if name == "" {
+			name = sub[4]
+		}
+		value, set := os.LookupEnv(name)
+		switch op {
+		case ":-":
+			if value == "" {
+				return def
+			}
+		case "-":
+			if !set {
+				return def
+			}
+		}
+		return value
+	})
+}
@@ -0,0 +1,215 @@
+package extractor
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const testCompose = `services:
+  web_app:
+    image: registry.example.com/web:${WEB_TAG:-1.0}
+    command: ["--port", "8080"]
+    ports:
+      - "80:8080"
+      - "9090"
+    environment:
+      LOG_LEVEL: debug
+    env_file: web.env
+    volumes:
+      - uploads:/data/uploads
+      - ./config:/etc/web:ro
+    depends_on:
+      - db
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:8080/health"]
+      interval: 30s
+      retries: 3
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 512m
+  db:
+    image: postgres:16
+    environment:
+      - POSTGRES_DB=app
+    expose:
+      - "5432"
+    volumes:
+      - type: volume
+        source: pgdata
+        target: /var/lib/postgresql/data
+volumes:
+  uploads: {}
+  pgdata: {}
+`
+
+func writeCompose(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"docker-compose.yml": testCompose,
+		"web.env":            "# comment\nLOG_LEVEL=info\nFEATURE_X=on\n",
+	})
+	return dir
+}
+
+func findObject(objects []*unstructured.Unstructured, kind, name string) *unstructured.Unstructured {
+	for _, obj := range objects {
+		if obj.GetKind() == kind && obj.GetName() == name {
+			return obj
+		}
+	}
+	return nil
+}
+
+func TestConvertCompose(t *testing.T) {
+	dir := writeCompose(t)
+
+	objects, warnings, err := ConvertCompose(filepath.Join(dir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ConvertCompose: %v", err)
+	}
+
+	var got []string
+	for _, obj := range objects {
+		got = append(got, obj.GetKind()+"/"+obj.GetName())
+	}
+	want := []string{"Deployment/db", "Deployment/web-app", "PersistentVolumeClaim/pgdata", "PersistentVolumeClaim/uploads", "Service/db", "Service/web-app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("objects = %v, want %v", got, want)
+	}
+
+	web := findObject(objects, "Deployment", "web-app")
+	if replicas, _, _ := unstructured.NestedInt64(web.Object, "spec", "replicas"); replicas != 2 {
+		t.Errorf("replicas = %d", replicas)
+	}
+	if web.GetAnnotations()[composeDependsOnAnnotation] != "db" {
+		t.Errorf("depends_on not recorded: %v", web.GetAnnotations())
+	}
+
+	containers, _, _ := unstructured.NestedSlice(web.Object, "spec", "template", "spec", "containers")
+	c := containers[0].(map[string]interface{})
+	if c["image"] != "registry.example.com/web:1.0" {
+		t.Errorf("image = %v; ${WEB_TAG:-1.0} should fall back to the default", c["image"])
+	}
+	if !reflect.DeepEqual(c["args"], []interface{}{"--port", "8080"}) {
+		t.Errorf("args = %v", c["args"])
+	}
+	wantEnv := []interface{}{
+		map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+		map[string]interface{}{"name": "FEATURE_X", "value": "on"},
+	}
+	if !reflect.DeepEqual(c["env"], wantEnv) {
+		t.Errorf("environment should override env_file: %v", c["env"])
+	}
+	if limits, _, _ := unstructured.NestedStringMap(c, "resources", "limits"); limits["cpu"] != "0.5" || limits["memory"] != "512Mi" {
+		t.Errorf("limits = %v", limits)
+	}
+	if cmd, _, _ := unstructured.NestedStringSlice(c, "livenessProbe", "exec", "command"); strings.Join(cmd, " ") != "curl -f http://localhost:8080/health" {
+		t.Errorf("probe command = %v", cmd)
+	}
+	if period, _, _ := unstructured.NestedInt64(c, "livenessProbe", "periodSeconds"); period != 30 {
+		t.Errorf("periodSeconds = %d", period)
+	}
+
+	ports, _, _ := unstructured.NestedSlice(findObject(objects, "Service", "web-app").Object, "spec", "ports")
+	if len(ports) != 2 || ports[0].(map[string]interface{})["port"] != int64(8080) || ports[1].(map[string]interface{})["port"] != int64(9090) {
+		t.Errorf("service ports should use the container ports: %v", ports)
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(web.Object, "spec", "template", "spec", "volumes")
+	if claim, _, _ := unstructured.NestedString(volumes[0].(map[string]interface{}), "persistentVolumeClaim", "claimName"); claim != "uploads" {
+		t.Errorf("named volume should mount a PVC: %v", volumes)
+	}
+	if _, ok := volumes[1].(map[string]interface{})["emptyDir"]; !ok {
+		t.Errorf("bind mount should become emptyDir: %v", volumes)
+	}
+	if pvc := findObject(objects, "PersistentVolumeClaim", "uploads"); pvc.GetLabels()["app.kubernetes.io/name"] != "web-app" {
+		t.Errorf("a claim used by one service should carry its labels: %v", pvc.GetLabels())
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "./config") {
+		t.Errorf("expected a bind mount warning, got %v", warnings)
+	}
+}
+
+func TestConvertCompose_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"empty.yml":   "version: '3'\n",
+		"noimage.yml": "services:\n  web:\n    ports: ['80']\n",
+		"badport.yml": "services:\n  web:\n    image: nginx\n    ports: ['http']\n",
+	})
+	for file, want := range map[string]string{
+		"empty.yml":   "defines no services",
+		"noimage.yml": "image or build is required",
+		"badport.yml": "invalid port",
+	} {
+		if _, _, err := ConvertCompose(filepath.Join(dir, file)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", file, want, err)
+		}
+	}
+}
+
+func TestComposeExtractor_Extract(t *testing.T) {
+	dir := writeCompose(t)
+	e := NewComposeExtractor()
+	if e.Source() != types.SourceCompose {
+		t.Errorf("Source() = %q", e.Source())
+	}
+	if err := e.Validate(context.Background(), Options{Paths: []string{t.TempDir()}}); err == nil {
+		t.Error("expected an error for a directory without a compose file")
+	}
+
+	opts := Options{Paths: []string{dir}, ExcludeKinds: []string{"PersistentVolumeClaim"}}
+	if err := e.Validate(context.Background(), opts); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	resCh, errCh := e.Extract(context.Background(), opts)
+	var resources []*types.ExtractedResource
+	for r := range resCh {
+		resources = append(resources, r)
+	}
+	var errs []error
+	for err := range errCh {
+		errs = append(errs, err)
+	}
+
+	if len(resources) != 4 {
+		t.Errorf("got %d resources; want 2 Deployments and 2 Services", len(resources))
+	}
+	for _, r := range resources {
+		if r.Source != types.SourceCompose || r.SourcePath != filepath.Join(dir, "docker-compose.yml") {
+			t.Errorf("unexpected source: %s %s", r.Source, r.SourcePath)
+		}
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the bind mount warning on the error channel, got %v", errs)
+	}
+}
+
+func TestInterpolateCompose(t *testing.T) {
+	t.Setenv("DHG_SET", "value")
+	t.Setenv("DHG_EMPTY", "")
+	tests := map[string]string{
+		"${DHG_SET}":             "value",
+		"$DHG_SET":               "value",
+		"${DHG_EMPTY:-fallback}": "fallback",
+		"${DHG_EMPTY-fallback}":  "",
+		"${DHG_UNSET-fallback}":  "fallback",
+		"$$DHG_SET":              "$DHG_SET",
+	}
+	for in, want := range tests {
+		if got := interpolateCompose(in); got != want {
+			t.Errorf("interpolateCompose(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
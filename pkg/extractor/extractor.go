@@ -4,6 +4,9 @@ package extractor
 
 import (
 	"context"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
@@ -28,9 +31,23 @@ type Options struct {
 	// ExcludeKinds excludes specific resource kinds from extraction.
 	ExcludeKinds []string
 
+	// OmitFields are field paths removed from every extracted object (see
+	// ParseFieldPath for the syntax and DefaultOmitFields for a sensible set).
+	OmitFields []string
+
 	// Recursive enables recursive directory scanning for file extraction.
 	Recursive bool
 
+	// HelmValues are values files used to render Helm charts found by file
+	// extraction, merged in order over the chart's values.yaml.
+	HelmValues []string
+
+	// HelmReleaseName is .Release.Name when rendering Helm charts.
+	HelmReleaseName string
+
+	// Stdin is read for the path "-" (default os.Stdin).
+	Stdin io.Reader
+
 	// KubeConfig is the path to kubeconfig for cluster extraction.
 	KubeConfig string
 
@@ -48,6 +65,25 @@ type Options struct {
 
 	// GitAuth contains authentication credentials for private repos.
 	GitAuth *GitAuthOptions
+
+	// OnSkip, when set, is called for objects dropped because they do not
+	// belong in a chart (controller-owned or created by Kubernetes), with the
+	// reason. Objects dropped by the kind and namespace filters are not
+	// reported.
+	OnSkip func(obj *unstructured.Unstructured, reason string)
+}
+
+// Reasons passed to Options.OnSkip.
+const (
+	SkipControllerOwned = "owned by a controller"
+	SkipSystemManaged   = "created by Kubernetes"
+)
+
+// skip reports obj as skipped to OnSkip.
+func (o Options) skip(obj *unstructured.Unstructured, reason string) {
+	if o.OnSkip != nil {
+		o.OnSkip(obj, reason)
+	}
 }
 
 // GitAuthOptions contains git authentication options.
@@ -106,6 +142,8 @@ func (r *Registry) Get(source types.Source) (Extractor, bool) {
 func DefaultRegistry() *Registry {
 	r := NewRegistry()
 	r.Register(NewFileExtractor())
+	r.Register(NewClusterExtractor())
 	r.Register(NewGitOpsExtractor())
+	r.Register(NewComposeExtractor())
 	return r
 }
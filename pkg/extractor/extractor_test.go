@@ -53,6 +53,9 @@ func TestDefaultRegistry(t *testing.T) {
 	if fe.Source() != types.SourceFile {
 		t.Error("file extractor Source() incorrect")
 	}
+	if _, ok := r.Get(types.SourceCluster); !ok {
+		t.Error("DefaultRegistry should include cluster extractor")
+	}
 }
 
 // ── FileExtractor.Source ─────────────────────────────────────────────────────
@@ -315,6 +318,51 @@ func TestFileExtractor_Extract_NonRecursive(t *testing.T) {
 	}
 }
 
+func TestFileExtractor_Extract_PathsReadOnce(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	a := filepath.Join(dir, "a.yaml")
+	if err := os.WriteFile(a, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata: {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := filepath.Join(sub, "b.yaml")
+	if err := os.WriteFile(b, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\ndata: {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  int
+	}{
+		{"same file twice", []string{a, a}, 1},
+		{"same file through another path", []string{a, filepath.Join(sub, "..", "a.yaml")}, 1},
+		{"directory and a file in it", []string{dir, b}, 2},
+		{"file and its directory", []string{a, dir}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe := NewFileExtractor()
+			resCh, errCh := fe.Extract(context.Background(), Options{Paths: tt.paths, Recursive: true})
+
+			var resources []*types.ExtractedResource
+			for r := range resCh {
+				resources = append(resources, r)
+			}
+			for range errCh {
+			}
+
+			if len(resources) != tt.want {
+				t.Errorf("got %d resources; want %d", len(resources), tt.want)
+			}
+		})
+	}
+}
+
 // ── Filters ──────────────────────────────────────────────────────────────────
 
 func TestFileExtractor_Extract_IncludeKinds(t *testing.T) {
@@ -457,6 +505,120 @@ func TestFileExtractor_Extract_CancelledContext(t *testing.T) {
 
 // ── splitYAMLDocuments ───────────────────────────────────────────────────────
 
+func TestFileExtractor_Validate_Stdin(t *testing.T) {
+	e := NewFileExtractor()
+	if err := e.Validate(context.Background(), Options{Paths: []string{"-"}}); err != nil {
+		t.Errorf("expected - to be valid, got %v", err)
+	}
+	if err := e.Validate(context.Background(), Options{Paths: []string{"-", "-"}}); err == nil {
+		t.Error("expected error when - is given twice")
+	}
+}
+
+func TestFileExtractor_Extract_Stdin(t *testing.T) {
+	stream := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: prod
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other-config
+  namespace: dev
+`
+	resources, errs := extractFiles(t, Options{
+		Paths:        []string{"-"},
+		Stdin:        strings.NewReader(stream),
+		IncludeKinds: []string{"ConfigMap"},
+		Namespaces:   []string{"prod"},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("expected 1 resource after filters, got %d", len(resources))
+	}
+	r := resources[0]
+	if r.Object.GetName() != "app-config" {
+		t.Errorf("expected app-config, got %s", r.Object.GetName())
+	}
+	if r.SourcePath != "<stdin>" {
+		t.Errorf("expected SourcePath <stdin>, got %q", r.SourcePath)
+	}
+}
+
+func TestFileExtractor_Extract_StdinList(t *testing.T) {
+	// kubectl get all -o yaml output
+	stream := `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: prod
+    resourceVersion: "1234"
+    uid: 0b1c
+    managedFields:
+    - manager: kubectl
+  spec:
+    replicas: 2
+  status:
+    readyReplicas: 2
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: web-7d4b9c-x2x7k
+    namespace: prod
+    resourceVersion: "1240"
+    ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+      name: web-7d4b9c
+      uid: 9f2e
+      controller: true
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: kubernetes
+    namespace: default
+    resourceVersion: "12"
+metadata:
+  resourceVersion: ""
+`
+	skipped := make(map[string]string)
+	resources, errs := extractFiles(t, Options{Paths: []string{"-"}, Stdin: strings.NewReader(stream), OnSkip: func(obj *unstructured.Unstructured, reason string) {
+		skipped[obj.GetKind()] = reason
+	}})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("expected only the Deployment, got %d resources", len(resources))
+	}
+	if len(skipped) != 2 || skipped["Pod"] != SkipControllerOwned || skipped["Service"] != SkipSystemManaged {
+		t.Errorf("expected the Pod and the Service reported as skipped, got %v", skipped)
+	}
+	obj := resources[0].Object
+	if obj.GetKind() != "Deployment" {
+		t.Fatalf("expected Deployment, got %s", obj.GetKind())
+	}
+	if _, ok := obj.Object["status"]; ok {
+		t.Error("expected status to be stripped")
+	}
+	if obj.GetResourceVersion() != "" || obj.GetUID() != "" || len(obj.GetManagedFields()) != 0 {
+		t.Errorf("expected runtime metadata to be stripped, got %v", obj.Object["metadata"])
+	}
+}
+
 func TestSplitYAMLDocuments(t *testing.T) {
 	input := []byte("doc1\n---\ndoc2\n---\ndoc3")
 	docs := splitYAMLDocuments(input)
@@ -17,6 +17,13 @@ import (
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
+// StdinPath is the path that makes the file extractor read a multi-document
+// YAML stream from standard input (-f -).
+const StdinPath = "-"
+
+// stdinSourcePath is the SourcePath of resources read from standard input.
+const stdinSourcePath = "<stdin>"
+
 // FileExtractor extracts Kubernetes resources from YAML files.
 type FileExtractor struct{}
 
@@ -35,8 +42,19 @@ func (e *FileExtractor) Validate(ctx context.Context, opts Options) error {
 	if len(opts.Paths) == 0 {
 		return fmt.Errorf("at least one path is required")
 	}
+	if err := validateOmitFields(opts); err != nil {
+		return err
+	}
 
+	stdin := 0
 	for _, path := range opts.Paths {
+		if path == StdinPath {
+			if stdin++; stdin > 1 {
+				return fmt.Errorf("standard input (-) can only be read once")
+			}
+			continue
+		}
+
 		info, err := os.Stat(path)
 		if err != nil {
 			return fmt.Errorf("cannot access path %s: %w", path, err)
@@ -62,13 +80,16 @@ func (e *FileExtractor) Extract(ctx context.Context, opts Options) (<-chan *type
 		defer close(resources)
 		defer close(errors)
 
+		// A file passed twice, or both on its own and through its
+		// directory, is read once.
+		read := make(map[string]bool)
 		for _, path := range opts.Paths {
 			if err := ctx.Err(); err != nil {
 				errors <- err
 				return
 			}
 
-			if err := e.extractPath(ctx, path, opts, resources, errors); err != nil {
+			if err := e.extractPath(ctx, path, opts, read, resources, errors); err != nil {
 				errors <- err
 			}
 		}
@@ -77,20 +98,71 @@ func (e *FileExtractor) Extract(ctx context.Context, opts Options) (<-chan *type
 	return resources, errors
 }
 
-func (e *FileExtractor) extractPath(ctx context.Context, path string, opts Options, resources chan<- *types.ExtractedResource, errors chan<- error) error {
+// firstRead reports whether path, a file or a Helm chart or Kustomize
+// directory, is not in read yet, and adds it.
+func firstRead(read map[string]bool, path string) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if read[path] {
+		return false
+	}
+	read[path] = true
+	return true
+}
+
+func (e *FileExtractor) extractPath(ctx context.Context, path string, opts Options, read map[string]bool, resources chan<- *types.ExtractedResource, errors chan<- error) error {
+	if path == StdinPath {
+		stdin := opts.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		return e.parseYAMLStream(ctx, stdin, stdinSourcePath, opts, resources, errors)
+	}
+
 	info, err := os.Stat(path)
 	if err != nil {
 		return fmt.Errorf("cannot stat %s: %w", path, err)
 	}
 
 	if info.IsDir() {
-		return e.extractDirectory(ctx, path, opts, resources, errors)
+		if isHelmChart(path) {
+			if !firstRead(read, path) {
+				return nil
+			}
+			return e.extractHelmChart(ctx, path, opts, resources, errors)
+		}
+		if kustomizationFile(path) != "" {
+			if !firstRead(read, path) {
+				return nil
+			}
+			return e.extractKustomization(ctx, path, opts, resources, errors)
+		}
+		return e.extractDirectory(ctx, path, opts, read, resources, errors)
 	}
 
+	if filepath.Base(path) == "Chart.yaml" {
+		if !firstRead(read, filepath.Dir(path)) {
+			return nil
+		}
+		return e.extractHelmChart(ctx, filepath.Dir(path), opts, resources, errors)
+	}
+	if isKustomizationFile(path) {
+		if !firstRead(read, filepath.Dir(path)) {
+			return nil
+		}
+		return e.extractKustomization(ctx, filepath.Dir(path), opts, resources, errors)
+	}
+	if !firstRead(read, path) {
+		return nil
+	}
 	return e.extractFile(ctx, path, opts, resources, errors)
 }
 
-func (e *FileExtractor) extractDirectory(ctx context.Context, dir string, opts Options, resources chan<- *types.ExtractedResource, errors chan<- error) error {
+func (e *FileExtractor) extractDirectory(ctx context.Context, dir string, opts Options, read map[string]bool, resources chan<- *types.ExtractedResource, errors chan<- error) error {
 	fileCount := 0
 
 	walkFn := func(path string, info os.FileInfo, err error) error {
@@ -108,11 +180,31 @@ func (e *FileExtractor) extractDirectory(ctx context.Context, dir string, opts O
 			if !opts.Recursive && path != dir {
 				return filepath.SkipDir
 			}
+			// Helm charts are rendered and Kustomize roots are built
+			// instead of read file by file
+			if path != dir && isHelmChart(path) {
+				if !firstRead(read, path) {
+					return filepath.SkipDir
+				}
+				if err := e.extractHelmChart(ctx, path, opts, resources, errors); err != nil {
+					errors <- err
+				}
+				return filepath.SkipDir
+			}
+			if path != dir && kustomizationFile(path) != "" {
+				if !firstRead(read, path) {
+					return filepath.SkipDir
+				}
+				if err := e.extractKustomization(ctx, path, opts, resources, errors); err != nil {
+					errors <- err
+				}
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		// Only process YAML files
-		if !isYAMLFile(path) {
+		if !isYAMLFile(path) || !firstRead(read, path) {
 			return nil
 		}
 
@@ -183,36 +275,85 @@ func (e *FileExtractor) parseYAMLStream(ctx context.Context, reader io.Reader, s
 			continue
 		}
 
-		gvk := obj.GroupVersionKind()
-
-		// Filter by kinds if specified
-		if !e.matchesKindFilters(gvk.Kind, opts) {
+		// kubectl get -o yaml wraps multiple objects in a List
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				errors <- fmt.Errorf("cannot parse List in %s: %w", sourcePath, err)
+				continue
+			}
+			for i := range list.Items {
+				if err := e.emit(ctx, &list.Items[i], sourcePath, opts, resources); err != nil {
+					return err
+				}
+			}
 			continue
 		}
 
-		// Filter by namespace if specified
-		if !e.matchesNamespaceFilters(obj.GetNamespace(), opts) {
-			continue
+		if err := e.emit(ctx, obj, sourcePath, opts, resources); err != nil {
+			return err
 		}
+	}
 
-		resource := &types.ExtractedResource{
-			Object:     obj,
-			Source:     types.SourceFile,
-			SourcePath: sourcePath,
-			GVK:        gvk,
-		}
+	return nil
+}
 
-		select {
-		case resources <- resource:
-		case <-ctx.Done():
-			return ctx.Err()
+// emit filters obj and sends it on resources. Objects dumped from a live
+// cluster (they carry a resourceVersion) are cleaned the way cluster
+// extraction does: controller-owned and system objects are dropped and
+// server-populated fields are removed.
+func (e *FileExtractor) emit(ctx context.Context, obj *unstructured.Unstructured, sourcePath string, opts Options, resources chan<- *types.ExtractedResource) error {
+	if obj.GetResourceVersion() != "" {
+		if isControllerOwned(obj) {
+			opts.skip(obj, SkipControllerOwned)
+			return nil
 		}
+		if isSystemManaged(obj) {
+			opts.skip(obj, SkipSystemManaged)
+			return nil
+		}
+		stripRuntimeFields(obj)
 	}
 
+	gvk := obj.GroupVersionKind()
+
+	// Filter by kinds if specified
+	if !e.matchesKindFilters(gvk.Kind, opts) {
+		return nil
+	}
+
+	// Filter by namespace if specified
+	if !e.matchesNamespaceFilters(obj.GetNamespace(), opts) {
+		return nil
+	}
+
+	omitFields(obj, opts)
+
+	resource := &types.ExtractedResource{
+		Object:     obj,
+		Source:     types.SourceFile,
+		SourcePath: sourcePath,
+		GVK:        gvk,
+	}
+
+	select {
+	case resources <- resource:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	return nil
 }
 
 func (e *FileExtractor) matchesKindFilters(kind string, opts Options) bool {
+	return matchesKindFilters(kind, opts)
+}
+
+func (e *FileExtractor) matchesNamespaceFilters(namespace string, opts Options) bool {
+	return matchesNamespaceFilters(namespace, opts)
+}
+
+// matchesKindFilters reports whether kind passes the include/exclude kind filters.
+func matchesKindFilters(kind string, opts Options) bool {
 	// Check exclude list first
 	for _, excluded := range opts.ExcludeKinds {
 		if strings.EqualFold(kind, excluded) {
@@ -235,7 +376,9 @@ func (e *FileExtractor) matchesKindFilters(kind string, opts Options) bool {
 	return false
 }
 
-func (e *FileExtractor) matchesNamespaceFilters(namespace string, opts Options) bool {
+// matchesNamespaceFilters reports whether namespace passes the namespace
+// filters. Cluster-scoped resources (empty namespace) always pass.
+func matchesNamespaceFilters(namespace string, opts Options) bool {
 	// If no namespace filters, include all
 	if opts.Namespace == "" && len(opts.Namespaces) == 0 {
 		return true
@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// isHelmChart reports whether dir is a Helm chart (contains Chart.yaml).
+func isHelmChart(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "Chart.yaml"))
+	return err == nil && !info.IsDir()
+}
+
+// extractHelmChart renders the chart in dir with opts.HelmValues and extracts
+// the rendered resources. SourcePath of each resource is the template it was
+// rendered from. Subcharts under charts/ are not rendered.
+func (e *FileExtractor) extractHelmChart(ctx context.Context, dir string, opts Options, resources chan<- *types.ExtractedResource, errors chan<- error) error {
+	values, err := helm.ReadValuesFiles(opts.HelmValues...)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := helm.RenderChart(dir, helm.RenderOptions{
+		ReleaseName: opts.HelmReleaseName,
+		Namespace:   opts.Namespace,
+		Values:      values,
+	})
+	if err != nil {
+		return fmt.Errorf("render chart %s: %w", dir, err)
+	}
+
+	paths := make([]string, 0, len(rendered.Manifests))
+	for p := range rendered.Manifests {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		manifest := rendered.Manifests[p]
+		if strings.TrimSpace(manifest) == "" {
+			continue
+		}
+		sourcePath := filepath.Join(dir, filepath.FromSlash(p))
+		if err := e.parseYAMLStream(ctx, strings.NewReader(manifest), sourcePath, opts, resources, errors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
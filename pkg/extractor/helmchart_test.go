@@ -0,0 +1,133 @@
+package extractor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func helmChartTree(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"legacy/Chart.yaml":  "apiVersion: v2\nname: legacy\nversion: 1.0.0\n",
+		"legacy/values.yaml": "replicas: 1\nimage: nginx:1.25\ningress:\n  enabled: false\n",
+		"legacy/templates/_helpers.tpl": `{{- define "legacy.fullname" -}}
+{{ .Release.Name }}-{{ .Chart.Name }}
+{{- end }}`,
+		"legacy/templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "legacy.fullname" . }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  replicas: {{ .Values.replicas }}
+  template:
+    spec:
+      containers:
+        - name: app
+          image: {{ .Values.image }}
+`,
+		"legacy/templates/ingress.yaml": `{{- if .Values.ingress.enabled }}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ include "legacy.fullname" . }}
+{{- end }}
+`,
+		"legacy/templates/NOTES.txt": "installed\n",
+		"prod.yaml":                  "replicas: 3\ningress:\n  enabled: true\n",
+	})
+	return dir
+}
+
+func TestFileExtractor_Extract_HelmChart(t *testing.T) {
+	dir := helmChartTree(t)
+	chart := filepath.Join(dir, "legacy")
+
+	resources, errs := extractFiles(t, Options{Paths: []string{chart}})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("got %d resources; want only the Deployment (ingress disabled)", len(resources))
+	}
+	deploy := resources[0]
+	if deploy.Object.GetName() != "release-legacy" || deploy.Object.GetNamespace() != "default" {
+		t.Errorf("unexpected metadata: %s/%s", deploy.Object.GetNamespace(), deploy.Object.GetName())
+	}
+	if deploy.SourcePath != filepath.Join(chart, "templates", "deployment.yaml") {
+		t.Errorf("SourcePath = %q", deploy.SourcePath)
+	}
+}
+
+func TestFileExtractor_Extract_HelmChartValues(t *testing.T) {
+	dir := helmChartTree(t)
+
+	resources, errs := extractFiles(t, Options{
+		Paths:           []string{filepath.Join(dir, "legacy", "Chart.yaml")},
+		HelmValues:      []string{filepath.Join(dir, "prod.yaml")},
+		HelmReleaseName: "shop",
+		Namespace:       "production",
+	})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	kinds := make(map[string]string)
+	for _, r := range resources {
+		kinds[r.GVK.Kind] = r.Object.GetName()
+	}
+	if kinds["Deployment"] != "shop-legacy" || kinds["Ingress"] != "shop-legacy" {
+		t.Fatalf("values and release name not applied: %v", kinds)
+	}
+	for _, r := range resources {
+		if r.GVK.Kind == "Deployment" {
+			if replicas, _, _ := unstructured.NestedFieldNoCopy(r.Object.Object, "spec", "replicas"); fmt.Sprint(replicas) != "3" {
+				t.Errorf("replicas = %v; want the value from prod.yaml", replicas)
+			}
+			if r.Object.GetNamespace() != "production" {
+				t.Errorf("namespace = %q", r.Object.GetNamespace())
+			}
+		}
+	}
+}
+
+func TestFileExtractor_Extract_RecursiveRendersCharts(t *testing.T) {
+	dir := helmChartTree(t)
+	writeFiles(t, dir, map[string]string{
+		"plain.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: plain\n",
+	})
+
+	// prod.yaml has no apiVersion/kind and is skipped; the chart templates
+	// are rendered rather than parsed as raw YAML.
+	resources, errs := extractFiles(t, Options{Paths: []string{dir}, Recursive: true})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(resources) != 2 {
+		t.Errorf("got %d resources; want plain.yaml and the rendered Deployment", len(resources))
+	}
+}
+
+func TestFileExtractor_Extract_HelmChartErrors(t *testing.T) {
+	dir := helmChartTree(t)
+	writeFiles(t, dir, map[string]string{
+		"broken/Chart.yaml":       "apiVersion: v2\nname: broken\nversion: 1.0.0\n",
+		"broken/templates/a.yaml": "{{ required \"host is required\" .Values.host }}\n",
+	})
+
+	_, errs := extractFiles(t, Options{Paths: []string{filepath.Join(dir, "broken")}})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "host is required") {
+		t.Errorf("expected a render error, got %v", errs)
+	}
+
+	_, errs = extractFiles(t, Options{
+		Paths:      []string{filepath.Join(dir, "legacy")},
+		HelmValues: []string{filepath.Join(dir, "missing.yaml")},
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "values file") {
+		t.Errorf("expected a values file error, got %v", errs)
+	}
+}
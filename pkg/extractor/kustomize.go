@@ -0,0 +1,64 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// kustomizationFile returns the kustomization file in dir, or "" if dir is
+// not a Kustomize root.
+func kustomizationFile(dir string) string {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		p := filepath.Join(dir, name)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// isKustomizationFile reports whether path is named like a kustomization file.
+func isKustomizationFile(path string) bool {
+	base := filepath.Base(path)
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildKustomization runs kustomize build on the Kustomize root dir and
+// returns the resulting multi-document YAML. Bases, overlays, patches and
+// generators are resolved; remote bases and plugins are not supported.
+func BuildKustomization(dir string) ([]byte, error) {
+	opts := krusty.MakeDefaultOptions()
+	resMap, err := krusty.MakeKustomizer(opts).Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build %s: %w", dir, err)
+	}
+	out, err := resMap.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build %s: %w", dir, err)
+	}
+	return out, nil
+}
+
+// extractKustomization builds the Kustomize root dir and extracts the
+// resulting resources. SourcePath of each resource is the kustomization file.
+func (e *FileExtractor) extractKustomization(ctx context.Context, dir string, opts Options, resources chan<- *types.ExtractedResource, errors chan<- error) error {
+	out, err := BuildKustomization(dir)
+	if err != nil {
+		return err
+	}
+	return e.parseYAMLStream(ctx, bytes.NewReader(out), kustomizationFile(dir), opts, resources, errors)
+}
@@ -0,0 +1,147 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// kustomizeTree writes a base with a Deployment and a prod overlay that
+// renames, patches and generates a ConfigMap.
+func kustomizeTree(t *testing.T) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base/kustomization.yaml": "resources:\n  - deployment.yaml\n",
+		"base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+`,
+		"overlays/prod/kustomization.yaml": `resources:
+  - ../../base
+namePrefix: prod-
+namespace: production
+patches:
+  - path: replicas.yaml
+configMapGenerator:
+  - name: web-config
+    literals:
+      - LOG_LEVEL=warn
+`,
+		"overlays/prod/replicas.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n",
+	})
+	return dir
+}
+
+func extractFiles(t *testing.T, opts Options) ([]*types.ExtractedResource, []error) {
+	t.Helper()
+	resCh, errCh := NewFileExtractor().Extract(context.Background(), opts)
+	var resources []*types.ExtractedResource
+	for r := range resCh {
+		resources = append(resources, r)
+	}
+	var errs []error
+	for err := range errCh {
+		errs = append(errs, err)
+	}
+	return resources, errs
+}
+
+func TestFileExtractor_Extract_Kustomization(t *testing.T) {
+	dir := kustomizeTree(t)
+	overlay := filepath.Join(dir, "overlays", "prod")
+
+	for _, path := range []string{overlay, filepath.Join(overlay, "kustomization.yaml")} {
+		resources, errs := extractFiles(t, Options{Paths: []string{path}})
+		if len(errs) != 0 {
+			t.Fatalf("%s: unexpected errors: %v", path, errs)
+		}
+		if len(resources) != 2 {
+			t.Fatalf("%s: got %d resources; want the Deployment and the generated ConfigMap", path, len(resources))
+		}
+
+		byKind := make(map[string]*types.ExtractedResource)
+		for _, r := range resources {
+			byKind[r.GVK.Kind] = r
+		}
+		deploy := byKind["Deployment"]
+		if deploy == nil || deploy.Object.GetName() != "prod-web" || deploy.Object.GetNamespace() != "production" {
+			t.Fatalf("overlay not applied to the Deployment: %+v", deploy)
+		}
+		if replicas, _, _ := unstructured.NestedFieldNoCopy(deploy.Object.Object, "spec", "replicas"); fmt.Sprint(replicas) != "3" {
+			t.Errorf("patch not applied: replicas = %v", replicas)
+		}
+		if deploy.SourcePath != filepath.Join(overlay, "kustomization.yaml") {
+			t.Errorf("SourcePath = %q", deploy.SourcePath)
+		}
+		cm := byKind["ConfigMap"]
+		if cm == nil || !strings.HasPrefix(cm.Object.GetName(), "prod-web-config-") {
+			t.Errorf("expected a generated ConfigMap with a hash suffix, got %+v", cm)
+		}
+	}
+}
+
+func TestFileExtractor_Extract_RecursiveBuildsKustomizeRoots(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"plain.yaml":             "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: plain\n",
+		"app/kustomization.yaml": "resources:\n  - cm.yaml\nlabels:\n  - pairs:\n      team: web\n",
+		"app/cm.yaml":            "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+		"app/unreferenced.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: unreferenced\n",
+	})
+
+	resources, errs := extractFiles(t, Options{Paths: []string{dir}, Recursive: true})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	names := make(map[string]map[string]string)
+	for _, r := range resources {
+		names[r.Object.GetName()] = r.Object.GetLabels()
+	}
+	if len(names) != 2 || names["app"]["team"] != "web" {
+		t.Errorf("expected plain.yaml and the built app kustomization only, got %v", names)
+	}
+}
+
+func TestFileExtractor_Extract_KustomizationError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"kustomization.yaml": "resources:\n  - missing.yaml\n"})
+
+	_, errs := extractFiles(t, Options{Paths: []string{dir}})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "kustomize build") {
+		t.Errorf("expected a kustomize build error, got %v", errs)
+	}
+}
@@ -0,0 +1,148 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultOmitFields are the field paths dhg generate strips from extracted
+// objects by default: server-populated metadata, status and the node a Pod
+// was scheduled to, none of which belong in a chart.
+var DefaultOmitFields = []string{
+	`metadata.annotations["kubectl.kubernetes.io/last-applied-configuration"]`,
+	`metadata.annotations["deployment.kubernetes.io/revision"]`,
+	"metadata.managedFields",
+	"metadata.creationTimestamp",
+	"metadata.resourceVersion",
+	"metadata.uid",
+	"metadata.generation",
+	"metadata.selfLink",
+	"status",
+	"spec.nodeName",
+	"spec.template.spec.nodeName",
+}
+
+// ParseFieldPath splits a field path into its segments. Segments are
+// separated by dots; a key containing dots or slashes is written in brackets
+// and quotes (metadata.annotations["example.com/key"]), and * matches every
+// key of a map or element of a list (spec.containers.*.resources).
+func ParseFieldPath(path string) ([]string, error) {
+	var segments []string
+	rest := path
+	for rest != "" {
+		var segment string
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("field path %q: unterminated [", path)
+			}
+			key, err := strconv.Unquote(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("field path %q: bracketed key must be quoted: %s", path, rest[1:end])
+			}
+			segment, rest = key, rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			segment, rest = rest[:end], rest[end:]
+			if segment == "" {
+				return nil, fmt.Errorf("field path %q: empty segment", path)
+			}
+		}
+		segments = append(segments, segment)
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("field path %q: trailing dot", path)
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("field path is empty")
+	}
+	return segments, nil
+}
+
+// validateOmitFields checks that every Options.OmitFields path parses.
+func validateOmitFields(opts Options) error {
+	for _, path := range opts.OmitFields {
+		if _, err := ParseFieldPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// omitFields removes the Options.OmitFields paths from obj. Maps left empty
+// by a removal (metadata.annotations after dropping the last annotation) are
+// removed as well. Invalid paths are skipped; Validate reports them.
+func omitFields(obj *unstructured.Unstructured, opts Options) {
+	for _, path := range opts.OmitFields {
+		segments, err := ParseFieldPath(path)
+		if err != nil {
+			continue
+		}
+		removeField(obj.Object, segments)
+	}
+}
+
+// removeField removes the field at segments under m and reports whether m
+// was left empty.
+func removeField(m map[string]interface{}, segments []string) bool {
+	key := segments[0]
+	keys := []string{key}
+	if key == "*" {
+		keys = keys[:0]
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		child, ok := m[k]
+		if !ok {
+			continue
+		}
+		if len(segments) == 1 {
+			delete(m, k)
+			continue
+		}
+		if removeFieldIn(child, segments[1:]) {
+			delete(m, k)
+		}
+	}
+	return len(m) == 0
+}
+
+// removeFieldIn removes the field at segments under a map or list value and
+// reports whether a map value was left empty.
+func removeFieldIn(v interface{}, segments []string) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			return false
+		}
+		return removeField(t, segments)
+	case []interface{}:
+		if segments[0] != "*" {
+			i, err := strconv.Atoi(segments[0])
+			if err != nil || i < 0 || i >= len(t) {
+				return false
+			}
+			if len(segments) > 1 {
+				removeFieldIn(t[i], segments[1:])
+			}
+			return false
+		}
+		if len(segments) > 1 {
+			for _, item := range t {
+				removeFieldIn(item, segments[1:])
+			}
+		}
+	}
+	return false
+}
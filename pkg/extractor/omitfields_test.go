@@ -0,0 +1,142 @@
+package extractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "status", want: []string{"status"}},
+		{path: "spec.template.spec.nodeName", want: []string{"spec", "template", "spec", "nodeName"}},
+		{path: `metadata.annotations["kubectl.kubernetes.io/last-applied-configuration"]`,
+			want: []string{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"}},
+		{path: `spec.containers.*.env`, want: []string{"spec", "containers", "*", "env"}},
+		{path: `metadata.labels["a.b/c"].x`, want: []string{"metadata", "labels", "a.b/c", "x"}},
+		{path: "", wantErr: true},
+		{path: "spec..nodeName", wantErr: true},
+		{path: "spec.", wantErr: true},
+		{path: `metadata.annotations[unquoted]`, wantErr: true},
+		{path: `metadata.annotations["open`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseFieldPath(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOmitFields(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":              "web",
+			"creationTimestamp": "2024-01-01T00:00:00Z",
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"nodeName": "node-1",
+					"containers": []interface{}{
+						map[string]interface{}{"name": "a", "terminationMessagePath": "/dev/termination-log"},
+						map[string]interface{}{"name": "b", "terminationMessagePath": "/dev/termination-log"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	}}
+
+	omitFields(obj, Options{OmitFields: append(append([]string{}, DefaultOmitFields...),
+		"spec.template.spec.containers.*.terminationMessagePath")})
+
+	if _, ok := obj.Object["status"]; ok {
+		t.Error("status was not removed")
+	}
+	metadata := obj.Object["metadata"].(map[string]interface{})
+	if _, ok := metadata["creationTimestamp"]; ok {
+		t.Error("creationTimestamp was not removed")
+	}
+	if _, ok := metadata["annotations"]; ok {
+		t.Error("annotations emptied by the removal should be removed")
+	}
+	podSpec, _, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+	if _, ok := podSpec["nodeName"]; ok {
+		t.Error("nodeName was not removed")
+	}
+	for _, c := range podSpec["containers"].([]interface{}) {
+		container := c.(map[string]interface{})
+		if _, ok := container["terminationMessagePath"]; ok || container["name"] == nil {
+			t.Errorf("expected only terminationMessagePath removed, got %v", container)
+		}
+	}
+}
+
+func TestFileExtractor_Extract_OmitFields(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "cm.yaml")
+	if err := os.WriteFile(f, []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cfg
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+    team: shop
+data:
+  a: b
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fe := NewFileExtractor()
+	resCh, errCh := fe.Extract(context.Background(), Options{Paths: []string{f}, OmitFields: DefaultOmitFields})
+
+	var resources []*types.ExtractedResource
+	for r := range resCh {
+		resources = append(resources, r)
+	}
+	for range errCh {
+	}
+
+	if len(resources) != 1 {
+		t.Fatalf("got %d resources; want 1", len(resources))
+	}
+	want := map[string]string{"team": "shop"}
+	if got := resources[0].Object.GetAnnotations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations = %v; want %v", got, want)
+	}
+}
+
+func TestFileExtractor_Validate_InvalidOmitField(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewFileExtractor().Validate(context.Background(), Options{Paths: []string{dir}, OmitFields: []string{"spec..x"}}); err == nil {
+		t.Error("expected error for an invalid omit field path")
+	}
+}
@@ -0,0 +1,41 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// podMetricsResource is the metrics-server resource with the current usage of
+// every container of a pod.
+var podMetricsResource = apiResource{
+	Group:      "metrics.k8s.io",
+	Version:    "v1beta1",
+	Kind:       "PodMetrics",
+	Name:       "pods",
+	Namespaced: true,
+}
+
+// PodMetrics lists the metrics-server PodMetrics of the namespaces and label
+// selector of opts. The usage is a snapshot of the last metrics-server scrape.
+func (e *ClusterExtractor) PodMetrics(ctx context.Context, opts Options) ([]*unstructured.Unstructured, error) {
+	client, err := e.getClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cluster client: %w", err)
+	}
+
+	var items []*unstructured.Unstructured
+	for _, namespace := range e.effectiveNamespaces(opts) {
+		err := client.listResources(ctx, podMetricsResource, namespace, e.effectiveSelector(opts), e.config.Pagination.Limit, func(obj *unstructured.Unstructured) {
+			if e.isExcludedNamespace(obj.GetNamespace()) {
+				return
+			}
+			items = append(items, obj)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list pod metrics (is metrics-server installed?): %w", err)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,61 @@
+package extractor
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func podMetricsItem(name, namespace, cpu, memory string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": namespace},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":  "app",
+				"usage": map[string]interface{}{"cpu": cpu, "memory": memory},
+			},
+		},
+	}
+}
+
+func TestClusterExtractor_PodMetrics(t *testing.T) {
+	fake := newFakeKubeAPIServer()
+	defer fake.close()
+
+	fake.setResponse("/apis/metrics.k8s.io/v1beta1/namespaces/prod/pods", itemList(
+		podMetricsItem("web-7d9f8b6c5d-x2x4z", "prod", "120m", "200Mi"),
+	))
+	fake.setResponse("/apis/metrics.k8s.io/v1beta1/namespaces/stage/pods", itemList(
+		podMetricsItem("web-5c4b8d7f9-q8w2r", "stage", "10m", "50Mi"),
+	))
+
+	ce := NewClusterExtractor()
+	ce.SetClient(fake.client())
+
+	items, err := ce.PodMetrics(context.Background(), Options{Namespaces: []string{"prod", "stage"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d pod metrics; want 2", len(items))
+	}
+	if items[0].GetKind() != "PodMetrics" || items[0].GetAPIVersion() != "metrics.k8s.io/v1beta1" {
+		t.Errorf("GVK = %s; want metrics.k8s.io/v1beta1 PodMetrics", items[0].GroupVersionKind())
+	}
+	if items[1].GetNamespace() != "stage" {
+		t.Errorf("namespace = %q; want stage", items[1].GetNamespace())
+	}
+}
+
+func TestClusterExtractor_PodMetrics_NoMetricsServer(t *testing.T) {
+	fake := newFakeKubeAPIServer()
+	defer fake.close()
+
+	ce := NewClusterExtractor()
+	ce.SetClient(fake.client())
+
+	_, err := ce.PodMetrics(context.Background(), Options{})
+	if err == nil || !strings.Contains(err.Error(), "metrics-server") {
+		t.Errorf("expected a metrics-server error, got %v", err)
+	}
+}
@@ -15,6 +15,14 @@ type ImageRef struct {
 	Tag        string // e.g. "1.21" or "latest"
 	Digest     string // e.g. "sha256:abc123..."
 	FullRef    string // original full reference string
+
+	// Platforms are the platforms of the image to mirror, with the digests
+	// of their manifests, when resolved by ResolveImagePlatforms.
+	Platforms []ImagePlatform
+
+	// FilterPlatforms marks a multi-platform image of which only Platforms
+	// are mirrored.
+	FilterPlatforms bool
 }
 
 // imageRefRegex matches image references in YAML templates.
@@ -68,10 +76,14 @@ func parseImageRef(raw string) ImageRef {
 		}
 	}
 
-	// Check for digest reference: image@sha256:...
+	// Check for digest reference: image@sha256:... or image:tag@sha256:...
 	if idx := strings.Index(raw, "@"); idx != -1 {
 		ref.Repository = raw[:idx]
 		ref.Digest = raw[idx+1:]
+		if colon := strings.LastIndex(ref.Repository, ":"); colon != -1 && !strings.Contains(ref.Repository[colon+1:], "/") {
+			ref.Tag = ref.Repository[colon+1:]
+			ref.Repository = ref.Repository[:colon]
+		}
 		return ref
 	}
 
@@ -102,25 +114,38 @@ func parseImageRef(raw string) ImageRef {
 }
 
 // GenerateImageList generates images.txt content — one unique image per line, sorted.
+// The resolved platforms of an image follow it as comment lines
+// ("#   linux/arm64 sha256:..."), which ParseImageList reads back.
 func GenerateImageList(refs []ImageRef) string {
 	if len(refs) == 0 {
 		return ""
 	}
 
 	seen := make(map[string]bool)
-	var images []string
+	var images []ImageRef
 
 	for _, ref := range refs {
 		if !seen[ref.FullRef] {
 			seen[ref.FullRef] = true
-			images = append(images, ref.FullRef)
+			images = append(images, ref)
 		}
 	}
 
-	sort.Strings(images)
-	return strings.Join(images, "\n") + "\n"
+	sort.Slice(images, func(i, j int) bool { return images[i].FullRef < images[j].FullRef })
+	var sb strings.Builder
+	for _, ref := range images {
+		sb.WriteString(ref.FullRef + "\n")
+		for _, p := range ref.Platforms {
+			fmt.Fprintf(&sb, "%s%s %s\n", platformLinePrefix, p.Platform, p.Digest)
+		}
+	}
+	return sb.String()
 }
 
+// platformLinePrefix starts the lines of images.txt listing the platforms of
+// the image above.
+const platformLinePrefix = "#   "
+
 // GenerateAirgapValues generates values-airgap.yaml with global.imageRegistry override.
 func GenerateAirgapValues(registry string) map[string]interface{} {
 	return map[string]interface{}{
@@ -135,13 +160,37 @@ func GenerateAirgapValues(registry string) map[string]interface{} {
 	}
 }
 
+// MirrorTool is the tool the mirror script copies images with.
+type MirrorTool string
+
+const (
+	// MirrorToolSkopeo copies images with "skopeo copy --all".
+	MirrorToolSkopeo MirrorTool = "skopeo"
+
+	// MirrorToolCrane copies images with "crane copy".
+	MirrorToolCrane MirrorTool = "crane"
+)
+
 // GenerateMirrorScript generates mirror-images.sh with skopeo copy commands.
 // Returns an error if targetRegistry contains unsafe shell characters.
 // Image references with unsafe characters are silently skipped.
 func GenerateMirrorScript(refs []ImageRef, targetRegistry string) (string, error) {
+	return GenerateMirrorScriptWith(refs, targetRegistry, MirrorToolSkopeo)
+}
+
+// GenerateMirrorScriptWith generates mirror-images.sh copying the images
+// with tool. Both tools copy every platform of multi-platform images, so
+// the mirrored images keep their digests, except for images marked with
+// FilterPlatforms: crane copies their selected platforms with "crane index
+// filter", skopeo copies their only selected platform. skopeo cannot copy
+// several platforms out of a manifest list.
+func GenerateMirrorScriptWith(refs []ImageRef, targetRegistry string, tool MirrorTool) (string, error) {
 	if err := validateShellSafe(targetRegistry, "targetRegistry"); err != nil {
 		return "", err
 	}
+	if tool != MirrorToolSkopeo && tool != MirrorToolCrane {
+		return "", fmt.Errorf("unknown mirror tool %q (must be skopeo or crane)", tool)
+	}
 
 	var sb strings.Builder
 
@@ -179,27 +228,58 @@ func GenerateMirrorScript(refs []ImageRef, targetRegistry string) (string, error
 			continue
 		}
 
-		source := fmt.Sprintf("docker://%s", ref.FullRef)
-		// Build target: replace original registry with target
-		targetImage := ref.FullRef
-		// If image has a registry prefix, strip it for the target
-		if strings.Contains(ref.Repository, "/") {
-			parts := strings.SplitN(ref.Repository, "/", 2)
-			if strings.Contains(parts[0], ".") || strings.Contains(parts[0], ":") {
-				// Has explicit registry — replace it
-				if ref.Digest != "" {
-					targetImage = parts[1] + "@" + ref.Digest
-				} else if ref.Tag != "" && ref.Tag != "latest" {
-					targetImage = parts[1] + ":" + ref.Tag
-				} else {
-					targetImage = parts[1] + ":latest"
-				}
+		target := "${TARGET_REGISTRY}/" + mirrorTarget(ref)
+		switch {
+		case ref.FilterPlatforms && tool == MirrorToolCrane:
+			var platforms strings.Builder
+			for _, p := range ref.Platforms {
+				platforms.WriteString(" --platform " + p.Platform)
+			}
+			sb.WriteString(fmt.Sprintf("crane index filter %s%s -t %s\n", ref.FullRef, platforms.String(), target))
+		case ref.FilterPlatforms:
+			if len(ref.Platforms) != 1 {
+				return "", fmt.Errorf("skopeo cannot mirror %d of the platforms of %s; use crane", len(ref.Platforms), ref.FullRef)
 			}
+			sb.WriteString(fmt.Sprintf("skopeo copy %s docker://%s docker://%s\n", skopeoPlatformFlags(ref.Platforms[0].Platform), ref.FullRef, target))
+		case tool == MirrorToolCrane:
+			sb.WriteString(fmt.Sprintf("crane copy %s %s\n", ref.FullRef, target))
+		default:
+			sb.WriteString(fmt.Sprintf("skopeo copy --all docker://%s docker://%s\n", ref.FullRef, target))
 		}
-		target := fmt.Sprintf("docker://${TARGET_REGISTRY}/%s", targetImage)
-		sb.WriteString(fmt.Sprintf("skopeo copy %s %s\n", source, target))
 	}
 
 	sb.WriteString("\necho 'Image mirroring complete.'\n")
 	return sb.String(), nil
 }
+
+// skopeoPlatformFlags returns the skopeo flags selecting platform out of a
+// manifest list.
+func skopeoPlatformFlags(platform string) string {
+	parts := strings.Split(platform, "/")
+	flags := "--override-os " + parts[0] + " --override-arch " + parts[1]
+	if len(parts) > 2 {
+		flags += " --override-variant " + parts[2]
+	}
+	return flags
+}
+
+// mirrorTarget returns the reference of a mirrored image relative to the
+// target registry: the original reference without its registry.
+func mirrorTarget(ref ImageRef) string {
+	if !strings.Contains(ref.Repository, "/") {
+		return ref.FullRef
+	}
+	parts := strings.SplitN(ref.Repository, "/", 2)
+	if !strings.Contains(parts[0], ".") && !strings.Contains(parts[0], ":") {
+		return ref.FullRef
+	}
+	// Has explicit registry — replace it
+	switch {
+	case ref.Digest != "":
+		return parts[1] + "@" + ref.Digest
+	case ref.Tag != "" && ref.Tag != "latest":
+		return parts[1] + ":" + ref.Tag
+	default:
+		return parts[1] + ":latest"
+	}
+}
@@ -0,0 +1,325 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// BestPracticeFix is a change ApplyBestPractices made to the values of a
+// workload, or a violation it left for the user when Skipped is set.
+type BestPracticeFix struct {
+	// Practice is the ID of the violated best practice.
+	Practice string `json:"practice"`
+
+	// Resource is the violating workload.
+	Resource types.ResourceKey `json:"-"`
+
+	// Path is the values path that was set.
+	Path string `json:"path,omitempty"`
+
+	// Value is the value set at Path.
+	Value interface{} `json:"value,omitempty"`
+
+	// Skipped is the reason the violation was not fixed.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// String formats the fix as a line of the fix report.
+func (f BestPracticeFix) String() string {
+	resource := f.Resource.GVK.Kind + "/" + f.Resource.Name
+	if f.Skipped != "" {
+		return fmt.Sprintf("%-11s %s: not fixed, %s", f.Practice, resource, f.Skipped)
+	}
+	value, err := json.Marshal(f.Value)
+	if err != nil {
+		value = []byte(fmt.Sprint(f.Value))
+	}
+	return fmt.Sprintf("%-11s %s: %s = %s", f.Practice, resource, f.Path, value)
+}
+
+// valueChange is a value set by a best-practice fixer, at a path relative to
+// the workload values.
+type valueChange struct {
+	path  string
+	value interface{}
+}
+
+// bestPracticeFixer fixes a best-practice violation in the values of a
+// workload. The fix takes effect only in workload templates rendering the
+// values it sets, which contain renders.
+type bestPracticeFixer struct {
+	renders string
+	field   string
+	fix     func(kind string, workload map[string]interface{}) ([]valueChange, string)
+}
+
+// bestPracticeFixers are the fixers of the auto-fixable best practices, by
+// ID. Fixers only set values that are missing.
+var bestPracticeFixers = map[string]bestPracticeFixer{
+	"BP-001":     {renders: "{{- with .resources }}", field: "resources", fix: fixResources},
+	"BP-002":     {renders: "{{- with .resources }}", field: "resources", fix: fixResources},
+	"BP-QOS-001": {renders: "{{- with .resources }}", field: "resources", fix: fixResources},
+	"BP-HA-001":  {renders: "replicas: {{ .replicas", field: "replicas", fix: fixReplicas},
+	"BP-HA-002":  {renders: "{{- with .readinessProbe }}", field: "probes", fix: fixProbes},
+	"BP-SEC-001": {renders: "          {{- with .securityContext }}", field: "the container securityContext", fix: containerSecurityFix("runAsNonRoot")},
+	"BP-SEC-002": {renders: "          {{- with .securityContext }}", field: "the container securityContext", fix: containerSecurityFix("readOnlyRootFilesystem")},
+	"BP-SEC-005": {renders: "{{- with .podSecurityContext }}", field: "the pod securityContext", fix: fixSeccompProfile},
+	"BP-SEC-009": {renders: `{{- if hasKey . "automountServiceAccountToken" }}`, field: "automountServiceAccountToken", fix: fixTokenAutomount},
+}
+
+// bestPracticeHints tell how to fix the auto-fixable best practices that
+// apply to the input resources rather than to the chart values.
+var bestPracticeHints = map[string]string{
+	"BP-API-001":  "regenerate with --api-upgrade",
+	"BP-IMG-001":  "regenerate with --pin-digests",
+	"BP-IMG-002":  "regenerate with --pin-digests",
+	"BP-IMG-003":  "regenerate with --image-rewrite to the approved registry",
+	"BP-STOR-001": "regenerate with --skip-persistent-volumes",
+}
+
+// ApplyBestPractices fixes the auto-fixable violations of practices in the
+// values of the workloads of chart: resource requests and limits of the web
+// profile, TCP probe stubs, securityContext settings of the restricted Pod
+// Security Standard, two replicas and no token automounting. Only workloads
+// whose template renders the fixed values are changed; the other violations
+// are reported as skipped. Copy-on-write. Returns the patched chart and the
+// fixes, sorted by practice and resource.
+func ApplyBestPractices(chart *types.GeneratedChart, graph *types.ResourceGraph, practices []pattern.BestPractice) (*types.GeneratedChart, []BestPracticeFix) {
+	result := copyChartTemplates(chart)
+	if graph == nil {
+		return result, nil
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &values); err != nil {
+		return result, nil
+	}
+
+	var fixes []BestPracticeFix
+	changed := false
+	for _, practice := range practices {
+		if practice.Compliant || !practice.AutoFixable {
+			continue
+		}
+		fixer, fixable := bestPracticeFixers[practice.ID]
+		for _, key := range practice.AffectedResources {
+			r, ok := graph.Resources[key]
+			if !ok || r.ValuesPath == "" {
+				continue
+			}
+			template, inChart := chart.Templates[r.TemplatePath]
+			workload, _ := lookupValuesPath(values, r.ValuesPath).(map[string]interface{})
+			if !inChart || workload == nil {
+				continue
+			}
+
+			fix := BestPracticeFix{Practice: practice.ID, Resource: key}
+			switch {
+			case !fixable:
+				fix.Skipped = bestPracticeHints[practice.ID]
+				if fix.Skipped == "" {
+					fix.Skipped = "no automatic fix"
+				}
+			case practice.ID == "BP-HA-002" && strings.Contains(template, "$probePort"):
+				fix.Skipped = "probes are synthesized from the container ports"
+			case !strings.Contains(template, fixer.renders):
+				fix.Skipped = fmt.Sprintf("the %s template does not render %s", key.GVK.Kind, fixer.field)
+			default:
+				changes, skipped := fixer.fix(key.GVK.Kind, workload)
+				for _, c := range changes {
+					fixes = append(fixes, BestPracticeFix{Practice: practice.ID, Resource: key, Path: r.ValuesPath + "." + c.path, Value: c.value})
+					changed = true
+				}
+				fix.Skipped = skipped
+			}
+			if fix.Skipped != "" {
+				fixes = append(fixes, fix)
+			}
+		}
+	}
+
+	if changed {
+		if out, err := yaml.Marshal(values); err == nil {
+			result.ValuesYAML = string(out)
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		if fixes[i].Practice != fixes[j].Practice {
+			return fixes[i].Practice < fixes[j].Practice
+		}
+		return fixes[i].Resource.String() < fixes[j].Resource.String()
+	})
+	return result, fixes
+}
+
+// lookupValuesPath returns the value at the dot-separated path of values, or
+// nil.
+func lookupValuesPath(values map[string]interface{}, path string) interface{} {
+	var node interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[key]
+	}
+	return node
+}
+
+// workloadContainers returns the containers of workload values.
+func workloadContainers(workload map[string]interface{}) []map[string]interface{} {
+	list, _ := workload["containers"].([]interface{})
+	containers := make([]map[string]interface{}, 0, len(list))
+	for _, c := range list {
+		if container, ok := c.(map[string]interface{}); ok {
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+// childMap returns the map at key of parent, adding an empty one if missing.
+func childMap(parent map[string]interface{}, key string) map[string]interface{} {
+	child, ok := parent[key].(map[string]interface{})
+	if !ok {
+		child = make(map[string]interface{})
+		parent[key] = child
+	}
+	return child
+}
+
+// fixResources sets the missing requests of the containers to their limits,
+// which Kubernetes defaults them to, or to the web profile, then the missing
+// limits to the web profile, raised to the request when it is higher.
+func fixResources(_ string, workload map[string]interface{}) ([]valueChange, string) {
+	profile := resourceProfiles[WorkloadWeb]
+	var changes []valueChange
+	for i, container := range workloadContainers(workload) {
+		resources := childMap(container, "resources")
+		requests := childMap(resources, "requests")
+		limits := childMap(resources, "limits")
+		for name, def := range map[string]string{"cpu": profile.CPURequest, "memory": profile.MemoryRequest} {
+			if _, set := requests[name]; set {
+				continue
+			}
+			var request interface{} = def
+			if limit, ok := limits[name]; ok {
+				request = limit
+			}
+			requests[name] = request
+			changes = append(changes, valueChange{fmt.Sprintf("containers[%d].resources.requests.%s", i, name), request})
+		}
+		for name, def := range map[string]string{"cpu": profile.CPULimit, "memory": profile.MemoryLimit} {
+			if _, set := limits[name]; set {
+				continue
+			}
+			limit := resource.MustParse(def)
+			if req, err := resource.ParseQuantity(fmt.Sprint(requests[name])); err == nil && req.Cmp(limit) > 0 {
+				limit = req
+			}
+			limits[name] = limit.String()
+			changes = append(changes, valueChange{fmt.Sprintf("containers[%d].resources.limits.%s", i, name), limit.String()})
+		}
+	}
+	return sortChanges(changes), ""
+}
+
+// fixReplicas runs Deployments without autoscaling with two replicas.
+func fixReplicas(kind string, workload map[string]interface{}) ([]valueChange, string) {
+	if kind != "Deployment" {
+		return nil, ""
+	}
+	if n, ok := valuesInt(workload["replicas"]); ok && n > 1 {
+		return nil, ""
+	}
+	workload["replicas"] = 2
+	return []valueChange{{"replicas", 2}}, ""
+}
+
+// fixProbes adds TCP readiness and liveness probe stubs on the first port of
+// the containers without probes. Containers without ports are reported.
+func fixProbes(_ string, workload map[string]interface{}) ([]valueChange, string) {
+	var changes []valueChange
+	var unprobed []string
+	for i, container := range workloadContainers(workload) {
+		if container["livenessProbe"] != nil || container["readinessProbe"] != nil {
+			continue
+		}
+		ports, _ := container["ports"].([]interface{})
+		var port interface{}
+		if len(ports) > 0 {
+			if p, ok := ports[0].(map[string]interface{}); ok {
+				port = p["containerPort"]
+			}
+		}
+		if port == nil {
+			unprobed = append(unprobed, fmt.Sprint(container["name"]))
+			continue
+		}
+		container["readinessProbe"] = map[string]interface{}{
+			"tcpSocket":     map[string]interface{}{"port": port},
+			"periodSeconds": 10,
+		}
+		container["livenessProbe"] = map[string]interface{}{
+			"tcpSocket":           map[string]interface{}{"port": port},
+			"initialDelaySeconds": 10,
+			"periodSeconds":       10,
+		}
+		changes = append(changes,
+			valueChange{fmt.Sprintf("containers[%d].livenessProbe", i), container["livenessProbe"]},
+			valueChange{fmt.Sprintf("containers[%d].readinessProbe", i), container["readinessProbe"]})
+	}
+	if len(unprobed) > 0 {
+		return changes, fmt.Sprintf("no port to probe in container %s", strings.Join(unprobed, ", "))
+	}
+	return changes, ""
+}
+
+// containerSecurityFix returns a fixer setting field of the securityContext
+// of the containers to true.
+func containerSecurityFix(field string) func(string, map[string]interface{}) ([]valueChange, string) {
+	return func(_ string, workload map[string]interface{}) ([]valueChange, string) {
+		var changes []valueChange
+		for i, container := range workloadContainers(workload) {
+			sc := childMap(container, "securityContext")
+			if v, ok := sc[field].(bool); ok && v {
+				continue
+			}
+			sc[field] = true
+			changes = append(changes, valueChange{fmt.Sprintf("containers[%d].securityContext.%s", i, field), true})
+		}
+		return changes, ""
+	}
+}
+
+// fixSeccompProfile sets the RuntimeDefault seccomp profile at pod level.
+func fixSeccompProfile(_ string, workload map[string]interface{}) ([]valueChange, string) {
+	podSC := childMap(workload, "podSecurityContext")
+	if _, set := podSC["seccompProfile"]; set {
+		return nil, "a seccompProfile is already set; check the container profiles"
+	}
+	podSC["seccompProfile"] = map[string]interface{}{"type": "RuntimeDefault"}
+	return []valueChange{{"podSecurityContext.seccompProfile.type", "RuntimeDefault"}}, ""
+}
+
+// fixTokenAutomount disables mounting the ServiceAccount token.
+func fixTokenAutomount(_ string, workload map[string]interface{}) ([]valueChange, string) {
+	if _, set := workload["automountServiceAccountToken"]; set {
+		return nil, ""
+	}
+	workload["automountServiceAccountToken"] = false
+	return []valueChange{{"automountServiceAccountToken", false}}, ""
+}
+
+// sortChanges orders changes by path.
+func sortChanges(changes []valueChange) []valueChange {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes
+}
@@ -0,0 +1,109 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestApplyBestPractices(t *testing.T) {
+	deployment := "{{- with $svc.deployment }}\n  replicas: {{ .replicas | default 1 }}\n" +
+		"      {{- with .podSecurityContext }}\n          {{- with .resources }}\n          {{- with .readinessProbe }}\n" +
+		"          {{- with .securityContext }}\n"
+	chart := makeChart("app", map[string]string{
+		"templates/web-deployment.yaml": deployment,
+		"templates/db-statefulset.yaml": "{{- with $svc.statefulSet }}\n          {{- with .resources }}\n",
+	})
+	chart.ValuesYAML = `services:
+  web:
+    deployment:
+      replicas: 1
+      containers:
+      - name: web
+        ports:
+        - containerPort: 8080
+        resources:
+          limits:
+            cpu: "2"
+      - name: sidecar
+  db:
+    statefulSet:
+      containers:
+      - name: db
+        resources:
+          requests:
+            memory: 1Gi
+`
+	graph := types.NewResourceGraph()
+	add := func(kind, name, valuesPath, templatePath string) types.ResourceKey {
+		r := makeProcessedResource(kind, name, "default", nil)
+		r.ValuesPath = valuesPath
+		r.TemplatePath = templatePath
+		graph.AddResource(r)
+		return r.Original.ResourceKey()
+	}
+	web := add("Deployment", "web", "services.web.deployment", "templates/web-deployment.yaml")
+	db := add("StatefulSet", "db", "services.db.statefulSet", "templates/db-statefulset.yaml")
+
+	practices := []pattern.BestPractice{
+		{ID: "BP-001", AutoFixable: true, AffectedResources: []types.ResourceKey{db}},
+		{ID: "BP-002", AutoFixable: true, AffectedResources: []types.ResourceKey{web}},
+		{ID: "BP-HA-001", AutoFixable: true, AffectedResources: []types.ResourceKey{web}},
+		{ID: "BP-HA-002", AutoFixable: true, AffectedResources: []types.ResourceKey{web}},
+		{ID: "BP-SEC-001", AutoFixable: true, AffectedResources: []types.ResourceKey{web, db}},
+		{ID: "BP-IMG-001", AutoFixable: true, AffectedResources: []types.ResourceKey{web}},
+		{ID: "BP-SEC-003", AffectedResources: []types.ResourceKey{web}},
+		{ID: "BP-SEC-005", AutoFixable: true, Compliant: true},
+	}
+	result, fixes := ApplyBestPractices(chart, graph, practices)
+
+	var report []string
+	for _, fix := range fixes {
+		report = append(report, fix.String())
+	}
+	got := strings.Join(report, "\n")
+	for _, want := range []string{
+		`BP-001      StatefulSet/db: services.db.statefulSet.containers[0].resources.limits.memory = "1Gi"`,
+		`BP-002      Deployment/web: services.web.deployment.containers[0].resources.requests.cpu = "2"`,
+		`BP-002      Deployment/web: services.web.deployment.containers[1].resources.requests.memory = "128Mi"`,
+		`BP-HA-001   Deployment/web: services.web.deployment.replicas = 2`,
+		`BP-HA-002   Deployment/web: services.web.deployment.containers[0].readinessProbe = {"periodSeconds":10,"tcpSocket":{"port":8080}}`,
+		`BP-HA-002   Deployment/web: not fixed, no port to probe in container sidecar`,
+		`BP-IMG-001  Deployment/web: not fixed, regenerate with --pin-digests`,
+		`BP-SEC-001  Deployment/web: services.web.deployment.containers[1].securityContext.runAsNonRoot = true`,
+		`BP-SEC-001  StatefulSet/db: not fixed, the StatefulSet template does not render the container securityContext`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in the report, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "BP-SEC-003") || strings.Contains(got, "BP-SEC-005") {
+		t.Errorf("practices that are not fixable or compliant should not be reported, got:\n%s", got)
+	}
+
+	var values struct {
+		Services map[string]map[string]struct {
+			Replicas   int `json:"replicas"`
+			Containers []struct {
+				Resources map[string]map[string]string `json:"resources"`
+			} `json:"containers"`
+		}
+	}
+	if err := yaml.Unmarshal([]byte(result.ValuesYAML), &values); err != nil {
+		t.Fatal(err)
+	}
+	if got := values.Services["web"]["deployment"]; got.Replicas != 2 || got.Containers[0].Resources["limits"]["cpu"] != "2" {
+		t.Errorf("unexpected web values: %+v", got)
+	}
+	if limits := values.Services["db"]["statefulSet"].Containers[0].Resources["limits"]; limits["memory"] != "1Gi" || limits["cpu"] != "500m" {
+		t.Errorf("limits should default to the web profile and be at least the requests, got %v", limits)
+	}
+
+	if _, again := ApplyBestPractices(result, graph, practices); len(again) != 3 {
+		t.Errorf("fixing twice should only report the skipped violations, got %v", again)
+	}
+}
@@ -0,0 +1,296 @@
+package generator
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+// Files of an air-gap bundle written by WriteBundle.
+const (
+	// BundleImagesFile lists the images of the bundled charts, one per line.
+	BundleImagesFile = "images.txt"
+
+	// BundleMirrorScript copies the images to the target registry.
+	BundleMirrorScript = "mirror-images.sh"
+
+	// BundleAirgapValues points the charts at the target registry.
+	BundleAirgapValues = "values-airgap.yaml"
+
+	// BundleChecksumsFile lists the sha256 of every other file of the
+	// bundle, in the format of sha256sum.
+	BundleChecksumsFile = "SHA256SUMS"
+)
+
+// bundleModTime is the modification time of every bundled file, so that
+// bundling the same charts twice yields the same tarball.
+var bundleModTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// BundleFile is a file of an air-gap bundle.
+type BundleFile struct {
+	// Path is the slash-separated path of the file in the bundle.
+	Path string
+
+	// Content is the file content.
+	Content []byte
+
+	// Executable marks scripts.
+	Executable bool
+}
+
+// WriteBundle writes files to w as one gzipped tarball, followed by a
+// SHA256SUMS file with their checksums.
+func WriteBundle(w io.Writer, files []BundleFile) error {
+	gz := gzip.NewWriter(w)
+	gz.ModTime = bundleModTime
+	tw := tar.NewWriter(gz)
+
+	var sums strings.Builder
+	for _, f := range files {
+		if f.Path == BundleChecksumsFile {
+			return fmt.Errorf("bundle: %s is reserved", BundleChecksumsFile)
+		}
+		sum := sha256.Sum256(f.Content)
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), f.Path)
+		if err := writeBundleFile(tw, f); err != nil {
+			return err
+		}
+	}
+	if err := writeBundleFile(tw, BundleFile{Path: BundleChecksumsFile, Content: []byte(sums.String())}); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeBundleFile adds f to tw.
+func writeBundleFile(tw *tar.Writer, f BundleFile) error {
+	mode := int64(0644)
+	if f.Executable {
+		mode = 0755
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    f.Path,
+		Mode:    mode,
+		Size:    int64(len(f.Content)),
+		ModTime: bundleModTime,
+	}); err != nil {
+		return fmt.Errorf("bundle: %s: %w", f.Path, err)
+	}
+	if _, err := tw.Write(f.Content); err != nil {
+		return fmt.Errorf("bundle: %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// ReadBundle reads a bundle written by WriteBundle and checks its files
+// against SHA256SUMS: every file must be listed with its checksum and every
+// listed file must be present. It returns the files by path, without
+// SHA256SUMS.
+func ReadBundle(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("bundle: %s: %w", hdr.Name, err)
+		}
+		files[hdr.Name] = data
+	}
+
+	sums, ok := files[BundleChecksumsFile]
+	if !ok {
+		return nil, fmt.Errorf("bundle: %s not found", BundleChecksumsFile)
+	}
+	delete(files, BundleChecksumsFile)
+
+	var errs []error
+	listed := make(map[string]bool, len(files))
+	scanner := bufio.NewScanner(strings.NewReader(string(sums)))
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("bundle: %s: invalid line %q", BundleChecksumsFile, scanner.Text())
+		}
+		listed[name] = true
+		data, ok := files[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: missing", name))
+			continue
+		}
+		if actual := sha256.Sum256(data); hex.EncodeToString(actual[:]) != sum {
+			errs = append(errs, fmt.Errorf("%s: checksum mismatch", name))
+		}
+	}
+	var unlisted []string
+	for name := range files {
+		if !listed[name] {
+			unlisted = append(unlisted, name)
+		}
+	}
+	sort.Strings(unlisted)
+	for _, name := range unlisted {
+		errs = append(errs, fmt.Errorf("%s: not listed in %s", name, BundleChecksumsFile))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("bundle: %w", errors.Join(errs...))
+	}
+	return files, nil
+}
+
+// ChartImages returns the images of the containers the chart in chartDir
+// and its subcharts in charts/ render with their default values.
+func ChartImages(chartDir string) ([]ImageRef, error) {
+	dirs := []string{chartDir}
+	if entries, err := os.ReadDir(filepath.Join(chartDir, "charts")); err == nil {
+		for _, e := range entries {
+			sub := filepath.Join(chartDir, "charts", e.Name())
+			if _, err := os.Stat(filepath.Join(sub, "Chart.yaml")); e.IsDir() && err == nil {
+				dirs = append(dirs, sub)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var refs []ImageRef
+	for _, dir := range dirs {
+		rendered, err := helm.RenderChart(dir, helm.RenderOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		for _, doc := range strings.Split("\n"+rendered.Bundle(), "\n---") {
+			var m map[string]interface{}
+			if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+				return nil, fmt.Errorf("%s: rendered output is not valid YAML: %w", dir, err)
+			}
+			for _, c := range processor.ContainerImages(&unstructured.Unstructured{Object: m}) {
+				if !seen[c.Image] {
+					seen[c.Image] = true
+					refs = append(refs, parseImageRef(c.Image))
+				}
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].FullRef < refs[j].FullRef })
+	return refs, nil
+}
+
+// MirroredImage is the result of checking that an image of a bundle has
+// been mirrored to the target registry.
+type MirroredImage struct {
+	// Image is the image reference of the charts.
+	Image string
+
+	// Target is the reference of the mirrored image.
+	Target string
+
+	// Digest is the digest of the mirrored image, empty when it was not
+	// found.
+	Digest string
+
+	// Err reports why the image is not mirrored.
+	Err error
+}
+
+// VerifyMirroredImages checks that every image of images has been mirrored
+// to registry, the way the mirror script copies them, by resolving the
+// digest of its target reference. Images pinned by digest must have been
+// mirrored with that digest. When platforms is not nil, images listed with
+// their Platforms must have been mirrored with each of them, with the same
+// digests.
+func VerifyMirroredImages(ctx context.Context, images []ImageRef, registry string, resolve processor.DigestResolver, platforms PlatformResolver) []MirroredImage {
+	results := make([]MirroredImage, 0, len(images))
+	for _, ref := range images {
+		result := MirroredImage{Image: ref.FullRef, Target: strings.TrimSuffix(registry, "/") + "/" + mirrorTarget(ref)}
+		digest, err := resolve(ctx, result.Target)
+		switch {
+		case err != nil:
+			result.Err = err
+		case ref.Digest != "" && digest != ref.Digest:
+			result.Digest = digest
+			result.Err = fmt.Errorf("digest %s does not match the pinned digest %s", digest, ref.Digest)
+		default:
+			result.Digest = digest
+			if platforms != nil && len(ref.Platforms) > 0 {
+				result.Err = verifyMirroredPlatforms(ctx, ref.Platforms, result.Target, platforms)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// verifyMirroredPlatforms checks that the image target has the manifest of
+// every platform of want. Manifests are compared by digest: a platform
+// copied out of a manifest list may not record its variant.
+func verifyMirroredPlatforms(ctx context.Context, want []ImagePlatform, target string, resolve PlatformResolver) error {
+	mirrored, err := resolve(ctx, target)
+	if err != nil {
+		return err
+	}
+	digests := make(map[string]bool, len(mirrored))
+	for _, p := range mirrored {
+		digests[p.Digest] = true
+	}
+	var errs []error
+	for _, p := range want {
+		if !digests[p.Digest] {
+			errs = append(errs, fmt.Errorf("platform %s (%s) not mirrored (has %s)", p.Platform, p.Digest, platformNames(mirrored)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseImageList parses images.txt content, one image per line, with the
+// platform lines GenerateImageList writes after an image.
+func ParseImageList(content string) []ImageRef {
+	var refs []ImageRef
+	for _, line := range strings.Split(content, "\n") {
+		if platform, ok := strings.CutPrefix(line, platformLinePrefix); ok && len(refs) > 0 {
+			if fields := strings.Fields(platform); len(fields) == 2 && strings.Contains(fields[0], "/") {
+				last := &refs[len(refs)-1]
+				last.Platforms = append(last.Platforms, ImagePlatform{Platform: fields[0], Digest: fields[1]})
+			}
+			continue
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			refs = append(refs, parseImageRef(line))
+		}
+	}
+	return refs
+}
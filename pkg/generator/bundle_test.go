@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteBundle_ReadBundle(t *testing.T) {
+	files := []BundleFile{
+		{Path: "charts/app-0.1.0.tgz", Content: []byte("chart")},
+		{Path: BundleImagesFile, Content: []byte("nginx:1.25\n")},
+		{Path: BundleMirrorScript, Content: []byte("#!/usr/bin/env bash\n"), Executable: true},
+	}
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, files); err != nil {
+		t.Fatalf("WriteBundle: %v", err)
+	}
+
+	var again bytes.Buffer
+	if err := WriteBundle(&again, files); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("bundles of the same files should be identical")
+	}
+
+	got, err := ReadBundle(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBundle: %v", err)
+	}
+	if len(got) != len(files) {
+		t.Errorf("expected %d files, got %d", len(files), len(got))
+	}
+	for _, f := range files {
+		if !bytes.Equal(got[f.Path], f.Content) {
+			t.Errorf("%s = %q, want %q", f.Path, got[f.Path], f.Content)
+		}
+	}
+
+	if err := WriteBundle(&bytes.Buffer{}, []BundleFile{{Path: BundleChecksumsFile}}); err == nil {
+		t.Errorf("expected an error bundling a file named %s", BundleChecksumsFile)
+	}
+}
+
+func TestReadBundle_Tampered(t *testing.T) {
+	// Neither listed checksum is that of a file of the bundle.
+	sums := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef  images.txt\n" +
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef  values-airgap.yaml\n"
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []BundleFile{
+		{Path: BundleImagesFile, Content: []byte("nginx:1.25\n")},
+		{Path: BundleMirrorScript, Content: []byte("#!/usr/bin/env bash\n")},
+		{Path: BundleChecksumsFile, Content: []byte(sums)},
+	} {
+		if err := writeBundleFile(tw, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadBundle(&buf)
+	if err == nil {
+		t.Fatal("expected an error for a tampered bundle")
+	}
+	for _, want := range []string{
+		"images.txt: checksum mismatch",
+		"values-airgap.yaml: missing",
+		"mirror-images.sh: not listed in SHA256SUMS",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestVerifyMirroredImages(t *testing.T) {
+	mirrored := map[string]string{
+		"registry.internal/nginx:1.25":       "sha256:aaa",
+		"registry.internal/team/api:v2":      "sha256:bbb",
+		"registry.internal/redis@sha256:ccc": "sha256:ddd",
+	}
+	resolve := func(_ context.Context, image string) (string, error) {
+		if digest, ok := mirrored[image]; ok {
+			return digest, nil
+		}
+		return "", errors.New("MANIFEST_UNKNOWN")
+	}
+
+	images := ParseImageList("nginx:1.25\nghcr.io/team/api:v2\n\nredis@sha256:ccc\npostgres:16\n")
+	results := VerifyMirroredImages(context.Background(), images, "registry.internal/", resolve, nil)
+
+	want := []struct {
+		target string
+		err    string
+	}{
+		{"registry.internal/nginx:1.25", ""},
+		{"registry.internal/team/api:v2", ""},
+		{"registry.internal/redis@sha256:ccc", "does not match the pinned digest sha256:ccc"},
+		{"registry.internal/postgres:16", "MANIFEST_UNKNOWN"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Target != w.target {
+			t.Errorf("results[%d].Target = %q, want %q", i, r.Target, w.target)
+		}
+		switch {
+		case w.err == "" && r.Err != nil:
+			t.Errorf("%s: unexpected error: %v", r.Image, r.Err)
+		case w.err != "" && (r.Err == nil || !strings.Contains(r.Err.Error(), w.err)):
+			t.Errorf("%s: expected error containing %q, got %v", r.Image, w.err, r.Err)
+		}
+	}
+}
+
+func TestGenerateMirrorScriptWith(t *testing.T) {
+	refs := []ImageRef{parseImageRef("ghcr.io/team/api:v2"), parseImageRef("nginx:1.25")}
+
+	script, err := GenerateMirrorScriptWith(refs, "registry.internal", MirrorToolCrane)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"crane copy ghcr.io/team/api:v2 ${TARGET_REGISTRY}/team/api:v2\n",
+		"crane copy nginx:1.25 ${TARGET_REGISTRY}/nginx:1.25\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected %q in script:\n%s", want, script)
+		}
+	}
+
+	if _, err := GenerateMirrorScriptWith(refs, "registry.internal", "docker"); err == nil {
+		t.Error("expected an error for an unknown mirror tool")
+	}
+}
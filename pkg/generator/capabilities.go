@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// capabilityFallbacks maps apiVersion/Kind of resources whose API moved to
+// GA to the older apiVersion used by clusters that do not serve it yet.
+var capabilityFallbacks = map[string]string{
+	"policy/v1/PodDisruptionBudget":          "policy/v1beta1",
+	"autoscaling/v2/HorizontalPodAutoscaler": "autoscaling/v2beta2",
+	"batch/v1/CronJob":                       "batch/v1beta1",
+}
+
+// apiVersionRegex matches the top-level `apiVersion:` line of a YAML document.
+var apiVersionRegex = regexp.MustCompile(`(?m)^apiVersion:\s*(\S+)\s*$`)
+
+// InjectCapabilitiesGuards makes the templates of chart depend on the APIs
+// served by the cluster through .Capabilities.APIVersions.Has:
+//   - resources of APIs that are not built into Kubernetes (CRDs, alpha and
+//     beta APIs) are rendered only when their group/version is served;
+//   - resources with a GA apiVersion that has an older fallback (PDB
+//     policy/v1, HPA autoscaling/v2, CronJob batch/v1) select it when the GA
+//     version is not served.
+//
+// Templates with several apiVersions are left alone. Copy-on-write. Returns
+// the patched chart and the count of guarded templates.
+func InjectCapabilitiesGuards(chart *types.GeneratedChart) (*types.GeneratedChart, int) {
+	result := copyChartTemplates(chart)
+	builtin := make(map[string]bool, len(helm.DefaultAPIVersions))
+	for _, v := range helm.DefaultAPIVersions {
+		builtin[v] = true
+	}
+
+	count := 0
+	for path, content := range chart.Templates {
+		matches := apiVersionRegex.FindAllStringSubmatch(content, -1)
+		if len(matches) != 1 || strings.Contains(matches[0][1], "{{") {
+			continue
+		}
+		apiVersion := matches[0][1]
+		kind := extractKind(content)
+
+		if fallback, ok := capabilityFallbacks[apiVersion+"/"+kind]; ok {
+			result.Templates[path] = apiVersionRegex.ReplaceAllLiteralString(content, fmt.Sprintf(
+				"apiVersion: {{ if $.Capabilities.APIVersions.Has %q }}%s{{ else }}%s{{ end }}",
+				apiVersion, apiVersion, fallback))
+			count++
+			continue
+		}
+		if builtin[apiVersion] || strings.Contains(content, "$.Capabilities.APIVersions.Has") {
+			continue
+		}
+		result.Templates[path] = fmt.Sprintf("{{- if $.Capabilities.APIVersions.Has %q }}\n%s\n{{- end }}",
+			apiVersion, strings.TrimRight(content, "\n"))
+		count++
+	}
+
+	return result, count
+}
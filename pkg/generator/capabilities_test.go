@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInjectCapabilitiesGuards(t *testing.T) {
+	chart := makeChart("myapp", map[string]string{
+		"templates/monitor.yaml":    "{{- with .Values.monitor }}\napiVersion: monitoring.coreos.com/v1\nkind: ServiceMonitor\nmetadata:\n  name: myapp\n{{- end }}\n",
+		"templates/pdb.yaml":        "apiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: myapp\n",
+		"templates/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: myapp\n",
+		"templates/both.yaml":       "apiVersion: v1\nkind: ConfigMap\n---\napiVersion: example.com/v1\nkind: Widget\n",
+	})
+
+	result, count := InjectCapabilitiesGuards(chart)
+	if count != 2 {
+		t.Errorf("expected 2 guarded templates, got %d", count)
+	}
+	monitor := result.Templates["templates/monitor.yaml"]
+	if !strings.HasPrefix(monitor, "{{- if $.Capabilities.APIVersions.Has \"monitoring.coreos.com/v1\" }}\n{{- with .Values.monitor }}") ||
+		!strings.HasSuffix(monitor, "{{- end }}\n{{- end }}") {
+		t.Errorf("expected the ServiceMonitor to be guarded by its API:\n%s", monitor)
+	}
+	if pdb := result.Templates["templates/pdb.yaml"]; !strings.Contains(pdb,
+		`apiVersion: {{ if $.Capabilities.APIVersions.Has "policy/v1" }}policy/v1{{ else }}policy/v1beta1{{ end }}`) {
+		t.Errorf("expected the PDB to fall back to policy/v1beta1:\n%s", pdb)
+	}
+	for _, path := range []string{"templates/deployment.yaml", "templates/both.yaml"} {
+		if result.Templates[path] != chart.Templates[path] {
+			t.Errorf("%s should be left alone:\n%s", path, result.Templates[path])
+		}
+	}
+	if strings.Contains(chart.Templates["templates/pdb.yaml"], "Capabilities") {
+		t.Error("the original chart must not be modified")
+	}
+
+	again, count := InjectCapabilitiesGuards(result)
+	if count != 0 || again.Templates["templates/monitor.yaml"] != monitor {
+		t.Errorf("expected guarded templates to be left alone, %d guarded", count)
+	}
+}
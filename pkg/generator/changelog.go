@@ -0,0 +1,352 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ChangelogFile is the chart changelog written by BumpChart and
+// UpdateGenerationChangelog. It is maintained by dhg across generations and is
+// not part of the generated files.
+const ChangelogFile = "CHANGELOG.md"
+
+// changelogTitle starts the CHANGELOG.md written by BumpChart and
+// UpdateGenerationChangelog.
+const changelogTitle = "# Changelog\n"
+
+// templateKindPattern finds the kind of the resource rendered by a template.
+var templateKindPattern = regexp.MustCompile(`(?m)^kind:\s*([A-Za-z]+)\s*$`)
+
+// ResourceChange is a chart template added, removed or changed between two
+// generations.
+type ResourceChange struct {
+	// Kind is the resource kind declared by the template, if any.
+	Kind string
+
+	// Path is relative to the chart directory, e.g. templates/web-deployment.yaml.
+	Path string
+}
+
+// ValueChange is a default in values.yaml that changed between two generations.
+type ValueChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// GenerationChanges summarizes the differences between two generations of a
+// chart, as found by DetectDrift.
+type GenerationChanges struct {
+	// Version is the chart version of the new generation.
+	Version string
+
+	AddedResources   []ResourceChange
+	RemovedResources []ResourceChange
+	ChangedResources []ResourceChange
+
+	// ChangedDefaults are values keys present in both generations with
+	// different values.
+	ChangedDefaults []ValueChange
+
+	// AddedKeys and RemovedKeys are values keys present in one generation
+	// only, collapsed to the top-most new (or removed) map.
+	AddedKeys   []string
+	RemovedKeys []string
+}
+
+// IsEmpty reports whether the generations are the same.
+func (c *GenerationChanges) IsEmpty() bool {
+	return len(c.AddedResources)+len(c.RemovedResources)+len(c.ChangedResources)+
+		len(c.ChangedDefaults)+len(c.AddedKeys)+len(c.RemovedKeys) == 0
+}
+
+// CompareGenerations compares the previous generation of a chart with the
+// next one. Partials and NOTES.txt are not compared as resources.
+func CompareGenerations(prev, next *types.GeneratedChart) *GenerationChanges {
+	var meta struct {
+		Version string `json:"version"`
+	}
+	_ = yaml.Unmarshal([]byte(next.ChartYAML), &meta)
+	changes := &GenerationChanges{Version: meta.Version}
+
+	drift := DetectDrift(resourceTemplates(prev), resourceTemplates(next))
+	for _, item := range drift.Templates {
+		switch item.Category {
+		case DriftAdded:
+			changes.AddedResources = append(changes.AddedResources, resourceChange(item.Path, next.Templates[item.Path]))
+		case DriftRemoved:
+			changes.RemovedResources = append(changes.RemovedResources, resourceChange(item.Path, prev.Templates[item.Path]))
+		case DriftChanged:
+			changes.ChangedResources = append(changes.ChangedResources, resourceChange(item.Path, next.Templates[item.Path]))
+		}
+	}
+
+	prevValues, nextValues := flattenYAML(prev.ValuesYAML), flattenYAML(next.ValuesYAML)
+	for _, item := range drift.Values {
+		switch item.Category {
+		case DriftAdded:
+			changes.AddedKeys = append(changes.AddedKeys, item.Path)
+		case DriftRemoved:
+			changes.RemovedKeys = append(changes.RemovedKeys, item.Path)
+		case DriftChanged:
+			changes.ChangedDefaults = append(changes.ChangedDefaults, valueChanges(item.Path, prevValues[item.Path], nextValues[item.Path])...)
+		}
+	}
+	changes.AddedKeys = collapseKeys(changes.AddedKeys, prevValues)
+	changes.RemovedKeys = collapseKeys(changes.RemovedKeys, nextValues)
+	return changes
+}
+
+// UpdateGenerationChangelog compares the previous generation of the chart in
+// chartDir (its base snapshot) with next and prepends a section with the
+// differences to chartDir/CHANGELOG.md. It returns nil changes when chartDir
+// has no previous generation, and writes nothing when the generations are the
+// same. Call it before the base snapshot is replaced.
+func UpdateGenerationChangelog(chartDir string, next *types.GeneratedChart, now time.Time) (*GenerationChanges, error) {
+	prevDir := filepath.Join(chartDir, BaseSnapshotDir)
+	if _, err := os.Stat(prevDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	prev, err := ReadGeneratedChart(prevDir)
+	if err != nil {
+		return nil, fmt.Errorf("changelog: %w", err)
+	}
+	changes := CompareGenerations(prev, next)
+	if changes.IsEmpty() {
+		return changes, nil
+	}
+	if err := prependChangelogFile(chartDir, "", generationChangelogEntry(changes, now)); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// generationChangelogEntry renders changes as a CHANGELOG.md section.
+func generationChangelogEntry(changes *GenerationChanges, now time.Time) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Regenerated %s", now.Format("2006-01-02 15:04"))
+	if changes.Version != "" {
+		fmt.Fprintf(&sb, " (chart %s)", changes.Version)
+	}
+	sb.WriteString("\n\n")
+
+	writeResources := func(heading string, resources []ResourceChange) {
+		if len(resources) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "### %s\n\n", heading)
+		for _, r := range resources {
+			if r.Kind != "" {
+				fmt.Fprintf(&sb, "- %s `%s`\n", r.Kind, r.Path)
+			} else {
+				fmt.Fprintf(&sb, "- `%s`\n", r.Path)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	writeKeys := func(heading string, keys []string) {
+		if len(keys) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "### %s\n\n", heading)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "- `%s`\n", k)
+		}
+		sb.WriteString("\n")
+	}
+
+	writeResources("Added resources", changes.AddedResources)
+	writeResources("Removed resources", changes.RemovedResources)
+	writeResources("Changed resources", changes.ChangedResources)
+	if len(changes.ChangedDefaults) > 0 {
+		sb.WriteString("### Changed defaults\n\n")
+		for _, v := range changes.ChangedDefaults {
+			fmt.Fprintf(&sb, "- `%s`: `%s` → `%s`\n", v.Key, v.Old, v.New)
+		}
+		sb.WriteString("\n")
+	}
+	writeKeys("New values keys", changes.AddedKeys)
+	writeKeys("Removed values keys", changes.RemovedKeys)
+	return sb.String()
+}
+
+// prependChangelogFile adds entry at the top of chartDir/CHANGELOG.md.
+func prependChangelogFile(chartDir, replaceHeading, entry string) error {
+	changelogPath := filepath.Join(chartDir, ChangelogFile)
+	changelog, err := os.ReadFile(changelogPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("changelog: %w", err)
+	}
+	return writeFile(changelogPath, prependChangelog(string(changelog), replaceHeading, entry))
+}
+
+// prependChangelog adds entry at the top of changelog, after its title. When
+// the first entry starts with replaceHeading, entry replaces it.
+func prependChangelog(changelog, replaceHeading, entry string) string {
+	body := strings.TrimLeft(strings.TrimPrefix(changelog, changelogTitle), "\n")
+	if replaceHeading != "" && strings.HasPrefix(body, replaceHeading) {
+		if next := strings.Index(body[len(replaceHeading):], "\n## "); next >= 0 {
+			body = body[len(replaceHeading)+next+1:]
+		} else {
+			body = ""
+		}
+	}
+	return changelogTitle + "\n" + entry + body
+}
+
+// ReadGeneratedChart reads the Chart.yaml, values.yaml and templates of the
+// chart in dir, without their ownership headers.
+func ReadGeneratedChart(dir string) (*types.GeneratedChart, error) {
+	files, err := readChartFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	chart := &types.GeneratedChart{Path: dir, Templates: make(map[string]string)}
+	for rel, content := range files {
+		if hasOwnershipHeader(content) {
+			_, content, _ = strings.Cut(content, "\n")
+		}
+		switch {
+		case rel == "Chart.yaml":
+			chart.ChartYAML = content
+		case rel == "values.yaml":
+			chart.ValuesYAML = content
+		case strings.HasPrefix(rel, "templates/"):
+			chart.Templates[rel] = content
+		}
+	}
+	return chart, nil
+}
+
+// resourceTemplates returns a copy of chart with only the templates that
+// render resources, leaving out partials and NOTES.txt.
+func resourceTemplates(chart *types.GeneratedChart) *types.GeneratedChart {
+	filtered := &types.GeneratedChart{ValuesYAML: chart.ValuesYAML, Templates: make(map[string]string, len(chart.Templates))}
+	for rel, content := range chart.Templates {
+		if base := path.Base(rel); !strings.HasPrefix(base, "_") && base != "NOTES.txt" {
+			filtered.Templates[rel] = content
+		}
+	}
+	return filtered
+}
+
+// valueChanges returns the changed leaves of a values key. Lists of the same
+// length are compared item by item; items with a name are addressed as
+// key[name], like containers[web].
+func valueChanges(key string, prev, next interface{}) []ValueChange {
+	switch p := prev.(type) {
+	case map[string]interface{}:
+		n, ok := next.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(p)+len(n))
+		for k := range p {
+			keys[k] = true
+		}
+		for k := range n {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		var changes []ValueChange
+		for _, k := range sorted {
+			changes = append(changes, valueChanges(key+"."+k, p[k], n[k])...)
+		}
+		return changes
+	case []interface{}:
+		n, ok := next.([]interface{})
+		if !ok || len(n) != len(p) {
+			break
+		}
+		var changes []ValueChange
+		for i := range p {
+			index := strconv.Itoa(i)
+			if item, ok := p[i].(map[string]interface{}); ok {
+				if name, ok := item["name"].(string); ok && name != "" {
+					index = name
+				}
+			}
+			changes = append(changes, valueChanges(key+"["+index+"]", p[i], n[i])...)
+		}
+		return changes
+	}
+	if reflect.DeepEqual(prev, next) {
+		return nil
+	}
+	return []ValueChange{{Key: key, Old: formatValue(prev), New: formatValue(next)}}
+}
+
+// formatValue formats a values leaf for the changelog.
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "unset"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func resourceChange(rel, content string) ResourceChange {
+	change := ResourceChange{Path: rel}
+	if m := templateKindPattern.FindStringSubmatch(content); m != nil {
+		change.Kind = m[1]
+	}
+	return change
+}
+
+// collapseKeys reduces flattened values keys missing from other to their
+// top-most path that other does not have, so that a new service is listed
+// once instead of key by key.
+func collapseKeys(keys []string, other map[string]interface{}) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixes := make(map[string]bool)
+	for k := range other {
+		parts := strings.Split(k, ".")
+		for i := 1; i <= len(parts); i++ {
+			prefixes[strings.Join(parts[:i], ".")] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var collapsed []string
+	for _, k := range keys {
+		parts := strings.Split(k, ".")
+		top := k
+		for i := 1; i <= len(parts); i++ {
+			if p := strings.Join(parts[:i], "."); !prefixes[p] {
+				top = p
+				break
+			}
+		}
+		if !seen[top] {
+			seen[top] = true
+			collapsed = append(collapsed, top)
+		}
+	}
+	sort.Strings(collapsed)
+	return collapsed
+}
@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const changelogPrevValues = `
+services:
+  web:
+    deployment:
+      replicas: 1
+      containers:
+        - name: web
+          image:
+            repository: nginx
+            tag: "1.25"
+    service:
+      type: ClusterIP
+`
+
+const changelogNextValues = `
+services:
+  web:
+    deployment:
+      replicas: 3
+      containers:
+        - name: web
+          image:
+            repository: nginx
+            tag: "1.27"
+  worker:
+    deployment:
+      replicas: 1
+`
+
+func changelogCharts() (prev, next *types.GeneratedChart) {
+	prev = &types.GeneratedChart{
+		ChartYAML:  "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		ValuesYAML: changelogPrevValues,
+		Templates: map[string]string{
+			"templates/web-deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\n",
+			"templates/web-service.yaml":    "apiVersion: v1\nkind: Service\n",
+			"templates/_helpers.tpl":        "{{- define \"old\" -}}{{- end -}}\n",
+		},
+	}
+	next = &types.GeneratedChart{
+		ChartYAML:  "apiVersion: v2\nname: app\nversion: 0.2.0\n",
+		ValuesYAML: changelogNextValues,
+		Templates: map[string]string{
+			"templates/web-deployment.yaml":    "apiVersion: apps/v1\nkind: Deployment\n",
+			"templates/worker-deployment.yaml": "{{- if .Values.services.worker.enabled }}\napiVersion: apps/v1\nkind: Deployment\n{{- end }}\n",
+			"templates/_helpers.tpl":           "{{- define \"new\" -}}{{- end -}}\n",
+		},
+	}
+	return prev, next
+}
+
+func TestCompareGenerations(t *testing.T) {
+	prev, next := changelogCharts()
+	changes := CompareGenerations(prev, next)
+
+	if changes.Version != "0.2.0" {
+		t.Errorf("Version = %q; want 0.2.0", changes.Version)
+	}
+	if len(changes.AddedResources) != 1 || changes.AddedResources[0] != (ResourceChange{Kind: "Deployment", Path: "templates/worker-deployment.yaml"}) {
+		t.Errorf("AddedResources = %+v", changes.AddedResources)
+	}
+	if len(changes.RemovedResources) != 1 || changes.RemovedResources[0] != (ResourceChange{Kind: "Service", Path: "templates/web-service.yaml"}) {
+		t.Errorf("RemovedResources = %+v", changes.RemovedResources)
+	}
+	if len(changes.ChangedResources) != 0 {
+		t.Errorf("partials should not be compared as resources: %+v", changes.ChangedResources)
+	}
+	want := []ValueChange{
+		{Key: "services.web.deployment.containers[web].image.tag", Old: "1.25", New: "1.27"},
+		{Key: "services.web.deployment.replicas", Old: "1", New: "3"},
+	}
+	if len(changes.ChangedDefaults) != len(want) {
+		t.Fatalf("ChangedDefaults = %+v; want %+v", changes.ChangedDefaults, want)
+	}
+	for i := range want {
+		if changes.ChangedDefaults[i] != want[i] {
+			t.Errorf("ChangedDefaults[%d] = %+v; want %+v", i, changes.ChangedDefaults[i], want[i])
+		}
+	}
+	if strings.Join(changes.AddedKeys, ",") != "services.worker" {
+		t.Errorf("AddedKeys = %v; want the new service collapsed to services.worker", changes.AddedKeys)
+	}
+	if strings.Join(changes.RemovedKeys, ",") != "services.web.service" {
+		t.Errorf("RemovedKeys = %v; want services.web.service", changes.RemovedKeys)
+	}
+}
+
+func TestCompareGenerations_Identical(t *testing.T) {
+	prev, _ := changelogCharts()
+	if changes := CompareGenerations(prev, prev); !changes.IsEmpty() {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestReadGeneratedChart_StripsOwnershipHeaders(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"Chart.yaml":                    "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"values.yaml":                   "replicas: 1\n",
+		"templates/web-deployment.yaml": "kind: Deployment\n",
+		ChangelogFile:                   "# Changelog\n",
+	})
+	if _, err := StampOwnership(dir, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	chart, err := ReadGeneratedChart(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chart.Templates["templates/web-deployment.yaml"] != "kind: Deployment\n" || chart.ValuesYAML != "replicas: 1\n" {
+		t.Errorf("ownership headers not stripped: %+v", chart)
+	}
+	if data := readFile(t, filepath.Join(dir, ChangelogFile)); data != "# Changelog\n" {
+		t.Errorf("CHANGELOG.md should not be stamped:\n%s", data)
+	}
+}
+
+func TestUpdateGenerationChangelog(t *testing.T) {
+	chartDir := t.TempDir()
+	prev, next := changelogCharts()
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	if changes, err := UpdateGenerationChangelog(chartDir, next, now); err != nil || changes != nil {
+		t.Fatalf("first generation: got %+v, %v; want no changes", changes, err)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, ChangelogFile)); !os.IsNotExist(err) {
+		t.Error("the first generation should not write a changelog")
+	}
+
+	files := map[string]string{"Chart.yaml": prev.ChartYAML, "values.yaml": prev.ValuesYAML}
+	for rel, content := range prev.Templates {
+		files[rel] = content
+	}
+	writeTree(t, filepath.Join(chartDir, BaseSnapshotDir), files)
+	writeTree(t, chartDir, map[string]string{ChangelogFile: "# Changelog\n\n## [0.1.0] - 2026-10-01\n\nInitial release.\n\n"})
+
+	if _, err := UpdateGenerationChangelog(chartDir, next, now); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\n" +
+		"## Regenerated 2026-10-17 09:30 (chart 0.2.0)\n\n" +
+		"### Added resources\n\n- Deployment `templates/worker-deployment.yaml`\n\n" +
+		"### Removed resources\n\n- Service `templates/web-service.yaml`\n\n" +
+		"### Changed defaults\n\n" +
+		"- `services.web.deployment.containers[web].image.tag`: `1.25` → `1.27`\n" +
+		"- `services.web.deployment.replicas`: `1` → `3`\n\n" +
+		"### New values keys\n\n- `services.worker`\n\n" +
+		"### Removed values keys\n\n- `services.web.service`\n\n" +
+		"## [0.1.0] - 2026-10-01\n\nInitial release.\n\n"
+	if got := readFile(t, filepath.Join(chartDir, ChangelogFile)); got != want {
+		t.Errorf("CHANGELOG.md =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// Chart API versions charts can be generated for.
+const (
+	// ChartAPIVersionV2 is the Chart.yaml apiVersion of Helm 3 charts, which
+	// list their dependencies in Chart.yaml.
+	ChartAPIVersionV2 = "v2"
+
+	// ChartAPIVersionV1 is the Chart.yaml apiVersion of Helm 2 charts, which
+	// list their dependencies in requirements.yaml and have no chart type.
+	ChartAPIVersionV1 = "v1"
+)
+
+// RequirementsFile is the file apiVersion v1 charts list their dependencies
+// in.
+const RequirementsFile = "requirements.yaml"
+
+// RequirementsLockFile is the lock file of apiVersion v1 charts.
+const RequirementsLockFile = "requirements.lock"
+
+// ConvertChartToV1 returns a copy of chart for consumers of apiVersion v1
+// charts: Chart.yaml has apiVersion v1 and no type, its dependencies move
+// to requirements.yaml, and Chart.lock becomes requirements.lock, with the
+// digest Helm 2 computes. A library chart becomes a chart of named
+// templates only, which Helm 2 shares with the charts depending on it.
+// Charts in the external files, such as the example consumer of a library
+// chart, are converted the same way. Returns nil if chart is nil.
+func ConvertChartToV1(chart *types.GeneratedChart) (*types.GeneratedChart, error) {
+	if chart == nil {
+		return nil, nil
+	}
+
+	result := *chart
+	var requirements string
+	result.ChartYAML, requirements = splitChartYAML(chart.ChartYAML)
+
+	result.ExternalFiles = make([]types.ExternalFileInfo, 0, len(chart.ExternalFiles)+1)
+	if requirements != "" {
+		result.ExternalFiles = append(result.ExternalFiles, types.ExternalFileInfo{Path: RequirementsFile, Content: requirements})
+	}
+	for _, f := range chart.ExternalFiles {
+		switch {
+		case f.Path == ChartLockFile:
+			lock, err := requirementsLock(requirements, f.Content)
+			if err != nil {
+				return nil, fmt.Errorf("chart %s: %w", chart.Name, err)
+			}
+			result.ExternalFiles = append(result.ExternalFiles, types.ExternalFileInfo{Path: RequirementsLockFile, Content: lock})
+		case path.Base(f.Path) == "Chart.yaml":
+			chartYAML, deps := splitChartYAML(f.Content)
+			result.ExternalFiles = append(result.ExternalFiles, types.ExternalFileInfo{Path: f.Path, Content: chartYAML})
+			if deps != "" {
+				result.ExternalFiles = append(result.ExternalFiles, types.ExternalFileInfo{Path: path.Join(path.Dir(f.Path), RequirementsFile), Content: deps})
+			}
+		default:
+			result.ExternalFiles = append(result.ExternalFiles, f)
+		}
+	}
+	return &result, nil
+}
+
+// splitChartYAML returns Chart.yaml content with apiVersion v1 and without
+// the type and dependencies fields, and the requirements.yaml content
+// listing the dependencies, empty when there are none.
+func splitChartYAML(chartYAML string) (chart, requirements string) {
+	var chartLines, depLines []string
+	inDeps := false
+	for _, line := range strings.Split(strings.TrimRight(chartYAML, "\n"), "\n") {
+		topLevel := line != "" && line[0] != ' ' && line[0] != '-'
+		if topLevel {
+			inDeps = line == "dependencies:"
+		}
+		switch {
+		case inDeps:
+			if !topLevel && strings.TrimSpace(line) != "" {
+				depLines = append(depLines, line)
+			}
+		case strings.HasPrefix(line, "apiVersion:"):
+			chartLines = append(chartLines, "apiVersion: "+ChartAPIVersionV1)
+		case strings.HasPrefix(line, "type:"):
+		default:
+			chartLines = append(chartLines, line)
+		}
+	}
+
+	chart = strings.TrimRight(strings.Join(chartLines, "\n"), "\n") + "\n"
+	if len(depLines) > 0 {
+		requirements = "dependencies:\n" + strings.Join(depLines, "\n") + "\n"
+	}
+	return chart, requirements
+}
+
+// requirementsLock converts the Chart.lock of a chart to its
+// requirements.lock. Helm 2 digests the requirements alone, where Helm 3
+// digests the dependencies of Chart.yaml together with the lock.
+func requirementsLock(requirements, chartLock string) (string, error) {
+	var req struct {
+		Dependencies []lockDependency `json:"dependencies"`
+	}
+	if err := yaml.Unmarshal([]byte(requirements), &req); err != nil {
+		return "", fmt.Errorf("%s: %w", RequirementsFile, err)
+	}
+	var lock struct {
+		Dependencies []lockDependency `json:"dependencies"`
+		Generated    string           `json:"generated"`
+	}
+	if err := yaml.Unmarshal([]byte(chartLock), &lock); err != nil {
+		return "", fmt.Errorf("%s: %w", ChartLockFile, err)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("requirements lock: %w", err)
+	}
+	return formatLock(lock.Dependencies, sha256.Sum256(data), lock.Generated), nil
+}
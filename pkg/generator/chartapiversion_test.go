@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestConvertChartToV1(t *testing.T) {
+	deps := []helm.Dependency{{Name: "redis", Version: "19.6.4", Repository: bitnamiRepo, Condition: "redis.enabled"}}
+	chart := InjectDependencies(&types.GeneratedChart{
+		Name: "myapp",
+		ChartYAML: helm.GenerateChartYAML(helm.ChartMetadata{
+			Name:     "myapp",
+			Type:     "library",
+			Keywords: []string{"web"},
+		}),
+		ExternalFiles: []types.ExternalFileInfo{
+			{Path: "examples/consumer/Chart.yaml", Content: "apiVersion: v2\nname: example\ntype: application\ndependencies:\n  - name: myapp\n    repository: file://../..\n"},
+			{Path: "files/config.json", Content: "{}"},
+		},
+	}, deps)
+	chart, err := InjectChartLock(chart, deps, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ConvertChartToV1(chart)
+	if err != nil {
+		t.Fatalf("ConvertChartToV1: %v", err)
+	}
+	wantChart := "apiVersion: v1\nname: myapp\ndescription: A Helm chart for myapp\nversion: 0.1.0\nappVersion: 1.0.0\nkeywords:\n  - web\n"
+	if got.ChartYAML != wantChart {
+		t.Errorf("Chart.yaml:\n%s\nwant:\n%s", got.ChartYAML, wantChart)
+	}
+
+	files := make(map[string]string, len(got.ExternalFiles))
+	for _, f := range got.ExternalFiles {
+		files[f.Path] = f.Content
+	}
+	wantFiles := map[string]string{
+		RequirementsFile:                      "dependencies:\n  - name: redis\n    version: 19.6.4\n    repository: " + bitnamiRepo + "\n    condition: redis.enabled\n",
+		"examples/consumer/Chart.yaml":        "apiVersion: v1\nname: example\n",
+		"examples/consumer/requirements.yaml": "dependencies:\n  - name: myapp\n    repository: file://../..\n",
+		"files/config.json":                   "{}",
+	}
+	for path, want := range wantFiles {
+		if files[path] != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", path, files[path], want)
+		}
+	}
+	if _, ok := files[ChartLockFile]; ok {
+		t.Errorf("%s should be replaced by %s", ChartLockFile, RequirementsLockFile)
+	}
+
+	// Helm 2 digests the JSON of the requirements alone.
+	sum := sha256.Sum256([]byte(`{"dependencies":[{"name":"redis","version":"19.6.4","repository":"` + bitnamiRepo + `","condition":"redis.enabled"}]}`))
+	wantLock := "dependencies:\n- name: redis\n  repository: " + bitnamiRepo + "\n  version: 19.6.4\n" +
+		"digest: sha256:" + hex.EncodeToString(sum[:]) + "\ngenerated: \"2024-05-01T12:00:00Z\"\n"
+	if files[RequirementsLockFile] != wantLock {
+		t.Errorf("%s:\n%s\nwant:\n%s", RequirementsLockFile, files[RequirementsLockFile], wantLock)
+	}
+
+	if chart.ChartYAML == got.ChartYAML || len(chart.ExternalFiles) != 3 {
+		t.Error("input chart was modified")
+	}
+}
+
+func TestConvertChartToV1_NoDependencies(t *testing.T) {
+	chart := &types.GeneratedChart{Name: "myapp", ChartYAML: helm.GenerateChartYAML(helm.ChartMetadata{Name: "myapp"})}
+	got, err := ConvertChartToV1(chart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.ExternalFiles) != 0 {
+		t.Errorf("expected no requirements.yaml, got %v", got.ExternalFiles)
+	}
+	if got.ChartYAML != "apiVersion: v1\nname: myapp\ndescription: A Helm chart for myapp\nversion: 0.1.0\nappVersion: 1.0.0\n" {
+		t.Errorf("unexpected Chart.yaml:\n%s", got.ChartYAML)
+	}
+}
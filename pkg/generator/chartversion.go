@@ -0,0 +1,305 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ChartVersionAuto is the --chart-version value that derives the chart
+// version from the git history of the input.
+const ChartVersionAuto = "auto"
+
+// ReleaseSnapshotDir holds the templates of the last version released with
+// BumpChart, relative to the chart directory. The next bump diffs against it.
+const ReleaseSnapshotDir = ".dhg/release"
+
+// BumpLevel selects the SemVer component incremented by BumpChart.
+type BumpLevel string
+
+const (
+	BumpPatch BumpLevel = "patch"
+	BumpMinor BumpLevel = "minor"
+	BumpMajor BumpLevel = "major"
+)
+
+// chartSemverPattern matches a SemVer 2 version with an optional "v" prefix.
+var chartSemverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// chartVersionLine matches the top-level version field of a Chart.yaml.
+var chartVersionLine = regexp.MustCompile(`(?m)^version:.*$`)
+
+// GitChartVersion derives a chart version from the git repository containing
+// path: the latest SemVer tag (with or without a "v" prefix) when HEAD is
+// tagged, <tag with patch+1>-dev.<commits since the tag> otherwise, and
+// 0.1.0-dev.<commit count> in a repository without SemVer tags.
+func GitChartVersion(ctx context.Context, path string) (string, error) {
+	dir := path
+	if info, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("chart version: %w", err)
+	} else if !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	described, err := runGit(ctx, dir, "describe", "--tags", "--long", "--match", "v[0-9]*", "--match", "[0-9]*")
+	if err != nil {
+		count, countErr := runGit(ctx, dir, "rev-list", "--count", "HEAD")
+		if countErr != nil {
+			return "", fmt.Errorf("chart version: %s is not in a git repository with commits: %w", path, countErr)
+		}
+		return "0.1.0-dev." + count, nil
+	}
+
+	// <tag>-<commits>-g<sha>; the tag itself may contain dashes.
+	parts := strings.Split(described, "-")
+	if len(parts) < 3 {
+		return "", fmt.Errorf("chart version: unexpected git describe output %q", described)
+	}
+	tag := strings.Join(parts[:len(parts)-2], "-")
+	ahead, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return "", fmt.Errorf("chart version: unexpected git describe output %q", described)
+	}
+	return versionFromTag(tag, ahead)
+}
+
+// versionFromTag returns the chart version of a commit ahead commits after tag.
+func versionFromTag(tag string, ahead int) (string, error) {
+	m := chartSemverPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return "", fmt.Errorf("chart version: tag %q is not a SemVer version", tag)
+	}
+	version := strings.TrimPrefix(tag, "v")
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+	switch {
+	case ahead == 0:
+		return version, nil
+	case m[4] != "":
+		// A pre-release tag: stay below the release it precedes.
+		return fmt.Sprintf("%s.dev.%d", version, ahead), nil
+	default:
+		patch, _ := strconv.Atoi(m[3])
+		return fmt.Sprintf("%s.%s.%d-dev.%d", m[1], m[2], patch+1, ahead), nil
+	}
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// BumpVersion increments the level component of a SemVer version and resets
+// the lower ones. Pre-release and build metadata are dropped.
+func BumpVersion(version string, level BumpLevel) (string, error) {
+	m := chartSemverPattern.FindStringSubmatch(version)
+	if m == nil {
+		return "", fmt.Errorf("version %q is not a SemVer version", version)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	switch level {
+	case BumpMajor:
+		major, minor, patch = major+1, 0, 0
+	case BumpMinor:
+		minor, patch = minor+1, 0
+	case BumpPatch:
+		patch++
+	default:
+		return "", fmt.Errorf("invalid bump level %q (must be patch, minor or major)", level)
+	}
+	return fmt.Sprintf("%d.%d.%d", major, minor, patch), nil
+}
+
+// TemplateChange is a template added, changed or removed since the last
+// released version.
+type TemplateChange struct {
+	// Path is relative to the chart directory, e.g. templates/web-deployment.yaml.
+	Path string
+
+	// Added and Removed count the changed lines; both are zero for a file
+	// that was only added or removed.
+	Added   int
+	Removed int
+}
+
+// BumpResult describes a version bump.
+type BumpResult struct {
+	PreviousVersion string
+	Version         string
+
+	// Initial is set when the chart had no release snapshot; every template
+	// is then listed as added.
+	Initial bool
+
+	// HasHistory is set when CHANGELOG.md already had entries for earlier
+	// versions or generations, so an Initial bump is not the first release.
+	HasHistory bool
+
+	Added   []TemplateChange
+	Changed []TemplateChange
+	Removed []TemplateChange
+}
+
+// BumpChart increments the version in chartDir/Chart.yaml, prepends a
+// CHANGELOG.md entry listing the templates added, changed and removed since
+// the last bump, and records the templates in ReleaseSnapshotDir for the next
+// one. An existing entry for the new version is replaced. now dates the entry.
+// A Chart.yaml recorded as unmodified in the generation manifest stays so.
+func BumpChart(chartDir string, level BumpLevel, now time.Time) (*BumpResult, error) {
+	chartFile := filepath.Join(chartDir, "Chart.yaml")
+	data, err := os.ReadFile(chartFile)
+	if err != nil {
+		return nil, fmt.Errorf("bump: %w", err)
+	}
+	var meta struct {
+		Version string `json:"version"`
+	}
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("bump: Chart.yaml: %w", err)
+	}
+	version, err := BumpVersion(meta.Version, level)
+	if err != nil {
+		return nil, fmt.Errorf("bump: Chart.yaml: %w", err)
+	}
+
+	current, err := readTemplates(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("bump: %w", err)
+	}
+	released, err := readTemplates(filepath.Join(chartDir, ReleaseSnapshotDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("bump: %w", err)
+	}
+
+	result := &BumpResult{PreviousVersion: meta.Version, Version: version, Initial: released == nil}
+	for _, rel := range sortedKeys(current) {
+		prev, ok := released[rel]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, TemplateChange{Path: rel})
+		case prev != current[rel]:
+			change := TemplateChange{Path: rel}
+			for _, h := range DiffHunks(prev, current[rel]) {
+				change.Added += len(h.New)
+				change.Removed += len(h.Old)
+			}
+			result.Changed = append(result.Changed, change)
+		}
+	}
+	for _, rel := range sortedKeys(released) {
+		if _, ok := current[rel]; !ok {
+			result.Removed = append(result.Removed, TemplateChange{Path: rel})
+		}
+	}
+
+	heading := fmt.Sprintf("## [%s]", version)
+	changelog, err := os.ReadFile(filepath.Join(chartDir, ChangelogFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("bump: %w", err)
+	}
+	result.HasHistory = changelogHasEntries(string(changelog), heading)
+
+	updated := chartVersionLine.ReplaceAllString(string(data), "version: "+version)
+	if err := rewriteGeneratedFile(chartDir, "Chart.yaml", string(data), updated); err != nil {
+		return nil, err
+	}
+
+	if err := prependChangelogFile(chartDir, heading, bumpChangelogEntry(result, now)); err != nil {
+		return nil, err
+	}
+
+	snapshotDir := filepath.Join(chartDir, ReleaseSnapshotDir)
+	if err := os.RemoveAll(snapshotDir); err != nil {
+		return nil, fmt.Errorf("bump: %w", err)
+	}
+	for rel, content := range current {
+		if err := writeFile(filepath.Join(snapshotDir, filepath.FromSlash(rel)), content); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// readTemplates returns the files under dir/templates keyed by path relative
+// to dir, or nil and an IsNotExist error when dir does not exist.
+func readTemplates(dir string) (map[string]string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	files, err := readChartFiles(filepath.Join(dir, "templates"))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]string, len(files))
+	for rel, content := range files {
+		templates["templates/"+rel] = content
+	}
+	return templates, nil
+}
+
+// changelogHasEntries reports whether changelog has an entry other than the
+// one starting with replaceHeading, which a bump replaces.
+func changelogHasEntries(changelog, replaceHeading string) bool {
+	for _, line := range strings.Split(changelog, "\n") {
+		if strings.HasPrefix(line, "## ") && !strings.HasPrefix(line, replaceHeading) {
+			return true
+		}
+	}
+	return false
+}
+
+// bumpChangelogEntry renders the CHANGELOG.md entry of a bump.
+func bumpChangelogEntry(result *BumpResult, now time.Time) string {
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "## [%s] - %s\n\n", result.Version, now.Format("2006-01-02"))
+	switch {
+	case result.Initial && result.HasHistory:
+		entry.WriteString("First version bumped by dhg; template changes are listed from the next version on.\n\n")
+	case result.Initial:
+		entry.WriteString("Initial release.\n\n")
+	case len(result.Added)+len(result.Changed)+len(result.Removed) == 0:
+		entry.WriteString("No template changes.\n\n")
+	}
+	writeChanges := func(heading string, changes []TemplateChange) {
+		if len(changes) == 0 || result.Initial {
+			return
+		}
+		fmt.Fprintf(&entry, "### %s\n\n", heading)
+		for _, c := range changes {
+			if c.Added+c.Removed > 0 {
+				fmt.Fprintf(&entry, "- `%s` (+%d/-%d lines)\n", c.Path, c.Added, c.Removed)
+			} else {
+				fmt.Fprintf(&entry, "- `%s`\n", c.Path)
+			}
+		}
+		entry.WriteString("\n")
+	}
+	writeChanges("Added", result.Added)
+	writeChanges("Changed", result.Changed)
+	writeChanges("Removed", result.Removed)
+	return entry.String()
+}
@@ -0,0 +1,259 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gitRepo creates a repository with one commit per message and returns its
+// directory. The test is skipped without a git binary.
+func gitRepo(t *testing.T, messages ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	for _, msg := range messages {
+		gitCommit(t, dir, msg)
+	}
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func gitCommit(t *testing.T, dir, msg string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "deploy.yaml"), []byte("# "+msg+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", msg)
+}
+
+func TestGitChartVersion(t *testing.T) {
+	dir := gitRepo(t, "one", "two")
+	file := filepath.Join(dir, "deploy.yaml")
+
+	if v, err := GitChartVersion(context.Background(), file); err != nil || v != "0.1.0-dev.2" {
+		t.Errorf("untagged: got %q, %v; want 0.1.0-dev.2", v, err)
+	}
+
+	git(t, dir, "tag", "v1.2.3")
+	if v, err := GitChartVersion(context.Background(), dir); err != nil || v != "1.2.3" {
+		t.Errorf("tagged HEAD: got %q, %v; want 1.2.3", v, err)
+	}
+
+	gitCommit(t, dir, "three")
+	gitCommit(t, dir, "four")
+	if v, err := GitChartVersion(context.Background(), file); err != nil || v != "1.2.4-dev.2" {
+		t.Errorf("after the tag: got %q, %v; want 1.2.4-dev.2", v, err)
+	}
+}
+
+func TestGitChartVersion_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	if _, err := GitChartVersion(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected an error outside a git repository")
+	}
+}
+
+func TestVersionFromTag(t *testing.T) {
+	tests := []struct {
+		tag   string
+		ahead int
+		want  string
+	}{
+		{"v1.2.3", 0, "1.2.3"},
+		{"1.2.3+build.5", 0, "1.2.3"},
+		{"v1.2.3", 4, "1.2.4-dev.4"},
+		{"v2.0.0-rc.1", 3, "2.0.0-rc.1.dev.3"},
+	}
+	for _, tt := range tests {
+		if got, err := versionFromTag(tt.tag, tt.ahead); err != nil || got != tt.want {
+			t.Errorf("versionFromTag(%q, %d) = %q, %v; want %q", tt.tag, tt.ahead, got, err, tt.want)
+		}
+	}
+	if _, err := versionFromTag("release-2024", 0); err == nil {
+		t.Error("expected an error for a non-SemVer tag")
+	}
+}
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		level   BumpLevel
+		want    string
+	}{
+		{"0.1.0", BumpPatch, "0.1.1"},
+		{"0.1.9", BumpMinor, "0.2.0"},
+		{"v1.4.2", BumpMajor, "2.0.0"},
+		{"1.2.4-dev.3", BumpPatch, "1.2.5"},
+	}
+	for _, tt := range tests {
+		if got, err := BumpVersion(tt.version, tt.level); err != nil || got != tt.want {
+			t.Errorf("BumpVersion(%q, %s) = %q, %v; want %q", tt.version, tt.level, got, err, tt.want)
+		}
+	}
+	if _, err := BumpVersion("latest", BumpPatch); err == nil {
+		t.Error("expected an error for a non-SemVer version")
+	}
+	if _, err := BumpVersion("1.0.0", "huge"); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestBumpChart(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"Chart.yaml":                   "# Generated by dhg\napiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n  - name: redis\n    version: 17.0.0\n",
+		"templates/deployment.yaml":    "kind: Deployment\nreplicas: 1\n",
+		"templates/service.yaml":       "kind: Service\n",
+		"templates/tests/connect.yaml": "kind: Pod\n",
+	})
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	result, err := BumpChart(chartDir, BumpPatch, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Initial || result.Version != "0.1.1" || len(result.Added) != 3 {
+		t.Errorf("unexpected first bump: %+v", result)
+	}
+	chart := readFile(t, filepath.Join(chartDir, "Chart.yaml"))
+	if !strings.Contains(chart, "\nversion: 0.1.1\n") || !strings.Contains(chart, "    version: 17.0.0\n") || !strings.HasPrefix(chart, "# Generated by dhg\n") {
+		t.Errorf("only the chart version should change:\n%s", chart)
+	}
+
+	writeTree(t, chartDir, map[string]string{
+		"templates/deployment.yaml": "kind: Deployment\nreplicas: 2\n",
+		"templates/ingress.yaml":    "kind: Ingress\n",
+	})
+	if err := os.Remove(filepath.Join(chartDir, "templates", "service.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = BumpChart(chartDir, BumpMinor, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Initial || result.PreviousVersion != "0.1.1" || result.Version != "0.2.0" {
+		t.Errorf("unexpected second bump: %+v", result)
+	}
+	if len(result.Changed) != 1 || result.Changed[0] != (TemplateChange{Path: "templates/deployment.yaml", Added: 1, Removed: 1}) {
+		t.Errorf("Changed = %+v", result.Changed)
+	}
+
+	changelog := readFile(t, filepath.Join(chartDir, ChangelogFile))
+	want := "# Changelog\n\n" +
+		"## [0.2.0] - 2026-10-18\n\n" +
+		"### Added\n\n- `templates/ingress.yaml`\n\n" +
+		"### Changed\n\n- `templates/deployment.yaml` (+1/-1 lines)\n\n" +
+		"### Removed\n\n- `templates/service.yaml`\n\n" +
+		"## [0.1.1] - 2026-10-17\n\nInitial release.\n\n"
+	if changelog != want {
+		t.Errorf("CHANGELOG.md =\n%s\nwant\n%s", changelog, want)
+	}
+}
+
+func TestBumpChart_ReplacesEntryOfSameVersion(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"templates/deployment.yaml": "kind: Deployment\n",
+	})
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	if _, err := BumpChart(chartDir, BumpPatch, day); err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, chartDir, map[string]string{"Chart.yaml": "apiVersion: v2\nname: app\nversion: 0.1.0\n"})
+	if _, err := BumpChart(chartDir, BumpPatch, day); err != nil {
+		t.Fatal(err)
+	}
+
+	changelog := readFile(t, filepath.Join(chartDir, ChangelogFile))
+	if strings.Count(changelog, "## [0.1.1]") != 1 || !strings.Contains(changelog, "No template changes.") {
+		t.Errorf("expected a single regenerated 0.1.1 entry:\n%s", changelog)
+	}
+}
+
+func TestBumpChart_EarlierChangelogEntries(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"templates/deployment.yaml": "kind: Deployment\n",
+		ChangelogFile:               "# Changelog\n\n## [0.1.0] - 2026-10-01\n\nInitial release.\n\n",
+	})
+	result, err := BumpChart(chartDir, BumpPatch, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Initial || !result.HasHistory {
+		t.Errorf("expected a first bump of a chart with history: %+v", result)
+	}
+	changelog := readFile(t, filepath.Join(chartDir, ChangelogFile))
+	if strings.Count(changelog, "Initial release.") != 1 || !strings.Contains(changelog, "## [0.1.1] - 2026-10-17\n\nFirst version bumped by dhg") {
+		t.Errorf("expected the 0.1.1 entry not to claim the initial release:\n%s", changelog)
+	}
+}
+
+func TestBumpChart_KeepsChartYAMLUnmodified(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"values.yaml": "replicas: 1\n",
+	})
+	if _, err := StampOwnership(chartDir, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BumpChart(chartDir, BumpPatch, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := ChartStatus(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.State != FileUnmodified {
+			t.Errorf("%s is %s after the bump", s.Path, s.State)
+		}
+	}
+	chart := readFile(t, filepath.Join(chartDir, "Chart.yaml"))
+	_, body, _ := strings.Cut(chart, "\n")
+	if !strings.Contains(body, "version: 0.1.1") || chart != ownershipHeader("Chart.yaml", body)+body {
+		t.Errorf("expected a bumped Chart.yaml with an up-to-date ownership header:\n%s", chart)
+	}
+
+	// A Chart.yaml edited by hand stays modified.
+	writeTree(t, chartDir, map[string]string{"Chart.yaml": chart + "description: edited\n"})
+	if _, err := BumpChart(chartDir, BumpPatch, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if statuses, _ = ChartStatus(chartDir); statuses[0].Path != "Chart.yaml" || statuses[0].State != FileModified {
+		t.Errorf("expected the edited Chart.yaml to stay modified: %+v", statuses)
+	}
+}
+
+func TestBumpChart_InvalidVersion(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{"Chart.yaml": "apiVersion: v2\nname: app\nversion: latest\n"})
+	if _, err := BumpChart(chartDir, BumpPatch, time.Now()); err == nil || !strings.Contains(err.Error(), "not a SemVer version") {
+		t.Errorf("expected a SemVer error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, ChangelogFile)); !os.IsNotExist(err) {
+		t.Error("a failed bump should not write the changelog")
+	}
+}
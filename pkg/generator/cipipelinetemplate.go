@@ -63,7 +63,7 @@ func GenerateCIPipelineTemplate(chart *types.GeneratedChart, opts CIPipelineOpti
 		"CI pipeline template for chart %q (platform: %s)\n"+
 			"Generated workflow file(s):\n",
 		chartName, platform)
-	for k := range workflows {
+	for _, k := range sortedKeys(workflows) {
 		notesTxt += fmt.Sprintf("  - %s\n", k)
 	}
 	notesTxt += "\nCopy the workflow file(s) to your repository and commit.\n"
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+	"sigs.k8s.io/yaml"
 )
 
 // CloudProvider identifies a cloud infrastructure provider.
@@ -17,6 +19,12 @@ const (
 	CloudGCP   CloudProvider = "gcp"
 	CloudAzure CloudProvider = "azure"
 
+	// CloudYandex, CloudVK and CloudOpenStack are the providers of
+	// Deckhouse clusters in Yandex Cloud, VK Cloud and OpenStack.
+	CloudYandex    CloudProvider = "yandex"
+	CloudVK        CloudProvider = "vk"
+	CloudOpenStack CloudProvider = "openstack"
+
 	// CloudProviderAWS/GCP/Azure are aliases used by cost estimation.
 	CloudProviderAWS   CloudProvider = "aws"
 	CloudProviderGCP   CloudProvider = "gcp"
@@ -30,6 +38,64 @@ type CloudAnnotationConfig struct {
 	Scheme   string // "internet-facing" or "internal" for AWS
 }
 
+// cloudProfile holds the defaults of a provider beyond load balancers.
+type cloudProfile struct {
+	// IngressClassName is the class of Ingresses that do not set one.
+	IngressClassName string
+	// StorageClassName is the class of PVCs that do not set one.
+	StorageClassName string
+	// IdentityAnnotation binds a ServiceAccount to a cloud identity, set
+	// from cloud.workloadIdentity.<IdentityKey>; none when empty.
+	IdentityAnnotation string
+	IdentityKey        string
+}
+
+// cloudProfiles maps providers to their defaults.
+var cloudProfiles = map[CloudProvider]cloudProfile{
+	CloudAWS: {
+		IngressClassName:   "alb",
+		StorageClassName:   "gp3",
+		IdentityAnnotation: "eks.amazonaws.com/role-arn",
+		IdentityKey:        "roleArn",
+	},
+	CloudGCP: {
+		IngressClassName:   "gce",
+		StorageClassName:   "standard-rwo",
+		IdentityAnnotation: "iam.gke.io/gcp-service-account",
+		IdentityKey:        "gcpServiceAccount",
+	},
+	CloudAzure: {
+		IngressClassName:   "webapprouting.kubernetes.azure.com",
+		StorageClassName:   "managed-csi",
+		IdentityAnnotation: "azure.workload.identity/client-id",
+		IdentityKey:        "clientId",
+	},
+	// Deckhouse serves Ingresses with the nginx class of ingress-nginx and
+	// names StorageClasses after the disk types of the cloud; OpenStack
+	// volume types differ between clouds, so PVCs keep the default class.
+	CloudYandex: {
+		IngressClassName: "nginx",
+		StorageClassName: "network-ssd",
+	},
+	CloudVK: {
+		IngressClassName: "nginx",
+		StorageClassName: "ceph-ssd",
+	},
+	CloudOpenStack: {
+		IngressClassName: "nginx",
+	},
+}
+
+// profile returns the defaults of the provider of config; GKE internal
+// Ingresses use the gce-internal class.
+func (config CloudAnnotationConfig) profile() (cloudProfile, bool) {
+	p, ok := cloudProfiles[config.Provider]
+	if ok && config.Provider == CloudGCP && config.Internal {
+		p.IngressClassName = "gce-internal"
+	}
+	return p, ok
+}
+
 // metadataNameRegex matches the metadata block through the name field, capturing
 // the entire "metadata:\n  name: <value>" section for replacement.
 var metadataNameRegex = regexp.MustCompile(`(metadata:\s*\n\s+name:\s*[^\n]+)`)
@@ -61,6 +127,17 @@ func GenerateCloudAnnotations(config CloudAnnotationConfig) map[string]string {
 			annotations["service.beta.kubernetes.io/azure-load-balancer-internal"] = "true"
 		}
 
+	case CloudYandex:
+		if config.Internal {
+			annotations["yandex.cloud/load-balancer-type"] = "internal"
+		}
+
+	case CloudVK, CloudOpenStack:
+		// VK Cloud runs the OpenStack cloud controller manager.
+		if config.Internal {
+			annotations["service.beta.kubernetes.io/openstack-internal-load-balancer"] = "true"
+		}
+
 	default:
 		// Unknown or empty provider — return empty map without panicking.
 	}
@@ -69,8 +146,11 @@ func GenerateCloudAnnotations(config CloudAnnotationConfig) map[string]string {
 }
 
 // InjectCloudAnnotations injects provider-specific annotations into all Service (and Ingress
-// for AWS) templates in the chart. Returns nil if chart is nil. The original chart is not
-// mutated; a new chart with updated templates is returned.
+// for AWS) templates in the chart, and applies the cloud profile of the provider to the
+// templates generated from values: Ingresses and PVCs without a class get the class of
+// cloud.ingressClassName and cloud.storageClassName, ServiceAccounts the workload identity
+// annotation of cloud.workloadIdentity. Returns nil if chart is nil. The original chart is
+// not mutated; a new chart with updated templates is returned.
 func InjectCloudAnnotations(chart *types.GeneratedChart, config CloudAnnotationConfig) *types.GeneratedChart {
 	if chart == nil {
 		return nil
@@ -89,27 +169,35 @@ func InjectCloudAnnotations(chart *types.GeneratedChart, config CloudAnnotationC
 				templates[name] = injectAnnotationsIntoTemplate(content, svcAnnotations)
 			}
 		}
+	}
 
-		// Only AWS Ingress gets ALB annotations. GCP and Azure configure
-		// load balancers via Service annotations (handled above), not Ingress.
-		if extractKind(content) == "Ingress" && config.Provider == CloudAWS {
-			scheme := config.Scheme
-			if scheme == "" {
-				scheme = "internet-facing"
-			}
-			albAnnotations := map[string]string{
-				"alb.ingress.kubernetes.io/scheme":      scheme,
-				"alb.ingress.kubernetes.io/target-type": "ip",
-			}
-			templates[name] = injectAnnotationsIntoTemplate(content, albAnnotations)
+	// Only AWS Ingress gets ALB annotations. GCP and Azure configure
+	// load balancers via Service annotations (handled above), not Ingress.
+	valuesYAML := chart.ValuesYAML
+	if config.Provider == CloudAWS {
+		scheme := config.Scheme
+		if scheme == "" {
+			scheme = "internet-facing"
 		}
+		albAnnotations := map[string]string{
+			"alb.ingress.kubernetes.io/scheme":      scheme,
+			"alb.ingress.kubernetes.io/target-type": "ip",
+		}
+		valuesYAML = injectIngressAnnotations(valuesYAML, templates, albAnnotations)
+	}
+
+	if profile, ok := config.profile(); ok {
+		for name, content := range templates {
+			templates[name] = applyCloudProfile(content, profile)
+		}
+		valuesYAML = appendCloudValues(valuesYAML, config)
 	}
 
 	return &types.GeneratedChart{
 		Name:          chart.Name,
 		Path:          chart.Path,
 		ChartYAML:     chart.ChartYAML,
-		ValuesYAML:    chart.ValuesYAML,
+		ValuesYAML:    valuesYAML,
 		Templates:     templates,
 		Helpers:       chart.Helpers,
 		Notes:         chart.Notes,
@@ -118,19 +206,94 @@ func InjectCloudAnnotations(chart *types.GeneratedChart, config CloudAnnotationC
 	}
 }
 
-// generateCloudValues builds a cloud configuration values map.
-// Currently unused — cloud settings are injected via annotations only.
-// Retained for potential future use in values.yaml cloud section.
+// generateCloudValues builds the cloud section of values: the provider and
+// load balancer the chart was generated for and the profile defaults read by
+// the templates.
 func generateCloudValues(config CloudAnnotationConfig) map[string]interface{} {
-	return map[string]interface{}{
-		"cloud": map[string]interface{}{
-			"provider": string(config.Provider),
-			"loadBalancer": map[string]interface{}{
-				"internal": config.Internal,
-				"scheme":   config.Scheme,
-			},
+	cloud := map[string]interface{}{
+		"provider": string(config.Provider),
+		"loadBalancer": map[string]interface{}{
+			"internal": config.Internal,
+			"scheme":   config.Scheme,
 		},
 	}
+	if profile, ok := config.profile(); ok {
+		cloud["ingressClassName"] = profile.IngressClassName
+		cloud["storageClassName"] = profile.StorageClassName
+		if profile.IdentityAnnotation != "" {
+			cloud["workloadIdentity"] = map[string]interface{}{profile.IdentityKey: ""}
+		}
+	}
+	return map[string]interface{}{"cloud": cloud}
+}
+
+// appendCloudValues appends the cloud section to valuesYAML unless it has one.
+func appendCloudValues(valuesYAML string, config CloudAnnotationConfig) string {
+	var values map[string]interface{}
+	if yaml.Unmarshal([]byte(valuesYAML), &values) == nil {
+		if _, ok := values["cloud"]; ok {
+			return valuesYAML
+		}
+	}
+	data, err := yaml.Marshal(generateCloudValues(config))
+	if err != nil {
+		return valuesYAML
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(valuesYAML, "\n"))
+	sb.WriteString("\n\n# Cloud provider profile (--cloud-provider): default IngressClass and\n")
+	sb.WriteString("# StorageClass, workload identity of the ServiceAccounts\n")
+	sb.Write(data)
+	return sb.String()
+}
+
+// applyCloudProfile makes a template generated from values fall back to the
+// cloud profile: the class of Ingresses and PVCs, the workload identity
+// annotation of ServiceAccounts. A class or annotation set in the values of
+// the resource takes precedence.
+func applyCloudProfile(content string, profile cloudProfile) string {
+	switch extractKind(content) {
+	case "Ingress":
+		return strings.Replace(content,
+			"  {{- with .className }}\n  ingressClassName:",
+			"  {{- with .className | default $.Values.cloud.ingressClassName }}\n  ingressClassName:", 1)
+	case "PersistentVolumeClaim":
+		return strings.Replace(content,
+			"  {{- with .storageClassName }}\n  storageClassName:",
+			"  {{- with .storageClassName | default $.Values.cloud.storageClassName }}\n  storageClassName:", 1)
+	case "StatefulSet":
+		// An empty storageClassName of a claim disables dynamic provisioning
+		// and is kept; only claims without one get the profile class.
+		return strings.Replace(content,
+			"        storageClassName: {{ $claim.storageClassName | quote }}\n",
+			"        storageClassName: {{ $claim.storageClassName | quote }}\n"+
+				"        {{- else if $.Values.cloud.storageClassName }}\n"+
+				"        storageClassName: {{ $.Values.cloud.storageClassName | quote }}\n", 1)
+	case "ServiceAccount":
+		if profile.IdentityAnnotation == "" {
+			return content
+		}
+		const annotations = "  {{- with .annotations }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\n"
+		if !strings.Contains(content, annotations) {
+			return content
+		}
+		var sb strings.Builder
+		sb.WriteString("  {{- $identity := \"\" }}\n")
+		sb.WriteString(fmt.Sprintf("  {{- if not (hasKey (.annotations | default dict) %q) }}\n", profile.IdentityAnnotation))
+		sb.WriteString(fmt.Sprintf("  {{- $identity = $.Values.cloud.workloadIdentity.%s }}\n", profile.IdentityKey))
+		sb.WriteString("  {{- end }}\n")
+		sb.WriteString("  {{- if or .annotations $identity }}\n")
+		sb.WriteString("  annotations:\n")
+		sb.WriteString("    {{- with .annotations }}\n")
+		sb.WriteString("    {{- toYaml . | nindent 4 }}\n")
+		sb.WriteString("    {{- end }}\n")
+		sb.WriteString("    {{- with $identity }}\n")
+		sb.WriteString(fmt.Sprintf("    %s: {{ . | quote }}\n", profile.IdentityAnnotation))
+		sb.WriteString("    {{- end }}\n")
+		sb.WriteString("  {{- end }}\n")
+		return strings.Replace(content, annotations, sb.String(), 1)
+	}
+	return content
 }
 
 // annotationsLineRegex matches an existing "  annotations:" line.
@@ -175,7 +338,7 @@ func injectAnnotationsIntoTemplate(template string, annotations map[string]strin
 		for _, k := range keys {
 			// Only add the key if it is not already present in the block.
 			if !strings.Contains(existingBlock, k+":") {
-				newLines = append(newLines, fmt.Sprintf("    %s: %s", k, annotations[k]))
+				newLines = append(newLines, fmt.Sprintf("    %s: %s", k, annotationValue(annotations[k])))
 			}
 		}
 
@@ -192,9 +355,19 @@ func injectAnnotationsIntoTemplate(template string, annotations map[string]strin
 	var lines []string
 	lines = append(lines, "  annotations:")
 	for _, k := range keys {
-		lines = append(lines, fmt.Sprintf("    %s: %s", k, annotations[k]))
+		lines = append(lines, fmt.Sprintf("    %s: %s", k, annotationValue(annotations[k])))
 	}
 	annotationsBlock := strings.Join(lines, "\n")
 
 	return metadataNameRegex.ReplaceAllString(template, "$1\n"+annotationsBlock)
 }
+
+// annotationValue returns v as a YAML scalar, quoted unless YAML reads it as
+// the string v (annotation values such as "true" or JSON must be strings).
+func annotationValue(v string) string {
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(v), &parsed); err == nil && parsed == v {
+		return v
+	}
+	return strconv.Quote(v)
+}
@@ -24,6 +24,8 @@ import (
 // 11. TestCloudAnnotations_ValuesStructure         — happy    generateCloudValues → required keys present
 // 12. TestCloudAnnotations_MultipleServices        — edge     2 Service templates → both annotated
 // 13. TestCloudAnnotations_NilChart_ReturnsNil     — error    nil chart → nil returned, no panic
+// 14. TestCloudAnnotations_Profile                — integration  IngressClass, StorageClass, workload identity from values
+// 15. TestCloudAnnotations_DeckhouseClouds        — happy    Yandex, VK Cloud, OpenStack → internal LB annotations and profiles
 
 // ============================================================
 // Helpers — note: makeChart is defined in airgap_test.go
@@ -560,3 +562,93 @@ func TestCloudAnnotations_NilChart_ReturnsNil(t *testing.T) {
 		t.Errorf("expected nil return for nil chart input, got %+v", result)
 	}
 }
+
+// ============================================================
+// Section 8: cloud profiles — IngressClass, StorageClass, workload identity
+// ============================================================
+
+func TestCloudAnnotations_Profile(t *testing.T) {
+	chart := makeChart("myapp", map[string]string{
+		"templates/web-ingress.yaml": "{{- with $svc.ingress }}\napiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: web\nspec:\n" +
+			"  {{- with .className }}\n  ingressClassName: {{ . }}\n  {{- end }}\n{{- end }}\n",
+		"templates/web-pvc-data.yaml": "apiVersion: v1\nkind: PersistentVolumeClaim\nmetadata:\n  name: data\nspec:\n" +
+			"  {{- with .storageClassName }}\n  storageClassName: {{ . }}\n  {{- end }}\n",
+		"templates/db-statefulset.yaml": "apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: db\nspec:\n  volumeClaimTemplates:\n" +
+			"        {{- if hasKey $claim \"storageClassName\" }}\n        storageClassName: {{ $claim.storageClassName | quote }}\n        {{- end }}\n",
+		"templates/web-serviceaccount.yaml": "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: {{ .name }}\n" +
+			"  {{- with .annotations }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\n",
+	})
+
+	result := InjectCloudAnnotations(chart, CloudAnnotationConfig{Provider: CloudGCP, Internal: true})
+
+	for path, want := range map[string]string{
+		"templates/web-ingress.yaml":        "{{- with .className | default $.Values.cloud.ingressClassName }}",
+		"templates/web-pvc-data.yaml":       "{{- with .storageClassName | default $.Values.cloud.storageClassName }}",
+		"templates/db-statefulset.yaml":     "{{- else if $.Values.cloud.storageClassName }}\n        storageClassName: {{ $.Values.cloud.storageClassName | quote }}\n        {{- end }}",
+		"templates/web-serviceaccount.yaml": "{{- if not (hasKey (.annotations | default dict) \"iam.gke.io/gcp-service-account\") }}\n  {{- $identity = $.Values.cloud.workloadIdentity.gcpServiceAccount }}",
+	} {
+		if !strings.Contains(result.Templates[path], want) {
+			t.Errorf("expected %q in %s:\n%s", want, path, result.Templates[path])
+		}
+	}
+	if sa := result.Templates["templates/web-serviceaccount.yaml"]; !strings.Contains(sa, "  {{- if or .annotations $identity }}\n  annotations:") || strings.Count(sa, "annotations:") != 1 {
+		t.Errorf("expected a single annotations block:\n%s", sa)
+	}
+
+	for _, want := range []string{"cloud:\n", "  ingressClassName: gce-internal\n", "  storageClassName: standard-rwo\n", "  workloadIdentity:\n    gcpServiceAccount: \"\"\n"} {
+		if !strings.Contains(result.ValuesYAML, want) {
+			t.Errorf("expected %q in values:\n%s", want, result.ValuesYAML)
+		}
+	}
+	again := InjectCloudAnnotations(result, CloudAnnotationConfig{Provider: CloudGCP, Internal: true})
+	if strings.Count(again.ValuesYAML, "cloud:") != 1 {
+		t.Errorf("expected a single cloud section:\n%s", again.ValuesYAML)
+	}
+
+	aws := InjectCloudAnnotations(chart, CloudAnnotationConfig{Provider: CloudAWS})
+	for _, want := range []string{"  ingressClassName: alb\n", "  storageClassName: gp3\n", "    roleArn: \"\"\n"} {
+		if !strings.Contains(aws.ValuesYAML, want) {
+			t.Errorf("expected %q in AWS values:\n%s", want, aws.ValuesYAML)
+		}
+	}
+	if !strings.Contains(aws.Templates["templates/web-serviceaccount.yaml"], "eks.amazonaws.com/role-arn: {{ . | quote }}") {
+		t.Errorf("expected the IRSA annotation:\n%s", aws.Templates["templates/web-serviceaccount.yaml"])
+	}
+}
+
+func TestCloudAnnotations_DeckhouseClouds(t *testing.T) {
+	if a := GenerateCloudAnnotations(CloudAnnotationConfig{Provider: CloudYandex, Internal: true}); len(a) != 1 || a["yandex.cloud/load-balancer-type"] != "internal" {
+		t.Errorf("unexpected Yandex internal annotations: %v", a)
+	}
+	for _, provider := range []CloudProvider{CloudYandex, CloudVK, CloudOpenStack} {
+		if a := GenerateCloudAnnotations(CloudAnnotationConfig{Provider: provider}); len(a) != 0 {
+			t.Errorf("%s: expected no annotations for an external load balancer, got %v", provider, a)
+		}
+	}
+
+	chart := makeChart("myapp", map[string]string{
+		"templates/service.yaml": cloudSvcTemplate,
+		"templates/web-serviceaccount.yaml": "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: {{ .name }}\n" +
+			"  {{- with .annotations }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\n",
+	})
+	result := InjectCloudAnnotations(chart, CloudAnnotationConfig{Provider: CloudVK, Internal: true})
+	if !strings.Contains(result.Templates["templates/service.yaml"], `service.beta.kubernetes.io/openstack-internal-load-balancer: "true"`) {
+		t.Errorf("expected a quoted OpenStack internal annotation:\n%s", result.Templates["templates/service.yaml"])
+	}
+	if result.Templates["templates/web-serviceaccount.yaml"] != chart.Templates["templates/web-serviceaccount.yaml"] {
+		t.Errorf("ServiceAccounts should be left alone without workload identity:\n%s", result.Templates["templates/web-serviceaccount.yaml"])
+	}
+	for _, want := range []string{"  ingressClassName: nginx\n", "  storageClassName: ceph-ssd\n"} {
+		if !strings.Contains(result.ValuesYAML, want) {
+			t.Errorf("expected %q in values:\n%s", want, result.ValuesYAML)
+		}
+	}
+	if strings.Contains(result.ValuesYAML, "workloadIdentity") {
+		t.Errorf("expected no workloadIdentity values for VK Cloud:\n%s", result.ValuesYAML)
+	}
+
+	openstack := InjectCloudAnnotations(chart, CloudAnnotationConfig{Provider: CloudOpenStack})
+	if !strings.Contains(openstack.ValuesYAML, "  storageClassName: \"\"\n") {
+		t.Errorf("expected the default StorageClass for OpenStack:\n%s", openstack.ValuesYAML)
+	}
+}
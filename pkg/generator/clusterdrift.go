@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// ClusterState classifies a resource of a chart against the live cluster.
+type ClusterState string
+
+const (
+	// ClusterInSync: applying the chart would not change the live object.
+	ClusterInSync ClusterState = "in-sync"
+
+	// ClusterDrifted: the live object differs from the chart.
+	ClusterDrifted ClusterState = "drifted"
+
+	// ClusterMissing: the object does not exist in the cluster.
+	ClusterMissing ClusterState = "missing"
+
+	// ClusterFailed: the object could not be compared.
+	ClusterFailed ClusterState = "failed"
+)
+
+// ResourceDrift is the comparison of a chart resource with the cluster.
+type ResourceDrift struct {
+	Kind      string
+	Namespace string
+	Name      string
+	State     ClusterState
+
+	// Hunks turn the live object into the object applying the chart would
+	// leave, both as YAML without server-managed fields.
+	Hunks []Hunk
+
+	// Err reports why a ClusterFailed resource could not be compared.
+	Err error
+}
+
+// String returns Kind namespace/name, or Kind name for cluster-scoped
+// resources.
+func (d ResourceDrift) String() string {
+	if d.Namespace == "" {
+		return d.Kind + " " + d.Name
+	}
+	return d.Kind + " " + d.Namespace + "/" + d.Name
+}
+
+// serverManagedFields are set by the API server on every write and left out
+// of the comparison, with status.
+var serverManagedFields = [][]string{
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+	{"metadata", "uid"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "selfLink"},
+	{"status"},
+}
+
+// CompareLiveObject compares the live object of a chart resource with the
+// result of a server-side dry-run apply of the resource, so that defaults
+// and admission changes of the API server are not reported as drift. live
+// is nil for an object that does not exist.
+func CompareLiveObject(desired, live, applied *unstructured.Unstructured, applyErr error) ResourceDrift {
+	d := ResourceDrift{Kind: desired.GetKind(), Namespace: desired.GetNamespace(), Name: desired.GetName()}
+	switch {
+	case applyErr != nil:
+		d.State, d.Err = ClusterFailed, applyErr
+		return d
+	case live == nil:
+		d.State = ClusterMissing
+		return d
+	}
+
+	liveYAML, err := comparableYAML(live)
+	if err != nil {
+		d.State, d.Err = ClusterFailed, err
+		return d
+	}
+	appliedYAML, err := comparableYAML(applied)
+	if err != nil {
+		d.State, d.Err = ClusterFailed, err
+		return d
+	}
+	d.State = ClusterInSync
+	if d.Hunks = DiffHunks(liveYAML, appliedYAML); len(d.Hunks) > 0 {
+		d.State = ClusterDrifted
+	}
+	return d
+}
+
+// comparableYAML returns obj as YAML without server-managed fields.
+func comparableYAML(obj *unstructured.Unstructured) (string, error) {
+	obj = obj.DeepCopy()
+	for _, field := range serverManagedFields {
+		unstructured.RemoveNestedField(obj.Object, field...)
+	}
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCompareLiveObject(t *testing.T) {
+	object := func(replicas int64, resourceVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":            "web",
+				"namespace":       "prod",
+				"resourceVersion": resourceVersion,
+				"managedFields":   []interface{}{map[string]interface{}{"manager": "helm"}},
+			},
+			"spec":   map[string]interface{}{"replicas": replicas},
+			"status": map[string]interface{}{"readyReplicas": replicas},
+		}}
+	}
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "prod"},
+	}}
+
+	if d := CompareLiveObject(desired, object(3, "41"), object(3, "42"), nil); d.State != ClusterInSync || d.String() != "Deployment prod/web" {
+		t.Errorf("server-managed fields should be ignored, got %s %s %v", d, d.State, d.Hunks)
+	}
+
+	d := CompareLiveObject(desired, object(5, "41"), object(3, "41"), nil)
+	if d.State != ClusterDrifted || len(d.Hunks) != 1 {
+		t.Fatalf("expected one drifted hunk, got %s %+v", d.State, d.Hunks)
+	}
+	if h := d.Hunks[0]; len(h.Old) != 1 || h.Old[0] != "  replicas: 5\n" || h.New[0] != "  replicas: 3\n" {
+		t.Errorf("unexpected hunk %+v", h)
+	}
+
+	if d := CompareLiveObject(desired, nil, object(3, ""), nil); d.State != ClusterMissing {
+		t.Errorf("expected missing, got %s", d.State)
+	}
+
+	desired.SetNamespace("")
+	d = CompareLiveObject(desired, nil, nil, errors.New("forbidden"))
+	if d.State != ClusterFailed || d.Err == nil || d.String() != "Deployment web" {
+		t.Errorf("expected failed, got %s %s %v", d, d.State, d.Err)
+	}
+}
@@ -0,0 +1,353 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ClusterScopeStrategy selects where the cluster-scoped resources of a chart
+// (CRDs, ClusterRoles, StorageClasses, PriorityClasses, ...) are placed.
+// Installing them needs cluster-wide permissions, and they are shared by
+// every release of the chart in the cluster.
+type ClusterScopeStrategy string
+
+const (
+	// ClusterScopeTemplates keeps cluster-scoped resources in the templates
+	// of the chart with the namespaced ones.
+	ClusterScopeTemplates ClusterScopeStrategy = "templates"
+
+	// ClusterScopeCRDs moves CustomResourceDefinitions to crds/, which Helm
+	// installs before the templates and never upgrades or deletes. crds/ is
+	// not rendered, so the other cluster-scoped resources, which reference
+	// the renamed resources of the release, stay in the templates.
+	ClusterScopeCRDs ClusterScopeStrategy = "crds"
+
+	// ClusterScopeSubchart moves cluster-scoped resources and their values
+	// to the ClusterResourcesSubchart subchart, installed unless
+	// cluster-resources.enabled is false.
+	ClusterScopeSubchart ClusterScopeStrategy = "subchart"
+
+	// ClusterScopeValue renders cluster-scoped resources only when the
+	// ClusterResourcesValue value is true.
+	ClusterScopeValue ClusterScopeStrategy = "value"
+)
+
+// ClusterResourcesSubchart is the name of the subchart of ClusterScopeSubchart.
+const ClusterResourcesSubchart = "cluster-resources"
+
+// ClusterResourcesValue is the values key gating cluster-scoped resources with
+// ClusterScopeValue.
+const ClusterResourcesValue = "installClusterResources"
+
+// clusterScopedKinds lists the cluster-scoped kinds of Kubernetes and of the
+// CRDs commonly found in charts.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                        true,
+	"CustomResourceDefinition":         true,
+	"ClusterRole":                      true,
+	"ClusterRoleBinding":               true,
+	"PriorityClass":                    true,
+	"RuntimeClass":                     true,
+	"IngressClass":                     true,
+	"StorageClass":                     true,
+	"PersistentVolume":                 true,
+	"CSIDriver":                        true,
+	"VolumeSnapshotClass":              true,
+	"ValidatingWebhookConfiguration":   true,
+	"MutatingWebhookConfiguration":     true,
+	"ValidatingAdmissionPolicy":        true,
+	"ValidatingAdmissionPolicyBinding": true,
+	"APIService":                       true,
+	"PodSecurityPolicy":                true,
+	"GatewayClass":                     true,
+	"ClusterIssuer":                    true,
+	"ClusterAuthorizationRule":         true,
+}
+
+// IsClusterScoped reports whether kind is a known cluster-scoped kind.
+func IsClusterScoped(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// serviceValuesRegex matches references to the values of a service:
+// .Values.services.<service> and the key that follows it, if any.
+var serviceValuesRegex = regexp.MustCompile(`\.Values\.services\.([A-Za-z_][A-Za-z0-9_]*)(?:\.([A-Za-z_][A-Za-z0-9_]*))?`)
+
+// serviceVarRegex matches the keys read from the $svc variable that
+// templates bind to the values of their service.
+var serviceVarRegex = regexp.MustCompile(`\$svc\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ApplyClusterScopeStrategy places the cluster-scoped resources of chart by
+// strategy and returns the patched chart with the sorted paths of the
+// templates it moved or gated. crds maps the template paths of
+// CustomResourceDefinitions to their crds/ files; a CRD without one stays in
+// the templates with ClusterScopeCRDs. Charts of cluster-scoped resources
+// only are left alone by ClusterScopeCRDs and ClusterScopeSubchart.
+// Copy-on-write.
+func ApplyClusterScopeStrategy(chart *types.GeneratedChart, strategy ClusterScopeStrategy, crds map[string]types.ExternalFileInfo) (*types.GeneratedChart, []string, error) {
+	if chart == nil {
+		return nil, nil, nil
+	}
+
+	switch strategy {
+	case "", ClusterScopeTemplates:
+		return chart, nil, nil
+	case ClusterScopeCRDs, ClusterScopeValue, ClusterScopeSubchart:
+	default:
+		return nil, nil, fmt.Errorf("unknown cluster scope strategy: %q", strategy)
+	}
+
+	var paths []string
+	for p, content := range chart.Templates {
+		kind := extractKind(content)
+		if !clusterScopedKinds[kind] {
+			continue
+		}
+		if strategy == ClusterScopeCRDs {
+			if _, ok := crds[p]; !ok || kind != "CustomResourceDefinition" {
+				continue
+			}
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		return chart, nil, nil
+	}
+	if len(paths) == len(chart.Templates) && strategy != ClusterScopeValue {
+		// Nothing namespaced to separate them from: moving them all would
+		// leave the chart without templates.
+		return chart, nil, nil
+	}
+
+	result := copyChartTemplatesWithExternalFiles(chart)
+	switch strategy {
+	case ClusterScopeCRDs:
+		moved := make([]string, 0, len(paths))
+		for _, p := range paths {
+			moved = append(moved, chart.Templates[p])
+			delete(result.Templates, p)
+			result.ExternalFiles = append(result.ExternalFiles, crds[p])
+		}
+		values, _, err := splitServiceValues(chart.ValuesYAML, moved, remainingTemplates(result))
+		if err != nil {
+			return nil, nil, fmt.Errorf("chart %s: %w", chart.Name, err)
+		}
+		result.ValuesYAML = values
+
+	case ClusterScopeValue:
+		for _, p := range paths {
+			result.Templates[p] = wrapTemplateWithGuard(chart.Templates[p], ClusterResourcesValue)
+		}
+		result.ValuesYAML = strings.TrimRight(chart.ValuesYAML, "\n") + fmt.Sprintf(`
+
+# Install the cluster-scoped resources of the chart (CRDs, ClusterRoles,
+# StorageClasses, ...). Disable when they are installed by a cluster
+# administrator or by another release.
+%s: true
+`, ClusterResourcesValue)
+
+	case ClusterScopeSubchart:
+		if err := moveToClusterSubchart(chart, result, paths); err != nil {
+			return nil, nil, fmt.Errorf("chart %s: %w", chart.Name, err)
+		}
+	}
+
+	return result, paths, nil
+}
+
+// moveToClusterSubchart moves the templates at paths from chart to the
+// ClusterResourcesSubchart subchart of result, with the values they read.
+// The subchart shares the helpers of the chart, and its nameOverride keeps
+// the names the helpers give resources those of the chart.
+func moveToClusterSubchart(chart, result *types.GeneratedChart, paths []string) error {
+	moved := make([]string, 0, len(paths))
+	for _, p := range paths {
+		moved = append(moved, chart.Templates[p])
+		delete(result.Templates, p)
+	}
+	values, subValues, err := splitServiceValues(chart.ValuesYAML, moved, remainingTemplates(result))
+	if err != nil {
+		return err
+	}
+
+	parent := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(values), &parent); err != nil {
+		return fmt.Errorf("values.yaml: %w", err)
+	}
+	if global, ok := parent["global"]; ok {
+		subValues["global"] = global
+	}
+	subValues["nameOverride"] = chart.Name
+	parent[ClusterResourcesSubchart] = map[string]interface{}{"enabled": true}
+	parentYAML, err := yaml.Marshal(parent)
+	if err != nil {
+		return fmt.Errorf("values.yaml: %w", err)
+	}
+	subYAML, err := yaml.Marshal(subValues)
+	if err != nil {
+		return fmt.Errorf("%s values.yaml: %w", ClusterResourcesSubchart, err)
+	}
+	result.ValuesYAML = string(parentYAML)
+
+	var meta struct {
+		Version string `json:"version"`
+	}
+	if err := yaml.Unmarshal([]byte(chart.ChartYAML), &meta); err != nil {
+		return fmt.Errorf("Chart.yaml: %w", err)
+	}
+	result.ChartYAML = appendChartDependency(chart.ChartYAML, helm.Dependency{
+		Name:      ClusterResourcesSubchart,
+		Version:   meta.Version,
+		Condition: ClusterResourcesSubchart + ".enabled",
+	})
+
+	dir := path.Join("charts", ClusterResourcesSubchart)
+	result.ExternalFiles = append(result.ExternalFiles,
+		types.ExternalFileInfo{Path: path.Join(dir, "Chart.yaml"), Content: helm.GenerateChartYAML(helm.ChartMetadata{
+			Name:        ClusterResourcesSubchart,
+			Version:     meta.Version,
+			Description: fmt.Sprintf("Cluster-scoped resources of %s", chart.Name),
+			APIVersion:  "v2",
+			Type:        "application",
+		})},
+		types.ExternalFileInfo{Path: path.Join(dir, "values.yaml"), Content: string(subYAML)},
+	)
+	if chart.Helpers != "" {
+		result.ExternalFiles = append(result.ExternalFiles,
+			types.ExternalFileInfo{Path: path.Join(dir, "templates/_helpers.tpl"), Content: chart.Helpers})
+	}
+	for _, p := range paths {
+		result.ExternalFiles = append(result.ExternalFiles,
+			types.ExternalFileInfo{Path: path.Join(dir, p), Content: chart.Templates[p]})
+	}
+	return nil
+}
+
+// remainingTemplates returns the templates of chart.
+func remainingTemplates(chart *types.GeneratedChart) []string {
+	contents := make([]string, 0, len(chart.Templates))
+	for _, content := range chart.Templates {
+		contents = append(contents, content)
+	}
+	return contents
+}
+
+// splitServiceValues moves the services.<service>.<key> values read by the
+// moved templates out of valuesYAML, keeping those the remaining templates
+// read too. It returns the values left and the values moved, each moved
+// service with its enabled toggle. A service left without values the
+// remaining templates read is removed.
+func splitServiceValues(valuesYAML string, moved, remaining []string) (string, map[string]interface{}, error) {
+	base := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(valuesYAML), &base); err != nil {
+		return "", nil, fmt.Errorf("values.yaml: %w", err)
+	}
+	out := make(map[string]interface{})
+	services, _ := base["services"].(map[string]interface{})
+	if services == nil {
+		return valuesYAML, out, nil
+	}
+
+	movedRefs := serviceValueRefs(moved)
+	remainingRefs := serviceValueRefs(remaining)
+	outServices := make(map[string]interface{})
+	for service, keys := range movedRefs {
+		values, ok := services[service].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		outValues := make(map[string]interface{})
+		if enabled, ok := values["enabled"]; ok {
+			outValues["enabled"] = enabled
+		}
+		for key := range keys {
+			v, ok := values[key]
+			if !ok {
+				continue
+			}
+			outValues[key] = v
+			if !remainingRefs[service][key] {
+				delete(values, key)
+			}
+		}
+		outServices[service] = outValues
+		if _, used := remainingRefs[service]; !used {
+			delete(values, "enabled")
+			if len(values) == 0 {
+				delete(services, service)
+			}
+		}
+	}
+	if len(outServices) > 0 {
+		out["services"] = outServices
+	}
+
+	data, err := yaml.Marshal(base)
+	if err != nil {
+		return "", nil, fmt.Errorf("values.yaml: %w", err)
+	}
+	return string(data), out, nil
+}
+
+// serviceValueRefs returns the keys of the services' values that templates
+// read, by service. Templates reading a service without a key map it to an
+// empty set.
+func serviceValueRefs(templates []string) map[string]map[string]bool {
+	refs := make(map[string]map[string]bool)
+	add := func(service, key string) {
+		if refs[service] == nil {
+			refs[service] = make(map[string]bool)
+		}
+		if key != "" && key != "enabled" {
+			refs[service][key] = true
+		}
+	}
+	for _, content := range templates {
+		svc := ""
+		for _, m := range serviceValuesRegex.FindAllStringSubmatchIndex(content, -1) {
+			service := content[m[2]:m[3]]
+			key := ""
+			if m[4] >= 0 {
+				key = content[m[4]:m[5]]
+			}
+			add(service, key)
+			if strings.HasSuffix(strings.TrimRight(content[:m[0]], " "), "$svc :=") {
+				svc = service
+			}
+		}
+		if svc == "" {
+			continue
+		}
+		for _, m := range serviceVarRegex.FindAllStringSubmatch(content, -1) {
+			add(svc, m[1])
+		}
+	}
+	return refs
+}
+
+// appendChartDependency adds dep to the dependencies of chartYAML, the last
+// section of the Chart.yaml helm.GenerateChartYAML writes. The dependency has
+// no repository: Helm expects it in charts/.
+func appendChartDependency(chartYAML string, dep helm.Dependency) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(chartYAML, "\n"))
+	sb.WriteString("\n")
+	if !strings.Contains(chartYAML, "\ndependencies:\n") && !strings.HasPrefix(chartYAML, "dependencies:\n") {
+		sb.WriteString("dependencies:\n")
+	}
+	sb.WriteString(fmt.Sprintf("  - name: %s\n", dep.Name))
+	sb.WriteString(fmt.Sprintf("    version: %s\n", dep.Version))
+	if dep.Condition != "" {
+		sb.WriteString(fmt.Sprintf("    condition: %s\n", dep.Condition))
+	}
+	return sb.String()
+}
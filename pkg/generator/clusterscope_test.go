@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func makeClusterScopeChart() *types.GeneratedChart {
+	chart := makeChart("myapp", map[string]string{
+		"templates/deployment.yaml":  "{{- $svc := .Values.services.web -}}\n{{- if $svc.enabled }}\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: {{ $svc.deployment.replicas }}\n{{- end }}\n",
+		"templates/clusterrole.yaml": "{{- $svc := .Values.services.web -}}\n{{- if $svc.enabled }}\n{{- with $svc.clusterRole }}\napiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: web\n{{- end }}\n{{- end }}\n",
+		"templates/crd.yaml":         "{{- if .Values.services.widgets.customResourceDefinition.enabled }}\napiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.example.com\n{{- end }}\n",
+	})
+	chart.ValuesYAML = `global:
+  imageRegistry: ""
+services:
+  web:
+    enabled: true
+    deployment:
+      replicas: 2
+    clusterRole:
+      rules: []
+  widgets:
+    enabled: true
+    customResourceDefinition:
+      enabled: true
+`
+	chart.Helpers = `{{- define "myapp.labels" -}}{{- end }}`
+	return chart
+}
+
+func externalFile(chart *types.GeneratedChart, path string) (string, bool) {
+	for _, f := range chart.ExternalFiles {
+		if f.Path == path {
+			return f.Content, true
+		}
+	}
+	return "", false
+}
+
+func TestApplyClusterScopeStrategy_Templates(t *testing.T) {
+	chart := makeClusterScopeChart()
+	result, placed, err := ApplyClusterScopeStrategy(chart, ClusterScopeTemplates, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != chart || len(placed) != 0 {
+		t.Errorf("expected the chart to be left alone, placed %v", placed)
+	}
+
+	if _, _, err := ApplyClusterScopeStrategy(chart, "cluster", nil); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestApplyClusterScopeStrategy_CRDs(t *testing.T) {
+	chart := makeClusterScopeChart()
+	crds := map[string]types.ExternalFileInfo{
+		"templates/crd.yaml": {Path: "crds/widgets.example.com.yaml", Content: "kind: CustomResourceDefinition\n"},
+	}
+	result, placed, err := ApplyClusterScopeStrategy(chart, ClusterScopeCRDs, crds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placed) != 1 || placed[0] != "templates/crd.yaml" {
+		t.Errorf("expected only the CRD to be moved, got %v", placed)
+	}
+	if _, ok := result.Templates["templates/crd.yaml"]; ok {
+		t.Error("the CRD template should be removed")
+	}
+	if _, ok := result.Templates["templates/clusterrole.yaml"]; !ok {
+		t.Error("the ClusterRole should stay in the templates")
+	}
+	if _, ok := externalFile(result, "crds/widgets.example.com.yaml"); !ok {
+		t.Error("expected crds/widgets.example.com.yaml")
+	}
+	if strings.Contains(result.ValuesYAML, "widgets") {
+		t.Errorf("expected the values of the CRD to be removed:\n%s", result.ValuesYAML)
+	}
+	if _, ok := chart.Templates["templates/crd.yaml"]; !ok {
+		t.Error("the original chart must not be modified")
+	}
+}
+
+func TestApplyClusterScopeStrategy_Value(t *testing.T) {
+	chart := makeClusterScopeChart()
+	result, placed, err := ApplyClusterScopeStrategy(chart, ClusterScopeValue, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placed) != 2 {
+		t.Errorf("expected 2 gated templates, got %v", placed)
+	}
+	for _, p := range []string{"templates/clusterrole.yaml", "templates/crd.yaml"} {
+		if !strings.HasPrefix(result.Templates[p], "{{- if .Values.installClusterResources }}\n") {
+			t.Errorf("expected %s to be gated:\n%s", p, result.Templates[p])
+		}
+	}
+	if result.Templates["templates/deployment.yaml"] != chart.Templates["templates/deployment.yaml"] {
+		t.Error("the Deployment should be left alone")
+	}
+	if !strings.HasSuffix(result.ValuesYAML, "installClusterResources: true\n") {
+		t.Errorf("expected installClusterResources in values:\n%s", result.ValuesYAML)
+	}
+}
+
+func TestApplyClusterScopeStrategy_Subchart(t *testing.T) {
+	chart := makeClusterScopeChart()
+	result, placed, err := ApplyClusterScopeStrategy(chart, ClusterScopeSubchart, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placed) != 2 || len(result.Templates) != 1 {
+		t.Fatalf("expected the 2 cluster-scoped templates to be moved, got %v", placed)
+	}
+	if !strings.Contains(result.ChartYAML, "dependencies:\n  - name: cluster-resources\n    version: 0.1.0\n    condition: cluster-resources.enabled\n") {
+		t.Errorf("expected the subchart dependency in Chart.yaml:\n%s", result.ChartYAML)
+	}
+	for _, p := range []string{"Chart.yaml", "templates/_helpers.tpl", "templates/clusterrole.yaml", "templates/crd.yaml"} {
+		if _, ok := externalFile(result, "charts/cluster-resources/"+p); !ok {
+			t.Errorf("expected charts/cluster-resources/%s", p)
+		}
+	}
+
+	var values, subValues map[string]interface{}
+	if err := yaml.Unmarshal([]byte(result.ValuesYAML), &values); err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := externalFile(result, "charts/cluster-resources/values.yaml")
+	if err := yaml.Unmarshal([]byte(sub), &subValues); err != nil {
+		t.Fatal(err)
+	}
+	web := values["services"].(map[string]interface{})["web"].(map[string]interface{})
+	if _, ok := web["clusterRole"]; ok || web["deployment"] == nil || web["enabled"] != true {
+		t.Errorf("expected the web service to keep its Deployment values only: %v", web)
+	}
+	if _, ok := values["services"].(map[string]interface{})["widgets"]; ok {
+		t.Error("expected the widgets service to move to the subchart")
+	}
+	if values["cluster-resources"].(map[string]interface{})["enabled"] != true {
+		t.Errorf("expected cluster-resources.enabled: %v", values["cluster-resources"])
+	}
+	subWeb := subValues["services"].(map[string]interface{})["web"].(map[string]interface{})
+	if _, ok := subWeb["clusterRole"]; !ok || subWeb["enabled"] != true || subWeb["deployment"] != nil {
+		t.Errorf("expected the subchart to get the ClusterRole values of web: %v", subWeb)
+	}
+	if subValues["nameOverride"] != "myapp" {
+		t.Errorf("expected nameOverride myapp, got %v", subValues["nameOverride"])
+	}
+}
+
+func TestApplyClusterScopeStrategy_ClusterScopedOnly(t *testing.T) {
+	chart := makeChart("myapp", map[string]string{
+		"templates/clusterrole.yaml": "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: web\n",
+	})
+	result, placed, err := ApplyClusterScopeStrategy(chart, ClusterScopeSubchart, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != chart || len(placed) != 0 {
+		t.Errorf("expected a chart of cluster-scoped resources only to be left alone, placed %v", placed)
+	}
+}
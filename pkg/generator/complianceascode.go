@@ -261,7 +261,7 @@ func detectViolations(graph *types.ResourceGraph, opts ComplianceOptions) []Comp
 	minLevel := severityLevel(opts.Severity)
 
 	for _, standard := range opts.Standards {
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			kind := r.Original.GVK.Kind
 			if kind != "Deployment" && kind != "StatefulSet" && kind != "DaemonSet" &&
 				kind != "Pod" && kind != "Job" && kind != "CronJob" {
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -71,18 +72,36 @@ type CostEstimateOptions struct {
 	Region         CostRegion
 	Unit           CostUnit
 	IncludeStorage bool
+
+	// CPUPricePerHour overrides the provider price of a vCPU-hour (USD)
+	// when positive.
+	CPUPricePerHour float64
+
+	// MemoryPricePerGiBHour overrides the provider price of a GiB-hour
+	// (USD) when positive.
+	MemoryPricePerGiBHour float64
+}
+
+// IsCostProvider reports whether p has pricing presets.
+func IsCostProvider(p CloudProvider) bool {
+	_, ok := cloudPrices[p]
+	return ok
 }
 
 // WorkloadCostEstimate holds per-workload cost breakdown.
 type WorkloadCostEstimate struct {
-	Name       string
-	Namespace  string
-	Kind       string
-	Replicas   int
-	CPUCost    float64
-	MemoryCost float64
-	TotalCost  float64
-	Warnings   []string
+	Name      string
+	Namespace string
+	Kind      string
+	Service   string
+	Replicas  int
+	// CPUMillicores and MemoryMiB are the requests of one replica.
+	CPUMillicores int64
+	MemoryMiB     int64
+	CPUCost       float64
+	MemoryCost    float64
+	TotalCost     float64
+	Warnings      []string
 }
 
 // CostEstimateReport holds the full cost estimation result.
@@ -91,8 +110,41 @@ type CostEstimateReport struct {
 	Region           CostRegion
 	Unit             CostUnit
 	Workloads        []WorkloadCostEstimate
+	Services         []ServiceCostEstimate
 	TotalStorageCost float64
 	GrandTotal       float64
+	// CPUPricePerHour and MemoryPricePerGiBHour are the prices used (USD).
+	CPUPricePerHour       float64
+	MemoryPricePerGiBHour float64
+}
+
+// ServiceCostEstimate holds the cost of the workloads and, with
+// IncludeStorage, the PersistentVolumeClaims of one service.
+type ServiceCostEstimate struct {
+	Name        string
+	Workloads   int
+	CPUCost     float64
+	MemoryCost  float64
+	StorageCost float64
+	TotalCost   float64
+}
+
+// ServiceCostDelta holds the cost change of one service between two
+// estimates. Services missing from one estimate cost 0 there.
+type ServiceCostDelta struct {
+	Name     string
+	Baseline float64
+	Current  float64
+	Delta    float64
+}
+
+// CostEstimateDelta compares the cost of two sets of manifests.
+type CostEstimateDelta struct {
+	Unit          CostUnit
+	Services      []ServiceCostDelta
+	BaselineTotal float64
+	CurrentTotal  float64
+	Delta         float64
 }
 
 // parseResourceQuantity parses a Kubernetes quantity string.
@@ -158,22 +210,33 @@ func extractReplicasFromResource(obj *unstructured.Unstructured) int {
 	kind := obj.GetKind()
 	switch kind {
 	case "Job":
-		v, found, err := unstructured.NestedInt64(obj.Object, "spec", "parallelism")
-		if err == nil && found && v > 0 {
-			return int(v)
+		if v := nestedCount(obj, "spec", "parallelism"); v > 0 {
+			return v
 		}
 		return 1
 	case "CronJob":
 		return 1
 	default:
-		v, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
-		if err == nil && found && v > 0 {
-			return int(v)
+		if v := nestedCount(obj, "spec", "replicas"); v > 0 {
+			return v
 		}
 		return 1
 	}
 }
 
+// nestedCount reads an integer field that YAML decoding may have left as
+// int64 or float64.
+func nestedCount(obj *unstructured.Unstructured, fields ...string) int {
+	raw, _, _ := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	switch v := raw.(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
 // extractContainersFromObj returns the containers slice for the workload kind.
 func extractContainersFromObj(obj *unstructured.Unstructured) ([]interface{}, bool) {
 	kind := obj.GetKind()
@@ -207,6 +270,7 @@ func estimateWorkloadCost(r *types.ProcessedResource, pricing providerPricing, u
 		Name:      obj.GetName(),
 		Namespace: obj.GetNamespace(),
 		Kind:      obj.GetKind(),
+		Service:   r.ServiceName,
 		Replicas:  extractReplicasFromResource(obj),
 	}
 
@@ -226,13 +290,21 @@ func estimateWorkloadCost(r *types.ProcessedResource, pricing providerPricing, u
 		}
 
 		requests, _, _ := unstructured.NestedStringMap(c, "resources", "requests")
+		limits, _, _ := unstructured.NestedStringMap(c, "resources", "limits")
 		cpuStr := requests["cpu"]
 		memStr := requests["memory"]
 
 		// Check if there are any requests at all.
-		if cpuStr == "" && memStr == "" {
+		if cpuStr == "" && memStr == "" && len(limits) == 0 {
 			est.Warnings = append(est.Warnings, "container has no resource requests; using default values")
 		}
+		// Kubernetes defaults a missing request to the limit.
+		if cpuStr == "" {
+			cpuStr = limits["cpu"]
+		}
+		if memStr == "" {
+			memStr = limits["memory"]
+		}
 
 		// Parse CPU.
 		cpuMillis := int64(defaultCPUMillicores)
@@ -260,6 +332,9 @@ func estimateWorkloadCost(r *types.ProcessedResource, pricing providerPricing, u
 		totalMemMiB += memMiB
 	}
 
+	est.CPUMillicores = totalCPUMillis
+	est.MemoryMiB = totalMemMiB
+
 	// Compute per-replica hourly costs
 	cpuCostPerReplicaHourly := float64(totalCPUMillis) * pricing.CPUPerMillicorePerHour
 	memCostPerReplicaHourly := float64(totalMemMiB) * pricing.MemPerMiBPerHour
@@ -293,20 +368,36 @@ func GenerateCostEstimate(graph *types.ResourceGraph, opts CostEstimateOptions)
 	if !ok {
 		pricing = cloudPrices[CloudProviderAWS]
 	}
+	if opts.CPUPricePerHour > 0 {
+		pricing.CPUPerMillicorePerHour = opts.CPUPricePerHour / 1000.0
+	}
+	if opts.MemoryPricePerGiBHour > 0 {
+		pricing.MemPerMiBPerHour = opts.MemoryPricePerGiBHour / 1024.0
+	}
 
 	report := &CostEstimateReport{
-		Provider:  opts.Provider,
-		Region:    region,
-		Unit:      opts.Unit,
-		Workloads: []WorkloadCostEstimate{},
+		Provider:              opts.Provider,
+		Region:                region,
+		Unit:                  opts.Unit,
+		Workloads:             []WorkloadCostEstimate{},
+		CPUPricePerHour:       pricing.CPUPerMillicorePerHour * 1000.0,
+		MemoryPricePerGiBHour: pricing.MemPerMiBPerHour * 1024.0,
 	}
 
 	if graph == nil {
 		return report
 	}
 
+	services := make(map[string]*ServiceCostEstimate)
+	service := func(name string) *ServiceCostEstimate {
+		if services[name] == nil {
+			services[name] = &ServiceCostEstimate{Name: name}
+		}
+		return services[name]
+	}
+
 	// Process workloads.
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		kind := r.Original.GVK.Kind
 		if !isWorkloadKind(kind) {
 			continue
@@ -314,11 +405,17 @@ func GenerateCostEstimate(graph *types.ResourceGraph, opts CostEstimateOptions)
 		est := estimateWorkloadCost(r, pricing, opts.Unit)
 		report.Workloads = append(report.Workloads, est)
 		report.GrandTotal += est.TotalCost
+
+		svc := service(est.Service)
+		svc.Workloads++
+		svc.CPUCost += est.CPUCost
+		svc.MemoryCost += est.MemoryCost
+		svc.TotalCost += est.TotalCost
 	}
 
 	// Process storage if requested.
 	if opts.IncludeStorage {
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			if r.Original.GVK.Kind != "PersistentVolumeClaim" {
 				continue
 			}
@@ -350,13 +447,55 @@ func GenerateCostEstimate(graph *types.ResourceGraph, opts CostEstimateOptions)
 				}
 				report.TotalStorageCost += cost
 				report.GrandTotal += cost
+
+				svc := service(r.ServiceName)
+				svc.StorageCost += cost
+				svc.TotalCost += cost
 			}
 		}
 	}
 
+	for _, svc := range services {
+		report.Services = append(report.Services, *svc)
+	}
+	sort.Slice(report.Services, func(i, j int) bool {
+		return report.Services[i].Name < report.Services[j].Name
+	})
+
 	return report
 }
 
+// CompareCostEstimates returns the per-service and total cost change from
+// baseline to current. Both estimates should use the same unit and prices.
+func CompareCostEstimates(baseline, current *CostEstimateReport) *CostEstimateDelta {
+	delta := &CostEstimateDelta{Unit: current.Unit}
+	byName := make(map[string]*ServiceCostDelta)
+	var names []string
+	entry := func(name string) *ServiceCostDelta {
+		if byName[name] == nil {
+			byName[name] = &ServiceCostDelta{Name: name}
+			names = append(names, name)
+		}
+		return byName[name]
+	}
+	for _, svc := range baseline.Services {
+		entry(svc.Name).Baseline = svc.TotalCost
+	}
+	for _, svc := range current.Services {
+		entry(svc.Name).Current = svc.TotalCost
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := byName[name]
+		d.Delta = d.Current - d.Baseline
+		delta.Services = append(delta.Services, *d)
+	}
+	delta.BaselineTotal = baseline.GrandTotal
+	delta.CurrentTotal = current.GrandTotal
+	delta.Delta = current.GrandTotal - baseline.GrandTotal
+	return delta
+}
+
 // InjectCostNotes injects a cost estimate section into the chart's NOTES.txt.
 // Returns a copy of the chart with updated Notes and a boolean indicating whether
 // injection occurred (false if notes were already present).
@@ -16,6 +16,11 @@ package generator
 // 11. TestCostEstimate_EmptyRegion_UsesDefault          — edge    empty Region → report Region is non-empty (default applied)
 // 12. TestCostEstimate_MalformedQuantity_Warning        — error   "garbage" CPU quantity → workload-level warning emitted
 // 13. TestInjectCostNotes_Idempotent                    — integration second inject does not duplicate NOTES content
+// 14. TestCostEstimate_CustomPrices                     — happy   $/vCPU-hr and $/GiB-hr override the provider preset
+// 15. TestCostEstimate_LimitsOnly_UsesLimits            — edge    container with limits only → limits used as requests, no warning
+// 16. TestCostEstimate_Services_Aggregated              — happy   workloads of one service are summed per service
+// 17. TestCompareCostEstimates_Delta                    — happy   per-service and total deltas, added and removed services
+// 18. TestCostEstimate_FloatReplicas                    — edge    replicas decoded from YAML as float64 are counted
 // ============================================================
 
 import (
@@ -549,3 +554,110 @@ func TestInjectCostNotes_Idempotent(t *testing.T) {
 		t.Errorf("second InjectCostNotes duplicated content: count before=%d, after=%d", count1, count2)
 	}
 }
+
+// ─── Section 10: Custom prices, limits and services ──────────────────────────
+
+func TestCostEstimate_CustomPrices(t *testing.T) {
+	graph := makeTestGraphWithWorkload("Deployment", "web", "default", 2, "1", "", "1Gi", "")
+	report := GenerateCostEstimate(graph, CostEstimateOptions{
+		Provider:              CloudProviderAWS,
+		Unit:                  CostUnitHourly,
+		CPUPricePerHour:       0.10,
+		MemoryPricePerGiBHour: 0.01,
+	})
+
+	w := report.Workloads[0]
+	if w.CPUMillicores != 1000 || w.MemoryMiB != 1024 {
+		t.Errorf("unexpected requests: %dm, %dMi", w.CPUMillicores, w.MemoryMiB)
+	}
+	// 2 replicas × 1 vCPU × $0.10 + 2 replicas × 1 GiB × $0.01
+	if diff := report.GrandTotal - 0.22; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("expected GrandTotal=0.22, got %f", report.GrandTotal)
+	}
+}
+
+func TestCostEstimate_LimitsOnly_UsesLimits(t *testing.T) {
+	graph := makeTestGraphWithWorkload("Deployment", "web", "default", 1, "", "2", "", "512Mi")
+	report := GenerateCostEstimate(graph, CostEstimateOptions{Provider: CloudProviderAWS, Unit: CostUnitMonthly})
+
+	w := report.Workloads[0]
+	if w.CPUMillicores != 2000 || w.MemoryMiB != 512 {
+		t.Errorf("expected the limits 2000m/512Mi, got %dm/%dMi", w.CPUMillicores, w.MemoryMiB)
+	}
+	if len(w.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", w.Warnings)
+	}
+}
+
+func TestCostEstimate_Services_Aggregated(t *testing.T) {
+	graph := makeTestGraphWithWorkload("Deployment", "web", "default", 1, "500m", "", "256Mi", "")
+	worker := makeTestGraphWithWorkload("Deployment", "worker", "default", 2, "250m", "", "128Mi", "")
+	for _, r := range worker.Resources {
+		r.ServiceName = "web"
+		graph.AddResource(r)
+	}
+	db := makeTestGraphWithWorkload("StatefulSet", "db", "default", 1, "1", "", "1Gi", "")
+	for _, r := range db.Resources {
+		graph.AddResource(r)
+	}
+
+	report := GenerateCostEstimate(graph, CostEstimateOptions{Provider: CloudProviderGCP, Unit: CostUnitMonthly})
+
+	if len(report.Services) != 2 || report.Services[0].Name != "db" || report.Services[1].Name != "web" {
+		t.Fatalf("expected services db and web, got %+v", report.Services)
+	}
+	web := report.Services[1]
+	if web.Workloads != 2 {
+		t.Errorf("expected 2 web workloads, got %d", web.Workloads)
+	}
+	var sum float64
+	for _, svc := range report.Services {
+		sum += svc.TotalCost
+	}
+	if diff := sum - report.GrandTotal; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("service totals %f do not add up to GrandTotal %f", sum, report.GrandTotal)
+	}
+}
+
+func TestCompareCostEstimates_Delta(t *testing.T) {
+	baseline := &CostEstimateReport{
+		Unit:       CostUnitMonthly,
+		Services:   []ServiceCostEstimate{{Name: "web", TotalCost: 10}, {Name: "legacy", TotalCost: 5}},
+		GrandTotal: 15,
+	}
+	current := &CostEstimateReport{
+		Unit:       CostUnitMonthly,
+		Services:   []ServiceCostEstimate{{Name: "web", TotalCost: 30}, {Name: "api", TotalCost: 4}},
+		GrandTotal: 34,
+	}
+
+	delta := CompareCostEstimates(baseline, current)
+
+	want := []ServiceCostDelta{
+		{Name: "api", Baseline: 0, Current: 4, Delta: 4},
+		{Name: "legacy", Baseline: 5, Current: 0, Delta: -5},
+		{Name: "web", Baseline: 10, Current: 30, Delta: 20},
+	}
+	if len(delta.Services) != len(want) {
+		t.Fatalf("expected %d services, got %+v", len(want), delta.Services)
+	}
+	for i := range want {
+		if delta.Services[i] != want[i] {
+			t.Errorf("service %d = %+v, want %+v", i, delta.Services[i], want[i])
+		}
+	}
+	if delta.Delta != 19 || delta.BaselineTotal != 15 || delta.CurrentTotal != 34 {
+		t.Errorf("unexpected totals: %+v", delta)
+	}
+}
+
+func TestCostEstimate_FloatReplicas(t *testing.T) {
+	graph := makeTestGraphWithWorkload("Deployment", "web", "default", 1, "1", "", "1Gi", "")
+	for _, r := range graph.Resources {
+		_ = unstructured.SetNestedField(r.Original.Object.Object, float64(3), "spec", "replicas")
+	}
+	report := GenerateCostEstimate(graph, CostEstimateOptions{Provider: CloudProviderAWS, Unit: CostUnitMonthly})
+	if report.Workloads[0].Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", report.Workloads[0].Replicas)
+	}
+}
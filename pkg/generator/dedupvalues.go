@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/value"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// dedupContainerKeys are the container values blocks --dedup-values factors
+// into common:, in the order they are written.
+var dedupContainerKeys = []string{"resources", "livenessProbe", "readinessProbe", "startupProbe", "securityContext"}
+
+// dedupWorkloadKeys are the pod-level values blocks --dedup-values factors
+// into common:.
+var dedupWorkloadKeys = []string{"podSecurityContext"}
+
+// DeduplicateValues factors the resources, probes and securityContext blocks
+// repeated across workloads into common values. A block is factored when its
+// most frequent value occurs at least twice and every workload (or container)
+// whose template renders it sets it; workloads then keep only the fields that
+// differ, and their templates merge them over .Values.common.<key> with the
+// "<chart>.mergeValues" helper (see MergeValuesHelper).
+//
+// It returns the common values and copies of the groups with the factored
+// blocks stripped and templates rewritten; the input groups are not modified.
+func DeduplicateValues(groups []*types.ResourceGroup, chartName string) (map[string]interface{}, []*types.ResourceGroup) {
+	out := make([]*types.ResourceGroup, 0, len(groups))
+	var resources []*types.ProcessedResource
+	for _, group := range groups {
+		copied := *group
+		copied.Resources = make([]*types.ProcessedResource, 0, len(group.Resources))
+		for _, r := range group.Resources {
+			rc := *r
+			if r.Values != nil {
+				rc.Values = cloneValue(r.Values).(map[string]interface{})
+			}
+			copied.Resources = append(copied.Resources, &rc)
+			resources = append(resources, &rc)
+		}
+		out = append(out, &copied)
+	}
+
+	common := make(map[string]interface{})
+	for _, key := range dedupWorkloadKeys {
+		var holders []*types.ProcessedResource
+		var blocks []map[string]interface{}
+		for _, r := range resources {
+			if rendersValuesBlock(r.TemplateContent, key) {
+				holders = append(holders, r)
+				blocks = append(blocks, r.Values)
+			}
+		}
+		if v := value.FactorCommon(blocks, key, 2); v != nil {
+			common[key] = v
+			mergeCommonInTemplates(holders, key, chartName)
+		}
+	}
+	for _, key := range dedupContainerKeys {
+		var holders []*types.ProcessedResource
+		var blocks []map[string]interface{}
+		for _, r := range resources {
+			if !rendersValuesBlock(r.TemplateContent, key) {
+				continue
+			}
+			containers, _ := r.Values["containers"].([]interface{})
+			for _, c := range containers {
+				if container, ok := c.(map[string]interface{}); ok {
+					blocks = append(blocks, container)
+				}
+			}
+			holders = append(holders, r)
+		}
+		if v := value.FactorCommon(blocks, key, 2); v != nil {
+			common[key] = v
+			mergeCommonInTemplates(holders, key, chartName)
+		}
+	}
+
+	return common, out
+}
+
+// valuesBlockPattern matches the "{{- with .<key> }}" opening of a values block.
+func valuesBlockPattern(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^( *)\{\{- with \.` + regexp.QuoteMeta(key) + ` \}\}$`)
+}
+
+// rendersValuesBlock reports whether a template renders the values block key.
+func rendersValuesBlock(content, key string) bool {
+	return valuesBlockPattern(key).MatchString(content)
+}
+
+// mergeCommonInTemplates makes the templates render key merged over
+// .Values.common.<key>.
+func mergeCommonInTemplates(resources []*types.ProcessedResource, key, chartName string) {
+	pattern := valuesBlockPattern(key)
+	replacement := fmt.Sprintf(`${1}{{- with include "%s.mergeValues" (list $$.Values.common.%s .%s) | fromYaml }}`, chartName, key, key)
+	for _, r := range resources {
+		r.TemplateContent = pattern.ReplaceAllString(r.TemplateContent, replacement)
+	}
+}
+
+// MergeValuesHelper returns the "<chart>.mergeValues" named template that
+// merges per-service values over common values for --dedup-values.
+func MergeValuesHelper(chartName string) string {
+	var sb strings.Builder
+	sb.WriteString("{{/*\n")
+	sb.WriteString("Merge per-service values over common values: (list <common> <values>)\n")
+	sb.WriteString("Fields set per service win; the result is YAML, read it with fromYaml\n")
+	sb.WriteString("*/}}\n")
+	sb.WriteString(fmt.Sprintf("{{- define \"%s.mergeValues\" -}}\n", chartName))
+	sb.WriteString("{{- $common := index . 0 | default dict -}}\n")
+	sb.WriteString("{{- $values := index . 1 | default dict -}}\n")
+	sb.WriteString("{{- toYaml (merge (dict) $values $common) -}}\n")
+	sb.WriteString("{{- end }}\n")
+	return sb.String()
+}
@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const dedupTestTemplate = `{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.deployment }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: %s
+spec:
+  template:
+    spec:
+      containers:
+        {{- range .containers }}
+        - name: {{ .name }}
+          {{- with .resources }}
+          resources:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .readinessProbe }}
+          readinessProbe:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+        {{- end }}
+{{- end }}
+{{- end }}
+`
+
+func dedupTestDeployment(name, cpuLimit string) *types.ProcessedResource {
+	values := map[string]interface{}{
+		"containers": []map[string]interface{}{{
+			"name": name,
+			"resources": map[string]interface{}{
+				"limits":   map[string]interface{}{"cpu": cpuLimit, "memory": "256Mi"},
+				"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+			},
+			"readinessProbe": map[string]interface{}{
+				"httpGet":       map[string]interface{}{"path": "/healthz", "port": 8080},
+				"periodSeconds": 10,
+			},
+		}},
+	}
+	r := makeProcessedResourceWithValues("Deployment", name, "default",
+		map[string]string{"app.kubernetes.io/name": name}, values,
+		strings.ReplaceAll(dedupTestTemplate, "%s", name))
+	r.ServiceName = name
+	r.TemplatePath = "templates/" + name + "-deployment.yaml"
+	return r
+}
+
+func dedupTestGraph() *types.ResourceGraph {
+	graph := buildGraph([]*types.ProcessedResource{
+		dedupTestDeployment("web", "500m"),
+		dedupTestDeployment("api", "500m"),
+		dedupTestDeployment("worker", "1"),
+	}, nil)
+	for _, name := range []string{"web", "api", "worker"} {
+		graph.Groups = append(graph.Groups, &types.ResourceGroup{
+			Name:      name,
+			Resources: []*types.ProcessedResource{graph.Resources[resourceKey(dedupTestDeployment(name, ""))]},
+		})
+	}
+	return graph
+}
+
+func TestDeduplicateValues(t *testing.T) {
+	graph := dedupTestGraph()
+
+	common, groups := DeduplicateValues(graph.Groups, "shop")
+
+	if _, ok := common["resources"]; !ok {
+		t.Fatalf("expected resources factored into common, got %v", common)
+	}
+	if _, ok := common["readinessProbe"]; !ok {
+		t.Fatalf("expected readinessProbe factored into common, got %v", common)
+	}
+	web := groups[0].Resources[0]
+	if _, ok := web.Values["containers"].([]interface{})[0].(map[string]interface{})["resources"]; ok {
+		t.Error("expected resources equal to common removed from web")
+	}
+	if !strings.Contains(web.TemplateContent, `{{- with include "shop.mergeValues" (list $.Values.common.resources .resources) | fromYaml }}`) {
+		t.Errorf("template does not merge common resources:\n%s", web.TemplateContent)
+	}
+	if graph.Groups[0].Resources[0].TemplateContent == web.TemplateContent {
+		t.Error("input template was modified")
+	}
+}
+
+func TestDeduplicateValues_NothingShared(t *testing.T) {
+	graph := dedupTestGraph()
+	graph.Groups = graph.Groups[2:]
+
+	common, groups := DeduplicateValues(graph.Groups, "shop")
+
+	if len(common) != 0 {
+		t.Errorf("expected no common values for a single workload, got %v", common)
+	}
+	if strings.Contains(groups[0].Resources[0].TemplateContent, "mergeValues") {
+		t.Error("template should not be rewritten when nothing is factored")
+	}
+}
+
+func TestUniversalGenerator_DedupValuesRender(t *testing.T) {
+	charts, err := NewUniversalGenerator().Generate(context.Background(), dedupTestGraph(),
+		Options{ChartName: "shop", ChartVersion: "0.1.0", DedupValues: true})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	chart := charts[0]
+	if !strings.Contains(chart.ValuesYAML, "common:") || strings.Count(chart.ValuesYAML, "memory: 256Mi") != 1 {
+		t.Errorf("expected resources written once under common:\n%s", chart.ValuesYAML)
+	}
+
+	dir := t.TempDir()
+	if err := WriteChart(chart, dir); err != nil {
+		t.Fatalf("WriteChart: %v", err)
+	}
+	rendered, err := helm.RenderChart(filepath.Join(dir, "shop"), helm.RenderOptions{})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	web := rendered.Manifests["templates/web-deployment.yaml"]
+	worker := rendered.Manifests["templates/worker-deployment.yaml"]
+	for _, want := range []string{"cpu: 500m", "memory: 256Mi", "memory: 128Mi", "path: /healthz", "periodSeconds: 10"} {
+		if !strings.Contains(web, want) {
+			t.Errorf("web missing %q:\n%s", want, web)
+		}
+	}
+	for _, want := range []string{"cpu: \"1\"", "memory: 256Mi", "cpu: 100m"} {
+		if !strings.Contains(worker, want) {
+			t.Errorf("worker missing %q:\n%s", want, worker)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
@@ -18,6 +19,54 @@ func DetectCrossChartDeps(groups []*ServiceGroup, graph *types.ResourceGraph, ch
 		return make(ChartDependencyMap), nil
 	}
 
+	crossDeps := crossGroupDeps(groups, graph)
+
+	// Check for circular dependencies using DFS.
+	if err := detectCircular(crossDeps); err != nil {
+		return nil, err
+	}
+
+	tags := dependentTags(crossDeps)
+
+	// Convert to helm.Dependency format.
+	result := make(ChartDependencyMap)
+	for chartName, depNames := range crossDeps {
+		names := make([]string, 0, len(depNames))
+		for depName := range depNames {
+			names = append(names, depName)
+		}
+		sort.Strings(names)
+
+		deps := make([]helm.Dependency, 0, len(names))
+		for _, depName := range names {
+			deps = append(deps, helm.Dependency{
+				Name:       depName,
+				Version:    chartVersion,
+				Repository: fmt.Sprintf("file://../%s", depName),
+				Condition:  fmt.Sprintf("%s.enabled", depName),
+				Tags:       tags[depName],
+			})
+		}
+		result[chartName] = deps
+	}
+
+	return result, nil
+}
+
+// DependencyTags returns, for each service group required by other groups,
+// the sorted names of the groups that depend on it directly or transitively.
+// They become the group's dependency tags, so "tags.<service>" selects a
+// service together with everything it needs.
+func DependencyTags(groups []*ServiceGroup, graph *types.ResourceGraph) map[string][]string {
+	if len(groups) <= 1 {
+		return make(map[string][]string)
+	}
+	return dependentTags(crossGroupDeps(groups, graph))
+}
+
+// crossGroupDeps returns, for each group name, the set of group names it
+// depends on through relationships between their resources.
+func crossGroupDeps(groups []*ServiceGroup, graph *types.ResourceGraph) map[string]map[string]bool {
 	// Build resource key -> group name mapping.
 	resourceToGroup := make(map[types.ResourceKey]string)
 	for _, group := range groups {
@@ -48,27 +97,38 @@ func DetectCrossChartDeps(groups []*ServiceGroup, graph *types.ResourceGraph, ch
 		crossDeps[fromGroup][toGroup] = true
 	}
 
-	// Check for circular dependencies using DFS.
-	if err := detectCircular(crossDeps); err != nil {
-		return nil, err
-	}
+	return crossDeps
+}
 
-	// Convert to helm.Dependency format.
-	result := make(ChartDependencyMap)
-	for chartName, depNames := range crossDeps {
-		deps := make([]helm.Dependency, 0, len(depNames))
-		for depName := range depNames {
-			deps = append(deps, helm.Dependency{
-				Name:       depName,
-				Version:    chartVersion,
-				Repository: fmt.Sprintf("file://../%s", depName),
-				Condition:  fmt.Sprintf("%s.enabled", depName),
-			})
+// dependentTags inverts crossDeps into the transitive dependents of each
+// group. Cycles are tolerated: a group never lists itself.
+func dependentTags(crossDeps map[string]map[string]bool) map[string][]string {
+	dependents := make(map[string]map[string]bool)
+	var visit func(dependent, group string)
+	visit = func(dependent, group string) {
+		for dep := range crossDeps[group] {
+			if dep == dependent || dependents[dep][dependent] {
+				continue
+			}
+			if dependents[dep] == nil {
+				dependents[dep] = make(map[string]bool)
+			}
+			dependents[dep][dependent] = true
+			visit(dependent, dep)
 		}
-		result[chartName] = deps
+	}
+	for group := range crossDeps {
+		visit(group, group)
 	}
 
-	return result, nil
+	tags := make(map[string][]string, len(dependents))
+	for group, names := range dependents {
+		for name := range names {
+			tags[group] = append(tags[group], name)
+		}
+		sort.Strings(tags[group])
+	}
+	return tags
 }
 
 // detectCircular checks for circular dependencies using DFS.
@@ -380,3 +380,63 @@ func TestInterChartDeps_Edge_EmptyGroups(t *testing.T) {
 		t.Errorf("expected empty deps map, got %d entries", len(deps))
 	}
 }
+
+// ============================================================
+// Subtask 7: Dependency tags
+// ============================================================
+
+// threeTierGraph builds frontend -> backend -> database relationships.
+func threeTierGraph() *types.ResourceGraph {
+	frontDeploy := makeProcessedResourceWithValues("Deployment", "frontend", "default",
+		map[string]string{"app.kubernetes.io/name": "frontend"}, map[string]interface{}{}, "# fe")
+	backDeploy := makeProcessedResourceWithValues("Deployment", "backend", "default",
+		map[string]string{"app.kubernetes.io/name": "backend"}, map[string]interface{}{}, "# be")
+	backSvc := makeProcessedResourceWithValues("Service", "backend-svc", "default",
+		map[string]string{"app.kubernetes.io/name": "backend"}, map[string]interface{}{}, "# be-svc")
+	dbSvc := makeProcessedResourceWithValues("Service", "database-svc", "default",
+		map[string]string{"app.kubernetes.io/name": "database"}, map[string]interface{}{}, "# db-svc")
+
+	relationships := []types.Relationship{
+		{From: resourceKey(frontDeploy), To: resourceKey(backSvc), Type: types.RelationNameReference},
+		{From: resourceKey(backDeploy), To: resourceKey(dbSvc), Type: types.RelationNameReference},
+	}
+	return buildGraph([]*types.ProcessedResource{frontDeploy, backDeploy, backSvc, dbSvc}, relationships)
+}
+
+func TestInterChartDeps_DependencyTags_Transitive(t *testing.T) {
+	graph := threeTierGraph()
+	groupResult, err := GroupResources(graph)
+	if err != nil {
+		t.Fatalf("GroupResources returned error: %v", err)
+	}
+
+	tags := DependencyTags(groupResult.Groups, graph)
+
+	if got := strings.Join(tags["database"], ","); got != "backend,frontend" {
+		t.Errorf("expected database tags backend,frontend, got %q", got)
+	}
+	if got := strings.Join(tags["backend"], ","); got != "frontend" {
+		t.Errorf("expected backend tags frontend, got %q", got)
+	}
+	if _, ok := tags["frontend"]; ok {
+		t.Errorf("frontend has no dependents, got tags %v", tags["frontend"])
+	}
+}
+
+func TestInterChartDeps_DependencyTags_OnDependencies(t *testing.T) {
+	graph := threeTierGraph()
+	groupResult, err := GroupResources(graph)
+	if err != nil {
+		t.Fatalf("GroupResources returned error: %v", err)
+	}
+
+	deps, err := DetectCrossChartDeps(groupResult.Groups, graph, "0.1.0")
+	if err != nil {
+		t.Fatalf("DetectCrossChartDeps returned error: %v", err)
+	}
+
+	backDeps := deps["backend"]
+	if len(backDeps) != 1 || strings.Join(backDeps[0].Tags, ",") != "backend,frontend" {
+		t.Errorf("expected database dependency tagged backend,frontend, got %+v", backDeps)
+	}
+}
@@ -0,0 +1,286 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DefaultArtifactHubURL is the ArtifactHub instance queried for dependency
+// versions when no repository index is configured.
+const DefaultArtifactHubURL = "https://artifacthub.io"
+
+// ChartLockFile is the lock file written next to Chart.yaml.
+const ChartLockFile = "Chart.lock"
+
+// DependencyResolver looks up the version a dependency should be pinned to.
+type DependencyResolver interface {
+	LatestVersion(ctx context.Context, dep helm.Dependency) (string, error)
+}
+
+// ArtifactHubResolver resolves the latest version of a chart through the
+// ArtifactHub packages API.
+type ArtifactHubResolver struct {
+	// BaseURL is the ArtifactHub URL (DefaultArtifactHubURL when empty).
+	BaseURL string
+
+	// Client is the HTTP client (a client with a 30s timeout when nil).
+	Client *http.Client
+}
+
+// artifactHubRepos maps chart repository URLs to ArtifactHub repository names.
+var artifactHubRepos = map[string]string{
+	bitnamiRepo: "bitnami",
+}
+
+// LatestVersion implements DependencyResolver.
+func (r *ArtifactHubResolver) LatestVersion(ctx context.Context, dep helm.Dependency) (string, error) {
+	repo, ok := artifactHubRepos[strings.TrimSuffix(dep.Repository, "/")]
+	if !ok {
+		return "", fmt.Errorf("%s: repository %s is not known to ArtifactHub; configure a repository index", dep.Name, dep.Repository)
+	}
+	baseURL := r.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultArtifactHubURL
+	}
+
+	data, err := httpGet(ctx, r.Client, fmt.Sprintf("%s/api/v1/packages/helm/%s/%s", strings.TrimSuffix(baseURL, "/"), repo, dep.Name))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", dep.Name, err)
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("%s: invalid ArtifactHub response: %w", dep.Name, err)
+	}
+	if pkg.Version == "" {
+		return "", fmt.Errorf("%s: ArtifactHub returned no version", dep.Name)
+	}
+	return pkg.Version, nil
+}
+
+// RepoIndexResolver resolves the latest stable version of a chart from a Helm
+// repository index.yaml. Indexes are fetched once per URL.
+type RepoIndexResolver struct {
+	// IndexURL overrides the index location for every dependency. When empty
+	// the index is read from <dependency repository>/index.yaml.
+	IndexURL string
+
+	// Client is the HTTP client (a client with a 30s timeout when nil).
+	Client *http.Client
+
+	mu      sync.Mutex
+	indexes map[string]map[string][]string
+}
+
+// LatestVersion implements DependencyResolver.
+func (r *RepoIndexResolver) LatestVersion(ctx context.Context, dep helm.Dependency) (string, error) {
+	url := r.IndexURL
+	if url == "" {
+		url = strings.TrimSuffix(dep.Repository, "/") + "/index.yaml"
+	}
+
+	entries, err := r.index(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", dep.Name, err)
+	}
+
+	latest := ""
+	for _, v := range entries[dep.Name] {
+		if strings.Contains(v, "-") {
+			continue // pre-release
+		}
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("%s: no stable version in %s", dep.Name, url)
+	}
+	return latest, nil
+}
+
+func (r *RepoIndexResolver) index(ctx context.Context, url string) (map[string][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entries, ok := r.indexes[url]; ok {
+		return entries, nil
+	}
+
+	data, err := httpGet(ctx, r.Client, url)
+	if err != nil {
+		return nil, err
+	}
+	var idx struct {
+		Entries map[string][]struct {
+			Version string `json:"version"`
+		} `json:"entries"`
+	}
+	if err := yaml.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("invalid repository index %s: %w", url, err)
+	}
+
+	entries := make(map[string][]string, len(idx.Entries))
+	for name, versions := range idx.Entries {
+		for _, v := range versions {
+			entries[name] = append(entries[name], v.Version)
+		}
+	}
+	if r.indexes == nil {
+		r.indexes = make(map[string]map[string][]string)
+	}
+	r.indexes[url] = entries
+	return entries, nil
+}
+
+// ResolveDependencyVersions pins every dependency to the version returned by
+// resolver. Dependencies that cannot be resolved keep their version range;
+// their errors are joined into the returned error.
+func ResolveDependencyVersions(ctx context.Context, deps []helm.Dependency, resolver DependencyResolver) ([]helm.Dependency, error) {
+	resolved := make([]helm.Dependency, len(deps))
+	copy(resolved, deps)
+
+	var errs []error
+	for i := range resolved {
+		version, err := resolver.LatestVersion(ctx, resolved[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		resolved[i].Version = version
+	}
+	return resolved, errors.Join(errs...)
+}
+
+// lockDependency mirrors the dependency fields Helm hashes into the Chart.lock
+// digest.
+type lockDependency struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	Repository string   `json:"repository"`
+	Condition  string   `json:"condition,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Enabled    bool     `json:"enabled,omitempty"`
+	Alias      string   `json:"alias,omitempty"`
+}
+
+// GenerateChartLock returns Chart.lock content for dependencies pinned to
+// exact versions. The digest is computed the way "helm dependency update"
+// does, so "helm dependency build" accepts the lock as up to date.
+func GenerateChartLock(deps []helm.Dependency, generated time.Time) (string, error) {
+	req := make([]lockDependency, 0, len(deps))
+	lock := make([]lockDependency, 0, len(deps))
+	for _, d := range deps {
+		req = append(req, lockDependency{
+			Name: d.Name, Version: d.Version, Repository: d.Repository,
+			Condition: d.Condition, Tags: d.Tags, Enabled: d.Enabled, Alias: d.Alias,
+		})
+		lock = append(lock, lockDependency{Name: d.Name, Version: d.Version, Repository: d.Repository})
+	}
+
+	data, err := json.Marshal([2][]lockDependency{req, lock})
+	if err != nil {
+		return "", fmt.Errorf("chart lock: %w", err)
+	}
+	return formatLock(lock, sha256.Sum256(data), generated.UTC().Format(time.RFC3339Nano)), nil
+}
+
+// formatLock returns the content of a lock file pinning the lock
+// dependencies, with the digest sum of the dependencies it was computed for.
+func formatLock(lock []lockDependency, sum [sha256.Size]byte, generated string) string {
+	var sb strings.Builder
+	sb.WriteString("dependencies:\n")
+	for _, d := range lock {
+		sb.WriteString(fmt.Sprintf("- name: %s\n", d.Name))
+		sb.WriteString(fmt.Sprintf("  repository: %s\n", d.Repository))
+		sb.WriteString(fmt.Sprintf("  version: %s\n", d.Version))
+	}
+	sb.WriteString(fmt.Sprintf("digest: sha256:%s\n", hex.EncodeToString(sum[:])))
+	sb.WriteString(fmt.Sprintf("generated: %q\n", generated))
+	return sb.String()
+}
+
+// InjectChartLock adds a Chart.lock for deps to the chart's external files.
+// Returns nil if chart is nil.
+func InjectChartLock(chart *types.GeneratedChart, deps []helm.Dependency, generated time.Time) (*types.GeneratedChart, error) {
+	if chart == nil {
+		return nil, nil
+	}
+	if len(deps) == 0 {
+		return chart, nil
+	}
+
+	lock, err := GenerateChartLock(deps, generated)
+	if err != nil {
+		return nil, err
+	}
+
+	result := *chart
+	result.ExternalFiles = make([]types.ExternalFileInfo, 0, len(chart.ExternalFiles)+1)
+	for _, f := range chart.ExternalFiles {
+		if f.Path != ChartLockFile {
+			result.ExternalFiles = append(result.ExternalFiles, f)
+		}
+	}
+	result.ExternalFiles = append(result.ExternalFiles, types.ExternalFileInfo{Path: ChartLockFile, Content: lock})
+	return &result, nil
+}
+
+// compareVersions compares dotted numeric versions; missing or non-numeric
+// components compare as zero.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
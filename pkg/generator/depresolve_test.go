@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+)
+
+const testRepoIndex = `apiVersion: v1
+entries:
+  postgresql:
+  - version: 15.5.38
+  - version: 16.0.0-rc.1
+  - version: 15.10.2
+  - version: 12.12.10
+  redis:
+  - version: 19.6.4
+`
+
+func TestRepoIndexResolver_LatestStable(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testRepoIndex))
+	}))
+	defer srv.Close()
+
+	resolver := &RepoIndexResolver{}
+	deps := []helm.Dependency{
+		{Name: "postgresql", Version: "12.x.x", Repository: srv.URL, Condition: "postgresql.enabled"},
+		{Name: "redis", Version: "18.x.x", Repository: srv.URL + "/", Condition: "redis.enabled"},
+	}
+
+	resolved, err := ResolveDependencyVersions(context.Background(), deps, resolver)
+	if err != nil {
+		t.Fatalf("ResolveDependencyVersions: %v", err)
+	}
+	if resolved[0].Version != "15.10.2" || resolved[1].Version != "19.6.4" {
+		t.Errorf("unexpected versions: %+v", resolved)
+	}
+	if deps[0].Version != "12.x.x" {
+		t.Error("input dependencies must not be modified")
+	}
+	if requests != 1 {
+		t.Errorf("index should be fetched once, got %d requests", requests)
+	}
+}
+
+func TestRepoIndexResolver_UnknownChart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testRepoIndex))
+	}))
+	defer srv.Close()
+
+	deps := []helm.Dependency{
+		{Name: "kafka", Version: "26.x.x", Repository: bitnamiRepo},
+		{Name: "redis", Version: "18.x.x", Repository: bitnamiRepo},
+	}
+	resolved, err := ResolveDependencyVersions(context.Background(), deps, &RepoIndexResolver{IndexURL: srv.URL + "/index.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "kafka") {
+		t.Errorf("expected an error for kafka, got %v", err)
+	}
+	if resolved[0].Version != "26.x.x" || resolved[1].Version != "19.6.4" {
+		t.Errorf("unresolved dependencies keep their range: %+v", resolved)
+	}
+}
+
+func TestArtifactHubResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/packages/helm/bitnami/postgresql" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"postgresql","version":"16.2.1"}`))
+	}))
+	defer srv.Close()
+
+	resolver := &ArtifactHubResolver{BaseURL: srv.URL}
+	version, err := resolver.LatestVersion(context.Background(), helm.Dependency{Name: "postgresql", Repository: bitnamiRepo})
+	if err != nil || version != "16.2.1" {
+		t.Errorf("LatestVersion = %q, %v", version, err)
+	}
+
+	if _, err := resolver.LatestVersion(context.Background(), helm.Dependency{Name: "redis", Repository: bitnamiRepo}); err == nil {
+		t.Error("expected an error for a missing package")
+	}
+	if _, err := resolver.LatestVersion(context.Background(), helm.Dependency{Name: "app", Repository: "https://example.com/charts"}); err == nil {
+		t.Error("expected an error for a repository unknown to ArtifactHub")
+	}
+}
+
+func TestGenerateChartLock(t *testing.T) {
+	deps := []helm.Dependency{
+		{Name: "postgresql", Version: "15.10.2", Repository: bitnamiRepo, Condition: "postgresql.enabled"},
+	}
+	generated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	lock, err := GenerateChartLock(deps, generated)
+	if err != nil {
+		t.Fatalf("GenerateChartLock: %v", err)
+	}
+	for _, want := range []string{
+		"- name: postgresql\n",
+		"  repository: " + bitnamiRepo + "\n",
+		"  version: 15.10.2\n",
+		"digest: sha256:",
+		"generated: \"2024-05-01T12:00:00Z\"\n",
+	} {
+		if !strings.Contains(lock, want) {
+			t.Errorf("Chart.lock should contain %q:\n%s", want, lock)
+		}
+	}
+
+	again, _ := GenerateChartLock(deps, generated)
+	if again != lock {
+		t.Error("Chart.lock must be deterministic")
+	}
+	deps[0].Version = "15.10.3"
+	changed, _ := GenerateChartLock(deps, generated)
+	if digestLine(changed) == digestLine(lock) {
+		t.Error("digest should change with the pinned versions")
+	}
+}
+
+func digestLine(lock string) string {
+	for _, line := range strings.Split(lock, "\n") {
+		if strings.HasPrefix(line, "digest:") {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestInjectChartLock(t *testing.T) {
+	chart := makeChart("myapp", nil)
+	deps := []helm.Dependency{{Name: "redis", Version: "19.6.4", Repository: bitnamiRepo}}
+
+	result, err := InjectChartLock(chart, deps, time.Now())
+	if err != nil {
+		t.Fatalf("InjectChartLock: %v", err)
+	}
+	if len(chart.ExternalFiles) != 0 {
+		t.Error("input chart must not be modified")
+	}
+	if len(result.ExternalFiles) != 1 || result.ExternalFiles[0].Path != ChartLockFile {
+		t.Fatalf("expected a Chart.lock external file, got %+v", result.ExternalFiles)
+	}
+
+	// Injecting again replaces the lock.
+	result, _ = InjectChartLock(result, deps, time.Now())
+	if len(result.ExternalFiles) != 1 {
+		t.Errorf("Chart.lock should not be duplicated: %+v", result.ExternalFiles)
+	}
+
+	if got, _ := InjectChartLock(nil, deps, time.Now()); got != nil {
+		t.Error("nil chart should yield nil")
+	}
+}
@@ -45,7 +45,7 @@ func GenerateEgressPolicies(graph *types.ResourceGraph, opts EgressOptions) *Egr
 
 	// Step 1: detect from env vars
 	if opts.DetectFromEnv && graph != nil {
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			if r == nil || r.Original == nil {
 				continue
 			}
@@ -56,7 +56,8 @@ func GenerateEgressPolicies(graph *types.ResourceGraph, opts EgressOptions) *Egr
 			deploymentNames = append(deploymentNames, name)
 
 			envVars := extractEgressEnvVars(r)
-			for envName, envVal := range envVars {
+			for _, envName := range sortedKeys(envVars) {
+				envVal := envVars[envName]
 				if isURLEnvVar(envName) {
 					host := extractHostFromURL(envVal)
 					if host != "" && !seenHosts[host] {
@@ -68,7 +69,7 @@ func GenerateEgressPolicies(graph *types.ResourceGraph, opts EgressOptions) *Egr
 		}
 	} else if graph != nil {
 		// Collect deployment names even when DetectFromEnv=false
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			if r == nil || r.Original == nil {
 				continue
 			}
@@ -55,7 +55,7 @@ func DetectESOSecrets(graph *types.ResourceGraph) []ESOSecretRef {
 	if graph == nil {
 		return result
 	}
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind != "Secret" {
 			continue
 		}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 
@@ -31,6 +32,104 @@ type FeatureFlagConfig struct {
 	// KindToCategory maps a Kubernetes resource kind (e.g. "ServiceMonitor") to
 	// the feature category that gates it.
 	KindToCategory map[string]FeatureCategory
+
+	// Custom lists user-defined flags.  They are matched before the
+	// categories; the first flag matching a template gates it.
+	Custom []CustomFeatureFlag
+
+	// ResourceNames maps template paths to the names of their resources,
+	// matched by the Resources of custom flags when a template names its
+	// resource through a helper.
+	ResourceNames map[string]string
+}
+
+// CustomFeatureFlag is a user-defined feature flag that gates resources by
+// kind or by name with its own values key, e.g. canary.enabled.
+type CustomFeatureFlag struct {
+	// Name identifies the flag.
+	Name string `json:"name"`
+
+	// Default is the value of the flag written to values.yaml; true when
+	// unset.
+	Default *bool `json:"default,omitempty"`
+
+	// Kinds lists the kinds, matched case-insensitively, that the flag
+	// gates.  Workload kinds are gated too when listed.
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Resources lists the resources that the flag gates as name or
+	// Kind/name; names may contain shell wildcards (migrate-*).
+	Resources []string `json:"resources,omitempty"`
+
+	// ValuesPath is the dotted values key of the flag; features.<name> when
+	// unset.
+	ValuesPath string `json:"valuesPath,omitempty"`
+}
+
+// valuesKeyRegex matches a values key usable in a .Values field chain.
+var valuesKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks that the flag has a name, a values path usable in a
+// template and at least one kind or resource to gate.
+func (f CustomFeatureFlag) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("feature flag without a name")
+	}
+	if f.ValuesPath == "" && !valuesKeyRegex.MatchString(f.Name) {
+		return fmt.Errorf("feature flag %s: the name is not a valid values key; set valuesPath", f.Name)
+	}
+	for _, key := range strings.Split(f.valuesPath(), ".") {
+		if !valuesKeyRegex.MatchString(key) {
+			return fmt.Errorf("feature flag %s: invalid valuesPath %q", f.Name, f.ValuesPath)
+		}
+	}
+	if len(f.Kinds) == 0 && len(f.Resources) == 0 {
+		return fmt.Errorf("feature flag %s: no kinds or resources to gate", f.Name)
+	}
+	for _, r := range f.Resources {
+		if _, err := path.Match(r[strings.Index(r, "/")+1:], ""); err != nil || strings.HasSuffix(r, "/") {
+			return fmt.Errorf("feature flag %s: invalid resource %q", f.Name, r)
+		}
+	}
+	return nil
+}
+
+// valuesPath returns the values key of the flag.
+func (f CustomFeatureFlag) valuesPath() string {
+	if f.ValuesPath != "" {
+		return f.ValuesPath
+	}
+	return "features." + f.Name
+}
+
+// defaultValue returns the value of the flag written to values.yaml.
+func (f CustomFeatureFlag) defaultValue() bool {
+	return f.Default == nil || *f.Default
+}
+
+// matches reports whether the flag gates the resource of kind and name.
+func (f CustomFeatureFlag) matches(kind, name string) bool {
+	for _, k := range f.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	if name == "" {
+		return false
+	}
+	for _, r := range f.Resources {
+		pattern := r
+		if i := strings.Index(r, "/"); i >= 0 {
+			if !strings.EqualFold(r[:i], kind) {
+				continue
+			}
+			pattern = r[i+1:]
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // neverWrapKinds lists workload and infrastructure kinds that must NOT be wrapped
@@ -117,10 +216,21 @@ func InjectFeatureFlags(chart *types.GeneratedChart, config *FeatureFlagConfig)
 	result.Templates = make(map[string]string, len(chart.Templates))
 
 	usedCategories := make(map[FeatureCategory]bool)
+	usedCustom := make(map[int]bool)
 
 	for path, content := range chart.Templates {
 		kind := extractKind(content)
 
+		name, ok := config.ResourceNames[path]
+		if !ok {
+			name = extractMetadataName(content)
+		}
+		if i := matchCustomFeatureFlag(config.Custom, kind, name); i >= 0 {
+			result.Templates[path] = wrapTemplateWithGuard(content, config.Custom[i].valuesPath())
+			usedCustom[i] = true
+			continue
+		}
+
 		// Determine whether this kind should be wrapped.
 		// Rule: skip if in neverWrapKinds AND there is no explicit override in
 		// KindToCategory (an explicit mapping always wins).
@@ -152,10 +262,33 @@ func InjectFeatureFlags(chart *types.GeneratedChart, config *FeatureFlagConfig)
 	if len(usedCategories) > 0 {
 		result.ValuesYAML = mergeFeatureValues(result.ValuesYAML, config, usedCategories)
 	}
+	if len(usedCustom) > 0 {
+		used := make([]CustomFeatureFlag, 0, len(usedCustom))
+		for i, flag := range config.Custom {
+			if usedCustom[i] {
+				used = append(used, flag)
+			}
+		}
+		result.ValuesYAML = mergeCustomFeatureValues(result.ValuesYAML, used)
+	}
 
 	return &result
 }
 
+// matchCustomFeatureFlag returns the index of the first flag gating the
+// resource of kind and name, or -1.
+func matchCustomFeatureFlag(flags []CustomFeatureFlag, kind, name string) int {
+	if kind == "" {
+		return -1
+	}
+	for i, flag := range flags {
+		if flag.matches(kind, name) {
+			return i
+		}
+	}
+	return -1
+}
+
 // wrapTemplateWithFeatureFlag surrounds templateContent with a Helm feature-flag
 // conditional guard for the given category.  If the content is already wrapped
 // with a features guard, it is returned unchanged (idempotent).
@@ -163,7 +296,17 @@ func wrapTemplateWithFeatureFlag(templateContent string, category FeatureCategor
 	if strings.HasPrefix(strings.TrimSpace(templateContent), "{{- if .Values.features.") {
 		return templateContent
 	}
-	return fmt.Sprintf("{{- if .Values.features.%s }}\n%s\n{{- end }}", category, templateContent)
+	return wrapTemplateWithGuard(templateContent, "features."+string(category))
+}
+
+// wrapTemplateWithGuard surrounds templateContent with a `{{- if .Values.<valuesPath> }}`
+// guard, unless it already starts with that guard.
+func wrapTemplateWithGuard(templateContent, valuesPath string) string {
+	guard := fmt.Sprintf("{{- if .Values.%s }}", valuesPath)
+	if strings.HasPrefix(strings.TrimSpace(templateContent), guard) {
+		return templateContent
+	}
+	return fmt.Sprintf("%s\n%s\n{{- end }}", guard, templateContent)
 }
 
 // generateFeatureValues returns a flat map[string]interface{} where every key is
@@ -210,6 +353,47 @@ func mergeFeatureValues(existingYAML string, config *FeatureFlagConfig, usedCate
 	return string(out)
 }
 
+// mergeCustomFeatureValues sets the values key of each flag in existingYAML to
+// its default, keeping a value already set there.
+func mergeCustomFeatureValues(existingYAML string, flags []CustomFeatureFlag) string {
+	base := make(map[string]interface{})
+	if strings.TrimSpace(existingYAML) != "" {
+		if err := yaml.Unmarshal([]byte(existingYAML), &base); err != nil {
+			return existingYAML
+		}
+	}
+
+	for _, flag := range flags {
+		keys := strings.Split(flag.valuesPath(), ".")
+		node := base
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := node[key].(map[string]interface{})
+			if !ok {
+				if _, set := node[key]; set {
+					// A scalar in the way: leave the values alone.
+					node = nil
+					break
+				}
+				child = make(map[string]interface{})
+				node[key] = child
+			}
+			node = child
+		}
+		if node == nil {
+			continue
+		}
+		if _, set := node[keys[len(keys)-1]]; !set {
+			node[keys[len(keys)-1]] = flag.defaultValue()
+		}
+	}
+
+	out, err := yaml.Marshal(base)
+	if err != nil {
+		return existingYAML
+	}
+	return string(out)
+}
+
 // hasExistingGuard returns true when the template content already starts with a
 // Helm feature-flag conditional (e.g. `{{- if .Values.namespace...}}`).  This
 // prevents double-wrapping resources that were pre-guarded by earlier pipeline
@@ -227,3 +411,33 @@ func extractKind(yamlContent string) string {
 	}
 	return strings.TrimSpace(matches[1])
 }
+
+// extractMetadataName returns the literal metadata.name of yamlContent, or an
+// empty string if it is absent or templated.
+func extractMetadataName(yamlContent string) string {
+	inMetadata := false
+	for _, line := range strings.Split(yamlContent, "\n") {
+		if strings.HasPrefix(line, "metadata:") {
+			inMetadata = true
+			continue
+		}
+		if !inMetadata {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "{{") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			return ""
+		}
+		if name, ok := strings.CutPrefix(line, "  name:"); ok {
+			name = strings.Trim(strings.TrimSpace(name), `"'`)
+			if strings.Contains(name, "{{") {
+				return ""
+			}
+			return name
+		}
+	}
+	return ""
+}
@@ -576,3 +576,75 @@ func TestFeatureFlags_WrapIdempotent(t *testing.T) {
 		t.Errorf("expected exactly 1 feature guard, got %d", guardCount)
 	}
 }
+
+// ============================================================
+// Test 18: custom flags gate kinds and named resources
+// ============================================================
+
+func TestFeatureFlags_CustomFlags(t *testing.T) {
+	disabled := false
+	chart := makeChart("myapp", map[string]string{
+		"templates/migrate-job.yaml":    "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: {{ include \"myapp.fullname\" $ }}-migrate\n",
+		"templates/canary-deploy.yaml":  "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web-canary\n",
+		"templates/web-deploy.yaml":     "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+		"templates/canary-monitor.yaml": "apiVersion: monitoring.coreos.com/v1\nkind: ServiceMonitor\nmetadata:\n  name: web-canary\n",
+	})
+	chart.ValuesYAML = "replicaCount: 1\ncanary:\n  weight: 10\n"
+	config := DefaultFeatureFlagConfig()
+	config.Custom = []CustomFeatureFlag{
+		{Name: "migrations", Default: &disabled, Kinds: []string{"job"}},
+		{Name: "canary", ValuesPath: "canary.enabled", Resources: []string{"*-canary"}},
+	}
+	config.ResourceNames = map[string]string{"templates/migrate-job.yaml": "db-migrate"}
+
+	result := InjectFeatureFlags(chart, config)
+
+	for path, guard := range map[string]string{
+		"templates/migrate-job.yaml":    "{{- if .Values.features.migrations }}",
+		"templates/canary-deploy.yaml":  "{{- if .Values.canary.enabled }}",
+		"templates/canary-monitor.yaml": "{{- if .Values.canary.enabled }}",
+	} {
+		if !strings.HasPrefix(result.Templates[path], guard+"\n") {
+			t.Errorf("expected %s to be guarded by %q, got:\n%s", path, guard, result.Templates[path])
+		}
+	}
+	if strings.Contains(result.Templates["templates/web-deploy.yaml"], "{{- if") {
+		t.Errorf("web Deployment should not be gated:\n%s", result.Templates["templates/web-deploy.yaml"])
+	}
+	for _, want := range []string{"canary:\n  enabled: true\n  weight: 10\n", "features:\n  migrations: false\n"} {
+		if !strings.Contains(result.ValuesYAML, want) {
+			t.Errorf("expected %q in values:\n%s", want, result.ValuesYAML)
+		}
+	}
+	if strings.Contains(result.ValuesYAML, "monitoring:") {
+		t.Errorf("the ServiceMonitor gated by canary should not enable the monitoring category:\n%s", result.ValuesYAML)
+	}
+
+	// A flag matching by Kind/name only gates that kind; a value already
+	// set is kept.
+	config.Custom = []CustomFeatureFlag{{Name: "canary", ValuesPath: "canary.weight", Resources: []string{"ServiceMonitor/web-canary"}}}
+	result = InjectFeatureFlags(chart, config)
+	if strings.Contains(result.Templates["templates/canary-deploy.yaml"], "{{- if") {
+		t.Errorf("Deployment/web-canary should not match ServiceMonitor/web-canary:\n%s", result.Templates["templates/canary-deploy.yaml"])
+	}
+	if !strings.Contains(result.ValuesYAML, "weight: 10") {
+		t.Errorf("expected the existing value to be kept:\n%s", result.ValuesYAML)
+	}
+}
+
+func TestCustomFeatureFlag_Validate(t *testing.T) {
+	if err := (CustomFeatureFlag{Name: "canary", ValuesPath: "canary.enabled", Resources: []string{"Deployment/*-canary"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for flag, want := range map[*CustomFeatureFlag]string{
+		{Kinds: []string{"Job"}}:                                                "without a name",
+		{Name: "db-migrations", Kinds: []string{"Job"}}:                         "set valuesPath",
+		{Name: "canary", ValuesPath: "canary..enabled", Kinds: []string{"Job"}}: "invalid valuesPath",
+		{Name: "canary"}: "no kinds or resources",
+		{Name: "canary", Resources: []string{"Deployment/[web"}}: "invalid resource",
+	} {
+		if err := flag.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%+v: expected an error containing %q, got %v", *flag, want, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// fileBatch writes a set of files atomically: each file is written to a
+// temporary file next to its target and renamed into place on commit, so a
+// failed or interrupted write never leaves a half-written chart file. Files
+// and their directories are synced once, at commit, instead of after every
+// write.
+type fileBatch struct {
+	pending []pendingFile
+}
+
+// pendingFile is a file written to tmp, to be renamed to path on commit.
+type pendingFile struct {
+	tmp  string
+	path string
+}
+
+// write writes data to a temporary file for path, creating its directory.
+func (b *fileBatch) write(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	b.pending = append(b.pending, pendingFile{tmp: f.Name(), path: path})
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// commit syncs the written files, renames them into place and syncs their
+// directories. On error the files not yet renamed are removed.
+func (b *fileBatch) commit() error {
+	defer b.abort()
+	for _, p := range b.pending {
+		if err := syncPath(p.tmp, os.O_WRONLY); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", p.path, err)
+		}
+	}
+	dirs := make(map[string]bool)
+	for len(b.pending) > 0 {
+		p := b.pending[0]
+		if err := os.Rename(p.tmp, p.path); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p.path, err)
+		}
+		b.pending = b.pending[1:]
+		dirs[filepath.Dir(p.path)] = true
+	}
+	// Directories cannot be opened for syncing on Windows.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	for dir := range dirs {
+		if err := syncPath(dir, os.O_RDONLY); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// abort removes the files written since the last commit.
+func (b *fileBatch) abort() {
+	for _, p := range b.pending {
+		os.Remove(p.tmp)
+	}
+	b.pending = nil
+}
+
+// syncPath flushes a file or directory to disk.
+func syncPath(path string, flag int) error {
+	f, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestFileBatch_Commit(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "templates", "web.yaml")
+	writeTree(t, dir, map[string]string{"templates/web.yaml": "old\n"})
+
+	var batch fileBatch
+	if err := batch.write(target, []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.write(filepath.Join(dir, "nested", "a", "b.yaml"), []byte("b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, target); got != "old\n" {
+		t.Errorf("file replaced before commit: %q", got)
+	}
+	if err := batch.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, target); got != "new\n" {
+		t.Errorf("content = %q after commit", got)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v; want 0644", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestFileBatch_Abort(t *testing.T) {
+	dir := t.TempDir()
+	var batch fileBatch
+	if err := batch.write(filepath.Join(dir, "Chart.yaml"), []byte("name: app\n")); err != nil {
+		t.Fatal(err)
+	}
+	batch.abort()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("abort should remove the written files: %v", entries)
+	}
+}
+
+func TestWriteChart_FailedWriteLeavesChartUntouched(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"app/values.yaml": "replicas: 1\n"})
+	chart := &types.GeneratedChart{
+		Name:          "app",
+		ChartYAML:     "apiVersion: v2\n",
+		ValuesYAML:    "replicas: 3\n",
+		Templates:     map[string]string{"templates/x.yaml": "# x"},
+		ExternalFiles: []types.ExternalFileInfo{{Path: "../outside.yaml", Content: "x"}},
+	}
+	if err := WriteChart(chart, dir); err == nil {
+		t.Fatal("expected an error for a file outside the chart")
+	}
+	if got := readFile(t, filepath.Join(dir, "app", "values.yaml")); got != "replicas: 1\n" {
+		t.Errorf("values.yaml = %q; a failed write should not replace any file", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app", "Chart.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Chart.yaml should not be written: %v", err)
+	}
+}
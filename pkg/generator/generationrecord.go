@@ -0,0 +1,225 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// GenerationRecordFile records how a chart was generated, relative to the
+// chart directory. dhg regenerate replays the generation from it.
+const GenerationRecordFile = ".dhg/generation.yaml"
+
+// SourceSnapshotFile holds the resources a chart was generated from when they
+// cannot be read again (a live cluster or stdin), relative to the chart
+// directory.
+const SourceSnapshotFile = ".dhg/source.yaml"
+
+// GenerationRecord describes a generation of a chart well enough to replay it.
+type GenerationRecord struct {
+	// DhgVersion is the version of dhg that generated the chart.
+	DhgVersion string `json:"dhgVersion"`
+
+	// GeneratedAt is the SOURCE_DATE_EPOCH of the generation, when set. The
+	// wall clock is not recorded so that identical generations are
+	// byte-identical.
+	GeneratedAt *time.Time `json:"generatedAt,omitempty"`
+
+	// Source is the --source the resources were extracted from.
+	Source string `json:"source"`
+
+	// Options are the generate flags keyed by name: a string, or a list of
+	// strings for repeatable flags. Paths are absolute.
+	Options map[string]interface{} `json:"options"`
+
+	// Sources are the input files with their content hashes.
+	Sources []SourceFile `json:"sources,omitempty"`
+
+	// Snapshot is set when the resources were recorded in SourceSnapshotFile.
+	Snapshot *SourceSnapshot `json:"snapshot,omitempty"`
+
+	// Resources is the inventory of the resources the chart was generated from.
+	Resources []InventoryResource `json:"resources"`
+}
+
+// SourceFile is an input file of a generation.
+type SourceFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// SourceSnapshot is the recorded copy of the extracted resources.
+type SourceSnapshot struct {
+	// File is SourceSnapshotFile.
+	File string `json:"file"`
+
+	// SHA256 is the digest of the snapshot content.
+	SHA256 string `json:"sha256"`
+}
+
+// InventoryResource is a resource a chart was generated from.
+type InventoryResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+
+	// Service is the service the resource was grouped into.
+	Service string `json:"service,omitempty"`
+}
+
+// ResourceInventory lists processed resources, sorted by kind, namespace and
+// name.
+func ResourceInventory(resources []*types.ProcessedResource) []InventoryResource {
+	inventory := make([]InventoryResource, 0, len(resources))
+	for _, r := range resources {
+		key := r.Original.ResourceKey()
+		inventory = append(inventory, InventoryResource{
+			APIVersion: key.GVK.GroupVersion().String(),
+			Kind:       key.GVK.Kind,
+			Namespace:  key.Namespace,
+			Name:       key.Name,
+			Service:    r.ServiceName,
+		})
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		a, b := inventory[i], inventory[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return inventory
+}
+
+// HashSourceFiles returns the sha256 of every file under the given paths
+// (files or directories, .git excluded), sorted by absolute path.
+func HashSourceFiles(paths []string) ([]SourceFile, error) {
+	seen := make(map[string]bool)
+	var sources []SourceFile
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("source hashes: %w", err)
+		}
+		err = filepath.WalkDir(abs, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !d.Type().IsRegular() || seen[file] {
+				return nil
+			}
+			seen[file] = true
+			digest, err := fileDigest(file)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, SourceFile{Path: filepath.ToSlash(file), SHA256: digest})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("source hashes: %w", err)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Path < sources[j].Path })
+	return sources, nil
+}
+
+// ChangedSources compares recorded source files with their current state and
+// returns the paths that were modified, deleted or added, sorted.
+func ChangedSources(recorded, current []SourceFile) []string {
+	hashes := make(map[string]string, len(recorded))
+	for _, s := range recorded {
+		hashes[s.Path] = s.SHA256
+	}
+	var changed []string
+	for _, s := range current {
+		if hash, ok := hashes[s.Path]; !ok || hash != s.SHA256 {
+			changed = append(changed, s.Path)
+		}
+		delete(hashes, s.Path)
+	}
+	for p := range hashes {
+		changed = append(changed, p)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// MarshalSourceSnapshot serializes extracted resources as a multi-document
+// YAML stream, in extraction order. Call it before the resources are
+// processed, as processing modifies them.
+func MarshalSourceSnapshot(resources []*types.ExtractedResource) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, r := range resources {
+		data, err := yaml.Marshal(r.Object.Object)
+		if err != nil {
+			return nil, fmt.Errorf("source snapshot: %s: %w", r.ResourceKey(), err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteGenerationRecord writes the generation record of a chart, along with
+// the source snapshot when there is one.
+func WriteGenerationRecord(chartDir string, record *GenerationRecord, snapshot []byte) error {
+	if snapshot != nil {
+		sum := sha256.Sum256(snapshot)
+		record.Snapshot = &SourceSnapshot{File: SourceSnapshotFile, SHA256: hex.EncodeToString(sum[:])}
+		if err := writeFile(filepath.Join(chartDir, SourceSnapshotFile), string(snapshot)); err != nil {
+			return err
+		}
+	}
+	data, err := yaml.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("generation record: %w", err)
+	}
+	return writeFile(filepath.Join(chartDir, GenerationRecordFile), string(data))
+}
+
+// ReadGenerationRecord reads the generation record of a chart.
+func ReadGenerationRecord(chartDir string) (*GenerationRecord, error) {
+	data, err := os.ReadFile(filepath.Join(chartDir, GenerationRecordFile))
+	if err != nil {
+		return nil, fmt.Errorf("generation record: %w", err)
+	}
+	var record GenerationRecord
+	if err := yaml.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("generation record: %w", err)
+	}
+	return &record, nil
+}
+
+// VerifySnapshot checks that the source snapshot of a chart still has the
+// recorded digest.
+func (r *GenerationRecord) VerifySnapshot(chartDir string) error {
+	if r.Snapshot == nil {
+		return nil
+	}
+	digest, err := fileDigest(filepath.Join(chartDir, filepath.FromSlash(r.Snapshot.File)))
+	if err != nil {
+		return fmt.Errorf("source snapshot: %w", err)
+	}
+	if digest != r.Snapshot.SHA256 {
+		return fmt.Errorf("source snapshot %s was modified since generation", r.Snapshot.File)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func recordResource(apiVersion, kind, namespace, name string) *types.ExtractedResource {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+	}}
+	return &types.ExtractedResource{Object: obj, GVK: schema.FromAPIVersionAndKind(apiVersion, kind)}
+}
+
+func TestResourceInventory(t *testing.T) {
+	resources := []*types.ProcessedResource{
+		{Original: recordResource("v1", "Service", "prod", "web"), ServiceName: "web"},
+		{Original: recordResource("apps/v1", "Deployment", "prod", "web"), ServiceName: "web"},
+	}
+	inventory := ResourceInventory(resources)
+	want := []InventoryResource{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "web", Service: "web"},
+		{APIVersion: "v1", Kind: "Service", Namespace: "prod", Name: "web", Service: "web"},
+	}
+	if len(inventory) != len(want) {
+		t.Fatalf("inventory = %+v; want %+v", inventory, want)
+	}
+	for i := range want {
+		if inventory[i] != want[i] {
+			t.Errorf("inventory[%d] = %+v; want %+v", i, inventory[i], want[i])
+		}
+	}
+}
+
+func TestHashSourceFiles_ChangedSources(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"deploy.yaml": "kind: Deployment\n",
+		"svc.yaml":    "kind: Service\n",
+		".git/HEAD":   "ref: refs/heads/main\n",
+	})
+	recorded, err := HashSourceFiles([]string{dir, filepath.Join(dir, "svc.yaml")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("recorded = %+v; want deploy.yaml and svc.yaml once each, without .git", recorded)
+	}
+
+	writeTree(t, dir, map[string]string{"svc.yaml": "kind: Service\nspec: {}\n", "ingress.yaml": "kind: Ingress\n"})
+	if err := os.Remove(filepath.Join(dir, "deploy.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	current, err := HashSourceFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range ChangedSources(recorded, current) {
+		names = append(names, filepath.Base(p))
+	}
+	if got := strings.Join(names, ","); got != "deploy.yaml,ingress.yaml,svc.yaml" {
+		t.Errorf("changed = %s; want the deleted, added and modified files", got)
+	}
+}
+
+func TestGenerationRecord_RoundTrip(t *testing.T) {
+	chartDir := t.TempDir()
+	snapshot, err := MarshalSourceSnapshot([]*types.ExtractedResource{
+		recordResource("apps/v1", "Deployment", "prod", "web"),
+		recordResource("v1", "Service", "prod", "web"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(snapshot), "---\n") != 2 || !strings.Contains(string(snapshot), "kind: Service") {
+		t.Errorf("unexpected snapshot:\n%s", snapshot)
+	}
+
+	generatedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	record := &GenerationRecord{
+		DhgVersion:  "1.4.0",
+		GeneratedAt: &generatedAt,
+		Source:      "cluster",
+		Options:     map[string]interface{}{"chart-name": "app", "include-kinds": []string{"Deployment", "Service"}},
+		Resources:   []InventoryResource{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+	}
+	if err := WriteGenerationRecord(chartDir, record, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadGenerationRecord(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DhgVersion != "1.4.0" || got.Source != "cluster" || got.GeneratedAt == nil || !got.GeneratedAt.Equal(generatedAt) {
+		t.Errorf("record = %+v", got)
+	}
+	if kinds, ok := got.Options["include-kinds"].([]interface{}); !ok || len(kinds) != 2 {
+		t.Errorf("include-kinds = %#v; want a list", got.Options["include-kinds"])
+	}
+	if got.Snapshot == nil || got.Snapshot.File != SourceSnapshotFile {
+		t.Fatalf("Snapshot = %+v", got.Snapshot)
+	}
+	if err := got.VerifySnapshot(chartDir); err != nil {
+		t.Errorf("VerifySnapshot: %v", err)
+	}
+
+	writeTree(t, chartDir, map[string]string{SourceSnapshotFile: "---\nkind: Secret\n"})
+	if err := got.VerifySnapshot(chartDir); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("expected a modified snapshot error, got %v", err)
+	}
+}
+
+func TestReadGenerationRecord_Missing(t *testing.T) {
+	if _, err := ReadGenerationRecord(t.TempDir()); err == nil {
+		t.Error("expected an error for a chart without a generation record")
+	}
+}
@@ -61,9 +61,43 @@ type Options struct {
 	// for easier --set reference (e.g., "# image.repository").
 	ValuesFlat bool
 
+	// ValuesDocs adds helm-docs "# --" comments to values.yaml naming the
+	// source resource and manifest field of each value and, with
+	// IncludeREADME, writes README.md with a values table.
+	ValuesDocs bool
+
+	// PreserveNamespaces keeps resources in their source namespaces,
+	// rendered from .Values.namespaces, and generates the Namespace
+	// objects (universal mode only).
+	PreserveNamespaces bool
+
+	// DedupValues factors resources, probes and securityContext blocks
+	// repeated across workloads into a common: values section that
+	// per-service values are merged over (universal mode only).
+	DedupValues bool
+
+	// TemplateEnv rewrites Service DNS names in container env vars and
+	// ConfigMap data to services.<name>.env values that default to the
+	// Service's name in the release (universal mode only).
+	TemplateEnv bool
+
+	// ValueOverrides is deep-merged over the generated values, so that the
+	// chart defaults can differ from the source manifests (universal mode
+	// only).
+	ValueOverrides map[string]interface{}
+
+	// Injection adds organization-wide labels and annotations to the
+	// generated resources (universal mode only).
+	Injection *InjectionPolicy
+
 	// IncludeHooks generates Helm lifecycle hook Job templates
 	// (pre-upgrade, post-install, pre-delete).
 	IncludeHooks bool
+
+	// ChartMetadata holds user-supplied Chart.yaml fields (maintainers, home,
+	// sources, keywords, icon, kubeVersion, annotations) applied to every
+	// generated chart; see helm.MergeChartMetadata.
+	ChartMetadata helm.ChartMetadata
 }
 
 // Generator generates Helm charts from a resource graph.
@@ -92,8 +126,19 @@ func (g BaseGenerator) Mode() types.OutputMode {
 	return g.mode
 }
 
-// WriteChart writes a generated chart to disk.
+// WriteChart writes a generated chart to disk. Files are replaced atomically
+// and synced once all of them are written.
 func WriteChart(chart *types.GeneratedChart, outputDir string) error {
+	var batch fileBatch
+	if err := writeChart(&batch, chart, outputDir); err != nil {
+		batch.abort()
+		return err
+	}
+	return batch.commit()
+}
+
+// writeChart writes the files of a chart to batch.
+func writeChart(batch *fileBatch, chart *types.GeneratedChart, outputDir string) error {
 	chartDir := filepath.Join(outputDir, chart.Name)
 
 	// Create chart directory structure
@@ -109,12 +154,12 @@ func WriteChart(chart *types.GeneratedChart, outputDir string) error {
 	}
 
 	// Write Chart.yaml
-	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chart.ChartYAML), 0644); err != nil {
+	if err := batch.write(filepath.Join(chartDir, "Chart.yaml"), []byte(chart.ChartYAML)); err != nil {
 		return fmt.Errorf("failed to write Chart.yaml: %w", err)
 	}
 
 	// Write values.yaml
-	if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(chart.ValuesYAML), 0644); err != nil {
+	if err := batch.write(filepath.Join(chartDir, "values.yaml"), []byte(chart.ValuesYAML)); err != nil {
 		return fmt.Errorf("failed to write values.yaml: %w", err)
 	}
 
@@ -127,10 +172,7 @@ func WriteChart(chart *types.GeneratedChart, outputDir string) error {
 	for _, path := range templatePaths {
 		content := chart.Templates[path]
 		templatePath := filepath.Join(chartDir, path)
-		if err := os.MkdirAll(filepath.Dir(templatePath), 0755); err != nil {
-			return fmt.Errorf("failed to create template directory for %s: %w", path, err)
-		}
-		if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
+		if err := batch.write(templatePath, []byte(content)); err != nil {
 			return fmt.Errorf("failed to write template %s: %w", path, err)
 		}
 	}
@@ -138,7 +180,7 @@ func WriteChart(chart *types.GeneratedChart, outputDir string) error {
 	// Write _helpers.tpl
 	if chart.Helpers != "" {
 		helpersPath := filepath.Join(chartDir, "templates", "_helpers.tpl")
-		if err := os.WriteFile(helpersPath, []byte(chart.Helpers), 0644); err != nil {
+		if err := batch.write(helpersPath, []byte(chart.Helpers)); err != nil {
 			return fmt.Errorf("failed to write _helpers.tpl: %w", err)
 		}
 	}
@@ -146,7 +188,7 @@ func WriteChart(chart *types.GeneratedChart, outputDir string) error {
 	// Write NOTES.txt
 	if chart.Notes != "" {
 		notesPath := filepath.Join(chartDir, "templates", "NOTES.txt")
-		if err := os.WriteFile(notesPath, []byte(chart.Notes), 0644); err != nil {
+		if err := batch.write(notesPath, []byte(chart.Notes)); err != nil {
 			return fmt.Errorf("failed to write NOTES.txt: %w", err)
 		}
 	}
@@ -154,14 +196,14 @@ func WriteChart(chart *types.GeneratedChart, outputDir string) error {
 	// Write values.schema.json if present
 	if chart.ValuesSchema != "" {
 		schemaPath := filepath.Join(chartDir, "values.schema.json")
-		if err := os.WriteFile(schemaPath, []byte(chart.ValuesSchema), 0644); err != nil {
+		if err := batch.write(schemaPath, []byte(chart.ValuesSchema)); err != nil {
 			return fmt.Errorf("failed to write values.schema.json: %w", err)
 		}
 	}
 
 	// Write .helmignore
 	helmignorePath := filepath.Join(chartDir, ".helmignore")
-	if err := os.WriteFile(helmignorePath, []byte(helm.GenerateHelmIgnore()), 0644); err != nil {
+	if err := batch.write(helmignorePath, []byte(helm.GenerateHelmIgnore())); err != nil {
 		return fmt.Errorf("failed to write .helmignore: %w", err)
 	}
 
@@ -181,10 +223,7 @@ func WriteChart(chart *types.GeneratedChart, outputDir string) error {
 			if err != nil || strings.HasPrefix(rel, "..") {
 				return fmt.Errorf("invalid external file path %q: outside chart directory", file.Path)
 			}
-			if err := os.MkdirAll(filepath.Dir(absFilePath), 0755); err != nil {
-				return fmt.Errorf("failed to create directory for external file %s: %w", file.Path, err)
-			}
-			if err := os.WriteFile(absFilePath, []byte(file.Content), 0644); err != nil {
+			if err := batch.write(absFilePath, []byte(file.Content)); err != nil {
 				return fmt.Errorf("failed to write external file %s: %w", file.Path, err)
 			}
 		}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -472,6 +474,55 @@ func TestUniversalGenerator_Generate_Basic(t *testing.T) {
 	}
 }
 
+func TestUniversalGenerator_Generate_MonitoringEnabled(t *testing.T) {
+	deploy := makeProcessedResourceWithValues("Deployment", "myapp", "default", nil, nil, "# deployment template")
+	sm := makeProcessedResourceWithValues("ServiceMonitor", "myapp", "default", nil, nil, "# servicemonitor template")
+
+	gen := NewUniversalGenerator()
+	opts := Options{ChartName: "test-chart", ChartVersion: "1.0.0", AppVersion: "1.0.0"}
+
+	graph := buildGraph([]*types.ProcessedResource{deploy, sm}, nil)
+	graph.Groups = []*types.ResourceGroup{{Name: "myapp", Resources: []*types.ProcessedResource{deploy, sm}}}
+	charts, err := gen.Generate(context.Background(), graph, opts)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if !strings.Contains(charts[0].ValuesYAML, "monitoring:\n  enabled: true") {
+		t.Errorf("expected monitoring.enabled in values.yaml, got:\n%s", charts[0].ValuesYAML)
+	}
+
+	graph = buildGraph([]*types.ProcessedResource{deploy}, nil)
+	graph.Groups = []*types.ResourceGroup{{Name: "myapp", Resources: []*types.ProcessedResource{deploy}}}
+	charts, err = gen.Generate(context.Background(), graph, opts)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if strings.Contains(charts[0].ValuesYAML, "monitoring:") {
+		t.Errorf("expected no monitoring section without monitors, got:\n%s", charts[0].ValuesYAML)
+	}
+}
+
+func TestBuildServiceConfig_IstioAndGatewayAPIGateways(t *testing.T) {
+	apiGW := makeProcessedResourceWithValues("Gateway", "public", "default", nil,
+		map[string]interface{}{"gatewayClassName": "nginx"}, "")
+	istioGW := makeProcessedResourceWithValues("Gateway", "mesh-public", "default", nil,
+		map[string]interface{}{"servers": []interface{}{}}, "")
+	istioGW.Original.GVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1", Kind: "Gateway"}
+
+	gen := NewUniversalGenerator()
+	config := gen.buildServiceConfig(&types.ResourceGroup{
+		Name:      "web",
+		Resources: []*types.ProcessedResource{apiGW, istioGW},
+	})
+
+	if _, ok := config["gateway"]; !ok {
+		t.Errorf("expected Gateway API values under gateway, got keys %v", config)
+	}
+	if _, ok := config["istioGateway"]; !ok {
+		t.Errorf("expected Istio Gateway values under istioGateway, got keys %v", config)
+	}
+}
+
 func TestUniversalGenerator_Generate_WithSchema(t *testing.T) {
 	deploy := makeProcessedResourceWithValues("Deployment", "myapp", "default",
 		map[string]string{"app.kubernetes.io/name": "myapp"},
@@ -590,100 +641,6 @@ func TestBuildServiceConfig_Secret(t *testing.T) {
 	}
 }
 
-// ============================================================
-// kindToValuesKey Tests
-// ============================================================
-
-func TestKindToValuesKey(t *testing.T) {
-	tests := []struct {
-		kind     string
-		expected string
-	}{
-		{"Deployment", "deployment"},
-		{"StatefulSet", "statefulSet"},
-		{"DaemonSet", "daemonSet"},
-		{"Service", "service"},
-		{"ConfigMap", "configMap"},
-		{"PersistentVolumeClaim", "pvc"},
-		{"Ingress", "ingress"},
-		{"", "resource"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.kind, func(t *testing.T) {
-			result := kindToValuesKey(tt.kind)
-			if result != tt.expected {
-				t.Errorf("kindToValuesKey(%q) = %q, want %q", tt.kind, result, tt.expected)
-			}
-		})
-	}
-}
-
-// ============================================================
-// pluralizeKind Tests
-// ============================================================
-
-func TestPluralizeKind(t *testing.T) {
-	tests := []struct {
-		kind     string
-		expected string
-	}{
-		{"Ingress", "ingresses"},
-		{"Service", "services"},
-		{"ConfigMap", "configMaps"},
-		{"Secret", "secrets"},
-		{"ServiceAccount", "serviceAccounts"},
-		{"Deployment", "deployments"},
-		{"StatefulSet", "statefulSets"},
-		{"DaemonSet", "daemonSets"},
-		{"PersistentVolumeClaim", "persistentVolumeClaims"},
-		{"Role", "roles"},
-		{"RoleBinding", "roleBindings"},
-		{"ClusterRole", "clusterRoles"},
-		{"ClusterRoleBinding", "clusterRoleBindings"},
-		{"CustomResource", "CustomResources"}, // default: add 's'
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.kind, func(t *testing.T) {
-			result := pluralizeKind(tt.kind)
-			if result != tt.expected {
-				t.Errorf("pluralizeKind(%q) = %q, want %q", tt.kind, result, tt.expected)
-			}
-		})
-	}
-}
-
-// ============================================================
-// sanitizeName Tests
-// ============================================================
-
-func TestSanitizeName(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{"simple", "myapp", "myapp"},
-		{"hyphen", "my-app", "myApp"},
-		{"underscore", "my_app", "myApp"},
-		{"dot", "my.app", "myApp"},
-		{"uppercase-first", "MyApp", "myApp"},
-		{"mixed", "my-app_config.v2", "myAppConfigV2"},
-		{"empty", "", "config"},
-		{"special-chars-only", "---", "config"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := sanitizeName(tt.input)
-			if result != tt.expected {
-				t.Errorf("sanitizeName(%q) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
 // ============================================================
 // nameForComponent Tests
 // ============================================================
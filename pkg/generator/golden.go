@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SnapshotState classifies a rendered template against its golden file.
+type SnapshotState string
+
+const (
+	// SnapshotMatch: the template renders as its golden file.
+	SnapshotMatch SnapshotState = "match"
+
+	// SnapshotChanged: the template renders differently.
+	SnapshotChanged SnapshotState = "changed"
+
+	// SnapshotNew: the template has no golden file.
+	SnapshotNew SnapshotState = "new"
+
+	// SnapshotRemoved: the golden file belongs to a template that no longer
+	// renders.
+	SnapshotRemoved SnapshotState = "removed"
+)
+
+// SnapshotResult is the comparison of a rendered template with its golden
+// file.
+type SnapshotResult struct {
+	// Path is the template path relative to the chart, and of the golden
+	// file relative to the golden directory, e.g. templates/service.yaml.
+	Path  string
+	State SnapshotState
+
+	// Hunks turn the golden file into the rendered template, for
+	// SnapshotChanged.
+	Hunks []Hunk
+}
+
+// CompareSnapshots compares rendered templates, keyed by path relative to
+// the chart (e.g. templates/service.yaml), with the golden files under
+// templates/ in dir, in path order. Other files of dir are ignored, and a
+// missing dir has no golden files.
+func CompareSnapshots(rendered map[string]string, dir string) ([]SnapshotResult, error) {
+	golden, err := readSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool)
+	for p := range rendered {
+		paths[p] = true
+	}
+	for p := range golden {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	results := make([]SnapshotResult, 0, len(sorted))
+	for _, p := range sorted {
+		content, isRendered := rendered[p]
+		want, hasGolden := golden[p]
+		r := SnapshotResult{Path: p, State: SnapshotMatch}
+		switch {
+		case !hasGolden:
+			r.State = SnapshotNew
+		case !isRendered:
+			r.State = SnapshotRemoved
+		default:
+			if r.Hunks = DiffHunks(want, snapshotContent(content)); len(r.Hunks) > 0 {
+				r.State = SnapshotChanged
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// UpdateSnapshots makes the golden files in dir match rendered: it writes
+// new and changed templates and removes the golden files of templates that
+// no longer render. It returns the comparison before the update.
+func UpdateSnapshots(rendered map[string]string, dir string) ([]SnapshotResult, error) {
+	results, err := CompareSnapshots(rendered, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		path := filepath.Join(dir, filepath.FromSlash(r.Path))
+		switch r.State {
+		case SnapshotNew, SnapshotChanged:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(path, []byte(snapshotContent(rendered[r.Path])), 0644); err != nil {
+				return nil, err
+			}
+		case SnapshotRemoved:
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// readSnapshots returns the golden files under templates/ in dir, keyed by
+// slash-separated path relative to dir.
+func readSnapshots(dir string) (map[string]string, error) {
+	golden := make(map[string]string)
+	err := filepath.WalkDir(filepath.Join(dir, "templates"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		golden[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("cannot read golden files: %w", err)
+	}
+	return golden, nil
+}
+
+// snapshotContent returns rendered output as stored in a golden file: with
+// exactly one trailing newline.
+func snapshotContent(content string) string {
+	return strings.TrimRight(content, "\n") + "\n"
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshots(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "golden")
+	rendered := map[string]string{
+		"templates/cm.yaml":  "kind: ConfigMap\ndata:\n  key: a",
+		"templates/svc.yaml": "kind: Service\n",
+	}
+
+	results, err := CompareSnapshots(rendered, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].State != SnapshotNew || results[1].State != SnapshotNew {
+		t.Fatalf("a missing golden directory should make every template new, got %+v", results)
+	}
+
+	if _, err := UpdateSnapshots(rendered, dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "templates", "cm.yaml")); err != nil || string(data) != "kind: ConfigMap\ndata:\n  key: a\n" {
+		t.Fatalf("golden file = %q, %v", data, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("snapshots\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err = CompareSnapshots(rendered, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.State != SnapshotMatch {
+			t.Errorf("%s: expected a match after update, got %s", r.Path, r.State)
+		}
+	}
+
+	rendered = map[string]string{
+		"templates/cm.yaml":  "kind: ConfigMap\ndata:\n  key: b\n",
+		"templates/hpa.yaml": "kind: HorizontalPodAutoscaler\n",
+	}
+	results, err = UpdateSnapshots(rendered, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]SnapshotState{
+		"templates/cm.yaml":  SnapshotChanged,
+		"templates/hpa.yaml": SnapshotNew,
+		"templates/svc.yaml": SnapshotRemoved,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for _, r := range results {
+		if r.State != want[r.Path] {
+			t.Errorf("%s: got %s, want %s", r.Path, r.State, want[r.Path])
+		}
+	}
+	if h := results[0].Hunks; len(h) != 1 || h[0].Line != 3 || h[0].Old[0] != "  key: a\n" || h[0].New[0] != "  key: b\n" {
+		t.Errorf("unexpected hunks %+v", h)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "templates", "svc.yaml")); !os.IsNotExist(err) {
+		t.Error("the golden file of a removed template should be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); err != nil {
+		t.Error("files outside templates/ should be kept")
+	}
+}
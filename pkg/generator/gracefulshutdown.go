@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ShutdownExposure is how traffic reaches a workload. It sets how long the
+// pods of the workload keep serving after they are removed from endpoints.
+type ShutdownExposure string
+
+const (
+	// ExposureService: the workload is selected by a Service.
+	ExposureService ShutdownExposure = "service"
+	// ExposureIngress: a Service of the workload is referenced by an
+	// Ingress, a Gateway API route or a VirtualService.
+	ExposureIngress ShutdownExposure = "ingress"
+)
+
+// preStopSleepByExposure is the preStop sleep of workloads by exposure:
+// kube-proxy reprograms endpoints within seconds, while ingress controllers
+// and cloud load balancers take longer to stop sending requests.
+var preStopSleepByExposure = map[ShutdownExposure]int{
+	ExposureService: 5,
+	ExposureIngress: 15,
+}
+
+// GracefulShutdownTargets returns the Deployments, StatefulSets and
+// DaemonSets of graph behind a Service, keyed by template path, with their
+// exposure.
+func GracefulShutdownTargets(graph *types.ResourceGraph) map[string]ShutdownExposure {
+	targets := make(map[string]ShutdownExposure)
+	if graph == nil {
+		return targets
+	}
+
+	exposed := make(map[types.ResourceKey]bool)
+	for _, rel := range graph.Relationships {
+		if rel.Type == types.RelationNameReference && rel.To.GVK.Kind == "Service" && exposingKinds[rel.From.GVK.Kind] {
+			exposed[rel.To] = true
+		}
+	}
+	for _, rel := range graph.Relationships {
+		if rel.Type != types.RelationLabelSelector || rel.From.GVK.Kind != "Service" {
+			continue
+		}
+		switch rel.To.GVK.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+		default:
+			continue
+		}
+		workload, ok := graph.GetResourceByKey(rel.To)
+		if !ok || workload.TemplatePath == "" {
+			continue
+		}
+		exposure := ExposureService
+		if exposed[rel.From] {
+			exposure = ExposureIngress
+		}
+		if targets[workload.TemplatePath] != ExposureIngress {
+			targets[workload.TemplatePath] = exposure
+		}
+	}
+	return targets
+}
+
+// GracefulShutdownValues returns the gracefulShutdown values of a workload of
+// kind: the preStop sleep of its exposure and a termination grace period
+// leaving the application the shutdown time of its kind after the sleep.
+func GracefulShutdownValues(kind string, exposure ShutdownExposure) map[string]interface{} {
+	sleep := preStopSleepByExposure[exposure]
+	if sleep == 0 {
+		sleep = preStopSleepByExposure[ExposureService]
+	}
+	gracePeriod := gracePeriodByKind[kind]
+	if gracePeriod == 0 {
+		gracePeriod = 30
+	}
+	return map[string]interface{}{
+		"enabled":                       true,
+		"preStopSleepSeconds":           sleep,
+		"terminationGracePeriodSeconds": sleep + gracePeriod,
+	}
+}
+
+// shutdownValuesRe matches the workload templates rendered from
+// services.<service>.<kind> values.
+var shutdownValuesRe = regexp.MustCompile(`^\{\{- \$svc := \.Values\.services\.(\S+) -\}\}\n(?:.*\n)*?\{\{- with \$svc\.(deployment|statefulSet|daemonSet) \}\}`)
+
+const (
+	// shutdownPodAnchor starts the containers of the pod spec of workload
+	// templates rendered from values.
+	shutdownPodAnchor = "      containers:\n        {{- range .containers }}\n"
+	// shutdownContainerAnchor ends the containers of the pod spec.
+	shutdownContainerAnchor = "        {{- end }}\n      {{- with .volumes }}\n"
+)
+
+// shutdownPodBlock sets the termination grace period of the pod from the
+// gracefulShutdown values of the workload.
+const shutdownPodBlock = `      {{- $shutdown := .gracefulShutdown }}
+      {{- if and $shutdown $shutdown.enabled }}
+      terminationGracePeriodSeconds: {{ $shutdown.terminationGracePeriodSeconds }}
+      {{- end }}
+`
+
+// shutdownContainerBlock adds the sleep-based preStop hook to the containers.
+const shutdownContainerBlock = `          {{- if and $shutdown $shutdown.enabled }}
+          lifecycle:
+            preStop:
+              exec:
+                command: ["sh", "-c", "sleep {{ $shutdown.preStopSleepSeconds }}"]
+          {{- end }}
+`
+
+// InjectGracefulShutdownValues adds a parameterized termination grace period
+// and preStop sleep to the workload templates of targets, rendered from
+// values, with services.<service>.<kind>.gracefulShutdown defaults sized by
+// GracefulShutdownValues. Values already set are kept. Copy-on-write.
+// Returns the patched chart and the count of patched templates.
+func InjectGracefulShutdownValues(chart *types.GeneratedChart, targets map[string]ShutdownExposure) (*types.GeneratedChart, int) {
+	result := copyChartTemplates(chart)
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &values); err != nil {
+		return result, 0
+	}
+	services, _ := values["services"].(map[string]interface{})
+
+	count := 0
+	for path, exposure := range targets {
+		content, ok := chart.Templates[path]
+		if !ok || strings.Contains(content, "$shutdown") {
+			continue
+		}
+		m := shutdownValuesRe.FindStringSubmatch(content)
+		if m == nil || !strings.Contains(content, shutdownPodAnchor) || !strings.Contains(content, shutdownContainerAnchor) {
+			continue
+		}
+		svc, _ := services[m[1]].(map[string]interface{})
+		workload, ok := svc[m[2]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, set := workload["gracefulShutdown"]; !set {
+			workload["gracefulShutdown"] = GracefulShutdownValues(extractKind(content), exposure)
+		}
+
+		content = strings.Replace(content, shutdownPodAnchor, shutdownPodBlock+shutdownPodAnchor, 1)
+		result.Templates[path] = strings.Replace(content, shutdownContainerAnchor, shutdownContainerBlock+shutdownContainerAnchor, 1)
+		count++
+	}
+
+	if count > 0 {
+		if out, err := yaml.Marshal(values); err == nil {
+			result.ValuesYAML = string(out)
+		}
+	}
+	return result, count
+}
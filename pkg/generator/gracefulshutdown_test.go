@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestGracefulShutdownTargets(t *testing.T) {
+	graph := types.NewResourceGraph()
+	add := func(kind, name, templatePath string) types.ResourceKey {
+		r := makeProcessedResource(kind, name, "default", nil)
+		r.TemplatePath = templatePath
+		graph.AddResource(r)
+		return r.Original.ResourceKey()
+	}
+	web := add("Deployment", "web", "templates/web-deployment.yaml")
+	db := add("StatefulSet", "db", "templates/db-statefulset.yaml")
+	add("Deployment", "worker", "templates/worker-deployment.yaml")
+	migrate := add("Job", "migrate", "templates/migrate-job.yaml")
+	webSvc := add("Service", "web-svc", "templates/webSvc-service.yaml")
+	dbSvc := add("Service", "db", "templates/db-service.yaml")
+	ingress := add("Ingress", "web", "templates/web-ingress.yaml")
+
+	graph.AddRelationship(types.Relationship{From: webSvc, To: web, Type: types.RelationLabelSelector})
+	graph.AddRelationship(types.Relationship{From: dbSvc, To: db, Type: types.RelationLabelSelector})
+	graph.AddRelationship(types.Relationship{From: dbSvc, To: migrate, Type: types.RelationLabelSelector})
+	graph.AddRelationship(types.Relationship{From: ingress, To: webSvc, Type: types.RelationNameReference})
+
+	targets := GracefulShutdownTargets(graph)
+	want := map[string]ShutdownExposure{
+		"templates/web-deployment.yaml": ExposureIngress,
+		"templates/db-statefulset.yaml": ExposureService,
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("targets = %v, want %v", targets, want)
+	}
+	for path, exposure := range want {
+		if targets[path] != exposure {
+			t.Errorf("%s: exposure = %q, want %q", path, targets[path], exposure)
+		}
+	}
+}
+
+func TestInjectGracefulShutdownValues(t *testing.T) {
+	workload := func(service, kind, key string) string {
+		return "{{- $svc := .Values.services." + service + " -}}\n{{- if $svc.enabled }}\n{{- with $svc." + key + " }}\n" +
+			"apiVersion: apps/v1\nkind: " + kind + "\nspec:\n  template:\n    spec:\n" + shutdownPodAnchor +
+			"        - name: {{ .name }}\n" + shutdownContainerAnchor + "      volumes:\n{{- end }}\n{{- end }}\n"
+	}
+	chart := makeChart("app", map[string]string{
+		"templates/web-deployment.yaml":    workload("web", "Deployment", "deployment"),
+		"templates/db-statefulset.yaml":    workload("db", "StatefulSet", "statefulSet"),
+		"templates/worker-deployment.yaml": workload("worker", "Deployment", "deployment"),
+	})
+	chart.ValuesYAML = `services:
+  web:
+    deployment:
+      replicas: 2
+  db:
+    statefulSet:
+      gracefulShutdown:
+        enabled: true
+        preStopSleepSeconds: 10
+        terminationGracePeriodSeconds: 120
+  worker:
+    deployment:
+      replicas: 1
+`
+	targets := map[string]ShutdownExposure{
+		"templates/web-deployment.yaml": ExposureIngress,
+		"templates/db-statefulset.yaml": ExposureService,
+	}
+
+	result, count := InjectGracefulShutdownValues(chart, targets)
+	if count != 2 {
+		t.Errorf("expected 2 patched templates, got %d", count)
+	}
+	web := result.Templates["templates/web-deployment.yaml"]
+	for _, want := range []string{
+		"      {{- if and $shutdown $shutdown.enabled }}\n      terminationGracePeriodSeconds: {{ $shutdown.terminationGracePeriodSeconds }}\n      {{- end }}\n      containers:",
+		`command: ["sh", "-c", "sleep {{ $shutdown.preStopSleepSeconds }}"]` + "\n          {{- end }}\n        {{- end }}\n      {{- with .volumes }}",
+	} {
+		if !strings.Contains(web, want) {
+			t.Errorf("expected %q in the Deployment template, got:\n%s", want, web)
+		}
+	}
+	if result.Templates["templates/worker-deployment.yaml"] != chart.Templates["templates/worker-deployment.yaml"] {
+		t.Error("a workload not behind a Service should be left alone")
+	}
+	if again, n := InjectGracefulShutdownValues(result, targets); n != 0 || again.Templates["templates/web-deployment.yaml"] != web {
+		t.Error("injecting twice should leave the templates unchanged")
+	}
+
+	var values struct {
+		Services map[string]map[string]struct {
+			GracefulShutdown map[string]interface{} `json:"gracefulShutdown"`
+		}
+	}
+	if err := yaml.Unmarshal([]byte(result.ValuesYAML), &values); err != nil {
+		t.Fatal(err)
+	}
+	if got := values.Services["web"]["deployment"].GracefulShutdown; got["preStopSleepSeconds"] != float64(15) || got["terminationGracePeriodSeconds"] != float64(45) {
+		t.Errorf("web behind an Ingress: unexpected gracefulShutdown %v", got)
+	}
+	if got := values.Services["db"]["statefulSet"].GracefulShutdown; got["terminationGracePeriodSeconds"] != float64(120) {
+		t.Errorf("values already set should be kept, got %v", got)
+	}
+	if values.Services["worker"]["deployment"].GracefulShutdown != nil {
+		t.Error("worker should get no gracefulShutdown values")
+	}
+}
+
+func TestGracefulShutdownValues(t *testing.T) {
+	for _, tc := range []struct {
+		kind     string
+		exposure ShutdownExposure
+		sleep    int
+		grace    int
+	}{
+		{"Deployment", ExposureService, 5, 35},
+		{"Deployment", ExposureIngress, 15, 45},
+		{"StatefulSet", ExposureService, 5, 65},
+	} {
+		got := GracefulShutdownValues(tc.kind, tc.exposure)
+		if got["preStopSleepSeconds"] != tc.sleep || got["terminationGracePeriodSeconds"] != tc.grace {
+			t.Errorf("%s/%s: got %v", tc.kind, tc.exposure, got)
+		}
+	}
+}
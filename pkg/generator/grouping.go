@@ -1,6 +1,8 @@
 package generator
 
 import (
+	"sort"
+
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -61,8 +63,12 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 	grouped := make(map[types.ResourceKey]bool)
 	groupsByName := make(map[string]*ServiceGroup)
 
+	// Resources are visited in key order so groups come out the same on every run.
+	keys := sortedResourceKeys(graph)
+
 	// Pass 1: Group by standard labels (highest priority).
-	for key, resource := range graph.Resources {
+	for _, key := range keys {
+		resource := graph.Resources[key]
 		appName := extractAppLabel(resource)
 		if appName == "" {
 			continue
@@ -81,10 +87,10 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 	}
 
 	// Pass 2: Group ungrouped resources by relationship connected components.
-	ungrouped := make(map[types.ResourceKey]*types.ProcessedResource)
-	for key, resource := range graph.Resources {
+	var ungrouped []types.ResourceKey
+	for _, key := range keys {
 		if !grouped[key] {
-			ungrouped[key] = resource
+			ungrouped = append(ungrouped, key)
 		}
 	}
 
@@ -92,13 +98,16 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 		// Build adjacency list (undirected) for ungrouped resources.
 		adj := make(map[types.ResourceKey][]types.ResourceKey)
 		for _, rel := range graph.Relationships {
+			if !rel.JoinsGroup() {
+				continue
+			}
 			adj[rel.From] = append(adj[rel.From], rel.To)
 			adj[rel.To] = append(adj[rel.To], rel.From)
 		}
 
 		// BFS to find connected components among ALL resources connected by relationships.
 		visited := make(map[types.ResourceKey]bool)
-		for key := range ungrouped {
+		for _, key := range ungrouped {
 			if visited[key] {
 				continue
 			}
@@ -141,7 +150,8 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 				existingGroupName := ""
 				for _, r := range component {
 					rKey := r.Original.ResourceKey()
-					for gName, g := range groupsByName {
+					for _, gName := range groupNames(groupsByName) {
+						g := groupsByName[gName]
 						for _, gr := range g.Resources {
 							if gr.Original.ResourceKey() == rKey {
 								existingGroupName = gName
@@ -184,7 +194,8 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 
 	// Pass 3: Group remaining ungrouped resources by namespace.
 	nsByNamespace := make(map[string][]*types.ProcessedResource)
-	for key, resource := range graph.Resources {
+	for _, key := range keys {
+		resource := graph.Resources[key]
 		if !grouped[key] {
 			ns := resource.Original.Object.GetNamespace()
 			nsByNamespace[ns] = append(nsByNamespace[ns], resource)
@@ -192,7 +203,13 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 		}
 	}
 
-	for ns, resources := range nsByNamespace {
+	namespaces := make([]string, 0, len(nsByNamespace))
+	for ns := range nsByNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		resources := nsByNamespace[ns]
 		name := ns
 		if name == "" {
 			// Use first resource name if no namespace.
@@ -210,17 +227,50 @@ func GroupResources(graph *types.ResourceGraph) (*GroupingResult, error) {
 		}
 	}
 
-	// Collect all groups into result.
+	// Collect all groups into result, ordered by name.
 	result := &GroupingResult{
 		Groups: make([]*ServiceGroup, 0, len(groupsByName)),
 	}
-	for _, g := range groupsByName {
-		result.Groups = append(result.Groups, g)
+	for _, name := range groupNames(groupsByName) {
+		result.Groups = append(result.Groups, groupsByName[name])
 	}
 
 	return result, nil
 }
 
+// sortedResourceKeys returns the graph's resource keys in string order.
+func sortedResourceKeys(graph *types.ResourceGraph) []types.ResourceKey {
+	keys := make([]types.ResourceKey, 0, len(graph.Resources))
+	for key := range graph.Resources {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// sortedResources returns the graph's resources in key order, so output built
+// from them does not depend on map iteration order.
+func sortedResources(graph *types.ResourceGraph) []*types.ProcessedResource {
+	keys := sortedResourceKeys(graph)
+	resources := make([]*types.ProcessedResource, 0, len(keys))
+	for _, key := range keys {
+		resources = append(resources, graph.Resources[key])
+	}
+	return resources
+}
+
+// groupNames returns the names of groups in sorted order.
+func groupNames(groups map[string]*ServiceGroup) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // extractAppLabel extracts the application name from standard Kubernetes labels.
 // Checks labels in priority order: app.kubernetes.io/name > app.kubernetes.io/instance > app > name.
 func extractAppLabel(resource *types.ProcessedResource) string {
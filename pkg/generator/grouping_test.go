@@ -65,6 +65,10 @@ func gvkForKind(kind string) schema.GroupVersionKind {
 		return schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: kind}
 	case "PersistentVolumeClaim":
 		return schema.GroupVersionKind{Group: "", Version: "v1", Kind: kind}
+	case "Certificate", "Issuer", "ClusterIssuer":
+		return schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind}
+	case "ServiceMonitor", "PodMonitor":
+		return schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: kind}
 	default:
 		return schema.GroupVersionKind{Group: "", Version: "v1", Kind: kind}
 	}
@@ -372,6 +376,113 @@ func TestGroupResources_ByRelationship_ChainedDependencies(t *testing.T) {
 	}
 }
 
+func TestGroupResources_ByRelationship_NetworkPeerDoesNotMerge(t *testing.T) {
+	// Input: two workloads, each with its own ConfigMap, allowed to talk by a
+	// NetworkPolicy (network_peer). Expected: 2 groups, not 1.
+	deployA := makeProcessedResource("Deployment", "app-a", "default", nil)
+	cmA := makeProcessedResource("ConfigMap", "config-a", "default", nil)
+	deployB := makeProcessedResource("Deployment", "app-b", "default", nil)
+	cmB := makeProcessedResource("ConfigMap", "config-b", "default", nil)
+
+	relationships := []types.Relationship{
+		{From: resourceKey(deployA), To: resourceKey(cmA), Type: types.RelationVolumeMount},
+		{From: resourceKey(deployB), To: resourceKey(cmB), Type: types.RelationVolumeMount},
+		{From: resourceKey(deployA), To: resourceKey(deployB), Type: types.RelationNetworkPeer},
+	}
+
+	graph := buildGraph([]*types.ProcessedResource{deployA, cmA, deployB, cmB}, relationships)
+
+	result, err := GroupResources(graph)
+	if err != nil {
+		t.Fatalf("GroupResources returned error: %v", err)
+	}
+
+	if len(result.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %v", len(result.Groups), sortedGroupNames(result))
+	}
+	for _, g := range result.Groups {
+		if len(g.Resources) != 2 {
+			t.Errorf("group %q: expected 2 resources, got %d", g.Name, len(g.Resources))
+		}
+	}
+}
+
+func TestGroupResources_ByRelationship_SharedGatewayDoesNotMerge(t *testing.T) {
+	// Input: two Services, each routed by its own VirtualService through the
+	// same Gateway (gateway_route). Expected: the services stay apart.
+	svcA := makeProcessedResource("Service", "app-a", "default", nil)
+	vsA := makeProcessedResource("VirtualService", "app-a", "default", nil)
+	svcB := makeProcessedResource("Service", "app-b", "default", nil)
+	vsB := makeProcessedResource("VirtualService", "app-b", "default", nil)
+	gw := makeProcessedResource("Gateway", "public", "istio-system", nil)
+
+	relationships := []types.Relationship{
+		{From: resourceKey(vsA), To: resourceKey(svcA), Type: types.RelationNameReference},
+		{From: resourceKey(vsB), To: resourceKey(svcB), Type: types.RelationNameReference},
+		{From: resourceKey(vsA), To: resourceKey(gw), Type: types.RelationGatewayRoute},
+		{From: resourceKey(vsB), To: resourceKey(gw), Type: types.RelationGatewayRoute},
+	}
+
+	graph := buildGraph([]*types.ProcessedResource{svcA, vsA, svcB, vsB, gw}, relationships)
+
+	result, err := GroupResources(graph)
+	if err != nil {
+		t.Fatalf("GroupResources returned error: %v", err)
+	}
+
+	for _, g := range result.Groups {
+		keys := make(map[types.ResourceKey]bool)
+		for _, r := range g.Resources {
+			keys[resourceKey(r)] = true
+		}
+		if keys[resourceKey(svcA)] && keys[resourceKey(svcB)] {
+			t.Fatalf("group %q merges both services through the shared Gateway", g.Name)
+		}
+		if keys[resourceKey(svcA)] != keys[resourceKey(vsA)] {
+			t.Errorf("group %q: Service app-a and its VirtualService should be grouped together", g.Name)
+		}
+	}
+}
+
+func TestGroupResources_ByRelationship_SharedClusterIssuerDoesNotMerge(t *testing.T) {
+	// Input: two Ingresses, each with the Certificate for its TLS secret, both
+	// annotated with the same ClusterIssuer. Expected: each Certificate is
+	// grouped with its Ingress and the Ingresses stay apart.
+	ingA := makeProcessedResource("Ingress", "app-a", "default", nil)
+	certA := makeProcessedResource("Certificate", "app-a", "default", nil)
+	ingB := makeProcessedResource("Ingress", "app-b", "default", nil)
+	certB := makeProcessedResource("Certificate", "app-b", "default", nil)
+	issuer := makeProcessedResource("ClusterIssuer", "letsencrypt", "", nil)
+
+	relationships := []types.Relationship{
+		{From: resourceKey(ingA), To: resourceKey(certA), Type: types.RelationNameReference},
+		{From: resourceKey(ingB), To: resourceKey(certB), Type: types.RelationNameReference},
+		{From: resourceKey(ingA), To: resourceKey(issuer), Type: types.RelationAnnotation},
+		{From: resourceKey(ingB), To: resourceKey(issuer), Type: types.RelationAnnotation},
+		{From: resourceKey(certA), To: resourceKey(issuer), Type: types.RelationNameReference},
+	}
+
+	graph := buildGraph([]*types.ProcessedResource{ingA, certA, ingB, certB, issuer}, relationships)
+
+	result, err := GroupResources(graph)
+	if err != nil {
+		t.Fatalf("GroupResources returned error: %v", err)
+	}
+
+	for _, g := range result.Groups {
+		keys := make(map[types.ResourceKey]bool)
+		for _, r := range g.Resources {
+			keys[resourceKey(r)] = true
+		}
+		if keys[resourceKey(ingA)] && keys[resourceKey(ingB)] {
+			t.Fatalf("group %q merges both Ingresses through the shared ClusterIssuer", g.Name)
+		}
+		if keys[resourceKey(ingA)] != keys[resourceKey(certA)] || keys[resourceKey(ingB)] != keys[resourceKey(certB)] {
+			t.Errorf("group %q: each Certificate should be grouped with its Ingress", g.Name)
+		}
+	}
+}
+
 // ============================================================
 // Subtask 5: Strategy priority (label > relationship > namespace > individual)
 // ============================================================
@@ -591,3 +702,42 @@ func TestGroupResources_GroupNaming_FromResourceName(t *testing.T) {
 		t.Errorf("expected group name 'standalone-worker', got '%s'", result.Groups[0].Name)
 	}
 }
+
+func TestGroupResources_Deterministic(t *testing.T) {
+	var resources []*types.ProcessedResource
+	for _, name := range []string{"web", "api", "worker", "db", "cache"} {
+		resources = append(resources,
+			makeProcessedResource("Deployment", name, "default", map[string]string{"app.kubernetes.io/name": name}),
+			makeProcessedResource("Service", name, "default", map[string]string{"app.kubernetes.io/name": name}))
+	}
+	graph := buildGraph(resources, nil)
+
+	first, err := GroupResources(graph)
+	if err != nil {
+		t.Fatalf("GroupResources returned error: %v", err)
+	}
+	names := make([]string, len(first.Groups))
+	for i, g := range first.Groups {
+		names[i] = g.Name
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("expected groups ordered by name, got %v", names)
+	}
+
+	for run := 0; run < 10; run++ {
+		result, err := GroupResources(graph)
+		if err != nil {
+			t.Fatalf("GroupResources returned error: %v", err)
+		}
+		for i, g := range result.Groups {
+			if g.Name != first.Groups[i].Name {
+				t.Fatalf("run %d: group %d is %s, first run had %s", run, i, g.Name, first.Groups[i].Name)
+			}
+			for j, r := range g.Resources {
+				if r != first.Groups[i].Resources[j] {
+					t.Fatalf("run %d: resources of group %s reordered", run, g.Name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ImageUser is a container of a workload that runs an image.
+type ImageUser struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+}
+
+// String returns "Kind namespace/name (container)".
+func (u ImageUser) String() string {
+	name := u.Name
+	if u.Namespace != "" {
+		name = u.Namespace + "/" + u.Name
+	}
+	return u.Kind + " " + name + " (" + u.Container + ")"
+}
+
+// InventoryImage is an image referenced by the input resources together
+// with the containers that use it.
+type InventoryImage struct {
+	Image      string      `json:"image"`
+	Repository string      `json:"repository"`
+	Tag        string      `json:"tag,omitempty"`
+	Digest     string      `json:"digest,omitempty"`
+	UsedBy     []ImageUser `json:"usedBy"`
+}
+
+// BuildImageInventory lists every image referenced by the containers, init
+// containers and ephemeral containers of resources, sorted by image. The
+// users of an image are kept in input order.
+func BuildImageInventory(resources []*types.ExtractedResource) []InventoryImage {
+	index := make(map[string]int)
+	var images []InventoryImage
+	for _, r := range resources {
+		for _, c := range processor.ContainerImages(r.Object) {
+			i, ok := index[c.Image]
+			if !ok {
+				ref := parseImageRef(c.Image)
+				images = append(images, InventoryImage{
+					Image:      c.Image,
+					Repository: ref.Repository,
+					Tag:        ref.Tag,
+					Digest:     ref.Digest,
+				})
+				i = len(images) - 1
+				index[c.Image] = i
+			}
+			images[i].UsedBy = append(images[i].UsedBy, ImageUser{
+				Kind:      r.Object.GetKind(),
+				Namespace: r.Object.GetNamespace(),
+				Name:      r.Object.GetName(),
+				Container: c.Container,
+			})
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Image < images[j].Image })
+	return images
+}
+
+// cycloneDXBOM is the subset of the CycloneDX 1.5 JSON format dhg writes.
+type cycloneDXBOM struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    cycloneDXMetadata    `json:"metadata"`
+	Components  []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Tools struct {
+		Components []cycloneDXComponent `json:"components"`
+	} `json:"tools"`
+}
+
+type cycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GenerateCycloneDX renders an image inventory as a CycloneDX 1.5 JSON BOM
+// with one "container" component per image, identified by an OCI package
+// URL. The containers using an image are listed as "dhg:usedBy"
+// properties. The output has no timestamp or serial number, so it is
+// reproducible.
+func GenerateCycloneDX(images []InventoryImage, toolVersion string) ([]byte, error) {
+	bom := cycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Components:  make([]cycloneDXComponent, 0, len(images)),
+	}
+	bom.Metadata.Tools.Components = []cycloneDXComponent{{
+		Type:    "application",
+		Name:    "dhg",
+		Version: toolVersion,
+	}}
+
+	for _, img := range images {
+		version := img.Tag
+		if img.Digest != "" {
+			version = img.Digest
+		}
+		component := cycloneDXComponent{
+			Type:    "container",
+			BOMRef:  img.Image,
+			Name:    img.Repository,
+			Version: version,
+			PURL:    ociPURL(img),
+		}
+		for _, u := range img.UsedBy {
+			component.Properties = append(component.Properties, cycloneDXProperty{Name: "dhg:usedBy", Value: u.String()})
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	// Package URLs contain "&", which must not be HTML-escaped.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(bom); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ociPURL returns the package URL of an image
+// (pkg:oci/name@digest?repository_url=...&tag=...). Docker Hub images
+// without a registry host are qualified with docker.io (and library/ for
+// official images).
+func ociPURL(img InventoryImage) string {
+	repo := img.Repository
+	first, _, hasSlash := strings.Cut(repo, "/")
+	if !hasSlash {
+		repo = "docker.io/library/" + repo
+	} else if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		repo = "docker.io/" + repo
+	}
+
+	purl := "pkg:oci/" + strings.ToLower(repo[strings.LastIndex(repo, "/")+1:])
+	if img.Digest != "" {
+		purl += "@" + strings.ReplaceAll(img.Digest, ":", "%3A")
+	}
+	purl += "?repository_url=" + repo
+	if img.Tag != "" {
+		purl += "&tag=" + img.Tag
+	}
+	return purl
+}
@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func workloadWithImages(kind, name string, images map[string]string) *types.ExtractedResource {
+	containers := make([]interface{}, 0, len(images))
+	for container, image := range images {
+		containers = append(containers, map[string]interface{}{"name": container, "image": image})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "prod"},
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"containers": containers,
+		}}},
+	}}
+	return &types.ExtractedResource{Object: obj, GVK: obj.GroupVersionKind()}
+}
+
+func TestBuildImageInventory(t *testing.T) {
+	resources := []*types.ExtractedResource{
+		workloadWithImages("Deployment", "web", map[string]string{"web": "nginx:1.25"}),
+		workloadWithImages("StatefulSet", "db", map[string]string{"db": "ghcr.io/acme/postgres:16@sha256:abc"}),
+		workloadWithImages("DaemonSet", "proxy", map[string]string{"proxy": "nginx:1.25"}),
+		{Object: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}},
+	}
+
+	images := BuildImageInventory(resources)
+	if len(images) != 2 {
+		t.Fatalf("expected 2 images, got %+v", images)
+	}
+
+	db := images[0]
+	if db.Repository != "ghcr.io/acme/postgres" || db.Tag != "16" || db.Digest != "sha256:abc" {
+		t.Errorf("unexpected image: %+v", db)
+	}
+	nginx := images[1]
+	if nginx.Image != "nginx:1.25" || len(nginx.UsedBy) != 2 {
+		t.Fatalf("unexpected image: %+v", nginx)
+	}
+	if got := nginx.UsedBy[1].String(); got != "DaemonSet prod/proxy (proxy)" {
+		t.Errorf("UsedBy[1] = %q", got)
+	}
+}
+
+func TestGenerateCycloneDX(t *testing.T) {
+	images := BuildImageInventory([]*types.ExtractedResource{
+		workloadWithImages("Deployment", "web", map[string]string{"web": "nginx:1.25"}),
+		workloadWithImages("StatefulSet", "db", map[string]string{"db": "ghcr.io/acme/postgres:16@sha256:abc"}),
+	})
+
+	data, err := GenerateCycloneDX(images, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bom struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		Components  []struct {
+			Type       string `json:"type"`
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			PURL       string `json:"purl"`
+			Properties []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || len(bom.Components) != 2 {
+		t.Fatalf("unexpected BOM:\n%s", data)
+	}
+
+	db, nginx := bom.Components[0], bom.Components[1]
+	if db.Type != "container" || db.Version != "sha256:abc" {
+		t.Errorf("unexpected component: %+v", db)
+	}
+	if want := "pkg:oci/postgres@sha256%3Aabc?repository_url=ghcr.io/acme/postgres&tag=16"; db.PURL != want {
+		t.Errorf("purl = %q, want %q", db.PURL, want)
+	}
+	if want := "pkg:oci/nginx?repository_url=docker.io/library/nginx&tag=1.25"; nginx.PURL != want {
+		t.Errorf("purl = %q, want %q", nginx.PURL, want)
+	}
+	if len(nginx.Properties) != 1 || nginx.Properties[0].Value != "Deployment prod/web (web)" {
+		t.Errorf("unexpected properties: %+v", nginx.Properties)
+	}
+}
@@ -1,9 +1,12 @@
 package generator
 
 import (
+	"regexp"
 	"sort"
 	"strings"
 
+	"sigs.k8s.io/yaml"
+
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -238,17 +241,15 @@ func InjectIngressAnnotations(chart *types.GeneratedChart, controller IngressCon
 	// Copy templates map — do not mutate the original chart.
 	newTemplates := make(map[string]string, len(chart.Templates))
 	for path, content := range chart.Templates {
-		if extractKind(content) == "Ingress" {
-			content = injectAnnotationsIntoTemplate(content, annotations)
-		}
 		newTemplates[path] = content
 	}
+	valuesYAML := injectIngressAnnotations(chart.ValuesYAML, newTemplates, annotations)
 
 	return &types.GeneratedChart{
 		Name:          chart.Name,
 		Path:          chart.Path,
 		ChartYAML:     chart.ChartYAML,
-		ValuesYAML:    chart.ValuesYAML,
+		ValuesYAML:    valuesYAML,
 		Templates:     newTemplates,
 		Helpers:       chart.Helpers,
 		Notes:         chart.Notes,
@@ -257,3 +258,66 @@ func InjectIngressAnnotations(chart *types.GeneratedChart, controller IngressCon
 	}
 }
 
+// ingressValuesRe matches the values binding of the Ingress templates of the
+// ingress processor, which render services.<name>.ingress.annotations.
+var ingressValuesRe = regexp.MustCompile(`^\{\{- \$svc := \.Values\.services\.(\S+) -\}\}\n(?:.*\n)*?\{\{- with \$svc\.ingress \}\}`)
+
+// injectIngressAnnotations adds annotations to the Ingress templates among
+// templates and returns the updated valuesYAML. The annotations of Ingress
+// templates rendered from values are merged into
+// services.<name>.ingress.annotations, keeping the keys already there, so
+// that they can be overridden like any other value; other Ingress templates
+// get them injected into their metadata.
+func injectIngressAnnotations(valuesYAML string, templates map[string]string, annotations map[string]string) string {
+	if len(annotations) == 0 {
+		return valuesYAML
+	}
+	var values map[string]interface{}
+	valuesChanged := false
+	for path, content := range templates {
+		if extractKind(content) != "Ingress" {
+			continue
+		}
+		if m := ingressValuesRe.FindStringSubmatch(content); m != nil {
+			if values == nil && yaml.Unmarshal([]byte(valuesYAML), &values) != nil {
+				values = map[string]interface{}{}
+			}
+			if mergeIngressValuesAnnotations(values, m[1], annotations) {
+				valuesChanged = true
+				continue
+			}
+		}
+		templates[path] = injectAnnotationsIntoTemplate(content, annotations)
+	}
+	if !valuesChanged {
+		return valuesYAML
+	}
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return valuesYAML
+	}
+	return string(out)
+}
+
+// mergeIngressValuesAnnotations adds annotations missing from
+// services.<service>.ingress.annotations of values. It returns false if
+// values have no such ingress.
+func mergeIngressValuesAnnotations(values map[string]interface{}, service string, annotations map[string]string) bool {
+	services, _ := values["services"].(map[string]interface{})
+	svc, _ := services[service].(map[string]interface{})
+	ingress, ok := svc["ingress"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	existing, _ := ingress["annotations"].(map[string]interface{})
+	if existing == nil {
+		existing = make(map[string]interface{}, len(annotations))
+		ingress["annotations"] = existing
+	}
+	for k, v := range annotations {
+		if _, ok := existing[k]; !ok {
+			existing[k] = v
+		}
+	}
+	return true
+}
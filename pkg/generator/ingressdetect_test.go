@@ -536,3 +536,25 @@ func TestInjectIngressAnnotations_NoIngressTemplate_PreservesChart(t *testing.T)
 	}
 }
 
+
+func TestInjectIngressAnnotations_MergesIntoValues(t *testing.T) {
+	chart := makeChart("myapp", map[string]string{
+		"templates/web-ingress.yaml": "{{- $svc := .Values.services.web -}}\n{{- if $svc.enabled }}\n{{- with $svc.ingress }}\napiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: web\n{{- end }}\n{{- end }}\n",
+	})
+	chart.ValuesYAML = "services:\n  web:\n    enabled: true\n    ingress:\n      annotations:\n        nginx.ingress.kubernetes.io/ssl-redirect: \"false\"\n"
+
+	result := InjectIngressAnnotations(chart, ControllerNginx, []IngressFeature{IngressSSLRedirect, IngressCORS})
+
+	if result.Templates["templates/web-ingress.yaml"] != chart.Templates["templates/web-ingress.yaml"] {
+		t.Errorf("a values-driven Ingress template should not be changed:\n%s", result.Templates["templates/web-ingress.yaml"])
+	}
+	for _, want := range []string{
+		`nginx.ingress.kubernetes.io/ssl-redirect: "false"`,
+		`nginx.ingress.kubernetes.io/enable-cors: "true"`,
+		`nginx.ingress.kubernetes.io/cors-allow-origin: '*'`,
+	} {
+		if !strings.Contains(result.ValuesYAML, want) {
+			t.Errorf("expected %q in values:\n%s", want, result.ValuesYAML)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// InjectionPolicy adds organization-mandated labels and annotations, such as
+// the owning team, cost center or Backstage component, to every generated
+// resource. The chart renders them from the commonLabels and
+// commonAnnotations values, so they can be overridden per release.
+type InjectionPolicy struct {
+	// Labels are added through the chart's labels helper, to resources and
+	// to the pods of workloads.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the metadata of resources.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ExcludeKinds lists kinds, matched case-insensitively, that get
+	// neither.
+	ExcludeKinds []string `json:"excludeKinds,omitempty"`
+}
+
+// reservedLabelPrefixes are the label prefixes set by the chart's helpers.
+var reservedLabelPrefixes = []string{"app.kubernetes.io/", "helm.sh/"}
+
+// Validate checks that the labels and annotations are valid and that no
+// label is one the chart's helpers set.
+func (p *InjectionPolicy) Validate() error {
+	for _, key := range sortedKeys(p.Labels) {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(p.Labels[key]); len(errs) > 0 {
+			return fmt.Errorf("invalid value of label %s: %s", key, strings.Join(errs, "; "))
+		}
+		for _, prefix := range reservedLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return fmt.Errorf("label %s is set by the chart's labels helper", key)
+			}
+		}
+	}
+	for _, key := range sortedKeys(p.Annotations) {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// Values returns the commonLabels and commonAnnotations values of the
+// policy.
+func (p *InjectionPolicy) Values() map[string]interface{} {
+	values := make(map[string]interface{})
+	if len(p.Labels) > 0 {
+		values["commonLabels"] = stringMapValues(p.Labels)
+	}
+	if len(p.Annotations) > 0 {
+		values["commonAnnotations"] = stringMapValues(p.Annotations)
+	}
+	return values
+}
+
+// excludes reports whether resources of kind are left alone.
+func (p *InjectionPolicy) excludes(kind string) bool {
+	for _, k := range p.ExcludeKinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// InjectMetadata rewrites the templates of the resources for the policy:
+// resources of excluded kinds render their labels from the chartLabels
+// helper, which leaves out commonLabels, and the others render
+// commonAnnotations in their metadata. Annotations a template already
+// renders from values take precedence over commonAnnotations.
+//
+// It returns copies of the groups; the input groups are not modified.
+func InjectMetadata(groups []*types.ResourceGroup, policy *InjectionPolicy, chartName string) []*types.ResourceGroup {
+	labels := fmt.Sprintf(`include "%s.labels"`, chartName)
+	chartLabels := fmt.Sprintf(`include "%s.chartLabels"`, chartName)
+	annotations := fmt.Sprintf(`include "%s.annotations" $`, chartName)
+
+	out := make([]*types.ResourceGroup, 0, len(groups))
+	for _, group := range groups {
+		copied := *group
+		copied.Resources = make([]*types.ProcessedResource, 0, len(group.Resources))
+		for _, r := range group.Resources {
+			rc := *r
+			if policy.excludes(r.Original.Object.GetKind()) {
+				rc.TemplateContent = strings.ReplaceAll(r.TemplateContent, labels, chartLabels)
+			} else {
+				rc.TemplateContent = injectAnnotations(r.TemplateContent, annotations)
+			}
+			copied.Resources = append(copied.Resources, &rc)
+		}
+		out = append(out, &copied)
+	}
+	return out
+}
+
+// injectAnnotations adds the annotations rendered by include, a template
+// expression, to every top-level metadata block of a template.
+func injectAnnotations(template, include string) string {
+	lines := strings.Split(template, "\n")
+	out := make([]string, 0, len(lines)+4)
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		if lines[i] != "metadata:" {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && strings.HasPrefix(lines[end], "  ") {
+			end++
+		}
+		out = append(out, annotateMetadata(lines[i+1:end], include)...)
+		i = end - 1
+	}
+	return strings.Join(out, "\n")
+}
+
+// annotateMetadata returns the lines of a metadata block with the
+// annotations rendered by include added:
+//
+//   - merged under the annotations a "with" block renders from values;
+//   - added to an annotations block rendered under "if", which is then also
+//     rendered when include is not empty, and merged under the annotations
+//     it renders from values;
+//   - added to an unconditional annotations block;
+//   - in a new annotations block, when the block has none.
+func annotateMetadata(block []string, include string) []string {
+	out := append([]string(nil), block...)
+	for j, line := range out {
+		if line != "  annotations:" {
+			continue
+		}
+		var prev string
+		if j > 0 {
+			prev = strings.TrimSpace(out[j-1])
+		}
+		if mergeWith(out, j-1, j+1, "  ", include) {
+			return out
+		}
+		if strings.HasPrefix(prev, "{{- if ") {
+			cond := strings.TrimSuffix(strings.TrimPrefix(prev, "{{- if "), " }}")
+			out[j-1] = fmt.Sprintf("  {{- if or (%s) (%s) }}", cond, include)
+			if mergeWith(out, j+1, j+2, "    ", include) {
+				return out
+			}
+		}
+		added := "    {{- " + include + " | nindent 4 }}"
+		return append(out[:j+1], append([]string{added}, out[j+1:]...)...)
+	}
+	return append(out,
+		"  {{- with "+include+" }}",
+		"  annotations:",
+		"    {{- . | nindent 4 }}",
+		"  {{- end }}",
+	)
+}
+
+// mergeWith rewrites a "with" block at lines[i], indented by indent, whose
+// body renders annotations from values with toYaml at lines[body], to render
+// them merged over the annotations rendered by include. It reports whether
+// lines[i] is such a block.
+func mergeWith(lines []string, i, body int, indent, include string) bool {
+	if i < 0 || body >= len(lines) {
+		return false
+	}
+	expr, ok := strings.CutPrefix(lines[i], indent+"{{- with ")
+	if !ok || !strings.HasSuffix(expr, " }}") || strings.TrimSpace(lines[body]) != "{{- toYaml . | nindent 4 }}" {
+		return false
+	}
+	expr = strings.TrimSuffix(expr, " }}")
+	lines[i] = fmt.Sprintf("%s{{- with merge (dict) (%s | default dict) (%s | fromYaml) }}", indent, expr, include)
+	return true
+}
+
+// stringMapValues converts a string map to values.
+func stringMapValues(m map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		values[k] = v
+	}
+	return values
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestInjectAnnotations(t *testing.T) {
+	const include = `include "app.annotations" $`
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "no annotations",
+			template: "metadata:\n  name: web\n  labels:\n    {{- include \"app.labels\" $ | nindent 4 }}\nspec:\n  x: 1\n",
+			want: "metadata:\n  name: web\n  labels:\n    {{- include \"app.labels\" $ | nindent 4 }}\n" +
+				"  {{- with include \"app.annotations\" $ }}\n  annotations:\n    {{- . | nindent 4 }}\n  {{- end }}\nspec:\n  x: 1\n",
+		},
+		{
+			name:     "annotations from values",
+			template: "metadata:\n  name: web\n  {{- with .annotations }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\nspec: {}\n",
+			want:     "metadata:\n  name: web\n  {{- with merge (dict) (.annotations | default dict) (include \"app.annotations\" $ | fromYaml) }}\n  annotations:\n    {{- toYaml . | nindent 4 }}\n  {{- end }}\nspec: {}\n",
+		},
+		{
+			name:     "conditional annotations",
+			template: "metadata:\n  {{- if or .annotations .certManager }}\n  annotations:\n    {{- with .annotations }}\n    {{- toYaml . | nindent 4 }}\n    {{- end }}\n  {{- end }}\n",
+			want:     "metadata:\n  {{- if or (or .annotations .certManager) (include \"app.annotations\" $) }}\n  annotations:\n    {{- with merge (dict) (.annotations | default dict) (include \"app.annotations\" $ | fromYaml) }}\n    {{- toYaml . | nindent 4 }}\n    {{- end }}\n  {{- end }}\n",
+		},
+		{
+			name:     "literal annotations",
+			template: "metadata:\n  annotations:\n    helm.sh/hook: pre-install\n---\nmetadata:\n  name: b\n",
+			want: "metadata:\n  annotations:\n    {{- include \"app.annotations\" $ | nindent 4 }}\n    helm.sh/hook: pre-install\n---\n" +
+				"metadata:\n  name: b\n  {{- with include \"app.annotations\" $ }}\n  annotations:\n    {{- . | nindent 4 }}\n  {{- end }}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := injectAnnotations(tt.template, include); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInjectMetadata(t *testing.T) {
+	const labelsLine = "  labels:\n    {{- include \"app.labels\" $ | nindent 4 }}\n"
+	deploy := makeProcessedResourceWithValues("Deployment", "web", "prod", nil, nil, "metadata:\n  name: web\n"+labelsLine)
+	crd := makeProcessedResourceWithValues("CustomResourceDefinition", "widgets", "", nil, nil, "metadata:\n  name: widgets\n"+labelsLine)
+	groups := []*types.ResourceGroup{{Name: "web", Resources: []*types.ProcessedResource{deploy, crd}}}
+
+	out := InjectMetadata(groups, &InjectionPolicy{ExcludeKinds: []string{"customresourcedefinition"}}, "app")
+	if got := out[0].Resources[0].TemplateContent; !strings.Contains(got, `include "app.labels"`) || !strings.Contains(got, `include "app.annotations" $`) {
+		t.Errorf("expected the Deployment to render commonLabels and commonAnnotations:\n%s", got)
+	}
+	if got := out[0].Resources[1].TemplateContent; !strings.Contains(got, `include "app.chartLabels"`) || strings.Contains(got, "annotations") {
+		t.Errorf("expected the excluded CRD to render only the chart labels:\n%s", got)
+	}
+	if groups[0].Resources[0].TemplateContent != "metadata:\n  name: web\n"+labelsLine {
+		t.Error("input groups were modified")
+	}
+}
+
+func TestInjectionPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  InjectionPolicy
+		wantErr string
+	}{
+		{"valid", InjectionPolicy{Labels: map[string]string{"team": "payments", "example.com/cost-center": "cc-42"}, Annotations: map[string]string{"backstage.io/kubernetes-id": "shop"}}, ""},
+		{"reserved label", InjectionPolicy{Labels: map[string]string{"app.kubernetes.io/part-of": "shop"}}, "set by the chart's labels helper"},
+		{"invalid label value", InjectionPolicy{Labels: map[string]string{"team": "Payments Team"}}, "invalid value of label team"},
+		{"invalid annotation", InjectionPolicy{Annotations: map[string]string{"bad key": "x"}}, "invalid annotation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
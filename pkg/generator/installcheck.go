@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// InstallAction is what installing or upgrading a chart would do with one of
+// its resources.
+type InstallAction string
+
+const (
+	// InstallCreate: the object does not exist and would be created.
+	InstallCreate InstallAction = "create"
+
+	// InstallUpdate: the live object would be changed.
+	InstallUpdate InstallAction = "update"
+
+	// InstallUnchanged: the live object would not be changed.
+	InstallUnchanged InstallAction = "unchanged"
+
+	// InstallRejected: the API server would reject the object.
+	InstallRejected InstallAction = "rejected"
+
+	// InstallSkipped: the object could not be checked, e.g. a custom
+	// resource whose CRD the chart installs.
+	InstallSkipped InstallAction = "skipped"
+)
+
+// Rejection reasons recognised in API server errors.
+const (
+	RejectAdmissionWebhook = "admission webhook"
+	RejectAdmissionPolicy  = "admission policy"
+	RejectImmutableField   = "immutable field"
+	RejectInvalid          = "invalid"
+	RejectForbidden        = "forbidden"
+)
+
+// InstallResult is the outcome of a dry-run install of a chart resource.
+type InstallResult struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Action    InstallAction
+
+	// Reason classifies a rejection, e.g. RejectImmutableField, or explains
+	// a skip. It is empty when the error is not recognised.
+	Reason string
+
+	// Err is the error of the API server for a rejected object.
+	Err error
+}
+
+// String returns Kind namespace/name, or Kind name for cluster-scoped
+// resources.
+func (r InstallResult) String() string {
+	if r.Namespace == "" {
+		return r.Kind + " " + r.Name
+	}
+	return r.Kind + " " + r.Namespace + "/" + r.Name
+}
+
+// CheckInstallObject classifies the server-side dry-run apply of a chart
+// resource: live is nil for an object that does not exist, and applyErr is
+// the error of the API server. Unlike CompareLiveObject it does not diff the
+// objects, it only tells whether the install would change them.
+func CheckInstallObject(desired, live, applied *unstructured.Unstructured, applyErr error) InstallResult {
+	r := InstallResult{Kind: desired.GetKind(), Namespace: desired.GetNamespace(), Name: desired.GetName()}
+	if applyErr != nil {
+		r.Action, r.Reason, r.Err = InstallRejected, RejectionReason(applyErr), applyErr
+		return r
+	}
+	if live == nil {
+		r.Action = InstallCreate
+		return r
+	}
+	switch d := CompareLiveObject(desired, live, applied, nil); d.State {
+	case ClusterInSync:
+		r.Action = InstallUnchanged
+	case ClusterFailed:
+		r.Action, r.Err = InstallRejected, d.Err
+	default:
+		r.Action = InstallUpdate
+	}
+	return r
+}
+
+// RejectionReason classifies an API server error by its message, the only
+// place the API server reports which check rejected a request.
+func RejectionReason(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "admission webhook"):
+		return RejectAdmissionWebhook
+	case strings.Contains(msg, "ValidatingAdmissionPolicy"):
+		return RejectAdmissionPolicy
+	case strings.Contains(msg, "field is immutable"):
+		return RejectImmutableField
+	case strings.Contains(msg, " is forbidden"):
+		return RejectForbidden
+	case strings.Contains(msg, " is invalid"):
+		return RejectInvalid
+	}
+	return ""
+}
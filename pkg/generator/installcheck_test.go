@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckInstallObject(t *testing.T) {
+	configMap := func(value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "app", "namespace": "prod"},
+			"data":       map[string]interface{}{"key": value},
+		}}
+	}
+	desired := configMap("new")
+
+	tests := []struct {
+		name          string
+		live, applied *unstructured.Unstructured
+		err           error
+		action        InstallAction
+		reason        string
+	}{
+		{name: "create", applied: configMap("new"), action: InstallCreate},
+		{name: "update", live: configMap("old"), applied: configMap("new"), action: InstallUpdate},
+		{name: "unchanged", live: configMap("new"), applied: configMap("new"), action: InstallUnchanged},
+		{
+			name:   "webhook",
+			err:    errors.New(`admission webhook "validate.kyverno.svc" denied the request: image tag latest is not allowed`),
+			action: InstallRejected, reason: RejectAdmissionWebhook,
+		},
+		{
+			name:   "admission policy",
+			err:    errors.New(`configmaps "app" is forbidden: ValidatingAdmissionPolicy 'labels' with binding 'labels' denied request`),
+			action: InstallRejected, reason: RejectAdmissionPolicy,
+		},
+		{
+			name:   "immutable",
+			err:    errors.New(`Deployment.apps "app" is invalid: spec.selector: Invalid value: {}: field is immutable`),
+			action: InstallRejected, reason: RejectImmutableField,
+		},
+		{name: "invalid", err: errors.New(`ConfigMap "app" is invalid: data: Invalid value`), action: InstallRejected, reason: RejectInvalid},
+		{name: "unknown", err: errors.New("connection refused"), action: InstallRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := CheckInstallObject(desired, tt.live, tt.applied, tt.err)
+			if r.Action != tt.action || r.Reason != tt.reason {
+				t.Errorf("got %s (%q), want %s (%q)", r.Action, r.Reason, tt.action, tt.reason)
+			}
+			if r.String() != "ConfigMap prod/app" {
+				t.Errorf("String() = %q", r.String())
+			}
+		})
+	}
+}
@@ -50,10 +50,10 @@ func buildCanaryVirtualServiceYAML(opts IstioCanaryOptions) string {
 	// Header-based routing block (takes priority, routes to canary)
 	if len(opts.HeaderRouting) > 0 {
 		sb.WriteString("  - match:\n")
-		for headerName, headerValue := range opts.HeaderRouting {
+		for _, headerName := range sortedKeys(opts.HeaderRouting) {
 			sb.WriteString("    - headers:\n")
 			fmt.Fprintf(&sb, "        %s:\n", headerName)
-			fmt.Fprintf(&sb, "          exact: %s\n", headerValue)
+			fmt.Fprintf(&sb, "          exact: %s\n", opts.HeaderRouting[headerName])
 		}
 		sb.WriteString("    route:\n")
 		sb.WriteString("    - destination:\n")
@@ -32,7 +32,7 @@ func GenerateIstioTraffic(graph *types.ResourceGraph, opts IstioTrafficOptions)
 		return result
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r == nil || r.Original == nil || r.Original.GVK.Kind != "Service" {
 			continue
 		}
@@ -167,8 +167,8 @@ func ValidateK8sVersionMatrix(chart *types.GeneratedChart, opts K8sVersionOption
 		kind       string
 	}
 	var resources []resourceRef
-	for path, content := range chart.Templates {
-		av, kind := extractAPIVersionAndKind(content)
+	for _, path := range sortedKeys(chart.Templates) {
+		av, kind := extractAPIVersionAndKind(chart.Templates[path])
 		if av == "" {
 			continue
 		}
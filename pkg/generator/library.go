@@ -3,9 +3,11 @@ package generator
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -22,24 +24,72 @@ func NewLibraryGenerator() *LibraryGenerator {
 	}
 }
 
+// libraryTemplate is a named template of the library chart rendering a
+// resource kind.
+type libraryTemplate struct {
+	// Kind is the resource kind.
+	Kind string
+
+	// Name is the template name after the library chart name: <library>.<Name>.
+	Name string
+
+	// Body is the template body, written for a library chart named "library".
+	Body string
+}
+
+// libraryTemplates are the named templates of the library chart. Each takes a
+// dict of "context" (the root context of the including chart), "values" (the
+// values of the resource, with the keys the generator extracts for its kind)
+// and "name" (the default resource name).
+var libraryTemplates = []libraryTemplate{
+	{Kind: "Deployment", Name: "deployment", Body: deploymentTemplate},
+	{Kind: "StatefulSet", Name: "statefulset", Body: statefulsetTemplate},
+	{Kind: "DaemonSet", Name: "daemonset", Body: daemonsetTemplate},
+	{Kind: "Service", Name: "service", Body: serviceTemplate},
+	{Kind: "Ingress", Name: "ingress", Body: ingressTemplate},
+	{Kind: "ConfigMap", Name: "configmap", Body: configmapTemplate},
+	{Kind: "Secret", Name: "secret", Body: secretTemplate},
+	{Kind: "PersistentVolumeClaim", Name: "pvc", Body: pvcTemplate},
+	{Kind: "HorizontalPodAutoscaler", Name: "hpa", Body: hpaTemplate},
+	{Kind: "PodDisruptionBudget", Name: "pdb", Body: pdbTemplate},
+	{Kind: "NetworkPolicy", Name: "networkpolicy", Body: networkpolicyTemplate},
+	{Kind: "CronJob", Name: "cronjob", Body: cronjobTemplate},
+	{Kind: "Job", Name: "job", Body: jobTemplate},
+	{Kind: "ServiceAccount", Name: "serviceaccount", Body: serviceaccountTemplate},
+	{Kind: "Role", Name: "role", Body: roleTemplate},
+	{Kind: "ClusterRole", Name: "clusterrole", Body: clusterroleTemplate},
+	{Kind: "RoleBinding", Name: "rolebinding", Body: rolebindingTemplate},
+	{Kind: "ClusterRoleBinding", Name: "clusterrolebinding", Body: clusterrolebindingTemplate},
+}
+
+// findLibraryTemplate returns the library template rendering kind.
+func findLibraryTemplate(kind string) (libraryTemplate, bool) {
+	for _, t := range libraryTemplates {
+		if t.Kind == kind {
+			return t, true
+		}
+	}
+	return libraryTemplate{}, false
+}
+
 // Generate creates a library chart and wrapper charts from the resource graph.
 func (g *LibraryGenerator) Generate(ctx context.Context, graph *types.ResourceGraph, opts Options) ([]*types.GeneratedChart, error) {
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
-	charts := make([]*types.GeneratedChart, 0)
-
-	// Always generate the library chart with all named templates.
-	libChart := g.generateLibraryChart(opts)
-	charts = append(charts, libChart)
-
-	// Group resources and generate wrapper charts.
+	// Group resources for the wrapper charts.
 	groupResult, err := GroupResources(graph)
 	if err != nil {
 		return nil, fmt.Errorf("failed to group resources: %w", err)
 	}
 
+	charts := make([]*types.GeneratedChart, 0, len(groupResult.Groups)+1)
+
+	// Always generate the library chart with all named templates.
+	libChart := g.generateLibraryChart(libraryChartName(opts.ChartName, groupResult.Groups), opts)
+	charts = append(charts, libChart)
+
 	for _, group := range groupResult.Groups {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
@@ -51,10 +101,22 @@ func (g *LibraryGenerator) Generate(ctx context.Context, graph *types.ResourceGr
 	return charts, nil
 }
 
-// generateLibraryChart creates the base library chart with named templates for all K8s types.
-func (g *LibraryGenerator) generateLibraryChart(opts Options) *types.GeneratedChart {
-	chartName := "library"
+// libraryChartName returns the name of the library chart: the chart name, with
+// a -lib suffix when a wrapper chart has the same name.
+func libraryChartName(chartName string, groups []*ServiceGroup) string {
+	if chartName == "" {
+		return "library"
+	}
+	for _, group := range groups {
+		if group.Name == chartName {
+			return chartName + "-lib"
+		}
+	}
+	return chartName
+}
 
+// generateLibraryChart creates the base library chart with named templates for all K8s types.
+func (g *LibraryGenerator) generateLibraryChart(chartName string, opts Options) *types.GeneratedChart {
 	chartMeta := helm.ChartMetadata{
 		Name:        chartName,
 		Version:     opts.ChartVersion,
@@ -68,39 +130,27 @@ func (g *LibraryGenerator) generateLibraryChart(opts Options) *types.GeneratedCh
 	templates := make(map[string]string)
 
 	// Generate named templates for all 18 supported K8s resource types.
-	templates["templates/_deployment.tpl"] = generateNamedTemplate("deployment", deploymentTemplate)
-	templates["templates/_statefulset.tpl"] = generateNamedTemplate("statefulset", statefulsetTemplate)
-	templates["templates/_daemonset.tpl"] = generateNamedTemplate("daemonset", daemonsetTemplate)
-	templates["templates/_service.tpl"] = generateNamedTemplate("service", serviceTemplate)
-	templates["templates/_ingress.tpl"] = generateNamedTemplate("ingress", ingressTemplate)
-	templates["templates/_configmap.tpl"] = generateNamedTemplate("configmap", configmapTemplate)
-	templates["templates/_secret.tpl"] = generateNamedTemplate("secret", secretTemplate)
-	templates["templates/_pvc.tpl"] = generateNamedTemplate("pvc", pvcTemplate)
-	templates["templates/_hpa.tpl"] = generateNamedTemplate("hpa", hpaTemplate)
-	templates["templates/_pdb.tpl"] = generateNamedTemplate("pdb", pdbTemplate)
-	templates["templates/_networkpolicy.tpl"] = generateNamedTemplate("networkpolicy", networkpolicyTemplate)
-	templates["templates/_cronjob.tpl"] = generateNamedTemplate("cronjob", cronjobTemplate)
-	templates["templates/_job.tpl"] = generateNamedTemplate("job", jobTemplate)
-	templates["templates/_serviceaccount.tpl"] = generateNamedTemplate("serviceaccount", serviceaccountTemplate)
-	templates["templates/_role.tpl"] = generateNamedTemplate("role", roleTemplate)
-	templates["templates/_clusterrole.tpl"] = generateNamedTemplate("clusterrole", clusterroleTemplate)
-	templates["templates/_rolebinding.tpl"] = generateNamedTemplate("rolebinding", rolebindingTemplate)
-	templates["templates/_clusterrolebinding.tpl"] = generateNamedTemplate("clusterrolebinding", clusterrolebindingTemplate)
-
-	// Add DRY shared sub-templates (resources, probes, securityContext, env, volumeMounts, volumes, annotations).
-	addSharedSubTemplates(templates)
+	for _, t := range libraryTemplates {
+		templates["templates/_"+t.Name+".tpl"] = generateKindTemplate(chartName, t)
+	}
+
+	// Add DRY shared sub-templates (metadata, pod spec, containers, resources, probes, env, volumes, ...).
+	addSharedSubTemplates(templates, chartName)
 
 	return &types.GeneratedChart{
-		Name:       chartName,
-		Path:       opts.OutputDir,
-		ChartYAML:  helm.GenerateChartYAML(chartMeta),
-		ValuesYAML: "# Library charts do not have values.yaml\n# Values are provided by wrapper charts\n",
-		Templates:  templates,
-		Helpers:    helm.GenerateHelpers(chartName),
+		Name:          chartName,
+		Path:          opts.OutputDir,
+		ChartYAML:     helm.GenerateChartYAML(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata)),
+		ValuesYAML:    "# Library charts do not have values.yaml\n# Values are provided by wrapper charts\n",
+		Templates:     templates,
+		Helpers:       helm.GenerateHelpers(chartName),
+		ExternalFiles: generateLibraryExample(chartName, opts),
 	}
 }
 
-// generateWrapperChart creates a thin wrapper chart for a service group.
+// generateWrapperChart creates a thin wrapper chart for a service group. Each
+// resource of a kind the library renders is an include of its named template
+// with the resource values; other resources keep their templates.
 func (g *LibraryGenerator) generateWrapperChart(group *ServiceGroup, libraryName string, opts Options) *types.GeneratedChart {
 	chartName := group.Name
 
@@ -120,16 +170,39 @@ func (g *LibraryGenerator) generateWrapperChart(group *ServiceGroup, libraryName
 		},
 	}
 
-	// Build wrapper templates that call library includes.
-	templates := make(map[string]string)
-	kindsUsed := make(map[string]bool)
+	// Build wrapper templates that call library includes, one file per kind.
+	resourcesByKind := make(map[string][]*types.ProcessedResource)
 	for _, resource := range group.Resources {
-		kind := strings.ToLower(resource.Original.GVK.Kind)
-		if !kindsUsed[kind] {
-			kindsUsed[kind] = true
-			tmplName := fmt.Sprintf("templates/%s.yaml", kind)
-			templates[tmplName] = generateWrapperTemplate(libraryName, kind)
+		kind := processor.ValuesKind(resource.Original.GVK, resource.Original.Object)
+		resourcesByKind[kind] = append(resourcesByKind[kind], resource)
+	}
+
+	templates := make(map[string]string)
+	for kind, resources := range resourcesByKind {
+		t, ok := findLibraryTemplate(kind)
+		if !ok {
+			for _, resource := range resources {
+				if resource.TemplatePath != "" && resource.TemplateContent != "" {
+					content := rewriteTemplateForSeparateMode(resource.TemplateContent, resource.ServiceName)
+					templates[resource.TemplatePath] = renameHelperIncludes(content, opts.ChartName, chartName)
+				}
+			}
+			continue
 		}
+
+		sort.Slice(resources, func(i, j int) bool {
+			return resources[i].Original.Object.GetName() < resources[j].Original.Object.GetName()
+		})
+		includes := make([]string, 0, len(resources))
+		for _, resource := range resources {
+			name := resource.Original.Object.GetName()
+			valuesPath := ".Values." + processor.ValuesKey(kind)
+			if processor.ValuesByName(kind) || len(resources) > 1 {
+				valuesPath = fmt.Sprintf("(index .Values.%s %q)", processor.PluralValuesKey(kind), processor.SanitizeValuesKey(name))
+			}
+			includes = append(includes, generateWrapperInclude(libraryName, t.Name, valuesPath, name))
+		}
+		templates[fmt.Sprintf("templates/%s.yaml", strings.ToLower(kind))] = strings.Join(includes, "---\n")
 	}
 
 	// Build flat values for this service.
@@ -143,381 +216,551 @@ func (g *LibraryGenerator) generateWrapperChart(group *ServiceGroup, libraryName
 	return &types.GeneratedChart{
 		Name:       chartName,
 		Path:       opts.OutputDir,
-		ChartYAML:  helm.GenerateChartYAML(chartMeta),
+		ChartYAML:  helm.GenerateChartYAML(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata)),
 		ValuesYAML: valuesYAML,
 		Templates:  templates,
 		Helpers:    helm.GenerateHelpers(chartName),
+		Notes:      helm.GenerateNOTES(chartName, []string{chartName}, notesContext(group.Resources, notesNaming{})),
 	}
 }
 
-// generateNamedTemplate wraps template content in a named define block.
-func generateNamedTemplate(kind, body string) string {
-	return fmt.Sprintf(`{{- define "library.%s" -}}
+// generateNamedTemplate wraps template content in a named define block of the
+// library chart, renaming the library templates the body includes.
+func generateNamedTemplate(libraryName, name, body string) string {
+	body = strings.ReplaceAll(body, `"library.`, `"`+libraryName+`.`)
+	return fmt.Sprintf(`{{- define "%s.%s" -}}
 %s
 {{- end -}}
-`, kind, body)
+`, libraryName, name, body)
+}
+
+// generateKindTemplate returns the named template rendering a resource kind,
+// documented with its usage. Resources whose values have enabled: false are
+// not rendered.
+func generateKindTemplate(libraryName string, t libraryTemplate) string {
+	return fmt.Sprintf(`{{/*
+%[1]s.%[2]s renders a %[3]s.
+Usage: {{ include "%[1]s.%[2]s" (dict "context" $ "values" <values> "name" <name>) }}
+<values> has the keys of the %[3]s values extracted by the generator.
+*/}}
+`, libraryName, t.Name, t.Kind) + generateNamedTemplate(libraryName, t.Name,
+		"{{- if dig \"enabled\" true .values }}\n"+t.Body+"\n{{- end }}")
 }
 
-// generateWrapperTemplate creates a template that calls a library include.
-func generateWrapperTemplate(libraryName, kind string) string {
-	return fmt.Sprintf(`{{- include "library.%s" (dict "context" . "values" .Values) -}}
-`, kind)
+// generateWrapperInclude creates a template that renders a resource with a
+// library include of its values.
+func generateWrapperInclude(libraryName, templateName, valuesPath, name string) string {
+	return fmt.Sprintf(`{{ include "%s.%s" (dict "context" $ "values" (%s | default dict) "name" %q) }}
+`, libraryName, templateName, valuesPath, name)
+}
+
+// libraryExampleDir is the directory of the example consumer chart in the
+// library chart.
+const libraryExampleDir = "examples/consumer"
+
+// generateLibraryExample returns the files of an example application chart
+// consuming the library chart: a Deployment, Service and ConfigMap rendered
+// by library includes from values following the library contract.
+func generateLibraryExample(libraryName string, opts Options) []types.ExternalFileInfo {
+	version := opts.ChartVersion
+	if version == "" {
+		version = "0.1.0"
+	}
+	chartYAML := helm.GenerateChartYAML(helm.ChartMetadata{
+		Name:        libraryName + "-example",
+		Version:     "0.1.0",
+		Description: fmt.Sprintf("Example application chart using the %s library chart", libraryName),
+		APIVersion:  "v2",
+		Type:        "application",
+		Dependencies: []helm.Dependency{
+			{
+				Name:       libraryName,
+				Version:    version,
+				Repository: "file://../..",
+			},
+		},
+	})
+
+	values := `# Values of each resource follow the contract of the library templates: the
+# keys the generator extracts for the resource kind. Set enabled: false to skip
+# a resource.
+deployment:
+  replicas: 2
+  containers:
+    - name: app
+      image:
+        repository: nginx
+        tag: "1.25"
+      ports:
+        - name: http
+          containerPort: 80
+      envFrom:
+        - configMapRef:
+            name: settings
+      resources:
+        requests:
+          cpu: 100m
+          memory: 128Mi
+
+service:
+  ports:
+    - name: http
+      port: 80
+      targetPort: http
+
+configMaps:
+  settings:
+    data:
+      LOG_LEVEL: info
+`
+
+	templates := fmt.Sprintf(`{{ include "%[1]s.deployment" (dict "context" $ "values" .Values.deployment) }}
+---
+{{ include "%[1]s.service" (dict "context" $ "values" .Values.service) }}
+---
+{{ include "%[1]s.configmap" (dict "context" $ "values" .Values.configMaps.settings "name" "settings") }}
+`, libraryName)
+
+	return []types.ExternalFileInfo{
+		{Path: libraryExampleDir + "/Chart.yaml", Content: chartYAML},
+		{Path: libraryExampleDir + "/values.yaml", Content: values},
+		{Path: libraryExampleDir + "/templates/app.yaml", Content: templates},
+	}
 }
 
 // ============================================================
 // Named template bodies for all 18 K8s resource types
 // ============================================================
 
-const deploymentTemplate = `apiVersion: apps/v1
+var deploymentTemplate = `apiVersion: apps/v1
 kind: Deployment
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
-  replicas: {{ .values.replicaCount | default 1 }}
+  replicas: {{ .values.replicas | default 1 }}
   selector:
-    matchLabels:
-      {{- include "library.selectorLabels" .context | nindent 6 }}
+    {{- include "library.selector" . | nindent 4 }}
+  {{- with .values.strategy }}
+  strategy:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
   template:
-    metadata:
-      labels:
-        {{- include "library.selectorLabels" .context | nindent 8 }}
-    spec:
-      containers:
-        - name: {{ .values.name | default .context.Chart.Name }}
-          {{- $dvals := .values | toJson | fromJson }}
-          image: "{{ dig "image" "repository" "nginx" $dvals }}:{{ dig "image" "tag" "latest" $dvals }}"
-          ports:
-            {{- range .values.ports | default (list (dict "containerPort" 80)) }}
-            - containerPort: {{ .containerPort }}
-            {{- end }}
-          {{- include "library.env" . | nindent 10 }}
-          {{- include "library.resources" . | nindent 10 }}
-          {{- include "library.probes" . | nindent 10 }}
-          {{- include "library.volumeMounts" . | nindent 10 }}
-      {{- include "library.volumes" . | nindent 6 }}`
-
-const statefulsetTemplate = `apiVersion: apps/v1
+    {{- include "library.podTemplate" . | nindent 4 }}
+` + podContainersTemplate(6)
+
+var statefulsetTemplate = `apiVersion: apps/v1
 kind: StatefulSet
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
-  replicas: {{ .values.replicaCount | default 1 }}
-  serviceName: {{ .values.serviceName | default (include "library.fullname" .context) }}
+  replicas: {{ .values.replicas | default 1 }}
+  serviceName: {{ .values.serviceName | default .name | default (include "library.fullname" .context) }}
   selector:
-    matchLabels:
-      {{- include "library.selectorLabels" .context | nindent 6 }}
+    {{- include "library.selector" . | nindent 4 }}
+  {{- with .values.podManagementPolicy }}
+  podManagementPolicy: {{ . }}
+  {{- end }}
+  {{- with .values.updateStrategy }}
+  updateStrategy:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
   template:
-    metadata:
-      labels:
-        {{- include "library.selectorLabels" .context | nindent 8 }}
-    spec:
-      containers:
-        - name: {{ .values.name | default .context.Chart.Name }}
-          {{- $dvals := .values | toJson | fromJson }}
-          image: "{{ dig "image" "repository" "nginx" $dvals }}:{{ dig "image" "tag" "latest" $dvals }}"
-          {{- include "library.env" . | nindent 10 }}
-          {{- include "library.resources" . | nindent 10 }}
-          {{- include "library.probes" . | nindent 10 }}
-          {{- include "library.volumeMounts" . | nindent 10 }}
-      {{- include "library.volumes" . | nindent 6 }}`
-
-const daemonsetTemplate = `apiVersion: apps/v1
+    {{- include "library.podTemplate" . | nindent 4 }}
+` + podContainersTemplate(6) + `
+  {{- with .values.persistence }}
+  volumeClaimTemplates:
+    {{- range $name, $claim := . }}
+    - metadata:
+        name: {{ $name }}
+        {{- with $claim.labels }}
+        labels:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        {{- with $claim.annotations }}
+        annotations:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+      spec:
+        accessModes:
+          {{- toYaml ($claim.accessModes | default (list "ReadWriteOnce")) | nindent 10 }}
+        {{- if hasKey $claim "storageClassName" }}
+        storageClassName: {{ $claim.storageClassName | quote }}
+        {{- end }}
+        {{- with $claim.volumeMode }}
+        volumeMode: {{ . }}
+        {{- end }}
+        {{- with $claim.selector }}
+        selector:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        {{- with $claim.dataSource }}
+        dataSource:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        {{- with $claim.dataSourceRef }}
+        dataSourceRef:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        resources:
+          requests:
+            storage: {{ $claim.size | quote }}
+          {{- with $claim.sizeLimit }}
+          limits:
+            storage: {{ . | quote }}
+          {{- end }}
+    {{- end }}
+  {{- end }}`
+
+var daemonsetTemplate = `apiVersion: apps/v1
 kind: DaemonSet
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
   selector:
-    matchLabels:
-      {{- include "library.selectorLabels" .context | nindent 6 }}
+    {{- include "library.selector" . | nindent 4 }}
+  {{- with .values.updateStrategy }}
+  updateStrategy:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
   template:
-    metadata:
-      labels:
-        {{- include "library.selectorLabels" .context | nindent 8 }}
-    spec:
-      containers:
-        - name: {{ .values.name | default .context.Chart.Name }}
-          {{- $dvals := .values | toJson | fromJson }}
-          image: "{{ dig "image" "repository" "nginx" $dvals }}:{{ dig "image" "tag" "latest" $dvals }}"
-          {{- include "library.env" . | nindent 10 }}
-          {{- include "library.resources" . | nindent 10 }}
-          {{- include "library.volumeMounts" . | nindent 10 }}
-      {{- include "library.volumes" . | nindent 6 }}`
+    {{- include "library.podTemplate" . | nindent 4 }}
+` + podContainersTemplate(6)
 
 const serviceTemplate = `apiVersion: v1
 kind: Service
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
   type: {{ .values.type | default "ClusterIP" }}
+  {{- with .values.clusterIP }}
+  clusterIP: {{ . }}
+  {{- end }}
+  {{- with .values.externalName }}
+  externalName: {{ . }}
+  {{- end }}
+  {{- with .values.externalIPs }}
+  externalIPs:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .values.externalTrafficPolicy }}
+  externalTrafficPolicy: {{ . }}
+  {{- end }}
+  {{- with .values.sessionAffinity }}
+  sessionAffinity: {{ . }}
+  {{- end }}
+  {{- with .values.loadBalancerSourceRanges }}
+  loadBalancerSourceRanges:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- if ne (.values.type | default "ClusterIP") "ExternalName" }}
   selector:
+    {{- if .values.selector }}
+    {{- toYaml .values.selector | nindent 4 }}
+    {{- else }}
     {{- include "library.selectorLabels" .context | nindent 4 }}
+    {{- end }}
+  {{- end }}
   ports:
-    {{- range .values.ports | default (list (dict "port" 80 "targetPort" 80 "protocol" "TCP" "name" "http")) }}
-    - port: {{ .port }}
-      targetPort: {{ .targetPort }}
-      protocol: {{ .protocol | default "TCP" }}
-      name: {{ .name | default "http" }}
-    {{- end }}`
+    {{- toYaml (.values.ports | default (list (dict "name" "http" "port" 80 "targetPort" 80 "protocol" "TCP"))) | nindent 4 }}`
 
 const ingressTemplate = `apiVersion: networking.k8s.io/v1
 kind: Ingress
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
+  name: {{ .values.name | default .name | default (include "library.fullname" .context) }}
+  namespace: {{ .context.Release.Namespace }}
   labels:
     {{- include "library.labels" .context | nindent 4 }}
-  {{- if .values.annotations }}
+  {{- if or .values.annotations .values.certManager }}
   annotations:
-    {{- toYaml .values.annotations | nindent 4 }}
+    {{- with .values.annotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with .values.certManager }}
+    {{- with .clusterIssuer }}
+    cert-manager.io/cluster-issuer: {{ . | quote }}
+    {{- end }}
+    {{- with .issuer }}
+    cert-manager.io/issuer: {{ . | quote }}
+    {{- end }}
+    {{- end }}
   {{- end }}
 spec:
-  {{- if .values.ingressClassName }}
-  ingressClassName: {{ .values.ingressClassName }}
+  {{- with .values.className }}
+  ingressClassName: {{ . }}
   {{- end }}
-  {{- if .values.tls }}
+  {{- with .values.tls }}
   tls:
-    {{- toYaml .values.tls | nindent 4 }}
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .values.defaultBackend }}
+  defaultBackend:
+    service:
+      name: {{ .service.name }}
+      port:
+        {{- if .service.portName }}
+        name: {{ .service.portName }}
+        {{- else }}
+        number: {{ .service.port }}
+        {{- end }}
   {{- end }}
   rules:
-    {{- range .values.rules | default (list) }}
-    - host: {{ .host }}
-      http:
+    {{- range .values.hosts | default .values.rules }}
+    - http:
         paths:
-          {{- range .paths | default (list) }}
+          {{- range .paths }}
           - path: {{ .path | default "/" }}
             pathType: {{ .pathType | default "Prefix" }}
             backend:
               service:
-                name: {{ .serviceName | default (include "library.fullname" $.context) }}
+                name: {{ .service.name | default (include "library.fullname" $.context) }}
                 port:
-                  number: {{ .servicePort | default 80 }}
+                  {{- if .service.portName }}
+                  name: {{ .service.portName }}
+                  {{- else }}
+                  number: {{ .service.port | default 80 }}
+                  {{- end }}
           {{- end }}
+      {{- with .host }}
+      host: {{ . | quote }}
+      {{- end }}
     {{- end }}`
 
 const configmapTemplate = `apiVersion: v1
 kind: ConfigMap
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
-{{- if .values.data }}
+  {{- include "library.metadata" . | nindent 2 }}
+{{- with .values.immutable }}
+immutable: {{ . }}
+{{- end }}
+{{- with .values.data }}
 data:
-  {{- range $key, $value := .values.data }}
-  {{ $key }}: {{ $value | quote }}
-  {{- end }}
+  {{- include "library.data" (dict "context" $.context "values" .) | trim | nindent 2 }}
+{{- end }}
+{{- with .values.binaryData }}
+binaryData:
+  {{- toYaml . | nindent 2 }}
 {{- end }}`
 
+// secretTemplate renders data as given: the generator extracts Secret data
+// base64-encoded.
 const secretTemplate = `apiVersion: v1
 kind: Secret
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 type: {{ .values.type | default "Opaque" }}
-{{- if .values.data }}
+{{- with .values.immutable }}
+immutable: {{ . }}
+{{- end }}
+{{- with .values.data }}
 data:
-  {{- range $key, $value := .values.data }}
-  {{ $key }}: {{ $value | b64enc | quote }}
-  {{- end }}
+  {{- include "library.data" (dict "context" $.context "values" .) | trim | nindent 2 }}
+{{- end }}
+{{- with .values.stringData }}
+stringData:
+  {{- include "library.data" (dict "context" $.context "values" .) | trim | nindent 2 }}
 {{- end }}`
 
 const pvcTemplate = `apiVersion: v1
 kind: PersistentVolumeClaim
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
   accessModes:
-    {{- range .values.accessModes | default (list "ReadWriteOnce") }}
-    - {{ . }}
-    {{- end }}
+    {{- toYaml (.values.accessModes | default (list "ReadWriteOnce")) | nindent 4 }}
+  {{- with .values.storageClassName }}
+  storageClassName: {{ . }}
+  {{- end }}
+  {{- with .values.volumeMode }}
+  volumeMode: {{ . }}
+  {{- end }}
+  {{- with .values.dataSource }}
+  dataSource:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .values.selector }}
+  selector:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
   resources:
-    requests:
-      storage: {{ .values.size | default "1Gi" }}
-  {{- if .values.storageClassName }}
-  storageClassName: {{ .values.storageClassName }}
-  {{- end }}`
+    {{- toYaml (.values.resources | default (dict "requests" (dict "storage" "1Gi"))) | nindent 4 }}`
 
 const hpaTemplate = `apiVersion: autoscaling/v2
 kind: HorizontalPodAutoscaler
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
   scaleTargetRef:
-    apiVersion: apps/v1
-    kind: {{ .values.targetKind | default "Deployment" }}
-    name: {{ .values.targetName | default (include "library.fullname" .context) }}
+    {{- toYaml (.values.scaleTargetRef | default (dict "apiVersion" "apps/v1" "kind" "Deployment" "name" (.name | default (include "library.fullname" .context)))) | nindent 4 }}
   minReplicas: {{ .values.minReplicas | default 1 }}
   maxReplicas: {{ .values.maxReplicas | default 10 }}
+  {{- with .values.metrics }}
   metrics:
-    {{- if .values.metrics }}
-    {{- toYaml .values.metrics | nindent 4 }}
-    {{- end }}`
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .values.behavior }}
+  behavior:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}`
 
 const pdbTemplate = `apiVersion: policy/v1
 kind: PodDisruptionBudget
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
-  {{- if .values.minAvailable }}
+  {{- if hasKey .values "minAvailable" }}
   minAvailable: {{ .values.minAvailable }}
   {{- end }}
-  {{- if .values.maxUnavailable }}
+  {{- if hasKey .values "maxUnavailable" }}
   maxUnavailable: {{ .values.maxUnavailable }}
   {{- end }}
+  {{- with .values.unhealthyPodEvictionPolicy }}
+  unhealthyPodEvictionPolicy: {{ . }}
+  {{- end }}
   selector:
-    matchLabels:
-      {{- include "library.selectorLabels" .context | nindent 6 }}`
+    {{- include "library.selector" . | nindent 4 }}`
 
 const networkpolicyTemplate = `apiVersion: networking.k8s.io/v1
 kind: NetworkPolicy
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
   podSelector:
+    {{- if .values.podSelector }}
+    {{- toYaml .values.podSelector | nindent 4 }}
+    {{- else }}
     matchLabels:
       {{- include "library.selectorLabels" .context | nindent 6 }}
-  policyTypes:
-    {{- range .values.policyTypes | default (list "Ingress") }}
-    - {{ . }}
     {{- end }}
-  {{- if .values.ingress }}
+  policyTypes:
+    {{- toYaml (.values.policyTypes | default (list "Ingress")) | nindent 4 }}
+  {{- with .values.ingress }}
   ingress:
-    {{- toYaml .values.ingress | nindent 4 }}
+    {{- toYaml . | nindent 4 }}
   {{- end }}
-  {{- if .values.egress }}
+  {{- with .values.egress }}
   egress:
-    {{- toYaml .values.egress | nindent 4 }}
+    {{- toYaml . | nindent 4 }}
   {{- end }}`
 
-const cronjobTemplate = `apiVersion: batch/v1
+var cronjobTemplate = `apiVersion: batch/v1
 kind: CronJob
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
   schedule: {{ .values.schedule | default "0 * * * *" | quote }}
-  {{- if .values.concurrencyPolicy }}
-  concurrencyPolicy: {{ .values.concurrencyPolicy }}
+  {{- with .values.timeZone }}
+  timeZone: {{ . | quote }}
+  {{- end }}
+  {{- with .values.concurrencyPolicy }}
+  concurrencyPolicy: {{ . }}
+  {{- end }}
+  {{- if hasKey .values "suspend" }}
+  suspend: {{ .values.suspend }}
+  {{- end }}
+  {{- if hasKey .values "successfulJobsHistoryLimit" }}
+  successfulJobsHistoryLimit: {{ .values.successfulJobsHistoryLimit }}
+  {{- end }}
+  {{- if hasKey .values "failedJobsHistoryLimit" }}
+  failedJobsHistoryLimit: {{ .values.failedJobsHistoryLimit }}
+  {{- end }}
+  {{- with .values.startingDeadlineSeconds }}
+  startingDeadlineSeconds: {{ . }}
   {{- end }}
   jobTemplate:
     spec:
+      {{- with .values.jobTemplate }}
+      {{- toYaml . | nindent 6 }}
+      {{- end }}
       template:
-        spec:
-          containers:
-            - name: {{ .values.name | default .context.Chart.Name }}
-              {{- $dvals := .values | toJson | fromJson }}
-              image: "{{ dig "image" "repository" "busybox" $dvals }}:{{ dig "image" "tag" "latest" $dvals }}"
-              {{- if .values.command }}
-              command:
-                {{- toYaml .values.command | nindent 16 }}
-              {{- end }}
-              {{- include "library.env" . | nindent 14 }}
-              {{- include "library.resources" . | nindent 14 }}
-              {{- include "library.volumeMounts" . | nindent 14 }}
-          restartPolicy: {{ .values.restartPolicy | default "OnFailure" }}
-          {{- include "library.volumes" . | nindent 10 }}`
-
-const jobTemplate = `apiVersion: batch/v1
+        {{- include "library.podTemplate" (merge (dict "restartPolicy" "OnFailure") .) | nindent 8 }}
+` + podContainersTemplate(10)
+
+var jobTemplate = `apiVersion: batch/v1
 kind: Job
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 spec:
-  {{- if .values.backoffLimit }}
+  {{- if hasKey .values "backoffLimit" }}
   backoffLimit: {{ .values.backoffLimit }}
   {{- end }}
+  {{- with .values.completions }}
+  completions: {{ . }}
+  {{- end }}
+  {{- with .values.parallelism }}
+  parallelism: {{ . }}
+  {{- end }}
+  {{- with .values.completionMode }}
+  completionMode: {{ . }}
+  {{- end }}
+  {{- with .values.activeDeadlineSeconds }}
+  activeDeadlineSeconds: {{ . }}
+  {{- end }}
+  {{- if hasKey .values "ttl" }}
+  ttlSecondsAfterFinished: {{ .values.ttl }}
+  {{- end }}
+  {{- if hasKey .values "suspend" }}
+  suspend: {{ .values.suspend }}
+  {{- end }}
   template:
-    spec:
-      containers:
-        - name: {{ .values.name | default .context.Chart.Name }}
-          {{- $dvals := .values | toJson | fromJson }}
-          image: "{{ dig "image" "repository" "busybox" $dvals }}:{{ dig "image" "tag" "latest" $dvals }}"
-          {{- if .values.command }}
-          command:
-            {{- toYaml .values.command | nindent 12 }}
-          {{- end }}
-          {{- include "library.env" . | nindent 10 }}
-          {{- include "library.resources" . | nindent 10 }}
-          {{- include "library.volumeMounts" . | nindent 10 }}
-      restartPolicy: {{ .values.restartPolicy | default "Never" }}
-      {{- include "library.volumes" . | nindent 6 }}`
+    {{- include "library.podTemplate" (merge (dict "restartPolicy" "Never") .) | nindent 4 }}
+` + podContainersTemplate(6)
 
 const serviceaccountTemplate = `apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
-  {{- if .values.annotations }}
-  annotations:
-    {{- toYaml .values.annotations | nindent 4 }}
-  {{- end }}`
+  {{- include "library.metadata" . | nindent 2 }}
+{{- if hasKey .values "automountServiceAccountToken" }}
+automountServiceAccountToken: {{ .values.automountServiceAccountToken }}
+{{- end }}
+{{- with .values.imagePullSecrets }}
+imagePullSecrets:
+  {{- toYaml . | nindent 2 }}
+{{- end }}`
 
 const roleTemplate = `apiVersion: rbac.authorization.k8s.io/v1
 kind: Role
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
-{{- if .values.rules }}
+  {{- include "library.metadata" . | nindent 2 }}
+{{- with .values.rules }}
 rules:
-  {{- toYaml .values.rules | nindent 2 }}
+  {{- toYaml . | nindent 2 }}
 {{- end }}`
 
 const clusterroleTemplate = `apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
-{{- if .values.rules }}
+  {{- include "library.metadata" (merge (dict "clusterScoped" true) .) | nindent 2 }}
+{{- with .values.aggregationRule }}
+aggregationRule:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .values.rules }}
 rules:
-  {{- toYaml .values.rules | nindent 2 }}
+  {{- toYaml . | nindent 2 }}
 {{- end }}`
 
 const rolebindingTemplate = `apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" . | nindent 2 }}
 roleRef:
   apiGroup: rbac.authorization.k8s.io
-  kind: {{ .values.roleRef.kind | default "Role" }}
-  name: {{ .values.roleRef.name | default (include "library.fullname" .context) }}
+  kind: {{ dig "roleRef" "kind" "Role" .values }}
+  name: {{ dig "roleRef" "name" "" .values | default (include "library.fullname" .context) }}
+{{- with .values.subjects }}
 subjects:
-  {{- if .values.subjects }}
-  {{- toYaml .values.subjects | nindent 2 }}
-  {{- end }}`
+  {{- toYaml . | nindent 2 }}
+{{- end }}`
 
 const clusterrolebindingTemplate = `apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRoleBinding
 metadata:
-  name: {{ .values.name | default (include "library.fullname" .context) }}
-  labels:
-    {{- include "library.labels" .context | nindent 4 }}
+  {{- include "library.metadata" (merge (dict "clusterScoped" true) .) | nindent 2 }}
 roleRef:
   apiGroup: rbac.authorization.k8s.io
-  kind: {{ .values.roleRef.kind | default "ClusterRole" }}
-  name: {{ .values.roleRef.name | default (include "library.fullname" .context) }}
+  kind: ClusterRole
+  name: {{ dig "roleRef" "name" "" .values | default (include "library.fullname" .context) }}
+{{- with .values.subjects }}
 subjects:
-  {{- if .values.subjects }}
-  {{- toYaml .values.subjects | nindent 2 }}
-  {{- end }}`
+  {{- toYaml . | nindent 2 }}
+{{- end }}`
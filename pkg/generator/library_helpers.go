@@ -1,5 +1,10 @@
 package generator
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Shared sub-template constants for DRY library chart.
 // These are named templates included by workload resource templates.
 
@@ -53,20 +58,149 @@ volumes:
   {{- toYaml .values.volumes | nindent 2 }}
 {{- end }}`
 
-// annotationsTemplate renders metadata annotations.
+// annotationsTemplate renders metadata annotations. It is named
+// resourceAnnotations: <library>.annotations is the common annotations helper.
 const annotationsTemplate = `{{- with .values.annotations }}
 annotations:
   {{- toYaml . | nindent 2 }}
 {{- end }}`
 
+// metadataTemplate renders the metadata of a resource: its name (.values.name,
+// the resource name in .name, or the fullname of the including chart),
+// namespace, labels and annotations. Cluster-scoped resources pass
+// "clusterScoped" true to omit the namespace.
+const metadataTemplate = `name: {{ .values.name | default .name | default (include "library.fullname" .context) }}
+{{- if not .clusterScoped }}
+namespace: {{ .context.Release.Namespace }}
+{{- end }}
+labels:
+  {{- include "library.labels" .context | nindent 2 }}
+{{- include "library.resourceAnnotations" . }}`
+
+// selectorTemplate renders the label selector of a workload: .values.selector,
+// or the selector labels of the including chart.
+const selectorTemplate = `{{- if .values.selector }}
+{{- toYaml .values.selector }}
+{{- else -}}
+matchLabels:
+  {{- include "library.selectorLabels" .context | nindent 2 }}
+{{- end }}`
+
+// podTemplateTemplate renders the pod template of a workload. Pods are
+// labelled with .values.podLabels, or with the labels the workload selects.
+const podTemplateTemplate = `metadata:
+  labels:
+    {{- if .values.podLabels }}
+    {{- toYaml .values.podLabels | nindent 4 }}
+    {{- else if and .values.selector .values.selector.matchLabels }}
+    {{- toYaml .values.selector.matchLabels | nindent 4 }}
+    {{- else }}
+    {{- include "library.selectorLabels" .context | nindent 4 }}
+    {{- end }}
+  {{- with .values.podAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  {{- include "library.podSpec" . | trim | nindent 2 }}`
+
+// podSpecTemplate renders the fields of a pod spec but its containers.
+// .restartPolicy is the default restart policy of the workload kind.
+const podSpecTemplate = `{{- with .values.serviceAccountName }}
+serviceAccountName: {{ . }}
+{{- end }}
+{{- if hasKey .values "automountServiceAccountToken" }}
+automountServiceAccountToken: {{ .values.automountServiceAccountToken }}
+{{- end }}
+{{- with .values.priorityClassName }}
+priorityClassName: {{ . }}
+{{- end }}
+{{- with .values.imagePullSecrets }}
+imagePullSecrets:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .values.restartPolicy | default .restartPolicy }}
+restartPolicy: {{ . }}
+{{- end }}
+{{- include "library.securityContext" . }}
+{{- include "library.volumes" . }}
+{{- with .values.nodeSelector }}
+nodeSelector:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .values.affinity }}
+affinity:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .values.tolerations }}
+tolerations:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with .values.topologySpreadConstraints }}
+topologySpreadConstraints:
+  {{- toYaml . | nindent 2 }}
+{{- end }}`
+
+// podContainersTemplate renders the containers of a workload with the
+// pod spec fields at indent. A container image is either a string or a map of
+// repository, tag, digest and pullPolicy.
+func podContainersTemplate(indent int) string {
+	lines := []string{
+		`containers:`,
+		`  {{- range .values.containers }}`,
+		`  - name: {{ .name }}`,
+		`    {{- if kindIs "string" .image }}`,
+		`    image: {{ .image | quote }}`,
+		`    {{- else }}`,
+		`    image: "{{ .image.repository }}{{ if .image.digest }}@{{ .image.digest }}{{ else }}:{{ .image.tag | default "latest" }}{{ end }}"`,
+		`    {{- with .image.pullPolicy }}`,
+		`    imagePullPolicy: {{ . }}`,
+		`    {{- end }}`,
+		`    {{- end }}`,
+	}
+	for _, field := range []string{"command", "args", "ports", "envFrom"} {
+		lines = append(lines,
+			`    {{- with .`+field+` }}`,
+			`    `+field+`:`,
+			fmt.Sprintf(`      {{- toYaml . | nindent %d }}`, indent+6),
+			`    {{- end }}`)
+	}
+	for _, block := range []string{"env", "resources", "probes", "containerSecurityContext", "volumeMounts"} {
+		lines = append(lines, fmt.Sprintf(`    {{- include "library.%s" (dict "values" .) | nindent %d }}`, block, indent+4))
+	}
+	lines = append(lines, `  {{- end }}`)
+
+	pad := strings.Repeat(" ", indent)
+	return pad + strings.Join(lines, "\n"+pad)
+}
+
+// dataTemplate renders the data of a ConfigMap or Secret in .values: inline
+// values, or files of the including chart referenced by _externalFile.
+const dataTemplate = `{{- range $key, $value := .values }}
+{{- if and (kindIs "map" $value) (hasKey $value "_externalFile") }}
+{{- if hasKey $value "_base64" }}
+{{ $key }}: {{ $.context.Files.Get $value._externalFile | b64enc | quote }}
+{{- else }}
+{{ $key }}: {{ $.context.Files.Get $value._externalFile | quote }}
+{{- end }}
+{{- else }}
+{{ $key }}: {{ $value | quote }}
+{{- end }}
+{{- end }}`
+
 // addSharedSubTemplates registers all DRY sub-templates into the library chart's template map.
-func addSharedSubTemplates(templates map[string]string) {
-	templates["templates/_resources.tpl"] = generateNamedTemplate("resources", resourcesTemplate)
-	templates["templates/_probes.tpl"] = generateNamedTemplate("probes", probesTemplate)
-	templates["templates/_securitycontext.tpl"] = generateNamedTemplate("securityContext", securityContextTemplate) +
-		generateNamedTemplate("containerSecurityContext", containerSecurityContextTemplate)
-	templates["templates/_env.tpl"] = generateNamedTemplate("env", envTemplate)
-	templates["templates/_volumemounts.tpl"] = generateNamedTemplate("volumeMounts", volumeMountsTemplate) +
-		generateNamedTemplate("volumes", volumesTemplate)
-	templates["templates/_annotations.tpl"] = generateNamedTemplate("annotations", annotationsTemplate)
+func addSharedSubTemplates(templates map[string]string, libraryName string) {
+	templates["templates/_resources.tpl"] = generateNamedTemplate(libraryName, "resources", resourcesTemplate)
+	templates["templates/_probes.tpl"] = generateNamedTemplate(libraryName, "probes", probesTemplate)
+	templates["templates/_securitycontext.tpl"] = generateNamedTemplate(libraryName, "securityContext", securityContextTemplate) +
+		generateNamedTemplate(libraryName, "containerSecurityContext", containerSecurityContextTemplate)
+	templates["templates/_env.tpl"] = generateNamedTemplate(libraryName, "env", envTemplate)
+	templates["templates/_volumemounts.tpl"] = generateNamedTemplate(libraryName, "volumeMounts", volumeMountsTemplate) +
+		generateNamedTemplate(libraryName, "volumes", volumesTemplate)
+	templates["templates/_annotations.tpl"] = generateNamedTemplate(libraryName, "resourceAnnotations", annotationsTemplate)
+	templates["templates/_metadata.tpl"] = generateNamedTemplate(libraryName, "metadata", metadataTemplate) +
+		generateNamedTemplate(libraryName, "selector", selectorTemplate)
+	templates["templates/_podspec.tpl"] = generateNamedTemplate(libraryName, "podTemplate", podTemplateTemplate) +
+		generateNamedTemplate(libraryName, "podSpec", podSpecTemplate)
+	templates["templates/_data.tpl"] = generateNamedTemplate(libraryName, "data", dataTemplate)
 }
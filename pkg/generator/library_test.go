@@ -2,9 +2,12 @@ package generator
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -422,6 +425,217 @@ func TestLibraryGenerator_CancelledContext(t *testing.T) {
 	}
 }
 
+// ============================================================
+// Subtask 10: Chart-named templates with a values contract
+// ============================================================
+
+// makeLibraryDeployment returns Deployment name of service app with the
+// values the deployment processor extracts.
+func makeLibraryDeployment(name string) *types.ProcessedResource {
+	return makeProcessedResourceWithValues("Deployment", name, "default",
+		map[string]string{"app.kubernetes.io/name": "app"},
+		map[string]interface{}{
+			"replicas": 2,
+			"containers": []interface{}{map[string]interface{}{
+				"name":  name,
+				"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+				"ports": []interface{}{map[string]interface{}{"containerPort": 80}},
+			}},
+		}, "# deploy")
+}
+
+func TestLibraryGenerator_NamedAfterChart(t *testing.T) {
+	graph := buildGraph([]*types.ProcessedResource{makeLibraryDeployment("web")}, nil)
+
+	charts, err := NewLibraryGenerator().Generate(context.Background(), graph, Options{ChartName: "platform", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	libChart := findLibraryChart(charts)
+	if libChart == nil || libChart.Name != "platform" {
+		t.Fatalf("expected library chart platform, got %+v", libChart)
+	}
+	if !strings.Contains(libChart.Templates["templates/_deployment.tpl"], `define "platform.deployment"`) {
+		t.Errorf("expected platform.deployment:\n%s", libChart.Templates["templates/_deployment.tpl"])
+	}
+	for path, content := range libChart.Templates {
+		if strings.Contains(content, `"library.`) {
+			t.Errorf("%s still references library.* templates", path)
+		}
+	}
+
+	wrapper := findChartByName(charts, "app")
+	if wrapper == nil {
+		t.Fatal("wrapper chart app not found")
+	}
+	if !strings.Contains(wrapper.ChartYAML, "name: platform") || !strings.Contains(wrapper.ChartYAML, "file://../platform") {
+		t.Errorf("expected a dependency on platform:\n%s", wrapper.ChartYAML)
+	}
+	want := `{{ include "platform.deployment" (dict "context" $ "values" (.Values.deployment | default dict) "name" "web") }}`
+	if !strings.Contains(wrapper.Templates["templates/deployment.yaml"], want) {
+		t.Errorf("expected %s in:\n%s", want, wrapper.Templates["templates/deployment.yaml"])
+	}
+}
+
+func TestLibraryGenerator_ChartNameOfService(t *testing.T) {
+	graph := buildGraph([]*types.ProcessedResource{makeLibraryDeployment("web")}, nil)
+
+	charts, err := NewLibraryGenerator().Generate(context.Background(), graph, Options{ChartName: "app", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if libChart := findLibraryChart(charts); libChart == nil || libChart.Name != "app-lib" {
+		t.Errorf("expected library chart app-lib next to wrapper chart app, got %+v", libChart)
+	}
+}
+
+func TestLibraryGenerator_WrapperValuesPaths(t *testing.T) {
+	cm := makeProcessedResourceWithValues("ConfigMap", "app-config", "default",
+		map[string]string{"app.kubernetes.io/name": "app"},
+		map[string]interface{}{"data": map[string]interface{}{"LOG_LEVEL": "info"}}, "# cm")
+	cert := makeProcessedResourceWithValues("Certificate", "app-tls", "default",
+		map[string]string{"app.kubernetes.io/name": "app"},
+		map[string]interface{}{}, "{{ .Values.services.app.certificate }}")
+	cert.ServiceName = "app"
+	graph := buildGraph([]*types.ProcessedResource{makeLibraryDeployment("web"), makeLibraryDeployment("api"), cm, cert}, nil)
+
+	charts, err := NewLibraryGenerator().Generate(context.Background(), graph, Options{ChartName: "platform", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	wrapper := findChartByName(charts, "app")
+	if wrapper == nil {
+		t.Fatal("wrapper chart app not found")
+	}
+
+	for path, want := range map[string]string{
+		"templates/deployment.yaml":  `(dict "context" $ "values" ((index .Values.deployments "web") | default dict) "name" "web")`,
+		"templates/configmap.yaml":   `(dict "context" $ "values" ((index .Values.configMaps "appConfig") | default dict) "name" "app-config")`,
+		"templates/certificate.yaml": "{{ .Values.certificate }}",
+	} {
+		if !strings.Contains(wrapper.Templates[path], want) {
+			t.Errorf("%s: expected %s in:\n%s", path, want, wrapper.Templates[path])
+		}
+	}
+	if n := strings.Count(wrapper.Templates["templates/deployment.yaml"], "include"); n != 2 {
+		t.Errorf("expected one include per Deployment, got %d", n)
+	}
+}
+
+// renderWithLibrary renders chart with the templates of the library chart
+// lib, as Helm does once the dependency is built.
+func renderWithLibrary(t *testing.T, lib, chart *types.GeneratedChart) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":                 chart.ChartYAML,
+		"values.yaml":                chart.ValuesYAML,
+		"templates/_helpers.tpl":     chart.Helpers,
+		"templates/_lib_helpers.tpl": lib.Helpers,
+	}
+	for path, content := range chart.Templates {
+		files[path] = content
+	}
+	for path, content := range lib.Templates {
+		files["templates/_lib"+strings.TrimPrefix(path, "templates/")] = content
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rendered, err := helm.RenderChart(dir, helm.RenderOptions{})
+	if err != nil {
+		t.Fatalf("render %s: %v", chart.Name, err)
+	}
+	return rendered.Manifests
+}
+
+func TestLibraryGenerator_RenderWrapper(t *testing.T) {
+	graph := buildGraph([]*types.ProcessedResource{makeLibraryDeployment("web")}, nil)
+
+	charts, err := NewLibraryGenerator().Generate(context.Background(), graph, Options{ChartName: "platform", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	manifests := renderWithLibrary(t, findLibraryChart(charts), findChartByName(charts, "app"))
+	deployment := manifests["templates/deployment.yaml"]
+	for _, want := range []string{"kind: Deployment", "  name: web\n", "replicas: 2", `image: "nginx:1.25"`, "containerPort: 80"} {
+		if !strings.Contains(deployment, want) {
+			t.Errorf("expected %q in:\n%s", want, deployment)
+		}
+	}
+}
+
+func TestLibraryGenerator_RenderStatefulSetPersistence(t *testing.T) {
+	sts := makeProcessedResourceWithValues("StatefulSet", "db", "default",
+		map[string]string{"app.kubernetes.io/name": "app"},
+		map[string]interface{}{
+			"serviceName": "db",
+			"containers": []interface{}{map[string]interface{}{
+				"name":  "db",
+				"image": map[string]interface{}{"repository": "postgres", "tag": "16"},
+			}},
+			"persistence": map[string]interface{}{
+				"data": map[string]interface{}{"storageClassName": "fast-ssd", "size": "10Gi"},
+			},
+		}, "# sts")
+	charts, err := NewLibraryGenerator().Generate(context.Background(), buildGraph([]*types.ProcessedResource{sts}, nil), Options{ChartName: "platform", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	var statefulSet string
+	for _, manifest := range renderWithLibrary(t, findLibraryChart(charts), findChartByName(charts, "app")) {
+		if strings.Contains(manifest, "kind: StatefulSet") {
+			statefulSet = manifest
+		}
+	}
+	for _, want := range []string{"volumeClaimTemplates:\n    - metadata:\n        name: data\n", "storageClassName: \"fast-ssd\"", "- ReadWriteOnce", "storage: \"10Gi\""} {
+		if !strings.Contains(statefulSet, want) {
+			t.Errorf("expected %q in:\n%s", want, statefulSet)
+		}
+	}
+}
+
+func TestLibraryGenerator_ExampleConsumer(t *testing.T) {
+	charts, err := NewLibraryGenerator().Generate(context.Background(), buildGraph(nil, nil), Options{ChartName: "platform", ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	libChart := findLibraryChart(charts)
+
+	example := &types.GeneratedChart{Templates: make(map[string]string)}
+	for _, ef := range libChart.ExternalFiles {
+		switch path := strings.TrimPrefix(ef.Path, libraryExampleDir+"/"); path {
+		case "Chart.yaml":
+			example.ChartYAML = ef.Content
+		case "values.yaml":
+			example.ValuesYAML = ef.Content
+		default:
+			example.Templates[path] = ef.Content
+		}
+	}
+	if !strings.Contains(example.ChartYAML, "name: platform-example") || !strings.Contains(example.ChartYAML, "file://../..") {
+		t.Fatalf("unexpected example Chart.yaml:\n%s", example.ChartYAML)
+	}
+	example.Helpers = helm.GenerateHelpers("platform-example")
+
+	app := renderWithLibrary(t, libChart, example)["templates/app.yaml"]
+	for _, want := range []string{"kind: Deployment", "kind: Service", "kind: ConfigMap", "  name: settings\n", `LOG_LEVEL: "info"`} {
+		if !strings.Contains(app, want) {
+			t.Errorf("expected %q in:\n%s", want, app)
+		}
+	}
+}
+
 // ============================================================
 // Helpers
 // ============================================================
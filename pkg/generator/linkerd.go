@@ -35,7 +35,7 @@ func GenerateLinkerdConfig(graph *types.ResourceGraph, opts LinkerdOptions) *Lin
 	}
 
 	if opts.ServiceProfiles {
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			if r.Original.GVK.Kind != "Service" {
 				continue
 			}
@@ -48,7 +48,7 @@ func GenerateLinkerdConfig(graph *types.ResourceGraph, opts LinkerdOptions) *Lin
 
 	if opts.TrafficSplit {
 		seen := make(map[string]bool)
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			kind := r.Original.GVK.Kind
 			if kind != "Deployment" && kind != "Service" {
 				continue
@@ -64,7 +64,7 @@ func GenerateLinkerdConfig(graph *types.ResourceGraph, opts LinkerdOptions) *Lin
 		}
 		// If TrafficSplit enabled and we have any services, generate for them.
 		if len(result.Templates) == 0 {
-			for _, r := range graph.Resources {
+			for _, r := range sortedResources(graph) {
 				if r.Original.GVK.Kind != "Service" && r.Original.GVK.Kind != "Deployment" {
 					continue
 				}
@@ -0,0 +1,787 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ModuleHookKind identifies what a Deckhouse module hook does.
+type ModuleHookKind string
+
+const (
+	// ModuleHookWatchSecrets watches the Secrets the workloads read and
+	// stores a checksum of their data in the module internal values, so that
+	// the workloads are rolled out when the Secrets change.
+	ModuleHookWatchSecrets ModuleHookKind = "watch-secrets"
+
+	// ModuleHookTLSCertificate generates a self-signed certificate for a
+	// kubernetes.io/tls Secret the workloads mount, reusing the certificate
+	// in the cluster while it is valid.
+	ModuleHookTLSCertificate ModuleHookKind = "tls-certificate"
+)
+
+// ModuleHook is a hook the Deckhouse module of a chart needs, detected from
+// its resources by DetectModuleHooks. GenerateDeckhouseModule writes a Go hook
+// skeleton (module-sdk) and its test into hooks/ for each.
+type ModuleHook struct {
+	// Kind is what the hook does.
+	Kind ModuleHookKind
+
+	// Name is the hook file name without extension, in snake_case.
+	Name string
+
+	// Namespaces are the namespaces of the watched Secrets.
+	Namespaces []string
+
+	// Secrets are the names of the watched Secrets.
+	Secrets []string
+
+	// CommonName is the certificate common name (ModuleHookTLSCertificate).
+	CommonName string
+
+	// DNSNames are the certificate DNS names (ModuleHookTLSCertificate).
+	DNSNames []string
+}
+
+// certificateGroup is the API group of cert-manager Certificates, which issue
+// the Secret named in spec.secretName.
+const certificateGroup = "cert-manager.io"
+
+// DetectModuleHooks returns the hooks the Deckhouse module of moduleName
+// needs:
+//   - a ModuleHookWatchSecrets hook if the workloads read Secrets from env,
+//     envFrom or volumes;
+//   - a ModuleHookTLSCertificate hook for each kubernetes.io/tls Secret of the
+//     chart a workload mounts, unless a cert-manager Certificate issues it.
+//     Its DNS names are those of the Services selecting the workload.
+//
+// Resources without a namespace are assumed to be in the module namespace,
+// d8-<moduleName>.
+func DetectModuleHooks(graph *types.ResourceGraph, moduleName string) []ModuleHook {
+	if graph == nil {
+		return nil
+	}
+	defaultNamespace := "d8-" + moduleName
+	namespaceOf := func(ns string) string {
+		if ns == "" {
+			return defaultNamespace
+		}
+		return ns
+	}
+
+	keys := make([]types.ResourceKey, 0, len(graph.Resources))
+	for key := range graph.Resources {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	issued := make(map[types.ResourceKey]bool)
+	for _, key := range keys {
+		obj := graph.Resources[key].Original.Object
+		if key.GVK.Group != certificateGroup || key.GVK.Kind != "Certificate" {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(obj.Object, "spec", "secretName"); name != "" {
+			issued[secretKey(key.Namespace, name)] = true
+		}
+	}
+
+	watchedNamespaces := make(map[string]bool)
+	watchedSecrets := make(map[string]bool)
+	var tlsHooks []ModuleHook
+	tlsSeen := make(map[types.ResourceKey]bool)
+
+	for _, key := range keys {
+		obj := graph.Resources[key].Original.Object
+		if !isHookWorkload(key.GVK.Kind) {
+			continue
+		}
+		specPath := processor.PodSpecPath(key.GVK.Kind)
+
+		for _, name := range podSecretNames(obj, specPath) {
+			watchedNamespaces[namespaceOf(key.Namespace)] = true
+			watchedSecrets[name] = true
+		}
+
+		for _, name := range podSecretVolumeNames(obj, specPath) {
+			sk := secretKey(key.Namespace, name)
+			secret, ok := graph.Resources[sk]
+			if !ok || tlsSeen[sk] || issued[sk] {
+				continue
+			}
+			if t, _, _ := unstructured.NestedString(secret.Original.Object.Object, "type"); t != "kubernetes.io/tls" {
+				continue
+			}
+			tlsSeen[sk] = true
+			tlsHooks = append(tlsHooks, tlsCertificateHook(graph, key, name, namespaceOf(key.Namespace)))
+		}
+	}
+
+	var hooks []ModuleHook
+	if len(watchedSecrets) > 0 {
+		hooks = append(hooks, ModuleHook{
+			Kind:       ModuleHookWatchSecrets,
+			Name:       "watch_secrets",
+			Namespaces: sortedSet(watchedNamespaces),
+			Secrets:    sortedSet(watchedSecrets),
+		})
+	}
+	return append(hooks, tlsHooks...)
+}
+
+// tlsCertificateHook returns the certificate hook of the TLS Secret name that
+// workload mounts, valid for the Services selecting the workload.
+func tlsCertificateHook(graph *types.ResourceGraph, workload types.ResourceKey, name, namespace string) ModuleHook {
+	var services []string
+	for _, rel := range graph.GetRelationshipsTo(workload) {
+		if rel.Type == types.RelationLabelSelector && rel.From.GVK.Kind == "Service" {
+			services = append(services, rel.From.Name)
+		}
+	}
+	sort.Strings(services)
+
+	commonName := workload.Name
+	if len(services) > 0 {
+		commonName = services[0]
+	}
+	var dnsNames []string
+	for _, svc := range services {
+		dnsNames = append(dnsNames, svc, svc+"."+namespace, svc+"."+namespace+".svc")
+	}
+	if len(dnsNames) == 0 {
+		dnsNames = []string{commonName}
+	}
+
+	return ModuleHook{
+		Kind:       ModuleHookTLSCertificate,
+		Name:       "tls_" + strings.NewReplacer("-", "_", ".", "_").Replace(name),
+		Namespaces: []string{namespace},
+		Secrets:    []string{name},
+		CommonName: commonName,
+		DNSNames:   dnsNames,
+	}
+}
+
+func isHookWorkload(kind string) bool {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob":
+		return true
+	}
+	return false
+}
+
+func secretKey(namespace, name string) types.ResourceKey {
+	return types.ResourceKey{
+		GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+		Namespace: namespace,
+		Name:      name,
+	}
+}
+
+// podSecretNames returns the names of the Secrets the pods of obj read from
+// env, envFrom and volumes. Image pull secrets are not included.
+func podSecretNames(obj *unstructured.Unstructured, specPath []string) []string {
+	names := podSecretVolumeNames(obj, specPath)
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(obj.Object, append(specPath, field)...)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			env, _, _ := unstructured.NestedSlice(container, "env")
+			for _, e := range env {
+				if v, ok := e.(map[string]interface{}); ok {
+					if name, _, _ := unstructured.NestedString(v, "valueFrom", "secretKeyRef", "name"); name != "" {
+						names = append(names, name)
+					}
+				}
+			}
+			envFrom, _, _ := unstructured.NestedSlice(container, "envFrom")
+			for _, e := range envFrom {
+				if v, ok := e.(map[string]interface{}); ok {
+					if name, _, _ := unstructured.NestedString(v, "secretRef", "name"); name != "" {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+	return uniqueStrings(names)
+}
+
+// podSecretVolumeNames returns the names of the Secrets mounted as volumes,
+// including projected volume sources, by the pods of obj.
+func podSecretVolumeNames(obj *unstructured.Unstructured, specPath []string) []string {
+	var names []string
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, append(specPath, "volumes")...)
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(volume, "secret", "secretName"); name != "" {
+			names = append(names, name)
+		}
+		sources, _, _ := unstructured.NestedSlice(volume, "projected", "sources")
+		for _, s := range sources {
+			if source, ok := s.(map[string]interface{}); ok {
+				if name, _, _ := unstructured.NestedString(source, "secret", "name"); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return uniqueStrings(names)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func sortedSet(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for k := range set {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// moduleHookFiles returns the hooks/ files of a module with hooks: a Go
+// module with one module-sdk hook and test per hook.
+func moduleHookFiles(moduleName string, hooks []ModuleHook) []types.ExternalFileInfo {
+	valuesKey := ModuleValuesKey(moduleName)
+
+	files := []types.ExternalFileInfo{
+		{Path: "hooks/go.mod", Content: fmt.Sprintf("module %s/hooks\n\ngo 1.23\n", moduleName)},
+		{Path: "hooks/main.go", Content: hooksMainSource},
+	}
+
+	var readme strings.Builder
+	fmt.Fprintf(&readme, "# Hooks for %s\n\n", moduleName)
+	readme.WriteString("Go hooks (github.com/deckhouse/module-sdk) generated from the module resources:\n\n")
+
+	tlsHelpers := false
+	for _, hook := range hooks {
+		var source, test string
+		switch hook.Kind {
+		case ModuleHookWatchSecrets:
+			source, test = watchSecretsHookSource(valuesKey, hook), watchSecretsHookTest(hook)
+			fmt.Fprintf(&readme, "- `%s.go` — watches Secrets %s and stores the checksum of their data in `%s.internal.secretsChecksum`.\n",
+				hook.Name, strings.Join(hook.Secrets, ", "), valuesKey)
+		case ModuleHookTLSCertificate:
+			source, test = tlsCertificateHookSource(valuesKey, hook), tlsCertificateHookTest(hook)
+			fmt.Fprintf(&readme, "- `%s.go` — keeps a self-signed certificate for Secret %s in `%s.internal.%s`.\n",
+				hook.Name, hook.Secrets[0], valuesKey, processor.SanitizeServiceName(hook.Secrets[0]))
+			tlsHelpers = true
+		default:
+			continue
+		}
+		files = append(files,
+			types.ExternalFileInfo{Path: "hooks/" + hook.Name + ".go", Content: source},
+			types.ExternalFileInfo{Path: "hooks/" + hook.Name + "_test.go", Content: test},
+		)
+	}
+	if tlsHelpers {
+		files = append(files,
+			types.ExternalFileInfo{Path: "hooks/tls.go", Content: tlsHelpersSource},
+			types.ExternalFileInfo{Path: "hooks/tls_test.go", Content: tlsHelpersTest},
+		)
+	}
+
+	readme.WriteString("\nThe values are declared in openapi/values.yaml; use them in the templates, e.g.\n")
+	fmt.Fprintf(&readme, "a `checksum/secrets: {{ .Values.%s.internal.secretsChecksum | quote }}` pod annotation.\n\n", valuesKey)
+	readme.WriteString("Build and test:\n\n```shell\ncd hooks\ngo get github.com/deckhouse/module-sdk@latest\ngo mod tidy\ngo test ./...\n```\n")
+
+	return append(files, types.ExternalFileInfo{Path: "hooks/README.md", Content: readme.String()})
+}
+
+// moduleInternalValuesSchema returns openapi/values.yaml declaring the
+// internal values set by hooks.
+func moduleInternalValuesSchema(hooks []ModuleHook) string {
+	if len(hooks) == 0 {
+		return "type: object\nadditionalProperties: true\nproperties:\n  internal:\n    type: object\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("type: object\nadditionalProperties: true\nproperties:\n  internal:\n    type: object\n    default: {}\n    properties:\n")
+	for _, hook := range hooks {
+		switch hook.Kind {
+		case ModuleHookWatchSecrets:
+			sb.WriteString("      secretsChecksum:\n        type: string\n        default: \"\"\n")
+		case ModuleHookTLSCertificate:
+			fmt.Fprintf(&sb, "      %s:\n        type: object\n        default: {}\n        properties:\n", processor.SanitizeServiceName(hook.Secrets[0]))
+			for _, field := range []string{"ca", "crt", "key"} {
+				fmt.Fprintf(&sb, "          %s:\n            type: string\n", field)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// goStrings returns values as a Go []string literal.
+func goStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// goSecretBinding returns the Kubernetes binding of a hook watching secrets.
+func goSecretBinding(name string, hook ModuleHook, jqFilter string) string {
+	return fmt.Sprintf(`		{
+			Name:       %q,
+			APIVersion: "v1",
+			Kind:       "Secret",
+			NamespaceSelector: &pkg.NamespaceSelector{
+				NameSelector: &pkg.NameSelector{MatchNames: %s},
+			},
+			NameSelector: &pkg.NameSelector{MatchNames: %s},
+			JqFilter:     %s,
+		},
+`, name, goStrings(hook.Namespaces), goStrings(hook.Secrets), "`"+jqFilter+"`")
+}
+
+const hooksMainSource = `package main
+
+import (
+	"github.com/deckhouse/module-sdk/pkg/app"
+)
+
+func main() {
+	app.Run()
+}
+`
+
+func watchSecretsHookSource(valuesKey string, hook ModuleHook) string {
+	return `package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/deckhouse/module-sdk/pkg"
+	"github.com/deckhouse/module-sdk/pkg/registry"
+)
+
+// watchSecretsConfig watches the Secrets the module workloads read. A change
+// of their data changes the checksum and re-renders the module.
+var watchSecretsConfig = &pkg.HookConfig{
+	OnBeforeHelm: &pkg.OrderedConfig{Order: 10},
+	Kubernetes: []pkg.KubernetesConfig{
+` + goSecretBinding("secrets", hook, `{"name": .metadata.name, "data": .data}`) + `	},
+}
+
+var _ = registry.RegisterFunc(watchSecretsConfig, handleWatchSecrets)
+
+// watchedSecret is a snapshot of a watched Secret.
+type watchedSecret struct {
+	Name string
+	Data map[string]string
+}
+
+func handleWatchSecrets(_ context.Context, input *pkg.HookInput) error {
+	snapshots := input.Snapshots.Get("secrets")
+	secrets := make([]watchedSecret, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var secret watchedSecret
+		if err := snapshot.UnmarshalTo(&secret); err != nil {
+			return fmt.Errorf("unmarshal Secret snapshot: %w", err)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	input.Values.Set("` + valuesKey + `.internal.secretsChecksum", secretsChecksum(secrets))
+	return nil
+}
+
+// secretsChecksum returns the checksum of the names and data of secrets,
+// independent of their order.
+func secretsChecksum(secrets []watchedSecret) string {
+	sorted := append([]watchedSecret(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	h := sha256.New()
+	for _, secret := range sorted {
+		keys := make([]string, 0, len(secret.Data))
+		for k := range secret.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(h, "%s\x00", secret.Name)
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s=%s\x00", k, secret.Data[k])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+`
+}
+
+func watchSecretsHookTest(hook ModuleHook) string {
+	return `package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWatchSecretsBinding(t *testing.T) {
+	binding := watchSecretsConfig.Kubernetes[0]
+	if binding.Kind != "Secret" {
+		t.Fatalf("binding kind = %q, want Secret", binding.Kind)
+	}
+	if want := ` + goStrings(hook.Secrets) + `; !reflect.DeepEqual(binding.NameSelector.MatchNames, want) {
+		t.Errorf("watched Secrets = %v, want %v", binding.NameSelector.MatchNames, want)
+	}
+}
+
+func TestSecretsChecksum(t *testing.T) {
+	a := watchedSecret{Name: "a", Data: map[string]string{"password": "MQ=="}}
+	b := watchedSecret{Name: "b", Data: map[string]string{"password": "Mg==", "user": "YWRtaW4="}}
+
+	sum := secretsChecksum([]watchedSecret{a, b})
+	if got := secretsChecksum([]watchedSecret{b, a}); got != sum {
+		t.Error("checksum depends on the order of the Secrets")
+	}
+
+	b.Data = map[string]string{"password": "Mw==", "user": "YWRtaW4="}
+	if got := secretsChecksum([]watchedSecret{a, b}); got == sum {
+		t.Error("checksum did not change with the Secret data")
+	}
+}
+`
+}
+
+// tlsHookIdent returns the Go identifier prefix of the certificate hook of a
+// Secret: tls-web -> tlsTlsWeb.
+func tlsHookIdent(secretName string) string {
+	name := processor.SanitizeServiceName(secretName)
+	return "tls" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+func tlsCertificateHookSource(valuesKey string, hook ModuleHook) string {
+	secret := hook.Secrets[0]
+	ident := tlsHookIdent(secret)
+	handler := "handle" + strings.ToUpper(ident[:1]) + ident[1:]
+
+	return fmt.Sprintf(`package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deckhouse/module-sdk/pkg"
+	"github.com/deckhouse/module-sdk/pkg/registry"
+)
+
+// %[1]sDNSNames are the DNS names of the certificate in Secret %[2]s.
+var %[1]sDNSNames = %[3]s
+
+// %[1]sConfig keeps the certificate of Secret %[2]s in the values:
+// the one in the cluster while it is valid, a new self-signed one otherwise.
+var %[1]sConfig = &pkg.HookConfig{
+	OnBeforeHelm: &pkg.OrderedConfig{Order: 5},
+	Kubernetes: []pkg.KubernetesConfig{
+%[4]s	},
+}
+
+var _ = registry.RegisterFunc(%[1]sConfig, %[5]s)
+
+func %[5]s(_ context.Context, input *pkg.HookInput) error {
+	var secret tlsSecret
+	for _, snapshot := range input.Snapshots.Get("secret") {
+		if err := snapshot.UnmarshalTo(&secret); err != nil {
+			return fmt.Errorf("unmarshal Secret snapshot: %%w", err)
+		}
+	}
+	cert, err := secret.certificate()
+	if err != nil {
+		return err
+	}
+
+	if !certificateValid(cert, %[1]sDNSNames) {
+		cert, err = generateCertificate(%[6]q, %[1]sDNSNames)
+		if err != nil {
+			return fmt.Errorf("generate certificate for Secret %[2]s: %%w", err)
+		}
+	}
+
+	input.Values.Set(%[7]q, cert.values())
+	return nil
+}
+`, ident, secret, goStrings(hook.DNSNames),
+		goSecretBinding("secret", hook, `{"ca": .data."ca.crt", "crt": .data."tls.crt", "key": .data."tls.key"}`),
+		handler, hook.CommonName, valuesKey+".internal."+processor.SanitizeServiceName(secret))
+}
+
+func tlsCertificateHookTest(hook ModuleHook) string {
+	ident := tlsHookIdent(hook.Secrets[0])
+	test := "Test" + strings.ToUpper(ident[:1]) + ident[1:]
+
+	return fmt.Sprintf(`package main
+
+import (
+	"testing"
+)
+
+func %[1]sBinding(t *testing.T) {
+	binding := %[2]sConfig.Kubernetes[0]
+	if binding.Kind != "Secret" || binding.NameSelector.MatchNames[0] != %[3]q {
+		t.Errorf("binding watches %%s %%v, want Secret %[3]s", binding.Kind, binding.NameSelector.MatchNames)
+	}
+}
+
+func %[1]sCertificate(t *testing.T) {
+	cert, err := generateCertificate(%[4]q, %[2]sDNSNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !certificateValid(cert, %[2]sDNSNames) {
+		t.Error("generated certificate is not valid for its DNS names")
+	}
+}
+`, test, ident, hook.Secrets[0], hook.CommonName)
+}
+
+const tlsHelpersSource = `package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// certificateValidity is the validity of generated certificates.
+	certificateValidity = 10 * 365 * 24 * time.Hour
+
+	// certificateRenewBefore is how long before it expires a certificate
+	// is regenerated.
+	certificateRenewBefore = 30 * 24 * time.Hour
+)
+
+// certificate is a CA and a serving certificate issued by it, in PEM.
+type certificate struct {
+	CA  string
+	Crt string
+	Key string
+}
+
+// values returns the certificate as module internal values.
+func (c certificate) values() map[string]string {
+	return map[string]string{"ca": c.CA, "crt": c.Crt, "key": c.Key}
+}
+
+// tlsSecret is a snapshot of a kubernetes.io/tls Secret, base64-encoded.
+type tlsSecret struct {
+	CA  string
+	Crt string
+	Key string
+}
+
+// certificate returns the decoded certificate of the Secret.
+func (s tlsSecret) certificate() (certificate, error) {
+	var c certificate
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{{&c.CA, s.CA}, {&c.Crt, s.Crt}, {&c.Key, s.Key}} {
+		data, err := base64.StdEncoding.DecodeString(field.src)
+		if err != nil {
+			return certificate{}, fmt.Errorf("decode Secret data: %w", err)
+		}
+		*field.dst = string(data)
+	}
+	return c, nil
+}
+
+// certificateValid reports whether c is complete, valid for all dnsNames and
+// does not expire within certificateRenewBefore.
+func certificateValid(c certificate, dnsNames []string) bool {
+	if c.CA == "" || c.Key == "" {
+		return false
+	}
+	block, _ := pem.Decode([]byte(c.Crt))
+	if block == nil {
+		return false
+	}
+	crt, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || time.Now().Add(certificateRenewBefore).After(crt.NotAfter) {
+		return false
+	}
+	for _, name := range dnsNames {
+		if crt.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// generateCertificate generates a self-signed CA and a serving certificate
+// issued by it for dnsNames.
+func generateCertificate(commonName string, dnsNames []string) (certificate, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return certificate{}, err
+	}
+	caSerial, err := serialNumber()
+	if err != nil {
+		return certificate{}, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          caSerial,
+		Subject:               pkix.Name{CommonName: commonName + "-ca"},
+		NotBefore:             now,
+		NotAfter:              now.Add(certificateValidity),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return certificate{}, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return certificate{}, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return certificate{}, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    now,
+		NotAfter:     now.Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return certificate{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return certificate{}, err
+	}
+
+	return certificate{
+		CA:  encodePEM("CERTIFICATE", caDER),
+		Crt: encodePEM("CERTIFICATE", der),
+		Key: encodePEM("EC PRIVATE KEY", keyDER),
+	}, nil
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func encodePEM(blockType string, der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+`
+
+const tlsHelpersTest = `package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	dnsNames := []string{"web", "web.default", "web.default.svc"}
+	cert, err := generateCertificate("web", dnsNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(cert.CA)) {
+		t.Fatal("invalid CA certificate")
+	}
+	block, _ := pem.Decode([]byte(cert.Crt))
+	if block == nil {
+		t.Fatal("invalid certificate")
+	}
+	crt, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crt.Verify(x509.VerifyOptions{Roots: roots, DNSName: "web.default.svc"}); err != nil {
+		t.Errorf("certificate is not issued by the CA: %v", err)
+	}
+	if _, err := tls.X509KeyPair([]byte(cert.Crt), []byte(cert.Key)); err != nil {
+		t.Errorf("key does not match the certificate: %v", err)
+	}
+}
+
+func TestCertificateValid(t *testing.T) {
+	cert, err := generateCertificate("web", []string{"web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !certificateValid(cert, []string{"web"}) {
+		t.Error("expected the generated certificate to be valid")
+	}
+	if certificateValid(cert, []string{"web", "api"}) {
+		t.Error("expected a certificate without all DNS names to be regenerated")
+	}
+	if certificateValid(certificate{}, []string{"web"}) {
+		t.Error("expected a missing certificate to be generated")
+	}
+}
+
+func TestTLSSecretCertificate(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	secret := tlsSecret{CA: encode("ca"), Crt: encode("crt"), Key: encode("key")}
+
+	cert, err := secret.certificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert != (certificate{CA: "ca", Crt: "crt", Key: "key"}) {
+		t.Errorf("certificate = %+v", cert)
+	}
+}
+`
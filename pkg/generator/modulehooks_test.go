@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// makeModuleHooksGraph returns a graph with Deployment web reading Secret
+// db-creds from env and mounting TLS Secrets web-tls and api-tls, the latter
+// issued by a cert-manager Certificate, and Service web selecting it.
+func makeModuleHooksGraph() *types.ResourceGraph {
+	graph := types.NewResourceGraph()
+
+	web := makeProcessedResource("Deployment", "web", "prod", nil)
+	web.Original.Object.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name": "web",
+					"env": []interface{}{map[string]interface{}{
+						"name": "DB_PASSWORD",
+						"valueFrom": map[string]interface{}{
+							"secretKeyRef": map[string]interface{}{"name": "db-creds", "key": "password"},
+						},
+					}},
+				}},
+				"volumes": []interface{}{
+					map[string]interface{}{"name": "tls", "secret": map[string]interface{}{"secretName": "web-tls"}},
+					map[string]interface{}{"name": "api", "secret": map[string]interface{}{"secretName": "api-tls"}},
+				},
+			},
+		},
+	}
+	graph.AddResource(web)
+
+	for _, name := range []string{"web-tls", "api-tls"} {
+		secret := makeProcessedResource("Secret", name, "prod", nil)
+		secret.Original.Object.Object["type"] = "kubernetes.io/tls"
+		graph.AddResource(secret)
+	}
+
+	certificate := makeProcessedResource("Certificate", "api", "prod", nil)
+	certificate.Original.Object.Object["spec"] = map[string]interface{}{"secretName": "api-tls"}
+	graph.AddResource(certificate)
+
+	service := makeProcessedResource("Service", "web", "prod", nil)
+	graph.AddResource(service)
+	graph.AddRelationship(types.Relationship{
+		From: service.Original.ResourceKey(),
+		To:   web.Original.ResourceKey(),
+		Type: types.RelationLabelSelector,
+	})
+
+	return graph
+}
+
+func TestDetectModuleHooks(t *testing.T) {
+	hooks := DetectModuleHooks(makeModuleHooksGraph(), "my-module")
+
+	if len(hooks) != 2 {
+		t.Fatalf("expected a watch and a certificate hook, got %+v", hooks)
+	}
+
+	watch := hooks[0]
+	if watch.Kind != ModuleHookWatchSecrets || watch.Name != "watch_secrets" {
+		t.Errorf("unexpected watch hook: %+v", watch)
+	}
+	if want := []string{"api-tls", "db-creds", "web-tls"}; !reflect.DeepEqual(watch.Secrets, want) {
+		t.Errorf("watched Secrets = %v, want %v", watch.Secrets, want)
+	}
+	if want := []string{"prod"}; !reflect.DeepEqual(watch.Namespaces, want) {
+		t.Errorf("watched namespaces = %v, want %v", watch.Namespaces, want)
+	}
+
+	tls := hooks[1]
+	if tls.Kind != ModuleHookTLSCertificate || tls.Name != "tls_web_tls" {
+		t.Errorf("unexpected certificate hook: %+v", tls)
+	}
+	if want := []string{"web-tls"}; !reflect.DeepEqual(tls.Secrets, want) {
+		t.Errorf("certificate Secrets = %v, want %v (api-tls is issued by cert-manager)", tls.Secrets, want)
+	}
+	if want := []string{"web", "web.prod", "web.prod.svc"}; tls.CommonName != "web" || !reflect.DeepEqual(tls.DNSNames, want) {
+		t.Errorf("certificate %s %v, want web %v", tls.CommonName, tls.DNSNames, want)
+	}
+}
+
+func TestDetectModuleHooks_None(t *testing.T) {
+	graph := types.NewResourceGraph()
+	graph.AddResource(makeProcessedResource("Deployment", "web", "", nil))
+
+	if hooks := DetectModuleHooks(graph, "my-module"); len(hooks) != 0 {
+		t.Errorf("expected no hooks without Secrets, got %+v", hooks)
+	}
+	if hooks := DetectModuleHooks(nil, "my-module"); hooks != nil {
+		t.Errorf("expected no hooks for a nil graph, got %+v", hooks)
+	}
+}
+
+func TestModuleScaffold_Hooks(t *testing.T) {
+	hooks := DetectModuleHooks(makeModuleHooksGraph(), "my-module")
+
+	result := GenerateDeckhouseModule(makeTestChart("my-module"), nil, hooks...)
+
+	files := make(map[string]string)
+	for _, ef := range result.ExternalFiles {
+		files[ef.Path] = ef.Content
+	}
+	for _, path := range []string{
+		"hooks/go.mod", "hooks/main.go", "hooks/README.md",
+		"hooks/watch_secrets.go", "hooks/watch_secrets_test.go",
+		"hooks/tls_web_tls.go", "hooks/tls_web_tls_test.go",
+		"hooks/tls.go", "hooks/tls_test.go",
+	} {
+		content, ok := files[path]
+		if !ok {
+			t.Errorf("expected %s", path)
+			continue
+		}
+		if strings.HasSuffix(path, ".go") {
+			if _, err := parser.ParseFile(token.NewFileSet(), path, content, 0); err != nil {
+				t.Errorf("%s is not valid Go: %v", path, err)
+			}
+		}
+	}
+
+	watch := files["hooks/watch_secrets.go"]
+	for _, want := range []string{
+		`MatchNames: []string{"api-tls", "db-creds", "web-tls"}`,
+		`MatchNames: []string{"prod"}`,
+		`input.Values.Set("myModule.internal.secretsChecksum"`,
+	} {
+		if !strings.Contains(watch, want) {
+			t.Errorf("watch_secrets.go: expected %s:\n%s", want, watch)
+		}
+	}
+	if !strings.Contains(files["hooks/tls_web_tls.go"], `input.Values.Set("myModule.internal.webTls"`) {
+		t.Errorf("tls_web_tls.go: expected the certificate in myModule.internal.webTls:\n%s", files["hooks/tls_web_tls.go"])
+	}
+
+	schema := files["openapi/values.yaml"]
+	for _, want := range []string{"      secretsChecksum:\n", "      webTls:\n"} {
+		if !strings.Contains(schema, want) {
+			t.Errorf("openapi/values.yaml: expected %q:\n%s", want, schema)
+		}
+	}
+}
+
+func TestModuleScaffold_NoHooks(t *testing.T) {
+	result := GenerateDeckhouseModule(makeTestChart("my-module"), nil)
+
+	for _, ef := range result.ExternalFiles {
+		if strings.HasPrefix(ef.Path, "hooks/") && ef.Path != "hooks/README.md" {
+			t.Errorf("unexpected hook file %s without detected hooks", ef.Path)
+		}
+	}
+}
@@ -4,13 +4,25 @@ import (
 	"fmt"
 	"strings"
 
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
 // GenerateDeckhouseModule transforms a standard Helm chart into a Deckhouse module structure.
 // It adds helm_lib dependency, OpenAPI schemas, images/ and hooks/ directories,
 // and injects helm_lib helpers into templates.
-func GenerateDeckhouseModule(chart *types.GeneratedChart, values map[string]interface{}) *types.GeneratedChart {
+//
+// settings are the module settings (spec.settings of its ModuleConfig). They
+// define openapi/config-values.yaml, with their values as defaults, and are
+// added to values.yaml under the module values key (see ModuleValuesKey), as
+// Deckhouse passes them to the templates.
+//
+// hooks are the hooks the module needs (see DetectModuleHooks). hooks/ gets a
+// Go hook skeleton and test for each, and openapi/values.yaml declares the
+// internal values they set.
+func GenerateDeckhouseModule(chart *types.GeneratedChart, settings map[string]interface{}, hooks ...ModuleHook) *types.GeneratedChart {
 	result := *chart
 
 	// Modify Chart.yaml to add helm_lib dependency
@@ -19,12 +31,36 @@ func GenerateDeckhouseModule(chart *types.GeneratedChart, values map[string]inte
 	// Inject helm_lib includes into templates
 	result.Templates = injectHelmLibIncludes(chart.Templates)
 
+	// Map the module settings into values.yaml
+	if len(settings) > 0 {
+		result.ValuesYAML = appendModuleSettings(chart.ValuesYAML, chart.Name, settings)
+	}
+
 	// Generate external files
-	result.ExternalFiles = generateModuleExternalFiles(chart.Name, values)
+	result.ExternalFiles = append(append([]types.ExternalFileInfo{}, chart.ExternalFiles...),
+		generateModuleExternalFiles(chart.Name, settings, hooks)...)
 
 	return &result
 }
 
+// ModuleValuesKey returns the values key Deckhouse passes the settings of a
+// module under: the module name in lowerCamelCase (my-module -> myModule).
+func ModuleValuesKey(moduleName string) string {
+	return processor.SanitizeServiceName(moduleName)
+}
+
+func appendModuleSettings(valuesYAML, moduleName string, settings map[string]interface{}) string {
+	data, err := yaml.Marshal(map[string]interface{}{ModuleValuesKey(moduleName): settings})
+	if err != nil {
+		return valuesYAML
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(valuesYAML, "\n"))
+	sb.WriteString("\n\n# Module settings (ModuleConfig spec.settings), validated by openapi/config-values.yaml\n")
+	sb.Write(data)
+	return sb.String()
+}
+
 func injectHelmLibDep(chartYAML string) string {
 	if strings.Contains(chartYAML, "helm_lib") {
 		return chartYAML
@@ -59,21 +95,20 @@ func addHelmLibComment(content string) string {
 	return header + content
 }
 
-func generateModuleExternalFiles(chartName string, values map[string]interface{}) []types.ExternalFileInfo {
+func generateModuleExternalFiles(chartName string, settings map[string]interface{}, hooks []ModuleHook) []types.ExternalFileInfo {
 	files := make([]types.ExternalFileInfo, 0, 5)
 
 	// openapi/config-values.yaml — public config schema
-	configSchema := GenerateOpenAPISchema(values)
+	configSchema := GenerateConfigValuesSchema(settings)
 	files = append(files, types.ExternalFileInfo{
 		Path:    "openapi/config-values.yaml",
 		Content: configSchema,
 	})
 
 	// openapi/values.yaml — internal values schema
-	internalSchema := "type: object\nadditionalProperties: true\nproperties:\n  internal:\n    type: object\n"
 	files = append(files, types.ExternalFileInfo{
 		Path:    "openapi/values.yaml",
-		Content: internalSchema,
+		Content: moduleInternalValuesSchema(hooks),
 	})
 
 	// images/README.md
@@ -82,7 +117,10 @@ func generateModuleExternalFiles(chartName string, values map[string]interface{}
 		Content: fmt.Sprintf("# Images for %s\n\nPlace Dockerfile directories here.\n", chartName),
 	})
 
-	// hooks/README.md
+	// hooks/ — Go hooks for the detected needs, or a README to add them
+	if len(hooks) > 0 {
+		return append(files, moduleHookFiles(chartName, hooks)...)
+	}
 	files = append(files, types.ExternalFileInfo{
 		Path:    "hooks/README.md",
 		Content: fmt.Sprintf("# Hooks for %s\n\nPlace Go or Shell hooks here.\n", chartName),
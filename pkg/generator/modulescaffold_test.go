@@ -170,3 +170,30 @@ func TestModuleScaffold_DefaultDisabled(t *testing.T) {
 		t.Error("Expected DeckhouseModule to default to false")
 	}
 }
+
+func TestModuleScaffold_MapsSettingsToValues(t *testing.T) {
+	chart := makeTestChart("my-module")
+	chart.ExternalFiles = []types.ExternalFileInfo{{Path: "files/config.json", Content: "{}"}}
+
+	result := GenerateDeckhouseModule(chart, map[string]interface{}{"logLevel": "debug"})
+
+	if !strings.Contains(result.ValuesYAML, "global: {}\n") {
+		t.Errorf("expected the chart values to be kept:\n%s", result.ValuesYAML)
+	}
+	if !strings.Contains(result.ValuesYAML, "\nmyModule:\n  logLevel: debug\n") {
+		t.Errorf("expected the settings under myModule:\n%s", result.ValuesYAML)
+	}
+	if result.ExternalFiles[0].Path != "files/config.json" {
+		t.Errorf("expected the chart external files to be kept, got %v", result.ExternalFiles)
+	}
+}
+
+func TestModuleScaffold_NoSettings(t *testing.T) {
+	chart := makeTestChart("my-module")
+
+	result := GenerateDeckhouseModule(chart, nil)
+
+	if result.ValuesYAML != chart.ValuesYAML {
+		t.Errorf("expected values.yaml unchanged without settings:\n%s", result.ValuesYAML)
+	}
+}
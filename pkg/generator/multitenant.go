@@ -39,6 +39,15 @@ func GenerateMultiTenantOverlay(chart *types.GeneratedChart, tenantCount int) *t
 	if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &existingValues); err != nil {
 		existingValues = make(map[string]interface{})
 	}
+	return applyTenantOverlay(chart, existingValues, tenants)
+}
+
+// applyTenantOverlay sets the tenants values and adds the tenant templates.
+// Returns chart unchanged if the values cannot be encoded.
+func applyTenantOverlay(chart *types.GeneratedChart, existingValues map[string]interface{}, tenants []interface{}) *types.GeneratedChart {
+	if existingValues == nil {
+		existingValues = make(map[string]interface{})
+	}
 	existingValues["tenants"] = tenants
 
 	newValuesBytes, err := yaml.Marshal(existingValues)
@@ -75,6 +84,8 @@ func generateTenantNamespaceTemplate(chartName string) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("{{- /* Tenant Namespaces for %s */ -}}\n", chartName))
 	sb.WriteString("{{- range .Values.tenants }}\n")
+	sb.WriteString("{{- /* The release namespace of a per-tenant install already exists */}}\n")
+	sb.WriteString("{{- if ne .namespace $.Release.Namespace }}\n")
 	sb.WriteString("---\n")
 	sb.WriteString("apiVersion: v1\n")
 	sb.WriteString("kind: Namespace\n")
@@ -83,6 +94,8 @@ func generateTenantNamespaceTemplate(chartName string) string {
 	sb.WriteString("  labels:\n")
 	sb.WriteString("    tenant: {{ .name }}\n")
 	sb.WriteString(fmt.Sprintf("    app.kubernetes.io/managed-by: %s\n", chartName))
+	writePodSecurityLabels(&sb, "$.Values.namespace", "    ")
+	sb.WriteString("{{- end }}\n")
 	sb.WriteString("{{- end }}\n")
 	return sb.String()
 }
@@ -103,6 +116,12 @@ func generateTenantResourceQuotaTemplate(chartName string) string {
 	sb.WriteString("    requests.memory: {{ .resources.memory }}\n")
 	sb.WriteString("    limits.cpu: {{ .resources.cpu }}\n")
 	sb.WriteString("    limits.memory: {{ .resources.memory }}\n")
+	sb.WriteString("    {{- with .resources.pods }}\n")
+	sb.WriteString("    pods: {{ . | quote }}\n")
+	sb.WriteString("    {{- end }}\n")
+	sb.WriteString("    {{- with .resources.storage }}\n")
+	sb.WriteString("    requests.storage: {{ . }}\n")
+	sb.WriteString("    {{- end }}\n")
 	sb.WriteString("{{- end }}\n")
 	return sb.String()
 }
@@ -150,11 +169,23 @@ func generateTenantNetworkPolicyTemplate(chartName string) string {
 	sb.WriteString("        - namespaceSelector:\n")
 	sb.WriteString("            matchLabels:\n")
 	sb.WriteString("              tenant: {{ .name }}\n")
+	sb.WriteString("        - namespaceSelector:\n")
+	sb.WriteString("            matchLabels:\n")
+	sb.WriteString("              kubernetes.io/metadata.name: {{ .namespace }}\n")
+	sb.WriteString("    {{- with .ingressNamespace }}\n")
+	sb.WriteString("    - from:\n")
+	sb.WriteString("        - namespaceSelector:\n")
+	sb.WriteString("            matchLabels:\n")
+	sb.WriteString("              kubernetes.io/metadata.name: {{ . }}\n")
+	sb.WriteString("    {{- end }}\n")
 	sb.WriteString("  egress:\n")
 	sb.WriteString("    - to:\n")
 	sb.WriteString("        - namespaceSelector:\n")
 	sb.WriteString("            matchLabels:\n")
 	sb.WriteString("              tenant: {{ .name }}\n")
+	sb.WriteString("        - namespaceSelector:\n")
+	sb.WriteString("            matchLabels:\n")
+	sb.WriteString("              kubernetes.io/metadata.name: {{ .namespace }}\n")
 	sb.WriteString("    - to:\n")
 	sb.WriteString("        - namespaceSelector:\n")
 	sb.WriteString("            matchLabels:\n")
@@ -162,6 +162,11 @@ func TestMultiTenant_NamespaceTemplate(t *testing.T) {
 	if !hasNamespace {
 		t.Error("expected tenant Namespace template with range loop")
 	}
+
+	ns := result.Templates["templates/tenant-namespaces.yaml"]
+	if !strings.Contains(ns, "pod-security.kubernetes.io/enforce: {{ . }}") || !strings.Contains(ns, "{{- with $.Values.namespace }}") {
+		t.Errorf("expected tenant namespaces labeled with namespace.podSecurity:\n%s", ns)
+	}
 }
 
 // ============================================================
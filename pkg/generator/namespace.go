@@ -3,6 +3,8 @@ package generator
 import (
 	"fmt"
 	"strings"
+
+	"sigs.k8s.io/yaml"
 )
 
 // NamespaceOpts configures namespace resource generation.
@@ -10,6 +12,18 @@ type NamespaceOpts struct {
 	ResourceQuota bool
 	LimitRange    bool
 	NetworkPolicy bool
+
+	// Preset sizes the ResourceQuota and LimitRange (the medium preset
+	// when zero).
+	Preset NamespacePreset
+
+	// PodSecurity is the default level of the pod-security.kubernetes.io
+	// labels of the release Namespace; no Namespace template when empty.
+	PodSecurity PSSLevel
+
+	// ChartName is the name of the chart the templates are written to,
+	// whose helpers they include (the name of each group when empty).
+	ChartName string
 }
 
 // GenerateNamespaceResources generates namespace-level governance templates.
@@ -20,100 +34,199 @@ func GenerateNamespaceResources(groups []*ServiceGroup, opts NamespaceOpts) map[
 	}
 
 	result := make(map[string]string)
+	if opts.PodSecurity != "" {
+		result["templates/namespace.yaml"] = GenerateNamespaceTemplate()
+	}
 
 	for _, group := range groups {
 		if group == nil {
 			continue
 		}
+		chartName := opts.ChartName
+		if chartName == "" {
+			chartName = group.Name
+		}
 		if opts.ResourceQuota {
 			path := fmt.Sprintf("templates/%s-resourcequota.yaml", group.Name)
-			result[path] = GenerateResourceQuotaTemplate(group)
+			result[path] = GenerateResourceQuotaTemplateFor(chartName, group)
 		}
 		if opts.LimitRange {
 			path := fmt.Sprintf("templates/%s-limitrange.yaml", group.Name)
-			result[path] = GenerateLimitRangeTemplate(group)
+			result[path] = GenerateLimitRangeTemplateFor(chartName, group)
 		}
 		if opts.NetworkPolicy {
 			path := fmt.Sprintf("templates/%s-networkpolicy-default.yaml", group.Name)
-			result[path] = GenerateNetworkPolicyTemplate(group)
+			result[path] = GenerateNetworkPolicyTemplateFor(chartName, group)
 		}
 	}
 
 	return result
 }
 
-// GenerateResourceQuotaTemplate generates a ResourceQuota template from aggregated resources.
-func GenerateResourceQuotaTemplate(group *ServiceGroup) string {
-	cpuReq, memReq, cpuLim, memLim := extractFirstResourceValues(group)
-
-	if cpuReq == "" {
-		cpuReq = "1"
+// AppendNamespaceValues appends the namespace section of the templates of
+// GenerateNamespaceResources to valuesYAML: their toggles and the quota and
+// LimitRange of each group, sized by opts.Preset.
+func AppendNamespaceValues(valuesYAML string, groups []*ServiceGroup, opts NamespaceOpts) string {
+	toggles := make(map[string]interface{})
+	if opts.ResourceQuota {
+		hard := make(map[string]interface{})
+		for _, group := range groups {
+			if group != nil {
+				hard[group.Name] = namespaceQuotaValues(SizeNamespaceQuota(group, opts.Preset))
+			}
+		}
+		toggles["resourceQuota"] = map[string]interface{}{"enabled": true, "hard": hard}
+	}
+	if opts.LimitRange {
+		preset := opts.Preset.withDefaults()
+		limits := make(map[string]interface{})
+		for _, group := range groups {
+			if group != nil {
+				limits[group.Name] = map[string]interface{}{
+					"default":        namespaceResourcesValues(preset.LimitRange.Default),
+					"defaultRequest": namespaceResourcesValues(preset.LimitRange.DefaultRequest),
+					"max":            namespaceResourcesValues(SizeNamespaceQuota(group, preset).MaxContainer),
+				}
+			}
+		}
+		toggles["limitRange"] = map[string]interface{}{"enabled": true, "limits": limits}
+	}
+	if opts.NetworkPolicy {
+		toggles["networkPolicy"] = map[string]interface{}{"enabled": true}
+	}
+	if opts.PodSecurity != "" {
+		// The release namespace usually exists before the install
+		// (helm install --create-namespace); charts applied without Helm,
+		// with werf or as a Deckhouse module may create it.
+		toggles["create"] = false
+		toggles["podSecurity"] = map[string]interface{}{
+			"enforce": string(opts.PodSecurity),
+			"audit":   string(opts.PodSecurity),
+			"warn":    string(opts.PodSecurity),
+		}
 	}
-	if memReq == "" {
-		memReq = "1Gi"
+	if len(toggles) == 0 {
+		return valuesYAML
 	}
-	if cpuLim == "" {
-		cpuLim = "2"
+	data, err := yaml.Marshal(map[string]interface{}{"namespace": toggles})
+	if err != nil {
+		return valuesYAML
 	}
-	if memLim == "" {
-		memLim = "2Gi"
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(valuesYAML, "\n"))
+	sb.WriteString("\n\n# Namespace governance resources (--namespace-resources)\n")
+	sb.Write(data)
+	return sb.String()
+}
+
+// namespaceQuotaValues returns the spec.hard of a ResourceQuota of size.
+func namespaceQuotaValues(size NamespaceQuotaSize) map[string]interface{} {
+	hard := map[string]interface{}{
+		"requests.cpu":    size.Requests.CPU,
+		"requests.memory": size.Requests.Memory,
+		"limits.cpu":      size.Limits.CPU,
+		"limits.memory":   size.Limits.Memory,
+	}
+	if size.Pods > 0 {
+		hard["pods"] = fmt.Sprintf("%d", size.Pods)
+	}
+	return hard
+}
+
+// namespaceResourcesValues returns the cpu and memory of r.
+func namespaceResourcesValues(r NamespaceResources) map[string]interface{} {
+	return map[string]interface{}{"cpu": r.CPU, "memory": r.Memory}
+}
+
+// GenerateNamespaceTemplate generates the release Namespace, created with
+// namespace.create, labeled with the Pod Security admission levels of
+// namespace.podSecurity.
+func GenerateNamespaceTemplate() string {
+	var sb strings.Builder
+	sb.WriteString("{{- if .Values.namespace.create }}\n")
+	sb.WriteString("apiVersion: v1\n")
+	sb.WriteString("kind: Namespace\n")
+	sb.WriteString("metadata:\n")
+	sb.WriteString("  name: {{ .Release.Namespace }}\n")
+	sb.WriteString("  labels:\n")
+	sb.WriteString("    app.kubernetes.io/managed-by: {{ .Release.Service }}\n")
+	writePodSecurityLabels(&sb, ".Values.namespace", "    ")
+	sb.WriteString("  annotations:\n")
+	sb.WriteString("    # Keep the namespace and its workloads on helm uninstall\n")
+	sb.WriteString("    helm.sh/resource-policy: keep\n")
+	sb.WriteString("{{- end }}\n")
+	return sb.String()
+}
+
+// writePodSecurityLabels writes the pod-security.kubernetes.io labels of the
+// podSecurity levels under namespaceValues; an empty level leaves its label
+// out.
+func writePodSecurityLabels(sb *strings.Builder, namespaceValues, indent string) {
+	sb.WriteString(fmt.Sprintf("%s{{- with %s }}\n", indent, namespaceValues))
+	sb.WriteString(indent + "{{- with .podSecurity }}\n")
+	for _, mode := range []string{"enforce", "audit", "warn"} {
+		sb.WriteString(fmt.Sprintf("%s{{- with .%s }}\n", indent, mode))
+		sb.WriteString(fmt.Sprintf("%spod-security.kubernetes.io/%s: {{ . }}\n", indent, mode))
+		sb.WriteString(indent + "{{- end }}\n")
 	}
+	sb.WriteString(indent + "{{- end }}\n")
+	sb.WriteString(indent + "{{- end }}\n")
+}
 
+// GenerateResourceQuotaTemplate generates the ResourceQuota template of a
+// group including the helpers of the group chart.
+func GenerateResourceQuotaTemplate(group *ServiceGroup) string {
+	return GenerateResourceQuotaTemplateFor(group.Name, group)
+}
+
+// GenerateResourceQuotaTemplateFor generates the ResourceQuota template of a
+// group in chart chartName. The quota is namespace.resourceQuota.hard.<group>,
+// set by AppendNamespaceValues.
+func GenerateResourceQuotaTemplateFor(chartName string, group *ServiceGroup) string {
 	var sb strings.Builder
 	sb.WriteString("{{- if .Values.namespace.resourceQuota.enabled }}\n")
+	sb.WriteString(fmt.Sprintf("{{- with index (.Values.namespace.resourceQuota.hard | default dict) %q }}\n", group.Name))
 	sb.WriteString("apiVersion: v1\n")
 	sb.WriteString("kind: ResourceQuota\n")
 	sb.WriteString("metadata:\n")
-	sb.WriteString(fmt.Sprintf("  name: {{ include \"%s.fullname\" . }}-quota\n", group.Name))
-	sb.WriteString("  namespace: {{ .Release.Namespace }}\n")
+	sb.WriteString(fmt.Sprintf("  name: {{ include \"%s.fullname\" $ }}-%s-quota\n", chartName, group.Name))
+	sb.WriteString("  namespace: {{ $.Release.Namespace }}\n")
 	sb.WriteString("  labels:\n")
-	sb.WriteString(fmt.Sprintf("    {{- include \"%s.labels\" . | nindent 4 }}\n", group.Name))
+	sb.WriteString(fmt.Sprintf("    {{- include \"%s.labels\" $ | nindent 4 }}\n", chartName))
 	sb.WriteString("spec:\n")
 	sb.WriteString("  hard:\n")
-	sb.WriteString(fmt.Sprintf("    requests.cpu: \"%s\"\n", cpuReq))
-	sb.WriteString(fmt.Sprintf("    requests.memory: \"%s\"\n", memReq))
-	sb.WriteString(fmt.Sprintf("    limits.cpu: \"%s\"\n", cpuLim))
-	sb.WriteString(fmt.Sprintf("    limits.memory: \"%s\"\n", memLim))
+	sb.WriteString("    {{- toYaml . | nindent 4 }}\n")
+	sb.WriteString("{{- end }}\n")
 	sb.WriteString("{{- end }}\n")
 
 	return sb.String()
 }
 
-// GenerateLimitRangeTemplate generates a LimitRange template with defaults from workload analysis.
+// GenerateLimitRangeTemplate generates the LimitRange template of a group
+// including the helpers of the group chart.
 func GenerateLimitRangeTemplate(group *ServiceGroup) string {
-	cpuReq, memReq, cpuLim, memLim := extractFirstResourceValues(group)
-
-	if cpuReq == "" {
-		cpuReq = "100m"
-	}
-	if memReq == "" {
-		memReq = "128Mi"
-	}
-	if cpuLim == "" {
-		cpuLim = "500m"
-	}
-	if memLim == "" {
-		memLim = "512Mi"
-	}
+	return GenerateLimitRangeTemplateFor(group.Name, group)
+}
 
+// GenerateLimitRangeTemplateFor generates the LimitRange template of a group
+// in chart chartName. The container default, defaultRequest and max are
+// namespace.limitRange.limits.<group>, set by AppendNamespaceValues.
+func GenerateLimitRangeTemplateFor(chartName string, group *ServiceGroup) string {
 	var sb strings.Builder
 	sb.WriteString("{{- if .Values.namespace.limitRange.enabled }}\n")
+	sb.WriteString(fmt.Sprintf("{{- with index (.Values.namespace.limitRange.limits | default dict) %q }}\n", group.Name))
 	sb.WriteString("apiVersion: v1\n")
 	sb.WriteString("kind: LimitRange\n")
 	sb.WriteString("metadata:\n")
-	sb.WriteString(fmt.Sprintf("  name: {{ include \"%s.fullname\" . }}-limits\n", group.Name))
-	sb.WriteString("  namespace: {{ .Release.Namespace }}\n")
+	sb.WriteString(fmt.Sprintf("  name: {{ include \"%s.fullname\" $ }}-%s-limits\n", chartName, group.Name))
+	sb.WriteString("  namespace: {{ $.Release.Namespace }}\n")
 	sb.WriteString("  labels:\n")
-	sb.WriteString(fmt.Sprintf("    {{- include \"%s.labels\" . | nindent 4 }}\n", group.Name))
+	sb.WriteString(fmt.Sprintf("    {{- include \"%s.labels\" $ | nindent 4 }}\n", chartName))
 	sb.WriteString("spec:\n")
 	sb.WriteString("  limits:\n")
 	sb.WriteString("    - type: Container\n")
-	sb.WriteString("      default:\n")
-	sb.WriteString(fmt.Sprintf("        cpu: \"%s\"\n", cpuLim))
-	sb.WriteString(fmt.Sprintf("        memory: \"%s\"\n", memLim))
-	sb.WriteString("      defaultRequest:\n")
-	sb.WriteString(fmt.Sprintf("        cpu: \"%s\"\n", cpuReq))
-	sb.WriteString(fmt.Sprintf("        memory: \"%s\"\n", memReq))
+	sb.WriteString("      {{- toYaml . | nindent 6 }}\n")
+	sb.WriteString("{{- end }}\n")
 	sb.WriteString("{{- end }}\n")
 
 	return sb.String()
@@ -121,13 +234,19 @@ func GenerateLimitRangeTemplate(group *ServiceGroup) string {
 
 // GenerateNetworkPolicyTemplate generates a default deny-all + allow same-namespace NetworkPolicy.
 func GenerateNetworkPolicyTemplate(group *ServiceGroup) string {
+	return GenerateNetworkPolicyTemplateFor(group.Name, group)
+}
+
+// GenerateNetworkPolicyTemplateFor generates the NetworkPolicy of
+// GenerateNetworkPolicyTemplate in chart chartName.
+func GenerateNetworkPolicyTemplateFor(chartName string, group *ServiceGroup) string {
 	var sb strings.Builder
 
 	sb.WriteString("{{- if .Values.namespace.networkPolicy.enabled }}\n")
 	sb.WriteString("apiVersion: networking.k8s.io/v1\n")
 	sb.WriteString("kind: NetworkPolicy\n")
 	sb.WriteString("metadata:\n")
-	sb.WriteString(fmt.Sprintf("  name: {{ include \"%s.fullname\" . }}-default\n", group.Name))
+	sb.WriteString(fmt.Sprintf("  name: {{ include \"%s.fullname\" . }}-%s-default\n", chartName, group.Name))
 	sb.WriteString("  namespace: {{ .Release.Namespace }}\n")
 	sb.WriteString("spec:\n")
 	sb.WriteString("  podSelector: {}\n")
@@ -159,33 +278,3 @@ func GenerateNetworkPolicyTemplate(group *ServiceGroup) string {
 
 	return sb.String()
 }
-
-// TODO(HC-4): Implement proper aggregation using k8s.io/apimachinery/pkg/api/resource.Quantity
-
-// extractFirstResourceValues returns the first non-empty resource values found in the group's workloads.
-// For accurate quota calculation with multiple workloads, implement proper aggregation.
-func extractFirstResourceValues(group *ServiceGroup) (cpuReq, memReq, cpuLim, memLim string) {
-	for _, r := range group.Resources {
-		resources, ok := r.Values["resources"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		if req, ok := resources["requests"].(map[string]interface{}); ok {
-			if v, ok := req["cpu"].(string); ok && cpuReq == "" {
-				cpuReq = v
-			}
-			if v, ok := req["memory"].(string); ok && memReq == "" {
-				memReq = v
-			}
-		}
-		if lim, ok := resources["limits"].(map[string]interface{}); ok {
-			if v, ok := lim["cpu"].(string); ok && cpuLim == "" {
-				cpuLim = v
-			}
-			if v, ok := lim["memory"].(string); ok && memLim == "" {
-				memLim = v
-			}
-		}
-	}
-	return
-}
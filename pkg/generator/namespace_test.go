@@ -1,9 +1,11 @@
 package generator
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -347,3 +349,114 @@ func TestNamespace_GenerateNamespaceResources_MultipleGroups(t *testing.T) {
 		t.Errorf("expected at least 2 templates (one per group), got %d", len(result))
 	}
 }
+
+func TestAppendNamespaceValues(t *testing.T) {
+	groups := []*ServiceGroup{makeGroup("web", "default", nil)}
+	values := AppendNamespaceValues("global: {}\n", groups, NamespaceOpts{ResourceQuota: true, LimitRange: true, NetworkPolicy: true})
+	want := `global: {}
+
+# Namespace governance resources (--namespace-resources)
+namespace:
+  limitRange:
+    enabled: true
+    limits:
+      web:
+        default:
+          cpu: 500m
+          memory: 512Mi
+        defaultRequest:
+          cpu: 100m
+          memory: 128Mi
+        max:
+          cpu: "2"
+          memory: 4Gi
+  networkPolicy:
+    enabled: true
+  resourceQuota:
+    enabled: true
+    hard:
+      web:
+        limits.cpu: "2"
+        limits.memory: 2Gi
+        pods: "10"
+        requests.cpu: "1"
+        requests.memory: 1Gi
+`
+	if values != want {
+		t.Errorf("got:\n%s\nwant:\n%s", values, want)
+	}
+	if got := AppendNamespaceValues("global: {}\n", groups, NamespaceOpts{}); got != "global: {}\n" {
+		t.Errorf("expected values unchanged without templates, got:\n%s", got)
+	}
+}
+
+func TestGenerateNamespaceResources_PodSecurity(t *testing.T) {
+	groups := []*ServiceGroup{makeGroup("web", "default", nil)}
+	if _, ok := GenerateNamespaceResources(groups, NamespaceOpts{ResourceQuota: true})["templates/namespace.yaml"]; ok {
+		t.Error("expected no Namespace template without a Pod Security level")
+	}
+
+	ns := GenerateNamespaceResources(groups, NamespaceOpts{PodSecurity: PSSRestricted})["templates/namespace.yaml"]
+	for _, want := range []string{
+		"{{- if .Values.namespace.create }}",
+		"kind: Namespace",
+		"name: {{ .Release.Namespace }}",
+		"{{- with .Values.namespace }}",
+		"pod-security.kubernetes.io/enforce: {{ . }}",
+		"pod-security.kubernetes.io/audit: {{ . }}",
+		"pod-security.kubernetes.io/warn: {{ . }}",
+		"helm.sh/resource-policy: keep",
+	} {
+		if !strings.Contains(ns, want) {
+			t.Errorf("expected %q in Namespace template:\n%s", want, ns)
+		}
+	}
+
+	values := AppendNamespaceValues("global: {}\n", groups, NamespaceOpts{PodSecurity: PSSBaseline})
+	want := "namespace:\n  create: false\n  podSecurity:\n    audit: baseline\n    enforce: baseline\n    warn: baseline\n"
+	if !strings.Contains(values, want) {
+		t.Errorf("expected %q in values:\n%s", want, values)
+	}
+}
+
+func TestGenerateNamespaceResources_Render(t *testing.T) {
+	web := makeWorkload("Deployment", "web", 2, map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+		"limits":   map[string]interface{}{"cpu": "1", "memory": "2Gi"},
+	})
+	groups := []*ServiceGroup{makeGroup("web", "default", []*types.ProcessedResource{web})}
+	opts := NamespaceOpts{ResourceQuota: true, LimitRange: true, NetworkPolicy: true, PodSecurity: PSSRestricted, ChartName: "shop"}
+
+	chart := makeChart("shop", GenerateNamespaceResources(groups, opts))
+	chart.ValuesYAML = AppendNamespaceValues("global: {}\n", groups, opts)
+	chart.Helpers = helm.GenerateHelpers("shop")
+	dir := t.TempDir()
+	if err := WriteChart(chart, dir); err != nil {
+		t.Fatalf("WriteChart: %v", err)
+	}
+
+	// Quota amounts are values.
+	rendered, err := helm.RenderChart(filepath.Join(dir, "shop"), helm.RenderOptions{Values: map[string]interface{}{
+		"namespace": map[string]interface{}{"resourceQuota": map[string]interface{}{"hard": map[string]interface{}{
+			"web": map[string]interface{}{"requests.cpu": "8"},
+		}}},
+	}})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	quota := rendered.Manifests["templates/web-resourcequota.yaml"]
+	for _, want := range []string{"kind: ResourceQuota", "app.kubernetes.io/name: shop", `requests.cpu: "8"`, "limits.memory: 6Gi"} {
+		if !strings.Contains(quota, want) {
+			t.Errorf("expected %q in the ResourceQuota:\n%s", want, quota)
+		}
+	}
+	limits := rendered.Manifests["templates/web-limitrange.yaml"]
+	for _, want := range []string{"kind: LimitRange", "- type: Container\n      default:\n", "max:\n        cpu: \"2\"\n        memory: 4Gi"} {
+		if !strings.Contains(limits, want) {
+			t.Errorf("expected %q in the LimitRange:\n%s", want, limits)
+		}
+	}
+	if policy := rendered.Manifests["templates/web-networkpolicy-default.yaml"]; !strings.Contains(policy, "kind: NetworkPolicy") {
+		t.Errorf("expected the NetworkPolicy to render:\n%s", policy)
+	}
+}
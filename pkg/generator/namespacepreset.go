@@ -0,0 +1,306 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultNamespacePreset is the preset of --namespace-resources without
+// --namespace-preset.
+const DefaultNamespacePreset = "medium"
+
+// NamespacePreset sizes the ResourceQuota and LimitRange of
+// --namespace-resources. A preset file:
+//
+//	# Quota = summed requests and limits of the workloads x replicas x headroom
+//	headroom: 1.5
+//	# Floor of the quota, e.g. for a namespace that grows
+//	minimum:
+//	  requests: {cpu: "1", memory: 1Gi}
+//	  limits: {cpu: "2", memory: 2Gi}
+//	  pods: 10
+//	# Container defaults, also used to size containers without requests
+//	limitRange:
+//	  defaultRequest: {cpu: 100m, memory: 128Mi}
+//	  default: {cpu: 500m, memory: 512Mi}
+//	  max: {cpu: "2", memory: 4Gi}
+//
+// Fields left out keep the values of the medium preset.
+type NamespacePreset struct {
+	// Headroom multiplies the summed resources and pods, leaving room for
+	// rolling updates and scaling; at least 1.
+	Headroom float64 `json:"headroom,omitempty"`
+
+	Minimum    NamespaceQuota      `json:"minimum,omitempty"`
+	LimitRange NamespaceLimitRange `json:"limitRange,omitempty"`
+}
+
+// NamespaceResources is a CPU and memory pair of quantities.
+type NamespaceResources struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// NamespaceQuota is the hard limits of a ResourceQuota. Pods are only
+// limited when not zero.
+type NamespaceQuota struct {
+	Requests NamespaceResources `json:"requests,omitempty"`
+	Limits   NamespaceResources `json:"limits,omitempty"`
+	Pods     int64              `json:"pods,omitempty"`
+}
+
+// NamespaceLimitRange is the container limits of a LimitRange.
+type NamespaceLimitRange struct {
+	DefaultRequest NamespaceResources `json:"defaultRequest,omitempty"`
+	Default        NamespaceResources `json:"default,omitempty"`
+	Max            NamespaceResources `json:"max,omitempty"`
+}
+
+// NamespacePresets are the built-in presets of --namespace-preset.
+var NamespacePresets = map[string]NamespacePreset{
+	"small": {
+		Headroom: 1.25,
+		Minimum: NamespaceQuota{
+			Requests: NamespaceResources{CPU: "500m", Memory: "512Mi"},
+			Limits:   NamespaceResources{CPU: "1", Memory: "1Gi"},
+			Pods:     5,
+		},
+		LimitRange: NamespaceLimitRange{
+			DefaultRequest: NamespaceResources{CPU: "50m", Memory: "64Mi"},
+			Default:        NamespaceResources{CPU: "250m", Memory: "256Mi"},
+			Max:            NamespaceResources{CPU: "1", Memory: "1Gi"},
+		},
+	},
+	"medium": {
+		Headroom: 1.5,
+		Minimum: NamespaceQuota{
+			Requests: NamespaceResources{CPU: "1", Memory: "1Gi"},
+			Limits:   NamespaceResources{CPU: "2", Memory: "2Gi"},
+			Pods:     10,
+		},
+		LimitRange: NamespaceLimitRange{
+			DefaultRequest: NamespaceResources{CPU: "100m", Memory: "128Mi"},
+			Default:        NamespaceResources{CPU: "500m", Memory: "512Mi"},
+			Max:            NamespaceResources{CPU: "2", Memory: "4Gi"},
+		},
+	},
+	"large": {
+		Headroom: 2,
+		Minimum: NamespaceQuota{
+			Requests: NamespaceResources{CPU: "4", Memory: "8Gi"},
+			Limits:   NamespaceResources{CPU: "8", Memory: "16Gi"},
+			Pods:     50,
+		},
+		LimitRange: NamespaceLimitRange{
+			DefaultRequest: NamespaceResources{CPU: "250m", Memory: "256Mi"},
+			Default:        NamespaceResources{CPU: "1", Memory: "1Gi"},
+			Max:            NamespaceResources{CPU: "8", Memory: "16Gi"},
+		},
+	},
+}
+
+// IsNamespacePreset reports whether name is a built-in preset.
+func IsNamespacePreset(name string) bool {
+	_, ok := NamespacePresets[name]
+	return ok
+}
+
+// namespacePresetNames returns the built-in preset names, sorted.
+func namespacePresetNames() string {
+	names := make([]string, 0, len(NamespacePresets))
+	for name := range NamespacePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// LoadNamespacePreset returns a built-in preset by name, or reads a preset
+// file.
+func LoadNamespacePreset(nameOrFile string) (NamespacePreset, error) {
+	if preset, ok := NamespacePresets[nameOrFile]; ok {
+		return preset, nil
+	}
+	data, err := os.ReadFile(nameOrFile)
+	if err != nil {
+		return NamespacePreset{}, fmt.Errorf("namespace preset %q is not one of %s or a readable file: %w", nameOrFile, namespacePresetNames(), err)
+	}
+	return ParseNamespacePreset(data)
+}
+
+// ParseNamespacePreset parses and validates a preset file, filling the
+// fields left out from the medium preset.
+func ParseNamespacePreset(data []byte) (NamespacePreset, error) {
+	var p NamespacePreset
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return NamespacePreset{}, fmt.Errorf("namespace preset: %w", err)
+	}
+	if p.Headroom != 0 && p.Headroom < 1 {
+		return NamespacePreset{}, fmt.Errorf("namespace preset: headroom must be at least 1, got %g", p.Headroom)
+	}
+	if p.Minimum.Pods < 0 {
+		return NamespacePreset{}, fmt.Errorf("namespace preset: minimum.pods must not be negative")
+	}
+	p = p.withDefaults()
+	for field, q := range map[string]string{
+		"minimum.requests.cpu":             p.Minimum.Requests.CPU,
+		"minimum.requests.memory":          p.Minimum.Requests.Memory,
+		"minimum.limits.cpu":               p.Minimum.Limits.CPU,
+		"minimum.limits.memory":            p.Minimum.Limits.Memory,
+		"limitRange.defaultRequest.cpu":    p.LimitRange.DefaultRequest.CPU,
+		"limitRange.defaultRequest.memory": p.LimitRange.DefaultRequest.Memory,
+		"limitRange.default.cpu":           p.LimitRange.Default.CPU,
+		"limitRange.default.memory":        p.LimitRange.Default.Memory,
+		"limitRange.max.cpu":               p.LimitRange.Max.CPU,
+		"limitRange.max.memory":            p.LimitRange.Max.Memory,
+	} {
+		if q == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(q); err != nil {
+			return NamespacePreset{}, fmt.Errorf("namespace preset: %s %q: %w", field, q, err)
+		}
+	}
+	return p, nil
+}
+
+// withDefaults fills the fields of p left out from the medium preset.
+func (p NamespacePreset) withDefaults() NamespacePreset {
+	d := NamespacePresets[DefaultNamespacePreset]
+	if p.Headroom == 0 {
+		p.Headroom = d.Headroom
+	}
+	fill := func(r *NamespaceResources, def NamespaceResources) {
+		if r.CPU == "" {
+			r.CPU = def.CPU
+		}
+		if r.Memory == "" {
+			r.Memory = def.Memory
+		}
+	}
+	fill(&p.Minimum.Requests, d.Minimum.Requests)
+	fill(&p.Minimum.Limits, d.Minimum.Limits)
+	if p.Minimum.Pods == 0 {
+		p.Minimum.Pods = d.Minimum.Pods
+	}
+	fill(&p.LimitRange.DefaultRequest, d.LimitRange.DefaultRequest)
+	fill(&p.LimitRange.Default, d.LimitRange.Default)
+	fill(&p.LimitRange.Max, d.LimitRange.Max)
+	return p
+}
+
+// NamespaceQuotaSize is the quota of a service group sized by a preset.
+type NamespaceQuotaSize struct {
+	NamespaceQuota
+
+	// MaxContainer is the LimitRange max: that of the preset, raised to the
+	// largest container limits of the group.
+	MaxContainer NamespaceResources
+}
+
+// SizeNamespaceQuota sizes the quota of a group: the requests and limits of
+// the containers of its workloads, times their replicas (Job parallelism),
+// summed, times the headroom of preset, and at least the preset minimum.
+// Containers without requests or limits count with the LimitRange defaults
+// that the API server would give them; a request left out defaults to the
+// limit, as in Kubernetes. Pods are the summed replicas times the headroom.
+func SizeNamespaceQuota(group *ServiceGroup, preset NamespacePreset) NamespaceQuotaSize {
+	preset = preset.withDefaults()
+	defaults := preset.LimitRange
+
+	// CPU in millicores, memory in bytes.
+	var reqCPU, reqMem, limCPU, limMem, maxCPU, maxMem, pods int64
+	for _, r := range group.Resources {
+		if r == nil || r.Original == nil || r.Original.Object == nil || !isWorkloadKind(r.Original.Object.GetKind()) {
+			continue
+		}
+		obj := r.Original.Object
+		replicas := int64(extractReplicasFromResource(obj))
+		pods += replicas
+		containers, _ := extractContainersFromObj(obj)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			requests := containerResources(container, "requests")
+			limits := containerResources(container, "limits")
+
+			cpuLim := quantityOr(limits["cpu"], defaults.Default.CPU).MilliValue()
+			memLim := quantityOr(limits["memory"], defaults.Default.Memory).Value()
+			cpuReq := quantityOr(requests["cpu"], firstNonEmpty(limits["cpu"], defaults.DefaultRequest.CPU)).MilliValue()
+			memReq := quantityOr(requests["memory"], firstNonEmpty(limits["memory"], defaults.DefaultRequest.Memory)).Value()
+
+			reqCPU += cpuReq * replicas
+			reqMem += memReq * replicas
+			limCPU += cpuLim * replicas
+			limMem += memLim * replicas
+			maxCPU = max(maxCPU, cpuLim)
+			maxMem = max(maxMem, memLim)
+		}
+	}
+
+	size := NamespaceQuotaSize{}
+	size.Requests.CPU = sizeQuantity(reqCPU, preset.Headroom, preset.Minimum.Requests.CPU, true)
+	size.Requests.Memory = sizeQuantity(reqMem, preset.Headroom, preset.Minimum.Requests.Memory, false)
+	size.Limits.CPU = sizeQuantity(limCPU, preset.Headroom, preset.Minimum.Limits.CPU, true)
+	size.Limits.Memory = sizeQuantity(limMem, preset.Headroom, preset.Minimum.Limits.Memory, false)
+	size.Pods = max(int64(math.Ceil(float64(pods)*preset.Headroom)), preset.Minimum.Pods)
+	size.MaxContainer.CPU = sizeQuantity(maxCPU, 1, preset.LimitRange.Max.CPU, true)
+	size.MaxContainer.Memory = sizeQuantity(maxMem, 1, preset.LimitRange.Max.Memory, false)
+	return size
+}
+
+// containerResources returns the resource requests or limits of a
+// container as strings.
+func containerResources(container map[string]interface{}, field string) map[string]string {
+	resources := make(map[string]string)
+	raw, _, _ := unstructured.NestedMap(container, "resources", field)
+	for name, v := range raw {
+		resources[name] = fmt.Sprint(v)
+	}
+	return resources
+}
+
+// quantityOr parses s, or fallback when s is empty or invalid.
+func quantityOr(s, fallback string) *resource.Quantity {
+	if q, err := resource.ParseQuantity(s); err == nil {
+		return &q
+	}
+	q, _ := resource.ParseQuantity(fallback)
+	return &q
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sizeQuantity returns v times headroom, and at least minimum. CPU v is in
+// millicores and rounded up to 100m, memory v in bytes and rounded up to
+// 64Mi; the quota of a group without workloads is its minimum.
+func sizeQuantity(v int64, headroom float64, minimum string, cpu bool) string {
+	var q *resource.Quantity
+	if cpu {
+		q = resource.NewMilliQuantity(int64(math.Ceil(float64(v)*headroom/100))*100, resource.DecimalSI)
+	} else {
+		const step = 64 << 20
+		q = resource.NewQuantity(int64(math.Ceil(float64(v)*headroom/step))*step, resource.BinarySI)
+	}
+	if floor := quantityOr(minimum, "0"); q.Cmp(*floor) < 0 {
+		return floor.String()
+	}
+	return q.String()
+}
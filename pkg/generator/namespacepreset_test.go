@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// makeWorkload returns a workload with replicas and containers given as
+// resources maps.
+func makeWorkload(kind, name string, replicas int64, containers ...map[string]interface{}) *types.ProcessedResource {
+	r := makeProcessedResource(kind, name, "default", nil)
+	spec := make([]interface{}, 0, len(containers))
+	for i, c := range containers {
+		spec = append(spec, map[string]interface{}{"name": name + string(rune('a'+i)), "resources": c})
+	}
+	r.Original.Object.Object["spec"] = map[string]interface{}{
+		"replicas": replicas,
+		"template": map[string]interface{}{"spec": map[string]interface{}{"containers": spec}},
+	}
+	return r
+}
+
+func TestSizeNamespaceQuota(t *testing.T) {
+	web := makeWorkload("Deployment", "web", 3, map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "200m", "memory": "256Mi"},
+		"limits":   map[string]interface{}{"cpu": "1", "memory": "512Mi"},
+	})
+	// No resources: counted with the LimitRange defaults.
+	worker := makeWorkload("Deployment", "worker", 2, map[string]interface{}{})
+	// Limits only: requests default to the limits.
+	big := makeWorkload("StatefulSet", "db", 1, map[string]interface{}{
+		"limits": map[string]interface{}{"cpu": "3", "memory": "6Gi"},
+	})
+	cm := makeProcessedResource("ConfigMap", "config", "default", nil)
+	group := makeGroup("app", "default", []*types.ProcessedResource{web, worker, big, cm})
+
+	size := SizeNamespaceQuota(group, NamespacePreset{Headroom: 1})
+	// requests: 3x200m + 2x100m + 3 = 3800m; 3x256Mi + 2x128Mi + 6Gi = 7168Mi
+	// limits: 3x1 + 2x500m + 3 = 7; 3x512Mi + 2x512Mi + 6Gi = 8704Mi
+	want := NamespaceQuota{
+		Requests: NamespaceResources{CPU: "3800m", Memory: "7Gi"},
+		Limits:   NamespaceResources{CPU: "7", Memory: "8704Mi"},
+		Pods:     10,
+	}
+	if size.NamespaceQuota != want {
+		t.Errorf("quota = %+v, want %+v", size.NamespaceQuota, want)
+	}
+	if size.MaxContainer != (NamespaceResources{CPU: "3", Memory: "6Gi"}) {
+		t.Errorf("LimitRange max should be raised to the largest limits, got %+v", size.MaxContainer)
+	}
+
+	size = SizeNamespaceQuota(group, NamespacePresets["medium"])
+	if size.Requests.CPU != "5700m" || size.Limits.Memory != "13056Mi" || size.Pods != 10 {
+		t.Errorf("medium headroom 1.5: got %+v", size.NamespaceQuota)
+	}
+
+	empty := SizeNamespaceQuota(makeGroup("empty", "default", []*types.ProcessedResource{cm}), NamespacePresets["large"])
+	if empty.NamespaceQuota != NamespacePresets["large"].Minimum || empty.MaxContainer != NamespacePresets["large"].LimitRange.Max {
+		t.Errorf("a group without workloads should get the preset minimum, got %+v", empty)
+	}
+}
+
+func TestGenerateNamespaceResources_Preset(t *testing.T) {
+	web := makeWorkload("Deployment", "web", 4, map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+		"limits":   map[string]interface{}{"cpu": "1", "memory": "2Gi"},
+	})
+	groups := []*ServiceGroup{makeGroup("web", "default", []*types.ProcessedResource{web})}
+
+	values := AppendNamespaceValues("", groups, NamespaceOpts{ResourceQuota: true, LimitRange: true, Preset: NamespacePresets["small"]})
+	for _, want := range []string{
+		"hard:\n      web:\n        limits.cpu: \"5\"\n        limits.memory: 10Gi\n        pods: \"5\"\n        requests.cpu: 2500m\n        requests.memory: 5Gi\n",
+		"default:\n          cpu: 250m\n",
+		"defaultRequest:\n          cpu: 50m\n",
+		"max:\n          cpu: \"1\"\n          memory: 2Gi\n",
+	} {
+		if !strings.Contains(values, want) {
+			t.Errorf("expected %q in values:\n%s", want, values)
+		}
+	}
+}
+
+func TestLoadNamespacePreset(t *testing.T) {
+	if p, err := LoadNamespacePreset("large"); err != nil || p.Headroom != 2 {
+		t.Errorf("large: %+v, %v", p, err)
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "preset.yaml")
+	if err := os.WriteFile(file, []byte("headroom: 1.2\nminimum:\n  requests:\n    cpu: 250m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadNamespacePreset(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	medium := NamespacePresets["medium"]
+	if p.Headroom != 1.2 || p.Minimum.Requests.CPU != "250m" || p.Minimum.Requests.Memory != medium.Minimum.Requests.Memory || p.LimitRange != medium.LimitRange {
+		t.Errorf("fields left out should come from the medium preset, got %+v", p)
+	}
+
+	for content, want := range map[string]string{
+		"headroom: 0.5\n":                         "headroom must be at least 1",
+		"minimum:\n  limits:\n    memory: lots\n": "minimum.limits.memory",
+		"limitRange:\n  defaults: {cpu: 1}\n":     "unknown field",
+		"minimum:\n  pods: -1\n":                  "minimum.pods",
+	} {
+		if _, err := ParseNamespacePreset([]byte(content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected an error containing %q, got %v", content, want, err)
+		}
+	}
+	if _, err := LoadNamespacePreset("huge"); err == nil || !strings.Contains(err.Error(), "large, medium, small") {
+		t.Errorf("expected an unknown preset to list the presets, got %v", err)
+	}
+}
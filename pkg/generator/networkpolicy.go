@@ -6,6 +6,9 @@ import (
 	"strconv"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -30,7 +33,33 @@ var envPortMapping = map[string]int{
 	"ELASTIC_HOST":  9200,
 }
 
-// GenerateAutoNetworkPolicies creates fine-grained NetworkPolicies from service relationship analysis.
+// exposingKinds route traffic from outside the namespace, through the ingress
+// controller or a gateway, to the Services they reference.
+var exposingKinds = map[string]bool{
+	"Ingress":        true,
+	"HTTPRoute":      true,
+	"GRPCRoute":      true,
+	"TLSRoute":       true,
+	"TCPRoute":       true,
+	"UDPRoute":       true,
+	"VirtualService": true,
+}
+
+// GenerateAutoNetworkPolicies creates a least-privilege NetworkPolicy for the
+// workloads of each group from the traffic found in the graph:
+//
+//   - ingress to the target ports of a Service from the ingress controller
+//     when an Ingress or route exposes it, from anywhere for LoadBalancer and
+//     NodePort Services, and from the workloads that address it by its DNS
+//     name in env vars or mounted ConfigMaps; a Service without any detected
+//     client stays open to the pods of the release;
+//   - egress to DNS, to the pods behind the Services the workloads address and
+//     to the well-known ports of external dependencies named by env vars;
+//   - traffic allowed by NetworkPolicies of the input and with foreign
+//     namespaces.
+//
+// Each policy is toggled by networkPolicies.policies.<group> in values.yaml
+// (see AppendNetworkPolicyValues). Groups without workloads get no policy.
 // Returns map of template path → template content.
 func GenerateAutoNetworkPolicies(graph *types.ResourceGraph, groups []*ServiceGroup) map[string]string {
 	if len(groups) == 0 {
@@ -42,88 +71,389 @@ func GenerateAutoNetworkPolicies(graph *types.ResourceGraph, groups []*ServiceGr
 	// Build cross-namespace relationship index
 	crossNS := buildCrossNamespaceIndex(graph, groups)
 
-	for _, group := range groups {
-		// Extract Service ports from group resources
-		ingressPorts := extractServicePorts(group)
+	// Foreign namespaces each group is allowed to reach by existing NetworkPolicies.
+	peerEgress := buildPeerEgressIndex(graph, groups)
 
-		// Extract egress ports from env var analysis
-		egressPorts := extractEnvBasedPorts(group)
+	traffic := buildNetworkTraffic(graph, groups)
 
-		// Check if this group has cross-namespace relationships
-		crossNamespaces := crossNS[group.Name]
+	for _, group := range groups {
+		components := groupComponents(group)
+		if len(components) == 0 {
+			continue
+		}
+
+		// Egress to external dependencies named by env vars that do not
+		// address a Service of the chart.
+		egress := traffic.egress[group.Name]
+		if ports := extractEnvBasedPorts(group, traffic.resolvedEnv); len(ports) > 0 {
+			egress = append(egress, trafficRule{comment: "External dependencies named by env vars", peer: trafficPeer{anywhere: true}, ports: ports})
+		}
 
 		path := fmt.Sprintf("templates/%s-networkpolicy.yaml", group.Name)
-		result[path] = generateNetworkPolicy(group, ingressPorts, egressPorts, crossNamespaces)
+		result[path] = generateNetworkPolicy(group, components, traffic.ingress[group.Name], egress, crossNS[group.Name], peerEgress[group.Name])
 	}
 
 	return result
 }
 
-// portInfo holds port number and protocol.
+// NetworkPolicyGroups returns the names of the groups that get a policy from
+// GenerateAutoNetworkPolicies.
+func NetworkPolicyGroups(groups []*ServiceGroup) []string {
+	var names []string
+	for _, group := range groups {
+		if group != nil && len(groupComponents(group)) > 0 {
+			names = append(names, group.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// trafficPeer is the other side of a NetworkPolicy rule: the pods of the
+// release with the given app.kubernetes.io/component labels (all pods of the
+// release when there are none), the ingress controller, or anywhere.
+type trafficPeer struct {
+	components        []string
+	ingressController bool
+	anywhere          bool
+}
+
+// trafficRule is an ingress or egress rule of a NetworkPolicy. No ports allow
+// every port of the peer.
+type trafficRule struct {
+	comment string
+	peer    trafficPeer
+	ports   []portInfo
+}
+
+// networkTraffic holds the rules of each group, by group name, and the env
+// vars of each workload that address a Service of the chart.
+type networkTraffic struct {
+	ingress     map[string][]trafficRule
+	egress      map[string][]trafficRule
+	resolvedEnv map[types.ResourceKey]map[string]bool
+}
+
+// buildNetworkTraffic derives the ingress and egress rules of the groups
+// from the relationships of the graph: Ingress/route → Service → workload,
+// workload → Service by env var (env_service_url), ConfigMap → Service
+// (config_service_url) for the workloads mounting the ConfigMap, and
+// workload → workload allowed by a NetworkPolicy (network_peer).
+func buildNetworkTraffic(graph *types.ResourceGraph, groups []*ServiceGroup) networkTraffic {
+	traffic := networkTraffic{
+		ingress:     make(map[string][]trafficRule),
+		egress:      make(map[string][]trafficRule),
+		resolvedEnv: make(map[types.ResourceKey]map[string]bool),
+	}
+
+	resourceToGroup := make(map[types.ResourceKey]*ServiceGroup)
+	resources := make(map[types.ResourceKey]*types.ProcessedResource)
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, r := range g.Resources {
+			resourceToGroup[r.Original.ResourceKey()] = g
+			resources[r.Original.ResourceKey()] = r
+		}
+	}
+
+	var relationships []types.Relationship
+	if graph != nil {
+		relationships = graph.Relationships
+	}
+
+	selected := make(map[types.ResourceKey][]types.ResourceKey)  // Service → workloads
+	consumers := make(map[types.ResourceKey][]types.ResourceKey) // ConfigMap → workloads
+	exposedBy := make(map[types.ResourceKey][]string)            // Service → "Ingress web"
+	for _, rel := range relationships {
+		switch rel.Type {
+		case types.RelationLabelSelector:
+			if rel.From.GVK.Kind == "Service" && isWorkloadKind(rel.To.GVK.Kind) {
+				selected[rel.From] = append(selected[rel.From], rel.To)
+			}
+		case types.RelationVolumeMount, types.RelationEnvFrom, types.RelationEnvValueFrom:
+			if rel.To.GVK.Kind == "ConfigMap" && isWorkloadKind(rel.From.GVK.Kind) {
+				consumers[rel.To] = append(consumers[rel.To], rel.From)
+			}
+		case types.RelationNameReference:
+			if rel.To.GVK.Kind == "Service" && exposingKinds[rel.From.GVK.Kind] {
+				exposedBy[rel.To] = append(exposedBy[rel.To], rel.From.GVK.Kind+" "+rel.From.Name)
+			}
+		}
+	}
+
+	// Clients of each Service: workload → what addresses the Service.
+	clients := make(map[types.ResourceKey]map[types.ResourceKey]string)
+	addClient := func(svc, workload types.ResourceKey, via string) {
+		if resourceToGroup[workload] == nil {
+			return
+		}
+		if clients[svc] == nil {
+			clients[svc] = make(map[types.ResourceKey]string)
+		}
+		if _, ok := clients[svc][workload]; !ok {
+			clients[svc][workload] = via
+		}
+	}
+	for _, rel := range relationships {
+		switch rel.Type {
+		case types.RelationEnvServiceURL:
+			addClient(rel.To, rel.From, "env "+rel.Details["env"])
+			if traffic.resolvedEnv[rel.From] == nil {
+				traffic.resolvedEnv[rel.From] = make(map[string]bool)
+			}
+			traffic.resolvedEnv[rel.From][rel.Details["env"]] = true
+		case types.RelationConfigServiceURL:
+			for _, workload := range consumers[rel.From] {
+				addClient(rel.To, workload, "ConfigMap "+rel.From.Name)
+			}
+		}
+	}
+
+	component := func(key types.ResourceKey) string {
+		return workloadComponent(resources[key], resourceToGroup[key])
+	}
+
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, svc := range resourcesByName(g.Resources) {
+			if svc.Original.GVK.Kind != "Service" {
+				continue
+			}
+			ports := serviceTargetPorts(svc)
+			if len(ports) == 0 {
+				continue
+			}
+			svcKey := svc.Original.ResourceKey()
+			name := svc.Original.Object.GetName()
+
+			exposures := exposedBy[svcKey]
+			sort.Strings(exposures)
+			for _, by := range exposures {
+				traffic.ingress[g.Name] = append(traffic.ingress[g.Name], trafficRule{
+					comment: fmt.Sprintf("From the ingress controller: %s → Service %s", by, name),
+					peer:    trafficPeer{ingressController: true},
+					ports:   ports,
+				})
+			}
+			svcType, _, _ := unstructured.NestedString(svc.Original.Object.Object, "spec", "type")
+			external := svcType == "LoadBalancer" || svcType == "NodePort"
+			if external {
+				traffic.ingress[g.Name] = append(traffic.ingress[g.Name], trafficRule{
+					comment: fmt.Sprintf("From anywhere: Service %s is a %s", name, svcType),
+					peer:    trafficPeer{anywhere: true},
+					ports:   ports,
+				})
+			}
+
+			// Pods behind the Service, all workloads of its group unless the
+			// selected ones are known.
+			var backends []string
+			for _, key := range selected[svcKey] {
+				if resourceToGroup[key] != nil {
+					backends = appendUnique(backends, component(key))
+				}
+			}
+			if len(backends) == 0 {
+				backends = groupComponents(g)
+			}
+			sort.Strings(backends)
+
+			workloads := make([]types.ResourceKey, 0, len(clients[svcKey]))
+			for key := range clients[svcKey] {
+				workloads = append(workloads, key)
+			}
+			sort.Slice(workloads, func(i, j int) bool { return workloads[i].String() < workloads[j].String() })
+
+			var clientComponents, via []string
+			egressed := make(map[string]bool)
+			for _, key := range workloads {
+				clientComponents = appendUnique(clientComponents, component(key))
+				via = appendUnique(via, component(key)+" ("+clients[svcKey][key]+")")
+
+				clientGroup := resourceToGroup[key].Name
+				if egressed[clientGroup] || len(backends) == 0 {
+					continue
+				}
+				egressed[clientGroup] = true
+				traffic.egress[clientGroup] = append(traffic.egress[clientGroup], trafficRule{
+					comment: fmt.Sprintf("To Service %s (%s)", name, clients[svcKey][key]),
+					peer:    trafficPeer{components: backends},
+					ports:   ports,
+				})
+			}
+			if len(clientComponents) > 0 {
+				sort.Strings(clientComponents)
+				traffic.ingress[g.Name] = append(traffic.ingress[g.Name], trafficRule{
+					comment: fmt.Sprintf("From the clients of Service %s: %s", name, strings.Join(via, ", ")),
+					peer:    trafficPeer{components: clientComponents},
+					ports:   ports,
+				})
+			}
+
+			if len(exposures) == 0 && !external && len(clientComponents) == 0 {
+				traffic.ingress[g.Name] = append(traffic.ingress[g.Name], trafficRule{
+					comment: fmt.Sprintf("From the release: no client of Service %s detected", name),
+					peer:    trafficPeer{},
+					ports:   ports,
+				})
+			}
+		}
+	}
+
+	// Workloads allowed to talk by NetworkPolicies of the input; peers in
+	// foreign namespaces are covered by the namespace rules.
+	for _, rel := range relationships {
+		if rel.Type != types.RelationNetworkPeer || rel.From.Namespace != rel.To.Namespace {
+			continue
+		}
+		from, to := resourceToGroup[rel.From], resourceToGroup[rel.To]
+		if from == nil || to == nil || !isWorkloadKind(rel.From.GVK.Kind) || !isWorkloadKind(rel.To.GVK.Kind) {
+			continue
+		}
+		comment := "Allowed by NetworkPolicy " + rel.Details["policy"]
+		traffic.ingress[to.Name] = append(traffic.ingress[to.Name], trafficRule{comment: comment, peer: trafficPeer{components: []string{component(rel.From)}}})
+		traffic.egress[from.Name] = append(traffic.egress[from.Name], trafficRule{comment: comment, peer: trafficPeer{components: []string{component(rel.To)}}})
+	}
+
+	return traffic
+}
+
+// groupComponents returns the app.kubernetes.io/component labels of the pods
+// of the group workloads, sorted.
+func groupComponents(group *ServiceGroup) []string {
+	var components []string
+	for _, r := range group.Resources {
+		if isWorkloadKind(r.Original.GVK.Kind) {
+			components = appendUnique(components, workloadComponent(r, group))
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+// workloadComponent returns the app.kubernetes.io/component label of the
+// pods of a generated workload: its service name.
+func workloadComponent(r *types.ProcessedResource, group *ServiceGroup) string {
+	if r != nil && r.ServiceName != "" {
+		return r.ServiceName
+	}
+	return group.Name
+}
+
+// resourcesByName returns resources sorted by name.
+func resourcesByName(resources []*types.ProcessedResource) []*types.ProcessedResource {
+	sorted := append([]*types.ProcessedResource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Original.Object.GetName() < sorted[j].Original.Object.GetName()
+	})
+	return sorted
+}
+
+// portInfo holds port number and protocol. A named target port has Name set
+// instead of Port.
 type portInfo struct {
 	Port     int
+	Name     string
 	Protocol string
 }
 
-// extractServicePorts extracts ports from Service resources in the group.
+// extractServicePorts extracts the target ports of the Service resources in
+// the group: the pod ports their traffic arrives on.
 func extractServicePorts(group *ServiceGroup) []portInfo {
 	var ports []portInfo
-	seen := make(map[int]bool)
+	seen := make(map[portInfo]bool)
 
 	for _, r := range group.Resources {
 		if r.Original.GVK.Kind != "Service" {
 			continue
 		}
-
-		spec, ok := r.Original.Object.Object["spec"].(map[string]interface{})
-		if !ok {
-			continue
+		for _, p := range serviceTargetPorts(r) {
+			if !seen[p] {
+				seen[p] = true
+				ports = append(ports, p)
+			}
 		}
+	}
+
+	return ports
+}
 
-		portList, ok := spec["ports"].([]interface{})
+// serviceTargetPorts returns the target ports of a Service, its port when a
+// targetPort is not set.
+func serviceTargetPorts(svc *types.ProcessedResource) []portInfo {
+	var ports []portInfo
+	seen := make(map[portInfo]bool)
+
+	spec, ok := svc.Original.Object.Object["spec"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	portList, ok := spec["ports"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, p := range portList {
+		portMap, ok := p.(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		for _, p := range portList {
-			portMap, ok := p.(map[string]interface{})
-			if !ok {
-				continue
-			}
+		protocol := "TCP"
+		if p, ok := portMap["protocol"].(string); ok {
+			protocol = p
+		}
 
-			var portNum int
-			switch v := portMap["port"].(type) {
-			case int64:
-				portNum = int(v)
-			case float64:
-				if v != float64(int(v)) {
-					continue
-				}
-				portNum = int(v)
-			case int:
-				portNum = v
-			case string:
-				if n, err := strconv.Atoi(v); err == nil {
-					portNum = n
-				}
-			}
+		info := portInfo{Port: portNumber(portMap["port"]), Protocol: protocol}
+		if name, ok := portMap["targetPort"].(string); ok && name != "" && portNumber(name) == 0 {
+			info = portInfo{Name: name, Protocol: protocol}
+		} else if n := portNumber(portMap["targetPort"]); n > 0 {
+			info.Port = n
+		}
 
-			if portNum > 0 && portNum <= 65535 && !seen[portNum] {
-				seen[portNum] = true
-				protocol := "TCP"
-				if p, ok := portMap["protocol"].(string); ok {
-					protocol = p
-				}
-				ports = append(ports, portInfo{Port: portNum, Protocol: protocol})
-			}
+		if (info.Name != "" || info.Port > 0) && !seen[info] {
+			seen[info] = true
+			ports = append(ports, info)
 		}
 	}
 
 	return ports
 }
 
-// extractEnvBasedPorts detects egress ports from environment variables in workloads.
-func extractEnvBasedPorts(group *ServiceGroup) []portInfo {
+// portNumber returns a port given as a number or numeric string, 0 when it
+// is not a valid port.
+func portNumber(v interface{}) int {
+	var portNum int
+	switch v := v.(type) {
+	case int64:
+		portNum = int(v)
+	case float64:
+		if v != float64(int(v)) {
+			return 0
+		}
+		portNum = int(v)
+	case int:
+		portNum = v
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			portNum = n
+		}
+	}
+	if portNum <= 0 || portNum > 65535 {
+		return 0
+	}
+	return portNum
+}
+
+// extractEnvBasedPorts detects egress ports from environment variables in
+// workloads, skipping the env vars in resolved, which address a Service of
+// the chart.
+func extractEnvBasedPorts(group *ServiceGroup, resolved map[types.ResourceKey]map[string]bool) []portInfo {
 	var ports []portInfo
 	seen := make(map[int]bool)
 
@@ -134,7 +464,15 @@ func extractEnvBasedPorts(group *ServiceGroup) []portInfo {
 		}
 
 		envVars := extractEnvVarsFromWorkload(r)
+		names := make([]string, 0, len(envVars))
 		for envName := range envVars {
+			names = append(names, envName)
+		}
+		sort.Strings(names)
+		for _, envName := range names {
+			if resolved[r.Original.ResourceKey()][envName] {
+				continue
+			}
 			if port, ok := envPortMapping[envName]; ok {
 				if !seen[port] {
 					seen[port] = true
@@ -253,10 +591,53 @@ func buildCrossNamespaceIndex(graph *types.ResourceGraph, groups []*ServiceGroup
 	return result
 }
 
-// generateNetworkPolicy builds a NetworkPolicy YAML template.
-func generateNetworkPolicy(group *ServiceGroup, ingressPorts, egressPorts []portInfo, crossNamespaces []string) string {
+// buildPeerEgressIndex maps group name → foreign namespaces its workloads are
+// allowed to reach by network_peer relationships (NetworkPolicy egress/ingress
+// rules found in the input).
+func buildPeerEgressIndex(graph *types.ResourceGraph, groups []*ServiceGroup) map[string][]string {
+	result := make(map[string][]string)
+	if graph == nil {
+		return result
+	}
+
+	resourceToGroup := make(map[types.ResourceKey]*ServiceGroup)
+	for _, g := range groups {
+		for _, r := range g.Resources {
+			resourceToGroup[r.Original.ResourceKey()] = g
+		}
+	}
+
+	egress := make(map[string]map[string]bool)
+	for _, rel := range graph.Relationships {
+		if rel.Type != types.RelationNetworkPeer {
+			continue
+		}
+		fromGroup := resourceToGroup[rel.From]
+		if fromGroup == nil || rel.To.Namespace == "" || rel.To.Namespace == fromGroup.Namespace {
+			continue
+		}
+		if egress[fromGroup.Name] == nil {
+			egress[fromGroup.Name] = make(map[string]bool)
+		}
+		egress[fromGroup.Name][rel.To.Namespace] = true
+	}
+
+	for name, nsMap := range egress {
+		for ns := range nsMap {
+			result[name] = append(result[name], ns)
+		}
+		sort.Strings(result[name])
+	}
+	return result
+}
+
+// generateNetworkPolicy builds a NetworkPolicy YAML template selecting the
+// pods of the group components.
+func generateNetworkPolicy(group *ServiceGroup, components []string, ingress, egress []trafficRule, crossNamespaces, egressNamespaces []string) string {
 	var sb strings.Builder
+	values := fmt.Sprintf("(index .Values.networkPolicies.policies %q)", group.Name)
 
+	sb.WriteString(fmt.Sprintf("{{- if %s.enabled }}\n", values))
 	sb.WriteString("apiVersion: networking.k8s.io/v1\n")
 	sb.WriteString("kind: NetworkPolicy\n")
 	sb.WriteString("metadata:\n")
@@ -264,25 +645,15 @@ func generateNetworkPolicy(group *ServiceGroup, ingressPorts, egressPorts []port
 	sb.WriteString("  namespace: {{ .Release.Namespace }}\n")
 	sb.WriteString("spec:\n")
 	sb.WriteString("  podSelector:\n")
-	sb.WriteString("    matchLabels:\n")
-	sb.WriteString(fmt.Sprintf("      app.kubernetes.io/name: %s\n", group.Name))
+	writePodSelector(&sb, "    ", components)
 	sb.WriteString("  policyTypes:\n")
 	sb.WriteString("    - Ingress\n")
 	sb.WriteString("    - Egress\n")
 
 	// Ingress rules
 	sb.WriteString("  ingress:\n")
-	if len(ingressPorts) > 0 {
-		sb.WriteString("    - from:\n")
-		sb.WriteString("        - podSelector: {}\n")
-		sb.WriteString("      ports:\n")
-		for _, p := range ingressPorts {
-			sb.WriteString(fmt.Sprintf("        - port: %d\n", p.Port))
-			sb.WriteString(fmt.Sprintf("          protocol: %s\n", p.Protocol))
-		}
-	} else {
-		sb.WriteString("    - from:\n")
-		sb.WriteString("        - podSelector: {}\n")
+	for _, rule := range ingress {
+		writeTrafficRule(&sb, "from", rule)
 	}
 
 	// Cross-namespace ingress
@@ -292,6 +663,9 @@ func generateNetworkPolicy(group *ServiceGroup, ingressPorts, egressPorts []port
 		sb.WriteString("            matchLabels:\n")
 		sb.WriteString(fmt.Sprintf("              kubernetes.io/metadata.name: %s\n", ns))
 	}
+	sb.WriteString(fmt.Sprintf("    {{- with %s.extraIngress }}\n", values))
+	sb.WriteString("    {{- toYaml . | nindent 4 }}\n")
+	sb.WriteString("    {{- end }}\n")
 
 	// Egress rules
 	sb.WriteString("  egress:\n")
@@ -308,26 +682,124 @@ func generateNetworkPolicy(group *ServiceGroup, ingressPorts, egressPorts []port
 	sb.WriteString("        - port: 53\n")
 	sb.WriteString("          protocol: TCP\n")
 
-	// Env-based egress
-	if len(egressPorts) > 0 {
-		sb.WriteString("    # Detected service dependencies\n")
+	for _, rule := range egress {
+		writeTrafficRule(&sb, "to", rule)
+	}
+
+	// Cross-namespace peers allowed by existing NetworkPolicies
+	for _, ns := range egressNamespaces {
+		sb.WriteString("    - to:\n")
+		sb.WriteString("        - namespaceSelector:\n")
+		sb.WriteString("            matchLabels:\n")
+		sb.WriteString(fmt.Sprintf("              kubernetes.io/metadata.name: %s\n", ns))
+	}
+	sb.WriteString(fmt.Sprintf("    {{- with %s.extraEgress }}\n", values))
+	sb.WriteString("    {{- toYaml . | nindent 4 }}\n")
+	sb.WriteString("    {{- end }}\n")
+	sb.WriteString("{{- end }}\n")
+
+	return sb.String()
+}
+
+// writePodSelector writes the selector of the pods of the release with the
+// given components, of all pods of the release when there are none.
+func writePodSelector(sb *strings.Builder, indent string, components []string) {
+	sb.WriteString(indent + "matchLabels:\n")
+	sb.WriteString(indent + "  app.kubernetes.io/instance: {{ .Release.Name }}\n")
+	switch len(components) {
+	case 0:
+	case 1:
+		sb.WriteString(fmt.Sprintf("%s  app.kubernetes.io/component: %s\n", indent, components[0]))
+	default:
+		sb.WriteString(indent + "matchExpressions:\n")
+		sb.WriteString(indent + "  - key: app.kubernetes.io/component\n")
+		sb.WriteString(indent + "    operator: In\n")
+		sb.WriteString(fmt.Sprintf("%s    values: [%s]\n", indent, strings.Join(components, ", ")))
+	}
+}
+
+// writeTrafficRule writes an ingress (direction "from") or egress ("to")
+// rule.
+func writeTrafficRule(sb *strings.Builder, direction string, rule trafficRule) {
+	sb.WriteString(fmt.Sprintf("    # %s\n", rule.comment))
+	switch {
+	case rule.peer.anywhere:
+		if len(rule.ports) == 0 {
+			return
+		}
 		sb.WriteString("    - ports:\n")
-		for _, p := range egressPorts {
+		writePorts(sb, rule.ports)
+		return
+	case rule.peer.ingressController:
+		sb.WriteString(fmt.Sprintf("    - %s:\n", direction))
+		sb.WriteString("        - namespaceSelector:\n")
+		sb.WriteString("            matchLabels:\n")
+		sb.WriteString("              kubernetes.io/metadata.name: {{ .Values.networkPolicies.ingressNamespace }}\n")
+	default:
+		sb.WriteString(fmt.Sprintf("    - %s:\n", direction))
+		sb.WriteString("        - podSelector:\n")
+		writePodSelector(sb, "            ", rule.peer.components)
+	}
+	if len(rule.ports) > 0 {
+		sb.WriteString("      ports:\n")
+		writePorts(sb, rule.ports)
+	}
+}
+
+// writePorts writes the ports of a rule.
+func writePorts(sb *strings.Builder, ports []portInfo) {
+	for _, p := range ports {
+		if p.Name != "" {
+			sb.WriteString(fmt.Sprintf("        - port: %s\n", p.Name))
+		} else {
 			sb.WriteString(fmt.Sprintf("        - port: %d\n", p.Port))
-			sb.WriteString(fmt.Sprintf("          protocol: %s\n", p.Protocol))
 		}
+		sb.WriteString(fmt.Sprintf("          protocol: %s\n", p.Protocol))
 	}
+}
 
-	// Allow same-namespace
-	sb.WriteString("    # Allow same-namespace\n")
-	sb.WriteString("    - to:\n")
-	sb.WriteString("        - podSelector: {}\n")
-
+// AppendNetworkPolicyValues appends the networkPolicies section toggling the
+// default-deny policy and the policies of GenerateAutoNetworkPolicies to
+// valuesYAML.
+func AppendNetworkPolicyValues(valuesYAML string, groups []*ServiceGroup) string {
+	policies := make(map[string]interface{})
+	for _, name := range NetworkPolicyGroups(groups) {
+		policies[name] = map[string]interface{}{
+			"enabled":      true,
+			"extraIngress": []interface{}{},
+			"extraEgress":  []interface{}{},
+		}
+	}
+	data, err := yaml.Marshal(map[string]interface{}{
+		"networkPolicies": map[string]interface{}{
+			"defaultDeny":      map[string]interface{}{"enabled": true},
+			"ingressNamespace": DefaultTenantIngressNamespace,
+			"policies":         policies,
+		},
+	})
+	if err != nil {
+		return valuesYAML
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(valuesYAML, "\n"))
+	sb.WriteString("\n\n# NetworkPolicies from the detected traffic; ingressNamespace is the namespace\n")
+	sb.WriteString("# of the ingress controller, extraIngress/extraEgress add rules to a policy\n")
+	sb.Write(data)
 	return sb.String()
 }
 
+// DefaultDenyPolicyPath is the template of the default-deny NetworkPolicy
+// generated with GenerateAutoNetworkPolicies.
+const DefaultDenyPolicyPath = "templates/networkpolicy-default-deny.yaml"
+
+// GenerateDefaultDenyTemplate returns the default-deny NetworkPolicy, toggled
+// by networkPolicies.defaultDeny.enabled.
+func GenerateDefaultDenyTemplate() string {
+	return "{{- if .Values.networkPolicies.defaultDeny.enabled }}\n" + GenerateDefaultDenyPolicy("") + "{{- end }}\n"
+}
+
 // GenerateDefaultDenyPolicy generates a default-deny-all NetworkPolicy for the given namespace.
-// Ingress: deny all except from same namespace.
+// Ingress: deny all.
 // Egress: deny all except DNS (UDP+TCP 53 to kube-system).
 func GenerateDefaultDenyPolicy(namespace string) string {
 	var sb strings.Builder
@@ -347,10 +819,8 @@ func GenerateDefaultDenyPolicy(namespace string) string {
 	sb.WriteString("    - Ingress\n")
 	sb.WriteString("    - Egress\n")
 
-	// Ingress: allow only from same namespace
-	sb.WriteString("  ingress:\n")
-	sb.WriteString("    - from:\n")
-	sb.WriteString("        - podSelector: {}\n")
+	// Ingress: no rules, deny all
+	sb.WriteString("  ingress: []\n")
 
 	// Egress: allow only DNS to kube-system
 	sb.WriteString("  egress:\n")
@@ -589,3 +589,188 @@ func TestDefaultDeny_EmptyNamespace(t *testing.T) {
 		t.Error("expected Helm template namespace even with empty input")
 	}
 }
+
+// ============================================================
+// Network peers from existing NetworkPolicies
+// ============================================================
+
+func TestAutoNP_PeerEgressToForeignNamespace(t *testing.T) {
+	frontend := makeDeploymentWithEnv("frontend", "web", nil)
+	api := makeDeploymentWithEnv("api", "backend", nil)
+	groups := []*ServiceGroup{
+		makeGroup("frontend", "web", []*types.ProcessedResource{frontend}),
+		makeGroup("api", "backend", []*types.ProcessedResource{api}),
+	}
+	rels := []types.Relationship{
+		{From: resourceKey(frontend), To: resourceKey(api), Type: types.RelationNetworkPeer},
+	}
+	graph := buildGraph([]*types.ProcessedResource{frontend, api}, rels)
+
+	if got := buildPeerEgressIndex(graph, groups); !reflect.DeepEqual(got, map[string][]string{"frontend": {"backend"}}) {
+		t.Errorf("unexpected peer egress index: %v", got)
+	}
+
+	result := GenerateAutoNetworkPolicies(graph, groups)
+
+	frontendNP := result["templates/frontend-networkpolicy.yaml"]
+	egress := frontendNP[strings.Index(frontendNP, "  egress:\n"):]
+	if !strings.Contains(egress, "kubernetes.io/metadata.name: backend") {
+		t.Errorf("expected frontend egress to the backend namespace, got:\n%s", frontendNP)
+	}
+
+	// The server side only gets ingress from the client namespace.
+	apiNP := result["templates/api-networkpolicy.yaml"]
+	apiEgress := apiNP[strings.Index(apiNP, "  egress:\n"):]
+	if strings.Contains(apiEgress, "kubernetes.io/metadata.name: web") {
+		t.Errorf("api must not get egress to the web namespace, got:\n%s", apiNP)
+	}
+	if !strings.Contains(apiNP, "kubernetes.io/metadata.name: web") {
+		t.Errorf("expected api ingress from the web namespace, got:\n%s", apiNP)
+	}
+}
+
+func TestBuildPeerEgressIndex_IgnoresOtherRelationsAndSameNamespace(t *testing.T) {
+	a := makeDeploymentWithEnv("a", "default", nil)
+	b := makeDeploymentWithEnv("b", "default", nil)
+	c := makeDeploymentWithEnv("c", "other", nil)
+	groups := []*ServiceGroup{
+		makeGroup("a", "default", []*types.ProcessedResource{a}),
+		makeGroup("b", "default", []*types.ProcessedResource{b}),
+		makeGroup("c", "other", []*types.ProcessedResource{c}),
+	}
+	rels := []types.Relationship{
+		{From: resourceKey(a), To: resourceKey(b), Type: types.RelationNetworkPeer},
+		{From: resourceKey(a), To: resourceKey(c), Type: types.RelationNameReference},
+	}
+
+	if got := buildPeerEgressIndex(buildGraph([]*types.ProcessedResource{a, b, c}, rels), groups); len(got) != 0 {
+		t.Errorf("expected empty index, got %v", got)
+	}
+	if got := buildPeerEgressIndex(nil, groups); len(got) != 0 {
+		t.Errorf("expected empty index for nil graph, got %v", got)
+	}
+}
+
+// ============================================================
+// Least-privilege rules from the traffic relationships
+// ============================================================
+
+func TestAutoNP_TrafficFromGraph(t *testing.T) {
+	web := makeDeploymentWithEnv("web", "default", map[string]string{
+		"API_URL":      "http://api:8080",
+		"DATABASE_URL": "postgres://db.example.com:5432/app",
+	})
+	web.ServiceName = "web"
+	webSvc := makeServiceWithPorts("web", "default", []int64{80})
+	ingress := makeProcessedResource("Ingress", "web", "default", nil)
+
+	api := makeDeploymentWithEnv("api", "default", nil)
+	api.ServiceName = "api"
+	apiSvc := makeProcessedResource("Service", "api", "default", nil)
+	apiSvc.Original.Object.Object["spec"] = map[string]interface{}{
+		"type":  "LoadBalancer",
+		"ports": []interface{}{map[string]interface{}{"port": int64(8080), "targetPort": "http"}},
+	}
+	config := makeProcessedResource("ConfigMap", "proxy", "default", nil)
+	proxy := makeDeploymentWithEnv("proxy", "default", nil)
+	proxy.ServiceName = "proxy"
+
+	groups := []*ServiceGroup{
+		makeGroup("web", "default", []*types.ProcessedResource{web, webSvc, ingress}),
+		makeGroup("api", "default", []*types.ProcessedResource{api, apiSvc}),
+		makeGroup("proxy", "default", []*types.ProcessedResource{proxy, config}),
+	}
+	rels := []types.Relationship{
+		{From: resourceKey(ingress), To: resourceKey(webSvc), Type: types.RelationNameReference},
+		{From: resourceKey(webSvc), To: resourceKey(web), Type: types.RelationLabelSelector},
+		{From: resourceKey(apiSvc), To: resourceKey(api), Type: types.RelationLabelSelector},
+		{From: resourceKey(web), To: resourceKey(apiSvc), Type: types.RelationEnvServiceURL, Details: map[string]string{"env": "API_URL"}},
+		{From: resourceKey(proxy), To: resourceKey(config), Type: types.RelationVolumeMount},
+		{From: resourceKey(config), To: resourceKey(apiSvc), Type: types.RelationConfigServiceURL},
+	}
+	graph := buildGraph([]*types.ProcessedResource{web, webSvc, ingress, api, apiSvc, config, proxy}, rels)
+
+	result := GenerateAutoNetworkPolicies(graph, groups)
+
+	webNP := result["templates/web-networkpolicy.yaml"]
+	for _, want := range []string{
+		`{{- if (index .Values.networkPolicies.policies "web").enabled }}`,
+		"  podSelector:\n    matchLabels:\n      app.kubernetes.io/instance: {{ .Release.Name }}\n      app.kubernetes.io/component: web\n",
+		"# From the ingress controller: Ingress web → Service web",
+		"kubernetes.io/metadata.name: {{ .Values.networkPolicies.ingressNamespace }}",
+		"# To Service api (env API_URL)\n    - to:\n        - podSelector:\n            matchLabels:\n              app.kubernetes.io/instance: {{ .Release.Name }}\n              app.kubernetes.io/component: api\n      ports:\n        - port: http\n",
+		// DATABASE_URL is not a Service of the chart.
+		"# External dependencies named by env vars\n    - ports:\n        - port: 5432\n",
+	} {
+		if !strings.Contains(webNP, want) {
+			t.Errorf("expected %q in web policy:\n%s", want, webNP)
+		}
+	}
+	if strings.Contains(webNP, "podSelector: {}") {
+		t.Errorf("web policy must not allow the whole namespace:\n%s", webNP)
+	}
+
+	apiNP := result["templates/api-networkpolicy.yaml"]
+	for _, want := range []string{
+		"# From anywhere: Service api is a LoadBalancer\n    - ports:\n        - port: http\n",
+		"# From the clients of Service api: proxy (ConfigMap proxy), web (env API_URL)\n",
+		"              operator: In\n                values: [proxy, web]\n",
+	} {
+		if !strings.Contains(apiNP, want) {
+			t.Errorf("expected %q in api policy:\n%s", want, apiNP)
+		}
+	}
+	if strings.Contains(apiNP, "From the release") {
+		t.Errorf("api has detected clients and must not be open to the release:\n%s", apiNP)
+	}
+
+	proxyNP := result["templates/proxy-networkpolicy.yaml"]
+	if !strings.Contains(proxyNP, "# To Service api (ConfigMap proxy)") {
+		t.Errorf("expected egress from proxy to api:\n%s", proxyNP)
+	}
+}
+
+func TestAutoNP_SkipsGroupsWithoutWorkloads(t *testing.T) {
+	deploy := makeDeploymentWithEnv("app", "default", nil)
+	cm := makeProcessedResource("ConfigMap", "shared", "default", nil)
+	groups := []*ServiceGroup{
+		makeGroup("app", "default", []*types.ProcessedResource{deploy}),
+		makeGroup("shared", "default", []*types.ProcessedResource{cm}),
+	}
+
+	result := GenerateAutoNetworkPolicies(buildGraph([]*types.ProcessedResource{deploy, cm}, nil), groups)
+	if _, ok := result["templates/shared-networkpolicy.yaml"]; ok || len(result) != 1 {
+		t.Errorf("expected a policy for app only, got %v", reflect.ValueOf(result).MapKeys())
+	}
+	if got := NetworkPolicyGroups(groups); !reflect.DeepEqual(got, []string{"app"}) {
+		t.Errorf("NetworkPolicyGroups = %v", got)
+	}
+
+	values := AppendNetworkPolicyValues("global: {}\n", groups)
+	for _, want := range []string{"global: {}\n\n#", "networkPolicies:\n  defaultDeny:\n    enabled: true\n  ingressNamespace: d8-ingress-nginx\n  policies:\n    app:\n      enabled: true\n"} {
+		if !strings.Contains(values, want) {
+			t.Errorf("expected %q in values:\n%s", want, values)
+		}
+	}
+}
+
+func TestServiceTargetPorts(t *testing.T) {
+	svc := makeProcessedResource("Service", "svc", "default", nil)
+	svc.Original.Object.Object["spec"] = map[string]interface{}{
+		"ports": []interface{}{
+			map[string]interface{}{"port": int64(80), "targetPort": int64(8080)},
+			map[string]interface{}{"port": int64(443), "targetPort": "https"},
+			map[string]interface{}{"port": int64(53), "targetPort": "5353", "protocol": "UDP"},
+			map[string]interface{}{"port": int64(9090)},
+		},
+	}
+	want := []portInfo{
+		{Port: 8080, Protocol: "TCP"},
+		{Name: "https", Protocol: "TCP"},
+		{Port: 5353, Protocol: "UDP"},
+		{Port: 9090, Protocol: "TCP"},
+	}
+	if got := serviceTargetPorts(svc); !reflect.DeepEqual(got, want) {
+		t.Errorf("serviceTargetPorts = %v, want %v", got, want)
+	}
+}
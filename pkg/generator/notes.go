@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// releaseNamespace is the namespace of resources installed into the release
+// namespace, as a template expression.
+const releaseNamespace = "{{ .Release.Namespace }}"
+
+// notesNaming is how the charts of an output mode name the resources of a
+// service and gate their templates, for NOTES.txt.
+type notesNaming struct {
+	// fullname is the name templates prefix resource names with, as a
+	// template expression; "" when resources keep their own names.
+	fullname string
+
+	// values returns the values of a service, as a template expression; nil
+	// when the templates are not gated by values.
+	values func(serviceName string) string
+
+	// namespace returns the namespace of a resource, as a template
+	// expression; nil for the release namespace.
+	namespace func(r *types.ProcessedResource) string
+}
+
+// universalNotesNaming is the naming of the universal chart.
+func universalNotesNaming(chartName string, preserveNamespaces bool) notesNaming {
+	naming := notesNaming{
+		fullname: fmt.Sprintf(`{{ include "%s.fullname" . }}`, chartName),
+		values:   func(serviceName string) string { return ".Values.services." + serviceName },
+	}
+	if preserveNamespaces {
+		naming.namespace = func(r *types.ProcessedResource) string {
+			if ns := r.Original.Object.GetNamespace(); ns != "" {
+				return fmt.Sprintf("{{ .Values.namespaces.%s }}", processor.SanitizeValuesKey(ns))
+			}
+			return releaseNamespace
+		}
+	}
+	return naming
+}
+
+// separateNotesNaming is the naming of the chart of a service group in
+// separate mode and of umbrella subcharts.
+func separateNotesNaming(chartName string) notesNaming {
+	return notesNaming{
+		fullname: fmt.Sprintf(`{{ include "%s.fullname" . }}`, chartName),
+		values:   func(string) string { return ".Values" },
+	}
+}
+
+// umbrellaNotesNaming is the naming of the subchart of a service group, seen
+// from the umbrella chart. The fullname is the default fullname of the
+// subchart; nameOverride and fullnameOverride are not taken into account.
+func umbrellaNotesNaming(subchart string) notesNaming {
+	return notesNaming{
+		fullname: fmt.Sprintf(`{{ if contains %q .Release.Name }}{{ .Release.Name }}{{ else }}{{ .Release.Name }}-%s{{ end }}`, subchart, subchart),
+		values:   func(string) string { return fmt.Sprintf("(index .Values %q)", subchart) },
+	}
+}
+
+// notesContext describes the Services, Ingresses and Secrets among resources
+// for NOTES.txt: how to reach the Services, the URLs of the Ingresses and how
+// to read the Secrets.
+func notesContext(resources []*types.ProcessedResource, naming notesNaming) helm.NOTESContext {
+	sorted := append([]*types.ProcessedResource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ServiceName != sorted[j].ServiceName {
+			return sorted[i].ServiceName < sorted[j].ServiceName
+		}
+		return sorted[i].Original.Object.GetName() < sorted[j].Original.Object.GetName()
+	})
+
+	var ctx helm.NOTESContext
+	for _, r := range sorted {
+		obj := r.Original.Object
+		if r.Original.GVK.Group == "" && r.Original.GVK.Kind == "Service" {
+			serviceType, _, _ := unstructured.NestedString(obj.Object, "spec", "type")
+			if serviceType == "" {
+				serviceType = "ClusterIP"
+			}
+			ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+			if serviceType == "ExternalName" || len(ports) == 0 {
+				continue
+			}
+			port, _ := ports[0].(map[string]interface{})
+			number, _, _ := unstructured.NestedInt64(port, "port")
+			if number == 0 {
+				continue
+			}
+			ctx.Services = append(ctx.Services, helm.NOTESService{
+				Service:   r.ServiceName,
+				Name:      naming.name(r, r.ServiceName),
+				Namespace: naming.namespaceOf(r),
+				Type:      serviceType,
+				Port:      number,
+				Condition: naming.condition(r),
+			})
+		}
+		if r.Original.GVK.Group == "networking.k8s.io" && r.Original.GVK.Kind == "Ingress" {
+			ctx.Ingresses = append(ctx.Ingresses, helm.NOTESIngress{
+				Name:      naming.name(r, r.ServiceName),
+				Namespace: naming.namespaceOf(r),
+				URLs:      ingressURLs(obj),
+				Condition: naming.condition(r),
+			})
+		}
+		if r.Original.GVK.Group == "" && r.Original.GVK.Kind == "Secret" {
+			secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+			keys := secretKeys(obj)
+			if secretType == "kubernetes.io/service-account-token" || len(keys) == 0 {
+				continue
+			}
+			ctx.Secrets = append(ctx.Secrets, helm.NOTESSecret{
+				Name:      naming.name(r, obj.GetName()),
+				Namespace: naming.namespaceOf(r),
+				Keys:      keys,
+				Condition: naming.condition(r),
+			})
+		}
+	}
+	return ctx
+}
+
+// name returns the name of the resource r renders: the fullname followed by
+// suffix, or the original name.
+func (n notesNaming) name(r *types.ProcessedResource, suffix string) string {
+	if n.fullname == "" {
+		return r.Original.Object.GetName()
+	}
+	return n.fullname + "-" + suffix
+}
+
+// namespaceOf returns the namespace of the resource r renders.
+func (n notesNaming) namespaceOf(r *types.ProcessedResource) string {
+	if n.namespace == nil {
+		return releaseNamespace
+	}
+	return n.namespace(r)
+}
+
+// condition returns the condition the template of r is rendered under: its
+// service is enabled and, for resources with their own enabled value such as
+// Ingresses and Secrets, the resource is.
+func (n notesNaming) condition(r *types.ProcessedResource) string {
+	if n.values == nil {
+		return ""
+	}
+	values := n.values(r.ServiceName)
+	if r.Original.GVK.Kind == "Service" {
+		return values + ".enabled"
+	}
+	rest, ok := strings.CutPrefix(r.ValuesPath, "services."+r.ServiceName+".")
+	if !ok {
+		return values + ".enabled"
+	}
+	return fmt.Sprintf("and %s.enabled %s.%s.enabled", values, values, rest)
+}
+
+// ingressURLs returns the URLs of the rules of an Ingress with a host, https
+// for the hosts with TLS.
+func ingressURLs(obj *unstructured.Unstructured) []string {
+	tlsHosts := make(map[string]bool)
+	tls, _, _ := unstructured.NestedSlice(obj.Object, "spec", "tls")
+	for _, t := range tls {
+		entry, _ := t.(map[string]interface{})
+		hosts, _, _ := unstructured.NestedStringSlice(entry, "hosts")
+		for _, host := range hosts {
+			tlsHosts[host] = true
+		}
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, _ := r.(map[string]interface{})
+		host, _ := rule["host"].(string)
+		if host == "" {
+			continue
+		}
+		scheme := "http"
+		if tlsHosts[host] {
+			scheme = "https"
+		}
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		if len(paths) == 0 {
+			paths = []interface{}{map[string]interface{}{}}
+		}
+		for _, p := range paths {
+			entry, _ := p.(map[string]interface{})
+			path, _ := entry["path"].(string)
+			if path == "" {
+				path = "/"
+			}
+			url := scheme + "://" + host + path
+			if !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls
+}
+
+// secretKeys returns the sorted data and stringData keys of a Secret.
+func secretKeys(obj *unstructured.Unstructured) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, field := range []string{"data", "stringData"} {
+		data, _, _ := unstructured.NestedMap(obj.Object, field)
+		for key := range data {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestNotesContext(t *testing.T) {
+	svc := makeProcessedResource("Service", "web", "prod", nil)
+	svc.ServiceName = "web"
+	svc.Original.Object.Object["spec"] = map[string]interface{}{
+		"type":  "NodePort",
+		"ports": []interface{}{map[string]interface{}{"port": int64(80)}},
+	}
+	external := makeProcessedResource("Service", "upstream", "prod", nil)
+	external.ServiceName = "web"
+	external.Original.Object.Object["spec"] = map[string]interface{}{"type": "ExternalName", "externalName": "example.com"}
+	ing := makeProcessedResource("Ingress", "web", "prod", nil)
+	ing.ServiceName = "web"
+	ing.ValuesPath = "services.web.ingress"
+	ing.Original.Object.Object["spec"] = map[string]interface{}{
+		"tls": []interface{}{map[string]interface{}{"hosts": []interface{}{"shop.example.com"}}},
+		"rules": []interface{}{
+			map[string]interface{}{"host": "shop.example.com", "http": map[string]interface{}{"paths": []interface{}{
+				map[string]interface{}{"path": "/"}, map[string]interface{}{"path": "/api"},
+			}}},
+			map[string]interface{}{"host": "admin.example.com"},
+			map[string]interface{}{"http": map[string]interface{}{}},
+		},
+	}
+	secret := makeProcessedResource("Secret", "web-auth", "prod", nil)
+	secret.ServiceName = "web"
+	secret.ValuesPath = "services.web.secrets.webAuth"
+	secret.Original.Object.Object["data"] = map[string]interface{}{"username": "dXNlcg==", "password": "cGFzcw=="}
+	token := makeProcessedResource("Secret", "web-token", "prod", nil)
+	token.ServiceName = "web"
+	token.Original.Object.Object["type"] = "kubernetes.io/service-account-token"
+	token.Original.Object.Object["data"] = map[string]interface{}{"token": "dG9r"}
+	resources := []*types.ProcessedResource{secret, token, ing, external, svc}
+
+	ctx := notesContext(resources, universalNotesNaming("shop", true))
+	if len(ctx.Services) != 1 || len(ctx.Ingresses) != 1 || len(ctx.Secrets) != 1 {
+		t.Fatalf("unexpected NOTES context: %+v", ctx)
+	}
+	s := ctx.Services[0]
+	if s.Name != `{{ include "shop.fullname" . }}-web` || s.Namespace != "{{ .Values.namespaces.prod }}" ||
+		s.Type != "NodePort" || s.Port != 80 || s.Condition != ".Values.services.web.enabled" {
+		t.Errorf("unexpected Service: %+v", s)
+	}
+	i := ctx.Ingresses[0]
+	if want := []string{"https://shop.example.com/", "https://shop.example.com/api", "http://admin.example.com/"}; !reflect.DeepEqual(i.URLs, want) {
+		t.Errorf("URLs = %v; want %v", i.URLs, want)
+	}
+	if i.Condition != "and .Values.services.web.enabled .Values.services.web.ingress.enabled" {
+		t.Errorf("Ingress condition = %q", i.Condition)
+	}
+	sec := ctx.Secrets[0]
+	if sec.Name != `{{ include "shop.fullname" . }}-web-auth` || !reflect.DeepEqual(sec.Keys, []string{"password", "username"}) ||
+		sec.Condition != "and .Values.services.web.enabled .Values.services.web.secrets.webAuth.enabled" {
+		t.Errorf("unexpected Secret: %+v", sec)
+	}
+
+	ctx = notesContext(resources, separateNotesNaming("web"))
+	if c := ctx.Ingresses[0].Condition; c != "and .Values.enabled .Values.ingress.enabled" {
+		t.Errorf("separate Ingress condition = %q", c)
+	}
+	ctx = notesContext(resources, umbrellaNotesNaming("web"))
+	if c := ctx.Services[0].Condition; c != `(index .Values "web").enabled` {
+		t.Errorf("umbrella Service condition = %q", c)
+	}
+
+	// Library wrapper charts keep the resource names and are not gated.
+	ctx = notesContext(resources, notesNaming{})
+	if s := ctx.Services[0]; s.Name != "web" || s.Namespace != "{{ .Release.Namespace }}" || s.Condition != "" {
+		t.Errorf("unexpected library Service: %+v", s)
+	}
+}
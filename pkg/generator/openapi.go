@@ -2,8 +2,11 @@ package generator
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
+
+	"sigs.k8s.io/yaml"
 )
 
 // GenerateOpenAPISchema generates an OpenAPI v3 schema YAML from values map.
@@ -49,16 +52,77 @@ func writeProperties(sb *strings.Builder, values map[string]interface{}, indent
 }
 
 func inferType(val interface{}) string {
-	switch val.(type) {
+	switch v := val.(type) {
 	case string:
 		return "string"
 	case bool:
 		return "boolean"
 	case int, int32, int64:
 		return "integer"
-	case float32, float64:
+	case float64:
+		// YAML and JSON numbers decode as float64: whole numbers are integers.
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case float32:
 		return "number"
 	default:
 		return "string"
 	}
 }
+
+// GenerateConfigValuesSchema generates the openapi/config-values.yaml schema
+// of a Deckhouse module from its settings: every setting becomes a property of
+// the inferred type with its value as the default, so ModuleConfigs may omit
+// it.
+func GenerateConfigValuesSchema(settings map[string]interface{}) string {
+	var sb strings.Builder
+	sb.WriteString("type: object\n")
+	if len(settings) == 0 {
+		sb.WriteString("properties: {}\n")
+		return sb.String()
+	}
+	schema, err := yaml.Marshal(map[string]interface{}{"properties": settingsProperties(settings, true)})
+	if err != nil {
+		sb.WriteString("properties: {}\n")
+		return sb.String()
+	}
+	sb.Write(schema)
+	return sb.String()
+}
+
+func settingsProperties(settings map[string]interface{}, withDefaults bool) map[string]interface{} {
+	properties := make(map[string]interface{}, len(settings))
+	for key, val := range settings {
+		properties[key] = settingSchema(val, withDefaults)
+	}
+	return properties
+}
+
+// settingSchema returns the schema of a setting value, with the value as the
+// default when withDefault is set. Objects default to {} so Deckhouse applies
+// the defaults of their properties.
+func settingSchema(val interface{}, withDefault bool) map[string]interface{} {
+	var schema map[string]interface{}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		schema = map[string]interface{}{"type": "object", "properties": settingsProperties(v, withDefault)}
+		if withDefault {
+			schema["default"] = map[string]interface{}{}
+		}
+		return schema
+	case []interface{}:
+		items := map[string]interface{}{"type": "string"}
+		if len(v) > 0 {
+			items = settingSchema(v[0], false)
+		}
+		schema = map[string]interface{}{"type": "array", "items": items}
+	default:
+		schema = map[string]interface{}{"type": inferType(val)}
+	}
+	if withDefault && val != nil {
+		schema["default"] = val
+	}
+	return schema
+}
@@ -85,3 +85,31 @@ func TestOpenAPIFromValues_Array(t *testing.T) {
 		t.Error("Expected 'type: array' for array field")
 	}
 }
+
+func TestGenerateConfigValuesSchema_Defaults(t *testing.T) {
+	result := GenerateConfigValuesSchema(map[string]interface{}{
+		"logLevel": "info",
+		"replicas": float64(2),
+		"auth": map[string]interface{}{
+			"enabled": false,
+		},
+	})
+
+	for _, want := range []string{
+		"type: object\n",
+		"  logLevel:\n    default: info\n    type: string\n",
+		"  replicas:\n    default: 2\n    type: integer\n",
+		"  auth:\n    default: {}\n",
+		"      enabled:\n        default: false\n        type: boolean\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in schema:\n%s", want, result)
+		}
+	}
+}
+
+func TestGenerateConfigValuesSchema_Empty(t *testing.T) {
+	if result := GenerateConfigValuesSchema(nil); result != "type: object\nproperties: {}\n" {
+		t.Errorf("unexpected schema without settings:\n%s", result)
+	}
+}
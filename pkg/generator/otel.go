@@ -38,7 +38,7 @@ func GenerateOTELInstrumentation(graph *types.ResourceGraph, opts OTELOptions) *
 		return result
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		kind := r.Original.GVK.Kind
 		if kind != "Deployment" && kind != "StatefulSet" && kind != "DaemonSet" {
 			continue
@@ -47,7 +47,7 @@ func GenerateAdvancedOTEL(graph *types.ResourceGraph, opts AdvancedOTELOptions)
 		return result
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r == nil || r.Original == nil {
 			continue
 		}
@@ -71,8 +71,8 @@ func GenerateAdvancedOTEL(graph *types.ResourceGraph, opts AdvancedOTELOptions)
 		fmt.Fprintf(&sb, "    argument: \"%v\"\n", opts.SamplingRate)
 		if len(opts.ResourceAttributes) > 0 {
 			sb.WriteString("  resource:\n    attributes:\n")
-			for k, v := range opts.ResourceAttributes {
-				fmt.Fprintf(&sb, "      %s: %s\n", k, v)
+			for _, k := range sortedKeys(opts.ResourceAttributes) {
+				fmt.Fprintf(&sb, "      %s: %s\n", k, opts.ResourceAttributes[k])
 			}
 		}
 		result.Instrumentations[name] = sb.String()
@@ -0,0 +1,212 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the generation manifest, relative to the chart directory.
+const ManifestFile = ".dhg/manifest.json"
+
+// ownershipMarker starts the ownership header of every generated file that
+// can carry a comment.
+const ownershipMarker = "Generated by dhg"
+
+// GenerationManifest records the files produced by the last generation of a
+// chart and their content hashes.
+type GenerationManifest struct {
+	// Version is the dhg version that generated the chart.
+	Version string `json:"version"`
+
+	// Files maps slash-separated paths relative to the chart directory to the
+	// sha256 of the file content as written.
+	Files map[string]string `json:"files"`
+}
+
+// FileState classifies a chart file against the generation manifest.
+type FileState string
+
+const (
+	// FileUnmodified is a generated file that was not changed since generation.
+	FileUnmodified FileState = "unmodified"
+
+	// FileModified is a generated file that was edited after generation.
+	FileModified FileState = "modified"
+
+	// FileMissing is a generated file that was deleted.
+	FileMissing FileState = "missing"
+
+	// FileOrphaned carries an ownership header but was not produced by the
+	// last generation (e.g. a resource removed from the input).
+	FileOrphaned FileState = "orphaned"
+
+	// FileUntracked is a file added by the user.
+	FileUntracked FileState = "untracked"
+)
+
+// FileStatus is the state of one chart file.
+type FileStatus struct {
+	Path  string
+	State FileState
+}
+
+// StampOwnership adds an ownership header with the content hash to every file
+// in chartDir that can carry a comment, and writes the generation manifest.
+// Files under files/ (read by .Files.Get) and JSON files are only recorded in
+// the manifest. Call it right after the chart is written.
+func StampOwnership(chartDir, version string) (*GenerationManifest, error) {
+	files, err := readChartFiles(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("ownership: %w", err)
+	}
+
+	manifest := &GenerationManifest{Version: version, Files: make(map[string]string, len(files))}
+	for rel, content := range files {
+		stamped := content
+		if header := ownershipHeader(rel, content); header != "" && !hasOwnershipHeader(content) {
+			stamped = header + content
+			if err := writeFile(filepath.Join(chartDir, filepath.FromSlash(rel)), stamped); err != nil {
+				return nil, err
+			}
+		}
+		manifest.Files[rel] = contentHash(stamped)
+	}
+
+	if err := WriteGenerationManifest(chartDir, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ReadGenerationManifest reads the generation manifest of a chart.
+func ReadGenerationManifest(chartDir string) (*GenerationManifest, error) {
+	data, err := os.ReadFile(filepath.Join(chartDir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("generation manifest: %w", err)
+	}
+	var m GenerationManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("generation manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// WriteGenerationManifest writes the generation manifest of a chart.
+func WriteGenerationManifest(chartDir string, m *GenerationManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("generation manifest: %w", err)
+	}
+	return writeFile(filepath.Join(chartDir, ManifestFile), string(data)+"\n")
+}
+
+// rewriteGeneratedFile replaces the content of the generated file rel in
+// chartDir, edited by dhg itself after generation (e.g. Chart.yaml on a bump),
+// with next. When the generation manifest records the file as unmodified, its
+// ownership header and hash are updated so that it stays unmodified; a file
+// edited by hand stays modified.
+func rewriteGeneratedFile(chartDir, rel, prev, next string) error {
+	manifest, err := ReadGenerationManifest(chartDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	tracked := manifest != nil && manifest.Files[rel] == contentHash(prev)
+	if tracked && hasOwnershipHeader(next) {
+		_, body, _ := strings.Cut(next, "\n")
+		next = ownershipHeader(rel, body) + body
+	}
+	if err := writeFile(filepath.Join(chartDir, filepath.FromSlash(rel)), next); err != nil {
+		return err
+	}
+	if !tracked {
+		return nil
+	}
+	manifest.Files[rel] = contentHash(next)
+	return WriteGenerationManifest(chartDir, manifest)
+}
+
+// ChartStatus compares the files in chartDir with its generation manifest.
+// Results are sorted by path.
+func ChartStatus(chartDir string) ([]FileStatus, error) {
+	manifest, err := ReadGenerationManifest(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := readChartFiles(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+
+	var statuses []FileStatus
+	for rel, hash := range manifest.Files {
+		content, ok := files[rel]
+		switch {
+		case !ok:
+			statuses = append(statuses, FileStatus{Path: rel, State: FileMissing})
+		case contentHash(content) == hash:
+			statuses = append(statuses, FileStatus{Path: rel, State: FileUnmodified})
+		default:
+			statuses = append(statuses, FileStatus{Path: rel, State: FileModified})
+		}
+	}
+	for rel, content := range files {
+		if _, ok := manifest.Files[rel]; ok {
+			continue
+		}
+		state := FileUntracked
+		if hasOwnershipHeader(content) {
+			state = FileOrphaned
+		}
+		statuses = append(statuses, FileStatus{Path: rel, State: state})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Path < statuses[j].Path })
+	return statuses, nil
+}
+
+// ownershipHeader returns the comment header for a generated file, or "" for
+// files that cannot carry one. The hash covers the content below the header.
+func ownershipHeader(rel, content string) string {
+	text := fmt.Sprintf("%s (sha256:%s)", ownershipMarker, contentHash(content)[:16])
+	base := path.Base(rel)
+	ext := path.Ext(rel)
+
+	switch {
+	case inChartDir(rel, "files"):
+		return ""
+	case inChartDir(rel, "templates") && (ext == ".yaml" || ext == ".yml" || ext == ".tpl" || ext == ".txt"):
+		// A template comment keeps the header out of rendered manifests.
+		return "{{/* " + text + " */ -}}\n"
+	case ext == ".yaml" || ext == ".yml" || base == ".helmignore":
+		return "# " + text + "\n"
+	case ext == ".md":
+		return "<!-- " + text + " -->\n"
+	}
+	return ""
+}
+
+// inChartDir reports whether rel is inside the named directory of the chart
+// or of one of its subcharts.
+func inChartDir(rel, dir string) bool {
+	return strings.HasPrefix(rel, dir+"/") || strings.Contains(rel, "/"+dir+"/")
+}
+
+// hasOwnershipHeader reports whether content starts with an ownership header.
+func hasOwnershipHeader(content string) bool {
+	first, _, _ := strings.Cut(content, "\n")
+	return strings.Contains(first, ownershipMarker+" (sha256:")
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStampOwnership(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"Chart.yaml":                "name: app\n",
+		"values.yaml":               "replicas: 1\n",
+		"README.md":                 "# app\n",
+		".helmignore":               ".git/\n",
+		"values.schema.json":        "{}\n",
+		"templates/deployment.yaml": "kind: Deployment\n",
+		"templates/_helpers.tpl":    "{{- define \"app.name\" -}}app{{- end }}\n",
+		"files/config.yaml":         "key: value\n",
+	})
+
+	manifest, err := StampOwnership(chartDir, "1.2.3")
+	if err != nil {
+		t.Fatalf("StampOwnership: %v", err)
+	}
+	if manifest.Version != "1.2.3" || len(manifest.Files) != 8 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	prefixes := map[string]string{
+		"Chart.yaml":                "# Generated by dhg (sha256:",
+		"values.yaml":               "# Generated by dhg (sha256:",
+		".helmignore":               "# Generated by dhg (sha256:",
+		"README.md":                 "<!-- Generated by dhg (sha256:",
+		"templates/deployment.yaml": "{{/* Generated by dhg (sha256:",
+		"templates/_helpers.tpl":    "{{/* Generated by dhg (sha256:",
+	}
+	for rel, prefix := range prefixes {
+		if got := readFile(t, filepath.Join(chartDir, rel)); !strings.HasPrefix(got, prefix) {
+			t.Errorf("%s should start with %q:\n%s", rel, prefix, got)
+		}
+	}
+	for rel, want := range map[string]string{"values.schema.json": "{}\n", "files/config.yaml": "key: value\n"} {
+		if got := readFile(t, filepath.Join(chartDir, rel)); got != want {
+			t.Errorf("%s must not get a header, got %q", rel, got)
+		}
+	}
+
+	// Stamping again does not stack headers.
+	before := readFile(t, filepath.Join(chartDir, "values.yaml"))
+	if _, err := StampOwnership(chartDir, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(chartDir, "values.yaml")); got != before {
+		t.Errorf("header should be added once:\n%s", got)
+	}
+
+	read, err := ReadGenerationManifest(chartDir)
+	if err != nil {
+		t.Fatalf("ReadGenerationManifest: %v", err)
+	}
+	if !reflect.DeepEqual(read, manifest) {
+		t.Errorf("manifest round trip = %+v, want %+v", read, manifest)
+	}
+}
+
+func TestChartStatus(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"values.yaml":         "replicas: 1\n",
+		"templates/a.yaml":    "kind: A\n",
+		"templates/b.yaml":    "kind: B\n",
+		"templates/gone.yaml": "kind: Gone\n",
+	})
+	if _, err := StampOwnership(chartDir, "dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file stamped by an older generation and no longer in the manifest.
+	orphan := readFile(t, filepath.Join(chartDir, "templates/b.yaml"))
+	writeTree(t, chartDir, map[string]string{
+		"templates/a.yaml":      readFile(t, filepath.Join(chartDir, "templates/a.yaml")) + "# edited\n",
+		"templates/old.yaml":    orphan,
+		"templates/custom.yaml": "kind: Custom\n",
+	})
+	if err := os.Remove(filepath.Join(chartDir, "templates/gone.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ChartStatus(chartDir)
+	if err != nil {
+		t.Fatalf("ChartStatus: %v", err)
+	}
+	want := []FileStatus{
+		{Path: "templates/a.yaml", State: FileModified},
+		{Path: "templates/b.yaml", State: FileUnmodified},
+		{Path: "templates/custom.yaml", State: FileUntracked},
+		{Path: "templates/gone.yaml", State: FileMissing},
+		{Path: "templates/old.yaml", State: FileOrphaned},
+		{Path: "values.yaml", State: FileUnmodified},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChartStatus = %+v, want %+v", got, want)
+	}
+}
+
+func TestChartStatus_NoManifest(t *testing.T) {
+	if _, err := ChartStatus(t.TempDir()); err == nil {
+		t.Error("expected an error for a chart without a generation manifest")
+	}
+}
@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PlatformsAll selects every platform of the images: ResolveImagePlatforms
+// lists their per-platform digests without filtering.
+const PlatformsAll = "all"
+
+// ImagePlatform is a platform of an image with the digest of its manifest.
+type ImagePlatform struct {
+	// Platform is os/arch[/variant], e.g. linux/arm64/v8.
+	Platform string
+
+	// Digest is the digest of the image manifest of the platform.
+	Digest string
+}
+
+// PlatformResolver returns the platforms of an image: those of its manifest
+// list, or the platform of a single-platform image.
+type PlatformResolver func(ctx context.Context, image string) ([]ImagePlatform, error)
+
+// ValidatePlatforms checks a --platforms selection: PlatformsAll, or
+// platforms in the form os/arch[/variant].
+func ValidatePlatforms(platforms []string) error {
+	for _, p := range platforms {
+		if p == PlatformsAll {
+			if len(platforms) > 1 {
+				return fmt.Errorf("platform %q cannot be combined with other platforms", PlatformsAll)
+			}
+			continue
+		}
+		parts := strings.Split(p, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			return fmt.Errorf("invalid platform %q (must be os/arch[/variant] or %s)", p, PlatformsAll)
+		}
+		for _, part := range parts {
+			if part == "" {
+				return fmt.Errorf("invalid platform %q (must be os/arch[/variant] or %s)", p, PlatformsAll)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveImagePlatforms sets the Platforms of every image to its platforms
+// among platforms, or all of them for PlatformsAll, and marks the
+// multi-platform images of which only some are selected for filtering.
+// Images pinned by digest are never filtered: their mirror must keep the
+// digest of the whole manifest list. An image without one of the selected
+// platforms is an error.
+func ResolveImagePlatforms(ctx context.Context, refs []ImageRef, platforms []string, resolve PlatformResolver) ([]ImageRef, error) {
+	all := len(platforms) == 1 && platforms[0] == PlatformsAll
+	resolved := make([]ImageRef, 0, len(refs))
+	var errs []error
+	for _, ref := range refs {
+		available, err := resolve(ctx, ref.FullRef)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref.FullRef, err))
+			continue
+		}
+		ref.Platforms, ref.FilterPlatforms = available, false
+		if !all {
+			var selected []ImagePlatform
+			seen := make(map[string]bool)
+			for _, want := range platforms {
+				found := false
+				for _, p := range available {
+					if !matchPlatform(want, p.Platform) {
+						continue
+					}
+					found = true
+					if !seen[p.Digest] {
+						seen[p.Digest] = true
+						selected = append(selected, p)
+					}
+				}
+				if !found {
+					errs = append(errs, fmt.Errorf("%s: no %s image (has %s)", ref.FullRef, want, platformNames(available)))
+				}
+			}
+			ref.Platforms = selected
+			ref.FilterPlatforms = ref.Digest == "" && len(selected) < len(available)
+		}
+		resolved = append(resolved, ref)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return resolved, nil
+}
+
+// matchPlatform reports whether platform satisfies want: want without a
+// variant matches every variant of its os/arch.
+func matchPlatform(want, platform string) bool {
+	return platform == want || strings.HasPrefix(platform, want+"/")
+}
+
+// platformNames returns the platforms, comma-separated.
+func platformNames(platforms []ImagePlatform) string {
+	names := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		names = append(names, p.Platform)
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePlatforms(t *testing.T) {
+	for _, valid := range [][]string{nil, {"all"}, {"linux/amd64", "linux/arm64/v8"}} {
+		if err := ValidatePlatforms(valid); err != nil {
+			t.Errorf("ValidatePlatforms(%q): %v", valid, err)
+		}
+	}
+	for _, invalid := range [][]string{{"linux"}, {"linux/"}, {"linux/arm/v7/extra"}, {"all", "linux/amd64"}} {
+		if err := ValidatePlatforms(invalid); err == nil {
+			t.Errorf("ValidatePlatforms(%q): expected an error", invalid)
+		}
+	}
+}
+
+// testPlatforms resolves nginx to a manifest list of two platforms and
+// busybox to a single-platform image.
+func testPlatforms(_ context.Context, image string) ([]ImagePlatform, error) {
+	switch strings.SplitN(image, "@", 2)[0] {
+	case "nginx:1.25", "nginx":
+		return []ImagePlatform{
+			{Platform: "linux/amd64", Digest: "sha256:amd64"},
+			{Platform: "linux/arm64/v8", Digest: "sha256:arm64"},
+		}, nil
+	case "busybox:1.36":
+		return []ImagePlatform{{Platform: "linux/amd64", Digest: "sha256:busybox"}}, nil
+	}
+	return nil, errors.New("MANIFEST_UNKNOWN")
+}
+
+func TestResolveImagePlatforms(t *testing.T) {
+	refs := []ImageRef{parseImageRef("nginx:1.25"), parseImageRef("nginx@sha256:index")}
+
+	resolved, err := ResolveImagePlatforms(context.Background(), refs, []string{"linux/arm64"}, testPlatforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved[0]; len(got.Platforms) != 1 || got.Platforms[0].Digest != "sha256:arm64" || !got.FilterPlatforms {
+		t.Errorf("nginx:1.25 should be filtered to linux/arm64/v8, got %+v", got)
+	}
+	if got := resolved[1]; len(got.Platforms) != 1 || got.FilterPlatforms {
+		t.Errorf("an image pinned by digest should not be filtered, got %+v", got)
+	}
+
+	resolved, err = ResolveImagePlatforms(context.Background(), refs[:1], []string{PlatformsAll}, testPlatforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved[0]; len(got.Platforms) != 2 || got.FilterPlatforms {
+		t.Errorf("all platforms should be listed without filtering, got %+v", got)
+	}
+
+	resolved, err = ResolveImagePlatforms(context.Background(), refs[:1], []string{"linux/amd64", "linux/arm64/v8"}, testPlatforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved[0].FilterPlatforms {
+		t.Error("selecting every platform should not filter")
+	}
+
+	_, err = ResolveImagePlatforms(context.Background(), []ImageRef{parseImageRef("busybox:1.36"), parseImageRef("redis:7")}, []string{"linux/arm64"}, testPlatforms)
+	if err == nil {
+		t.Fatal("expected errors for a missing platform and an unknown image")
+	}
+	for _, want := range []string{"busybox:1.36: no linux/arm64 image (has linux/amd64)", "redis:7: MANIFEST_UNKNOWN"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestImageList_Platforms(t *testing.T) {
+	refs, err := ResolveImagePlatforms(context.Background(), []ImageRef{parseImageRef("nginx:1.25"), parseImageRef("busybox:1.36")}, []string{PlatformsAll}, testPlatforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := GenerateImageList(refs)
+	want := "busybox:1.36\n" +
+		"#   linux/amd64 sha256:busybox\n" +
+		"nginx:1.25\n" +
+		"#   linux/amd64 sha256:amd64\n" +
+		"#   linux/arm64/v8 sha256:arm64\n"
+	if list != want {
+		t.Errorf("images.txt =\n%s\nwant\n%s", list, want)
+	}
+
+	parsed := ParseImageList("# comment\n" + list)
+	if len(parsed) != 2 || parsed[0].FullRef != "busybox:1.36" || len(parsed[0].Platforms) != 1 || len(parsed[1].Platforms) != 2 {
+		t.Fatalf("unexpected parsed list %+v", parsed)
+	}
+	if parsed[1].Platforms[1] != (ImagePlatform{Platform: "linux/arm64/v8", Digest: "sha256:arm64"}) {
+		t.Errorf("unexpected platform %+v", parsed[1].Platforms[1])
+	}
+}
+
+func TestGenerateMirrorScriptWith_Platforms(t *testing.T) {
+	filtered := parseImageRef("ghcr.io/team/api:v2")
+	filtered.FilterPlatforms = true
+	filtered.Platforms = []ImagePlatform{{Platform: "linux/arm64/v8", Digest: "sha256:arm64"}}
+
+	script, err := GenerateMirrorScriptWith([]ImageRef{filtered}, "registry.internal", MirrorToolSkopeo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "skopeo copy --override-os linux --override-arch arm64 --override-variant v8 docker://ghcr.io/team/api:v2 docker://${TARGET_REGISTRY}/team/api:v2\n"; !strings.Contains(script, want) {
+		t.Errorf("expected %q in script:\n%s", want, script)
+	}
+
+	filtered.Platforms = append(filtered.Platforms, ImagePlatform{Platform: "linux/amd64", Digest: "sha256:amd64"})
+	script, err = GenerateMirrorScriptWith([]ImageRef{filtered}, "registry.internal", MirrorToolCrane)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "crane index filter ghcr.io/team/api:v2 --platform linux/arm64/v8 --platform linux/amd64 -t ${TARGET_REGISTRY}/team/api:v2\n"; !strings.Contains(script, want) {
+		t.Errorf("expected %q in script:\n%s", want, script)
+	}
+
+	if _, err := GenerateMirrorScriptWith([]ImageRef{filtered}, "registry.internal", MirrorToolSkopeo); err == nil || !strings.Contains(err.Error(), "use crane") {
+		t.Errorf("expected skopeo to refuse several platforms, got %v", err)
+	}
+}
+
+func TestVerifyMirroredImages_Platforms(t *testing.T) {
+	images := ParseImageList("nginx:1.25\n#   linux/arm64/v8 sha256:arm64\nbusybox:1.36\n#   linux/arm64 sha256:other\n")
+	digest := func(context.Context, string) (string, error) { return "sha256:index", nil }
+	platforms := func(ctx context.Context, image string) ([]ImagePlatform, error) {
+		return testPlatforms(ctx, strings.TrimPrefix(image, "registry.internal/"))
+	}
+
+	results := VerifyMirroredImages(context.Background(), images, "registry.internal", digest, platforms)
+	if results[0].Err != nil {
+		t.Errorf("nginx: unexpected error: %v", results[0].Err)
+	}
+	if err := results[1].Err; err == nil || !strings.Contains(err.Error(), "platform linux/arm64 (sha256:other) not mirrored (has linux/amd64)") {
+		t.Errorf("busybox: expected a missing platform, got %v", err)
+	}
+
+	if results := VerifyMirroredImages(context.Background(), images, "registry.internal", digest, nil); results[1].Err != nil {
+		t.Errorf("platforms should not be checked without a resolver, got %v", results[1].Err)
+	}
+}
@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// NamespacesTemplatePath is where --preserve-namespaces writes the Namespace objects.
+const NamespacesTemplatePath = "templates/namespaces.yaml"
+
+// releaseNamespacePattern matches the metadata namespace line of resource templates.
+var releaseNamespacePattern = regexp.MustCompile(`(?m)^( *)namespace: \{\{ \$?\.Release\.Namespace \}\}$`)
+
+// PreserveNamespaces keeps every resource in the namespace it was extracted
+// from instead of the release namespace. Each namespace becomes a value under
+// namespaces.<key> (the namespace name in camelCase), resource templates
+// render it as their metadata.namespace, and a Namespace object is generated
+// for it. Namespace objects found among the resources are folded into the
+// generated ones, keeping their labels and annotations.
+//
+// It returns the values to merge into values.yaml, the Namespace template
+// (empty when no resource has a namespace) and copies of the groups with
+// templates rewritten; the input groups are not modified.
+func PreserveNamespaces(groups []*types.ResourceGroup, chartName string) (map[string]interface{}, string, []*types.ResourceGroup) {
+	namespaceObjects := make(map[string]*types.ProcessedResource)
+	keys := make(map[string]string)
+	for _, group := range groups {
+		for _, r := range group.Resources {
+			if ns := r.Original.Object.GetNamespace(); ns != "" {
+				keys[ns] = processor.SanitizeValuesKey(ns)
+			}
+		}
+	}
+	for _, group := range groups {
+		for _, r := range group.Resources {
+			if r.Original.GVK.Kind == "Namespace" && r.Original.GVK.Group == "" {
+				if _, ok := keys[r.Original.Object.GetName()]; ok {
+					namespaceObjects[r.Original.Object.GetName()] = r
+				}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, "", groups
+	}
+
+	out := make([]*types.ResourceGroup, 0, len(groups))
+	for _, group := range groups {
+		copied := *group
+		copied.Resources = make([]*types.ProcessedResource, 0, len(group.Resources))
+		for _, r := range group.Resources {
+			if ns := r.Original.Object.GetName(); namespaceObjects[ns] == r {
+				continue
+			}
+			rc := *r
+			if ns := r.Original.Object.GetNamespace(); ns != "" {
+				rc.TemplateContent = releaseNamespacePattern.ReplaceAllString(r.TemplateContent,
+					fmt.Sprintf("${1}namespace: {{ $$.Values.namespaces.%s }}", keys[ns]))
+			}
+			copied.Resources = append(copied.Resources, &rc)
+		}
+		if len(copied.Resources) > 0 {
+			out = append(out, &copied)
+		}
+	}
+
+	names := make([]string, 0, len(keys))
+	namespaces := make(map[string]interface{}, len(keys))
+	for ns, key := range keys {
+		names = append(names, ns)
+		namespaces[key] = ns
+	}
+	sort.Strings(names)
+
+	values := map[string]interface{}{
+		"createNamespaces": true,
+		"namespaces":       namespaces,
+	}
+	return values, namespacesTemplate(names, keys, namespaceObjects, chartName), out
+}
+
+// namespacesTemplate renders a Namespace object per namespace, gated by
+// createNamespaces. Namespaces are kept on uninstall: deleting one would
+// delete everything in it, including resources the chart does not own.
+func namespacesTemplate(names []string, keys map[string]string, objects map[string]*types.ProcessedResource, chartName string) string {
+	var sb strings.Builder
+	sb.WriteString("{{- if .Values.createNamespaces }}\n")
+	for _, ns := range names {
+		sb.WriteString("---\n")
+		sb.WriteString("apiVersion: v1\n")
+		sb.WriteString("kind: Namespace\n")
+		sb.WriteString("metadata:\n")
+		sb.WriteString(fmt.Sprintf("  name: {{ .Values.namespaces.%s }}\n", keys[ns]))
+		sb.WriteString("  labels:\n")
+		sb.WriteString(fmt.Sprintf("    {{- include \"%s.labels\" . | nindent 4 }}\n", chartName))
+		var labels, annotations map[string]string
+		if obj, ok := objects[ns]; ok {
+			labels = obj.Original.Object.GetLabels()
+			annotations = obj.Original.Object.GetAnnotations()
+		}
+		for _, k := range sortedKeys(labels) {
+			if helperLabelKeys[k] || k == "kubernetes.io/metadata.name" {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("    %s: %q\n", k, labels[k]))
+		}
+		sb.WriteString("  annotations:\n")
+		sb.WriteString("    helm.sh/resource-policy: keep\n")
+		for _, k := range sortedKeys(annotations) {
+			if k == "helm.sh/resource-policy" {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("    %s: %q\n", k, annotations[k]))
+		}
+	}
+	sb.WriteString("{{- end }}\n")
+	return sb.String()
+}
+
+// UnresolvedNamespaceReferences lists the references of resources to
+// resources in another namespace that are not part of the graph: with
+// --preserve-namespaces the chart installs into those namespaces, so the
+// referenced objects must already exist in the cluster.
+func UnresolvedNamespaceReferences(graph *types.ResourceGraph) []string {
+	var problems []string
+	for _, r := range sortedResources(graph) {
+		ns := r.Original.Object.GetNamespace()
+		for _, dep := range r.Dependencies {
+			if dep.Namespace == "" || dep.Namespace == ns {
+				continue
+			}
+			if _, ok := graph.Resources[dep]; ok {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s %s/%s references %s %s/%s, which is not part of the chart",
+				r.Original.GVK.Kind, ns, r.Original.Object.GetName(), dep.GVK.Kind, dep.Namespace, dep.Name))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const preserveNamespacesTestTemplate = `{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.configMaps.%s }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  namespace: {{ $.Release.Namespace }}
+data:
+  key: value
+{{- end }}
+{{- end }}
+`
+
+func preserveNamespacesTestConfigMap(name, namespace string) *types.ProcessedResource {
+	r := makeProcessedResourceWithValues("ConfigMap", name, namespace, nil,
+		map[string]interface{}{"enabled": true}, strings.ReplaceAll(preserveNamespacesTestTemplate, "%s", name))
+	r.ServiceName = name
+	r.TemplatePath = "templates/" + name + "-configmap.yaml"
+	return r
+}
+
+func preserveNamespacesTestGraph() *types.ResourceGraph {
+	frontend := preserveNamespacesTestConfigMap("frontend", "shop-frontend")
+	backend := preserveNamespacesTestConfigMap("backend", "shop-backend")
+	ns := makeProcessedResource("Namespace", "shop-backend", "", map[string]string{
+		"team":                        "shop",
+		"kubernetes.io/metadata.name": "shop-backend",
+	})
+	ns.TemplatePath = "templates/shop-backend-namespace.yaml"
+	ns.TemplateContent = "kind: Namespace\n"
+
+	graph := buildGraph([]*types.ProcessedResource{frontend, backend, ns}, nil)
+	graph.Groups = []*types.ResourceGroup{
+		{Name: "frontend", Resources: []*types.ProcessedResource{frontend}},
+		{Name: "backend", Resources: []*types.ProcessedResource{backend}},
+		{Name: "namespaces", Resources: []*types.ProcessedResource{ns}},
+	}
+	return graph
+}
+
+func TestPreserveNamespaces(t *testing.T) {
+	graph := preserveNamespacesTestGraph()
+
+	values, tmpl, groups := PreserveNamespaces(graph.Groups, "shop")
+
+	namespaces, ok := values["namespaces"].(map[string]interface{})
+	if !ok || namespaces["shopFrontend"] != "shop-frontend" || namespaces["shopBackend"] != "shop-backend" {
+		t.Fatalf("unexpected namespaces values: %v", values["namespaces"])
+	}
+	if values["createNamespaces"] != true {
+		t.Errorf("expected createNamespaces: true, got %v", values["createNamespaces"])
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected the Namespace object's group to be dropped, got %d groups", len(groups))
+	}
+	if !strings.Contains(groups[0].Resources[0].TemplateContent, "  namespace: {{ $.Values.namespaces.shopFrontend }}\n") {
+		t.Errorf("template does not render the source namespace:\n%s", groups[0].Resources[0].TemplateContent)
+	}
+	if graph.Groups[0].Resources[0].TemplateContent != strings.ReplaceAll(preserveNamespacesTestTemplate, "%s", "frontend") {
+		t.Error("input template was modified")
+	}
+
+	for _, want := range []string{
+		"name: {{ .Values.namespaces.shopBackend }}",
+		"name: {{ .Values.namespaces.shopFrontend }}",
+		`team: "shop"`,
+		"helm.sh/resource-policy: keep",
+	} {
+		if !strings.Contains(tmpl, want) {
+			t.Errorf("namespaces template missing %q:\n%s", want, tmpl)
+		}
+	}
+	if strings.Contains(tmpl, "kubernetes.io/metadata.name") {
+		t.Errorf("namespaces template should not set the immutable metadata.name label:\n%s", tmpl)
+	}
+}
+
+func TestPreserveNamespaces_NoNamespaces(t *testing.T) {
+	r := preserveNamespacesTestConfigMap("frontend", "")
+	groups := []*types.ResourceGroup{{Name: "frontend", Resources: []*types.ProcessedResource{r}}}
+
+	values, tmpl, out := PreserveNamespaces(groups, "shop")
+
+	if values != nil || tmpl != "" {
+		t.Errorf("expected nothing generated for cluster-scoped input, got %v\n%s", values, tmpl)
+	}
+	if out[0] != groups[0] {
+		t.Error("expected groups to be returned unchanged")
+	}
+}
+
+func TestUnresolvedNamespaceReferences(t *testing.T) {
+	graph := preserveNamespacesTestGraph()
+	frontend := graph.Groups[0].Resources[0]
+	backend := graph.Groups[1].Resources[0]
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	frontend.Dependencies = []types.ResourceKey{
+		resourceKey(backend),
+		{GVK: configMap, Namespace: "shared", Name: "settings"},
+		{GVK: configMap, Namespace: "shop-frontend", Name: "local"},
+	}
+
+	problems := UnresolvedNamespaceReferences(graph)
+
+	if len(problems) != 1 || !strings.Contains(problems[0], "ConfigMap shared/settings") {
+		t.Errorf("expected only the reference to shared/settings reported, got %v", problems)
+	}
+}
+
+func TestUniversalGenerator_PreserveNamespacesRender(t *testing.T) {
+	charts, err := NewUniversalGenerator().Generate(context.Background(), preserveNamespacesTestGraph(),
+		Options{ChartName: "shop", ChartVersion: "0.1.0", PreserveNamespaces: true})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	chart := charts[0]
+
+	dir := t.TempDir()
+	if err := WriteChart(chart, dir); err != nil {
+		t.Fatalf("WriteChart: %v", err)
+	}
+	rendered, err := helm.RenderChart(filepath.Join(dir, "shop"), helm.RenderOptions{})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if got := rendered.Manifests["templates/backend-configmap.yaml"]; !strings.Contains(got, "namespace: shop-backend") {
+		t.Errorf("backend ConfigMap not in its source namespace:\n%s", got)
+	}
+	namespaces := rendered.Manifests[NamespacesTemplatePath]
+	if strings.Count(namespaces, "kind: Namespace") != 2 || !strings.Contains(namespaces, "name: shop-frontend") {
+		t.Errorf("expected both Namespace objects rendered:\n%s", namespaces)
+	}
+	if _, ok := rendered.Manifests["templates/shop-backend-namespace.yaml"]; ok {
+		t.Error("source Namespace object should be folded into namespaces.yaml")
+	}
+}
@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DefaultProbePath is the HTTP path of synthesized probes.
+const DefaultProbePath = "/healthz"
+
+// httpPortNumbers are the container ports assumed to serve HTTP when the
+// port is not named.
+var httpPortNumbers = map[int]bool{80: true, 3000: true, 8000: true, 8080: true}
+
+// probeValuesRe matches the Deployment templates rendered from
+// services.<service>.deployment values.
+var probeValuesRe = regexp.MustCompile(`^\{\{- \$svc := \.Values\.services\.(\S+) -\}\}\n(?:.*\n)*?\{\{- with \$svc\.deployment \}\}`)
+
+// probeContainerAnchor follows the probes of the containers of Deployment
+// templates rendered from values.
+const probeContainerAnchor = "          {{- with .startupProbe }}\n"
+
+// probeContainerBlock renders HTTP readiness and liveness probes on the port
+// of the container in services.<service>.probes.ports, unless the container
+// has probes of its own.
+const probeContainerBlock = `          {{- $probePort := and $svc.probes $svc.probes.enabled (not .livenessProbe) (not .readinessProbe) (get ($svc.probes.ports | default dict) .name) }}
+          {{- with $probePort }}
+          livenessProbe:
+            httpGet:
+              path: {{ $svc.probes.path | default "/healthz" | quote }}
+              port: {{ . }}
+            initialDelaySeconds: 10
+            periodSeconds: 10
+          readinessProbe:
+            httpGet:
+              path: {{ $svc.probes.path | default "/healthz" | quote }}
+              port: {{ . }}
+            periodSeconds: 10
+          {{- end }}
+`
+
+// httpContainerPort returns the port of container values serving HTTP: its
+// name when the port is named http, web, http-* or *-http, or its number when
+// an unnamed port is 80, 3000, 8000 or 8080. Returns nil when the container
+// exposes no HTTP port.
+func httpContainerPort(container map[string]interface{}) interface{} {
+	ports, _ := container["ports"].([]interface{})
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if protocol, _ := port["protocol"].(string); protocol != "" && protocol != "TCP" {
+			continue
+		}
+		if name, _ := port["name"].(string); name != "" {
+			if name == "http" || name == "web" || strings.HasPrefix(name, "http-") || strings.HasSuffix(name, "-http") {
+				return name
+			}
+			continue
+		}
+		if n, ok := valuesInt(port["containerPort"]); ok && httpPortNumbers[n] {
+			return n
+		}
+	}
+	return nil
+}
+
+// InjectProbes adds HTTP readiness and liveness probes on path to the
+// containers of the Deployment templates of chart that expose an HTTP port
+// and have no probes, gated by services.<service>.probes.enabled. The port of
+// each container is set in services.<service>.probes.ports; values already
+// set are kept. Copy-on-write. Returns the patched chart and the count of
+// patched templates.
+func InjectProbes(chart *types.GeneratedChart, path string) (*types.GeneratedChart, int) {
+	result := copyChartTemplates(chart)
+	if path == "" {
+		path = DefaultProbePath
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &values); err != nil {
+		return result, 0
+	}
+	services, _ := values["services"].(map[string]interface{})
+
+	count := 0
+	for tmplPath, content := range chart.Templates {
+		if strings.Contains(content, "$probePort") || !strings.Contains(content, probeContainerAnchor) {
+			continue
+		}
+		m := probeValuesRe.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+		svc, _ := services[m[1]].(map[string]interface{})
+		workload, _ := svc["deployment"].(map[string]interface{})
+		if workload == nil {
+			continue
+		}
+
+		ports := make(map[string]interface{})
+		for _, container := range workloadContainers(workload) {
+			if container["livenessProbe"] != nil || container["readinessProbe"] != nil {
+				continue
+			}
+			name, _ := container["name"].(string)
+			if port := httpContainerPort(container); port != nil && name != "" {
+				ports[name] = port
+			}
+		}
+		if len(ports) == 0 {
+			continue
+		}
+		if _, set := svc["probes"]; !set {
+			svc["probes"] = map[string]interface{}{
+				"enabled": true,
+				"path":    path,
+				"ports":   ports,
+			}
+		}
+
+		result.Templates[tmplPath] = strings.Replace(content, probeContainerAnchor, probeContainerBlock+probeContainerAnchor, 1)
+		count++
+	}
+
+	if count > 0 {
+		if out, err := yaml.Marshal(values); err == nil {
+			result.ValuesYAML = string(out)
+		}
+	}
+	return result, count
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestInjectProbes(t *testing.T) {
+	deployment := func(service string) string {
+		return "{{- $svc := .Values.services." + service + " -}}\n{{- if $svc.enabled }}\n{{- with $svc.deployment }}\n" +
+			"apiVersion: apps/v1\nkind: Deployment\nspec:\n  template:\n    spec:\n      containers:\n        {{- range .containers }}\n" +
+			"        - name: {{ .name }}\n" + probeContainerAnchor + "          {{- end }}\n        {{- end }}\n{{- end }}\n{{- end }}\n"
+	}
+	chart := makeChart("app", map[string]string{
+		"templates/web-deployment.yaml":    deployment("web"),
+		"templates/api-deployment.yaml":    deployment("api"),
+		"templates/worker-deployment.yaml": deployment("worker"),
+		"templates/db-statefulset.yaml":    "{{- $svc := .Values.services.db -}}\n{{- with $svc.statefulSet }}\n",
+	})
+	chart.ValuesYAML = `services:
+  web:
+    deployment:
+      containers:
+      - name: web
+        ports:
+        - containerPort: 8080
+      - name: metrics
+        ports:
+        - name: metrics
+          containerPort: 9090
+  api:
+    deployment:
+      containers:
+      - name: api
+        ports:
+        - name: http-api
+          containerPort: 9000
+        readinessProbe:
+          tcpSocket:
+            port: 9000
+  worker:
+    deployment:
+      containers:
+      - name: worker
+  db:
+    statefulSet:
+      containers:
+      - name: db
+        ports:
+        - name: http
+          containerPort: 8080
+`
+
+	result, count := InjectProbes(chart, "/ready")
+	if count != 1 {
+		t.Errorf("expected 1 patched template, got %d", count)
+	}
+	web := result.Templates["templates/web-deployment.yaml"]
+	if !strings.Contains(web, probeContainerBlock+probeContainerAnchor) {
+		t.Errorf("expected the probes block before the startupProbe, got:\n%s", web)
+	}
+	for _, path := range []string{"templates/api-deployment.yaml", "templates/worker-deployment.yaml", "templates/db-statefulset.yaml"} {
+		if result.Templates[path] != chart.Templates[path] {
+			t.Errorf("%s: workloads with probes or without HTTP ports should be left alone", path)
+		}
+	}
+	if again, n := InjectProbes(result, "/ready"); n != 0 || again.Templates["templates/web-deployment.yaml"] != web {
+		t.Error("injecting twice should leave the templates unchanged")
+	}
+
+	var values struct {
+		Services map[string]struct {
+			Probes map[string]interface{} `json:"probes"`
+		}
+	}
+	if err := yaml.Unmarshal([]byte(result.ValuesYAML), &values); err != nil {
+		t.Fatal(err)
+	}
+	probes := values.Services["web"].Probes
+	if probes["enabled"] != true || probes["path"] != "/ready" {
+		t.Errorf("unexpected web probes values: %v", probes)
+	}
+	if ports, _ := probes["ports"].(map[string]interface{}); len(ports) != 1 || ports["web"] != float64(8080) {
+		t.Errorf("expected only the web container probed on 8080, got %v", probes["ports"])
+	}
+	if values.Services["api"].Probes != nil {
+		t.Error("api has probes of its own and should get no probes values")
+	}
+}
+
+func TestHTTPContainerPort(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		ports string
+		want  interface{}
+	}{
+		{"named http", "[{name: http, containerPort: 9000}]", "http"},
+		{"named prefix", "[{name: metrics, containerPort: 9090}, {name: http-api, containerPort: 9000}]", "http-api"},
+		{"unnamed common port", "[{containerPort: 8080}]", 8080},
+		{"named other port", "[{name: grpc, containerPort: 8080}]", nil},
+		{"https", "[{name: https, containerPort: 8443}]", nil},
+		{"udp", "[{containerPort: 80, protocol: UDP}]", nil},
+		{"no ports", "[]", nil},
+	} {
+		var ports []interface{}
+		if err := yaml.Unmarshal([]byte(tc.ports), &ports); err != nil {
+			t.Fatal(err)
+		}
+		if got := httpContainerPort(map[string]interface{}{"ports": ports}); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -60,7 +60,7 @@ func SuggestProgressiveDelivery(graph *types.ResourceGraph, opts ProgressiveDeli
 		steps = defaultCanarySteps
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind != "Deployment" {
 			continue
 		}
@@ -51,7 +51,8 @@ func AnalyzePSSCompliance(chart *types.GeneratedChart) PSSReport {
 	var violations []PSSViolation
 	hasWorkload := false
 
-	for path, content := range chart.Templates {
+	for _, path := range sortedKeys(chart.Templates) {
+		content := chart.Templates[path]
 		if !isWorkloadTemplate(content) {
 			continue
 		}
@@ -132,7 +132,7 @@ func AnalyzePVBestPractices(graph *types.ResourceGraph, opts PVAnalysisOptions)
 
 	// Build a map of StorageClass → reclaimPolicy from the graph.
 	scReclaimPolicy := make(map[string]string)
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind == "StorageClass" {
 			obj := r.Original.Object
 			name := obj.GetName()
@@ -144,7 +144,7 @@ func AnalyzePVBestPractices(graph *types.ResourceGraph, opts PVAnalysisOptions)
 	}
 
 	// Iterate PVCs.
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind != "PersistentVolumeClaim" {
 			continue
 		}
@@ -45,7 +45,7 @@ func GenerateRecordingRules(graph *types.ResourceGraph, opts RecordingRulesOptio
 		ns = "default"
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r == nil || r.Original == nil {
 			continue
 		}
@@ -31,7 +31,7 @@ func DetectReloaderCandidates(graph *types.ResourceGraph) []ReloaderCandidate {
 
 	var candidates []ReloaderCandidate
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		kind := r.Original.GVK.Kind
 		if kind != "Deployment" && kind != "StatefulSet" && kind != "DaemonSet" {
 			continue
@@ -47,7 +47,7 @@ func DetectReloaderCandidates(graph *types.ResourceGraph) []ReloaderCandidate {
 		if len(configMaps) == 0 && len(secrets) == 0 {
 			key := r.Original.ResourceKey()
 			for _, rel := range graph.GetRelationshipsFrom(key) {
-				for _, target := range graph.Resources {
+				for _, target := range sortedResources(graph) {
 					if target.Original.ResourceKey() == rel.To {
 						targetKind := target.Original.GVK.Kind
 						targetName := target.Original.Object.GetName()
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
@@ -185,7 +186,7 @@ func AnalyzeRightSizing(graph *types.ResourceGraph, opts RightSizingOptions) *Ri
 		return report
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		kind := r.Original.GVK.Kind
 		if !isWorkloadKind(kind) {
 			continue
@@ -247,8 +248,13 @@ func GenerateRightSizingNotes(report *RightSizingReport) string {
 
 	if len(report.IssuesByType) > 0 {
 		sb.WriteString("Issue summary:\n")
-		for issType, count := range report.IssuesByType {
-			sb.WriteString(fmt.Sprintf("  - %s: %d\n", issType, count))
+		issTypes := make([]string, 0, len(report.IssuesByType))
+		for issType := range report.IssuesByType {
+			issTypes = append(issTypes, string(issType))
+		}
+		sort.Strings(issTypes)
+		for _, issType := range issTypes {
+			sb.WriteString(fmt.Sprintf("  - %s: %d\n", issType, report.IssuesByType[RightSizingIssue(issType)]))
 		}
 		sb.WriteString("\n")
 	}
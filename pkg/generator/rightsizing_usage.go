@@ -0,0 +1,323 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/metrics"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// UsageHeadroom is the factor applied to the observed peak usage to get the
+// recommended requests.
+const UsageHeadroom = 1.2
+
+// UsageLimitRatio is the limit/request ratio of recommended limits.
+const UsageLimitRatio = 1.5
+
+// UsageChangeThreshold is the relative difference between the current and the
+// recommended value below which a value is left unchanged.
+const UsageChangeThreshold = 0.25
+
+const (
+	minCPURequestMillicores = 10
+	minMemoryRequestBytes   = 16 << 20
+)
+
+// ContainerResources holds the CPU and memory requests and limits of a
+// container as quantity strings; empty means unset.
+type ContainerResources struct {
+	CPURequest    string
+	CPULimit      string
+	MemoryRequest string
+	MemoryLimit   string
+}
+
+// Values returns the resources as a container resources values map.
+func (r ContainerResources) Values() map[string]interface{} {
+	values := make(map[string]interface{})
+	set := func(section, name, value string) {
+		if value == "" {
+			return
+		}
+		m, _ := values[section].(map[string]interface{})
+		if m == nil {
+			m = make(map[string]interface{})
+			values[section] = m
+		}
+		m[name] = value
+	}
+	set("requests", "cpu", r.CPURequest)
+	set("requests", "memory", r.MemoryRequest)
+	set("limits", "cpu", r.CPULimit)
+	set("limits", "memory", r.MemoryLimit)
+	return values
+}
+
+// ResourceRecommendation is a request/limit adjustment for one container,
+// derived from its observed usage.
+type ResourceRecommendation struct {
+	Service   string
+	Kind      string
+	Name      string
+	Namespace string
+	Container string
+
+	// ValuesPath is the values path of the workload, e.g.
+	// services.web.deployment.
+	ValuesPath string
+
+	// Pods is the number of pods the usage was observed on.
+	Pods int
+
+	// CPUMillicores and MemoryBytes are the peak usage across the pods.
+	CPUMillicores int64
+	MemoryBytes   int64
+
+	Current     ContainerResources
+	Recommended ContainerResources
+
+	// Changes describes each adjusted value, e.g. "CPU request 500m → 120m".
+	Changes []string
+
+	// UnderProvisioned is true when the observed usage exceeds a current
+	// request or a request is unset.
+	UnderProvisioned bool
+}
+
+// RecommendResources compares the observed usage of every workload container
+// in the graph with its requests and limits. Requests are recommended at
+// UsageHeadroom × the peak usage, memory limits at UsageLimitRatio × the
+// request; CPU limits are only raised when they fall below the recommended
+// request. Containers without usage or whose values are all within
+// UsageChangeThreshold of the recommendation are omitted.
+func RecommendResources(graph *types.ResourceGraph, usage []metrics.ContainerUsage) []ResourceRecommendation {
+	if graph == nil || len(usage) == 0 {
+		return nil
+	}
+
+	var recs []ResourceRecommendation
+	for _, r := range sortedResources(graph) {
+		obj := r.Original.Object
+		kind := obj.GetKind()
+		if !isWorkloadKind(kind) {
+			continue
+		}
+		containers, ok := extractContainersFromObj(obj)
+		if !ok {
+			continue
+		}
+		for _, raw := range containers {
+			c, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := c["name"].(string)
+
+			pods := make(map[string]bool)
+			rec := ResourceRecommendation{
+				Service:    r.ServiceName,
+				Kind:       kind,
+				Name:       obj.GetName(),
+				Namespace:  obj.GetNamespace(),
+				Container:  name,
+				ValuesPath: r.ValuesPath,
+			}
+			for _, u := range usage {
+				if u.Container != name || (rec.Namespace != "" && u.Namespace != rec.Namespace) ||
+					!metrics.PodBelongsTo(kind, rec.Name, u.Pod) {
+					continue
+				}
+				pods[u.Namespace+"/"+u.Pod] = true
+				rec.CPUMillicores = max(rec.CPUMillicores, u.CPUMillicores)
+				rec.MemoryBytes = max(rec.MemoryBytes, u.MemoryBytes)
+			}
+			if len(pods) == 0 {
+				continue
+			}
+			rec.Pods = len(pods)
+
+			requests, _, _ := unstructuredStringMap(c, "resources", "requests")
+			limits, _, _ := unstructuredStringMap(c, "resources", "limits")
+			rec.Current = ContainerResources{
+				CPURequest:    requests["cpu"],
+				CPULimit:      limits["cpu"],
+				MemoryRequest: requests["memory"],
+				MemoryLimit:   limits["memory"],
+			}
+			if recommendContainer(&rec) {
+				recs = append(recs, rec)
+			}
+		}
+	}
+	return recs
+}
+
+// recommendContainer fills the recommended resources and changes of rec from
+// its usage and reports whether any value changed.
+func recommendContainer(rec *ResourceRecommendation) bool {
+	cpuRequest := max(int64(math.Ceil(float64(rec.CPUMillicores)*UsageHeadroom)), minCPURequestMillicores)
+	memoryRequest := max(roundUpMiB(float64(rec.MemoryBytes)*UsageHeadroom), minMemoryRequestBytes)
+	memoryLimit := roundUpMiB(float64(memoryRequest) * UsageLimitRatio)
+
+	rec.Recommended = rec.Current
+	currentCPURequest, hasCPURequest := parseMilli(rec.Current.CPURequest)
+	currentMemoryRequest, hasMemoryRequest := parseBytes(rec.Current.MemoryRequest)
+	rec.UnderProvisioned = !hasCPURequest || !hasMemoryRequest ||
+		rec.CPUMillicores > currentCPURequest || rec.MemoryBytes > currentMemoryRequest
+
+	adjust := func(label, current string, has bool, currentValue, recommended int64, format func(int64) string, field *string) {
+		if has && !significantChange(currentValue, recommended) {
+			return
+		}
+		*field = format(recommended)
+		if current == "" {
+			current = "unset"
+		}
+		rec.Changes = append(rec.Changes, fmt.Sprintf("%s %s → %s", label, current, *field))
+	}
+	adjust("CPU request", rec.Current.CPURequest, hasCPURequest, currentCPURequest, cpuRequest, formatMilli, &rec.Recommended.CPURequest)
+	adjust("memory request", rec.Current.MemoryRequest, hasMemoryRequest, currentMemoryRequest, memoryRequest, formatBytes, &rec.Recommended.MemoryRequest)
+	currentMemoryLimit, hasMemoryLimit := parseBytes(rec.Current.MemoryLimit)
+	adjust("memory limit", rec.Current.MemoryLimit, hasMemoryLimit, currentMemoryLimit, memoryLimit, formatBytes, &rec.Recommended.MemoryLimit)
+	if currentCPULimit, ok := parseMilli(rec.Current.CPULimit); ok && currentCPULimit < cpuRequest {
+		adjust("CPU limit", rec.Current.CPULimit, false, currentCPULimit, int64(math.Ceil(float64(cpuRequest)*UsageLimitRatio)), formatMilli, &rec.Recommended.CPULimit)
+	}
+	return len(rec.Changes) > 0
+}
+
+// significantChange reports whether recommended differs from current by more
+// than UsageChangeThreshold.
+func significantChange(current, recommended int64) bool {
+	if current <= 0 {
+		return true
+	}
+	return math.Abs(float64(recommended-current))/float64(current) > UsageChangeThreshold
+}
+
+func roundUpMiB(b float64) int64 {
+	return int64(math.Ceil(b/(1<<20))) << 20
+}
+
+func parseMilli(s string) (int64, bool) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, false
+	}
+	return q.MilliValue(), true
+}
+
+func parseBytes(s string) (int64, bool) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, false
+	}
+	return q.Value(), true
+}
+
+func formatMilli(m int64) string {
+	return resource.NewMilliQuantity(m, resource.DecimalSI).String()
+}
+
+func formatBytes(b int64) string {
+	return resource.NewQuantity(b, resource.BinarySI).String()
+}
+
+// ApplyResourceRecommendations writes the recommended resources into an
+// environment values file such as values-prod.yaml. Container values are a
+// list, so the containers of each recommended workload are copied from
+// baseValues with the resources replaced. Workloads whose values path is not
+// in baseValues (another chart in separate mode) are skipped. Leading comment
+// lines of valuesFile are kept. Returns the new file and the number of
+// recommendations applied.
+func ApplyResourceRecommendations(valuesFile []byte, baseValues map[string]interface{}, recs []ResourceRecommendation) ([]byte, int, error) {
+	overlay := make(map[string]interface{})
+	applied := 0
+	for _, rec := range recs {
+		path := strings.Split(rec.ValuesPath, ".")
+		containers, ok := overlayContainers(overlay, baseValues, path)
+		if !ok {
+			continue
+		}
+		for _, raw := range containers {
+			c, ok := raw.(map[string]interface{})
+			if !ok || c["name"] != rec.Container {
+				continue
+			}
+			c["resources"] = rec.Recommended.Values()
+			applied++
+		}
+	}
+	if applied == 0 {
+		return valuesFile, 0, nil
+	}
+
+	var header bytes.Buffer
+	body := valuesFile
+	for len(body) > 0 && body[0] == '#' {
+		line, rest, _ := bytes.Cut(body, []byte("\n"))
+		header.Write(line)
+		header.WriteByte('\n')
+		body = rest
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(body, &values); err != nil {
+		return nil, 0, fmt.Errorf("parsing values: %w", err)
+	}
+	out, err := yaml.Marshal(MergeEnvProfiles(values, overlay))
+	if err != nil {
+		return nil, 0, err
+	}
+	return append(header.Bytes(), out...), applied, nil
+}
+
+// overlayContainers returns the containers list at path in overlay, copying
+// it from baseValues on first use.
+func overlayContainers(overlay, baseValues map[string]interface{}, path []string) ([]interface{}, bool) {
+	base := baseValues
+	for _, key := range path {
+		next, ok := base[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		base = next
+	}
+	baseContainers, ok := base["containers"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	parent := overlay
+	for _, key := range path {
+		next, _ := parent[key].(map[string]interface{})
+		if next == nil {
+			next = make(map[string]interface{})
+			parent[key] = next
+		}
+		parent = next
+	}
+	if containers, ok := parent["containers"].([]interface{}); ok {
+		return containers, true
+	}
+
+	containers := make([]interface{}, len(baseContainers))
+	for i, raw := range baseContainers {
+		c, ok := raw.(map[string]interface{})
+		if !ok {
+			containers[i] = raw
+			continue
+		}
+		copied := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			copied[k] = v
+		}
+		containers[i] = copied
+	}
+	parent["containers"] = containers
+	return containers, true
+}
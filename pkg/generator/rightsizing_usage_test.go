@@ -0,0 +1,210 @@
+package generator
+
+// ============================================================
+// Test Plan — rightsizing_usage_test.go
+//
+//  1. TestRecommendResources_Overprovisioned            — happy   usage well below requests → requests and memory limit lowered, CPU limit kept
+//  2. TestRecommendResources_MissingRequests            — happy   no requests or limits → all set from usage, flagged under-provisioned
+//  3. TestRecommendResources_WithinThreshold            — boundary values within 25% of the recommendation → no recommendation
+//  4. TestRecommendResources_CPULimitBelowRequest       — edge    CPU limit below the recommended request → limit raised
+//  5. TestRecommendResources_NoMatchingPods             — edge    usage of other workloads and namespaces → no recommendation
+//  6. TestApplyResourceRecommendations                  — integration containers copied from base values, resources replaced, header kept
+//  7. TestApplyResourceRecommendations_OtherChart       — edge    values path not in base values → file unchanged
+// ============================================================
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/metrics"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func makeUsageTestGraph(cpuReq, cpuLim, memReq, memLim string) *types.ResourceGraph {
+	graph := makeTestGraphWithWorkload("Deployment", "web", "prod", 2, cpuReq, cpuLim, memReq, memLim)
+	for _, r := range graph.Resources {
+		r.ValuesPath = "services.web.deployment"
+	}
+	return graph
+}
+
+func webUsage(pod string, cpu int64, memoryMiB int64) metrics.ContainerUsage {
+	return metrics.ContainerUsage{Namespace: "prod", Pod: pod, Container: "app", CPUMillicores: cpu, MemoryBytes: memoryMiB << 20}
+}
+
+func assertChanges(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("Changes = %q; want %q", got, want)
+	}
+}
+
+// ─── Section 1: Recommendations ─────────────────────────────────────────────
+
+func TestRecommendResources_Overprovisioned(t *testing.T) {
+	graph := makeUsageTestGraph("500m", "1", "1Gi", "2Gi")
+	recs := RecommendResources(graph, []metrics.ContainerUsage{
+		webUsage("web-7d9f8b6c5d-x2x4z", 80, 200),
+		webUsage("web-7d9f8b6c5d-q8w2r", 100, 150),
+	})
+	if len(recs) != 1 {
+		t.Fatalf("got %d recommendations; want 1", len(recs))
+	}
+	rec := recs[0]
+	if rec.Pods != 2 || rec.CPUMillicores != 100 || rec.MemoryBytes != 200<<20 {
+		t.Errorf("usage = %d pods, %dm, %d bytes; want 2 pods, 100m, 200Mi", rec.Pods, rec.CPUMillicores, rec.MemoryBytes)
+	}
+	if rec.UnderProvisioned {
+		t.Error("expected the container not to be under-provisioned")
+	}
+	want := ContainerResources{CPURequest: "120m", CPULimit: "1", MemoryRequest: "240Mi", MemoryLimit: "360Mi"}
+	if rec.Recommended != want {
+		t.Errorf("Recommended = %+v; want %+v", rec.Recommended, want)
+	}
+	assertChanges(t, rec.Changes, "CPU request 500m → 120m", "memory request 1Gi → 240Mi", "memory limit 2Gi → 360Mi")
+	if rec.ValuesPath != "services.web.deployment" || rec.Container != "app" || rec.Service != "web" {
+		t.Errorf("unexpected target %s %s/%s", rec.ValuesPath, rec.Service, rec.Container)
+	}
+}
+
+func TestRecommendResources_MissingRequests(t *testing.T) {
+	graph := makeUsageTestGraph("", "", "", "")
+	recs := RecommendResources(graph, []metrics.ContainerUsage{webUsage("web-7d9f8b6c5d-x2x4z", 300, 600)})
+	if len(recs) != 1 {
+		t.Fatalf("got %d recommendations; want 1", len(recs))
+	}
+	if !recs[0].UnderProvisioned {
+		t.Error("expected a container without requests to be under-provisioned")
+	}
+	assertChanges(t, recs[0].Changes, "CPU request unset → 360m", "memory request unset → 720Mi", "memory limit unset → 1080Mi")
+}
+
+func TestRecommendResources_WithinThreshold(t *testing.T) {
+	graph := makeUsageTestGraph("100m", "200m", "128Mi", "192Mi")
+	recs := RecommendResources(graph, []metrics.ContainerUsage{webUsage("web-7d9f8b6c5d-x2x4z", 90, 110)})
+	if len(recs) != 0 {
+		t.Errorf("expected no recommendation, got %+v", recs)
+	}
+}
+
+func TestRecommendResources_CPULimitBelowRequest(t *testing.T) {
+	graph := makeUsageTestGraph("100m", "100m", "128Mi", "192Mi")
+	recs := RecommendResources(graph, []metrics.ContainerUsage{webUsage("web-7d9f8b6c5d-x2x4z", 200, 110)})
+	if len(recs) != 1 {
+		t.Fatalf("got %d recommendations; want 1", len(recs))
+	}
+	if !recs[0].UnderProvisioned {
+		t.Error("expected usage above the CPU request to be under-provisioned")
+	}
+	assertChanges(t, recs[0].Changes, "CPU request 100m → 240m", "CPU limit 100m → 360m")
+}
+
+func TestRecommendResources_NoMatchingPods(t *testing.T) {
+	graph := makeUsageTestGraph("500m", "1", "1Gi", "2Gi")
+	other := webUsage("web-7d9f8b6c5d-x2x4z", 10, 10)
+	other.Namespace = "stage"
+	recs := RecommendResources(graph, []metrics.ContainerUsage{
+		other,
+		webUsage("web-api-7d9f8b6c5d-x2x4z", 10, 10),
+		webUsage("web-0", 10, 10),
+	})
+	if len(recs) != 0 {
+		t.Errorf("expected no recommendation, got %+v", recs)
+	}
+}
+
+// ─── Section 2: values-prod.yaml ────────────────────────────────────────────
+
+const usageBaseValues = `
+services:
+  web:
+    deployment:
+      replicas: 2
+      containers:
+      - name: app
+        image:
+          repository: web
+          tag: "1.0"
+        resources:
+          requests:
+            cpu: 500m
+      - name: sidecar
+        image:
+          repository: envoy
+          tag: "1.29"
+`
+
+func TestApplyResourceRecommendations(t *testing.T) {
+	var base map[string]interface{}
+	if err := yaml.Unmarshal([]byte(usageBaseValues), &base); err != nil {
+		t.Fatal(err)
+	}
+	prod := []byte("# Production environment overrides\nreplicaCount: 3\n")
+	recs := []ResourceRecommendation{{
+		Container:   "app",
+		ValuesPath:  "services.web.deployment",
+		Recommended: ContainerResources{CPURequest: "120m", MemoryRequest: "240Mi", MemoryLimit: "360Mi"},
+	}}
+
+	out, applied, err := ApplyResourceRecommendations(prod, base, recs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 {
+		t.Errorf("applied = %d; want 1", applied)
+	}
+	if !strings.HasPrefix(string(out), "# Production environment overrides\n") {
+		t.Errorf("header comment lost:\n%s", out)
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(out, &values); err != nil {
+		t.Fatal(err)
+	}
+	if values["replicaCount"] != float64(3) {
+		t.Errorf("replicaCount = %v; want 3", values["replicaCount"])
+	}
+	deployment := values["services"].(map[string]interface{})["web"].(map[string]interface{})["deployment"].(map[string]interface{})
+	if _, ok := deployment["replicas"]; ok {
+		t.Error("only the containers should be overridden")
+	}
+	containers := deployment["containers"].([]interface{})
+	if len(containers) != 2 {
+		t.Fatalf("got %d containers; want 2 (the list is replaced as a whole)", len(containers))
+	}
+	app := containers[0].(map[string]interface{})
+	if app["image"].(map[string]interface{})["repository"] != "web" {
+		t.Errorf("image not copied from the base values: %v", app)
+	}
+	resources := app["resources"].(map[string]interface{})
+	if resources["requests"].(map[string]interface{})["cpu"] != "120m" || resources["limits"].(map[string]interface{})["memory"] != "360Mi" {
+		t.Errorf("unexpected resources: %v", resources)
+	}
+	if _, ok := containers[1].(map[string]interface{})["resources"]; ok {
+		t.Error("sidecar resources should be left unset")
+	}
+	if base["services"].(map[string]interface{})["web"].(map[string]interface{})["deployment"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["resources"].(map[string]interface{})["requests"].(map[string]interface{})["cpu"] != "500m" {
+		t.Error("base values must not be modified")
+	}
+}
+
+func TestApplyResourceRecommendations_OtherChart(t *testing.T) {
+	var base map[string]interface{}
+	if err := yaml.Unmarshal([]byte(usageBaseValues), &base); err != nil {
+		t.Fatal(err)
+	}
+	prod := []byte("# Production environment overrides\nreplicaCount: 3\n")
+	recs := []ResourceRecommendation{{Container: "app", ValuesPath: "services.api.deployment"}}
+
+	out, applied, err := ApplyResourceRecommendations(prod, base, recs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 0 || string(out) != string(prod) {
+		t.Errorf("expected the file unchanged, got %d applied:\n%s", applied, out)
+	}
+}
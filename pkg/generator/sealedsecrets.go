@@ -44,7 +44,7 @@ func DetectSealedSecretCandidates(graph *types.ResourceGraph) []SealedSecretEntr
 	if graph == nil {
 		return result
 	}
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind != "Secret" {
 			continue
 		}
@@ -50,7 +50,7 @@ func DetectSecretsForExample(graph *types.ResourceGraph) []SecretExampleEntry {
 	if graph == nil {
 		return result
 	}
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind != "Secret" {
 			continue
 		}
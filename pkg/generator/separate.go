@@ -3,9 +3,14 @@ package generator
 import (
 	"context"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor/value"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 
 	"sigs.k8s.io/yaml"
@@ -39,6 +44,14 @@ func (g *SeparateGenerator) Generate(ctx context.Context, graph *types.ResourceG
 		return []*types.GeneratedChart{}, nil
 	}
 
+	// Charts depend on the charts of the services they reference, so one
+	// service can be installed together with what it needs. Circular
+	// references leave the charts independent.
+	crossDeps, err := DetectCrossChartDeps(groupResult.Groups, graph, opts.ChartVersion)
+	if err != nil {
+		crossDeps = nil
+	}
+
 	charts := make([]*types.GeneratedChart, 0, len(groupResult.Groups))
 
 	for _, group := range groupResult.Groups {
@@ -46,7 +59,7 @@ func (g *SeparateGenerator) Generate(ctx context.Context, graph *types.ResourceG
 			return nil, ctx.Err()
 		}
 
-		chart, err := g.generateChartForGroup(group, opts)
+		chart, err := g.generateChartForGroup(group, crossDeps[group.Name], opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate chart for group %s: %w", group.Name, err)
 		}
@@ -57,28 +70,46 @@ func (g *SeparateGenerator) Generate(ctx context.Context, graph *types.ResourceG
 }
 
 // generateChartForGroup creates a complete Helm chart for a single service group.
-func (g *SeparateGenerator) generateChartForGroup(group *ServiceGroup, opts Options) (*types.GeneratedChart, error) {
+// Dependencies on other service charts are disabled by default in values.yaml.
+func (g *SeparateGenerator) generateChartForGroup(group *ServiceGroup, deps []helm.Dependency, opts Options) (*types.GeneratedChart, error) {
 	chartName := group.Name
 
 	// Build Chart.yaml.
 	chartMeta := helm.ChartMetadata{
-		Name:        chartName,
-		Version:     opts.ChartVersion,
-		AppVersion:  opts.AppVersion,
-		Description: fmt.Sprintf("Helm chart for %s", chartName),
-		APIVersion:  "v2",
-		Type:        "application",
-		Keywords:    []string{"kubernetes", "deckhouse"},
+		Name:         chartName,
+		Version:      opts.ChartVersion,
+		AppVersion:   opts.AppVersion,
+		Description:  fmt.Sprintf("Helm chart for %s", chartName),
+		APIVersion:   "v2",
+		Type:         "application",
+		Keywords:     []string{"kubernetes", "deckhouse"},
+		Dependencies: deps,
 	}
-	chartYAML := helm.GenerateChartYAML(chartMeta)
+	chartYAML := helm.GenerateChartYAML(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata))
 
 	// Build flat values (no service name nesting).
 	values := g.buildFlatValues(group)
+	values["global"] = map[string]interface{}{
+		"imageRegistry":    "",
+		"imagePullSecrets": []interface{}{},
+	}
+	for _, dep := range deps {
+		if _, exists := values[dep.Name]; !exists {
+			values[dep.Name] = map[string]interface{}{"enabled": false}
+		}
+	}
 	valuesYAML, err := marshalFlatValues(chartName, values)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal values: %w", err)
 	}
 
+	var docs map[string]string
+	if opts.ValuesDocs {
+		docs = make(map[string]string)
+		describeResourceValues("", values, group.Resources, docs)
+		valuesYAML = helm.AddValuesDocs(valuesYAML, docs)
+	}
+
 	// Collect templates and rewrite value paths for flat structure.
 	// Processor-generated templates reference .Values.services.<serviceName>.<kind>
 	// but in separate mode, values are flat: .Values.<kind>.
@@ -86,7 +117,7 @@ func (g *SeparateGenerator) generateChartForGroup(group *ServiceGroup, opts Opti
 	for _, resource := range group.Resources {
 		if resource.TemplatePath != "" && resource.TemplateContent != "" {
 			content := rewriteTemplateForSeparateMode(resource.TemplateContent, resource.ServiceName)
-			templates[resource.TemplatePath] = content
+			templates[resource.TemplatePath] = renameHelperIncludes(content, opts.ChartName, chartName)
 		}
 	}
 
@@ -94,52 +125,81 @@ func (g *SeparateGenerator) generateChartForGroup(group *ServiceGroup, opts Opti
 	helpers := helm.GenerateHelpers(chartName)
 
 	// Generate NOTES.txt.
-	notes := helm.GenerateNOTES(chartName, []string{chartName}, helm.NOTESContext{})
+	notes := helm.GenerateNOTES(chartName, []string{chartName}, notesContext(group.Resources, separateNotesNaming(chartName)))
 
-	return &types.GeneratedChart{
-		Name:      chartName,
-		Path:      opts.OutputDir,
-		ChartYAML: chartYAML,
+	chart := &types.GeneratedChart{
+		Name:       chartName,
+		Path:       opts.OutputDir,
+		ChartYAML:  chartYAML,
 		ValuesYAML: valuesYAML,
-		Templates: templates,
-		Helpers:   helpers,
-		Notes:     notes,
-	}, nil
+		Templates:  templates,
+		Helpers:    helpers,
+		Notes:      notes,
+	}
+	if opts.ExternalFileManager != nil {
+		chart.ExternalFiles = append(chart.ExternalFiles, groupExternalFiles(group, opts.ExternalFileManager)...)
+	}
+	if docs != nil && opts.IncludeREADME {
+		chart.ExternalFiles = append(chart.ExternalFiles, types.ExternalFileInfo{
+			Path:    "README.md",
+			Content: helm.GenerateValuesREADME(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata), []string{chartName}, values, docs),
+		})
+	}
+	return chart, nil
+}
+
+// groupExternalFiles returns the files/<service>/ files of the services of
+// group, sorted by path.
+func groupExternalFiles(group *ServiceGroup, manager *value.ExternalFileManager) []types.ExternalFileInfo {
+	prefixes := make(map[string]bool)
+	for _, resource := range group.Resources {
+		prefixes["files/"+resource.ServiceName+"/"] = true
+	}
+	var files []types.ExternalFileInfo
+	for _, file := range manager.GetFiles() {
+		if dir, _ := path.Split(file.Path); prefixes[dir] {
+			files = append(files, types.ExternalFileInfo{Path: file.Path, Content: file.Content})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
 }
 
 // buildFlatValues builds flat values for a service group.
 // Unlike universal mode, values are NOT nested under a service name.
+// The enabled flag gates every template of the chart.
 func (g *SeparateGenerator) buildFlatValues(group *ServiceGroup) map[string]interface{} {
-	values := make(map[string]interface{})
+	values := map[string]interface{}{"enabled": true}
 
 	// Organize resources by kind.
 	resourcesByKind := make(map[string][]*types.ProcessedResource)
 	for _, resource := range group.Resources {
-		kind := resource.Original.GVK.Kind
+		kind := processor.ValuesKind(resource.Original.GVK, resource.Original.Object)
 		resourcesByKind[kind] = append(resourcesByKind[kind], resource)
 	}
 
 	// Build values per kind.
 	for kind, resources := range resourcesByKind {
-		if kind == "ConfigMap" || kind == "Secret" {
-			// Always use nested structure for ConfigMaps and Secrets.
+		if processor.ValuesByName(kind) {
+			// Always use nested structure for ConfigMaps, Secrets,
+			// PersistentVolumes and StorageClasses.
 			kindMap := make(map[string]interface{})
 			for _, resource := range resources {
-				resourceName := sanitizeName(resource.Original.Object.GetName())
+				resourceName := processor.SanitizeValuesKey(resource.Original.Object.GetName())
 				kindMap[resourceName] = resource.Values
 			}
-			values[pluralizeKind(kind)] = kindMap
+			values[processor.PluralValuesKey(kind)] = kindMap
 		} else if len(resources) == 1 {
 			// Single resource: nest under kind key.
-			values[kindToValuesKey(kind)] = resources[0].Values
+			values[processor.ValuesKey(kind)] = resources[0].Values
 		} else {
 			// Multiple resources of same kind.
 			kindMap := make(map[string]interface{})
 			for _, resource := range resources {
-				resourceName := sanitizeName(resource.Original.Object.GetName())
+				resourceName := processor.SanitizeValuesKey(resource.Original.Object.GetName())
 				kindMap[resourceName] = resource.Values
 			}
-			values[pluralizeKind(kind)] = kindMap
+			values[processor.PluralValuesKey(kind)] = kindMap
 		}
 	}
 
@@ -165,10 +225,31 @@ func marshalFlatValues(chartName string, values map[string]interface{}) (string,
 	return sb.String(), nil
 }
 
+// helperIncludeRegex matches the chart name prefix of the named templates
+// included by a template: {{ include "<chart>.labels" . }}.
+var helperIncludeRegex = regexp.MustCompile(`((?:include|template)\s+")([A-Za-z0-9_-]+)\.`)
+
+// renameHelperIncludes points the helpers included by a template of chart from
+// at those of chart to. Processors name the helpers after the chart they
+// generate for, while each chart of a service group defines its own.
+func renameHelperIncludes(content, from, to string) string {
+	if from == "" || from == to {
+		return content
+	}
+	return helperIncludeRegex.ReplaceAllStringFunc(content, func(m string) string {
+		sub := helperIncludeRegex.FindStringSubmatch(m)
+		if sub[2] != from {
+			return m
+		}
+		return sub[1] + to + "."
+	})
+}
+
 // rewriteTemplateForSeparateMode rewrites template content to use flat value paths.
 // Replaces patterns like:
-//   .Values.services.<svc>.<path> -> .Values.<path>
-//   $svc := .Values.services.<svc> -> $svc := .Values
+//
+//	.Values.services.<svc>.<path> -> .Values.<path>
+//	$svc := .Values.services.<svc> -> $svc := .Values
 func rewriteTemplateForSeparateMode(content, serviceName string) string {
 	if serviceName == "" {
 		return content
@@ -176,6 +257,13 @@ func rewriteTemplateForSeparateMode(content, serviceName string) string {
 
 	// Replace $svc variable assignment pattern.
 	// e.g., {{- $svc := .Values.services.frontend }} -> {{- $svc := .Values }}
+	// Some processors key the values by the sanitized service name
+	// (web-app -> webApp).
+	if sanitized := processor.SanitizeServiceName(serviceName); sanitized != serviceName {
+		content = strings.ReplaceAll(content,
+			".Values.services."+sanitized,
+			".Values")
+	}
 	content = strings.ReplaceAll(content,
 		".Values.services."+serviceName,
 		".Values")
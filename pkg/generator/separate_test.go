@@ -455,6 +455,52 @@ func TestSeparateGenerator_Templates_ServiceContent(t *testing.T) {
 // Subtask 8: Edge cases
 // ============================================================
 
+func TestSeparateGenerator_Values_EnabledFlag(t *testing.T) {
+	graph := buildGraph([]*types.ProcessedResource{
+		makeProcessedResourceWithValues("Deployment", "frontend", "default",
+			map[string]string{"app.kubernetes.io/name": "frontend"},
+			map[string]interface{}{"replicas": int64(2)}, "{{- if $svc.enabled }}"),
+	}, nil)
+
+	charts, err := NewSeparateGenerator().Generate(context.Background(), graph, Options{ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	if !strings.Contains(charts[0].ValuesYAML, "\nenabled: true\n") {
+		t.Errorf("values.yaml must enable the chart templates:\n%s", charts[0].ValuesYAML)
+	}
+}
+
+func TestSeparateGenerator_CrossChartDependencies(t *testing.T) {
+	gen := NewSeparateGenerator()
+	charts, err := gen.Generate(context.Background(), threeTierGraph(), Options{ChartVersion: "0.1.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	frontend := findChartByName(charts, "frontend")
+	if frontend == nil {
+		t.Fatal("frontend chart not found")
+	}
+	for _, want := range []string{"- name: backend", "repository: file://../backend", "condition: backend.enabled", "tags:\n      - frontend"} {
+		if !strings.Contains(frontend.ChartYAML, want) {
+			t.Errorf("frontend Chart.yaml missing %q:\n%s", want, frontend.ChartYAML)
+		}
+	}
+	if !strings.Contains(frontend.ValuesYAML, "backend:\n  enabled: false") {
+		t.Errorf("dependency must be disabled by default in values.yaml:\n%s", frontend.ValuesYAML)
+	}
+
+	database := findChartByName(charts, "database")
+	if database == nil {
+		t.Fatal("database chart not found")
+	}
+	if strings.Contains(database.ChartYAML, "dependencies:") {
+		t.Errorf("database has no dependencies:\n%s", database.ChartYAML)
+	}
+}
+
 func TestSeparateGenerator_Edge_EmptyGraph(t *testing.T) {
 	// Input: Empty resource graph
 	// Expected: 0 charts, no error
@@ -495,3 +541,27 @@ func TestSeparateGenerator_Edge_SingleResourceGroup(t *testing.T) {
 		t.Error("expected at least 1 template")
 	}
 }
+
+func TestRenameHelperIncludes(t *testing.T) {
+	content := `name: {{ include "app.fullname" $ }}
+labels:
+  {{- include "app.labels" $ | nindent 2 }}
+  {{- template "app.selectorLabels" . }}
+  {{- include "app-lib.labels" $ | nindent 2 }}
+  app: {{ .Values.app.name }}`
+
+	got := renameHelperIncludes(content, "app", "frontend")
+
+	want := `name: {{ include "frontend.fullname" $ }}
+labels:
+  {{- include "frontend.labels" $ | nindent 2 }}
+  {{- template "frontend.selectorLabels" . }}
+  {{- include "app-lib.labels" $ | nindent 2 }}
+  app: {{ .Values.app.name }}`
+	if got != want {
+		t.Errorf("rename mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if renameHelperIncludes(content, "app", "app") != content {
+		t.Error("content should be unchanged when the chart keeps its name")
+	}
+}
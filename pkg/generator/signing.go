@@ -0,0 +1,339 @@
+package generator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Chart signing methods of SignChartPackage.
+const (
+	// SignPGP writes a Helm provenance file (<archive>.prov) clearsigned
+	// with gpg, verifiable with "helm verify".
+	SignPGP = "pgp"
+
+	// SignCosign signs the archive and attests its provenance with cosign,
+	// keyless unless a key is given.
+	SignCosign = "cosign"
+)
+
+// ProvenanceBuilderID identifies dhg as the builder of a chart package; the
+// dhg version is appended as @<version>.
+const ProvenanceBuilderID = "https://github.com/deckhouse/deckhouse-helm-generator"
+
+// ProvenanceBuildType is the buildType of chart package provenance: the
+// invocation parameters are the dhg generate flags.
+const ProvenanceBuildType = "https://github.com/deckhouse/deckhouse-helm-generator/generate@v1"
+
+// ProvenanceSuffix is appended to a chart archive to name its in-toto
+// provenance statement.
+const ProvenanceSuffix = ".provenance.json"
+
+// ProvenanceMaterial is a source a chart was generated from.
+type ProvenanceMaterial struct {
+	// URI locates the source, e.g. file:///src/manifests.
+	URI string
+
+	// SHA256 is the digest of the source content (empty when unknown,
+	// e.g. for a live cluster).
+	SHA256 string
+}
+
+// ChartProvenance describes how a chart was produced.
+type ChartProvenance struct {
+	// DhgVersion is the version of dhg that generated the chart.
+	DhgVersion string
+
+	// Materials are the sources the chart was generated from.
+	Materials []ProvenanceMaterial
+
+	// Parameters are the options dhg was invoked with, keyed by flag name.
+	Parameters map[string]string
+}
+
+// SignOptions configures SignChartPackage.
+type SignOptions struct {
+	// Method is SignPGP or SignCosign.
+	Method string
+
+	// Key is the gpg key (user ID or fingerprint) for SignPGP, or the cosign
+	// key reference (file, KMS URI) for SignCosign. gpg uses its default key
+	// and cosign signs keyless when empty.
+	Key string
+}
+
+// ValidateSignMethod checks a --sign value.
+func ValidateSignMethod(method string) error {
+	if method != SignPGP && method != SignCosign {
+		return fmt.Errorf("invalid signing method: %s (must be %s or %s)", method, SignPGP, SignCosign)
+	}
+	return nil
+}
+
+// SourceDigest returns the sha256 of the files under p (or of the file p):
+// the digest covers every file's slash-separated relative path and content,
+// in path order, so it does not depend on file timestamps.
+func SourceDigest(p string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("source digest: %w", err)
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		rel, err := filepath.Rel(p, file)
+		if err != nil {
+			return "", fmt.Errorf("source digest: %w", err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("source digest: %w", err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ProvenanceStatement returns the in-toto SLSA provenance statement of a chart
+// archive: the archive digest as subject, dhg and its version as builder, the
+// flags as invocation parameters and the sources as materials.
+func ProvenanceStatement(archive string, prov ChartProvenance) ([]byte, error) {
+	digest, err := fileDigest(archive)
+	if err != nil {
+		return nil, fmt.Errorf("provenance: %w", err)
+	}
+	stmt := slsaStatement{
+		Type:          "https://in-toto.io/Statement/v0.1",
+		PredicateType: "https://slsa.dev/provenance/v0.2",
+		Subject: []slsaSubject{{
+			Name:   filepath.Base(archive),
+			Digest: map[string]string{"sha256": digest},
+		}},
+		Predicate: provenancePredicate(prov),
+	}
+	data, err := json.MarshalIndent(stmt, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("provenance: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func provenancePredicate(prov ChartProvenance) slsaPredicate {
+	predicate := slsaPredicate{
+		Builder:    slsaBuilder{ID: ProvenanceBuilderID + "@" + prov.DhgVersion},
+		BuildType:  ProvenanceBuildType,
+		Invocation: &slsaInvocation{Parameters: prov.Parameters},
+		Materials:  make([]slsaMaterial, 0, len(prov.Materials)),
+	}
+	complete := true
+	for _, m := range prov.Materials {
+		material := slsaMaterial{URI: m.URI}
+		if m.SHA256 != "" {
+			material.Digest = map[string]string{"sha256": m.SHA256}
+		} else {
+			complete = false
+		}
+		predicate.Materials = append(predicate.Materials, material)
+	}
+	predicate.Metadata.Completeness.Parameters = true
+	predicate.Metadata.Completeness.Materials = complete && len(prov.Materials) > 0
+	return predicate
+}
+
+// SignChartPackage writes the provenance statement of a chart archive next to
+// it (<archive>.provenance.json) and signs the archive:
+//
+//   - SignPGP: <archive>.prov, a Helm provenance file clearsigned by gpg that
+//     also covers the provenance statement digest;
+//   - SignCosign: <archive>.cosign.bundle with the signature of the archive and
+//     <archive>.intoto.bundle with the provenance attestation.
+//
+// It returns the files written, starting with the provenance statement.
+func SignChartPackage(ctx context.Context, archive string, opts SignOptions, prov ChartProvenance) ([]string, error) {
+	if err := ValidateSignMethod(opts.Method); err != nil {
+		return nil, err
+	}
+	statement, err := ProvenanceStatement(archive, prov)
+	if err != nil {
+		return nil, err
+	}
+	statementFile := archive + ProvenanceSuffix
+	if err := writeFile(statementFile, string(statement)); err != nil {
+		return nil, err
+	}
+	written := []string{statementFile}
+
+	switch opts.Method {
+	case SignPGP:
+		body, err := helmProvenanceBody(archive, statementFile)
+		if err != nil {
+			return nil, err
+		}
+		args := []string{"--clearsign", "--batch", "--yes", "--armor", "--output", archive + ".prov"}
+		if opts.Key != "" {
+			args = append(args, "--local-user", opts.Key)
+		}
+		if err := runSigner(ctx, "gpg", body, args...); err != nil {
+			return nil, err
+		}
+		written = append(written, archive+".prov")
+
+	case SignCosign:
+		args := []string{"sign-blob", "--yes", "--bundle", archive + ".cosign.bundle"}
+		if opts.Key != "" {
+			args = append(args, "--key", opts.Key)
+		}
+		if err := runSigner(ctx, "cosign", nil, append(args, archive)...); err != nil {
+			return nil, err
+		}
+		written = append(written, archive+".cosign.bundle")
+
+		predicate, err := json.Marshal(provenancePredicate(prov))
+		if err != nil {
+			return nil, fmt.Errorf("provenance: %w", err)
+		}
+		predicateFile, err := os.CreateTemp("", "dhg-predicate-*.json")
+		if err != nil {
+			return nil, fmt.Errorf("provenance: %w", err)
+		}
+		defer os.Remove(predicateFile.Name())
+		if _, err := predicateFile.Write(predicate); err != nil {
+			predicateFile.Close()
+			return nil, fmt.Errorf("provenance: %w", err)
+		}
+		if err := predicateFile.Close(); err != nil {
+			return nil, fmt.Errorf("provenance: %w", err)
+		}
+		args = []string{"attest-blob", "--yes", "--type", "slsaprovenance", "--predicate", predicateFile.Name(), "--bundle", archive + ".intoto.bundle"}
+		if opts.Key != "" {
+			args = append(args, "--key", opts.Key)
+		}
+		if err := runSigner(ctx, "cosign", nil, append(args, archive)...); err != nil {
+			return nil, err
+		}
+		written = append(written, archive+".intoto.bundle")
+	}
+	return written, nil
+}
+
+// helmProvenanceBody returns the message Helm signs in a .prov file: the
+// chart metadata, a "..." separator and the sha256 of the archive, here along
+// with the provenance statement.
+func helmProvenanceBody(archive, statementFile string) ([]byte, error) {
+	chartYAML, err := archiveChartYAML(archive)
+	if err != nil {
+		return nil, fmt.Errorf("provenance: %w", err)
+	}
+	var meta map[string]interface{}
+	if err := yaml.Unmarshal(chartYAML, &meta); err != nil {
+		return nil, fmt.Errorf("provenance: Chart.yaml: %w", err)
+	}
+	metaYAML, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("provenance: %w", err)
+	}
+
+	files := make(map[string]string, 2)
+	for _, file := range []string{archive, statementFile} {
+		digest, err := fileDigest(file)
+		if err != nil {
+			return nil, fmt.Errorf("provenance: %w", err)
+		}
+		files[filepath.Base(file)] = "sha256:" + digest
+	}
+	sums, err := yaml.Marshal(map[string]interface{}{"files": files})
+	if err != nil {
+		return nil, fmt.Errorf("provenance: %w", err)
+	}
+
+	var body bytes.Buffer
+	body.Write(metaYAML)
+	body.WriteString("\n...\n")
+	body.Write(sums)
+	return body.Bytes(), nil
+}
+
+// archiveChartYAML returns the top-level Chart.yaml of a chart archive.
+func archiveChartYAML(archive string) ([]byte, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: Chart.yaml not found", archive)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if dir, file := path.Split(hdr.Name); file == "Chart.yaml" && strings.Count(dir, "/") == 1 {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+func fileDigest(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// runSigner runs a signing tool with stdin, reporting its stderr on failure.
+func runSigner(ctx context.Context, tool string, stdin []byte, args ...string) error {
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("signing requires %s in PATH: %w", tool, err)
+	}
+	proc := exec.CommandContext(ctx, tool, args...) //nolint:gosec
+	if stdin != nil {
+		proc.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	proc.Stderr = &stderr
+	if err := proc.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", tool, args[0], err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", tool, args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeChartArchive writes a minimal chart archive to dir.
+func writeChartArchive(t *testing.T, dir string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	chartYAML := "apiVersion: v2\nname: app\nversion: 0.1.0\n"
+	if err := tw.WriteHeader(&tar.Header{Name: "app/Chart.yaml", Mode: 0644, Size: int64(len(chartYAML))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(chartYAML)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "app-0.1.0.tgz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+// fakeSigner installs a shell script named tool first in PATH, logging
+// its arguments and stdin to <dir>/<tool>.log and creating the files passed as
+// --output or --bundle.
+func fakeSigner(t *testing.T, dir, tool string) string {
+	t.Helper()
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, tool+".log")
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+[ -t 0 ] || cat >> ` + log + `
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --output|--bundle) echo signed > "$2"; shift ;;
+  esac
+  shift
+done
+`
+	if err := os.WriteFile(filepath.Join(bin, tool), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func testProvenance() ChartProvenance {
+	return ChartProvenance{
+		DhgVersion: "1.4.0",
+		Materials:  []ProvenanceMaterial{{URI: "file:///src/manifests", SHA256: "abc123"}},
+		Parameters: map[string]string{"chart-name": "app", "sign": "pgp"},
+	}
+}
+
+func TestProvenanceStatement(t *testing.T) {
+	archive := writeChartArchive(t, t.TempDir())
+	data, err := ProvenanceStatement(archive, testProvenance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stmt slsaStatement
+	if err := json.Unmarshal(data, &stmt); err != nil {
+		t.Fatalf("invalid statement: %v\n%s", err, data)
+	}
+	digest, _ := fileDigest(archive)
+	if len(stmt.Subject) != 1 || stmt.Subject[0].Name != "app-0.1.0.tgz" || stmt.Subject[0].Digest["sha256"] != digest {
+		t.Errorf("Subject = %+v; want the archive digest", stmt.Subject)
+	}
+	if stmt.Predicate.Builder.ID != ProvenanceBuilderID+"@1.4.0" {
+		t.Errorf("Builder.ID = %s", stmt.Predicate.Builder.ID)
+	}
+	if stmt.Predicate.Invocation == nil || stmt.Predicate.Invocation.Parameters["chart-name"] != "app" {
+		t.Errorf("Invocation = %+v; want the flags as parameters", stmt.Predicate.Invocation)
+	}
+	if len(stmt.Predicate.Materials) != 1 || stmt.Predicate.Materials[0].Digest["sha256"] != "abc123" {
+		t.Errorf("Materials = %+v", stmt.Predicate.Materials)
+	}
+	if !stmt.Predicate.Metadata.Completeness.Materials {
+		t.Error("materials with digests should be complete")
+	}
+}
+
+func TestSourceDigest(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"deploy.yaml":  "kind: Deployment\n",
+		"svc/svc.yaml": "kind: Service\n",
+		".git/HEAD":    "ref: refs/heads/main\n",
+	})
+	first, err := SourceDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, dir, map[string]string{".git/HEAD": "ref: refs/heads/other\n"})
+	if second, _ := SourceDigest(dir); second != first {
+		t.Error("the digest should not cover .git")
+	}
+	writeTree(t, dir, map[string]string{"svc/svc.yaml": "kind: Service\nspec: {}\n"})
+	if third, _ := SourceDigest(dir); third == first {
+		t.Error("the digest should change with the content")
+	}
+}
+
+func TestSignChartPackage_PGP(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeChartArchive(t, dir)
+	log := fakeSigner(t, dir, "gpg")
+
+	files, err := SignChartPackage(context.Background(), archive, SignOptions{Method: SignPGP, Key: "ops@example.com"}, testProvenance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{archive + ProvenanceSuffix, archive + ".prov"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v; want %v", files, want)
+	}
+	calls := readFile(t, log)
+	for _, s := range []string{"--clearsign", "--local-user ops@example.com", "name: app", "\n...\n", "app-0.1.0.tgz: sha256:", "app-0.1.0.tgz.provenance.json: sha256:"} {
+		if !strings.Contains(calls, s) {
+			t.Errorf("gpg invocation missing %q:\n%s", s, calls)
+		}
+	}
+}
+
+func TestSignChartPackage_Cosign(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeChartArchive(t, dir)
+	log := fakeSigner(t, dir, "cosign")
+
+	files, err := SignChartPackage(context.Background(), archive, SignOptions{Method: SignCosign}, testProvenance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{archive + ProvenanceSuffix, archive + ".cosign.bundle", archive + ".intoto.bundle"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v; want %v", files, want)
+	}
+	calls := readFile(t, log)
+	if !strings.Contains(calls, "sign-blob --yes --bundle "+archive+".cosign.bundle "+archive) {
+		t.Errorf("cosign sign-blob not called:\n%s", calls)
+	}
+	if !strings.Contains(calls, "attest-blob --yes --type slsaprovenance") || strings.Contains(calls, "--key") {
+		t.Errorf("cosign attest-blob should be keyless:\n%s", calls)
+	}
+}
+
+func TestSignChartPackage_Errors(t *testing.T) {
+	dir := t.TempDir()
+	archive := writeChartArchive(t, dir)
+
+	if _, err := SignChartPackage(context.Background(), archive, SignOptions{Method: "x509"}, testProvenance()); err == nil || !strings.Contains(err.Error(), "invalid signing method") {
+		t.Errorf("expected an invalid method error, got %v", err)
+	}
+	if _, err := os.Stat(archive + ProvenanceSuffix); !os.IsNotExist(err) {
+		t.Error("nothing should be written for an invalid method")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := SignChartPackage(context.Background(), archive, SignOptions{Method: SignPGP}, testProvenance()); err == nil || !strings.Contains(err.Error(), "requires gpg in PATH") {
+		t.Errorf("expected a missing gpg error, got %v", err)
+	}
+}
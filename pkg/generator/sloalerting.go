@@ -49,7 +49,7 @@ func GenerateSLOConfig(graph *types.ResourceGraph, opts SLOOptions) *SLOResult {
 		return result
 	}
 
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r == nil || r.Original == nil {
 			continue
 		}
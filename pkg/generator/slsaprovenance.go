@@ -51,12 +51,18 @@ type slsaMetadata struct {
 	Reproducible bool `json:"reproducible"`
 }
 
+// slsaInvocation holds the parameters the build was invoked with.
+type slsaInvocation struct {
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
 // slsaPredicate represents the SLSA provenance predicate.
 type slsaPredicate struct {
-	Builder   slsaBuilder    `json:"builder"`
-	BuildType string         `json:"buildType"`
-	Materials []slsaMaterial `json:"materials"`
-	Metadata  slsaMetadata   `json:"metadata"`
+	Builder    slsaBuilder     `json:"builder"`
+	BuildType  string          `json:"buildType"`
+	Invocation *slsaInvocation `json:"invocation,omitempty"`
+	Materials  []slsaMaterial  `json:"materials"`
+	Metadata   slsaMetadata    `json:"metadata"`
 }
 
 // slsaSubject represents a subject of the provenance statement.
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strings"
 
+	"sigs.k8s.io/yaml"
+
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -15,6 +17,10 @@ const (
 	SpotAWS   SpotProvider = "aws"
 	SpotGCP   SpotProvider = "gcp"
 	SpotAzure SpotProvider = "azure"
+
+	SpotYandex    SpotProvider = "yandex"
+	SpotVK        SpotProvider = "vk"
+	SpotOpenStack SpotProvider = "openstack"
 )
 
 // SpotConfig holds the configuration for spot/preemptible instance support.
@@ -55,6 +61,27 @@ func GenerateSpotTolerations(provider SpotProvider) []map[string]interface{} {
 				"operator": "Equal",
 			},
 		}
+	case SpotYandex:
+		// Preemptible nodes carry the yandex.cloud/preemptible label; taint
+		// their node groups with it to keep other pods off.
+		return []map[string]interface{}{
+			{
+				"key":      "yandex.cloud/preemptible",
+				"value":    "true",
+				"effect":   "NoSchedule",
+				"operator": "Equal",
+			},
+		}
+	case SpotVK, SpotOpenStack:
+		// No preemptible instances: tolerate the taint of a dedicated
+		// Deckhouse node group with any effect.
+		return []map[string]interface{}{
+			{
+				"key":      "dedicated.deckhouse.io",
+				"value":    "preemptible",
+				"operator": "Equal",
+			},
+		}
 	default:
 		return []map[string]interface{}{}
 	}
@@ -133,65 +160,274 @@ spec:
 `, chartName, chartName, minAvailable, chartName)
 }
 
+// GenerateSpotOnDemandAffinity returns a node affinity preferring the nodes
+// without the spot taint of provider, set as a label on spot nodes as well.
+func GenerateSpotOnDemandAffinity(provider SpotProvider) map[string]interface{} {
+	tolerations := GenerateSpotTolerations(provider)
+	if len(tolerations) == 0 {
+		return nil
+	}
+	expressions := make([]interface{}, 0, len(tolerations))
+	for _, tol := range tolerations {
+		expressions = append(expressions, map[string]interface{}{
+			"key":      tol["key"],
+			"operator": "NotIn",
+			"values":   []interface{}{tol["value"]},
+		})
+	}
+	return map[string]interface{}{
+		"nodeAffinity": map[string]interface{}{
+			"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+				map[string]interface{}{
+					"weight":     100,
+					"preference": map[string]interface{}{"matchExpressions": expressions},
+				},
+			},
+		},
+	}
+}
+
 // GenerateSpotValues returns a values map containing spot instance configuration
 // suitable for inclusion in a Helm chart's values.yaml.
 func GenerateSpotValues(config SpotConfig) map[string]interface{} {
-	return map[string]interface{}{
-		"spot": map[string]interface{}{
-			"enabled":     config.Enabled,
-			"provider":    string(config.Provider),
-			"gracePeriod": config.GracePeriod,
-		},
+	tolerations := make([]interface{}, 0)
+	for _, tol := range GenerateSpotTolerations(config.Provider) {
+		tolerations = append(tolerations, tol)
 	}
+	spot := map[string]interface{}{
+		"enabled":     config.Enabled,
+		"provider":    string(config.Provider),
+		"gracePeriod": config.GracePeriod,
+		"tolerations": tolerations,
+	}
+	if affinity := GenerateSpotOnDemandAffinity(config.Provider); affinity != nil {
+		spot["onDemandAffinity"] = affinity
+	}
+	return map[string]interface{}{"spot": spot}
 }
 
-// InjectSpotConfig injects spot/preemptible tolerations into Deployment and StatefulSet
-// templates within the chart. Job and CronJob templates are left unmodified since spot
-// termination handling is typically not appropriate for batch workloads.
-// Returns nil if chart is nil. The original chart is not mutated.
+// spotMinReplicas is the least number of replicas of a Deployment scheduled
+// on spot nodes: a single replica goes down with its node.
+const spotMinReplicas = 2
+
+// spotValuesRe matches the Deployment and StatefulSet templates rendered from
+// services.<service> values.
+var spotValuesRe = regexp.MustCompile(`^\{\{- \$svc := \.Values\.services\.(\S+) -\}\}\n(?:.*\n)*?\{\{- with \$svc\.(deployment|statefulSet) \}\}`)
+
+// spotPodBlock is the affinity and tolerations of the pod spec of Deployment
+// and StatefulSet templates rendered from values.
+const spotPodBlock = `      {{- with .affinity }}
+      affinity:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      {{- with .tolerations }}
+      tolerations:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+`
+
+// InjectSpotConfig schedules the eligible workloads of the chart on
+// spot/preemptible nodes: stateless Deployments with at least two replicas
+// get the spot tolerations and a PDB. StatefulSets and Deployments with fewer
+// replicas prefer on-demand nodes through node affinity. DaemonSets, Jobs and
+// CronJobs are left unmodified.
+//
+// Templates rendered from values are switched with services.<name>.spot.enabled,
+// defaulting to the eligibility of the service, and the spot section of the
+// values. Returns nil if chart is nil. The original chart is not mutated.
 func InjectSpotConfig(chart *types.GeneratedChart, config SpotConfig) *types.GeneratedChart {
 	if chart == nil {
 		return nil
 	}
 
 	tolerations := GenerateSpotTolerations(config.Provider)
+	affinity := GenerateSpotOnDemandAffinity(config.Provider)
+	result := copyChartTemplates(chart)
 
-	// Copy templates map — do not mutate the original.
-	templates := make(map[string]string, len(chart.Templates))
-	for k, v := range chart.Templates {
-		templates[k] = v
+	values := make(map[string]interface{})
+	if strings.TrimSpace(chart.ValuesYAML) != "" {
+		if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &values); err != nil {
+			values = nil
+		}
 	}
+	toggles := make(map[string]bool)
 
 	// defaultReplicas is used when replica count cannot be parsed from the template.
 	const defaultReplicas = 2
 
-	for name, content := range templates {
-		// Only inject into Deployments and StatefulSets.
-		if strings.Contains(content, "kind: Deployment") || strings.Contains(content, "kind: StatefulSet") {
-			// Skip Jobs and CronJobs that might also match.
-			if strings.Contains(content, "kind: Job") || strings.Contains(content, "kind: CronJob") {
+	for name, content := range chart.Templates {
+		kind := extractKind(content)
+		if kind != "Deployment" && kind != "StatefulSet" {
+			continue
+		}
+
+		if m := spotValuesRe.FindStringSubmatch(content); m != nil {
+			if values == nil || !strings.Contains(content, spotPodBlock) {
 				continue
 			}
-			templates[name] = injectTolerationsIntoTemplate(content, tolerations)
+			service := m[1]
+			if kind == "StatefulSet" {
+				result.Templates[name] = strings.Replace(content, spotPodBlock,
+					spotAffinityBlock("$.Values.spot.enabled")+spotTolerationsBlock(false), 1)
+				continue
+			}
+			result.Templates[name] = strings.Replace(content, spotPodBlock,
+				"      {{- $spot := and $.Values.spot.enabled $svc.spot $svc.spot.enabled }}\n"+
+					spotAffinityBlock("$.Values.spot.enabled (not $spot)")+spotTolerationsBlock(true), 1)
+			replicas := serviceReplicas(values, service)
+			toggles[service] = replicas >= spotMinReplicas
+			if toggles[service] && !hasServicePDB(chart.Templates, service) {
+				result.Templates[spotPDBTemplateKey(name)] = generateSpotServicePDB(chart.Name, service, replicas)
+			}
+			continue
+		}
 
-			// Generate a PDB template for each Deployment/StatefulSet.
-			replicas := extractReplicas(content, defaultReplicas)
-			pdbKey := spotPDBTemplateKey(name)
-			templates[pdbKey] = GenerateSpotPDBHelm(chart.Name, replicas)
+		replicas := extractReplicas(content, defaultReplicas)
+		if kind == "Deployment" && replicas >= spotMinReplicas {
+			result.Templates[name] = injectTolerationsIntoTemplate(content, tolerations)
+			result.Templates[spotPDBTemplateKey(name)] = GenerateSpotPDBHelm(chart.Name, replicas)
+			continue
 		}
+		result.Templates[name] = injectAffinityIntoTemplate(content, affinity)
 	}
 
-	return &types.GeneratedChart{
-		Name:          chart.Name,
-		Path:          chart.Path,
-		ChartYAML:     chart.ChartYAML,
-		ValuesYAML:    chart.ValuesYAML,
-		Templates:     templates,
-		Helpers:       chart.Helpers,
-		Notes:         chart.Notes,
-		ValuesSchema:  chart.ValuesSchema,
-		ExternalFiles: chart.ExternalFiles,
+	result.ValuesYAML = mergeSpotValues(chart.ValuesYAML, values, GenerateSpotValues(config), toggles)
+	return result
+}
+
+// spotAffinityBlock renders the affinity of the pod, defaulting to the
+// on-demand affinity of the spot values when condition holds.
+func spotAffinityBlock(condition string) string {
+	return "      {{- $affinity := .affinity }}\n" +
+		fmt.Sprintf("      {{- if and %s (not $affinity) }}\n", condition) +
+		"      {{- $affinity = $.Values.spot.onDemandAffinity }}\n" +
+		"      {{- end }}\n" +
+		"      {{- with $affinity }}\n" +
+		"      affinity:\n" +
+		"        {{- toYaml . | nindent 8 }}\n" +
+		"      {{- end }}\n"
+}
+
+// spotTolerationsBlock renders the tolerations of the pod, adding the spot
+// tolerations of the values when spot is true in the template.
+func spotTolerationsBlock(spot bool) string {
+	if !spot {
+		return "      {{- with .tolerations }}\n" +
+			"      tolerations:\n" +
+			"        {{- toYaml . | nindent 8 }}\n" +
+			"      {{- end }}\n"
 	}
+	return "      {{- $tolerations := .tolerations }}\n" +
+		"      {{- if $spot }}\n" +
+		"      {{- $tolerations = concat (.tolerations | default list) $.Values.spot.tolerations }}\n" +
+		"      {{- end }}\n" +
+		"      {{- with $tolerations }}\n" +
+		"      tolerations:\n" +
+		"        {{- toYaml . | nindent 8 }}\n" +
+		"      {{- end }}\n"
+}
+
+// serviceReplicas returns the replicas of the Deployment of service in
+// values: the minReplicas of its enabled autoscaling, else its replicas (1
+// when unset, as rendered by the template).
+func serviceReplicas(values map[string]interface{}, service string) int {
+	services, _ := values["services"].(map[string]interface{})
+	svc, _ := services[service].(map[string]interface{})
+	if autoscaling, ok := svc["autoscaling"].(map[string]interface{}); ok && autoscaling["enabled"] == true {
+		if n, ok := valuesInt(autoscaling["minReplicas"]); ok {
+			return n
+		}
+	}
+	deployment, _ := svc["deployment"].(map[string]interface{})
+	if n, ok := valuesInt(deployment["replicas"]); ok {
+		return n
+	}
+	return 1
+}
+
+// valuesInt converts a number decoded from YAML to an int.
+func valuesInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// hasServicePDB reports whether templates hold a PodDisruptionBudget of
+// service: a second one would make its pods unevictable.
+func hasServicePDB(templates map[string]string, service string) bool {
+	prefix := fmt.Sprintf("{{- $svc := .Values.services.%s -}}\n", service)
+	for _, content := range templates {
+		if strings.HasPrefix(content, prefix) && extractKind(content) == "PodDisruptionBudget" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateSpotServicePDB returns the PodDisruptionBudget of the pods of
+// service on spot nodes, rendered while its spot toggle is on.
+func generateSpotServicePDB(chartName, service string, replicas int) string {
+	minAvailable := "minAvailable: 1"
+	if replicas > 2 {
+		minAvailable = `minAvailable: "50%"`
+	}
+	return fmt.Sprintf(`{{- $svc := .Values.services.%[2]s -}}
+{{- if and $svc.enabled $.Values.spot.enabled $svc.spot $svc.spot.enabled }}
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: {{ include "%[1]s.fullname" $ }}-%[2]s-spot
+  namespace: {{ $.Release.Namespace }}
+  labels:
+    {{- include "%[1]s.labels" $ | nindent 4 }}
+    app.kubernetes.io/component: %[2]s
+spec:
+  %[3]s
+  selector:
+    matchLabels:
+      {{- include "%[1]s.selectorLabels" $ | nindent 6 }}
+      app.kubernetes.io/component: %[2]s
+{{- end }}
+`, chartName, service, minAvailable)
+}
+
+// mergeSpotValues adds the spot section and the spot toggles of services to
+// values, keeping the ones already set. Unparsable values get the spot
+// section appended.
+func mergeSpotValues(valuesYAML string, values, spot map[string]interface{}, toggles map[string]bool) string {
+	if values == nil {
+		data, err := yaml.Marshal(spot)
+		if err != nil {
+			return valuesYAML
+		}
+		return strings.TrimRight(valuesYAML, "\n") + "\n\n# Spot/preemptible instances (--spot)\n" + string(data)
+	}
+
+	if _, ok := values["spot"]; !ok {
+		values["spot"] = spot["spot"]
+	}
+	services, _ := values["services"].(map[string]interface{})
+	for service, enabled := range toggles {
+		svc, ok := services[service].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, set := svc["spot"]; !set {
+			svc["spot"] = map[string]interface{}{"enabled": enabled}
+		}
+	}
+
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return valuesYAML
+	}
+	return string(out)
 }
 
 // injectTolerationsIntoTemplate inserts a tolerations section into the pod spec
@@ -232,7 +468,9 @@ func injectTolerationsIntoTemplate(template string, tolerations []map[string]int
 			lines = append(lines, indent+"- key: "+key)
 			lines = append(lines, indent+"  operator: "+operator)
 			lines = append(lines, indent+"  value: "+value)
-			lines = append(lines, indent+"  effect: "+effect)
+			if effect != "" {
+				lines = append(lines, indent+"  effect: "+effect)
+			}
 		}
 
 		tolerationsBlock := strings.Join(lines, "\n")
@@ -253,13 +491,39 @@ func injectTolerationsIntoTemplate(template string, tolerations []map[string]int
 		lines = append(lines, fmt.Sprintf("      - key: %s", key))
 		lines = append(lines, fmt.Sprintf("        operator: %s", operator))
 		lines = append(lines, fmt.Sprintf("        value: %s", value))
-		lines = append(lines, fmt.Sprintf("        effect: %s", effect))
+		if effect != "" {
+			lines = append(lines, fmt.Sprintf("        effect: %s", effect))
+		}
 	}
 
 	tolerationsBlock := strings.Join(lines, "\n")
 	return template + "\n" + tolerationsBlock
 }
 
+// injectAffinityIntoTemplate inserts affinity into the pod spec of a
+// workload template before its containers, unless the template already has
+// an affinity.
+func injectAffinityIntoTemplate(template string, affinity map[string]interface{}) string {
+	if affinity == nil || strings.Contains(template, "affinity:") {
+		return template
+	}
+	match := regexp.MustCompile(`(\n)([ \t]+)(containers:\s*\n)`).FindStringSubmatchIndex(template)
+	if match == nil {
+		return template
+	}
+	data, err := yaml.Marshal(map[string]interface{}{"affinity": affinity})
+	if err != nil {
+		return template
+	}
+	indent := template[match[4]:match[5]]
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		sb.WriteString(indent + line + "\n")
+	}
+	insertPos := match[0] + 1
+	return template[:insertPos] + sb.String() + template[insertPos:]
+}
+
 // extractReplicas parses the `replicas:` value from a Kubernetes workload template.
 // Returns defaultVal if the field is absent or uses a Helm template expression.
 func extractReplicas(content string, defaultVal int) int {
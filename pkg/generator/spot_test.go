@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 
+	"sigs.k8s.io/yaml"
+
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -13,6 +15,7 @@ import (
 //  1. TestSpot_AWS_Tolerations              — happy   AWS → key="node.kubernetes.io/lifecycle", value="spot"
 //  2. TestSpot_GCP_Tolerations              — happy   GCP → key="cloud.google.com/gke-preemptible"
 //  3. TestSpot_Azure_Tolerations            — happy   Azure → key="kubernetes.azure.com/scalesetpriority"
+//     TestSpot_DeckhouseClouds_Tolerations  — happy   Yandex → yandex.cloud/preemptible, VK/OpenStack → dedicated.deckhouse.io
 //  4. TestSpot_PreStopHook_Default15s       — happy   gracePeriod=15 → command contains "sleep 15"
 //  5. TestSpot_PreStopHook_Custom30s        — happy   gracePeriod=30 → command contains "sleep 30"
 //  6. TestSpot_PDB_LowReplicas_MinAvailable1    — boundary replicas=1 → PDB YAML contains "minAvailable: 1"
@@ -116,6 +119,29 @@ func TestSpot_Azure_Tolerations(t *testing.T) {
 	}
 }
 
+func TestSpot_DeckhouseClouds_Tolerations(t *testing.T) {
+	yandex := GenerateSpotTolerations(SpotYandex)
+	if len(yandex) != 1 || yandex[0]["key"] != "yandex.cloud/preemptible" || yandex[0]["value"] != "true" || yandex[0]["effect"] != "NoSchedule" {
+		t.Errorf("unexpected Yandex tolerations: %v", yandex)
+	}
+
+	for _, provider := range []SpotProvider{SpotVK, SpotOpenStack} {
+		tolerations := GenerateSpotTolerations(provider)
+		if len(tolerations) != 1 || tolerations[0]["key"] != "dedicated.deckhouse.io" || tolerations[0]["value"] != "preemptible" {
+			t.Errorf("%s: unexpected tolerations: %v", provider, tolerations)
+		}
+	}
+
+	chart := makeChart("myapp", map[string]string{
+		"templates/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: myapp\nspec:\n  template:\n    spec:\n      containers:\n        - name: app\n",
+	})
+	content := InjectSpotConfig(chart, SpotConfig{Provider: SpotOpenStack}).Templates["templates/deployment.yaml"]
+	want := "      tolerations:\n      - key: dedicated.deckhouse.io\n        operator: Equal\n        value: preemptible\n      containers:"
+	if !strings.Contains(content, want) {
+		t.Errorf("expected a toleration of any effect:\n%s", content)
+	}
+}
+
 // ============================================================
 // Section 2: GenerateSpotPreStopHook — gracePeriod values
 // ============================================================
@@ -595,7 +621,7 @@ spec:
 	}
 }
 
-func TestInjectSpotConfig_GeneratesPDB_LowReplicas(t *testing.T) {
+func TestInjectSpotConfig_SingleReplica_PrefersOnDemand(t *testing.T) {
 	deploymentYAML := `apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -623,18 +649,23 @@ spec:
 		t.Fatal("InjectSpotConfig returned nil")
 	}
 
-	pdbContent, ok := result.Templates["templates/deployment-spot-pdb.yaml"]
-	if !ok {
-		t.Fatalf("expected PDB template, available keys: %v", templateKeys(result))
+	// A single replica is not eligible for spot nodes.
+	if _, ok := result.Templates["templates/deployment-spot-pdb.yaml"]; ok {
+		t.Error("a single-replica Deployment must not get a spot PDB")
 	}
-
-	// With replicas=1 (<=2), minAvailable should be 1.
-	if !strings.Contains(pdbContent, "minAvailable: 1") {
-		t.Errorf("PDB template for replicas=1 must contain 'minAvailable: 1', got:\n%s", pdbContent)
+	content := result.Templates["templates/deployment.yaml"]
+	if strings.Contains(content, "tolerations:") {
+		t.Errorf("a single-replica Deployment must not tolerate spot nodes, got:\n%s", content)
+	}
+	want := "      affinity:\n        nodeAffinity:\n          preferredDuringSchedulingIgnoredDuringExecution:\n" +
+		"          - preference:\n              matchExpressions:\n              - key: cloud.google.com/gke-preemptible\n" +
+		"                operator: NotIn\n                values:\n                - \"true\"\n            weight: 100\n      containers:"
+	if !strings.Contains(content, want) {
+		t.Errorf("expected an on-demand node affinity before containers, got:\n%s", content)
 	}
 }
 
-func TestInjectSpotConfig_GeneratesPDB_StatefulSet(t *testing.T) {
+func TestInjectSpotConfig_StatefulSet_PrefersOnDemand(t *testing.T) {
 	stsYAML := `apiVersion: apps/v1
 kind: StatefulSet
 metadata:
@@ -662,19 +693,15 @@ spec:
 		t.Fatal("InjectSpotConfig returned nil")
 	}
 
-	pdbKey := "templates/statefulset-spot-pdb.yaml"
-	pdbContent, ok := result.Templates[pdbKey]
-	if !ok {
-		t.Fatalf("expected PDB template at key %q, available keys: %v", pdbKey, templateKeys(result))
+	if _, ok := result.Templates["templates/statefulset-spot-pdb.yaml"]; ok {
+		t.Error("a StatefulSet must not get a spot PDB")
 	}
-
-	if !strings.Contains(pdbContent, "kind: PodDisruptionBudget") {
-		t.Errorf("PDB template must contain 'kind: PodDisruptionBudget', got:\n%s", pdbContent)
+	content := result.Templates["templates/statefulset.yaml"]
+	if strings.Contains(content, "tolerations:") {
+		t.Errorf("a StatefulSet must not tolerate spot nodes, got:\n%s", content)
 	}
-
-	// replicas=5 → 50%
-	if !strings.Contains(pdbContent, `"50%"`) {
-		t.Errorf("PDB template for replicas=5 must contain '50%%', got:\n%s", pdbContent)
+	if !strings.Contains(content, "key: kubernetes.azure.com/scalesetpriority\n                operator: NotIn") {
+		t.Errorf("expected a StatefulSet to prefer on-demand nodes, got:\n%s", content)
 	}
 }
 
@@ -754,3 +781,92 @@ func templateKeys(chart *types.GeneratedChart) []string {
 	}
 	return keys
 }
+
+func TestInjectSpotConfig_ValuesTemplates(t *testing.T) {
+	workload := func(service, kind, key string) string {
+		return "{{- $svc := .Values.services." + service + " -}}\n{{- if $svc.enabled }}\n{{- with $svc." + key + " }}\n" +
+			"apiVersion: apps/v1\nkind: " + kind + "\nspec:\n  template:\n    spec:\n" + spotPodBlock +
+			"      containers:\n        - name: app\n{{- end }}\n{{- end }}\n"
+	}
+	chart := makeChart("app", map[string]string{
+		"templates/web-deployment.yaml":    workload("web", "Deployment", "deployment"),
+		"templates/admin-deployment.yaml":  workload("admin", "Deployment", "deployment"),
+		"templates/worker-deployment.yaml": workload("worker", "Deployment", "deployment"),
+		"templates/db-statefulset.yaml":    workload("db", "StatefulSet", "statefulSet"),
+	})
+	chart.ValuesYAML = `services:
+  web:
+    deployment:
+      replicas: 3
+  admin:
+    deployment:
+      replicas: 1
+  worker:
+    deployment:
+      replicas: 1
+    autoscaling:
+      enabled: true
+      minReplicas: 2
+    spot:
+      enabled: false
+  db:
+    statefulSet:
+      replicas: 3
+`
+
+	result := InjectSpotConfig(chart, SpotConfig{Provider: SpotAWS, GracePeriod: 15, Enabled: true})
+
+	web := result.Templates["templates/web-deployment.yaml"]
+	for _, want := range []string{
+		"{{- $spot := and $.Values.spot.enabled $svc.spot $svc.spot.enabled }}",
+		"{{- if and $.Values.spot.enabled (not $spot) (not $affinity) }}",
+		"{{- $tolerations = concat (.tolerations | default list) $.Values.spot.tolerations }}",
+	} {
+		if !strings.Contains(web, want) {
+			t.Errorf("expected %q in the Deployment template, got:\n%s", want, web)
+		}
+	}
+	db := result.Templates["templates/db-statefulset.yaml"]
+	if !strings.Contains(db, "{{- if and $.Values.spot.enabled (not $affinity) }}") || strings.Contains(db, "$spot") {
+		t.Errorf("a StatefulSet should only prefer on-demand nodes, got:\n%s", db)
+	}
+	if again := InjectSpotConfig(result, SpotConfig{Provider: SpotAWS, Enabled: true}); again.Templates["templates/web-deployment.yaml"] != web {
+		t.Error("injecting twice should leave the templates unchanged")
+	}
+
+	pdb, ok := result.Templates["templates/web-deployment-spot-pdb.yaml"]
+	if !ok || !strings.Contains(pdb, "{{- if and $svc.enabled $.Values.spot.enabled $svc.spot $svc.spot.enabled }}") ||
+		!strings.Contains(pdb, "app.kubernetes.io/component: web") || !strings.Contains(pdb, `minAvailable: "50%"`) {
+		t.Errorf("expected a spot PDB of the web component, got:\n%s", pdb)
+	}
+	for _, key := range []string{"templates/admin-deployment-spot-pdb.yaml", "templates/db-statefulset-spot-pdb.yaml"} {
+		if _, ok := result.Templates[key]; ok {
+			t.Errorf("unexpected %s for a workload excluded from spot nodes", key)
+		}
+	}
+
+	var values struct {
+		Spot struct {
+			Enabled          bool
+			Tolerations      []map[string]interface{}
+			OnDemandAffinity map[string]interface{} `json:"onDemandAffinity"`
+		}
+		Services map[string]struct {
+			Spot *struct{ Enabled bool }
+		}
+	}
+	if err := yaml.Unmarshal([]byte(result.ValuesYAML), &values); err != nil {
+		t.Fatal(err)
+	}
+	if !values.Spot.Enabled || len(values.Spot.Tolerations) != 1 || values.Spot.OnDemandAffinity == nil {
+		t.Errorf("unexpected spot values: %+v", values.Spot)
+	}
+	for service, want := range map[string]bool{"web": true, "admin": false, "worker": false} {
+		if s := values.Services[service].Spot; s == nil || s.Enabled != want {
+			t.Errorf("services.%s.spot.enabled: want %v, got %+v", service, want, s)
+		}
+	}
+	if values.Services["db"].Spot != nil {
+		t.Error("a StatefulSet service should have no spot toggle")
+	}
+}
@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncReport lists the files changed by SyncGeneration, as slash-separated
+// paths relative to the output directory. dhg metadata under .dhg is not
+// listed.
+type SyncReport struct {
+	// Added are new files.
+	Added []string
+
+	// Updated are files rewritten with new content.
+	Updated []string
+
+	// Removed are files of the previous generation that are no longer
+	// generated.
+	Removed []string
+}
+
+// IsEmpty reports whether the sync changed no files.
+func (r *SyncReport) IsEmpty() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) == 0
+}
+
+// SyncGeneration updates outputDir with a generation written to generatedDir,
+// rewriting only the files whose content changed. Files of a chart's previous
+// generation that are no longer generated are removed unless they were edited
+// since (see ChartStatus).
+func SyncGeneration(outputDir, generatedDir string) (*SyncReport, error) {
+	// Charts are the directories with dhg metadata; their previous generation
+	// manifests are read before they are replaced.
+	var charts []string
+	previous := make(map[string]*GenerationManifest)
+	err := filepath.WalkDir(generatedDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || d.Name() != ".dhg" {
+			return err
+		}
+		rel, err := filepath.Rel(generatedDir, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		charts = append(charts, rel)
+		if manifest, err := ReadGenerationManifest(filepath.Join(outputDir, rel)); err == nil {
+			previous[rel] = manifest
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sync: %w", err)
+	}
+
+	report := &SyncReport{}
+	err = filepath.WalkDir(generatedDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(generatedDir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Base snapshots are replaced as a whole below.
+			if filepath.Base(p) == filepath.Base(BaseSnapshotDir) && filepath.Base(filepath.Dir(p)) == ".dhg" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(outputDir, rel)
+		current, err := os.ReadFile(target)
+		existed := err == nil
+		if existed && string(current) == string(data) {
+			return nil
+		}
+		if err := writeFile(target, string(data)); err != nil {
+			return err
+		}
+		switch rel = filepath.ToSlash(rel); {
+		case isDhgMetadata(rel):
+		case !existed:
+			report.Added = append(report.Added, rel)
+		default:
+			report.Updated = append(report.Updated, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sync: %w", err)
+	}
+
+	for _, dir := range charts {
+		base, err := readChartFiles(filepath.Join(generatedDir, dir, BaseSnapshotDir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sync: %w", err)
+		}
+		if err := writeBaseSnapshot(filepath.Join(outputDir, dir), base); err != nil {
+			return nil, err
+		}
+	}
+
+	for dir, prev := range previous {
+		next, err := ReadGenerationManifest(filepath.Join(generatedDir, dir))
+		if err != nil {
+			continue
+		}
+		for rel, hash := range prev.Files {
+			if _, ok := next.Files[rel]; ok {
+				continue
+			}
+			file := filepath.Join(outputDir, dir, filepath.FromSlash(rel))
+			data, err := os.ReadFile(file)
+			if err != nil || contentHash(string(data)) != hash {
+				continue
+			}
+			if err := os.Remove(file); err != nil {
+				return nil, fmt.Errorf("sync: %w", err)
+			}
+			report.Removed = append(report.Removed, path.Join(filepath.ToSlash(dir), rel))
+		}
+	}
+	sort.Strings(report.Added)
+	sort.Strings(report.Updated)
+	sort.Strings(report.Removed)
+	return report, nil
+}
+
+// isDhgMetadata reports whether a slash-separated path is under a .dhg
+// directory.
+func isDhgMetadata(rel string) bool {
+	return strings.HasPrefix(rel, ".dhg/") || strings.Contains(rel, "/.dhg/")
+}
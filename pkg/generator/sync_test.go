@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeGeneration writes a stamped chart generation to dir/app.
+func writeGeneration(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	chartDir := filepath.Join(dir, "app")
+	writeTree(t, chartDir, files)
+	if _, err := StampOwnership(chartDir, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveBaseSnapshot(chartDir); err != nil {
+		t.Fatal(err)
+	}
+	return chartDir
+}
+
+func TestSyncGeneration(t *testing.T) {
+	outputDir := t.TempDir()
+	chartDir := writeGeneration(t, outputDir, map[string]string{
+		"Chart.yaml":                    "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"values.yaml":                   "replicas: 1\n",
+		"templates/web-deployment.yaml": "kind: Deployment\n",
+		"templates/web-service.yaml":    "kind: Service\n",
+		"templates/web-ingress.yaml":    "kind: Ingress\n",
+	})
+	// A local edit keeps web-ingress.yaml when it is no longer generated.
+	ingress := filepath.Join(chartDir, "templates", "web-ingress.yaml")
+	if err := os.WriteFile(ingress, []byte(readFile(t, ingress)+"# edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chartYAMLInfo, err := os.Stat(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generatedDir := t.TempDir()
+	writeGeneration(t, generatedDir, map[string]string{
+		"Chart.yaml":                    "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"values.yaml":                   "replicas: 3\n",
+		"templates/web-deployment.yaml": "kind: Deployment\n",
+		"templates/web-hpa.yaml":        "kind: HorizontalPodAutoscaler\n",
+	})
+
+	report, err := SyncGeneration(outputDir, generatedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(report.Added, ","); got != "app/templates/web-hpa.yaml" {
+		t.Errorf("Added = %s", got)
+	}
+	if got := strings.Join(report.Updated, ","); got != "app/values.yaml" {
+		t.Errorf("Updated = %s; want only the changed values.yaml", got)
+	}
+	if got := strings.Join(report.Removed, ","); got != "app/templates/web-service.yaml" {
+		t.Errorf("Removed = %s; want the unedited web-service.yaml", got)
+	}
+	if _, err := os.Stat(ingress); err != nil {
+		t.Errorf("edited file should be kept: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(chartDir, "Chart.yaml")); err != nil || !info.ModTime().Equal(chartYAMLInfo.ModTime()) {
+		t.Error("unchanged Chart.yaml should not be rewritten")
+	}
+
+	if _, err := os.Stat(filepath.Join(chartDir, BaseSnapshotDir, "templates", "web-service.yaml")); !os.IsNotExist(err) {
+		t.Error("the base snapshot should be replaced by the new generation")
+	}
+	manifest, err := ReadGenerationManifest(chartDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := manifest.Files["templates/web-hpa.yaml"]; !ok {
+		t.Errorf("generation manifest not synced: %v", manifest.Files)
+	}
+
+	again, err := SyncGeneration(outputDir, generatedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.IsEmpty() {
+		t.Errorf("a second sync should change nothing: %+v", again)
+	}
+}
@@ -0,0 +1,309 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DefaultTenantIngressNamespace is the namespace of the Deckhouse ingress-nginx
+// controllers, allowed to reach tenants with ingress domains.
+const DefaultTenantIngressNamespace = "d8-ingress-nginx"
+
+// TenantManifest is a tenants.yaml file describing the tenants of a
+// multi-tenant chart:
+//
+//	ingressNamespace: d8-ingress-nginx
+//	tenants:
+//	  - name: acme
+//	    namespace: acme-prod
+//	    quota:
+//	      cpu: "4"
+//	      memory: 8Gi
+//	      pods: 50
+//	      storage: 100Gi
+//	    domains:
+//	      - acme.example.com
+//	    values:
+//	      services:
+//	        web:
+//	          deployment:
+//	            replicas: 3
+type TenantManifest struct {
+	// IngressNamespace is the namespace of the ingress controller allowed to
+	// reach tenants with domains (DefaultTenantIngressNamespace when empty).
+	IngressNamespace string `json:"ingressNamespace,omitempty"`
+
+	Tenants []Tenant `json:"tenants"`
+}
+
+// Tenant is one tenant of a TenantManifest.
+type Tenant struct {
+	Name string `json:"name"`
+
+	// Namespace is the tenant namespace (<chart>-<name> when empty).
+	Namespace string `json:"namespace,omitempty"`
+
+	Quota TenantQuota `json:"quota,omitempty"`
+
+	// Domains replace the ingress hosts of the chart, in order, in the
+	// tenant values file.
+	Domains []string `json:"domains,omitempty"`
+
+	// Values are merged into the tenant values file.
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// TenantQuota is the ResourceQuota of a tenant namespace. CPU and memory
+// default to 1 and 2Gi; pods and storage are only limited when set.
+type TenantQuota struct {
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Pods    int    `json:"pods,omitempty"`
+	Storage string `json:"storage,omitempty"`
+}
+
+// LoadTenantManifest reads and validates a tenants.yaml file.
+func LoadTenantManifest(file string) (*TenantManifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("tenants file: %w", err)
+	}
+	return ParseTenantManifest(data)
+}
+
+// ParseTenantManifest parses and validates tenants.yaml content.
+func ParseTenantManifest(data []byte) (*TenantManifest, error) {
+	var m TenantManifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("tenants file: %w", err)
+	}
+	if len(m.Tenants) == 0 {
+		return nil, fmt.Errorf("tenants file: no tenants")
+	}
+	if m.IngressNamespace != "" {
+		if errs := validation.IsDNS1123Label(m.IngressNamespace); len(errs) > 0 {
+			return nil, fmt.Errorf("tenants file: ingressNamespace %q: %s", m.IngressNamespace, strings.Join(errs, "; "))
+		}
+	}
+
+	names := make(map[string]bool, len(m.Tenants))
+	namespaces := make(map[string]bool, len(m.Tenants))
+	for i, t := range m.Tenants {
+		if errs := validation.IsDNS1123Label(t.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("tenants file: tenants[%d]: name %q: %s", i, t.Name, strings.Join(errs, "; "))
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("tenants file: duplicate tenant %q", t.Name)
+		}
+		names[t.Name] = true
+		if t.Namespace != "" {
+			if errs := validation.IsDNS1123Label(t.Namespace); len(errs) > 0 {
+				return nil, fmt.Errorf("tenants file: %s: namespace %q: %s", t.Name, t.Namespace, strings.Join(errs, "; "))
+			}
+			if namespaces[t.Namespace] {
+				return nil, fmt.Errorf("tenants file: %s: namespace %q is used by another tenant", t.Name, t.Namespace)
+			}
+			namespaces[t.Namespace] = true
+		}
+		for field, q := range map[string]string{"cpu": t.Quota.CPU, "memory": t.Quota.Memory, "storage": t.Quota.Storage} {
+			if q == "" {
+				continue
+			}
+			if _, err := resource.ParseQuantity(q); err != nil {
+				return nil, fmt.Errorf("tenants file: %s: quota.%s %q: %w", t.Name, field, q, err)
+			}
+		}
+		if t.Quota.Pods < 0 {
+			return nil, fmt.Errorf("tenants file: %s: quota.pods must not be negative", t.Name)
+		}
+		for _, d := range t.Domains {
+			if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(d, "*.")); len(errs) > 0 {
+				return nil, fmt.Errorf("tenants file: %s: domain %q: %s", t.Name, d, strings.Join(errs, "; "))
+			}
+		}
+	}
+	return &m, nil
+}
+
+// GenerateTenantOverlay adds the multi-tenant overlay of
+// GenerateMultiTenantOverlay for the tenants of a manifest: the tenants
+// values carry their namespaces, quotas and domains, the NetworkPolicies of
+// tenants with domains admit the ingress controller namespace, and a
+// values-tenant-<name>.yaml file per tenant maps the chart's ingress hosts to
+// the tenant domains and carries the tenant's values.
+func GenerateTenantOverlay(chart *types.GeneratedChart, manifest *TenantManifest) *types.GeneratedChart {
+	if manifest == nil || len(manifest.Tenants) == 0 {
+		return chart
+	}
+	ingressNamespace := manifest.IngressNamespace
+	if ingressNamespace == "" {
+		ingressNamespace = DefaultTenantIngressNamespace
+	}
+
+	var baseValues map[string]interface{}
+	if err := yaml.Unmarshal([]byte(chart.ValuesYAML), &baseValues); err != nil || baseValues == nil {
+		baseValues = make(map[string]interface{})
+	}
+
+	tenants := make([]interface{}, 0, len(manifest.Tenants))
+	files := make([]types.ExternalFileInfo, 0, len(manifest.Tenants))
+	for _, t := range manifest.Tenants {
+		entry := tenantEntry(chart.Name, t, ingressNamespace)
+		tenants = append(tenants, entry)
+
+		content, err := tenantValuesFile(chart.Name, baseValues, t, entry)
+		if err != nil {
+			return chart
+		}
+		files = append(files, types.ExternalFileInfo{Path: "values-tenant-" + t.Name + ".yaml", Content: content})
+	}
+
+	result := applyTenantOverlay(chart, baseValues, tenants)
+	if result == chart {
+		return chart
+	}
+	result.ExternalFiles = append(append([]types.ExternalFileInfo{}, chart.ExternalFiles...), files...)
+	return result
+}
+
+// tenantEntry returns the tenants values entry of t.
+func tenantEntry(chartName string, t Tenant, ingressNamespace string) map[string]interface{} {
+	namespace := t.Namespace
+	if namespace == "" {
+		namespace = chartName + "-" + t.Name
+	}
+	resources := map[string]interface{}{"cpu": "1", "memory": "2Gi"}
+	if t.Quota.CPU != "" {
+		resources["cpu"] = t.Quota.CPU
+	}
+	if t.Quota.Memory != "" {
+		resources["memory"] = t.Quota.Memory
+	}
+	if t.Quota.Pods > 0 {
+		resources["pods"] = t.Quota.Pods
+	}
+	if t.Quota.Storage != "" {
+		resources["storage"] = t.Quota.Storage
+	}
+
+	entry := map[string]interface{}{
+		"name":      t.Name,
+		"namespace": namespace,
+		"resources": resources,
+	}
+	if len(t.Domains) > 0 {
+		domains := make([]interface{}, len(t.Domains))
+		for i, d := range t.Domains {
+			domains[i] = d
+		}
+		entry["domains"] = domains
+		entry["ingressNamespace"] = ingressNamespace
+	}
+	return entry
+}
+
+// tenantValuesFile renders the values-tenant-<name>.yaml of t: the tenant's
+// own tenants entry, the ingresses with hosts mapped to its domains and the
+// manifest values on top.
+func tenantValuesFile(chartName string, baseValues map[string]interface{}, t Tenant, entry map[string]interface{}) (string, error) {
+	values := map[string]interface{}{"tenants": []interface{}{entry}}
+	if ingresses := tenantIngressValues(baseValues, t); len(ingresses) > 0 {
+		values["services"] = ingresses
+	}
+	if len(t.Values) > 0 {
+		values = MergeEnvProfiles(values, t.Values)
+	}
+
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Values of tenant %s\n", t.Name))
+	sb.WriteString(fmt.Sprintf("#   helm install %s . --namespace %s --create-namespace -f values-tenant-%s.yaml\n", t.Name, entry["namespace"], t.Name))
+	sb.Write(data)
+	return sb.String(), nil
+}
+
+// tenantIngressValues returns services.<svc>.ingress overrides with the
+// ingress hosts of baseValues replaced by the tenant domains: the n-th
+// distinct host (services in alphabetical order) becomes the n-th domain;
+// hosts beyond the domains are prefixed with the tenant name.
+func tenantIngressValues(baseValues map[string]interface{}, t Tenant) map[string]interface{} {
+	if len(t.Domains) == 0 {
+		return nil
+	}
+	services, _ := baseValues["services"].(map[string]interface{})
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hosts := make(map[string]string)
+	mapHost := func(host string) string {
+		if mapped, ok := hosts[host]; ok {
+			return mapped
+		}
+		mapped := t.Name + "." + host
+		if n := len(hosts); n < len(t.Domains) {
+			mapped = t.Domains[n]
+		}
+		hosts[host] = mapped
+		return mapped
+	}
+
+	overrides := make(map[string]interface{})
+	for _, name := range names {
+		svc, _ := services[name].(map[string]interface{})
+		ingress, _ := svc["ingress"].(map[string]interface{})
+		if ingress == nil {
+			continue
+		}
+		override := make(map[string]interface{})
+		for _, key := range []string{"rules", "hosts"} {
+			rules, ok := ingress[key].([]interface{})
+			if !ok {
+				continue
+			}
+			rules = runtime.DeepCopyJSONValue(rules).([]interface{})
+			for _, raw := range rules {
+				if rule, ok := raw.(map[string]interface{}); ok {
+					if host, ok := rule["host"].(string); ok && host != "" {
+						rule["host"] = mapHost(host)
+					}
+				}
+			}
+			override[key] = rules
+		}
+		if tls, ok := ingress["tls"].([]interface{}); ok {
+			tls = runtime.DeepCopyJSONValue(tls).([]interface{})
+			for _, raw := range tls {
+				entry, ok := raw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				tlsHosts, _ := entry["hosts"].([]interface{})
+				for i, h := range tlsHosts {
+					if host, ok := h.(string); ok {
+						tlsHosts[i] = mapHost(host)
+					}
+				}
+			}
+			override["tls"] = tls
+		}
+		if len(override) > 0 {
+			overrides[name] = map[string]interface{}{"ingress": override}
+		}
+	}
+	return overrides
+}
@@ -0,0 +1,200 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const testTenantManifest = `
+tenants:
+  - name: acme
+    namespace: acme-prod
+    quota:
+      cpu: "4"
+      memory: 8Gi
+      pods: 50
+      storage: 100Gi
+    domains:
+      - acme.example.com
+    values:
+      services:
+        web:
+          deployment:
+            replicas: 3
+  - name: globex
+`
+
+const testTenantChartValues = `
+services:
+  web:
+    ingress:
+      enabled: true
+      rules:
+        - host: web.example.com
+          paths:
+            - path: /
+        - host: api.example.com
+          paths:
+            - path: /api
+      tls:
+        - hosts:
+            - web.example.com
+            - api.example.com
+          secretName: web-tls
+`
+
+func makeTenantChart() *types.GeneratedChart {
+	chart := makeBaseChart("shop")
+	chart.ValuesYAML = testTenantChartValues
+	return chart
+}
+
+func TestParseTenantManifest(t *testing.T) {
+	m, err := ParseTenantManifest([]byte(testTenantManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Tenants) != 2 || m.Tenants[0].Quota.Pods != 50 || m.Tenants[0].Domains[0] != "acme.example.com" {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+}
+
+func TestParseTenantManifest_Invalid(t *testing.T) {
+	tests := []struct {
+		name, manifest, want string
+	}{
+		{"no tenants", "tenants: []", "no tenants"},
+		{"invalid name", "tenants: [{name: Acme}]", `name "Acme"`},
+		{"duplicate name", "tenants: [{name: acme}, {name: acme}]", "duplicate tenant"},
+		{"shared namespace", "tenants: [{name: a, namespace: ns}, {name: b, namespace: ns}]", "used by another tenant"},
+		{"invalid quota", "tenants: [{name: a, quota: {memory: lots}}]", "quota.memory"},
+		{"negative pods", "tenants: [{name: a, quota: {pods: -1}}]", "quota.pods"},
+		{"invalid domain", "tenants: [{name: a, domains: [not_a_domain]}]", "domain"},
+		{"unknown field", "tenants: [{name: a, quotas: {}}]", "unknown field"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTenantManifest([]byte(tt.manifest))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestLoadTenantManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	if err := os.WriteFile(path, []byte(testTenantManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTenantManifest(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTenantManifest(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestGenerateTenantOverlay_Values(t *testing.T) {
+	m, err := ParseTenantManifest([]byte(testTenantManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := GenerateTenantOverlay(makeTenantChart(), m)
+
+	var vals map[string]interface{}
+	if err := yaml.Unmarshal([]byte(result.ValuesYAML), &vals); err != nil {
+		t.Fatal(err)
+	}
+	tenants := vals["tenants"].([]interface{})
+	if len(tenants) != 2 {
+		t.Fatalf("got %d tenants; want 2", len(tenants))
+	}
+	acme := tenants[0].(map[string]interface{})
+	resources := acme["resources"].(map[string]interface{})
+	if acme["namespace"] != "acme-prod" || resources["cpu"] != "4" || resources["pods"] != float64(50) || resources["storage"] != "100Gi" {
+		t.Errorf("unexpected acme entry: %v", acme)
+	}
+	if acme["ingressNamespace"] != DefaultTenantIngressNamespace {
+		t.Errorf("ingressNamespace = %v; want %s", acme["ingressNamespace"], DefaultTenantIngressNamespace)
+	}
+	globex := tenants[1].(map[string]interface{})
+	if globex["namespace"] != "shop-globex" || globex["resources"].(map[string]interface{})["memory"] != "2Gi" {
+		t.Errorf("expected defaults for globex, got %v", globex)
+	}
+	if _, ok := globex["ingressNamespace"]; ok {
+		t.Error("a tenant without domains should not admit the ingress controller")
+	}
+
+	for _, path := range []string{"templates/tenant-namespaces.yaml", "templates/tenant-resourcequotas.yaml", "templates/tenant-networkpolicies.yaml"} {
+		if _, ok := result.Templates[path]; !ok {
+			t.Errorf("missing %s", path)
+		}
+	}
+	if !strings.Contains(result.Templates["templates/tenant-resourcequotas.yaml"], "requests.storage") {
+		t.Error("ResourceQuota template should limit storage when set")
+	}
+	if !strings.Contains(result.Templates["templates/tenant-networkpolicies.yaml"], "with .ingressNamespace") {
+		t.Error("NetworkPolicy template should admit the ingress controller namespace")
+	}
+}
+
+func TestGenerateTenantOverlay_TenantValuesFiles(t *testing.T) {
+	m, err := ParseTenantManifest([]byte(testTenantManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := GenerateTenantOverlay(makeTenantChart(), m)
+
+	files := make(map[string]string)
+	for _, f := range result.ExternalFiles {
+		files[f.Path] = f.Content
+	}
+	if len(files) != 2 {
+		t.Fatalf("got files %v; want values-tenant-acme.yaml and values-tenant-globex.yaml", files)
+	}
+
+	acme := files["values-tenant-acme.yaml"]
+	if !strings.Contains(acme, "helm install acme . --namespace acme-prod") {
+		t.Errorf("missing install hint:\n%s", acme)
+	}
+	var vals map[string]interface{}
+	if err := yaml.Unmarshal([]byte(acme), &vals); err != nil {
+		t.Fatal(err)
+	}
+	if tenants := vals["tenants"].([]interface{}); len(tenants) != 1 || tenants[0].(map[string]interface{})["name"] != "acme" {
+		t.Errorf("expected only the acme tenant, got %v", tenants)
+	}
+	web := vals["services"].(map[string]interface{})["web"].(map[string]interface{})
+	if web["deployment"].(map[string]interface{})["replicas"] != float64(3) {
+		t.Errorf("manifest values not merged: %v", web)
+	}
+	ingress := web["ingress"].(map[string]interface{})
+	rules := ingress["rules"].([]interface{})
+	if rules[0].(map[string]interface{})["host"] != "acme.example.com" || rules[1].(map[string]interface{})["host"] != "acme.api.example.com" {
+		t.Errorf("unexpected hosts: %v", rules)
+	}
+	tlsHosts := ingress["tls"].([]interface{})[0].(map[string]interface{})["hosts"].([]interface{})
+	if tlsHosts[0] != "acme.example.com" || tlsHosts[1] != "acme.api.example.com" {
+		t.Errorf("TLS hosts not mapped like the rules: %v", tlsHosts)
+	}
+
+	globex := files["values-tenant-globex.yaml"]
+	if strings.Contains(globex, "services:") {
+		t.Errorf("a tenant without domains or values should not override services:\n%s", globex)
+	}
+	if !strings.Contains(result.ValuesYAML, "host: web.example.com") {
+		t.Error("the base values must keep the source hosts")
+	}
+}
+
+func TestGenerateTenantOverlay_NilManifest(t *testing.T) {
+	chart := makeTenantChart()
+	if result := GenerateTenantOverlay(chart, nil); result != chart {
+		t.Error("expected the chart unchanged without a manifest")
+	}
+}
@@ -0,0 +1,257 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// envTemplateLine is how workload templates render container env vars.
+const envTemplateLine = "          env:\n            {{- toYaml . | nindent 12 }}"
+
+// envTplTemplateLine renders container env vars through tpl.
+const envTplTemplateLine = "          env:\n            {{- tpl (toYaml .) $ | nindent 12 }}"
+
+// configMapDataLine is how ConfigMap templates render inline data values.
+const configMapDataLine = "  {{- else }}\n  {{ $key }}: |\n    {{- $value | nindent 4 }}\n  {{- end }}\n  {{- end }}"
+
+// configMapTplDataLine renders inline ConfigMap data values through tpl.
+const configMapTplDataLine = "  {{- else }}\n  {{ $key }}: |\n    {{- tpl $value $ | nindent 4 }}\n  {{- end }}\n  {{- end }}"
+
+// TemplateServiceURLs rewrites the Service DNS names found in container env
+// vars (env_service_url relationships) and ConfigMap data
+// (config_service_url relationships) to references to services.<a>.env.<B>_URL,
+// where a is the service of the referencing resource and B the service the
+// name points at. The values default to the DNS name of B's Service in the
+// release, built from the chart's fullname helper, so that references
+// follow renamed releases and can be pointed elsewhere:
+//
+//	http://api.prod.svc.cluster.local:8080 → http://{{ tpl .Values.services.frontend.env.BACKEND_URL . }}:8080
+//	services.frontend.env.BACKEND_URL: {{ include "app.fullname" . }}-backend.{{ .Release.Namespace }}.svc
+//
+// With preserveNamespaces the namespace is rendered from .Values.namespaces.
+// The rewritten workloads render env, and the rewritten ConfigMaps their
+// data, through tpl; literal "{{" in the other values of those fields is
+// escaped.
+//
+// It returns the env values keyed by group name and copies of the groups
+// with values and templates rewritten; the input groups are not modified.
+func TemplateServiceURLs(groups []*types.ResourceGroup, graph *types.ResourceGraph, chartName string, preserveNamespaces bool) (map[string]map[string]interface{}, []*types.ResourceGroup) {
+	if graph == nil {
+		return nil, groups
+	}
+	bySource := make(map[types.ResourceKey][]types.Relationship)
+	for _, rel := range graph.Relationships {
+		if rel.Type == types.RelationEnvServiceURL || rel.Type == types.RelationConfigServiceURL {
+			bySource[rel.From] = append(bySource[rel.From], rel)
+		}
+	}
+	if len(bySource) == 0 {
+		return nil, groups
+	}
+
+	envValues := make(map[string]map[string]interface{})
+	out := make([]*types.ResourceGroup, 0, len(groups))
+	for _, group := range groups {
+		copied := *group
+		copied.Resources = make([]*types.ProcessedResource, 0, len(group.Resources))
+		for _, r := range group.Resources {
+			rels := bySource[r.Original.ResourceKey()]
+			var from, to string
+			switch {
+			case len(rels) == 0:
+			case strings.Contains(r.TemplateContent, envTemplateLine):
+				from, to = envTemplateLine, envTplTemplateLine
+			case strings.Contains(r.TemplateContent, configMapDataLine):
+				from, to = configMapDataLine, configMapTplDataLine
+			}
+			if from == "" {
+				copied.Resources = append(copied.Resources, r)
+				continue
+			}
+
+			// Rewrites keyed by container and env var name, or by data key.
+			rewrites := make(map[string][]hostRewrite)
+			for _, rel := range rels {
+				target, ok := graph.Resources[rel.To]
+				if !ok {
+					continue
+				}
+				offset, err := strconv.Atoi(rel.Details["offset"])
+				if err != nil {
+					continue
+				}
+				key := serviceURLKey(target.ServiceName)
+				if envValues[group.Name] == nil {
+					envValues[group.Name] = make(map[string]interface{})
+				}
+				envValues[group.Name][key] = serviceDNSName(chartName, target, preserveNamespaces)
+
+				host := rel.Details["host"]
+				field := rel.Details["key"]
+				if rel.Type == types.RelationEnvServiceURL {
+					field = rel.Details["container"] + "/" + rel.Details["env"]
+				}
+				rewrites[field] = append(rewrites[field], hostRewrite{
+					start:       offset,
+					end:         offset + len(host),
+					host:        host,
+					replacement: fmt.Sprintf("{{ tpl .Values.services.%s.env.%s . }}", group.Name, key),
+				})
+			}
+
+			rc := *r
+			if from == envTemplateLine {
+				rc.Values = templateEnvValues(r.Values, rewrites)
+			} else {
+				rc.Values = templateConfigMapValues(r.Values, rewrites)
+			}
+			rc.TemplateContent = strings.Replace(r.TemplateContent, from, to, 1)
+			copied.Resources = append(copied.Resources, &rc)
+		}
+		out = append(out, &copied)
+	}
+	return envValues, out
+}
+
+// serviceURLKey returns the env values key of a service: BACKEND_URL for
+// backend, API_GATEWAY_URL for apiGateway.
+func serviceURLKey(serviceName string) string {
+	var b strings.Builder
+	for i, c := range serviceName {
+		switch {
+		case c == '-' || c == '.':
+			b.WriteByte('_')
+		case c >= 'A' && c <= 'Z':
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+		default:
+			b.WriteString(strings.ToUpper(string(c)))
+		}
+	}
+	return b.String() + "_URL"
+}
+
+// serviceDNSName returns the DNS name of a Service in the release, as a
+// template.
+func serviceDNSName(chartName string, service *types.ProcessedResource, preserveNamespaces bool) string {
+	namespace := "{{ .Release.Namespace }}"
+	if ns := service.Original.Object.GetNamespace(); preserveNamespaces && ns != "" {
+		namespace = fmt.Sprintf("{{ .Values.namespaces.%s }}", processor.SanitizeValuesKey(ns))
+	}
+	return fmt.Sprintf(`{{ include "%s.fullname" . }}-%s.%s.svc`, chartName, service.ServiceName, namespace)
+}
+
+// hostRewrite replaces host, found at value[start:end], with replacement.
+type hostRewrite struct {
+	start, end  int
+	host        string
+	replacement string
+}
+
+// templateEnvValues returns a copy of workload values with the env values of
+// its containers rewritten for tpl.
+func templateEnvValues(values map[string]interface{}, rewrites map[string][]hostRewrite) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	var containers []map[string]interface{}
+	switch cs := values["containers"].(type) {
+	case []map[string]interface{}:
+		containers = cs
+	case []interface{}:
+		for _, c := range cs {
+			if m, ok := c.(map[string]interface{}); ok {
+				containers = append(containers, m)
+			}
+		}
+	}
+	copiedContainers := make([]map[string]interface{}, 0, len(containers))
+	for _, container := range containers {
+		cc := make(map[string]interface{}, len(container))
+		for k, v := range container {
+			cc[k] = v
+		}
+		name, _ := container["name"].(string)
+		if env, ok := container["env"].([]interface{}); ok {
+			copiedEnv := make([]interface{}, 0, len(env))
+			for _, e := range env {
+				envVar, ok := e.(map[string]interface{})
+				if !ok {
+					copiedEnv = append(copiedEnv, e)
+					continue
+				}
+				ev := make(map[string]interface{}, len(envVar))
+				for k, v := range envVar {
+					ev[k] = v
+				}
+				if value, ok := envVar["value"].(string); ok {
+					envName, _ := envVar["name"].(string)
+					ev["value"] = rewriteHosts(value, rewrites[name+"/"+envName])
+				}
+				copiedEnv = append(copiedEnv, ev)
+			}
+			cc["env"] = copiedEnv
+		}
+		copiedContainers = append(copiedContainers, cc)
+	}
+	copied["containers"] = copiedContainers
+	return copied
+}
+
+// templateConfigMapValues returns a copy of ConfigMap values with the inline
+// data values rewritten for tpl. Data kept in files/ is left alone.
+func templateConfigMapValues(values map[string]interface{}, rewrites map[string][]hostRewrite) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	data := make(map[string]interface{})
+	switch d := values["data"].(type) {
+	case map[string]string:
+		for k, v := range d {
+			data[k] = rewriteHosts(v, rewrites[k])
+		}
+	case map[string]interface{}:
+		for k, v := range d {
+			if s, ok := v.(string); ok {
+				v = rewriteHosts(s, rewrites[k])
+			}
+			data[k] = v
+		}
+	default:
+		return copied
+	}
+	copied["data"] = data
+	return copied
+}
+
+// rewriteHosts applies the host rewrites to a value and escapes the rest of
+// it for tpl. Rewrites that do not match the value are skipped.
+func rewriteHosts(value string, rewrites []hostRewrite) string {
+	sort.Slice(rewrites, func(i, j int) bool { return rewrites[i].start < rewrites[j].start })
+	var b strings.Builder
+	pos := 0
+	for _, rw := range rewrites {
+		if rw.start < pos || rw.end > len(value) || value[rw.start:rw.end] != rw.host {
+			continue
+		}
+		b.WriteString(escapeTpl(value[pos:rw.start]))
+		b.WriteString(rw.replacement)
+		pos = rw.end
+	}
+	b.WriteString(escapeTpl(value[pos:]))
+	return b.String()
+}
+
+// escapeTpl keeps "{{" in s literal when s is rendered by tpl.
+func escapeTpl(s string) string {
+	return strings.ReplaceAll(s, "{{", `{{ "{{" }}`)
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestTemplateServiceURLs(t *testing.T) {
+	env := []interface{}{
+		map[string]interface{}{"name": "API_URL", "value": "http://api.prod.svc.cluster.local:8080"},
+		map[string]interface{}{"name": "CACHE", "value": "cache:6379"},
+		map[string]interface{}{"name": "GREETING", "value": "{{ hello }}"},
+	}
+	frontend := makeProcessedResourceWithValues("Deployment", "frontend", "prod", nil, map[string]interface{}{
+		"containers": []map[string]interface{}{{"name": "app", "env": env}},
+	}, envTemplateLine+"\n")
+	frontend.ServiceName = "frontend"
+	proxy := makeProcessedResourceWithValues("ConfigMap", "proxy", "prod", nil, map[string]interface{}{
+		"data": map[string]interface{}{"nginx.conf": "proxy_pass http://api:8080;", "other": "{{ x }}"},
+	}, configMapDataLine+"\n")
+	proxy.ServiceName = "frontend"
+	api := makeProcessedResource("Service", "api", "prod", nil)
+	api.ServiceName = "backend"
+	cache := makeProcessedResource("Service", "cache", "prod", nil)
+	cache.ServiceName = "redisCache"
+
+	rel := func(from, to *types.ProcessedResource, relType types.RelationshipType, details map[string]string) types.Relationship {
+		return types.Relationship{From: resourceKey(from), To: resourceKey(to), Type: relType, Details: details}
+	}
+	graph := buildGraph([]*types.ProcessedResource{frontend, proxy, api, cache}, []types.Relationship{
+		rel(frontend, api, types.RelationEnvServiceURL, map[string]string{"container": "app", "env": "API_URL", "host": "api.prod.svc.cluster.local", "offset": "7"}),
+		rel(frontend, cache, types.RelationEnvServiceURL, map[string]string{"container": "app", "env": "CACHE", "host": "cache", "offset": "0"}),
+		rel(proxy, api, types.RelationConfigServiceURL, map[string]string{"key": "nginx.conf", "host": "api", "offset": "18"}),
+	})
+	groups := []*types.ResourceGroup{{Name: "frontend", Resources: []*types.ProcessedResource{frontend, proxy}}}
+
+	envValues, out := TemplateServiceURLs(groups, graph, "shop", false)
+	if got := envValues["frontend"]["BACKEND_URL"]; got != `{{ include "shop.fullname" . }}-backend.{{ .Release.Namespace }}.svc` {
+		t.Errorf("BACKEND_URL = %q", got)
+	}
+	if got := envValues["frontend"]["REDIS_CACHE_URL"]; got != `{{ include "shop.fullname" . }}-redisCache.{{ .Release.Namespace }}.svc` {
+		t.Errorf("REDIS_CACHE_URL = %q", got)
+	}
+
+	deploy := out[0].Resources[0]
+	if deploy.TemplateContent != envTplTemplateLine+"\n" {
+		t.Errorf("env is not rendered through tpl:\n%s", deploy.TemplateContent)
+	}
+	got := deploy.Values["containers"].([]map[string]interface{})[0]["env"].([]interface{})
+	want := []string{
+		`http://{{ tpl .Values.services.frontend.env.BACKEND_URL . }}:8080`,
+		`{{ tpl .Values.services.frontend.env.REDIS_CACHE_URL . }}:6379`,
+		`{{ "{{" }} hello }}`,
+	}
+	for i, w := range want {
+		if v := got[i].(map[string]interface{})["value"]; v != w {
+			t.Errorf("env[%d] = %q; want %q", i, v, w)
+		}
+	}
+	if env[0].(map[string]interface{})["value"] != "http://api.prod.svc.cluster.local:8080" {
+		t.Error("input values were modified")
+	}
+
+	cm := out[0].Resources[1]
+	if cm.TemplateContent != configMapTplDataLine+"\n" {
+		t.Errorf("ConfigMap data is not rendered through tpl:\n%s", cm.TemplateContent)
+	}
+	data := cm.Values["data"].(map[string]interface{})
+	if data["nginx.conf"] != "proxy_pass http://{{ tpl .Values.services.frontend.env.BACKEND_URL . }}:8080;" || data["other"] != `{{ "{{" }} x }}` {
+		t.Errorf("unexpected ConfigMap data: %v", data)
+	}
+
+	envValues, _ = TemplateServiceURLs(groups, graph, "shop", true)
+	if got := envValues["frontend"]["BACKEND_URL"]; got != `{{ include "shop.fullname" . }}-backend.{{ .Values.namespaces.prod }}.svc` {
+		t.Errorf("expected the preserved namespace, got %q", got)
+	}
+}
+
+func TestTemplateServiceURLs_NoRelationships(t *testing.T) {
+	r := makeProcessedResourceWithValues("Deployment", "frontend", "prod", nil, nil, envTemplateLine+"\n")
+	groups := []*types.ResourceGroup{{Name: "frontend", Resources: []*types.ProcessedResource{r}}}
+
+	envValues, out := TemplateServiceURLs(groups, buildGraph([]*types.ProcessedResource{r}, nil), "shop", false)
+	if envValues != nil || out[0] != groups[0] {
+		t.Error("expected groups to be returned unchanged")
+	}
+}
@@ -48,15 +48,24 @@ func (g *UmbrellaGenerator) Generate(ctx context.Context, graph *types.ResourceG
 	sep := &SeparateGenerator{}
 	parentValues := make(map[string]interface{})
 
-	for _, group := range groupResult.Groups {
+	// Lift settings shared by all subcharts into the parent's global values.
+	globalVals, groups := LiftGlobalValues(groupResult.Groups)
+
+	// Tag each subchart with the services that need it.
+	tags := DependencyTags(groupResult.Groups, graph)
+
+	// Only the NOTES.txt of the umbrella chart is shown on install, so it
+	// covers the resources of every subchart.
+	var notesCtx helm.NOTESContext
+	subcharts := make([]string, 0, len(groups))
+
+	for _, group := range groups {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 
 		// Generate subchart using SeparateGenerator logic.
-		subOpts := opts
-		subOpts.ChartName = group.Name
-		subchart, err := sep.generateChartForGroup(group, subOpts)
+		subchart, err := sep.generateChartForGroup(group, nil, opts)
 		if err != nil {
 			return nil, fmt.Errorf("generating subchart for %s: %w", group.Name, err)
 		}
@@ -72,22 +81,25 @@ func (g *UmbrellaGenerator) Generate(ctx context.Context, graph *types.ResourceG
 			Name:      group.Name,
 			Version:   opts.ChartVersion,
 			Condition: fmt.Sprintf("%s.enabled", group.Name),
+			Tags:      tags[group.Name],
 		})
 
 		// Collect flat values for parent values.yaml (with enabled flag).
-		flatVals := sep.buildFlatValues(group)
-		flatVals["enabled"] = true
-		parentValues[group.Name] = flatVals
-	}
+		parentValues[group.Name] = sep.buildFlatValues(group)
 
-	// Extract shared global values from all groups.
-	globalVals := ExtractGlobalValues(groupResult.Groups)
+		groupNotes := notesContext(group.Resources, umbrellaNotesNaming(group.Name))
+		notesCtx.Services = append(notesCtx.Services, groupNotes.Services...)
+		notesCtx.Ingresses = append(notesCtx.Ingresses, groupNotes.Ingresses...)
+		notesCtx.Secrets = append(notesCtx.Secrets, groupNotes.Secrets...)
+		subcharts = append(subcharts, group.Name)
+	}
 
 	// Generate parent chart.
 	parentChart, err := g.generateParentChart(parentName, deps, parentValues, globalVals, opts)
 	if err != nil {
 		return nil, fmt.Errorf("generating parent chart: %w", err)
 	}
+	parentChart.Notes = helm.GenerateNOTES(parentName, subcharts, notesCtx)
 
 	return append([]*types.GeneratedChart{parentChart}, charts...), nil
 }
@@ -112,13 +124,12 @@ func (g *UmbrellaGenerator) generateParentChart(
 	// Build parent values: global + per-subchart sections.
 	allValues := make(map[string]interface{})
 
-	if len(globalVals) > 0 {
-		allValues["global"] = globalVals
-	} else {
-		allValues["global"] = map[string]interface{}{
-			"imageRegistry": "",
-		}
+	global := make(map[string]interface{}, len(globalVals)+1)
+	global["imageRegistry"] = ""
+	for k, v := range globalVals {
+		global[k] = v
 	}
+	allValues["global"] = global
 
 	for name, vals := range subValues {
 		allValues[name] = vals
@@ -133,7 +144,7 @@ func (g *UmbrellaGenerator) generateParentChart(
 	return &types.GeneratedChart{
 		Name:       chartName,
 		Path:       opts.OutputDir,
-		ChartYAML:  helm.GenerateChartYAML(chartMeta),
+		ChartYAML:  helm.GenerateChartYAML(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata)),
 		ValuesYAML: valuesYAML,
 		Templates:  map[string]string{},
 		Helpers:    helm.GenerateHelpers(chartName),
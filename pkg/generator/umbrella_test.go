@@ -357,6 +357,28 @@ func TestUmbrellaGenerator_GlobalValues(t *testing.T) {
 	}
 }
 
+func TestUmbrellaGenerator_DependencyTags(t *testing.T) {
+	gen := NewUmbrellaGenerator()
+	charts, err := gen.Generate(context.Background(), threeTierGraph(), Options{ChartVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	parent := findParentChart(charts)
+	if parent == nil {
+		t.Fatal("parent chart not found")
+	}
+	want := "  - name: database\n    version: 1.0.0\n    repository: \n    condition: database.enabled\n    tags:\n      - backend\n      - frontend\n"
+	if !strings.Contains(parent.ChartYAML, want) {
+		t.Errorf("parent Chart.yaml missing tagged database dependency:\n%s", parent.ChartYAML)
+	}
+	for _, sub := range findSubcharts(charts) {
+		if !strings.Contains(sub.ValuesYAML, "enabled: true") {
+			t.Errorf("subchart %s values.yaml missing enabled flag:\n%s", sub.Name, sub.ValuesYAML)
+		}
+	}
+}
+
 // ============================================================
 // Subtask 8: Edge cases
 // ============================================================
@@ -0,0 +1,367 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// imageRepositoryExpr is how workload templates start rendering a container image.
+const imageRepositoryExpr = `"{{ .image.repository }}`
+
+var (
+	// envBlockPattern matches the container env block of workload templates.
+	envBlockPattern = regexp.MustCompile(`(?m)^( *)\{\{- with \.env \}\}\n *env:\n *\{\{- toYaml \. \| nindent (\d+) \}\}\n *\{\{- end \}\}\n`)
+
+	// labelsIncludePattern matches the common labels include of resource and pod metadata.
+	labelsIncludePattern = regexp.MustCompile(`(?m)^( *)(\{\{- include "[^"]+\.labels" \$ \| nindent (\d+) \}\})\n`)
+)
+
+// helperLabelKeys are set by the chart's labels helper; lifting them into
+// global.labels would render them twice.
+var helperLabelKeys = map[string]bool{
+	"helm.sh/chart":                true,
+	"app.kubernetes.io/name":       true,
+	"app.kubernetes.io/instance":   true,
+	"app.kubernetes.io/version":    true,
+	"app.kubernetes.io/managed-by": true,
+}
+
+// LiftGlobalValues moves configuration shared by all service groups into
+// umbrella global values: the image registry common to every container image,
+// plain env vars set on every container and pod labels set on every workload.
+// It returns the global values and copies of the groups with the lifted
+// settings stripped from their values and templates rewritten to read
+// .Values.global.* instead. The input groups are not modified.
+func LiftGlobalValues(groups []*ServiceGroup) (map[string]interface{}, []*ServiceGroup) {
+	global := make(map[string]interface{})
+	if len(groups) < 2 {
+		return global, groups
+	}
+
+	lifted := make([]*ServiceGroup, 0, len(groups))
+	for _, group := range groups {
+		copied := *group
+		copied.Resources = make([]*types.ProcessedResource, 0, len(group.Resources))
+		for _, r := range group.Resources {
+			rc := *r
+			if r.Values != nil {
+				rc.Values = cloneValue(r.Values).(map[string]interface{})
+			}
+			copied.Resources = append(copied.Resources, &rc)
+		}
+		lifted = append(lifted, &copied)
+	}
+
+	if registry := liftImageRegistry(lifted); registry != "" {
+		global["imageRegistry"] = registry
+	}
+	if env := liftContainerEnv(lifted); len(env) > 0 {
+		global["env"] = env
+	}
+	if labels := liftPodLabels(lifted); len(labels) > 0 {
+		global["labels"] = labels
+	}
+
+	return global, lifted
+}
+
+// liftImageRegistry strips the registry shared by every templated container
+// image and prefixes the image templates with global.imageRegistry.
+func liftImageRegistry(groups []*ServiceGroup) string {
+	var images []map[string]interface{}
+	var resources []*types.ProcessedResource
+	groupsWithImages := 0
+	for _, group := range groups {
+		found := false
+		for _, r := range group.Resources {
+			if !strings.Contains(r.TemplateContent, imageRepositoryExpr) {
+				continue
+			}
+			n := len(images)
+			images = collectImageValues(r.Values, images)
+			if len(images) > n {
+				found = true
+				resources = append(resources, r)
+			}
+		}
+		if found {
+			groupsWithImages++
+		}
+	}
+	if groupsWithImages < 2 {
+		return ""
+	}
+
+	registry := ""
+	for _, image := range images {
+		reg := imageRepositoryRegistry(image["repository"].(string))
+		if reg == "" || (registry != "" && reg != registry) {
+			return ""
+		}
+		registry = reg
+	}
+
+	for _, image := range images {
+		image["repository"] = strings.TrimPrefix(image["repository"].(string), registry+"/")
+	}
+	for _, r := range resources {
+		r.TemplateContent = strings.ReplaceAll(r.TemplateContent, imageRepositoryExpr,
+			`"{{ with $.Values.global.imageRegistry }}{{ . }}/{{ end }}{{ .image.repository }}`)
+	}
+	return registry
+}
+
+// collectImageValues appends every image map with a string repository found in v.
+func collectImageValues(v interface{}, images []map[string]interface{}) []map[string]interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if image, ok := t["image"].(map[string]interface{}); ok {
+			if _, ok := image["repository"].(string); ok {
+				images = append(images, image)
+			}
+		}
+		for k, child := range t {
+			if k == "image" {
+				continue
+			}
+			images = collectImageValues(child, images)
+		}
+	case []interface{}:
+		for _, child := range t {
+			images = collectImageValues(child, images)
+		}
+	}
+	return images
+}
+
+// imageRepositoryRegistry returns the registry host of an image repository,
+// or "" when the repository has none (Docker Hub shorthand).
+func imageRepositoryRegistry(repository string) string {
+	i := strings.Index(repository, "/")
+	if i == -1 {
+		return ""
+	}
+	host := repository[:i]
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return ""
+}
+
+// liftContainerEnv strips plain env vars set with the same value on every
+// container of every workload whose template renders env, and makes those
+// templates render global.env ahead of the container's own variables.
+func liftContainerEnv(groups []*ServiceGroup) map[string]interface{} {
+	var containers []map[string]interface{}
+	var resources []*types.ProcessedResource
+	groupsWithEnv := 0
+	for _, group := range groups {
+		found := false
+		for _, r := range group.Resources {
+			if !envBlockPattern.MatchString(r.TemplateContent) {
+				continue
+			}
+			list, _ := r.Values["containers"].([]interface{})
+			n := len(containers)
+			for _, c := range list {
+				if container, ok := c.(map[string]interface{}); ok {
+					containers = append(containers, container)
+				}
+			}
+			if len(containers) > n {
+				found = true
+				resources = append(resources, r)
+			}
+		}
+		if found {
+			groupsWithEnv++
+		}
+	}
+	if groupsWithEnv < 2 {
+		return nil
+	}
+
+	var common map[string]string
+	for _, container := range containers {
+		vars := plainEnvVars(container)
+		if common == nil {
+			common = vars
+			continue
+		}
+		for name, value := range common {
+			if vars[name] != value {
+				delete(common, name)
+			}
+		}
+	}
+	if len(common) == 0 {
+		return nil
+	}
+
+	for _, container := range containers {
+		env, _ := container["env"].([]interface{})
+		kept := make([]interface{}, 0, len(env))
+		for _, e := range env {
+			if entry, ok := e.(map[string]interface{}); ok {
+				if name, ok := entry["name"].(string); ok {
+					if _, lifted := common[name]; lifted {
+						continue
+					}
+				}
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) == 0 {
+			delete(container, "env")
+		} else {
+			container["env"] = kept
+		}
+	}
+	for _, r := range resources {
+		r.TemplateContent = envBlockPattern.ReplaceAllStringFunc(r.TemplateContent, globalEnvBlock)
+	}
+
+	result := make(map[string]interface{}, len(common))
+	for name, value := range common {
+		result[name] = value
+	}
+	return result
+}
+
+// plainEnvVars returns the container env vars that have a literal value.
+func plainEnvVars(container map[string]interface{}) map[string]string {
+	vars := make(map[string]string)
+	env, _ := container["env"].([]interface{})
+	for _, e := range env {
+		entry, ok := e.(map[string]interface{})
+		if !ok || entry["valueFrom"] != nil {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		value, ok := entry["value"].(string)
+		if name != "" && ok {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// globalEnvBlock rewrites a matched container env block to render global.env
+// before the container's own env vars.
+func globalEnvBlock(block string) string {
+	m := envBlockPattern.FindStringSubmatch(block)
+	indent, nindent := m[1], m[2]
+	var sb strings.Builder
+	sb.WriteString(indent + "{{- if or .env $.Values.global.env }}\n")
+	sb.WriteString(indent + "env:\n")
+	sb.WriteString(indent + "  {{- range $name, $value := $.Values.global.env }}\n")
+	sb.WriteString(indent + "  - name: {{ $name }}\n")
+	sb.WriteString(indent + "    value: {{ $value | quote }}\n")
+	sb.WriteString(indent + "  {{- end }}\n")
+	sb.WriteString(indent + "  {{- with .env }}\n")
+	sb.WriteString(fmt.Sprintf("%s  {{- toYaml . | nindent %s }}\n", indent, nindent))
+	sb.WriteString(indent + "  {{- end }}\n")
+	sb.WriteString(indent + "{{- end }}\n")
+	return sb.String()
+}
+
+// liftPodLabels strips pod labels set with the same value on every workload
+// and renders global.labels next to the common labels of every resource.
+func liftPodLabels(groups []*ServiceGroup) map[string]interface{} {
+	var workloads []*types.ProcessedResource
+	groupsWithWorkloads := 0
+	for _, group := range groups {
+		found := false
+		for _, r := range group.Resources {
+			if _, ok := r.Values["containers"]; !ok {
+				continue
+			}
+			if _, ok := r.Values["podLabels"].(map[string]interface{}); !ok {
+				return nil
+			}
+			workloads = append(workloads, r)
+			found = true
+		}
+		if found {
+			groupsWithWorkloads++
+		}
+	}
+	if groupsWithWorkloads < 2 {
+		return nil
+	}
+
+	common := make(map[string]interface{})
+	for key, value := range workloads[0].Values["podLabels"].(map[string]interface{}) {
+		if helperLabelKeys[key] {
+			continue
+		}
+		shared := true
+		for _, r := range workloads[1:] {
+			if r.Values["podLabels"].(map[string]interface{})[key] != value {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			common[key] = value
+		}
+	}
+	if len(common) == 0 {
+		return nil
+	}
+
+	for _, r := range workloads {
+		labels := r.Values["podLabels"].(map[string]interface{})
+		for key := range common {
+			delete(labels, key)
+		}
+		if len(labels) == 0 {
+			delete(r.Values, "podLabels")
+		}
+	}
+	for _, group := range groups {
+		for _, r := range group.Resources {
+			r.TemplateContent = labelsIncludePattern.ReplaceAllString(r.TemplateContent,
+				"$1$2\n$1{{- with $$.Values.global.labels }}\n$1{{- toYaml . | nindent $3 }}\n$1{{- end }}\n")
+		}
+	}
+	return common
+}
+
+// cloneValue deep-copies a values tree so lifting can strip settings without
+// touching the processed resources shared with other generators. Typed maps
+// and slices are copied as their generic form, the shape they have once
+// loaded from YAML.
+func cloneValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			out[k] = cloneValue(child)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			out[k] = child
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, child := range t {
+			out[i] = cloneValue(child)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]interface{}, len(t))
+		for i, child := range t {
+			out[i] = cloneValue(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+const liftTestTemplate = `metadata:
+  labels:
+    {{- include "app.labels" $ | nindent 4 }}
+spec:
+  containers:
+    {{- range .containers }}
+    - name: {{ .name }}
+      image: "{{ .image.repository }}:{{ .image.tag }}"
+      {{- with .env }}
+      env:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+    {{- end }}
+`
+
+func liftTestWorkload(name, repository string, env []interface{}, podLabels map[string]string) *types.ProcessedResource {
+	values := map[string]interface{}{
+		"containers": []map[string]interface{}{{
+			"name":  name,
+			"image": map[string]interface{}{"repository": repository, "tag": "1.0"},
+			"env":   env,
+		}},
+		"podLabels": podLabels,
+	}
+	return makeProcessedResourceWithValues("Deployment", name, "default",
+		map[string]string{"app.kubernetes.io/name": name}, values, liftTestTemplate)
+}
+
+func liftTestGroups() []*ServiceGroup {
+	return []*ServiceGroup{
+		{Name: "frontend", Resources: []*types.ProcessedResource{
+			liftTestWorkload("frontend", "registry.example.com/shop/frontend",
+				[]interface{}{
+					map[string]interface{}{"name": "ENVIRONMENT", "value": "prod"},
+					map[string]interface{}{"name": "PORT", "value": "8080"},
+				},
+				map[string]string{"app.kubernetes.io/name": "frontend", "team": "shop"}),
+		}},
+		{Name: "backend", Resources: []*types.ProcessedResource{
+			liftTestWorkload("backend", "registry.example.com/shop/backend",
+				[]interface{}{
+					map[string]interface{}{"name": "ENVIRONMENT", "value": "prod"},
+				},
+				map[string]string{"app.kubernetes.io/name": "backend", "team": "shop"}),
+		}},
+	}
+}
+
+func liftedContainer(group *ServiceGroup) map[string]interface{} {
+	return group.Resources[0].Values["containers"].([]interface{})[0].(map[string]interface{})
+}
+
+func TestLiftGlobalValues_ImageRegistry(t *testing.T) {
+	global, groups := LiftGlobalValues(liftTestGroups())
+
+	if global["imageRegistry"] != "registry.example.com" {
+		t.Fatalf("expected imageRegistry registry.example.com, got %v", global["imageRegistry"])
+	}
+	image := liftedContainer(groups[0])["image"].(map[string]interface{})
+	if image["repository"] != "shop/frontend" {
+		t.Errorf("expected registry stripped from repository, got %v", image["repository"])
+	}
+	if !strings.Contains(groups[0].Resources[0].TemplateContent, "{{ with $.Values.global.imageRegistry }}{{ . }}/{{ end }}{{ .image.repository }}") {
+		t.Errorf("image template does not read global.imageRegistry:\n%s", groups[0].Resources[0].TemplateContent)
+	}
+}
+
+func TestLiftGlobalValues_DifferentRegistries(t *testing.T) {
+	groups := liftTestGroups()
+	groups[1].Resources[0] = liftTestWorkload("backend", "ghcr.io/shop/backend", nil,
+		map[string]string{"app.kubernetes.io/name": "backend"})
+
+	global, lifted := LiftGlobalValues(groups)
+
+	if _, ok := global["imageRegistry"]; ok {
+		t.Errorf("expected no imageRegistry for different registries, got %v", global["imageRegistry"])
+	}
+	if strings.Contains(lifted[0].Resources[0].TemplateContent, "global.imageRegistry") {
+		t.Error("image template should not be rewritten when no registry is lifted")
+	}
+}
+
+func TestLiftGlobalValues_Env(t *testing.T) {
+	global, groups := LiftGlobalValues(liftTestGroups())
+
+	env, ok := global["env"].(map[string]interface{})
+	if !ok || env["ENVIRONMENT"] != "prod" || len(env) != 1 {
+		t.Fatalf("expected global env {ENVIRONMENT: prod}, got %v", global["env"])
+	}
+	feEnv := liftedContainer(groups[0])["env"].([]interface{})
+	if len(feEnv) != 1 || feEnv[0].(map[string]interface{})["name"] != "PORT" {
+		t.Errorf("expected only PORT left in frontend env, got %v", feEnv)
+	}
+	if _, ok := liftedContainer(groups[1])["env"]; ok {
+		t.Error("expected empty backend env to be removed")
+	}
+	tmpl := groups[1].Resources[0].TemplateContent
+	if !strings.Contains(tmpl, "{{- if or .env $.Values.global.env }}") ||
+		!strings.Contains(tmpl, "{{- range $name, $value := $.Values.global.env }}") {
+		t.Errorf("env template does not render global.env:\n%s", tmpl)
+	}
+}
+
+func TestLiftGlobalValues_Labels(t *testing.T) {
+	global, groups := LiftGlobalValues(liftTestGroups())
+
+	labels, ok := global["labels"].(map[string]interface{})
+	if !ok || labels["team"] != "shop" {
+		t.Fatalf("expected global labels {team: shop}, got %v", global["labels"])
+	}
+	if _, ok := labels["app.kubernetes.io/name"]; ok {
+		t.Error("labels set by the labels helper must not be lifted")
+	}
+	podLabels := groups[0].Resources[0].Values["podLabels"].(map[string]interface{})
+	if _, ok := podLabels["team"]; ok {
+		t.Error("expected lifted label stripped from podLabels")
+	}
+	if !strings.Contains(groups[0].Resources[0].TemplateContent, "    {{- with $.Values.global.labels }}\n    {{- toYaml . | nindent 4 }}\n") {
+		t.Errorf("labels template does not render global.labels:\n%s", groups[0].Resources[0].TemplateContent)
+	}
+}
+
+func TestLiftGlobalValues_DoesNotModifyInput(t *testing.T) {
+	groups := liftTestGroups()
+	LiftGlobalValues(groups)
+
+	container := groups[0].Resources[0].Values["containers"].([]map[string]interface{})[0]
+	if container["image"].(map[string]interface{})["repository"] != "registry.example.com/shop/frontend" {
+		t.Error("input image repository was modified")
+	}
+	if len(container["env"].([]interface{})) != 2 {
+		t.Error("input env was modified")
+	}
+	if groups[0].Resources[0].TemplateContent != liftTestTemplate {
+		t.Error("input template was modified")
+	}
+}
+
+func TestLiftGlobalValues_SingleGroup(t *testing.T) {
+	groups := liftTestGroups()[:1]
+	global, lifted := LiftGlobalValues(groups)
+
+	if len(global) != 0 {
+		t.Errorf("expected no global values for a single group, got %v", global)
+	}
+	if lifted[0] != groups[0] {
+		t.Error("expected single group to be returned unchanged")
+	}
+}
+
+func TestUmbrellaGenerator_LiftsGlobalValues(t *testing.T) {
+	var resources []*types.ProcessedResource
+	for _, g := range liftTestGroups() {
+		resources = append(resources, g.Resources...)
+	}
+	graph := buildGraph(resources, nil)
+
+	charts, err := NewUmbrellaGenerator().Generate(context.Background(), graph, Options{ChartName: "shop", ChartVersion: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	parent := findParentChart(charts)
+	if parent == nil {
+		t.Fatal("parent chart not found")
+	}
+	for _, want := range []string{"imageRegistry: registry.example.com", "ENVIRONMENT: prod", "team: shop"} {
+		if !strings.Contains(parent.ValuesYAML, want) {
+			t.Errorf("parent values.yaml missing %q:\n%s", want, parent.ValuesYAML)
+		}
+	}
+	for _, sub := range findSubcharts(charts) {
+		if strings.Contains(sub.ValuesYAML, "registry.example.com") || strings.Contains(sub.ValuesYAML, "ENVIRONMENT") {
+			t.Errorf("subchart %s still duplicates lifted values:\n%s", sub.Name, sub.ValuesYAML)
+		}
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"strings"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
@@ -46,36 +46,99 @@ func (g *UniversalGenerator) Generate(ctx context.Context, graph *types.Resource
 	valuesBuilder.SetGlobal("imageRegistry", "")
 	valuesBuilder.SetGlobal("imagePullSecrets", []interface{}{})
 
+	// Prometheus Operator monitors are gated by monitoring.enabled.
+	if hasMonitoringResources(graph) {
+		valuesBuilder.SetValue("monitoring.enabled", true)
+	}
+
+	// helm-docs descriptions keyed by values path (--values-docs)
+	var docs map[string]string
+	if opts.ValuesDocs {
+		docs = make(map[string]string)
+	}
+
+	// Factor repeated resources/probes/securityContext blocks into common:.
+	groups := graph.Groups
+	var commonValues map[string]interface{}
+	if opts.DedupValues {
+		commonValues, groups = DeduplicateValues(graph.Groups, opts.ChartName)
+		if len(commonValues) > 0 {
+			valuesBuilder.SetValue("common", commonValues)
+		}
+	}
+
+	// Keep resources in their source namespaces.
+	var namespacesTemplate string
+	if opts.PreserveNamespaces {
+		var nsValues map[string]interface{}
+		nsValues, namespacesTemplate, groups = PreserveNamespaces(groups, opts.ChartName)
+		for key, value := range nsValues {
+			valuesBuilder.SetValue(key, value)
+		}
+	}
+
+	// Point Service DNS names in env vars and ConfigMaps at the release's
+	// names, through services.<name>.env values.
+	var serviceEnv map[string]map[string]interface{}
+	if opts.TemplateEnv {
+		serviceEnv, groups = TemplateServiceURLs(groups, graph, opts.ChartName, opts.PreserveNamespaces)
+	}
+
+	// Add the organization-wide labels and annotations.
+	if opts.Injection != nil {
+		valuesBuilder.MergeValues(opts.Injection.Values())
+		groups = InjectMetadata(groups, opts.Injection, opts.ChartName)
+	}
+
 	// Process each service group
-	serviceNames := make([]string, 0, len(graph.Groups))
-	for _, group := range graph.Groups {
+	serviceNames := make([]string, 0, len(groups))
+	for _, group := range groups {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 
 		serviceNames = append(serviceNames, group.Name)
 		serviceConfig := g.buildServiceConfig(group)
+		if env := serviceEnv[group.Name]; len(env) > 0 {
+			serviceConfig["env"] = env
+		}
 		valuesBuilder.AddService(group.Name, serviceConfig)
+
+		if docs != nil {
+			prefix := "services." + group.Name
+			docs[prefix+".enabled"] = fmt.Sprintf("Enable the %s service", group.Name)
+			describeResourceValues(prefix, serviceConfig, group.Resources, docs)
+			for key := range serviceEnv[group.Name] {
+				docs[prefix+".env."+key] = "DNS name of a service of the chart, rendered with tpl"
+			}
+		}
 	}
 
 	// Sort service names for consistent output
 	sort.Strings(serviceNames)
 
+	if len(opts.ValueOverrides) > 0 {
+		valuesBuilder.MergeValues(opts.ValueOverrides)
+	}
+
 	// TODO: apply template style variants (standard vs helm-specific functions)
 	// based on opts.TemplateStyle
 
 	// Build templates map
 	templates := make(map[string]string)
-	for _, group := range graph.Groups {
+	for _, group := range groups {
 		for _, resource := range group.Resources {
 			if resource.TemplatePath != "" && resource.TemplateContent != "" {
 				templates[resource.TemplatePath] = resource.TemplateContent
 			}
 		}
 	}
+	if namespacesTemplate != "" {
+		templates[NamespacesTemplatePath] = namespacesTemplate
+	}
 
 	// Generate Chart.yaml
-	chartYAML := helm.GenerateChartYAML(chartMeta)
+	chartYAML := helm.GenerateChartYAML(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata))
 
 	// Generate values.yaml
 	var valuesYAML string
@@ -88,9 +151,18 @@ func (g *UniversalGenerator) Generate(ctx context.Context, graph *types.Resource
 	if err != nil {
 		return nil, fmt.Errorf("failed to build values.yaml: %w", err)
 	}
+	if docs != nil {
+		for path, doc := range globalValuesDocs {
+			docs[path] = doc
+		}
+		valuesYAML = helm.AddValuesDocs(valuesYAML, docs)
+	}
 
 	// Generate _helpers.tpl
 	helpers := helm.GenerateHelpers(opts.ChartName)
+	if len(commonValues) > 0 {
+		helpers = helpers + "\n" + MergeValuesHelper(opts.ChartName)
+	}
 
 	// Collect external files from ExternalFileManager
 	externalFiles := make([]types.ExternalFileInfo, 0)
@@ -111,7 +183,11 @@ func (g *UniversalGenerator) Generate(ctx context.Context, graph *types.Resource
 	}
 
 	// Generate NOTES.txt
-	notes := helm.GenerateNOTES(opts.ChartName, serviceNames, helm.NOTESContext{})
+	var resources []*types.ProcessedResource
+	for _, group := range groups {
+		resources = append(resources, group.Resources...)
+	}
+	notes := helm.GenerateNOTES(opts.ChartName, serviceNames, notesContext(resources, universalNotesNaming(opts.ChartName, opts.PreserveNamespaces)))
 
 	// Generate values.schema.json if requested
 	var valuesSchema string
@@ -131,6 +207,13 @@ func (g *UniversalGenerator) Generate(ctx context.Context, graph *types.Resource
 		ExternalFiles: externalFiles,
 	}
 
+	if docs != nil && opts.IncludeREADME {
+		chart.ExternalFiles = append(chart.ExternalFiles, types.ExternalFileInfo{
+			Path:    "README.md",
+			Content: helm.GenerateValuesREADME(helm.MergeChartMetadata(chartMeta, opts.ChartMetadata), serviceNames, valuesBuilder.BuildMap(), docs),
+		})
+	}
+
 	// Generate helm-unittest test files if requested
 	if opts.IncludeTests {
 		testFiles := GenerateHelmTests(chart)
@@ -159,38 +242,39 @@ func (g *UniversalGenerator) buildServiceConfig(group *types.ResourceGroup) map[
 	// Organize resources by kind
 	resourcesByKind := make(map[string][]*types.ProcessedResource)
 	for _, resource := range group.Resources {
-		kind := resource.Original.GVK.Kind
+		kind := processor.ValuesKind(resource.Original.GVK, resource.Original.Object)
 		resourcesByKind[kind] = append(resourcesByKind[kind], resource)
 	}
 
 	// Merge values from all resources
 	for kind, resources := range resourcesByKind {
-		// Always use nested structure for ConfigMaps and Secrets
-		if kind == "ConfigMap" || kind == "Secret" {
+		// Always use nested structure for ConfigMaps, Secrets, PersistentVolumes
+		// and StorageClasses
+		if processor.ValuesByName(kind) {
 			kindMap := make(map[string]interface{})
 			for _, resource := range resources {
-				resourceName := sanitizeName(resource.Original.Object.GetName())
+				resourceName := processor.SanitizeValuesKey(resource.Original.Object.GetName())
 				kindMap[resourceName] = resource.Values
 			}
-			kindKey := pluralizeKind(kind)
+			kindKey := processor.PluralValuesKey(kind)
 			config[kindKey] = kindMap
 		} else if len(resources) == 1 {
 			// Single resource: nest under kind key to match template references
 			// (e.g., $svc.deployment, $svc.service, $svc.statefulSet)
 			resource := resources[0]
-			kindKey := kindToValuesKey(kind)
+			kindKey := processor.ValuesKey(kind)
 			config[kindKey] = resource.Values
 		} else {
 			// Multiple resources of the same kind
 			// Create a map with resource names as keys
 			kindMap := make(map[string]interface{})
 			for _, resource := range resources {
-				resourceName := resource.Original.Object.GetName()
+				resourceName := processor.SanitizeValuesKey(resource.Original.Object.GetName())
 				kindMap[resourceName] = resource.Values
 			}
 
 			// Use a pluralized kind name as the key
-			kindKey := pluralizeKind(kind)
+			kindKey := processor.PluralValuesKey(kind)
 			config[kindKey] = kindMap
 		}
 	}
@@ -199,92 +283,15 @@ func (g *UniversalGenerator) buildServiceConfig(group *types.ResourceGroup) map[
 }
 
 
-// kindToValuesKey converts a GVK Kind name to the values.yaml key used by templates.
-// Templates reference values as $svc.deployment, $svc.service, $svc.statefulSet, etc.
-func kindToValuesKey(kind string) string {
-	switch kind {
-	case "PersistentVolumeClaim":
-		return "pvc"
-	default:
-		if len(kind) == 0 {
-			return "resource"
-		}
-		// lowerCamelCase: Deployment → deployment, StatefulSet → statefulSet
-		return strings.ToLower(kind[:1]) + kind[1:]
-	}
-}
-
-// sanitizeName converts a Kubernetes resource name to a valid Go/YAML key (camelCase).
-// Similar to processor.SanitizeServiceName but also handles _ as separator and
-// always lowercases the first character.
-func sanitizeName(name string) string {
-	result := make([]byte, 0, len(name))
-	for _, c := range name {
-		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
-			result = append(result, byte(c))
-		} else if c == '-' || c == '_' || c == '.' {
-			// Always use '_' as a camelCase marker for the second pass.
-			result = append(result, '_')
-		}
-	}
-	// Handle camelCase conversion
-	final := make([]byte, 0, len(result))
-	capitalize := false
-	for i, c := range result {
-		if c == '_' {
-			capitalize = true
-			continue
-		}
-		if capitalize && c >= 'a' && c <= 'z' {
-			final = append(final, c - 32)
-			capitalize = false
-		} else if i == 0 && c >= 'A' && c <= 'Z' {
-			// Lowercase first character
-			final = append(final, c + 32)
-		} else {
-			final = append(final, c)
+// hasMonitoringResources reports whether the graph contains ServiceMonitors or
+// PodMonitors.
+func hasMonitoringResources(graph *types.ResourceGraph) bool {
+	for key := range graph.Resources {
+		if key.GVK.Group == "monitoring.coreos.com" && (key.GVK.Kind == "ServiceMonitor" || key.GVK.Kind == "PodMonitor") {
+			return true
 		}
 	}
-	if len(final) == 0 {
-		return "config"
-	}
-	return string(final)
-}
-
-// pluralizeKind returns a pluralized version of the kind name.
-func pluralizeKind(kind string) string {
-	// Simple pluralization rules
-	switch kind {
-	case "Ingress":
-		return "ingresses"
-	case "Service":
-		return "services"
-	case "ConfigMap":
-		return "configMaps"
-	case "Secret":
-		return "secrets"
-	case "ServiceAccount":
-		return "serviceAccounts"
-	case "Deployment":
-		return "deployments"
-	case "StatefulSet":
-		return "statefulSets"
-	case "DaemonSet":
-		return "daemonSets"
-	case "PersistentVolumeClaim":
-		return "persistentVolumeClaims"
-	case "Role":
-		return "roles"
-	case "RoleBinding":
-		return "roleBindings"
-	case "ClusterRole":
-		return "clusterRoles"
-	case "ClusterRoleBinding":
-		return "clusterRoleBindings"
-	default:
-		// Default: add 's'
-		return kind + "s"
-	}
+	return false
 }
 
 // GetServiceNames extracts service names from a resource graph.
@@ -0,0 +1,403 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BaseSnapshotDir holds the last generated version of a chart, relative to the
+// chart directory. upgrade-chart uses it as the common ancestor when merging
+// a new generation into a hand-edited chart.
+const BaseSnapshotDir = ".dhg/base"
+
+// RejectSuffix is appended to a chart file to name the file holding generated
+// changes that conflict with local edits.
+const RejectSuffix = ".rej"
+
+// UpgradeReport summarizes an in-place chart upgrade. Paths are relative to
+// the chart directory.
+type UpgradeReport struct {
+	// Added are new generated files.
+	Added []string
+
+	// Updated are files that received generated changes.
+	Updated []string
+
+	// Removed are files no longer generated and not edited locally.
+	Removed []string
+
+	// Conflicts are files whose generated changes overlap local edits; the
+	// rejected changes are written next to them with RejectSuffix.
+	Conflicts []string
+
+	// Kept are files no longer generated but edited locally; they are left in place.
+	Kept []string
+}
+
+// HasConflicts reports whether any generated change was rejected.
+func (r *UpgradeReport) HasConflicts() bool {
+	return len(r.Conflicts) > 0
+}
+
+// Hunk is a block of lines replaced by a newer generation.
+type Hunk struct {
+	// Line is the 1-based line in the current file where the hunk applies.
+	Line int
+
+	// Old are the lines being replaced.
+	Old []string
+
+	// New are the replacement lines.
+	New []string
+}
+
+// SaveBaseSnapshot records the chart in chartDir as the base for the next
+// upgrade, replacing any previous snapshot.
+func SaveBaseSnapshot(chartDir string) error {
+	files, err := readChartFiles(chartDir)
+	if err != nil {
+		return fmt.Errorf("base snapshot: %w", err)
+	}
+	return writeBaseSnapshot(chartDir, files)
+}
+
+// UpgradeChart merges a freshly generated chart in generatedDir into the
+// existing chart in chartDir. Each file is merged three ways against the base
+// snapshot: generated changes are applied where the file was not edited, and
+// overlapping hunks are kept as edited and written to a .rej file. Without a
+// base snapshot, any file that differs from the new generation is a conflict.
+// The new generation becomes the base for the next upgrade, and its
+// generation manifest and record, if any, replace the chart's.
+func UpgradeChart(chartDir, generatedDir string) (*UpgradeReport, error) {
+	generated, err := readChartFiles(generatedDir)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	base, err := readChartFiles(filepath.Join(chartDir, BaseSnapshotDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+
+	report := &UpgradeReport{}
+
+	for _, rel := range sortedKeys(generated) {
+		gen := generated[rel]
+		prev, hasBase := base[rel]
+		path := filepath.Join(chartDir, rel)
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			if !hasBase {
+				if err := writeFile(path, gen); err != nil {
+					return nil, err
+				}
+				report.Added = append(report.Added, rel)
+			} else if gen != prev {
+				// Deleted locally but changed by the generator.
+				if err := writeRejects(path, []Hunk{{Line: 1, New: splitLines(gen)}}); err != nil {
+					return nil, err
+				}
+				report.Conflicts = append(report.Conflicts, rel)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("upgrade: %w", err)
+		}
+
+		current := string(data)
+		if current == gen {
+			continue
+		}
+
+		var merged string
+		var rejects []Hunk
+		if hasBase {
+			merged, rejects = Merge3(prev, current, gen)
+		} else {
+			merged, rejects = current, DiffHunks(current, gen)
+		}
+
+		if merged != current {
+			if err := writeFile(path, merged); err != nil {
+				return nil, err
+			}
+			report.Updated = append(report.Updated, rel)
+		}
+		if len(rejects) > 0 {
+			if err := writeRejects(path, rejects); err != nil {
+				return nil, err
+			}
+			report.Conflicts = append(report.Conflicts, rel)
+		}
+	}
+
+	for _, rel := range sortedKeys(base) {
+		if _, ok := generated[rel]; ok {
+			continue
+		}
+		path := filepath.Join(chartDir, rel)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("upgrade: %w", err)
+		}
+		if string(data) != base[rel] {
+			report.Kept = append(report.Kept, rel)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("upgrade: %w", err)
+		}
+		report.Removed = append(report.Removed, rel)
+	}
+
+	if err := writeBaseSnapshot(chartDir, generated); err != nil {
+		return nil, err
+	}
+	if manifest, err := ReadGenerationManifest(generatedDir); err == nil {
+		if err := WriteGenerationManifest(chartDir, manifest); err != nil {
+			return nil, err
+		}
+	}
+	for _, rel := range []string{GenerationRecordFile, SourceSnapshotFile} {
+		data, err := os.ReadFile(filepath.Join(generatedDir, rel))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("upgrade: %w", err)
+		}
+		if err := writeFile(filepath.Join(chartDir, rel), string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// Merge3 merges the changes from base to generated into current, line by
+// line. Hunks where current and generated both changed the same base lines
+// differently keep the current lines and are returned as rejects.
+func Merge3(base, current, generated string) (string, []Hunk) {
+	o, a, b := splitLines(base), splitLines(current), splitLines(generated)
+	matchA, matchB := lcsMatch(o, a), lcsMatch(o, b)
+
+	var out []string
+	var rejects []Hunk
+	i, ai, bi := 0, 0, 0
+	for i < len(o) || ai < len(a) || bi < len(b) {
+		if i < len(o) && matchA[i] == ai && matchB[i] == bi {
+			out = append(out, o[i])
+			i, ai, bi = i+1, ai+1, bi+1
+			continue
+		}
+
+		// Find the next base line kept by both sides; everything before it
+		// is an unstable chunk.
+		j := i
+		for j < len(o) && (matchA[j] < 0 || matchB[j] < 0) {
+			j++
+		}
+		aEnd, bEnd := len(a), len(b)
+		if j < len(o) {
+			aEnd, bEnd = matchA[j], matchB[j]
+		}
+		oc, ac, bc := o[i:j], a[ai:aEnd], b[bi:bEnd]
+
+		switch {
+		case equalLines(ac, oc):
+			out = append(out, bc...)
+		case equalLines(bc, oc), equalLines(ac, bc):
+			out = append(out, ac...)
+		default:
+			rejects = append(rejects, Hunk{Line: len(out) + 1, Old: oc, New: bc})
+			out = append(out, ac...)
+		}
+		i, ai, bi = j, aEnd, bEnd
+	}
+
+	return strings.Join(out, ""), rejects
+}
+
+// DiffHunks returns the hunks that turn a into b.
+func DiffHunks(a, b string) []Hunk {
+	al, bl := splitLines(a), splitLines(b)
+	match := lcsMatch(al, bl)
+
+	var hunks []Hunk
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		if i < len(al) && match[i] == j {
+			i, j = i+1, j+1
+			continue
+		}
+		k := i
+		for k < len(al) && match[k] < 0 {
+			k++
+		}
+		end := len(bl)
+		if k < len(al) {
+			end = match[k]
+		}
+		hunks = append(hunks, Hunk{Line: i + 1, Old: al[i:k], New: bl[j:end]})
+		i, j = k, end
+	}
+	return hunks
+}
+
+// FormatRejects renders hunks in a unified-diff-like format.
+func FormatRejects(hunks []Hunk) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated changes that conflict with local edits.\n")
+	sb.WriteString("# Apply them manually, then delete this file.\n")
+	for _, h := range hunks {
+		fmt.Fprintf(&sb, "@@ line %d @@\n", h.Line)
+		for _, l := range h.Old {
+			sb.WriteString("-" + strings.TrimSuffix(l, "\n") + "\n")
+		}
+		for _, l := range h.New {
+			sb.WriteString("+" + strings.TrimSuffix(l, "\n") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// lcsMatch returns, for every line of a, the index of the matching line of b
+// in a longest common subsequence, or -1.
+func lcsMatch(a, b []string) []int {
+	n, m := len(a), len(b)
+	table := make([][]int32, n+1)
+	for i := range table {
+		table[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				table[i][j] = table[i+1][j+1] + 1
+			case table[i+1][j] >= table[i][j+1]:
+				table[i][j] = table[i+1][j]
+			default:
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i, j = i+1, j+1
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+// splitLines splits s into lines, keeping line terminators so that joining
+// them restores s exactly.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readChartFiles returns the files under dir keyed by slash-separated
+// relative path, skipping dhg metadata, the changelog and reject files.
+func readChartFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == ".dhg" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(rel, RejectSuffix) || rel == ChangelogFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func writeBaseSnapshot(chartDir string, files map[string]string) error {
+	baseDir := filepath.Join(chartDir, BaseSnapshotDir)
+	if err := os.RemoveAll(baseDir); err != nil {
+		return fmt.Errorf("base snapshot: %w", err)
+	}
+	for rel, content := range files {
+		if err := writeFile(filepath.Join(baseDir, filepath.FromSlash(rel)), content); err != nil {
+			return fmt.Errorf("base snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeRejects(path string, hunks []Hunk) error {
+	return writeFile(path+RejectSuffix, FormatRejects(hunks))
+}
+
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
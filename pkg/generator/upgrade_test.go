@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name        string
+		current     string
+		generated   string
+		want        string
+		wantRejects int
+	}{
+		{name: "no changes", current: base, generated: base, want: base},
+		{name: "generated change only", current: base, generated: "a\nB\nc\nd\ne\n", want: "a\nB\nc\nd\ne\n"},
+		{name: "local change only", current: "a\nb\nc\nD\ne\n", generated: base, want: "a\nb\nc\nD\ne\n"},
+		{name: "separate hunks", current: "a\nb\nc\nD\ne\n", generated: "a\nB\nc\nd\ne\n", want: "a\nB\nc\nD\ne\n"},
+		{name: "same change on both sides", current: "a\nX\nc\nd\ne\n", generated: "a\nX\nc\nd\ne\n", want: "a\nX\nc\nd\ne\n"},
+		{name: "generated insertion", current: "a\nb\nc\nD\ne\n", generated: "a\nb\nnew\nc\nd\ne\n", want: "a\nb\nnew\nc\nD\ne\n"},
+		{name: "generated deletion", current: "a\nb\nc\nD\ne\n", generated: "b\nc\nd\ne\n", want: "b\nc\nD\ne\n"},
+		{name: "conflict keeps current", current: "a\nmine\nc\nd\ne\n", generated: "a\ntheirs\nc\nd\ne\n", want: "a\nmine\nc\nd\ne\n", wantRejects: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rejects := Merge3(base, tt.current, tt.generated)
+			if got != tt.want {
+				t.Errorf("merged:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if len(rejects) != tt.wantRejects {
+				t.Errorf("expected %d rejects, got %v", tt.wantRejects, rejects)
+			}
+		})
+	}
+}
+
+func TestMerge3_ConflictHunk(t *testing.T) {
+	_, rejects := Merge3("a\nb\nc\n", "a\nmine\nc\n", "a\ntheirs\nc\n")
+	want := []Hunk{{Line: 2, Old: []string{"b\n"}, New: []string{"theirs\n"}}}
+	if !reflect.DeepEqual(rejects, want) {
+		t.Errorf("rejects = %#v, want %#v", rejects, want)
+	}
+
+	text := FormatRejects(rejects)
+	for _, line := range []string{"@@ line 2 @@", "-b", "+theirs"} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("reject file should contain %q:\n%s", line, text)
+		}
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	got := DiffHunks("a\nb\nc\n", "a\nB\nc\nd\n")
+	want := []Hunk{
+		{Line: 2, Old: []string{"b\n"}, New: []string{"B\n"}},
+		{Line: 4, Old: []string{}, New: []string{"d\n"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffHunks = %#v, want %#v", got, want)
+	}
+}
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUpgradeChart(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"Chart.yaml":                 "name: app\nversion: 0.1.0\n",
+		"values.yaml":                "replicas: 1\nimage: app:1.0\n",
+		"templates/deployment.yaml":  "kind: Deployment\nreplicas: 1\nimage: app\n",
+		"templates/old-cm.yaml":      "kind: ConfigMap\n",
+		"templates/old-edited.yaml":  "kind: Secret\n",
+		"templates/conflicting.yaml": "key: base\n",
+	})
+	if err := SaveBaseSnapshot(chartDir); err != nil {
+		t.Fatalf("SaveBaseSnapshot: %v", err)
+	}
+
+	// Hand edits after the first generation.
+	writeTree(t, chartDir, map[string]string{
+		"templates/deployment.yaml":  "# tuned by hand\nkind: Deployment\nreplicas: 1\nimage: app\n",
+		"templates/old-edited.yaml":  "kind: Secret\n# edited\n",
+		"templates/conflicting.yaml": "key: mine\n",
+		"templates/custom.yaml":      "kind: Custom\n",
+	})
+
+	generatedDir := t.TempDir()
+	writeTree(t, generatedDir, map[string]string{
+		"Chart.yaml":                 "name: app\nversion: 0.1.0\n",
+		"values.yaml":                "replicas: 1\nimage: app:2.0\n",
+		"templates/deployment.yaml":  "kind: Deployment\nreplicas: 2\nimage: app\n",
+		"templates/conflicting.yaml": "key: theirs\n",
+		"templates/service.yaml":     "kind: Service\n",
+	})
+
+	report, err := UpgradeChart(chartDir, generatedDir)
+	if err != nil {
+		t.Fatalf("UpgradeChart: %v", err)
+	}
+
+	want := &UpgradeReport{
+		Added:     []string{"templates/service.yaml"},
+		Updated:   []string{"templates/deployment.yaml", "values.yaml"},
+		Removed:   []string{"templates/old-cm.yaml"},
+		Conflicts: []string{"templates/conflicting.yaml"},
+		Kept:      []string{"templates/old-edited.yaml"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+
+	if got := readFile(t, filepath.Join(chartDir, "templates/deployment.yaml")); got != "# tuned by hand\nkind: Deployment\nreplicas: 2\nimage: app\n" {
+		t.Errorf("deployment should keep the hand edit and take the new replicas:\n%s", got)
+	}
+	if got := readFile(t, filepath.Join(chartDir, "templates/conflicting.yaml")); got != "key: mine\n" {
+		t.Errorf("conflicting file should keep local content, got %q", got)
+	}
+	if rej := readFile(t, filepath.Join(chartDir, "templates/conflicting.yaml.rej")); !strings.Contains(rej, "+key: theirs") {
+		t.Errorf("reject file should contain the generated change:\n%s", rej)
+	}
+	if got := readFile(t, filepath.Join(chartDir, "templates/custom.yaml")); got != "kind: Custom\n" {
+		t.Error("user-added files must not be touched")
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, "templates/old-cm.yaml")); !os.IsNotExist(err) {
+		t.Error("unedited file that is no longer generated should be removed")
+	}
+
+	// The new generation becomes the base.
+	if got := readFile(t, filepath.Join(chartDir, BaseSnapshotDir, "templates/conflicting.yaml")); got != "key: theirs\n" {
+		t.Errorf("base snapshot should hold the new generation, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, BaseSnapshotDir, "templates/old-cm.yaml")); !os.IsNotExist(err) {
+		t.Error("base snapshot should drop files that are no longer generated")
+	}
+}
+
+func TestUpgradeChart_WithoutBase(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{
+		"values.yaml":      "replicas: 3\n",
+		"templates/a.yaml": "same\n",
+	})
+	generatedDir := t.TempDir()
+	writeTree(t, generatedDir, map[string]string{
+		"values.yaml":      "replicas: 1\n",
+		"templates/a.yaml": "same\n",
+	})
+
+	report, err := UpgradeChart(chartDir, generatedDir)
+	if err != nil {
+		t.Fatalf("UpgradeChart: %v", err)
+	}
+	if !report.HasConflicts() || report.Conflicts[0] != "values.yaml" || len(report.Updated) != 0 {
+		t.Errorf("without a base, differing files must be conflicts: %+v", report)
+	}
+	if got := readFile(t, filepath.Join(chartDir, "values.yaml")); got != "replicas: 3\n" {
+		t.Errorf("local content must be kept, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, BaseSnapshotDir, "values.yaml")); err != nil {
+		t.Errorf("base snapshot should be recorded: %v", err)
+	}
+}
+
+func TestUpgradeChart_LocallyDeletedFile(t *testing.T) {
+	chartDir := t.TempDir()
+	writeTree(t, chartDir, map[string]string{"templates/a.yaml": "v1\n", "templates/b.yaml": "v1\n"})
+	if err := SaveBaseSnapshot(chartDir); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"templates/a.yaml", "templates/b.yaml"} {
+		if err := os.Remove(filepath.Join(chartDir, f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generatedDir := t.TempDir()
+	writeTree(t, generatedDir, map[string]string{"templates/a.yaml": "v1\n", "templates/b.yaml": "v2\n"})
+
+	report, err := UpgradeChart(chartDir, generatedDir)
+	if err != nil {
+		t.Fatalf("UpgradeChart: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, "templates/a.yaml")); !os.IsNotExist(err) {
+		t.Error("an unchanged file deleted by the user must stay deleted")
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0] != "templates/b.yaml" {
+		t.Errorf("a changed file deleted by the user is a conflict: %+v", report)
+	}
+}
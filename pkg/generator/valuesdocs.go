@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// globalValuesDocs documents the values every universal chart has.
+var globalValuesDocs = map[string]string{
+	"global.imageRegistry":    "Global Docker image registry",
+	"global.imagePullSecrets": "Global image pull secrets",
+	"monitoring.enabled":      "Create Prometheus Operator monitors (ServiceMonitor, PodMonitor)",
+}
+
+// describeResourceValues adds helm-docs descriptions to docs for every leaf
+// value under values (found at dot path prefix) that was extracted from one
+// of resources. A description names the source resource and, when it can be
+// located, the field of the original manifest, e.g.
+// "Deployment prod/web: spec.replicas".
+func describeResourceValues(prefix string, values map[string]interface{}, resources []*types.ProcessedResource, docs map[string]string) {
+	byValues := make(map[uintptr]*types.ProcessedResource, len(resources))
+	for _, r := range resources {
+		if len(r.Values) > 0 {
+			byValues[reflect.ValueOf(r.Values).Pointer()] = r
+		}
+	}
+	walkResourceValues(prefix, values, byValues, docs)
+}
+
+func walkResourceValues(prefix string, values map[string]interface{}, byValues map[uintptr]*types.ProcessedResource, docs map[string]string) {
+	for key, v := range values {
+		m, ok := v.(map[string]interface{})
+		if !ok || len(m) == 0 {
+			continue
+		}
+		path := joinValuesPath(prefix, key)
+		if r, ok := byValues[reflect.ValueOf(m).Pointer()]; ok {
+			describeLeaves(path, m, nil, r, docs)
+			continue
+		}
+		walkResourceValues(path, m, byValues, docs)
+	}
+}
+
+// describeLeaves documents the leaves of the values of resource r. keys is
+// the key path within r.Values.
+func describeLeaves(path string, values map[string]interface{}, keys []string, r *types.ProcessedResource, docs map[string]string) {
+	source := r.Original.GVK.Kind + " " + r.Original.Object.GetName()
+	if ns := r.Original.Object.GetNamespace(); ns != "" {
+		source = r.Original.GVK.Kind + " " + ns + "/" + r.Original.Object.GetName()
+	}
+
+	for key, v := range values {
+		leafPath := joinValuesPath(path, key)
+		leafKeys := append(append([]string{}, keys...), key)
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			describeLeaves(leafPath, m, leafKeys, r, docs)
+			continue
+		}
+
+		doc := source
+		if field := findFieldPath(r.Original.Object.Object, leafKeys, v); field != "" {
+			doc += ": " + field
+		} else if key == "enabled" && len(keys) == 0 {
+			doc = "Create " + source
+		}
+		docs[leafPath] = doc
+	}
+}
+
+func joinValuesPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// findFieldPath returns the path of the field of obj a value was extracted
+// from. keys is the key path of the value within the resource's values;
+// fields whose path ends with the longest suffix of keys win, and fields
+// with an equal value win over fields that only share the name (processors
+// may normalize values). For split values such as image.repository, a field
+// named like the parent key whose string value contains the value is used.
+// It returns "" when nothing matches.
+func findFieldPath(obj map[string]interface{}, keys []string, value interface{}) string {
+	want := fmt.Sprint(value)
+	for _, sameValue := range []bool{true, false} {
+		for n := len(keys); n > 0; n-- {
+			suffix := keys[len(keys)-n:]
+			if path := searchField(obj, func(names []string, v interface{}) bool {
+				return hasSuffix(names, suffix) && (!sameValue || fmt.Sprint(v) == want)
+			}); path != "" {
+				return path
+			}
+		}
+	}
+
+	s, ok := value.(string)
+	if !ok || s == "" || len(keys) < 2 {
+		return ""
+	}
+	parent := keys[len(keys)-2]
+	return searchField(obj, func(names []string, v interface{}) bool {
+		vs, ok := v.(string)
+		return ok && names[len(names)-1] == parent && strings.Contains(vs, s)
+	})
+}
+
+func hasSuffix(names, suffix []string) bool {
+	if len(names) < len(suffix) {
+		return false
+	}
+	offset := len(names) - len(suffix)
+	for i, k := range suffix {
+		if names[offset+i] != k {
+			return false
+		}
+	}
+	return true
+}
+
+// searchField walks obj breadth-first, skipping metadata.managedFields and
+// status, and returns the path of the first field for which match returns
+// true. match gets the field names leading to the field (list indices
+// omitted) and its value.
+func searchField(obj map[string]interface{}, match func(names []string, value interface{}) bool) string {
+	type node struct {
+		path  string
+		names []string
+		value interface{}
+	}
+	queue := []node{{value: obj}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		switch v := n.value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				if n.path == "" && k == "status" || n.path == "metadata" && k == "managedFields" {
+					continue
+				}
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				child := node{
+					path:  joinValuesPath(n.path, k),
+					names: append(append([]string{}, n.names...), k),
+					value: v[k],
+				}
+				if match(child.names, child.value) {
+					return child.path
+				}
+				queue = append(queue, child)
+			}
+		case []interface{}:
+			for i, item := range v {
+				queue = append(queue, node{path: fmt.Sprintf("%s[%d]", n.path, i), names: n.names, value: item})
+			}
+		}
+	}
+	return ""
+}
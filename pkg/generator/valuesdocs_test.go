@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestFindFieldPath(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"app": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "web"},
+			},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": "nginx:1.25"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		keys  []string
+		value interface{}
+		want  string
+	}{
+		{[]string{"replicas"}, int64(3), "spec.replicas"},
+		{[]string{"replicas"}, 1, "spec.replicas"},
+		{[]string{"selector", "matchLabels", "app"}, "web", "spec.selector.matchLabels.app"},
+		{[]string{"image", "repository"}, "nginx", "spec.template.spec.containers[0].image"},
+		{[]string{"podLabels"}, map[string]interface{}{"app": "web"}, ""},
+	}
+	for _, tt := range tests {
+		if got := findFieldPath(obj, tt.keys, tt.value); got != tt.want {
+			t.Errorf("findFieldPath(%v, %v) = %q, want %q", tt.keys, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUniversalGenerator_Generate_ValuesDocs(t *testing.T) {
+	deploy := makeProcessedResourceWithValues("Deployment", "web", "prod", nil,
+		map[string]interface{}{"replicas": int64(3)},
+		"# deployment template")
+	deploy.Original.Object.Object["spec"] = map[string]interface{}{"replicas": int64(3)}
+
+	graph := buildGraph([]*types.ProcessedResource{deploy}, nil)
+	graph.Groups = []*types.ResourceGroup{{Name: "web", Resources: []*types.ProcessedResource{deploy}}}
+
+	charts, err := NewUniversalGenerator().Generate(context.Background(), graph, Options{
+		ChartName:     "app",
+		ValuesDocs:    true,
+		IncludeREADME: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chart := charts[0]
+
+	for _, want := range []string{
+		"    # -- Enable the web service\n    enabled: true",
+		"      # -- Deployment prod/web: spec.replicas\n      replicas: 3",
+		"  # -- Global Docker image registry\n  imageRegistry:",
+	} {
+		if !strings.Contains(chart.ValuesYAML, want) {
+			t.Errorf("values.yaml missing %q:\n%s", want, chart.ValuesYAML)
+		}
+	}
+
+	var readme string
+	for _, f := range chart.ExternalFiles {
+		if f.Path == "README.md" {
+			readme = f.Content
+		}
+	}
+	if !strings.Contains(readme, "| services.web.deployment.replicas | int | `3` | Deployment prod/web: spec.replicas |") {
+		t.Errorf("README.md missing the replicas row:\n%s", readme)
+	}
+}
+
+func TestUniversalGenerator_Generate_NoValuesDocs(t *testing.T) {
+	deploy := makeProcessedResourceWithValues("Deployment", "web", "prod", nil,
+		map[string]interface{}{"replicas": int64(3)},
+		"# deployment template")
+	graph := buildGraph([]*types.ProcessedResource{deploy}, nil)
+	graph.Groups = []*types.ResourceGroup{{Name: "web", Resources: []*types.ProcessedResource{deploy}}}
+
+	charts, err := NewUniversalGenerator().Generate(context.Background(), graph, Options{ChartName: "app", IncludeREADME: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(charts[0].ValuesYAML, "# --") {
+		t.Errorf("unexpected helm-docs comments without ValuesDocs:\n%s", charts[0].ValuesYAML)
+	}
+	if len(charts[0].ExternalFiles) != 0 {
+		t.Errorf("unexpected external files: %v", charts[0].ExternalFiles)
+	}
+}
@@ -83,7 +83,7 @@ func InjectVaultAgentAnnotations(chart *types.GeneratedChart, graph *types.Resou
 	// Collect Vault secrets from the graph (Secrets referenced by workloads).
 	var secrets []VaultAgentSecret
 	if graph != nil {
-		for _, r := range graph.Resources {
+		for _, r := range sortedResources(graph) {
 			if r.Original.GVK.Kind != "Secret" {
 				continue
 			}
@@ -121,8 +121,8 @@ func injectVaultAnnotationsIntoTemplate(content string, annotations map[string]s
 
 	// Build annotation block.
 	var sb strings.Builder
-	for k, v := range annotations {
-		sb.WriteString(fmt.Sprintf("        %s: %q\n", k, v))
+	for _, k := range sortedKeys(annotations) {
+		sb.WriteString(fmt.Sprintf("        %s: %q\n", k, annotations[k]))
 	}
 	annotationBlock := sb.String()
 
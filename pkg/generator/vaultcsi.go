@@ -46,7 +46,7 @@ func DetectVaultCSICandidates(graph *types.ResourceGraph, opts VaultCSIOptions)
 	if graph == nil {
 		return result
 	}
-	for _, r := range graph.Resources {
+	for _, r := range sortedResources(graph) {
 		if r.Original.GVK.Kind != "Secret" {
 			continue
 		}
@@ -0,0 +1,239 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// WerfChartDir is the directory of the chart in a werf project.
+const WerfChartDir = ".helm"
+
+// WerfImage is an image built by werf for a service.
+type WerfImage struct {
+	// Name is the werf image name: the service name, or
+	// <service>-<container> for services running several images.
+	Name string
+
+	// From is the base image: the image in the input resources.
+	From string
+
+	// Service is the service running the image.
+	Service string
+
+	// Users are the containers running the image.
+	Users []WerfImageUser
+}
+
+// WerfImageUser is a container running a werf image.
+type WerfImageUser struct {
+	// TemplatePath is the chart template of the workload.
+	TemplatePath string
+
+	// Workload is "Kind/name" of the workload.
+	Workload string
+
+	// Container is the container name.
+	Container string
+}
+
+// BuildWerfImages infers the werf images of the workloads of graph: one per
+// service, or one per distinct image for services running several. Only
+// regular containers get werf images; init containers keep their images.
+func BuildWerfImages(graph *types.ResourceGraph) []WerfImage {
+	if graph == nil {
+		return nil
+	}
+
+	keys := make([]types.ResourceKey, 0, len(graph.Resources))
+	for key := range graph.Resources {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	var services []string
+	byService := make(map[string][]*WerfImage)
+	for _, key := range keys {
+		r := graph.Resources[key]
+		specPath := processor.PodSpecPath(key.GVK.Kind)
+		if specPath == nil || r.TemplatePath == "" {
+			continue
+		}
+		service := r.ServiceName
+		if service == "" {
+			service = key.Name
+		}
+
+		for _, c := range podContainerImages(r.Original.Object.Object, specPath) {
+			user := WerfImageUser{TemplatePath: r.TemplatePath, Workload: key.GVK.Kind + "/" + key.Name, Container: c.Container}
+
+			images, known := byService[service]
+			if !known {
+				services = append(services, service)
+			}
+			var image *WerfImage
+			for _, img := range images {
+				if img.From == c.Image {
+					image = img
+					break
+				}
+			}
+			if image == nil {
+				image = &WerfImage{Name: service + "-" + c.Container, From: c.Image, Service: service}
+				byService[service] = append(images, image)
+			}
+			image.Users = append(image.Users, user)
+		}
+	}
+
+	var result []WerfImage
+	used := make(map[string]bool)
+	for _, service := range services {
+		images := byService[service]
+		if len(images) == 1 {
+			images[0].Name = service
+		}
+		for _, image := range images {
+			name := image.Name
+			for i := 2; used[name]; i++ {
+				name = fmt.Sprintf("%s-%d", image.Name, i)
+			}
+			used[name] = true
+			image.Name = name
+			result = append(result, *image)
+		}
+	}
+	return result
+}
+
+// podContainerImages returns the images of the regular containers of a pod
+// spec at specPath.
+func podContainerImages(obj map[string]interface{}, specPath []string) []processor.ContainerImage {
+	var images []processor.ContainerImage
+	spec := obj
+	for _, field := range specPath {
+		next, ok := spec[field].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		spec = next
+	}
+	containers, _ := spec["containers"].([]interface{})
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		if name != "" && image != "" {
+			images = append(images, processor.ContainerImage{Container: name, Image: image})
+		}
+	}
+	return images
+}
+
+// GenerateWerfConfig returns the werf.yaml of project: one image per
+// WerfImage, built from its base image.
+func GenerateWerfConfig(project string, images []WerfImage) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "project: %s\nconfigVersion: 1\n", project)
+	for _, image := range images {
+		users := make([]string, 0, len(image.Users))
+		for _, u := range image.Users {
+			users = append(users, u.Workload+" ("+u.Container+")")
+		}
+		sb.WriteString("---\n")
+		fmt.Fprintf(&sb, "# Service %s: %s\n", image.Service, strings.Join(users, ", "))
+		sb.WriteString("# Add git/shell instructions to build on top of the base image, or replace\n")
+		sb.WriteString("# `from` with `dockerfile` to build the image from a Dockerfile.\n")
+		fmt.Fprintf(&sb, "image: %s\nfrom: %s\n", image.Name, image.From)
+	}
+	return sb.String()
+}
+
+// werfImageLine matches the image line of a container in the templates
+// generated by the workload processors.
+var werfImageLine = regexp.MustCompile(`(?m)^([ \t]*)image: ("\{\{ \.image\.repository \}\}.*")$`)
+
+// InjectWerfImages returns a copy of chart whose containers run the images
+// werf builds (.Values.werf.image.<name>) when rendered by werf, and the
+// images in values.yaml otherwise, together with the images the chart uses.
+// Each template running werf images gets a header mapping its containers to
+// their werf images.
+func InjectWerfImages(chart *types.GeneratedChart, images []WerfImage) (*types.GeneratedChart, []WerfImage) {
+	if chart == nil {
+		return nil, nil
+	}
+
+	var used []WerfImage
+	byTemplate := make(map[string]map[string]string)
+	for _, image := range images {
+		inChart := false
+		for _, u := range image.Users {
+			if _, ok := chart.Templates[u.TemplatePath]; !ok {
+				continue
+			}
+			if byTemplate[u.TemplatePath] == nil {
+				byTemplate[u.TemplatePath] = make(map[string]string)
+			}
+			byTemplate[u.TemplatePath][u.Container] = image.Name
+			inChart = true
+		}
+		if inChart {
+			used = append(used, image)
+		}
+	}
+
+	result := *chart
+	result.Templates = make(map[string]string, len(chart.Templates))
+	for path, content := range chart.Templates {
+		if containers, ok := byTemplate[path]; ok && werfImageLine.MatchString(content) {
+			content = werfTemplateHeader(containers) + werfImageLine.ReplaceAllString(content,
+				`${1}image: {{ if and $$.Values.werf (index $$werfImages .name) }}{{ index $$.Values.werf.image (index $$werfImages .name) | quote }}{{ else }}${2}{{ end }}`)
+		}
+		result.Templates[path] = content
+	}
+	return &result, used
+}
+
+// werfTemplateHeader defines $werfImages, the werf image of each container
+// of a template.
+func werfTemplateHeader(containers map[string]string) string {
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%q %q", name, containers[name]))
+	}
+	return "{{- /* werf: containers run the images built by werf (werf.yaml) when rendered by werf */ -}}\n" +
+		"{{- $werfImages := dict " + strings.Join(pairs, " ") + " -}}\n"
+}
+
+// WriteWerfProject writes chart as a werf project: werf.yaml in
+// outputDir/<chart name> and the chart in its WerfChartDir.
+func WriteWerfProject(chart *types.GeneratedChart, werfYAML, outputDir string) error {
+	projectDir := filepath.Join(outputDir, chart.Name)
+	var batch fileBatch
+	if err := batch.write(filepath.Join(projectDir, "werf.yaml"), []byte(werfYAML)); err != nil {
+		batch.abort()
+		return fmt.Errorf("failed to write werf.yaml: %w", err)
+	}
+
+	helmChart := *chart
+	helmChart.Name = WerfChartDir
+	if err := writeChart(&batch, &helmChart, projectDir); err != nil {
+		batch.abort()
+		return err
+	}
+	return batch.commit()
+}
@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// makeWerfWorkload returns a Deployment of service with one container per
+// name=image pair.
+func makeWerfWorkload(service, name string, containers ...string) *types.ProcessedResource {
+	r := makeProcessedResource("Deployment", name, "default", nil)
+	r.ServiceName = service
+	r.TemplatePath = "templates/" + service + "-deployment.yaml"
+
+	list := make([]interface{}, 0, len(containers))
+	for _, c := range containers {
+		parts := strings.SplitN(c, "=", 2)
+		list = append(list, map[string]interface{}{"name": parts[0], "image": parts[1]})
+	}
+	r.Original.Object.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"containers": list},
+		},
+	}
+	return r
+}
+
+func TestBuildWerfImages(t *testing.T) {
+	graph := types.NewResourceGraph()
+	graph.AddResource(makeWerfWorkload("api", "api", "api=registry.example.com/api:1.0"))
+	graph.AddResource(makeWerfWorkload("web", "web", "web=nginx:1.25", "exporter=nginx-exporter:0.11"))
+
+	images := BuildWerfImages(graph)
+
+	want := map[string]string{
+		"api":          "registry.example.com/api:1.0",
+		"web-web":      "nginx:1.25",
+		"web-exporter": "nginx-exporter:0.11",
+	}
+	if len(images) != len(want) {
+		t.Fatalf("expected %d images, got %+v", len(want), images)
+	}
+	for _, image := range images {
+		if want[image.Name] != image.From {
+			t.Errorf("image %s from %s, want %q", image.Name, image.From, want[image.Name])
+		}
+		if len(image.Users) != 1 || image.Users[0].TemplatePath != "templates/"+image.Service+"-deployment.yaml" {
+			t.Errorf("image %s: unexpected users %+v", image.Name, image.Users)
+		}
+	}
+}
+
+func TestGenerateWerfConfig(t *testing.T) {
+	config := GenerateWerfConfig("app", []WerfImage{{
+		Name:    "web",
+		From:    "nginx:1.25",
+		Service: "web",
+		Users:   []WerfImageUser{{Workload: "Deployment/web", Container: "web"}},
+	}})
+
+	for _, want := range []string{"project: app\nconfigVersion: 1\n---\n", "# Service web: Deployment/web (web)\n", "image: web\nfrom: nginx:1.25\n"} {
+		if !strings.Contains(config, want) {
+			t.Errorf("expected %q in werf.yaml:\n%s", want, config)
+		}
+	}
+}
+
+func TestInjectWerfImages(t *testing.T) {
+	chart := &types.GeneratedChart{
+		Name: "app",
+		Templates: map[string]string{
+			"templates/web-deployment.yaml": "kind: Deployment\n" +
+				"        - name: {{ .name }}\n" +
+				`          image: "{{ .image.repository }}:{{ .image.tag }}"` + "\n",
+			"templates/web-service.yaml": "kind: Service\n",
+		},
+	}
+	images := []WerfImage{
+		{Name: "web", From: "nginx:1.25", Users: []WerfImageUser{{TemplatePath: "templates/web-deployment.yaml", Container: "web"}}},
+		{Name: "api", From: "api:1.0", Users: []WerfImageUser{{TemplatePath: "templates/api-deployment.yaml", Container: "api"}}},
+	}
+
+	result, used := InjectWerfImages(chart, images)
+
+	if len(used) != 1 || used[0].Name != "web" {
+		t.Errorf("expected only the web image to be used by the chart, got %+v", used)
+	}
+	deployment := result.Templates["templates/web-deployment.yaml"]
+	for _, want := range []string{
+		`{{- $werfImages := dict "web" "web" -}}`,
+		`image: {{ if and $.Values.werf (index $werfImages .name) }}{{ index $.Values.werf.image (index $werfImages .name) | quote }}{{ else }}"{{ .image.repository }}:{{ .image.tag }}"{{ end }}`,
+	} {
+		if !strings.Contains(deployment, want) {
+			t.Errorf("expected %s in:\n%s", want, deployment)
+		}
+	}
+	if result.Templates["templates/web-service.yaml"] != "kind: Service\n" {
+		t.Error("expected templates without werf images unchanged")
+	}
+	if strings.Contains(chart.Templates["templates/web-deployment.yaml"], "werf") {
+		t.Error("expected the input chart unchanged")
+	}
+}
+
+func TestWriteWerfProject(t *testing.T) {
+	dir := t.TempDir()
+	chart := &types.GeneratedChart{
+		Name:       "app",
+		ChartYAML:  "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		ValuesYAML: "services: {}\n",
+		Templates:  map[string]string{"templates/web-deployment.yaml": "kind: Deployment\n"},
+	}
+
+	if err := WriteWerfProject(chart, "project: app\nconfigVersion: 1\n", dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"werf.yaml", ".helm/Chart.yaml", ".helm/values.yaml", ".helm/templates/web-deployment.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, "app", path)); err != nil {
+			t.Errorf("expected app/%s: %v", path, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package helm
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -20,6 +21,7 @@ type ChartMetadata struct {
 	Maintainers []Maintainer
 	Icon        string
 	KubeVersion string
+	Annotations map[string]string
 	Dependencies []Dependency
 }
 
@@ -123,9 +125,22 @@ func GenerateChartYAML(meta ChartMetadata) string {
 		sb.WriteString(fmt.Sprintf("icon: %s\n", meta.Icon))
 	}
 
-	// KubeVersion
+	// KubeVersion (quoted: constraints such as ">=1.25.0-0" are not plain YAML scalars)
 	if meta.KubeVersion != "" {
-		sb.WriteString(fmt.Sprintf("kubeVersion: %s\n", meta.KubeVersion))
+		sb.WriteString(fmt.Sprintf("kubeVersion: %q\n", meta.KubeVersion))
+	}
+
+	// Annotations
+	if len(meta.Annotations) > 0 {
+		keys := make([]string, 0, len(meta.Annotations))
+		for k := range meta.Annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("annotations:\n")
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("  %s: %q\n", k, meta.Annotations[k]))
+		}
 	}
 
 	// Dependencies
@@ -158,6 +173,37 @@ type NOTESContext struct {
 	ServiceTypes []string // Kubernetes Service types present (e.g., "LoadBalancer", "ClusterIP")
 	HasIngress   bool     // Whether the chart includes Ingress resources
 	HasAuth      bool     // Whether the chart includes authentication configuration
+
+	Services  []NOTESService // Services of the chart, with access instructions
+	Ingresses []NOTESIngress // Ingresses of the chart, with their URLs
+	Secrets   []NOTESSecret  // Secrets of the chart, with retrieval commands
+}
+
+// NOTESService describes how to reach a Service of the chart. Name,
+// Namespace and Condition are template expressions.
+type NOTESService struct {
+	Service   string // Chart service the Service belongs to
+	Name      string // Service name, e.g. {{ include "app.fullname" . }}-web
+	Namespace string // Service namespace, e.g. {{ .Release.Namespace }}
+	Type      string // ClusterIP, NodePort or LoadBalancer
+	Port      int64  // First port of the Service
+	Condition string // Condition the Service is rendered under, or ""
+}
+
+// NOTESIngress describes the URLs an Ingress of the chart serves.
+type NOTESIngress struct {
+	Name      string   // Ingress name
+	Namespace string   // Ingress namespace
+	URLs      []string // URLs of the rules with a host; none lists the Ingress instead
+	Condition string   // Condition the Ingress is rendered under, or ""
+}
+
+// NOTESSecret describes a Secret of the chart and the keys it holds.
+type NOTESSecret struct {
+	Name      string   // Secret name
+	Namespace string   // Secret namespace
+	Keys      []string // Data keys
+	Condition string   // Condition the Secret is rendered under, or ""
 }
 
 // GenerateNOTES generates the NOTES.txt content with context-aware sections.
@@ -202,7 +248,7 @@ func GenerateNOTES(chartName string, services []string, ctx NOTESContext) string
 			break
 		}
 	}
-	if !hasLB && !ctx.HasIngress {
+	if len(ctx.ServiceTypes) > 0 && !hasLB && !ctx.HasIngress {
 		sb.WriteString("Access the application via port-forward:\n\n")
 		sb.WriteString(fmt.Sprintf("  kubectl port-forward svc/%s 8080:80 -n {{ .Release.Namespace }}\n\n", chartName))
 	}
@@ -213,6 +259,10 @@ func GenerateNOTES(chartName string, services []string, ctx NOTESContext) string
 		sb.WriteString("  kubectl get secret -l app.kubernetes.io/instance={{ .Release.Name }} -n {{ .Release.Namespace }}\n\n")
 	}
 
+	writeNOTESServices(&sb, ctx.Services)
+	writeNOTESIngresses(&sb, ctx.Ingresses)
+	writeNOTESSecrets(&sb, ctx.Secrets)
+
 	sb.WriteString("To customize the installation, edit the values.yaml file and upgrade:\n\n")
 	sb.WriteString(fmt.Sprintf("  helm upgrade {{ .Release.Name }} ./%s -n {{ .Release.Namespace }}\n\n", chartName))
 
@@ -221,8 +271,111 @@ func GenerateNOTES(chartName string, services []string, ctx NOTESContext) string
 	return sb.String()
 }
 
+// writeNOTESServices writes the access instructions of the Services: the
+// external address of LoadBalancer Services, the node address and port of
+// NodePort Services and a port-forward command for the others.
+func writeNOTESServices(sb *strings.Builder, services []NOTESService) {
+	if len(services) == 0 {
+		return
+	}
+	sb.WriteString("Access the services:\n\n")
+	for _, svc := range services {
+		writeNOTESCondition(sb, svc.Condition)
+		switch svc.Type {
+		case "LoadBalancer":
+			sb.WriteString(fmt.Sprintf("  %s (LoadBalancer, the external IP may take a few minutes to appear):\n\n", svc.Service))
+			sb.WriteString(fmt.Sprintf("    export SERVICE_IP=$(kubectl get svc %s -n %s -o jsonpath='{.status.loadBalancer.ingress[0].ip}')\n", svc.Name, svc.Namespace))
+			sb.WriteString(fmt.Sprintf("    echo http://$SERVICE_IP:%d\n\n", svc.Port))
+		case "NodePort":
+			sb.WriteString(fmt.Sprintf("  %s (NodePort):\n\n", svc.Service))
+			sb.WriteString(fmt.Sprintf("    export NODE_PORT=$(kubectl get svc %s -n %s -o jsonpath='{.spec.ports[0].nodePort}')\n", svc.Name, svc.Namespace))
+			sb.WriteString("    export NODE_IP=$(kubectl get nodes -o jsonpath='{.items[0].status.addresses[0].address}')\n")
+			sb.WriteString("    echo http://$NODE_IP:$NODE_PORT\n\n")
+		default:
+			// Local ports below 1024 need root.
+			localPort := svc.Port
+			if localPort < 1024 {
+				localPort = 8080
+			}
+			sb.WriteString(fmt.Sprintf("  %s:\n\n", svc.Service))
+			sb.WriteString(fmt.Sprintf("    kubectl port-forward svc/%s %d:%d -n %s\n", svc.Name, localPort, svc.Port, svc.Namespace))
+			sb.WriteString(fmt.Sprintf("    echo http://127.0.0.1:%d\n\n", localPort))
+		}
+		writeNOTESEnd(sb, svc.Condition)
+	}
+}
+
+// writeNOTESIngresses writes the URLs the Ingresses serve.
+func writeNOTESIngresses(sb *strings.Builder, ingresses []NOTESIngress) {
+	if len(ingresses) == 0 {
+		return
+	}
+	sb.WriteString("Application URLs:\n\n")
+	for _, ing := range ingresses {
+		writeNOTESCondition(sb, ing.Condition)
+		if len(ing.URLs) == 0 {
+			sb.WriteString(fmt.Sprintf("  kubectl get ingress %s -n %s\n", ing.Name, ing.Namespace))
+		}
+		for _, url := range ing.URLs {
+			sb.WriteString(fmt.Sprintf("  %s\n", url))
+		}
+		writeNOTESEnd(sb, ing.Condition)
+	}
+	sb.WriteString("\n")
+}
+
+// writeNOTESSecrets writes the commands that read the keys of the Secrets.
+func writeNOTESSecrets(sb *strings.Builder, secrets []NOTESSecret) {
+	if len(secrets) == 0 {
+		return
+	}
+	sb.WriteString("Retrieve credentials:\n\n")
+	for _, secret := range secrets {
+		writeNOTESCondition(sb, secret.Condition)
+		for _, key := range secret.Keys {
+			sb.WriteString(fmt.Sprintf("  kubectl get secret %s -n %s -o jsonpath='{.data.%s}' | base64 -d\n",
+				secret.Name, secret.Namespace, strings.ReplaceAll(key, ".", `\.`)))
+		}
+		writeNOTESEnd(sb, secret.Condition)
+	}
+	sb.WriteString("\n")
+}
+
+// writeNOTESCondition opens the if block of a conditional NOTES section, at
+// the start of its first line.
+func writeNOTESCondition(sb *strings.Builder, condition string) {
+	if condition != "" {
+		sb.WriteString(fmt.Sprintf("{{ if %s }}", condition))
+	}
+}
+
+// writeNOTESEnd closes the block opened by writeNOTESCondition, at the start
+// of the line after the section.
+func writeNOTESEnd(sb *strings.Builder, condition string) {
+	if condition != "" {
+		sb.WriteString("{{ end }}")
+	}
+}
+
 // GenerateREADME generates a basic README.md for the chart.
 func GenerateREADME(meta ChartMetadata, services []string) string {
+	var params strings.Builder
+	params.WriteString("## Configuration\n\n")
+	params.WriteString("The following table lists the configurable parameters:\n\n")
+	params.WriteString("| Parameter | Description | Default |\n")
+	params.WriteString("|-----------|-------------|----------|\n")
+	params.WriteString("| `global.imageRegistry` | Global Docker image registry | `\"\"` |\n")
+	params.WriteString("| `global.imagePullSecrets` | Global image pull secrets | `[]` |\n\n")
+	return generateREADME(meta, services, params.String())
+}
+
+// GenerateValuesREADME generates README.md with a helm-docs compatible table
+// of the values documented in docs (see GenerateValuesTable).
+func GenerateValuesREADME(meta ChartMetadata, services []string, values map[string]interface{}, docs map[string]string) string {
+	return generateREADME(meta, services, "## Values\n\n"+GenerateValuesTable(values, docs)+"\n")
+}
+
+func generateREADME(meta ChartMetadata, services []string, configuration string) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("# %s\n\n", meta.Name))
@@ -238,12 +391,7 @@ func GenerateREADME(meta ChartMetadata, services []string) string {
 	sb.WriteString(fmt.Sprintf("helm install my-release ./%s\n", meta.Name))
 	sb.WriteString("```\n\n")
 
-	sb.WriteString("## Configuration\n\n")
-	sb.WriteString("The following table lists the configurable parameters:\n\n")
-	sb.WriteString("| Parameter | Description | Default |\n")
-	sb.WriteString("|-----------|-------------|----------|\n")
-	sb.WriteString("| `global.imageRegistry` | Global Docker image registry | `\"\"` |\n")
-	sb.WriteString("| `global.imagePullSecrets` | Global image pull secrets | `[]` |\n\n")
+	sb.WriteString(configuration)
 
 	if len(services) > 0 {
 		sb.WriteString("## Services\n\n")
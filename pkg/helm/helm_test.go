@@ -53,7 +53,7 @@ func TestGenerateChartYAML_Full(t *testing.T) {
 		"version: 2.0.0",
 		"appVersion: 3.0.0",
 		"home: https://example.com",
-		"kubeVersion: >=1.22",
+		`kubeVersion: ">=1.22"`,
 		"icon: https://example.com/icon.png",
 		"- k8s",
 		"- name: Dev",
@@ -146,6 +146,33 @@ func TestGenerateNOTES_WithAuth(t *testing.T) {
 	}
 }
 
+func TestGenerateNOTES_Resources(t *testing.T) {
+	out := GenerateNOTES("myapp", []string{"web"}, NOTESContext{
+		Services: []NOTESService{
+			{Service: "web", Name: "web-svc", Namespace: "prod", Type: "NodePort", Port: 80, Condition: ".Values.web.enabled"},
+			{Service: "api", Name: "api-svc", Namespace: "prod", Type: "ClusterIP", Port: 9090},
+		},
+		Ingresses: []NOTESIngress{{Name: "web", Namespace: "prod"}},
+		Secrets:   []NOTESSecret{{Name: "db", Namespace: "prod", Keys: []string{"tls.crt"}}},
+	})
+	for _, want := range []string{
+		"{{ if .Values.web.enabled }}  web (NodePort):",
+		"kubectl get svc web-svc -n prod -o jsonpath='{.spec.ports[0].nodePort}'",
+		"echo http://$NODE_IP:$NODE_PORT\n\n{{ end }}",
+		"kubectl port-forward svc/api-svc 9090:9090 -n prod",
+		"kubectl get ingress web -n prod",
+		`jsonpath='{.data.tls\.crt}' | base64 -d`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in NOTES:\n%s", want, out)
+		}
+	}
+	// The generic sections only follow ServiceTypes, HasIngress and HasAuth.
+	if strings.Contains(out, "svc/myapp") {
+		t.Error("NOTES should not suggest a port-forward to the chart name")
+	}
+}
+
 func TestGenerateREADME(t *testing.T) {
 	out := GenerateREADME(ChartMetadata{Name: "myapp"}, []string{"web"})
 	for _, want := range []string{"# myapp", "helm install", "web", "helm uninstall"} {
@@ -416,4 +443,9 @@ func TestGenerateValuesSchema(t *testing.T) {
 	if !strings.Contains(out, "imageRegistry") {
 		t.Error("schema should contain global properties")
 	}
+	for _, want := range []string{"- NodePort", "minimum: 30000", "maximum: 32767"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("schema should contain %q", want)
+		}
+	}
 }
@@ -45,6 +45,16 @@ func GenerateHelpers(chartName string) string {
 	sb.WriteString("Common labels\n")
 	sb.WriteString("*/}}\n")
 	sb.WriteString(fmt.Sprintf("{{- define \"%s.labels\" -}}\n", chartName))
+	sb.WriteString(fmt.Sprintf("{{ include \"%s.chartLabels\" . }}\n", chartName))
+	sb.WriteString("{{- with .Values.commonLabels }}\n")
+	sb.WriteString("{{ toYaml . }}\n")
+	sb.WriteString("{{- end }}\n")
+	sb.WriteString("{{- end }}\n\n")
+
+	sb.WriteString("{{/*\n")
+	sb.WriteString("Chart labels, without commonLabels\n")
+	sb.WriteString("*/}}\n")
+	sb.WriteString(fmt.Sprintf("{{- define \"%s.chartLabels\" -}}\n", chartName))
 	sb.WriteString(fmt.Sprintf("helm.sh/chart: {{ include \"%s.chart\" . }}\n", chartName))
 	sb.WriteString(fmt.Sprintf("{{ include \"%s.selectorLabels\" . }}\n", chartName))
 	sb.WriteString("{{- if .Chart.AppVersion }}\n")
@@ -86,16 +96,16 @@ func GenerateHelpers(chartName string) string {
 
 	sb.WriteString("{{/*\n")
 	sb.WriteString("Image name helper\n")
-	sb.WriteString("Combines repository, registry, and tag\n")
+	sb.WriteString("Combines repository, registry, and tag; a digest, when set, wins over the tag\n")
 	sb.WriteString("*/}}\n")
 	sb.WriteString(fmt.Sprintf("{{- define \"%s.image\" -}}\n", chartName))
 	sb.WriteString("{{- $registry := .registry | default .global.imageRegistry -}}\n")
 	sb.WriteString("{{- $repository := .repository | required \"image repository is required\" -}}\n")
-	sb.WriteString("{{- $tag := .tag | default .global.imageTag | default \"latest\" -}}\n")
-	sb.WriteString("{{- if $registry }}\n")
-	sb.WriteString("{{- printf \"%s/%s:%s\" $registry $repository $tag -}}\n")
+	sb.WriteString("{{- if $registry }}{{ $repository = printf \"%s/%s\" $registry $repository }}{{ end -}}\n")
+	sb.WriteString("{{- if .digest }}\n")
+	sb.WriteString("{{- printf \"%s@%s\" $repository .digest -}}\n")
 	sb.WriteString("{{- else }}\n")
-	sb.WriteString("{{- printf \"%s:%s\" $repository $tag -}}\n")
+	sb.WriteString("{{- printf \"%s:%s\" $repository (.tag | default .global.imageTag | default \"latest\") -}}\n")
 	sb.WriteString("{{- end }}\n")
 	sb.WriteString("{{- end }}\n\n")
 
@@ -162,6 +172,9 @@ func GenerateHelmIgnore() string {
 README.md.gotmpl
 docs/
 examples/
+# dhg upgrade state and rejected merge hunks
+.dhg/
+*.rej
 `
 }
 
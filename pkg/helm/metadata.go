@@ -0,0 +1,130 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// chartMetadataFile is the on-disk form of the Chart.yaml fields that can be
+// supplied with --chart-metadata. Field names follow Chart.yaml.
+type chartMetadataFile struct {
+	Description string            `json:"description,omitempty"`
+	Keywords    []string          `json:"keywords,omitempty"`
+	Home        string            `json:"home,omitempty"`
+	Sources     []string          `json:"sources,omitempty"`
+	Maintainers []Maintainer      `json:"maintainers,omitempty"`
+	Icon        string            `json:"icon,omitempty"`
+	KubeVersion string            `json:"kubeVersion,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ReadChartMetadata reads Chart.yaml metadata (description, keywords, home,
+// sources, maintainers, icon, kubeVersion, annotations) from a YAML file.
+// Other Chart.yaml fields are rejected, since name and versions come from
+// their own flags.
+func ReadChartMetadata(path string) (ChartMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ChartMetadata{}, fmt.Errorf("cannot read chart metadata file: %w", err)
+	}
+	var f chartMetadataFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return ChartMetadata{}, fmt.Errorf("cannot parse chart metadata file %s: %w", path, err)
+	}
+	for i, m := range f.Maintainers {
+		if m.Name == "" {
+			return ChartMetadata{}, fmt.Errorf("%s: maintainers[%d]: name is required", path, i)
+		}
+	}
+	return ChartMetadata{
+		Description: f.Description,
+		Keywords:    f.Keywords,
+		Home:        f.Home,
+		Sources:     f.Sources,
+		Maintainers: f.Maintainers,
+		Icon:        f.Icon,
+		KubeVersion: f.KubeVersion,
+		Annotations: f.Annotations,
+	}, nil
+}
+
+// ParseMaintainer parses a maintainer in the form
+// "Name <email> (url)"; email and url are optional.
+func ParseMaintainer(s string) (Maintainer, error) {
+	var m Maintainer
+	rest := strings.TrimSpace(s)
+
+	if strings.HasSuffix(rest, ")") {
+		i := strings.LastIndex(rest, "(")
+		if i < 0 {
+			return m, fmt.Errorf("invalid maintainer %q: unbalanced parentheses", s)
+		}
+		m.URL = strings.TrimSpace(rest[i+1 : len(rest)-1])
+		rest = strings.TrimSpace(rest[:i])
+	}
+	if strings.HasSuffix(rest, ">") {
+		i := strings.LastIndex(rest, "<")
+		if i < 0 {
+			return m, fmt.Errorf("invalid maintainer %q: unbalanced angle brackets", s)
+		}
+		m.Email = strings.TrimSpace(rest[i+1 : len(rest)-1])
+		rest = strings.TrimSpace(rest[:i])
+	}
+
+	m.Name = rest
+	if m.Name == "" {
+		return m, fmt.Errorf("invalid maintainer %q: name is required (format \"Name <email> (url)\")", s)
+	}
+	return m, nil
+}
+
+// MergeChartMetadata returns base with the user-supplied fields of extra
+// applied: scalar fields and lists replace those of base when set, except
+// keywords, which are appended without duplicates, and annotations, which
+// are merged key by key.
+func MergeChartMetadata(base, extra ChartMetadata) ChartMetadata {
+	out := base
+	if extra.Description != "" {
+		out.Description = extra.Description
+	}
+	if len(extra.Keywords) > 0 {
+		seen := make(map[string]bool, len(base.Keywords))
+		keywords := make([]string, 0, len(base.Keywords)+len(extra.Keywords))
+		for _, kw := range append(append([]string{}, base.Keywords...), extra.Keywords...) {
+			if !seen[kw] {
+				seen[kw] = true
+				keywords = append(keywords, kw)
+			}
+		}
+		out.Keywords = keywords
+	}
+	if extra.Home != "" {
+		out.Home = extra.Home
+	}
+	if len(extra.Sources) > 0 {
+		out.Sources = extra.Sources
+	}
+	if len(extra.Maintainers) > 0 {
+		out.Maintainers = extra.Maintainers
+	}
+	if extra.Icon != "" {
+		out.Icon = extra.Icon
+	}
+	if extra.KubeVersion != "" {
+		out.KubeVersion = extra.KubeVersion
+	}
+	if len(extra.Annotations) > 0 {
+		annotations := make(map[string]string, len(base.Annotations)+len(extra.Annotations))
+		for k, v := range base.Annotations {
+			annotations[k] = v
+		}
+		for k, v := range extra.Annotations {
+			annotations[k] = v
+		}
+		out.Annotations = annotations
+	}
+	return out
+}
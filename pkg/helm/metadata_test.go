@@ -0,0 +1,118 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestParseMaintainer(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Maintainer
+		wantErr bool
+	}{
+		{in: "Jane Doe", want: Maintainer{Name: "Jane Doe"}},
+		{in: "Jane Doe <jane@example.com>", want: Maintainer{Name: "Jane Doe", Email: "jane@example.com"}},
+		{in: "Jane <jane@example.com> (https://jane.dev)", want: Maintainer{Name: "Jane", Email: "jane@example.com", URL: "https://jane.dev"}},
+		{in: "Platform team (https://example.com/team)", want: Maintainer{Name: "Platform team", URL: "https://example.com/team"}},
+		{in: "<jane@example.com>", wantErr: true},
+		{in: "Jane jane@example.com>", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMaintainer(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMaintainer(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseMaintainer(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMergeChartMetadata(t *testing.T) {
+	base := ChartMetadata{
+		Name:        "app",
+		Keywords:    []string{"kubernetes", "deckhouse"},
+		Home:        "https://old.example.com",
+		Annotations: map[string]string{"a": "1"},
+	}
+	got := MergeChartMetadata(base, ChartMetadata{
+		Keywords:    []string{"deckhouse", "web"},
+		Home:        "https://example.com",
+		Annotations: map[string]string{"b": "2"},
+	})
+
+	if got.Name != "app" {
+		t.Errorf("Name = %q, want app", got.Name)
+	}
+	if want := []string{"kubernetes", "deckhouse", "web"}; !reflect.DeepEqual(got.Keywords, want) {
+		t.Errorf("Keywords = %v, want %v", got.Keywords, want)
+	}
+	if got.Home != "https://example.com" {
+		t.Errorf("Home = %q", got.Home)
+	}
+	if want := map[string]string{"a": "1", "b": "2"}; !reflect.DeepEqual(got.Annotations, want) {
+		t.Errorf("Annotations = %v, want %v", got.Annotations, want)
+	}
+	if len(base.Keywords) != 2 || len(base.Annotations) != 1 {
+		t.Errorf("base was modified: %+v", base)
+	}
+}
+
+func TestReadChartMetadata(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chart-metadata.yaml")
+	content := `home: https://example.com
+sources:
+  - https://github.com/example/app
+maintainers:
+  - name: Platform team
+    email: platform@example.com
+kubeVersion: ">=1.25.0-0"
+annotations:
+  artifacthub.io/license: Apache-2.0
+  artifacthub.io/links: |
+    - name: Documentation
+      url: https://example.com/docs
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := ReadChartMetadata(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Home != "https://example.com" || meta.KubeVersion != ">=1.25.0-0" {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if len(meta.Maintainers) != 1 || meta.Maintainers[0].Email != "platform@example.com" {
+		t.Errorf("unexpected maintainers: %+v", meta.Maintainers)
+	}
+
+	// The generated Chart.yaml must round-trip, including multi-line annotations.
+	meta.Name = "app"
+	var chart map[string]interface{}
+	if err := yaml.Unmarshal([]byte(GenerateChartYAML(meta)), &chart); err != nil {
+		t.Fatalf("generated Chart.yaml is not valid YAML: %v", err)
+	}
+	if chart["kubeVersion"] != ">=1.25.0-0" {
+		t.Errorf("kubeVersion = %v", chart["kubeVersion"])
+	}
+	annotations, _ := chart["annotations"].(map[string]interface{})
+	if annotations["artifacthub.io/links"] != "- name: Documentation\n  url: https://example.com/docs\n" {
+		t.Errorf("links annotation = %q", annotations["artifacthub.io/links"])
+	}
+
+	if err := os.WriteFile(path, []byte("name: other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadChartMetadata(path); err == nil {
+		t.Error("expected an error for a field that is not Chart.yaml metadata")
+	}
+}
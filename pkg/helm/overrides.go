@@ -0,0 +1,218 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ValueOverrides builds the values to merge over generated values from
+// values files, merged in order, and path=value assignments, which take
+// precedence over the files, in the syntax of helm --set and --set-string:
+//
+//	services.web.deployment.replicas=2,services.web.enabled=false
+//	services.web.args={--verbose,--port=8080}
+//
+// As with helm, sets values are typed: true, false and null, and integers
+// without a leading zero; anything else, including 1.5 and 01, is a string.
+// setStrings values are always strings. Both are applied in order, setStrings
+// after sets.
+func ValueOverrides(files, sets, setStrings []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read values file: %w", err)
+		}
+		var fileValues map[string]interface{}
+		if err := yaml.Unmarshal(data, &fileValues); err != nil {
+			return nil, fmt.Errorf("cannot parse values file %s: %w", file, err)
+		}
+		values = mergeMaps(values, fileValues)
+	}
+	apply := func(assignments []string, asString bool) error {
+		for _, s := range assignments {
+			parsed, err := ParseSetValues(s, asString)
+			if err != nil {
+				return err
+			}
+			for _, v := range parsed {
+				if err := setPath(values, v.Path, v.Value); err != nil {
+					return fmt.Errorf("invalid value %q: %w", s, err)
+				}
+			}
+		}
+		return nil
+	}
+	if err := apply(sets, false); err != nil {
+		return nil, err
+	}
+	if err := apply(setStrings, true); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// SetValue is one path=value assignment of a --set argument.
+type SetValue struct {
+	// Path holds the keys of the path; an escaped dot (\.) is part of a key.
+	Path  []string
+	Value interface{}
+}
+
+// ParseSetValues parses the comma-separated path=value assignments of a
+// --set argument, such as a.b=1,c={x,y}. A backslash escapes the next
+// character, so a\.b=1 sets the key "a.b" and a=x\,y sets "x,y". With
+// asString, as for --set-string, values are not typed. List indexes
+// (a[0]=x) are not supported.
+func ParseSetValues(s string, asString bool) ([]SetValue, error) {
+	runes := []rune(s)
+	var values []SetValue
+	for i := 0; i < len(runes); {
+		path, next, err := parseSetKey(runes, i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", s, err)
+		}
+		i = next
+		var value interface{}
+		if i < len(runes) && runes[i] == '{' {
+			value, i, err = parseSetList(runes, i+1, asString)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q: %w", s, err)
+			}
+		} else {
+			var raw string
+			raw, i = scanSetValue(runes, i, ",")
+			value = typedSetValue(raw, asString)
+		}
+		values = append(values, SetValue{Path: path, Value: value})
+		if i < len(runes) {
+			// Skip the comma ending the assignment.
+			i++
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("invalid value %q (must be path=value)", s)
+	}
+	return values, nil
+}
+
+// parseSetKey reads the dot-separated keys of an assignment starting at i
+// and returns them with the index after the "=".
+func parseSetKey(runes []rune, i int) ([]string, int, error) {
+	var path []string
+	var key []rune
+	for ; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				key = append(key, runes[i])
+			}
+		case '.', '=':
+			if len(key) == 0 {
+				return nil, 0, fmt.Errorf("empty key in path %s", string(runes[:i]))
+			}
+			path = append(path, string(key))
+			key = nil
+			if r == '=' {
+				return path, i + 1, nil
+			}
+		case ',':
+			return nil, 0, fmt.Errorf("%s has no value (must be path=value)", string(key))
+		case '[':
+			return nil, 0, fmt.Errorf("list indexes are not supported in %s; set the whole list with {a,b}", string(key))
+		default:
+			key = append(key, r)
+		}
+	}
+	return nil, 0, fmt.Errorf("%s has no value (must be path=value)", string(key))
+}
+
+// parseSetList reads the items of a {a,b} list starting after the "{" and
+// returns them with the index after the "}".
+func parseSetList(runes []rune, i int, asString bool) ([]interface{}, int, error) {
+	list := []interface{}{}
+	for {
+		raw, next := scanSetValue(runes, i, ",}")
+		if next >= len(runes) {
+			return nil, 0, fmt.Errorf("list is not closed with }")
+		}
+		if raw != "" || runes[next] == ',' || len(list) > 0 {
+			list = append(list, typedSetValue(raw, asString))
+		}
+		i = next + 1
+		if runes[next] == '}' {
+			if i < len(runes) && runes[i] != ',' {
+				return nil, 0, fmt.Errorf("unexpected %q after }", runes[i])
+			}
+			return list, i, nil
+		}
+	}
+}
+
+// scanSetValue reads an unescaped value starting at i up to one of the
+// stop characters or the end of runes, and returns it with the index of the
+// stop character.
+func scanSetValue(runes []rune, i int, stop string) (string, int) {
+	var value []rune
+	for ; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' && i+1 < len(runes) {
+			i++
+			value = append(value, runes[i])
+			continue
+		}
+		if strings.ContainsRune(stop, r) {
+			break
+		}
+		value = append(value, r)
+	}
+	return string(value), i
+}
+
+// typedSetValue types a --set value the way helm does.
+func typedSetValue(s string, asString bool) interface{} {
+	switch {
+	case asString:
+		return s
+	case strings.EqualFold(s, "true"):
+		return true
+	case strings.EqualFold(s, "false"):
+		return false
+	case strings.EqualFold(s, "null"):
+		return nil
+	case s == "0":
+		return int64(0)
+	case s != "" && s[0] != '0':
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	}
+	return s
+}
+
+// setPath sets the value at a path of values, creating the intermediate
+// maps.
+func setPath(values map[string]interface{}, parts []string, value interface{}) error {
+	current := values
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := current[part]
+		if !ok || next == nil {
+			m := make(map[string]interface{})
+			current[part] = m
+			current = m
+			continue
+		}
+		m, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not a map", strings.Join(parts[:i+1], "."))
+		}
+		current = m
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
@@ -0,0 +1,91 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSetValues(t *testing.T) {
+	tests := []struct {
+		in       string
+		asString bool
+		want     []SetValue
+		wantErr  bool
+	}{
+		{in: "services.web.deployment.replicas=2", want: []SetValue{{Path: []string{"services", "web", "deployment", "replicas"}, Value: int64(2)}}},
+		{in: "services.web.enabled=false", want: []SetValue{{Path: []string{"services", "web", "enabled"}, Value: false}}},
+		{in: "image.tag=1.25.3", want: []SetValue{{Path: []string{"image", "tag"}, Value: "1.25.3"}}},
+		{in: "ratio=1.5", want: []SetValue{{Path: []string{"ratio"}, Value: "1.5"}}},
+		{in: "code=01", want: []SetValue{{Path: []string{"code"}, Value: "01"}}},
+		{in: "code=0", want: []SetValue{{Path: []string{"code"}, Value: int64(0)}}},
+		{in: "port=8080", asString: true, want: []SetValue{{Path: []string{"port"}, Value: "8080"}}},
+		{in: "annotation=null", want: []SetValue{{Path: []string{"annotation"}, Value: nil}}},
+		{in: "args={a,b}", want: []SetValue{{Path: []string{"args"}, Value: []interface{}{"a", "b"}}}},
+		{in: "ports={80,443},name=web", want: []SetValue{
+			{Path: []string{"ports"}, Value: []interface{}{int64(80), int64(443)}},
+			{Path: []string{"name"}, Value: "web"},
+		}},
+		{in: "args={}", want: []SetValue{{Path: []string{"args"}, Value: []interface{}{}}}},
+		{in: "a=1,b=x", want: []SetValue{{Path: []string{"a"}, Value: int64(1)}, {Path: []string{"b"}, Value: "x"}}},
+		{in: `list=a\,b`, want: []SetValue{{Path: []string{"list"}, Value: "a,b"}}},
+		{in: `annotations.nginx\.ingress\.kubernetes\.io/rewrite-target=/`, want: []SetValue{{Path: []string{"annotations", "nginx.ingress.kubernetes.io/rewrite-target"}, Value: "/"}}},
+		{in: "url=http://x=y", want: []SetValue{{Path: []string{"url"}, Value: "http://x=y"}}},
+		{in: "name=", want: []SetValue{{Path: []string{"name"}, Value: ""}}},
+		{in: "replicas", wantErr: true},
+		{in: "a=1,replicas", wantErr: true},
+		{in: "=1", wantErr: true},
+		{in: "services..replicas=1", wantErr: true},
+		{in: "args={a,b", wantErr: true},
+		{in: "args={a}b", wantErr: true},
+		{in: "ports[0]=80", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSetValues(tt.in, tt.asString)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSetValues(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSetValues(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValueOverrides(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	if err := os.WriteFile(base, []byte("services:\n  web:\n    deployment:\n      replicas: 3\n    enabled: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(prod, []byte("services:\n  web:\n    deployment:\n      replicas: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ValueOverrides([]string{base, prod}, []string{"services.web.deployment.replicas=1", "services.api.enabled=false"}, []string{"services.api.version=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{
+				"deployment": map[string]interface{}{"replicas": int64(1)},
+				"enabled":    true,
+			},
+			"api": map[string]interface{}{"enabled": false, "version": "2"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValueOverrides() = %v, want %v", got, want)
+	}
+
+	if _, err := ValueOverrides(nil, []string{"a=1", "a.b=2"}, nil); err == nil {
+		t.Error("expected an error setting a key under a scalar")
+	}
+	if _, err := ValueOverrides([]string{filepath.Join(dir, "missing.yaml")}, nil, nil); err == nil {
+		t.Error("expected an error for a missing values file")
+	}
+}
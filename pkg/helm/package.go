@@ -0,0 +1,165 @@
+package helm
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// packageModTime is the modification time of every packaged file, so that
+// packaging the same chart twice yields the same digest.
+var packageModTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// PackageChart archives the chart in chartDir the way "helm package" does:
+// a gzipped tarball <name>-<version>.tgz in destDir with the files under a
+// <name>/ directory, leaving out the paths matched by .helmignore. Negated
+// .helmignore patterns are not supported. It returns the path of the archive.
+func PackageChart(chartDir, destDir string) (string, error) {
+	meta, err := readYAMLMap(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		return "", fmt.Errorf("package: %w", err)
+	}
+	name, _ := meta["name"].(string)
+	version, _ := meta["version"].(string)
+	if name == "" || version == "" {
+		return "", fmt.Errorf("package: Chart.yaml: name and version are required")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteChartArchive(&buf, chartDir); err != nil {
+		return "", fmt.Errorf("package: %w", err)
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("package: %w", err)
+	}
+	archive := filepath.Join(destDir, fmt.Sprintf("%s-%s.tgz", name, version))
+	if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("package: %w", err)
+	}
+	return archive, nil
+}
+
+// WriteChartArchive writes the charts in chartDirs to w as one gzipped
+// tarball with the files of each chart under a <name>/ directory, leaving out
+// the paths matched by its .helmignore. An archive of a single chart is the
+// one PackageChart writes.
+func WriteChartArchive(w io.Writer, chartDirs ...string) error {
+	gz := gzip.NewWriter(w)
+	gz.ModTime = packageModTime
+	tw := tar.NewWriter(gz)
+	for _, chartDir := range chartDirs {
+		if err := archiveChart(tw, chartDir); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// archiveChart adds the files of the chart in chartDir to tw.
+func archiveChart(tw *tar.Writer, chartDir string) error {
+	meta, err := readYAMLMap(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		return err
+	}
+	name, _ := meta["name"].(string)
+	if name == "" {
+		return fmt.Errorf("Chart.yaml: name is required")
+	}
+
+	ignore, err := readHelmIgnore(filepath.Join(chartDir, ".helmignore"))
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(chartDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(chartDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if helmIgnored(ignore, rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    path.Join(name, rel),
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: packageModTime,
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+}
+
+// readHelmIgnore returns the patterns of a .helmignore file, or none when
+// the file does not exist.
+func readHelmIgnore(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// helmIgnored reports whether the slash-separated path rel matches one of
+// the .helmignore patterns: "dir/" matches directories only, patterns with a
+// slash match the whole relative path and others match the base name.
+func helmIgnored(patterns []string, rel string, isDir bool) bool {
+	for _, p := range patterns {
+		target := path.Base(rel)
+		if strings.HasSuffix(p, "/") {
+			if !isDir {
+				continue
+			}
+			p = strings.TrimSuffix(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			target = rel
+			p = strings.TrimPrefix(p, "/")
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
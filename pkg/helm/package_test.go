@@ -0,0 +1,105 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func archiveEntries(t *testing.T, archive string) []string {
+	t.Helper()
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPackageChart(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":                     "apiVersion: v2\nname: app\nversion: 1.2.3\n",
+		"values.yaml":                    "replicas: 1\n",
+		"templates/web-deployment.yaml":  "kind: Deployment\n",
+		"templates/web-service.yaml.rej": "conflict\n",
+		".helmignore":                    "# dhg state\n.dhg/\n*.rej\n",
+		".dhg/base/values.yaml":          "replicas: 1\n",
+	})
+	dest := t.TempDir()
+
+	archive, err := PackageChart(dir, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive != filepath.Join(dest, "app-1.2.3.tgz") {
+		t.Errorf("archive = %s; want app-1.2.3.tgz in %s", archive, dest)
+	}
+	want := "app/.helmignore,app/Chart.yaml,app/templates/web-deployment.yaml,app/values.yaml"
+	if got := strings.Join(archiveEntries(t, archive), ","); got != want {
+		t.Errorf("entries = %s; want %s", got, want)
+	}
+
+	first, _ := os.ReadFile(archive)
+	if _, err := PackageChart(dir, dest); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(archive)
+	if !bytes.Equal(first, second) {
+		t.Error("packaging the same chart twice should produce the same archive")
+	}
+}
+
+func TestPackageChart_MissingVersion(t *testing.T) {
+	dir := writeChart(t, map[string]string{"Chart.yaml": "apiVersion: v2\nname: app\n"})
+	if _, err := PackageChart(dir, t.TempDir()); err == nil {
+		t.Error("expected an error for a Chart.yaml without version")
+	}
+}
+
+func TestWriteChartArchive(t *testing.T) {
+	app := writeChart(t, map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"values.yaml": "replicas: 1\n",
+	})
+	db := writeChart(t, map[string]string{
+		"Chart.yaml":         "apiVersion: v2\nname: db\nversion: 0.1.0\n",
+		"templates/sts.yaml": "kind: StatefulSet\n",
+	})
+	archive := filepath.Join(t.TempDir(), "charts.tgz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteChartArchive(f, app, db); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	want := "app/Chart.yaml,app/values.yaml,db/Chart.yaml,db/templates/sts.yaml"
+	if got := strings.Join(archiveEntries(t, archive), ","); got != want {
+		t.Errorf("entries = %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,839 @@
+package helm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultKubeVersion is the Kubernetes version reported through
+// .Capabilities when RenderOptions.KubeVersion is empty.
+const DefaultKubeVersion = "v1.30.0"
+
+// DefaultAPIVersions are the built-in group/versions served by
+// DefaultKubeVersion, reported by .Capabilities.APIVersions.Has like the
+// default version set of "helm template".
+var DefaultAPIVersions = []string{
+	"v1",
+	"admissionregistration.k8s.io/v1",
+	"apiextensions.k8s.io/v1",
+	"apiregistration.k8s.io/v1",
+	"apps/v1",
+	"authentication.k8s.io/v1",
+	"authorization.k8s.io/v1",
+	"autoscaling/v1",
+	"autoscaling/v2",
+	"batch/v1",
+	"certificates.k8s.io/v1",
+	"coordination.k8s.io/v1",
+	"discovery.k8s.io/v1",
+	"events.k8s.io/v1",
+	"flowcontrol.apiserver.k8s.io/v1",
+	"flowcontrol.apiserver.k8s.io/v1beta3",
+	"networking.k8s.io/v1",
+	"node.k8s.io/v1",
+	"policy/v1",
+	"rbac.authorization.k8s.io/v1",
+	"scheduling.k8s.io/v1",
+	"storage.k8s.io/v1",
+}
+
+// RenderOptions configures RenderChart.
+type RenderOptions struct {
+	// ReleaseName is .Release.Name ("release" when empty).
+	ReleaseName string
+
+	// Namespace is .Release.Namespace ("default" when empty).
+	Namespace string
+
+	// KubeVersion is .Capabilities.KubeVersion (DefaultKubeVersion when empty).
+	KubeVersion string
+
+	// APIVersions are reported by .Capabilities.APIVersions.Has in addition
+	// to DefaultAPIVersions, e.g. the group/versions of installed CRDs.
+	APIVersions []string
+
+	// Values are merged over values.yaml.
+	Values map[string]interface{}
+}
+
+// RenderedChart is the output of RenderChart.
+type RenderedChart struct {
+	// Name is the chart name from Chart.yaml.
+	Name string
+
+	// Values are the values the chart was rendered with.
+	Values map[string]interface{}
+
+	// Manifests maps template paths relative to the chart directory
+	// (e.g. "templates/deployment.yaml") to rendered content. Partials
+	// (_*.tpl) and NOTES.txt are not included.
+	Manifests map[string]string
+
+	// Notes is the rendered templates/NOTES.txt, as printed by helm install.
+	Notes string
+}
+
+// RenderChart renders the templates of the chart in chartDir the way
+// "helm template" does, with a subset of the Helm and Sprig template
+// functions that covers the charts dhg generates. Subcharts are not rendered.
+func RenderChart(chartDir string, opts RenderOptions) (*RenderedChart, error) {
+	chartMeta, err := readYAMLMap(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	name, _ := chartMeta["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("Chart.yaml: name is required")
+	}
+
+	values, err := readYAMLMap(filepath.Join(chartDir, "values.yaml"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	values = MergeValues(values, opts.Values)
+
+	sources, err := ReadTemplates(chartDir)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := ParseTemplates(name, sources)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(sources))
+	for p := range sources {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	kubeVersion := opts.KubeVersion
+	if kubeVersion == "" {
+		kubeVersion = DefaultKubeVersion
+	}
+	if !strings.HasPrefix(kubeVersion, "v") {
+		kubeVersion = "v" + kubeVersion
+	}
+	major, minor, _ := strings.Cut(strings.TrimPrefix(kubeVersion, "v"), ".")
+	minor, _, _ = strings.Cut(minor, ".")
+
+	releaseName := opts.ReleaseName
+	if releaseName == "" {
+		releaseName = "release"
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	apiVersions := append(APIVersionSet{}, DefaultAPIVersions...)
+	apiVersions = append(apiVersions, opts.APIVersions...)
+
+	rendered := &RenderedChart{Name: name, Values: values, Manifests: make(map[string]string)}
+	for _, p := range paths {
+		base := path.Base(p)
+		if strings.HasPrefix(base, "_") || (base == "NOTES.txt" && p != "templates/NOTES.txt") {
+			continue
+		}
+		data := map[string]interface{}{
+			"Values": values,
+			"Chart":  chartObject(chartMeta),
+			"Files":  chartFiles{dir: chartDir},
+			"Release": map[string]interface{}{
+				"Name":      releaseName,
+				"Namespace": namespace,
+				"Service":   "Helm",
+				"IsInstall": true,
+				"IsUpgrade": false,
+				"Revision":  1,
+			},
+			"Capabilities": map[string]interface{}{
+				"KubeVersion": map[string]interface{}{
+					"Version":    kubeVersion,
+					"GitVersion": kubeVersion,
+					"Major":      major,
+					"Minor":      minor,
+				},
+				"APIVersions": apiVersions,
+			},
+			"Template": map[string]interface{}{
+				"Name":     path.Join(name, p),
+				"BasePath": path.Join(name, "templates"),
+			},
+		}
+
+		var buf bytes.Buffer
+		if err := root.ExecuteTemplate(&buf, path.Join(name, p), data); err != nil {
+			return nil, fmt.Errorf("render %s: %w", p, err)
+		}
+		if base == "NOTES.txt" {
+			rendered.Notes = strings.ReplaceAll(buf.String(), "<no value>", "")
+			continue
+		}
+		rendered.Manifests[p] = strings.ReplaceAll(buf.String(), "<no value>", "")
+	}
+	return rendered, nil
+}
+
+// chartFiles is .Files: the files of the chart outside templates/.
+type chartFiles struct {
+	dir string
+}
+
+// Get returns the content of the chart file at name, or "" if there is none.
+func (f chartFiles) Get(name string) string {
+	return string(f.GetBytes(name))
+}
+
+// GetBytes returns the content of the chart file at name, or nil if there is
+// none.
+func (f chartFiles) GetBytes(name string) []byte {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" || name == "templates" || strings.HasPrefix(name, "templates/") {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(f.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Bundle returns the rendered manifests as one YAML stream, as printed by
+// "helm template": documents in template path order, each preceded by a
+// "# Source: <chart>/<path>" comment. Empty documents are left out.
+func (r *RenderedChart) Bundle() string {
+	paths := make([]string, 0, len(r.Manifests))
+	for p := range r.Manifests {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, p := range paths {
+		for _, doc := range splitManifest(r.Manifests[p]) {
+			fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", path.Join(r.Name, p), doc)
+		}
+	}
+	return b.String()
+}
+
+// splitManifest splits a rendered template on "---" lines into its
+// non-empty documents, without trailing newlines.
+func splitManifest(manifest string) []string {
+	var docs []string
+	var doc []string
+	flush := func() {
+		if text := strings.TrimRight(strings.Join(doc, "\n"), " \t\n"); strings.TrimSpace(text) != "" {
+			docs = append(docs, strings.TrimLeft(text, "\n"))
+		}
+		doc = nil
+	}
+	for _, line := range strings.Split(manifest, "\n") {
+		if strings.TrimSpace(line) == "---" {
+			flush()
+			continue
+		}
+		doc = append(doc, line)
+	}
+	flush()
+	return docs
+}
+
+// ParseTemplates parses chart template sources, keyed by paths relative to
+// the chart directory, into one template set. Each file is named
+// "<chart>/<path>" as in Helm, so ParseName identifies the source file.
+func ParseTemplates(chartName string, sources map[string]string) (*template.Template, error) {
+	root := template.New(chartName).Option("missingkey=zero")
+	root.Funcs(renderFuncs(root))
+
+	paths := make([]string, 0, len(sources))
+	for p := range sources {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if _, err := root.New(path.Join(chartName, p)).Parse(sources[p]); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", p, err)
+		}
+	}
+	return root, nil
+}
+
+// APIVersionSet implements .Capabilities.APIVersions.
+type APIVersionSet []string
+
+// Has reports whether the group/version or group/version/kind is available.
+func (s APIVersionSet) Has(apiVersion string) bool {
+	for _, v := range s {
+		if v == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeValues returns base with override merged over it; nested maps are
+// merged recursively. Neither input is modified.
+func MergeValues(base, override map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		if bm, ok := out[k].(map[string]interface{}); ok {
+			if om, ok := v.(map[string]interface{}); ok {
+				out[k] = MergeValues(bm, om)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// ReadValuesFiles reads and merges values files in order, later files
+// overriding earlier ones.
+func ReadValuesFiles(files ...string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read values file: %w", err)
+		}
+		var m map[string]interface{}
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("cannot parse values file %s: %w", f, err)
+		}
+		values = MergeValues(values, m)
+	}
+	return values, nil
+}
+
+func readYAMLMap(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+	}
+	return m, nil
+}
+
+// ReadTemplates returns the contents of templates/ keyed by slash paths
+// relative to the chart directory.
+func ReadTemplates(chartDir string) (map[string]string, error) {
+	sources := make(map[string]string)
+	templatesDir := filepath.Join(chartDir, "templates")
+	err := filepath.WalkDir(templatesDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(p) {
+		case ".yaml", ".yml", ".tpl", ".txt":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(chartDir, p)
+		if err != nil {
+			return err
+		}
+		sources[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// chartObject exposes Chart.yaml fields under their Helm names (.Chart.Name,
+// .Chart.AppVersion, ...).
+func chartObject(meta map[string]interface{}) map[string]interface{} {
+	obj := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		if k == "" {
+			continue
+		}
+		obj[strings.ToUpper(k[:1])+k[1:]] = v
+	}
+	if _, ok := obj["AppVersion"]; !ok {
+		obj["AppVersion"] = ""
+	}
+	return obj
+}
+
+// renderFuncs returns the template functions available to chart templates.
+func renderFuncs(root *template.Template) template.FuncMap {
+	includeDepth := 0
+	funcs := template.FuncMap{
+		"include": func(name string, data interface{}) (string, error) {
+			if includeDepth > 1000 {
+				return "", fmt.Errorf("include %q: too many nested includes", name)
+			}
+			includeDepth++
+			defer func() { includeDepth-- }()
+			var buf bytes.Buffer
+			if err := root.ExecuteTemplate(&buf, name, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+		"tpl": func(text string, data interface{}) (string, error) {
+			t, err := root.Clone()
+			if err != nil {
+				return "", err
+			}
+			t, err = t.New("tpl").Parse(text)
+			if err != nil {
+				return "", err
+			}
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return "", err
+			}
+			return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+		},
+		"required": func(msg string, v interface{}) (interface{}, error) {
+			if v == nil || (reflect.ValueOf(v).Kind() == reflect.String && v.(string) == "") {
+				return nil, errors.New(msg)
+			}
+			return v, nil
+		},
+		"fail":   func(msg string) (string, error) { return "", errors.New(msg) },
+		"lookup": func(...interface{}) map[string]interface{} { return map[string]interface{}{} },
+
+		"toYaml": func(v interface{}) string {
+			data, err := yaml.Marshal(v)
+			if err != nil {
+				return ""
+			}
+			return strings.TrimSuffix(string(data), "\n")
+		},
+		"fromYaml": func(s string) map[string]interface{} {
+			m := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+				m["Error"] = err.Error()
+			}
+			return m
+		},
+		"toJson": func(v interface{}) string {
+			data, _ := json.Marshal(v)
+			return string(data)
+		},
+		"fromJson": func(s string) map[string]interface{} {
+			m := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(s), &m); err != nil {
+				m["Error"] = err.Error()
+			}
+			return m
+		},
+
+		"default": func(d interface{}, given ...interface{}) interface{} {
+			if len(given) == 0 || isEmpty(given[0]) {
+				return d
+			}
+			return given[0]
+		},
+		"empty": isEmpty,
+		"coalesce": func(v ...interface{}) interface{} {
+			for _, x := range v {
+				if !isEmpty(x) {
+					return x
+				}
+			}
+			return nil
+		},
+		"ternary": func(t, f interface{}, cond bool) interface{} {
+			if cond {
+				return t
+			}
+			return f
+		},
+
+		"quote": func(v ...interface{}) string {
+			out := make([]string, 0, len(v))
+			for _, x := range v {
+				if x != nil {
+					out = append(out, strconv.Quote(toString(x)))
+				}
+			}
+			return strings.Join(out, " ")
+		},
+		"squote": func(v ...interface{}) string {
+			out := make([]string, 0, len(v))
+			for _, x := range v {
+				if x != nil {
+					out = append(out, "'"+toString(x)+"'")
+				}
+			}
+			return strings.Join(out, " ")
+		},
+		"indent":     indent,
+		"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+		"toString":   toString,
+		"trunc":      trunc,
+		"trim":       strings.TrimSpace,
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title": func(s string) string {
+			words := strings.Fields(s)
+			for i, w := range words {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+			return strings.Join(words, " ")
+		},
+		"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"join": func(sep string, v interface{}) string {
+			var out []string
+			for _, x := range toList(v) {
+				out = append(out, toString(x))
+			}
+			return strings.Join(out, sep)
+		},
+		"splitList": func(sep, s string) []interface{} {
+			parts := strings.Split(s, sep)
+			out := make([]interface{}, len(parts))
+			for i, p := range parts {
+				out[i] = p
+			}
+			return out
+		},
+		"regexMatch": func(re, s string) (bool, error) { return regexp.MatchString(re, s) },
+		"regexReplaceAll": func(re, s, repl string) (string, error) {
+			r, err := regexp.Compile(re)
+			if err != nil {
+				return "", err
+			}
+			return r.ReplaceAllString(s, repl), nil
+		},
+		"b64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec": func(s string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(s)
+			return string(data), err
+		},
+		"sha256sum": func(s string) string {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		},
+
+		"dict": func(kv ...interface{}) map[string]interface{} {
+			m := make(map[string]interface{}, len(kv)/2)
+			for i := 0; i+1 < len(kv); i += 2 {
+				m[toString(kv[i])] = kv[i+1]
+			}
+			return m
+		},
+		"list": func(v ...interface{}) []interface{} { return v },
+		"hasKey": func(m map[string]interface{}, key string) bool {
+			_, ok := m[key]
+			return ok
+		},
+		"get": func(m map[string]interface{}, key string) interface{} {
+			if v, ok := m[key]; ok {
+				return v
+			}
+			return ""
+		},
+		"dig": func(args ...interface{}) (interface{}, error) {
+			if len(args) < 3 {
+				return nil, errors.New("dig: keys, a default and a map are required")
+			}
+			m, ok := args[len(args)-1].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("dig: expected a map, got %T", args[len(args)-1])
+			}
+			def := args[len(args)-2]
+			keys := args[:len(args)-2]
+			for i, k := range keys {
+				key, _ := k.(string)
+				v, ok := m[key]
+				if !ok {
+					return def, nil
+				}
+				if i == len(keys)-1 {
+					return v, nil
+				}
+				if m, ok = v.(map[string]interface{}); !ok {
+					return def, nil
+				}
+			}
+			return def, nil
+		},
+		"set": func(m map[string]interface{}, key string, v interface{}) map[string]interface{} {
+			m[key] = v
+			return m
+		},
+		"keys": func(maps ...map[string]interface{}) []string {
+			var out []string
+			for _, m := range maps {
+				for k := range m {
+					out = append(out, k)
+				}
+			}
+			sort.Strings(out)
+			return out
+		},
+		"merge": func(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+			for _, src := range srcs {
+				for k, v := range MergeValues(src, dst) {
+					dst[k] = v
+				}
+			}
+			return dst
+		},
+		"first": func(v interface{}) interface{} {
+			if l := toList(v); len(l) > 0 {
+				return l[0]
+			}
+			return nil
+		},
+		"last": func(v interface{}) interface{} {
+			if l := toList(v); len(l) > 0 {
+				return l[len(l)-1]
+			}
+			return nil
+		},
+		"append": func(v interface{}, x interface{}) []interface{} { return append(toList(v), x) },
+		"has": func(needle interface{}, v interface{}) bool {
+			for _, x := range toList(v) {
+				if reflect.DeepEqual(x, needle) {
+					return true
+				}
+			}
+			return false
+		},
+		"kindIs": func(kind string, v interface{}) bool { return v != nil && reflect.ValueOf(v).Kind().String() == kind },
+		"typeOf": func(v interface{}) string { return fmt.Sprintf("%T", v) },
+
+		"int":     func(v interface{}) int { return int(toInt64(v)) },
+		"int64":   toInt64,
+		"float64": func(v interface{}) float64 { f, _ := strconv.ParseFloat(toString(v), 64); return f },
+		"add": func(v ...interface{}) int64 {
+			var sum int64
+			for _, x := range v {
+				sum += toInt64(x)
+			}
+			return sum
+		},
+		"sub": func(a, b interface{}) int64 { return toInt64(a) - toInt64(b) },
+		"mul": func(a, b interface{}) int64 { return toInt64(a) * toInt64(b) },
+		"div": func(a, b interface{}) (int64, error) {
+			if toInt64(b) == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return toInt64(a) / toInt64(b), nil
+		},
+		"max": func(a interface{}, v ...interface{}) int64 {
+			m := toInt64(a)
+			for _, x := range v {
+				if n := toInt64(x); n > m {
+					m = n
+				}
+			}
+			return m
+		},
+		"min": func(a interface{}, v ...interface{}) int64 {
+			m := toInt64(a)
+			for _, x := range v {
+				if n := toInt64(x); n < m {
+					m = n
+				}
+			}
+			return m
+		},
+
+		"semverCompare": SemverCompare,
+	}
+	for name, fn := range sprigFuncs() {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// SemverCompare reports whether version satisfies constraint, a
+// comma-separated list of comparisons (">=1.19-0", "<1.25", "=1.30.0").
+// Pre-release suffixes are ignored.
+func SemverCompare(constraint, version string) (bool, error) {
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		op := strings.TrimRight(c[:min(2, len(c))], "0123456789v")
+		want := strings.TrimSpace(strings.TrimPrefix(c, op))
+		if want == "" {
+			return false, fmt.Errorf("invalid semver constraint %q", constraint)
+		}
+		cmp := compareSemver(version, want)
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		case "=", "":
+			ok = cmp == 0
+		default:
+			return false, fmt.Errorf("unsupported semver operator %q in %q", op, constraint)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareSemver compares major.minor.patch, ignoring a "v" prefix and
+// pre-release or build suffixes. Missing components compare as zero.
+func compareSemver(a, b string) int {
+	parse := func(v string) [3]int {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		var out [3]int
+		for i, p := range strings.SplitN(v, ".", 3) {
+			out[i], _ = strconv.Atoi(p)
+		}
+		return out
+	}
+	pa, pb := parse(a), parse(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func trunc(n int, s string) string {
+	if n >= 0 && len(s) > n {
+		return s[:n]
+	}
+	if n < 0 && len(s) > -n {
+		return s[len(s)+n:]
+	}
+	return s
+}
+
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toInt64(v interface{}) int64 {
+	switch x := v.(type) {
+	case int:
+		return int64(x)
+	case int64:
+		return x
+	case int32:
+		return int64(x)
+	case float64:
+		return int64(x)
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	}
+	n, _ := strconv.ParseFloat(toString(v), 64)
+	return int64(n)
+}
+
+func toList(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
@@ -0,0 +1,251 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// ── RenderChart ───────────────────────────────────────────────────────────────
+
+func TestRenderChart(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: app\nversion: 0.1.0\nappVersion: \"1.2\"\n",
+		"values.yaml": "replicas: 2\nimage:\n  repository: nginx\n  tag: \"1.25\"\nlabels:\n  team: web\n",
+		"templates/_helpers.tpl": `{{- define "app.fullname" -}}
+{{- printf "%s-%s" .Release.Name .Chart.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}`,
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "app.fullname" . }}
+  namespace: {{ .Release.Namespace }}
+  labels:
+    {{- toYaml .Values.labels | nindent 4 }}
+    version: {{ .Chart.AppVersion | quote }}
+spec:
+  replicas: {{ .Values.replicas | default 1 }}
+  template:
+    spec:
+      containers:
+        - image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+{{- if semverCompare ">=1.25-0" .Capabilities.KubeVersion.Version }}
+      modern: true
+{{- end }}
+`,
+		"templates/NOTES.txt": "installed {{ .Release.Name }}\n",
+	})
+
+	rendered, err := RenderChart(dir, RenderOptions{ReleaseName: "rel", Namespace: "prod"})
+	if err != nil {
+		t.Fatalf("RenderChart: %v", err)
+	}
+	if rendered.Name != "app" {
+		t.Errorf("Name = %q", rendered.Name)
+	}
+	if len(rendered.Manifests) != 1 {
+		t.Fatalf("partials and NOTES.txt must not be rendered as manifests: %v", rendered.Manifests)
+	}
+
+	out := rendered.Manifests["templates/deployment.yaml"]
+	for _, want := range []string{
+		"name: rel-app\n",
+		"namespace: prod\n",
+		"\n    team: web\n",
+		"version: \"1.2\"\n",
+		"replicas: 2\n",
+		`image: "nginx:1.25"`,
+		"modern: true",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderedChart_Bundle(t *testing.T) {
+	rendered := &RenderedChart{
+		Name: "app",
+		Manifests: map[string]string{
+			"templates/web-service.yaml":    "\napiVersion: v1\nkind: Service\n",
+			"templates/web-deployment.yaml": "---\nkind: Deployment\n---\n\n---\nkind: ConfigMap\n\n",
+			"templates/web-ingress.yaml":    "\n  \n",
+		},
+	}
+	want := "---\n# Source: app/templates/web-deployment.yaml\nkind: Deployment\n" +
+		"---\n# Source: app/templates/web-deployment.yaml\nkind: ConfigMap\n" +
+		"---\n# Source: app/templates/web-service.yaml\napiVersion: v1\nkind: Service\n"
+	if got := rendered.Bundle(); got != want {
+		t.Errorf("Bundle() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderChart_ValuesOverrideAndKubeVersion(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  "name: app\nversion: 0.1.0\n",
+		"values.yaml": "image:\n  repository: nginx\n  tag: \"1.25\"\n",
+		"templates/cm.yaml": `image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+{{- if semverCompare "<1.25" .Capabilities.KubeVersion.Version }}
+legacy: true
+{{- end }}
+`,
+	})
+
+	rendered, err := RenderChart(dir, RenderOptions{
+		KubeVersion: "1.24.3",
+		Values:      map[string]interface{}{"image": map[string]interface{}{"tag": "1.26"}},
+	})
+	if err != nil {
+		t.Fatalf("RenderChart: %v", err)
+	}
+	out := rendered.Manifests["templates/cm.yaml"]
+	if !strings.Contains(out, "image: nginx:1.26") || !strings.Contains(out, "legacy: true") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRenderChart_APIVersions(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": "name: app\nversion: 0.1.0\n",
+		"templates/cm.yaml": `policy: {{ .Capabilities.APIVersions.Has "policy/v1" }}
+monitoring: {{ .Capabilities.APIVersions.Has "monitoring.coreos.com/v1" }}
+`,
+	})
+
+	rendered, err := RenderChart(dir, RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderChart: %v", err)
+	}
+	if out := rendered.Manifests["templates/cm.yaml"]; out != "policy: true\nmonitoring: false\n" {
+		t.Errorf("expected the built-in APIs only:\n%s", out)
+	}
+
+	rendered, err = RenderChart(dir, RenderOptions{APIVersions: []string{"monitoring.coreos.com/v1"}})
+	if err != nil {
+		t.Fatalf("RenderChart: %v", err)
+	}
+	if out := rendered.Manifests["templates/cm.yaml"]; out != "policy: true\nmonitoring: true\n" {
+		t.Errorf("expected the API versions of the options to be added:\n%s", out)
+	}
+}
+
+func TestRenderChart_Dig(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  "name: app\nversion: 0.1.0\n",
+		"values.yaml": "roleRef:\n  kind: ClusterRole\n",
+		"templates/cm.yaml": `kind: {{ dig "roleRef" "kind" "Role" .Values }}
+name: {{ dig "roleRef" "name" "default" .Values }}
+enabled: {{ dig "enabled" true .Values }}
+`,
+	})
+
+	rendered, err := RenderChart(dir, RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderChart: %v", err)
+	}
+	if out := rendered.Manifests["templates/cm.yaml"]; out != "kind: ClusterRole\nname: default\nenabled: true\n" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRenderChart_Files(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":         "name: app\nversion: 0.1.0\n",
+		"files/app/app.conf": "port=80\n",
+		"templates/cm.yaml": `conf: {{ .Files.Get "files/app/app.conf" | quote }}
+missing: {{ .Files.Get "files/none" | quote }}
+template: {{ .Files.Get "templates/cm.yaml" | quote }}
+outside: {{ .Files.Get "../Chart.yaml" | quote }}
+`,
+	})
+
+	rendered, err := RenderChart(dir, RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderChart: %v", err)
+	}
+	want := "conf: \"port=80\\n\"\nmissing: \"\"\ntemplate: \"\"\noutside: \"name: app\\nversion: 0.1.0\\n\"\n"
+	if out := rendered.Manifests["templates/cm.yaml"]; out != want {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRenderChart_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "parse error",
+			files: map[string]string{"Chart.yaml": "name: app\n", "templates/a.yaml": "{{ .Values.my-key }}\n"},
+			want:  "parse templates/a.yaml",
+		},
+		{
+			name:  "required value",
+			files: map[string]string{"Chart.yaml": "name: app\n", "templates/a.yaml": `{{ required "host is required" .Values.host }}`},
+			want:  "host is required",
+		},
+		{
+			name:  "missing chart name",
+			files: map[string]string{"Chart.yaml": "version: 0.1.0\n"},
+			want:  "name is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RenderChart(writeChart(t, tt.files), RenderOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		constraint, version string
+		want                bool
+	}{
+		{">=1.19-0", "v1.30.0", true},
+		{">=1.19-0", "v1.18.5", false},
+		{"<1.25", "1.24.3", true},
+		{">=1.21, <1.25", "v1.25.0", false},
+		{"=1.30.0", "v1.30.0", true},
+	}
+	for _, tt := range tests {
+		got, err := SemverCompare(tt.constraint, tt.version)
+		if err != nil || got != tt.want {
+			t.Errorf("SemverCompare(%q, %q) = %v, %v; want %v", tt.constraint, tt.version, got, err, tt.want)
+		}
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{"a": map[string]interface{}{"x": 1, "y": 2}, "b": 1}
+	got := MergeValues(base, map[string]interface{}{"a": map[string]interface{}{"y": 3}, "c": 4})
+
+	a := got["a"].(map[string]interface{})
+	if a["x"] != 1 || a["y"] != 3 || got["b"] != 1 || got["c"] != 4 {
+		t.Errorf("unexpected merge result: %v", got)
+	}
+	if base["a"].(map[string]interface{})["y"] != 2 {
+		t.Error("base must not be modified")
+	}
+}
@@ -0,0 +1,377 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"sigs.k8s.io/yaml"
+)
+
+// sprigFuncs returns the Sprig functions charts use beyond the core set of
+// renderFuncs. Functions with random output (randAlphaNum, uuidv4, ...)
+// return fixed values so that renders can be compared.
+func sprigFuncs() template.FuncMap {
+	return template.FuncMap{
+		"deepCopy": func(v interface{}) interface{} { return deepCopy(v) },
+		"mustDeepCopy": func(v interface{}) (interface{}, error) {
+			if v == nil {
+				return nil, fmt.Errorf("deepCopy: cannot copy nil")
+			}
+			return deepCopy(v), nil
+		},
+		"mergeOverwrite": func(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+			for _, src := range srcs {
+				for k, v := range MergeValues(dst, src) {
+					dst[k] = v
+				}
+			}
+			return dst
+		},
+		"pick": func(m map[string]interface{}, keys ...string) map[string]interface{} {
+			out := make(map[string]interface{}, len(keys))
+			for _, k := range keys {
+				if v, ok := m[k]; ok {
+					out[k] = v
+				}
+			}
+			return out
+		},
+		"omit": func(m map[string]interface{}, keys ...string) map[string]interface{} {
+			skip := make(map[string]bool, len(keys))
+			for _, k := range keys {
+				skip[k] = true
+			}
+			out := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				if !skip[k] {
+					out[k] = v
+				}
+			}
+			return out
+		},
+		"unset": func(m map[string]interface{}, key string) map[string]interface{} {
+			delete(m, key)
+			return m
+		},
+		"values": func(m map[string]interface{}) []interface{} {
+			out := make([]interface{}, 0, len(m))
+			for _, k := range sortedMapKeys(m) {
+				out = append(out, m[k])
+			}
+			return out
+		},
+
+		"rest": func(v interface{}) []interface{} {
+			if l := toList(v); len(l) > 0 {
+				return l[1:]
+			}
+			return nil
+		},
+		"initial": func(v interface{}) []interface{} {
+			if l := toList(v); len(l) > 0 {
+				return l[:len(l)-1]
+			}
+			return nil
+		},
+		"reverse": func(v interface{}) []interface{} {
+			l := toList(v)
+			out := make([]interface{}, len(l))
+			for i, x := range l {
+				out[len(l)-1-i] = x
+			}
+			return out
+		},
+		"uniq": func(v interface{}) []interface{} {
+			var out []interface{}
+			for _, x := range toList(v) {
+				if !containsValue(out, x) {
+					out = append(out, x)
+				}
+			}
+			return out
+		},
+		"without": func(v interface{}, omit ...interface{}) []interface{} {
+			var out []interface{}
+			for _, x := range toList(v) {
+				if !containsValue(omit, x) {
+					out = append(out, x)
+				}
+			}
+			return out
+		},
+		"compact": func(v interface{}) []interface{} {
+			var out []interface{}
+			for _, x := range toList(v) {
+				if !isEmpty(x) {
+					out = append(out, x)
+				}
+			}
+			return out
+		},
+		"concat": func(lists ...interface{}) []interface{} {
+			var out []interface{}
+			for _, l := range lists {
+				out = append(out, toList(l)...)
+			}
+			return out
+		},
+		"prepend": func(v interface{}, x interface{}) []interface{} { return append([]interface{}{x}, toList(v)...) },
+		"sortAlpha": func(v interface{}) []string {
+			out := toStrings(v)
+			sort.Strings(out)
+			return out
+		},
+		"toStrings": toStrings,
+		"until": func(n int) []int {
+			out := make([]int, 0, n)
+			for i := 0; i < n; i++ {
+				out = append(out, i)
+			}
+			return out
+		},
+		"untilStep": func(start, stop, step int) []int {
+			var out []int
+			if step == 0 {
+				return out
+			}
+			for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+				out = append(out, i)
+			}
+			return out
+		},
+
+		"toPrettyJson": func(v interface{}) string {
+			data, _ := json.MarshalIndent(v, "", "  ")
+			return string(data)
+		},
+		"toRawJson": func(v interface{}) string {
+			var buf strings.Builder
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(v)
+			return strings.TrimSuffix(buf.String(), "\n")
+		},
+		"fromYamlArray": func(s string) []interface{} {
+			var out []interface{}
+			if err := yaml.Unmarshal([]byte(s), &out); err != nil {
+				return []interface{}{err.Error()}
+			}
+			return out
+		},
+		"fromJsonArray": func(s string) []interface{} {
+			var out []interface{}
+			if err := json.Unmarshal([]byte(s), &out); err != nil {
+				return []interface{}{err.Error()}
+			}
+			return out
+		},
+
+		"regexFind": func(re, s string) (string, error) {
+			r, err := regexp.Compile(re)
+			if err != nil {
+				return "", err
+			}
+			return r.FindString(s), nil
+		},
+		"regexFindAll": func(re, s string, n int) ([]string, error) {
+			r, err := regexp.Compile(re)
+			if err != nil {
+				return nil, err
+			}
+			return r.FindAllString(s, n), nil
+		},
+		"regexSplit": func(re, s string, n int) ([]string, error) {
+			r, err := regexp.Compile(re)
+			if err != nil {
+				return nil, err
+			}
+			return r.Split(s, n), nil
+		},
+		"split": func(sep, s string) map[string]string {
+			out := make(map[string]string)
+			for i, p := range strings.Split(s, sep) {
+				out["_"+strconv.Itoa(i)] = p
+			}
+			return out
+		},
+		"splitn": func(sep string, n int, s string) map[string]string {
+			out := make(map[string]string)
+			for i, p := range strings.SplitN(s, sep, n) {
+				out["_"+strconv.Itoa(i)] = p
+			}
+			return out
+		},
+		"cat": func(v ...interface{}) string {
+			var out []string
+			for _, x := range v {
+				if x != nil {
+					out = append(out, toString(x))
+				}
+			}
+			return strings.Join(out, " ")
+		},
+		"repeat":  func(n int, s string) string { return strings.Repeat(s, n) },
+		"nospace": func(s string) string { return strings.Join(strings.Fields(s), "") },
+		"trimAll": func(cutset, s string) string { return strings.Trim(s, cutset) },
+		"substr": func(start, end int, s string) string {
+			if start < 0 {
+				start = 0
+			}
+			if end < 0 || end > len(s) {
+				end = len(s)
+			}
+			if start > end {
+				return ""
+			}
+			return s[start:end]
+		},
+		"abbrev": func(n int, s string) string {
+			if n < 4 || len(s) <= n {
+				return s
+			}
+			return s[:n-3] + "..."
+		},
+		"untitle": func(s string) string {
+			if s == "" {
+				return s
+			}
+			return strings.ToLower(s[:1]) + s[1:]
+		},
+		"camelcase": func(s string) string {
+			var sb strings.Builder
+			for _, w := range splitWords(s) {
+				sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+			}
+			return sb.String()
+		},
+		"snakecase": func(s string) string { return strings.ToLower(strings.Join(splitWords(s), "_")) },
+		"kebabcase": func(s string) string { return strings.ToLower(strings.Join(splitWords(s), "-")) },
+		"plural": func(one, many string, n int) string {
+			if n == 1 {
+				return one
+			}
+			return many
+		},
+
+		"atoi":      func(s string) int { n, _ := strconv.Atoi(s); return n },
+		"mod":       func(a, b interface{}) int64 { return toInt64(a) % nonZero(toInt64(b)) },
+		"ceil":      func(v interface{}) float64 { f, _ := strconv.ParseFloat(toString(v), 64); return math.Ceil(f) },
+		"floor":     func(v interface{}) float64 { f, _ := strconv.ParseFloat(toString(v), 64); return math.Floor(f) },
+		"round":     roundTo,
+		"kindOf":    func(v interface{}) string { return reflect.ValueOf(v).Kind().String() },
+		"typeIs":    func(name string, v interface{}) bool { return fmt.Sprintf("%T", v) == name },
+		"deepEqual": reflect.DeepEqual,
+
+		"now":  time.Now,
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+
+		"randAlphaNum": func(n int) string { return strings.Repeat("a", n) },
+		"randAlpha":    func(n int) string { return strings.Repeat("a", n) },
+		"randNumeric":  func(n int) string { return strings.Repeat("0", n) },
+		"randAscii":    func(n int) string { return strings.Repeat("a", n) },
+		"uuidv4":       func() string { return "00000000-0000-4000-8000-000000000000" },
+	}
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, x := range t {
+			out[k] = deepCopy(x)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, x := range t {
+			out[i] = deepCopy(x)
+		}
+		return out
+	}
+	return v
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, x := range list {
+		if reflect.DeepEqual(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toStrings(v interface{}) []string {
+	l := toList(v)
+	out := make([]string, 0, len(l))
+	for _, x := range l {
+		out = append(out, toString(x))
+	}
+	return out
+}
+
+// splitWords splits s into words at separators and lower-to-upper case
+// changes ("fooBar_baz" -> foo, Bar, baz).
+func splitWords(s string) []string {
+	var words []string
+	var cur []rune
+	prevLower := false
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+			if len(cur) > 0 {
+				words = append(words, string(cur))
+			}
+			cur, prevLower = nil, false
+			continue
+		case unicode.IsUpper(r) && prevLower:
+			words = append(words, string(cur))
+			cur = nil
+		}
+		cur = append(cur, r)
+		prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+	return words
+}
+
+func nonZero(n int64) int64 {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+func roundTo(v interface{}, precision int, roundOn ...float64) float64 {
+	f, _ := strconv.ParseFloat(toString(v), 64)
+	pow := math.Pow(10, float64(precision))
+	threshold := 0.5
+	if len(roundOn) > 0 {
+		threshold = roundOn[0]
+	}
+	digit := f * pow
+	_, frac := math.Modf(digit)
+	if frac >= threshold {
+		return math.Ceil(digit) / pow
+	}
+	return math.Floor(digit) / pow
+}
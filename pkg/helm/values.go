@@ -368,9 +368,63 @@ func buildServiceSchemaProperties(services []string) map[string]interface{} {
 					"type":        "boolean",
 					"description": fmt.Sprintf("Enable %s service", svc),
 				},
+				"service": serviceSchema(),
 			},
 		}
 	}
 
 	return props
 }
+
+// serviceSchema returns the schema of services.<name>.service: the Service
+// type and its ports, with node ports limited to the default
+// --service-node-port-range of the API server.
+func serviceSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{
+				"type":        "string",
+				"description": "Service type",
+				"enum":        []interface{}{"ClusterIP", "NodePort", "LoadBalancer", "ExternalName"},
+			},
+			"clusterIP": map[string]interface{}{
+				"type":        "string",
+				"description": "Cluster IP; None makes the Service headless",
+			},
+			"healthCheckNodePort": map[string]interface{}{
+				"type":    "integer",
+				"minimum": 30000,
+				"maximum": 32767,
+			},
+			"ports": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"port"},
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string"},
+						"port": map[string]interface{}{
+							"type":    "integer",
+							"minimum": 1,
+							"maximum": 65535,
+						},
+						"targetPort": map[string]interface{}{
+							"type": []interface{}{"integer", "string"},
+						},
+						"protocol": map[string]interface{}{
+							"type": "string",
+							"enum": []interface{}{"TCP", "UDP", "SCTP"},
+						},
+						"nodePort": map[string]interface{}{
+							"type":        "integer",
+							"description": "Node port, used by NodePort and LoadBalancer Services",
+							"minimum":     30000,
+							"maximum":     32767,
+						},
+					},
+				},
+			},
+		},
+	}
+}
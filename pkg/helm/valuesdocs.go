@@ -0,0 +1,180 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// AddValuesDocs inserts helm-docs style "# -- description" comments above
+// the keys of valuesYAML whose dot-notation path has an entry in docs.
+// Keys inside lists and block scalars are left alone, so list values are
+// documented as a whole.
+func AddValuesDocs(valuesYAML string, docs map[string]string) string {
+	if len(docs) == 0 {
+		return valuesYAML
+	}
+
+	type stackEntry struct {
+		indent int
+		key    string
+	}
+	var pathStack []stackEntry
+	// skipIndent is the indentation of the list or block scalar whose
+	// content is being skipped, or -1.
+	skipIndent := -1
+
+	lines := strings.Split(valuesYAML, "\n")
+	result := make([]string, 0, len(lines)+len(docs))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			result = append(result, line)
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		isListItem := trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+
+		if skipIndent >= 0 {
+			if indent > skipIndent || (indent == skipIndent && isListItem) {
+				result = append(result, line)
+				continue
+			}
+			skipIndent = -1
+		}
+		if isListItem {
+			skipIndent = indent
+			result = append(result, line)
+			continue
+		}
+
+		key, rest, ok := splitYAMLKey(trimmed)
+		if !ok {
+			result = append(result, line)
+			continue
+		}
+
+		for len(pathStack) > 0 && pathStack[len(pathStack)-1].indent >= indent {
+			pathStack = pathStack[:len(pathStack)-1]
+		}
+		pathStack = append(pathStack, stackEntry{indent: indent, key: key})
+
+		parts := make([]string, len(pathStack))
+		for i, entry := range pathStack {
+			parts[i] = entry.key
+		}
+		if doc, ok := docs[strings.Join(parts, ".")]; ok {
+			result = append(result, fmt.Sprintf("%s# -- %s", line[:indent], doc))
+		}
+		result = append(result, line)
+
+		if strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">") {
+			skipIndent = indent
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// splitYAMLKey splits a "key: value" line into the (unquoted) key and the
+// rest of the line.
+func splitYAMLKey(line string) (key, rest string, ok bool) {
+	if strings.HasPrefix(line, `"`) || strings.HasPrefix(line, "'") {
+		end := strings.Index(line[1:], line[:1])
+		if end < 0 {
+			return "", "", false
+		}
+		key = line[1 : end+1]
+		line = line[end+2:]
+		if !strings.HasPrefix(line, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(line[1:]), true
+	}
+
+	idx := strings.Index(line, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return line[:idx], strings.TrimSpace(line[idx+1:]), true
+}
+
+// GenerateValuesTable renders the values documented in docs as a helm-docs
+// compatible Markdown table (Key, Type, Default, Description), sorted by
+// key. values is the parsed values.yaml the defaults are taken from.
+func GenerateValuesTable(values map[string]interface{}, docs map[string]string) string {
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("| Key | Type | Default | Description |\n")
+	sb.WriteString("|-----|------|---------|-------------|\n")
+	for _, key := range keys {
+		value, ok := lookupValuePath(values, key)
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			escapeTableCell(key), valueType(value), escapeTableCell(formatDefault(value)), escapeTableCell(docs[key])))
+	}
+	return sb.String()
+}
+
+// lookupValuePath resolves a dot-notation path produced by AddValuesDocs'
+// key walk. Map keys may themselves contain dots, so every split is tried.
+func lookupValuePath(values map[string]interface{}, path string) (interface{}, bool) {
+	if v, ok := values[path]; ok {
+		return v, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if next, ok := values[path[:i]].(map[string]interface{}); ok {
+			if v, ok := lookupValuePath(next, path[i+1:]); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// valueType returns the helm-docs type name of a value.
+func valueType(v interface{}) string {
+	if v == nil {
+		return "string"
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	default:
+		return "object"
+	}
+}
+
+func formatDefault(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("`%v`", v)
+	}
+	return "`" + string(data) + "`"
+}
+
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
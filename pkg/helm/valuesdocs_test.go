@@ -0,0 +1,75 @@
+package helm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddValuesDocs(t *testing.T) {
+	in := `# header
+services:
+  web:
+    config: |
+      replicas: 5
+    containers:
+    - name: web
+      replicas: 2
+    replicas: 3
+    "app.kubernetes.io/name": web
+`
+	docs := map[string]string{
+		"services.web.replicas":               "Deployment web: spec.replicas",
+		"services.web.containers":             "Deployment web: spec.template.spec.containers",
+		"services.web.config.replicas":        "never applied inside a block scalar",
+		"services.web.containers.replicas":    "never applied inside a list",
+		"services.web.app.kubernetes.io/name": "quoted key",
+	}
+	want := `# header
+services:
+  web:
+    config: |
+      replicas: 5
+    # -- Deployment web: spec.template.spec.containers
+    containers:
+    - name: web
+      replicas: 2
+    # -- Deployment web: spec.replicas
+    replicas: 3
+    # -- quoted key
+    "app.kubernetes.io/name": web
+`
+	if got := AddValuesDocs(in, docs); got != want {
+		t.Errorf("AddValuesDocs() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerateValuesTable(t *testing.T) {
+	values := map[string]interface{}{
+		"web": map[string]interface{}{
+			"replicas": int64(3),
+			"ports":    []interface{}{map[string]interface{}{"port": 80}},
+			"data":     map[string]interface{}{"nginx.conf": "a|b\n"},
+		},
+	}
+	docs := map[string]string{
+		"web.replicas":        "Deployment web: spec.replicas",
+		"web.ports":           "Service web: spec.ports",
+		"web.data.nginx.conf": "ConfigMap web: data.nginx.conf",
+		"web.missing":         "not in values",
+	}
+
+	table := GenerateValuesTable(values, docs)
+	for _, want := range []string{
+		"| Key | Type | Default | Description |",
+		"| web.replicas | int | `3` | Deployment web: spec.replicas |",
+		"| web.ports | list | `[{\"port\":80}]` | Service web: spec.ports |",
+		"| web.data.nginx.conf | string | `\"a\\|b\\n\"` | ConfigMap web: data.nginx.conf |",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table missing %q:\n%s", want, table)
+		}
+	}
+	if strings.Contains(table, "web.missing") {
+		t.Errorf("undocumented path without a value listed:\n%s", table)
+	}
+}
@@ -0,0 +1,83 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+)
+
+// ConfigFile is the lint configuration file looked up in the chart directory.
+const ConfigFile = ".dhglint.yaml"
+
+// Config is the content of a .dhglint.yaml file:
+//
+//	disable:
+//	  - missing-notes
+//	  - BP-HA-001
+//	severity:
+//	  unused-values: error
+type Config struct {
+	// Disable lists rule IDs that are not reported.
+	Disable []string `json:"disable,omitempty"`
+
+	// Severity overrides the severity of rule IDs.
+	Severity map[string]Severity `json:"severity,omitempty"`
+
+	// Rules tunes the best-practice checkers. It is loaded from
+	// pattern.RulesFile rather than from the lint configuration file.
+	Rules *pattern.Rules `json:"-"`
+}
+
+// LoadConfig reads a lint configuration file. A missing file yields an empty
+// configuration.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lint config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses lint configuration YAML.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("lint config: %w", err)
+	}
+	for rule, sev := range cfg.Severity {
+		if _, err := ParseSeverity(string(sev)); err != nil {
+			return nil, fmt.Errorf("lint config: rule %s: %w", rule, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// apply drops disabled rules and applies severity overrides.
+func (c *Config) apply(findings []Finding) []Finding {
+	if c == nil {
+		return findings
+	}
+	disabled := make(map[string]bool, len(c.Disable))
+	for _, rule := range c.Disable {
+		disabled[rule] = true
+	}
+
+	out := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		if disabled[f.Rule] {
+			continue
+		}
+		if sev, ok := c.Severity[f.Rule]; ok {
+			f.Severity = sev
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,357 @@
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	nodev1 "k8s.io/api/node/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	rbacv1beta1 "k8s.io/api/rbac/v1beta1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// kubeScheme holds the built-in Kubernetes API types rendered objects are
+// checked against when no schema bundle covers them.
+var kubeScheme = newKubeScheme()
+
+func newKubeScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		admissionregistrationv1.AddToScheme,
+		appsv1.AddToScheme, appsv1beta1.AddToScheme, appsv1beta2.AddToScheme,
+		autoscalingv1.AddToScheme, autoscalingv2.AddToScheme, autoscalingv2beta1.AddToScheme, autoscalingv2beta2.AddToScheme,
+		batchv1.AddToScheme, batchv1beta1.AddToScheme,
+		certificatesv1.AddToScheme,
+		coordinationv1.AddToScheme,
+		corev1.AddToScheme,
+		discoveryv1.AddToScheme,
+		extensionsv1beta1.AddToScheme,
+		flowcontrolv1.AddToScheme,
+		networkingv1.AddToScheme, networkingv1beta1.AddToScheme,
+		nodev1.AddToScheme,
+		policyv1.AddToScheme, policyv1beta1.AddToScheme,
+		rbacv1.AddToScheme, rbacv1beta1.AddToScheme,
+		schedulingv1.AddToScheme,
+		storagev1.AddToScheme, storagev1beta1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			panic(err)
+		}
+	}
+	return scheme
+}
+
+// checkKubeSchemas validates rendered objects against the Kubernetes API of
+// kubeVersion. A schema from schemaLocation, a directory of kubeconform-style
+// standalone JSON schemas, takes precedence; otherwise the built-in API types
+// are used, which also tell which beta APIs kubeVersion serves. Objects of
+// kinds with no schema (custom resources) are reported at info level.
+func checkKubeSchemas(objects []*unstructured.Unstructured, sources map[*unstructured.Unstructured]string, kubeVersion, schemaLocation string) []Finding {
+	major, minor, err := parseKubeVersion(kubeVersion)
+	if err != nil {
+		return []Finding{{Rule: RuleKubeSchema, Severity: SeverityError, Message: err.Error()}}
+	}
+	version := fmt.Sprintf("%d.%d", major, minor)
+
+	var findings []Finding
+	for _, obj := range objects {
+		file := sources[obj]
+		gvk := obj.GroupVersionKind()
+		name := fmt.Sprintf("%s %s/%s", obj.GetAPIVersion(), obj.GetKind(), obj.GetName())
+		report := func(sev Severity, format string, args ...interface{}) {
+			findings = append(findings, Finding{
+				Rule: RuleKubeSchema, Severity: sev, File: file,
+				Message: name + ": " + fmt.Sprintf(format, args...),
+			})
+		}
+		if gvk.Kind == "" || gvk.Version == "" {
+			report(SeverityError, "apiVersion and kind are required")
+			continue
+		}
+
+		if schemaLocation != "" {
+			schema, err := loadKubeSchema(schemaLocation, fmt.Sprintf("v%d.%d.0", major, minor), gvk.Group, gvk.Version, gvk.Kind)
+			if err != nil {
+				report(SeverityError, "%v", err)
+				continue
+			}
+			if schema != nil {
+				for _, problem := range validateSchema(schema, obj.Object, "") {
+					report(SeverityError, "%s", problem)
+				}
+				continue
+			}
+		}
+
+		typed, err := kubeScheme.New(gvk)
+		if err != nil {
+			report(SeverityInfo, "no schema for Kubernetes %s, not validated", version)
+			continue
+		}
+		if removed, ok := typed.(interface{ APILifecycleRemoved() (int, int) }); ok {
+			if rMajor, rMinor := removed.APILifecycleRemoved(); rMajor != 0 && versionAtLeast(major, minor, rMajor, rMinor) {
+				report(SeverityError, "not served by Kubernetes %s (removed in %d.%d)", version, rMajor, rMinor)
+				continue
+			}
+		}
+		if introduced, ok := typed.(interface{ APILifecycleIntroduced() (int, int) }); ok {
+			if iMajor, iMinor := introduced.APILifecycleIntroduced(); iMajor != 0 && !versionAtLeast(major, minor, iMajor, iMinor) {
+				report(SeverityError, "not served by Kubernetes %s (introduced in %d.%d)", version, iMajor, iMinor)
+				continue
+			}
+		}
+		for _, problem := range checkTypedFields(obj.Object, reflect.TypeOf(typed), "") {
+			report(SeverityError, "%s", problem)
+		}
+	}
+	return findings
+}
+
+// parseKubeVersion parses "1.29", "v1.29" or "v1.29.3".
+func parseKubeVersion(v string) (int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid Kubernetes version %q (expected e.g. 1.29)", v)
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid Kubernetes version %q (expected e.g. 1.29)", v)
+	}
+	return major, minor, nil
+}
+
+func versionAtLeast(major, minor, wantMajor, wantMinor int) bool {
+	return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+}
+
+// loadKubeSchema reads the schema of group/version/kind from a directory laid
+// out like the kubernetes-json-schema bundles kubeconform uses:
+// <dir>/<version>-standalone-strict/deployment-apps-v1.json, falling back to
+// <dir>/<version>-standalone/ and <dir>/. It returns nil when the directory
+// has no schema for the kind.
+func loadKubeSchema(dir, kubeVersion, group, version, kind string) (map[string]interface{}, error) {
+	file := strings.ToLower(kind)
+	if group != "" {
+		file += "-" + strings.ToLower(strings.Split(group, ".")[0])
+	}
+	file += "-" + strings.ToLower(version) + ".json"
+
+	for _, sub := range []string{kubeVersion + "-standalone-strict", kubeVersion + "-standalone", ""} {
+		data, err := os.ReadFile(filepath.Join(dir, sub, file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var schema map[string]interface{}
+		if err := yaml.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", filepath.Join(sub, file), err)
+		}
+		return schema, nil
+	}
+	return nil, nil
+}
+
+var (
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	timeType        = reflect.TypeOf(metav1.Time{})
+	microTimeType   = reflect.TypeOf(metav1.MicroTime{})
+	durationType    = reflect.TypeOf(metav1.Duration{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// checkTypedFields checks a decoded object against the Go API type t: every
+// field must be known to the type and every value of the type's kind.
+func checkTypedFields(v interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if v == nil {
+		return nil
+	}
+	mismatch := func(want string) []string {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", schemaPathOrRoot(path), want, schemaTypeOf(normalizeNumber(v)))}
+	}
+
+	switch t {
+	case intOrStringType, quantityType:
+		switch normalizeNumber(v).(type) {
+		case string, float64:
+			return nil
+		}
+		return mismatch("string or integer")
+	case timeType, microTimeType, durationType:
+		if _, ok := v.(string); ok {
+			return nil
+		}
+		return mismatch("string")
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		// Custom JSON encoding (RawExtension, JSON, ...): accept any value.
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch("object")
+		}
+		fields := jsonFields(t)
+		var problems []string
+		for _, k := range sortedKeys(m) {
+			ft, ok := fields[k]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown field", joinSchemaPath(path, k)))
+				continue
+			}
+			problems = append(problems, checkTypedFields(m[k], ft, joinSchemaPath(path, k))...)
+		}
+		return problems
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch("object")
+		}
+		var problems []string
+		for _, k := range sortedKeys(m) {
+			problems = append(problems, checkTypedFields(m[k], t.Elem(), joinSchemaPath(path, k))...)
+		}
+		return problems
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if _, ok := v.(string); ok {
+				return nil
+			}
+			return mismatch("string")
+		}
+		l, ok := v.([]interface{})
+		if !ok {
+			return mismatch("array")
+		}
+		var problems []string
+		for i, item := range l {
+			problems = append(problems, checkTypedFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return problems
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			return mismatch("string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			return mismatch("boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !schemaTypeMatches("integer", normalizeNumber(v)) {
+			return mismatch("integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := normalizeNumber(v).(float64); !ok {
+			return mismatch("number")
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the JSON field names of struct t with their types,
+// including the fields of inlined embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && (name == "" || strings.Contains(opts, "inline")) {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// normalizeNumber converts the integer types unstructured objects may hold
+// to float64, the type YAML-decoded numbers have.
+func normalizeNumber(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return v
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schemaPathOrRoot(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
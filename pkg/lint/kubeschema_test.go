@@ -0,0 +1,151 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+const kubeSchemaDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: {{ .Values.replicas }}
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          imagePullPolcy: Always
+          ports:
+            - containerPort: 8080
+          resources:
+            limits:
+              cpu: 500m
+              memory: 1
+          livenessProbe:
+            httpGet:
+              port: http
+`
+
+func kubeSchemaMessages(t *testing.T, files map[string]string, opts ValidateOptions) string {
+	t.Helper()
+	files["Chart.yaml"] = lintChartYAML
+	opts.KubeSchemas = true
+	var messages []string
+	for _, f := range findRule(Validate(writeChart(t, files), opts), RuleKubeSchema) {
+		messages = append(messages, string(f.Severity)+" "+f.File+" "+f.Message)
+	}
+	return strings.Join(messages, "\n")
+}
+
+func TestValidate_KubeSchemaFields(t *testing.T) {
+	got := kubeSchemaMessages(t, map[string]string{
+		"values.yaml":           "replicas: three\n",
+		"templates/deploy.yaml": kubeSchemaDeployment,
+	}, ValidateOptions{KubeVersion: "1.29"})
+
+	for _, want := range []string{
+		"error templates/deploy.yaml apps/v1 Deployment/web: spec.replicas: expected integer, got string",
+		"spec.template.spec.containers[0].imagePullPolcy: unknown field",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	for _, valid := range []string{"memory", "port", "cpu"} {
+		if strings.Contains(got, valid) {
+			t.Errorf("quantities and int-or-string ports are valid, got:\n%s", got)
+		}
+	}
+}
+
+func TestValidate_KubeSchemaVersions(t *testing.T) {
+	files := map[string]string{
+		"values.yaml": "{}\n",
+		"templates/ing.yaml": `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+`,
+		"templates/crd.yaml": `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  anything: goes
+`,
+	}
+
+	got := kubeSchemaMessages(t, files, ValidateOptions{KubeVersion: "1.29"})
+	if !strings.Contains(got, "error templates/ing.yaml extensions/v1beta1 Ingress/web: not served by Kubernetes 1.29 (removed in 1.22)") {
+		t.Errorf("expected the removed Ingress API reported:\n%s", got)
+	}
+	if !strings.Contains(got, "info templates/crd.yaml example.com/v1 Widget/w: no schema") {
+		t.Errorf("expected the custom resource skipped at info level:\n%s", got)
+	}
+
+	if got := kubeSchemaMessages(t, files, ValidateOptions{KubeVersion: "1.20"}); strings.Contains(got, "not served") {
+		t.Errorf("extensions/v1beta1 Ingress is still served by 1.20:\n%s", got)
+	}
+}
+
+func TestValidate_KubeSchemaLocation(t *testing.T) {
+	bundle := writeChart(t, map[string]string{
+		"v1.29.0-standalone-strict/widget-example-v1.json": `{
+  "type": "object",
+  "required": ["spec"],
+  "additionalProperties": false,
+  "properties": {
+    "apiVersion": {"type": "string"},
+    "kind": {"type": "string"},
+    "metadata": {"type": "object"},
+    "spec": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {"size": {"oneOf": [{"type": "string"}, {"type": "integer"}]}}
+    }
+  }
+}`,
+	})
+
+	got := kubeSchemaMessages(t, map[string]string{
+		"values.yaml": "{}\n",
+		"templates/crd.yaml": `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  size: true
+  colour: red
+`,
+	}, ValidateOptions{KubeVersion: "v1.29.3", SchemaLocation: bundle})
+
+	for _, want := range []string{
+		"example.com/v1 Widget/w: spec: property \"colour\" is not allowed",
+		"example.com/v1 Widget/w: spec.size: does not match exactly one of the allowed schemas",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestValidate_KubeSchemaInvalidVersion(t *testing.T) {
+	got := kubeSchemaMessages(t, map[string]string{
+		"values.yaml":       "{}\n",
+		"templates/cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n",
+	}, ValidateOptions{KubeVersion: "latest"})
+
+	if !strings.Contains(got, `invalid Kubernetes version "latest"`) {
+		t.Errorf("expected an invalid version error, got:\n%s", got)
+	}
+}
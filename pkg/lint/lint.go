@@ -0,0 +1,407 @@
+// Package lint checks generated Helm charts against chart rules and the
+// best-practice checkers of the pattern analyzer.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/dhg"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// Severity is the severity of a lint finding.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// ParseSeverity validates a severity name.
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(s); sev {
+	case SeverityInfo, SeverityWarning, SeverityError:
+		return sev, nil
+	}
+	return "", fmt.Errorf("invalid severity %q (must be info, warning or error)", s)
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as severe as threshold or more.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() >= threshold.rank()
+}
+
+// Chart rule IDs. Findings of the pattern checkers use the best-practice ID
+// (e.g. BP-SEC-001) as their rule.
+const (
+	RuleRender          = "render"
+	RuleUndefinedValues = "undefined-values"
+	RuleUnusedValues    = "unused-values"
+	RuleMissingNotes    = "missing-notes"
+	RuleDeprecatedAPI   = "deprecated-api"
+	RuleChart           = "chart"
+	RuleValues          = "values"
+	RuleValuesSchema    = "values-schema"
+	RuleTemplateSyntax  = "template-syntax"
+	RuleKubeSchema      = "kube-schema"
+	RulePolicy          = "policy"
+)
+
+// Finding is a single lint result.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Result holds the findings for one chart.
+type Result struct {
+	Chart    string    `json:"chart"`
+	Findings []Finding `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity.
+func (r *Result) Count(sev Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == sev {
+			n++
+		}
+	}
+	return n
+}
+
+// Fails reports whether any finding is at or above threshold.
+func (r *Result) Fails(threshold Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks the chart in chartDir. cfg may be nil.
+func Lint(ctx context.Context, chartDir string, cfg *Config) (*Result, error) {
+	meta, err := readChartMeta(chartDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	if _, err := os.Stat(filepath.Join(chartDir, "templates", "NOTES.txt")); os.IsNotExist(err) {
+		findings = append(findings, Finding{
+			Rule: RuleMissingNotes, Severity: SeverityInfo, File: "templates/NOTES.txt",
+			Message: "chart has no NOTES.txt; users get no post-install instructions",
+		})
+	}
+
+	findings = append(findings, checkValues(chartDir, meta)...)
+
+	rendered, err := helm.RenderChart(chartDir, helm.RenderOptions{})
+	if err != nil {
+		findings = append(findings, renderFinding(err))
+		return finish(chartDir, findings, cfg), nil
+	}
+
+	objects, sources, renderFindings := parseManifests(rendered)
+	findings = append(findings, renderFindings...)
+	findings = append(findings, checkDeprecatedAPIs(objects, sources)...)
+
+	var rules *pattern.Rules
+	if cfg != nil {
+		rules = cfg.Rules
+	}
+	practiceFindings, err := checkPractices(ctx, rendered.Name, objects, sources, rules)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, practiceFindings...)
+
+	return finish(chartDir, findings, cfg), nil
+}
+
+func finish(chartDir string, findings []Finding, cfg *Config) *Result {
+	findings = cfg.apply(findings)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity.rank() > findings[j].Severity.rank()
+		}
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+	return &Result{Chart: chartDir, Findings: findings}
+}
+
+// chartMeta holds the Chart.yaml fields lint needs.
+type chartMeta struct {
+	Name         string `json:"name"`
+	Dependencies []struct {
+		Name  string `json:"name"`
+		Alias string `json:"alias"`
+	} `json:"dependencies"`
+}
+
+func readChartMeta(chartDir string) (*chartMeta, error) {
+	data, err := os.ReadFile(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a chart: %w", chartDir, err)
+	}
+	var meta chartMeta
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("Chart.yaml: %w", err)
+	}
+	// apiVersion v1 charts list their dependencies in requirements.yaml.
+	if data, err := os.ReadFile(filepath.Join(chartDir, "requirements.yaml")); err == nil {
+		var req chartMeta
+		if err := yaml.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("requirements.yaml: %w", err)
+		}
+		meta.Dependencies = append(meta.Dependencies, req.Dependencies...)
+	}
+	return &meta, nil
+}
+
+// checkValues reports values.yaml keys no template uses and .Values
+// references that values.yaml does not define. Templates or values that do
+// not parse are left to the render check.
+func checkValues(chartDir string, meta *chartMeta) []Finding {
+	values := map[string]interface{}{}
+	if data, err := os.ReadFile(filepath.Join(chartDir, "values.yaml")); err == nil {
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil
+		}
+	}
+	sources, err := helm.ReadTemplates(chartDir)
+	if err != nil {
+		return nil
+	}
+	root, err := helm.ParseTemplates(meta.Name, sources)
+	if err != nil {
+		return nil
+	}
+	refs := valueRefs(root)
+
+	findings := undefinedValues(values, refs)
+	for _, p := range unusedValues(values, refs, subchartKeys(chartDir, meta)) {
+		findings = append(findings, Finding{
+			Rule: RuleUnusedValues, Severity: SeverityWarning, File: "values.yaml",
+			Message: fmt.Sprintf("%s is not used by any template", p),
+		})
+	}
+	return findings
+}
+
+// undefinedValues reports the unguarded .Values references values does not
+// define, once per file at their first line.
+func undefinedValues(values map[string]interface{}, refs []valueRef) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, r := range refs {
+		if r.Guarded || definedValue(values, r.Path) {
+			continue
+		}
+		key := r.File + "\x00" + strings.Join(r.Path, ".")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		findings = append(findings, Finding{
+			Rule: RuleUndefinedValues, Severity: SeverityWarning, File: r.File, Line: r.Line,
+			Message: fmt.Sprintf(".Values.%s is used but not defined in values.yaml", strings.Join(r.Path, ".")),
+		})
+	}
+	return findings
+}
+
+// templateErrorPattern matches the location text/template puts in parse
+// and execution errors: "template: <chart>/templates/x.yaml:12:3: ...".
+var templateErrorPattern = regexp.MustCompile(`template: [^/:\s]+/([^:\s]+):(\d+)`)
+
+// templateErrorLocation returns the template file (relative to the chart
+// directory) and line an error of text/template points at.
+func templateErrorLocation(err error) (string, int) {
+	m := templateErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return "", 0
+	}
+	line, _ := strconv.Atoi(m[2])
+	return m[1], line
+}
+
+// renderFinding reports a render error at the template location it names.
+func renderFinding(err error) Finding {
+	file, line := templateErrorLocation(err)
+	return Finding{Rule: RuleRender, Severity: SeverityError, File: file, Line: line, Message: err.Error()}
+}
+
+// subchartKeys returns the top-level values keys that configure subcharts
+// rather than the chart's own templates.
+func subchartKeys(chartDir string, meta *chartMeta) map[string]bool {
+	keys := map[string]bool{"global": true}
+	for _, d := range meta.Dependencies {
+		keys[d.Name] = true
+		if d.Alias != "" {
+			keys[d.Alias] = true
+		}
+	}
+	if entries, err := os.ReadDir(filepath.Join(chartDir, "charts")); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				keys[e.Name()] = true
+			}
+		}
+	}
+	return keys
+}
+
+// parseManifests decodes the rendered documents. sources maps each object to
+// its template.
+func parseManifests(rendered *helm.RenderedChart) ([]*unstructured.Unstructured, map[*unstructured.Unstructured]string, []Finding) {
+	paths := make([]string, 0, len(rendered.Manifests))
+	for p := range rendered.Manifests {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var objects []*unstructured.Unstructured
+	sources := make(map[*unstructured.Unstructured]string)
+	var findings []Finding
+	for _, p := range paths {
+		for _, doc := range strings.Split("\n"+rendered.Manifests[p], "\n---") {
+			if strings.TrimSpace(doc) == "" {
+				continue
+			}
+			var m map[string]interface{}
+			if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+				findings = append(findings, Finding{
+					Rule: RuleRender, Severity: SeverityError, File: p,
+					Message: fmt.Sprintf("rendered output is not valid YAML: %v", err),
+				})
+				continue
+			}
+			if len(m) == 0 {
+				continue
+			}
+			obj := &unstructured.Unstructured{Object: m}
+			objects = append(objects, obj)
+			sources[obj] = p
+		}
+	}
+	return objects, sources, findings
+}
+
+func checkDeprecatedAPIs(objects []*unstructured.Unstructured, sources map[*unstructured.Unstructured]string) []Finding {
+	var findings []Finding
+	for _, obj := range objects {
+		m := generator.GetMigrationInfo(obj.GetAPIVersion(), obj.GetKind())
+		if m == nil {
+			continue
+		}
+		msg := fmt.Sprintf("%s %s is deprecated since Kubernetes %s and removed in %s", m.OldAPIVersion, m.OldKind, m.DeprecatedIn, m.RemovedIn)
+		if m.NewAPIVersion != "" {
+			msg += fmt.Sprintf("; use %s", m.NewAPIVersion)
+		} else if m.Notes != "" {
+			msg += "; " + m.Notes
+		}
+		findings = append(findings, Finding{Rule: RuleDeprecatedAPI, Severity: SeverityError, File: sources[obj], Message: msg})
+	}
+	return findings
+}
+
+// checkPractices runs the pattern analyzer's best-practice checkers, tuned by
+// rules, on the rendered objects. rules may be nil.
+func checkPractices(ctx context.Context, chartName string, objects []*unstructured.Unstructured, sources map[*unstructured.Unstructured]string, rules *pattern.Rules) ([]Finding, error) {
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	resources := make([]*types.ExtractedResource, 0, len(objects))
+	for _, obj := range objects {
+		resources = append(resources, &types.ExtractedResource{
+			Object:     obj,
+			Source:     types.SourceFile,
+			SourcePath: sources[obj],
+			GVK:        obj.GroupVersionKind(),
+		})
+	}
+
+	g := dhg.New(dhg.Options{ChartName: chartName})
+	processed, err := g.Process(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := g.Analyze(ctx, processed)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[types.ResourceKey]string, len(resources))
+	for _, r := range resources {
+		files[r.ResourceKey()] = r.SourcePath
+	}
+
+	analyzer := pattern.DefaultAnalyzer()
+	analyzer.ApplyRules(rules)
+
+	var findings []Finding
+	for _, bp := range analyzer.Analyze(graph).BestPractices {
+		if bp.Compliant || strings.HasPrefix(bp.ID, "BP-API-") {
+			// Deprecated APIs are already reported by the deprecated-api rule.
+			continue
+		}
+		sev := practiceSeverity(bp.Severity)
+		if len(bp.AffectedResources) == 0 {
+			findings = append(findings, Finding{Rule: bp.ID, Severity: sev, Message: bp.Title})
+			continue
+		}
+		for _, key := range bp.AffectedResources {
+			findings = append(findings, Finding{
+				Rule: bp.ID, Severity: sev, File: files[key],
+				Message: fmt.Sprintf("%s: %s/%s", bp.Title, key.GVK.Kind, key.Name),
+			})
+		}
+	}
+	return findings, nil
+}
+
+func practiceSeverity(s pattern.Severity) Severity {
+	switch s {
+	case pattern.SeverityError, pattern.SeverityCritical:
+		return SeverityError
+	case pattern.SeverityWarning:
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
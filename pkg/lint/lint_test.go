@@ -0,0 +1,242 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range files {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func findRule(r *Result, rule string) []Finding {
+	var out []Finding
+	for _, f := range r.Findings {
+		if f.Rule == rule {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+const lintChartYAML = "apiVersion: v2\nname: app\nversion: 0.1.0\n"
+
+func TestLint_ValuesRules(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": lintChartYAML + "dependencies:\n  - name: redis\n    version: 19.6.4\n    repository: https://charts.bitnami.com/bitnami\n",
+		"values.yaml": `image:
+  repository: nginx
+  tag: "1.25"
+  pullPolicy: IfNotPresent
+unusedBlock:
+  a: 1
+config: {}
+redis:
+  enabled: false
+global: {}
+`,
+		"templates/NOTES.txt": "ok\n",
+		"templates/cm.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+  digest: "{{ .Values.image.digest }}"
+  {{- if .Values.extra }}
+  extra: {{ .Values.extra | quote }}
+  {{- end }}
+  level: {{ .Values.logLevel | default "info" }}
+  {{- with .Values.config }}
+  {{- toYaml . | nindent 2 }}
+  {{- end }}
+  {{- if hasKey .Values "debug" }}
+  debug: "true"
+  {{- end }}
+`,
+	})
+
+	result, err := Lint(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	undefined := findRule(result, RuleUndefinedValues)
+	if len(undefined) != 1 || !strings.Contains(undefined[0].Message, ".Values.image.digest") || undefined[0].File != "templates/cm.yaml" {
+		t.Errorf("expected only .Values.image.digest to be undefined, got %+v", undefined)
+	}
+
+	unused := findRule(result, RuleUnusedValues)
+	var got []string
+	for _, f := range unused {
+		got = append(got, strings.Fields(f.Message)[0])
+	}
+	if strings.Join(got, ",") != "image.pullPolicy,unusedBlock" {
+		t.Errorf("unused values = %v, want [image.pullPolicy unusedBlock]", got)
+	}
+
+	if len(findRule(result, RuleMissingNotes)) != 0 {
+		t.Error("NOTES.txt exists")
+	}
+}
+
+func TestLint_RequirementsDependencies(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":          "apiVersion: v1\nname: app\nversion: 0.1.0\n",
+		"requirements.yaml":   "dependencies:\n  - name: redis\n    version: 19.6.4\n    repository: https://charts.bitnami.com/bitnami\n",
+		"values.yaml":         "redis:\n  enabled: false\n",
+		"templates/NOTES.txt": "ok\n",
+	})
+
+	result, err := Lint(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if unused := findRule(result, RuleUnusedValues); len(unused) != 0 {
+		t.Errorf("values of requirements.yaml dependencies should not be unused, got %+v", unused)
+	}
+}
+
+func TestLint_RenderedRules(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  lintChartYAML,
+		"values.yaml": "replicas: 1\n",
+		"templates/pdb.yaml": `apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: app
+spec:
+  minAvailable: 1
+`,
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: {{ .Values.replicas }}
+  selector:
+    matchLabels:
+      app: app
+  template:
+    metadata:
+      labels:
+        app: app
+    spec:
+      containers:
+        - name: app
+          image: app:1.0
+`,
+	})
+
+	result, err := Lint(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	deprecated := findRule(result, RuleDeprecatedAPI)
+	if len(deprecated) != 1 || deprecated[0].File != "templates/pdb.yaml" || deprecated[0].Severity != SeverityError ||
+		!strings.Contains(deprecated[0].Message, "policy/v1") {
+		t.Errorf("expected a deprecated-api error for the PDB, got %+v", deprecated)
+	}
+	if len(findRule(result, RuleMissingNotes)) != 1 {
+		t.Error("expected a missing-notes finding")
+	}
+
+	practices := 0
+	for _, f := range result.Findings {
+		if strings.HasPrefix(f.Rule, "BP-") {
+			practices++
+			if f.File != "templates/deployment.yaml" {
+				t.Errorf("best-practice finding should point at the deployment template: %+v", f)
+			}
+		}
+	}
+	if practices == 0 {
+		t.Error("expected best-practice findings for a deployment without probes or limits")
+	}
+	if !result.Fails(SeverityError) {
+		t.Error("a removed apiVersion should fail at error level")
+	}
+}
+
+func TestLint_RenderError(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":          lintChartYAML,
+		"templates/NOTES.txt": "ok\n",
+		"templates/a.yaml":    "name: {{ .Values.services.api-server.name }}\n",
+	})
+
+	result, err := Lint(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	render := findRule(result, RuleRender)
+	if len(render) != 1 || render[0].Severity != SeverityError {
+		t.Errorf("expected a render error, got %+v", result.Findings)
+	}
+}
+
+func TestLint_NotAChart(t *testing.T) {
+	if _, err := Lint(context.Background(), t.TempDir(), nil); err == nil {
+		t.Error("expected an error for a directory without Chart.yaml")
+	}
+}
+
+func TestLint_Config(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":       lintChartYAML,
+		"values.yaml":      "unused: 1\n",
+		"templates/a.yaml": "kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: a\n",
+	})
+
+	cfg, err := ParseConfig([]byte("disable: [missing-notes]\nseverity:\n  unused-values: error\n"))
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	result, err := Lint(context.Background(), dir, cfg)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if len(findRule(result, RuleMissingNotes)) != 0 {
+		t.Error("disabled rule must not be reported")
+	}
+	unused := findRule(result, RuleUnusedValues)
+	if len(unused) != 1 || unused[0].Severity != SeverityError {
+		t.Errorf("severity override not applied: %+v", unused)
+	}
+	if result.Findings[0].Rule != RuleUnusedValues {
+		t.Errorf("errors should be listed first: %+v", result.Findings)
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	for _, data := range []string{
+		"severity:\n  unused-values: fatal\n",
+		"disabled: [x]\n",
+	} {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+}
+
+func TestLoadConfig_Missing(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), ConfigFile))
+	if err != nil || cfg == nil {
+		t.Errorf("a missing config should yield an empty one, got %v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,179 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validateSchema checks v against a JSON schema and returns one message per
+// violation. It supports the keywords values schemas use in practice: type,
+// properties, required, additionalProperties, items, enum, anyOf, oneOf,
+// minimum, maximum, minLength, maxLength and pattern; other keywords are
+// ignored. A null value matches any type: like an undefined value, it is
+// meant to be set by users.
+func validateSchema(schema map[string]interface{}, v interface{}, path string) []string {
+	var problems []string
+	at := func(format string, args ...interface{}) {
+		problems = append(problems, schemaPathOrRoot(path)+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok && v != nil {
+		var allowed []string
+		switch t := t.(type) {
+		case string:
+			allowed = []string{t}
+		case []interface{}:
+			for _, x := range t {
+				if s, ok := x.(string); ok {
+					allowed = append(allowed, s)
+				}
+			}
+		}
+		matched := false
+		for _, name := range allowed {
+			if schemaTypeMatches(name, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched && len(allowed) > 0 {
+			at("expected %s, got %s", strings.Join(allowed, " or "), schemaTypeOf(v))
+			return problems
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && matchingSchemas(anyOf, v, path) == 0 {
+		at("does not match any of the allowed schemas")
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok && matchingSchemas(oneOf, v, path) != 1 {
+		at("does not match exactly one of the allowed schemas")
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			at("%v is not one of %v", v, enum)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, set := v[name]; !set {
+						at("missing required property %q", name)
+					}
+				}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := joinSchemaPath(path, k)
+			if ps, ok := props[k].(map[string]interface{}); ok {
+				problems = append(problems, validateSchema(ps, v[k], child)...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					at("property %q is not allowed", k)
+				}
+			case map[string]interface{}:
+				problems = append(problems, validateSchema(extra, v[k], child)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			at("%v is less than the minimum %v", v, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			at("%v is greater than the maximum %v", v, max)
+		}
+	case string:
+		if min, ok := schema["minLength"].(float64); ok && float64(len(v)) < min {
+			at("%q is shorter than %v characters", v, min)
+		}
+		if max, ok := schema["maxLength"].(float64); ok && float64(len(v)) > max {
+			at("%q is longer than %v characters", v, max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				at("%q does not match %s", v, pattern)
+			}
+		}
+	}
+	return problems
+}
+
+// matchingSchemas returns how many of schemas v is valid against.
+func matchingSchemas(schemas []interface{}, v interface{}, path string) int {
+	n := 0
+	for _, s := range schemas {
+		if m, ok := s.(map[string]interface{}); ok && len(validateSchema(m, v, path)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// schemaTypeMatches reports whether v, decoded from YAML, is of the JSON
+// schema type name.
+func schemaTypeMatches(name string, v interface{}) bool {
+	switch name {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return schemaTypeOf(v) == name
+}
+
+// schemaTypeOf returns the JSON schema type of v.
+func schemaTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
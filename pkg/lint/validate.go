@@ -0,0 +1,252 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/helm"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/policy"
+)
+
+// semverPattern matches the SemVer 2 versions Helm accepts in Chart.yaml.
+var semverPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// yamlLinePattern matches the line YAML parse errors point at.
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// ValidateOptions configures Validate.
+type ValidateOptions struct {
+	// KubeVersion is the Kubernetes version the chart is rendered for
+	// (helm.DefaultKubeVersion when empty).
+	KubeVersion string
+
+	// KubeSchemas validates the rendered resources against the Kubernetes
+	// API of KubeVersion.
+	KubeSchemas bool
+
+	// SchemaLocation is a directory of kubeconform-style standalone JSON
+	// schemas used before the built-in API types (optional).
+	SchemaLocation string
+
+	// PolicyPaths are files and directories of Kyverno policies and Rego
+	// policies the rendered resources are evaluated against (optional).
+	PolicyPaths []string
+
+	// OPA is the opa executable Rego policies are evaluated with
+	// (policy.DefaultOPA when empty).
+	OPA string
+}
+
+// Validate checks that the chart in chartDir is well-formed, like
+// "helm lint" but without helm: the required Chart.yaml fields, values.yaml
+// and its values.schema.json, the syntax of every template, .Values
+// references values.yaml does not define, and rendering with the chart's
+// values, optionally followed by Kubernetes API schema and policy checks of
+// the rendered resources. Unlike Lint it runs no best-practice checks. Findings point at
+// the file and, where known, the line.
+func Validate(chartDir string, opts ValidateOptions) *Result {
+	var findings []Finding
+
+	name, chartFindings := validateChartYAML(chartDir)
+	findings = append(findings, chartFindings...)
+
+	values, valuesFindings := validateValues(chartDir)
+	findings = append(findings, valuesFindings...)
+
+	sources, err := helm.ReadTemplates(chartDir)
+	if err != nil {
+		findings = append(findings, Finding{Rule: RuleTemplateSyntax, Severity: SeverityError, File: "templates", Message: err.Error()})
+		return finish(chartDir, findings, nil)
+	}
+	if len(sources) == 0 {
+		findings = append(findings, Finding{Rule: RuleTemplateSyntax, Severity: SeverityWarning, File: "templates", Message: "chart has no templates"})
+	}
+
+	// Parse each file on its own so that every syntax error is reported,
+	// not only the first one.
+	parseName := name
+	if parseName == "" {
+		parseName = "chart"
+	}
+	paths := make([]string, 0, len(sources))
+	for p := range sources {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	syntaxErrors := 0
+	for _, p := range paths {
+		if _, err := helm.ParseTemplates(parseName, map[string]string{p: sources[p]}); err != nil {
+			_, line := templateErrorLocation(err)
+			findings = append(findings, Finding{Rule: RuleTemplateSyntax, Severity: SeverityError, File: p, Line: line, Message: err.Error()})
+			syntaxErrors++
+		}
+	}
+	if syntaxErrors > 0 || name == "" || values == nil {
+		return finish(chartDir, findings, nil)
+	}
+
+	root, err := helm.ParseTemplates(name, sources)
+	if err != nil {
+		findings = append(findings, renderFinding(err))
+		return finish(chartDir, findings, nil)
+	}
+	findings = append(findings, undefinedValues(values, valueRefs(root))...)
+
+	kubeVersion := opts.KubeVersion
+	if kubeVersion == "" {
+		kubeVersion = helm.DefaultKubeVersion
+	}
+	if strings.Count(kubeVersion, ".") == 1 {
+		kubeVersion += ".0" // .Capabilities.KubeVersion is a full version
+	}
+	rendered, err := helm.RenderChart(chartDir, helm.RenderOptions{KubeVersion: kubeVersion})
+	if err != nil {
+		findings = append(findings, renderFinding(err))
+		return finish(chartDir, findings, nil)
+	}
+	objects, objectSources, renderFindings := parseManifests(rendered)
+	findings = append(findings, renderFindings...)
+	if opts.KubeSchemas {
+		findings = append(findings, checkKubeSchemas(objects, objectSources, kubeVersion, opts.SchemaLocation)...)
+	}
+	if len(opts.PolicyPaths) > 0 {
+		findings = append(findings, checkPolicies(objects, objectSources, opts)...)
+	}
+
+	return finish(chartDir, findings, nil)
+}
+
+// checkPolicies evaluates the rendered resources against the policies in
+// opts.PolicyPaths. Enforced violations are errors, audit-only ones warnings.
+func checkPolicies(objects []*unstructured.Unstructured, sources map[*unstructured.Unstructured]string, opts ValidateOptions) []Finding {
+	set, err := policy.Load(opts.PolicyPaths...)
+	if err != nil {
+		return []Finding{{Rule: RulePolicy, Severity: SeverityError, File: strings.Join(opts.PolicyPaths, ", "), Message: err.Error()}}
+	}
+	set.OPA = opts.OPA
+	violations, err := set.Evaluate(context.Background(), objects)
+	if err != nil {
+		return []Finding{{Rule: RulePolicy, Severity: SeverityError, File: strings.Join(opts.PolicyPaths, ", "), Message: err.Error()}}
+	}
+
+	findings := make([]Finding, 0, len(violations))
+	for _, v := range violations {
+		severity := SeverityError
+		if !v.Enforced {
+			severity = SeverityWarning
+		}
+		name := v.Policy
+		if v.Rule != "" {
+			name += "/" + v.Rule
+		}
+		findings = append(findings, Finding{
+			Rule: RulePolicy, Severity: severity, File: sources[v.Object],
+			Message: fmt.Sprintf("%s: %s: %s", v.Resource, name, v.Message),
+		})
+	}
+	return findings
+}
+
+// validateChartYAML checks Chart.yaml and returns the chart name, empty
+// when the chart cannot be rendered.
+func validateChartYAML(chartDir string) (string, []Finding) {
+	chartError := func(format string, args ...interface{}) Finding {
+		return Finding{Rule: RuleChart, Severity: SeverityError, File: "Chart.yaml", Message: fmt.Sprintf(format, args...)}
+	}
+
+	data, err := os.ReadFile(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", []Finding{chartError("Chart.yaml not found")}
+		}
+		return "", []Finding{chartError("cannot read Chart.yaml: %v", err)}
+	}
+	var meta map[string]interface{}
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		f := chartError("invalid YAML: %v", err)
+		f.Line = yamlErrorLine(err)
+		return "", []Finding{f}
+	}
+
+	var findings []Finding
+	for _, field := range []string{"apiVersion", "name", "version"} {
+		if s, _ := meta[field].(string); s == "" {
+			findings = append(findings, chartError("missing required field: %s", field))
+		}
+	}
+	if v, _ := meta["apiVersion"].(string); v != "" && v != "v1" && v != "v2" {
+		findings = append(findings, chartError("apiVersion %q is not supported (must be v1 or v2)", v))
+	}
+	if v, _ := meta["version"].(string); v != "" && !semverPattern.MatchString(v) {
+		findings = append(findings, chartError("version %q is not a valid SemVer 2 version", v))
+	}
+	name, _ := meta["name"].(string)
+	return name, findings
+}
+
+// validateValues parses values.yaml and checks it against values.schema.json
+// when the chart has one. It returns nil values when values.yaml is invalid.
+func validateValues(chartDir string) (map[string]interface{}, []Finding) {
+	values := map[string]interface{}{}
+	var findings []Finding
+
+	data, err := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		findings = append(findings, Finding{Rule: RuleValues, Severity: SeverityWarning, File: "values.yaml", Message: "values.yaml not found"})
+	case err != nil:
+		return nil, append(findings, Finding{Rule: RuleValues, Severity: SeverityError, File: "values.yaml", Message: err.Error()})
+	default:
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, append(findings, Finding{
+				Rule: RuleValues, Severity: SeverityError, File: "values.yaml", Line: yamlErrorLine(err),
+				Message: fmt.Sprintf("invalid YAML: %v", err),
+			})
+		}
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(chartDir, "values.schema.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return values, findings
+	}
+	if err != nil {
+		return values, append(findings, Finding{Rule: RuleValuesSchema, Severity: SeverityError, File: "values.schema.json", Message: err.Error()})
+	}
+	var schema map[string]interface{}
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return values, append(findings, Finding{
+			Rule: RuleValuesSchema, Severity: SeverityError, File: "values.schema.json", Line: yamlErrorLine(err),
+			Message: fmt.Sprintf("invalid schema: %v", err),
+		})
+	}
+	for _, problem := range validateSchema(schema, values, "") {
+		findings = append(findings, Finding{
+			Rule: RuleValuesSchema, Severity: SeverityError, File: "values.yaml",
+			Message: problem + " (values.schema.json)",
+		})
+	}
+	return values, findings
+}
+
+// yamlErrorLine returns the line a YAML parse error points at, or 0.
+func yamlErrorLine(err error) int {
+	m := yamlLinePattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
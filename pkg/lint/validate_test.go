@@ -0,0 +1,218 @@
+package lint
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validateCM = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  replicas: "{{ .Values.replicas }}"
+`
+
+func TestValidate_ValidChart(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":        lintChartYAML,
+		"values.yaml":       "replicas: 2\n",
+		"templates/cm.yaml": validateCM,
+	})
+
+	if result := Validate(dir, ValidateOptions{}); len(result.Findings) != 0 {
+		t.Errorf("expected no findings, got %+v", result.Findings)
+	}
+}
+
+func TestValidate_ChartYAML(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":        "apiVersion: v3\nname: app\nversion: one\n",
+		"values.yaml":       "replicas: 2\n",
+		"templates/cm.yaml": validateCM,
+	})
+
+	chart := findRule(Validate(dir, ValidateOptions{}), RuleChart)
+	if len(chart) != 2 {
+		t.Fatalf("expected apiVersion and version findings, got %+v", chart)
+	}
+	for _, f := range chart {
+		if f.Severity != SeverityError || f.File != "Chart.yaml" {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+
+	missing := findRule(Validate(t.TempDir(), ValidateOptions{}), RuleChart)
+	if len(missing) != 1 || !strings.Contains(missing[0].Message, "not found") {
+		t.Errorf("expected Chart.yaml not found, got %+v", missing)
+	}
+}
+
+func TestValidate_TemplateSyntaxLines(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":       lintChartYAML,
+		"values.yaml":      "replicas: 2\n",
+		"templates/a.yaml": "kind: ConfigMap\nmetadata:\n  name: {{ .Values.replicas ) }}\n",
+		"templates/b.yaml": "kind: ConfigMap\n{{- if .Values.replicas }}\n",
+		"templates/c.yaml": validateCM,
+	})
+
+	syntax := findRule(Validate(dir, ValidateOptions{}), RuleTemplateSyntax)
+	if len(syntax) != 2 {
+		t.Fatalf("expected a syntax error in each broken template, got %+v", syntax)
+	}
+	if syntax[0].File != "templates/a.yaml" || syntax[0].Line != 3 {
+		t.Errorf("expected templates/a.yaml:3, got %s:%d", syntax[0].File, syntax[0].Line)
+	}
+	if syntax[1].File != "templates/b.yaml" || syntax[1].Line == 0 {
+		t.Errorf("expected a line in templates/b.yaml, got %s:%d", syntax[1].File, syntax[1].Line)
+	}
+}
+
+func TestValidate_UndefinedValues(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  lintChartYAML,
+		"values.yaml": "replicas: 2\n",
+		"templates/cm.yaml": validateCM + `  image: "{{ .Values.image.tag }}"
+  {{- with .Values.extra }}
+  extra: {{ . }}
+  {{- end }}
+`,
+	})
+
+	undefined := findRule(Validate(dir, ValidateOptions{}), RuleUndefinedValues)
+	if len(undefined) != 1 || !strings.Contains(undefined[0].Message, ".Values.image.tag") || undefined[0].Line != 7 {
+		t.Errorf("expected .Values.image.tag reported at line 7, got %+v", undefined)
+	}
+}
+
+func TestValidate_RenderErrorLine(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":        lintChartYAML,
+		"values.yaml":       "replicas: 2\n",
+		"templates/cm.yaml": validateCM + `  name: {{ required "name is required" .Values.name }}` + "\n",
+	})
+
+	render := findRule(Validate(dir, ValidateOptions{}), RuleRender)
+	if len(render) != 1 || render[0].File != "templates/cm.yaml" || render[0].Line != 7 {
+		t.Errorf("expected a render error at templates/cm.yaml:7, got %+v", render)
+	}
+}
+
+func TestValidate_ValuesSchema(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  lintChartYAML,
+		"values.yaml": "replicas: two\nimage:\n  pullPolicy: Sometimes\n",
+		"values.schema.json": `{
+  "type": "object",
+  "required": ["replicas", "image"],
+  "properties": {
+    "replicas": {"type": "integer", "minimum": 1},
+    "image": {
+      "type": "object",
+      "required": ["repository"],
+      "properties": {
+        "pullPolicy": {"enum": ["Always", "IfNotPresent", "Never"]}
+      }
+    }
+  }
+}`,
+		"templates/cm.yaml": validateCM,
+	})
+
+	var messages []string
+	for _, f := range findRule(Validate(dir, ValidateOptions{}), RuleValuesSchema) {
+		messages = append(messages, f.Message)
+	}
+	got := strings.Join(messages, "\n")
+	for _, want := range []string{
+		"replicas: expected integer, got string",
+		`image: missing required property "repository"`,
+		"image.pullPolicy: Sometimes is not one of",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("schema findings missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestValidateSchema_Types(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"port":  map[string]interface{}{"type": "integer", "maximum": float64(65535)},
+			"ratio": map[string]interface{}{"type": "number"},
+			"name":  map[string]interface{}{"type": []interface{}{"string", "null"}, "pattern": "^[a-z]+$"},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+	values := map[string]interface{}{
+		"port":  float64(70000),
+		"ratio": 0.5,
+		"name":  "Web",
+		"tags":  []interface{}{"a", float64(1)},
+		"extra": true,
+	}
+
+	got := strings.Join(validateSchema(schema, values, ""), "\n")
+	for _, want := range []string{
+		"port: 70000 is greater than the maximum 65535",
+		`name: "Web" does not match ^[a-z]+$`,
+		"tags[1]: expected string, got number",
+		`(root): property "extra" is not allowed`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ratio") {
+		t.Errorf("ratio is a valid number:\n%s", got)
+	}
+}
+
+func TestValidate_Policies(t *testing.T) {
+	policies := writeChart(t, map[string]string{
+		"require-labels.yaml": `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  validationFailureAction: Enforce
+  rules:
+    - name: team-label
+      match:
+        any:
+          - resources:
+              kinds: [ConfigMap]
+      validate:
+        message: "label team is required"
+        pattern:
+          metadata:
+            labels:
+              team: "?*"
+`,
+	})
+	chart := writeChart(t, map[string]string{
+		"Chart.yaml":        lintChartYAML,
+		"values.yaml":       "team: \"\"\n",
+		"templates/cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n  labels:\n    team: {{ .Values.team | quote }}\n",
+	})
+
+	result := Validate(chart, ValidateOptions{PolicyPaths: []string{policies}})
+	findings := findRule(result, RulePolicy)
+	if len(findings) != 1 {
+		t.Fatalf("expected one policy finding, got %+v", result.Findings)
+	}
+	f := findings[0]
+	if f.Severity != SeverityError || f.File != "templates/cm.yaml" ||
+		f.Message != "v1 ConfigMap/c: require-labels/team-label: label team is required (failed at path /metadata/labels/team/)" {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	result = Validate(chart, ValidateOptions{PolicyPaths: []string{filepath.Join(policies, "missing")}})
+	if findings := findRule(result, RulePolicy); len(findings) != 1 || !strings.Contains(findings[0].Message, "load policies") {
+		t.Errorf("expected a load error finding, got %+v", result.Findings)
+	}
+}
@@ -0,0 +1,263 @@
+package lint
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// valueRef is a .Values path referenced from a template.
+type valueRef struct {
+	Path []string
+	File string
+	Line int
+
+	// Guarded is set when the reference is tested or defaulted (if, with,
+	// default, hasKey, ...), so a missing key is expected.
+	Guarded bool
+}
+
+// guardFuncs are template functions whose arguments may legitimately be
+// undefined values.
+var guardFuncs = map[string]bool{
+	"default":  true,
+	"empty":    true,
+	"coalesce": true,
+	"hasKey":   true,
+	"required": true,
+	"ternary":  true,
+	"and":      true,
+	"or":       true,
+	"not":      true,
+}
+
+// valueRefs collects the .Values references of every template in the set.
+func valueRefs(root *template.Template) []valueRef {
+	var refs []valueRef
+	for _, t := range root.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		file := t.ParseName
+		if i := strings.Index(file, "/"); i >= 0 {
+			file = file[i+1:] // strip the chart name
+		}
+		w := refWalker{file: file, tree: t.Tree}
+		w.node(t.Tree.Root, false)
+		refs = append(refs, w.refs...)
+	}
+	return refs
+}
+
+type refWalker struct {
+	file string
+	tree *parse.Tree
+	refs []valueRef
+
+	// tested holds the paths checked by enclosing if/with conditions.
+	tested [][]string
+}
+
+func (w *refWalker) node(n parse.Node, guarded bool) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			w.node(c, guarded)
+		}
+	case *parse.ActionNode:
+		w.pipe(n.Pipe, guarded)
+	case *parse.IfNode:
+		w.branch(&n.BranchNode, guarded)
+	case *parse.WithNode:
+		w.branch(&n.BranchNode, guarded)
+	case *parse.RangeNode:
+		w.branch(&n.BranchNode, guarded)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe, guarded)
+	case *parse.PipeNode:
+		w.pipe(n, guarded)
+	case *parse.FieldNode:
+		w.ref(n, n.Ident, guarded)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			w.ref(n, n.Ident[1:], guarded)
+		}
+	}
+}
+
+func (w *refWalker) branch(b *parse.BranchNode, guarded bool) {
+	// The condition is a test: a missing key just takes the else branch, and
+	// the body may use what the condition checked.
+	before := len(w.refs)
+	w.pipe(b.Pipe, true)
+	outer := w.tested
+	for _, r := range w.refs[before:] {
+		w.tested = append(w.tested, r.Path)
+	}
+	w.node(b.List, guarded)
+	w.tested = outer
+	if b.ElseList != nil {
+		w.node(b.ElseList, guarded)
+	}
+}
+
+func (w *refWalker) pipe(p *parse.PipeNode, guarded bool) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range p.Cmds {
+		if len(cmd.Args) > 0 {
+			if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok && guardFuncs[id.Ident] {
+				guarded = true
+			}
+		}
+	}
+	for _, cmd := range p.Cmds {
+		if w.index(cmd, guarded) {
+			continue
+		}
+		for _, arg := range cmd.Args {
+			w.node(arg, guarded)
+		}
+	}
+}
+
+// keyFuncs look up string keys in their first argument.
+var keyFuncs = map[string]bool{"index": true, "hasKey": true, "get": true}
+
+// index records `index .Values "a" "b"` (and hasKey, get) as the path a.b.
+// It reports whether the command was handled.
+func (w *refWalker) index(cmd *parse.CommandNode, guarded bool) bool {
+	if len(cmd.Args) < 3 {
+		return false
+	}
+	if id, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || !keyFuncs[id.Ident] {
+		return false
+	}
+	var base []string
+	switch n := cmd.Args[1].(type) {
+	case *parse.FieldNode:
+		base = n.Ident
+	case *parse.VariableNode:
+		if len(n.Ident) < 2 || n.Ident[0] != "$" {
+			return false
+		}
+		base = n.Ident[1:]
+	default:
+		return false
+	}
+	path := append([]string(nil), base...)
+	for _, arg := range cmd.Args[2:] {
+		s, ok := arg.(*parse.StringNode)
+		if !ok {
+			break
+		}
+		path = append(path, s.Text)
+	}
+	w.ref(cmd, path, guarded)
+	return true
+}
+
+func (w *refWalker) ref(n parse.Node, ident []string, guarded bool) {
+	if len(ident) == 0 || ident[0] != "Values" {
+		return
+	}
+	path := ident[1:]
+	for _, t := range w.tested {
+		if hasPathPrefix(t, path) {
+			guarded = true
+		}
+	}
+	w.refs = append(w.refs, valueRef{Path: path, File: w.file, Line: w.line(n), Guarded: guarded})
+}
+
+// line returns the line of n in its template file.
+func (w *refWalker) line(n parse.Node) int {
+	location, _ := w.tree.ErrorContext(n)
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return line
+}
+
+// unusedValues returns the shallowest values paths that no template
+// references. Top-level keys in skip (global, subchart values) are ignored.
+func unusedValues(values map[string]interface{}, refs []valueRef, skip map[string]bool) []string {
+	var unused []string
+	var walk func(m map[string]interface{}, prefix []string)
+	walk = func(m map[string]interface{}, prefix []string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if len(prefix) == 0 && skip[k] {
+				continue
+			}
+			p := append(append([]string(nil), prefix...), k)
+
+			covered, deeper := false, false
+			for _, r := range refs {
+				if hasPathPrefix(p, r.Path) {
+					covered = true
+					break
+				}
+				if hasPathPrefix(r.Path, p) {
+					deeper = true
+				}
+			}
+			switch {
+			case covered:
+			case deeper:
+				if child, ok := m[k].(map[string]interface{}); ok {
+					walk(child, p)
+				}
+			default:
+				unused = append(unused, strings.Join(p, "."))
+			}
+		}
+	}
+	walk(values, nil)
+	return unused
+}
+
+// definedValue reports whether path resolves in values. Reaching a null
+// value counts as defined: the key is declared and meant to be set by users.
+func definedValue(values map[string]interface{}, path []string) bool {
+	var cur interface{} = values
+	for _, key := range path {
+		if cur == nil {
+			return true
+		}
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if cur, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPathPrefix reports whether prefix is a prefix of path.
+func hasPathPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+// Package logging provides leveled, structured logging and progress reporting
+// for the generation pipeline. It is a thin layer over log/slog with a compact
+// human-readable text format and a JSON format for CI consumption.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the log output format.
+type Format string
+
+const (
+	// FormatText writes compact human-readable lines.
+	FormatText Format = "text"
+
+	// FormatJSON writes one JSON object per line.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat converts a --log-format flag value to a Format.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case FormatText, "":
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unknown log format: %q (must be text or json)", s)
+	}
+}
+
+// ParseLevel converts a --log-level flag value to a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level: %q (must be debug, info, warn, or error)", s)
+	}
+}
+
+// Logger is a structured logger with pipeline stage timing and progress helpers.
+type Logger struct {
+	*slog.Logger
+
+	out      io.Writer
+	format   Format
+	level    slog.Level
+	warnings *warningLog
+}
+
+// New creates a logger writing records at or above level to w.
+func New(w io.Writer, format Format, level slog.Level) *Logger {
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	default:
+		format = FormatText
+		handler = newTextHandler(w, level)
+	}
+
+	warnings := &warningLog{}
+	return &Logger{
+		Logger:   slog.New(&recordingHandler{Handler: handler, warnings: warnings}),
+		out:      w,
+		format:   format,
+		level:    level,
+		warnings: warnings,
+	}
+}
+
+// Discard returns a logger that drops all records.
+func Discard() *Logger {
+	return New(io.Discard, FormatText, slog.LevelError+1)
+}
+
+// Format returns the logger's output format.
+func (l *Logger) Format() Format {
+	return l.format
+}
+
+// Warnings returns the warning and error records logged so far, formatted
+// as text lines without the level, including those below the logger's
+// level.
+func (l *Logger) Warnings() []string {
+	l.warnings.mu.Lock()
+	defer l.warnings.mu.Unlock()
+	return append([]string{}, l.warnings.lines...)
+}
+
+// Stage times a single pipeline stage.
+type Stage struct {
+	logger *Logger
+	name   string
+	start  time.Time
+}
+
+// StartStage logs the beginning of a pipeline stage and starts its timer.
+func (l *Logger) StartStage(name string) *Stage {
+	l.Debug("stage started", "stage", name)
+	return &Stage{logger: l, name: name, start: time.Now()}
+}
+
+// Done logs stage completion with its duration and any extra attributes
+// (e.g. "resources", 42) and returns the elapsed time.
+func (s *Stage) Done(args ...any) time.Duration {
+	elapsed := time.Since(s.start)
+	attrs := append([]any{"stage", s.name, "duration_ms", elapsed.Milliseconds()}, args...)
+	s.logger.Info("stage completed", attrs...)
+	return elapsed
+}
+
+// warningLog collects the warning lines of a logger and its derivatives.
+type warningLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// recordingHandler passes records to Handler and keeps warnings and errors
+// in a warningLog whatever the level of Handler.
+type recordingHandler struct {
+	slog.Handler
+	warnings *warningLog
+	attrs    []slog.Attr
+}
+
+// Enabled reports whether records at level are written or recorded.
+func (h *recordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn || h.Handler.Enabled(ctx, level)
+}
+
+// Handle records warnings and passes enabled records on.
+func (h *recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		var b strings.Builder
+		b.WriteString(r.Message)
+		for _, a := range h.attrs {
+			writeAttr(&b, a)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			writeAttr(&b, a)
+			return true
+		})
+		h.warnings.mu.Lock()
+		h.warnings.lines = append(h.warnings.lines, b.String())
+		h.warnings.mu.Unlock()
+	}
+	if !h.Handler.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *recordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &recordingHandler{
+		Handler:  h.Handler.WithAttrs(attrs),
+		warnings: h.warnings,
+		attrs:    append(append([]slog.Attr{}, h.attrs...), attrs...),
+	}
+}
+
+// WithGroup returns a handler that qualifies the keys of later attributes.
+func (h *recordingHandler) WithGroup(name string) slog.Handler {
+	return &recordingHandler{Handler: h.Handler.WithGroup(name), warnings: h.warnings, attrs: h.attrs}
+}
+
+// textHandler renders records as "LEVEL message key=value ..." lines.
+// Timestamps are omitted: text output is meant for humans watching a run.
+type textHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+}
+
+func newTextHandler(w io.Writer, level slog.Level) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+// Enabled reports whether records at level are written.
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// Handle writes a single record.
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if r.Level != slog.LevelInfo {
+		b.WriteString(r.Level.String())
+		b.WriteByte(' ')
+	}
+	b.WriteString(r.Message)
+
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs())
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for _, a := range attrs {
+		writeAttr(&b, a)
+	}
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	merged = append(merged, h.attrs...)
+	merged = append(merged, attrs...)
+	return &textHandler{mu: h.mu, w: h.w, level: h.level, attrs: merged}
+}
+
+// WithGroup is not supported by the text format; groups are flattened.
+func (h *textHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+// writeAttr appends " key=value", quoting values that contain spaces.
+func writeAttr(b *strings.Builder, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Key < group[j].Key })
+		for _, ga := range group {
+			ga.Key = a.Key + "." + ga.Key
+			writeAttr(b, ga)
+		}
+		return
+	}
+
+	val := a.Value.String()
+	if val == "" || strings.ContainsAny(val, " \t\n\"=") {
+		val = fmt.Sprintf("%q", val)
+	}
+	b.WriteByte(' ')
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(val)
+}
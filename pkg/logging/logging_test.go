@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// ── ParseFormat / ParseLevel ──────────────────────────────────────────────────
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"JSON", FormatJSON, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"trace", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// ── Text format ───────────────────────────────────────────────────────────────
+
+func TestLogger_TextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatText, slog.LevelInfo)
+
+	log.Debug("hidden")
+	log.Info("resources extracted", "count", 3, "path", "my dir")
+	log.Warn("skipped file")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("debug record should be filtered at info level, got:\n%s", out)
+	}
+	if !strings.Contains(out, `resources extracted count=3 path="my dir"`) {
+		t.Errorf("unexpected info line, got:\n%s", out)
+	}
+	if !strings.Contains(out, "WARN skipped file") {
+		t.Errorf("expected level prefix on warning, got:\n%s", out)
+	}
+}
+
+func TestLogger_TextFormat_WithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatText, slog.LevelInfo)
+
+	log.With("chart", "myapp").Info("written")
+
+	if got := buf.String(); got != "written chart=myapp\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+// ── JSON format ───────────────────────────────────────────────────────────────
+
+func TestLogger_JSONStage(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatJSON, slog.LevelInfo)
+
+	stage := log.StartStage("extract")
+	stage.Done("resources", 12)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "stage completed" {
+		t.Errorf("expected msg %q, got %v", "stage completed", record["msg"])
+	}
+	if record["stage"] != "extract" {
+		t.Errorf("expected stage %q, got %v", "extract", record["stage"])
+	}
+	if record["resources"] != float64(12) {
+		t.Errorf("expected resources 12, got %v", record["resources"])
+	}
+	if _, ok := record["duration_ms"]; !ok {
+		t.Error("expected duration_ms attribute")
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	log := Discard()
+	log.Error("dropped")
+	log.StartStage("noop").Done()
+}
+
+func TestLogger_Warnings(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, FormatText, slog.LevelError)
+	l.Info("ignored")
+	l.Warn("extraction warning", "error", "bad yaml")
+	l.With("chart", "app").Error("write failed")
+
+	if buf.String() != "ERROR write failed chart=app\n" {
+		t.Errorf("output = %q; warnings below the level should not be written", buf.String())
+	}
+	want := []string{"extraction warning error=\"bad yaml\"", "write failed chart=app"}
+	got := l.Warnings()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Warnings() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressInterval throttles redraws so large inputs don't flood the terminal.
+const progressInterval = 100 * time.Millisecond
+
+// progressWidth is the number of cells in a progress bar.
+const progressWidth = 30
+
+// Progress renders a single-line progress indicator on a terminal.
+// With a known total it draws a bar; otherwise it shows a running counter.
+// It is a no-op for JSON output and when the writer is not a terminal, so CI
+// logs contain only structured stage records.
+type Progress struct {
+	w        io.Writer
+	label    string
+	total    int
+	current  int
+	enabled  bool
+	lastDraw time.Time
+}
+
+// NewProgress creates a progress indicator. Pass total <= 0 when the number
+// of items is not known in advance (e.g. streaming extraction).
+func (l *Logger) NewProgress(label string, total int) *Progress {
+	return &Progress{
+		w:       l.out,
+		label:   label,
+		total:   total,
+		enabled: l.format == FormatText && isTerminal(l.out),
+	}
+}
+
+// Increment advances the indicator by one item.
+func (p *Progress) Increment() {
+	p.current++
+	if !p.enabled {
+		return
+	}
+	if time.Since(p.lastDraw) < progressInterval && p.current != p.total {
+		return
+	}
+	p.draw()
+}
+
+// Finish clears the indicator line. It is safe to call more than once.
+func (p *Progress) Finish() {
+	if !p.enabled || p.lastDraw.IsZero() {
+		return
+	}
+	fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", len(p.line())))
+	p.lastDraw = time.Time{}
+}
+
+// Current returns the number of items processed so far.
+func (p *Progress) Current() int {
+	return p.current
+}
+
+func (p *Progress) draw() {
+	p.lastDraw = time.Now()
+	fmt.Fprintf(p.w, "\r%s", p.line())
+}
+
+// line renders the current state without carriage control characters.
+func (p *Progress) line() string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s: %d", p.label, p.current)
+	}
+	filled := progressWidth * p.current / p.total
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	return fmt.Sprintf("%s [%s%s] %d/%d", p.label,
+		strings.Repeat("#", filled), strings.Repeat(".", progressWidth-filled),
+		p.current, p.total)
+}
+
+// isTerminal reports whether w is a character device such as a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+// ── Progress ──────────────────────────────────────────────────────────────────
+
+func TestProgress_NonTerminalIsSilent(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, FormatText, slog.LevelInfo)
+
+	p := log.NewProgress("Processing", 3)
+	for i := 0; i < 3; i++ {
+		p.Increment()
+	}
+	p.Finish()
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no progress output for non-terminal writer, got %q", buf.String())
+	}
+	if p.Current() != 3 {
+		t.Errorf("expected current 3, got %d", p.Current())
+	}
+}
+
+func TestProgress_Line(t *testing.T) {
+	bar := &Progress{label: "Processing", total: 4, current: 2}
+	if got := bar.line(); got != "Processing [###############...............] 2/4" {
+		t.Errorf("unexpected bar %q", got)
+	}
+
+	counter := &Progress{label: "Extracting", current: 7}
+	if got := counter.line(); got != "Extracting: 7" {
+		t.Errorf("unexpected counter %q", got)
+	}
+}
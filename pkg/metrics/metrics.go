@@ -0,0 +1,112 @@
+// Package metrics collects the observed resource usage of containers from
+// metrics-server PodMetrics or a Prometheus server and attributes pods to the
+// workloads that created them.
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ContainerUsage is the observed resource usage of one container of a pod.
+type ContainerUsage struct {
+	Namespace string
+	Pod       string
+	Container string
+
+	// CPUMillicores is the CPU usage in millicores.
+	CPUMillicores int64
+
+	// MemoryBytes is the memory working set in bytes.
+	MemoryBytes int64
+}
+
+// FromPodMetrics converts metrics-server PodMetrics objects into container
+// usage samples.
+func FromPodMetrics(items []*unstructured.Unstructured) ([]ContainerUsage, error) {
+	var usage []ContainerUsage
+	for _, item := range items {
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		for _, raw := range containers {
+			c, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(c, "name")
+			cpu, _, _ := unstructured.NestedString(c, "usage", "cpu")
+			memory, _, _ := unstructured.NestedString(c, "usage", "memory")
+
+			u := ContainerUsage{Namespace: item.GetNamespace(), Pod: item.GetName(), Container: name}
+			if cpu != "" {
+				q, err := resource.ParseQuantity(cpu)
+				if err != nil {
+					return nil, fmt.Errorf("pod %s/%s container %s: invalid CPU usage %q: %w", u.Namespace, u.Pod, name, cpu, err)
+				}
+				u.CPUMillicores = q.MilliValue()
+			}
+			if memory != "" {
+				q, err := resource.ParseQuantity(memory)
+				if err != nil {
+					return nil, fmt.Errorf("pod %s/%s container %s: invalid memory usage %q: %w", u.Namespace, u.Pod, name, memory, err)
+				}
+				u.MemoryBytes = q.Value()
+			}
+			usage = append(usage, u)
+		}
+	}
+	return usage, nil
+}
+
+// nameSuffixAlphabet is the alphabet of the pod-template-hash and of the
+// random suffixes Kubernetes appends to generated pod names.
+const nameSuffixAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+// PodBelongsTo reports whether pod is named like a pod of the workload kind
+// and name: <name>-<hash>-<suffix> for a Deployment, <name>-<ordinal> for a
+// StatefulSet, <name>-<suffix> for a DaemonSet or Job and
+// <name>-<schedule>-<suffix> for a CronJob.
+func PodBelongsTo(kind, name, pod string) bool {
+	rest, ok := strings.CutPrefix(pod, name+"-")
+	if !ok {
+		return false
+	}
+	parts := strings.Split(rest, "-")
+	switch kind {
+	case "Deployment":
+		return len(parts) == 2 && isNameSuffix(parts[0]) && isNameSuffix(parts[1])
+	case "StatefulSet":
+		return len(parts) == 1 && isDigits(parts[0])
+	case "DaemonSet", "Job":
+		return len(parts) == 1 && isNameSuffix(parts[0])
+	case "CronJob":
+		return len(parts) == 2 && isDigits(parts[0]) && isNameSuffix(parts[1])
+	}
+	return false
+}
+
+func isNameSuffix(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune(nameSuffixAlphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
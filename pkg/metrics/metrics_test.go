@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFromPodMetrics(t *testing.T) {
+	item := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "PodMetrics",
+		"metadata": map[string]interface{}{"name": "web-7d9f8b6c5d-x2x4z", "namespace": "prod"},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":  "web",
+				"usage": map[string]interface{}{"cpu": "123456789n", "memory": "204800Ki"},
+			},
+			map[string]interface{}{
+				"name":  "sidecar",
+				"usage": map[string]interface{}{"cpu": "2m", "memory": "16Mi"},
+			},
+		},
+	}}
+
+	usage, err := FromPodMetrics([]*unstructured.Unstructured{item})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ContainerUsage{
+		{Namespace: "prod", Pod: "web-7d9f8b6c5d-x2x4z", Container: "web", CPUMillicores: 124, MemoryBytes: 200 << 20},
+		{Namespace: "prod", Pod: "web-7d9f8b6c5d-x2x4z", Container: "sidecar", CPUMillicores: 2, MemoryBytes: 16 << 20},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("got %d samples; want %d", len(usage), len(want))
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Errorf("usage[%d] = %+v; want %+v", i, usage[i], want[i])
+		}
+	}
+}
+
+func TestFromPodMetrics_InvalidQuantity(t *testing.T) {
+	item := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web-0"},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "web", "usage": map[string]interface{}{"cpu": "lots"}},
+		},
+	}}
+	if _, err := FromPodMetrics([]*unstructured.Unstructured{item}); err == nil {
+		t.Error("expected an error for an invalid CPU quantity")
+	}
+}
+
+func TestPodBelongsTo(t *testing.T) {
+	tests := []struct {
+		kind, name, pod string
+		want            bool
+	}{
+		{"Deployment", "web", "web-7d9f8b6c5d-x2x4z", true},
+		{"Deployment", "web", "web-api-7d9f8b6c5d-x2x4z", false},
+		{"Deployment", "web", "web-api-x2x4z", false},
+		{"Deployment", "web", "web-0", false},
+		{"StatefulSet", "db", "db-0", true},
+		{"StatefulSet", "db", "db-12", true},
+		{"StatefulSet", "db", "db-x2x4z", false},
+		{"DaemonSet", "agent", "agent-x2x4z", true},
+		{"DaemonSet", "agent", "agent-7d9f8b6c5d-x2x4z", false},
+		{"Job", "migrate", "migrate-q8w2r", true},
+		{"CronJob", "backup", "backup-28790520-q8w2r", true},
+		{"CronJob", "backup", "backup-q8w2r", false},
+		{"Service", "web", "web-x2x4z", false},
+	}
+	for _, tt := range tests {
+		if got := PodBelongsTo(tt.kind, tt.name, tt.pod); got != tt.want {
+			t.Errorf("PodBelongsTo(%s, %s, %s) = %v; want %v", tt.kind, tt.name, tt.pod, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWindow is the usage history Prometheus queries look back over.
+const DefaultWindow = 7 * 24 * time.Hour
+
+// PrometheusClient queries the container usage recorded by cAdvisor
+// (container_cpu_usage_seconds_total, container_memory_working_set_bytes)
+// from the Prometheus HTTP API.
+type PrometheusClient struct {
+	// URL is the Prometheus server URL, e.g. http://prometheus:9090.
+	URL string
+
+	// Window is the usage history to look back over (DefaultWindow when zero).
+	Window time.Duration
+
+	// Client is the HTTP client (a client with a 30s timeout when nil).
+	Client *http.Client
+}
+
+// Usage returns per-container usage over the window: the 95th percentile of
+// the 5-minute CPU rate and the peak memory working set. An empty namespaces
+// list queries all namespaces.
+func (c *PrometheusClient) Usage(ctx context.Context, namespaces []string) ([]ContainerUsage, error) {
+	window := c.Window
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	selector := `container!="",container!="POD"`
+	if len(namespaces) > 0 {
+		quoted := make([]string, len(namespaces))
+		for i, ns := range namespaces {
+			quoted[i] = regexp.QuoteMeta(ns)
+		}
+		selector += `,namespace=~"` + strings.Join(quoted, "|") + `"`
+	}
+	rangeSel := fmt.Sprintf("%ds", int64(window.Seconds()))
+
+	cpu, err := c.query(ctx, fmt.Sprintf(
+		"max by (namespace, pod, container) (quantile_over_time(0.95, rate(container_cpu_usage_seconds_total{%s}[5m])[%s:5m]))",
+		selector, rangeSel))
+	if err != nil {
+		return nil, fmt.Errorf("CPU usage: %w", err)
+	}
+	memory, err := c.query(ctx, fmt.Sprintf(
+		"max by (namespace, pod, container) (max_over_time(container_memory_working_set_bytes{%s}[%s]))",
+		selector, rangeSel))
+	if err != nil {
+		return nil, fmt.Errorf("memory usage: %w", err)
+	}
+
+	byKey := make(map[string]*ContainerUsage)
+	var usage []*ContainerUsage
+	sample := func(s promSample) *ContainerUsage {
+		key := s.Metric["namespace"] + "/" + s.Metric["pod"] + "/" + s.Metric["container"]
+		if u, ok := byKey[key]; ok {
+			return u
+		}
+		u := &ContainerUsage{Namespace: s.Metric["namespace"], Pod: s.Metric["pod"], Container: s.Metric["container"]}
+		byKey[key] = u
+		usage = append(usage, u)
+		return u
+	}
+	for _, s := range cpu {
+		sample(s).CPUMillicores = int64(s.value * 1000)
+	}
+	for _, s := range memory {
+		sample(s).MemoryBytes = int64(s.value)
+	}
+
+	result := make([]ContainerUsage, len(usage))
+	for i, u := range usage {
+		result[i] = *u
+	}
+	return result, nil
+}
+
+// promSample is one series of an instant vector query result.
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+	value  float64
+}
+
+type promResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string       `json:"resultType"`
+		Result     []promSample `json:"result"`
+	} `json:"data"`
+}
+
+// query runs an instant query and returns its vector samples.
+func (c *PrometheusClient) query(ctx context.Context, query string) ([]promSample, error) {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	endpoint := strings.TrimRight(c.URL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var parsed promResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("GET %s: %s", c.URL, resp.Status)
+	}
+	if parsed.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", parsed.Error)
+	}
+	if parsed.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("prometheus query returned %s, expected vector", parsed.Data.ResultType)
+	}
+
+	samples := parsed.Data.Result[:0]
+	for _, s := range parsed.Data.Result {
+		if len(s.Value) != 2 {
+			continue
+		}
+		str, _ := s.Value[1].(string)
+		v, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			continue
+		}
+		s.value = v
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
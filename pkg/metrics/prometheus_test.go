@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func promVector(samples ...[]interface{}) map[string]interface{} {
+	result := make([]interface{}, 0, len(samples))
+	for _, s := range samples {
+		result = append(result, map[string]interface{}{
+			"metric": map[string]string{"namespace": s[0].(string), "pod": s[1].(string), "container": s[2].(string)},
+			"value":  []interface{}{1700000000.0, s[3]},
+		})
+	}
+	return map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "vector", "result": result},
+	}
+}
+
+func TestPrometheusClient_Usage(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		var resp map[string]interface{}
+		if strings.Contains(query, "container_cpu_usage_seconds_total") {
+			resp = promVector([]interface{}{"prod", "web-0", "web", "0.25"})
+		} else {
+			resp = promVector(
+				[]interface{}{"prod", "web-0", "web", "268435456"},
+				[]interface{}{"prod", "web-1", "web", "134217728"},
+			)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := &PrometheusClient{URL: server.URL + "/", Window: 24 * time.Hour}
+	usage, err := client.Usage(context.Background(), []string{"prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ContainerUsage{
+		{Namespace: "prod", Pod: "web-0", Container: "web", CPUMillicores: 250, MemoryBytes: 256 << 20},
+		{Namespace: "prod", Pod: "web-1", Container: "web", MemoryBytes: 128 << 20},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("got %+v; want %+v", usage, want)
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Errorf("usage[%d] = %+v; want %+v", i, usage[i], want[i])
+		}
+	}
+
+	if len(queries) != 2 {
+		t.Fatalf("got %d queries; want 2", len(queries))
+	}
+	for _, q := range queries {
+		if !strings.Contains(q, `namespace=~"prod"`) || !strings.Contains(q, "[86400s") {
+			t.Errorf("query %q should filter the namespace and look back 24h", q)
+		}
+	}
+}
+
+func TestPrometheusClient_QueryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "error", "error": "parse error"})
+	}))
+	defer server.Close()
+
+	client := &PrometheusClient{URL: server.URL}
+	_, err := client.Usage(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("expected the Prometheus error, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// CheckRequest is sent to the `check` command.
+type CheckRequest struct {
+	// Checker is the name of the checker from the manifest.
+	Checker string `json:"checker"`
+
+	// Resources are all processed resources, sorted by key.
+	Resources []WireResource `json:"resources"`
+
+	// Relationships are all detected relationships.
+	Relationships []WireRelationship `json:"relationships"`
+}
+
+// CheckResponse is returned by the `check` command.
+type CheckResponse struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is the JSON form of a pattern.BestPractice.
+type Finding struct {
+	ID                string    `json:"id"`
+	Title             string    `json:"title"`
+	Description       string    `json:"description,omitempty"`
+	Severity          string    `json:"severity"`
+	Compliant         bool      `json:"compliant"`
+	Recommendations   []string  `json:"recommendations,omitempty"`
+	AffectedResources []WireKey `json:"affectedResources,omitempty"`
+	AutoFixable       bool      `json:"autoFixable,omitempty"`
+}
+
+// checkerAdapter exposes a plugin checker as a pattern.BestPracticeChecker.
+type checkerAdapter struct {
+	plugin *Plugin
+	spec   CheckerSpec
+}
+
+func newCheckerAdapter(p *Plugin, spec CheckerSpec) *checkerAdapter {
+	return &checkerAdapter{plugin: p, spec: spec}
+}
+
+// Name returns the checker name, prefixed with the plugin name.
+func (c *checkerAdapter) Name() string {
+	return c.plugin.Name() + "/" + c.spec.Name
+}
+
+// Category returns the category declared in the manifest.
+func (c *checkerAdapter) Category() string {
+	return c.spec.Category
+}
+
+// Check sends the graph to the plugin and converts returned findings.
+func (c *checkerAdapter) Check(graph *types.ResourceGraph) []pattern.BestPractice {
+	req := CheckRequest{
+		Checker:       c.spec.Name,
+		Resources:     toWireResources(graph.Resources),
+		Relationships: make([]WireRelationship, 0, len(graph.Relationships)),
+	}
+	for _, rel := range graph.Relationships {
+		req.Relationships = append(req.Relationships, toWireRelationship(rel))
+	}
+
+	var resp CheckResponse
+	if err := c.plugin.call(context.Background(), CommandCheck, req, &resp); err != nil {
+		c.plugin.reportError(err)
+		return nil
+	}
+
+	practices := make([]pattern.BestPractice, 0, len(resp.Findings))
+	for _, f := range resp.Findings {
+		bp := pattern.BestPractice{
+			ID:              f.ID,
+			Title:           f.Title,
+			Description:     f.Description,
+			Category:        c.spec.Category,
+			Severity:        pattern.Severity(f.Severity),
+			Compliant:       f.Compliant,
+			Recommendations: f.Recommendations,
+			AutoFixable:     f.AutoFixable,
+		}
+		if bp.Severity == "" {
+			bp.Severity = pattern.SeverityWarning
+		}
+		for _, k := range f.AffectedResources {
+			bp.AffectedResources = append(bp.AffectedResources, k.ResourceKey())
+		}
+		practices = append(practices, bp)
+	}
+	return practices
+}
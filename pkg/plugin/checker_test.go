@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func TestRegisterCheckers_ReportsFindings(t *testing.T) {
+	p := loadFake(t)
+	a := pattern.NewAnalyzer()
+	p.RegisterCheckers(a)
+
+	graph := types.NewResourceGraph()
+	weak := processedTopic("weak", 1)
+	strong := processedTopic("strong", 3)
+	graph.AddResource(weak)
+	graph.AddResource(strong)
+
+	result := a.Analyze(graph)
+
+	var found *pattern.BestPractice
+	for i := range result.BestPractices {
+		if result.BestPractices[i].ID == "KAFKA-001" {
+			found = &result.BestPractices[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("expected KAFKA-001 finding, got %+v", result.BestPractices)
+	}
+	if found.Compliant {
+		t.Error("expected finding to be non-compliant")
+	}
+	if found.Category != "reliability" {
+		t.Errorf("expected category from manifest, got %q", found.Category)
+	}
+	if len(found.AffectedResources) != 1 || found.AffectedResources[0] != weak.Original.ResourceKey() {
+		t.Errorf("expected only the weak topic to be affected, got %v", found.AffectedResources)
+	}
+}
+
+func TestCheckerAdapter_DefaultsSeverity(t *testing.T) {
+	path := writeScript(t, `echo '{"findings":[{"id":"X-1","title":"t"}]}'`)
+	p := &Plugin{Path: path, Manifest: Manifest{Name: "x"}}
+	c := newCheckerAdapter(p, CheckerSpec{Name: "c", Category: "custom"})
+
+	practices := c.Check(types.NewResourceGraph())
+	if len(practices) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(practices))
+	}
+	if practices[0].Severity != pattern.SeverityWarning {
+		t.Errorf("expected default severity warning, got %q", practices[0].Severity)
+	}
+	if c.Name() != "x/c" {
+		t.Errorf("unexpected checker name %q", c.Name())
+	}
+}
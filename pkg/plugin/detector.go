@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DetectRequest is sent to the `detect` command. The plugin receives every
+// processed resource at once and returns all relationships it finds, so it is
+// invoked once per analysis rather than once per resource.
+type DetectRequest struct {
+	// Detector is the name of the detector from the manifest.
+	Detector string `json:"detector"`
+
+	// Resources are all processed resources, sorted by key.
+	Resources []WireResource `json:"resources"`
+}
+
+// DetectResponse is returned by the `detect` command.
+type DetectResponse struct {
+	Relationships []WireRelationship `json:"relationships"`
+}
+
+// detectorAdapter exposes a plugin detector as an analyzer.Detector.
+type detectorAdapter struct {
+	plugin   *Plugin
+	spec     DetectorSpec
+	mu       sync.Mutex
+	cacheFor string
+	byFrom   map[types.ResourceKey][]types.Relationship
+}
+
+func newDetectorAdapter(p *Plugin, spec DetectorSpec) *detectorAdapter {
+	return &detectorAdapter{plugin: p, spec: spec}
+}
+
+// Name returns the detector name, prefixed with the plugin name.
+func (d *detectorAdapter) Name() string {
+	return d.plugin.Name() + "/" + d.spec.Name
+}
+
+// Priority returns the detector priority declared in the manifest.
+func (d *detectorAdapter) Priority() int {
+	return d.spec.Priority
+}
+
+// Detect returns relationships originating from resource. The plugin is run
+// on the first call for a given resource set and the results are cached.
+func (d *detectorAdapter) Detect(ctx context.Context, resource *types.ProcessedResource, allResources map[types.ResourceKey]*types.ProcessedResource) []types.Relationship {
+	if resource == nil || resource.Original == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// The analyzer passes the same map for every resource of one run.
+	identity := fmt.Sprintf("%p", allResources)
+	if d.cacheFor != identity {
+		d.byFrom = d.detectAll(ctx, allResources)
+		d.cacheFor = identity
+	}
+
+	return d.byFrom[resource.Original.ResourceKey()]
+}
+
+// detectAll invokes the plugin and indexes the returned relationships by source.
+func (d *detectorAdapter) detectAll(ctx context.Context, allResources map[types.ResourceKey]*types.ProcessedResource) map[types.ResourceKey][]types.Relationship {
+	req := DetectRequest{
+		Detector:  d.spec.Name,
+		Resources: toWireResources(allResources),
+	}
+
+	var resp DetectResponse
+	if err := d.plugin.call(ctx, CommandDetect, req, &resp); err != nil {
+		d.plugin.reportError(err)
+		return nil
+	}
+
+	byFrom := make(map[types.ResourceKey][]types.Relationship)
+	for _, wr := range resp.Relationships {
+		rel := wr.Relationship()
+		if _, ok := allResources[rel.From]; !ok {
+			d.plugin.reportError(fmt.Errorf("plugin %q detect: relationship source %s is not a known resource", d.plugin.Path, rel.From))
+			continue
+		}
+		byFrom[rel.From] = append(byFrom[rel.From], rel)
+	}
+	return byFrom
+}
@@ -0,0 +1,103 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func processedTopic(name string, replicas int64) *types.ProcessedResource {
+	obj := kafkaTopic(name, replicas)
+	return &types.ProcessedResource{
+		Original: &types.ExtractedResource{
+			Object: obj,
+			Source: types.SourceFile,
+			GVK:    obj.GroupVersionKind(),
+		},
+		ServiceName: "orders",
+	}
+}
+
+func processedKafka(name string) *types.ProcessedResource {
+	obj := kafkaTopic(name, 3)
+	obj.SetKind("Kafka")
+	obj.SetLabels(nil)
+	return &types.ProcessedResource{
+		Original: &types.ExtractedResource{
+			Object: obj,
+			Source: types.SourceFile,
+			GVK:    obj.GroupVersionKind(),
+		},
+		ServiceName: "kafka",
+	}
+}
+
+func TestRegisterDetectors_AddsRelationships(t *testing.T) {
+	p := loadFake(t)
+	a := analyzer.NewDefaultAnalyzer()
+	p.RegisterDetectors(a)
+
+	topic := processedTopic("orders", 3)
+	cluster := processedKafka("main")
+
+	graph, err := a.Analyze(context.Background(), []*types.ProcessedResource{topic, cluster})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if len(graph.Relationships) != 1 {
+		t.Fatalf("expected 1 relationship, got %d: %+v", len(graph.Relationships), graph.Relationships)
+	}
+	rel := graph.Relationships[0]
+	if rel.From != topic.Original.ResourceKey() || rel.To != cluster.Original.ResourceKey() {
+		t.Errorf("unexpected relationship %s -> %s", rel.From, rel.To)
+	}
+	if rel.Type != types.RelationCustomDependency {
+		t.Errorf("expected type %q, got %q", types.RelationCustomDependency, rel.Type)
+	}
+}
+
+func TestDetectorAdapter_InvokesPluginOncePerRun(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "calls")
+	path := writeScript(t, `echo call >> '`+counter+`'; echo '{"relationships":[]}'`)
+	p := &Plugin{Path: path, Manifest: Manifest{Name: "counter"}}
+	d := newDetectorAdapter(p, DetectorSpec{Name: "d"})
+
+	all := map[types.ResourceKey]*types.ProcessedResource{}
+	for _, r := range []*types.ProcessedResource{processedTopic("a", 1), processedTopic("b", 1), processedTopic("c", 1)} {
+		all[r.Original.ResourceKey()] = r
+	}
+	for _, r := range all {
+		d.Detect(context.Background(), r, all)
+	}
+
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	if calls := strings.Count(string(data), "call"); calls != 1 {
+		t.Errorf("expected 1 plugin invocation for 3 resources, got %d", calls)
+	}
+}
+
+func TestDetectorAdapter_ReportsErrors(t *testing.T) {
+	path := writeScript(t, `exit 1`)
+	var got error
+	p := &Plugin{Path: path, Manifest: Manifest{Name: "broken"}, OnError: func(err error) { got = err }}
+	d := newDetectorAdapter(p, DetectorSpec{Name: "d"})
+
+	r := processedTopic("a", 1)
+	rels := d.Detect(context.Background(), r, map[types.ResourceKey]*types.ProcessedResource{r.Original.ResourceKey(): r})
+
+	if rels != nil {
+		t.Errorf("expected no relationships, got %v", rels)
+	}
+	if got == nil {
+		t.Error("expected error to be reported via OnError")
+	}
+}
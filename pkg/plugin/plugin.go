@@ -0,0 +1,227 @@
+// Package plugin loads external plugin executables and adapts them to the
+// processor, relationship detector and best-practice checker registries.
+//
+// Plugins follow the subprocess JSON protocol (ADR-022): the executable is
+// invoked with a single command argument, reads a JSON request from stdin and
+// writes a JSON response to stdout. Supported commands:
+//
+//	describe  → Manifest (no stdin)
+//	process   → ProcessRequest  / ProcessResponse
+//	detect    → DetectRequest   / DetectResponse
+//	check     → CheckRequest    / CheckResponse
+//
+// A plugin only needs to implement the commands for the components it
+// declares in its manifest.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/analyzer/pattern"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+// ProtocolVersion is the plugin protocol version understood by this package.
+const ProtocolVersion = "v1"
+
+// DefaultTimeout bounds a single plugin invocation.
+const DefaultTimeout = 30 * time.Second
+
+// Plugin command names.
+const (
+	CommandDescribe = "describe"
+	CommandProcess  = "process"
+	CommandDetect   = "detect"
+	CommandCheck    = "check"
+)
+
+// Manifest is returned by the `describe` command and declares the components
+// a plugin provides.
+type Manifest struct {
+	// Name is the plugin name (defaults to the executable basename).
+	Name string `json:"name"`
+
+	// Version is the plugin version string (optional).
+	Version string `json:"version,omitempty"`
+
+	// ProtocolVersion is the protocol the plugin implements (empty means v1).
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+
+	// Processors lists resource processors provided by the plugin.
+	Processors []ProcessorSpec `json:"processors,omitempty"`
+
+	// Detectors lists relationship detectors provided by the plugin.
+	Detectors []DetectorSpec `json:"detectors,omitempty"`
+
+	// Checkers lists best-practice checkers provided by the plugin.
+	Checkers []CheckerSpec `json:"checkers,omitempty"`
+}
+
+// ProcessorSpec declares a plugin processor.
+type ProcessorSpec struct {
+	Name     string    `json:"name"`
+	Priority int       `json:"priority"`
+	GVKs     []WireGVK `json:"gvks"`
+}
+
+// DetectorSpec declares a plugin relationship detector.
+type DetectorSpec struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// CheckerSpec declares a plugin best-practice checker.
+type CheckerSpec struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// Plugin is a loaded plugin executable.
+type Plugin struct {
+	// Path is the path to the executable.
+	Path string
+
+	// Manifest is the plugin's self-description.
+	Manifest Manifest
+
+	// Timeout bounds each invocation; zero means DefaultTimeout.
+	Timeout time.Duration
+
+	// OnError receives errors from adapters whose interfaces cannot return
+	// them (detectors and checkers). Nil discards such errors.
+	OnError func(error)
+}
+
+// Load runs the plugin's `describe` command and returns the loaded plugin.
+func Load(ctx context.Context, path string, timeout time.Duration) (*Plugin, error) {
+	p := &Plugin{Path: path, Timeout: timeout}
+
+	var manifest Manifest
+	if err := p.call(ctx, CommandDescribe, nil, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.ProtocolVersion != "" && manifest.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("plugin %q: unsupported protocol version %q (want %s)", path, manifest.ProtocolVersion, ProtocolVersion)
+	}
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(path)
+	}
+	for _, spec := range manifest.Processors {
+		if len(spec.GVKs) == 0 {
+			return nil, fmt.Errorf("plugin %q: processor %q declares no gvks", path, spec.Name)
+		}
+	}
+
+	p.Manifest = manifest
+	return p, nil
+}
+
+// LoadAll loads the given plugin executables and every executable found in
+// the given directories. Directories are scanned with generator.DiscoverPlugins.
+func LoadAll(ctx context.Context, paths, dirs []string, timeout time.Duration) ([]*Plugin, error) {
+	all := append([]string{}, paths...)
+	for _, dir := range dirs {
+		found, err := generator.DiscoverPlugins(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range found {
+			all = append(all, info.Path)
+		}
+	}
+
+	plugins := make([]*Plugin, 0, len(all))
+	for _, path := range all {
+		p, err := Load(ctx, path, timeout)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Name returns the plugin name from its manifest.
+func (p *Plugin) Name() string {
+	return p.Manifest.Name
+}
+
+// RegisterProcessors adds the plugin's processors to reg.
+func (p *Plugin) RegisterProcessors(reg *processor.Registry) {
+	for _, spec := range p.Manifest.Processors {
+		reg.Register(newProcessorAdapter(p, spec))
+	}
+}
+
+// RegisterDetectors adds the plugin's relationship detectors to a.
+func (p *Plugin) RegisterDetectors(a *analyzer.DefaultAnalyzer) {
+	for _, spec := range p.Manifest.Detectors {
+		a.AddDetector(newDetectorAdapter(p, spec))
+	}
+}
+
+// RegisterCheckers adds the plugin's best-practice checkers to a.
+func (p *Plugin) RegisterCheckers(a *pattern.Analyzer) {
+	for _, spec := range p.Manifest.Checkers {
+		a.AddChecker(newCheckerAdapter(p, spec))
+	}
+}
+
+// call invokes the plugin with command, sending req as JSON on stdin (when
+// non-nil) and decoding stdout into resp.
+func (p *Plugin) call(ctx context.Context, command string, req, resp interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdin []byte
+	if req != nil {
+		var err error
+		stdin, err = json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("plugin %q %s: marshal request: %w", p.Path, command, err)
+		}
+	}
+
+	proc := exec.CommandContext(ctx, p.Path, command) //nolint:gosec
+	proc.Stdin = bytes.NewReader(stdin)
+	// Don't wait on pipes held open by children of a killed plugin.
+	proc.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	proc.Stdout = &stdout
+	proc.Stderr = &stderr
+
+	if err := proc.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("plugin %q %s: timeout after %v", p.Path, command, timeout)
+		}
+		return fmt.Errorf("plugin %q %s: %w (stderr: %s)", p.Path, command, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return fmt.Errorf("plugin %q %s: decode response: %w", p.Path, command, err)
+	}
+	return nil
+}
+
+// reportError forwards err to OnError when set.
+func (p *Plugin) reportError(err error) {
+	if p.OnError != nil && err != nil {
+		p.OnError(err)
+	}
+}
@@ -0,0 +1,236 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+// fakePluginPath is the compiled fake plugin shared by all tests in the package.
+var fakePluginPath string
+
+// fakePluginSrc implements every protocol command for a Strimzi-like KafkaTopic CRD.
+const fakePluginSrc = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type key struct {
+	APIVersion string ` + "`json:\"apiVersion\"`" + `
+	Kind       string ` + "`json:\"kind\"`" + `
+	Namespace  string ` + "`json:\"namespace,omitempty\"`" + `
+	Name       string ` + "`json:\"name\"`" + `
+}
+
+type resource struct {
+	Key    key                    ` + "`json:\"key\"`" + `
+	Object map[string]interface{} ` + "`json:\"object\"`" + `
+}
+
+func main() {
+	var in map[string]interface{}
+	if os.Args[1] != "describe" {
+		if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	out := json.NewEncoder(os.Stdout)
+
+	switch os.Args[1] {
+	case "describe":
+		out.Encode(map[string]interface{}{
+			"name":    "strimzi",
+			"version": "0.1.0",
+			"processors": []interface{}{map[string]interface{}{
+				"name": "kafka-topic", "priority": 200,
+				"gvks": []interface{}{map[string]string{"group": "kafka.strimzi.io", "version": "v1beta2", "kind": "KafkaTopic"}},
+			}},
+			"detectors": []interface{}{map[string]interface{}{"name": "topic-cluster", "priority": 10}},
+			"checkers":  []interface{}{map[string]interface{}{"name": "topic-replicas", "category": "reliability"}},
+		})
+	case "process":
+		obj := in["object"].(map[string]interface{})
+		meta := obj["metadata"].(map[string]interface{})
+		if meta["name"] == "skip" {
+			out.Encode(map[string]interface{}{"processed": false})
+			return
+		}
+		spec, _ := obj["spec"].(map[string]interface{})
+		out.Encode(map[string]interface{}{
+			"processed":       true,
+			"templateContent": "kind: KafkaTopic # " + in["serviceName"].(string),
+			"values":          map[string]interface{}{"partitions": spec["partitions"]},
+		})
+	case "detect":
+		var req struct{ Resources []resource }
+		b, _ := json.Marshal(in)
+		json.Unmarshal(b, &req)
+		var rels []interface{}
+		for _, r := range req.Resources {
+			if r.Key.Kind != "KafkaTopic" {
+				continue
+			}
+			labels, _ := r.Object["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+			cluster, _ := labels["strimzi.io/cluster"].(string)
+			if cluster == "" {
+				continue
+			}
+			rels = append(rels, map[string]interface{}{
+				"from": r.Key,
+				"to":   key{APIVersion: "kafka.strimzi.io/v1beta2", Kind: "Kafka", Namespace: r.Key.Namespace, Name: cluster},
+				"type": "custom_dependency",
+				"field": "metadata.labels[strimzi.io/cluster]",
+			})
+		}
+		out.Encode(map[string]interface{}{"relationships": rels})
+	case "check":
+		var req struct{ Resources []resource }
+		b, _ := json.Marshal(in)
+		json.Unmarshal(b, &req)
+		var affected []key
+		for _, r := range req.Resources {
+			spec, _ := r.Object["spec"].(map[string]interface{})
+			if replicas, _ := spec["replicas"].(float64); r.Key.Kind == "KafkaTopic" && replicas < 3 {
+				affected = append(affected, r.Key)
+			}
+		}
+		out.Encode(map[string]interface{}{"findings": []interface{}{map[string]interface{}{
+			"id": "KAFKA-001", "title": "Topic replication factor below 3",
+			"severity": "warning", "compliant": len(affected) == 0, "affectedResources": affected,
+		}}})
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command", os.Args[1])
+		os.Exit(1)
+	}
+}
+`
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "dhg-plugin-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	src := filepath.Join(dir, "main.go")
+	fakePluginPath = filepath.Join(dir, "dhg-strimzi")
+	if runtime.GOOS == "windows" {
+		fakePluginPath += ".exe"
+	}
+	if err := os.WriteFile(src, []byte(fakePluginSrc), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if out, err := exec.Command("go", "build", "-o", fakePluginPath, src).CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "build fake plugin: %v\n%s", err, out)
+		os.Exit(1)
+	}
+	// Keep the plugin directory free of the source file for LoadAll tests.
+	_ = os.Remove(src)
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// writeScript creates an executable shell script plugin.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return path
+}
+
+func loadFake(t *testing.T) *Plugin {
+	t.Helper()
+	p, err := Load(context.Background(), fakePluginPath, 0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return p
+}
+
+// ── Load ──────────────────────────────────────────────────────────────────────
+
+func TestLoad_Manifest(t *testing.T) {
+	p := loadFake(t)
+
+	if p.Name() != "strimzi" {
+		t.Errorf("expected name %q, got %q", "strimzi", p.Name())
+	}
+	if len(p.Manifest.Processors) != 1 || len(p.Manifest.Detectors) != 1 || len(p.Manifest.Checkers) != 1 {
+		t.Errorf("unexpected manifest components: %+v", p.Manifest)
+	}
+}
+
+func TestLoad_DefaultsNameToBasename(t *testing.T) {
+	path := writeScript(t, `echo '{"checkers":[{"name":"c","category":"x"}]}'`)
+	p, err := Load(context.Background(), path, time.Second)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Name() != "plugin.sh" {
+		t.Errorf("expected basename name, got %q", p.Name())
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		wantErr string
+	}{
+		{"non-zero exit", `echo boom >&2; exit 3`, time.Second, "boom"},
+		{"invalid json", `echo not-json`, time.Second, "decode response"},
+		{"protocol mismatch", `echo '{"name":"x","protocolVersion":"v9"}'`, time.Second, "protocol version"},
+		{"processor without gvks", `echo '{"name":"x","processors":[{"name":"p"}]}'`, time.Second, "declares no gvks"},
+		{"timeout", `exec sleep 5`, 100 * time.Millisecond, "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScript(t, tt.script)
+			_, err := Load(context.Background(), path, tt.timeout)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadAll_Directory(t *testing.T) {
+	plugins, err := LoadAll(context.Background(), nil, []string{filepath.Dir(fakePluginPath)}, 0)
+	if err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Name() != "strimzi" {
+		t.Errorf("expected the strimzi plugin, got %v", plugins)
+	}
+}
+
+func TestLoadAll_MissingDirectory(t *testing.T) {
+	if _, err := LoadAll(context.Background(), nil, []string{"/nonexistent/plugins"}, 0); err == nil {
+		t.Fatal("expected error for missing plugin directory")
+	}
+}
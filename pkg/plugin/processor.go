@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+// ProcessRequest is sent to the `process` command.
+type ProcessRequest struct {
+	// Processor is the name of the processor from the manifest.
+	Processor string `json:"processor"`
+
+	// ChartName is the name of the chart being generated.
+	ChartName string `json:"chartName"`
+
+	// ServiceName is the service name assigned by the pipeline.
+	ServiceName string `json:"serviceName"`
+
+	// Object is the Kubernetes resource.
+	Object map[string]interface{} `json:"object"`
+}
+
+// ProcessResponse is returned by the `process` command.
+type ProcessResponse struct {
+	// Processed is false when the plugin declines the resource; the next
+	// processor (or the generic fallback) is tried.
+	Processed bool `json:"processed"`
+
+	ServiceName     string                 `json:"serviceName,omitempty"`
+	TemplatePath    string                 `json:"templatePath"`
+	TemplateContent string                 `json:"templateContent"`
+	ValuesPath      string                 `json:"valuesPath,omitempty"`
+	Values          map[string]interface{} `json:"values,omitempty"`
+	Dependencies    []WireKey              `json:"dependencies,omitempty"`
+}
+
+// processorAdapter exposes a plugin processor as a processor.Processor.
+type processorAdapter struct {
+	processor.BaseProcessor
+	plugin *Plugin
+	spec   string
+}
+
+func newProcessorAdapter(p *Plugin, spec ProcessorSpec) *processorAdapter {
+	gvks := make([]schema.GroupVersionKind, 0, len(spec.GVKs))
+	for _, g := range spec.GVKs {
+		gvks = append(gvks, g.GVK())
+	}
+
+	return &processorAdapter{
+		BaseProcessor: processor.NewBaseProcessor(p.Name()+"/"+spec.Name, spec.Priority, gvks...),
+		plugin:        p,
+		spec:          spec.Name,
+	}
+}
+
+// Process sends the resource to the plugin and converts its response.
+func (a *processorAdapter) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
+
+	req := ProcessRequest{
+		Processor:   a.spec,
+		ChartName:   ctx.ChartName,
+		ServiceName: serviceName,
+		Object:      obj.Object,
+	}
+
+	var resp ProcessResponse
+	if err := a.plugin.call(ctx.Ctx, CommandProcess, req, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Processed {
+		return &processor.Result{Processed: false}, nil
+	}
+
+	if resp.ServiceName == "" {
+		resp.ServiceName = serviceName
+	}
+	if resp.TemplatePath == "" {
+		resp.TemplatePath = processor.TemplatePathForResource(obj.GetKind(), obj.GetName(), obj.GetNamespace())
+	}
+	if resp.ValuesPath == "" {
+		resp.ValuesPath = processor.ValuesPathForKind(obj.GetKind(), resp.ServiceName)
+	}
+
+	result := &processor.Result{
+		Processed:       true,
+		ServiceName:     resp.ServiceName,
+		TemplatePath:    resp.TemplatePath,
+		TemplateContent: resp.TemplateContent,
+		ValuesPath:      resp.ValuesPath,
+		Values:          resp.Values,
+		Metadata:        map[string]interface{}{"plugin": a.plugin.Name()},
+	}
+	for _, dep := range resp.Dependencies {
+		result.Dependencies = append(result.Dependencies, dep.ResourceKey())
+	}
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+func kafkaTopic(name string, replicas int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kafka.strimzi.io/v1beta2",
+		"kind":       "KafkaTopic",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "kafka",
+			"labels": map[string]interface{}{
+				"app":                "orders",
+				"strimzi.io/cluster": "main",
+			},
+		},
+		"spec": map[string]interface{}{
+			"partitions": int64(6),
+			"replicas":   replicas,
+		},
+	}}
+	return obj
+}
+
+func TestRegisterProcessors_HandlesDeclaredGVK(t *testing.T) {
+	p := loadFake(t)
+	reg := processor.NewRegistry()
+	p.RegisterProcessors(reg)
+
+	gvk := schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaTopic"}
+	proc, ok := reg.GetProcessor(gvk)
+	if !ok {
+		t.Fatal("expected a processor for KafkaTopic")
+	}
+	if proc.Name() != "strimzi/kafka-topic" {
+		t.Errorf("unexpected processor name %q", proc.Name())
+	}
+	if proc.Priority() != 200 {
+		t.Errorf("expected priority 200, got %d", proc.Priority())
+	}
+}
+
+func TestProcessorAdapter_Process(t *testing.T) {
+	p := loadFake(t)
+	reg := processor.NewRegistry()
+	p.RegisterProcessors(reg)
+
+	ctx := processor.Context{Ctx: context.Background(), ChartName: "demo", ServiceName: "order-events"}
+	result, err := reg.Process(ctx, kafkaTopic("orders", 3))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if !result.Processed {
+		t.Fatal("expected resource to be processed")
+	}
+	if result.ServiceName != "orderEvents" {
+		t.Errorf("expected sanitized assigned service name, got %q", result.ServiceName)
+	}
+	if !strings.Contains(result.TemplateContent, "# orderEvents") {
+		t.Errorf("expected plugin template, got %q", result.TemplateContent)
+	}
+	if result.TemplatePath != "templates/kafkatopic-orders.yaml" {
+		t.Errorf("expected default template path, got %q", result.TemplatePath)
+	}
+	if result.ValuesPath != "services.orderEvents.kafkaTopic" {
+		t.Errorf("expected default values path, got %q", result.ValuesPath)
+	}
+	if result.Values["partitions"] != float64(6) {
+		t.Errorf("expected partitions 6, got %v", result.Values["partitions"])
+	}
+}
+
+func TestProcessorAdapter_DeclinedFallsBackToGeneric(t *testing.T) {
+	p := loadFake(t)
+	reg := processor.NewRegistry()
+	p.RegisterProcessors(reg)
+
+	result, err := reg.Process(processor.Context{Ctx: context.Background()}, kafkaTopic("skip", 3))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !result.Processed {
+		t.Fatal("expected generic fallback to process the resource")
+	}
+	if strings.Contains(result.TemplateContent, "# ") {
+		t.Errorf("expected generic template, got plugin output %q", result.TemplateContent)
+	}
+}
@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// WireGVK is the JSON form of a GroupVersionKind.
+type WireGVK struct {
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// WireKey is the JSON form of a types.ResourceKey.
+type WireKey struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// WireResource is a processed resource as sent to detectors and checkers.
+type WireResource struct {
+	Key         WireKey                `json:"key"`
+	ServiceName string                 `json:"serviceName"`
+	Object      map[string]interface{} `json:"object"`
+	Values      map[string]interface{} `json:"values,omitempty"`
+}
+
+// WireRelationship is the JSON form of a types.Relationship.
+type WireRelationship struct {
+	From    WireKey           `json:"from"`
+	To      WireKey           `json:"to"`
+	Type    string            `json:"type"`
+	Field   string            `json:"field,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// GVK converts the wire form to a schema.GroupVersionKind.
+func (g WireGVK) GVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: g.Group, Version: g.Version, Kind: g.Kind}
+}
+
+// toWireKey converts a resource key to its wire form.
+func toWireKey(k types.ResourceKey) WireKey {
+	return WireKey{
+		APIVersion: k.GVK.GroupVersion().String(),
+		Kind:       k.GVK.Kind,
+		Namespace:  k.Namespace,
+		Name:       k.Name,
+	}
+}
+
+// ResourceKey converts the wire form back to a types.ResourceKey.
+func (k WireKey) ResourceKey() types.ResourceKey {
+	gv, err := schema.ParseGroupVersion(k.APIVersion)
+	if err != nil {
+		gv = schema.GroupVersion{Version: k.APIVersion}
+	}
+	return types.ResourceKey{
+		GVK:       gv.WithKind(k.Kind),
+		Namespace: k.Namespace,
+		Name:      k.Name,
+	}
+}
+
+// toWireRelationship converts a relationship to its wire form.
+func toWireRelationship(r types.Relationship) WireRelationship {
+	return WireRelationship{
+		From:    toWireKey(r.From),
+		To:      toWireKey(r.To),
+		Type:    string(r.Type),
+		Field:   r.Field,
+		Details: r.Details,
+	}
+}
+
+// Relationship converts the wire form back to a types.Relationship.
+func (r WireRelationship) Relationship() types.Relationship {
+	return types.Relationship{
+		From:    r.From.ResourceKey(),
+		To:      r.To.ResourceKey(),
+		Type:    types.RelationshipType(r.Type),
+		Field:   r.Field,
+		Details: r.Details,
+	}
+}
+
+// toWireResources converts processed resources to their wire form, sorted by
+// key so plugins see a deterministic order.
+func toWireResources(resources map[types.ResourceKey]*types.ProcessedResource) []WireResource {
+	out := make([]WireResource, 0, len(resources))
+	for key, r := range resources {
+		wr := WireResource{
+			Key:         toWireKey(key),
+			ServiceName: r.ServiceName,
+			Values:      r.Values,
+		}
+		if r.Original != nil && r.Original.Object != nil {
+			wr.Object = r.Original.Object.Object
+		}
+		out = append(out, wr)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Key, out[j].Key
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return out
+}
@@ -0,0 +1,792 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Kyverno policies are evaluated in-process. The supported subset covers
+// validate rules with pattern, anyPattern and deny conditions, match and
+// exclude blocks (kinds, names, namespaces, label selectors, operations),
+// preconditions, and {{ request.object.* }} variables. Pod rules are applied
+// to the pod templates of workload controllers, as Kyverno's auto-generated
+// rules do. namespaceSelector cannot be evaluated without a cluster and is
+// ignored; other rule types (mutate, generate, foreach, cel, podSecurity,
+// verifyImages) are skipped.
+
+// podControllers maps workload kinds to the path of their pod template.
+var podControllers = map[string][]string{
+	"Deployment":            {"spec", "template"},
+	"StatefulSet":           {"spec", "template"},
+	"DaemonSet":             {"spec", "template"},
+	"ReplicaSet":            {"spec", "template"},
+	"ReplicationController": {"spec", "template"},
+	"Job":                   {"spec", "template"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+}
+
+// autogenAnnotation lists the controllers Pod rules are applied to.
+const autogenAnnotation = "pod-policies.kyverno.io/autogen-controllers"
+
+// variablePattern matches Kyverno {{ ... }} variables.
+var variablePattern = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+
+// kyvernoTarget is a resource a rule is evaluated against: the rendered
+// object itself, or the pod template of a controller as a Pod.
+type kyvernoTarget struct {
+	object map[string]interface{}
+
+	// pathPrefix locates object within the rendered resource.
+	pathPrefix string
+}
+
+// evaluateKyverno applies the validate rules of policy to obj.
+func evaluateKyverno(policy, obj *unstructured.Unstructured) []Violation {
+	if policy.GetKind() == "Policy" && obj.GetNamespace() != policy.GetNamespace() {
+		return nil
+	}
+	spec, _ := policy.Object["spec"].(map[string]interface{})
+	rules, _ := spec["rules"].([]interface{})
+	action, _ := spec["validationFailureAction"].(string)
+
+	var violations []Violation
+	for _, r := range rules {
+		rule, _ := r.(map[string]interface{})
+		validate, ok := rule["validate"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ruleAction := action
+		if a, ok := validate["failureAction"].(string); ok {
+			ruleAction = a
+		}
+		name, _ := rule["name"].(string)
+
+		for _, target := range kyvernoTargets(policy, rule, obj) {
+			message, err := evaluateRule(rule, validate, target)
+			if err == nil && message == "" {
+				continue
+			}
+			v := Violation{
+				Policy:   policy.GetName(),
+				Rule:     name,
+				Resource: ResourceName(obj),
+				Object:   obj,
+				Message:  message,
+				Enforced: strings.EqualFold(ruleAction, "Enforce"),
+			}
+			if err != nil {
+				v.Message = "rule cannot be evaluated: " + err.Error()
+				v.Enforced = false
+			}
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// kyvernoTargets returns what rule applies to: obj when the rule matches it,
+// and the pod template of a controller when the rule matches Pods.
+func kyvernoTargets(policy *unstructured.Unstructured, rule map[string]interface{}, obj *unstructured.Unstructured) []kyvernoTarget {
+	var targets []kyvernoTarget
+	if ruleMatches(rule, obj.Object) {
+		targets = append(targets, kyvernoTarget{object: obj.Object})
+	}
+
+	templatePath, ok := podControllers[obj.GetKind()]
+	if !ok {
+		return targets
+	}
+	if controllers, ok := policy.GetAnnotations()[autogenAnnotation]; ok {
+		if controllers == "none" || !containsWord(controllers, obj.GetKind()) {
+			return targets
+		}
+	}
+	template, found, _ := unstructured.NestedMap(obj.Object, templatePath...)
+	if !found {
+		return targets
+	}
+	metadata := map[string]interface{}{}
+	if m, ok := template["metadata"].(map[string]interface{}); ok {
+		for k, v := range m {
+			metadata[k] = v
+		}
+	}
+	if _, ok := metadata["name"]; !ok {
+		metadata["name"] = obj.GetName()
+	}
+	if ns := obj.GetNamespace(); ns != "" {
+		metadata["namespace"] = ns
+	}
+	pod := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   metadata,
+		"spec":       template["spec"],
+	}
+	if ruleMatches(rule, pod) {
+		targets = append(targets, kyvernoTarget{object: pod, pathPrefix: "/" + strings.Join(templatePath, "/")})
+	}
+	return targets
+}
+
+func containsWord(list, word string) bool {
+	for _, w := range strings.Split(list, ",") {
+		if strings.TrimSpace(w) == word {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateRule returns the failure message of a validate rule, or "" when
+// target satisfies it or the rule's preconditions do not hold.
+func evaluateRule(rule, validate map[string]interface{}, target kyvernoTarget) (string, error) {
+	if pre, ok := rule["preconditions"]; ok {
+		pass, err := evaluateConditions(pre, target.object)
+		if err != nil || !pass {
+			return "", err
+		}
+	}
+
+	message, _ := validate["message"].(string)
+	message = substituteVariables(message, target.object)
+	if message == "" {
+		message = "validation failed"
+	}
+
+	switch {
+	case validate["pattern"] != nil:
+		if path, ok := matchPattern(validate["pattern"], target.object, target.pathPrefix+"/"); !ok {
+			return fmt.Sprintf("%s (failed at path %s)", message, path), nil
+		}
+	case validate["anyPattern"] != nil:
+		patterns, _ := validate["anyPattern"].([]interface{})
+		for _, p := range patterns {
+			if _, ok := matchPattern(p, target.object, "/"); ok {
+				return "", nil
+			}
+		}
+		return message + " (no pattern matched)", nil
+	case validate["deny"] != nil:
+		deny, _ := validate["deny"].(map[string]interface{})
+		conditions, ok := deny["conditions"]
+		if !ok {
+			return message, nil // a deny without conditions always denies
+		}
+		denied, err := evaluateConditions(conditions, target.object)
+		if err != nil || !denied {
+			return "", err
+		}
+		return message, nil
+	}
+	return "", nil
+}
+
+// ruleMatches reports whether the rule's match block selects obj and its
+// exclude block does not.
+func ruleMatches(rule map[string]interface{}, obj map[string]interface{}) bool {
+	match, _ := rule["match"].(map[string]interface{})
+	if match == nil || !matchBlock(match, obj) {
+		return false
+	}
+	exclude, _ := rule["exclude"].(map[string]interface{})
+	return exclude == nil || !matchBlock(exclude, obj)
+}
+
+// matchBlock evaluates a match or exclude block: any, all, or a single
+// resources filter.
+func matchBlock(block map[string]interface{}, obj map[string]interface{}) bool {
+	if anyOf, ok := block["any"].([]interface{}); ok {
+		for _, f := range anyOf {
+			if filter, _ := f.(map[string]interface{}); matchFilter(filter, obj) {
+				return true
+			}
+		}
+		return false
+	}
+	if allOf, ok := block["all"].([]interface{}); ok {
+		for _, f := range allOf {
+			if filter, _ := f.(map[string]interface{}); !matchFilter(filter, obj) {
+				return false
+			}
+		}
+		return len(allOf) > 0
+	}
+	return matchFilter(block, obj)
+}
+
+// matchFilter evaluates the resources description of a filter.
+func matchFilter(filter map[string]interface{}, obj map[string]interface{}) bool {
+	res, _ := filter["resources"].(map[string]interface{})
+	if res == nil {
+		return false
+	}
+	u := &unstructured.Unstructured{Object: obj}
+
+	if kinds := stringList(res["kinds"]); len(kinds) > 0 && !matchKinds(kinds, u) {
+		return false
+	}
+	names := stringList(res["names"])
+	if name, ok := res["name"].(string); ok {
+		names = append(names, name)
+	}
+	if len(names) > 0 && !matchAnyWildcard(names, u.GetName()) {
+		return false
+	}
+	if namespaces := stringList(res["namespaces"]); len(namespaces) > 0 && !matchAnyWildcard(namespaces, u.GetNamespace()) {
+		return false
+	}
+	if ops := stringList(res["operations"]); len(ops) > 0 && !matchAnyWildcard(ops, "CREATE") {
+		return false
+	}
+	if annotations, ok := res["annotations"].(map[string]interface{}); ok {
+		for k, v := range annotations {
+			if !wildcardMatch(fmt.Sprint(v), u.GetAnnotations()[k]) {
+				return false
+			}
+		}
+	}
+	if sel, ok := res["selector"].(map[string]interface{}); ok {
+		var ls metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(sel, &ls); err != nil {
+			return false
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&ls)
+		if err != nil || !selector.Matches(labels.Set(u.GetLabels())) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchKinds matches Kind, Version/Kind and Group/Version/Kind entries, with
+// wildcards.
+func matchKinds(kinds []string, u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	for _, k := range kinds {
+		parts := strings.Split(k, "/")
+		var ok bool
+		switch len(parts) {
+		case 1:
+			ok = wildcardMatch(parts[0], gvk.Kind)
+		case 2:
+			ok = wildcardMatch(parts[0], gvk.Version) && wildcardMatch(parts[1], gvk.Kind)
+		default:
+			ok = wildcardMatch(parts[0], gvk.Group) && wildcardMatch(parts[1], gvk.Version) && wildcardMatch(parts[2], gvk.Kind)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern validates value against a Kyverno pattern. On failure it
+// returns the path of the first field that does not match.
+func matchPattern(pattern, value interface{}, path string) (string, bool) {
+	switch p := pattern.(type) {
+	case map[string]interface{}:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return path, false
+		}
+		return matchMap(p, m, path)
+	case []interface{}:
+		list, ok := value.([]interface{})
+		if !ok {
+			return path, false
+		}
+		if len(p) == 0 {
+			return "", true
+		}
+		for i, item := range list {
+			if failed, ok := matchPattern(p[0], item, path+strconv.Itoa(i)+"/"); !ok {
+				return failed, false
+			}
+		}
+		return "", true
+	default:
+		if !matchValue(pattern, value) {
+			return path, false
+		}
+		return "", true
+	}
+}
+
+// matchMap validates a map against a map pattern, honouring anchors:
+// (key) conditions, =(key) equality, ^(key) existence, X(key) negation and
+// +(key) add-if-absent.
+func matchMap(pattern, value map[string]interface{}, path string) (string, bool) {
+	// Conditional anchors decide whether the rest of the pattern applies.
+	for key, p := range pattern {
+		anchor, field := parseAnchor(key)
+		if anchor != "(" && anchor != "<(" {
+			continue
+		}
+		v, ok := value[field]
+		if !ok {
+			return "", true
+		}
+		if _, ok := matchPattern(p, v, path+field+"/"); !ok {
+			return "", true
+		}
+	}
+
+	for _, key := range sortedMapKeys(pattern) {
+		p := pattern[key]
+		anchor, field := parseAnchor(key)
+		v, present := value[field]
+		fieldPath := path + field + "/"
+		switch anchor {
+		case "(", "<(":
+			continue
+		case "X(":
+			if present {
+				return fieldPath, false
+			}
+		case "=(", "+(":
+			if present {
+				if failed, ok := matchPattern(p, v, fieldPath); !ok {
+					return failed, false
+				}
+			}
+		case "^(":
+			list, _ := v.([]interface{})
+			items, _ := p.([]interface{})
+			if len(items) == 0 {
+				continue
+			}
+			found := false
+			for _, item := range list {
+				if _, ok := matchPattern(items[0], item, fieldPath); ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fieldPath, false
+			}
+		default:
+			if !present {
+				if s, ok := p.(string); ok && strings.HasPrefix(s, "!") {
+					continue // "!value" is satisfied by an absent field
+				}
+				return fieldPath, false
+			}
+			if failed, ok := matchPattern(p, v, fieldPath); !ok {
+				return failed, false
+			}
+		}
+	}
+	return "", true
+}
+
+// parseAnchor splits "=(key)" into its anchor "=(" and the field name.
+func parseAnchor(key string) (string, string) {
+	if !strings.HasSuffix(key, ")") {
+		return "", key
+	}
+	for _, a := range []string{"<(", "=(", "^(", "X(", "+(", "("} {
+		if strings.HasPrefix(key, a) {
+			return a, key[len(a) : len(key)-1]
+		}
+	}
+	return "", key
+}
+
+// matchValue matches a scalar against a pattern value. String patterns may
+// hold wildcards (* and ?), alternatives separated by | and &, and the
+// operators !, >, >=, < and <=, which compare quantities and numbers.
+func matchValue(pattern, value interface{}) bool {
+	s, ok := pattern.(string)
+	if !ok {
+		if pattern == nil {
+			return value == nil
+		}
+		return scalarString(pattern) == scalarString(value)
+	}
+	for _, alt := range strings.Split(s, "|") {
+		all := true
+		for _, part := range strings.Split(alt, "&") {
+			if !matchOperator(strings.TrimSpace(part), value) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOperator(pattern string, value interface{}) bool {
+	for _, op := range []string{">=", "<=", ">", "<", "!"} {
+		if !strings.HasPrefix(pattern, op) {
+			continue
+		}
+		operand := strings.TrimSpace(pattern[len(op):])
+		if op == "!" {
+			return value == nil || !wildcardMatch(operand, scalarString(value))
+		}
+		cmp, ok := compareScalars(value, operand)
+		if !ok {
+			return false
+		}
+		switch op {
+		case ">=":
+			return cmp >= 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		default:
+			return cmp < 0
+		}
+	}
+	if value == nil {
+		return false
+	}
+	return wildcardMatch(pattern, scalarString(value))
+}
+
+// compareScalars compares a value with an operand as numbers or resource
+// quantities.
+func compareScalars(value interface{}, operand string) (int, bool) {
+	a, errA := resource.ParseQuantity(scalarString(value))
+	b, errB := resource.ParseQuantity(operand)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	return a.Cmp(b), true
+}
+
+// evaluateConditions evaluates Kyverno conditions: a list (all must hold) or
+// an object with any and all lists.
+func evaluateConditions(conditions interface{}, obj map[string]interface{}) (bool, error) {
+	switch c := conditions.(type) {
+	case []interface{}:
+		return evaluateConditionList(c, obj, true)
+	case map[string]interface{}:
+		if anyOf, ok := c["any"].([]interface{}); ok {
+			pass, err := evaluateConditionList(anyOf, obj, false)
+			if err != nil || !pass {
+				return false, err
+			}
+		}
+		if allOf, ok := c["all"].([]interface{}); ok {
+			return evaluateConditionList(allOf, obj, true)
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("conditions must be a list or an object with any/all")
+}
+
+func evaluateConditionList(list []interface{}, obj map[string]interface{}, all bool) (bool, error) {
+	for _, item := range list {
+		cond, _ := item.(map[string]interface{})
+		pass, err := evaluateCondition(cond, obj)
+		if err != nil {
+			return false, err
+		}
+		if all && !pass {
+			return false, nil
+		}
+		if !all && pass {
+			return true, nil
+		}
+	}
+	return all, nil
+}
+
+// evaluateCondition evaluates one key/operator/value condition.
+func evaluateCondition(cond map[string]interface{}, obj map[string]interface{}) (bool, error) {
+	key := resolveVariables(cond["key"], obj)
+	value := resolveVariables(cond["value"], obj)
+	operator, _ := cond["operator"].(string)
+
+	switch operator {
+	case "Equals", "Equal":
+		return conditionEquals(key, value), nil
+	case "NotEquals", "NotEqual":
+		return !conditionEquals(key, value), nil
+	case "In", "AnyIn":
+		return anyIn(key, value), nil
+	case "AllIn":
+		return allIn(key, value), nil
+	case "NotIn", "AllNotIn":
+		return !anyIn(key, value), nil
+	case "AnyNotIn":
+		return !allIn(key, value), nil
+	case "GreaterThan", "GreaterThanOrEquals", "LessThan", "LessThanOrEquals":
+		cmp, ok := compareScalars(key, scalarString(value))
+		if !ok {
+			return false, nil
+		}
+		switch operator {
+		case "GreaterThan":
+			return cmp > 0, nil
+		case "GreaterThanOrEquals":
+			return cmp >= 0, nil
+		case "LessThan":
+			return cmp < 0, nil
+		default:
+			return cmp <= 0, nil
+		}
+	}
+	return false, fmt.Errorf("unsupported condition operator %q", operator)
+}
+
+func conditionEquals(key, value interface{}) bool {
+	if s, ok := value.(string); ok {
+		if _, isMap := key.(map[string]interface{}); !isMap && key != nil {
+			return wildcardMatch(s, scalarString(key))
+		}
+	}
+	return reflect.DeepEqual(normalize(key), normalize(value))
+}
+
+// anyIn reports whether any element of key (or key itself) is in value.
+func anyIn(key, value interface{}) bool {
+	for _, k := range asList(key) {
+		for _, v := range asList(value) {
+			if conditionEquals(k, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allIn reports whether every element of key (or key itself) is in value.
+func allIn(key, value interface{}) bool {
+	keys := asList(key)
+	for _, k := range keys {
+		if !anyIn(k, value) {
+			return false
+		}
+	}
+	return len(keys) > 0
+}
+
+func asList(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	return []interface{}{v}
+}
+
+// resolveVariables replaces {{ ... }} variables in v. A string that is a
+// single variable resolves to the variable's value, keeping its type.
+func resolveVariables(v interface{}, obj map[string]interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		if m := variablePattern.FindStringSubmatch(t); m != nil && m[0] == strings.TrimSpace(t) {
+			return lookupVariable(m[1], obj)
+		}
+		return substituteVariables(t, obj)
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, x := range t {
+			out[i] = resolveVariables(x, obj)
+		}
+		return out
+	}
+	return v
+}
+
+// substituteVariables interpolates {{ ... }} variables into s.
+func substituteVariables(s string, obj map[string]interface{}) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		expr := variablePattern.FindStringSubmatch(match)[1]
+		v := lookupVariable(expr, obj)
+		if v == nil {
+			return ""
+		}
+		return scalarString(v)
+	})
+}
+
+// lookupVariable resolves request.object paths, with an optional
+// "|| default" fallback. Other variables resolve to nil.
+func lookupVariable(expr string, obj map[string]interface{}) interface{} {
+	var fallback interface{}
+	if i := strings.Index(expr, "||"); i >= 0 {
+		fallback = parseLiteral(strings.TrimSpace(expr[i+2:]))
+		expr = strings.TrimSpace(expr[:i])
+	}
+	var v interface{}
+	switch {
+	case expr == "request.operation":
+		v = "CREATE"
+	case expr == "request.object":
+		v = obj
+	case strings.HasPrefix(expr, "request.object."):
+		v = lookupPath(obj, splitPath(strings.TrimPrefix(expr, "request.object.")))
+	}
+	if v == nil {
+		return fallback
+	}
+	return v
+}
+
+// splitPath splits a JMESPath-style field path into names and [N] indices;
+// quoted names may contain dots.
+func splitPath(path string) []string {
+	var parts []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range path {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == '.' || r == '[') && !quoted:
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+			}
+		case r == ']' && !quoted:
+			parts = append(parts, "["+cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts
+}
+
+func lookupPath(v interface{}, parts []string) interface{} {
+	for _, p := range parts {
+		if strings.HasPrefix(p, "[") {
+			list, _ := v.([]interface{})
+			i, err := strconv.Atoi(p[1:])
+			if err != nil || i < 0 || i >= len(list) {
+				return nil
+			}
+			v = list[i]
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+	return v
+}
+
+// parseLiteral parses a JMESPath literal default: 'string', `json` or a
+// bare number.
+func parseLiteral(s string) interface{} {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '`') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// wildcardMatch matches s against a pattern with * and ? wildcards.
+func wildcardMatch(pattern, s string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == s
+	}
+	var re strings.Builder
+	re.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	ok, _ := regexp.MatchString(re.String(), s)
+	return ok
+}
+
+func matchAnyWildcard(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if wildcardMatch(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, x := range list {
+		if s, ok := x.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// scalarString formats a scalar the way it appears in YAML.
+func scalarString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// normalize converts numbers to float64 so that values decoded from YAML
+// and JSON compare equal.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int32:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, x := range t {
+			out[i] = normalize(x)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, x := range t {
+			out[k] = normalize(x)
+		}
+		return out
+	}
+	return v
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+// Package policy evaluates admission policies against rendered chart output:
+// Kyverno ClusterPolicy and Policy resources natively, and Rego policies
+// through the opa CLI.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultOPA is the opa executable Rego policies are evaluated with.
+const DefaultOPA = "opa"
+
+// Set is a collection of policies loaded from files.
+type Set struct {
+	// Kyverno holds the Kyverno ClusterPolicy and Policy resources.
+	Kyverno []*unstructured.Unstructured
+
+	// RegoFiles are the .rego files, evaluated together.
+	RegoFiles []string
+
+	// OPA is the opa executable (DefaultOPA when empty).
+	OPA string
+}
+
+// Violation is a policy rule a resource does not satisfy.
+type Violation struct {
+	// Policy is the Kyverno policy name or the Rego package path.
+	Policy string
+
+	// Rule is the Kyverno rule name or the Rego rule (deny, violation, warn).
+	Rule string
+
+	// Resource identifies the resource ("apps/v1 Deployment/web").
+	Resource string
+
+	// Object is the evaluated resource.
+	Object *unstructured.Unstructured
+
+	Message string
+
+	// Enforced is false for violations that only warn: Kyverno policies in
+	// Audit mode and Rego warn rules.
+	Enforced bool
+}
+
+// Load reads the policies under the given files and directories. Directories
+// are walked recursively; .rego files are Rego policies, and Kyverno policies
+// are read from YAML files (other YAML documents are ignored).
+func Load(paths ...string) (*Set, error) {
+	set := &Set{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			switch filepath.Ext(p) {
+			case ".rego":
+				if !strings.HasSuffix(p, "_test.rego") {
+					set.RegoFiles = append(set.RegoFiles, p)
+				}
+			case ".yaml", ".yml":
+				policies, err := readKyvernoPolicies(p)
+				if err != nil {
+					return err
+				}
+				set.Kyverno = append(set.Kyverno, policies...)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load policies: %w", err)
+		}
+	}
+	sort.Strings(set.RegoFiles)
+	if len(set.Kyverno) == 0 && len(set.RegoFiles) == 0 {
+		return nil, fmt.Errorf("load policies: no Kyverno or Rego policies found in %s", strings.Join(paths, ", "))
+	}
+	return set, nil
+}
+
+// readKyvernoPolicies returns the Kyverno policies in a YAML file.
+func readKyvernoPolicies(file string) ([]*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var policies []*unstructured.Unstructured
+	for _, doc := range strings.Split("\n"+string(data), "\n---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		obj := &unstructured.Unstructured{Object: m}
+		if isKyvernoPolicy(obj) {
+			policies = append(policies, obj)
+		}
+	}
+	return policies, nil
+}
+
+func isKyvernoPolicy(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == "kyverno.io" && (gvk.Kind == "ClusterPolicy" || gvk.Kind == "Policy")
+}
+
+// Evaluate checks every object against the policies. Violations are ordered
+// by resource, then policy and rule.
+func (s *Set) Evaluate(ctx context.Context, objects []*unstructured.Unstructured) ([]Violation, error) {
+	var violations []Violation
+	for _, obj := range objects {
+		for _, p := range s.Kyverno {
+			violations = append(violations, evaluateKyverno(p, obj)...)
+		}
+	}
+	if len(s.RegoFiles) > 0 {
+		opa := s.OPA
+		if opa == "" {
+			opa = DefaultOPA
+		}
+		for _, obj := range objects {
+			v, err := evaluateRego(ctx, opa, s.RegoFiles, obj)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, v...)
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Resource != violations[j].Resource {
+			return violations[i].Resource < violations[j].Resource
+		}
+		if violations[i].Policy != violations[j].Policy {
+			return violations[i].Policy < violations[j].Policy
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+	return violations, nil
+}
+
+// ResourceName identifies obj in violations.
+func ResourceName(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s %s/%s", obj.GetAPIVersion(), obj.GetKind(), obj.GetName())
+}
+
+// errOPANotFound is returned when Rego policies are given but opa is missing.
+var errOPANotFound = errors.New("evaluating Rego policies requires the opa CLI on PATH (https://www.openpolicyagent.org/docs/latest/#running-opa)")
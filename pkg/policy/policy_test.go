@@ -0,0 +1,329 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+  labels:
+    app: web
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:latest
+          resources:
+            limits:
+              memory: 2Gi
+        - name: sidecar
+          image: envoy:1.29
+          securityContext:
+            privileged: true
+`
+
+const kyvernoPolicies = `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: disallow-latest-tag
+spec:
+  validationFailureAction: Enforce
+  rules:
+    - name: require-image-tag
+      match:
+        any:
+          - resources:
+              kinds: [Pod]
+      validate:
+        message: "An image tag other than latest is required."
+        pattern:
+          spec:
+            containers:
+              - image: "!*:latest"
+---
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: limits
+spec:
+  validationFailureAction: Audit
+  rules:
+    - name: memory-limit
+      match:
+        any:
+          - resources:
+              kinds: [Pod]
+      validate:
+        message: "Memory limits must not exceed 1Gi."
+        pattern:
+          spec:
+            containers:
+              - =(resources):
+                  =(limits):
+                    =(memory): "<=1Gi"
+    - name: no-privileged
+      match:
+        any:
+          - resources:
+              kinds: [Pod]
+      validate:
+        message: "Privileged containers are not allowed."
+        pattern:
+          spec:
+            containers:
+              - =(securityContext):
+                  =(privileged): "false"
+---
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: replicas
+spec:
+  validationFailureAction: Enforce
+  rules:
+    - name: min-replicas
+      match:
+        any:
+          - resources:
+              kinds: [apps/v1/Deployment]
+              namespaces: [prod]
+      exclude:
+        any:
+          - resources:
+              names: [canary-*]
+      validate:
+        message: "{{ request.object.metadata.name }} runs {{ request.object.spec.replicas }} replica(s), prod needs 2."
+        deny:
+          conditions:
+            any:
+              - key: "{{ request.object.spec.replicas }}"
+                operator: LessThan
+                value: 2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-a-policy
+`
+
+func readObject(t *testing.T, doc string) *unstructured.Unstructured {
+	t.Helper()
+	var m map[string]interface{}
+	if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+		t.Fatal(err)
+	}
+	return &unstructured.Unstructured{Object: m}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func violationLines(violations []Violation) string {
+	var lines []string
+	for _, v := range violations {
+		severity := "warn"
+		if v.Enforced {
+			severity = "deny"
+		}
+		lines = append(lines, severity+" "+v.Policy+"/"+v.Rule+": "+v.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"kyverno/policies.yaml":   kyvernoPolicies,
+		"rego/main.rego":          "package main\n",
+		"rego/main_test.rego":     "package main\n",
+		"README.md":               "# policies\n",
+		"kyverno/nested/one.yml":  "apiVersion: kyverno.io/v1\nkind: Policy\nmetadata:\n  name: one\n  namespace: prod\n",
+		"kyverno/nested/skip.yml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: prod\n",
+	})
+
+	set, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(set.Kyverno) != 4 {
+		t.Errorf("expected 4 Kyverno policies, got %d", len(set.Kyverno))
+	}
+	if len(set.RegoFiles) != 1 || filepath.Base(set.RegoFiles[0]) != "main.rego" {
+		t.Errorf("expected main.rego only, got %v", set.RegoFiles)
+	}
+
+	if _, err := Load(writeFiles(t, map[string]string{"cm.yaml": "apiVersion: v1\nkind: ConfigMap\n"})); err == nil {
+		t.Error("expected an error for a directory without policies")
+	}
+	if _, err := Load(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
+
+func TestEvaluate_Kyverno(t *testing.T) {
+	set, err := Load(writeFiles(t, map[string]string{"policies.yaml": kyvernoPolicies}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	violations, err := set.Evaluate(context.Background(), []*unstructured.Unstructured{readObject(t, deployment)})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	got := violationLines(violations)
+	for _, want := range []string{
+		"deny disallow-latest-tag/require-image-tag: An image tag other than latest is required. (failed at path /spec/template/spec/containers/0/image/)",
+		"warn limits/memory-limit: Memory limits must not exceed 1Gi. (failed at path /spec/template/spec/containers/0/resources/limits/memory/)",
+		"warn limits/no-privileged: Privileged containers are not allowed. (failed at path /spec/template/spec/containers/1/securityContext/privileged/)",
+		"deny replicas/min-replicas: web runs 1 replica(s), prod needs 2.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if len(violations) != 4 {
+		t.Errorf("expected 4 violations, got:\n%s", got)
+	}
+	for _, v := range violations {
+		if v.Resource != "apps/v1 Deployment/web" {
+			t.Errorf("unexpected resource %q", v.Resource)
+		}
+	}
+}
+
+func TestEvaluate_KyvernoCompliant(t *testing.T) {
+	set, err := Load(writeFiles(t, map[string]string{"policies.yaml": kyvernoPolicies}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	compliant := readObject(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          resources:
+            limits:
+              memory: 512Mi
+`)
+	canary := readObject(t, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: canary-web\n  namespace: prod\nspec:\n  replicas: 1\n")
+	staging := readObject(t, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: staging\nspec:\n  replicas: 1\n")
+
+	violations, err := set.Evaluate(context.Background(), []*unstructured.Unstructured{compliant, canary, staging})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("expected no violations, got:\n%s", violationLines(violations))
+	}
+}
+
+func TestMatchPattern_Anchors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		object  string
+		ok      bool
+	}{
+		{"wildcard", "image: 'registry.example.com/*'", "image: registry.example.com/web:1", true},
+		{"wildcard mismatch", "image: 'registry.example.com/*'", "image: docker.io/web:1", false},
+		{"required field", "name: '?*'", "other: x", false},
+		{"alternatives", "policy: 'Always | IfNotPresent'", "policy: IfNotPresent", true},
+		{"quantity range", "cpu: '>=100m & <=2'", "cpu: 500m", true},
+		{"quantity range exceeded", "cpu: '>=100m & <=2'", "cpu: '4'", false},
+		{"conditional anchor skips", "(name): db\nport: 5432", "name: web\nport: 80", true},
+		{"conditional anchor applies", "(name): db\nport: 5432", "name: db\nport: 80", false},
+		{"negation anchor", "X(hostPath): null", "hostPath: {path: /}", false},
+		{"existence anchor", "^(ports):\n  - port: 443", "ports: [{port: 80}, {port: 443}]", true},
+		{"existence anchor missing", "^(ports):\n  - port: 443", "ports: [{port: 80}]", false},
+		{"boolean", "privileged: false", "privileged: true", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pattern, object map[string]interface{}
+			if err := yaml.Unmarshal([]byte(tt.pattern), &pattern); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.object), &object); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := matchPattern(pattern, object, "/"); ok != tt.ok {
+				t.Errorf("matchPattern = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Rego(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script opa stand-in is not supported on windows")
+	}
+	// A stand-in for opa that checks its arguments and reports a deny and a
+	// warn rule result in the format of "opa eval --format json".
+	dir := writeFiles(t, map[string]string{
+		"policies/main.rego": "package main\n",
+		"opa": `#!/bin/sh
+case "$*" in
+  *"eval --format json --stdin-input --data "*"/main.rego data") ;;
+  *) echo "unexpected arguments: $*" >&2; exit 1 ;;
+esac
+grep -q '"kind":"Deployment"' || { echo "missing input" >&2; exit 1; }
+echo '{"result":[{"expressions":[{"value":{"main":{"deny":["containers must not run as root"],"warn":[{"msg":"image uses latest tag"}],"allow":true}}}]}]}'
+`,
+	})
+
+	set, err := Load(filepath.Join(dir, "policies"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	set.OPA = filepath.Join(dir, "opa")
+	violations, err := set.Evaluate(context.Background(), []*unstructured.Unstructured{readObject(t, deployment)})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	want := "deny main/deny: containers must not run as root\nwarn main/warn: image uses latest tag"
+	if got := violationLines(violations); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEvaluate_RegoWithoutOPA(t *testing.T) {
+	set := &Set{RegoFiles: []string{"main.rego"}, OPA: "dhg-opa-not-installed"}
+	_, err := set.Evaluate(context.Background(), []*unstructured.Unstructured{readObject(t, deployment)})
+	if err == nil || !strings.Contains(err.Error(), "opa CLI") {
+		t.Errorf("expected a missing opa error, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// regoTimeout bounds a single opa invocation.
+const regoTimeout = 30 * time.Second
+
+// regoRules are the rule names read from Rego packages, following the
+// conftest convention: deny and violation fail, warn only warns.
+var regoRules = map[string]bool{"deny": true, "violation": true, "warn": false}
+
+// evaluateRego runs opa with obj as input and collects the messages of the
+// deny, violation and warn rules in every package.
+func evaluateRego(ctx context.Context, opa string, files []string, obj *unstructured.Unstructured) ([]Violation, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, regoTimeout)
+	defer cancel()
+
+	input, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("rego: marshal input: %w", err)
+	}
+
+	args := []string{"eval", "--format", "json", "--stdin-input"}
+	for _, f := range files {
+		args = append(args, "--data", f)
+	}
+	args = append(args, "data")
+
+	proc := exec.CommandContext(ctx, opa, args...) //nolint:gosec
+	proc.Stdin = bytes.NewReader(input)
+	proc.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	proc.Stdout = &stdout
+	proc.Stderr = &stderr
+
+	if err := proc.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return nil, errOPANotFound
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("rego: opa timeout after %v", regoTimeout)
+		}
+		return nil, fmt.Errorf("rego: opa eval: %w (stderr: %s)", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value interface{} `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("rego: decode opa output: %w", err)
+	}
+
+	var violations []Violation
+	resource := ResourceName(obj)
+	for _, r := range out.Result {
+		for _, e := range r.Expressions {
+			collectRego(e.Value, nil, func(pkg, rule string, enforced bool, msg string) {
+				violations = append(violations, Violation{
+					Policy: pkg, Rule: rule, Resource: resource, Object: obj, Message: msg, Enforced: enforced,
+				})
+			})
+		}
+	}
+	return violations, nil
+}
+
+// collectRego walks the data document opa returns, reporting each message of
+// a deny, violation or warn rule with the package path it belongs to.
+func collectRego(v interface{}, path []string, report func(pkg, rule string, enforced bool, msg string)) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enforced, isRule := regoRules[k]
+		list, isList := m[k].([]interface{})
+		if !isRule || !isList {
+			collectRego(m[k], append(path, k), report)
+			continue
+		}
+		pkg := strings.Join(path, ".")
+		for _, item := range list {
+			report(pkg, k, enforced, regoMessage(item))
+		}
+	}
+}
+
+// regoMessage returns the text of a rule result: a string, or an object with
+// a msg field.
+func regoMessage(item interface{}) string {
+	switch t := item.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		if msg, ok := t["msg"].(string); ok {
+			return msg
+		}
+	}
+	data, _ := json.Marshal(item)
+	return string(data)
+}
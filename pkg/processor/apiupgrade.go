@@ -0,0 +1,223 @@
+package processor
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// APIMigration describes a deprecated or removed Kubernetes API and its
+// replacement.
+type APIMigration struct {
+	// OldAPIVersion is the deprecated API version (e.g., "extensions/v1beta1")
+	OldAPIVersion string
+	// OldKind is the resource kind (may change during migration)
+	OldKind string
+	// NewAPIVersion is the replacement API version; empty if the API was
+	// removed without a replacement
+	NewAPIVersion string
+	// NewKind is the replacement kind (usually same as OldKind)
+	NewKind string
+	// DeprecatedIn is the Kubernetes version where this API was deprecated
+	DeprecatedIn string
+	// RemovedIn is the Kubernetes version where this API was removed
+	RemovedIn string
+	// Notes contains migration-specific notes
+	Notes string
+}
+
+// apiMigrations is the migration table for known deprecated APIs.
+var apiMigrations = []APIMigration{
+	{
+		OldAPIVersion: "extensions/v1beta1", OldKind: "Ingress",
+		NewAPIVersion: "networking.k8s.io/v1", NewKind: "Ingress",
+		DeprecatedIn: "1.14", RemovedIn: "1.22",
+		Notes: "spec.rules[].http.paths[].pathType is now required",
+	},
+	{
+		OldAPIVersion: "networking.k8s.io/v1beta1", OldKind: "Ingress",
+		NewAPIVersion: "networking.k8s.io/v1", NewKind: "Ingress",
+		DeprecatedIn: "1.19", RemovedIn: "1.22",
+		Notes: "spec.rules[].http.paths[].pathType is now required",
+	},
+	{
+		OldAPIVersion: "extensions/v1beta1", OldKind: "NetworkPolicy",
+		NewAPIVersion: "networking.k8s.io/v1", NewKind: "NetworkPolicy",
+		DeprecatedIn: "1.9", RemovedIn: "1.16",
+	},
+	{
+		OldAPIVersion: "policy/v1beta1", OldKind: "PodDisruptionBudget",
+		NewAPIVersion: "policy/v1", NewKind: "PodDisruptionBudget",
+		DeprecatedIn: "1.21", RemovedIn: "1.25",
+	},
+	{
+		OldAPIVersion: "policy/v1beta1", OldKind: "PodSecurityPolicy",
+		NewAPIVersion: "", NewKind: "", // Removed, no direct replacement
+		DeprecatedIn: "1.21", RemovedIn: "1.25",
+		Notes: "Use Pod Security Standards (PSS) instead",
+	},
+	{
+		OldAPIVersion: "rbac.authorization.k8s.io/v1beta1", OldKind: "ClusterRole",
+		NewAPIVersion: "rbac.authorization.k8s.io/v1", NewKind: "ClusterRole",
+		DeprecatedIn: "1.17", RemovedIn: "1.22",
+	},
+	{
+		OldAPIVersion: "rbac.authorization.k8s.io/v1beta1", OldKind: "ClusterRoleBinding",
+		NewAPIVersion: "rbac.authorization.k8s.io/v1", NewKind: "ClusterRoleBinding",
+		DeprecatedIn: "1.17", RemovedIn: "1.22",
+	},
+	{
+		OldAPIVersion: "rbac.authorization.k8s.io/v1beta1", OldKind: "Role",
+		NewAPIVersion: "rbac.authorization.k8s.io/v1", NewKind: "Role",
+		DeprecatedIn: "1.17", RemovedIn: "1.22",
+	},
+	{
+		OldAPIVersion: "rbac.authorization.k8s.io/v1beta1", OldKind: "RoleBinding",
+		NewAPIVersion: "rbac.authorization.k8s.io/v1", NewKind: "RoleBinding",
+		DeprecatedIn: "1.17", RemovedIn: "1.22",
+	},
+	{
+		OldAPIVersion: "autoscaling/v2beta1", OldKind: "HorizontalPodAutoscaler",
+		NewAPIVersion: "autoscaling/v2", NewKind: "HorizontalPodAutoscaler",
+		DeprecatedIn: "1.23", RemovedIn: "1.26",
+		Notes: "metrics[].resource.targetAverageUtilization/targetAverageValue moved to metrics[].resource.target",
+	},
+	{
+		OldAPIVersion: "autoscaling/v2beta2", OldKind: "HorizontalPodAutoscaler",
+		NewAPIVersion: "autoscaling/v2", NewKind: "HorizontalPodAutoscaler",
+		DeprecatedIn: "1.23", RemovedIn: "1.26",
+	},
+	{
+		OldAPIVersion: "batch/v1beta1", OldKind: "CronJob",
+		NewAPIVersion: "batch/v1", NewKind: "CronJob",
+		DeprecatedIn: "1.21", RemovedIn: "1.25",
+	},
+}
+
+// LookupAPIMigration returns the migration for a deprecated apiVersion and
+// kind, or nil if the API is current.
+func LookupAPIMigration(apiVersion, kind string) *APIMigration {
+	for _, m := range apiMigrations {
+		if m.OldAPIVersion == apiVersion && m.OldKind == kind {
+			return &m
+		}
+	}
+	return nil
+}
+
+// APIMigrations returns a copy of the migration table.
+func APIMigrations() []APIMigration {
+	result := make([]APIMigration, len(apiMigrations))
+	copy(result, apiMigrations)
+	return result
+}
+
+// UpgradeAPIVersion rewrites obj in place from a deprecated apiVersion to its
+// replacement, converting the fields whose schema changed between versions.
+// It returns the migration applied, or nil if obj uses a current API or one
+// that was removed without a replacement.
+func UpgradeAPIVersion(obj *unstructured.Unstructured) *APIMigration {
+	m := LookupAPIMigration(obj.GetAPIVersion(), obj.GetKind())
+	if m == nil || m.NewAPIVersion == "" {
+		return nil
+	}
+
+	switch m.OldKind {
+	case "Ingress":
+		upgradeIngressSpec(obj.Object)
+	case "HorizontalPodAutoscaler":
+		if m.OldAPIVersion == "autoscaling/v2beta1" {
+			upgradeHPAv2beta1Metrics(obj.Object)
+		}
+	}
+
+	obj.SetAPIVersion(m.NewAPIVersion)
+	obj.SetKind(m.NewKind)
+	return m
+}
+
+// upgradeIngressSpec converts v1beta1 backends (serviceName/servicePort) to
+// the networking.k8s.io/v1 form and sets the now required pathType.
+func upgradeIngressSpec(obj map[string]interface{}) {
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if backend, ok := spec["backend"].(map[string]interface{}); ok {
+		spec["defaultBackend"] = upgradeIngressBackend(backend)
+		delete(spec, "backend")
+	}
+
+	rules, _ := spec["rules"].([]interface{})
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		http, ok := rule["http"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _ := http["paths"].([]interface{})
+		for _, p := range paths {
+			path, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				path["backend"] = upgradeIngressBackend(backend)
+			}
+			if _, ok := path["pathType"]; !ok {
+				path["pathType"] = "ImplementationSpecific"
+			}
+		}
+	}
+}
+
+func upgradeIngressBackend(backend map[string]interface{}) map[string]interface{} {
+	name, hasName := backend["serviceName"]
+	if !hasName {
+		return backend // already v1 or a resource backend
+	}
+
+	port := map[string]interface{}{}
+	switch p := backend["servicePort"].(type) {
+	case string:
+		port["name"] = p
+	case int64, int32, int, float64:
+		port["number"] = p
+	}
+
+	out := map[string]interface{}{"service": map[string]interface{}{"name": name, "port": port}}
+	for k, v := range backend {
+		if k != "serviceName" && k != "servicePort" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// upgradeHPAv2beta1Metrics moves targetAverageUtilization/targetAverageValue
+// of Resource metrics into the autoscaling/v2 target struct.
+func upgradeHPAv2beta1Metrics(obj map[string]interface{}) {
+	metrics, _, _ := unstructured.NestedSlice(obj, "spec", "metrics")
+	if len(metrics) == 0 {
+		return
+	}
+	for _, m := range metrics {
+		metric, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resource, ok := metric["resource"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := resource["targetAverageUtilization"]; ok {
+			resource["target"] = map[string]interface{}{"type": "Utilization", "averageUtilization": v}
+			delete(resource, "targetAverageUtilization")
+		} else if v, ok := resource["targetAverageValue"]; ok {
+			resource["target"] = map[string]interface{}{"type": "AverageValue", "averageValue": v}
+			delete(resource, "targetAverageValue")
+		}
+	}
+	_ = unstructured.SetNestedSlice(obj, metrics, "spec", "metrics")
+}
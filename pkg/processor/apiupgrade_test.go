@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUpgradeAPIVersion_Ingress(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "extensions/v1beta1",
+		"kind":       "Ingress",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"backend": map[string]interface{}{"serviceName": "default", "servicePort": "http"},
+			"rules": []interface{}{map[string]interface{}{
+				"http": map[string]interface{}{"paths": []interface{}{
+					map[string]interface{}{
+						"path":    "/",
+						"backend": map[string]interface{}{"serviceName": "web", "servicePort": int64(80)},
+					},
+					map[string]interface{}{
+						"path":     "/api",
+						"pathType": "Prefix",
+						"backend":  map[string]interface{}{"serviceName": "api", "servicePort": "grpc"},
+					},
+				}},
+			}},
+		},
+	}}
+
+	m := UpgradeAPIVersion(obj)
+	if m == nil || m.OldAPIVersion != "extensions/v1beta1" {
+		t.Fatalf("expected the extensions/v1beta1 migration, got %+v", m)
+	}
+	if obj.GetAPIVersion() != "networking.k8s.io/v1" {
+		t.Errorf("apiVersion = %q", obj.GetAPIVersion())
+	}
+
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "defaultBackend", "service", "name"); name != "default" {
+		t.Errorf("spec.backend should become spec.defaultBackend: %v", obj.Object["spec"])
+	}
+	if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "backend"); found {
+		t.Error("spec.backend should be removed")
+	}
+
+	paths, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	http := paths[0].(map[string]interface{})["http"].(map[string]interface{})["paths"].([]interface{})
+	first, second := http[0].(map[string]interface{}), http[1].(map[string]interface{})
+
+	if first["pathType"] != "ImplementationSpecific" || second["pathType"] != "Prefix" {
+		t.Errorf("pathType should default to ImplementationSpecific and keep explicit values: %v, %v", first["pathType"], second["pathType"])
+	}
+	if port, _, _ := unstructured.NestedInt64(first, "backend", "service", "port", "number"); port != 80 {
+		t.Errorf("numeric servicePort should become port.number: %v", first["backend"])
+	}
+	if port, _, _ := unstructured.NestedString(second, "backend", "service", "port", "name"); port != "grpc" {
+		t.Errorf("named servicePort should become port.name: %v", second["backend"])
+	}
+}
+
+func TestUpgradeAPIVersion_HPAv2beta1(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2beta1",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"metrics": []interface{}{map[string]interface{}{
+				"type":     "Resource",
+				"resource": map[string]interface{}{"name": "cpu", "targetAverageUtilization": int64(70)},
+			}},
+		},
+	}}
+
+	if UpgradeAPIVersion(obj) == nil || obj.GetAPIVersion() != "autoscaling/v2" {
+		t.Fatalf("expected an upgrade to autoscaling/v2, got %q", obj.GetAPIVersion())
+	}
+	metrics, _, _ := unstructured.NestedSlice(obj.Object, "spec", "metrics")
+	target, _, _ := unstructured.NestedMap(metrics[0].(map[string]interface{}), "resource", "target")
+	if target["type"] != "Utilization" || target["averageUtilization"] != int64(70) {
+		t.Errorf("unexpected target: %v", metrics[0])
+	}
+}
+
+func TestUpgradeAPIVersion_SimpleRename(t *testing.T) {
+	tests := []struct {
+		apiVersion, kind, want string
+	}{
+		{"policy/v1beta1", "PodDisruptionBudget", "policy/v1"},
+		{"batch/v1beta1", "CronJob", "batch/v1"},
+		{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "rbac.authorization.k8s.io/v1"},
+	}
+	for _, tt := range tests {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(tt.apiVersion)
+		obj.SetKind(tt.kind)
+		if UpgradeAPIVersion(obj) == nil || obj.GetAPIVersion() != tt.want {
+			t.Errorf("%s %s: apiVersion = %q, want %q", tt.apiVersion, tt.kind, obj.GetAPIVersion(), tt.want)
+		}
+	}
+}
+
+func TestUpgradeAPIVersion_NotUpgraded(t *testing.T) {
+	for _, apiVersion := range []string{"policy/v1beta1", "apps/v1"} {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind("PodSecurityPolicy")
+		if m := UpgradeAPIVersion(obj); m != nil || obj.GetAPIVersion() != apiVersion {
+			t.Errorf("%s should not be upgraded, got %+v", apiVersion, m)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GeneratedAutoscalerAnnotation marks the HorizontalPodAutoscalers returned
+// by NewHorizontalPodAutoscaler. Their values are keyed autoscaling instead
+// of hpa, the key of HorizontalPodAutoscalers from the input; the annotation
+// is not rendered.
+const GeneratedAutoscalerAnnotation = "dhg.deckhouse.io/generated-autoscaler"
+
+// Defaults of generated HorizontalPodAutoscalers.
+const (
+	// DefaultHPATargetCPUUtilization is the target average CPU utilization,
+	// in percent of the CPU requests.
+	DefaultHPATargetCPUUtilization = 80
+	// DefaultHPAMaxReplicasFactor is maxReplicas relative to minReplicas.
+	DefaultHPAMaxReplicasFactor = 3
+)
+
+// IsStatelessDeployment reports whether obj is a Deployment whose pods mount
+// no PersistentVolumeClaim, so its replicas can be scaled freely.
+func IsStatelessDeployment(obj *unstructured.Unstructured) bool {
+	if obj.GetKind() != "Deployment" {
+		return false
+	}
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "volumes")
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]interface{}); ok && volume["persistentVolumeClaim"] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// HasCPURequests reports whether every container of a workload requests
+// CPU. CPU utilization is measured against the requests, so a
+// HorizontalPodAutoscaler cannot compute it for pods with a container
+// without one.
+func HasCPURequests(obj *unstructured.Unstructured) bool {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return false
+	}
+	containers, _, _ := unstructured.NestedSlice(obj.Object, append(specPath, "containers")...)
+	if len(containers) == 0 {
+		return false
+	}
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, found, _ := unstructured.NestedFieldNoCopy(container, "resources", "requests", "cpu"); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// NewHorizontalPodAutoscaler returns an autoscaling/v2
+// HorizontalPodAutoscaler for a Deployment. It has the name, namespace and
+// labels of the Deployment, so it is grouped into the same service. It keeps
+// at least the current replicas, scales up to DefaultHPAMaxReplicasFactor
+// times as many, and targets DefaultHPATargetCPUUtilization of the CPU
+// requests. It carries GeneratedAutoscalerAnnotation.
+func NewHorizontalPodAutoscaler(deployment *unstructured.Unstructured) *unstructured.Unstructured {
+	minReplicas, _ := WorkloadReplicas(deployment)
+	if minReplicas < 1 {
+		minReplicas = 1
+	}
+
+	hpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata": map[string]interface{}{
+			"name":        deployment.GetName(),
+			"annotations": map[string]interface{}{GeneratedAutoscalerAnnotation: "true"},
+		},
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"name":       deployment.GetName(),
+			},
+			"minReplicas": minReplicas,
+			"maxReplicas": minReplicas * DefaultHPAMaxReplicasFactor,
+			"metrics": []interface{}{
+				map[string]interface{}{
+					"type": "Resource",
+					"resource": map[string]interface{}{
+						"name": "cpu",
+						"target": map[string]interface{}{
+							"type":               "Utilization",
+							"averageUtilization": int64(DefaultHPATargetCPUUtilization),
+						},
+					},
+				},
+			},
+		},
+	}}
+	if ns := deployment.GetNamespace(); ns != "" {
+		hpa.SetNamespace(ns)
+	}
+	if l := deployment.GetLabels(); len(l) > 0 {
+		hpa.SetLabels(l)
+	}
+	return hpa
+}
+
+// IsGeneratedAutoscaler reports whether obj is a HorizontalPodAutoscaler
+// returned by NewHorizontalPodAutoscaler.
+func IsGeneratedAutoscaler(obj *unstructured.Unstructured) bool {
+	return obj.GetKind() == "HorizontalPodAutoscaler" && obj.GetAnnotations()[GeneratedAutoscalerAnnotation] == "true"
+}
+
+// ScaledByHorizontalPodAutoscaler reports whether one of hpas, in the
+// namespace of workload, targets the workload.
+func ScaledByHorizontalPodAutoscaler(workload *unstructured.Unstructured, hpas []*unstructured.Unstructured) bool {
+	for _, hpa := range hpas {
+		if hpa.GetNamespace() != workload.GetNamespace() {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name")
+		if kind == workload.GetKind() && name == workload.GetName() {
+			return true
+		}
+	}
+	return false
+}
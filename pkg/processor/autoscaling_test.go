@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func withPodSpec(obj *unstructured.Unstructured, podSpec map[string]interface{}) *unstructured.Unstructured {
+	_ = unstructured.SetNestedMap(obj.Object, podSpec, "spec", "template", "spec")
+	return obj
+}
+
+func container(cpu string) map[string]interface{} {
+	c := map[string]interface{}{"name": "app", "image": "nginx:1.25"}
+	if cpu != "" {
+		c["resources"] = map[string]interface{}{"requests": map[string]interface{}{"cpu": cpu}}
+	}
+	return c
+}
+
+func TestIsStatelessDeployment(t *testing.T) {
+	pvc := withPodSpec(workload("Deployment", int64(1)), map[string]interface{}{
+		"volumes": []interface{}{map[string]interface{}{
+			"name":                  "data",
+			"persistentVolumeClaim": map[string]interface{}{"claimName": "data"},
+		}},
+	})
+	emptyDir := withPodSpec(workload("Deployment", int64(1)), map[string]interface{}{
+		"volumes": []interface{}{map[string]interface{}{"name": "tmp", "emptyDir": map[string]interface{}{}}},
+	})
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{"Deployment", workload("Deployment", nil), true},
+		{"emptyDir volume", emptyDir, true},
+		{"PVC volume", pvc, false},
+		{"StatefulSet", workload("StatefulSet", int64(3)), false},
+	}
+	for _, tt := range tests {
+		if got := IsStatelessDeployment(tt.obj); got != tt.want {
+			t.Errorf("%s: IsStatelessDeployment = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHasCPURequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []interface{}
+		want       bool
+	}{
+		{"all containers", []interface{}{container("100m"), container("1")}, true},
+		{"one without", []interface{}{container("100m"), container("")}, false},
+		{"no containers", nil, false},
+	}
+	for _, tt := range tests {
+		obj := withPodSpec(workload("Deployment", nil), map[string]interface{}{"containers": tt.containers})
+		if got := HasCPURequests(obj); got != tt.want {
+			t.Errorf("%s: HasCPURequests = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewHorizontalPodAutoscaler(t *testing.T) {
+	hpa := NewHorizontalPodAutoscaler(workload("Deployment", float64(2)))
+
+	if hpa.GetAPIVersion() != "autoscaling/v2" || hpa.GetKind() != "HorizontalPodAutoscaler" {
+		t.Errorf("unexpected GVK: %s", hpa.GroupVersionKind())
+	}
+	if hpa.GetName() != "web" || hpa.GetNamespace() != "prod" || hpa.GetLabels()["app"] != "web" {
+		t.Errorf("expected the Deployment's name, namespace and labels: %v", hpa.Object["metadata"])
+	}
+	if name, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name"); name != "web" {
+		t.Errorf("scaleTargetRef.name = %q", name)
+	}
+	minReplicas, _, _ := unstructured.NestedInt64(hpa.Object, "spec", "minReplicas")
+	maxReplicas, _, _ := unstructured.NestedInt64(hpa.Object, "spec", "maxReplicas")
+	if minReplicas != 2 || maxReplicas != 2*DefaultHPAMaxReplicasFactor {
+		t.Errorf("replicas = %d..%d", minReplicas, maxReplicas)
+	}
+	metrics, _, _ := unstructured.NestedSlice(hpa.Object, "spec", "metrics")
+	if len(metrics) != 1 {
+		t.Fatalf("expected one metric, got %v", metrics)
+	}
+	target, _, _ := unstructured.NestedInt64(metrics[0].(map[string]interface{}), "resource", "target", "averageUtilization")
+	if target != DefaultHPATargetCPUUtilization {
+		t.Errorf("averageUtilization = %d", target)
+	}
+
+	if v, _, _ := unstructured.NestedInt64(NewHorizontalPodAutoscaler(workload("Deployment", nil)).Object, "spec", "minReplicas"); v != 1 {
+		t.Errorf("minReplicas without replicas = %d, want 1", v)
+	}
+}
+
+func TestScaledByHorizontalPodAutoscaler(t *testing.T) {
+	deploy := workload("Deployment", int64(2))
+	hpa := NewHorizontalPodAutoscaler(deploy)
+
+	if !ScaledByHorizontalPodAutoscaler(deploy, []*unstructured.Unstructured{hpa}) {
+		t.Error("expected the Deployment to be scaled by its HPA")
+	}
+	other := hpa.DeepCopy()
+	other.SetNamespace("dev")
+	if ScaledByHorizontalPodAutoscaler(deploy, []*unstructured.Unstructured{other}) {
+		t.Error("an HPA in another namespace should not count")
+	}
+	if ScaledByHorizontalPodAutoscaler(workload("StatefulSet", int64(2)), []*unstructured.Unstructured{hpa}) {
+		t.Error("an HPA targeting a Deployment should not count for a StatefulSet")
+	}
+}
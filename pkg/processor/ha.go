@@ -0,0 +1,91 @@
+package processor
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultPDBMaxUnavailable is the maxUnavailable of generated
+// PodDisruptionBudgets.
+const DefaultPDBMaxUnavailable = 1
+
+// NeedsPodDisruptionBudget reports whether obj is a Deployment or
+// StatefulSet that runs more than one replica, i.e. one a
+// PodDisruptionBudget can keep available during voluntary disruptions.
+func NeedsPodDisruptionBudget(obj *unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet":
+	default:
+		return false
+	}
+	replicas, ok := WorkloadReplicas(obj)
+	return ok && replicas > 1
+}
+
+// WorkloadReplicas returns spec.replicas of a workload and whether it is set.
+func WorkloadReplicas(obj *unstructured.Unstructured) (int64, bool) {
+	// Numbers parsed from YAML are float64, built objects use int64.
+	raw, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas")
+	switch replicas := raw.(type) {
+	case int64:
+		return replicas, true
+	case float64:
+		return int64(replicas), true
+	case int:
+		return int64(replicas), true
+	}
+	return 0, false
+}
+
+// NewPodDisruptionBudget returns a policy/v1 PodDisruptionBudget for a
+// workload. It has the name, namespace and labels of the workload, so it is
+// grouped into the same service. It has no selector: the PDB template then
+// selects the pods by the chart's selector labels, like the workload does.
+func NewPodDisruptionBudget(workload *unstructured.Unstructured) *unstructured.Unstructured {
+	pdb := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy/v1",
+		"kind":       "PodDisruptionBudget",
+		"metadata": map[string]interface{}{
+			"name": workload.GetName(),
+		},
+		"spec": map[string]interface{}{
+			"maxUnavailable": int64(DefaultPDBMaxUnavailable),
+		},
+	}}
+	if ns := workload.GetNamespace(); ns != "" {
+		pdb.SetNamespace(ns)
+	}
+	if l := workload.GetLabels(); len(l) > 0 {
+		pdb.SetLabels(l)
+	}
+	return pdb
+}
+
+// CoveredByPodDisruptionBudget reports whether one of pdbs, in the
+// namespace of workload, selects the workload's pods.
+func CoveredByPodDisruptionBudget(workload *unstructured.Unstructured, pdbs []*unstructured.Unstructured) bool {
+	podLabels, _, _ := unstructured.NestedStringMap(workload.Object, "spec", "template", "metadata", "labels")
+	for _, pdb := range pdbs {
+		if pdb.GetNamespace() != workload.GetNamespace() {
+			continue
+		}
+		raw, found, _ := unstructured.NestedMap(pdb.Object, "spec", "selector")
+		if !found {
+			continue
+		}
+		var ls metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &ls); err != nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&ls)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			return true
+		}
+	}
+	return false
+}
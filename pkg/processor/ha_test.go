@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func workload(kind string, replicas interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		"template": map[string]interface{}{"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{"app": "web", "tier": "frontend"},
+		}},
+	}
+	if replicas != nil {
+		spec["replicas"] = replicas
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      "web",
+			"namespace": "prod",
+			"labels":    map[string]interface{}{"app": "web"},
+		},
+		"spec": spec,
+	}}
+}
+
+func TestNeedsPodDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{"Deployment int64", workload("Deployment", int64(3)), true},
+		{"StatefulSet parsed from YAML", workload("StatefulSet", float64(2)), true},
+		{"single replica", workload("Deployment", int64(1)), false},
+		{"replicas unset", workload("Deployment", nil), false},
+		{"DaemonSet", workload("DaemonSet", int64(3)), false},
+	}
+	for _, tt := range tests {
+		if got := NeedsPodDisruptionBudget(tt.obj); got != tt.want {
+			t.Errorf("%s: NeedsPodDisruptionBudget = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewPodDisruptionBudget(t *testing.T) {
+	pdb := NewPodDisruptionBudget(workload("Deployment", int64(3)))
+
+	if pdb.GetAPIVersion() != "policy/v1" || pdb.GetKind() != "PodDisruptionBudget" {
+		t.Errorf("unexpected GVK: %s", pdb.GroupVersionKind())
+	}
+	if pdb.GetName() != "web" || pdb.GetNamespace() != "prod" || pdb.GetLabels()["app"] != "web" {
+		t.Errorf("expected the workload's name, namespace and labels: %v", pdb.Object["metadata"])
+	}
+	if v, _, _ := unstructured.NestedInt64(pdb.Object, "spec", "maxUnavailable"); v != DefaultPDBMaxUnavailable {
+		t.Errorf("maxUnavailable = %d", v)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(pdb.Object, "spec", "selector"); found {
+		t.Error("the selector must be left to the template")
+	}
+}
+
+func TestCoveredByPodDisruptionBudget(t *testing.T) {
+	pdb := func(namespace string, matchLabels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "policy/v1",
+			"kind":       "PodDisruptionBudget",
+			"metadata":   map[string]interface{}{"name": "pdb", "namespace": namespace},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": matchLabels},
+			},
+		}}
+	}
+	w := workload("Deployment", int64(3))
+
+	if !CoveredByPodDisruptionBudget(w, []*unstructured.Unstructured{pdb("prod", map[string]interface{}{"tier": "frontend"})}) {
+		t.Error("a PDB selecting the pod labels covers the workload")
+	}
+	if CoveredByPodDisruptionBudget(w, []*unstructured.Unstructured{pdb("staging", map[string]interface{}{"app": "web"})}) {
+		t.Error("a PDB in another namespace does not cover the workload")
+	}
+	if CoveredByPodDisruptionBudget(w, []*unstructured.Unstructured{pdb("prod", map[string]interface{}{"app": "api"})}) {
+		t.Error("a PDB selecting other pods does not cover the workload")
+	}
+	if CoveredByPodDisruptionBudget(w, nil) {
+		t.Error("no PDBs cover nothing")
+	}
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// ValuesHintAnnotation lists, comma-separated, the dotted field paths of a
+// resource without a processor that the generic fallback moves to
+// values.yaml, e.g. "spec.replicas,spec.image". The rest of the resource is
+// rendered as is.
+const ValuesHintAnnotation = "dhg.deckhouse.io/values"
+
+// identifierRe matches values keys usable in a dotted .Values reference.
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// valuesHints returns the paths of the ValuesHintAnnotation of obj.
+func valuesHints(obj *unstructured.Unstructured) [][]string {
+	var paths [][]string
+	for _, p := range strings.Split(obj.GetAnnotations()[ValuesHintAnnotation], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, strings.Split(p, "."))
+		}
+	}
+	return paths
+}
+
+// hintedBody renders the fields of obj other than apiVersion, kind, metadata
+// and status, with the fields at paths replaced by references to values under
+// valuesPath. It returns the template and the values of the replaced fields,
+// nested as in obj. ok is false when no path names a field of obj; paths into
+// lists and into apiVersion, kind, metadata or status are ignored.
+func hintedBody(obj *unstructured.Unstructured, paths [][]string, valuesPath string) (template string, values map[string]interface{}, ok bool) {
+	body := obj.DeepCopy().Object
+	for _, field := range []string{"apiVersion", "kind", "metadata", "status"} {
+		delete(body, field)
+	}
+
+	values = make(map[string]interface{})
+	refs := make(map[string]string)
+	for _, path := range paths {
+		value, found, err := unstructured.NestedFieldNoCopy(body, path...)
+		if err != nil || !found {
+			continue
+		}
+		placeholder := fmt.Sprintf("DHG_VALUE_%d_", len(refs))
+		if err := unstructured.SetNestedField(values, value, path...); err != nil {
+			continue
+		}
+		if err := unstructured.SetNestedField(body, placeholder, path...); err != nil {
+			continue
+		}
+		refs[placeholder] = valuesRef(valuesPath, path)
+	}
+	if len(refs) == 0 {
+		return "", nil, false
+	}
+
+	data, err := yaml.Marshal(body)
+	if err != nil {
+		return "", nil, false
+	}
+	template = strings.ReplaceAll(string(data), "{{", `{{ "{{" }}`)
+	// toJson renders any value, scalar or not, as valid inline YAML.
+	for placeholder, ref := range refs {
+		template = strings.Replace(template, placeholder, "{{ toJson "+ref+" }}", 1)
+	}
+	return template, values, true
+}
+
+// valuesRef returns the template reference of the value at path under
+// valuesPath, using index for keys that are not identifiers.
+func valuesRef(valuesPath string, path []string) string {
+	for _, key := range path {
+		if !identifierRe.MatchString(key) {
+			return "(index .Values." + valuesPath + ` "` + strings.Join(path, `" "`) + `")`
+		}
+	}
+	return ".Values." + valuesPath + "." + strings.Join(path, ".")
+}
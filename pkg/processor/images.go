@@ -0,0 +1,201 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ImageRewrite replaces a registry or repository prefix of container image
+// references, e.g. docker.io=registry.example.com/mirror.
+type ImageRewrite struct {
+	// From is the prefix to replace. It matches whole path components only:
+	// "docker.io" matches "docker.io/nginx" but not "docker.io2/nginx".
+	// "docker.io" also matches images without a registry host ("nginx").
+	From string
+	// To replaces From.
+	To string
+}
+
+// ParseImageRewrite parses an "old=new" image rewrite rule.
+func ParseImageRewrite(s string) (ImageRewrite, error) {
+	from, to, ok := strings.Cut(s, "=")
+	from = strings.TrimSuffix(strings.TrimSpace(from), "/")
+	to = strings.TrimSuffix(strings.TrimSpace(to), "/")
+	if !ok || from == "" || to == "" {
+		return ImageRewrite{}, fmt.Errorf("invalid image rewrite %q (must be old=new)", s)
+	}
+	return ImageRewrite{From: from, To: to}, nil
+}
+
+// Apply returns image with the rule applied and whether it matched.
+func (r ImageRewrite) Apply(image string) (string, bool) {
+	name := image
+	if r.From == "docker.io" && !hasRegistryHost(image) {
+		name = "docker.io/" + image
+	}
+	if !strings.HasPrefix(name, r.From) {
+		return image, false
+	}
+	rest := name[len(r.From):]
+	if rest != "" && !strings.ContainsAny(rest[:1], "/:@") {
+		return image, false
+	}
+	return r.To + rest, true
+}
+
+// hasRegistryHost reports whether the first path component of image is a
+// registry host, following the Docker reference rules.
+func hasRegistryHost(image string) bool {
+	first, _, ok := strings.Cut(image, "/")
+	if !ok {
+		return false
+	}
+	return strings.ContainsAny(first, ".:") || first == "localhost"
+}
+
+// RewriteImage applies the first matching rule, preferring the longest
+// From, and returns the rewritten image.
+func RewriteImage(image string, rules []ImageRewrite) string {
+	best := -1
+	for i, r := range rules {
+		if _, ok := r.Apply(image); ok && (best < 0 || len(r.From) > len(rules[best].From)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return image
+	}
+	out, _ := rules[best].Apply(image)
+	return out
+}
+
+// ImageChange records a container image reference changed in place.
+type ImageChange struct {
+	// Container is the container name.
+	Container string
+	// From is the original image reference.
+	From string
+	// To is the new image reference.
+	To string
+}
+
+// RewriteImages applies rules to the images of every container of a
+// workload in place and returns the changed references.
+func RewriteImages(obj *unstructured.Unstructured, rules []ImageRewrite) []ImageChange {
+	changes, _ := mapContainerImages(obj, func(_, image string) (string, error) {
+		return RewriteImage(image, rules), nil
+	})
+	return changes
+}
+
+// DigestResolver resolves an image reference to its manifest digest
+// ("sha256:...").
+type DigestResolver func(ctx context.Context, image string) (string, error)
+
+// RegistryDigest resolves image against its registry, using the credentials
+// of the local Docker config.
+func RegistryDigest(ctx context.Context, image string) (string, error) {
+	return crane.Digest(image, crane.WithContext(ctx), crane.WithAuthFromKeychain(authn.DefaultKeychain))
+}
+
+// PinImageDigests appends the digest returned by resolve to the images of
+// every container of a workload that are not pinned yet
+// ("nginx:1.25" becomes "nginx:1.25@sha256:..."), and returns the changed
+// references.
+func PinImageDigests(ctx context.Context, obj *unstructured.Unstructured, resolve DigestResolver) ([]ImageChange, error) {
+	return mapContainerImages(obj, func(_, image string) (string, error) {
+		if strings.Contains(image, "@") {
+			return image, nil
+		}
+		digest, err := resolve(ctx, image)
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve digest of image %s: %w", image, err)
+		}
+		return image + "@" + digest, nil
+	})
+}
+
+// ContainerImage is the image of one container of a workload.
+type ContainerImage struct {
+	// Container is the container name.
+	Container string
+	// Image is the image reference.
+	Image string
+}
+
+// ContainerImages returns the images of the init, regular and ephemeral
+// containers of a workload, in that order.
+func ContainerImages(obj *unstructured.Unstructured) []ContainerImage {
+	var images []ContainerImage
+	// The image is returned unchanged, so obj is not modified.
+	_, _ = mapContainerImages(obj, func(container, image string) (string, error) {
+		images = append(images, ContainerImage{Container: container, Image: image})
+		return image, nil
+	})
+	return images
+}
+
+// PodSpecPath returns the path of the pod spec of a workload kind, or nil
+// for kinds without one.
+func PodSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return []string{"spec", "template", "spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+	return nil
+}
+
+// mapContainerImages replaces the image of every container, init container
+// and ephemeral container of obj with the result of fn.
+func mapContainerImages(obj *unstructured.Unstructured, fn func(container, image string) (string, error)) ([]ImageChange, error) {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return nil, nil
+	}
+
+	var changes []ImageChange
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		path := append(append([]string{}, specPath...), field)
+		containers, found, _ := unstructured.NestedSlice(obj.Object, path...)
+		if !found {
+			continue
+		}
+		changed := false
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, ok := container["image"].(string)
+			if !ok || image == "" {
+				continue
+			}
+			name, _ := container["name"].(string)
+			newImage, err := fn(name, image)
+			if err != nil {
+				return changes, err
+			}
+			if newImage == image {
+				continue
+			}
+			container["image"] = newImage
+			changes = append(changes, ImageChange{Container: name, From: image, To: newImage})
+			changed = true
+		}
+		if changed {
+			if err := unstructured.SetNestedSlice(obj.Object, containers, path...); err != nil {
+				return changes, err
+			}
+		}
+	}
+	return changes, nil
+}
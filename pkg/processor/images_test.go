@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseImageRewrite(t *testing.T) {
+	r, err := ParseImageRewrite("docker.io=registry.example.com/mirror/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.From != "docker.io" || r.To != "registry.example.com/mirror" {
+		t.Errorf("unexpected rule: %+v", r)
+	}
+	for _, s := range []string{"docker.io", "=registry.example.com", "docker.io="} {
+		if _, err := ParseImageRewrite(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	rules := []ImageRewrite{
+		{From: "docker.io", To: "mirror.local/dockerhub"},
+		{From: "ghcr.io", To: "mirror.local/ghcr"},
+		{From: "ghcr.io/acme/api", To: "mirror.local/acme-api"},
+	}
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"nginx:1.25", "mirror.local/dockerhub/nginx:1.25"},
+		{"bitnami/redis:7", "mirror.local/dockerhub/bitnami/redis:7"},
+		{"docker.io/library/nginx", "mirror.local/dockerhub/library/nginx"},
+		{"ghcr.io/acme/web@sha256:abc", "mirror.local/ghcr/acme/web@sha256:abc"},
+		{"ghcr.io/acme/api:v2", "mirror.local/acme-api:v2"},
+		{"ghcr.io/acme/api-gateway:v2", "mirror.local/ghcr/acme/api-gateway:v2"},
+		{"ghcr.iox/acme/web", "ghcr.iox/acme/web"},
+		{"quay.io/prometheus/prometheus", "quay.io/prometheus/prometheus"},
+		{"localhost:5000/app", "localhost:5000/app"},
+	}
+	for _, tt := range tests {
+		if got := RewriteImage(tt.image, rules); got != tt.want {
+			t.Errorf("RewriteImage(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+}
+
+func cronJobWithImages(images ...string) *unstructured.Unstructured {
+	containers := make([]interface{}, 0, len(images))
+	for i, image := range images {
+		containers = append(containers, map[string]interface{}{"name": string(rune('a' + i)), "image": image})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   map[string]interface{}{"name": "backup"},
+		"spec": map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "busybox"}},
+				"containers":     containers,
+			}},
+		}}},
+	}}
+}
+
+func TestRewriteImages(t *testing.T) {
+	obj := cronJobWithImages("docker.io/postgres:16", "quay.io/minio/mc")
+	changes := RewriteImages(obj, []ImageRewrite{{From: "docker.io", To: "mirror.local"}})
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0] != (ImageChange{Container: "init", From: "busybox", To: "mirror.local/busybox"}) {
+		t.Errorf("unexpected change: %+v", changes[0])
+	}
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "mirror.local/postgres:16" {
+		t.Errorf("image = %v", image)
+	}
+	if image := containers[1].(map[string]interface{})["image"]; image != "quay.io/minio/mc" {
+		t.Errorf("image = %v", image)
+	}
+
+	if changes := RewriteImages(&unstructured.Unstructured{Object: map[string]interface{}{"kind": "ConfigMap"}}, nil); changes != nil {
+		t.Errorf("expected no changes for a ConfigMap, got %+v", changes)
+	}
+}
+
+func TestPinImageDigests(t *testing.T) {
+	var resolved []string
+	resolve := func(_ context.Context, image string) (string, error) {
+		resolved = append(resolved, image)
+		return "sha256:0123", nil
+	}
+	obj := cronJobWithImages("postgres:16", "quay.io/minio/mc@sha256:ffff")
+
+	changes, err := PinImageDigests(context.Background(), obj, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[1].To != "postgres:16@sha256:0123" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if len(resolved) != 2 {
+		t.Errorf("already pinned images must not be resolved, resolved %v", resolved)
+	}
+
+	_, err = PinImageDigests(context.Background(), cronJobWithImages("app:v1"), func(context.Context, string) (string, error) {
+		return "", errors.New("unauthorized")
+	})
+	if err == nil {
+		t.Error("expected a resolve error")
+	}
+}
@@ -33,7 +33,7 @@ func (p *FlaggerCanaryProcessor) Process(ctx processor.Context, obj *unstructure
 		return nil, errors.New("Canary object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
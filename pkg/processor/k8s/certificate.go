@@ -8,6 +8,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
 // CertificateProcessor processes cert-manager Certificate resources.
@@ -32,7 +33,7 @@ func (p *CertificateProcessor) Process(ctx processor.Context, obj *unstructured.
 		return nil, errors.New("Certificate object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -40,7 +41,7 @@ func (p *CertificateProcessor) Process(ctx processor.Context, obj *unstructured.
 	name := obj.GetName()
 	namespace := obj.GetNamespace()
 
-	values := p.extractValues(obj)
+	values, deps := p.extractValues(obj)
 	template := p.generateTemplate(ctx, serviceName)
 
 	return &processor.Result{
@@ -50,6 +51,7 @@ func (p *CertificateProcessor) Process(ctx processor.Context, obj *unstructured.
 		TemplateContent: template,
 		ValuesPath:      fmt.Sprintf("services.%s.certificate", serviceName),
 		Values:          values,
+		Dependencies:    deps,
 		Metadata: map[string]interface{}{
 			"name":      name,
 			"namespace": namespace,
@@ -57,8 +59,9 @@ func (p *CertificateProcessor) Process(ctx processor.Context, obj *unstructured.
 	}, nil
 }
 
-func (p *CertificateProcessor) extractValues(obj *unstructured.Unstructured) map[string]interface{} {
+func (p *CertificateProcessor) extractValues(obj *unstructured.Unstructured) (map[string]interface{}, []types.ResourceKey) {
 	values := make(map[string]interface{})
+	var deps []types.ResourceKey
 
 	// Preserve full spec for pipeline integration
 	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec"); ok {
@@ -70,9 +73,13 @@ func (p *CertificateProcessor) extractValues(obj *unstructured.Unstructured) map
 		values["dnsNames"] = dnsNames
 	}
 
-	// Extract issuerRef
+	// Extract issuerRef and create a dependency on the Issuer or ClusterIssuer
 	if issuerRef, ok, _ := unstructured.NestedMap(obj.Object, "spec", "issuerRef"); ok {
 		values["issuerRef"] = issuerRef
+
+		if key, ok := issuerRefKey(issuerRef, obj.GetNamespace()); ok {
+			deps = append(deps, key)
+		}
 	}
 
 	// Extract secretName
@@ -90,7 +97,36 @@ func (p *CertificateProcessor) extractValues(obj *unstructured.Unstructured) map
 		values["renewBefore"] = renewBefore
 	}
 
-	return values
+	return values, deps
+}
+
+// issuerRefKey resolves a cert-manager issuerRef to the key of the referenced
+// Issuer (the default kind, in the Certificate's namespace) or ClusterIssuer.
+// External issuers of other API groups do not resolve.
+func issuerRefKey(issuerRef map[string]interface{}, namespace string) (types.ResourceKey, bool) {
+	name, _ := issuerRef["name"].(string)
+	if name == "" {
+		return types.ResourceKey{}, false
+	}
+	if group, _ := issuerRef["group"].(string); group != "" && group != "cert-manager.io" {
+		return types.ResourceKey{}, false
+	}
+
+	kind, _ := issuerRef["kind"].(string)
+	switch kind {
+	case "ClusterIssuer":
+		namespace = ""
+	case "", "Issuer":
+		kind = "Issuer"
+	default:
+		return types.ResourceKey{}, false
+	}
+
+	return types.ResourceKey{
+		GVK:       schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: kind},
+		Namespace: namespace,
+		Name:      name,
+	}, true
 }
 
 func (p *CertificateProcessor) generateTemplate(ctx processor.Context, serviceName string) string {
@@ -214,3 +214,38 @@ func TestCertificateProcessor_ServiceName(t *testing.T) {
 	testutil.AssertNoError(t, err)
 	testutil.AssertEqual(t, "myapp-cert", result.ServiceName, "ServiceName")
 }
+
+func TestCertificateProcessor_IssuerDependency(t *testing.T) {
+	tests := []struct {
+		name      string
+		issuerRef map[string]interface{}
+		kind      string
+		namespace string
+	}{
+		{name: "DefaultIssuer", issuerRef: map[string]interface{}{"name": "ca"}, kind: "Issuer", namespace: "default"},
+		{name: "ClusterIssuer", issuerRef: map[string]interface{}{"name": "ca", "kind": "ClusterIssuer"}, kind: "ClusterIssuer", namespace: ""},
+		{name: "ExternalIssuer", issuerRef: map[string]interface{}{"name": "ca", "kind": "AWSPCAIssuer", "group": "awspca.cert-manager.io"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := makeCertificateObj("myapp-cert", "default", map[string]interface{}{
+				"secretName": "myapp-tls",
+				"issuerRef":  tt.issuerRef,
+			})
+
+			result, err := NewCertificateProcessor().Process(newTestProcessorContext(), obj)
+			testutil.AssertNoError(t, err)
+
+			if tt.kind == "" {
+				if len(result.Dependencies) != 0 {
+					t.Errorf("expected no dependencies, got %v", result.Dependencies)
+				}
+				return
+			}
+			if !hasDependency(result.Dependencies, tt.kind, tt.namespace, "ca") {
+				t.Errorf("expected %s dependency, got %v", tt.kind, result.Dependencies)
+			}
+		})
+	}
+}
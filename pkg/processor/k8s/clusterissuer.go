@@ -32,7 +32,7 @@ func (p *ClusterIssuerProcessor) Process(ctx processor.Context, obj *unstructure
 		return nil, errors.New("ClusterIssuer object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -56,6 +56,12 @@ func (p *ClusterIssuerProcessor) Process(ctx processor.Context, obj *unstructure
 }
 
 func (p *ClusterIssuerProcessor) extractValues(obj *unstructured.Unstructured) map[string]interface{} {
+	return extractIssuerValues(obj)
+}
+
+// extractIssuerValues extracts the spec of an Issuer or ClusterIssuer; both
+// kinds share the same spec.
+func extractIssuerValues(obj *unstructured.Unstructured) map[string]interface{} {
 	values := make(map[string]interface{})
 
 	// Preserve full spec for pipeline integration
@@ -32,7 +32,7 @@ func (p *ServiceAccountProcessor) Process(ctx processor.Context, obj *unstructur
 		return nil, fmt.Errorf("serviceaccount object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -69,8 +69,8 @@ metadata:
   annotations:
     {{- toYaml . | nindent 4 }}
   {{- end }}
-{{- with .automountServiceAccountToken }}
-automountServiceAccountToken: {{ . }}
+{{- if hasKey . "automountServiceAccountToken" }}
+automountServiceAccountToken: {{ .automountServiceAccountToken }}
 {{- end }}
 {{- with .imagePullSecrets }}
 imagePullSecrets:
@@ -113,12 +113,15 @@ func (p *StatefulSetProcessor) Process(ctx processor.Context, obj *unstructured.
 		return nil, fmt.Errorf("statefulset object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
 
 	values, deps := extractWorkloadValues(obj)
+	if preserveSelectors(ctx) {
+		extractSelectorValues(obj, values)
+	}
 
 	// StatefulSet-specific values
 	if svcName, found, _ := unstructured.NestedString(obj.Object, "spec", "serviceName"); found {
@@ -131,8 +134,8 @@ func (p *StatefulSetProcessor) Process(ctx processor.Context, obj *unstructured.
 	}
 
 	// Volume claim templates
-	if vcts, found, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates"); found {
-		values["volumeClaimTemplates"] = vcts
+	if vcts, found, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates"); found && len(vcts) > 0 {
+		values["persistence"] = extractPersistence(vcts)
 	}
 
 	// Pod management policy
@@ -178,10 +181,7 @@ spec:
   {{- with .podManagementPolicy }}
   podManagementPolicy: {{ . }}
   {{- end }}
-  selector:
-    matchLabels:
-      {{- include "%s.selectorLabels" $ | nindent 6 }}
-      app.kubernetes.io/component: %s
+%s
   {{- with .updateStrategy }}
   updateStrategy:
     {{- toYaml . | nindent 4 }}
@@ -192,9 +192,7 @@ spec:
       annotations:
         {{- toYaml . | nindent 8 }}
       {{- end }}
-      labels:
-        {{- include "%s.labels" $ | nindent 8 }}
-        app.kubernetes.io/component: %s
+%s
     spec:
       {{- with $.Values.global.imagePullSecrets }}
       imagePullSecrets:
@@ -203,10 +201,16 @@ spec:
       {{- with .serviceAccountName }}
       serviceAccountName: {{ . }}
       {{- end }}
+      {{- if hasKey . "automountServiceAccountToken" }}
+      automountServiceAccountToken: {{ .automountServiceAccountToken }}
+      {{- end }}
+      {{- with .priorityClassName }}
+      priorityClassName: {{ . }}
+      {{- end }}
       containers:
         {{- range .containers }}
         - name: {{ .name }}
-          image: "{{ .image.repository }}:{{ .image.tag }}"
+          image: "{{ .image.repository }}{{ if .image.digest }}@{{ .image.digest }}{{ else }}:{{ .image.tag }}{{ end }}"
           imagePullPolicy: {{ .image.pullPolicy | default "IfNotPresent" }}
           {{- with .ports }}
           ports:
@@ -233,20 +237,120 @@ spec:
       nodeSelector:
         {{- toYaml . | nindent 8 }}
       {{- end }}
+      {{- with .affinity }}
+      affinity:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
       {{- with .tolerations }}
       tolerations:
         {{- toYaml . | nindent 8 }}
       {{- end }}
-  {{- with .volumeClaimTemplates }}
+      {{- with .topologySpreadConstraints }}
+      topologySpreadConstraints:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+  {{- with .persistence }}
   volumeClaimTemplates:
-    {{- toYaml . | nindent 4 }}
+    {{- range $name, $claim := . }}
+    - metadata:
+        name: {{ $name }}
+        {{- with $claim.labels }}
+        labels:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        {{- with $claim.annotations }}
+        annotations:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+      spec:
+        accessModes:
+          {{- toYaml ($claim.accessModes | default (list "ReadWriteOnce")) | nindent 10 }}
+        {{- if hasKey $claim "storageClassName" }}
+        storageClassName: {{ $claim.storageClassName | quote }}
+        {{- end }}
+        {{- with $claim.volumeMode }}
+        volumeMode: {{ . }}
+        {{- end }}
+        {{- with $claim.selector }}
+        selector:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        {{- with $claim.dataSource }}
+        dataSource:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        {{- with $claim.dataSourceRef }}
+        dataSourceRef:
+          {{- toYaml . | nindent 10 }}
+        {{- end }}
+        resources:
+          requests:
+            storage: {{ $claim.size | quote }}
+          {{- with $claim.sizeLimit }}
+          limits:
+            storage: {{ . | quote }}
+          {{- end }}
+    {{- end }}
   {{- end }}
 {{- end }}
 {{- end }}
 `, serviceName, fullnameHelper, serviceName,
 		ctx.ChartName, serviceName,
-		ctx.ChartName, serviceName,
-		ctx.ChartName, serviceName)
+		workloadSelector(ctx, serviceName),
+		workloadPodLabels(ctx, serviceName, ""))
+}
+
+// extractPersistence converts StatefulSet volumeClaimTemplates to values
+// keyed by claim name, so that the storage class, size and access modes of
+// each claim can be overridden:
+//
+//	persistence:
+//	  data:
+//	    storageClassName: fast-ssd
+//	    size: 10Gi
+//	    accessModes: [ReadWriteOnce]
+//
+// Labels, annotations, volumeMode, selector, data sources and a storage
+// limit (sizeLimit) are kept when set; the claim status is dropped.
+func extractPersistence(vcts []interface{}) map[string]interface{} {
+	persistence := make(map[string]interface{}, len(vcts))
+	for i, item := range vcts {
+		vct, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(vct, "metadata", "name")
+		if name == "" {
+			name = fmt.Sprintf("claim%d", i)
+		}
+		claim := map[string]interface{}{}
+		if labels, found, _ := unstructured.NestedMap(vct, "metadata", "labels"); found && len(labels) > 0 {
+			claim["labels"] = labels
+		}
+		if annotations, found, _ := unstructured.NestedMap(vct, "metadata", "annotations"); found && len(annotations) > 0 {
+			claim["annotations"] = annotations
+		}
+		spec, _, _ := unstructured.NestedMap(vct, "spec")
+		if modes, ok := spec["accessModes"].([]interface{}); ok && len(modes) > 0 {
+			claim["accessModes"] = modes
+		}
+		if class, ok := spec["storageClassName"].(string); ok {
+			claim["storageClassName"] = class
+		}
+		if size, found, _ := unstructured.NestedFieldNoCopy(spec, "resources", "requests", "storage"); found {
+			claim["size"] = size
+		}
+		if limit, found, _ := unstructured.NestedFieldNoCopy(spec, "resources", "limits", "storage"); found {
+			claim["sizeLimit"] = limit
+		}
+		for _, key := range []string{"volumeMode", "selector", "dataSource", "dataSourceRef"} {
+			if v, ok := spec[key]; ok && v != nil {
+				claim[key] = v
+			}
+		}
+		persistence[name] = claim
+	}
+	return persistence
 }
 
 // DaemonSetProcessor processes Kubernetes DaemonSets.
@@ -271,12 +375,15 @@ func (p *DaemonSetProcessor) Process(ctx processor.Context, obj *unstructured.Un
 		return nil, fmt.Errorf("daemonset object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
 
 	values, deps := extractWorkloadValues(obj)
+	if preserveSelectors(ctx) {
+		extractSelectorValues(obj, values)
+	}
 
 	// Update strategy
 	if strategy, found, _ := unstructured.NestedMap(obj.Object, "spec", "updateStrategy"); found {
@@ -311,10 +418,7 @@ metadata:
     {{- include "%s.labels" $ | nindent 4 }}
     app.kubernetes.io/component: %s
 spec:
-  selector:
-    matchLabels:
-      {{- include "%s.selectorLabels" $ | nindent 6 }}
-      app.kubernetes.io/component: %s
+%s
   {{- with .updateStrategy }}
   updateStrategy:
     {{- toYaml . | nindent 4 }}
@@ -325,9 +429,7 @@ spec:
       annotations:
         {{- toYaml . | nindent 8 }}
       {{- end }}
-      labels:
-        {{- include "%s.labels" $ | nindent 8 }}
-        app.kubernetes.io/component: %s
+%s
     spec:
       {{- with $.Values.global.imagePullSecrets }}
       imagePullSecrets:
@@ -336,10 +438,16 @@ spec:
       {{- with .serviceAccountName }}
       serviceAccountName: {{ . }}
       {{- end }}
+      {{- if hasKey . "automountServiceAccountToken" }}
+      automountServiceAccountToken: {{ .automountServiceAccountToken }}
+      {{- end }}
+      {{- with .priorityClassName }}
+      priorityClassName: {{ . }}
+      {{- end }}
       containers:
         {{- range .containers }}
         - name: {{ .name }}
-          image: "{{ .image.repository }}:{{ .image.tag }}"
+          image: "{{ .image.repository }}{{ if .image.digest }}@{{ .image.digest }}{{ else }}:{{ .image.tag }}{{ end }}"
           imagePullPolicy: {{ .image.pullPolicy | default "IfNotPresent" }}
           {{- with .ports }}
           ports:
@@ -366,16 +474,24 @@ spec:
       nodeSelector:
         {{- toYaml . | nindent 8 }}
       {{- end }}
+      {{- with .affinity }}
+      affinity:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
       {{- with .tolerations }}
       tolerations:
         {{- toYaml . | nindent 8 }}
       {{- end }}
+      {{- with .topologySpreadConstraints }}
+      topologySpreadConstraints:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
 {{- end }}
 {{- end }}
 `, serviceName, fullnameHelper, serviceName,
 		ctx.ChartName, serviceName,
-		ctx.ChartName, serviceName,
-		ctx.ChartName, serviceName)
+		workloadSelector(ctx, serviceName),
+		workloadPodLabels(ctx, serviceName, ""))
 }
 
 // PVCProcessor processes Kubernetes PersistentVolumeClaims.
@@ -400,7 +516,7 @@ func (p *PVCProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 		return nil, fmt.Errorf("pvc object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -484,7 +600,7 @@ func extractWorkloadValues(obj *unstructured.Unstructured) (map[string]interface
 	var deps []types.ResourceKey
 
 	// Replicas (not for DaemonSet)
-	if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+	if replicas, found := nestedInt64(obj.Object, "spec", "replicas"); found {
 		values["replicas"] = replicas
 	}
 
@@ -502,11 +618,7 @@ func extractWorkloadValues(obj *unstructured.Unstructured) (map[string]interface
 				cv["name"] = name
 			}
 			if image, ok := container["image"].(string); ok {
-				repo, tag := parseImage(image)
-				cv["image"] = map[string]interface{}{
-					"repository": repo,
-					"tag":        tag,
-				}
+				cv["image"] = imageValues(image)
 			}
 			if resources, ok := container["resources"].(map[string]interface{}); ok {
 				cv["resources"] = resources
@@ -543,6 +655,20 @@ func extractWorkloadValues(obj *unstructured.Unstructured) (map[string]interface
 		})
 	}
 
+	// Token automounting; set to false by --no-token-automount
+	if automount, found, _ := unstructured.NestedBool(obj.Object, "spec", "template", "spec", "automountServiceAccountToken"); found {
+		values["automountServiceAccountToken"] = automount
+	}
+
+	// PriorityClass (cluster-scoped)
+	if pc, found, _ := unstructured.NestedString(obj.Object, "spec", "template", "spec", "priorityClassName"); found && pc != "" {
+		values["priorityClassName"] = pc
+		deps = append(deps, types.ResourceKey{
+			GVK:  schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+			Name: pc,
+		})
+	}
+
 	// Node selector
 	if nodeSelector, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "spec", "nodeSelector"); found {
 		values["nodeSelector"] = nodeSelector
@@ -553,6 +679,16 @@ func extractWorkloadValues(obj *unstructured.Unstructured) (map[string]interface
 		values["tolerations"] = tolerations
 	}
 
+	// Affinity
+	if affinity, found, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec", "affinity"); found {
+		values["affinity"] = affinity
+	}
+
+	// Topology spread constraints
+	if tsc, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "topologySpreadConstraints"); len(tsc) > 0 {
+		values["topologySpreadConstraints"] = tsc
+	}
+
 	// Pod annotations
 	if annotations, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "annotations"); found {
 		values["podAnnotations"] = annotations
@@ -560,3 +696,65 @@ func extractWorkloadValues(obj *unstructured.Unstructured) (map[string]interface
 
 	return values, deps
 }
+
+// deploymentPodLabels appends the original pod labels to the chart's labels
+// in Deployment pod templates.
+const deploymentPodLabels = `
+        {{- with .podLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}`
+
+// preserveSelectors reports whether workload templates render the original
+// spec.selector (processor.OptionPreserveSelectors).
+func preserveSelectors(ctx processor.Context) bool {
+	preserve, _ := ctx.Options[processor.OptionPreserveSelectors].(bool)
+	return preserve
+}
+
+// workloadSelector returns the spec.selector of a workload template. The
+// selector of Deployments, StatefulSets and DaemonSets is immutable, so a
+// chart that takes over live objects must keep it: with
+// OptionPreserveSelectors the original selector (.selector) is rendered
+// verbatim instead of one built from the selectorLabels helper.
+func workloadSelector(ctx processor.Context, serviceName string) string {
+	if preserveSelectors(ctx) {
+		return fmt.Sprintf(`  selector:
+  {{- with .selector }}
+    {{- toYaml . | nindent 4 }}
+  {{- else }}
+    matchLabels:
+      {{- include "%s.selectorLabels" $ | nindent 6 }}
+      app.kubernetes.io/component: %s
+  {{- end }}`, ctx.ChartName, serviceName)
+	}
+	return fmt.Sprintf(`  selector:
+    matchLabels:
+      {{- include "%s.selectorLabels" $ | nindent 6 }}
+      app.kubernetes.io/component: %s`, ctx.ChartName, serviceName)
+}
+
+// workloadPodLabels returns the pod template labels of a workload template:
+// the chart's labels followed by extra. With OptionPreserveSelectors the
+// original pod labels (.podLabels) take precedence over the chart's labels,
+// so that the pods keep matching the preserved selector.
+func workloadPodLabels(ctx processor.Context, serviceName, extra string) string {
+	if preserveSelectors(ctx) {
+		return fmt.Sprintf(`      labels:
+        {{- $labels := merge (dict) (.podLabels | default dict) (include "%s.labels" $ | fromYaml) (dict "app.kubernetes.io/component" "%s") }}
+        {{- toYaml $labels | nindent 8 }}`, ctx.ChartName, serviceName)
+	}
+	return fmt.Sprintf(`      labels:
+        {{- include "%s.labels" $ | nindent 8 }}
+        app.kubernetes.io/component: %s`, ctx.ChartName, serviceName) + extra
+}
+
+// extractSelectorValues stores the original selector and pod labels of a
+// workload for workloadSelector and workloadPodLabels.
+func extractSelectorValues(obj *unstructured.Unstructured, values map[string]interface{}) {
+	if selector, found, _ := unstructured.NestedMap(obj.Object, "spec", "selector"); found {
+		values["selector"] = selector
+	}
+	if labels, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels"); found {
+		values["podLabels"] = labels
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
 )
 
@@ -179,18 +180,60 @@ func TestProcessStatefulSet_ExtractsVolumeClaimTemplates(t *testing.T) {
 	result, err := p.Process(ctx, obj)
 
 	testutil.AssertNoError(t, err)
-	vcts, ok := result.Values["volumeClaimTemplates"].([]interface{})
+	if _, exists := result.Values["volumeClaimTemplates"]; exists {
+		t.Error("volumeClaimTemplates should be exposed as persistence")
+	}
+	persistence, ok := result.Values["persistence"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected persistence to be a map, got %T", result.Values["persistence"])
+	}
+	claim, ok := persistence["data"].(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected volumeClaimTemplates to be []interface{}")
+		t.Fatalf("Expected a claim named data, got %v", persistence)
 	}
-	testutil.AssertEqual(t, 1, len(vcts))
+	testutil.AssertEqual(t, "fast-ssd", claim["storageClassName"])
+	testutil.AssertEqual(t, "10Gi", claim["size"])
+	modes, _ := claim["accessModes"].([]interface{})
+	if len(modes) != 1 || modes[0] != "ReadWriteOnce" {
+		t.Errorf("accessModes = %v", claim["accessModes"])
+	}
+	testutil.AssertContains(t, result.TemplateContent, "range $name, $claim := .")
+}
 
-	vct, ok := vcts[0].(map[string]interface{})
+func TestExtractPersistence(t *testing.T) {
+	persistence := extractPersistence([]interface{}{
+		map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":        "logs",
+				"annotations": map[string]interface{}{"backup": "false"},
+			},
+			"spec": map[string]interface{}{
+				"storageClassName": "",
+				"volumeMode":       "Block",
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"storage": "1Gi"},
+					"limits":   map[string]interface{}{"storage": "2Gi"},
+				},
+			},
+			"status": map[string]interface{}{"phase": "Pending"},
+		},
+	})
+	claim, ok := persistence["logs"].(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected VCT element to be map")
+		t.Fatalf("persistence = %v", persistence)
+	}
+	if class, ok := claim["storageClassName"]; !ok || class != "" {
+		t.Errorf("an empty storageClassName should be kept, got %v", claim)
+	}
+	testutil.AssertEqual(t, "Block", claim["volumeMode"])
+	testutil.AssertEqual(t, "1Gi", claim["size"])
+	testutil.AssertEqual(t, "2Gi", claim["sizeLimit"])
+	if _, ok := claim["accessModes"]; ok {
+		t.Error("accessModes should not be set when the claim has none")
+	}
+	if _, ok := claim["status"]; ok {
+		t.Error("the claim status should be dropped")
 	}
-	meta, _ := vct["metadata"].(map[string]interface{})
-	testutil.AssertEqual(t, "data", meta["name"])
 }
 
 // ============================================================
@@ -294,6 +337,59 @@ func TestProcessDaemonSet_ExtractsNodeSelector(t *testing.T) {
 	testutil.AssertEqual(t, "ssd", ns["disktype"])
 }
 
+func TestProcessStatefulSet_ExtractsTopologySpreadConstraints(t *testing.T) {
+	p := NewStatefulSetProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeWorkloadSpec("db", "postgres:16")
+	spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["topologySpreadConstraints"] = []interface{}{
+		map[string]interface{}{
+			"maxSkew":           int64(1),
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "ScheduleAnyway",
+		},
+	}
+
+	obj := makeStatefulSetObj("db", "default", nil, spec)
+	result, err := p.Process(ctx, obj)
+
+	testutil.AssertNoError(t, err)
+	tsc, ok := result.Values["topologySpreadConstraints"].([]interface{})
+	if !ok || len(tsc) != 1 {
+		t.Fatalf("Expected one topologySpreadConstraint, got %v", result.Values["topologySpreadConstraints"])
+	}
+	testutil.AssertContains(t, result.TemplateContent, "topologySpreadConstraints:")
+}
+
+func TestProcessDaemonSet_ExtractsNodeAffinity(t *testing.T) {
+	p := NewDaemonSetProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeWorkloadSpec("agent", "agent:1.0")
+	spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["affinity"] = map[string]interface{}{
+		"nodeAffinity": map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+				"nodeSelectorTerms": []interface{}{
+					map[string]interface{}{
+						"matchExpressions": []interface{}{
+							map[string]interface{}{"key": "kubernetes.io/os", "operator": "In", "values": []interface{}{"linux"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	obj := makeDaemonSetObj("agent", "default", nil, spec)
+	result, err := p.Process(ctx, obj)
+
+	testutil.AssertNoError(t, err)
+	if _, ok := result.Values["affinity"].(map[string]interface{}); !ok {
+		t.Fatalf("Expected affinity in values, got %v", result.Values["affinity"])
+	}
+	testutil.AssertContains(t, result.TemplateContent, "affinity:")
+}
+
 // ============================================================
 // Subtask 7: PVC — Extract storageClassName
 // ============================================================
@@ -529,6 +625,45 @@ func TestProcessServiceAccount_ExtractsAutomountToken(t *testing.T) {
 	testutil.AssertEqual(t, false, result.Values["automountServiceAccountToken"])
 }
 
+// ============================================================
+// Preserved selectors
+// ============================================================
+
+func TestProcessWorkloads_PreserveSelectors(t *testing.T) {
+	selector := map[string]interface{}{"matchLabels": map[string]interface{}{"app": "db"}}
+	spec := makeWorkloadSpec("db", "postgres:15")
+	spec["selector"] = selector
+
+	ctx := newTestProcessorContext()
+	result, err := NewStatefulSetProcessor().Process(ctx, makeStatefulSetObj("db", "default", nil, spec))
+	testutil.AssertNoError(t, err)
+	if _, exists := result.Values["selector"]; exists {
+		t.Error("selector should only be stored when selectors are preserved")
+	}
+	if strings.Contains(result.TemplateContent, "{{- with .selector }}") {
+		t.Error("template should build the selector from the selectorLabels helper")
+	}
+
+	ctx.Options = map[string]interface{}{processor.OptionPreserveSelectors: true}
+	for _, tt := range []struct {
+		p   processor.Processor
+		obj *unstructured.Unstructured
+	}{
+		{NewStatefulSetProcessor(), makeStatefulSetObj("db", "default", nil, spec)},
+		{NewDaemonSetProcessor(), makeDaemonSetObj("db", "default", nil, spec)},
+	} {
+		result, err := tt.p.Process(ctx, tt.obj)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, selector, result.Values["selector"])
+		testutil.AssertEqual(t, map[string]string{"app": "db"}, result.Values["podLabels"])
+		for _, want := range []string{"{{- with .selector }}", "merge (dict) (.podLabels | default dict)"} {
+			if !strings.Contains(result.TemplateContent, want) {
+				t.Errorf("%s template: expected %q", tt.obj.GetKind(), want)
+			}
+		}
+	}
+}
+
 // ============================================================
 // Subtask 14: Edge cases
 // ============================================================
@@ -554,8 +689,8 @@ func TestProcessStatefulSet_EdgeCases(t *testing.T) {
 
 		testutil.AssertNoError(t, err)
 		testutil.AssertEqual(t, true, result.Processed)
-		if _, exists := result.Values["volumeClaimTemplates"]; exists {
-			t.Error("volumeClaimTemplates should not be set when not present")
+		if _, exists := result.Values["persistence"]; exists {
+			t.Error("persistence should not be set without volumeClaimTemplates")
 		}
 	})
 
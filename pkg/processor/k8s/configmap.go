@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"fmt"
+	"path"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -33,7 +34,7 @@ func (p *ConfigMapProcessor) Process(ctx processor.Context, obj *unstructured.Un
 		return nil, fmt.Errorf("cannot process nil ConfigMap")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -70,8 +71,29 @@ func (p *ConfigMapProcessor) extractValues(ctx processor.Context, obj *unstructu
 	values["enabled"] = true
 
 	// Process data with value processor if available
+	externalize, _ := ctx.Options[processor.OptionExternalizeConfigMaps].(bool)
 	if data, found, _ := unstructured.NestedStringMap(obj.Object, "data"); found && len(data) > 0 {
-		if ctx.ValueProcessor != nil && ctx.ExternalFileManager != nil {
+		if externalize && ctx.ValueProcessor != nil && ctx.ExternalFileManager != nil {
+			processedData := make(map[string]interface{}, len(data))
+			sourceResource := fmt.Sprintf("ConfigMap/%s/%s", obj.GetNamespace(), configMapName)
+			for key, val := range data {
+				pv := ctx.ValueProcessor.Process(key, val)
+				file, err := externalizeConfigMapKey(ctx.ExternalFileManager, serviceName, sourceResource, key, pv)
+				if err != nil {
+					// Keep inline if the path is taken by another file
+					processedData[key] = val
+					continue
+				}
+				externalFiles = append(externalFiles, file)
+				processedData[key] = map[string]interface{}{
+					"_externalFile": file.Path,
+					"_checksum":     file.Checksum,
+					"_type":         string(file.DataType),
+					"_tpl":          false,
+				}
+			}
+			values["data"] = processedData
+		} else if ctx.ValueProcessor != nil && ctx.ExternalFileManager != nil {
 			processedData := make(map[string]interface{})
 
 			for key, val := range data {
@@ -123,6 +145,24 @@ func (p *ConfigMapProcessor) extractValues(ctx processor.Context, obj *unstructu
 	return values, externalFiles
 }
 
+// externalizeConfigMapKey adds a ConfigMap data key to files as
+// files/<service>/<key>, with its original content rather than the
+// formatted one.
+func externalizeConfigMapKey(files *value.ExternalFileManager, serviceName, sourceResource, key string, pv *value.ProcessedValue) (*value.ExternalFile, error) {
+	file := &value.ExternalFile{
+		Path:           path.Join("files", serviceName, key),
+		Content:        pv.Original,
+		SourceKey:      key,
+		SourceResource: sourceResource,
+		DataType:       pv.DetectedType,
+		Checksum:       pv.Checksum,
+	}
+	if err := files.Add(file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
 func (p *ConfigMapProcessor) generateTemplate(ctx processor.Context, obj *unstructured.Unstructured, serviceName, configMapName string) string {
 	sanitizedName := sanitizeName(configMapName)
 	fullnameHelper := fmt.Sprintf("{{ include \"%s.fullname\" $ }}", ctx.ChartName)
@@ -150,7 +190,10 @@ immutable: {{ . }}
 data:
   {{- range $key, $value := . }}
   {{- if kindIs "map" $value }}
-  {{- if hasKey $value "_externalFile" }}
+  {{- if and (hasKey $value "_externalFile") $value._tpl }}
+  {{ $key }}: |
+    {{- tpl ($.Files.Get $value._externalFile) $ | nindent 4 }}
+  {{- else if hasKey $value "_externalFile" }}
   {{ $key }}: |
     {{- $.Files.Get $value._externalFile | nindent 4 }}
   {{- else }}
@@ -350,6 +350,51 @@ func TestProcessConfigMap_LargeValueExternalization(t *testing.T) {
 	// the value should still be present as string
 }
 
+func TestProcessConfigMap_ExternalizeOption(t *testing.T) {
+	proc := NewConfigMapProcessor()
+	ctx := newTestContextWithValueProcessor()
+	ctx.ServiceName = "web"
+	ctx.Options = map[string]interface{}{processor.OptionExternalizeConfigMaps: true}
+
+	nginxConf := "server {\n  listen 80;\n}\n"
+	obj := makeConfigMapObj("web-config", "default",
+		map[string]interface{}{"app": "web"}, nil,
+		map[string]interface{}{
+			"data": map[string]interface{}{
+				"nginx.conf":  nginxConf,
+				"config.json": `{"a":1}`,
+			},
+		})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+
+	data := result.Values["data"].(map[string]interface{})
+	ref, ok := data["nginx.conf"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected nginx.conf to be externalized, got %v", data["nginx.conf"])
+	}
+	testutil.AssertEqual(t, "files/web/nginx.conf", ref["_externalFile"], "external file path")
+	testutil.AssertEqual(t, false, ref["_tpl"], "tpl is off by default")
+	if len(result.ExternalFiles) != 2 {
+		t.Fatalf("Expected 2 external files, got %d", len(result.ExternalFiles))
+	}
+	for _, file := range result.ExternalFiles {
+		if file.Path == "files/web/config.json" && file.Content != `{"a":1}` {
+			t.Errorf("external file content should be unchanged, got %q", file.Content)
+		}
+	}
+
+	// The same key with other content in another ConfigMap of the service
+	// stays inline.
+	other := makeConfigMapObj("web-config-2", "default",
+		map[string]interface{}{"app": "web"}, nil,
+		map[string]interface{}{"data": map[string]interface{}{"nginx.conf": "events {}\n"}})
+	result, err = proc.Process(ctx, other)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "events {}\n", result.Values["data"].(map[string]interface{})["nginx.conf"], "conflicting key stays inline")
+}
+
 // ============================================================
 // Subtask 7: Edge cases
 // ============================================================
@@ -3,7 +3,6 @@ package k8s
 import (
 	"errors"
 	"fmt"
-	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -34,7 +33,7 @@ func (p *CronJobProcessor) Process(ctx processor.Context, obj *unstructured.Unst
 		return nil, errors.New("CronJob object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -131,12 +130,7 @@ func (p *CronJobProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 				container["name"] = name
 			}
 			if image, ok := cm["image"].(string); ok {
-				parts := strings.SplitN(image, ":", 2)
-				imageMap := map[string]interface{}{"repository": parts[0]}
-				if len(parts) > 1 {
-					imageMap["tag"] = parts[1]
-				}
-				container["image"] = imageMap
+				container["image"] = imageValues(image)
 			}
 			if resources, ok := cm["resources"].(map[string]interface{}); ok {
 				container["resources"] = resources
@@ -162,6 +156,41 @@ func (p *CronJobProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 		values["restartPolicy"] = policy
 	}
 
+	// Extract serviceAccountName
+	if sa, found, _ := unstructured.NestedString(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "serviceAccountName"); found && sa != "" {
+		values["serviceAccountName"] = sa
+		deps = append(deps, types.ResourceKey{
+			GVK:       schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"},
+			Namespace: obj.GetNamespace(),
+			Name:      sa,
+		})
+	}
+
+	// Token automounting; set to false by --no-token-automount
+	if automount, found, _ := unstructured.NestedBool(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "automountServiceAccountToken"); found {
+		values["automountServiceAccountToken"] = automount
+	}
+
+	// Extract priorityClassName (PriorityClasses are cluster-scoped)
+	if pc, found, _ := unstructured.NestedString(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "priorityClassName"); found && pc != "" {
+		values["priorityClassName"] = pc
+		deps = append(deps, types.ResourceKey{
+			GVK:  schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+			Name: pc,
+		})
+	}
+
+	// Extract scheduling constraints (kubernetes.io/os selectors included)
+	if nodeSelector, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "nodeSelector"); found {
+		values["nodeSelector"] = nodeSelector
+	}
+	if affinity, found, _ := unstructured.NestedMap(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "affinity"); found {
+		values["affinity"] = affinity
+	}
+	if tolerations, _, _ := unstructured.NestedSlice(obj.Object, "spec", "jobTemplate", "spec", "template", "spec", "tolerations"); len(tolerations) > 0 {
+		values["tolerations"] = tolerations
+	}
+
 	return values, deps
 }
 
@@ -218,11 +247,20 @@ spec:
       template:
         spec:
           restartPolicy: {{ .restartPolicy | default "OnFailure" }}
+          {{- with .serviceAccountName }}
+          serviceAccountName: {{ . }}
+          {{- end }}
+          {{- if hasKey . "automountServiceAccountToken" }}
+          automountServiceAccountToken: {{ .automountServiceAccountToken }}
+          {{- end }}
+          {{- with .priorityClassName }}
+          priorityClassName: {{ . }}
+          {{- end }}
           {{- with .containers }}
           containers:
             {{- range . }}
             - name: {{ .name }}
-              image: "{{ .image.repository }}:{{ .image.tag | default "latest" }}"
+              image: "{{ .image.repository }}{{ if .image.digest }}@{{ .image.digest }}{{ else }}:{{ .image.tag | default "latest" }}{{ end }}"
               {{- with .command }}
               command:
                 {{- toYaml . | nindent 16 }}
@@ -237,6 +275,18 @@ spec:
               {{- end }}
             {{- end }}
           {{- end }}
+          {{- with .nodeSelector }}
+          nodeSelector:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .affinity }}
+          affinity:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+          {{- with .tolerations }}
+          tolerations:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
 {{- end }}
 {{- end }}
 `, serviceName, fullnameHelper, serviceName, ctx.ChartName, serviceName)
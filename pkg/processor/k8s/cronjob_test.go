@@ -272,6 +272,24 @@ func TestProcessCronJob_ExtractsPodTemplate(t *testing.T) {
 // Edge cases and metadata
 // ============================================================
 
+func TestProcessCronJob_ExtractsNodeOSScheduling(t *testing.T) {
+	p := NewCronJobProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeBasicCronJobSpec("0 3 * * *", "cleanup", "busybox:1.36")
+	podSpec := spec["jobTemplate"].(map[string]interface{})["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	podSpec["nodeSelector"] = map[string]interface{}{"kubernetes.io/os": "linux"}
+
+	result, err := p.Process(ctx, makeCronJobObj("cleanup", "default", nil, spec))
+	testutil.AssertNoError(t, err)
+
+	nodeSelector, ok := result.Values["nodeSelector"].(map[string]string)
+	if !ok || nodeSelector["kubernetes.io/os"] != "linux" {
+		t.Errorf("Expected the OS nodeSelector in values, got %v", result.Values["nodeSelector"])
+	}
+	testutil.AssertContains(t, result.TemplateContent, "          nodeSelector:")
+}
+
 func TestProcessCronJob_EdgeCases(t *testing.T) {
 	p := NewCronJobProcessor()
 	ctx := newTestProcessorContext()
@@ -34,7 +34,7 @@ func (p *DeploymentProcessor) Process(ctx processor.Context, obj *unstructured.U
 		return nil, errors.New("deployment object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -73,7 +73,7 @@ func (p *DeploymentProcessor) extractValues(obj *unstructured.Unstructured) (map
 	}
 
 	// Replicas (default to 1 when not specified)
-	if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+	if replicas, found := nestedInt64(obj.Object, "spec", "replicas"); found {
 		values["replicas"] = replicas
 	} else {
 		values["replicas"] = int64(1)
@@ -109,11 +109,7 @@ func (p *DeploymentProcessor) extractValues(obj *unstructured.Unstructured) (map
 
 			// Image
 			if image, ok := container["image"].(string); ok {
-				repo, tag := parseImage(image)
-				cv["image"] = map[string]interface{}{
-					"repository": repo,
-					"tag":        tag,
-				}
+				cv["image"] = imageValues(image)
 			}
 
 			// Resources
@@ -185,6 +181,20 @@ func (p *DeploymentProcessor) extractValues(obj *unstructured.Unstructured) (map
 		})
 	}
 
+	// Token automounting; set to false by --no-token-automount
+	if automount, found, _ := unstructured.NestedBool(obj.Object, "spec", "template", "spec", "automountServiceAccountToken"); found {
+		values["automountServiceAccountToken"] = automount
+	}
+
+	// PriorityClass (cluster-scoped)
+	if pc, found, _ := unstructured.NestedString(obj.Object, "spec", "template", "spec", "priorityClassName"); found && pc != "" {
+		values["priorityClassName"] = pc
+		deps = append(deps, types.ResourceKey{
+			GVK:  schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+			Name: pc,
+		})
+	}
+
 	// ImagePullSecrets
 	if secrets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "imagePullSecrets"); len(secrets) > 0 {
 		values["imagePullSecrets"] = secrets
@@ -260,13 +270,10 @@ metadata:
     {{- include "%s.labels" $ | nindent 4 }}
     app.kubernetes.io/component: %s
 spec:
-  {{- if not .autoscaling }}
+  {{- if not (and $svc.autoscaling $svc.autoscaling.enabled) }}
   replicas: {{ .replicas | default 1 }}
   {{- end }}
-  selector:
-    matchLabels:
-      {{- include "%s.selectorLabels" $ | nindent 6 }}
-      app.kubernetes.io/component: %s
+%s
   {{- with .strategy }}
   strategy:
     {{- toYaml . | nindent 4 }}
@@ -277,12 +284,7 @@ spec:
       annotations:
         {{- toYaml . | nindent 8 }}
       {{- end }}
-      labels:
-        {{- include "%s.labels" $ | nindent 8 }}
-        app.kubernetes.io/component: %s
-        {{- with .podLabels }}
-        {{- toYaml . | nindent 8 }}
-        {{- end }}
+%s
     spec:
       {{- with $.Values.global.imagePullSecrets }}
       imagePullSecrets:
@@ -295,6 +297,12 @@ spec:
       {{- with .serviceAccountName }}
       serviceAccountName: {{ . }}
       {{- end }}
+      {{- if hasKey . "automountServiceAccountToken" }}
+      automountServiceAccountToken: {{ .automountServiceAccountToken }}
+      {{- end }}
+      {{- with .priorityClassName }}
+      priorityClassName: {{ . }}
+      {{- end }}
       {{- with .podSecurityContext }}
       securityContext:
         {{- toYaml . | nindent 8 }}
@@ -302,7 +310,7 @@ spec:
       containers:
         {{- range .containers }}
         - name: {{ .name }}
-          image: "{{ .image.repository }}:{{ .image.tag }}"
+          image: "{{ .image.repository }}{{ if .image.digest }}@{{ .image.digest }}{{ else }}:{{ .image.tag }}{{ end }}"
           imagePullPolicy: {{ .image.pullPolicy | default "IfNotPresent" }}
           {{- with .ports }}
           ports:
@@ -357,12 +365,16 @@ spec:
       tolerations:
         {{- toYaml . | nindent 8 }}
       {{- end }}
+      {{- with .topologySpreadConstraints }}
+      topologySpreadConstraints:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
 {{- end }}
 {{- end }}
 `, serviceName, fullnameHelper, serviceName,
 		ctx.ChartName, serviceName,
-		ctx.ChartName, serviceName,
-		ctx.ChartName, serviceName)
+		workloadSelector(ctx, serviceName),
+		workloadPodLabels(ctx, serviceName, deploymentPodLabels))
 
 	// Remove unused variable warning
 	_ = valuesPath
@@ -372,28 +384,52 @@ spec:
 
 // Helper functions
 
-func parseImage(image string) (repository, tag string) {
-	// Handle digest format
-	if strings.Contains(image, "@") {
-		parts := strings.SplitN(image, "@", 2)
-		return parts[0], parts[1]
+func parseImage(image string) (repository, tag, digest string) {
+	// Handle digest format: repo@digest or repo:tag@digest
+	if at := strings.Index(image, "@"); at != -1 {
+		digest = image[at+1:]
+		image = image[:at]
+		repository, tag = splitImageTag(image)
+		return repository, tag, digest
+	}
+	repository, tag = splitImageTag(image)
+	if tag == "" {
+		tag = "latest"
 	}
+	return repository, tag, ""
+}
 
-	// Handle tag format
+// splitImageTag splits an image reference without digest into repository
+// and tag; tag is empty when the reference has none.
+func splitImageTag(image string) (repository, tag string) {
 	lastColon := strings.LastIndex(image, ":")
 	if lastColon == -1 {
-		return image, "latest"
+		return image, ""
 	}
 
 	// Check if colon is part of port (e.g., registry:5000/image)
 	afterColon := image[lastColon+1:]
 	if strings.Contains(afterColon, "/") {
-		return image, "latest"
+		return image, ""
 	}
 
 	return image[:lastColon], afterColon
 }
 
+// imageValues returns the values of a container image: repository and tag,
+// plus digest for pinned images. Templates prefer the digest when it is set.
+func imageValues(image string) map[string]interface{} {
+	repo, tag, digest := parseImage(image)
+	values := map[string]interface{}{
+		"repository": repo,
+		"tag":        tag,
+	}
+	if digest != "" {
+		values["digest"] = digest
+	}
+	return values
+}
+
 func extractEnvDependencies(env []interface{}, namespace string) []types.ResourceKey {
 	var deps []types.ResourceKey
 	for _, e := range env {
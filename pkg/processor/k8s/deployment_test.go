@@ -1033,6 +1033,38 @@ func TestProcessDeployment_ExtractsNodeSelector(t *testing.T) {
 	testutil.AssertEqual(t, "us-east", ns["region"], "nodeSelector region")
 }
 
+func TestProcessDeployment_ExtractsPriorityClassName(t *testing.T) {
+	proc := NewDeploymentProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeBasicSpec(1, "web", "nginx:latest")
+	spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["priorityClassName"] = "critical"
+
+	result, err := proc.Process(ctx, makeDeploymentObj("web", "default", nil, spec))
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, "critical", result.Values["priorityClassName"], "priorityClassName")
+	if !hasDependency(result.Dependencies, "PriorityClass", "", "critical") {
+		t.Errorf("Expected a dependency on PriorityClass critical, got %v", result.Dependencies)
+	}
+	testutil.AssertContains(t, result.TemplateContent, "priorityClassName: {{ . }}")
+}
+
+func TestProcessDeployment_ExtractsAutomountServiceAccountToken(t *testing.T) {
+	proc := NewDeploymentProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeBasicSpec(1, "web", "nginx:latest")
+	spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["automountServiceAccountToken"] = false
+
+	result, err := proc.Process(ctx, makeDeploymentObj("web", "default", nil, spec))
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, false, result.Values["automountServiceAccountToken"], "automountServiceAccountToken")
+	// with would drop false; hasKey keeps it.
+	testutil.AssertContains(t, result.TemplateContent, `{{- if hasKey . "automountServiceAccountToken" }}`)
+}
+
 // ============================================================
 // Dependency detection tests
 // ============================================================
@@ -1403,22 +1435,26 @@ func TestParseImage(t *testing.T) {
 		image      string
 		wantRepo   string
 		wantTag    string
+		wantDigest string
 	}{
-		{"WithTag", "nginx:1.21", "nginx", "1.21"},
-		{"NoTag", "nginx", "nginx", "latest"},
-		{"LatestTag", "nginx:latest", "nginx", "latest"},
-		{"WithRegistry", "gcr.io/my-project/app:v2", "gcr.io/my-project/app", "v2"},
-		{"Digest", "nginx@sha256:abc123", "nginx", "sha256:abc123"},
-		{"RegistryPort", "registry:5000/myapp", "registry:5000/myapp", "latest"},
-		{"RegistryPortWithTag", "registry:5000/myapp:v1", "registry:5000/myapp", "v1"},
-		{"PrivateRegistry", "my.registry.io/org/image:1.0", "my.registry.io/org/image", "1.0"},
+		{"WithTag", "nginx:1.21", "nginx", "1.21", ""},
+		{"NoTag", "nginx", "nginx", "latest", ""},
+		{"LatestTag", "nginx:latest", "nginx", "latest", ""},
+		{"WithRegistry", "gcr.io/my-project/app:v2", "gcr.io/my-project/app", "v2", ""},
+		{"Digest", "nginx@sha256:abc123", "nginx", "", "sha256:abc123"},
+		{"TagAndDigest", "nginx:1.25@sha256:abc123", "nginx", "1.25", "sha256:abc123"},
+		{"RegistryPort", "registry:5000/myapp", "registry:5000/myapp", "latest", ""},
+		{"RegistryPortWithTag", "registry:5000/myapp:v1", "registry:5000/myapp", "v1", ""},
+		{"RegistryPortWithDigest", "registry:5000/myapp@sha256:abc123", "registry:5000/myapp", "", "sha256:abc123"},
+		{"PrivateRegistry", "my.registry.io/org/image:1.0", "my.registry.io/org/image", "1.0", ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo, tag := parseImage(tt.image)
+			repo, tag, digest := parseImage(tt.image)
 			testutil.AssertEqual(t, tt.wantRepo, repo, "repository for %q", tt.image)
 			testutil.AssertEqual(t, tt.wantTag, tag, "tag for %q", tt.image)
+			testutil.AssertEqual(t, tt.wantDigest, digest, "digest for %q", tt.image)
 		})
 	}
 }
@@ -0,0 +1,132 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DestinationRuleProcessor processes Istio DestinationRule resources.
+type DestinationRuleProcessor struct {
+	processor.BaseProcessor
+}
+
+// NewDestinationRuleProcessor creates a new DestinationRule processor.
+func NewDestinationRuleProcessor() *DestinationRuleProcessor {
+	return &DestinationRuleProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"destinationrule",
+			70,
+			istioGVKs("DestinationRule")...,
+		),
+	}
+}
+
+// Process processes a DestinationRule resource.
+func (p *DestinationRuleProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, errors.New("DestinationRule object is nil")
+	}
+
+	serviceName := processor.ResolveServiceName(ctx, obj)
+	if serviceName == "" {
+		serviceName = obj.GetName()
+	}
+
+	name := obj.GetName()
+	namespace := obj.GetNamespace()
+
+	values, deps := p.extractValues(obj)
+	template := p.generateTemplate(ctx, serviceName, obj.GetAPIVersion())
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/%s-destinationrule.yaml", serviceName),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.destinationRule", serviceName),
+		Values:          values,
+		Dependencies:    deps,
+		Metadata: map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+	}, nil
+}
+
+func (p *DestinationRuleProcessor) extractValues(obj *unstructured.Unstructured) (map[string]interface{}, []types.ResourceKey) {
+	values := make(map[string]interface{})
+	var deps []types.ResourceKey
+
+	// Preserve full spec for pipeline integration
+	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec"); ok {
+		values["spec"] = spec
+	}
+
+	// Extract host; DestinationRule depends on the Service it configures
+	if host, ok, _ := unstructured.NestedString(obj.Object, "spec", "host"); ok && host != "" {
+		values["host"] = host
+
+		if svcName, svcNamespace, ok := istioHostToService(host, obj.GetNamespace()); ok {
+			deps = append(deps, types.ResourceKey{
+				GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+				Namespace: svcNamespace,
+				Name:      svcName,
+			})
+		}
+	}
+
+	// Extract trafficPolicy (load balancing, connection pool, outlier detection, TLS)
+	if trafficPolicy, ok, _ := unstructured.NestedMap(obj.Object, "spec", "trafficPolicy"); ok {
+		values["trafficPolicy"] = trafficPolicy
+	}
+
+	// Extract subsets
+	if subsets, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "subsets"); ok && len(subsets) > 0 {
+		values["subsets"] = subsets
+	}
+
+	// Extract exportTo
+	if exportTo, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "exportTo"); ok && len(exportTo) > 0 {
+		values["exportTo"] = exportTo
+	}
+
+	return values, deps
+}
+
+func (p *DestinationRuleProcessor) generateTemplate(ctx processor.Context, serviceName, apiVersion string) string {
+	sanitized := processor.SanitizeServiceName(serviceName)
+
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.destinationRule }}
+apiVersion: %s
+kind: DestinationRule
+metadata:
+  name: %s
+  namespace: {{ $.Release.Namespace }}
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+spec:
+  host: {{ .host }}
+  {{- with .trafficPolicy }}
+  trafficPolicy:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .subsets }}
+  subsets:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .exportTo }}
+  exportTo:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`, sanitized, apiVersion, serviceName, ctx.ChartName)
+}
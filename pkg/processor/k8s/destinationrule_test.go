@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+)
+
+func TestDestinationRuleProcessor_Name(t *testing.T) {
+	testutil.AssertEqual(t, "destinationrule", NewDestinationRuleProcessor().Name(), "processor name")
+}
+
+func TestDestinationRuleProcessor_Process(t *testing.T) {
+	proc := NewDestinationRuleProcessor()
+	ctx := newTestProcessorContext()
+
+	obj := makeIstioObj("networking.istio.io/v1", "DestinationRule", "reviews", "default", map[string]interface{}{
+		"host": "reviews",
+		"trafficPolicy": map[string]interface{}{
+			"loadBalancer": map[string]interface{}{"simple": "LEAST_REQUEST"},
+		},
+		"subsets": []interface{}{
+			map[string]interface{}{"name": "v1", "labels": map[string]interface{}{"version": "v1"}},
+		},
+	})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "services.reviews.destinationRule", result.ValuesPath)
+	testutil.AssertEqual(t, "reviews", result.Values["host"])
+
+	if _, ok := result.Values["trafficPolicy"]; !ok {
+		t.Error("expected trafficPolicy in values")
+	}
+	if _, ok := result.Values["subsets"]; !ok {
+		t.Error("expected subsets in values")
+	}
+
+	if len(result.Dependencies) != 1 || result.Dependencies[0].GVK.Kind != "Service" || result.Dependencies[0].Name != "reviews" {
+		t.Errorf("expected a dependency on Service reviews, got %v", result.Dependencies)
+	}
+
+	if !strings.Contains(result.TemplateContent, "host: {{ .host }}") {
+		t.Error("template should parameterize the host")
+	}
+}
+
+func TestDestinationRuleProcessor_ExternalHost(t *testing.T) {
+	obj := makeIstioObj("networking.istio.io/v1", "DestinationRule", "external", "default", map[string]interface{}{
+		"host": "api.example.com",
+	})
+
+	result, err := NewDestinationRuleProcessor().Process(newTestProcessorContext(), obj)
+	testutil.AssertNoError(t, err)
+	if len(result.Dependencies) != 0 {
+		t.Errorf("expected no dependencies for an external host, got %v", result.Dependencies)
+	}
+}
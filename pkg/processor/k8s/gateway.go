@@ -32,7 +32,7 @@ func (p *GatewayProcessor) Process(ctx processor.Context, obj *unstructured.Unst
 		return nil, errors.New("Gateway object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
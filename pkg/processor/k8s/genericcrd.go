@@ -6,6 +6,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
 
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 )
@@ -39,7 +40,7 @@ func (p *GenericCRDProcessor) Process(ctx processor.Context, obj *unstructured.U
 	}
 
 	gvk := obj.GroupVersionKind()
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -226,7 +227,9 @@ func parseOpenAPISchemaToValues(schema map[string]interface{}, prefix string) ma
 }
 
 // GenerateCRDInstallFiles generates files for a crds/ directory in the chart.
-// CRD resources are installed before templates by Helm.
+// CRD resources are installed before templates by Helm. Each file holds the
+// CRD as-is: Helm does not render crds/, and only the name, labels,
+// annotations and spec of the CRD are kept.
 func GenerateCRDInstallFiles(resources []*unstructured.Unstructured) map[string]string {
 	files := make(map[string]string)
 
@@ -240,63 +243,34 @@ func GenerateCRDInstallFiles(resources []*unstructured.Unstructured) map[string]
 			continue
 		}
 
-		// Build the CRD YAML content
-		var b strings.Builder
-		b.WriteString("# WARNING: CRD resources in crds/ are installed by Helm before templates,\n")
-		b.WriteString("# but Helm does NOT manage CRD updates or deletions.\n")
-		b.WriteString("# To update a CRD, apply the new version manually with kubectl.\n")
-		b.WriteString(fmt.Sprintf("apiVersion: %s\n", obj.GetAPIVersion()))
-		b.WriteString(fmt.Sprintf("kind: %s\n", obj.GetKind()))
-		b.WriteString("metadata:\n")
-		b.WriteString(fmt.Sprintf("  name: %s\n", name))
-
+		metadata := map[string]interface{}{"name": name}
 		if labels := obj.GetLabels(); len(labels) > 0 {
-			b.WriteString("  labels:\n")
-			for k, v := range labels {
-				b.WriteString(fmt.Sprintf("    %s: %s\n", k, v))
-			}
+			metadata["labels"] = labels
 		}
-
 		if annotations := obj.GetAnnotations(); len(annotations) > 0 {
-			b.WriteString("  annotations:\n")
-			for k, v := range annotations {
-				b.WriteString(fmt.Sprintf("    %s: \"%s\"\n", k, v))
-			}
+			metadata["annotations"] = annotations
+		}
+		manifest := map[string]interface{}{
+			"apiVersion": obj.GetAPIVersion(),
+			"kind":       obj.GetKind(),
+			"metadata":   metadata,
 		}
-
-		// Include spec as-is
 		if spec, found, _ := unstructured.NestedMap(obj.Object, "spec"); found {
-			b.WriteString("spec:\n")
-			// We output spec via a simple recursive serializer
-			writeNestedYAML(&b, spec, 2)
+			manifest["spec"] = spec
+		}
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			continue
 		}
 
+		var b strings.Builder
+		b.WriteString("# WARNING: CRD resources in crds/ are installed by Helm before templates,\n")
+		b.WriteString("# but Helm does NOT manage CRD updates or deletions.\n")
+		b.WriteString("# To update a CRD, apply the new version manually with kubectl.\n")
+		b.Write(data)
+
 		files[fmt.Sprintf("crds/%s.yaml", name)] = b.String()
 	}
 
 	return files
 }
-
-// writeNestedYAML writes a nested map as YAML with indentation.
-func writeNestedYAML(b *strings.Builder, m map[string]interface{}, indent int) {
-	prefix := strings.Repeat(" ", indent)
-	for k, v := range m {
-		switch val := v.(type) {
-		case map[string]interface{}:
-			b.WriteString(fmt.Sprintf("%s%s:\n", prefix, k))
-			writeNestedYAML(b, val, indent+2)
-		case []interface{}:
-			b.WriteString(fmt.Sprintf("%s%s:\n", prefix, k))
-			for _, item := range val {
-				if itemMap, ok := item.(map[string]interface{}); ok {
-					b.WriteString(fmt.Sprintf("%s- \n", prefix))
-					writeNestedYAML(b, itemMap, indent+4)
-				} else {
-					b.WriteString(fmt.Sprintf("%s- %v\n", prefix, item))
-				}
-			}
-		default:
-			b.WriteString(fmt.Sprintf("%s%s: %v\n", prefix, k, v))
-		}
-	}
-}
@@ -107,7 +107,7 @@ func (p *GPUProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 
 	return &processor.Result{
 		Processed:   true,
-		ServiceName: processor.SanitizeServiceName(processor.ServiceNameFromResource(obj)),
+		ServiceName: processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj)),
 		Values:      values,
 	}, nil
 }
@@ -40,7 +40,7 @@ func (p *GrafanaDashboardProcessor) Process(ctx processor.Context, obj *unstruct
 		return &processor.Result{Processed: false}, nil
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -33,7 +33,7 @@ func (p *GRPCRouteProcessor) Process(ctx processor.Context, obj *unstructured.Un
 		return nil, errors.New("GRPCRoute object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
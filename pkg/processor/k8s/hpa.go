@@ -33,7 +33,7 @@ func (p *HPAProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 		return nil, errors.New("HPA object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -41,16 +41,24 @@ func (p *HPAProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 	name := obj.GetName()
 	namespace := obj.GetNamespace()
 
-	values, deps := p.extractValues(obj)
+	// HPAs from the input keep their values under hpa; generated ones use
+	// autoscaling, gated by autoscaling.enabled.
+	generated := processor.IsGeneratedAutoscaler(obj)
+	valuesKey := "hpa"
+	if generated {
+		valuesKey = "autoscaling"
+	}
+
+	values, deps := p.extractValues(obj, generated)
 
-	template := p.generateTemplate(ctx, obj, serviceName)
+	template := p.generateTemplate(ctx, obj, serviceName, valuesKey, generated)
 
 	return &processor.Result{
 		Processed:       true,
 		ServiceName:     serviceName,
 		TemplatePath:    fmt.Sprintf("templates/%s-hpa.yaml", serviceName),
 		TemplateContent: template,
-		ValuesPath:      fmt.Sprintf("services.%s.hpa", serviceName),
+		ValuesPath:      fmt.Sprintf("services.%s.%s", serviceName, valuesKey),
 		Values:          values,
 		Dependencies:    deps,
 		Metadata: map[string]interface{}{
@@ -60,8 +68,13 @@ func (p *HPAProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 	}, nil
 }
 
-func (p *HPAProcessor) extractValues(obj *unstructured.Unstructured) (map[string]interface{}, []types.ResourceKey) {
+func (p *HPAProcessor) extractValues(obj *unstructured.Unstructured, generated bool) (map[string]interface{}, []types.ResourceKey) {
 	values := make(map[string]interface{})
+	if generated {
+		// autoscaling.enabled also drops replicas from the Deployment
+		// template, so the HPA owns the replica count.
+		values["enabled"] = true
+	}
 	var deps []types.ResourceKey
 
 	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
@@ -127,12 +140,16 @@ func splitAPIVersion(apiVersion string) [2]string {
 	return [2]string{"", apiVersion}
 }
 
-func (p *HPAProcessor) generateTemplate(ctx processor.Context, obj *unstructured.Unstructured, serviceName string) string {
+func (p *HPAProcessor) generateTemplate(ctx processor.Context, obj *unstructured.Unstructured, serviceName, valuesKey string, generated bool) string {
 	fullnameHelper := fmt.Sprintf(`{{ include "%s.fullname" $ }}`, ctx.ChartName)
+	gate, gateEnd := "", ""
+	if generated {
+		gate, gateEnd = "\n{{- if .enabled }}", "\n{{- end }}"
+	}
 
 	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
 {{- if $svc.enabled }}
-{{- with $svc.hpa }}
+{{- with $svc.%s }}%s
 apiVersion: autoscaling/v2
 kind: HorizontalPodAutoscaler
 metadata:
@@ -155,8 +172,8 @@ spec:
   {{- with .behavior }}
   behavior:
     {{- toYaml . | nindent 4 }}
-  {{- end }}
+  {{- end }}%s
 {{- end }}
 {{- end }}
-`, serviceName, fullnameHelper, serviceName, ctx.ChartName, serviceName, fullnameHelper)
+`, serviceName, valuesKey, gate, fullnameHelper, serviceName, ctx.ChartName, serviceName, fullnameHelper, gateEnd)
 }
@@ -33,7 +33,7 @@ func (p *HTTPRouteProcessor) Process(ctx processor.Context, obj *unstructured.Un
 		return nil, errors.New("HTTPRoute object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -32,7 +32,7 @@ func (p *IngressProcessor) Process(ctx processor.Context, obj *unstructured.Unst
 		return nil, fmt.Errorf("ingress object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -81,22 +81,33 @@ func (p *IngressProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 
 	// Annotations (important for ingress controllers)
 	if annotations := obj.GetAnnotations(); len(annotations) > 0 {
-		values["annotations"] = annotations
-
-		// Check for cert-manager annotations
+		// cert-manager issuer annotations are lifted into certManager so the
+		// issuer name can be set per environment; the template renders them back.
+		certManager := make(map[string]interface{})
 		if issuer, ok := annotations["cert-manager.io/cluster-issuer"]; ok {
+			certManager["clusterIssuer"] = issuer
+			delete(annotations, "cert-manager.io/cluster-issuer")
 			deps = append(deps, types.ResourceKey{
 				GVK:  schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"},
 				Name: issuer,
 			})
 		}
 		if issuer, ok := annotations["cert-manager.io/issuer"]; ok {
+			certManager["issuer"] = issuer
+			delete(annotations, "cert-manager.io/issuer")
 			deps = append(deps, types.ResourceKey{
 				GVK:       schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"},
 				Namespace: obj.GetNamespace(),
 				Name:      issuer,
 			})
 		}
+
+		if len(certManager) > 0 {
+			values["certManager"] = certManager
+		}
+		if len(annotations) > 0 {
+			values["annotations"] = annotations
+		}
 	}
 
 	// TLS
@@ -136,28 +147,7 @@ func (p *IngressProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 
 	// Default backend
 	if defaultBackend, found, _ := unstructured.NestedMap(obj.Object, "spec", "defaultBackend"); found {
-		dbValues := make(map[string]interface{})
-		if service, ok := defaultBackend["service"].(map[string]interface{}); ok {
-			svcBackend := make(map[string]interface{})
-			if svcName, ok := service["name"].(string); ok {
-				svcBackend["name"] = svcName
-				deps = append(deps, types.ResourceKey{
-					GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
-					Namespace: obj.GetNamespace(),
-					Name:      svcName,
-				})
-			}
-			if port, ok := service["port"].(map[string]interface{}); ok {
-				if number, ok := port["number"].(int64); ok {
-					svcBackend["port"] = number
-				}
-				if name, ok := port["name"].(string); ok {
-					svcBackend["portName"] = name
-				}
-			}
-			dbValues["service"] = svcBackend
-		}
-		values["defaultBackend"] = dbValues
+		values["defaultBackend"] = extractIngressBackend(defaultBackend, obj.GetNamespace(), &deps)
 	}
 
 	// Rules
@@ -196,26 +186,8 @@ func (p *IngressProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 
 						// Backend
 						if backend, ok := pathEntry["backend"].(map[string]interface{}); ok {
-							if service, ok := backend["service"].(map[string]interface{}); ok {
-								svcBackend := make(map[string]interface{})
-								if svcName, ok := service["name"].(string); ok {
-									svcBackend["name"] = svcName
-									// Add dependency on service
-									deps = append(deps, types.ResourceKey{
-										GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
-										Namespace: obj.GetNamespace(),
-										Name:      svcName,
-									})
-								}
-								if port, ok := service["port"].(map[string]interface{}); ok {
-									if number, ok := port["number"].(int64); ok {
-										svcBackend["port"] = number
-									}
-									if name, ok := port["name"].(string); ok {
-										svcBackend["portName"] = name
-									}
-								}
-								pe["service"] = svcBackend
+							for k, v := range extractIngressBackend(backend, obj.GetNamespace(), &deps) {
+								pe[k] = v
 							}
 						}
 
@@ -227,13 +199,43 @@ func (p *IngressProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 
 			hosts = append(hosts, hostEntry)
 		}
-		values["rules"] = hosts
-		values["hosts"] = hosts // backward-compatible alias used by template
+		values["hosts"] = hosts
 	}
 
 	return values, deps
 }
 
+// extractIngressBackend returns the values of an Ingress backend: service
+// (name with port or portName) or resource, adding the backend Service to
+// deps.
+func extractIngressBackend(backend map[string]interface{}, namespace string, deps *[]types.ResourceKey) map[string]interface{} {
+	values := make(map[string]interface{})
+	if service, ok := backend["service"].(map[string]interface{}); ok {
+		svcBackend := make(map[string]interface{})
+		if svcName, ok := service["name"].(string); ok {
+			svcBackend["name"] = svcName
+			*deps = append(*deps, types.ResourceKey{
+				GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+				Namespace: namespace,
+				Name:      svcName,
+			})
+		}
+		if port, ok := service["port"].(map[string]interface{}); ok {
+			if number, ok := toInt64(port["number"]); ok {
+				svcBackend["port"] = number
+			}
+			if name, ok := port["name"].(string); ok {
+				svcBackend["portName"] = name
+			}
+		}
+		values["service"] = svcBackend
+	}
+	if resource, ok := backend["resource"].(map[string]interface{}); ok {
+		values["resource"] = resource
+	}
+	return values
+}
+
 func (p *IngressProcessor) generateTemplate(ctx processor.Context, obj *unstructured.Unstructured, serviceName string) string {
 	fullnameHelper := fmt.Sprintf("{{ include \"%s.fullname\" $ }}", ctx.ChartName)
 
@@ -249,43 +251,82 @@ metadata:
   labels:
     {{- include "%s.labels" $ | nindent 4 }}
     app.kubernetes.io/component: %s
-  {{- with .annotations }}
+  {{- if or .annotations .certManager }}
   annotations:
+    {{- with .annotations }}
     {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with .certManager }}
+    {{- with .clusterIssuer }}
+    cert-manager.io/cluster-issuer: {{ . | quote }}
+    {{- end }}
+    {{- with .issuer }}
+    cert-manager.io/issuer: {{ . | quote }}
+    {{- end }}
+    {{- end }}
   {{- end }}
 spec:
   {{- with .className }}
   ingressClassName: {{ . }}
   {{- end }}
-  {{- if .tls }}
+  {{- with .defaultBackend }}
+  defaultBackend:
+    {{- with .service }}
+    service:
+      name: {{ .name }}
+      port:
+        {{- if .portName }}
+        name: {{ .portName }}
+        {{- else }}
+        number: {{ .port }}
+        {{- end }}
+    {{- end }}
+    {{- with .resource }}
+    resource:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+  {{- end }}
+  {{- with .tls }}
   tls:
-    {{- range .tls }}
+    {{- range . }}
     - hosts:
         {{- range .hosts }}
         - {{ . | quote }}
         {{- end }}
-      secretName: {{ .secretName }}
+      {{- with .secretName }}
+      secretName: {{ . }}
+      {{- end }}
     {{- end }}
   {{- end }}
+  {{- with .hosts | default .rules }}
   rules:
-    {{- range .rules }}
-    - host: {{ .host | quote }}
-      http:
+    {{- range . }}
+    - http:
         paths:
           {{- range .paths }}
-          - path: {{ .path }}
+          - path: {{ .path | default "/" }}
             pathType: {{ .pathType | default "Prefix" }}
             backend:
+              {{- with .service }}
               service:
-                name: {{ .service.name }}
+                name: {{ .name }}
                 port:
-                  {{- if .service.portName }}
-                  name: {{ .service.portName }}
+                  {{- if .portName }}
+                  name: {{ .portName }}
                   {{- else }}
-                  number: {{ .service.port }}
+                  number: {{ .port }}
                   {{- end }}
+              {{- end }}
+              {{- with .resource }}
+              resource:
+                {{- toYaml . | nindent 16 }}
+              {{- end }}
           {{- end }}
+      {{- with .host }}
+      host: {{ . | quote }}
+      {{- end }}
     {{- end }}
+  {{- end }}
 {{- end }}
 {{- end }}
 {{- end }}
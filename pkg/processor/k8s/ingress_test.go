@@ -340,6 +340,17 @@ func TestProcessIngress_ExtractsAnnotations(t *testing.T) {
 		if !hasDependency(result.Dependencies, "ClusterIssuer", "", "letsencrypt-prod") {
 			t.Error("Expected ClusterIssuer dependency for cert-manager annotation")
 		}
+
+		certManager, ok := result.Values["certManager"].(map[string]interface{})
+		if !ok || certManager["clusterIssuer"] != "letsencrypt-prod" {
+			t.Errorf("Expected certManager.clusterIssuer in values, got %v", result.Values["certManager"])
+		}
+		if _, ok := result.Values["annotations"]; ok {
+			t.Errorf("Expected the issuer annotation to be lifted out of annotations, got %v", result.Values["annotations"])
+		}
+		if !strings.Contains(result.TemplateContent, "cert-manager.io/cluster-issuer: {{ . | quote }}") {
+			t.Error("Expected template to render the cluster-issuer annotation from values")
+		}
 	})
 
 	t.Run("CertManagerAnnotation_Issuer", func(t *testing.T) {
@@ -633,6 +644,39 @@ func TestProcessIngress_DefaultBackend(t *testing.T) {
 	}
 }
 
+func TestProcessIngress_ResourceBackend(t *testing.T) {
+	proc := NewIngressProcessor()
+	ctx := newTestProcessorContext()
+
+	resource := map[string]interface{}{"apiGroup": "k8s.example.com", "kind": "StorageBucket", "name": "static-assets"}
+	obj := makeIngressObj("my-ingress", "default",
+		map[string]interface{}{"app": "myapp"}, nil,
+		map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"http": map[string]interface{}{
+						"paths": []interface{}{
+							map[string]interface{}{"path": "/icons", "pathType": "ImplementationSpecific", "backend": map[string]interface{}{"resource": resource}},
+							map[string]interface{}{"path": "/", "backend": map[string]interface{}{"service": map[string]interface{}{
+								"name": "web", "port": map[string]interface{}{"number": float64(80)},
+							}}},
+						},
+					},
+				},
+			},
+		})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+
+	if _, ok := result.Values["rules"]; ok {
+		t.Error("rules should only be written as hosts")
+	}
+	paths := result.Values["hosts"].([]map[string]interface{})[0]["paths"].([]map[string]interface{})
+	testutil.AssertEqual(t, "StorageBucket", paths[0]["resource"].(map[string]interface{})["kind"], "resource backend")
+	testutil.AssertEqual(t, int64(80), paths[1]["service"].(map[string]interface{})["port"], "float port number")
+}
+
 // ============================================================
 // Result metadata and template tests
 // ============================================================
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+// IssuerProcessor processes namespaced cert-manager Issuer resources.
+type IssuerProcessor struct {
+	processor.BaseProcessor
+}
+
+// NewIssuerProcessor creates a new Issuer processor.
+func NewIssuerProcessor() *IssuerProcessor {
+	return &IssuerProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"issuer",
+			70,
+			schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Issuer"},
+		),
+	}
+}
+
+// Process processes an Issuer resource.
+func (p *IssuerProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, errors.New("Issuer object is nil")
+	}
+
+	serviceName := processor.ResolveServiceName(ctx, obj)
+	if serviceName == "" {
+		serviceName = obj.GetName()
+	}
+
+	name := obj.GetName()
+	namespace := obj.GetNamespace()
+
+	values := extractIssuerValues(obj)
+	template := p.generateTemplate(ctx, serviceName)
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/%s-issuer.yaml", serviceName),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.issuer", serviceName),
+		Values:          values,
+		Metadata: map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+	}, nil
+}
+
+func (p *IssuerProcessor) generateTemplate(ctx processor.Context, serviceName string) string {
+	sanitized := processor.SanitizeServiceName(serviceName)
+
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.issuer }}
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: %s
+  namespace: {{ $.Release.Namespace }}
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+spec:
+  {{- with .acme }}
+  acme:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .selfSigned }}
+  selfSigned:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .ca }}
+  ca:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`, sanitized, serviceName, ctx.ChartName)
+}
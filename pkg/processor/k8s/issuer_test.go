@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+)
+
+func TestIssuerProcessor_Process(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Issuer",
+			"metadata": map[string]interface{}{
+				"name":      "selfsigned",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"selfSigned": map[string]interface{}{},
+			},
+		},
+	}
+
+	proc := NewIssuerProcessor()
+	testutil.AssertEqual(t, "issuer", proc.Name(), "processor name")
+
+	result, err := proc.Process(newTestProcessorContext(), obj)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "services.selfsigned.issuer", result.ValuesPath)
+	testutil.AssertEqual(t, "default", result.Metadata["namespace"])
+
+	if _, ok := result.Values["selfSigned"]; !ok {
+		t.Error("expected selfSigned in values")
+	}
+	if !strings.Contains(result.TemplateContent, "kind: Issuer") || !strings.Contains(result.TemplateContent, "namespace: {{ $.Release.Namespace }}") {
+		t.Error("template should render a namespaced Issuer")
+	}
+}
+
+func TestIssuerProcessor_NilObject(t *testing.T) {
+	if _, err := NewIssuerProcessor().Process(newTestProcessorContext(), nil); err == nil {
+		t.Error("expected error for nil object")
+	}
+}
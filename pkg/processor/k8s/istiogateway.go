@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+// IstioGatewayProcessor processes Istio Gateway resources. Gateway API
+// Gateways are handled by GatewayProcessor.
+type IstioGatewayProcessor struct {
+	processor.BaseProcessor
+}
+
+// NewIstioGatewayProcessor creates a new Istio Gateway processor.
+func NewIstioGatewayProcessor() *IstioGatewayProcessor {
+	return &IstioGatewayProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"istiogateway",
+			70,
+			istioGVKs("Gateway")...,
+		),
+	}
+}
+
+// Process processes an Istio Gateway resource.
+func (p *IstioGatewayProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, errors.New("Istio Gateway object is nil")
+	}
+
+	serviceName := processor.ResolveServiceName(ctx, obj)
+	if serviceName == "" {
+		serviceName = obj.GetName()
+	}
+
+	name := obj.GetName()
+	namespace := obj.GetNamespace()
+
+	values := p.extractValues(obj)
+	template := p.generateTemplate(ctx, serviceName, obj.GetAPIVersion())
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/%s-istiogateway.yaml", serviceName),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.istioGateway", serviceName),
+		Values:          values,
+		Metadata: map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+	}, nil
+}
+
+func (p *IstioGatewayProcessor) extractValues(obj *unstructured.Unstructured) map[string]interface{} {
+	values := make(map[string]interface{})
+
+	// Preserve full spec for pipeline integration
+	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec"); ok {
+		values["spec"] = spec
+	}
+
+	// Extract selector (the ingress gateway pods serving this Gateway)
+	if selector, ok, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector"); ok && len(selector) > 0 {
+		values["selector"] = selector
+	}
+
+	// Extract servers (ports, hosts, TLS settings)
+	if servers, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "servers"); ok && len(servers) > 0 {
+		values["servers"] = servers
+	}
+
+	return values
+}
+
+func (p *IstioGatewayProcessor) generateTemplate(ctx processor.Context, serviceName, apiVersion string) string {
+	sanitized := processor.SanitizeServiceName(serviceName)
+
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.istioGateway }}
+apiVersion: %s
+kind: Gateway
+metadata:
+  name: %s
+  namespace: {{ $.Release.Namespace }}
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+spec:
+  {{- with .selector }}
+  selector:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .servers }}
+  servers:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`, sanitized, apiVersion, serviceName, ctx.ChartName)
+}
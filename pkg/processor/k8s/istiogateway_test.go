@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+)
+
+func TestIstioGatewayProcessor_Name(t *testing.T) {
+	testutil.AssertEqual(t, "istiogateway", NewIstioGatewayProcessor().Name(), "processor name")
+}
+
+func TestIstioGatewayProcessor_Process(t *testing.T) {
+	proc := NewIstioGatewayProcessor()
+	ctx := newTestProcessorContext()
+
+	obj := makeIstioObj("networking.istio.io/v1beta1", "Gateway", "public", "istio-system", map[string]interface{}{
+		"selector": map[string]interface{}{"istio": "ingressgateway"},
+		"servers": []interface{}{
+			map[string]interface{}{
+				"port":  map[string]interface{}{"number": int64(443), "name": "https", "protocol": "HTTPS"},
+				"hosts": []interface{}{"*.example.com"},
+				"tls":   map[string]interface{}{"mode": "SIMPLE", "credentialName": "example-tls"},
+			},
+		},
+	})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "templates/public-istiogateway.yaml", result.TemplatePath)
+	testutil.AssertEqual(t, "services.public.istioGateway", result.ValuesPath)
+	testutil.AssertEqual(t, map[string]string{"istio": "ingressgateway"}, result.Values["selector"])
+
+	if _, ok := result.Values["servers"]; !ok {
+		t.Error("expected servers in values")
+	}
+	if !strings.Contains(result.TemplateContent, "$svc.istioGateway") {
+		t.Error("template should read values from istioGateway, not the Gateway API gateway key")
+	}
+}
+
+func TestIstioGatewayProcessor_DoesNotClaimGatewayAPI(t *testing.T) {
+	for _, gvk := range NewIstioGatewayProcessor().Supports() {
+		if gvk.Group != "networking.istio.io" {
+			t.Errorf("unexpected GVK %v", gvk)
+		}
+	}
+}
@@ -104,7 +104,7 @@ func (p *IstioSidecarProcessor) Process(ctx processor.Context, obj *unstructured
 
 	return &processor.Result{
 		Processed:   true,
-		ServiceName: processor.SanitizeServiceName(processor.ServiceNameFromResource(obj)),
+		ServiceName: processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj)),
 		Values:      values,
 	}, nil
 }
@@ -35,7 +35,7 @@ func (p *JobProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 		return nil, errors.New("Job object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -45,8 +45,8 @@ func (p *JobProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 
 	values, deps := p.extractValues(obj)
 
-	// Get annotations for inline embedding in template
-	annotations := obj.GetAnnotations()
+	// Get annotations for inline embedding in template, including inferred hooks
+	annotations := hookAnnotations(ctx, obj)
 
 	template := p.generateTemplate(ctx, serviceName, annotations)
 
@@ -108,7 +108,7 @@ func (p *JobProcessor) extractValues(obj *unstructured.Unstructured) (map[string
 	if annotations := obj.GetAnnotations(); len(annotations) > 0 {
 		regularAnnotations := make(map[string]string)
 		for k, v := range annotations {
-			if !strings.HasPrefix(k, "helm.sh/") {
+			if !strings.HasPrefix(k, "helm.sh/") && k != hookOverrideAnnotation {
 				regularAnnotations[k] = v
 			}
 		}
@@ -130,12 +130,7 @@ func (p *JobProcessor) extractValues(obj *unstructured.Unstructured) (map[string
 				container["name"] = name
 			}
 			if image, ok := cm["image"].(string); ok {
-				parts := strings.SplitN(image, ":", 2)
-				imageMap := map[string]interface{}{"repository": parts[0]}
-				if len(parts) > 1 {
-					imageMap["tag"] = parts[1]
-				}
-				container["image"] = imageMap
+				container["image"] = imageValues(image)
 			}
 			if resources, ok := cm["resources"].(map[string]interface{}); ok {
 				container["resources"] = resources
@@ -161,9 +156,129 @@ func (p *JobProcessor) extractValues(obj *unstructured.Unstructured) (map[string
 		values["restartPolicy"] = policy
 	}
 
+	// Extract serviceAccountName
+	if sa, found, _ := unstructured.NestedString(obj.Object, "spec", "template", "spec", "serviceAccountName"); found && sa != "" {
+		values["serviceAccountName"] = sa
+		deps = append(deps, types.ResourceKey{
+			GVK:       schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"},
+			Namespace: obj.GetNamespace(),
+			Name:      sa,
+		})
+	}
+
+	// Token automounting; set to false by --no-token-automount
+	if automount, found, _ := unstructured.NestedBool(obj.Object, "spec", "template", "spec", "automountServiceAccountToken"); found {
+		values["automountServiceAccountToken"] = automount
+	}
+
+	// Extract priorityClassName (PriorityClasses are cluster-scoped)
+	if pc, found, _ := unstructured.NestedString(obj.Object, "spec", "template", "spec", "priorityClassName"); found && pc != "" {
+		values["priorityClassName"] = pc
+		deps = append(deps, types.ResourceKey{
+			GVK:  schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"},
+			Name: pc,
+		})
+	}
+
+	// Extract scheduling constraints (kubernetes.io/os selectors included)
+	if nodeSelector, found, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "spec", "nodeSelector"); found {
+		values["nodeSelector"] = nodeSelector
+	}
+	if affinity, found, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec", "affinity"); found {
+		values["affinity"] = affinity
+	}
+	if tolerations, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "tolerations"); len(tolerations) > 0 {
+		values["tolerations"] = tolerations
+	}
+
 	return values, deps
 }
 
+// hookOverrideAnnotation overrides hook inference for a single Job: "false"
+// keeps it a regular Job, "true" makes it a pre-install/pre-upgrade hook and
+// any other value is used as the helm.sh/hook value.
+const hookOverrideAnnotation = "dhg.deckhouse.io/hook"
+
+// Hook settings for inferred migration Jobs.
+const (
+	inferredHookPhases       = "pre-install,pre-upgrade"
+	inferredHookDeletePolicy = "before-hook-creation,hook-succeeded"
+)
+
+// hookJobNameMarkers are name fragments of Jobs that prepare the release
+// (schema migrations, initialization, seeding).
+var hookJobNameMarkers = []string{"migrat", "init", "seed"}
+
+// hookAnnotations returns the Job annotations with Helm hook annotations added
+// when the Job is inferred or configured to be a hook.
+func hookAnnotations(ctx processor.Context, obj *unstructured.Unstructured) map[string]string {
+	annotations := obj.GetAnnotations()
+	hook := inferHook(ctx, obj)
+	if hook == "" {
+		return annotations
+	}
+
+	out := make(map[string]string, len(annotations)+2)
+	for k, v := range annotations {
+		out[k] = v
+	}
+	out["helm.sh/hook"] = hook
+	if _, ok := out["helm.sh/hook-delete-policy"]; !ok {
+		out["helm.sh/hook-delete-policy"] = inferredHookDeletePolicy
+	}
+	return out
+}
+
+// inferHook returns the helm.sh/hook value for a Job that should become a
+// hook, or "" to keep it as is. Existing hook annotations are never changed.
+func inferHook(ctx processor.Context, obj *unstructured.Unstructured) string {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations["helm.sh/hook"]; ok {
+		return ""
+	}
+
+	switch override := annotations[hookOverrideAnnotation]; override {
+	case "":
+	case "false":
+		return ""
+	case "true":
+		return inferredHookPhases
+	default:
+		return override
+	}
+
+	if infer, _ := ctx.Options[processor.OptionInferHooks].(bool); !infer {
+		return ""
+	}
+	if !looksLikeHookJob(obj) {
+		return ""
+	}
+	return inferredHookPhases
+}
+
+// looksLikeHookJob reports whether a Job looks like a release preparation
+// step: a migrate/init/seed name, no owner (not spawned by a CronJob) and a
+// single run.
+func looksLikeHookJob(obj *unstructured.Unstructured) bool {
+	if len(obj.GetOwnerReferences()) > 0 {
+		return false
+	}
+	if completions, ok := nestedInt64(obj.Object, "spec", "completions"); ok && completions > 1 {
+		return false
+	}
+	if parallelism, ok := nestedInt64(obj.Object, "spec", "parallelism"); ok && parallelism > 1 {
+		return false
+	}
+
+	name := strings.ToLower(obj.GetName())
+	for _, marker := range hookJobNameMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 // buildAnnotationsBlock generates inline annotation YAML for the template.
 // Helm hook annotations are embedded directly; regular annotations use toYaml from values.
 func buildAnnotationsBlock(annotations map[string]string) string {
@@ -253,11 +368,20 @@ spec:
   template:
     spec:
       restartPolicy: {{ .restartPolicy | default "Never" }}
+      {{- with .serviceAccountName }}
+      serviceAccountName: {{ . }}
+      {{- end }}
+      {{- if hasKey . "automountServiceAccountToken" }}
+      automountServiceAccountToken: {{ .automountServiceAccountToken }}
+      {{- end }}
+      {{- with .priorityClassName }}
+      priorityClassName: {{ . }}
+      {{- end }}
       {{- with .containers }}
       containers:
         {{- range . }}
         - name: {{ .name }}
-          image: "{{ .image.repository }}:{{ .image.tag | default "latest" }}"
+          image: "{{ .image.repository }}{{ if .image.digest }}@{{ .image.digest }}{{ else }}:{{ .image.tag | default "latest" }}{{ end }}"
           {{- with .command }}
           command:
             {{- toYaml . | nindent 12 }}
@@ -272,6 +396,18 @@ spec:
           {{- end }}
         {{- end }}
       {{- end }}
+      {{- with .nodeSelector }}
+      nodeSelector:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      {{- with .affinity }}
+      affinity:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      {{- with .tolerations }}
+      tolerations:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
 {{- end }}
 {{- end }}
 `, serviceName, fullnameHelper, serviceName, ctx.ChartName, serviceName, annotationsPart)
@@ -4,9 +4,11 @@ import (
 	"strings"
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
 )
 
@@ -249,6 +251,53 @@ func TestProcessJob_ExtractsPodTemplate(t *testing.T) {
 	testutil.AssertEqual(t, "Never", restartPolicy)
 }
 
+func TestProcessJob_ExtractsNodeOSScheduling(t *testing.T) {
+	p := NewJobProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeBasicJobSpec("report", "mcr.microsoft.com/windows/servercore:ltsc2022")
+	podSpec := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	podSpec["nodeSelector"] = map[string]interface{}{"kubernetes.io/os": "windows"}
+	podSpec["tolerations"] = []interface{}{
+		map[string]interface{}{"key": "os", "operator": "Equal", "value": "windows", "effect": "NoSchedule"},
+	}
+
+	result, err := p.Process(ctx, makeJobObj("report", "default", nil, spec))
+	testutil.AssertNoError(t, err)
+
+	nodeSelector, ok := result.Values["nodeSelector"].(map[string]string)
+	if !ok || nodeSelector["kubernetes.io/os"] != "windows" {
+		t.Errorf("Expected the OS nodeSelector in values, got %v", result.Values["nodeSelector"])
+	}
+	if tolerations, ok := result.Values["tolerations"].([]interface{}); !ok || len(tolerations) != 1 {
+		t.Errorf("Expected one toleration in values, got %v", result.Values["tolerations"])
+	}
+	testutil.AssertContains(t, result.TemplateContent, "{{- with .nodeSelector }}")
+	testutil.AssertContains(t, result.TemplateContent, "{{- with .tolerations }}")
+	testutil.AssertContains(t, result.TemplateContent, "{{- with .affinity }}")
+}
+
+func TestProcessJob_ExtractsServiceAccountAndPriorityClass(t *testing.T) {
+	p := NewJobProcessor()
+	ctx := newTestProcessorContext()
+
+	spec := makeBasicJobSpec("report", "report:1.0")
+	podSpec := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	podSpec["serviceAccountName"] = "report"
+	podSpec["priorityClassName"] = "batch"
+
+	result, err := p.Process(ctx, makeJobObj("report", "default", nil, spec))
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, "report", result.Values["serviceAccountName"])
+	testutil.AssertEqual(t, "batch", result.Values["priorityClassName"])
+	if len(result.Dependencies) != 2 {
+		t.Errorf("Expected ServiceAccount and PriorityClass dependencies, got %v", result.Dependencies)
+	}
+	testutil.AssertContains(t, result.TemplateContent, "serviceAccountName: {{ . }}")
+	testutil.AssertContains(t, result.TemplateContent, "priorityClassName: {{ . }}")
+}
+
 // ============================================================
 // Subtask 9: Edge cases
 // ============================================================
@@ -351,3 +400,100 @@ func TestProcessJob_GeneratesTemplate(t *testing.T) {
 		t.Error("Expected template to reference chart name")
 	}
 }
+
+// ============================================================
+// Hook inference (--infer-hooks)
+// ============================================================
+
+func inferHooksContext() processor.Context {
+	ctx := newTestProcessorContext()
+	ctx.Options = map[string]interface{}{processor.OptionInferHooks: true}
+	return ctx
+}
+
+func TestProcessJob_InferHooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		jobName  string
+		mutate   func(obj *unstructured.Unstructured)
+		ctx      processor.Context
+		wantHook string
+	}{
+		{name: "migration job", jobName: "db-migrate", ctx: inferHooksContext(), wantHook: "pre-install,pre-upgrade"},
+		{name: "migrations job", jobName: "schema-migrations", ctx: inferHooksContext(), wantHook: "pre-install,pre-upgrade"},
+		{name: "seed job", jobName: "seed-data", ctx: inferHooksContext(), wantHook: "pre-install,pre-upgrade"},
+		{name: "inference disabled", jobName: "db-migrate", ctx: newTestProcessorContext()},
+		{name: "unrelated name", jobName: "report", ctx: inferHooksContext()},
+		{
+			name: "owned by a CronJob", jobName: "db-migrate-28500000", ctx: inferHooksContext(),
+			mutate: func(obj *unstructured.Unstructured) {
+				obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: "CronJob", Name: "db-migrate"}})
+			},
+		},
+		{
+			name: "parallel job", jobName: "init-shards", ctx: inferHooksContext(),
+			mutate: func(obj *unstructured.Unstructured) {
+				_ = unstructured.SetNestedField(obj.Object, int64(4), "spec", "completions")
+			},
+		},
+		{
+			name: "override disables", jobName: "db-migrate", ctx: inferHooksContext(),
+			mutate: func(obj *unstructured.Unstructured) {
+				obj.SetAnnotations(map[string]string{"dhg.deckhouse.io/hook": "false"})
+			},
+		},
+		{
+			name: "override sets phases without inference", jobName: "report", ctx: newTestProcessorContext(),
+			mutate: func(obj *unstructured.Unstructured) {
+				obj.SetAnnotations(map[string]string{"dhg.deckhouse.io/hook": "post-install"})
+			},
+			wantHook: "post-install",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := makeJobObj(tt.jobName, "default", nil, makeBasicJobSpec("app", "app:1.0"))
+			if tt.mutate != nil {
+				tt.mutate(obj)
+			}
+
+			result, err := NewJobProcessor().Process(tt.ctx, obj)
+			testutil.AssertNoError(t, err)
+
+			if tt.wantHook == "" {
+				if strings.Contains(result.TemplateContent, "helm.sh/hook") {
+					t.Errorf("expected no hook annotations, got:\n%s", result.TemplateContent)
+				}
+				return
+			}
+			testutil.AssertContains(t, result.TemplateContent, `helm.sh/hook: "`+tt.wantHook+`"`)
+			testutil.AssertContains(t, result.TemplateContent, `helm.sh/hook-delete-policy: "before-hook-creation,hook-succeeded"`)
+		})
+	}
+}
+
+func TestProcessJob_InferHooksKeepsExistingHook(t *testing.T) {
+	obj := makeJobObj("db-migrate", "default", nil, makeBasicJobSpec("app", "app:1.0"))
+	obj.SetAnnotations(map[string]string{
+		"helm.sh/hook":               "post-upgrade",
+		"helm.sh/hook-delete-policy": "hook-failed",
+	})
+
+	result, err := NewJobProcessor().Process(inferHooksContext(), obj)
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertContains(t, result.TemplateContent, `helm.sh/hook: "post-upgrade"`)
+	testutil.AssertContains(t, result.TemplateContent, `helm.sh/hook-delete-policy: "hook-failed"`)
+}
+
+func TestProcessJob_HookOverrideNotInValues(t *testing.T) {
+	obj := makeJobObj("db-migrate", "default", nil, makeBasicJobSpec("app", "app:1.0"))
+	obj.SetAnnotations(map[string]string{"dhg.deckhouse.io/hook": "true", "team": "data"})
+
+	result, err := NewJobProcessor().Process(newTestProcessorContext(), obj)
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, map[string]string{"team": "data"}, result.Values["annotations"])
+	testutil.AssertContains(t, result.TemplateContent, `helm.sh/hook: "pre-install,pre-upgrade"`)
+}
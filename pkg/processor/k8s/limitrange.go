@@ -33,7 +33,7 @@ func (p *LimitRangeProcessor) Process(ctx processor.Context, obj *unstructured.U
 		return nil, errors.New("LimitRange object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
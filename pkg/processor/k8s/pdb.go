@@ -32,7 +32,7 @@ func (p *PDBProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 		return nil, errors.New("PDB object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -59,7 +59,7 @@ func (p *PDBProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 }
 
 func (p *PDBProcessor) extractValues(obj *unstructured.Unstructured) map[string]interface{} {
-	values := make(map[string]interface{})
+	values := map[string]interface{}{"enabled": true}
 
 	// minAvailable can be int or string (percentage)
 	if val, exists, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "minAvailable"); exists {
@@ -90,6 +90,7 @@ func (p *PDBProcessor) generateTemplate(ctx processor.Context, serviceName strin
 	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
 {{- if $svc.enabled }}
 {{- with $svc.pdb }}
+{{- if .enabled }}
 apiVersion: policy/v1
 kind: PodDisruptionBudget
 metadata:
@@ -108,11 +109,16 @@ spec:
   {{- with .unhealthyPodEvictionPolicy }}
   unhealthyPodEvictionPolicy: {{ . }}
   {{- end }}
-  {{- with .selector }}
   selector:
+  {{- with .selector }}
     {{- toYaml . | nindent 4 }}
+  {{- else }}
+    matchLabels:
+      {{- include "%s.selectorLabels" $ | nindent 6 }}
+      app.kubernetes.io/component: %s
   {{- end }}
 {{- end }}
 {{- end }}
-`, serviceName, fullnameHelper, serviceName, ctx.ChartName, serviceName)
+{{- end }}
+`, serviceName, fullnameHelper, serviceName, ctx.ChartName, serviceName, ctx.ChartName, serviceName)
 }
@@ -0,0 +1,209 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// persistentVolumeSpecFields are the spec fields of a PersistentVolume that
+// are not its volume source.
+var persistentVolumeSpecFields = map[string]bool{
+	"capacity":                      true,
+	"accessModes":                   true,
+	"persistentVolumeReclaimPolicy": true,
+	"storageClassName":              true,
+	"volumeMode":                    true,
+	"mountOptions":                  true,
+	"nodeAffinity":                  true,
+	"claimRef":                      true,
+	"volumeAttributesClassName":     true,
+}
+
+// PersistentVolumeProcessor processes Kubernetes PersistentVolumes.
+// PersistentVolume is cluster-scoped and keeps its name. It is bound to the
+// storage of one cluster (volume handles, NFS servers, host paths), so the
+// whole volume source is a value, to be set per cluster, and every volume
+// has an enabled toggle.
+type PersistentVolumeProcessor struct {
+	processor.BaseProcessor
+}
+
+// NewPersistentVolumeProcessor creates a new PersistentVolume processor.
+func NewPersistentVolumeProcessor() *PersistentVolumeProcessor {
+	return &PersistentVolumeProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"persistentvolume",
+			100,
+			schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolume"},
+		),
+	}
+}
+
+// Process processes a PersistentVolume resource.
+func (p *PersistentVolumeProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, errors.New("PersistentVolume object is nil")
+	}
+
+	name := obj.GetName()
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
+	if serviceName == "" {
+		serviceName = processor.SanitizeServiceName(name)
+	}
+
+	values, deps := p.extractValues(ctx, obj)
+
+	template := p.generateTemplate(ctx, name, serviceName)
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/persistentvolume-%s.yaml", processor.SanitizeServiceName(name)),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.persistentVolumes.%s", serviceName, sanitizeName(name)),
+		Values:          values,
+		Dependencies:    deps,
+		Metadata: map[string]interface{}{
+			"name": name,
+		},
+	}, nil
+}
+
+// extractValues returns the values of a PersistentVolume and the PVC it is
+// bound to, when that PVC is in the input. The claimRef of such a PVC is
+// dropped: the chart renames the PVC, and it binds to the volume by storage
+// class, capacity and access modes.
+func (p *PersistentVolumeProcessor) extractValues(ctx processor.Context, obj *unstructured.Unstructured) (map[string]interface{}, []types.ResourceKey) {
+	values := map[string]interface{}{
+		"enabled": true,
+	}
+	deps := []types.ResourceKey{}
+
+	// pv.kubernetes.io/ annotations are set by the PV controller and
+	// provisioners of the source cluster.
+	annotations := make(map[string]interface{})
+	for k, v := range obj.GetAnnotations() {
+		if !strings.HasPrefix(k, "pv.kubernetes.io/") {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) > 0 {
+		values["annotations"] = annotations
+	}
+
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+
+	if capacity, ok := spec["capacity"].(map[string]interface{}); ok {
+		values["capacity"] = capacity
+	}
+	if modes, ok := spec["accessModes"].([]interface{}); ok {
+		values["accessModes"] = modes
+	}
+	if policy, ok := spec["persistentVolumeReclaimPolicy"].(string); ok && policy != "" {
+		values["reclaimPolicy"] = policy
+	}
+	// An empty storageClassName is kept: it binds the volume to PVCs
+	// without a class only.
+	if class, ok := spec["storageClassName"].(string); ok {
+		values["storageClassName"] = class
+	}
+	if mode, ok := spec["volumeMode"].(string); ok && mode != "" {
+		values["volumeMode"] = mode
+	}
+	if mountOptions, ok := spec["mountOptions"].([]interface{}); ok && len(mountOptions) > 0 {
+		values["mountOptions"] = mountOptions
+	}
+	if nodeAffinity, ok := spec["nodeAffinity"].(map[string]interface{}); ok {
+		values["nodeAffinity"] = nodeAffinity
+	}
+
+	if claimRef, ok := spec["claimRef"].(map[string]interface{}); ok {
+		claimName, _ := claimRef["name"].(string)
+		claimNamespace, _ := claimRef["namespace"].(string)
+		key := types.ResourceKey{
+			GVK:       schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"},
+			Namespace: claimNamespace,
+			Name:      claimName,
+		}
+		if _, ok := ctx.AllResources[key]; ok {
+			deps = append(deps, key)
+		} else if claimName != "" {
+			values["claimRef"] = map[string]interface{}{
+				"name":      claimName,
+				"namespace": claimNamespace,
+			}
+		}
+	}
+
+	source := make(map[string]interface{})
+	for k, v := range spec {
+		if !persistentVolumeSpecFields[k] {
+			source[k] = v
+		}
+	}
+	if len(source) > 0 {
+		values["source"] = source
+	}
+
+	return values, deps
+}
+
+func (p *PersistentVolumeProcessor) generateTemplate(ctx processor.Context, name, serviceName string) string {
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- $pv := $svc.persistentVolumes.%s -}}
+{{- if $pv.enabled }}
+apiVersion: v1
+kind: PersistentVolume
+metadata:
+  name: %s
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+    app.kubernetes.io/component: %s
+  {{- with $pv.annotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  capacity:
+    {{- toYaml $pv.capacity | nindent 4 }}
+  {{- with $pv.accessModes }}
+  accessModes:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $pv.reclaimPolicy }}
+  persistentVolumeReclaimPolicy: {{ . }}
+  {{- end }}
+  {{- if hasKey $pv "storageClassName" }}
+  storageClassName: {{ $pv.storageClassName | quote }}
+  {{- end }}
+  {{- with $pv.volumeMode }}
+  volumeMode: {{ . }}
+  {{- end }}
+  {{- with $pv.mountOptions }}
+  mountOptions:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $pv.nodeAffinity }}
+  nodeAffinity:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with $pv.claimRef }}
+  claimRef:
+    name: {{ .name }}
+    namespace: {{ .namespace }}
+  {{- end }}
+  {{- with $pv.source }}
+  {{- toYaml . | nindent 2 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`, serviceName, sanitizeName(name), name, ctx.ChartName, serviceName)
+}
@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func makePersistentVolumeObj(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolume",
+		"metadata": map[string]interface{}{
+			"name": "db-data",
+			"annotations": map[string]interface{}{
+				"pv.kubernetes.io/bound-by-controller": "yes",
+				"backup.example.com/policy":            "daily",
+			},
+		},
+		"spec": spec,
+		"status": map[string]interface{}{
+			"phase": "Bound",
+		},
+	}}
+}
+
+func nfsPersistentVolumeSpec() map[string]interface{} {
+	return map[string]interface{}{
+		"capacity":                      map[string]interface{}{"storage": "10Gi"},
+		"accessModes":                   []interface{}{"ReadWriteOnce"},
+		"persistentVolumeReclaimPolicy": "Retain",
+		"storageClassName":              "",
+		"mountOptions":                  []interface{}{"nfsvers=4.1"},
+		"claimRef": map[string]interface{}{
+			"name":      "data",
+			"namespace": "prod",
+			"uid":       "0b3b6f0e",
+		},
+		"nfs": map[string]interface{}{"server": "10.0.0.5", "path": "/exports/db"},
+	}
+}
+
+func TestNewPersistentVolumeProcessor(t *testing.T) {
+	p := NewPersistentVolumeProcessor()
+	testutil.AssertEqual(t, "persistentvolume", p.Name())
+	testutil.AssertEqual(t, 100, p.Priority())
+
+	gvks := p.Supports()
+	testutil.AssertEqual(t, 1, len(gvks))
+	testutil.AssertEqual(t, schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolume"}, gvks[0])
+}
+
+func TestProcessPersistentVolume_ExtractsValues(t *testing.T) {
+	p := NewPersistentVolumeProcessor()
+	result, err := p.Process(newTestProcessorContext(), makePersistentVolumeObj(nfsPersistentVolumeSpec()))
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "services.dbData.persistentVolumes.dbData", result.ValuesPath)
+
+	values := result.Values
+	testutil.AssertEqual(t, true, values["enabled"])
+	testutil.AssertEqual(t, "10Gi", values["capacity"].(map[string]interface{})["storage"])
+	testutil.AssertEqual(t, "Retain", values["reclaimPolicy"])
+	if class, ok := values["storageClassName"]; !ok || class != "" {
+		t.Errorf("an empty storageClassName should be kept, got %v", values)
+	}
+	source := values["source"].(map[string]interface{})
+	if len(source) != 1 || source["nfs"].(map[string]interface{})["server"] != "10.0.0.5" {
+		t.Errorf("expected the nfs volume source only, got %v", source)
+	}
+	claimRef := values["claimRef"].(map[string]interface{})
+	if len(claimRef) != 2 || claimRef["name"] != "data" || claimRef["namespace"] != "prod" {
+		t.Errorf("expected the claimRef name and namespace only, got %v", claimRef)
+	}
+	annotations := values["annotations"].(map[string]interface{})
+	if len(annotations) != 1 || annotations["backup.example.com/policy"] != "daily" {
+		t.Errorf("expected the pv.kubernetes.io/ annotations to be dropped, got %v", annotations)
+	}
+	testutil.AssertEqual(t, 0, len(result.Dependencies))
+}
+
+func TestProcessPersistentVolume_ClaimInInput(t *testing.T) {
+	p := NewPersistentVolumeProcessor()
+	claim := types.ResourceKey{GVK: schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}, Namespace: "prod", Name: "data"}
+	ctx := processor.Context{
+		ChartName:    "test-chart",
+		AllResources: map[types.ResourceKey]*types.ExtractedResource{claim: {}},
+	}
+
+	result, err := p.Process(ctx, makePersistentVolumeObj(nfsPersistentVolumeSpec()))
+	testutil.AssertNoError(t, err)
+	if _, ok := result.Values["claimRef"]; ok {
+		t.Error("the claimRef of a PVC of the chart should be dropped")
+	}
+	testutil.AssertEqual(t, 1, len(result.Dependencies))
+	testutil.AssertEqual(t, claim, result.Dependencies[0])
+}
+
+func TestProcessPersistentVolume_Template(t *testing.T) {
+	p := NewPersistentVolumeProcessor()
+	result, err := p.Process(newTestProcessorContext(), makePersistentVolumeObj(nfsPersistentVolumeSpec()))
+	testutil.AssertNoError(t, err)
+
+	for _, want := range []string{
+		"{{- $pv := $svc.persistentVolumes.dbData -}}",
+		"{{- if $pv.enabled }}",
+		"  name: db-data\n",
+		"  persistentVolumeReclaimPolicy: {{ . }}",
+		"  {{- with $pv.source }}\n  {{- toYaml . | nindent 2 }}",
+	} {
+		testutil.AssertContains(t, result.TemplateContent, want)
+	}
+	if strings.Contains(result.TemplateContent, "namespace: {{ $.Release.Namespace }}") {
+		t.Error("a PersistentVolume is cluster-scoped and should have no namespace")
+	}
+}
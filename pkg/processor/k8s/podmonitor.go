@@ -32,7 +32,7 @@ func (p *PodMonitorProcessor) Process(ctx processor.Context, obj *unstructured.U
 		return nil, errors.New("PodMonitor object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -68,6 +68,8 @@ func (p *PodMonitorProcessor) extractValues(obj *unstructured.Unstructured) map[
 	// Extract podMetricsEndpoints
 	if endpoints, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "podMetricsEndpoints"); ok && len(endpoints) > 0 {
 		values["podMetricsEndpoints"] = endpoints
+		values["interval"] = commonEndpointField(endpoints, "interval")
+		values["scrapeTimeout"] = commonEndpointField(endpoints, "scrapeTimeout")
 	}
 
 	// Extract jobLabel
@@ -87,8 +89,9 @@ func (p *PodMonitorProcessor) generateTemplate(ctx processor.Context, serviceNam
 	sanitized := processor.SanitizeServiceName(serviceName)
 
 	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
-{{- if $svc.enabled }}
+`+monitoringGate+`
 {{- with $svc.podMonitor }}
+{{- $mon := . }}
 apiVersion: monitoring.coreos.com/v1
 kind: PodMonitor
 metadata:
@@ -102,7 +105,12 @@ spec:
   {{- end }}
   {{- with .podMetricsEndpoints }}
   podMetricsEndpoints:
-    {{- toYaml . | nindent 4 }}
+    {{- range . }}
+    {{- $ep := deepCopy . }}
+    {{- with $mon.interval }}{{ $_ := set $ep "interval" . }}{{ end }}
+    {{- with $mon.scrapeTimeout }}{{ $_ := set $ep "scrapeTimeout" . }}{{ end }}
+    - {{- toYaml $ep | nindent 6 }}
+    {{- end }}
   {{- end }}
   {{- with .selector }}
   selector:
@@ -203,3 +203,25 @@ func TestPodMonitorProcessor_ServiceName(t *testing.T) {
 	testutil.AssertNoError(t, err)
 	testutil.AssertEqual(t, "myapp-pods", result.ServiceName, "ServiceName should be metadata.name")
 }
+
+// ============================================================
+// Test 8: Shared interval and monitoring gate
+// ============================================================
+
+func TestPodMonitorProcessor_IntervalAndGate(t *testing.T) {
+	proc := NewPodMonitorProcessor()
+	ctx := newTestProcessorContext()
+
+	obj := makePodMonitorObj("myapp-pods", "default", map[string]interface{}{
+		"podMetricsEndpoints": []interface{}{
+			map[string]interface{}{"port": "metrics", "interval": "20s"},
+		},
+	})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, "20s", result.Values["interval"], "shared interval")
+	testutil.AssertContains(t, result.TemplateContent, `hasKey $monitoring "enabled"`, "monitoring.enabled gate")
+	testutil.AssertContains(t, result.TemplateContent, `set $ep "interval"`, "interval override")
+}
@@ -12,7 +12,10 @@ import (
 )
 
 // PriorityClassProcessor processes Kubernetes PriorityClass resources (scheduling.k8s.io/v1).
-// PriorityClass is cluster-scoped — no namespace, no service wrapper.
+// PriorityClass is cluster-scoped: it has no namespace, and it keeps its name,
+// which workloads reference in priorityClassName. Its values are those of the
+// service, overridden by priorityClasses.<name>, where earlier versions kept
+// them.
 type PriorityClassProcessor struct {
 	processor.BaseProcessor
 }
@@ -34,20 +37,22 @@ func (p *PriorityClassProcessor) Process(ctx processor.Context, obj *unstructure
 		return nil, errors.New("PriorityClass object is nil")
 	}
 
-	// PriorityClass is cluster-scoped: use obj.GetName() directly as ServiceName.
 	name := obj.GetName()
-	safeName := processor.SanitizeServiceName(name)
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
+	if serviceName == "" {
+		serviceName = processor.SanitizeServiceName(name)
+	}
 
 	values := p.extractValues(obj)
 
-	template := p.generateTemplate(ctx, name, safeName)
+	template := p.generateTemplate(ctx, name, serviceName, processor.SanitizeServiceName(name))
 
 	return &processor.Result{
 		Processed:       true,
-		ServiceName:     safeName,
-		TemplatePath:    fmt.Sprintf("templates/priorityclass-%s.yaml", safeName),
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/priorityclass-%s.yaml", processor.SanitizeServiceName(name)),
 		TemplateContent: template,
-		ValuesPath:      fmt.Sprintf("priorityClasses.%s", safeName),
+		ValuesPath:      fmt.Sprintf("services.%s.priorityClass", serviceName),
 		Values:          values,
 		Dependencies:    []types.ResourceKey{},
 		Metadata: map[string]interface{}{
@@ -82,20 +87,24 @@ func (p *PriorityClassProcessor) extractValues(obj *unstructured.Unstructured) m
 	return values
 }
 
-func (p *PriorityClassProcessor) generateTemplate(ctx processor.Context, name, safeName string) string {
-	return fmt.Sprintf(`{{- with (index .Values.priorityClasses "%s") }}
+func (p *PriorityClassProcessor) generateTemplate(ctx processor.Context, name, serviceName, valuesKey string) string {
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with mergeOverwrite (deepCopy ($svc.priorityClass | default dict)) (index (.Values.priorityClasses | default dict) %q | default dict) }}
 apiVersion: scheduling.k8s.io/v1
 kind: PriorityClass
 metadata:
   name: %s
   labels:
     {{- include "%s.labels" $ | nindent 4 }}
-value: {{ .value }}
+    app.kubernetes.io/component: %s
+value: {{ int64 .value }}
 {{- with .description }}
 description: {{ . | quote }}
 {{- end }}
 globalDefault: {{ .globalDefault | default false }}
 preemptionPolicy: {{ .preemptionPolicy | default "PreemptLowerPriority" }}
 {{- end }}
-`, safeName, name, ctx.ChartName)
+{{- end }}
+`, serviceName, valuesKey, name, ctx.ChartName, serviceName)
 }
@@ -1,7 +1,6 @@
 package k8s
 
 import (
-	"strings"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -177,13 +176,13 @@ func TestProcessPriorityClass_ResultMetadata(t *testing.T) {
 	// Cluster-scoped: ServiceName is sanitized (hyphens → camelCase)
 	testutil.AssertEqual(t, "highPriority", result.ServiceName)
 	testutil.AssertEqual(t, "templates/priorityclass-highPriority.yaml", result.TemplatePath)
-	testutil.AssertEqual(t, "priorityClasses.highPriority", result.ValuesPath)
+	testutil.AssertEqual(t, "services.highPriority.priorityClass", result.ValuesPath)
 	testutil.AssertContains(t, result.TemplateContent, "kind: PriorityClass")
 	testutil.AssertContains(t, result.TemplateContent, "value:")
 }
 
 // ============================================================
-// Template generation tests (cluster-scoped, no service wrapper)
+// Template generation tests (cluster-scoped)
 // ============================================================
 
 func TestProcessPriorityClass_GeneratesTemplate(t *testing.T) {
@@ -207,9 +206,13 @@ func TestProcessPriorityClass_GeneratesTemplate(t *testing.T) {
 	testutil.AssertContains(t, tmpl, "globalDefault")
 	testutil.AssertContains(t, tmpl, "preemptionPolicy")
 	testutil.AssertContains(t, tmpl, "test-chart")
-	// Cluster-scoped: should NOT have services wrapper
-	if strings.Contains(tmpl, "$svc := .Values.services") {
-		t.Error("PriorityClass template should NOT have service wrapper (cluster-scoped)")
-	}
-	testutil.AssertContains(t, tmpl, "priorityClasses")
+	// Values are read from the service, like every other kind.
+	testutil.AssertContains(t, tmpl, "$svc := .Values.services.myPriority")
+	testutil.AssertContains(t, tmpl, "($svc.priorityClass | default dict)")
+	// Values set at the path of earlier versions still apply.
+	testutil.AssertContains(t, tmpl, `(index (.Values.priorityClasses | default dict) "myPriority" | default dict)`)
+	// Workloads reference the PriorityClass by name: it is not prefixed.
+	testutil.AssertContains(t, tmpl, "  name: my-priority\n")
+	// Large values are floats in Helm and must not render as 1e+06.
+	testutil.AssertContains(t, tmpl, "value: {{ int64 .value }}")
 }
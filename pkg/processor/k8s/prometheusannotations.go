@@ -60,7 +60,7 @@ func (p *PrometheusAnnotationsProcessor) Process(ctx processor.Context, obj *uns
 
 	return &processor.Result{
 		Processed:   true,
-		ServiceName: processor.SanitizeServiceName(processor.ServiceNameFromResource(obj)),
+		ServiceName: processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj)),
 		Values:      values,
 	}, nil
 }
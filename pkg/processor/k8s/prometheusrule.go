@@ -32,7 +32,7 @@ func (p *PrometheusRuleProcessor) Process(ctx processor.Context, obj *unstructur
 		return nil, errors.New("PrometheusRule object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
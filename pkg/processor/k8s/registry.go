@@ -22,6 +22,8 @@ func RegisterAll(r *processor.Registry) {
 
 	// Storage
 	r.Register(NewPVCProcessor())
+	r.Register(NewPersistentVolumeProcessor())
+	r.Register(NewStorageClassProcessor())
 
 	// Autoscaling
 	r.Register(NewHPAProcessor())
@@ -48,12 +50,17 @@ func RegisterAll(r *processor.Registry) {
 	r.Register(NewRoleBindingProcessor())
 	r.Register(NewClusterRoleBindingProcessor())
 
+	// Admission webhooks
+	r.Register(NewValidatingWebhookConfigurationProcessor())
+	r.Register(NewMutatingWebhookConfigurationProcessor())
+
 	// Argo Rollouts
 	r.Register(NewRolloutProcessor())
 
 	// cert-manager
 	r.Register(NewCertificateProcessor())
 	r.Register(NewClusterIssuerProcessor())
+	r.Register(NewIssuerProcessor())
 
 	// KEDA
 	r.Register(NewScaledObjectProcessor())
@@ -65,6 +72,11 @@ func RegisterAll(r *processor.Registry) {
 	r.Register(NewGRPCRouteProcessor())
 	r.Register(NewTLSRouteProcessor())
 
+	// Istio
+	r.Register(NewVirtualServiceProcessor())
+	r.Register(NewDestinationRuleProcessor())
+	r.Register(NewIstioGatewayProcessor())
+
 	// Flagger
 	r.Register(NewFlaggerCanaryProcessor())
 
@@ -33,7 +33,7 @@ func (p *ResourceQuotaProcessor) Process(ctx processor.Context, obj *unstructure
 		return nil, errors.New("ResourceQuota object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
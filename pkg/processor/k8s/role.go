@@ -32,7 +32,7 @@ func (p *RoleProcessor) Process(ctx processor.Context, obj *unstructured.Unstruc
 		return nil, errors.New("Role object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -59,7 +59,10 @@ func (p *RoleProcessor) Process(ctx processor.Context, obj *unstructured.Unstruc
 }
 
 func (p *RoleProcessor) extractValues(obj *unstructured.Unstructured) map[string]interface{} {
-	values := make(map[string]interface{})
+	// The Role keeps its name, so RoleBinding roleRefs still resolve.
+	values := map[string]interface{}{
+		"name": obj.GetName(),
+	}
 
 	if rules, ok, _ := unstructured.NestedSlice(obj.Object, "rules"); ok && len(rules) > 0 {
 		values["rules"] = rules
@@ -69,15 +72,13 @@ func (p *RoleProcessor) extractValues(obj *unstructured.Unstructured) map[string
 }
 
 func (p *RoleProcessor) generateTemplate(ctx processor.Context, serviceName string) string {
-	fullnameHelper := fmt.Sprintf(`{{ include "%s.fullname" $ }}`, ctx.ChartName)
-
 	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
 {{- if $svc.enabled }}
 {{- with $svc.role }}
 apiVersion: rbac.authorization.k8s.io/v1
 kind: Role
 metadata:
-  name: %s-%s
+  name: {{ .name }}
   namespace: {{ $.Release.Namespace }}
   labels:
     {{- include "%s.labels" $ | nindent 4 }}
@@ -88,5 +89,5 @@ rules:
 {{- end }}
 {{- end }}
 {{- end }}
-`, serviceName, fullnameHelper, serviceName, ctx.ChartName, serviceName)
+`, serviceName, ctx.ChartName, serviceName)
 }
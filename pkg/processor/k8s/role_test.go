@@ -209,3 +209,16 @@ func TestProcessRole_GeneratesTemplate(t *testing.T) {
 		t.Error("Expected template to reference chart name")
 	}
 }
+
+func TestProcessRole_KeepsNameForRoleBindings(t *testing.T) {
+	p := NewRoleProcessor()
+	ctx := newTestProcessorContext()
+
+	// A Role without rules is a scaffold; it must still render.
+	obj := makeRoleObj("web", "default", map[string]interface{}{"app": "web"}, nil)
+
+	result, err := p.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "web", result.Values["name"])
+	testutil.AssertContains(t, result.TemplateContent, "name: {{ .name }}")
+}
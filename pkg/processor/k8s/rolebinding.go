@@ -33,7 +33,7 @@ func (p *RoleBindingProcessor) Process(ctx processor.Context, obj *unstructured.
 		return nil, errors.New("RoleBinding object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -134,7 +134,18 @@ roleRef:
 {{- end }}
 {{- with .subjects }}
 subjects:
-  {{- toYaml . | nindent 2 }}
+  {{- range . }}
+  - kind: {{ .kind }}
+    name: {{ .name }}
+    {{- with .apiGroup }}
+    apiGroup: {{ . }}
+    {{- end }}
+    {{- if .namespace }}
+    namespace: {{ .namespace }}
+    {{- else if eq .kind "ServiceAccount" }}
+    namespace: {{ $.Release.Namespace }}
+    {{- end }}
+  {{- end }}
 {{- end }}
 {{- end }}
 {{- end }}
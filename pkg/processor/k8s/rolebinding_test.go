@@ -238,6 +238,9 @@ func TestProcessRoleBinding_GeneratesTemplate(t *testing.T) {
 	testutil.AssertContains(t, tmpl, "RoleBinding")
 	testutil.AssertContains(t, tmpl, "roleRef")
 	testutil.AssertContains(t, tmpl, "subjects")
+	// ServiceAccount subjects without a namespace are in the release namespace.
+	testutil.AssertContains(t, tmpl, `{{- else if eq .kind "ServiceAccount" }}
+    namespace: {{ $.Release.Namespace }}`)
 	if !strings.Contains(tmpl, "test-chart") {
 		t.Error("Expected template to reference chart name")
 	}
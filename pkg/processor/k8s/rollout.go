@@ -32,7 +32,7 @@ func (p *RolloutProcessor) Process(ctx processor.Context, obj *unstructured.Unst
 		return nil, errors.New("Rollout object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -33,7 +33,7 @@ func (p *ScaledObjectProcessor) Process(ctx processor.Context, obj *unstructured
 		return nil, errors.New("ScaledObject object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -32,7 +32,7 @@ func (p *ServiceProcessor) Process(ctx processor.Context, obj *unstructured.Unst
 		return nil, fmt.Errorf("cannot process nil Service")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -51,6 +51,10 @@ func (p *ServiceProcessor) Process(ctx processor.Context, obj *unstructured.Unst
 		"namespace": namespace,
 	}
 
+	if values["clusterIP"] == "None" {
+		metadata["headless"] = true
+	}
+
 	// Detect ExternalDNS annotations
 	if edns := detectExternalDNS(obj); edns != nil {
 		metadata["external_dns"] = edns
@@ -79,7 +83,9 @@ func (p *ServiceProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 		values["type"] = "ClusterIP"
 	}
 
-	// Ports
+	// Ports: every port is written out in full, so that each can be
+	// overridden in values.yaml. Kubernetes requires names when a Service has
+	// more than one port.
 	if ports, found, _ := unstructured.NestedSlice(obj.Object, "spec", "ports"); found {
 		portValues := make([]map[string]interface{}, 0, len(ports))
 		for _, p := range ports {
@@ -87,30 +93,7 @@ func (p *ServiceProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 			if !ok {
 				continue
 			}
-			pv := make(map[string]interface{})
-
-			if name, ok := port["name"].(string); ok {
-				pv["name"] = name
-			}
-			if portNum, ok := toInt64(port["port"]); ok {
-				pv["port"] = portNum
-			}
-			if targetPort := port["targetPort"]; targetPort != nil {
-				// targetPort can be int or string (named port)
-				if tp, ok := toInt64(targetPort); ok {
-					pv["targetPort"] = tp
-				} else {
-					pv["targetPort"] = targetPort
-				}
-			}
-			if protocol, ok := port["protocol"].(string); ok {
-				pv["protocol"] = protocol
-			}
-			if nodePort, ok := toInt64(port["nodePort"]); ok {
-				pv["nodePort"] = nodePort
-			}
-
-			portValues = append(portValues, pv)
+			portValues = append(portValues, extractServicePort(port, len(ports) > 1))
 		}
 		values["ports"] = portValues
 	}
@@ -137,13 +120,14 @@ func (p *ServiceProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 		}
 	}
 
-	// ClusterIP
-	if clusterIP, found, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); found {
-		if clusterIP != "" && clusterIP != "None" {
-			values["clusterIP"] = clusterIP
-		} else if clusterIP == "None" {
-			values["clusterIP"] = "None"
-		}
+	// ClusterIP; "None" makes the Service headless
+	if clusterIP, found, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); found && clusterIP != "" {
+		values["clusterIP"] = clusterIP
+	}
+
+	// Headless Services of StatefulSets usually publish not-ready pods
+	if publish, found, _ := unstructured.NestedBool(obj.Object, "spec", "publishNotReadyAddresses"); found {
+		values["publishNotReadyAddresses"] = publish
 	}
 
 	// External traffic policy
@@ -187,6 +171,47 @@ func (p *ServiceProcessor) extractValues(obj *unstructured.Unstructured) (map[st
 	return values, deps
 }
 
+// extractServicePort returns the values of a Service port with name,
+// targetPort and protocol filled in. Unnamed ports are named "http", or
+// "port-<port>" when the Service has several ports.
+func extractServicePort(port map[string]interface{}, multiPort bool) map[string]interface{} {
+	pv := make(map[string]interface{})
+
+	portNum, hasPort := toInt64(port["port"])
+	if hasPort {
+		pv["port"] = portNum
+	}
+	if name, ok := port["name"].(string); ok && name != "" {
+		pv["name"] = name
+	} else if multiPort && hasPort {
+		pv["name"] = fmt.Sprintf("port-%d", portNum)
+	} else {
+		pv["name"] = "http"
+	}
+	if targetPort := port["targetPort"]; targetPort != nil {
+		// targetPort can be int or string (named port)
+		if tp, ok := toInt64(targetPort); ok {
+			pv["targetPort"] = tp
+		} else {
+			pv["targetPort"] = targetPort
+		}
+	} else if hasPort {
+		pv["targetPort"] = portNum
+	}
+	if protocol, ok := port["protocol"].(string); ok && protocol != "" {
+		pv["protocol"] = protocol
+	} else {
+		pv["protocol"] = "TCP"
+	}
+	if appProtocol, ok := port["appProtocol"].(string); ok {
+		pv["appProtocol"] = appProtocol
+	}
+	if nodePort, ok := toInt64(port["nodePort"]); ok {
+		pv["nodePort"] = nodePort
+	}
+	return pv
+}
+
 // toInt64 converts numeric types (int64, float64, int, int32) to int64.
 // YAML/JSON parsers may produce different numeric types depending on the source.
 func toInt64(v interface{}) (int64, bool) {
@@ -223,11 +248,17 @@ metadata:
     {{- toYaml . | nindent 4 }}
   {{- end }}
 spec:
-  type: {{ .type | default "ClusterIP" }}
-  {{- if and (eq .type "ClusterIP") .clusterIP }}
-  clusterIP: {{ .clusterIP }}
+  {{- $type := .type | default "ClusterIP" }}
+  type: {{ $type }}
+  {{- if eq $type "ClusterIP" }}
+  {{- with .clusterIP }}
+  clusterIP: {{ . }}
   {{- end }}
-  {{- if eq .type "LoadBalancer" }}
+  {{- end }}
+  {{- if eq $type "ExternalName" }}
+  externalName: {{ .externalName }}
+  {{- end }}
+  {{- if eq $type "LoadBalancer" }}
   {{- with .loadBalancerIP }}
   loadBalancerIP: {{ . }}
   {{- end }}
@@ -235,6 +266,9 @@ spec:
   loadBalancerSourceRanges:
     {{- toYaml . | nindent 4 }}
   {{- end }}
+  {{- with .healthCheckNodePort }}
+  healthCheckNodePort: {{ . }}
+  {{- end }}
   {{- end }}
   {{- with .externalIPs }}
   externalIPs:
@@ -246,24 +280,33 @@ spec:
   {{- with .sessionAffinity }}
   sessionAffinity: {{ . }}
   {{- end }}
+  {{- if .publishNotReadyAddresses }}
+  publishNotReadyAddresses: true
+  {{- end }}
+  {{- with .ports }}
   ports:
-    {{- range .ports }}
+    {{- range . }}
     - name: {{ .name | default "http" }}
       port: {{ .port }}
       targetPort: {{ .targetPort | default .port }}
       protocol: {{ .protocol | default "TCP" }}
-      {{- if and (eq ($.Values.services.%s.service.type | default "ClusterIP") "NodePort") .nodePort }}
+      {{- with .appProtocol }}
+      appProtocol: {{ . }}
+      {{- end }}
+      {{- if and (or (eq $type "NodePort") (eq $type "LoadBalancer")) .nodePort }}
       nodePort: {{ .nodePort }}
       {{- end }}
     {{- end }}
+  {{- end }}
+  {{- if ne $type "ExternalName" }}
   selector:
     {{- include "%s.selectorLabels" $ | nindent 4 }}
     app.kubernetes.io/component: %s
+  {{- end }}
 {{- end }}
 {{- end }}
 `, serviceName, fullnameHelper, serviceName,
 		ctx.ChartName, serviceName,
-		serviceName,
 		ctx.ChartName, serviceName)
 
 	return template
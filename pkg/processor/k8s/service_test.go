@@ -681,6 +681,45 @@ func TestProcessService_ExtractsHealthCheckNodePort(t *testing.T) {
 // Subtask 9: Edge cases
 // ============================================================
 
+func TestProcessService_PortDefaults(t *testing.T) {
+	proc := NewServiceProcessor()
+	ctx := newTestProcessorContext()
+
+	obj := makeServiceResource("my-svc", "default",
+		map[string]interface{}{"app": "myapp"}, nil,
+		map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(80)},
+				map[string]interface{}{"name": "metrics", "port": int64(9090), "targetPort": "metrics"},
+				map[string]interface{}{"port": int64(53), "protocol": "UDP", "appProtocol": "dns"},
+			},
+		})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+
+	ports := result.Values["ports"].([]map[string]interface{})
+	if len(ports) != 3 {
+		t.Fatalf("Expected 3 ports, got %d", len(ports))
+	}
+	testutil.AssertEqual(t, "port-80", ports[0]["name"], "unnamed port in a multi-port service")
+	testutil.AssertEqual(t, int64(80), ports[0]["targetPort"], "targetPort defaults to port")
+	testutil.AssertEqual(t, "TCP", ports[0]["protocol"], "protocol defaults to TCP")
+	testutil.AssertEqual(t, "metrics", ports[1]["name"], "named port")
+	testutil.AssertEqual(t, "metrics", ports[1]["targetPort"], "named targetPort")
+	testutil.AssertEqual(t, "port-53", ports[2]["name"], "unnamed UDP port")
+	testutil.AssertEqual(t, "UDP", ports[2]["protocol"], "protocol")
+	testutil.AssertEqual(t, "dns", ports[2]["appProtocol"], "appProtocol")
+
+	single := makeServiceResource("single", "default",
+		map[string]interface{}{"app": "myapp"}, nil,
+		map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(5432)}}})
+	result, err = proc.Process(ctx, single)
+	testutil.AssertNoError(t, err)
+	ports = result.Values["ports"].([]map[string]interface{})
+	testutil.AssertEqual(t, "http", ports[0]["name"], "unnamed single port")
+}
+
 func TestProcessService_EdgeCases(t *testing.T) {
 	t.Run("HeadlessService", func(t *testing.T) {
 		proc := NewServiceProcessor()
@@ -706,6 +745,7 @@ func TestProcessService_EdgeCases(t *testing.T) {
 		testutil.AssertNoError(t, err)
 		testutil.AssertEqual(t, "None", result.Values["clusterIP"],
 			"headless service should have clusterIP=None")
+		testutil.AssertEqual(t, true, result.Metadata["headless"], "headless metadata")
 	})
 
 	t.Run("ServiceWithoutSelectors", func(t *testing.T) {
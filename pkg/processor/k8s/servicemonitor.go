@@ -11,6 +11,12 @@ import (
 	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
 )
 
+// monitoringGate renders a monitor only when its service is enabled and
+// monitoring.enabled is not false. A missing monitoring section counts as
+// enabled, so charts without it keep rendering their monitors.
+const monitoringGate = `{{- $monitoring := .Values.monitoring | default dict }}
+{{- if and $svc.enabled (ternary $monitoring.enabled true (hasKey $monitoring "enabled")) }}`
+
 // ServiceMonitorProcessor processes Prometheus Operator ServiceMonitor resources.
 type ServiceMonitorProcessor struct {
 	processor.BaseProcessor
@@ -33,7 +39,7 @@ func (p *ServiceMonitorProcessor) Process(ctx processor.Context, obj *unstructur
 		return nil, errors.New("ServiceMonitor object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -68,9 +74,12 @@ func (p *ServiceMonitorProcessor) extractValues(obj *unstructured.Unstructured)
 		values["spec"] = spec
 	}
 
-	// Extract endpoints
+	// Extract endpoints; a scrape interval/timeout shared by all endpoints is
+	// exposed as a single knob that overrides every endpoint.
 	if endpoints, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "endpoints"); ok && len(endpoints) > 0 {
 		values["endpoints"] = endpoints
+		values["interval"] = commonEndpointField(endpoints, "interval")
+		values["scrapeTimeout"] = commonEndpointField(endpoints, "scrapeTimeout")
 	}
 
 	// Extract namespaceSelector
@@ -97,8 +106,9 @@ func (p *ServiceMonitorProcessor) generateTemplate(ctx processor.Context, servic
 	sanitized := processor.SanitizeServiceName(serviceName)
 
 	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
-{{- if $svc.enabled }}
+`+monitoringGate+`
 {{- with $svc.serviceMonitor }}
+{{- $mon := . }}
 apiVersion: monitoring.coreos.com/v1
 kind: ServiceMonitor
 metadata:
@@ -109,7 +119,12 @@ metadata:
 spec:
   {{- with .endpoints }}
   endpoints:
-    {{- toYaml . | nindent 4 }}
+    {{- range . }}
+    {{- $ep := deepCopy . }}
+    {{- with $mon.interval }}{{ $_ := set $ep "interval" . }}{{ end }}
+    {{- with $mon.scrapeTimeout }}{{ $_ := set $ep "scrapeTimeout" . }}{{ end }}
+    - {{- toYaml $ep | nindent 6 }}
+    {{- end }}
   {{- end }}
   {{- with .namespaceSelector }}
   namespaceSelector:
@@ -123,3 +138,18 @@ spec:
 {{- end }}
 `, sanitized, serviceName, ctx.ChartName)
 }
+
+// commonEndpointField returns the value of field when every endpoint sets it
+// to the same string, and "" otherwise.
+func commonEndpointField(endpoints []interface{}, field string) string {
+	common := ""
+	for i, e := range endpoints {
+		ep, _ := e.(map[string]interface{})
+		value, _ := ep[field].(string)
+		if value == "" || (i > 0 && value != common) {
+			return ""
+		}
+		common = value
+	}
+	return common
+}
@@ -329,3 +329,68 @@ func TestServiceMonitorProcessor_Template(t *testing.T) {
 		t.Error("Template should reference endpoints")
 	}
 }
+
+// ============================================================
+// Test 11: Common interval/scrapeTimeout and monitoring gate
+// ============================================================
+
+func TestServiceMonitorProcessor_CommonInterval(t *testing.T) {
+	proc := NewServiceMonitorProcessor()
+	ctx := newTestProcessorContext()
+
+	obj := makeServiceMonitorObj("myapp", "default", map[string]interface{}{
+		"endpoints": []interface{}{
+			map[string]interface{}{"port": "metrics", "interval": "30s", "scrapeTimeout": "10s"},
+			map[string]interface{}{"port": "admin", "interval": "30s", "scrapeTimeout": "5s"},
+		},
+	})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, "30s", result.Values["interval"], "shared interval")
+	testutil.AssertEqual(t, "", result.Values["scrapeTimeout"], "differing scrapeTimeout is not shared")
+
+	tpl := result.TemplateContent
+	testutil.AssertContains(t, tpl, `set $ep "interval"`, "interval override")
+	testutil.AssertContains(t, tpl, `set $ep "scrapeTimeout"`, "scrapeTimeout override")
+}
+
+func TestServiceMonitorProcessor_MonitoringGate(t *testing.T) {
+	proc := NewServiceMonitorProcessor()
+	ctx := newTestProcessorContext()
+
+	result, err := proc.Process(ctx, makeServiceMonitorObj("myapp", "default", map[string]interface{}{}))
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertContains(t, result.TemplateContent, `.Values.monitoring | default dict`, "monitoring values lookup")
+	testutil.AssertContains(t, result.TemplateContent, `hasKey $monitoring "enabled"`, "monitoring.enabled gate")
+}
+
+func TestCommonEndpointField(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []interface{}
+		want      string
+	}{
+		{"empty", nil, ""},
+		{"shared", []interface{}{
+			map[string]interface{}{"interval": "15s"},
+			map[string]interface{}{"interval": "15s"},
+		}, "15s"},
+		{"differs", []interface{}{
+			map[string]interface{}{"interval": "15s"},
+			map[string]interface{}{"interval": "60s"},
+		}, ""},
+		{"missing on one", []interface{}{
+			map[string]interface{}{"interval": "15s"},
+			map[string]interface{}{"port": "admin"},
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tt.want, commonEndpointField(tt.endpoints, "interval"))
+		})
+	}
+}
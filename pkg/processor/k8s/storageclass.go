@@ -0,0 +1,157 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// DefaultStorageClassAnnotation marks the StorageClass of PVCs that do not
+// set storageClassName.
+const DefaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// StorageClassProcessor processes Kubernetes StorageClass resources (storage.k8s.io/v1).
+// StorageClass is cluster-scoped and shared by the PVCs of many services: it
+// has no namespace, and it keeps its name, which PVCs reference in
+// storageClassName.
+type StorageClassProcessor struct {
+	processor.BaseProcessor
+}
+
+// NewStorageClassProcessor creates a new StorageClass processor.
+func NewStorageClassProcessor() *StorageClassProcessor {
+	return &StorageClassProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"storageclass",
+			80,
+			schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"},
+		),
+	}
+}
+
+// Process processes a StorageClass resource.
+func (p *StorageClassProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, errors.New("StorageClass object is nil")
+	}
+
+	name := obj.GetName()
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
+	if serviceName == "" {
+		serviceName = processor.SanitizeServiceName(name)
+	}
+
+	values := p.extractValues(obj)
+
+	template := p.generateTemplate(ctx, name, serviceName)
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/storageclass-%s.yaml", processor.SanitizeServiceName(name)),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.storageClasses.%s", serviceName, sanitizeName(name)),
+		Values:          values,
+		Dependencies:    []types.ResourceKey{},
+		Metadata: map[string]interface{}{
+			"name": name,
+		},
+	}, nil
+}
+
+func (p *StorageClassProcessor) extractValues(obj *unstructured.Unstructured) map[string]interface{} {
+	values := map[string]interface{}{
+		"enabled": true,
+	}
+
+	// The default class annotation becomes a toggle: which class is the
+	// default differs between clusters.
+	annotations := make(map[string]interface{})
+	for k, v := range obj.GetAnnotations() {
+		if k == DefaultStorageClassAnnotation {
+			values["isDefault"] = v == "true"
+			continue
+		}
+		annotations[k] = v
+	}
+	if len(annotations) > 0 {
+		values["annotations"] = annotations
+	}
+
+	if provisioner, ok, _ := unstructured.NestedString(obj.Object, "provisioner"); ok {
+		values["provisioner"] = provisioner
+	}
+	if parameters, ok, _ := unstructured.NestedMap(obj.Object, "parameters"); ok && len(parameters) > 0 {
+		values["parameters"] = parameters
+	}
+	if reclaimPolicy, ok, _ := unstructured.NestedString(obj.Object, "reclaimPolicy"); ok && reclaimPolicy != "" {
+		values["reclaimPolicy"] = reclaimPolicy
+	}
+	if bindingMode, ok, _ := unstructured.NestedString(obj.Object, "volumeBindingMode"); ok && bindingMode != "" {
+		values["volumeBindingMode"] = bindingMode
+	}
+	if expansion, ok, _ := unstructured.NestedBool(obj.Object, "allowVolumeExpansion"); ok {
+		values["allowVolumeExpansion"] = expansion
+	}
+	if mountOptions, ok, _ := unstructured.NestedSlice(obj.Object, "mountOptions"); ok && len(mountOptions) > 0 {
+		values["mountOptions"] = mountOptions
+	}
+	if topologies, ok, _ := unstructured.NestedSlice(obj.Object, "allowedTopologies"); ok && len(topologies) > 0 {
+		values["allowedTopologies"] = topologies
+	}
+
+	return values
+}
+
+func (p *StorageClassProcessor) generateTemplate(ctx processor.Context, name, serviceName string) string {
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- $sc := $svc.storageClasses.%s -}}
+{{- if $sc.enabled }}
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: %s
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+    app.kubernetes.io/component: %s
+  {{- if or $sc.annotations $sc.isDefault }}
+  annotations:
+    {{- with $sc.annotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- if $sc.isDefault }}
+    %s: "true"
+    {{- end }}
+  {{- end }}
+provisioner: {{ $sc.provisioner }}
+{{- with $sc.parameters }}
+parameters:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with $sc.reclaimPolicy }}
+reclaimPolicy: {{ . }}
+{{- end }}
+{{- with $sc.volumeBindingMode }}
+volumeBindingMode: {{ . }}
+{{- end }}
+{{- if hasKey $sc "allowVolumeExpansion" }}
+allowVolumeExpansion: {{ $sc.allowVolumeExpansion }}
+{{- end }}
+{{- with $sc.mountOptions }}
+mountOptions:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- with $sc.allowedTopologies }}
+allowedTopologies:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end }}
+{{- end }}
+`, serviceName, sanitizeName(name), name, ctx.ChartName, serviceName, DefaultStorageClassAnnotation)
+}
@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+)
+
+func makeStorageClassObj(annotations map[string]interface{}, extra map[string]interface{}) *unstructured.Unstructured {
+	metadata := map[string]interface{}{"name": "fast-ssd"}
+	if annotations != nil {
+		metadata["annotations"] = annotations
+	}
+	obj := map[string]interface{}{
+		"apiVersion":  "storage.k8s.io/v1",
+		"kind":        "StorageClass",
+		"metadata":    metadata,
+		"provisioner": "ebs.csi.aws.com",
+	}
+	for k, v := range extra {
+		obj[k] = v
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func TestNewStorageClassProcessor(t *testing.T) {
+	p := NewStorageClassProcessor()
+	testutil.AssertEqual(t, "storageclass", p.Name())
+	testutil.AssertEqual(t, 80, p.Priority())
+
+	gvks := p.Supports()
+	testutil.AssertEqual(t, 1, len(gvks))
+	testutil.AssertEqual(t, schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"}, gvks[0])
+}
+
+func TestProcessStorageClass_ExtractsValues(t *testing.T) {
+	p := NewStorageClassProcessor()
+	obj := makeStorageClassObj(map[string]interface{}{
+		DefaultStorageClassAnnotation: "true",
+		"team":                        "storage",
+	}, map[string]interface{}{
+		"parameters":           map[string]interface{}{"type": "gp3", "iops": "3000"},
+		"reclaimPolicy":        "Retain",
+		"volumeBindingMode":    "WaitForFirstConsumer",
+		"allowVolumeExpansion": false,
+		"mountOptions":         []interface{}{"noatime"},
+	})
+
+	result, err := p.Process(newTestProcessorContext(), obj)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "services.fastSsd.storageClasses.fastSsd", result.ValuesPath)
+	testutil.AssertEqual(t, "templates/storageclass-fastSsd.yaml", result.TemplatePath)
+
+	values := result.Values
+	testutil.AssertEqual(t, true, values["enabled"])
+	testutil.AssertEqual(t, true, values["isDefault"])
+	testutil.AssertEqual(t, "ebs.csi.aws.com", values["provisioner"])
+	testutil.AssertEqual(t, "gp3", values["parameters"].(map[string]interface{})["type"])
+	testutil.AssertEqual(t, "Retain", values["reclaimPolicy"])
+	testutil.AssertEqual(t, "WaitForFirstConsumer", values["volumeBindingMode"])
+	testutil.AssertEqual(t, false, values["allowVolumeExpansion"])
+	annotations := values["annotations"].(map[string]interface{})
+	if _, ok := annotations[DefaultStorageClassAnnotation]; ok || annotations["team"] != "storage" {
+		t.Errorf("the default class annotation should become isDefault, got %v", annotations)
+	}
+}
+
+func TestProcessStorageClass_Template(t *testing.T) {
+	p := NewStorageClassProcessor()
+	result, err := p.Process(newTestProcessorContext(), makeStorageClassObj(nil, nil))
+	testutil.AssertNoError(t, err)
+
+	if _, ok := result.Values["isDefault"]; ok {
+		t.Error("isDefault should be set only from the default class annotation")
+	}
+	for _, want := range []string{
+		"{{- $sc := $svc.storageClasses.fastSsd -}}",
+		"  name: fast-ssd\n",
+		"provisioner: {{ $sc.provisioner }}",
+		`storageclass.kubernetes.io/is-default-class: "true"`,
+		`{{- if hasKey $sc "allowVolumeExpansion" }}`,
+	} {
+		testutil.AssertContains(t, result.TemplateContent, want)
+	}
+	if strings.Contains(result.TemplateContent, "namespace:") {
+		t.Error("a StorageClass is cluster-scoped and should have no namespace")
+	}
+}
@@ -33,7 +33,7 @@ func (p *TLSRouteProcessor) Process(ctx processor.Context, obj *unstructured.Uns
 		return nil, errors.New("TLSRoute object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
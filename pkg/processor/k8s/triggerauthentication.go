@@ -32,7 +32,7 @@ func (p *TriggerAuthenticationProcessor) Process(ctx processor.Context, obj *uns
 		return nil, errors.New("TriggerAuthentication object is nil")
 	}
 
-	serviceName := processor.ServiceNameFromResource(obj)
+	serviceName := processor.ResolveServiceName(ctx, obj)
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
@@ -0,0 +1,212 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// istioNetworkingGroup is the API group of Istio traffic management resources.
+const istioNetworkingGroup = "networking.istio.io"
+
+// istioGVKs returns the served versions of an Istio networking kind.
+func istioGVKs(kind string) []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{
+		{Group: istioNetworkingGroup, Version: "v1", Kind: kind},
+		{Group: istioNetworkingGroup, Version: "v1beta1", Kind: kind},
+		{Group: istioNetworkingGroup, Version: "v1alpha3", Kind: kind},
+	}
+}
+
+// VirtualServiceProcessor processes Istio VirtualService resources.
+type VirtualServiceProcessor struct {
+	processor.BaseProcessor
+}
+
+// NewVirtualServiceProcessor creates a new VirtualService processor.
+func NewVirtualServiceProcessor() *VirtualServiceProcessor {
+	return &VirtualServiceProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			"virtualservice",
+			70,
+			istioGVKs("VirtualService")...,
+		),
+	}
+}
+
+// Process processes a VirtualService resource.
+func (p *VirtualServiceProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, errors.New("VirtualService object is nil")
+	}
+
+	serviceName := processor.ResolveServiceName(ctx, obj)
+	if serviceName == "" {
+		serviceName = obj.GetName()
+	}
+
+	name := obj.GetName()
+	namespace := obj.GetNamespace()
+
+	values, deps := p.extractValues(obj)
+	template := p.generateTemplate(ctx, serviceName, obj.GetAPIVersion())
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/%s-virtualservice.yaml", serviceName),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.virtualService", serviceName),
+		Values:          values,
+		Dependencies:    deps,
+		Metadata: map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+	}, nil
+}
+
+func (p *VirtualServiceProcessor) extractValues(obj *unstructured.Unstructured) (map[string]interface{}, []types.ResourceKey) {
+	values := make(map[string]interface{})
+	var deps []types.ResourceKey
+	namespace := obj.GetNamespace()
+
+	// Preserve full spec for pipeline integration
+	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec"); ok {
+		values["spec"] = spec
+	}
+
+	// Extract hosts
+	if hosts, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "hosts"); ok && len(hosts) > 0 {
+		values["hosts"] = hosts
+	}
+
+	// Extract gateways and create dependencies to Istio Gateways.
+	// The reserved name "mesh" stands for all sidecars, not a Gateway.
+	if gateways, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "gateways"); ok && len(gateways) > 0 {
+		values["gateways"] = gateways
+
+		for _, g := range gateways {
+			gw, _ := g.(string)
+			if gw == "" || gw == "mesh" {
+				continue
+			}
+			gwNamespace, gwName := namespace, gw
+			if i := strings.Index(gw, "/"); i >= 0 {
+				gwNamespace, gwName = gw[:i], gw[i+1:]
+			}
+			deps = append(deps, types.ResourceKey{
+				GVK:       schema.GroupVersionKind{Group: istioNetworkingGroup, Version: "v1", Kind: "Gateway"},
+				Namespace: gwNamespace,
+				Name:      gwName,
+			})
+		}
+	}
+
+	// Extract routes; destinations of every route become Service dependencies.
+	for _, protocol := range []string{"http", "tcp", "tls"} {
+		routes, ok, _ := unstructured.NestedSlice(obj.Object, "spec", protocol)
+		if !ok || len(routes) == 0 {
+			continue
+		}
+		values[protocol] = routes
+
+		for _, r := range routes {
+			route, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			for _, d := range destinations {
+				dest, ok := d.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(dest, "destination", "host")
+				if svcName, svcNamespace, ok := istioHostToService(host, namespace); ok {
+					deps = append(deps, types.ResourceKey{
+						GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+						Namespace: svcNamespace,
+						Name:      svcName,
+					})
+				}
+			}
+		}
+	}
+
+	// Extract exportTo
+	if exportTo, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "exportTo"); ok && len(exportTo) > 0 {
+		values["exportTo"] = exportTo
+	}
+
+	return values, deps
+}
+
+func (p *VirtualServiceProcessor) generateTemplate(ctx processor.Context, serviceName, apiVersion string) string {
+	sanitized := processor.SanitizeServiceName(serviceName)
+
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.virtualService }}
+apiVersion: %s
+kind: VirtualService
+metadata:
+  name: %s
+  namespace: {{ $.Release.Namespace }}
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+spec:
+  {{- with .hosts }}
+  hosts:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .gateways }}
+  gateways:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .http }}
+  http:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .tcp }}
+  tcp:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .tls }}
+  tls:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .exportTo }}
+  exportTo:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`, sanitized, apiVersion, serviceName, ctx.ChartName)
+}
+
+// istioHostToService resolves an Istio destination host to a Service name
+// and namespace. Short names ("reviews") resolve in the referring namespace,
+// "reviews.prod.svc.cluster.local" names the namespace. Istio treats any other
+// dotted host as an external FQDN, so wildcard and external hosts do not resolve.
+func istioHostToService(host, namespace string) (string, string, bool) {
+	if host == "" || strings.Contains(host, "*") {
+		return "", "", false
+	}
+
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1:
+		return parts[0], namespace, true
+	case len(parts) >= 3 && parts[2] == "svc":
+		return parts[0], parts[1], true
+	default:
+		return "", "", false
+	}
+}
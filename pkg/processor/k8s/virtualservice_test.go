@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func makeIstioObj(apiVersion, kind, name, namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestVirtualServiceProcessor_NameAndSupports(t *testing.T) {
+	proc := NewVirtualServiceProcessor()
+	testutil.AssertEqual(t, "virtualservice", proc.Name(), "processor name")
+
+	gvks := proc.Supports()
+	if len(gvks) != 3 {
+		t.Fatalf("expected 3 supported GVKs, got %d", len(gvks))
+	}
+	for _, gvk := range gvks {
+		if gvk.Group != "networking.istio.io" || gvk.Kind != "VirtualService" {
+			t.Errorf("unexpected GVK %v", gvk)
+		}
+	}
+}
+
+func TestVirtualServiceProcessor_Process(t *testing.T) {
+	proc := NewVirtualServiceProcessor()
+	ctx := newTestProcessorContext()
+
+	obj := makeIstioObj("networking.istio.io/v1beta1", "VirtualService", "reviews", "default", map[string]interface{}{
+		"hosts":    []interface{}{"reviews.example.com"},
+		"gateways": []interface{}{"mesh", "istio-system/ingress"},
+		"http": []interface{}{
+			map[string]interface{}{
+				"route": []interface{}{
+					map[string]interface{}{"destination": map[string]interface{}{"host": "reviews", "subset": "v1"}, "weight": int64(90)},
+					map[string]interface{}{"destination": map[string]interface{}{"host": "ratings.prod.svc.cluster.local"}, "weight": int64(10)},
+					map[string]interface{}{"destination": map[string]interface{}{"host": "api.example.com"}},
+				},
+			},
+		},
+	})
+
+	result, err := proc.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "templates/reviews-virtualservice.yaml", result.TemplatePath)
+	testutil.AssertEqual(t, "services.reviews.virtualService", result.ValuesPath)
+
+	if _, ok := result.Values["hosts"]; !ok {
+		t.Error("expected hosts in values")
+	}
+	if _, ok := result.Values["http"]; !ok {
+		t.Error("expected http routes in values")
+	}
+	if _, ok := result.Values["tcp"]; ok {
+		t.Error("expected no tcp routes in values")
+	}
+
+	expected := []types.ResourceKey{
+		{GVK: schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1", Kind: "Gateway"}, Namespace: "istio-system", Name: "ingress"},
+		{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Service"}, Namespace: "default", Name: "reviews"},
+		{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Service"}, Namespace: "prod", Name: "ratings"},
+	}
+	testutil.AssertEqual(t, expected, result.Dependencies, "dependencies")
+
+	// The original apiVersion is kept so older Istio releases keep working.
+	if !strings.Contains(result.TemplateContent, "apiVersion: networking.istio.io/v1beta1") {
+		t.Error("template should keep the original apiVersion")
+	}
+	for _, field := range []string{"$svc.virtualService", "hosts:", "gateways:", "http:", "tcp:", "tls:"} {
+		if !strings.Contains(result.TemplateContent, field) {
+			t.Errorf("template should reference %q", field)
+		}
+	}
+}
+
+func TestVirtualServiceProcessor_NilObject(t *testing.T) {
+	_, err := NewVirtualServiceProcessor().Process(newTestProcessorContext(), nil)
+	if err == nil {
+		t.Error("expected error for nil object")
+	}
+}
+
+func TestIstioHostToService(t *testing.T) {
+	tests := []struct {
+		host      string
+		name      string
+		namespace string
+		ok        bool
+	}{
+		{host: "reviews", name: "reviews", namespace: "default", ok: true},
+		{host: "reviews.prod.svc.cluster.local", name: "reviews", namespace: "prod", ok: true},
+		{host: "reviews.prod.svc", name: "reviews", namespace: "prod", ok: true},
+		{host: "reviews.prod"},
+		{host: "api.example.com"},
+		{host: "*.example.com"},
+		{host: ""},
+	}
+
+	for _, tt := range tests {
+		name, namespace, ok := istioHostToService(tt.host, "default")
+		if ok != tt.ok || name != tt.name || namespace != tt.namespace {
+			t.Errorf("istioHostToService(%q) = %q, %q, %v", tt.host, name, namespace, ok)
+		}
+	}
+}
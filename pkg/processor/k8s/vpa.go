@@ -33,7 +33,7 @@ func (p *VPAProcessor) Process(ctx processor.Context, obj *unstructured.Unstruct
 		return nil, errors.New("VPA object is nil")
 	}
 
-	serviceName := processor.SanitizeServiceName(processor.ServiceNameFromResource(obj))
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
 	if serviceName == "" {
 		serviceName = obj.GetName()
 	}
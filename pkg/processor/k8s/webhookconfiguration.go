@@ -0,0 +1,270 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+// InjectCAFromAnnotation makes the cert-manager CA injector fill the
+// caBundle of webhooks from a Certificate, given as namespace/name.
+const InjectCAFromAnnotation = "cert-manager.io/inject-ca-from"
+
+// WebhookConfigurationProcessor processes admission webhook configurations
+// (ValidatingWebhookConfiguration, MutatingWebhookConfiguration).
+type WebhookConfigurationProcessor struct {
+	processor.BaseProcessor
+	kind string
+}
+
+// newWebhookConfigurationProcessor creates a processor for the given webhook
+// configuration kind.
+func newWebhookConfigurationProcessor(kind string) *WebhookConfigurationProcessor {
+	return &WebhookConfigurationProcessor{
+		BaseProcessor: processor.NewBaseProcessor(
+			strings.ToLower(kind),
+			80,
+			schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: kind},
+		),
+		kind: kind,
+	}
+}
+
+// NewValidatingWebhookConfigurationProcessor creates a processor for
+// ValidatingWebhookConfiguration.
+func NewValidatingWebhookConfigurationProcessor() *WebhookConfigurationProcessor {
+	return newWebhookConfigurationProcessor("ValidatingWebhookConfiguration")
+}
+
+// NewMutatingWebhookConfigurationProcessor creates a processor for
+// MutatingWebhookConfiguration.
+func NewMutatingWebhookConfigurationProcessor() *WebhookConfigurationProcessor {
+	return newWebhookConfigurationProcessor("MutatingWebhookConfiguration")
+}
+
+// valuesKey returns the values key of the kind: validatingWebhookConfiguration
+// or mutatingWebhookConfiguration.
+func (p *WebhookConfigurationProcessor) valuesKey() string {
+	return strings.ToLower(p.kind[:1]) + p.kind[1:]
+}
+
+// Process processes a webhook configuration.
+func (p *WebhookConfigurationProcessor) Process(ctx processor.Context, obj *unstructured.Unstructured) (*processor.Result, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("%s object is nil", p.kind)
+	}
+
+	serviceName := processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj))
+	if serviceName == "" {
+		serviceName = obj.GetName()
+	}
+
+	values, deps := p.extractValues(ctx, obj)
+	template := p.generateTemplate(ctx, serviceName)
+
+	return &processor.Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    fmt.Sprintf("templates/%s-%s.yaml", serviceName, strings.ToLower(p.kind)),
+		TemplateContent: template,
+		ValuesPath:      fmt.Sprintf("services.%s.%s", serviceName, p.valuesKey()),
+		Values:          values,
+		Dependencies:    deps,
+		Metadata: map[string]interface{}{
+			"name": obj.GetName(),
+			"kind": p.kind,
+		},
+	}, nil
+}
+
+// extractValues returns the values of a webhook configuration and the
+// Services and Certificate it references. caBundle, failurePolicy and
+// namespaceSelector shared by all webhooks are set once for the
+// configuration. References to Services and a Certificate of the input drop
+// their namespace, so that they resolve in the release namespace.
+func (p *WebhookConfigurationProcessor) extractValues(ctx processor.Context, obj *unstructured.Unstructured) (map[string]interface{}, []types.ResourceKey) {
+	values := make(map[string]interface{})
+	var deps []types.ResourceKey
+
+	annotations := make(map[string]interface{})
+	for k, v := range obj.GetAnnotations() {
+		if k != InjectCAFromAnnotation {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) > 0 {
+		values["annotations"] = annotations
+	}
+
+	injectCA := obj.GetAnnotations()[InjectCAFromAnnotation]
+	if namespace, name, ok := strings.Cut(injectCA, "/"); ok && name != "" {
+		key := types.ResourceKey{
+			GVK:       schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"},
+			Namespace: namespace,
+			Name:      name,
+		}
+		certManager := map[string]interface{}{"certificate": name}
+		if _, ok := ctx.AllResources[key]; ok {
+			deps = append(deps, key)
+		} else {
+			certManager["namespace"] = namespace
+		}
+		values["certManager"] = certManager
+	}
+
+	list, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+	webhooks := make([]interface{}, 0, len(list))
+	var caBundle string
+	for _, item := range list {
+		webhook, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entry := make(map[string]interface{}, len(webhook))
+		for k, v := range webhook {
+			if k != "clientConfig" {
+				entry[k] = v
+			}
+		}
+		clientConfig, _ := webhook["clientConfig"].(map[string]interface{})
+		if ca, _ := clientConfig["caBundle"].(string); ca != "" && caBundle == "" {
+			caBundle = ca
+		}
+		if url, ok := clientConfig["url"].(string); ok {
+			entry["url"] = url
+		}
+		if service, ok := clientConfig["service"].(map[string]interface{}); ok {
+			entry["service"] = webhookService(ctx, service, &deps)
+		}
+		webhooks = append(webhooks, entry)
+	}
+
+	// cert-manager injects the CA; a bundle from the source would be stale.
+	if caBundle != "" && injectCA == "" {
+		values["caBundle"] = caBundle
+	}
+	for _, field := range []string{"failurePolicy", "namespaceSelector"} {
+		if shared, ok := sharedWebhookField(webhooks, field); ok {
+			values[field] = shared
+			for _, w := range webhooks {
+				delete(w.(map[string]interface{}), field)
+			}
+		}
+	}
+	values["webhooks"] = webhooks
+
+	return values, deps
+}
+
+// webhookService returns the values of the Service a webhook calls, adding
+// the Service to deps.
+func webhookService(ctx processor.Context, service map[string]interface{}, deps *[]types.ResourceKey) map[string]interface{} {
+	values := make(map[string]interface{})
+	name, _ := service["name"].(string)
+	namespace, _ := service["namespace"].(string)
+	values["name"] = name
+
+	key := types.ResourceKey{
+		GVK:       schema.GroupVersionKind{Version: "v1", Kind: "Service"},
+		Namespace: namespace,
+		Name:      name,
+	}
+	if _, ok := ctx.AllResources[key]; ok {
+		*deps = append(*deps, key)
+	} else if namespace != "" {
+		values["namespace"] = namespace
+	}
+	if path, ok := service["path"].(string); ok {
+		values["path"] = path
+	}
+	if port, ok := toInt64(service["port"]); ok {
+		values["port"] = port
+	}
+	return values
+}
+
+// sharedWebhookField returns the value of field when every webhook sets it to
+// the same value.
+func sharedWebhookField(webhooks []interface{}, field string) (interface{}, bool) {
+	var shared interface{}
+	for i, w := range webhooks {
+		v, ok := w.(map[string]interface{})[field]
+		if !ok {
+			return nil, false
+		}
+		if i == 0 {
+			shared = v
+			continue
+		}
+		if !reflect.DeepEqual(v, shared) {
+			return nil, false
+		}
+	}
+	return shared, shared != nil
+}
+
+func (p *WebhookConfigurationProcessor) generateTemplate(ctx processor.Context, serviceName string) string {
+	fullnameHelper := fmt.Sprintf(`{{ include "%s.fullname" $ }}`, ctx.ChartName)
+
+	return fmt.Sprintf(`{{- $svc := .Values.services.%s -}}
+{{- if $svc.enabled }}
+{{- with $svc.%s }}
+{{- $cfg := . }}
+apiVersion: admissionregistration.k8s.io/v1
+kind: %s
+metadata:
+  name: %s-%s
+  labels:
+    {{- include "%s.labels" $ | nindent 4 }}
+    app.kubernetes.io/component: %s
+  {{- if or .annotations .certManager }}
+  annotations:
+    {{- with .annotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+    {{- with .certManager }}
+    %s: {{ .namespace | default $.Release.Namespace }}/{{ .certificate }}
+    {{- end }}
+  {{- end }}
+webhooks:
+  {{- range .webhooks }}
+  - name: {{ .name }}
+    clientConfig:
+      {{- with $cfg.caBundle }}
+      caBundle: {{ . }}
+      {{- end }}
+      {{- with .url }}
+      url: {{ . | quote }}
+      {{- end }}
+      {{- with .service }}
+      service:
+        name: {{ .name }}
+        namespace: {{ .namespace | default $.Release.Namespace }}
+        {{- with .path }}
+        path: {{ . | quote }}
+        {{- end }}
+        {{- with .port }}
+        port: {{ . }}
+        {{- end }}
+      {{- end }}
+    {{- with .failurePolicy | default $cfg.failurePolicy }}
+    failurePolicy: {{ . }}
+    {{- end }}
+    {{- with .namespaceSelector | default $cfg.namespaceSelector }}
+    namespaceSelector:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with omit . "name" "url" "service" "failurePolicy" "namespaceSelector" }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+`, serviceName, p.valuesKey(), p.kind, fullnameHelper, serviceName, ctx.ChartName, serviceName, InjectCAFromAnnotation)
+}
@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/testutil"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/types"
+)
+
+func makeWebhookConfigurationObj(kind string, annotations map[string]interface{}, webhooks ...interface{}) *unstructured.Unstructured {
+	metadata := map[string]interface{}{
+		"name":   "op-webhooks",
+		"labels": map[string]interface{}{"app": "op"},
+	}
+	if annotations != nil {
+		metadata["annotations"] = annotations
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       kind,
+		"metadata":   metadata,
+		"webhooks":   webhooks,
+	}}
+}
+
+func makeWebhook(name, path string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                    name,
+		"admissionReviewVersions": []interface{}{"v1"},
+		"sideEffects":             "None",
+		"failurePolicy":           "Fail",
+		"namespaceSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"webhooks": "enabled"},
+		},
+		"clientConfig": map[string]interface{}{
+			"caBundle": "Cg==",
+			"service": map[string]interface{}{
+				"name":      "op-webhook",
+				"namespace": "op-system",
+				"path":      path,
+				"port":      int64(443),
+			},
+		},
+	}
+}
+
+func TestWebhookConfigurationProcessor_Supports(t *testing.T) {
+	for _, tc := range []struct {
+		p    *WebhookConfigurationProcessor
+		kind string
+		key  string
+	}{
+		{NewValidatingWebhookConfigurationProcessor(), "ValidatingWebhookConfiguration", "validatingWebhookConfiguration"},
+		{NewMutatingWebhookConfigurationProcessor(), "MutatingWebhookConfiguration", "mutatingWebhookConfiguration"},
+	} {
+		gvks := tc.p.Supports()
+		testutil.AssertEqual(t, 1, len(gvks))
+		testutil.AssertEqual(t, schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: tc.kind}, gvks[0])
+		testutil.AssertEqual(t, strings.ToLower(tc.kind), tc.p.Name())
+
+		result, err := tc.p.Process(newTestProcessorContext(), makeWebhookConfigurationObj(tc.kind, nil, makeWebhook("a.example.com", "/a")))
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, "services.op."+tc.key, result.ValuesPath)
+		testutil.AssertContains(t, result.TemplateContent, "kind: "+tc.kind)
+	}
+}
+
+func TestWebhookConfigurationProcessor_SharedFields(t *testing.T) {
+	p := NewValidatingWebhookConfigurationProcessor()
+	second := makeWebhook("b.example.com", "/b")
+	second["failurePolicy"] = "Ignore"
+	obj := makeWebhookConfigurationObj("ValidatingWebhookConfiguration", nil, makeWebhook("a.example.com", "/a"), second)
+
+	result, err := p.Process(newTestProcessorContext(), obj)
+	testutil.AssertNoError(t, err)
+
+	values := result.Values
+	testutil.AssertEqual(t, "Cg==", values["caBundle"])
+	if _, ok := values["failurePolicy"]; ok {
+		t.Error("differing failurePolicy should stay on the webhooks")
+	}
+	if _, ok := values["namespaceSelector"].(map[string]interface{}); !ok {
+		t.Errorf("shared namespaceSelector should be set once, got %v", values)
+	}
+
+	webhooks := values["webhooks"].([]interface{})
+	testutil.AssertEqual(t, 2, len(webhooks))
+	first := webhooks[0].(map[string]interface{})
+	testutil.AssertEqual(t, "Fail", first["failurePolicy"])
+	if _, ok := first["namespaceSelector"]; ok {
+		t.Error("shared namespaceSelector should be removed from the webhooks")
+	}
+	if _, ok := first["clientConfig"]; ok {
+		t.Error("clientConfig should be split into service and url")
+	}
+	service := first["service"].(map[string]interface{})
+	testutil.AssertEqual(t, "op-webhook", service["name"])
+	testutil.AssertEqual(t, "op-system", service["namespace"])
+	testutil.AssertEqual(t, "/a", service["path"])
+	testutil.AssertEqual(t, int64(443), service["port"])
+	testutil.AssertEqual(t, 0, len(result.Dependencies))
+}
+
+func TestWebhookConfigurationProcessor_InputReferences(t *testing.T) {
+	p := NewMutatingWebhookConfigurationProcessor()
+	serviceKey := types.ResourceKey{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Service"}, Namespace: "op-system", Name: "op-webhook"}
+	certKey := types.ResourceKey{GVK: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, Namespace: "op-system", Name: "op-serving-cert"}
+	ctx := processor.Context{
+		ChartName:    "test-chart",
+		AllResources: map[types.ResourceKey]*types.ExtractedResource{serviceKey: {}, certKey: {}},
+	}
+	external := map[string]interface{}{
+		"name":         "ext.example.com",
+		"clientConfig": map[string]interface{}{"url": "https://hooks.example.com/mutate"},
+	}
+	obj := makeWebhookConfigurationObj("MutatingWebhookConfiguration", map[string]interface{}{
+		InjectCAFromAnnotation: "op-system/op-serving-cert",
+		"team":                 "platform",
+	}, makeWebhook("a.example.com", "/a"), external)
+
+	result, err := p.Process(ctx, obj)
+	testutil.AssertNoError(t, err)
+
+	values := result.Values
+	if _, ok := values["caBundle"]; ok {
+		t.Error("caBundle should be left to the cert-manager CA injector")
+	}
+	certManager := values["certManager"].(map[string]interface{})
+	testutil.AssertEqual(t, "op-serving-cert", certManager["certificate"])
+	if _, ok := certManager["namespace"]; ok {
+		t.Error("a Certificate of the input should resolve in the release namespace")
+	}
+	annotations := values["annotations"].(map[string]interface{})
+	if _, ok := annotations[InjectCAFromAnnotation]; ok || annotations["team"] != "platform" {
+		t.Errorf("unexpected annotations: %v", annotations)
+	}
+
+	webhooks := values["webhooks"].([]interface{})
+	service := webhooks[0].(map[string]interface{})["service"].(map[string]interface{})
+	if _, ok := service["namespace"]; ok {
+		t.Error("a Service of the input should resolve in the release namespace")
+	}
+	testutil.AssertEqual(t, "https://hooks.example.com/mutate", webhooks[1].(map[string]interface{})["url"])
+	testutil.AssertEqual(t, 2, len(result.Dependencies))
+	testutil.AssertEqual(t, certKey, result.Dependencies[0])
+	testutil.AssertEqual(t, serviceKey, result.Dependencies[1])
+}
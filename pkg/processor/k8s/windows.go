@@ -98,7 +98,7 @@ func (p *WindowsContainerProcessor) Process(ctx processor.Context, obj *unstruct
 
 	return &processor.Result{
 		Processed:   true,
-		ServiceName: processor.SanitizeServiceName(processor.ServiceNameFromResource(obj)),
+		ServiceName: processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj)),
 		Values:      values,
 	}, nil
 }
@@ -72,7 +72,7 @@ func (p *WorkloadIdentityProcessor) Process(ctx processor.Context, obj *unstruct
 
 	return &processor.Result{
 		Processed:   true,
-		ServiceName: processor.SanitizeServiceName(processor.ServiceNameFromResource(obj)),
+		ServiceName: processor.SanitizeServiceName(processor.ResolveServiceName(ctx, obj)),
 		Values:      values,
 	}, nil
 }
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NodeOSLabel is the well-known node label with the operating system of the
+// node, "linux" or "windows".
+const NodeOSLabel = "kubernetes.io/os"
+
+// PodNodeOS returns the operating system the pods of a workload are
+// scheduled to, from a NodeOSLabel nodeSelector or from a required node
+// affinity term that allows exactly one value of the label. It returns ""
+// when the pods may run on nodes of any OS or obj has no pod spec.
+func PodNodeOS(obj *unstructured.Unstructured) string {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return ""
+	}
+	nodeSelector, _, _ := unstructured.NestedStringMap(obj.Object, append(specPath, "nodeSelector")...)
+	if os := nodeSelector[NodeOSLabel]; os != "" {
+		return os
+	}
+
+	terms, _, _ := unstructured.NestedSlice(obj.Object, append(specPath,
+		"affinity", "nodeAffinity", "requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")...)
+	// Terms are ORed: the pods are bound to one OS only if every term is.
+	os := ""
+	for _, t := range terms {
+		term, ok := t.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		termOS := nodeSelectorTermOS(term)
+		if termOS == "" || (os != "" && termOS != os) {
+			return ""
+		}
+		os = termOS
+	}
+	return os
+}
+
+// nodeSelectorTermOS returns the single OS a node selector term allows, or
+// "" if it allows any or several.
+func nodeSelectorTermOS(term map[string]interface{}) string {
+	expressions, _, _ := unstructured.NestedSlice(term, "matchExpressions")
+	for _, e := range expressions {
+		expression, ok := e.(map[string]interface{})
+		if !ok || expression["key"] != NodeOSLabel || expression["operator"] != "In" {
+			continue
+		}
+		if values, ok := expression["values"].([]interface{}); ok && len(values) == 1 {
+			os, _ := values[0].(string)
+			return os
+		}
+	}
+	return ""
+}
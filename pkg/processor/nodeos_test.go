@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func osAffinity(values ...interface{}) map[string]interface{} {
+	return map[string]interface{}{"nodeAffinity": map[string]interface{}{
+		"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+			"nodeSelectorTerms": []interface{}{map[string]interface{}{
+				"matchExpressions": []interface{}{map[string]interface{}{
+					"key": NodeOSLabel, "operator": "In", "values": values,
+				}},
+			}},
+		},
+	}}
+}
+
+func TestPodNodeOS(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want string
+	}{
+		{"nodeSelector", withPodSpec(workload("Deployment", nil), map[string]interface{}{
+			"nodeSelector": map[string]interface{}{NodeOSLabel: "windows"},
+		}), "windows"},
+		{"node affinity", withPodSpec(workload("StatefulSet", nil), map[string]interface{}{
+			"affinity": osAffinity("linux"),
+		}), "linux"},
+		{"node affinity with several values", withPodSpec(workload("Deployment", nil), map[string]interface{}{
+			"affinity": osAffinity("linux", "windows"),
+		}), ""},
+		{"other nodeSelector", withPodSpec(workload("Deployment", nil), map[string]interface{}{
+			"nodeSelector": map[string]interface{}{"node-role": "worker"},
+		}), ""},
+		{"unset", workload("Deployment", nil), ""},
+		{"no pod spec", &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Service"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := PodNodeOS(tt.obj); got != tt.want {
+			t.Errorf("%s: PodNodeOS = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Passthrough reports whether resources of kind are copied into the chart
+// verbatim instead of templated: the kind is listed in
+// OptionPassthroughKinds, or OptionTemplateKinds is set and does not list
+// it. Kinds match case-insensitively.
+func Passthrough(ctx Context, kind string) bool {
+	passthrough, _ := ctx.Options[OptionPassthroughKinds].([]string)
+	if containsKind(passthrough, kind) {
+		return true
+	}
+	template, _ := ctx.Options[OptionTemplateKinds].([]string)
+	return len(template) > 0 && !containsKind(template, kind)
+}
+
+// containsKind reports whether kinds lists kind, ignoring case.
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// processPassthrough copies a resource into the chart as-is. The manifest
+// keeps its name, namespace and labels; the template only gates it on the
+// enabled value of the resource and keeps "{{" in it literal.
+//
+// The values are keyed the way the generator writes them: under
+// <PluralValuesKey>.<SanitizeValuesKey(name)> for ValuesByName kinds and for
+// the resources of a kind a service has several of, and under <ValuesKey>
+// otherwise, so the guard looks up the resource by name first.
+func (r *Registry) processPassthrough(ctx Context, obj *unstructured.Unstructured) (*Result, error) {
+	serviceName := SanitizeServiceName(ResolveServiceName(ctx, obj))
+	kind := obj.GetKind()
+	valuesKind := ValuesKind(obj.GroupVersionKind(), obj)
+
+	manifest, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s %s: %w", kind, obj.GetName(), err)
+	}
+	svcPath := "services." + serviceName
+	byName := fmt.Sprintf("index (.Values.%s.%s | default dict) %q", svcPath, PluralValuesKey(valuesKind), SanitizeValuesKey(obj.GetName()))
+	valuesPath := svcPath + "." + PluralValuesKey(valuesKind) + "." + SanitizeValuesKey(obj.GetName())
+	if !ValuesByName(valuesKind) {
+		byName += " | default .Values." + svcPath + "." + ValuesKey(valuesKind)
+		valuesPath = svcPath + "." + ValuesKey(valuesKind)
+	}
+
+	var template strings.Builder
+	template.WriteString("{{- $values := " + byName + " | default dict }}\n")
+	template.WriteString("{{- if or (not (hasKey $values \"enabled\")) $values.enabled }}\n")
+	template.WriteString(strings.ReplaceAll(string(manifest), "{{", `{{ "{{" }}`))
+	template.WriteString("{{- end }}\n")
+
+	return &Result{
+		Processed:       true,
+		ServiceName:     serviceName,
+		TemplatePath:    TemplatePathForResource(kind, obj.GetName(), obj.GetNamespace()),
+		TemplateContent: template.String(),
+		ValuesPath:      valuesPath,
+		Values:          map[string]interface{}{"enabled": true},
+		Processor:       PassthroughProcessorName,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPassthrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		passthrough []string
+		template    []string
+		kind        string
+		want        bool
+	}{
+		{"no policy", nil, nil, "Deployment", false},
+		{"passed through", []string{"ClusterRole"}, nil, "ClusterRole", true},
+		{"case-insensitive", []string{"customresourcedefinition"}, nil, "CustomResourceDefinition", true},
+		{"not listed", []string{"ClusterRole"}, nil, "Deployment", false},
+		{"templated", nil, []string{"Deployment", "Service"}, "Service", false},
+		{"not templated", nil, []string{"Deployment", "Service"}, "Role", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := Context{Options: map[string]interface{}{
+				OptionPassthroughKinds: tt.passthrough,
+				OptionTemplateKinds:    tt.template,
+			}}
+			if got := Passthrough(ctx, tt.kind); got != tt.want {
+				t.Errorf("Passthrough(%s) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_ProcessPassthrough(t *testing.T) {
+	r := NewRegistry()
+	r.Register(newStub("configmap", 10, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}))
+
+	obj := makeObj("ConfigMap", "settings", "prod")
+	obj.SetLabels(map[string]string{"app": "web"})
+	obj.Object["data"] = map[string]interface{}{"greeting": "{{ hello }}"}
+	ctx := Context{ChartName: "myapp", ServiceName: "web", Options: map[string]interface{}{
+		OptionPassthroughKinds: []string{"ConfigMap"},
+	}}
+
+	result, err := r.Process(ctx, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ServiceName != "web" {
+		t.Errorf("expected the registered processor to be skipped, got service %q", result.ServiceName)
+	}
+	want := "{{- $values := index (.Values.services.web.configMaps | default dict) \"settings\" | default dict }}\n" +
+		"{{- if or (not (hasKey $values \"enabled\")) $values.enabled }}\n" +
+		"apiVersion: v1\n" +
+		"data:\n" +
+		"  greeting: '{{ \"{{\" }} hello }}'\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  labels:\n" +
+		"    app: web\n" +
+		"  name: settings\n" +
+		"  namespace: prod\n" +
+		"{{- end }}\n"
+	if result.TemplateContent != want {
+		t.Errorf("unexpected template:\n%s\nwant:\n%s", result.TemplateContent, want)
+	}
+	if result.TemplatePath != "templates/configmap-settings.yaml" || result.ValuesPath != "services.web.configMaps.settings" {
+		t.Errorf("unexpected paths %q, %q", result.TemplatePath, result.ValuesPath)
+	}
+	if len(result.Values) != 1 || result.Values["enabled"] != true {
+		t.Errorf("unexpected values %v", result.Values)
+	}
+	if strings.Contains(result.TemplateContent, "fullname") {
+		t.Error("passed-through resources should keep their names")
+	}
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PriorityTier is one of the default PriorityClasses generated for a chart.
+type PriorityTier struct {
+	// Name is the tier name, appended to the chart name to name the
+	// PriorityClass.
+	Name string
+
+	// Value is the priority of pods in the tier.
+	Value int64
+
+	// PreemptionPolicy is PreemptLowerPriority or Never.
+	PreemptionPolicy string
+
+	// Description is the PriorityClass description.
+	Description string
+}
+
+// PriorityTiers are the default PriorityClass tiers, from the highest
+// priority to the lowest. Batch pods wait for capacity instead of evicting
+// other pods.
+var PriorityTiers = []PriorityTier{
+	{
+		Name:             "critical",
+		Value:            1000000,
+		PreemptionPolicy: "PreemptLowerPriority",
+		Description:      "Stateful and per-node workloads",
+	},
+	{
+		Name:             "standard",
+		Value:            100000,
+		PreemptionPolicy: "PreemptLowerPriority",
+		Description:      "Stateless services",
+	},
+	{
+		Name:             "batch",
+		Value:            1000,
+		PreemptionPolicy: "Never",
+		Description:      "Jobs and CronJobs",
+	},
+}
+
+// WorkloadPriorityTier returns the name of the PriorityTiers tier of a
+// workload by its kind: critical for StatefulSets and DaemonSets, standard
+// for Deployments and batch for Jobs and CronJobs. It returns "" for other
+// kinds.
+func WorkloadPriorityTier(obj *unstructured.Unstructured) string {
+	switch obj.GetKind() {
+	case "StatefulSet", "DaemonSet":
+		return "critical"
+	case "Deployment":
+		return "standard"
+	case "Job", "CronJob":
+		return "batch"
+	}
+	return ""
+}
+
+// PodPriorityClassName returns the priorityClassName of the pods of a
+// workload, or "" if it has none or obj has no pod spec.
+func PodPriorityClassName(obj *unstructured.Unstructured) string {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return ""
+	}
+	name, _, _ := unstructured.NestedString(obj.Object, append(specPath, "priorityClassName")...)
+	return name
+}
+
+// SetPodPriorityClassName sets the priorityClassName of the pods of a
+// workload.
+func SetPodPriorityClassName(obj *unstructured.Unstructured, name string) error {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return nil
+	}
+	return unstructured.SetNestedField(obj.Object, name, append(specPath, "priorityClassName")...)
+}
+
+// NewPriorityClass returns the scheduling.k8s.io/v1 PriorityClass name of a
+// tier. It is never the global default.
+func NewPriorityClass(name string, tier PriorityTier) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "scheduling.k8s.io/v1",
+		"kind":       "PriorityClass",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"value":            tier.Value,
+		"globalDefault":    false,
+		"preemptionPolicy": tier.PreemptionPolicy,
+		"description":      tier.Description,
+	}}
+}
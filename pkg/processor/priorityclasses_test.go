@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestWorkloadPriorityTier(t *testing.T) {
+	tests := []struct {
+		kind string
+		want string
+	}{
+		{"StatefulSet", "critical"},
+		{"DaemonSet", "critical"},
+		{"Deployment", "standard"},
+		{"Job", "batch"},
+		{"CronJob", "batch"},
+		{"Service", ""},
+	}
+	for _, tt := range tests {
+		if got := WorkloadPriorityTier(workload(tt.kind, nil)); got != tt.want {
+			t.Errorf("%s: WorkloadPriorityTier = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestSetPodPriorityClassName(t *testing.T) {
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "CronJob"}}
+	if got := PodPriorityClassName(cronJob); got != "" {
+		t.Errorf("PodPriorityClassName = %q, want none", got)
+	}
+	if err := SetPodPriorityClassName(cronJob, "app-batch"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := unstructured.NestedString(cronJob.Object, "spec", "jobTemplate", "spec", "template", "spec", "priorityClassName"); got != "app-batch" {
+		t.Errorf("priorityClassName = %q", got)
+	}
+	if got := PodPriorityClassName(cronJob); got != "app-batch" {
+		t.Errorf("PodPriorityClassName = %q", got)
+	}
+}
+
+func TestNewPriorityClass(t *testing.T) {
+	pc := NewPriorityClass("app-batch", PriorityTiers[2])
+
+	if pc.GetKind() != "PriorityClass" || pc.GetName() != "app-batch" || pc.GetNamespace() != "" {
+		t.Errorf("unexpected PriorityClass: %v", pc.Object)
+	}
+	if v, _, _ := unstructured.NestedInt64(pc.Object, "value"); v != PriorityTiers[2].Value {
+		t.Errorf("value = %d", v)
+	}
+	if p, _, _ := unstructured.NestedString(pc.Object, "preemptionPolicy"); p != "Never" {
+		t.Errorf("preemptionPolicy = %q, want Never for batch", p)
+	}
+	if d, _, _ := unstructured.NestedBool(pc.Object, "globalDefault"); d {
+		t.Error("a generated PriorityClass must not be the global default")
+	}
+}
@@ -43,6 +43,29 @@ type Context struct {
 	Options map[string]interface{}
 }
 
+// OptionInferHooks is the Options key (bool) that turns run-once migration
+// Jobs into Helm hooks (--infer-hooks).
+const OptionInferHooks = "hooks.infer"
+
+// OptionExternalizeConfigMaps is the Options key (bool) that writes every
+// ConfigMap data key to files/<service>/<key> in the chart
+// (--externalize-configmaps).
+const OptionExternalizeConfigMaps = "configmaps.externalize"
+
+// OptionPreserveSelectors is the Options key (bool) that makes workload
+// templates render the original spec.selector instead of one built from the
+// chart's selectorLabels helper (--preserve-selectors).
+const OptionPreserveSelectors = "selectors.preserve"
+
+// OptionPassthroughKinds is the Options key ([]string) listing the kinds
+// copied into the chart verbatim instead of templated (--passthrough-kinds).
+const OptionPassthroughKinds = "kinds.passthrough"
+
+// OptionTemplateKinds is the Options key ([]string) that, when set, limits
+// templating to the listed kinds; the others are copied verbatim
+// (--template-kinds).
+const OptionTemplateKinds = "kinds.template"
+
 // Result contains the processing result for a resource.
 type Result struct {
 	// Processed indicates if the processor handled this resource.
@@ -71,6 +94,11 @@ type Result struct {
 
 	// Metadata contains additional processor-specific metadata.
 	Metadata map[string]interface{}
+
+	// Processor is the name of the processor that produced the result, set
+	// by Registry.Process: a registered processor, GenericProcessorName or
+	// PassthroughProcessorName.
+	Processor string
 }
 
 // Processor defines the interface for processing Kubernetes resources.
@@ -160,6 +188,15 @@ func ServiceNameFromResource(obj *unstructured.Unstructured) string {
 	return name
 }
 
+// ResolveServiceName returns the service name assigned in ctx, falling back to
+// ServiceNameFromResource when the pipeline did not assign one.
+func ResolveServiceName(ctx Context, obj *unstructured.Unstructured) string {
+	if ctx.ServiceName != "" {
+		return ctx.ServiceName
+	}
+	return ServiceNameFromResource(obj)
+}
+
 // SanitizeServiceName converts a service name to a valid Go template identifier.
 // Hyphens and dots are converted to camelCase (e.g., "test-module" → "testModule").
 func SanitizeServiceName(name string) string {
@@ -120,6 +120,24 @@ func TestServiceNameFromResource_FallbackToName(t *testing.T) {
 	}
 }
 
+// ── ResolveServiceName ───────────────────────────────────────────────────────
+
+func TestResolveServiceName_AssignedWins(t *testing.T) {
+	obj := makeObj("Service", "svc", "default")
+	obj.SetLabels(map[string]string{"app.kubernetes.io/name": "from-label"})
+	if got := ResolveServiceName(Context{ServiceName: "assigned"}, obj); got != "assigned" {
+		t.Errorf("got %q; want assigned", got)
+	}
+}
+
+func TestResolveServiceName_FallbackToResource(t *testing.T) {
+	obj := makeObj("Service", "svc", "default")
+	obj.SetLabels(map[string]string{"app.kubernetes.io/name": "from-label"})
+	if got := ResolveServiceName(Context{}, obj); got != "from-label" {
+		t.Errorf("got %q; want from-label", got)
+	}
+}
+
 // ── SanitizeServiceName ──────────────────────────────────────────────────────
 
 func TestSanitizeServiceName(t *testing.T) {
@@ -400,6 +418,9 @@ func TestRegistry_Process_MatchingProcessor(t *testing.T) {
 	if result.ServiceName != "custom" {
 		t.Errorf("ServiceName = %q; want custom", result.ServiceName)
 	}
+	if result.Processor != "svc-proc" {
+		t.Errorf("Processor = %q; want svc-proc", result.Processor)
+	}
 }
 
 func TestRegistry_Process_GenericFallback(t *testing.T) {
@@ -415,6 +436,9 @@ func TestRegistry_Process_GenericFallback(t *testing.T) {
 	if !result.Processed {
 		t.Error("generic processor should set Processed=true")
 	}
+	if result.Processor != GenericProcessorName {
+		t.Errorf("Processor = %q; want %q", result.Processor, GenericProcessorName)
+	}
 	if result.TemplatePath == "" {
 		t.Error("TemplatePath should not be empty")
 	}
@@ -489,3 +513,45 @@ func TestGenerateGenericTemplate_WithSpec(t *testing.T) {
 		t.Error("values should include spec")
 	}
 }
+
+func TestGenerateGenericTemplate_ValuesHints(t *testing.T) {
+	obj := makeObj("Widget", "gadget", "default")
+	obj.SetAnnotations(map[string]string{
+		ValuesHintAnnotation: "spec.replicas, spec.config.log-level,spec.missing,metadata.name",
+		"note":               "val",
+	})
+	obj.Object["spec"] = map[string]interface{}{
+		"replicas": int64(3),
+		"image":    "nginx:1.25",
+		"config":   map[string]interface{}{"log-level": "info"},
+	}
+	obj.Object["data"] = map[string]interface{}{"greeting": "{{ hello }}"}
+	tpl, vals := generateGenericTemplate(Context{ChartName: "chart"}, obj, "app")
+
+	want := "spec:\n" +
+		"  config:\n" +
+		"    log-level: {{ toJson (index .Values.services.app.widget \"spec\" \"config\" \"log-level\") }}\n" +
+		"  image: nginx:1.25\n" +
+		"  replicas: {{ toJson .Values.services.app.widget.spec.replicas }}\n" +
+		"{{- end }}\n"
+	if !strings.HasSuffix(tpl, want) {
+		t.Errorf("expected the hinted fields parameterized:\n%s", tpl)
+	}
+	if !strings.Contains(tpl, "data:\n  greeting: '{{ \"{{\" }} hello }}'\n") {
+		t.Errorf("expected the other fields rendered as is:\n%s", tpl)
+	}
+	if strings.Contains(tpl, ValuesHintAnnotation) || !strings.Contains(tpl, "note:") {
+		t.Errorf("expected the hint annotation dropped:\n%s", tpl)
+	}
+
+	spec, _ := vals["spec"].(map[string]interface{})
+	config, _ := spec["config"].(map[string]interface{})
+	if vals["enabled"] != true || len(spec) != 2 || spec["replicas"] != int64(3) || config["log-level"] != "info" {
+		t.Errorf("expected only the hinted fields in values, got %v", vals)
+	}
+
+	obj.SetAnnotations(map[string]string{ValuesHintAnnotation: "spec.missing"})
+	if _, vals := generateGenericTemplate(Context{ChartName: "chart"}, obj, "app"); vals["spec"] == nil || len(vals["spec"].(map[string]interface{})) != 3 {
+		t.Errorf("expected the whole spec in values without matching hints, got %v", vals)
+	}
+}
@@ -9,6 +9,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// Names of the fallback processors of Registry.Process, reported in
+// Result.Processor.
+const (
+	GenericProcessorName     = "generic"
+	PassthroughProcessorName = "passthrough"
+)
+
 // Registry manages processor registration and lookup.
 type Registry struct {
 	mu         sync.RWMutex
@@ -70,8 +77,13 @@ func (r *Registry) GetProcessors(gvk schema.GroupVersionKind) []Processor {
 	return result
 }
 
-// Process processes a resource using the first matching processor.
+// Process processes a resource using the first matching processor, or
+// copies it verbatim when its kind is passed through (see Passthrough).
 func (r *Registry) Process(ctx Context, obj *unstructured.Unstructured) (*Result, error) {
+	if Passthrough(ctx, obj.GetKind()) {
+		return r.processPassthrough(ctx, obj)
+	}
+
 	gvk := obj.GroupVersionKind()
 
 	processors := r.GetProcessors(gvk)
@@ -87,6 +99,7 @@ func (r *Registry) Process(ctx Context, obj *unstructured.Unstructured) (*Result
 			return nil, err
 		}
 		if result != nil && result.Processed {
+			result.Processor = p.Name()
 			return result, nil
 		}
 	}
@@ -97,7 +110,7 @@ func (r *Registry) Process(ctx Context, obj *unstructured.Unstructured) (*Result
 
 // processGeneric provides a fallback for unhandled resources.
 func (r *Registry) processGeneric(ctx Context, obj *unstructured.Unstructured) (*Result, error) {
-	serviceName := SanitizeServiceName(ServiceNameFromResource(obj))
+	serviceName := SanitizeServiceName(ResolveServiceName(ctx, obj))
 	kind := obj.GetKind()
 	name := obj.GetName()
 
@@ -112,11 +125,15 @@ func (r *Registry) processGeneric(ctx Context, obj *unstructured.Unstructured) (
 		TemplateContent: template,
 		ValuesPath:      ValuesPathForKind(kind, serviceName),
 		Values:          values,
+		Processor:       GenericProcessorName,
 	}, nil
 }
 
-// generateGenericTemplate creates a basic template for any resource.
-// serviceName must be pre-sanitized for use in Go templates (no hyphens).
+// generateGenericTemplate creates a basic template for any resource: spec is
+// moved to values as a whole, or, when the resource has a
+// ValuesHintAnnotation, the resource is rendered as is except for the hinted
+// fields. serviceName must be pre-sanitized for use in Go templates (no
+// hyphens).
 func generateGenericTemplate(ctx Context, obj *unstructured.Unstructured, serviceName string) (string, map[string]interface{}) {
 	kind := obj.GetKind()
 	name := obj.GetName()
@@ -148,19 +165,39 @@ func generateGenericTemplate(ctx Context, obj *unstructured.Unstructured, servic
 
 	// Add original labels if present
 	if labels := obj.GetLabels(); len(labels) > 0 {
-		for k, v := range labels {
-			template += "    " + k + ": " + v + "\n"
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			template += "    " + k + ": " + labels[k] + "\n"
 		}
 	}
 
-	// Annotations if present
-	if annotations := obj.GetAnnotations(); len(annotations) > 0 {
+	// Annotations if present, without the values hints
+	annotations := obj.GetAnnotations()
+	delete(annotations, ValuesHintAnnotation)
+	if len(annotations) > 0 {
 		template += "  annotations:\n"
-		for k, v := range annotations {
-			template += "    " + k + ": \"" + escapeTemplateString(v) + "\"\n"
+		keys := make([]string, 0, len(annotations))
+		for k := range annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			template += "    " + k + ": \"" + escapeTemplateString(annotations[k]) + "\"\n"
 		}
 	}
 
+	// Resource with the hinted fields parameterized
+	if body, hinted, ok := hintedBody(obj, valuesHints(obj), valuesPath); ok {
+		template += body
+		template += "{{- end }}\n"
+		hinted["enabled"] = true
+		return template, hinted
+	}
+
 	// Spec (as-is for generic resources)
 	spec, found, _ := unstructured.NestedFieldCopy(obj.Object, "spec")
 	if found && spec != nil {
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultServiceAccountName is the ServiceAccount pods run as when their
+// spec names none.
+const DefaultServiceAccountName = "default"
+
+// PodServiceAccountName returns the ServiceAccount the pods of a workload run
+// as, DefaultServiceAccountName when the pod spec names none, or "" for kinds
+// without a pod spec.
+func PodServiceAccountName(obj *unstructured.Unstructured) string {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return ""
+	}
+	name, _, _ := unstructured.NestedString(obj.Object, append(specPath, "serviceAccountName")...)
+	if name == "" {
+		// serviceAccount is the deprecated alias of serviceAccountName.
+		name, _, _ = unstructured.NestedString(obj.Object, append(specPath, "serviceAccount")...)
+	}
+	if name == "" {
+		return DefaultServiceAccountName
+	}
+	return name
+}
+
+// SetPodServiceAccountName makes the pods of a workload run as the
+// ServiceAccount name.
+func SetPodServiceAccountName(obj *unstructured.Unstructured, name string) error {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return nil
+	}
+	unstructured.RemoveNestedField(obj.Object, append(specPath, "serviceAccount")...)
+	return unstructured.SetNestedField(obj.Object, name, append(specPath, "serviceAccountName")...)
+}
+
+// PodAutomountServiceAccountToken returns the automountServiceAccountToken
+// setting of the pods of a workload and whether the pod spec sets it.
+func PodAutomountServiceAccountToken(obj *unstructured.Unstructured) (automount, set bool) {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return false, false
+	}
+	automount, set, _ = unstructured.NestedBool(obj.Object, append(specPath, "automountServiceAccountToken")...)
+	return automount, set
+}
+
+// SetPodAutomountServiceAccountToken sets automountServiceAccountToken in the
+// pod spec of a workload.
+func SetPodAutomountServiceAccountToken(obj *unstructured.Unstructured, automount bool) error {
+	specPath := PodSpecPath(obj.GetKind())
+	if specPath == nil {
+		return nil
+	}
+	return unstructured.SetNestedField(obj.Object, automount, append(specPath, "automountServiceAccountToken")...)
+}
+
+// ServiceAccountKey returns the key of the ServiceAccount name in namespace
+// used by BoundServiceAccounts.
+func ServiceAccountKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// BoundServiceAccounts returns the ServiceAccounts that are subjects of the
+// RoleBindings and ClusterRoleBindings among objs, by ServiceAccountKey. A
+// RoleBinding subject without a namespace is in the namespace of the binding.
+func BoundServiceAccounts(objs []*unstructured.Unstructured) map[string]bool {
+	bound := make(map[string]bool)
+	for _, obj := range objs {
+		kind := obj.GetKind()
+		if kind != "RoleBinding" && kind != "ClusterRoleBinding" {
+			continue
+		}
+		subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+		for _, item := range subjects {
+			subject, ok := item.(map[string]interface{})
+			if !ok || subject["kind"] != "ServiceAccount" {
+				continue
+			}
+			name, _ := subject["name"].(string)
+			namespace, _ := subject["namespace"].(string)
+			if namespace == "" && kind == "RoleBinding" {
+				namespace = obj.GetNamespace()
+			}
+			bound[ServiceAccountKey(namespace, name)] = true
+		}
+	}
+	return bound
+}
+
+// NewServiceAccount returns a ServiceAccount for the pods of a workload,
+// with the workload's namespace and labels so it is grouped into the same
+// service.
+func NewServiceAccount(name string, workload *unstructured.Unstructured) *unstructured.Unstructured {
+	return newWorkloadRBACObject("v1", "ServiceAccount", name, workload)
+}
+
+// NewServiceAccountRole returns a Role scaffold without rules for the
+// ServiceAccount name: pods get no API access until rules are added.
+func NewServiceAccountRole(name string, workload *unstructured.Unstructured) *unstructured.Unstructured {
+	role := newWorkloadRBACObject("rbac.authorization.k8s.io/v1", "Role", name, workload)
+	role.Object["rules"] = []interface{}{}
+	return role
+}
+
+// NewServiceAccountRoleBinding returns a RoleBinding that grants the Role
+// name to the ServiceAccount name in the same namespace.
+func NewServiceAccountRoleBinding(name string, workload *unstructured.Unstructured) *unstructured.Unstructured {
+	binding := newWorkloadRBACObject("rbac.authorization.k8s.io/v1", "RoleBinding", name, workload)
+	binding.Object["roleRef"] = map[string]interface{}{
+		"apiGroup": "rbac.authorization.k8s.io",
+		"kind":     "Role",
+		"name":     name,
+	}
+	// The subject has no namespace: the chart installs the ServiceAccount
+	// into the release namespace.
+	binding.Object["subjects"] = []interface{}{
+		map[string]interface{}{
+			"kind": "ServiceAccount",
+			"name": name,
+		},
+	}
+	return binding
+}
+
+func newWorkloadRBACObject(apiVersion, kind, name string, workload *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+	}}
+	if ns := workload.GetNamespace(); ns != "" {
+		obj.SetNamespace(ns)
+	}
+	if l := workload.GetLabels(); len(l) > 0 {
+		obj.SetLabels(l)
+	}
+	return obj
+}
@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPodServiceAccountName(t *testing.T) {
+	named := withPodSpec(workload("Deployment", nil), map[string]interface{}{"serviceAccountName": "web"})
+	deprecated := withPodSpec(workload("Deployment", nil), map[string]interface{}{"serviceAccount": "legacy"})
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "CronJob"}}
+	_ = unstructured.SetNestedField(cronJob.Object, "backup", "spec", "jobTemplate", "spec", "template", "spec", "serviceAccountName")
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want string
+	}{
+		{"serviceAccountName", named, "web"},
+		{"deprecated serviceAccount", deprecated, "legacy"},
+		{"unset", workload("Deployment", nil), DefaultServiceAccountName},
+		{"CronJob", cronJob, "backup"},
+		{"no pod spec", &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Service"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := PodServiceAccountName(tt.obj); got != tt.want {
+			t.Errorf("%s: PodServiceAccountName = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetPodServiceAccountName(t *testing.T) {
+	obj := withPodSpec(workload("Deployment", nil), map[string]interface{}{"serviceAccount": "default"})
+	if err := SetPodServiceAccountName(obj, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if got := PodServiceAccountName(obj); got != "web" {
+		t.Errorf("PodServiceAccountName = %q", got)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "template", "spec", "serviceAccount"); found {
+		t.Error("the deprecated serviceAccount field should be removed")
+	}
+}
+
+func TestPodAutomountServiceAccountToken(t *testing.T) {
+	obj := workload("Deployment", nil)
+	if _, set := PodAutomountServiceAccountToken(obj); set {
+		t.Error("expected the setting to be unset")
+	}
+	if err := SetPodAutomountServiceAccountToken(obj, false); err != nil {
+		t.Fatal(err)
+	}
+	if automount, set := PodAutomountServiceAccountToken(obj); !set || automount {
+		t.Errorf("PodAutomountServiceAccountToken = %v, %v; want false, true", automount, set)
+	}
+}
+
+func TestBoundServiceAccounts(t *testing.T) {
+	binding := func(kind, namespace string, subjects ...interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": kind, "subjects": subjects}}
+		obj.SetNamespace(namespace)
+		return obj
+	}
+	bound := BoundServiceAccounts([]*unstructured.Unstructured{
+		binding("RoleBinding", "prod",
+			map[string]interface{}{"kind": "ServiceAccount", "name": "web"},
+			map[string]interface{}{"kind": "User", "name": "alice"}),
+		binding("ClusterRoleBinding", "",
+			map[string]interface{}{"kind": "ServiceAccount", "name": "agent", "namespace": "monitoring"}),
+		workload("Deployment", nil),
+	})
+	want := map[string]bool{"prod/web": true, "monitoring/agent": true}
+	if len(bound) != len(want) {
+		t.Errorf("BoundServiceAccounts = %v, want %v", bound, want)
+	}
+	for k := range want {
+		if !bound[k] {
+			t.Errorf("expected %s to be bound, got %v", k, bound)
+		}
+	}
+}
+
+func TestNewServiceAccountRoleBinding(t *testing.T) {
+	deploy := workload("Deployment", nil)
+
+	sa := NewServiceAccount("web", deploy)
+	if sa.GetKind() != "ServiceAccount" || sa.GetName() != "web" || sa.GetNamespace() != "prod" || sa.GetLabels()["app"] != "web" {
+		t.Errorf("unexpected ServiceAccount: %v", sa.Object)
+	}
+
+	role := NewServiceAccountRole("web", deploy)
+	if rules, found, _ := unstructured.NestedSlice(role.Object, "rules"); !found || len(rules) != 0 {
+		t.Errorf("expected a Role without rules, got %v", role.Object["rules"])
+	}
+
+	binding := NewServiceAccountRoleBinding("web", deploy)
+	if ref, _, _ := unstructured.NestedString(binding.Object, "roleRef", "name"); ref != "web" {
+		t.Errorf("roleRef.name = %q", ref)
+	}
+	subjects, _, _ := unstructured.NestedSlice(binding.Object, "subjects")
+	if len(subjects) != 1 {
+		t.Fatalf("expected one subject, got %v", subjects)
+	}
+	subject := subjects[0].(map[string]interface{})
+	if subject["kind"] != "ServiceAccount" || subject["name"] != "web" || subject["namespace"] != nil {
+		t.Errorf("unexpected subject: %v", subject)
+	}
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Defaults of injected topology spread constraints.
+const (
+	DefaultTopologySpreadMaxSkew           = 1
+	DefaultTopologySpreadWhenUnsatisfiable = "ScheduleAnyway"
+)
+
+// TopologySpreadKeys are the node labels pods are spread across: zones
+// first, then nodes.
+var TopologySpreadKeys = []string{"topology.kubernetes.io/zone", "kubernetes.io/hostname"}
+
+// NeedsTopologySpread reports whether obj is a Deployment or StatefulSet
+// that runs more than one replica and has no topologySpreadConstraints.
+func NeedsTopologySpread(obj *unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet":
+	default:
+		return false
+	}
+	if replicas, ok := WorkloadReplicas(obj); !ok || replicas <= 1 {
+		return false
+	}
+	existing, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "topologySpreadConstraints")
+	return len(existing) == 0
+}
+
+// AddTopologySpreadConstraints sets one topologySpreadConstraint per
+// TopologySpreadKeys on the pod template of a workload, selecting its pods
+// by the workload's selector.
+func AddTopologySpreadConstraints(obj *unstructured.Unstructured) error {
+	selector, _, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+
+	constraints := make([]interface{}, 0, len(TopologySpreadKeys))
+	for _, key := range TopologySpreadKeys {
+		constraint := map[string]interface{}{
+			"maxSkew":           int64(DefaultTopologySpreadMaxSkew),
+			"topologyKey":       key,
+			"whenUnsatisfiable": DefaultTopologySpreadWhenUnsatisfiable,
+		}
+		if len(selector) > 0 {
+			constraint["labelSelector"] = selector
+		}
+		constraints = append(constraints, constraint)
+	}
+	return unstructured.SetNestedSlice(obj.Object, constraints, "spec", "template", "spec", "topologySpreadConstraints")
+}
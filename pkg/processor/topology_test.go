@@ -0,0 +1,54 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNeedsTopologySpread(t *testing.T) {
+	spread := workload("Deployment", int64(3))
+	_ = AddTopologySpreadConstraints(spread)
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want bool
+	}{
+		{"Deployment", workload("Deployment", int64(3)), true},
+		{"StatefulSet parsed from YAML", workload("StatefulSet", float64(2)), true},
+		{"single replica", workload("Deployment", int64(1)), false},
+		{"DaemonSet", workload("DaemonSet", int64(3)), false},
+		{"constraints set", spread, false},
+	}
+	for _, tt := range tests {
+		if got := NeedsTopologySpread(tt.obj); got != tt.want {
+			t.Errorf("%s: NeedsTopologySpread = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAddTopologySpreadConstraints(t *testing.T) {
+	obj := workload("Deployment", int64(3))
+	if err := AddTopologySpreadConstraints(obj); err != nil {
+		t.Fatal(err)
+	}
+
+	constraints, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "topologySpreadConstraints")
+	if len(constraints) != len(TopologySpreadKeys) {
+		t.Fatalf("expected %d constraints, got %v", len(TopologySpreadKeys), constraints)
+	}
+	for i, c := range constraints {
+		constraint := c.(map[string]interface{})
+		if constraint["topologyKey"] != TopologySpreadKeys[i] {
+			t.Errorf("constraint %d: topologyKey = %v", i, constraint["topologyKey"])
+		}
+		if constraint["maxSkew"] != int64(DefaultTopologySpreadMaxSkew) || constraint["whenUnsatisfiable"] != DefaultTopologySpreadWhenUnsatisfiable {
+			t.Errorf("constraint %d: unexpected defaults: %v", i, constraint)
+		}
+		app, _, _ := unstructured.NestedString(constraint, "labelSelector", "matchLabels", "app")
+		if app != "web" {
+			t.Errorf("constraint %d: expected the workload selector, got %v", i, constraint["labelSelector"])
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package value
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// FactorCommon factors the value of key shared by most blocks out of them.
+// The common value is the map that occurs most often (at least minShared
+// times). Blocks equal to it lose key; the others keep only the fields that
+// differ, so merging a block over the common value restores it.
+//
+// Nothing is factored, and nil is returned, unless every block has key set
+// to a map that sets every field of the common value: a block without a
+// field could not be restored by merging.
+func FactorCommon(blocks []map[string]interface{}, key string, minShared int) map[string]interface{} {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, block := range blocks {
+		m, ok := block[key].(map[string]interface{})
+		if !ok || len(m) == 0 {
+			return nil
+		}
+		canonical, err := json.Marshal(m)
+		if err != nil {
+			return nil
+		}
+		counts[string(canonical)]++
+	}
+
+	// Most frequent value; ties go to the smallest encoding for stable output.
+	candidates := make([]string, 0, len(counts))
+	for c := range counts {
+		candidates = append(candidates, c)
+	}
+	sort.Strings(candidates)
+	best := ""
+	for _, c := range candidates {
+		if counts[c] > counts[best] {
+			best = c
+		}
+	}
+	if counts[best] < minShared {
+		return nil
+	}
+
+	var common map[string]interface{}
+	if err := json.Unmarshal([]byte(best), &common); err != nil {
+		return nil
+	}
+
+	for _, block := range blocks {
+		if !coversFields(common, normalize(block[key])) {
+			return nil
+		}
+	}
+
+	for _, block := range blocks {
+		override := diffValues(normalize(block[key]).(map[string]interface{}), common)
+		if len(override) == 0 {
+			delete(block, key)
+		} else {
+			block[key] = override
+		}
+	}
+	return common
+}
+
+// normalize returns v in its JSON shape (generic maps and slices, float64
+// numbers) so it compares equal to the decoded common value.
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// coversFields reports whether v sets every leaf field of common.
+func coversFields(common map[string]interface{}, v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, cv := range common {
+		child, exists := m[k]
+		if !exists {
+			return false
+		}
+		if cm, ok := cv.(map[string]interface{}); ok && !coversFields(cm, child) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffValues returns the fields of v that differ from common, recursing into
+// nested maps. Lists are compared as a whole.
+func diffValues(v, common map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	for k, val := range v {
+		cv, exists := common[k]
+		if !exists {
+			out[k] = val
+			continue
+		}
+		vm, vok := val.(map[string]interface{})
+		cm, cok := cv.(map[string]interface{})
+		if vok && cok {
+			if d := diffValues(vm, cm); len(d) > 0 {
+				out[k] = d
+			}
+			continue
+		}
+		if !reflect.DeepEqual(val, cv) {
+			out[k] = val
+		}
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package value
+
+import (
+	"reflect"
+	"testing"
+)
+
+func dedupTestBlocks() []map[string]interface{} {
+	resources := func(cpu string) map[string]interface{} {
+		return map[string]interface{}{
+			"limits":   map[string]interface{}{"cpu": cpu, "memory": "256Mi"},
+			"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+		}
+	}
+	return []map[string]interface{}{
+		{"name": "web", "resources": resources("500m")},
+		{"name": "api", "resources": resources("500m")},
+		{"name": "worker", "resources": resources("1")},
+	}
+}
+
+func TestFactorCommon(t *testing.T) {
+	blocks := dedupTestBlocks()
+
+	common := FactorCommon(blocks, "resources", 2)
+
+	want := map[string]interface{}{
+		"limits":   map[string]interface{}{"cpu": "500m", "memory": "256Mi"},
+		"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+	}
+	if !reflect.DeepEqual(common, want) {
+		t.Fatalf("unexpected common value: %v", common)
+	}
+	for _, block := range blocks[:2] {
+		if _, ok := block["resources"]; ok {
+			t.Errorf("expected resources removed from %s, got %v", block["name"], block["resources"])
+		}
+	}
+	override := map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}}
+	if !reflect.DeepEqual(blocks[2]["resources"], override) {
+		t.Errorf("expected only the differing field kept, got %v", blocks[2]["resources"])
+	}
+}
+
+func TestFactorCommon_BelowMinShared(t *testing.T) {
+	blocks := dedupTestBlocks()[1:]
+
+	if common := FactorCommon(blocks, "resources", 2); common != nil {
+		t.Errorf("expected nothing factored from distinct values, got %v", common)
+	}
+	if blocks[0]["resources"] == nil || blocks[1]["resources"] == nil {
+		t.Error("blocks must be left untouched")
+	}
+}
+
+func TestFactorCommon_MissingBlock(t *testing.T) {
+	blocks := dedupTestBlocks()
+	delete(blocks[2], "resources")
+
+	if common := FactorCommon(blocks, "resources", 2); common != nil {
+		t.Errorf("expected nothing factored when a block has no value, got %v", common)
+	}
+}
+
+func TestFactorCommon_MissingField(t *testing.T) {
+	blocks := dedupTestBlocks()
+	blocks[2]["resources"] = map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"},
+	}
+
+	// Merging the common limits over the worker would add limits it never set.
+	if common := FactorCommon(blocks, "resources", 2); common != nil {
+		t.Errorf("expected nothing factored when a block lacks a common field, got %v", common)
+	}
+}
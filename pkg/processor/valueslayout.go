@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ValuesKind returns the kind name used to key the values of obj, of type
+// gvk. Istio
+// Gateways are renamed so they do not collide with Gateway API Gateways, and
+// HorizontalPodAutoscalers generated by NewHorizontalPodAutoscaler so their
+// values (autoscaling) do not replace those of source ones (hpa).
+func ValuesKind(gvk schema.GroupVersionKind, obj *unstructured.Unstructured) string {
+	switch {
+	case gvk.Group == "networking.istio.io" && gvk.Kind == "Gateway":
+		return "IstioGateway"
+	case obj != nil && IsGeneratedAutoscaler(obj):
+		return "Autoscaling"
+	}
+	return gvk.Kind
+}
+
+// ValuesKey returns the key under services.<name> that holds the values of
+// the only resource of kind in a service. Templates reference values as
+// $svc.deployment, $svc.service, $svc.statefulSet, etc.
+func ValuesKey(kind string) string {
+	switch kind {
+	case "PersistentVolumeClaim":
+		return "pvc"
+	case "PodDisruptionBudget":
+		return "pdb"
+	case "HorizontalPodAutoscaler":
+		return "hpa"
+	case "VerticalPodAutoscaler":
+		return "vpa"
+	default:
+		if len(kind) == 0 {
+			return "resource"
+		}
+		// lowerCamelCase: Deployment → deployment, StatefulSet → statefulSet
+		return strings.ToLower(kind[:1]) + kind[1:]
+	}
+}
+
+// ValuesByName reports whether the values of kind are always keyed by the
+// sanitized resource name (e.g. configMaps.<name>), as the templates of the
+// kind expect.
+func ValuesByName(kind string) bool {
+	switch kind {
+	case "ConfigMap", "Secret", "PersistentVolume", "StorageClass":
+		return true
+	}
+	return false
+}
+
+// SanitizeValuesKey converts a Kubernetes resource name to a valid Go/YAML key
+// (camelCase). Similar to SanitizeServiceName but also handles _ as separator and
+// always lowercases the first character.
+func SanitizeValuesKey(name string) string {
+	result := make([]byte, 0, len(name))
+	for _, c := range name {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			result = append(result, byte(c))
+		} else if c == '-' || c == '_' || c == '.' {
+			// Always use '_' as a camelCase marker for the second pass.
+			result = append(result, '_')
+		}
+	}
+	// Handle camelCase conversion
+	final := make([]byte, 0, len(result))
+	capitalize := false
+	for i, c := range result {
+		if c == '_' {
+			capitalize = true
+			continue
+		}
+		if capitalize && c >= 'a' && c <= 'z' {
+			final = append(final, c-32)
+			capitalize = false
+		} else if i == 0 && c >= 'A' && c <= 'Z' {
+			// Lowercase first character
+			final = append(final, c+32)
+		} else {
+			final = append(final, c)
+		}
+	}
+	if len(final) == 0 {
+		return "config"
+	}
+	return string(final)
+}
+
+// PluralValuesKey returns the key under services.<name> that holds the
+// values of the resources of kind by name: always for ValuesByName kinds,
+// and for other kinds when a service has more than one resource of the kind.
+func PluralValuesKey(kind string) string {
+	// Simple pluralization rules
+	switch kind {
+	case "Ingress":
+		return "ingresses"
+	case "Service":
+		return "services"
+	case "ConfigMap":
+		return "configMaps"
+	case "Secret":
+		return "secrets"
+	case "ServiceAccount":
+		return "serviceAccounts"
+	case "Deployment":
+		return "deployments"
+	case "StatefulSet":
+		return "statefulSets"
+	case "DaemonSet":
+		return "daemonSets"
+	case "PersistentVolumeClaim":
+		return "persistentVolumeClaims"
+	case "PersistentVolume":
+		return "persistentVolumes"
+	case "StorageClass":
+		return "storageClasses"
+	case "Role":
+		return "roles"
+	case "RoleBinding":
+		return "roleBindings"
+	case "ClusterRole":
+		return "clusterRoles"
+	case "ClusterRoleBinding":
+		return "clusterRoleBindings"
+	default:
+		// Default: add 's'
+		return kind + "s"
+	}
+}
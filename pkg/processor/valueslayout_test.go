@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ============================================================
+// ValuesKind Tests
+// ============================================================
+
+func TestValuesKind(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "web"},
+	}}
+	generated := NewHorizontalPodAutoscaler(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+	}})
+	istio := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1",
+		"kind":       "Gateway",
+		"metadata":   map[string]interface{}{"name": "public"},
+	}}
+
+	tests := []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		expected string
+	}{
+		{"source HPA", source, "HorizontalPodAutoscaler"},
+		{"generated HPA", generated, "Autoscaling"},
+		{"Istio Gateway", istio, "IstioGateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValuesKind(tt.obj.GroupVersionKind(), tt.obj); got != tt.expected {
+				t.Errorf("ValuesKind() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// ============================================================
+// ValuesKey Tests
+// ============================================================
+
+func TestValuesKey(t *testing.T) {
+	tests := []struct {
+		kind     string
+		expected string
+	}{
+		{"Deployment", "deployment"},
+		{"StatefulSet", "statefulSet"},
+		{"DaemonSet", "daemonSet"},
+		{"Service", "service"},
+		{"ConfigMap", "configMap"},
+		{"PersistentVolumeClaim", "pvc"},
+		{"PodDisruptionBudget", "pdb"},
+		{"HorizontalPodAutoscaler", "hpa"},
+		{"Autoscaling", "autoscaling"},
+		{"VerticalPodAutoscaler", "vpa"},
+		{"Ingress", "ingress"},
+		{"", "resource"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			result := ValuesKey(tt.kind)
+			if result != tt.expected {
+				t.Errorf("ValuesKey(%q) = %q, want %q", tt.kind, result, tt.expected)
+			}
+		})
+	}
+}
+
+// ============================================================
+// PluralValuesKey Tests
+// ============================================================
+
+func TestPluralValuesKey(t *testing.T) {
+	tests := []struct {
+		kind     string
+		expected string
+	}{
+		{"Ingress", "ingresses"},
+		{"Service", "services"},
+		{"ConfigMap", "configMaps"},
+		{"Secret", "secrets"},
+		{"ServiceAccount", "serviceAccounts"},
+		{"Deployment", "deployments"},
+		{"StatefulSet", "statefulSets"},
+		{"DaemonSet", "daemonSets"},
+		{"PersistentVolumeClaim", "persistentVolumeClaims"},
+		{"Role", "roles"},
+		{"RoleBinding", "roleBindings"},
+		{"ClusterRole", "clusterRoles"},
+		{"ClusterRoleBinding", "clusterRoleBindings"},
+		{"CustomResource", "CustomResources"}, // default: add 's'
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			result := PluralValuesKey(tt.kind)
+			if result != tt.expected {
+				t.Errorf("PluralValuesKey(%q) = %q, want %q", tt.kind, result, tt.expected)
+			}
+		})
+	}
+}
+
+// ============================================================
+// SanitizeValuesKey Tests
+// ============================================================
+
+func TestSanitizeValuesKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"simple", "myapp", "myapp"},
+		{"hyphen", "my-app", "myApp"},
+		{"underscore", "my_app", "myApp"},
+		{"dot", "my.app", "myApp"},
+		{"uppercase-first", "MyApp", "myApp"},
+		{"mixed", "my-app_config.v2", "myAppConfigV2"},
+		{"empty", "", "config"},
+		{"special-chars-only", "---", "config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := SanitizeValuesKey(tt.input)
+			if result != tt.expected {
+				t.Errorf("SanitizeValuesKey(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+// Package registry configures access to container registries and chart
+// repositories: credentials, HTTP proxy and trusted certificate authorities.
+// The same options serve digest pinning, dependency version lookups and
+// bundle verification, so dhg works behind TLS-intercepting proxies.
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/generator"
+	"github.com/deckhouse/deckhouse-helm-generator/pkg/processor"
+)
+
+// AuthEnv is the environment variable with registry credentials in the
+// form of Credential, separated by commas. Flags take precedence over it
+// for the same host.
+const AuthEnv = "DHG_REGISTRY_AUTH"
+
+// Credential is a username and password for a registry or chart repository
+// host.
+type Credential struct {
+	// Host is the registry host, with the port if any, e.g.
+	// registry.example.com:5000. docker.io matches Docker Hub.
+	Host string
+
+	Username string
+	Password string
+}
+
+// ParseCredential parses a credential in the form host=username:password.
+// The password may contain colons.
+func ParseCredential(s string) (Credential, error) {
+	host, userPass, ok := strings.Cut(s, "=")
+	if !ok || host == "" {
+		return Credential{}, fmt.Errorf("invalid registry credential: expected host=username:password")
+	}
+	username, password, ok := strings.Cut(userPass, ":")
+	if !ok || username == "" {
+		return Credential{}, fmt.Errorf("invalid registry credential for %s: expected host=username:password", host)
+	}
+	return Credential{Host: host, Username: username, Password: password}, nil
+}
+
+// CredentialsFromEnv returns the credentials of AuthEnv.
+func CredentialsFromEnv() ([]Credential, error) {
+	var creds []Credential
+	for _, entry := range strings.Split(os.Getenv(AuthEnv), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		c, err := ParseCredential(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", AuthEnv, err)
+		}
+		creds = append(creds, c)
+	}
+	return creds, nil
+}
+
+// Options configures access to registries and chart repositories.
+type Options struct {
+	// Credentials are used for their hosts before DockerConfig.
+	Credentials []Credential
+
+	// DockerConfig is the path of a Docker config.json with registry
+	// credentials. When empty, the Docker config of the user
+	// ($DOCKER_CONFIG/config.json or ~/.docker/config.json) is used.
+	DockerConfig string
+
+	// Proxy is the URL of the HTTP proxy for all requests. When empty,
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY are honored.
+	Proxy string
+
+	// CAFile is a PEM file of certificate authorities trusted in addition
+	// to the system ones, such as the CA of a TLS-intercepting proxy.
+	CAFile string
+}
+
+// Transport returns the HTTP transport of the options: the default
+// transport with the proxy and certificate authorities applied.
+func (o Options) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", o.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", o.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
+
+// HTTPClient returns a client for chart repositories and APIs such as
+// ArtifactHub, with a 30s timeout. Requests to a host with a credential
+// use it for basic authentication.
+func (o Options) HTTPClient() (*http.Client, error) {
+	transport, err := o.Transport()
+	if err != nil {
+		return nil, err
+	}
+	var rt http.RoundTripper = transport
+	if len(o.Credentials) > 0 {
+		rt = &basicAuthTransport{base: transport, credentials: o.Credentials}
+	}
+	return &http.Client{Transport: rt, Timeout: 30 * time.Second}, nil
+}
+
+// basicAuthTransport adds the credential of the request host, if any, to
+// requests without an Authorization header.
+type basicAuthTransport struct {
+	base        http.RoundTripper
+	credentials []Credential
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		for _, c := range t.credentials {
+			if strings.EqualFold(c.Host, req.URL.Host) {
+				req = req.Clone(req.Context())
+				req.SetBasicAuth(c.Username, c.Password)
+				break
+			}
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Keychain returns the registry credentials of the options: Credentials,
+// then the Docker config.
+func (o Options) Keychain() authn.Keychain {
+	var docker authn.Keychain = authn.DefaultKeychain
+	if o.DockerConfig != "" {
+		docker = dockerConfigKeychain{path: o.DockerConfig}
+	}
+	return authn.NewMultiKeychain(credentialKeychain(o.Credentials), docker)
+}
+
+// credentialKeychain resolves registries to the credential of their host.
+type credentialKeychain []Credential
+
+// Resolve implements authn.Keychain.
+func (k credentialKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	for _, c := range k {
+		reg, err := name.NewRegistry(c.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry host %q: %w", c.Host, err)
+		}
+		if reg.RegistryStr() == target.RegistryStr() {
+			return authn.FromConfig(authn.AuthConfig{Username: c.Username, Password: c.Password}), nil
+		}
+	}
+	return authn.Anonymous, nil
+}
+
+// dockerConfigKeychain resolves registries to the credentials of a Docker
+// config file, including its credential helpers.
+type dockerConfigKeychain struct {
+	path string
+}
+
+// Resolve implements authn.Keychain.
+func (k dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	f, err := os.Open(k.path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read Docker config: %w", err)
+	}
+	defer f.Close()
+	cf, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse Docker config %s: %w", k.path, err)
+	}
+
+	// Docker config keys Docker Hub credentials by its legacy URL.
+	for _, key := range []string{target.String(), target.RegistryStr()} {
+		if key == name.DefaultRegistry {
+			key = authn.DefaultAuthKey
+		}
+		cfg, err := cf.GetAuthConfig(key)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Username != "" || cfg.Password != "" || cfg.Auth != "" || cfg.IdentityToken != "" || cfg.RegistryToken != "" {
+			return authn.FromConfig(authn.AuthConfig{
+				Username:      cfg.Username,
+				Password:      cfg.Password,
+				Auth:          cfg.Auth,
+				IdentityToken: cfg.IdentityToken,
+				RegistryToken: cfg.RegistryToken,
+			}), nil
+		}
+	}
+	return authn.Anonymous, nil
+}
+
+// DigestResolver returns a resolver of image digests that queries the
+// registries with the credentials, proxy and certificate authorities of the
+// options.
+func (o Options) DigestResolver() (processor.DigestResolver, error) {
+	transport, err := o.Transport()
+	if err != nil {
+		return nil, err
+	}
+	keychain := o.Keychain()
+	return func(ctx context.Context, image string) (string, error) {
+		return crane.Digest(image, crane.WithContext(ctx), crane.WithAuthFromKeychain(keychain), crane.WithTransport(transport))
+	}, nil
+}
+
+// PlatformResolver returns a resolver of the platforms of images, with the
+// same access as DigestResolver. The entries of manifest lists without a
+// platform, such as attestations, are left out.
+func (o Options) PlatformResolver() (generator.PlatformResolver, error) {
+	transport, err := o.Transport()
+	if err != nil {
+		return nil, err
+	}
+	keychain := o.Keychain()
+	return func(ctx context.Context, image string) ([]generator.ImagePlatform, error) {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return nil, err
+		}
+		desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(keychain), remote.WithTransport(transport))
+		if err != nil {
+			return nil, err
+		}
+
+		if !desc.MediaType.IsIndex() {
+			img, err := desc.Image()
+			if err != nil {
+				return nil, err
+			}
+			cfg, err := img.ConfigFile()
+			if err != nil {
+				return nil, err
+			}
+			return []generator.ImagePlatform{{Platform: platformString(cfg.Platform()), Digest: desc.Digest.String()}}, nil
+		}
+
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+		var platforms []generator.ImagePlatform
+		for _, m := range manifest.Manifests {
+			if m.Platform == nil || m.Platform.OS == "" || m.Platform.OS == "unknown" {
+				continue
+			}
+			platforms = append(platforms, generator.ImagePlatform{Platform: platformString(m.Platform), Digest: m.Digest.String()})
+		}
+		return platforms, nil
+	}, nil
+}
+
+// platformString returns p as os/arch[/variant].
+func platformString(p *v1.Platform) string {
+	if p == nil || p.OS == "" {
+		return "unknown"
+	}
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
@@ -0,0 +1,287 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestParseCredential(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Credential
+		wantErr bool
+	}{
+		{in: "registry.example.com=ci:s3cret", want: Credential{Host: "registry.example.com", Username: "ci", Password: "s3cret"}},
+		{in: "localhost:5000=ci:pa:ss", want: Credential{Host: "localhost:5000", Username: "ci", Password: "pa:ss"}},
+		{in: "registry.example.com=ci", wantErr: true},
+		{in: "=ci:s3cret", wantErr: true},
+		{in: "registry.example.com=:s3cret", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCredential(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCredential(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCredential(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	t.Setenv(AuthEnv, "registry.example.com=ci:s3cret, ghcr.io=bot:token")
+	creds, err := CredentialsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 2 || creds[1] != (Credential{Host: "ghcr.io", Username: "bot", Password: "token"}) {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+
+	t.Setenv(AuthEnv, "registry.example.com")
+	if _, err := CredentialsFromEnv(); err == nil || !strings.Contains(err.Error(), AuthEnv) {
+		t.Errorf("expected an error naming %s, got %v", AuthEnv, err)
+	}
+}
+
+func TestKeychain(t *testing.T) {
+	dockerConfig := filepath.Join(t.TempDir(), "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("docker:from-config"))
+	if err := os.WriteFile(dockerConfig, []byte(fmt.Sprintf(`{"auths": {
+  "registry.example.com": {"auth": %q},
+  "other.example.com": {"auth": %q}
+}}`, auth, auth)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keychain := Options{
+		Credentials:  []Credential{{Host: "registry.example.com", Username: "ci", Password: "s3cret"}, {Host: "docker.io", Username: "hub", Password: "token"}},
+		DockerConfig: dockerConfig,
+	}.Keychain()
+
+	tests := []struct {
+		image    string
+		username string
+		password string
+	}{
+		{"registry.example.com/team/app:1.0", "ci", "s3cret"},
+		{"other.example.com/app:1.0", "docker", "from-config"},
+		{"nginx:1.25", "hub", "token"},
+		{"unknown.example.com/app:1.0", "", ""},
+	}
+	for _, tt := range tests {
+		ref, err := name.ParseReference(tt.image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authenticator, err := keychain.Resolve(ref.Context())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.image, err)
+		}
+		cfg, err := authn.Authorization(context.Background(), authenticator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		username, password := cfg.Username, cfg.Password
+		if cfg.Auth != "" {
+			decoded, _ := base64.StdEncoding.DecodeString(cfg.Auth)
+			username, password, _ = strings.Cut(string(decoded), ":")
+		}
+		if username != tt.username || password != tt.password {
+			t.Errorf("%s: got %s:%s, want %s:%s", tt.image, username, password, tt.username, tt.password)
+		}
+	}
+}
+
+// writeCAFile writes the certificate of a TLS test server to a PEM file.
+func writeCAFile(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHTTPClient_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client, err := Options{}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("expected the certificate of the test server not to be trusted")
+	}
+
+	client, err = Options{CAFile: writeCAFile(t, srv)}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the CA file to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (Options{CAFile: empty}).HTTPClient(); err == nil || !strings.Contains(err.Error(), "no certificates") {
+		t.Errorf("expected an error for a CA file without certificates, got %v", err)
+	}
+}
+
+func TestHTTPClient_ProxyAndCredentials(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		fmt.Fprintf(w, "%s %s:%s", r.URL.Host, username, password)
+	}))
+	defer proxy.Close()
+
+	client, err := Options{
+		Proxy:       proxy.URL,
+		Credentials: []Credential{{Host: "charts.example.com", Username: "ci", Password: "s3cret"}},
+	}.HTTPClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for url, want := range map[string]string{
+		"http://charts.example.com/index.yaml": "charts.example.com ci:s3cret",
+		"http://other.example.com/index.yaml":  "other.example.com :",
+	} {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("GET %s through the proxy = %q, want %q", url, body, want)
+		}
+	}
+
+	if _, err := (Options{Proxy: "://bad"}).Transport(); err == nil {
+		t.Error("expected an error for an invalid proxy URL")
+	}
+}
+
+func TestDigestResolver(t *testing.T) {
+	srv := httptest.NewTLSServer(ggcrregistry.New())
+	defer srv.Close()
+	image := strings.TrimPrefix(srv.URL, "https://") + "/app:1.0"
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(img, image, crane.WithTransport(srv.Client().Transport)); err != nil {
+		t.Fatal(err)
+	}
+	want, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolve, err := Options{CAFile: writeCAFile(t, srv)}.DigestResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := resolve(context.Background(), image)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got != want.String() {
+		t.Errorf("digest = %s, want %s", got, want)
+	}
+}
+
+func TestPlatformResolver(t *testing.T) {
+	srv := httptest.NewServer(ggcrregistry.New())
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	amd64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amd64, err = mutate.ConfigFile(amd64, &v1.ConfigFile{OS: "linux", Architecture: "amd64"}); err != nil {
+		t.Fatal(err)
+	}
+	arm64, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attestation, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}}},
+		mutate.IndexAddendum{Add: attestation, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "unknown", Architecture: "unknown"}}},
+	)
+	indexRef, err := name.ParseReference(host + "/app:multi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteIndex(indexRef, index); err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(amd64, host+"/app:amd64"); err != nil {
+		t.Fatal(err)
+	}
+	amd64Digest, _ := amd64.Digest()
+	arm64Digest, _ := arm64.Digest()
+
+	resolve, err := Options{}.PlatformResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	platforms, err := resolve(context.Background(), host+"/app:multi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"linux/amd64 " + amd64Digest.String(), "linux/arm64/v8 " + arm64Digest.String()}
+	if len(platforms) != len(want) {
+		t.Fatalf("platforms = %+v, want %v", platforms, want)
+	}
+	for i, p := range platforms {
+		if got := p.Platform + " " + p.Digest; got != want[i] {
+			t.Errorf("platforms[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	platforms, err = resolve(context.Background(), host+"/app:amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(platforms) != 1 || platforms[0].Platform != "linux/amd64" || platforms[0].Digest != amd64Digest.String() {
+		t.Errorf("single-platform image: got %+v", platforms)
+	}
+}
@@ -59,10 +59,15 @@ const (
 	// RelationServiceMonitor indicates a ServiceMonitor selecting a Service.
 	RelationServiceMonitor RelationshipType = "service_monitor"
 
+	// RelationPodMonitor indicates a PodMonitor selecting workload pods.
+	RelationPodMonitor RelationshipType = "pod_monitor"
+
 	// RelationDeckhouse indicates a Deckhouse-specific relationship.
 	RelationDeckhouse RelationshipType = "deckhouse"
 
-	// RelationGatewayRoute indicates an HTTPRoute → Gateway relationship.
+	// RelationGatewayRoute indicates a route → Gateway relationship.
+	// Example: HTTPRoute parentRefs or Istio VirtualService gateways.
+	// A Gateway is usually shared by many services, so it does not merge groups.
 	RelationGatewayRoute RelationshipType = "gateway_route"
 
 	// RelationScaleTarget indicates a ScaledObject → target Deployment/StatefulSet.
@@ -70,8 +75,34 @@ const (
 
 	// RelationStorageClass indicates a StorageClass reference.
 	// Example: PVC referencing a StorageClass.
+	// A StorageClass is shared by many services, so it does not merge groups.
 	RelationStorageClass RelationshipType = "storage_class"
 
+	// RelationPriorityClass indicates a PriorityClass reference.
+	// Example: Deployment with spec.template.spec.priorityClassName.
+	// A PriorityClass is shared by many services, so it does not merge groups.
+	RelationPriorityClass RelationshipType = "priority_class"
+
+	// RelationNetworkPolicy indicates a NetworkPolicy selecting workload pods.
+	// Example: NetworkPolicy with podSelector app=web → Deployment web.
+	RelationNetworkPolicy RelationshipType = "network_policy"
+
+	// RelationNetworkPeer indicates traffic allowed between two workloads by a
+	// NetworkPolicy ingress/egress rule. From is the client, To is the server.
+	// It describes communication, not ownership, so it does not merge groups.
+	RelationNetworkPeer RelationshipType = "network_peer"
+
+	// RelationEnvServiceURL indicates a container env var addressing a Service
+	// by its cluster DNS name. Like network_peer it describes traffic, so it
+	// does not merge groups.
+	// Example: env API_URL=http://api.prod.svc.cluster.local → Service api.
+	RelationEnvServiceURL RelationshipType = "env_service_url"
+
+	// RelationConfigServiceURL indicates ConfigMap data addressing a Service
+	// by its cluster DNS name. It does not merge groups either.
+	// Example: nginx.conf with proxy_pass http://api:8080 → Service api.
+	RelationConfigServiceURL RelationshipType = "config_service_url"
+
 	// RelationCustomDependency indicates a custom dependency declared via annotation.
 	// Example: Resource with dhg.deckhouse.io/depends-on annotation.
 	RelationCustomDependency RelationshipType = "custom_dependency"
@@ -95,6 +126,21 @@ type Relationship struct {
 	Details map[string]string
 }
 
+// JoinsGroup reports whether the relationship ties its resources into one
+// service group. Traffic between workloads and references to infrastructure
+// shared by many services (Gateways, cert-manager issuers, PriorityClasses,
+// StorageClasses) do not.
+func (r Relationship) JoinsGroup() bool {
+	switch r.Type {
+	case RelationNetworkPeer, RelationEnvServiceURL, RelationConfigServiceURL, RelationGatewayRoute, RelationPriorityClass, RelationStorageClass:
+		return false
+	}
+	if r.To.GVK.Group == "cert-manager.io" && (r.To.GVK.Kind == "Issuer" || r.To.GVK.Kind == "ClusterIssuer") {
+		return false
+	}
+	return true
+}
+
 // ResourceGraph represents a graph of resources and their relationships.
 type ResourceGraph struct {
 	// Resources is a map of resource key to processed resource.
@@ -13,6 +13,7 @@ const (
 	SourceCluster Source = "cluster"
 	SourceFile    Source = "file"
 	SourceGitOps  Source = "gitops"
+	SourceCompose Source = "compose"
 )
 
 // ExtractedResource represents a Kubernetes resource extracted from any source.
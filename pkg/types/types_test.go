@@ -291,3 +291,31 @@ func TestRelationshipType_Constants(t *testing.T) {
 		}
 	}
 }
+
+// ── Relationship.JoinsGroup ───────────────────────────────────────────────────
+
+func TestRelationship_JoinsGroup(t *testing.T) {
+	deploy := ResourceKey{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "default", Name: "app"}
+	cm := ResourceKey{GVK: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Namespace: "default", Name: "cfg"}
+	issuer := ResourceKey{GVK: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}, Name: "letsencrypt"}
+
+	tests := []struct {
+		name string
+		rel  Relationship
+		want bool
+	}{
+		{"volume mount", makeRelationship(deploy, cm, RelationVolumeMount), true},
+		{"network peer", makeRelationship(deploy, deploy, RelationNetworkPeer), false},
+		{"env service url", makeRelationship(deploy, deploy, RelationEnvServiceURL), false},
+		{"config service url", makeRelationship(cm, deploy, RelationConfigServiceURL), false},
+		{"gateway route", makeRelationship(deploy, cm, RelationGatewayRoute), false},
+		{"cert-manager issuer", makeRelationship(deploy, issuer, RelationAnnotation), false},
+		{"priority class", makeRelationship(deploy, cm, RelationPriorityClass), false},
+		{"storage class", makeRelationship(cm, deploy, RelationStorageClass), false},
+	}
+	for _, tt := range tests {
+		if got := tt.rel.JoinsGroup(); got != tt.want {
+			t.Errorf("%s: JoinsGroup() = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -632,12 +632,12 @@ spec:
 	}
 
 	// Check ingress rules are in values
-	rulesVal, hasRules := findNestedKey(values, "rules")
+	rulesVal, hasRules := findNestedKey(values, "hosts")
 	if !hasRules {
-		t.Error("Ingress rules not found in values")
+		t.Error("Ingress hosts not found in values")
 	} else if rules, ok := rulesVal.([]interface{}); ok {
 		if len(rules) == 0 {
-			t.Error("Ingress rules empty")
+			t.Error("Ingress hosts empty")
 		}
 	}
 }
@@ -727,19 +727,17 @@ spec:
 		t.Error("Missing StatefulSet template")
 	}
 
-	// ── Values contain volumeClaimTemplates ──
+	// ── Values contain the volumeClaimTemplates as persistence ──
 	var values map[string]interface{}
 	if err := sigsyaml.Unmarshal([]byte(output.Charts[0].ValuesYAML), &values); err != nil {
 		t.Fatalf("Failed to parse values: %v", err)
 	}
 
-	vctVal, hasVCT := findNestedKey(values, "volumeClaimTemplates")
-	if !hasVCT {
-		t.Error("volumeClaimTemplates not found in values")
-	} else if vct, ok := vctVal.([]interface{}); ok {
-		if len(vct) == 0 {
-			t.Error("volumeClaimTemplates empty")
-		}
+	persistenceVal, hasPersistence := findNestedKey(values, "persistence")
+	if !hasPersistence {
+		t.Error("persistence not found in values")
+	} else if persistence, ok := persistenceVal.(map[string]interface{}); !ok || len(persistence) == 0 {
+		t.Errorf("persistence should list the claims, got %v", persistenceVal)
 	}
 
 	// ── Service→StatefulSet relationship ──
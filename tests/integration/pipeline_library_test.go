@@ -232,10 +232,17 @@ spec:
 		t.Fatal("no wrapper charts generated")
 	}
 
+	// The library chart is named after the chart, with a -lib suffix as
+	// the wrapper chart of service app is named app too.
+	libChart := findChartByTypeLibrary(output.Charts)
+	if libChart == nil || libChart.Name != "app-lib" {
+		t.Fatalf("expected library chart app-lib, got %+v", libChart)
+	}
+
 	for _, wrapper := range wrappers {
 		for path, content := range wrapper.Templates {
 			// Every wrapper template must call library include
-			if !strings.Contains(content, `include "library.`) {
+			if !strings.Contains(content, `include "app-lib.`) {
 				t.Errorf("wrapper template %s in chart %s does not call library include\ncontent: %s",
 					path, wrapper.Name, content)
 			}
@@ -324,7 +331,7 @@ spec:
 	allLibContent := libContent.String()
 
 	// Shared blocks must be defined EXACTLY ONCE in library
-	for _, block := range []string{"library.resources", "library.env", "library.probes", "library.volumeMounts", "library.volumes"} {
+	for _, block := range []string{"dry-test.resources", "dry-test.env", "dry-test.probes", "dry-test.volumeMounts", "dry-test.volumes"} {
 		define := `define "` + block + `"`
 		count := strings.Count(allLibContent, define)
 		if count != 1 {
@@ -335,7 +342,7 @@ spec:
 	// Wrapper templates must only reference (include), not define, shared blocks
 	for _, wrapper := range wrapperCharts(output.Charts) {
 		for path, content := range wrapper.Templates {
-			if strings.Contains(content, `define "library.`) {
+			if strings.Contains(content, `define "dry-test.`) {
 				t.Errorf("wrapper template %s in chart %s contains a library define (should be include-only)",
 					path, wrapper.Name)
 			}